@@ -0,0 +1,155 @@
+// Command ragbackup backs up and restores the RAG server's PostgreSQL tables and Qdrant collections
+// together. It reads the same environment as the server.
+//
+//	ragbackup backup -dir ./backups/2024-06-01 [-server http://localhost:8080]
+//	ragbackup restore -dir ./backups/2024-06-01 [-server http://localhost:8080] [-force]
+//
+// With -server, the server is put into read-only maintenance mode for the duration of the command
+// (using ADMIN_API_KEY), so the dump, snapshots and counts describe the same data.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"refo-rag-server/internal/backup"
+	"refo-rag-server/internal/config"
+	"refo-rag-server/internal/storage"
+	"refo-rag-server/internal/tlsutil"
+)
+
+func main() {
+	if len(os.Args) < 2 || (os.Args[1] != "backup" && os.Args[1] != "restore") {
+		fmt.Fprintln(os.Stderr, "usage: ragbackup backup|restore -dir DIR [-server URL] [-force]")
+		os.Exit(2)
+	}
+
+	if err := run(os.Args[1], os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run executes a backup or restore command
+func run(command string, args []string) error {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	dir := flags.String("dir", "", "backup directory")
+	serverURL := flags.String("server", "", "RAG server URL; when set, maintenance mode is enabled while the command runs")
+	force := flags.Bool("force", false, "restore even if the embedding model or dimension differs from the configuration")
+	pgDump := flags.String("pg-dump", "", "pg_dump binary (default: from PATH)")
+	pgRestore := flags.String("pg-restore", "", "pg_restore binary (default: from PATH)")
+	flags.Parse(args)
+
+	if *dir == "" {
+		return fmt.Errorf("-dir is required")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	postgresStore, err := storage.NewPostgresStore(cfg.GetPostgresDSN())
+	if err != nil {
+		return fmt.Errorf("failed to initialize PostgreSQL: %w", err)
+	}
+	defer postgresStore.Close()
+
+	collectionManager, err := newCollectionManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize Qdrant: %w", err)
+	}
+	defer collectionManager.Close()
+
+	if *serverURL != "" {
+		release, err := enterMaintenance(ctx, *serverURL, cfg.AdminAPIKey, "ragbackup "+command)
+		if err != nil {
+			return fmt.Errorf("failed to enable maintenance mode: %w", err)
+		}
+		defer release()
+	}
+
+	opts := backup.Options{
+		Dir:       *dir,
+		Database:  cfg.PostgresDB,
+		PGEnv:     cfg.GetPostgresEnv(),
+		PGDump:    *pgDump,
+		PGRestore: *pgRestore,
+	}
+
+	if command == "backup" {
+		manifest, err := backup.Backup(ctx, opts, postgresStore, collectionManager)
+		if err != nil {
+			return fmt.Errorf("backup failed: %w", err)
+		}
+		for _, table := range storage.BackupTables {
+			log.Printf("table %s: %d rows", table, manifest.Tables[table])
+		}
+		for _, collection := range manifest.Collections {
+			log.Printf("collection %s: %d points", collection.Name, collection.Points)
+		}
+		log.Printf("Backup written to %s", *dir)
+		return nil
+	}
+
+	mismatches, err := backup.Restore(ctx, opts, *force, postgresStore, collectionManager)
+	if err != nil {
+		return fmt.Errorf("restore failed: %w", err)
+	}
+	for _, m := range mismatches {
+		log.Printf("count mismatch for %s: expected %d, got %d", m.Name, m.Expected, m.Actual)
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("restore completed but %d counts don't match the manifest", len(mismatches))
+	}
+	log.Printf("Restored %s; all counts match the manifest", *dir)
+	return nil
+}
+
+// newCollectionManager creates the collection stores the server uses, without creating collections
+func newCollectionManager(cfg *config.Config) (*storage.CollectionManager, error) {
+	collectionConfigs := make([]storage.CollectionConfig, 0, len(cfg.Collections))
+	for contentType, collection := range cfg.Collections {
+		collectionConfigs = append(collectionConfigs, storage.CollectionConfig{
+			ContentType: contentType,
+			Name:        collection.Name,
+			Model:       collection.Model,
+			Dimension:   collection.Dimension,
+			Distance:    collection.Distance,
+
+			ShardNumber:            collection.ShardNumber,
+			ReplicationFactor:      collection.ReplicationFactor,
+			WriteConsistencyFactor: collection.WriteConsistencyFactor,
+
+			UserIsolation: collection.UserIsolation,
+		})
+	}
+
+	var qdrantTLS *tls.Config
+	if cfg.QdrantTLS {
+		var err error
+		qdrantTLS, err = tlsutil.ClientConfig(tlsutil.ClientOptions{
+			CAFile:     cfg.QdrantCACert,
+			CertFile:   cfg.QdrantClientCert,
+			KeyFile:    cfg.QdrantClientKey,
+			ServerName: cfg.QdrantTLSServerName,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure Qdrant TLS: %w", err)
+		}
+	}
+
+	client, err := storage.NewQdrantHTTPClient(qdrantTLS, cfg.EgressOptions(), cfg.QdrantClientOptions())
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewCollectionManager(cfg.GetQdrantURL(), client, collectionConfigs)
+}
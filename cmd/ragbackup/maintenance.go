@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"refo-rag-server/internal/models"
+)
+
+// enterMaintenance puts the server into read-only maintenance mode and returns a function that
+// restores the previous state. A server that is already in maintenance mode is left as is.
+func enterMaintenance(ctx context.Context, serverURL string, apiKey string, reason string) (func(), error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	endpoint := strings.TrimRight(serverURL, "/") + "/api/rag/admin/maintenance"
+
+	status, err := maintenanceRequest(ctx, client, http.MethodGet, endpoint, apiKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	if status.Enabled {
+		log.Printf("Server already in maintenance mode (%s)", status.Reason)
+		return func() {}, nil
+	}
+
+	enabled := true
+	if _, err := maintenanceRequest(ctx, client, http.MethodPut, endpoint, apiKey, &models.MaintenanceUpdateRequest{Enabled: &enabled, Reason: reason}); err != nil {
+		return nil, err
+	}
+	log.Println("Maintenance mode enabled")
+
+	return func() {
+		disabled := false
+		// Leave maintenance even if the command was interrupted
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+		if _, err := maintenanceRequest(ctx, client, http.MethodPut, endpoint, apiKey, &models.MaintenanceUpdateRequest{Enabled: &disabled}); err != nil {
+			log.Printf("warning: failed to disable maintenance mode: %v", err)
+			return
+		}
+		log.Println("Maintenance mode disabled")
+	}, nil
+}
+
+// maintenanceRequest calls the maintenance admin endpoint and decodes the reported status
+func maintenanceRequest(ctx context.Context, client *http.Client, method string, endpoint string, apiKey string, update *models.MaintenanceUpdateRequest) (*models.MaintenanceStatus, error) {
+	var body io.Reader
+	if update != nil {
+		data, err := json.Marshal(update)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal maintenance request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-API-Key", apiKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var apiResp struct {
+		Data models.MaintenanceStatus `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode maintenance response: %w", err)
+	}
+
+	return &apiResp.Data, nil
+}
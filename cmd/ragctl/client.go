@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"refo-rag-server/internal/models"
+)
+
+// client calls the RAG server API with the admin API key
+type client struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// newClient creates an API client for the server at baseURL
+func newClient(baseURL string, apiKey string, timeout time.Duration) *client {
+	return &client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: timeout},
+	}
+}
+
+// apiError is a failed API response
+type apiError struct {
+	Status int
+	Info   *models.ErrorInfo
+}
+
+func (e *apiError) Error() string {
+	if e.Info == nil {
+		return fmt.Sprintf("server returned status %d", e.Status)
+	}
+	msg := fmt.Sprintf("%s: %s (status %d)", e.Info.Code, e.Info.Message, e.Status)
+	if e.Info.Details != nil {
+		if details, err := json.Marshal(e.Info.Details); err == nil {
+			msg += " " + string(details)
+		}
+	}
+	return msg
+}
+
+// do sends a request and returns the response's data field and status code
+func (c *client) do(ctx context.Context, method string, path string, query url.Values, body interface{}) (json.RawMessage, int, error) {
+	if body == nil {
+		return c.send(ctx, method, path, query, nil, "")
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return c.send(ctx, method, path, query, bytes.NewReader(data), "application/json")
+}
+
+// send sends a request with a raw body of the given content type and returns the response's
+// data field and status code
+func (c *client) send(ctx context.Context, method string, path string, query url.Values, reader io.Reader, contentType string) (json.RawMessage, int, error) {
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		Success bool              `json:"success"`
+		Data    json.RawMessage   `json:"data"`
+		Error   *models.ErrorInfo `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, resp.StatusCode, &apiError{Status: resp.StatusCode}
+	}
+
+	// The health endpoint reports an unhealthy server with 503 but still returns data
+	if resp.StatusCode >= 400 && envelope.Data == nil {
+		return nil, resp.StatusCode, &apiError{Status: resp.StatusCode, Info: envelope.Error}
+	}
+
+	return envelope.Data, resp.StatusCode, nil
+}
+
+// get sends a GET request and decodes the response data into out
+func (c *client) get(ctx context.Context, path string, query url.Values, out interface{}) (json.RawMessage, error) {
+	data, _, err := c.do(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return data, decode(data, out)
+}
+
+// decode unmarshals response data unless out is nil
+func decode(data json.RawMessage, out interface{}) error {
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,725 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"refo-rag-server/internal/ingest"
+	"refo-rag-server/internal/models"
+)
+
+// adminPath is the prefix of the admin API
+const adminPath = "/api/rag/admin"
+
+// errUsage is returned by commands called with invalid arguments
+var errUsage = errors.New("invalid arguments")
+
+// runHealth reports the server's dependency health
+func runHealth(ctx context.Context, a *app, args []string) error {
+	var health models.HealthCheckResponse
+	data, err := a.client.get(ctx, "/api/rag/health", nil, &health)
+	if err != nil {
+		return err
+	}
+	if a.printer.format == outputJSON {
+		return a.printer.json(data)
+	}
+
+	deps := health.Dependencies
+	return a.printer.table([]string{"COMPONENT", "STATUS", "RESPONSE_MS", "ERROR"}, [][]string{
+		{"server", health.Status, "", ""},
+		{"postgresql", deps.PostgreSQL.Status, strconv.Itoa(deps.PostgreSQL.ResponseTimeMs), deps.PostgreSQL.Error},
+		{"qdrant", deps.Qdrant.Status, strconv.Itoa(deps.Qdrant.ResponseTimeMs), deps.Qdrant.Error},
+		{"openai", deps.OpenAI.Status, "", ""},
+	})
+}
+
+// runDoctor runs the server's self-test and prints its report, failing unless every check passed
+func runDoctor(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	readOnly := flags.Bool("read-only", false, "skip the round trip that saves, searches and deletes a scratch vector")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errUsage
+	}
+
+	query := url.Values{}
+	if *readOnly {
+		query.Set("round_trip", "false")
+	}
+	data, _, err := a.client.do(ctx, http.MethodPost, adminPath+"/doctor", query, nil)
+	if err != nil {
+		return err
+	}
+	var report models.DoctorReport
+	if err := decode(data, &report); err != nil {
+		return err
+	}
+
+	if a.printer.format == outputJSON {
+		err = a.printer.json(data)
+	} else {
+		rows := make([][]string, 0, len(report.Checks))
+		for _, check := range report.Checks {
+			rows = append(rows, []string{check.Name, check.Status, strconv.FormatInt(check.DurationMs, 10), check.Detail})
+		}
+		err = a.printer.table([]string{"CHECK", "STATUS", "MS", "DETAIL"}, rows)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !report.Passed {
+		failed := 0
+		for _, check := range report.Checks {
+			if check.Status == models.DoctorFail {
+				failed++
+			}
+		}
+		return fmt.Errorf("%d of %d checks failed", failed, len(report.Checks))
+	}
+	return nil
+}
+
+// runStats lists the vector collections with their point counts
+func runStats(ctx context.Context, a *app, args []string) error {
+	var list models.CollectionListResponse
+	data, err := a.client.get(ctx, adminPath+"/collections", nil, &list)
+	if err != nil {
+		return err
+	}
+	if a.printer.format == outputJSON {
+		return a.printer.json(data)
+	}
+
+	rows := make([][]string, 0, len(list.Collections))
+	for _, col := range list.Collections {
+		status, points := "unavailable", ""
+		if col.Live != nil {
+			status, points = col.Live.Status, strconv.FormatInt(col.Live.PointsCount, 10)
+		}
+		rows = append(rows, []string{col.ContentType, col.Name, col.Model, strconv.Itoa(col.Dimension), status, points})
+	}
+	return a.printer.table([]string{"CONTENT_TYPE", "COLLECTION", "MODEL", "DIM", "STATUS", "POINTS"}, rows)
+}
+
+// runReindex rebuilds a user's vectors
+func runReindex(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("reindex", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "report what would be reindexed without changing anything")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errUsage
+	}
+
+	query := url.Values{}
+	if *dryRun {
+		query.Set("dry_run", "true")
+	}
+
+	data, _, err := a.client.do(ctx, http.MethodPost, adminPath+"/users/"+url.PathEscape(flags.Arg(0))+"/reindex", query, nil)
+	if err != nil {
+		return err
+	}
+	if a.printer.format == outputJSON {
+		return a.printer.json(data)
+	}
+
+	var result models.UserReindexResponse
+	if err := decode(data, &result); err != nil {
+		return err
+	}
+	return a.printer.table([]string{"KIND", "VECTORS_DELETED", "INDEXED", "SKIPPED", "FAILED", "TEXT_BYTES"}, [][]string{
+		reindexRow("conversations", result.Conversations),
+		reindexRow("personal_info", result.PersonalInfo),
+	})
+}
+
+// reindexRow formats reindex counts as a table row
+func reindexRow(kind string, counts models.ReindexCounts) []string {
+	return []string{
+		kind,
+		strconv.FormatInt(counts.VectorsDeleted, 10),
+		strconv.Itoa(counts.Indexed),
+		strconv.Itoa(counts.Skipped),
+		strconv.Itoa(counts.Failed),
+		strconv.FormatInt(counts.TextBytes, 10),
+	}
+}
+
+// runPurgeUser deletes all of a user's data after showing the scope and asking for confirmation
+func runPurgeUser(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("purge-user", flag.ContinueOnError)
+	yes := flags.Bool("yes", false, "skip the interactive confirmation")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errUsage
+	}
+	userID := flags.Arg(0)
+	path := adminPath + "/users/" + url.PathEscape(userID)
+
+	// The first call only reports the scope and returns a confirmation token
+	data, _, err := a.client.do(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+	var pending models.UserDeletionResponse
+	if err := decode(data, &pending); err != nil {
+		return err
+	}
+	if pending.Confirmation == nil {
+		return fmt.Errorf("server did not return a confirmation token")
+	}
+
+	fmt.Fprintf(a.printer.w, "This permanently deletes all data of user %s:\n", userID)
+	if err := printDeletionScope(a.printer, pending.Scope); err != nil {
+		return err
+	}
+	if !*yes && !a.confirm("Type the user ID to confirm: ", userID) {
+		return errors.New("aborted")
+	}
+
+	data, _, err = a.client.do(ctx, http.MethodDelete, path, url.Values{"confirmation_token": {pending.Confirmation.Token}}, nil)
+	if err != nil {
+		return err
+	}
+	if a.printer.format == outputJSON {
+		return a.printer.json(data)
+	}
+	fmt.Fprintf(a.printer.w, "Deleted user %s\n", userID)
+	return nil
+}
+
+// printDeletionScope prints what a user deletion removes
+func printDeletionScope(p *printer, scope models.UserDataCounts) error {
+	return p.fields(
+		"conversations", strconv.FormatInt(scope.Conversations, 10),
+		"messages", strconv.FormatInt(scope.Messages, 10),
+		"personal_info", strconv.FormatInt(scope.PersonalInfo, 10),
+		"sessions", strconv.FormatInt(scope.Sessions, 10),
+		"profiles", strconv.FormatInt(scope.Profiles, 10),
+		"conversation_vectors", strconv.FormatInt(scope.ConversationVectors, 10),
+		"personal_info_vectors", strconv.FormatInt(scope.PersonalInfoVectors, 10),
+	)
+}
+
+// runRetention applies the retention policy after showing its scope and asking for confirmation
+func runRetention(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("retention", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "report what would be deleted without changing anything")
+	yes := flags.Bool("yes", false, "skip the interactive confirmation")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	path := adminPath + "/retention/run"
+
+	query := url.Values{}
+	if *dryRun {
+		query.Set("dry_run", "true")
+	}
+	data, _, err := a.client.do(ctx, http.MethodPost, path, query, nil)
+	if err != nil {
+		return err
+	}
+	var result models.RetentionRunResponse
+	if err := decode(data, &result); err != nil {
+		return err
+	}
+
+	if *dryRun || result.Confirmation == nil {
+		if a.printer.format == outputJSON {
+			return a.printer.json(data)
+		}
+		return printRetention(a.printer, result)
+	}
+
+	fmt.Fprintf(a.printer.w, "This permanently deletes %d conversations (%d bytes of text).\n", result.Conversations, result.TextBytes)
+	if !*yes && !a.confirm("Type 'delete' to confirm: ", "delete") {
+		return errors.New("aborted")
+	}
+
+	data, _, err = a.client.do(ctx, http.MethodPost, path, url.Values{"confirmation_token": {result.Confirmation.Token}}, nil)
+	if err != nil {
+		return err
+	}
+	if a.printer.format == outputJSON {
+		return a.printer.json(data)
+	}
+	if err := decode(data, &result); err != nil {
+		return err
+	}
+	return printRetention(a.printer, result)
+}
+
+// printRetention prints a retention run's result
+func printRetention(p *printer, result models.RetentionRunResponse) error {
+	return p.fields(
+		"job_id", result.JobID,
+		"dry_run", strconv.FormatBool(result.DryRun),
+		"conversations", strconv.Itoa(result.Conversations),
+		"text_bytes", strconv.FormatInt(result.TextBytes, 10),
+		"duration_ms", strconv.FormatInt(result.DurationMs, 10),
+	)
+}
+
+// runJobs lists recent admin jobs or shows one job
+func runJobs(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("jobs", flag.ContinueOnError)
+	kind := flags.String("kind", "", "only jobs of this kind")
+	limit := flags.Int("limit", 20, "maximum number of jobs")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 1 {
+		var job models.Job
+		data, err := a.client.get(ctx, adminPath+"/jobs/"+url.PathEscape(flags.Arg(0)), nil, &job)
+		if err != nil {
+			return err
+		}
+		if a.printer.format == outputJSON {
+			return a.printer.json(data)
+		}
+		return a.printer.fields(jobFields(&job)...)
+	}
+
+	query := url.Values{"limit": {strconv.Itoa(*limit)}}
+	if *kind != "" {
+		query.Set("kind", *kind)
+	}
+	var list models.JobListResponse
+	data, err := a.client.get(ctx, adminPath+"/jobs", query, &list)
+	if err != nil {
+		return err
+	}
+	if a.printer.format == outputJSON {
+		return a.printer.json(data)
+	}
+
+	rows := make([][]string, 0, len(list.Jobs))
+	for _, job := range list.Jobs {
+		rows = append(rows, []string{job.ID, job.Kind, job.Target, strconv.FormatBool(job.DryRun), job.Status, job.StartedAt.Format("2006-01-02 15:04:05")})
+	}
+	return a.printer.table([]string{"ID", "KIND", "TARGET", "DRY_RUN", "STATUS", "STARTED_AT"}, rows)
+}
+
+// jobFields formats a job as name/value pairs
+func jobFields(job *models.Job) []string {
+	finished := ""
+	if job.FinishedAt != nil {
+		finished = job.FinishedAt.Format("2006-01-02 15:04:05")
+	}
+	return []string{
+		"id", job.ID,
+		"kind", job.Kind,
+		"target", job.Target,
+		"dry_run", strconv.FormatBool(job.DryRun),
+		"status", job.Status,
+		"error", job.Error,
+		"started_at", job.StartedAt.Format("2006-01-02 15:04:05"),
+		"finished_at", finished,
+		"result", string(job.Result),
+	}
+}
+
+// runMaintenance shows or toggles read-only maintenance mode
+func runMaintenance(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("maintenance", flag.ContinueOnError)
+	reason := flags.String("reason", "", "reason shown while maintenance mode is on")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var data []byte
+	var err error
+	switch flags.Arg(0) {
+	case "":
+		data, err = a.client.get(ctx, adminPath+"/maintenance", nil, nil)
+	case "on", "off":
+		enabled := flags.Arg(0) == "on"
+		data, _, err = a.client.do(ctx, http.MethodPut, adminPath+"/maintenance", nil, &models.MaintenanceUpdateRequest{Enabled: &enabled, Reason: *reason})
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+	if a.printer.format == outputJSON {
+		return a.printer.json(data)
+	}
+
+	var status models.MaintenanceStatus
+	if err := decode(data, &status); err != nil {
+		return err
+	}
+	return a.printer.fields("enabled", strconv.FormatBool(status.Enabled), "standby", strconv.FormatBool(status.Standby), "reason", status.Reason, "since", status.Since)
+}
+
+// jobPollInterval is how often -wait checks a background job
+const jobPollInterval = 2 * time.Second
+
+// runImport uploads an assistant platform's conversation export to be imported in the background.
+// With -check the export is only parsed locally and summarized
+func runImport(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "export format: "+strings.Join(ingest.BulkFormats(), ", "))
+	userID := flags.String("user", "", "user of messages that don't name one")
+	assistants := flags.String("assistant", "", "comma-separated speaker names stored with the assistant role")
+	tz := flags.String("tz", "", "IANA time zone of timestamps without an offset")
+	check := flags.Bool("check", false, "parse the export locally and report what would be imported")
+	wait := flags.Bool("wait", false, "wait for the import job to finish")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *format == "" {
+		return errUsage
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if *check {
+		return checkImport(a, file, *format, *userID, *assistants, *tz)
+	}
+
+	query := url.Values{"format": {*format}}
+	if *userID != "" {
+		query.Set("user_id", *userID)
+	}
+	if *assistants != "" {
+		query.Set("assistant_speakers", *assistants)
+	}
+	if *tz != "" {
+		query.Set("tz", *tz)
+	}
+	contentType := "application/json"
+	if *format == ingest.FormatCSV {
+		contentType = "text/csv"
+	}
+
+	data, _, err := a.client.send(ctx, http.MethodPost, adminPath+"/conversations/import", query, file, contentType)
+	if err != nil {
+		return err
+	}
+	var started models.JobStartedResponse
+	if err := decode(data, &started); err != nil {
+		return err
+	}
+	if !*wait {
+		if a.printer.format == outputJSON {
+			return a.printer.json(data)
+		}
+		return a.printer.fields("job_id", started.JobID)
+	}
+
+	return waitForJob(ctx, a, started.JobID)
+}
+
+// waitForJob polls a background job until it finishes and prints it
+func waitForJob(ctx context.Context, a *app, jobID string) error {
+	for {
+		var job models.Job
+		data, err := a.client.get(ctx, adminPath+"/jobs/"+url.PathEscape(jobID), nil, &job)
+		if err != nil {
+			return err
+		}
+		if job.Status != models.JobStatusRunning {
+			if a.printer.format == outputJSON {
+				return a.printer.json(data)
+			}
+			return a.printer.fields(jobFields(&job)...)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(jobPollInterval):
+		}
+	}
+}
+
+// runBackfillLegacy starts the legacy conversation backfill job
+func runBackfillLegacy(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("backfill-legacy", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "report how conversations would be split and attributed without changing them")
+	userID := flags.String("user", "", "user of conversations whose metadata and session name none")
+	resume := flags.String("resume", "", "ID of an unfinished backfill job to continue")
+	wait := flags.Bool("wait", false, "wait for the backfill job to finish")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errUsage
+	}
+
+	req := models.LegacyBackfillRequest{DefaultUserID: *userID, DryRun: *dryRun, ResumeJobID: *resume}
+	data, _, err := a.client.do(ctx, http.MethodPost, adminPath+"/conversations/legacy-backfill", nil, req)
+	if err != nil {
+		return err
+	}
+	var started models.JobStartedResponse
+	if err := decode(data, &started); err != nil {
+		return err
+	}
+	if !*wait {
+		if a.printer.format == outputJSON {
+			return a.printer.json(data)
+		}
+		return a.printer.fields("job_id", started.JobID)
+	}
+	return waitForJob(ctx, a, started.JobID)
+}
+
+// runExport starts an export of stored vectors or of a day of analytics data to the blob store
+func runExport(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	flags := flag.NewFlagSet("export "+args[0], flag.ContinueOnError)
+	contentType := flags.String("content-type", "conversations", "collection to export: conversations, personal_info or documents")
+	date := flags.String("date", "", "UTC day of analytics data to export, as YYYY-MM-DD")
+	wait := flags.Bool("wait", false, "wait for the export job to finish")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errUsage
+	}
+
+	var data json.RawMessage
+	var err error
+	switch args[0] {
+	case "vectors":
+		query := url.Values{"content_type": {*contentType}}
+		data, _, err = a.client.do(ctx, http.MethodPost, adminPath+"/vectors/export", query, nil)
+	case "analytics":
+		if *date == "" {
+			return errUsage
+		}
+		data, _, err = a.client.do(ctx, http.MethodPost, adminPath+"/analytics/export", nil, &models.AnalyticsExportRequest{Date: *date})
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+	var started models.JobStartedResponse
+	if err := decode(data, &started); err != nil {
+		return err
+	}
+	if !*wait {
+		if a.printer.format == outputJSON {
+			return a.printer.json(data)
+		}
+		return a.printer.fields("job_id", started.JobID)
+	}
+	return waitForJob(ctx, a, started.JobID)
+}
+
+// runMigrate shows the schema migrations the server applies to its database on start, and fails
+// like the server's -migrate-preflight when the block guard would refuse one
+func runMigrate(ctx context.Context, a *app, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	var status models.MigrationStatus
+	data, err := a.client.get(ctx, adminPath+"/migrations", nil, &status)
+	if err != nil {
+		return err
+	}
+	if a.printer.format == outputJSON {
+		err = a.printer.json(data)
+	} else {
+		err = printMigrations(a.printer, &status)
+	}
+	if err != nil {
+		return err
+	}
+
+	if status.Blocking > 0 {
+		return fmt.Errorf("%d pending statements lock a large table and have no lock-friendly rewrite", status.Blocking)
+	}
+	return nil
+}
+
+// printMigrations prints a migration plan: a summary, the pending statements, then the lock risks
+func printMigrations(p *printer, status *models.MigrationStatus) error {
+	version := ""
+	if status.SchemaVersion > 0 {
+		version = strconv.Itoa(status.SchemaVersion)
+	}
+	if err := p.fields(
+		"backend", status.Backend,
+		"schema_version", version,
+		"guard", status.Guard,
+		"pending", strconv.Itoa(len(status.Pending)),
+		"blocking", strconv.Itoa(status.Blocking),
+	); err != nil {
+		return err
+	}
+
+	if len(status.Pending) > 0 {
+		fmt.Fprintln(p.w)
+		rows := make([][]string, 0, len(status.Pending))
+		for _, stmt := range status.Pending {
+			rows = append(rows, []string{stmt})
+		}
+		if err := p.table([]string{"PENDING_STATEMENT"}, rows); err != nil {
+			return err
+		}
+	}
+
+	if len(status.Risks) > 0 {
+		fmt.Fprintln(p.w)
+		rows := make([][]string, 0, len(status.Risks))
+		for _, risk := range status.Risks {
+			action := "blocked in block mode"
+			if risk.Rewrite != "" {
+				action = "runs as: " + risk.Rewrite
+			}
+			rows = append(rows, []string{risk.Table, strconv.FormatInt(risk.Rows, 10), risk.Reason, action})
+		}
+		return p.table([]string{"TABLE", "ROWS", "REASON", "ACTION"}, rows)
+	}
+	return nil
+}
+
+// checkImport parses an export locally and prints what it holds
+func checkImport(a *app, file *os.File, format string, userID string, assistants string, tz string) error {
+	importer, ok := ingest.LookupBulk(format)
+	if !ok {
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+	opts := ingest.Options{UserID: userID, AssistantSpeakers: strings.Split(assistants, ",")}
+	if tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return err
+		}
+		opts.Location = loc
+	}
+
+	export, err := importer.Import(file, opts)
+	if err != nil {
+		return err
+	}
+	progress := models.ConversationImportProgress{
+		Format:        format,
+		Records:       export.Records,
+		Skipped:       export.Skipped,
+		Users:         export.Users,
+		Sessions:      export.Sessions,
+		Conversations: len(export.Requests),
+		FailedIDs:     []string{},
+	}
+	if a.printer.format == outputJSON {
+		data, err := json.Marshal(progress)
+		if err != nil {
+			return err
+		}
+		return a.printer.json(data)
+	}
+	return a.printer.fields(
+		"format", progress.Format,
+		"records", strconv.Itoa(progress.Records),
+		"skipped", strconv.Itoa(progress.Skipped),
+		"users", strconv.Itoa(progress.Users),
+		"sessions", strconv.Itoa(progress.Sessions),
+		"conversations", strconv.Itoa(progress.Conversations),
+	)
+}
+
+// confirm asks the operator to type an expected answer
+func (a *app) confirm(prompt string, expected string) bool {
+	fmt.Fprint(a.printer.w, prompt)
+	answer, _ := a.stdin.ReadString('\n')
+	return strings.TrimSpace(answer) == expected
+}
+
+// runProjections lists or reloads the embedding projections, or starts training a PCA matrix
+func runProjections(ctx context.Context, a *app, args []string) error {
+	if len(args) > 0 && args[0] == "train" {
+		return trainProjection(ctx, a, args[1:])
+	}
+
+	var data []byte
+	var err error
+	switch {
+	case len(args) == 0:
+		data, err = a.client.get(ctx, adminPath+"/embeddings/projections", nil, nil)
+	case len(args) == 1 && args[0] == "reload":
+		data, _, err = a.client.do(ctx, http.MethodPost, adminPath+"/embeddings/projections/reload", nil, nil)
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+	if a.printer.format == outputJSON {
+		return a.printer.json(data)
+	}
+
+	var list models.EmbeddingProjectionListResponse
+	if err := decode(data, &list); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(list.Projections))
+	for _, p := range list.Projections {
+		input, variance, loaded := "", "", ""
+		if p.InputDimension > 0 {
+			input = strconv.Itoa(p.InputDimension)
+			variance = strconv.FormatFloat(p.ExplainedVariance, 'f', 4, 64)
+		}
+		if p.LoadedAt != nil {
+			loaded = p.LoadedAt.Format(time.RFC3339)
+		}
+		rows = append(rows, []string{p.Model, p.Method, input, strconv.Itoa(p.OutputDimension), variance, p.Path, loaded})
+	}
+	return a.printer.table([]string{"MODEL", "METHOD", "INPUT", "OUTPUT", "VARIANCE", "PATH", "LOADED_AT"}, rows)
+}
+
+// trainProjection starts training a PCA matrix and prints the job ID
+func trainProjection(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("projections train", flag.ContinueOnError)
+	dimension := flags.Int("dim", 0, "dimension to reduce vectors to")
+	model := flags.String("model", "", "embedding model (default: the conversations collection's)")
+	samples := flags.Int("samples", 0, "stored conversations to train on (default 5000)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dimension <= 0 || flags.NArg() != 0 {
+		return errUsage
+	}
+
+	req := &models.EmbeddingProjectionTrainRequest{Model: *model, Dimension: *dimension, Samples: *samples}
+	data, _, err := a.client.do(ctx, http.MethodPost, adminPath+"/embeddings/projections/train", nil, req)
+	if err != nil {
+		return err
+	}
+	if a.printer.format == outputJSON {
+		return a.printer.json(data)
+	}
+	var started models.JobStartedResponse
+	if err := decode(data, &started); err != nil {
+		return err
+	}
+	return a.printer.fields("job_id", started.JobID)
+}
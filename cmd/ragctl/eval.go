@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"refo-rag-server/internal/models"
+)
+
+// evalHelp describes the run-eval command beyond its usage line
+const evalHelp = `Runs a set of evaluation queries through the search playground and scores each configuration.
+
+The eval file holds one JSON object per line:
+
+  {"query": "where did we leave the lighthouse plans?", "user_id": "user-1", "relevant": ["<conversation id>"]}
+
+user_id defaults to -user and filter, a metadata filter, is optional. Every query is run through
+the configurations of -configs, a JSON array of playground configurations, or through the
+configured pipeline alone. Each configuration is scored by its recall of the relevant
+conversations in the top -top-k results and by the mean reciprocal rank of the first of them.
+Playground runs aren't logged as searches.
+`
+
+// evalCase is an evaluation query with the conversations a search for it should return
+type evalCase struct {
+	Query    string   `json:"query"`
+	UserID   string   `json:"user_id"`
+	Filter   string   `json:"filter"`
+	Relevant []string `json:"relevant"`
+}
+
+// evalReport scores each configuration over the evaluation queries
+type evalReport struct {
+	Cases          int                `json:"cases"`
+	TopK           int                `json:"top_k"`
+	Configurations []evalConfigReport `json:"configurations"`
+}
+
+// evalConfigReport scores one configuration; queries it failed count as missing every relevant
+// conversation
+type evalConfigReport struct {
+	Name    string  `json:"name"`
+	Errors  int     `json:"errors"`
+	Recall  float64 `json:"recall"`
+	MRR     float64 `json:"mrr"`
+	P50MS   float64 `json:"p50_ms"`
+	P95MS   float64 `json:"p95_ms"`
+	latency []float64
+}
+
+// runEval scores search configurations against evaluation queries with known relevant
+// conversations, failing when a query fails or a configuration's recall is below -min-recall
+func runEval(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("run-eval", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "%s\nflags:\n", evalHelp)
+		flags.PrintDefaults()
+	}
+	configsFile := flags.String("configs", "", "JSON array of playground configurations to compare; the configured pipeline by default")
+	userID := flags.String("user", "", "user of queries that don't name one")
+	topK := flags.Int("top-k", 10, "results scored per query (1-100)")
+	minRecall := flags.Float64("min-recall", 0, "fail if a configuration's recall is below this value")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *topK < 1 || *topK > 100 || *minRecall < 0 || *minRecall > 1 {
+		return errUsage
+	}
+
+	configs := []models.PlaygroundConfiguration{{Name: "configured"}}
+	if *configsFile != "" {
+		data, err := os.ReadFile(*configsFile)
+		if err != nil {
+			return fmt.Errorf("failed to read configurations: %w", err)
+		}
+		if err := json.Unmarshal(data, &configs); err != nil {
+			return fmt.Errorf("failed to parse configurations: %w", err)
+		}
+		if len(configs) == 0 {
+			return errors.New("the configurations file lists no configuration")
+		}
+	}
+
+	var input io.Reader = a.stdin
+	if name := flags.Arg(0); name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return fmt.Errorf("failed to open eval file: %w", err)
+		}
+		defer file.Close()
+		input = file
+	}
+	cases, err := readEvalCases(input, *userID)
+	if err != nil {
+		return err
+	}
+
+	report := &evalReport{Cases: len(cases), TopK: *topK, Configurations: make([]evalConfigReport, len(configs))}
+	for i, config := range configs {
+		report.Configurations[i].Name = config.Name
+	}
+	for _, c := range cases {
+		req := &models.PlaygroundRequest{Query: c.Query, UserID: c.UserID, TopK: *topK, Filter: c.Filter, Configurations: configs}
+		data, _, err := a.client.do(ctx, http.MethodPost, adminPath+"/search/playground", nil, req)
+		if err != nil {
+			return fmt.Errorf("query %q: %w", c.Query, err)
+		}
+		var resp models.PlaygroundResponse
+		if err := decode(data, &resp); err != nil {
+			return err
+		}
+		scoreEvalCase(report, c, resp.Runs)
+	}
+	for i := range report.Configurations {
+		config := &report.Configurations[i]
+		config.Recall /= float64(len(cases))
+		config.MRR /= float64(len(cases))
+		config.P50MS = percentile(config.latency, 0.50)
+		config.P95MS = percentile(config.latency, 0.95)
+	}
+
+	if a.printer.format == outputJSON {
+		data, err := json.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		err = a.printer.json(data)
+	} else {
+		err = printEvalReport(a.printer, report)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, config := range report.Configurations {
+		if config.Errors > 0 {
+			return fmt.Errorf("configuration %s failed %d of %d queries", config.Name, config.Errors, report.Cases)
+		}
+		if config.Recall < *minRecall {
+			return fmt.Errorf("configuration %s has recall %.3f, below %.3f", config.Name, config.Recall, *minRecall)
+		}
+	}
+	return nil
+}
+
+// readEvalCases reads the evaluation queries, one JSON object per line
+func readEvalCases(r io.Reader, userID string) ([]evalCase, error) {
+	var cases []evalCase
+	decoder := json.NewDecoder(r)
+	for {
+		var c evalCase
+		err := decoder.Decode(&c)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read eval query %d: %w", len(cases)+1, err)
+		}
+		if strings.TrimSpace(c.Query) == "" || len(c.Relevant) == 0 {
+			return nil, fmt.Errorf("eval query %d needs a query and at least one relevant conversation", len(cases)+1)
+		}
+		if c.UserID == "" {
+			c.UserID = userID
+		}
+		cases = append(cases, c)
+	}
+	if len(cases) == 0 {
+		return nil, errors.New("the eval file holds no query")
+	}
+	return cases, nil
+}
+
+// scoreEvalCase adds a query's recall, reciprocal rank and latency to each configuration's
+// totals; runs come back in configuration order
+func scoreEvalCase(report *evalReport, c evalCase, runs []models.PlaygroundRun) {
+	relevant := make(map[string]bool, len(c.Relevant))
+	for _, id := range c.Relevant {
+		relevant[id] = true
+	}
+	for i := range report.Configurations {
+		config := &report.Configurations[i]
+		if i >= len(runs) || runs[i].Error != "" {
+			config.Errors++
+			continue
+		}
+		config.latency = append(config.latency, float64(runs[i].LatencyMs))
+
+		found := 0
+		for _, result := range runs[i].Results {
+			if !relevant[result.ConversationID] {
+				continue
+			}
+			if found == 0 {
+				config.MRR += 1 / float64(result.Rank)
+			}
+			found++
+		}
+		config.Recall += float64(found) / float64(len(relevant))
+	}
+}
+
+// printEvalReport prints each configuration's scores
+func printEvalReport(p *printer, report *evalReport) error {
+	score := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 3, 64)
+	}
+	ms := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 1, 64)
+	}
+	rows := make([][]string, 0, len(report.Configurations))
+	for _, config := range report.Configurations {
+		rows = append(rows, []string{
+			config.Name,
+			strconv.Itoa(config.Errors),
+			score(config.Recall),
+			score(config.MRR),
+			ms(config.P50MS),
+			ms(config.P95MS),
+		})
+	}
+	header := []string{"CONFIGURATION", "ERRORS", "RECALL@" + strconv.Itoa(report.TopK), "MRR", "P50_MS", "P95_MS"}
+	if err := p.table(header, rows); err != nil {
+		return err
+	}
+	fmt.Fprintf(p.w, "\n%d queries\n", report.Cases)
+	return nil
+}
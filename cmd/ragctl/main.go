@@ -0,0 +1,117 @@
+// Command ragctl is an operator CLI for the RAG server's admin API.
+//
+//	ragctl [-server URL] [-api-key KEY] [-o table|json] <command> [flags] [args]
+//
+// The server URL and API key default to RAGCTL_SERVER and ADMIN_API_KEY.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// command is a ragctl subcommand
+type command struct {
+	usage string
+	run   func(ctx context.Context, app *app, args []string) error
+}
+
+// commands lists the subcommands by name
+var commands = map[string]command{
+	"health":          {"health", runHealth},
+	"doctor":          {"doctor [-read-only]", runDoctor},
+	"stats":           {"stats", runStats},
+	"reindex":         {"reindex [-dry-run] USER_ID", runReindex},
+	"purge-user":      {"purge-user [-yes] USER_ID", runPurgeUser},
+	"retention":       {"retention [-dry-run] [-yes]", runRetention},
+	"jobs":            {"jobs [-kind KIND] [-limit N] | jobs JOB_ID", runJobs},
+	"maintenance":     {"maintenance [on|off] [-reason TEXT]", runMaintenance},
+	"export":          {"export vectors [-content-type TYPE] [-wait] | export analytics -date YYYY-MM-DD [-wait]", runExport},
+	"import":          {"import -format FORMAT [-user USER_ID] [-assistant NAMES] [-tz ZONE] [-check] [-wait] FILE", runImport},
+	"migrate":         {"migrate", runMigrate},
+	"run-eval":        {"run-eval [-configs FILE] [-user USER_ID] [-top-k N] [-min-recall R] FILE|-", runEval},
+	"backfill-legacy": {"backfill-legacy [-dry-run] [-user USER_ID] [-resume JOB_ID] [-wait]", runBackfillLegacy},
+	"replay":          {"replay [-speed X] [-concurrency N] [-limit N] [-routes \"METHOD /route,...\"] FILE|-", runReplay},
+	"projections":     {"projections [reload] | projections train -dim N [-model MODEL] [-samples N]", runProjections},
+}
+
+// app holds what every command needs
+type app struct {
+	client  *client
+	printer *printer
+	stdin   *bufio.Reader
+}
+
+func main() {
+	serverURL := flag.String("server", envOr("RAGCTL_SERVER", "http://localhost:8080"), "RAG server URL")
+	apiKey := flag.String("api-key", os.Getenv("ADMIN_API_KEY"), "admin API key")
+	output := flag.String("o", outputTable, "output format: table or json")
+	timeout := flag.Duration("timeout", 10*time.Minute, "request timeout")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+	if *output != outputTable && *output != outputJSON {
+		fmt.Fprintf(os.Stderr, "unknown output format %q\n", *output)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	a := &app{
+		client:  newClient(*serverURL, *apiKey, *timeout),
+		printer: &printer{w: os.Stdout, format: *output},
+		stdin:   bufio.NewReader(os.Stdin),
+	}
+	if err := cmd.run(ctx, a, flag.Args()[1:]); err != nil {
+		stop()
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "usage: ragctl %s\n", cmd.usage)
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// usage prints the global flags and commands
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ragctl [-server URL] [-api-key KEY] [-o table|json] <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nflags:")
+	flag.PrintDefaults()
+}
+
+// envOr returns an environment variable or a default
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// printer writes command results as tables or as the raw response JSON
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+// json prints response data as indented JSON
+func (p *printer) json(data json.RawMessage) error {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return fmt.Errorf("failed to format response: %w", err)
+	}
+	buf.WriteByte('\n')
+	_, err := buf.WriteTo(p.w)
+	return err
+}
+
+// table prints rows under a header with aligned columns
+func (p *printer) table(header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// fields prints name/value pairs as a two-column table
+func (p *printer) fields(pairs ...string) error {
+	rows := make([][]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		rows = append(rows, []string{pairs[i], pairs[i+1]})
+	}
+	return p.table([]string{"FIELD", "VALUE"}, rows)
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/auditlog"
+	"refo-rag-server/internal/signing"
+)
+
+// replayDroppedHeaders are recorded headers not replayed: credentials are masked in the log and
+// signatures don't survive a replay, so replayed requests authenticate with the ragctl API key,
+// and the transport sets the rest
+var replayDroppedHeaders = map[string]bool{
+	"authorization":                          true,
+	"x-api-key":                              true,
+	"cookie":                                 true,
+	strings.ToLower(signing.HeaderClientID):  true,
+	strings.ToLower(signing.HeaderTimestamp): true,
+	strings.ToLower(signing.HeaderNonce):     true,
+	strings.ToLower(signing.HeaderSignature): true,
+	"content-length":                         true,
+	"host":                                   true,
+	"connection":                             true,
+	"accept-encoding":                        true,
+	"transfer-encoding":                      true,
+}
+
+// maxReplayMismatches caps the status mismatches listed in the report
+const maxReplayMismatches = 20
+
+// replayMaskMarkers are the placeholders the server's log masking writes in place of user content
+// and credentials; a request carrying one isn't the request the server answered
+var replayMaskMarkers = []string{"[redacted len=", "[secret sha256="}
+
+// replayHelp describes the replay command beyond its usage line
+const replayHelp = `Replays request audit records against the server and compares status codes and latencies.
+
+Authorization, X-API-Key, Cookie and request signature headers are dropped from the recorded
+requests; every replayed request authenticates with the ragctl -api-key instead. Records whose
+body or query was masked, because the recording server didn't log full content, are skipped and
+counted as masked: they would replay placeholder payloads, so their status and latency aren't
+comparable. Truncated records are skipped too.
+`
+
+// replayResult is the outcome of one replayed request
+type replayResult struct {
+	record    auditlog.Record
+	status    int
+	latencyMS float64
+	err       error
+}
+
+// replayReport compares replayed requests with their recorded originals
+type replayReport struct {
+	Requests   int                 `json:"requests"`
+	Skipped    int                 `json:"skipped"`
+	Masked     int                 `json:"masked"` // Records not replayed because their request was masked
+	Errors     int                 `json:"errors"`
+	StatusDiff int                 `json:"status_diff"`
+	DurationMs int64               `json:"duration_ms"`
+	Routes     []replayRouteReport `json:"routes"`
+	Mismatches []replayMismatch    `json:"mismatches"`
+}
+
+// replayRouteReport compares the status codes and latencies of one route
+type replayRouteReport struct {
+	Route           string  `json:"route"`
+	Requests        int     `json:"requests"`
+	Errors          int     `json:"errors"`
+	StatusDiff      int     `json:"status_diff"`
+	RecordedP50MS   float64 `json:"recorded_p50_ms"`
+	ReplayedP50MS   float64 `json:"replayed_p50_ms"`
+	RecordedP95MS   float64 `json:"recorded_p95_ms"`
+	ReplayedP95MS   float64 `json:"replayed_p95_ms"`
+	recordedLatency []float64
+	replayedLatency []float64
+}
+
+// replayMismatch is a replayed request whose status differs from the recorded one
+type replayMismatch struct {
+	RequestID string `json:"request_id"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Recorded  int    `json:"recorded"`
+	Replayed  int    `json:"replayed"`
+	Error     string `json:"error,omitempty"`
+}
+
+// runReplay replays request audit records against the server, at the recorded pace scaled by
+// -speed, and compares response codes and latencies with the recorded ones. Only records logged
+// unmasked are replayed, so user content is replayed only from a server that logged it in full
+func runReplay(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "%s\nflags:\n", replayHelp)
+		flags.PrintDefaults()
+	}
+	speed := flags.Float64("speed", 1, "pace relative to the recording: 2 replays twice as fast, 0 as fast as possible")
+	concurrency := flags.Int("concurrency", 16, "maximum requests in flight")
+	limit := flags.Int("limit", 0, "replay at most N requests; 0 replays all")
+	routes := flags.String("routes", "", "comma-separated \"METHOD /route\" patterns to replay; all by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *speed < 0 || *concurrency < 1 || *limit < 0 {
+		return errUsage
+	}
+
+	var input io.Reader = a.stdin
+	if name := flags.Arg(0); name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return fmt.Errorf("failed to open request log: %w", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	selected := make(map[string]bool)
+	for _, route := range strings.Split(*routes, ",") {
+		if route = strings.Join(strings.Fields(route), " "); route != "" {
+			selected[route] = true
+		}
+	}
+
+	records, skipped, masked, err := readReplayRecords(input, selected, *limit)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("no replayable requests in the log (%d skipped, %d masked)", skipped, masked)
+	}
+
+	start := time.Now()
+	results := a.client.replayAll(ctx, records, *speed, *concurrency)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	report := buildReplayReport(results, skipped, masked, time.Since(start))
+
+	if a.printer.format == outputJSON {
+		data, err := json.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		err = a.printer.json(data)
+	} else {
+		err = printReplayReport(a.printer, report)
+	}
+	if err != nil {
+		return err
+	}
+
+	if report.Errors > 0 || report.StatusDiff > 0 {
+		return fmt.Errorf("%d of %d replayed requests failed or returned a different status", report.Errors+report.StatusDiff, report.Requests)
+	}
+	return nil
+}
+
+// readReplayRecords reads the audit records of the selected routes, all if none are selected,
+// in recorded order. Records with a truncated body or that aren't records are skipped, and
+// records with a masked body or query are counted separately
+func readReplayRecords(r io.Reader, selected map[string]bool, limit int) ([]auditlog.Record, int, int, error) {
+	var records []auditlog.Record
+	skipped, masked := 0, 0
+	reader := bufio.NewReader(r)
+	for limit == 0 || len(records) < limit {
+		line, err := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			var record auditlog.Record
+			switch {
+			case json.Unmarshal(line, &record) != nil || record.Method == "" || record.Path == "":
+				skipped++
+			case record.Truncated:
+				skipped++
+			case len(selected) > 0 && !selected[record.Method+" "+record.Route]:
+			case replayMasked(record):
+				masked++
+			default:
+				records = append(records, record)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("failed to read request log: %w", err)
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return records, skipped, masked, nil
+}
+
+// replayMasked reports whether the logging masked part of a record's request. Masked query values
+// are URL-encoded in the recorded path
+func replayMasked(record auditlog.Record) bool {
+	path, err := url.QueryUnescape(record.Path)
+	if err != nil {
+		path = record.Path
+	}
+	for _, marker := range replayMaskMarkers {
+		if strings.Contains(record.RequestBody, marker) || strings.Contains(path, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// replayAll sends the records, each at its recorded offset from the first divided by speed, with
+// at most concurrency in flight. Requests due while all slots are busy start late
+func (c *client) replayAll(ctx context.Context, records []auditlog.Record, speed float64, concurrency int) []replayResult {
+	results := make([]replayResult, len(records))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	first := records[0].Time
+	for i, record := range records {
+		if speed > 0 {
+			due := start.Add(time.Duration(float64(record.Time.Sub(first)) / speed))
+			select {
+			case <-time.After(time.Until(due)):
+			case <-ctx.Done():
+			}
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, record auditlog.Record) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			status, latency, err := c.replay(ctx, record)
+			results[i] = replayResult{record: record, status: status, latencyMS: latency, err: err}
+		}(i, record)
+	}
+	wg.Wait()
+	return results
+}
+
+// replay sends one recorded request and returns the response status and the milliseconds until
+// its body was read
+func (c *client) replay(ctx context.Context, record auditlog.Record) (int, float64, error) {
+	var body io.Reader
+	if record.RequestBody != "" {
+		body = strings.NewReader(record.RequestBody)
+	}
+	req, err := http.NewRequestWithContext(ctx, record.Method, c.baseURL+record.Path, body)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range record.RequestHeaders {
+		if !replayDroppedHeaders[strings.ToLower(name)] {
+			req.Header.Set(name, value)
+		}
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return resp.StatusCode, 0, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.StatusCode, float64(time.Since(start).Microseconds()) / 1000, nil
+}
+
+// buildReplayReport groups the results by route
+func buildReplayReport(results []replayResult, skipped int, masked int, elapsed time.Duration) *replayReport {
+	report := &replayReport{
+		Skipped:    skipped,
+		Masked:     masked,
+		DurationMs: elapsed.Milliseconds(),
+		Routes:     []replayRouteReport{},
+		Mismatches: []replayMismatch{},
+	}
+	routes := make(map[string]*replayRouteReport)
+	for _, result := range results {
+		key := result.record.Method + " " + result.record.Route
+		route, ok := routes[key]
+		if !ok {
+			route = &replayRouteReport{Route: key}
+			routes[key] = route
+		}
+		report.Requests++
+		route.Requests++
+
+		if result.err != nil {
+			report.Errors++
+			route.Errors++
+		} else {
+			route.recordedLatency = append(route.recordedLatency, result.record.LatencyMS)
+			route.replayedLatency = append(route.replayedLatency, result.latencyMS)
+			if result.status == result.record.Status {
+				continue
+			}
+			report.StatusDiff++
+			route.StatusDiff++
+		}
+
+		if len(report.Mismatches) < maxReplayMismatches {
+			mismatch := replayMismatch{
+				RequestID: result.record.RequestID,
+				Method:    result.record.Method,
+				Path:      result.record.Path,
+				Recorded:  result.record.Status,
+				Replayed:  result.status,
+			}
+			if result.err != nil {
+				mismatch.Error = result.err.Error()
+			}
+			report.Mismatches = append(report.Mismatches, mismatch)
+		}
+	}
+
+	for _, route := range routes {
+		route.RecordedP50MS = percentile(route.recordedLatency, 0.5)
+		route.ReplayedP50MS = percentile(route.replayedLatency, 0.5)
+		route.RecordedP95MS = percentile(route.recordedLatency, 0.95)
+		route.ReplayedP95MS = percentile(route.replayedLatency, 0.95)
+		report.Routes = append(report.Routes, *route)
+	}
+	sort.Slice(report.Routes, func(i, j int) bool {
+		if report.Routes[i].Requests != report.Routes[j].Requests {
+			return report.Routes[i].Requests > report.Routes[j].Requests
+		}
+		return report.Routes[i].Route < report.Routes[j].Route
+	})
+	return report
+}
+
+// percentile returns the nearest-rank percentile of values, or 0 for none
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
+
+// printReplayReport prints the per-route comparison, then the mismatched requests
+func printReplayReport(p *printer, report *replayReport) error {
+	ms := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 1, 64)
+	}
+	rows := make([][]string, 0, len(report.Routes))
+	for _, route := range report.Routes {
+		rows = append(rows, []string{
+			route.Route,
+			strconv.Itoa(route.Requests),
+			strconv.Itoa(route.Errors),
+			strconv.Itoa(route.StatusDiff),
+			ms(route.RecordedP50MS), ms(route.ReplayedP50MS),
+			ms(route.RecordedP95MS), ms(route.ReplayedP95MS),
+		})
+	}
+	header := []string{"ROUTE", "REQUESTS", "ERRORS", "STATUS_DIFF", "REC_P50_MS", "P50_MS", "REC_P95_MS", "P95_MS"}
+	if err := p.table(header, rows); err != nil {
+		return err
+	}
+	fmt.Fprintf(p.w, "\n%d requests replayed in %s, %d skipped, %d masked and not comparable\n", report.Requests, time.Duration(report.DurationMs)*time.Millisecond, report.Skipped, report.Masked)
+
+	if len(report.Mismatches) == 0 {
+		return nil
+	}
+	fmt.Fprintln(p.w)
+	rows = make([][]string, 0, len(report.Mismatches))
+	for _, m := range report.Mismatches {
+		replayed := strconv.Itoa(m.Replayed)
+		if m.Error != "" {
+			replayed = m.Error
+		}
+		rows = append(rows, []string{m.RequestID, m.Method, m.Path, strconv.Itoa(m.Recorded), replayed})
+	}
+	return p.table([]string{"REQUEST_ID", "METHOD", "PATH", "RECORDED", "REPLAYED"}, rows)
+}
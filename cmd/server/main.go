@@ -7,88 +7,928 @@
 // @license.name Apache 2.0
 // @license.url http://www.apache.org/licenses/LICENSE-2.0.html
 // @basePath /
+// @securityDefinitions.apikey AdminAPIKey
+// @in header
+// @name X-API-Key
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"refo-rag-server/internal/analytics"
 	"refo-rag-server/internal/api"
+	"refo-rag-server/internal/apikey"
+	"refo-rag-server/internal/auditlog"
+	"refo-rag-server/internal/bandit"
+	"refo-rag-server/internal/blobstore"
+	"refo-rag-server/internal/bootstrap"
+	"refo-rag-server/internal/cache"
 	"refo-rag-server/internal/config"
+	"refo-rag-server/internal/coord"
+	"refo-rag-server/internal/deadline"
+	"refo-rag-server/internal/envelope"
+	"refo-rag-server/internal/erasure"
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/events"
+	"refo-rag-server/internal/featureflag"
+	"refo-rag-server/internal/health"
+	"refo-rag-server/internal/importance"
+	"refo-rag-server/internal/ipfilter"
+	"refo-rag-server/internal/lifecycle"
+	"refo-rag-server/internal/loadshed"
+	"refo-rag-server/internal/logging"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/plugin"
+	"refo-rag-server/internal/queryroute"
+	"refo-rag-server/internal/queue"
+	"refo-rag-server/internal/residency"
+	"refo-rag-server/internal/retrieval"
+	"refo-rag-server/internal/schedule"
+	"refo-rag-server/internal/seed"
+	"refo-rag-server/internal/server"
 	"refo-rag-server/internal/service"
+	"refo-rag-server/internal/shaper"
+	"refo-rag-server/internal/signing"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/snippet"
 	"refo-rag-server/internal/storage"
+	"refo-rag-server/internal/timeouts"
+	"refo-rag-server/internal/tracing"
+	"refo-rag-server/internal/usage"
+	"refo-rag-server/internal/vectorio"
 )
 
 func main() {
+	seedDemo := flag.Bool("seed", false, "load the demo dataset (users, sessions, conversations, personal info) before serving")
+	migratePreflight := flag.Bool("migrate-preflight", false, "print the pending database migrations and their lock risks, then exit")
+	printEffectiveConfig := flag.Bool("print-effective-config", false, "print the resolved configuration, secrets masked, then exit")
+	flag.Parse()
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	if err := bootstrap.Check(cfg); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if *printEffectiveConfig {
+		if err := cfg.WriteEffective(os.Stdout); err != nil {
+			log.Fatalf("Failed to print configuration: %v", err)
+		}
+		return
+	}
 
-	// Initialize PostgreSQL connection
-	postgresStore, err := storage.NewPostgresStore(cfg.GetPostgresDSN())
-	if err != nil {
-		log.Fatalf("Failed to initialize PostgreSQL: %v", err)
+	logging.SetFullContent(cfg.LogFullContent)
+	if cfg.LogFullContent {
+		log.Println("warning: full content logging is enabled; do not use in production")
 	}
-	defer postgresStore.Close()
 
-	// Run migrations
-	log.Println("Running database migrations...")
-	if err := storage.Migrate(postgresStore.GetDB()); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	slowlog.Configure(slowlog.Thresholds{
+		Postgres:   cfg.SlowPostgresThreshold,
+		Qdrant:     cfg.SlowQdrantThreshold,
+		Embedding:  cfg.SlowEmbeddingThreshold,
+		Completion: cfg.SlowCompletionThreshold,
+		Request:    cfg.SlowRequestThreshold,
+	})
+	timeouts.Configure(timeouts.Limits{
+		Postgres:   cfg.PostgresQueryTimeout,
+		Qdrant:     cfg.QdrantTimeout,
+		Embedding:  cfg.EmbeddingTimeout,
+		Completion: cfg.CompletionTimeout,
+	})
+	timeouts.ConfigureShares(timeouts.Shares{
+		Postgres:   cfg.DeadlineSharePostgres,
+		Qdrant:     cfg.DeadlineShareQdrant,
+		Embedding:  cfg.DeadlineShareEmbedding,
+		Completion: cfg.DeadlineShareCompletion,
+	})
+	deadline.Configure(deadline.Options{OptionalStageMin: cfg.DeadlineOptionalMin})
+
+	// Report panics, 5xx responses, and background failures when a DSN is configured
+	if cfg.SentryDSN != "" {
+		reporter, err := errreport.NewSentryReporter(errreport.SentryOptions{
+			DSN:         cfg.SentryDSN,
+			Environment: cfg.SentryEnvironment,
+			Release:     cfg.SentryRelease,
+			SampleRate:  cfg.SentrySampleRate,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize error reporting: %v", err)
+		}
+		errreport.SetDefault(reporter)
 	}
-	log.Println("Database migrations completed")
 
-	// Initialize Qdrant connection
-	qdrantStore, err := storage.NewQdrantStore(cfg.GetQdrantURL(), cfg.QdrantCollection)
+	// Export traces of requests and their embedding and Qdrant calls when an endpoint is
+	// configured; requests from a traced client join its trace
+	var shutdownTracing func(context.Context) error
+	if cfg.TracingEndpoint != "" {
+		shutdown, err := tracing.Setup(context.Background(), tracing.Options{
+			Endpoint:    cfg.TracingEndpoint,
+			ServiceName: cfg.TracingServiceName,
+			Environment: cfg.SentryEnvironment,
+			Release:     cfg.SentryRelease,
+			SampleRate:  cfg.TracingSampleRate,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize tracing: %v", err)
+		}
+		shutdownTracing = shutdown
+	}
+
+	// Label per-tenant metrics with the busiest and pinned tenants only
+	metrics.SetTenantLabels(cfg.MetricsTenants, cfg.MetricsTenantTopN)
+
+	// Connect to PostgreSQL or MySQL, waiting for it to come up, or open the SQLite database
+	relational, err := bootstrap.OpenRelational(cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize Qdrant: %v", err)
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer relational.Close()
+	store := relational.Store
+	postgresStore := relational.Postgres
+	memories := relational.Memories
+	locker := relational.Locker
+
+	migrationOpts := storage.MigrationOptions{
+		Guard:          cfg.MigrationGuard,
+		LargeTableRows: cfg.MigrationLargeTableRows,
+	}
+	if *migratePreflight {
+		if postgresStore == nil {
+			log.Fatalf("-migrate-preflight plans Postgres migrations; MEMORY_STORE_BACKEND=%s has none", cfg.MemoryStoreBackend)
+		}
+		os.Exit(migrationPreflight(postgresStore, migrationOpts))
+	}
+
+	// Run migrations; replicas starting together take turns. A standby's database is a read-only
+	// replica the primary migrates
+	if cfg.Standby {
+		if err := relational.CheckSchema(migrationOpts); err != nil {
+			log.Fatalf("Standby can't serve this database: %v", err)
+		}
+		log.Println("Standby mode: database schema is current")
+	} else {
+		log.Println("Running database migrations...")
+		if err := relational.Migrate(migrationOpts); err != nil {
+			log.Fatalf("Failed to run migrations: %v", err)
+		}
+		log.Println("Database migrations completed")
+	}
+
+	// Sum the tokens billed for embedding calls per day, tenant and model, refusing embedding
+	// calls once the budget is spent
+	var usageStore usage.Store = store
+	if cfg.Standby {
+		usageStore = usage.ReadOnly(store)
 	}
-	defer qdrantStore.Close()
+	usageAggregator := usage.NewAggregator(usageStore, cfg.EmbeddingBudget)
+	usage.SetAggregator(usageAggregator)
 
-	// Run Qdrant migrations
+	// Load service account keys
+	apiKeys := apikey.NewKeyring(store)
+	if err := apiKeys.Reload(context.Background()); err != nil {
+		log.Fatalf("Failed to load API keys: %v", err)
+	}
+
+	// Encrypt conversation content, session summaries and profiles at rest when master keys are configured
+	var encryption *envelope.Cipher
+	if len(cfg.EncryptionMasterKeys) > 0 {
+		masterKeys, err := envelope.ParseMasterKeys(cfg.EncryptionMasterKeys)
+		if err != nil {
+			log.Fatalf("Failed to configure encryption: %v", err)
+		}
+		encryption = envelope.NewCipher(store, masterKeys, envelope.Options{
+			Tenants:   cfg.EncryptionTenants,
+			CacheSize: cfg.EncryptionKeyCacheSize,
+		})
+		relational.SetContentCipher(encryption)
+		log.Printf("Conversation encryption enabled (master key %s)", encryption.MasterKeyID())
+	}
+
+	// Initialize Qdrant collections, one per content type, and run their migrations
 	log.Println("Running Qdrant migrations...")
-	if err := storage.MigrateQdrant(qdrantStore, cfg.EmbeddingDim); err != nil {
-		log.Fatalf("Failed to run Qdrant migrations: %v", err)
+	collectionManager, err := bootstrap.OpenCollections(cfg, locker)
+	if err != nil {
+		log.Fatalf("Failed to initialize Qdrant: %v", err)
 	}
+	defer collectionManager.Close()
 	log.Println("Qdrant migrations completed")
 
-	// Initialize OpenAI embedding provider
-	embeddingProvider := storage.NewOpenAIEmbeddingProvider(
-		cfg.OpenAIAPIKey,
-		cfg.OpenAIModel,
-		cfg.EmbeddingDim,
-	)
+	qdrantStore, err := collectionManager.Store(storage.ContentTypeConversations)
+	if err != nil {
+		log.Fatalf("Failed to initialize Qdrant: %v", err)
+	}
+	personalInfoVectorStore, err := collectionManager.Store(storage.ContentTypePersonalInfo)
+	if err != nil {
+		log.Fatalf("Failed to initialize Qdrant: %v", err)
+	}
+
+	// Initialize OpenAI embedding providers, one per distinct collection model
+	openAIClient, err := cfg.EgressOptions().Client(nil)
+	if err != nil {
+		log.Fatalf("Failed to configure OpenAI HTTP client: %v", err)
+	}
+	openAIClient.Transport = tracing.Transport("openai", openAIClient.Transport)
+	embeddingProjections, err := bootstrap.EmbeddingProjections(cfg, collectionManager)
+	if err != nil {
+		log.Fatalf("Failed to configure embedding projections: %v", err)
+	}
+	// In-process caches in front of the embedding provider and the search pipeline
+	var caches []cache.Inspector
+	var embeddingCache *cache.Cache[[]float32]
+	if cfg.EmbeddingCacheSize > 0 {
+		embeddingCache = cache.New[[]float32](cache.NameEmbedding, cfg.EmbeddingCacheSize, cfg.EmbeddingCacheTTL)
+		caches = append(caches, embeddingCache)
+	}
+	var queryCache *cache.Cache[[]retrieval.Candidate]
+	if cfg.QueryCacheSize > 0 {
+		queryCache = cache.New[[]retrieval.Candidate](cache.NameQuery, cfg.QueryCacheSize, cfg.QueryCacheTTL)
+		caches = append(caches, queryCache)
+	}
+	embeddingProviders, err := bootstrap.EmbeddingProviders(cfg, collectionManager, openAIClient, embeddingProjections, embeddingCache)
+	if err != nil {
+		log.Fatalf("Failed to configure embedding providers: %v", err)
+	}
+
+	// Load feature flags
+	featureFlags, err := featureflag.NewStore(cfg.FeatureFlagsFile, featureflag.DefaultFlags())
+	if err != nil {
+		log.Fatalf("Failed to load feature flags: %v", err)
+	}
 
 	// Initialize services
+	completionProvider := storage.NewOpenAICompletionProvider(cfg.OpenAIAPIKey, cfg.OpenAIChatModel, cfg.OpenAIChatMaxTokens, openAIClient)
+	sessionService := service.NewSessionService(relational.Sessions, completionProvider, service.SessionOptions{
+		RollingSummary: cfg.SessionRollingSummary,
+		SummaryTimeout: cfg.SessionSummaryTimeout,
+	})
+
+	importanceScorer, err := importance.NewScorer(cfg.ImportanceScorer, completionProvider)
+	if err != nil {
+		log.Fatalf("Failed to configure importance scoring: %v", err)
+	}
+
+	plugins, err := plugin.Enable(cfg.Plugins)
+	if err != nil {
+		log.Fatalf("Failed to enable plugins: %v", err)
+	}
+
+	// Per-user query adapters, keyed by the embedding model of the collections they apply to
+	var queryAdapters *service.QueryAdapters
+	var searchAdapter retrieval.QueryAdapter
+	if cfg.QueryAdapters {
+		dimensions := make(map[string]int, len(cfg.Collections))
+		for _, collection := range cfg.Collections {
+			dimensions[collection.Model] = collection.Dimension
+		}
+		queryAdapters = service.NewQueryAdapters(postgresStore, dimensions, cfg.Collections[storage.ContentTypeConversations].Model, service.QueryAdapterOptions{
+			CacheTTL:  cfg.QueryAdapterCacheTTL,
+			CacheSize: cfg.QueryAdapterCacheSize,
+		})
+		searchAdapter = queryAdapters
+		log.Println("Per-user query adapters enabled")
+	}
+
+	// Build the search pipeline and, when a share of searches goes to a canary, its pipeline
+	searchPipeline, canary, err := bootstrap.SearchPipelines(cfg, memories, collectionManager, embeddingProviders, searchAdapter)
+	if err != nil {
+		log.Fatalf("Failed to configure search pipeline: %v", err)
+	}
+
+	var fusionBandit *service.FusionBandit
+	if cfg.FusionBanditEnabled {
+		fusionBandit, err = service.NewFusionBandit(postgresStore, searchPipeline, service.FusionBanditOptions{
+			Arms: cfg.FusionBanditArms,
+			Guardrails: bandit.Guardrails{
+				BaselineShare: cfg.FusionBanditBaselineShare,
+				MinTrials:     int64(cfg.FusionBanditMinTrials),
+				MaxRegression: cfg.FusionBanditMaxRegression,
+			},
+			Refresh: cfg.FusionBanditRefresh,
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure fusion bandit: %v", err)
+		}
+		log.Printf("Fusion bandit enabled with %d arms", len(cfg.FusionBanditArms))
+	}
+
+	var ingestShaper *shaper.Shaper
+	if cfg.IngestShaperEnabled {
+		ingestShaper = shaper.New(shaper.Options{
+			Rate:          cfg.IngestShaperRate,
+			Burst:         cfg.IngestShaperBurst,
+			BackfillShare: cfg.IngestShaperBackfillShare,
+		})
+		log.Printf("Ingestion shaper enabled at %g embeddings/s with a burst of %d", cfg.IngestShaperRate, cfg.IngestShaperBurst)
+	}
+
+	userService := service.NewUserService(store)
+
+	// Serve only data homed in this deployment's region
+	var residencyGuard *residency.Guard
+	if cfg.Regions != nil {
+		residencyGuard = residency.NewGuard(cfg.Region, cfg.Regions, store)
+		userService.SetResidency(residencyGuard)
+		log.Printf("Data residency enabled (region %s)", cfg.Region)
+	}
+
+	// Mirror sampled traffic to the shadow embedding model; deletions reach its collection too
+	var conversationVectors storage.VectorStore = qdrantStore
+	var shadow *service.ShadowEvaluator
+	if cfg.ShadowModel != "" {
+		shadowStore, err := collectionManager.Store(storage.ContentTypeShadowConversations)
+		if err != nil {
+			log.Fatalf("Failed to initialize Qdrant: %v", err)
+		}
+		conversationVectors = storage.NewShadowedVectorStore(qdrantStore, shadowStore)
+		shadow = service.NewShadowEvaluator(embeddingProviders[cfg.ShadowModel], shadowStore, service.ShadowOptions{
+			Model:     cfg.ShadowModel,
+			SaveRate:  cfg.ShadowSaveRate,
+			QueryRate: cfg.ShadowQueryRate,
+		})
+		log.Printf("Shadow embedding model %s enabled (saves %.0f%%, searches %.0f%%)", cfg.ShadowModel, cfg.ShadowSaveRate*100, cfg.ShadowQueryRate*100)
+	}
+
+	// Strip greetings, filler and boilerplate from returned messages
+	var snippetFilter *snippet.Filter
+	if cfg.SnippetFilter {
+		patterns, err := snippet.LoadPatterns(cfg.SnippetFilterFile)
+		if err != nil {
+			log.Fatalf("Failed to load snippet filter: %v", err)
+		}
+		snippetFilter, err = snippet.New(patterns)
+		if err != nil {
+			log.Fatalf("Failed to configure snippet filter: %v", err)
+		}
+	}
+
+	// Record searches for usage analytics
+	var searchLog storage.SearchLogStore
+	if cfg.SearchLogEnabled {
+		searchLog = store
+	}
+
+	// Side effects of saves run as event subscribers, outside the request
+	eventBus := events.NewBus(events.Options{
+		QueueSize:      cfg.EventQueueSize,
+		Workers:        cfg.EventWorkers,
+		HandlerTimeout: cfg.EventHandlerTimeout,
+	})
+	if cfg.SessionRollingSummary {
+		eventBus.Subscribe(events.ConversationSaved, "session_rolling_summary", sessionService.OnConversationSaved)
+	}
+	if shadow != nil {
+		eventBus.Subscribe(events.ConversationSaved, "shadow_save", shadow.OnConversationSaved)
+	}
+
 	conversationService := service.NewConversationService(
-		postgresStore,
-		qdrantStore,
-		embeddingProvider,
+		memories,
+		sessionService,
+		conversationVectors,
+		embeddingProviders[cfg.Collections[storage.ContentTypeConversations].Model],
+		searchPipeline,
+		store,
+		featureFlags,
+		service.ConversationOptions{
+			EmbedText: service.EmbedTextOptions{
+				Roles:        cfg.EmbedRoles,
+				RolePrefixes: cfg.EmbedRolePrefixes,
+				Separator:    cfg.EmbedSeparator,
+				MaxTurns:     cfg.EmbedMaxTurns,
+				ChunkTurns:   cfg.EmbedChunkTurns,
+			},
+			EmbeddingModel:     cfg.Collections[storage.ContentTypeConversations].Model,
+			EmbeddingDimension: cfg.Collections[storage.ContentTypeConversations].Dimension,
+			ImportanceScorer:   importanceScorer,
+			Preprocessors:      append([]plugin.Preprocessor{userService}, plugins.Preprocessors()...),
+			VectorWriteMode:    cfg.VectorWriteMode,
+			Durability:         cfg.SaveDurability,
+			SearchLog:          searchLog,
+			Shadow:             shadow,
+			Canary:             canary,
+			Indexing:           qdrantStore,
+			MaxAge: service.MaxAgeDefaults{
+				Days:    cfg.SearchMaxAgeDays,
+				Tenants: cfg.SearchTenantMaxAgeDays,
+			},
+			SnippetFilter: snippetFilter,
+			Events:        eventBus,
+			QueryCache:    queryCache,
+			FusionBandit:  fusionBandit,
+			Shaper:        ingestShaper,
+		},
 	)
 
+	// Standing queries are compared with each saved conversation's vector
+	standingQueries := service.NewStandingQueryService(store, embeddingProviders[cfg.Collections[storage.ContentTypeConversations].Model], service.StandingQueryOptions{
+		MaxPerUser:   cfg.StandingQueryMaxPerUser,
+		StreamBuffer: cfg.StandingQueryStreamBuffer,
+	})
+	if cfg.StandingQueryWebhookURL != "" {
+		webhookClient, err := cfg.EgressOptions().Client(nil)
+		if err != nil {
+			log.Fatalf("Failed to configure the standing query webhook client: %v", err)
+		}
+		standingQueries.SetNotifier(usage.NewWebhook(cfg.StandingQueryWebhookURL, cfg.StandingQueryWebhookSecret, webhookClient))
+	}
+	eventBus.Subscribe(events.ConversationSaved, "standing_queries", standingQueries.OnConversationSaved)
+
+	// Experimental multivector retrieval keeps personal info in a second collection too; deletions
+	// reach it through the shadowed store
+	var personalInfoVectors storage.VectorStore = personalInfoVectorStore
+	var personalInfoMultiVectors *storage.QdrantStore
+	if cfg.PersonalInfoRetrieval == "multivector" {
+		personalInfoMultiVectors, err = collectionManager.Store(storage.ContentTypePersonalInfoMultivector)
+		if err != nil {
+			log.Fatalf("Failed to initialize Qdrant: %v", err)
+		}
+		personalInfoVectors = storage.NewShadowedVectorStore(personalInfoVectorStore, personalInfoMultiVectors)
+	}
+
+	personalInfoService := service.NewPersonalInfoService(
+		memories,
+		personalInfoVectors,
+		embeddingProviders[cfg.Collections[storage.ContentTypePersonalInfo].Model],
+		userService,
+		service.PersonalInfoTemplate(cfg.PersonalInfoEmbedTemplate),
+	)
+	if personalInfoMultiVectors != nil {
+		personalInfoService.SetMultiVector(personalInfoMultiVectors, service.MultiVectorOptions{
+			Granularity: cfg.PersonalInfoMultivectorGranularity,
+			MaxVectors:  cfg.PersonalInfoMultivectorMaxVectors,
+		})
+		log.Printf("Personal info multivector retrieval enabled (%s segments)", cfg.PersonalInfoMultivectorGranularity)
+	}
+	if queryAdapters != nil {
+		personalInfoService.SetQueryAdapters(queryAdapters, cfg.Collections[storage.ContentTypePersonalInfo].Model)
+	}
+
+	profileService := service.NewProfileService(
+		store,
+		memories,
+		memories,
+		completionProvider,
+		service.ProfileOptions{
+			CacheTTL:         cfg.ProfileCacheTTL,
+			MaxConversations: cfg.ProfileMaxConversations,
+			ReadOnly:         cfg.Standby,
+		},
+	)
+
+	// Load the demo dataset through the service layer when requested
+	if *seedDemo {
+		log.Println("Seeding demo data...")
+		result, err := seed.Load(context.Background(), conversationService, personalInfoService)
+		if err != nil {
+			log.Fatalf("Failed to seed demo data: %v", err)
+		}
+		log.Printf("Seeded %d users, %d conversations and %d personal info entries", result.Users, result.Conversations, result.PersonalInfo)
+	}
+
+	jobLog := service.NewJobLog(store)
+	confirmationTokens := service.NewConfirmationTokens(cfg.DeleteConfirmationTTL, []byte(cfg.DeleteConfirmationKey))
+	forgetting := service.NewForgettingService(memories, conversationVectors, jobLog, confirmationTokens, service.ForgettingPolicy{
+		Threshold: cfg.ForgetThreshold,
+		HalfLife:  cfg.ImportanceHalfLife,
+		MinAge:    cfg.ForgetMinAge,
+	})
+	var retrieveClassifier *queryroute.Classifier
+	if cfg.RetrieveRouting == "classifier" {
+		retrieveClassifier = queryroute.NewClassifier(cfg.RetrieveRoutingMinWeight)
+	}
+
+	drift := service.NewDriftService(conversationService, jobLog, cfg.DriftSampleSize, cfg.DriftThreshold)
+	projections := service.NewEmbeddingProjections(conversationService, embeddingProviders, embeddingProjections, jobLog, cfg.EmbeddingProjectionDir, cfg.OpenAIModel)
+	integrity := service.NewIntegrityService(conversationService, jobLog)
+
 	// Setup Gin router
-	router := api.Router(conversationService, postgresStore, qdrantStore)
+	readiness := lifecycle.NewReadiness("warming up")
+
+	elector := coord.NewElector(locker, "background_jobs", cfg.InstanceID, cfg.LeaderElectionInterval)
+
+	memoryService := service.NewMemoryService(conversationService, personalInfoService, sessionService, retrieveClassifier)
+	memoryService.SetCompressor(
+		service.NewContextCompressor(embeddingProviders[cfg.Collections[storage.ContentTypeConversations].Model], cfg.ContextCompressionRatio),
+		cfg.ContextCompression,
+	)
+
+	// Signed certificates of executed purges
+	userDeletion := service.NewUserDeletionService(relational.Users, conversationVectors, personalInfoVectors, confirmationTokens, jobLog)
+	bulkDelete := service.NewBulkDeleteService(memories, conversationVectors, jobLog, confirmationTokens)
+	var deletionCertificates *service.DeletionCertificates
+	if cfg.DeletionCertificateKey != "" {
+		signer, err := erasure.ParseKey(cfg.DeletionCertificateKey)
+		if err != nil {
+			log.Fatalf("Failed to configure deletion certificates: %v", err)
+		}
+		deletionCertificates = service.NewDeletionCertificates(postgresStore, signer)
+		forgetting.SetCertificates(deletionCertificates)
+		userDeletion.SetCertificates(deletionCertificates)
+		bulkDelete.SetCertificates(deletionCertificates)
+		log.Printf("Deletion certificates enabled (key %s)", signer.PublicKey().KeyID)
+	}
+
+	// A standby rejects writes for as long as it runs
+	maintenanceMode := service.NewMaintenanceMode(cfg.MaintenanceMode, "enabled at startup")
+	if cfg.Standby {
+		maintenanceMode = service.NewStandbyMode()
+		log.Println("Standby mode: serving reads only")
+	}
+
+	// Table maintenance and the legacy conversation backfill act on Postgres tables only
+	var tables storage.TableMaintenanceStore
+	var legacyBackfill *service.LegacyBackfillService
+	if postgresStore != nil {
+		tables = postgresStore
+		legacyBackfill = service.NewLegacyBackfillService(postgresStore, conversationService, jobLog)
+	}
+
+	deps := api.Dependencies{
+		ConversationService: conversationService,
+		PersonalInfoService: personalInfoService,
+		SessionService:      sessionService,
+		ProfileService:      profileService,
+		MemoryService:       memoryService,
+		ReindexService:      service.NewReindexService(conversationService, personalInfoService, jobLog),
+		ForgettingService:   forgetting,
+		UserDeletionService: userDeletion,
+		BulkDeleteService:   bulkDelete,
+		JobLog:              jobLog,
+		EmbeddingInspector:  service.NewEmbeddingInspector(collectionManager, embeddingProviders),
+		IndexService:        service.NewIndexService(collectionManager, tables, jobLog),
+		DeadLetterService:   service.NewDeadLetterService(store),
+		IntegrityService:    integrity,
+		DriftService:        drift,
+		Projections:         projections,
+		PersonalInfoReindex: service.NewPersonalInfoReindexService(personalInfoService, jobLog),
+		TopicMapService:     service.NewTopicMapService(conversationService, completionProvider),
+		InsightsService: service.NewInsightsService(qdrantStore, conversationService, completionProvider, service.InsightsOptions{
+			KAnonymity: cfg.InsightsKAnonymity,
+			Categories: cfg.InsightsCategories,
+		}),
+		Doctor:               service.NewDoctor(store, cfg.MemoryStoreBackend, migrationOpts, collectionManager, embeddingProviders, completionProvider),
+		ConversationImport:   service.NewConversationImportService(conversationService, jobLog),
+		LegacyBackfill:       legacyBackfill,
+		UsageService:         service.NewUsageService(store),
+		UserService:          userService,
+		StandingQueryService: standingQueries,
+		SuggestService: service.NewSuggestService(store, store, service.SuggestOptions{
+			Lookback:      cfg.SuggestLookback,
+			Conversations: cfg.SuggestConversations,
+			CacheTTL:      cfg.SuggestCacheTTL,
+			CacheSize:     cfg.SuggestCacheSize,
+		}),
+		APIKeyService:     service.NewAPIKeyService(store, apiKeys),
+		PostgresStore:     store,
+		QdrantStore:       qdrantStore,
+		CollectionManager: collectionManager,
+		MaintenanceMode:   maintenanceMode,
+		FeatureFlags:      featureFlags,
+		Readiness:         readiness,
+		HealthMonitor: health.NewMonitor(health.Options{
+			HistorySize:       cfg.HealthHistorySize,
+			FailureThreshold:  cfg.HealthFailureThreshold,
+			RecoveryThreshold: cfg.HealthRecoveryThreshold,
+		}),
+		Elector:        elector,
+		AdminAPIKey:    cfg.AdminAPIKey,
+		AdminUI:        cfg.AdminUI,
+		Tracing:        cfg.TracingEndpoint != "",
+		APIKeys:        apiKeys,
+		RequireAPIKeys: cfg.APIKeysRequired,
+		Encryption:     encryption,
+		Residency:      residencyGuard,
+		LoadShedder: loadshed.New(loadshed.Options{
+			Limits:       cfg.LoadShedLimits,
+			QueueTimeout: cfg.LoadShedQueueTimeout,
+			RetryAfter:   cfg.LoadShedRetryAfter,
+		}),
+		Middleware:    plugins.Middleware(),
+		Certificates:  deletionCertificates,
+		QueryAdapters: queryAdapters,
+		FusionBandit:  fusionBandit,
+		Caches:        caches,
+	}
+
+	// Analytics and vector exports to the blob store
+	var analyticsExports *service.AnalyticsExportService
+	if cfg.BlobStoreDir != "" {
+		blobs, err := blobstore.NewDir(cfg.BlobStoreDir)
+		if err != nil {
+			log.Fatalf("Failed to open blob store: %v", err)
+		}
+		// Analytics exports read conversations from Postgres
+		if cfg.MemoryStoreBackend == bootstrap.BackendPostgres {
+			analyticsExports = service.NewAnalyticsExportService(analytics.NewExporter(postgresStore, blobs, cfg.AnalyticsExportPrefix), jobLog)
+			deps.AnalyticsExports = analyticsExports
+		}
+		deps.VectorExports = service.NewVectorExportService(vectorio.New(blobs, cfg.VectorExportPrefix), collectionManager, jobLog)
+	}
+
+	// Background tasks run on their schedules on the leader replica
+	scheduler := schedule.New(elector.IsLeader)
+	addTask := func(name string, task config.ScheduledTask, run func(ctx context.Context) error) {
+		scheduler.Add(schedule.Task{Name: name, Schedule: task.Schedule, Enabled: task.Enabled, Jitter: task.Jitter, Run: run})
+	}
+	addTask("profile_refresh", cfg.ProfileRefresh, func(ctx context.Context) error {
+		_, err := profileService.RefreshStaleProfiles(ctx)
+		return err
+	})
+	addTask("forget", cfg.Forget, forgetting.RunScheduled)
+	addTask("embedding_drift", cfg.Drift, drift.Check)
+	addTask("analytics_export", cfg.AnalyticsExport, func(ctx context.Context) error {
+		return analyticsExports.ExportMissing(ctx, cfg.AnalyticsExportLookbackDays)
+	})
+	addTask("integrity_verify", cfg.IntegrityVerify, integrity.Verify)
+	addTask("signing_nonce_sweep", cfg.SigningNonceSweep, func(ctx context.Context) error {
+		_, err := store.DeleteExpiredSignatureNonces(ctx, time.Now())
+		return err
+	})
+	if cfg.EmbeddingAnomaly.Enabled {
+		anomalies := service.NewAnomalyService(conversationService, store, jobLog, service.AnomalyOptions{
+			Window:          cfg.EmbeddingAnomalyWindow,
+			BaselineSize:    cfg.EmbeddingAnomalyBaseline,
+			Threshold:       cfg.EmbeddingAnomalyThreshold,
+			DistressPhrases: cfg.EmbeddingAnomalyDistressPhrases,
+		})
+		if cfg.EmbeddingAnomalyWebhookURL != "" {
+			webhookClient, err := cfg.EgressOptions().Client(nil)
+			if err != nil {
+				log.Fatalf("Failed to configure the anomaly webhook client: %v", err)
+			}
+			anomalies.SetNotifier(usage.NewWebhook(cfg.EmbeddingAnomalyWebhookURL, cfg.EmbeddingAnomalyWebhookSecret, webhookClient))
+		}
+		addTask("embedding_anomaly", cfg.EmbeddingAnomaly, anomalies.Scan)
+	}
+	deps.Scheduler = scheduler
+
+	// IP allow and deny lists
+	deps.IPRules, err = ipfilter.NewRules(cfg.IPAllow, cfg.IPDeny)
+	if err != nil {
+		log.Fatalf("Failed to configure IP filtering: %v", err)
+	}
+	deps.AdminIPRules, err = ipfilter.NewRules(cfg.AdminIPAllow, cfg.AdminIPDeny)
+	if err != nil {
+		log.Fatalf("Failed to configure admin IP filtering: %v", err)
+	}
+
+	// HMAC-signed requests for callers without bearer tokens
+	if len(cfg.SigningClients) > 0 {
+		clients, err := signing.ParseClients(cfg.SigningClients)
+		if err != nil {
+			log.Fatalf("Failed to configure request signing: %v", err)
+		}
+		deps.SignatureVerifier = signing.NewVerifier(clients, cfg.SigningMaxSkew, store)
+		log.Printf("Request signing enabled for %d clients", len(clients))
+	}
+
+	// Sampled request/response audit logging
+	if cfg.RequestAuditEnabled {
+		routeRates, err := auditlog.ParseRouteRates(cfg.RequestAuditRouteRates)
+		if err != nil {
+			log.Fatalf("Failed to configure request audit logging: %v", err)
+		}
+		sink, err := auditlog.NewSink(cfg.RequestAuditSink, []byte(cfg.RequestAuditChainKey))
+		if err != nil {
+			log.Fatalf("Failed to configure request audit logging: %v", err)
+		}
+		defer func() {
+			// Logged so the chain's end is anchored outside the audit log
+			sequence, hash := sink.Head()
+			log.Printf("Request audit chain head: seq=%d hash=%s", sequence, hash)
+			sink.Close()
+		}()
+
+		deps.AuditSink = sink
+		deps.AuditSampler = auditlog.NewSampler(cfg.RequestAuditSampleRate, routeRates)
+		deps.AuditMaxBody = cfg.RequestAuditMaxBody
+		if sink.Path() != "" {
+			deps.AuditLog = sink
+		}
+		log.Printf("Request audit logging enabled (sink=%s, default rate=%g)", cfg.RequestAuditSink, cfg.RequestAuditSampleRate)
+	}
+
+	router := api.Router(deps)
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Failed to configure trusted proxies: %v", err)
+	}
 
 	// Start server
-	addr := fmt.Sprintf(":%d", cfg.Port)
-	log.Printf("Starting RAG server on %s", addr)
+	addr := cfg.GetListenAddr()
+	serverOpts := server.Options{
+		Addr:             addr,
+		TLSCertFile:      cfg.TLSCertFile,
+		TLSKeyFile:       cfg.TLSKeyFile,
+		AutocertDomains:  cfg.AutocertDomains,
+		AutocertCacheDir: cfg.AutocertCacheDir,
+		AutocertEmail:    cfg.AutocertEmail,
+		H2C:              cfg.HTTP2Cleartext,
+	}
+	if cfg.HTTPRedirectPort > 0 {
+		serverOpts.HTTPRedirectAddr = fmt.Sprintf(":%d", cfg.HTTPRedirectPort)
+	}
+
+	srv, err := server.New(router, serverOpts)
+	if err != nil {
+		log.Fatalf("Failed to configure server: %v", err)
+	}
+
+	listener, listenAddr, err := server.Listen(server.ListenOptions{
+		Addr:              addr,
+		SocketPath:        cfg.ListenSocket,
+		SocketMode:        cfg.ListenSocketMode,
+		SystemdActivation: cfg.SystemdActivation,
+	})
+	if err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+
+	scheme := "http"
+	if serverOpts.TLSEnabled() {
+		scheme = "https"
+	}
+	log.Printf("Starting RAG server on %s (%s)", listenAddr, scheme)
 
 	// Run server in a goroutine
 	go func() {
-		if err := router.Run(addr); err != nil {
+		if err := srv.Serve(listener); err != nil {
 			log.Fatalf("Failed to start server: %v", err)
 		}
 	}()
 
+	// Warm up dependencies before reporting ready
+	if cfg.WarmupEnabled {
+		go func() {
+			steps := warmupSteps(cfg, postgresStore, collectionManager, embeddingProviders, featureFlags)
+			if err := lifecycle.RunWarmup(context.Background(), cfg.WarmupTimeout, steps); err != nil {
+				log.Printf("Warm-up failed, server stays not ready: %v", err)
+				errreport.Background(context.Background(), "warmup", err)
+				readiness.MarkNotReady(err.Error())
+				return
+			}
+			readiness.MarkReady()
+		}()
+	} else {
+		readiness.MarkReady()
+	}
+
+	// Consume the shared work queue on every replica; refresh cached user profiles and forget
+	// low-importance conversations on the leader replica only
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	// Advisory locks on a standby's replica aren't shared with the primary, so a standby never
+	// campaigns and runs no scheduled tasks
+	if !cfg.Standby {
+		go elector.Run(backgroundCtx)
+	}
+	go queue.ReportMetrics(backgroundCtx, store, store, 15*time.Second)
+	if cfg.QuotaWebhookURL != "" {
+		webhookClient, err := cfg.EgressOptions().Client(nil)
+		if err != nil {
+			log.Fatalf("Failed to configure the quota webhook client: %v", err)
+		}
+		usageAggregator.SetQuotaNotifier(usage.NewWebhook(cfg.QuotaWebhookURL, cfg.QuotaWebhookSecret, webhookClient), elector.IsLeader)
+	}
+	go usageAggregator.Run(backgroundCtx, cfg.UsageFlushInterval)
+	go apiKeys.Run(backgroundCtx, cfg.APIKeyReloadInterval)
+	if cfg.QueueWorkerEnabled {
+		worker := queue.NewWorker(store, queue.Options{
+			Owner:        cfg.InstanceID,
+			Lease:        cfg.QueueLease,
+			PollInterval: cfg.QueuePollInterval,
+			BatchSize:    cfg.QueueBatchSize,
+			MaxAttempts:  cfg.QueueMaxAttempts,
+			Deferred:     []string{models.QueueKindConversationVectorBackfill},
+		})
+		for _, kind := range []string{models.QueueKindConversationVector, models.QueueKindConversationVectorBackfill} {
+			worker.Handle(kind, conversationService.HandleVectorJob)
+			worker.OnDeadLetter(kind, conversationService.HandleDeadVectorJob)
+		}
+		go worker.Run(backgroundCtx)
+	}
+	go scheduler.Run(backgroundCtx)
+
 	// Wait for interrupt signal
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	<-sigChan
 	log.Println("Shutting down RAG server...")
+	stopBackground()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Server shutdown did not complete cleanly: %v", err)
+	}
+	if err := eventBus.Close(ctx); err != nil {
+		log.Printf("Failed to drain events: %v", err)
+	}
+	if err := usageAggregator.Flush(ctx); err != nil {
+		log.Printf("Failed to flush embedding usage: %v", err)
+	}
+	if shutdownTracing != nil {
+		if err := shutdownTracing(ctx); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
+	}
+	errreport.Default().Flush(2 * time.Second)
+}
+
+// warmupSteps builds the startup warm-up sequence; Postgres connections are opened only when
+// there is a Postgres server
+func warmupSteps(
+	cfg *config.Config,
+	postgresStore *storage.PostgresStore,
+	collectionManager *storage.CollectionManager,
+	embeddingProviders map[string]storage.EmbeddingProvider,
+	featureFlags *featureflag.Store,
+) []lifecycle.WarmupStep {
+	steps := []lifecycle.WarmupStep{
+		{
+			Name:     "verify collections",
+			Required: true,
+			Run: func(ctx context.Context) error {
+				for _, contentType := range collectionManager.ContentTypes() {
+					store, err := collectionManager.Store(contentType)
+					if err != nil {
+						return err
+					}
+					exists, err := store.CollectionExists(ctx)
+					if err != nil {
+						return err
+					}
+					if !exists {
+						return fmt.Errorf("collection %q does not exist", store.Collection())
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Name: "prime embedding providers",
+			Run: func(ctx context.Context) error {
+				for model, provider := range embeddingProviders {
+					if _, err := provider.Embed(ctx, "warm-up"); err != nil {
+						return fmt.Errorf("model %s: %w", model, err)
+					}
+				}
+				return nil
+			},
+		},
+	}
+	if postgresStore != nil {
+		steps = append(steps, lifecycle.WarmupStep{
+			Name: "open postgres connections",
+			Run: func(ctx context.Context) error {
+				n := cfg.WarmupPostgresConnections
+				if n > postgresStore.MaxIdleConns() {
+					n = postgresStore.MaxIdleConns()
+				}
+				return postgresStore.WarmConnections(ctx, n)
+			},
+		})
+	}
+	return append(steps, lifecycle.WarmupStep{
+		Name: "load feature flags",
+		Run: func(ctx context.Context) error {
+			return featureFlags.Reload()
+		},
+	})
+}
+
+// migrationPreflight prints the pending database migrations and the lock-heavy ones among them,
+// returning the process exit code: 1 if any would be refused by the block guard
+func migrationPreflight(postgresStore *storage.PostgresStore, opts storage.MigrationOptions) int {
+	plan, err := storage.PlanMigrations(postgresStore.GetDB(), opts)
+	if err != nil {
+		log.Printf("Failed to plan migrations: %v", err)
+		return 2
+	}
+
+	fmt.Printf("%d pending statements\n", len(plan.Pending))
+	for _, stmt := range plan.Pending {
+		fmt.Printf("  %s\n", stmt)
+	}
+	for _, risk := range plan.Risks {
+		action := "blocked in block mode"
+		if risk.Rewrite != "" {
+			action = "runs as: " + risk.Rewrite
+		}
+		fmt.Printf("\nlock risk on %s (~%d rows): %s\n  %s\n  %s\n", risk.Table, risk.Rows, risk.Reason, risk.Statement, action)
+	}
+
+	if len(plan.Blocking()) > 0 {
+		return 1
+	}
+	return 0
 }
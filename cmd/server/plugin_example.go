@@ -0,0 +1,6 @@
+//go:build example_plugin
+
+package main
+
+// Compiles the example plugin into the server
+import _ "refo-rag-server/internal/plugin/example"
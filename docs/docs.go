@@ -23,56 +23,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/rag/conversation/search": {
-            "get": {
-                "description": "Search for conversations by semantic similarity",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "conversations"
-                ],
-                "summary": "Search conversations",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Search query",
-                        "name": "query",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Result limit (default: 10, max: 100)",
-                        "name": "top_k",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Search results with metadata",
-                        "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid request",
-                        "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Server error",
-                        "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/rag/conversation/store": {
+        "/api/rag/admin/analytics/export": {
             "post": {
-                "description": "Save a new conversation with messages and metadata",
+                "description": "Start a background job writing the conversations, messages and search logs created on a UTC day as\nParquet files to the blob store, read from one database snapshot and partitioned by date. An earlier\nexport of the day is replaced; use it to backfill days the schedule missed. The job result lists the\nfiles and their row counts. Track progress with GET /admin/jobs/{job_id}.",
                 "consumes": [
                     "application/json"
                 ],
@@ -80,71 +33,105 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "conversations"
+                    "admin"
                 ],
-                "summary": "Save a conversation",
+                "summary": "Export a day of analytics data",
                 "parameters": [
                     {
-                        "description": "Conversation save request",
+                        "description": "Day to export",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.ConversationSaveRequest"
+                            "$ref": "#/definitions/models.AnalyticsExportRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Conversation saved successfully",
+                    "202": {
+                        "description": "Job started",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.APIResponse-models_JobStartedResponse"
                         }
                     },
                     "400": {
                         "description": "Invalid request",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "An export is already running",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Server error",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
             }
         },
-        "/api/rag/health": {
+        "/api/rag/admin/api-keys": {
             "get": {
-                "description": "Check if the RAG server and its dependencies are healthy",
+                "description": "List service account keys newest first, without their secrets",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "admin"
+                ],
+                "summary": "List API keys",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Include revoked keys",
+                        "name": "include_revoked",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Health check",
                 "responses": {
                     "200": {
-                        "description": "Server is healthy",
+                        "description": "Keys",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.APIResponse-models_APIKeyListResponse"
                         }
                     },
-                    "503": {
-                        "description": "Service unavailable",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/api/rag/personal-info": {
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            },
             "post": {
-                "description": "Save personal information provided by guardians (medical, contact, emergency, etc.)",
+                "description": "Issue a service account key with the given scopes: read allows searches and other reads, write\nalso allows saves and updates, and admin also allows the admin API. The secret is only returned in\nthis response; the server stores a hash of it. Send it as \"Authorization: Bearer \u003csecret\u003e\" or\n\"X-API-Key: \u003csecret\u003e\".",
                 "consumes": [
                     "application/json"
                 ],
@@ -152,125 +139,158 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "personal-info"
+                    "admin"
                 ],
-                "summary": "Create personal information",
+                "summary": "Create an API key",
                 "parameters": [
                     {
-                        "description": "Personal info creation request",
+                        "description": "Key to create",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.PersonalInfoCreateRequest"
+                            "$ref": "#/definitions/models.APIKeyCreateRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Personal info created successfully",
+                        "description": "Key created",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.APIResponse-models_APIKeySecret"
                         }
                     },
                     "400": {
                         "description": "Invalid request",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Server error",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
             }
         },
-        "/api/rag/personal-info/user/{user_id}": {
+        "/api/rag/admin/api-keys/{key_id}": {
             "get": {
-                "description": "Retrieve all personal information entries for a specific user",
+                "description": "Get a service account key without its secret",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "personal-info"
+                    "admin"
                 ],
-                "summary": "Get all personal information for a user",
+                "summary": "Get an API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "user_id",
+                        "description": "API key ID",
+                        "name": "key_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Personal info list retrieved successfully",
+                        "description": "Key",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.APIResponse-models_APIKey"
                         }
                     },
-                    "400": {
-                        "description": "Invalid request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Key not found",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Server error",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/api/rag/personal-info/{info_id}": {
-            "get": {
-                "description": "Retrieve a personal information entry by ID",
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Revoke a service account key. This instance rejects it immediately; other instances reject it\nonce they reload their keys (API_KEY_RELOAD_INTERVAL).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "personal-info"
+                    "admin"
                 ],
-                "summary": "Get personal information",
+                "summary": "Revoke an API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Personal info ID",
-                        "name": "info_id",
+                        "description": "API key ID",
+                        "name": "key_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Personal info retrieved successfully",
+                        "description": "Key revoked",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.APIResponse-models_APIKey"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Personal info not found",
+                        "description": "Key not found",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Server error",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
-                }
-            },
-            "put": {
-                "description": "Update an existing personal information entry",
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/api-keys/{key_id}/rotate": {
+            "post": {
+                "description": "Issue a new key with the same name, scopes and expiry and return its secret. The old key keeps\nworking for grace_period_seconds (default 0, i.e. it stops immediately) so clients can switch over.",
                 "consumes": [
                     "application/json"
                 ],
@@ -278,209 +298,10696 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "personal-info"
+                    "admin"
                 ],
-                "summary": "Update personal information",
+                "summary": "Rotate an API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Personal info ID",
-                        "name": "info_id",
+                        "description": "API key ID",
+                        "name": "key_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Personal info update request",
+                        "description": "Grace period for the old key",
                         "name": "request",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.PersonalInfoUpdateRequest"
+                            "$ref": "#/definitions/models.APIKeyRotateRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Personal info updated successfully",
+                    "201": {
+                        "description": "Replacement key",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.APIResponse-models_APIKeySecret"
                         }
                     },
                     "400": {
                         "description": "Invalid request",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Personal info not found",
+                        "description": "Key not found",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Key is revoked, expired or already rotated",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Server error",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
-                }
-            },
-            "delete": {
-                "description": "Delete a personal information entry by ID",
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/audit/verify": {
+            "get": {
+                "description": "Re-compute the keyed hash chain of the request audit log file and report whether every record\nlinks to the one before it. An altered, removed or reordered record, or one hashed without the\nserver's REQUEST_AUDIT_CHAIN_KEY, is reported with its line and sequence number. The chain must end\nat the head stored next to the log, so records removed from the end are reported too; compare\nhead_sequence and head_hash with values logged earlier to detect the log and its head being\nreplaced together. Only available when REQUEST_AUDIT_SINK is a file.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "personal-info"
+                    "admin"
                 ],
-                "summary": "Delete personal information",
+                "summary": "Verify the audit log",
+                "responses": {
+                    "200": {
+                        "description": "Verification result",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_AuditVerification"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/caches": {
+            "get": {
+                "description": "Report each enabled in-process cache of this replica: the embedding cache in front of the embedding\nprovider and the query cache of search results. Counters run since the process started: hits,\nmisses and the hit ratio, the entries held against the capacity, and entries evicted to make room\nor because they expired. The same counters are exported on /metrics as rag_cache_*.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List cache stats",
+                "responses": {
+                    "200": {
+                        "description": "Cache stats",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_CacheStatsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/caches/{name}/flush": {
+            "post": {
+                "description": "Drop every entry of an in-process cache on this replica, e.g. when searches serve results that are\nout of date. Flush each replica to clear a cache everywhere. Its counters keep running.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Flush a cache",
                 "parameters": [
                     {
+                        "enum": [
+                            "embedding",
+                            "query"
+                        ],
                         "type": "string",
-                        "description": "Personal info ID",
-                        "name": "info_id",
+                        "description": "Cache name",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Personal info deleted successfully",
+                        "description": "Cache flushed",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.APIResponse-models_CacheFlushResponse"
                         }
                     },
-                    "404": {
-                        "description": "Personal info not found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Server error",
+                    "404": {
+                        "description": "No such cache, or it is disabled",
                         "schema": {
-                            "$ref": "#/definitions/models.APIResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "models.APIResponse": {
-            "type": "object",
-            "properties": {
-                "data": {},
-                "error": {
-                    "$ref": "#/definitions/models.ErrorInfo"
-                },
-                "metadata": {
-                    "$ref": "#/definitions/models.Metadata"
-                },
-                "success": {
-                    "type": "boolean"
-                }
-            }
-        },
-        "models.ConversationSaveRequest": {
-            "type": "object",
-            "properties": {
-                "conversation_id": {
-                    "type": "string"
                 },
-                "messages": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Message"
+                "security": [
+                    {
+                        "AdminAPIKey": []
                     }
-                },
-                "metadata": {
-                    "$ref": "#/definitions/models.Metadata"
-                }
+                ]
             }
         },
-        "models.ErrorInfo": {
-            "type": "object",
+        "/api/rag/admin/collections": {
+            "get": {
+                "description": "List managed Qdrant collections with their configured and live sharding/replication settings",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List vector collections",
+                "responses": {
+                    "200": {
+                        "description": "Collection list",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_CollectionListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/conversation-aliases/{alias_id}": {
+            "put": {
+                "description": "Make an old conversation ID, left behind by a merge or an import that remapped IDs, resolve to the\nconversation that replaced it, so external references to it stay valid. Reading or changing the\nold ID then acts on that conversation and names it in the X-Resolved-Conversation-ID header.\nAliases of the old ID are moved along to the new conversation. Replaces an earlier alias of the ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Alias an old conversation ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Old conversation ID",
+                        "name": "alias_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Conversation the ID resolves to",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ConversationAliasRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Alias saved",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_ConversationAlias"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Conversation not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Old ID is a stored conversation",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/conversations/import": {
+            "post": {
+                "description": "Read a chat export holding many users' sessions and save one conversation per user session in a\nbackground job, keeping the original users, sessions and timestamps. Supported formats are Dialogflow\nES/CX interaction logs (format=dialogflow), generic chat webhook logs with one JSON message per line\n(format=webhook) and CSV transcripts with a header row (format=csv). The export is parsed before the\njob starts, so malformed input is rejected right away. Conversation IDs are derived from the platform's\nuser and session IDs, so importing the same export again updates the imported conversations.\nImported conversations are saved in the backfill lane, behind live saves when ingestion is shaped.\nFollow the job with GET /admin/jobs/{job_id}.",
+                "consumes": [
+                    "application/json",
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Import conversations from another assistant platform",
+                "parameters": [
+                    {
+                        "enum": [
+                            "dialogflow",
+                            "webhook",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User of messages that don't name one",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated speaker names stored with the assistant role",
+                        "name": "assistant_speakers",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of timestamps without an offset",
+                        "name": "tz",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Job started",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_JobStartedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Unsupported format or invalid export",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "An import is already running",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Export too large",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/conversations/legacy-backfill": {
+            "post": {
+                "description": "Start a background job that upgrades conversations saved before messages and user IDs were stored.\nTheir concatenated question is split into turns at speaker labels at the start of a line, such as\n\"User:\", \"Assistant:\", \"Q:\" or \"A:\"; without labels it is kept as one user message, followed by the\nanswer. Conversations without a user ID get the user named by their metadata (user_id, userId or\nuser), else the owner of their session, else default_user_id. Upgraded conversations are re-embedded.\nA dry run changes nothing and reports the counts with a few sample conversations. The job result is\nupdated after every batch; follow it with GET /admin/jobs/{job_id}. A job that failed or was\ninterrupted by a restart can be continued with resume_job_id instead of starting over.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Backfill legacy conversations",
+                "parameters": [
+                    {
+                        "description": "Backfill options",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.LegacyBackfillRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Job started",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_JobStartedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or job can't be resumed",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A legacy backfill is already running",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/conversations/unembedded": {
+            "get": {
+                "description": "List conversations stored without a vector because the embedding provider refused their text for\na content policy or its length, most recently refused first. They are kept but can't be found by\nsearch until their embedding is retried.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List unembedded conversations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only conversations of this user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of conversations",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of conversations to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Unembedded conversations",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_UnembeddedListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/conversations/{conversation_id}/retry-embedding": {
+            "post": {
+                "description": "Embed a conversation the embedding provider refused and write its vector. Messages in the body\nreplace the stored ones first, so offending content can be removed or shortened. If the provider\nrefuses the text again, the response is 422 EMBEDDING_REJECTED and the conversation stays listed,\nwith any corrected messages kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Retry embedding a conversation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversation ID",
+                        "name": "conversation_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Corrected messages",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.UnembeddedRetryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Conversation embedded",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_SaveResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Conversation not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conversation was not refused",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Embedding provider refused the text again",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Embedding budget spent",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/deletion-certificates": {
+            "get": {
+                "description": "List the signed certificates issued after executed retention runs, user deletions and bulk deletions,\nnewest first. Only available when DELETION_CERTIFICATE_KEY is set.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List deletion certificates",
+                "parameters": [
+                    {
+                        "enum": [
+                            "retention",
+                            "user_delete",
+                            "conversation_bulk_delete"
+                        ],
+                        "type": "string",
+                        "description": "Only certificates of this purge kind",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only certificates of purges limited to this user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of certificates",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deletion certificates",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_DeletionCertificateListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/deletion-certificates/public-key": {
+            "get": {
+                "description": "Get the Ed25519 public key that verifies deletion certificates, for customers checking their\ncertificates independently. Certificates name the key they were signed with in key_id.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get the deletion certificate public key",
+                "responses": {
+                    "200": {
+                        "description": "Public key",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_DeletionCertificateKey"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/deletion-certificates/{certificate_id}": {
+            "get": {
+                "description": "Get a signed deletion certificate: what was removed, when and under which policy. The signature is\nthe Ed25519 signature of the certificate's JSON encoding without the signature field, verified\nwith the key from /api/rag/admin/deletion-certificates/public-key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get a deletion certificate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Certificate ID",
+                        "name": "certificate_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deletion certificate",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_DeletionCertificate"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Certificate not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/dlq": {
+            "get": {
+                "description": "List work queue items that failed on every attempt, newest first, with their payload and last error",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List dead letters",
+                "parameters": [
+                    {
+                        "enum": [
+                            "conversation_vector"
+                        ],
+                        "type": "string",
+                        "description": "Only items of this kind",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of items",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dead letters",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_DeadLetterListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/dlq/{id}": {
+            "get": {
+                "description": "Get a dead-lettered work queue item with its payload, attempt count and last error",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get a dead letter",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Dead letter ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dead letter",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_DeadLetter"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Dead letter not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete a dead-lettered item without processing it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Discard a dead letter",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Dead letter ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Item discarded",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_DeadLetterDiscardResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Dead letter not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/dlq/{id}/retry": {
+            "post": {
+                "description": "Move a dead-lettered item back to the work queue with its attempt count reset. It becomes\navailable to workers immediately.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Retry a dead letter",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Dead letter ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Item requeued",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_DeadLetterRequeueResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Dead letter not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/doctor": {
+            "post": {
+                "description": "Check connectivity to the database, Qdrant and the model providers, that no schema migration is pending, that\nevery collection exists with its configured vector size and distance, and that every embedding model returns\nvectors of the dimension of the collections bound to it; then save a scratch vector under the user\n__doctor__, find it by search and delete it, unless round_trip is false. Checks depending on a failed one\nare skipped. The report is returned with status 200 whether or not every check passed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Run the self-test",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Save, search and delete a scratch vector",
+                        "name": "round_trip",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Self-test report",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_DoctorReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameter",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/embeddings/drift": {
+            "post": {
+                "description": "Start a background job that re-embeds a random sample of stored conversations with the live embedding\nmodel and measures the cosine distance to their stored vectors. Conversations whose text changed since\nthey were embedded are skipped, so a non-zero distance means the model's output changed, e.g. after a\nsilent provider update. The job result reports the mean and maximum distance and whether the mean\nexceeded the alert threshold; both are also exported as metrics. Track progress with\nGET /admin/jobs/{job_id}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Check embedding drift",
+                "responses": {
+                    "202": {
+                        "description": "Job started",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_JobStartedResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A drift check is already running",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/embeddings/inspect": {
+            "post": {
+                "description": "Embed arbitrary text with a collection's model and return the embedding with the stored points\nnearest to it, including scores, distances and payloads. Use it to debug why a record was or\nwasn't retrieved for a query without direct Qdrant access.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Inspect an embedding and its nearest neighbors",
+                "parameters": [
+                    {
+                        "description": "Text to inspect",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.EmbeddingInspectRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Embedding and neighbors",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_EmbeddingInspection"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/embeddings/projections": {
+            "get": {
+                "description": "List the dimensionality reduction applied to each embedding model's vectors before they are stored\nor searched: truncation to the leading components, or a trained PCA matrix with the share of variance\nit keeps and when it was trained and loaded. Models without a projection aren't listed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List embedding projections",
+                "responses": {
+                    "200": {
+                        "description": "Embedding projections",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_EmbeddingProjectionListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/embeddings/projections/reload": {
+            "post": {
+                "description": "Read every configured PCA matrix from its file again, e.g. after copying a retrained matrix over it. A\nmatrix must keep the dimensions of the one it replaces; if one fails to load, it and the rest keep\ntheir current matrix. Stored vectors projected with the previous matrix don't match queries projected\nwith the new one, so reindex the model's collections afterwards.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reload embedding projections",
+                "responses": {
+                    "200": {
+                        "description": "Reloaded projections",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_EmbeddingProjectionListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "A matrix failed to load",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/embeddings/projections/train": {
+            "post": {
+                "description": "Start a background job that embeds a random sample of stored conversations with the full model, fits\na PCA matrix reducing its vectors to the requested dimension, and writes it to a new file under\nEMBEDDING_PROJECTION_DIR. The job result reports the file and the share of variance the matrix keeps.\nNothing changes until the file is configured in EMBEDDING_PROJECTIONS, or copied over the loaded\none and reloaded, and the model's collections are reindexed. Track progress with\nGET /admin/jobs/{job_id}.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Train an embedding projection",
+                "parameters": [
+                    {
+                        "description": "Model, dimension and sample size",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.EmbeddingProjectionTrainRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Job started",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_JobStartedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or unknown model",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A projection is already being trained",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/encryption/keys": {
+            "get": {
+                "description": "List every tenant's data key versions and the master key each is wrapped by; the key material is\nnever returned. Only available when conversation encryption is enabled.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List encryption data keys",
+                "responses": {
+                    "200": {
+                        "description": "Data keys",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_DataKeyListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/encryption/keys/rotate": {
+            "post": {
+                "description": "Create a new data key version for a tenant. Conversations saved afterwards are encrypted with it;\nexisting conversations stay readable with the older versions and are re-encrypted when saved again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Rotate a tenant's data key",
+                "parameters": [
+                    {
+                        "description": "Tenant to rotate",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DataKeyRotateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "New data key version",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_DataKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or tenant not in ENCRYPTION_TENANTS",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/encryption/rewrap": {
+            "post": {
+                "description": "Wrap every data key that is still wrapped by a retired master key with the current one (the first\nentry of ENCRYPTION_MASTER_KEYS). Run it after a master key rotation; once it succeeds the\nretired master keys can be removed from the configuration.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Rewrap data keys",
+                "responses": {
+                    "200": {
+                        "description": "Rewrap result",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_DataKeyRewrapResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/feature-flags": {
+            "get": {
+                "description": "List feature flags with their global defaults and per-tenant overrides",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List feature flags",
+                "responses": {
+                    "200": {
+                        "description": "Feature flags",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_FeatureFlagListResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/feature-flags/reload": {
+            "post": {
+                "description": "Re-read the feature flags file so rollouts and rollbacks take effect immediately",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reload feature flags",
+                "responses": {
+                    "200": {
+                        "description": "Reloaded feature flags",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_FeatureFlagListResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Flags file could not be loaded; previous flags remain active",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/fusion-bandit/tenants/{tenant}": {
+            "get": {
+                "description": "Get the fusion weight arms a tenant's searches are served with, the relevance feedback each collected,\nwhich arms the guardrails demoted, the best arm and whether the tenant is frozen to one arm.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get a tenant's fusion bandit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant",
+                        "name": "tenant",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Fusion bandit status",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_FusionBanditStatus"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/fusion-bandit/tenants/{tenant}/freeze": {
+            "post": {
+                "description": "Serve every search of a tenant with one arm, by default its best arm, e.g. once the bandit has\nconverged or to roll back to the baseline. Feedback isn't counted while frozen. Other replicas\nfollow within FUSION_BANDIT_REFRESH.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Freeze a tenant's fusion weights",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant",
+                        "name": "tenant",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Arm to freeze to",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.FusionBanditFreezeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Fusion bandit status",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_FusionBanditStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or unknown fusion arm",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Let the fusion bandit choose the arms of a tenant's searches again and count their feedback.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Unfreeze a tenant's fusion weights",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant",
+                        "name": "tenant",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Fusion bandit status",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_FusionBanditStatus"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/health/history": {
+            "get": {
+                "description": "Return recent raw health checks, damped status transitions, and a stability indicator for each dependency",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Dependency health history",
+                "responses": {
+                    "200": {
+                        "description": "Health history",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_HealthHistoryResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/index-health": {
+            "get": {
+                "description": "Report each Qdrant collection's status, optimizer state, segment count, indexed-vector percentage\nand memory estimate, and each Postgres table's size, dead rows and index sizes. Warnings flag failed\nor stalled optimizers and collections that are mostly unindexed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get index health",
+                "responses": {
+                    "200": {
+                        "description": "Index health",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_IndexHealthResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/index/optimize": {
+            "post": {
+                "description": "Start a background job that triggers Qdrant's optimizers on every collection, merging segments,\nvacuuming deleted points and building missing indexes, and runs VACUUM ANALYZE on the Postgres\ntables. Run it after heavy delete or retention workloads. Track progress with GET /admin/jobs/{job_id}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Optimize indexes",
+                "parameters": [
+                    {
+                        "enum": [
+                            "all",
+                            "qdrant",
+                            "postgres"
+                        ],
+                        "type": "string",
+                        "default": "all",
+                        "description": "What to optimize",
+                        "name": "target",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Job started",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_JobStartedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid target",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "An optimization job is already running",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/insights": {
+            "get": {
+                "description": "Sample recent unsuppressed conversations across users, at most 20 per user, cluster their vectors into\ncommon topics and assign each conversation to the nearest question category (INSIGHTS_QUESTION_CATEGORIES).\nOnly topics and categories with at least INSIGHTS_K_ANONYMITY distinct users are reported, as counts and\nshares; smaller groups are withheld and only counted. With label, the chat model names each reported\ntopic from conversations of different users; no conversation text, user or conversation ID is returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get cross-user insights",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 30,
+                        "description": "Days of conversations sampled, at most 365",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 2000,
+                        "description": "Conversations sampled, at most 10000",
+                        "name": "max_conversations",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of topics before suppression, at most 30; picked from the sample size by default",
+                        "name": "clusters",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Name the topics with the chat model",
+                        "name": "label",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Insights",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_Insights"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameter",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Too few users in the sample for the anonymity threshold",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/integrity/verify": {
+            "post": {
+                "description": "Start a background job that re-hashes the text each stored conversation embeds and compares it with\nthe SHA-256 recorded when it was last embedded and the hash in its Qdrant payload. The job result\nreports conversations whose vector was embedded from other text (vector_drift), whose text changed\nsince it was embedded (content_drift) or that have no vector (missing_vector). Reindex affected users\nto repair them. Track progress with GET /admin/jobs/{job_id}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Verify content integrity",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only verify this user's conversations",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Job started",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_JobStartedResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A verification job is already running",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/jobs": {
+            "get": {
+                "description": "List the most recent administrative jobs, including dry runs, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List admin jobs",
+                "parameters": [
+                    {
+                        "enum": [
+                            "user_reindex",
+                            "retention",
+                            "user_delete",
+                            "optimize",
+                            "integrity_verify"
+                        ],
+                        "type": "string",
+                        "description": "Only jobs of this kind",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of jobs",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Job list",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_JobListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/jobs/{job_id}": {
+            "get": {
+                "description": "Get an administrative job with its scope, status and result",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get an admin job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "job_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Job",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_Job"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/maintenance": {
+            "get": {
+                "description": "Report whether the server is in read-only maintenance mode",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get maintenance mode",
+                "responses": {
+                    "200": {
+                        "description": "Maintenance status",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_MaintenanceStatus"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Enable or disable read-only maintenance mode; writes return 503 while enabled and searches keep working",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Toggle maintenance mode",
+                "parameters": [
+                    {
+                        "description": "Maintenance toggle",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MaintenanceUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Maintenance status",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_MaintenanceStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Standby instances can't leave read-only mode",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/migrations": {
+            "get": {
+                "description": "Plan the schema migrations the server would apply to the configured database on start, without running\nthem, as the server's -migrate-preflight flag does. On Postgres, statements that hold a heavy lock on a large\ntable are listed as risks with the lock-friendly statement run instead, if any; blocking counts those\nthe block guard would refuse.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Show pending schema migrations",
+                "responses": {
+                    "200": {
+                        "description": "Migration plan",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_MigrationStatus"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Database unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/personal-info/reindex": {
+            "post": {
+                "description": "Start a background job that re-embeds every user's personal info entries and replaces their vectors,\ne.g. after changing how entries are turned into embedding text. Entries are processed in ID order and\nthe job result is updated after every batch with the counts so far and the last entry processed;\nfollow it with GET /admin/jobs/{job_id}. A job that failed or was interrupted by a restart can be\ncontinued with resume_job_id instead of starting over.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Re-embed all personal information",
+                "parameters": [
+                    {
+                        "description": "Job to resume",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.PersonalInfoReindexRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Job started",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_JobStartedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or job can't be resumed",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A personal info reindex is already running",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/retention/run": {
+            "post": {
+                "description": "Delete every unpinned conversation whose decayed importance is below the forgetting threshold.\nDeletion takes two calls: without confirmation_token the response lists what would be deleted\nand returns a token (202), which must be sent back before it expires to execute the run. A token\nis rejected if the number of affected conversations changed in between. With dry_run=true only\nthe preview is returned. Every run is recorded in the job log.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Run the retention policy",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Report what would be affected without executing",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token returned by the first call",
+                        "name": "confirmation_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Retention result",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_RetentionRunResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Confirmation required",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_RetentionRunResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid confirmation token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Scope changed since the token was issued",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Confirmation token expired",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/scheduler/tasks": {
+            "get": {
+                "description": "List the background tasks of the scheduler with their cron schedule, whether they are enabled, the\nnext and last run times, the last outcome and error, and run, failure and skip counts. Scheduled\nruns happen on the leader replica only, so ask the leader for the runs; leader tells whether this\nreplica is it. A run still going when the next one is due is skipped, not doubled.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List scheduled tasks",
+                "responses": {
+                    "200": {
+                        "description": "Scheduled tasks",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_ScheduledTasksResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/scheduler/tasks/{name}/run": {
+            "post": {
+                "description": "Start an enabled scheduled task on this replica without waiting for its schedule. The run is\nreported by GET /admin/scheduler/tasks like a scheduled one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Run a scheduled task now",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Run started",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_ScheduledTaskStatus"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No such task",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The task is disabled or already running",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/search/playground": {
+            "post": {
+                "description": "Run one conversation search through up to 8 named pipeline configurations, e.g. dense only,\nhybrid and reranked, and return each configuration's results side by side with final and raw\nscores, latency and a preview of each conversation. The first configuration is the baseline:\nevery other run reports its overlap with it, the conversations it added and dropped, and each\nresult's rank in the baseline. A configuration replaces the stage lists and reranker weights it\nsets and keeps the configured pipeline's others; an empty list drops a kind of stage. Retrieval\noverrides are accepted as on /debug/retrieval. Runs aren't logged as searches.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Compare search pipeline configurations",
+                "parameters": [
+                    {
+                        "description": "Query and configurations",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaygroundRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Runs in request order",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_PlaygroundResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or configuration",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Embedding budget spent",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/usage": {
+            "get": {
+                "description": "Get the embedding requests and tokens billed per UTC day, tenant and model, with totals over the range. Usage is flushed to the database periodically, so the last minute may be missing",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get embedding usage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day (YYYY-MM-DD); defaults to 29 days before to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day (YYYY-MM-DD); defaults to today",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only usage of this tenant",
+                        "name": "tenant",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Usage report",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_UsageReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid date",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/users": {
+            "get": {
+                "description": "List registered end users ordered by user ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List users",
+                "parameters": [
+                    {
+                        "enum": [
+                            "active",
+                            "disabled"
+                        ],
+                        "type": "string",
+                        "description": "Only users with this status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of users",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of users to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Users",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_UserListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid status",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Register an end user with a display name, retention exemption and home region. Users don't have to be\nregistered to store data: an unregistered user_id is treated as active and follows its tenant's region.\nWith data residency on, requests for a user homed in another region fail with 421 WRONG_REGION.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Register a user",
+                "parameters": [
+                    {
+                        "description": "User to register",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UserCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User registered",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "User already registered",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/users/{user_id}": {
+            "get": {
+                "description": "Get a registered end user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not registered",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete a user's conversations, messages, personal info, sessions, profile, registration and vectors. Deletion takes\ntwo calls: without confirmation_token the response lists what would be deleted and returns a token\n(202), which must be sent back before it expires to execute the deletion. A token only deletes the\nuser it was issued for and is rejected if the user's data changed in between. Both calls are\nrecorded in the job log.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete a user's data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token returned by the first call",
+                        "name": "confirmation_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User data deleted",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_UserDeletionResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Confirmation required",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_UserDeletionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid confirmation token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No data stored for user",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Scope changed since the token was issued",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Confirmation token expired",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            },
+            "patch": {
+                "description": "Change a registered user's display name, retention exemption or home region; omitted fields are\nunchanged. Changing the region doesn't move stored data, which has to be exported and imported.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Update a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UserUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User updated",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not registered",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/users/{user_id}/disable": {
+            "post": {
+                "description": "Disable an end user, registering it if needed. Saving conversations or creating personal info for a\ndisabled user fails with 403 USER_DISABLED; stored data stays searchable until it is deleted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Disable a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason for disabling",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserDisableRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/users/{user_id}/enable": {
+            "post": {
+                "description": "Lift a user's disablement so new conversations and personal info are accepted again",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Enable a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User enabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not registered",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/users/{user_id}/query-adapters": {
+            "get": {
+                "description": "List the adapters applied to a user's query embeddings, one per embedding model, without their\nvectors. Only available when QUERY_ADAPTERS is enabled.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List a user's query adapters",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Query adapters",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_QueryAdapterListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Store an adapter trained offline from the user's search feedback, replacing their adapter for the\nmodel. Each of the user's query vectors q is replaced by scale*q + bias, component by component and\nscaled back to unit length, before conversations and personal info embedded with the model are\nsearched. The bias, and the optional scale, have the dimension of the model's stored vectors after any\nprojection; the bias can't be longer than 1 and scale components must be positive. Other replicas\napply the adapter within QUERY_ADAPTER_CACHE_TTL.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Upload a user's query adapter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Model and adapter vectors",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.QueryAdapterUploadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stored adapter",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_QueryAdapterSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, unknown model or adapter not matching the model",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete a user's adapter for a model, so their queries are searched unadapted. Other replicas stop\napplying it within QUERY_ADAPTER_CACHE_TTL.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete a user's query adapter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Embedding model; defaults to the conversations collection's model",
+                        "name": "model",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deleted adapter",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_QueryAdapterDeleteResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "The user has no adapter for the model",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/users/{user_id}/reindex": {
+            "post": {
+                "description": "Delete all of a user's points from the conversation and personal info collections and re-embed\ntheir stored conversations and personal info. Use it to repair a user after a partial failure or\nan embedding model change without reindexing everything. With dry_run=true nothing is changed and\nthe response lists the vectors that would be deleted and the records that would be re-embedded.\nEvery run is recorded in the job log.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Rebuild a user's vectors",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Report what would be affected without executing",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reindex result",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_UserReindexResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/users/{user_id}/topic-map": {
+            "get": {
+                "description": "Cluster the stored vectors of a user's most recent conversations with k-means on cosine similarity\nand describe each topic by its size, time span, cohesion and the conversations closest to its center.\nWith label, the chat model names and summarizes each topic from those conversations; if it fails the\nmap is returned unlabeled. Suppressed conversations and conversations without a vector are left out.\nThe map is computed on every request; the same conversations always give the same topics.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get a user's topic map",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of topics, at most 30; picked from the number of conversations by default",
+                        "name": "clusters",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 3,
+                        "description": "Conversations listed per topic, at most 10",
+                        "name": "representatives",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Most recent conversations clustered, at most 5000",
+                        "name": "max_conversations",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Name the topics with the chat model",
+                        "name": "label",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Topic map",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_TopicMap"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameter",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No embedded conversations for the user",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/vectors/export": {
+            "post": {
+                "description": "Start a background job writing every vector of a content type's collection to the blob store for\noffline experiments: vectors.parquet (id, payload as JSON, embedding), embeddings.npy (an N x D\nfloat32 matrix for numpy.load), metadata.jsonl (the id and payload of each .npy row, in order) and\nmanifest.json. The job result lists the keys. Track progress with GET /admin/jobs/{job_id}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Export stored vectors",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "conversations",
+                        "description": "Collection to export: conversations, personal_info or documents",
+                        "name": "content_type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Job started",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_JobStartedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown content type",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A vector export or import is already running",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/admin/vectors/import": {
+            "post": {
+                "description": "Start a background job replacing the vectors of stored points with vectors read from the blob\nstore: a Parquet file with id and embedding columns, or a .npy float32 matrix whose rows are named\nby the id fields of ids_key, such as an export's metadata.jsonl. Vectors must have the\ncollection's dimension; rows whose id has no stored point are counted as missing and skipped, and\npayloads are kept. Imported vectors are served until the record is embedded again. Use dry_run to\nvalidate the file first. Track progress with GET /admin/jobs/{job_id}.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Import vectors computed offline",
+                "parameters": [
+                    {
+                        "description": "File to import",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.VectorImportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Job started",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_JobStartedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A vector export or import is already running",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/conversation/delete-by-filter": {
+            "post": {
+                "description": "Delete every conversation matching a metadata filter, optionally only one user's and only those\ncreated in a time range, together with their messages and vectors. Every set criterion must hold\nand at least one must be set. The filter is matched against the stored metadata, so conversations\nwithout a vector are deleted too. Deletion takes two calls: without confirmation_token the\nresponse lists what would be deleted and returns a token (202), which must be sent back with the\nsame body before it expires to execute the deletion. A token is rejected if the conversations\nmatched changed in between. With dry_run=true only the preview is returned. Both calls are\nrecorded in the job log.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "Delete conversations by filter",
+                "parameters": [
+                    {
+                        "description": "Conversations to delete",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ConversationBulkDeleteRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Report what would be deleted without executing",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token returned by the first call",
+                        "name": "confirmation_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Conversations deleted",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_ConversationBulkDeleteResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Confirmation required",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_ConversationBulkDeleteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, filter or confirmation token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Scope changed since the token was issued",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Confirmation token expired",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/conversation/recommend": {
+            "post": {
+                "description": "Get the user's conversations most like the liked example conversations and least like the disliked\nones, most similar first, e.g. for personalized memory review or to collect reranker training data.\nThe examples' stored vectors are compared directly, so nothing is embedded and no embedding budget\nis spent. The examples and suppressed conversations are left out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "Recommend conversations from examples",
+                "parameters": [
+                    {
+                        "description": "Example conversations",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RecommendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recommended conversations",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_RecommendResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, or an example isn't one of the user's indexed conversations",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/conversation/search": {
+            "get": {
+                "description": "Search for conversations by semantic similarity",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "Search conversations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "query",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Result limit (default: 10, max: 100)",
+                        "name": "top_k",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Drop results scoring below this value; scores are normalized when a search normalizer is configured",
+                        "name": "min_score",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Metadata filter, e.g. source = \\",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Restrict results to one user; required when user isolation is enabled",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only match conversations created within this many days; 0 searches all history (default: the tenant's SEARCH_MAX_AGE_DAYS)",
+                        "name": "max_age_days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Fetch this many times top_k candidates before filtering and reranking (1-10); needs the experiment scope",
+                        "name": "candidate_multiplier",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "HNSW search beam size (1-4096); needs the experiment scope",
+                        "name": "hnsw_ef",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Per-retriever fusion weights, e.g. vector=1,keyword=0.5; needs the experiment scope",
+                        "name": "fusion_weights",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recency reranker half-life, e.g. 72h; needs the experiment scope",
+                        "name": "recency_half_life",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Importance reranker half-life, e.g. 720h; needs the experiment scope",
+                        "name": "importance_half_life",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Qdrant replicas that must answer: majority, quorum, all or a number; fresher but slower than the configured default on clustered Qdrant",
+                        "name": "read_consistency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Search results with metadata",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_SearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Retrieval overrides without the experiment scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Embedding budget spent",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/conversation/search/feedback": {
+            "post": {
+                "description": "Report whether a result of a conversation search was relevant. The rating is credited to the fusion\nweights the search was served with, search_metadata.fusion_arm, so the tenant's searches drift towards\nthe weights whose results are rated relevant. Ratings aren't counted while the tenant is frozen to one\narm. Only available when FUSION_BANDIT_ENABLED is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "Rate a search result",
+                "parameters": [
+                    {
+                        "description": "Fusion arm and rating",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SearchFeedbackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Feedback recorded",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_SearchFeedbackResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or unknown fusion arm",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/conversation/store": {
+            "post": {
+                "description": "Save a new conversation with messages and metadata. Besides the native format, the body\ncan be an OpenAI chat-completion message array (format=openai), a LINE or KakaoTalk chat\nexport (format=line, format=kakaotalk), or a \"Speaker: text\" transcript (format=transcript).\nSaves are eventually consistent: the conversation can be read by ID at once, but its vector may\nstill be queued. With wait_for_indexing the response waits until search finds the conversation\nand reports consistency=searchable; if it isn't searchable in time the response is 503\nNOT_SEARCHABLE and the conversation stays stored, to become searchable later.\ndurability picks when the save is acknowledged: fast once the conversation is committed, with the\nvector written in the background; safe once the vector is written too; queued once the conversation\nis committed with a job that embeds it, answered with 202 and the job's ID. Only safe saves can\nwait_for_indexing.\nlane is interactive, the default, or backfill for history a chat platform replays. When the server\nshapes ingestion, a save arriving faster than embeddings are let through is queued as if durability\nwere queued and answered with 202 and shaped=true; backfill saves are queued sooner and embedded after\ninteractive ones.",
+                "consumes": [
+                    "application/json",
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "Save a conversation",
+                "parameters": [
+                    {
+                        "description": "Conversation save request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ConversationSaveRequest"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "native",
+                            "openai",
+                            "line",
+                            "kakaotalk",
+                            "transcript"
+                        ],
+                        "type": "string",
+                        "default": "native",
+                        "description": "Body format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Conversation ID for formats whose body does not carry one",
+                        "name": "conversation_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated chat export speakers stored with the assistant role",
+                        "name": "assistant_speakers",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone of chat export timestamps",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Respond only once the conversation is searchable, as with wait_for_indexing in the body",
+                        "name": "wait_for_indexing",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "fast",
+                            "safe",
+                            "queued"
+                        ],
+                        "type": "string",
+                        "description": "When the save is acknowledged, as with durability in the body",
+                        "name": "durability",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "interactive",
+                            "backfill"
+                        ],
+                        "type": "string",
+                        "description": "Ingestion lane, as with lane in the body",
+                        "name": "lane",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Conversation saved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_SaveResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Conversation stored and queued for embedding",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_SaveResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "User is disabled, or conversation_id names another user's conversation",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Session is closed",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Embedding budget spent",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Stored, but not searchable within the wait",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/conversation/{conversation_id}": {
+            "get": {
+                "description": "Get a stored conversation with its messages and status. A conversation is searchable once its\nstatus is indexed; pending means its vector write is still queued, failed that the vector couldn't\nbe written, and archived that it was taken out of search. An old ID left behind by a merge or an\nimport that remapped IDs returns the conversation that replaced it, named in the\nX-Resolved-Conversation-ID header; the archive and metadata routes follow such IDs too.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "Get a conversation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversation ID",
+                        "name": "conversation_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Conversation",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_ConversationResponse"
+                        },
+                        "headers": {
+                            "X-Resolved-Conversation-ID": {
+                                "type": "string",
+                                "description": "The conversation an old conversation_id resolved to"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified since the ETag in If-None-Match"
+                    },
+                    "404": {
+                        "description": "Conversation not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/conversation/{conversation_id}/archive": {
+            "put": {
+                "description": "Archive a conversation, removing its vector so search no longer finds it while it stays stored, or\nunarchive it with archived=false, which embeds it again. An unarchived conversation the embedding\nprovider refuses is kept with status failed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "Archive a conversation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversation ID",
+                        "name": "conversation_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Archive state",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ConversationArchiveRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Conversation status",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_ConversationStatusResponse"
+                        },
+                        "headers": {
+                            "X-Resolved-Conversation-ID": {
+                                "type": "string",
+                                "description": "The conversation an old conversation_id resolved to"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Conversation not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Embedding budget spent",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/conversation/{conversation_id}/metadata": {
+            "put": {
+                "description": "Replace a conversation's metadata, including custom fields used by search filters. Only the\nvector's payload is updated; the conversation isn't embedded again. session_id can't be changed\nand is ignored.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "Update conversation metadata",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversation ID",
+                        "name": "conversation_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New metadata",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ConversationMetadata"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Conversation with its new metadata",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_ConversationResponse"
+                        },
+                        "headers": {
+                            "X-Resolved-Conversation-ID": {
+                                "type": "string",
+                                "description": "The conversation an old conversation_id resolved to"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid metadata",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Conversation not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/conversation/{conversation_id}/pin": {
+            "put": {
+                "description": "Pin a conversation so it is always included in the user's memory context, or unpin it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "memory"
+                ],
+                "summary": "Pin a conversation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversation ID",
+                        "name": "conversation_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Pin state",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PinRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pin state updated",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_PinResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Conversation not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/conversation/{conversation_id}/related": {
+            "get": {
+                "description": "Get the same user's other conversations most similar to a conversation, most similar first, for\n\"see related memories\" navigation. The conversation's stored vector is compared directly, so nothing\nis embedded and no embedding budget is spent. Suppressed conversations are left out, and a\nconversation that isn't indexed has no related conversations. Old IDs are followed as by the get\nroute.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "Get related conversations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversation ID",
+                        "name": "conversation_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Maximum number of related conversations",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Related conversations",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_RelatedConversationsResponse"
+                        },
+                        "headers": {
+                            "X-Resolved-Conversation-ID": {
+                                "type": "string",
+                                "description": "The conversation an old conversation_id resolved to"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Conversation not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/conversation/{conversation_id}/suppression": {
+            "put": {
+                "description": "Mark a conversation as wrong, outdated or harmful so it is excluded from retrieval\nwithout being deleted, or restore it with suppressed=false",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "memory"
+                ],
+                "summary": "Suppress a conversation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Conversation ID",
+                        "name": "conversation_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Suppression state",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SuppressionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Suppression updated",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_SuppressionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Conversation not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/debug/retrieval": {
+            "get": {
+                "description": "Run a conversation search with the same parameters as /conversation/search and return every\nretrieval pipeline stage's intermediate output: the raw and transformed query, each retriever's\ncandidates, the fused set, candidates dropped by filters, normalized scores, per-reranker score\ndeltas and the final selection. Retrieval overrides are always accepted here and echoed in the trace.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Explain a conversation search",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "query",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Result limit (default: 10, max: 100)",
+                        "name": "top_k",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Drop results scoring below this value",
+                        "name": "min_score",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Metadata filter, e.g. source = \\",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Restrict results to one user; required when user isolation is enabled",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only match conversations created within this many days; 0 searches all history (default: the tenant's SEARCH_MAX_AGE_DAYS)",
+                        "name": "max_age_days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Fetch this many times top_k candidates before filtering and reranking (1-10)",
+                        "name": "candidate_multiplier",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "HNSW search beam size (1-4096)",
+                        "name": "hnsw_ef",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Per-retriever fusion weights, e.g. vector=1,keyword=0.5",
+                        "name": "fusion_weights",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recency reranker half-life, e.g. 72h",
+                        "name": "recency_half_life",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Importance reranker half-life, e.g. 720h",
+                        "name": "importance_half_life",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pipeline trace",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_RetrievalTrace"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/api/rag/health": {
+            "get": {
+                "description": "Check if the RAG server and its dependencies are healthy",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "Server is healthy",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_HealthCheckResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_HealthCheckResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/health/ready": {
+            "get": {
+                "description": "Report whether the server has finished warming up and is ready to receive traffic",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "Server is ready",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_ReadinessResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Server is still warming up",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_ReadinessResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/openapi.json": {
+            "get": {
+                "description": "Get an OpenAPI 3 description of this deployment's API for generating clients. It lists the routes\nactually registered, so endpoints disabled by configuration are left out and routes without\nannotations are still listed. Schemas come from the typed request and response models, with an\nexample for every JSON body; the error envelope, the X-Tenant-ID header and the authentication\nschemes the deployment accepts are described for every operation. No API key is needed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Get the OpenAPI document",
+                "responses": {
+                    "200": {
+                        "description": "OpenAPI 3 document",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/personal-info": {
+            "post": {
+                "description": "Save personal information provided by guardians (medical, contact, emergency, etc.)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "personal-info"
+                ],
+                "summary": "Create personal information",
+                "parameters": [
+                    {
+                        "description": "Personal info creation request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PersonalInfoCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Personal info created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_PersonalInfoResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "User is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Embedding budget spent",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/personal-info/user/{user_id}": {
+            "get": {
+                "description": "Retrieve all personal information entries for a specific user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "personal-info"
+                ],
+                "summary": "Get all personal information for a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Personal info list retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_PersonalInfoListResult"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified since the ETag in If-None-Match"
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/personal-info/{info_id}": {
+            "get": {
+                "description": "Retrieve a personal information entry by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "personal-info"
+                ],
+                "summary": "Get personal information",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Personal info ID",
+                        "name": "info_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Personal info retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_PersonalInfoResult"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified since the ETag in If-None-Match"
+                    },
+                    "404": {
+                        "description": "Personal info not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update an existing personal information entry",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "personal-info"
+                ],
+                "summary": "Update personal information",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Personal info ID",
+                        "name": "info_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the update is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only update if unmodified since this HTTP date",
+                        "name": "If-Unmodified-Since",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Personal info update request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PersonalInfoUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Personal info updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_PersonalInfoResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Personal info not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Entry modified since the given version",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Embedding budget spent",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a personal information entry by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "personal-info"
+                ],
+                "summary": "Delete personal information",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Personal info ID",
+                        "name": "info_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the delete is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only delete if unmodified since this HTTP date",
+                        "name": "If-Unmodified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Personal info deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_PersonalInfoDeleteResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Personal info not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Entry modified since the given version",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/personal-info/{info_id}/pin": {
+            "put": {
+                "description": "Pin a personal info entry so it is always included in the user's memory context, or unpin it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "memory"
+                ],
+                "summary": "Pin personal information",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Personal info ID",
+                        "name": "info_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Pin state",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PinRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pin state updated",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_PinResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Personal info not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/personal-info/{info_id}/suppression": {
+            "put": {
+                "description": "Mark a personal info entry as wrong, outdated or harmful so it is excluded from retrieval\nwithout being deleted, or restore it with suppressed=false",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "memory"
+                ],
+                "summary": "Suppress personal information",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Personal info ID",
+                        "name": "info_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Suppression state",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SuppressionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Suppression updated",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_SuppressionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Personal info not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/retrieve": {
+            "get": {
+                "description": "Get the memory context for a chat turn: the user's pinned memories (always included),\nthe user's conversations and personal info most similar to the query, and optionally the session\nrecap. With RETRIEVE_ROUTING=classifier only the collections the query is about are searched and the\nresponse reports the routing decision.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "memory"
+                ],
+                "summary": "Retrieve memory context",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Query text; similarity results are omitted when empty",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Similarity result limit (default: 5, max: 100)",
+                        "name": "top_k",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Session whose summary and recent messages are included",
+                        "name": "session_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only match conversations created within this many days; 0 searches all history (default: the tenant's SEARCH_MAX_AGE_DAYS)",
+                        "name": "max_age_days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of recent session messages to include (default: 0, max: 50)",
+                        "name": "recent_messages",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Fetch this many times top_k candidates before filtering and reranking (1-10); needs the experiment scope",
+                        "name": "candidate_multiplier",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "HNSW search beam size (1-4096); needs the experiment scope",
+                        "name": "hnsw_ef",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Per-retriever fusion weights, e.g. vector=1,keyword=0.5; needs the experiment scope",
+                        "name": "fusion_weights",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recency reranker half-life, e.g. 72h; needs the experiment scope",
+                        "name": "recency_half_life",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Importance reranker half-life, e.g. 720h; needs the experiment scope",
+                        "name": "importance_half_life",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Reduce similar conversations to their sentences most relevant to the query (default: CONTEXT_COMPRESSION)",
+                        "name": "compress",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Qdrant replicas that must answer: majority, quorum, all or a number; fresher but slower than the configured default on clustered Qdrant",
+                        "name": "read_consistency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Memory context",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_RetrieveResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Retrieval overrides without the experiment scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/sessions": {
+            "get": {
+                "description": "List sessions, optionally filtered by user and status",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "List sessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "open",
+                            "closed"
+                        ],
+                        "type": "string",
+                        "description": "Session status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default: 20, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session page",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_SessionListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create an open chat session; conversations join it by sending its ID as metadata.session_id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Create a session",
+                "parameters": [
+                    {
+                        "description": "Session creation request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SessionCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Session created",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_Session"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Session ID already exists",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/sessions/{session_id}": {
+            "get": {
+                "description": "Get a session with its conversation count and latest summary",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Get a session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_Session"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified since the ETag in If-None-Match"
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/sessions/{session_id}/close": {
+            "post": {
+                "description": "Close a session; later saves into it are rejected",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Close a session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Closed session",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_Session"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/sessions/{session_id}/context": {
+            "get": {
+                "description": "Get the session's rolling summary and its most recent messages, for callers that send a\ncompact session recap to the chat model instead of the full history",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Get session context",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of most recent messages to include (default: 0, max: 50)",
+                        "name": "recent_messages",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session context",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_SessionContextResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/sessions/{session_id}/summarize": {
+            "post": {
+                "description": "Generate a summary of the session transcript with the chat model and store it on the session",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Summarize a session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session with updated summary",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_Session"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Session has no conversations",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/sessions/{session_id}/transcript": {
+            "get": {
+                "description": "Get all conversations and messages of a session in chronological order",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Get a session transcript",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session transcript",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_SessionTranscriptResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified since the ETag in If-None-Match"
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/standing-queries": {
+            "post": {
+                "description": "Register a search that runs against every conversation the user saves from now on, such as \"tell me\nwhenever grandma mentions dizziness\". The query is embedded once; each saved conversation with a\nvector is compared with it, and matches scoring at least the threshold, on the scale of search scores,\nare posted to STANDING_QUERY_WEBHOOK_URL and sent to the user's match streams. The filter narrows\nmatches with the metadata filter syntax of searches.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "standing-queries"
+                ],
+                "summary": "Register a standing query",
+                "parameters": [
+                    {
+                        "description": "Standing query",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.StandingQueryCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Standing query registered",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_StandingQuery"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or filter",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "User has the maximum number of standing queries",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Embedding budget exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/standing-queries/{query_id}": {
+            "get": {
+                "description": "Get a standing query with how often it matched",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "standing-queries"
+                ],
+                "summary": "Get a standing query",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Standing query ID",
+                        "name": "query_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Standing query",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_StandingQuery"
+                        }
+                    },
+                    "404": {
+                        "description": "Standing query not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a standing query; saved conversations are no longer compared with it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "standing-queries"
+                ],
+                "summary": "Delete a standing query",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Standing query ID",
+                        "name": "query_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Standing query deleted",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_StandingQueryDeleteResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Standing query not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/suggest": {
+            "get": {
+                "description": "Complete a partly typed search with the queries the user searched before and found results for,\nthen with words and two-word phrases recurring in their recent conversations. Candidates starting\nwith the prefix come before those with a later word starting with it, then the most frequent. An\nempty prefix returns the user's top suggestions. A user's candidates are cached for\nSUGGEST_CACHE_TTL, so new searches and conversations appear within it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "Suggest search queries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Text typed so far",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 8,
+                        "description": "Maximum number of suggestions",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Suggestions",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_QuerySuggestResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing user ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/users/{user_id}/conversations": {
+            "get": {
+                "description": "List a user's conversations, newest first, optionally only those in one status, e.g. pending to\nfind those not yet searchable",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "List a user's conversations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "indexed",
+                            "failed",
+                            "archived"
+                        ],
+                        "type": "string",
+                        "description": "Only conversations in this status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of conversations",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of conversations to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Conversations",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_ConversationListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid status",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/users/{user_id}/pinned": {
+            "get": {
+                "description": "List the conversations and personal info entries pinned for a user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "memory"
+                ],
+                "summary": "List pinned memories",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pinned memories",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_PinnedMemories"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified since the ETag in If-None-Match"
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/users/{user_id}/profile": {
+            "get": {
+                "description": "Get a structured long-term profile (preferences, routines, health notes) synthesized from the\nuser's personal info and highest-scored conversations. Profiles are cached and refreshed periodically.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get a user profile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Regenerate the profile instead of serving the cached one",
+                        "name": "refresh",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User profile",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_UserProfile"
+                        }
+                    },
+                    "404": {
+                        "description": "No data for user",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/users/{user_id}/standing-queries": {
+            "get": {
+                "description": "List a user's standing queries, oldest first, with how often each matched",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "standing-queries"
+                ],
+                "summary": "List a user's standing queries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Standing queries",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_StandingQueryListResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/users/{user_id}/standing-queries/stream": {
+            "get": {
+                "description": "Stream the user's standing query matches as server-sent events named \"match\", each carrying a\nmodels.StandingQueryMatch as JSON, until the client disconnects. A stream only sees matches of\nconversations saved through this replica and misses those found while it is disconnected or too slow\nto keep up; use the webhook for reliable delivery.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "standing-queries"
+                ],
+                "summary": "Stream a user's standing query matches",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stream of match events",
+                        "schema": {
+                            "$ref": "#/definitions/models.StandingQueryMatch"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/users/{user_id}/stats": {
+            "get": {
+                "description": "Get a user's conversation count, last conversation time and the embedding tokens spent on their\nconversations. The stats are kept up to date as conversations are saved and deleted, so reading\nthem doesn't count the user's conversations. The last conversation time is kept when\nconversations are deleted; a user without conversations has zero stats.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "Get a user's conversation stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User stats",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_UserStats"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/users/{user_id}/suppressed": {
+            "get": {
+                "description": "List the conversations and personal info entries suppressed for a user, with reasons",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "memory"
+                ],
+                "summary": "List suppressed memories",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Suppressed memories",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_SuppressedMemories"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rag/users/{user_id}/timeline": {
+            "get": {
+                "description": "Get a user's conversations grouped by day, newest day first, with each day's conversation count and\na short summary of its newest conversations, for history views. Days without conversations are\nskipped. Pass next_before as before to get the next, older page; it is left out on the last page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "Get a user's conversation timeline",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only days before this date, YYYY-MM-DD; the latest day by default",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 7,
+                        "description": "Maximum number of days",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Maximum number of conversations listed per day",
+                        "name": "per_day",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA time zone days start in",
+                        "name": "time_zone",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Timeline",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_TimelineResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid date or time zone",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "health.CheckResult": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                }
+            }
+        },
+        "health.DependencyHistory": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/health.CheckResult"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "stability": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "success_ratio": {
+                    "type": "number"
+                },
+                "transitions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/health.Transition"
+                    }
+                }
+            }
+        },
+        "health.Transition": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "leading characters of the secret, to recognize a key",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "rotated_to": {
+                    "description": "RotatedTo is the ID of the key that replaced this one",
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.APIKeyCreateRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.APIKeyListResponse": {
+            "type": "object",
+            "properties": {
+                "api_keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.APIKey"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.APIKeyRotateRequest": {
+            "type": "object",
+            "properties": {
+                "grace_period_seconds": {
+                    "description": "GracePeriodSeconds keeps the old key valid for a while so clients can switch over",
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "models.APIKeySecret": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/models.APIKey"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.APIResponse-models_APIKey": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.APIKey"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_APIKeyListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.APIKeyListResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_APIKeySecret": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.APIKeySecret"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_AuditVerification": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.AuditVerification"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_CacheFlushResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.CacheFlushResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_CacheStatsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.CacheStatsResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_CollectionListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.CollectionListResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_ConversationAlias": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.ConversationAlias"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_ConversationBulkDeleteResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.ConversationBulkDeleteResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_ConversationListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.ConversationListResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_ConversationResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.ConversationResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_ConversationStatusResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.ConversationStatusResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_DataKey": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DataKey"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_DataKeyListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DataKeyListResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_DataKeyRewrapResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DataKeyRewrapResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_DeadLetter": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DeadLetter"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_DeadLetterDiscardResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DeadLetterDiscardResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_DeadLetterListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DeadLetterListResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_DeadLetterRequeueResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DeadLetterRequeueResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_DeletionCertificate": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DeletionCertificate"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_DeletionCertificateKey": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DeletionCertificateKey"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_DeletionCertificateListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DeletionCertificateListResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_DoctorReport": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DoctorReport"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_EmbeddingInspection": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.EmbeddingInspection"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_EmbeddingProjectionListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.EmbeddingProjectionListResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_FeatureFlagListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.FeatureFlagListResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_FusionBanditStatus": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.FusionBanditStatus"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_HealthCheckResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.HealthCheckResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_HealthHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.HealthHistoryResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_IndexHealthResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.IndexHealthResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_Insights": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.Insights"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_Job": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.Job"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_JobListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.JobListResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_JobStartedResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.JobStartedResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_MaintenanceStatus": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.MaintenanceStatus"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_MigrationStatus": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.MigrationStatus"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_PersonalInfoDeleteResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.PersonalInfoDeleteResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_PersonalInfoListResult": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.PersonalInfoListResult"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_PersonalInfoResult": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.PersonalInfoResult"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_PinResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.PinResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_PinnedMemories": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.PinnedMemories"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_PlaygroundResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.PlaygroundResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_QueryAdapterDeleteResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.QueryAdapterDeleteResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_QueryAdapterListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.QueryAdapterListResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_QueryAdapterSummary": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.QueryAdapterSummary"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_QuerySuggestResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.QuerySuggestResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_ReadinessResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.ReadinessResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_RecommendResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.RecommendResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_RelatedConversationsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.RelatedConversationsResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_RetentionRunResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.RetentionRunResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_RetrievalTrace": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.RetrievalTrace"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_RetrieveResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.RetrieveResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_SaveResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.SaveResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_ScheduledTaskStatus": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.ScheduledTaskStatus"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_ScheduledTasksResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.ScheduledTasksResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_SearchFeedbackResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.SearchFeedbackResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_SearchResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.SearchResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_Session": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.Session"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_SessionContextResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.SessionContextResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_SessionListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.SessionListResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_SessionTranscriptResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.SessionTranscriptResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_StandingQuery": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.StandingQuery"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_StandingQueryDeleteResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.StandingQueryDeleteResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_StandingQueryListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.StandingQueryListResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_SuppressedMemories": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.SuppressedMemories"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_SuppressionResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.SuppressionResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_TimelineResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.TimelineResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_TopicMap": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.TopicMap"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_UnembeddedListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.UnembeddedListResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_UsageReport": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.UsageReport"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_User": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.User"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_UserDeletionResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.UserDeletionResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_UserListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.UserListResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_UserProfile": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.UserProfile"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_UserReindexResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.UserReindexResponse"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.APIResponse-models_UserStats": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.UserStats"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.AnalyticsExportRequest": {
+            "type": "object",
+            "required": [
+                "date"
+            ],
+            "properties": {
+                "date": {
+                    "description": "Date is the UTC day to export as YYYY-MM-DD",
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditVerification": {
+            "type": "object",
+            "properties": {
+                "failed_line": {
+                    "description": "The first record that breaks the chain, when Valid is false",
+                    "type": "integer"
+                },
+                "failed_sequence": {
+                    "type": "integer"
+                },
+                "failure_reason": {
+                    "type": "string"
+                },
+                "head_hash": {
+                    "type": "string"
+                },
+                "head_sequence": {
+                    "description": "HeadSequence and HeadHash are the head stored with the log, which the chain must end at so\nrecords removed from the end are detected. Compare them with values logged or noted earlier\nto detect the log and its head being replaced together",
+                    "type": "integer"
+                },
+                "last_hash": {
+                    "description": "LastHash is the hash of the last verified record",
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "records": {
+                    "description": "Records is the number of chained records verified; Unchained counts records written before\nchaining was introduced, which precede the chain and can't be verified",
+                    "type": "integer"
+                },
+                "unchained": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.CacheFlushResponse": {
+            "type": "object",
+            "properties": {
+                "flushed": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CacheStats": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer"
+                },
+                "entries": {
+                    "type": "integer"
+                },
+                "evictions": {
+                    "description": "Evictions counts entries dropped to make room or because they had expired;\nExpiredEvictions counts the latter",
+                    "type": "integer"
+                },
+                "expired_evictions": {
+                    "type": "integer"
+                },
+                "flushed_at": {
+                    "type": "string"
+                },
+                "flushes": {
+                    "description": "Flushes counts the times the cache was emptied on request, last at FlushedAt",
+                    "type": "integer"
+                },
+                "hit_ratio": {
+                    "description": "HitRatio is hits over lookups; 0 before the first lookup",
+                    "type": "number"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "ttl_seconds": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CacheStatsResponse": {
+            "type": "object",
+            "properties": {
+                "caches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CacheStats"
+                    }
+                }
+            }
+        },
+        "models.ClusterSettings": {
+            "type": "object",
+            "properties": {
+                "replication_factor": {
+                    "type": "integer"
+                },
+                "shard_number": {
+                    "type": "integer"
+                },
+                "write_consistency_factor": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CollectionIndexHealth": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "indexed_percent": {
+                    "type": "number"
+                },
+                "indexed_vectors_count": {
+                    "type": "integer"
+                },
+                "memory_estimate_bytes": {
+                    "description": "MemoryEstimateBytes approximates the RAM held by the vectors and HNSW graph",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "on_disk": {
+                    "type": "boolean"
+                },
+                "optimizer_status": {
+                    "type": "string"
+                },
+                "points_count": {
+                    "type": "integer"
+                },
+                "segments_count": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CollectionListResponse": {
+            "type": "object",
+            "properties": {
+                "collections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CollectionStatusResponse"
+                    }
+                }
+            }
+        },
+        "models.CollectionStatusResponse": {
+            "type": "object",
+            "properties": {
+                "configured": {
+                    "$ref": "#/definitions/models.ClusterSettings"
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "datatype": {
+                    "type": "string"
+                },
+                "dimension": {
+                    "type": "integer"
+                },
+                "distance": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "live": {
+                    "$ref": "#/definitions/models.LiveCollectionStatus"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Confirmation": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ConnectionsInfo": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "integer"
+                },
+                "idle": {
+                    "type": "integer"
+                },
+                "max": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ContextCompression": {
+            "type": "object",
+            "properties": {
+                "sentences_kept": {
+                    "type": "integer"
+                },
+                "sentences_total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ConversationAlias": {
+            "type": "object",
+            "properties": {
+                "alias_id": {
+                    "type": "string"
+                },
+                "conversation_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ConversationAliasRequest": {
+            "type": "object",
+            "required": [
+                "conversation_id",
+                "reason"
+            ],
+            "properties": {
+                "conversation_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "merge or import_remap",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ConversationArchiveRequest": {
+            "type": "object",
+            "required": [
+                "archived"
+            ],
+            "properties": {
+                "archived": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.ConversationBulkDeleteRequest": {
+            "type": "object",
+            "properties": {
+                "created_after": {
+                    "description": "Only conversations created at or after this time",
+                    "type": "string"
+                },
+                "created_before": {
+                    "description": "Only conversations created before this time",
+                    "type": "string"
+                },
+                "filter": {
+                    "description": "Metadata filter, as in searches",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "Only this user's conversations; every user's when empty",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ConversationBulkDeleteResponse": {
+            "type": "object",
+            "properties": {
+                "certificate_id": {
+                    "description": "CertificateID identifies the deletion certificate of an executed deletion that deleted anything",
+                    "type": "string"
+                },
+                "confirmation": {
+                    "description": "Confirmation is set when the deletion was requested without a confirmation token; nothing was deleted",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Confirmation"
+                        }
+                    ]
+                },
+                "conversation_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "conversations": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "job_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ConversationListResponse": {
+            "type": "object",
+            "properties": {
+                "conversations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConversationResponse"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ConversationMetadata": {
+            "type": "object",
+            "properties": {
+                "conversation_score": {
+                    "type": "integer"
+                },
+                "custom": {
+                    "description": "Custom holds client-defined fields: strings, numbers, booleans, or lists of strings",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "field_types": {
+                    "description": "FieldTypes optionally declares custom field types: string, integer, number,\nboolean, datetime (RFC 3339), or string_list",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ConversationResponse": {
+            "type": "object",
+            "properties": {
+                "answer": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "importance": {
+                    "type": "number"
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Message"
+                    }
+                },
+                "metadata": {
+                    "type": "string"
+                },
+                "pinned": {
+                    "type": "boolean"
+                },
+                "question": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "suppression": {
+                    "$ref": "#/definitions/models.Suppression"
+                },
+                "unembedded": {
+                    "$ref": "#/definitions/models.Unembedded"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ConversationSaveRequest": {
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "type": "string"
+                },
+                "durability": {
+                    "description": "Durability is when the save is acknowledged: fast, safe or queued; empty uses the\nserver's default",
+                    "type": "string"
+                },
+                "lane": {
+                    "description": "Lane is the ingestion lane of the save: interactive, the default, or backfill for replayed\nor imported history. When the ingestion shaper is on, backfill saves yield to interactive ones",
+                    "type": "string"
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Message"
+                    }
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.ConversationMetadata"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "wait_for_indexing": {
+                    "description": "WaitForIndexing holds the response until the conversation's vector is searchable",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.ConversationSearchResult": {
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "type": "string"
+                },
+                "conversation_score": {
+                    "type": "integer"
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Message"
+                    }
+                },
+                "score": {
+                    "type": "number"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ConversationStatusResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DataKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "master_key_id": {
+                    "type": "string"
+                },
+                "tenant": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DataKeyListResponse": {
+            "type": "object",
+            "properties": {
+                "current_master_key_id": {
+                    "type": "string"
+                },
+                "data_keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DataKey"
+                    }
+                }
+            }
+        },
+        "models.DataKeyRewrapResponse": {
+            "type": "object",
+            "properties": {
+                "master_key_id": {
+                    "type": "string"
+                },
+                "rewrapped": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DataKeyRotateRequest": {
+            "type": "object",
+            "required": [
+                "tenant"
+            ],
+            "properties": {
+                "tenant": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "models.DeadLetter": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "failed_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                }
+            }
+        },
+        "models.DeadLetterDiscardResponse": {
+            "type": "object",
+            "properties": {
+                "discarded_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DeadLetterListResponse": {
+            "type": "object",
+            "properties": {
+                "dead_letters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DeadLetter"
+                    }
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DeadLetterRequeueResponse": {
+            "type": "object",
+            "properties": {
+                "requeued_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DeletionCertificate": {
+            "type": "object",
+            "properties": {
+                "conversation_ids": {
+                    "description": "Removed conversations, when listed by the purge",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "job_id": {
+                    "type": "string"
+                },
+                "key_id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "Job kind of the purge: retention, user_delete or conversation_bulk_delete",
+                    "type": "string"
+                },
+                "policy": {
+                    "description": "Policy describes the rule or request the records were removed under",
+                    "type": "string"
+                },
+                "removed": {
+                    "$ref": "#/definitions/models.UserDataCounts"
+                },
+                "signature": {
+                    "type": "string"
+                },
+                "target": {
+                    "description": "The user whose data was removed, if the purge was limited to one",
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeletionCertificateKey": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string"
+                },
+                "key_id": {
+                    "type": "string"
+                },
+                "public_key": {
+                    "description": "Base64 of the raw 32-byte Ed25519 public key",
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeletionCertificateListResponse": {
+            "type": "object",
+            "properties": {
+                "certificates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DeletionCertificate"
+                    }
+                }
+            }
+        },
+        "models.DependenciesStatus": {
+            "type": "object",
+            "properties": {
+                "openai": {
+                    "$ref": "#/definitions/models.OpenAIStatus"
+                },
+                "postgresql": {
+                    "$ref": "#/definitions/models.PostgreSQLStatus"
+                },
+                "qdrant": {
+                    "$ref": "#/definitions/models.QdrantStatus"
+                }
+            }
+        },
+        "models.DoctorCheck": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DoctorReport": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DoctorCheck"
+                    }
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "passed": {
+                    "description": "Passed is set unless a check failed",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.EmbeddingInspectRequest": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "description": "Collection to search; defaults to conversations",
+                    "type": "string"
+                },
+                "input_type": {
+                    "description": "Embed as a search query (default) or a stored document",
+                    "type": "string"
+                },
+                "limit": {
+                    "description": "Number of neighbors (default: 10, max: 100)",
+                    "type": "integer"
+                },
+                "omit_embedding": {
+                    "type": "boolean"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "Restricts neighbors to one user; required when user isolation is enabled",
+                    "type": "string"
+                }
+            }
+        },
+        "models.EmbeddingInspection": {
+            "type": "object",
+            "properties": {
+                "collection": {
+                    "type": "string"
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "dimension": {
+                    "type": "integer"
+                },
+                "distance": {
+                    "type": "string"
+                },
+                "embedding": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "model": {
+                    "type": "string"
+                },
+                "neighbors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.NearestPoint"
+                    }
+                },
+                "norm": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.EmbeddingProjection": {
+            "type": "object",
+            "properties": {
+                "explained_variance": {
+                    "type": "number"
+                },
+                "input_dimension": {
+                    "type": "integer"
+                },
+                "loaded_at": {
+                    "type": "string"
+                },
+                "method": {
+                    "description": "Method is truncate (keep the leading components) or pca (a trained matrix)",
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "output_dimension": {
+                    "type": "integer"
+                },
+                "path": {
+                    "description": "The rest describe the PCA matrix",
+                    "type": "string"
+                },
+                "samples": {
+                    "type": "integer"
+                },
+                "trained_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.EmbeddingProjectionListResponse": {
+            "type": "object",
+            "properties": {
+                "projections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.EmbeddingProjection"
+                    }
+                }
+            }
+        },
+        "models.EmbeddingProjectionTrainRequest": {
+            "type": "object",
+            "required": [
+                "dimension"
+            ],
+            "properties": {
+                "dimension": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "model": {
+                    "description": "Model defaults to the conversations collection's model",
+                    "type": "string"
+                },
+                "samples": {
+                    "description": "Samples is how many stored conversations are embedded to train on; defaults to 5000",
+                    "type": "integer",
+                    "maximum": 50000,
+                    "minimum": 1
+                }
+            }
+        },
+        "models.EmbeddingUsage": {
+            "type": "object",
+            "properties": {
+                "prompt_tokens": {
+                    "type": "integer"
+                },
+                "total_tokens": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ErrorInfo": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "details": {},
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/models.ErrorInfo"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.Metadata"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.FeatureFlagListResponse": {
+            "type": "object",
+            "properties": {
+                "flags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FeatureFlagResponse"
+                    }
+                },
+                "loaded_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FeatureFlagResponse": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "tenants": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "boolean"
+                    }
+                }
+            }
+        },
+        "models.FilteredCandidate": {
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "type": "string"
+                },
+                "filter": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.FusionBanditArmStatus": {
+            "type": "object",
+            "properties": {
+                "baseline": {
+                    "type": "boolean"
+                },
+                "demoted": {
+                    "description": "Demoted is set once the arm has proven worse than the baseline; it is no longer chosen",
+                    "type": "boolean"
+                },
+                "fusion_weights": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "recency_weight": {
+                    "type": "number"
+                },
+                "relevance_rate": {
+                    "description": "RelevanceRate is rewards over trials, 0 before any feedback",
+                    "type": "number"
+                },
+                "rewards": {
+                    "type": "integer"
+                },
+                "trials": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.FusionBanditFreezeRequest": {
+            "type": "object",
+            "properties": {
+                "arm": {
+                    "description": "Arm defaults to the tenant's best arm",
+                    "type": "string"
+                }
+            }
+        },
+        "models.FusionBanditStatus": {
+            "type": "object",
+            "properties": {
+                "arms": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FusionBanditArmStatus"
+                    }
+                },
+                "baseline_share": {
+                    "description": "BaselineShare, MinTrials and MaxRegression are the guardrails the bandit runs within",
+                    "type": "number"
+                },
+                "best_arm": {
+                    "description": "BestArm is the arm with the best relevance estimate among those not demoted",
+                    "type": "string"
+                },
+                "frozen_arm": {
+                    "description": "FrozenArm serves every search of the tenant while set",
+                    "type": "string"
+                },
+                "frozen_at": {
+                    "type": "string"
+                },
+                "max_regression": {
+                    "type": "number"
+                },
+                "min_trials": {
+                    "type": "integer"
+                },
+                "tenant": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.HealthCheckResponse": {
+            "type": "object",
+            "properties": {
+                "dependencies": {
+                    "$ref": "#/definitions/models.DependenciesStatus"
+                },
+                "leadership": {
+                    "$ref": "#/definitions/models.LeadershipStatus"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.HealthHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "dependencies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/health.DependencyHistory"
+                    }
+                }
+            }
+        },
+        "models.IndexHealthResponse": {
+            "type": "object",
+            "properties": {
+                "collections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CollectionIndexHealth"
+                    }
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "tables": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TableStats"
+                    }
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.IndexStats": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scans": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.InsightGroup": {
+            "type": "object",
+            "properties": {
+                "conversations": {
+                    "type": "integer"
+                },
+                "id": {
+                    "description": "ID numbers topics, largest first; categories have none",
+                    "type": "integer"
+                },
+                "label": {
+                    "description": "Label names a category, or a topic once labeled; Summary describes a labeled topic",
+                    "type": "string"
+                },
+                "share": {
+                    "description": "of the sampled conversations",
+                    "type": "number"
+                },
+                "summary": {
+                    "type": "string"
+                },
+                "users": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Insights": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.InsightGroup"
+                    }
+                },
+                "conversations": {
+                    "description": "Conversations and Users count the sample; each user contributes a bounded number of\nconversations, so a few heavy users don't dominate it",
+                    "type": "integer"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "k_anonymity": {
+                    "type": "integer"
+                },
+                "labeled": {
+                    "type": "boolean"
+                },
+                "since": {
+                    "type": "string"
+                },
+                "suppressed_categories": {
+                    "type": "integer"
+                },
+                "suppressed_topics": {
+                    "type": "integer"
+                },
+                "topics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.InsightGroup"
+                    }
+                },
+                "truncated": {
+                    "type": "boolean"
+                },
+                "users": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Job": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "result": {
+                    "type": "object"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "target": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.JobListResponse": {
+            "type": "object",
+            "properties": {
+                "jobs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Job"
+                    }
+                }
+            }
+        },
+        "models.JobStartedResponse": {
+            "type": "object",
+            "properties": {
+                "job_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LatencyBreakdown": {
+            "type": "object",
+            "properties": {
+                "db_fetch_ms": {
+                    "type": "integer"
+                },
+                "embed_ms": {
+                    "type": "integer"
+                },
+                "rerank_ms": {
+                    "type": "integer"
+                },
+                "vector_search_ms": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.LeadershipStatus": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "instance_id": {
+                    "type": "string"
+                },
+                "leader": {
+                    "type": "boolean"
+                },
+                "leader_since": {
+                    "type": "string"
+                },
+                "lock": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LegacyBackfillRequest": {
+            "type": "object",
+            "properties": {
+                "default_user_id": {
+                    "description": "DefaultUserID is given to conversations whose user is named neither in their metadata nor by\ntheir session; without it they keep an empty user ID",
+                    "type": "string"
+                },
+                "dry_run": {
+                    "description": "DryRun reports how conversations would be split and attributed without changing them",
+                    "type": "boolean"
+                },
+                "resume_job_id": {
+                    "description": "ResumeJobID continues an earlier backfill job that failed or was interrupted from the last\nconversation it finished, instead of starting over",
+                    "type": "string"
+                }
+            }
+        },
+        "models.LiveCollectionStatus": {
+            "type": "object",
+            "properties": {
+                "cluster": {
+                    "$ref": "#/definitions/models.ClusterSettings"
+                },
+                "datatype": {
+                    "type": "string"
+                },
+                "points_count": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MaintenanceStatus": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "since": {
+                    "type": "string"
+                },
+                "standby": {
+                    "description": "a standby stays read-only; writes go to the primary",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.MaintenanceUpdateRequest": {
+            "type": "object",
+            "required": [
+                "enabled"
+            ],
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Message": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "description": "Human-readable speaker name",
+                    "type": "string"
+                },
+                "message_id": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "\"user\", \"assistant\", \"system\", or \"tool\"",
+                    "type": "string"
+                },
+                "speaker": {
+                    "description": "Stable speaker identity, e.g. a user or agent ID",
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Metadata": {
+            "type": "object",
+            "properties": {
+                "conversation_score": {
+                    "type": "integer"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MigrationRisk": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "rewrite": {
+                    "description": "Rewrite is the lock-friendly statement run instead; risks with a rewrite don't block",
+                    "type": "string"
+                },
+                "rows": {
+                    "description": "Estimated from planner statistics",
+                    "type": "integer"
+                },
+                "statement": {
+                    "type": "string"
+                },
+                "table": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MigrationStatus": {
+            "type": "object",
+            "properties": {
+                "backend": {
+                    "description": "Backend is the relational backend, as MEMORY_STORE_BACKEND names it",
+                    "type": "string"
+                },
+                "blocking": {
+                    "description": "Blocking counts the risks without a lock-friendly rewrite, which the block guard refuses",
+                    "type": "integer"
+                },
+                "guard": {
+                    "description": "Guard is the migration guard mode the server runs migrations under",
+                    "type": "string"
+                },
+                "pending": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "risks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MigrationRisk"
+                    }
+                },
+                "schema_version": {
+                    "description": "SchemaVersion is the version of the Postgres layout; SQLite and MySQL have none",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.NearestPoint": {
+            "type": "object",
+            "properties": {
+                "distance": {
+                    "type": "number"
+                },
+                "id": {},
+                "payload": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.OpenAIStatus": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "last_check": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PersonalInfoCreateRequest": {
+            "type": "object",
+            "required": [
+                "category",
+                "content",
+                "importance",
+                "user_id"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "importance": {
+                    "type": "string",
+                    "enum": [
+                        "high",
+                        "medium",
+                        "low"
+                    ]
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PersonalInfoDeleteResponse": {
+            "type": "object",
+            "properties": {
+                "deleted_info_id": {
+                    "type": "string"
+                },
+                "processing_time_ms": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PersonalInfoListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PersonalInfoResponse"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PersonalInfoListResult": {
+            "type": "object",
+            "properties": {
+                "personal_info_list": {
+                    "$ref": "#/definitions/models.PersonalInfoListResponse"
+                },
+                "processing_time_ms": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PersonalInfoReindexRequest": {
+            "type": "object",
+            "properties": {
+                "resume_job_id": {
+                    "description": "ResumeJobID continues an earlier reindex job that failed or was interrupted from the last\nentry it finished, instead of starting over",
+                    "type": "string"
+                }
+            }
+        },
+        "models.PersonalInfoResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "importance": {
+                    "type": "string"
+                },
+                "pinned": {
+                    "type": "boolean"
+                },
+                "suppression": {
+                    "$ref": "#/definitions/models.Suppression"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PersonalInfoResult": {
+            "type": "object",
+            "properties": {
+                "personal_info": {
+                    "$ref": "#/definitions/models.PersonalInfoResponse"
+                },
+                "processing_time_ms": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PersonalInfoSearchResult": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "importance": {
+                    "type": "string"
+                },
+                "pinned": {
+                    "type": "boolean"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "suppression": {
+                    "$ref": "#/definitions/models.Suppression"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PersonalInfoUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "importance": {
+                    "type": "string",
+                    "enum": [
+                        "high",
+                        "medium",
+                        "low"
+                    ]
+                }
+            }
+        },
+        "models.PinRequest": {
+            "type": "object",
+            "required": [
+                "pinned"
+            ],
+            "properties": {
+                "pinned": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.PinResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "pinned": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "description": "\"conversation\" or \"personal_info\"",
+                    "type": "string"
+                }
+            }
+        },
+        "models.PinnedMemories": {
+            "type": "object",
+            "properties": {
+                "conversations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConversationResponse"
+                    }
+                },
+                "personal_info": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PersonalInfoResponse"
+                    }
+                }
+            }
+        },
+        "models.PlaygroundConfiguration": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "candidate_multiplier": {
+                    "description": "Retrieval overrides, as the search endpoint's parameters of the same names",
+                    "type": "number"
+                },
+                "filters": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "fuser": {
+                    "type": "string"
+                },
+                "fusion_weights": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "hnsw_ef": {
+                    "type": "integer"
+                },
+                "importance_half_life": {
+                    "type": "string"
+                },
+                "importance_weight": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "normalizer": {
+                    "type": "string"
+                },
+                "recency_half_life": {
+                    "type": "string"
+                },
+                "recency_weight": {
+                    "description": "Reranker blend weights replace the configured ones when set",
+                    "type": "number"
+                },
+                "rerankers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "retrievers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "transformers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.PlaygroundRequest": {
+            "type": "object",
+            "required": [
+                "configurations",
+                "query"
+            ],
+            "properties": {
+                "configurations": {
+                    "description": "Configurations are run in order; the first is the baseline the others are compared with",
+                    "type": "array",
+                    "maxItems": 8,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/models.PlaygroundConfiguration"
+                    }
+                },
+                "filter": {
+                    "description": "Filter is a metadata filter, e.g. source = \"slack\" AND priority \u003e= 3",
+                    "type": "string"
+                },
+                "min_score": {
+                    "type": "number"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "top_k": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PlaygroundResponse": {
+            "type": "object",
+            "properties": {
+                "query": {
+                    "type": "string"
+                },
+                "runs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PlaygroundRun"
+                    }
+                }
+            }
+        },
+        "models.PlaygroundResult": {
+            "type": "object",
+            "properties": {
+                "baseline_rank": {
+                    "description": "BaselineRank is the conversation's rank in the baseline run, 0 when the baseline missed it",
+                    "type": "integer"
+                },
+                "conversation_id": {
+                    "type": "string"
+                },
+                "preview": {
+                    "description": "Preview is the start of the conversation's question",
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "raw_score": {
+                    "description": "RawScore is the fused score before normalization and reranking",
+                    "type": "number"
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.PlaygroundRun": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "dropped": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "error": {
+                    "description": "Error is set instead of results when the configuration failed",
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "overlap": {
+                    "description": "Overlap is the share of the baseline's results this run also returned; Added and Dropped\nlist the conversations only this run or only the baseline returned",
+                    "type": "number"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PlaygroundResult"
+                    }
+                },
+                "stages": {
+                    "description": "Stages lists the pipeline stages the configuration resolved to",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PlaygroundStages"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.PlaygroundStages": {
+            "type": "object",
+            "properties": {
+                "filters": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "fuser": {
+                    "type": "string"
+                },
+                "normalizer": {
+                    "type": "string"
+                },
+                "rerankers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "retrievers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "transformers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.PostgreSQLStatus": {
+            "type": "object",
+            "properties": {
+                "connections": {
+                    "$ref": "#/definitions/models.ConnectionsInfo"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "response_time_ms": {
+                    "type": "integer"
+                },
+                "stability": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ProfileSources": {
+            "type": "object",
+            "properties": {
+                "conversation_count": {
+                    "type": "integer"
+                },
+                "personal_info_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.QdrantStatus": {
+            "type": "object",
+            "properties": {
+                "collections": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "last_success": {
+                    "type": "string"
+                },
+                "response_time_ms": {
+                    "type": "integer"
+                },
+                "stability": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total_vectors": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.QueryAdapterDeleteResponse": {
+            "type": "object",
+            "properties": {
+                "model": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.QueryAdapterListResponse": {
+            "type": "object",
+            "properties": {
+                "adapters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.QueryAdapterSummary"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.QueryAdapterSummary": {
+            "type": "object",
+            "properties": {
+                "bias_norm": {
+                    "description": "BiasNorm is the length of the bias, against unit-length query vectors",
+                    "type": "number"
+                },
+                "dimension": {
+                    "type": "integer"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "samples": {
+                    "type": "integer"
+                },
+                "scaled": {
+                    "type": "boolean"
+                },
+                "trained_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.QueryAdapterUploadRequest": {
+            "type": "object",
+            "required": [
+                "bias"
+            ],
+            "properties": {
+                "bias": {
+                    "description": "Bias and Scale have the dimension of the model's stored vectors, after any projection",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "model": {
+                    "description": "Model defaults to the conversations collection's model",
+                    "type": "string"
+                },
+                "samples": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "scale": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "trained_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.QuerySuggestResponse": {
+            "type": "object",
+            "properties": {
+                "prefix": {
+                    "type": "string"
+                },
+                "suggestions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.QuerySuggestion"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.QuerySuggestion": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Count is how often the query was searched, or how many conversations mention the topic",
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.QueryTransformTrace": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ReadinessResponse": {
+            "type": "object",
+            "properties": {
+                "ready": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RecommendRequest": {
+            "type": "object",
+            "required": [
+                "liked",
+                "user_id"
+            ],
+            "properties": {
+                "disliked": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "liked": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "limit": {
+                    "description": "Limit defaults to 10",
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 1
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RecommendResponse": {
+            "type": "object",
+            "properties": {
+                "recommendations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConversationSearchResult"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ReindexCounts": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "failed_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "indexed": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "text_bytes": {
+                    "type": "integer"
+                },
+                "vectors_deleted": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RelatedConversationsResponse": {
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "type": "string"
+                },
+                "related": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConversationSearchResult"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RerankTrace": {
+            "type": "object",
+            "properties": {
+                "deltas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ScoreDelta"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RetentionRunResponse": {
+            "type": "object",
+            "properties": {
+                "certificate_id": {
+                    "description": "CertificateID identifies the deletion certificate of an executed run that deleted anything",
+                    "type": "string"
+                },
+                "confirmation": {
+                    "description": "Confirmation is set when a run was requested without a confirmation token; nothing was deleted",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Confirmation"
+                        }
+                    ]
+                },
+                "conversation_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "conversations": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "job_id": {
+                    "type": "string"
+                },
+                "text_bytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RetrievalTrace": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "fetch_rounds": {
+                    "description": "FetchRounds is how many times candidates were fetched; searches whose filters left fewer\nresults than the limit fetch again with more candidates, and only the last round is traced",
+                    "type": "integer"
+                },
+                "filtered": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FilteredCandidate"
+                    }
+                },
+                "final": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TracedCandidate"
+                    }
+                },
+                "fused": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TracedCandidate"
+                    }
+                },
+                "fuser": {
+                    "type": "string"
+                },
+                "min_score": {
+                    "type": "number"
+                },
+                "missing": {
+                    "description": "Candidates whose conversation no longer exists",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "normalized": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TracedCandidate"
+                    }
+                },
+                "normalizer": {
+                    "type": "string"
+                },
+                "overrides": {
+                    "description": "Overrides lists the retrieval overrides the search ran with, as given",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "raw_query": {
+                    "type": "string"
+                },
+                "rerankers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RerankTrace"
+                    }
+                },
+                "retrievers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RetrieverTrace"
+                    }
+                },
+                "skipped": {
+                    "description": "Skipped lists the optional stages skipped to meet the caller's deadline, as kind:name",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "transformers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.QueryTransformTrace"
+                    }
+                }
+            }
+        },
+        "models.RetrieveResponse": {
+            "type": "object",
+            "properties": {
+                "compression": {
+                    "description": "Compression reports how many sentences of the similar conversations were kept, when they\nwere compressed",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ContextCompression"
+                        }
+                    ]
+                },
+                "personal_info": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PersonalInfoSearchResult"
+                    }
+                },
+                "pinned": {
+                    "$ref": "#/definitions/models.PinnedMemories"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConversationSearchResult"
+                    }
+                },
+                "routing": {
+                    "description": "Routing reports how the query classifier weighed the collections, when routing is enabled",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RetrieveRouting"
+                        }
+                    ]
+                },
+                "session": {
+                    "$ref": "#/definitions/models.SessionContextResponse"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RetrieveRouting": {
+            "type": "object",
+            "properties": {
+                "searched": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "weights": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                }
+            }
+        },
+        "models.RetrieverTrace": {
+            "type": "object",
+            "properties": {
+                "candidates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TracedCandidate"
+                    }
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SaveResponse": {
+            "type": "object",
+            "properties": {
+                "consistency": {
+                    "description": "Consistency is searchable when the save waited for the vector to be searchable and eventual\notherwise: the conversation is readable by ID at once but may take a moment to show up in\nsearch",
+                    "type": "string"
+                },
+                "conversation_id": {
+                    "type": "string"
+                },
+                "durability": {
+                    "description": "Durability is the durability level the save was acknowledged at",
+                    "type": "string"
+                },
+                "job_id": {
+                    "description": "JobID is the work queue item that embeds and indexes a queued save",
+                    "type": "integer"
+                },
+                "messages_skipped": {
+                    "type": "integer"
+                },
+                "messages_stored": {
+                    "type": "integer"
+                },
+                "processing_time_ms": {
+                    "type": "integer"
+                },
+                "shaped": {
+                    "description": "Shaped is set when the save was queued because embeddings were arriving faster than the\ningestion shaper lets through",
+                    "type": "boolean"
+                },
+                "status": {
+                    "description": "Status is indexed once the conversation is searchable and pending while its vector write\nis queued",
+                    "type": "string"
+                },
+                "stored_at": {
+                    "type": "string"
+                },
+                "unembedded": {
+                    "description": "Unembedded is set when the embedding provider refused the text; the conversation is stored\nbut not searchable until an admin retries it",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Unembedded"
+                        }
+                    ]
+                },
+                "usage": {
+                    "description": "Usage is the tokens billed for embedding the conversation; absent when no embedding call was made",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.EmbeddingUsage"
+                        }
+                    ]
+                },
+                "vectors_created": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ScheduledTaskStatus": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "failures": {
+                    "type": "integer"
+                },
+                "jitter_ms": {
+                    "type": "integer"
+                },
+                "last_duration_ms": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_finished_at": {
+                    "type": "string"
+                },
+                "last_outcome": {
+                    "description": "LastOutcome is succeeded or failed for the last finished run, or skipped when the last due\nrun found the previous one still going",
+                    "type": "string"
+                },
+                "last_started_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_run_at": {
+                    "description": "NextRunAt is when the task runs next if this replica is the leader then",
+                    "type": "string"
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "runs": {
+                    "type": "integer"
+                },
+                "schedule": {
+                    "type": "string"
+                },
+                "skipped": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ScheduledTasksResponse": {
+            "type": "object",
+            "properties": {
+                "leader": {
+                    "type": "boolean"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ScheduledTaskStatus"
+                    }
+                }
+            }
+        },
+        "models.ScoreDelta": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "number"
+                },
+                "before": {
+                    "type": "number"
+                },
+                "conversation_id": {
+                    "type": "string"
+                },
+                "delta": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.SearchFeedbackRequest": {
+            "type": "object",
+            "required": [
+                "fusion_arm",
+                "relevant"
+            ],
+            "properties": {
+                "fusion_arm": {
+                    "description": "FusionArm is search_metadata.fusion_arm of the rated search",
+                    "type": "string"
+                },
+                "relevant": {
+                    "description": "Relevant is whether the result answered the search",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.SearchFeedbackResponse": {
+            "type": "object",
+            "properties": {
+                "counted": {
+                    "description": "Counted is false while the tenant is frozen to one arm; the feedback isn't credited then",
+                    "type": "boolean"
+                },
+                "fusion_arm": {
+                    "type": "string"
+                },
+                "relevant": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.SearchMetadata": {
+            "type": "object",
+            "properties": {
+                "embedding_model": {
+                    "type": "string"
+                },
+                "features": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "fusion_arm": {
+                    "description": "FusionArm is the fusion bandit arm the search was served with; send it back with relevance\nfeedback on the results. Absent when the bandit is off or the search set its own weights",
+                    "type": "string"
+                },
+                "latency": {
+                    "description": "Latency splits the search time by stage",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.LatencyBreakdown"
+                        }
+                    ]
+                },
+                "search_time_ms": {
+                    "type": "integer"
+                },
+                "skipped_stages": {
+                    "description": "SkippedStages lists the optional stages skipped, as kind:name, because too little time\nremained until the caller's X-Request-Deadline",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "usage": {
+                    "description": "Usage is the tokens billed for embedding the query; absent when no embedding call was made",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.EmbeddingUsage"
+                        }
+                    ]
+                },
+                "vector_db": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SearchResponse": {
+            "type": "object",
+            "properties": {
+                "query": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConversationSearchResult"
+                    }
+                },
+                "search_metadata": {
+                    "$ref": "#/definitions/models.SearchMetadata"
+                },
+                "total_results": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Session": {
+            "type": "object",
+            "properties": {
+                "closed_at": {
+                    "type": "string"
+                },
+                "conversation_count": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "summary": {
+                    "type": "string"
+                },
+                "summary_updated_at": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SessionContextResponse": {
+            "type": "object",
+            "properties": {
+                "conversation_count": {
+                    "type": "integer"
+                },
+                "recent_messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Message"
+                    }
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "summary": {
+                    "type": "string"
+                },
+                "summary_updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SessionCreateRequest": {
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "session_id": {
+                    "description": "Optional client-chosen ID; generated when empty",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SessionListResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Session"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SessionTranscriptResponse": {
+            "type": "object",
+            "properties": {
+                "conversations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConversationResponse"
+                    }
+                },
+                "message_count": {
+                    "type": "integer"
+                },
+                "session": {
+                    "$ref": "#/definitions/models.Session"
+                }
+            }
+        },
+        "models.StandingQuery": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "filter": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_matched_at": {
+                    "type": "string"
+                },
+                "match_count": {
+                    "type": "integer"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "description": "Threshold is the similarity between the query and a conversation's vector, on the scale of\nsearch scores, at or above which the conversation matches",
+                    "type": "number"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.StandingQueryCreateRequest": {
+            "type": "object",
+            "required": [
+                "query",
+                "user_id"
+            ],
+            "properties": {
+                "filter": {
+                    "description": "Filter restricts matches with the metadata filter syntax of searches",
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "threshold": {
+                    "description": "Threshold defaults to DefaultStandingQueryThreshold",
+                    "type": "number",
+                    "maximum": 1
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.StandingQueryDeleteResponse": {
+            "type": "object",
+            "properties": {
+                "deleted_query_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.StandingQueryListResponse": {
+            "type": "object",
+            "properties": {
+                "standing_queries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StandingQuery"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.StandingQueryMatch": {
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "matched_at": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "standing_query_id": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SuppressedMemories": {
+            "type": "object",
+            "properties": {
+                "conversations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConversationResponse"
+                    }
+                },
+                "personal_info": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PersonalInfoResponse"
+                    }
+                }
+            }
+        },
+        "models.Suppression": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "suppressed_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SuppressionRequest": {
+            "type": "object",
+            "required": [
+                "suppressed"
+            ],
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "reason": {
+                    "description": "Required when suppressing",
+                    "type": "string",
+                    "enum": [
+                        "wrong",
+                        "outdated",
+                        "harmful"
+                    ]
+                },
+                "suppressed": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.SuppressionResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "suppression": {
+                    "$ref": "#/definitions/models.Suppression"
+                },
+                "type": {
+                    "description": "\"conversation\" or \"personal_info\"",
+                    "type": "string"
+                }
+            }
+        },
+        "models.TableStats": {
+            "type": "object",
+            "properties": {
+                "dead_rows": {
+                    "type": "integer"
+                },
+                "estimated_rows": {
+                    "type": "integer"
+                },
+                "index_bytes": {
+                    "type": "integer"
+                },
+                "indexes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.IndexStats"
+                    }
+                },
+                "last_vacuum": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "table_bytes": {
+                    "type": "integer"
+                },
+                "total_bytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TimelineDay": {
+            "type": "object",
+            "properties": {
+                "conversations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TimelineEntry"
+                    }
+                },
+                "count": {
+                    "description": "conversations on the day, including those not listed",
+                    "type": "integer"
+                },
+                "date": {
+                    "description": "YYYY-MM-DD in the timeline's time zone",
+                    "type": "string"
+                }
+            }
+        },
+        "models.TimelineEntry": {
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "message_count": {
+                    "type": "integer"
+                },
+                "pinned": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "summary": {
+                    "description": "the start of the conversation's first user message",
+                    "type": "string"
+                },
+                "suppressed": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.TimelineResponse": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TimelineDay"
+                    }
+                },
+                "next_before": {
+                    "description": "NextBefore is the before value of the next, older page; empty when there are no older days",
+                    "type": "string"
+                },
+                "time_zone": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Topic": {
+            "type": "object",
+            "properties": {
+                "cohesion": {
+                    "description": "Cohesion is the mean cosine similarity of the topic's conversations to its centroid",
+                    "type": "number"
+                },
+                "first_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "last_at": {
+                    "type": "string"
+                },
+                "representatives": {
+                    "description": "Representatives are the conversations closest to the topic's centroid",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TopicConversation"
+                    }
+                },
+                "share": {
+                    "description": "Share is the fraction of the clustered conversations in the topic",
+                    "type": "number"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "summary": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TopicConversation": {
+            "type": "object",
             "properties": {
-                "code": {
+                "conversation_id": {
                     "type": "string"
                 },
-                "details": {},
-                "message": {
+                "created_at": {
                     "type": "string"
+                },
+                "excerpt": {
+                    "type": "string"
+                },
+                "similarity": {
+                    "type": "number"
                 }
             }
         },
-        "models.Message": {
+        "models.TopicMap": {
             "type": "object",
             "properties": {
-                "content": {
+                "conversations": {
+                    "description": "Conversations is how many conversations were clustered: the user's most recent ones with a\nstored vector, excluding suppressed ones",
+                    "type": "integer"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "generated_at": {
                     "type": "string"
                 },
-                "role": {
-                    "description": "\"user\" or \"assistant\"",
+                "labeled": {
+                    "description": "Labeled is set when the chat model named the topics; labels are empty otherwise",
+                    "type": "boolean"
+                },
+                "topics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Topic"
+                    }
+                },
+                "truncated": {
+                    "description": "Truncated is set when the user has more conversations than were clustered",
+                    "type": "boolean"
+                },
+                "user_id": {
                     "type": "string"
                 }
             }
         },
-        "models.Metadata": {
+        "models.TracedCandidate": {
             "type": "object",
             "properties": {
-                "session_id": {
+                "conversation_id": {
                     "type": "string"
                 },
-                "source": {
+                "raw_score": {
+                    "description": "Fused score before normalization",
+                    "type": "number"
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.Unembedded": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "\"policy\" or \"length\"",
+                    "type": "string"
+                },
+                "rejected_at": {
                     "type": "string"
                 }
             }
         },
-        "models.PersonalInfoCreateRequest": {
+        "models.UnembeddedListResponse": {
+            "type": "object",
+            "properties": {
+                "conversations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConversationResponse"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UnembeddedRetryRequest": {
+            "type": "object",
+            "properties": {
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Message"
+                    }
+                }
+            }
+        },
+        "models.UsageRecord": {
+            "type": "object",
+            "properties": {
+                "day": {
+                    "description": "YYYY-MM-DD",
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "prompt_tokens": {
+                    "type": "integer"
+                },
+                "requests": {
+                    "type": "integer"
+                },
+                "tenant": {
+                    "type": "string"
+                },
+                "total_tokens": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UsageReport": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "prompt_tokens": {
+                    "type": "integer"
+                },
+                "records": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UsageRecord"
+                    }
+                },
+                "requests": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total_tokens": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "disabled_at": {
+                    "type": "string"
+                },
+                "disabled_reason": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "region": {
+                    "description": "Region is the home region of the user's data; empty follows the tenant's region",
+                    "type": "string"
+                },
+                "retention_exempt": {
+                    "description": "RetentionExempt protects the user's conversations from retention runs, e.g. under a legal hold",
+                    "type": "boolean"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UserCreateRequest": {
             "type": "object",
             "required": [
-                "category",
-                "content",
-                "importance",
                 "user_id"
             ],
             "properties": {
-                "category": {
+                "display_name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "region": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "retention_exempt": {
+                    "type": "boolean"
+                },
+                "user_id": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "models.UserDataCounts": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "description": "the users registry row",
+                    "type": "integer"
+                },
+                "conversation_vectors": {
+                    "type": "integer"
+                },
+                "conversations": {
+                    "type": "integer"
+                },
+                "messages": {
+                    "type": "integer"
+                },
+                "personal_info": {
+                    "type": "integer"
+                },
+                "personal_info_vectors": {
+                    "type": "integer"
+                },
+                "profiles": {
+                    "type": "integer"
+                },
+                "query_adapters": {
+                    "type": "integer"
+                },
+                "search_logs": {
+                    "type": "integer"
+                },
+                "sessions": {
+                    "type": "integer"
+                },
+                "standing_queries": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UserDeletionResponse": {
+            "type": "object",
+            "properties": {
+                "certificate_id": {
+                    "description": "CertificateID identifies the deletion certificate of an executed deletion",
                     "type": "string"
                 },
-                "content": {
+                "confirmation": {
+                    "$ref": "#/definitions/models.Confirmation"
+                },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "job_id": {
                     "type": "string"
                 },
-                "importance": {
+                "scope": {
+                    "$ref": "#/definitions/models.UserDataCounts"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UserDisableRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
                     "type": "string",
-                    "enum": [
-                        "high",
-                        "medium",
-                        "low"
-                    ]
+                    "maxLength": 500
+                }
+            }
+        },
+        "models.UserListResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.User"
+                    }
+                }
+            }
+        },
+        "models.UserProfile": {
+            "type": "object",
+            "properties": {
+                "generated_at": {
+                    "type": "string"
+                },
+                "health_notes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "preferences": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "routines": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "sources": {
+                    "$ref": "#/definitions/models.ProfileSources"
                 },
                 "user_id": {
                     "type": "string"
                 }
             }
         },
-        "models.PersonalInfoUpdateRequest": {
+        "models.UserReindexResponse": {
             "type": "object",
             "properties": {
-                "category": {
+                "conversations": {
+                    "$ref": "#/definitions/models.ReindexCounts"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "job_id": {
                     "type": "string"
                 },
-                "content": {
+                "personal_info": {
+                    "$ref": "#/definitions/models.ReindexCounts"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UserStats": {
+            "type": "object",
+            "properties": {
+                "conversation_count": {
+                    "type": "integer"
+                },
+                "last_conversation_at": {
+                    "description": "last activity; kept when conversations are deleted",
                     "type": "string"
                 },
-                "importance": {
+                "total_tokens": {
+                    "description": "embedding tokens spent on the user's conversations",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UserUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "display_name": {
                     "type": "string",
-                    "enum": [
-                        "high",
-                        "medium",
-                        "low"
-                    ]
+                    "maxLength": 255
+                },
+                "region": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "retention_exempt": {
+                    "type": "boolean"
                 }
             }
+        },
+        "models.VectorImportRequest": {
+            "type": "object",
+            "required": [
+                "content_type",
+                "key"
+            ],
+            "properties": {
+                "content_type": {
+                    "description": "ContentType selects the collection: conversations, personal_info or documents",
+                    "type": "string"
+                },
+                "dry_run": {
+                    "description": "DryRun validates the file and counts matching points without writing",
+                    "type": "boolean"
+                },
+                "ids_key": {
+                    "description": "IDsKey is a JSON lines file whose id fields name the rows of a .npy matrix, in order",
+                    "type": "string"
+                },
+                "key": {
+                    "description": "Key is a Parquet file with id and embedding columns, or a .npy float32 matrix",
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "AdminAPIKey": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
         }
     }
 }`
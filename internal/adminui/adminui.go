@@ -0,0 +1,32 @@
+// Package adminui serves the operator dashboard, a static page calling the admin API from the
+// browser. The page itself holds no data: every call it makes carries the admin key the operator
+// enters and is authorized by the admin API like any other client
+package adminui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the dashboard files below prefix, e.g. /admin/
+func Handler(prefix string) http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		// The directory is embedded at build time
+		panic(err)
+	}
+	fileServer := http.StripPrefix(prefix, http.FileServer(http.FS(files)))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The page runs only its own script and talks only to this server
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		w.Header().Set("Cache-Control", "no-cache")
+		fileServer.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,302 @@
+// Operator dashboard. Every call goes to the admin or RAG API with the admin key the operator
+// entered; the key is kept in session storage for this tab only.
+"use strict";
+
+const API = "/api/rag";
+const KEY_STORAGE = "rag-admin-key";
+
+function adminKey() {
+  return sessionStorage.getItem(KEY_STORAGE) || "";
+}
+
+// api calls an API path and returns the envelope's data, throwing the envelope's error. A body
+// is posted as JSON
+async function api(path, params, body) {
+  const url = new URL(API + path, location.origin);
+  for (const [name, value] of Object.entries(params || {})) {
+    if (value !== "" && value !== undefined && value !== null) {
+      url.searchParams.set(name, value);
+    }
+  }
+  const init = {
+    headers: { Authorization: "Bearer " + adminKey(), Accept: "application/json" },
+  };
+  if (body !== undefined) {
+    init.method = "POST";
+    init.headers["Content-Type"] = "application/json";
+    init.body = JSON.stringify(body);
+  }
+  const response = await fetch(url, init);
+  let envelope;
+  try {
+    envelope = await response.json();
+  } catch (e) {
+    throw new Error(response.status + " " + response.statusText);
+  }
+  if (!response.ok || envelope.success === false) {
+    const error = envelope.error || {};
+    throw new Error((error.code || response.status) + ": " + (error.message || response.statusText));
+  }
+  return envelope.data;
+}
+
+function output(name) {
+  return document.querySelector('[data-output="' + name + '"]');
+}
+
+function text(value) {
+  if (value === null || value === undefined) {
+    return "";
+  }
+  if (typeof value === "object") {
+    return JSON.stringify(value, null, 2);
+  }
+  return String(value);
+}
+
+// table renders a list of objects with one column per key seen
+function table(rows) {
+  const columns = [];
+  for (const row of rows) {
+    for (const key of Object.keys(row)) {
+      if (!columns.includes(key)) {
+        columns.push(key);
+      }
+    }
+  }
+  const el = document.createElement("table");
+  const head = el.createTHead().insertRow();
+  for (const column of columns) {
+    const th = document.createElement("th");
+    th.textContent = column;
+    head.appendChild(th);
+  }
+  const body = el.createTBody();
+  for (const row of rows) {
+    const tr = body.insertRow();
+    for (const column of columns) {
+      const pre = document.createElement("pre");
+      pre.textContent = text(row[column]);
+      tr.insertCell().appendChild(pre);
+    }
+  }
+  return el;
+}
+
+// render shows data as a table when it is, or holds, a list of objects and as JSON otherwise
+function render(name, data) {
+  const el = output(name);
+  el.replaceChildren();
+
+  let rows = Array.isArray(data) ? data : null;
+  let rest = null;
+  if (!rows && data && typeof data === "object") {
+    const listKey = Object.keys(data).find((key) => Array.isArray(data[key]) && data[key].every((v) => v && typeof v === "object"));
+    if (listKey) {
+      rows = data[listKey];
+      rest = Object.assign({}, data);
+      delete rest[listKey];
+    }
+  }
+
+  if (rest && Object.keys(rest).length > 0) {
+    const pre = document.createElement("pre");
+    pre.className = "json";
+    pre.textContent = text(rest);
+    el.appendChild(pre);
+  }
+  if (rows) {
+    if (rows.length === 0) {
+      const p = document.createElement("p");
+      p.className = "muted";
+      p.textContent = "Nothing found.";
+      el.appendChild(p);
+    } else {
+      el.appendChild(table(rows));
+    }
+    return;
+  }
+  const pre = document.createElement("pre");
+  pre.className = "json";
+  pre.textContent = text(data);
+  el.appendChild(pre);
+}
+
+function renderError(name, error) {
+  const el = output(name);
+  const div = document.createElement("div");
+  div.className = "error";
+  div.textContent = error.message;
+  el.replaceChildren(div);
+}
+
+// show calls the API and renders the result or the error into an output
+async function show(name, path, params) {
+  const el = output(name);
+  el.replaceChildren();
+  el.textContent = "Loading…";
+  try {
+    render(name, await api(path, params));
+  } catch (error) {
+    renderError(name, error);
+  }
+}
+
+function formValues(form) {
+  return Object.fromEntries(new FormData(form).entries());
+}
+
+const loaders = {
+  health() {
+    show("health", "/health");
+    show("health-history", "/admin/health/history");
+    show("collections", "/admin/collections");
+  },
+  stats() {
+    show("index-health", "/admin/index-health");
+    show("scheduler", "/admin/scheduler/tasks");
+    show("usage", "/admin/usage");
+    show("caches", "/admin/caches");
+  },
+  jobs() {
+    show("jobs", "/admin/jobs", { kind: formValues(document.getElementById("jobs-form")).kind });
+  },
+  dlq() {
+    show("dlq", "/admin/dlq", { kind: formValues(document.getElementById("dlq-form")).kind });
+  },
+};
+
+function selectView(view) {
+  for (const button of document.querySelectorAll("nav button")) {
+    button.classList.toggle("active", button.dataset.view === view);
+  }
+  for (const section of document.querySelectorAll("main section")) {
+    section.hidden = section.id !== "view-" + view;
+  }
+  if (loaders[view] && adminKey()) {
+    loaders[view]();
+  }
+}
+
+document.querySelectorAll("nav button").forEach((button) => {
+  button.addEventListener("click", () => selectView(button.dataset.view));
+});
+
+document.querySelectorAll("[data-load]").forEach((button) => {
+  button.addEventListener("click", () => loaders[button.dataset.load]());
+});
+
+document.getElementById("key-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  const input = document.getElementById("admin-key");
+  sessionStorage.setItem(KEY_STORAGE, input.value.trim());
+  input.value = "";
+  selectView(document.querySelector("nav button.active").dataset.view);
+});
+
+document.getElementById("forget-key").addEventListener("click", () => {
+  sessionStorage.removeItem(KEY_STORAGE);
+  document.querySelectorAll("[data-output]").forEach((el) => el.replaceChildren());
+});
+
+document.getElementById("search-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  show("search", "/conversation/search", formValues(event.target));
+});
+
+// renderRuns shows playground runs side by side, one column per configuration
+function renderRuns(data) {
+  const el = output("playground");
+  el.replaceChildren();
+  for (const run of data.runs) {
+    const column = document.createElement("div");
+    column.className = "run";
+    const title = document.createElement("h3");
+    title.textContent = run.name;
+    const summary = document.createElement("p");
+    summary.className = "muted";
+    summary.textContent = run.latency_ms + " ms, overlap " + Math.round(run.overlap * 100) + "%" +
+      (run.added.length ? ", +" + run.added.length : "") + (run.dropped.length ? ", -" + run.dropped.length : "");
+    const stages = document.createElement("p");
+    stages.className = "muted";
+    stages.textContent = [run.stages.retrievers.join("+"), run.stages.fuser, run.stages.normalizer]
+      .concat(run.stages.rerankers).join(" → ");
+    column.append(title, summary, stages);
+    if (run.error) {
+      const error = document.createElement("div");
+      error.className = "error";
+      error.textContent = run.error;
+      column.appendChild(error);
+    } else {
+      column.appendChild(table(run.results.map((result) => ({
+        rank: result.rank,
+        baseline: result.baseline_rank || "new",
+        score: result.score.toFixed(4),
+        raw: result.raw_score.toFixed(4),
+        conversation: result.preview || result.conversation_id,
+      }))));
+    }
+    el.appendChild(column);
+  }
+}
+
+document.getElementById("playground-form").addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const search = formValues(document.getElementById("search-form"));
+  const el = output("playground");
+  let configurations;
+  try {
+    configurations = JSON.parse(formValues(event.target).configurations);
+  } catch (error) {
+    renderError("playground", new Error("Configurations must be a JSON list: " + error.message));
+    return;
+  }
+  el.textContent = "Running…";
+  try {
+    renderRuns(await api("/admin/search/playground", null, {
+      query: search.query,
+      user_id: search.user_id,
+      filter: search.filter,
+      top_k: Number(search.top_k) || 10,
+      configurations: configurations,
+    }));
+  } catch (error) {
+    renderError("playground", error);
+  }
+});
+
+document.getElementById("jobs-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  loaders.jobs();
+});
+
+document.getElementById("job-lookup").addEventListener("click", () => {
+  const jobID = document.querySelector('#jobs-form [name="job_id"]').value.trim();
+  if (jobID) {
+    show("jobs", "/admin/jobs/" + encodeURIComponent(jobID));
+  }
+});
+
+document.getElementById("dlq-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  loaders.dlq();
+});
+
+document.getElementById("dlq-lookup").addEventListener("click", () => {
+  const id = document.querySelector('#dlq-form [name="id"]').value.trim();
+  if (id) {
+    show("dlq", "/admin/dlq/" + encodeURIComponent(id));
+  }
+});
+
+document.getElementById("user-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  const userID = encodeURIComponent(formValues(event.target).user_id.trim());
+  show("user", "/admin/users/" + userID);
+  show("user-conversations", "/users/" + userID + "/conversations");
+  show("user-personal-info", "/personal-info/user/" + userID);
+});
+
+if (adminKey()) {
+  selectView("health");
+}
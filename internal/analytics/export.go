@@ -0,0 +1,292 @@
+// Package analytics exports conversations, messages and search logs as Parquet files so usage
+// can be analyzed in a warehouse without querying the production database. Each UTC day is
+// exported from one database snapshot into Hive-style date partitions:
+//
+//	<prefix>/conversations/date=2024-05-01/part-0.parquet
+//	<prefix>/messages/date=2024-05-01/part-0.parquet
+//	<prefix>/search_logs/date=2024-05-01/part-0.parquet
+//	<prefix>/_manifests/date=2024-05-01.json
+//
+// Rows are partitioned by their creation time. The manifest is written last, so a day with a
+// manifest is completely exported
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"refo-rag-server/internal/blobstore"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// DateLayout is the layout of partition dates
+const DateLayout = "2006-01-02"
+
+// Exported tables
+const (
+	TableConversations = "conversations"
+	TableMessages      = "messages"
+	TableSearchLogs    = "search_logs"
+)
+
+// Exporter writes daily analytics exports to a blob store
+type Exporter struct {
+	store  storage.AnalyticsStore
+	blobs  blobstore.Store
+	prefix string
+}
+
+// NewExporter creates an exporter writing below prefix in blobs
+func NewExporter(store storage.AnalyticsStore, blobs blobstore.Store, prefix string) *Exporter {
+	return &Exporter{store: store, blobs: blobs, prefix: prefix}
+}
+
+// Export writes the rows created on a UTC day, replacing any earlier export of that day
+func (e *Exporter) Export(ctx context.Context, day time.Time) (*models.AnalyticsExport, error) {
+	startTime := time.Now()
+	from := truncateDay(day)
+	date := from.Format(DateLayout)
+
+	export := &models.AnalyticsExport{
+		Date:          date,
+		SchemaVersion: storage.SchemaVersion,
+		Files:         []models.AnalyticsExportFile{},
+	}
+
+	sink := &parquetSink{
+		conversations: &tableWriter[conversationRow]{table: TableConversations},
+		messages:      &tableWriter[messageRow]{table: TableMessages},
+		searches:      &tableWriter[searchRow]{table: TableSearchLogs},
+	}
+	tables := []table{sink.conversations, sink.messages, sink.searches}
+
+	err := e.readInto(ctx, from, date, sink, tables)
+	if err != nil {
+		for _, t := range tables {
+			t.abort()
+		}
+		return nil, fmt.Errorf("failed to export analytics for %s: %w", date, err)
+	}
+
+	// Publish every file before the manifest marks the day complete
+	for i, t := range tables {
+		if err := t.finish(); err != nil {
+			for _, rest := range tables[i+1:] {
+				rest.abort()
+			}
+			return nil, fmt.Errorf("failed to write analytics export for %s: %w", date, err)
+		}
+		export.Files = append(export.Files, models.AnalyticsExportFile{Table: t.name(), Key: t.key(), Rows: t.rows()})
+	}
+
+	export.ExportedAt = time.Now()
+	export.DurationMs = time.Since(startTime).Milliseconds()
+	manifest, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode export manifest: %w", err)
+	}
+	writer, err := e.blobs.Create(ctx, e.manifestKey(date))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(manifest); err != nil {
+		writer.Abort()
+		return nil, fmt.Errorf("failed to write export manifest: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// readInto opens every table's blob and streams the day's rows into them
+func (e *Exporter) readInto(ctx context.Context, from time.Time, date string, sink *parquetSink, tables []table) error {
+	for _, t := range tables {
+		if err := t.open(ctx, e.blobs, e.partitionKey(t.name(), date)); err != nil {
+			return err
+		}
+	}
+	return e.store.ExportAnalytics(ctx, from, from.AddDate(0, 0, 1), sink)
+}
+
+// Exported reports whether a UTC day has a complete export
+func (e *Exporter) Exported(ctx context.Context, day time.Time) (bool, error) {
+	reader, err := e.blobs.Open(ctx, e.manifestKey(truncateDay(day).Format(DateLayout)))
+	if errors.Is(err, blobstore.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	reader.Close()
+	return true, nil
+}
+
+// manifestKey returns the key of a day's manifest
+func (e *Exporter) manifestKey(date string) string {
+	return fmt.Sprintf("%s/_manifests/date=%s.json", e.prefix, date)
+}
+
+// partitionKey returns the key of a table's file for a day
+func (e *Exporter) partitionKey(table string, date string) string {
+	return fmt.Sprintf("%s/%s/date=%s/part-0.parquet", e.prefix, table, date)
+}
+
+// truncateDay returns the start of a time's UTC day
+func truncateDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// table is an exported table's file being written
+type table interface {
+	name() string
+	open(ctx context.Context, blobs blobstore.Store, key string) error
+	key() string
+	rows() int64
+	finish() error
+	abort()
+}
+
+// tableWriter streams the rows of one table into a Parquet blob
+type tableWriter[T any] struct {
+	table   string
+	blobKey string
+	blob    blobstore.Writer
+	writer  *parquet.GenericWriter[T]
+	count   int64
+}
+
+func (w *tableWriter[T]) open(ctx context.Context, blobs blobstore.Store, key string) error {
+	blob, err := blobs.Create(ctx, key)
+	if err != nil {
+		return err
+	}
+	w.blobKey = key
+	w.blob = blob
+	w.writer = parquet.NewGenericWriter[T](blob, parquet.Compression(&parquet.Zstd))
+	return nil
+}
+
+func (w *tableWriter[T]) write(row T) error {
+	if _, err := w.writer.Write([]T{row}); err != nil {
+		return fmt.Errorf("failed to encode %s row: %w", w.table, err)
+	}
+	w.count++
+	return nil
+}
+
+func (w *tableWriter[T]) name() string { return w.table }
+func (w *tableWriter[T]) key() string  { return w.blobKey }
+func (w *tableWriter[T]) rows() int64  { return w.count }
+
+// finish flushes the Parquet footer and publishes the blob
+func (w *tableWriter[T]) finish() error {
+	if err := w.writer.Close(); err != nil {
+		w.blob.Abort()
+		return fmt.Errorf("failed to encode %s: %w", w.table, err)
+	}
+	return w.blob.Close()
+}
+
+// abort discards the blob, if it was opened
+func (w *tableWriter[T]) abort() {
+	if w.blob != nil {
+		w.blob.Abort()
+	}
+}
+
+// parquetSink converts exported rows to their Parquet layout
+type parquetSink struct {
+	conversations *tableWriter[conversationRow]
+	messages      *tableWriter[messageRow]
+	searches      *tableWriter[searchRow]
+}
+
+func (s *parquetSink) Conversation(conv *models.Conversation) error {
+	return s.conversations.write(conversationRow{
+		ID:         conv.ID,
+		UserID:     conv.UserID,
+		SessionID:  conv.SessionID,
+		Question:   conv.Question,
+		Answer:     conv.Answer,
+		Metadata:   conv.Metadata,
+		Importance: conv.Importance,
+		Pinned:     conv.Pinned,
+		Suppressed: conv.Suppression != nil,
+		CreatedAt:  conv.CreatedAt,
+		UpdatedAt:  conv.UpdatedAt,
+	})
+}
+
+func (s *parquetSink) Message(msg *models.AnalyticsMessage) error {
+	return s.messages.write(messageRow{
+		ConversationID: msg.ConversationID,
+		MessageID:      msg.MessageID,
+		Position:       int32(msg.Position),
+		Role:           msg.Role,
+		Content:        msg.Content,
+		Speaker:        msg.Speaker,
+		DisplayName:    msg.DisplayName,
+		CreatedAt:      msg.CreatedAt,
+	})
+}
+
+func (s *parquetSink) Search(entry *models.SearchLog) error {
+	return s.searches.write(searchRow{
+		ID:         entry.ID,
+		Tenant:     entry.Tenant,
+		UserID:     entry.UserID,
+		Query:      entry.Query,
+		Results:    int32(entry.Results),
+		TopScore:   entry.TopScore,
+		DurationMs: entry.DurationMs,
+		Variant:    entry.Variant,
+		CreatedAt:  entry.CreatedAt,
+	})
+}
+
+// conversationRow is the Parquet layout of the conversations table
+type conversationRow struct {
+	ID         string    `parquet:"id"`
+	UserID     string    `parquet:"user_id"`
+	SessionID  string    `parquet:"session_id"`
+	Question   string    `parquet:"question"`
+	Answer     string    `parquet:"answer"`
+	Metadata   string    `parquet:"metadata,json"`
+	Importance float64   `parquet:"importance"`
+	Pinned     bool      `parquet:"pinned"`
+	Suppressed bool      `parquet:"suppressed"`
+	CreatedAt  time.Time `parquet:"created_at,timestamp(millisecond)"`
+	UpdatedAt  time.Time `parquet:"updated_at,timestamp(millisecond)"`
+}
+
+// messageRow is the Parquet layout of the messages table
+type messageRow struct {
+	ConversationID string    `parquet:"conversation_id"`
+	MessageID      string    `parquet:"message_id"`
+	Position       int32     `parquet:"position"`
+	Role           string    `parquet:"role,dict"`
+	Content        string    `parquet:"content"`
+	Speaker        string    `parquet:"speaker"`
+	DisplayName    string    `parquet:"display_name"`
+	CreatedAt      time.Time `parquet:"created_at,timestamp(millisecond)"`
+}
+
+// searchRow is the Parquet layout of the search_logs table
+type searchRow struct {
+	ID         int64     `parquet:"id"`
+	Tenant     string    `parquet:"tenant,dict"`
+	UserID     string    `parquet:"user_id"`
+	Query      string    `parquet:"query"`
+	Results    int32     `parquet:"results"`
+	TopScore   float32   `parquet:"top_score"`
+	DurationMs int64     `parquet:"duration_ms"`
+	Variant    string    `parquet:"variant,dict"`
+	CreatedAt  time.Time `parquet:"created_at,timestamp(millisecond)"`
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/featureflag"
+	"refo-rag-server/internal/health"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+	"refo-rag-server/internal/storage"
+)
+
+// AdminHandler handles operator-facing administrative requests
+type AdminHandler struct {
+	collectionManager *storage.CollectionManager
+	maintenanceMode   *service.MaintenanceMode
+	featureFlags      *featureflag.Store
+	healthMonitor     *health.Monitor
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(
+	collectionManager *storage.CollectionManager,
+	maintenanceMode *service.MaintenanceMode,
+	featureFlags *featureflag.Store,
+	healthMonitor *health.Monitor,
+) *AdminHandler {
+	return &AdminHandler{
+		collectionManager: collectionManager,
+		maintenanceMode:   maintenanceMode,
+		featureFlags:      featureFlags,
+		healthMonitor:     healthMonitor,
+	}
+}
+
+// ListCollections reports configured and live settings for every managed collection
+// @Summary List vector collections
+// @Description List managed Qdrant collections with their configured and live sharding/replication settings
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Success 200 {object} models.APIResponse[models.CollectionListResponse] "Collection list"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Router /api/rag/admin/collections [get]
+func (ah *AdminHandler) ListCollections(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	collections := make([]models.CollectionStatusResponse, 0)
+	for _, contentType := range ah.collectionManager.ContentTypes() {
+		cfg, _ := ah.collectionManager.Config(contentType)
+		status := models.CollectionStatusResponse{
+			ContentType: contentType,
+			Name:        cfg.Name,
+			Model:       cfg.Model,
+			Dimension:   cfg.Dimension,
+			Distance:    cfg.Distance,
+			Datatype:    cfg.Datatype,
+			Configured: models.ClusterSettings{
+				ShardNumber:            cfg.ShardNumber,
+				ReplicationFactor:      cfg.ReplicationFactor,
+				WriteConsistencyFactor: cfg.WriteConsistencyFactor,
+			},
+		}
+
+		store, err := ah.collectionManager.Store(contentType)
+		if err == nil {
+			var info *storage.CollectionInfo
+			info, err = store.GetCollectionInfo(ctx)
+			if err == nil {
+				status.Live = &models.LiveCollectionStatus{
+					Status:      info.Status,
+					PointsCount: info.PointsCount,
+					Datatype:    info.Datatype,
+					Cluster: models.ClusterSettings{
+						ShardNumber:            info.ShardNumber,
+						ReplicationFactor:      info.ReplicationFactor,
+						WriteConsistencyFactor: info.WriteConsistencyFactor,
+					},
+				}
+			}
+		}
+		if err != nil {
+			status.Error = err.Error()
+		}
+
+		collections = append(collections, status)
+	}
+
+	respondSuccess(c, http.StatusOK, models.CollectionListResponse{Collections: collections})
+}
+
+// GetMaintenance reports whether read-only maintenance mode is enabled
+// @Summary Get maintenance mode
+// @Description Report whether the server is in read-only maintenance mode
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Success 200 {object} models.APIResponse[models.MaintenanceStatus] "Maintenance status"
+// @Router /api/rag/admin/maintenance [get]
+func (ah *AdminHandler) GetMaintenance(c *gin.Context) {
+	respondSuccess(c, http.StatusOK, ah.maintenanceMode.Status())
+}
+
+// UpdateMaintenance enables or disables read-only maintenance mode
+// @Summary Toggle maintenance mode
+// @Description Enable or disable read-only maintenance mode; writes return 503 while enabled and searches keep working
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param request body models.MaintenanceUpdateRequest true "Maintenance toggle"
+// @Success 200 {object} models.APIResponse[models.MaintenanceStatus] "Maintenance status"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 409 {object} models.ErrorResponse "Standby instances can't leave read-only mode"
+// @Router /api/rag/admin/maintenance [put]
+func (ah *AdminHandler) UpdateMaintenance(c *gin.Context) {
+	var req models.MaintenanceUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if *req.Enabled {
+		ah.maintenanceMode.Enable(req.Reason)
+		log.Printf("Maintenance mode enabled: %s", req.Reason)
+	} else {
+		if err := ah.maintenanceMode.Disable(); err != nil {
+			respondError(c, http.StatusConflict, "STANDBY", err.Error(), nil)
+			return
+		}
+		log.Println("Maintenance mode disabled")
+	}
+
+	respondSuccess(c, http.StatusOK, ah.maintenanceMode.Status())
+}
+
+// ListFeatureFlags returns the currently loaded feature flags
+// @Summary List feature flags
+// @Description List feature flags with their global defaults and per-tenant overrides
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Success 200 {object} models.APIResponse[models.FeatureFlagListResponse] "Feature flags"
+// @Router /api/rag/admin/feature-flags [get]
+func (ah *AdminHandler) ListFeatureFlags(c *gin.Context) {
+	respondSuccess(c, http.StatusOK, ah.featureFlagList())
+}
+
+// ReloadFeatureFlags re-reads the feature flags file
+// @Summary Reload feature flags
+// @Description Re-read the feature flags file so rollouts and rollbacks take effect immediately
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Success 200 {object} models.APIResponse[models.FeatureFlagListResponse] "Reloaded feature flags"
+// @Failure 500 {object} models.ErrorResponse "Flags file could not be loaded; previous flags remain active"
+// @Router /api/rag/admin/feature-flags/reload [post]
+func (ah *AdminHandler) ReloadFeatureFlags(c *gin.Context) {
+	if err := ah.featureFlags.Reload(); err != nil {
+		respondError(c, http.StatusInternalServerError, "FEATURE_FLAGS_RELOAD_FAILED", "failed to reload feature flags", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	log.Println("Feature flags reloaded")
+	respondSuccess(c, http.StatusOK, ah.featureFlagList())
+}
+
+// featureFlagList converts the flag snapshot into a sorted response
+func (ah *AdminHandler) featureFlagList() models.FeatureFlagListResponse {
+	flags, loadedAt := ah.featureFlags.Snapshot()
+
+	items := make([]models.FeatureFlagResponse, 0, len(flags))
+	for name, flag := range flags {
+		items = append(items, models.FeatureFlagResponse{
+			Name:        name,
+			Enabled:     flag.Enabled,
+			Description: flag.Description,
+			Tenants:     flag.Tenants,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+
+	return models.FeatureFlagListResponse{
+		Flags:    items,
+		LoadedAt: loadedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// GetHealthHistory returns recent dependency health checks and status transitions
+// @Summary Dependency health history
+// @Description Return recent raw health checks, damped status transitions, and a stability indicator for each dependency
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Success 200 {object} models.APIResponse[models.HealthHistoryResponse] "Health history"
+// @Router /api/rag/admin/health/history [get]
+func (ah *AdminHandler) GetHealthHistory(c *gin.Context) {
+	respondSuccess(c, http.StatusOK, models.HealthHistoryResponse{
+		Dependencies: ah.healthMonitor.History(),
+	})
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/analytics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// AdminAnalyticsHandler handles analytics export requests
+type AdminAnalyticsHandler struct {
+	exports *service.AnalyticsExportService
+}
+
+// NewAdminAnalyticsHandler creates a new admin analytics handler
+func NewAdminAnalyticsHandler(exports *service.AnalyticsExportService) *AdminAnalyticsHandler {
+	return &AdminAnalyticsHandler{exports: exports}
+}
+
+// ExportDay starts an analytics export of one day
+// @Summary Export a day of analytics data
+// @Description Start a background job writing the conversations, messages and search logs created on a UTC day as
+// @Description Parquet files to the blob store, read from one database snapshot and partitioned by date. An earlier
+// @Description export of the day is replaced; use it to backfill days the schedule missed. The job result lists the
+// @Description files and their row counts. Track progress with GET /admin/jobs/{job_id}.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param request body models.AnalyticsExportRequest true "Day to export"
+// @Success 202 {object} models.APIResponse[models.JobStartedResponse] "Job started"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "An export is already running"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/analytics/export [post]
+func (aah *AdminAnalyticsHandler) ExportDay(c *gin.Context) {
+	var req models.AnalyticsExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	day, err := time.Parse(analytics.DateLayout, req.Date)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "date must be formatted as YYYY-MM-DD", map[string]interface{}{
+			"date": req.Date,
+		})
+		return
+	}
+
+	jobID, err := aah.exports.StartExport(c.Request.Context(), day)
+	if errors.Is(err, service.ErrExportFuture) {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "only days that have ended can be exported", map[string]interface{}{
+			"date": req.Date,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrExportRunning) {
+		respondError(c, http.StatusConflict, "JOB_RUNNING", "an analytics export is already running", nil)
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to start analytics export", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusAccepted, models.JobStartedResponse{JobID: jobID})
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// AdminAPIKeyHandler handles service account key management requests
+type AdminAPIKeyHandler struct {
+	apiKeys *service.APIKeyService
+}
+
+// NewAdminAPIKeyHandler creates a new admin API key handler
+func NewAdminAPIKeyHandler(apiKeys *service.APIKeyService) *AdminAPIKeyHandler {
+	return &AdminAPIKeyHandler{apiKeys: apiKeys}
+}
+
+// CreateAPIKey issues a service account key
+// @Summary Create an API key
+// @Description Issue a service account key with the given scopes: read allows searches and other reads, write
+// @Description also allows saves and updates, and admin also allows the admin API. The secret is only returned in
+// @Description this response; the server stores a hash of it. Send it as "Authorization: Bearer <secret>" or
+// @Description "X-API-Key: <secret>".
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param request body models.APIKeyCreateRequest true "Key to create"
+// @Success 201 {object} models.APIResponse[models.APIKeySecret] "Key created"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/api-keys [post]
+func (akh *AdminAPIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req models.APIKeyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	created, err := akh.apiKeys.Create(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrInvalidExpiry) {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to create API key", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusCreated, created)
+}
+
+// ListAPIKeys lists service account keys
+// @Summary List API keys
+// @Description List service account keys newest first, without their secrets
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param include_revoked query bool false "Include revoked keys"
+// @Success 200 {object} models.APIResponse[models.APIKeyListResponse] "Keys"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/api-keys [get]
+func (akh *AdminAPIKeyHandler) ListAPIKeys(c *gin.Context) {
+	response, err := akh.apiKeys.List(c.Request.Context(), c.Query("include_revoked") == "true")
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to list API keys", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, response)
+}
+
+// GetAPIKey retrieves a service account key
+// @Summary Get an API key
+// @Description Get a service account key without its secret
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param key_id path string true "API key ID"
+// @Success 200 {object} models.APIResponse[models.APIKey] "Key"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Key not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/api-keys/{key_id} [get]
+func (akh *AdminAPIKeyHandler) GetAPIKey(c *gin.Context) {
+	keyID := c.Param("key_id")
+
+	key, err := akh.apiKeys.Get(c.Request.Context(), keyID)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get API key", map[string]interface{}{
+			"key_id": keyID,
+			"error":  err.Error(),
+		})
+		return
+	}
+	if key == nil {
+		respondAPIKeyNotFound(c, keyID)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, key)
+}
+
+// RotateAPIKey replaces a key's secret
+// @Summary Rotate an API key
+// @Description Issue a new key with the same name, scopes and expiry and return its secret. The old key keeps
+// @Description working for grace_period_seconds (default 0, i.e. it stops immediately) so clients can switch over.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param key_id path string true "API key ID"
+// @Param request body models.APIKeyRotateRequest false "Grace period for the old key"
+// @Success 201 {object} models.APIResponse[models.APIKeySecret] "Replacement key"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Key not found"
+// @Failure 409 {object} models.ErrorResponse "Key is revoked, expired or already rotated"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/api-keys/{key_id}/rotate [post]
+func (akh *AdminAPIKeyHandler) RotateAPIKey(c *gin.Context) {
+	keyID := c.Param("key_id")
+
+	var req models.APIKeyRotateRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+
+	rotated, err := akh.apiKeys.Rotate(c.Request.Context(), keyID, time.Duration(req.GracePeriodSeconds)*time.Second)
+	if errors.Is(err, service.ErrAPIKeyInactive) {
+		respondError(c, http.StatusConflict, "API_KEY_INACTIVE", "API key is revoked, expired or already rotated", map[string]interface{}{
+			"key_id": keyID,
+		})
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to rotate API key", map[string]interface{}{
+			"key_id": keyID,
+			"error":  err.Error(),
+		})
+		return
+	}
+	if rotated == nil {
+		respondAPIKeyNotFound(c, keyID)
+		return
+	}
+
+	respondSuccess(c, http.StatusCreated, rotated)
+}
+
+// RevokeAPIKey revokes a key
+// @Summary Revoke an API key
+// @Description Revoke a service account key. This instance rejects it immediately; other instances reject it
+// @Description once they reload their keys (API_KEY_RELOAD_INTERVAL).
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param key_id path string true "API key ID"
+// @Success 200 {object} models.APIResponse[models.APIKey] "Key revoked"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Key not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/api-keys/{key_id} [delete]
+func (akh *AdminAPIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	keyID := c.Param("key_id")
+
+	key, err := akh.apiKeys.Revoke(c.Request.Context(), keyID)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to revoke API key", map[string]interface{}{
+			"key_id": keyID,
+			"error":  err.Error(),
+		})
+		return
+	}
+	if key == nil {
+		respondAPIKeyNotFound(c, keyID)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, key)
+}
+
+// respondAPIKeyNotFound writes the response for a missing API key
+func respondAPIKeyNotFound(c *gin.Context, keyID string) {
+	respondError(c, http.StatusNotFound, "NOT_FOUND", "API key not found", map[string]interface{}{
+		"key_id": keyID,
+	})
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/auditlog"
+	"refo-rag-server/internal/models"
+)
+
+// AdminAuditHandler handles request audit log verification requests
+type AdminAuditHandler struct {
+	log *auditlog.WriterSink
+}
+
+// NewAdminAuditHandler creates a new admin audit handler for the audit log file a sink writes
+func NewAdminAuditHandler(log *auditlog.WriterSink) *AdminAuditHandler {
+	return &AdminAuditHandler{log: log}
+}
+
+// VerifyAuditLog checks the audit log's hash chain
+// @Summary Verify the audit log
+// @Description Re-compute the keyed hash chain of the request audit log file and report whether every record
+// @Description links to the one before it. An altered, removed or reordered record, or one hashed without the
+// @Description server's REQUEST_AUDIT_CHAIN_KEY, is reported with its line and sequence number. The chain must end
+// @Description at the head stored next to the log, so records removed from the end are reported too; compare
+// @Description head_sequence and head_hash with values logged earlier to detect the log and its head being
+// @Description replaced together. Only available when REQUEST_AUDIT_SINK is a file.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Success 200 {object} models.APIResponse[models.AuditVerification] "Verification result"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/audit/verify [get]
+func (aah *AdminAuditHandler) VerifyAuditLog(c *gin.Context) {
+	result, err := aah.log.Verify()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to verify audit log", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess[*models.AuditVerification](c, http.StatusOK, result)
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/cache"
+	"refo-rag-server/internal/models"
+)
+
+// AdminCacheHandler handles in-process cache inspection and flush requests
+type AdminCacheHandler struct {
+	caches []cache.Inspector
+}
+
+// NewAdminCacheHandler creates a new admin cache handler over the enabled caches
+func NewAdminCacheHandler(caches []cache.Inspector) *AdminCacheHandler {
+	return &AdminCacheHandler{
+		caches: caches,
+	}
+}
+
+// ListCaches reports the stats of the enabled caches
+// @Summary List cache stats
+// @Description Report each enabled in-process cache of this replica: the embedding cache in front of the embedding
+// @Description provider and the query cache of search results. Counters run since the process started: hits,
+// @Description misses and the hit ratio, the entries held against the capacity, and entries evicted to make room
+// @Description or because they expired. The same counters are exported on /metrics as rag_cache_*.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Success 200 {object} models.APIResponse[models.CacheStatsResponse] "Cache stats"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Router /api/rag/admin/caches [get]
+func (ach *AdminCacheHandler) ListCaches(c *gin.Context) {
+	stats := make([]models.CacheStats, 0, len(ach.caches))
+	for _, cached := range ach.caches {
+		stats = append(stats, cached.Stats())
+	}
+	respondSuccess(c, http.StatusOK, models.CacheStatsResponse{Caches: stats})
+}
+
+// FlushCache empties a cache
+// @Summary Flush a cache
+// @Description Drop every entry of an in-process cache on this replica, e.g. when searches serve results that are
+// @Description out of date. Flush each replica to clear a cache everywhere. Its counters keep running.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param name path string true "Cache name" Enums(embedding, query)
+// @Success 200 {object} models.APIResponse[models.CacheFlushResponse] "Cache flushed"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "No such cache, or it is disabled"
+// @Router /api/rag/admin/caches/{name}/flush [post]
+func (ach *AdminCacheHandler) FlushCache(c *gin.Context) {
+	name := c.Param("name")
+	for _, cached := range ach.caches {
+		if cached.Name() == name {
+			respondSuccess(c, http.StatusOK, models.CacheFlushResponse{Name: name, Flushed: cached.Flush()})
+			return
+		}
+	}
+
+	names := make([]string, 0, len(ach.caches))
+	for _, cached := range ach.caches {
+		names = append(names, cached.Name())
+	}
+	respondError(c, http.StatusNotFound, "CACHE_NOT_FOUND", "no enabled cache has this name", map[string]interface{}{
+		"name":           name,
+		"enabled_caches": names,
+	})
+}
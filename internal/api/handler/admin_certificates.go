@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// AdminCertificateHandler serves the signed certificates of executed purges
+type AdminCertificateHandler struct {
+	certificates *service.DeletionCertificates
+}
+
+// NewAdminCertificateHandler creates a new admin deletion certificate handler
+func NewAdminCertificateHandler(certificates *service.DeletionCertificates) *AdminCertificateHandler {
+	return &AdminCertificateHandler{
+		certificates: certificates,
+	}
+}
+
+// ListCertificates lists deletion certificates
+// @Summary List deletion certificates
+// @Description List the signed certificates issued after executed retention runs, user deletions and bulk deletions,
+// @Description newest first. Only available when DELETION_CERTIFICATE_KEY is set.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param kind query string false "Only certificates of this purge kind" Enums(retention, user_delete, conversation_bulk_delete)
+// @Param user_id query string false "Only certificates of purges limited to this user"
+// @Param limit query int false "Maximum number of certificates" default(50)
+// @Success 200 {object} models.APIResponse[models.DeletionCertificateListResponse] "Deletion certificates"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/deletion-certificates [get]
+func (ach *AdminCertificateHandler) ListCertificates(c *gin.Context) {
+	limit, _ := pagination(c, 50, 500)
+
+	certificates, err := ach.certificates.List(c.Request.Context(), c.Query("kind"), c.Query("user_id"), limit)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to list deletion certificates", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, models.DeletionCertificateListResponse{Certificates: certificates})
+}
+
+// GetCertificate retrieves a deletion certificate
+// @Summary Get a deletion certificate
+// @Description Get a signed deletion certificate: what was removed, when and under which policy. The signature is
+// @Description the Ed25519 signature of the certificate's JSON encoding without the signature field, verified
+// @Description with the key from /api/rag/admin/deletion-certificates/public-key.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param certificate_id path string true "Certificate ID"
+// @Success 200 {object} models.APIResponse[models.DeletionCertificate] "Deletion certificate"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Certificate not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/deletion-certificates/{certificate_id} [get]
+func (ach *AdminCertificateHandler) GetCertificate(c *gin.Context) {
+	certificateID := c.Param("certificate_id")
+
+	certificate, err := ach.certificates.Get(c.Request.Context(), certificateID)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get deletion certificate", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if certificate == nil {
+		respondError(c, http.StatusNotFound, "NOT_FOUND", "deletion certificate not found", map[string]interface{}{
+			"certificate_id": certificateID,
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, certificate)
+}
+
+// GetPublicKey returns the key deletion certificates are verified with
+// @Summary Get the deletion certificate public key
+// @Description Get the Ed25519 public key that verifies deletion certificates, for customers checking their
+// @Description certificates independently. Certificates name the key they were signed with in key_id.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Success 200 {object} models.APIResponse[models.DeletionCertificateKey] "Public key"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Router /api/rag/admin/deletion-certificates/public-key [get]
+func (ach *AdminCertificateHandler) GetPublicKey(c *gin.Context) {
+	respondSuccess(c, http.StatusOK, ach.certificates.PublicKey())
+}
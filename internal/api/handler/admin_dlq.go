@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// AdminDeadLetterHandler handles inspection and replay of dead-lettered work queue items
+type AdminDeadLetterHandler struct {
+	deadLetters *service.DeadLetterService
+}
+
+// NewAdminDeadLetterHandler creates a new admin dead letter handler
+func NewAdminDeadLetterHandler(deadLetters *service.DeadLetterService) *AdminDeadLetterHandler {
+	return &AdminDeadLetterHandler{
+		deadLetters: deadLetters,
+	}
+}
+
+// ListDeadLetters lists dead-lettered queue items
+// @Summary List dead letters
+// @Description List work queue items that failed on every attempt, newest first, with their payload and last error
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param kind query string false "Only items of this kind" Enums(conversation_vector)
+// @Param limit query int false "Maximum number of items" default(50)
+// @Param offset query int false "Number of items to skip" default(0)
+// @Success 200 {object} models.APIResponse[models.DeadLetterListResponse] "Dead letters"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/dlq [get]
+func (adh *AdminDeadLetterHandler) ListDeadLetters(c *gin.Context) {
+	limit, offset := pagination(c, 50, 500)
+
+	response, err := adh.deadLetters.List(c.Request.Context(), c.Query("kind"), limit, offset)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to list dead letters", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, response)
+}
+
+// GetDeadLetter retrieves a dead-lettered queue item
+// @Summary Get a dead letter
+// @Description Get a dead-lettered work queue item with its payload, attempt count and last error
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param id path int true "Dead letter ID"
+// @Success 200 {object} models.APIResponse[models.DeadLetter] "Dead letter"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Dead letter not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/dlq/{id} [get]
+func (adh *AdminDeadLetterHandler) GetDeadLetter(c *gin.Context) {
+	id, ok := deadLetterID(c)
+	if !ok {
+		return
+	}
+
+	deadLetter, err := adh.deadLetters.Get(c.Request.Context(), id)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get dead letter", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if deadLetter == nil {
+		respondDeadLetterNotFound(c, id)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, deadLetter)
+}
+
+// RetryDeadLetter puts a dead-lettered item back on the work queue
+// @Summary Retry a dead letter
+// @Description Move a dead-lettered item back to the work queue with its attempt count reset. It becomes
+// @Description available to workers immediately.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param id path int true "Dead letter ID"
+// @Success 200 {object} models.APIResponse[models.DeadLetterRequeueResponse] "Item requeued"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Dead letter not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/dlq/{id}/retry [post]
+func (adh *AdminDeadLetterHandler) RetryDeadLetter(c *gin.Context) {
+	id, ok := deadLetterID(c)
+	if !ok {
+		return
+	}
+
+	found, err := adh.deadLetters.Retry(c.Request.Context(), id)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to retry dead letter", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !found {
+		respondDeadLetterNotFound(c, id)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, models.DeadLetterRequeueResponse{RequeuedID: id})
+}
+
+// DiscardDeadLetter deletes a dead-lettered item
+// @Summary Discard a dead letter
+// @Description Delete a dead-lettered item without processing it
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param id path int true "Dead letter ID"
+// @Success 200 {object} models.APIResponse[models.DeadLetterDiscardResponse] "Item discarded"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Dead letter not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/dlq/{id} [delete]
+func (adh *AdminDeadLetterHandler) DiscardDeadLetter(c *gin.Context) {
+	id, ok := deadLetterID(c)
+	if !ok {
+		return
+	}
+
+	found, err := adh.deadLetters.Discard(c.Request.Context(), id)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to discard dead letter", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !found {
+		respondDeadLetterNotFound(c, id)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, models.DeadLetterDiscardResponse{DiscardedID: id})
+}
+
+// deadLetterID parses the id path parameter, writing a 400 response if it isn't a number
+func deadLetterID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "dead letter ID must be an integer", map[string]interface{}{
+			"id": c.Param("id"),
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// respondDeadLetterNotFound writes the response for a missing dead letter
+func respondDeadLetterNotFound(c *gin.Context, id int64) {
+	respondError(c, http.StatusNotFound, "NOT_FOUND", "dead letter not found", map[string]interface{}{
+		"id": id,
+	})
+}
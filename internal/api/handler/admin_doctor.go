@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// AdminDoctorHandler handles self-test requests
+type AdminDoctorHandler struct {
+	doctor *service.Doctor
+}
+
+// NewAdminDoctorHandler creates a new admin doctor handler
+func NewAdminDoctorHandler(doctor *service.Doctor) *AdminDoctorHandler {
+	return &AdminDoctorHandler{
+		doctor: doctor,
+	}
+}
+
+// RunDoctor self-tests the server's dependencies and bindings
+// @Summary Run the self-test
+// @Description Check connectivity to the database, Qdrant and the model providers, that no schema migration is pending, that
+// @Description every collection exists with its configured vector size and distance, and that every embedding model returns
+// @Description vectors of the dimension of the collections bound to it; then save a scratch vector under the user
+// @Description __doctor__, find it by search and delete it, unless round_trip is false. Checks depending on a failed one
+// @Description are skipped. The report is returned with status 200 whether or not every check passed.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param round_trip query bool false "Save, search and delete a scratch vector" default(true)
+// @Success 200 {object} models.APIResponse[models.DoctorReport] "Self-test report"
+// @Failure 400 {object} models.ErrorResponse "Invalid parameter"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Router /api/rag/admin/doctor [post]
+func (adh *AdminDoctorHandler) RunDoctor(c *gin.Context) {
+	req := models.DoctorRequest{RoundTrip: true}
+	if raw := c.Query("round_trip"); raw != "" {
+		roundTrip, err := strconv.ParseBool(raw)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "round_trip must be true or false", map[string]interface{}{
+				"field": "round_trip",
+			})
+			return
+		}
+		req.RoundTrip = roundTrip
+	}
+
+	respondSuccess(c, http.StatusOK, adh.doctor.Run(c.Request.Context(), req))
+}
+
+// GetMigrations reports the pending schema migrations
+// @Summary Show pending schema migrations
+// @Description Plan the schema migrations the server would apply to the configured database on start, without running
+// @Description them, as the server's -migrate-preflight flag does. On Postgres, statements that hold a heavy lock on a large
+// @Description table are listed as risks with the lock-friendly statement run instead, if any; blocking counts those
+// @Description the block guard would refuse.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Success 200 {object} models.APIResponse[models.MigrationStatus] "Migration plan"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Failure 503 {object} models.ErrorResponse "Database unavailable"
+// @Router /api/rag/admin/migrations [get]
+func (adh *AdminDoctorHandler) GetMigrations(c *gin.Context) {
+	status, err := adh.doctor.Migrations()
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to plan migrations", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, status)
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/envelope"
+	"refo-rag-server/internal/models"
+)
+
+// AdminEncryptionHandler handles conversation encryption key management requests
+type AdminEncryptionHandler struct {
+	cipher *envelope.Cipher
+}
+
+// NewAdminEncryptionHandler creates a new admin encryption handler
+func NewAdminEncryptionHandler(cipher *envelope.Cipher) *AdminEncryptionHandler {
+	return &AdminEncryptionHandler{cipher: cipher}
+}
+
+// ListDataKeys lists the tenant data keys
+// @Summary List encryption data keys
+// @Description List every tenant's data key versions and the master key each is wrapped by; the key material is
+// @Description never returned. Only available when conversation encryption is enabled.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Success 200 {object} models.APIResponse[models.DataKeyListResponse] "Data keys"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/encryption/keys [get]
+func (aeh *AdminEncryptionHandler) ListDataKeys(c *gin.Context) {
+	keys, err := aeh.cipher.Keys(c.Request.Context())
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to list data keys", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, keys)
+}
+
+// RotateDataKey starts a new data key version for a tenant
+// @Summary Rotate a tenant's data key
+// @Description Create a new data key version for a tenant. Conversations saved afterwards are encrypted with it;
+// @Description existing conversations stay readable with the older versions and are re-encrypted when saved again.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param request body models.DataKeyRotateRequest true "Tenant to rotate"
+// @Success 201 {object} models.APIResponse[models.DataKey] "New data key version"
+// @Failure 400 {object} models.ErrorResponse "Invalid request or tenant not in ENCRYPTION_TENANTS"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/encryption/keys/rotate [post]
+func (aeh *AdminEncryptionHandler) RotateDataKey(c *gin.Context) {
+	var req models.DataKeyRotateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	key, err := aeh.cipher.Rotate(c.Request.Context(), req.Tenant)
+	if errors.Is(err, envelope.ErrUnknownTenant) {
+		respondError(c, http.StatusBadRequest, "UNKNOWN_TENANT", err.Error(), map[string]interface{}{
+			"tenant": req.Tenant,
+		})
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to rotate data key", map[string]interface{}{
+			"tenant": req.Tenant,
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusCreated, key)
+}
+
+// RewrapDataKeys rewraps data keys under the current master key
+// @Summary Rewrap data keys
+// @Description Wrap every data key that is still wrapped by a retired master key with the current one (the first
+// @Description entry of ENCRYPTION_MASTER_KEYS). Run it after a master key rotation; once it succeeds the
+// @Description retired master keys can be removed from the configuration.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Success 200 {object} models.APIResponse[models.DataKeyRewrapResponse] "Rewrap result"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/encryption/rewrap [post]
+func (aeh *AdminEncryptionHandler) RewrapDataKeys(c *gin.Context) {
+	rewrapped, err := aeh.cipher.Rewrap(c.Request.Context())
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to rewrap data keys", map[string]interface{}{
+			"rewrapped": rewrapped,
+			"error":     err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, models.DataKeyRewrapResponse{Rewrapped: rewrapped, MasterKeyID: aeh.cipher.MasterKeyID()})
+}
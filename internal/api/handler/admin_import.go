@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/ingest"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// maxImportBodyBytes bounds the size of a platform export upload
+const maxImportBodyBytes = 256 << 20
+
+// AdminImportHandler handles conversation import requests
+type AdminImportHandler struct {
+	imports *service.ConversationImportService
+}
+
+// NewAdminImportHandler creates a new admin import handler
+func NewAdminImportHandler(imports *service.ConversationImportService) *AdminImportHandler {
+	return &AdminImportHandler{imports: imports}
+}
+
+// Import starts importing an assistant platform's conversation export
+// @Summary Import conversations from another assistant platform
+// @Description Read a chat export holding many users' sessions and save one conversation per user session in a
+// @Description background job, keeping the original users, sessions and timestamps. Supported formats are Dialogflow
+// @Description ES/CX interaction logs (format=dialogflow), generic chat webhook logs with one JSON message per line
+// @Description (format=webhook) and CSV transcripts with a header row (format=csv). The export is parsed before the
+// @Description job starts, so malformed input is rejected right away. Conversation IDs are derived from the platform's
+// @Description user and session IDs, so importing the same export again updates the imported conversations.
+// @Description Imported conversations are saved in the backfill lane, behind live saves when ingestion is shaped.
+// @Description Follow the job with GET /admin/jobs/{job_id}.
+// @Tags admin
+// @Accept json
+// @Accept plain
+// @Produce json
+// @Security AdminAPIKey
+// @Param format query string true "Export format" Enums(dialogflow, webhook, csv)
+// @Param user_id query string false "User of messages that don't name one"
+// @Param assistant_speakers query string false "Comma-separated speaker names stored with the assistant role"
+// @Param tz query string false "IANA time zone of timestamps without an offset" default(UTC)
+// @Success 202 {object} models.APIResponse[models.JobStartedResponse] "Job started"
+// @Failure 400 {object} models.ErrorResponse "Unsupported format or invalid export"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "An import is already running"
+// @Failure 413 {object} models.ErrorResponse "Export too large"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/conversations/import [post]
+func (aih *AdminImportHandler) Import(c *gin.Context) {
+	format := strings.ToLower(c.Query("format"))
+	importer, ok := ingest.LookupBulk(format)
+	if !ok {
+		respondError(c, http.StatusBadRequest, "UNSUPPORTED_FORMAT", "unsupported export format", map[string]interface{}{
+			"provided_format": format,
+			"valid_formats":   ingest.BulkFormats(),
+		})
+		return
+	}
+
+	opts := ingest.Options{
+		UserID:            c.Query("user_id"),
+		AssistantSpeakers: strings.Split(c.Query("assistant_speakers"), ","),
+	}
+	if tz := c.Query("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid time zone", map[string]interface{}{
+				"tz": tz,
+			})
+			return
+		}
+		opts.Location = loc
+	}
+
+	export, err := importer.Import(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBodyBytes), opts)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondError(c, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", "export is too large", map[string]interface{}{
+			"max_bytes": tooLarge.Limit,
+		})
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid export", map[string]interface{}{
+			"format": format,
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	jobID, err := aih.imports.Start(c.Request.Context(), format, export)
+	if errors.Is(err, service.ErrConversationImportRunning) {
+		respondError(c, http.StatusConflict, "JOB_RUNNING", "a conversation import is already running", nil)
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to start conversation import", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusAccepted, models.JobStartedResponse{JobID: jobID})
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// AdminIndexHandler handles vector index and database table health requests
+type AdminIndexHandler struct {
+	indexService     *service.IndexService
+	integrityService *service.IntegrityService
+	driftService     *service.DriftService
+}
+
+// NewAdminIndexHandler creates a new admin index handler
+func NewAdminIndexHandler(indexService *service.IndexService, integrityService *service.IntegrityService, driftService *service.DriftService) *AdminIndexHandler {
+	return &AdminIndexHandler{
+		indexService:     indexService,
+		integrityService: integrityService,
+		driftService:     driftService,
+	}
+}
+
+// IndexHealth reports collection index coverage, optimizer state and table sizes
+// @Summary Get index health
+// @Description Report each Qdrant collection's status, optimizer state, segment count, indexed-vector percentage
+// @Description and memory estimate, and each Postgres table's size, dead rows and index sizes. Warnings flag failed
+// @Description or stalled optimizers and collections that are mostly unindexed.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Success 200 {object} models.APIResponse[models.IndexHealthResponse] "Index health"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/index-health [get]
+func (aih *AdminIndexHandler) IndexHealth(c *gin.Context) {
+	health, err := aih.indexService.Health(c.Request.Context())
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get index health", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, health)
+}
+
+// Optimize starts a background job compacting the vector indexes and database tables
+// @Summary Optimize indexes
+// @Description Start a background job that triggers Qdrant's optimizers on every collection, merging segments,
+// @Description vacuuming deleted points and building missing indexes, and runs VACUUM ANALYZE on the Postgres
+// @Description tables. Run it after heavy delete or retention workloads. Track progress with GET /admin/jobs/{job_id}.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param target query string false "What to optimize" Enums(all, qdrant, postgres) default(all)
+// @Success 202 {object} models.APIResponse[models.JobStartedResponse] "Job started"
+// @Failure 400 {object} models.ErrorResponse "Invalid target"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "An optimization job is already running"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/index/optimize [post]
+func (aih *AdminIndexHandler) Optimize(c *gin.Context) {
+	target := c.DefaultQuery("target", "all")
+	if target != "all" && target != "qdrant" && target != "postgres" {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid target", map[string]interface{}{
+			"target":        target,
+			"valid_targets": []string{"all", "qdrant", "postgres"},
+		})
+		return
+	}
+
+	jobID, err := aih.indexService.StartOptimize(c.Request.Context(), target != "postgres", target != "qdrant")
+	if errors.Is(err, service.ErrOptimizeRunning) {
+		respondError(c, http.StatusConflict, "JOB_RUNNING", "an optimization job is already running", nil)
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to start optimization", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusAccepted, models.JobStartedResponse{JobID: jobID})
+}
+
+// VerifyIntegrity starts a background job comparing stored conversations with their vectors
+// @Summary Verify content integrity
+// @Description Start a background job that re-hashes the text each stored conversation embeds and compares it with
+// @Description the SHA-256 recorded when it was last embedded and the hash in its Qdrant payload. The job result
+// @Description reports conversations whose vector was embedded from other text (vector_drift), whose text changed
+// @Description since it was embedded (content_drift) or that have no vector (missing_vector). Reindex affected users
+// @Description to repair them. Track progress with GET /admin/jobs/{job_id}.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param user_id query string false "Only verify this user's conversations"
+// @Success 202 {object} models.APIResponse[models.JobStartedResponse] "Job started"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "A verification job is already running"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/integrity/verify [post]
+func (aih *AdminIndexHandler) VerifyIntegrity(c *gin.Context) {
+	jobID, err := aih.integrityService.StartVerify(c.Request.Context(), c.Query("user_id"))
+	if errors.Is(err, service.ErrVerifyRunning) {
+		respondError(c, http.StatusConflict, "JOB_RUNNING", "an integrity verification job is already running", nil)
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to start integrity verification", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusAccepted, models.JobStartedResponse{JobID: jobID})
+}
+
+// CheckDrift starts a background job measuring embedding drift
+// @Summary Check embedding drift
+// @Description Start a background job that re-embeds a random sample of stored conversations with the live embedding
+// @Description model and measures the cosine distance to their stored vectors. Conversations whose text changed since
+// @Description they were embedded are skipped, so a non-zero distance means the model's output changed, e.g. after a
+// @Description silent provider update. The job result reports the mean and maximum distance and whether the mean
+// @Description exceeded the alert threshold; both are also exported as metrics. Track progress with
+// @Description GET /admin/jobs/{job_id}.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Success 202 {object} models.APIResponse[models.JobStartedResponse] "Job started"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "A drift check is already running"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/embeddings/drift [post]
+func (aih *AdminIndexHandler) CheckDrift(c *gin.Context) {
+	jobID, err := aih.driftService.StartCheck(c.Request.Context())
+	if errors.Is(err, service.ErrDriftRunning) {
+		respondError(c, http.StatusConflict, "JOB_RUNNING", "an embedding drift check is already running", nil)
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to start embedding drift check", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusAccepted, models.JobStartedResponse{JobID: jobID})
+}
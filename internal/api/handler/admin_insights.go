@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// AdminInsightsHandler handles cross-user insights requests
+type AdminInsightsHandler struct {
+	insights *service.InsightsService
+}
+
+// NewAdminInsightsHandler creates a new admin insights handler
+func NewAdminInsightsHandler(insights *service.InsightsService) *AdminInsightsHandler {
+	return &AdminInsightsHandler{
+		insights: insights,
+	}
+}
+
+// GetInsights computes aggregate statistics across users
+// @Summary Get cross-user insights
+// @Description Sample recent unsuppressed conversations across users, at most 20 per user, cluster their vectors into
+// @Description common topics and assign each conversation to the nearest question category (INSIGHTS_QUESTION_CATEGORIES).
+// @Description Only topics and categories with at least INSIGHTS_K_ANONYMITY distinct users are reported, as counts and
+// @Description shares; smaller groups are withheld and only counted. With label, the chat model names each reported
+// @Description topic from conversations of different users; no conversation text, user or conversation ID is returned.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param days query int false "Days of conversations sampled, at most 365" default(30)
+// @Param max_conversations query int false "Conversations sampled, at most 10000" default(2000)
+// @Param clusters query int false "Number of topics before suppression, at most 30; picked from the sample size by default"
+// @Param label query bool false "Name the topics with the chat model" default(true)
+// @Success 200 {object} models.APIResponse[models.Insights] "Insights"
+// @Failure 400 {object} models.ErrorResponse "Invalid parameter"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 422 {object} models.ErrorResponse "Too few users in the sample for the anonymity threshold"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/insights [get]
+func (aih *AdminInsightsHandler) GetInsights(c *gin.Context) {
+	req := models.InsightsRequest{Label: true}
+	if n, err := strconv.Atoi(c.Query("days")); err == nil && n > 0 {
+		req.Days = min(n, service.MaxInsightsDays)
+	}
+	if n, err := strconv.Atoi(c.Query("max_conversations")); err == nil && n > 0 {
+		req.MaxConversations = min(n, service.MaxInsightsConversations)
+	}
+	if n, err := strconv.Atoi(c.Query("clusters")); err == nil && n > 0 {
+		req.Clusters = min(n, service.MaxInsightsClusters)
+	}
+	if raw := c.Query("label"); raw != "" {
+		label, err := strconv.ParseBool(raw)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "label must be true or false", map[string]interface{}{
+				"field": "label",
+			})
+			return
+		}
+		req.Label = label
+	}
+
+	insights, err := aih.insights.Insights(c.Request.Context(), req)
+	if errors.Is(err, service.ErrInsufficientUsers) {
+		respondError(c, http.StatusUnprocessableEntity, "INSUFFICIENT_USERS", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to compute insights", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, insights)
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// AdminJobHandler handles the job log and operator-triggered batch jobs
+type AdminJobHandler struct {
+	jobLog     *service.JobLog
+	forgetting *service.ForgettingService
+}
+
+// NewAdminJobHandler creates a new admin job handler
+func NewAdminJobHandler(jobLog *service.JobLog, forgetting *service.ForgettingService) *AdminJobHandler {
+	return &AdminJobHandler{
+		jobLog:     jobLog,
+		forgetting: forgetting,
+	}
+}
+
+// ListJobs lists recent administrative jobs
+// @Summary List admin jobs
+// @Description List the most recent administrative jobs, including dry runs, newest first
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param kind query string false "Only jobs of this kind" Enums(user_reindex, retention, user_delete, optimize, integrity_verify)
+// @Param limit query int false "Maximum number of jobs" default(50)
+// @Success 200 {object} models.APIResponse[models.JobListResponse] "Job list"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/jobs [get]
+func (ajh *AdminJobHandler) ListJobs(c *gin.Context) {
+	limit, _ := pagination(c, 50, 500)
+
+	jobs, err := ajh.jobLog.List(c.Request.Context(), c.Query("kind"), limit)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to list jobs", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, models.JobListResponse{Jobs: jobs})
+}
+
+// GetJob retrieves an administrative job
+// @Summary Get an admin job
+// @Description Get an administrative job with its scope, status and result
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param job_id path string true "Job ID"
+// @Success 200 {object} models.APIResponse[models.Job] "Job"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Job not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/jobs/{job_id} [get]
+func (ajh *AdminJobHandler) GetJob(c *gin.Context) {
+	jobID := c.Param("job_id")
+
+	job, err := ajh.jobLog.Get(c.Request.Context(), jobID)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get job", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if job == nil {
+		respondError(c, http.StatusNotFound, "NOT_FOUND", "job not found", map[string]interface{}{
+			"job_id": jobID,
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, job)
+}
+
+// RunRetention applies the retention policy
+// @Summary Run the retention policy
+// @Description Delete every unpinned conversation whose decayed importance is below the forgetting threshold.
+// @Description Deletion takes two calls: without confirmation_token the response lists what would be deleted
+// @Description and returns a token (202), which must be sent back before it expires to execute the run. A token
+// @Description is rejected if the number of affected conversations changed in between. With dry_run=true only
+// @Description the preview is returned. Every run is recorded in the job log.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param dry_run query bool false "Report what would be affected without executing"
+// @Param confirmation_token query string false "Token returned by the first call"
+// @Success 200 {object} models.APIResponse[models.RetentionRunResponse] "Retention result"
+// @Success 202 {object} models.APIResponse[models.RetentionRunResponse] "Confirmation required"
+// @Failure 400 {object} models.ErrorResponse "Invalid confirmation token"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "Scope changed since the token was issued"
+// @Failure 410 {object} models.ErrorResponse "Confirmation token expired"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/retention/run [post]
+func (ajh *AdminJobHandler) RunRetention(c *gin.Context) {
+	ctx := c.Request.Context()
+	token := c.Query("confirmation_token")
+
+	var result *models.RetentionRunResponse
+	var err error
+	switch {
+	case c.Query("dry_run") == "true":
+		result, err = ajh.forgetting.Run(ctx, true)
+	case token == "":
+		result, err = ajh.forgetting.RequestRun(ctx)
+	default:
+		result, err = ajh.forgetting.ConfirmRun(ctx, token)
+	}
+	if respondConfirmationError(c, err) {
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to run retention", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if result.Confirmation != nil {
+		respondSuccess(c, http.StatusAccepted, result)
+		return
+	}
+	respondSuccess(c, http.StatusOK, result)
+}
+
+// respondConfirmationError writes the response for a rejected confirmation token and reports whether it did
+func respondConfirmationError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrConfirmationInvalid):
+		respondError(c, http.StatusBadRequest, "INVALID_CONFIRMATION", err.Error(), nil)
+	case errors.Is(err, service.ErrConfirmationExpired):
+		respondError(c, http.StatusGone, "CONFIRMATION_EXPIRED", err.Error(), nil)
+	case errors.Is(err, service.ErrScopeChanged):
+		respondError(c, http.StatusConflict, "SCOPE_CHANGED", err.Error(), nil)
+	default:
+		return false
+	}
+	return true
+}
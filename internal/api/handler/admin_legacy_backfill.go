@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// AdminLegacyBackfillHandler handles legacy conversation backfill requests
+type AdminLegacyBackfillHandler struct {
+	backfill *service.LegacyBackfillService
+}
+
+// NewAdminLegacyBackfillHandler creates a new admin legacy backfill handler
+func NewAdminLegacyBackfillHandler(backfill *service.LegacyBackfillService) *AdminLegacyBackfillHandler {
+	return &AdminLegacyBackfillHandler{backfill: backfill}
+}
+
+// Backfill starts upgrading legacy conversations
+// @Summary Backfill legacy conversations
+// @Description Start a background job that upgrades conversations saved before messages and user IDs were stored.
+// @Description Their concatenated question is split into turns at speaker labels at the start of a line, such as
+// @Description "User:", "Assistant:", "Q:" or "A:"; without labels it is kept as one user message, followed by the
+// @Description answer. Conversations without a user ID get the user named by their metadata (user_id, userId or
+// @Description user), else the owner of their session, else default_user_id. Upgraded conversations are re-embedded.
+// @Description A dry run changes nothing and reports the counts with a few sample conversations. The job result is
+// @Description updated after every batch; follow it with GET /admin/jobs/{job_id}. A job that failed or was
+// @Description interrupted by a restart can be continued with resume_job_id instead of starting over.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param request body models.LegacyBackfillRequest false "Backfill options"
+// @Success 202 {object} models.APIResponse[models.JobStartedResponse] "Job started"
+// @Failure 400 {object} models.ErrorResponse "Invalid request or job can't be resumed"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "A legacy backfill is already running"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/conversations/legacy-backfill [post]
+func (alh *AdminLegacyBackfillHandler) Backfill(c *gin.Context) {
+	var req models.LegacyBackfillRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+
+	jobID, err := alh.backfill.Start(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrLegacyBackfillRunning) {
+		respondError(c, http.StatusConflict, "JOB_RUNNING", "a legacy backfill is already running", nil)
+		return
+	}
+	if errors.Is(err, service.ErrResumeJobInvalid) {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "the job can't be resumed", map[string]interface{}{
+			"resume_job_id": req.ResumeJobID,
+			"error":         err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to start legacy backfill", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusAccepted, models.JobStartedResponse{JobID: jobID})
+}
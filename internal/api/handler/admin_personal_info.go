@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// AdminPersonalInfoHandler handles personal info maintenance requests
+type AdminPersonalInfoHandler struct {
+	reindex *service.PersonalInfoReindexService
+}
+
+// NewAdminPersonalInfoHandler creates a new admin personal info handler
+func NewAdminPersonalInfoHandler(reindex *service.PersonalInfoReindexService) *AdminPersonalInfoHandler {
+	return &AdminPersonalInfoHandler{reindex: reindex}
+}
+
+// Reindex starts re-embedding every personal info entry
+// @Summary Re-embed all personal information
+// @Description Start a background job that re-embeds every user's personal info entries and replaces their vectors,
+// @Description e.g. after changing how entries are turned into embedding text. Entries are processed in ID order and
+// @Description the job result is updated after every batch with the counts so far and the last entry processed;
+// @Description follow it with GET /admin/jobs/{job_id}. A job that failed or was interrupted by a restart can be
+// @Description continued with resume_job_id instead of starting over.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param request body models.PersonalInfoReindexRequest false "Job to resume"
+// @Success 202 {object} models.APIResponse[models.JobStartedResponse] "Job started"
+// @Failure 400 {object} models.ErrorResponse "Invalid request or job can't be resumed"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "A personal info reindex is already running"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/personal-info/reindex [post]
+func (aph *AdminPersonalInfoHandler) Reindex(c *gin.Context) {
+	var req models.PersonalInfoReindexRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+
+	jobID, err := aph.reindex.Start(c.Request.Context(), req.ResumeJobID)
+	if errors.Is(err, service.ErrPersonalInfoReindexRunning) {
+		respondError(c, http.StatusConflict, "JOB_RUNNING", "a personal info reindex is already running", nil)
+		return
+	}
+	if errors.Is(err, service.ErrResumeJobInvalid) {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "the job can't be resumed", map[string]interface{}{
+			"resume_job_id": req.ResumeJobID,
+			"error":         err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to start personal info reindex", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusAccepted, models.JobStartedResponse{JobID: jobID})
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// AdminProjectionHandler handles embedding dimensionality reduction requests
+type AdminProjectionHandler struct {
+	projections *service.EmbeddingProjections
+}
+
+// NewAdminProjectionHandler creates a new admin embedding projection handler
+func NewAdminProjectionHandler(projections *service.EmbeddingProjections) *AdminProjectionHandler {
+	return &AdminProjectionHandler{
+		projections: projections,
+	}
+}
+
+// ListProjections lists the embedding projections
+// @Summary List embedding projections
+// @Description List the dimensionality reduction applied to each embedding model's vectors before they are stored
+// @Description or searched: truncation to the leading components, or a trained PCA matrix with the share of variance
+// @Description it keeps and when it was trained and loaded. Models without a projection aren't listed.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Success 200 {object} models.APIResponse[models.EmbeddingProjectionListResponse] "Embedding projections"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Router /api/rag/admin/embeddings/projections [get]
+func (aph *AdminProjectionHandler) ListProjections(c *gin.Context) {
+	respondSuccess(c, http.StatusOK, models.EmbeddingProjectionListResponse{Projections: aph.projections.List()})
+}
+
+// ReloadProjections reloads the PCA matrices from their files
+// @Summary Reload embedding projections
+// @Description Read every configured PCA matrix from its file again, e.g. after copying a retrained matrix over it. A
+// @Description matrix must keep the dimensions of the one it replaces; if one fails to load, it and the rest keep
+// @Description their current matrix. Stored vectors projected with the previous matrix don't match queries projected
+// @Description with the new one, so reindex the model's collections afterwards.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Success 200 {object} models.APIResponse[models.EmbeddingProjectionListResponse] "Reloaded projections"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "A matrix failed to load"
+// @Router /api/rag/admin/embeddings/projections/reload [post]
+func (aph *AdminProjectionHandler) ReloadProjections(c *gin.Context) {
+	projections, err := aph.projections.Reload()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to reload embedding projections", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, models.EmbeddingProjectionListResponse{Projections: projections})
+}
+
+// TrainProjection starts a background job training a PCA matrix
+// @Summary Train an embedding projection
+// @Description Start a background job that embeds a random sample of stored conversations with the full model, fits
+// @Description a PCA matrix reducing its vectors to the requested dimension, and writes it to a new file under
+// @Description EMBEDDING_PROJECTION_DIR. The job result reports the file and the share of variance the matrix keeps.
+// @Description Nothing changes until the file is configured in EMBEDDING_PROJECTIONS, or copied over the loaded
+// @Description one and reloaded, and the model's collections are reindexed. Track progress with
+// @Description GET /admin/jobs/{job_id}.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param request body models.EmbeddingProjectionTrainRequest true "Model, dimension and sample size"
+// @Success 202 {object} models.APIResponse[models.JobStartedResponse] "Job started"
+// @Failure 400 {object} models.ErrorResponse "Invalid request or unknown model"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "A projection is already being trained"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/embeddings/projections/train [post]
+func (aph *AdminProjectionHandler) TrainProjection(c *gin.Context) {
+	var req models.EmbeddingProjectionTrainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	jobID, err := aph.projections.StartTraining(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrUnknownEmbeddingModel) {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error(), nil)
+		return
+	}
+	if errors.Is(err, service.ErrProjectionTraining) {
+		respondError(c, http.StatusConflict, "JOB_RUNNING", "an embedding projection is already being trained", nil)
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to start embedding projection training", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusAccepted, models.JobStartedResponse{JobID: jobID})
+}
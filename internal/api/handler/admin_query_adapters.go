@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// AdminQueryAdapterHandler handles per-user query adapter requests
+type AdminQueryAdapterHandler struct {
+	adapters *service.QueryAdapters
+}
+
+// NewAdminQueryAdapterHandler creates a new admin query adapter handler
+func NewAdminQueryAdapterHandler(adapters *service.QueryAdapters) *AdminQueryAdapterHandler {
+	return &AdminQueryAdapterHandler{
+		adapters: adapters,
+	}
+}
+
+// ListQueryAdapters lists a user's query adapters
+// @Summary List a user's query adapters
+// @Description List the adapters applied to a user's query embeddings, one per embedding model, without their
+// @Description vectors. Only available when QUERY_ADAPTERS is enabled.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param user_id path string true "User ID"
+// @Success 200 {object} models.APIResponse[models.QueryAdapterListResponse] "Query adapters"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/users/{user_id}/query-adapters [get]
+func (aqh *AdminQueryAdapterHandler) ListQueryAdapters(c *gin.Context) {
+	adapters, err := aqh.adapters.List(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to list query adapters", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, adapters)
+}
+
+// UploadQueryAdapter uploads a user's query adapter
+// @Summary Upload a user's query adapter
+// @Description Store an adapter trained offline from the user's search feedback, replacing their adapter for the
+// @Description model. Each of the user's query vectors q is replaced by scale*q + bias, component by component and
+// @Description scaled back to unit length, before conversations and personal info embedded with the model are
+// @Description searched. The bias, and the optional scale, have the dimension of the model's stored vectors after any
+// @Description projection; the bias can't be longer than 1 and scale components must be positive. Other replicas
+// @Description apply the adapter within QUERY_ADAPTER_CACHE_TTL.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param user_id path string true "User ID"
+// @Param request body models.QueryAdapterUploadRequest true "Model and adapter vectors"
+// @Success 200 {object} models.APIResponse[models.QueryAdapterSummary] "Stored adapter"
+// @Failure 400 {object} models.ErrorResponse "Invalid request, unknown model or adapter not matching the model"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/users/{user_id}/query-adapters [put]
+func (aqh *AdminQueryAdapterHandler) UploadQueryAdapter(c *gin.Context) {
+	var req models.QueryAdapterUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	summary, err := aqh.adapters.Upload(c.Request.Context(), c.Param("user_id"), &req)
+	if errors.Is(err, service.ErrUnknownEmbeddingModel) || errors.Is(err, service.ErrInvalidQueryAdapter) {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to save query adapter", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, summary)
+}
+
+// DeleteQueryAdapter deletes a user's query adapter
+// @Summary Delete a user's query adapter
+// @Description Delete a user's adapter for a model, so their queries are searched unadapted. Other replicas stop
+// @Description applying it within QUERY_ADAPTER_CACHE_TTL.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param user_id path string true "User ID"
+// @Param model query string false "Embedding model; defaults to the conversations collection's model"
+// @Success 200 {object} models.APIResponse[models.QueryAdapterDeleteResponse] "Deleted adapter"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "The user has no adapter for the model"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/users/{user_id}/query-adapters [delete]
+func (aqh *AdminQueryAdapterHandler) DeleteQueryAdapter(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	deleted, err := aqh.adapters.Delete(c.Request.Context(), userID, c.Query("model"))
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to delete query adapter", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if deleted == nil {
+		respondError(c, http.StatusNotFound, "NOT_FOUND", "query adapter not found", map[string]interface{}{
+			"user_id": userID,
+			"model":   c.Query("model"),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, deleted)
+}
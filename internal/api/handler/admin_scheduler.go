@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/coord"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/schedule"
+)
+
+// AdminSchedulerHandler handles scheduled background task requests
+type AdminSchedulerHandler struct {
+	scheduler *schedule.Scheduler
+	elector   *coord.Elector
+}
+
+// NewAdminSchedulerHandler creates a new admin scheduler handler
+func NewAdminSchedulerHandler(scheduler *schedule.Scheduler, elector *coord.Elector) *AdminSchedulerHandler {
+	return &AdminSchedulerHandler{scheduler: scheduler, elector: elector}
+}
+
+// ListTasks lists the scheduled tasks and their last runs
+// @Summary List scheduled tasks
+// @Description List the background tasks of the scheduler with their cron schedule, whether they are enabled, the
+// @Description next and last run times, the last outcome and error, and run, failure and skip counts. Scheduled
+// @Description runs happen on the leader replica only, so ask the leader for the runs; leader tells whether this
+// @Description replica is it. A run still going when the next one is due is skipped, not doubled.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Success 200 {object} models.APIResponse[models.ScheduledTasksResponse] "Scheduled tasks"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Router /api/rag/admin/scheduler/tasks [get]
+func (ash *AdminSchedulerHandler) ListTasks(c *gin.Context) {
+	respondSuccess(c, http.StatusOK, models.ScheduledTasksResponse{
+		Leader: ash.elector.IsLeader(),
+		Tasks:  ash.scheduler.Status(),
+	})
+}
+
+// RunTask starts a scheduled task now
+// @Summary Run a scheduled task now
+// @Description Start an enabled scheduled task on this replica without waiting for its schedule. The run is
+// @Description reported by GET /admin/scheduler/tasks like a scheduled one.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param name path string true "Task name"
+// @Success 202 {object} models.APIResponse[models.ScheduledTaskStatus] "Run started"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "No such task"
+// @Failure 409 {object} models.ErrorResponse "The task is disabled or already running"
+// @Router /api/rag/admin/scheduler/tasks/{name}/run [post]
+func (ash *AdminSchedulerHandler) RunTask(c *gin.Context) {
+	name := c.Param("name")
+	err := ash.scheduler.RunNow(name)
+	switch {
+	case errors.Is(err, schedule.ErrUnknownTask):
+		respondError(c, http.StatusNotFound, "NOT_FOUND", "no such scheduled task", map[string]interface{}{
+			"name": name,
+		})
+		return
+	case errors.Is(err, schedule.ErrTaskDisabled):
+		respondError(c, http.StatusConflict, "TASK_DISABLED", "the scheduled task is disabled", map[string]interface{}{
+			"name": name,
+		})
+		return
+	case errors.Is(err, schedule.ErrTaskRunning):
+		respondError(c, http.StatusConflict, "JOB_RUNNING", "the scheduled task is already running", map[string]interface{}{
+			"name": name,
+		})
+		return
+	}
+
+	for _, task := range ash.scheduler.Status() {
+		if task.Name == name {
+			respondSuccess(c, http.StatusAccepted, task)
+			return
+		}
+	}
+}
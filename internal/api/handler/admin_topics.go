@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// AdminTopicHandler handles topic map requests
+type AdminTopicHandler struct {
+	topics *service.TopicMapService
+}
+
+// NewAdminTopicHandler creates a new admin topic map handler
+func NewAdminTopicHandler(topics *service.TopicMapService) *AdminTopicHandler {
+	return &AdminTopicHandler{
+		topics: topics,
+	}
+}
+
+// GetTopicMap builds a topic map of a user's conversations
+// @Summary Get a user's topic map
+// @Description Cluster the stored vectors of a user's most recent conversations with k-means on cosine similarity
+// @Description and describe each topic by its size, time span, cohesion and the conversations closest to its center.
+// @Description With label, the chat model names and summarizes each topic from those conversations; if it fails the
+// @Description map is returned unlabeled. Suppressed conversations and conversations without a vector are left out.
+// @Description The map is computed on every request; the same conversations always give the same topics.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param user_id path string true "User ID"
+// @Param clusters query int false "Number of topics, at most 30; picked from the number of conversations by default"
+// @Param representatives query int false "Conversations listed per topic, at most 10" default(3)
+// @Param max_conversations query int false "Most recent conversations clustered, at most 5000" default(1000)
+// @Param label query bool false "Name the topics with the chat model" default(true)
+// @Success 200 {object} models.APIResponse[models.TopicMap] "Topic map"
+// @Failure 400 {object} models.ErrorResponse "Invalid parameter"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "No embedded conversations for the user"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/users/{user_id}/topic-map [get]
+func (ath *AdminTopicHandler) GetTopicMap(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	opts := models.TopicMapRequest{Label: true}
+	if n, err := strconv.Atoi(c.Query("clusters")); err == nil && n > 0 {
+		opts.Clusters = min(n, service.MaxTopicMapClusters)
+	}
+	if n, err := strconv.Atoi(c.Query("representatives")); err == nil && n > 0 {
+		opts.Representatives = min(n, service.MaxTopicMapRepresentatives)
+	}
+	if n, err := strconv.Atoi(c.Query("max_conversations")); err == nil && n > 0 {
+		opts.MaxConversations = min(n, service.MaxTopicMapConversations)
+	}
+	if raw := c.Query("label"); raw != "" {
+		label, err := strconv.ParseBool(raw)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "label must be true or false", map[string]interface{}{
+				"field": "label",
+			})
+			return
+		}
+		opts.Label = label
+	}
+
+	topicMap, err := ath.topics.TopicMap(c.Request.Context(), userID, opts)
+	if errors.Is(err, service.ErrTopicMapEmpty) {
+		respondError(c, http.StatusNotFound, "NOT_FOUND", "no embedded conversations for user", map[string]interface{}{
+			"user_id": userID,
+		})
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to build topic map", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, topicMap)
+}
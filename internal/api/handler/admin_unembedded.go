@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+	"refo-rag-server/internal/storage"
+)
+
+// AdminUnembeddedHandler handles conversations stored without a vector because the embedding
+// provider refused their text
+type AdminUnembeddedHandler struct {
+	conversationService *service.ConversationService
+}
+
+// NewAdminUnembeddedHandler creates a new admin unembedded conversation handler
+func NewAdminUnembeddedHandler(conversationService *service.ConversationService) *AdminUnembeddedHandler {
+	return &AdminUnembeddedHandler{
+		conversationService: conversationService,
+	}
+}
+
+// ListUnembedded lists conversations the embedding provider refused
+// @Summary List unembedded conversations
+// @Description List conversations stored without a vector because the embedding provider refused their text for
+// @Description a content policy or its length, most recently refused first. They are kept but can't be found by
+// @Description search until their embedding is retried.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param user_id query string false "Only conversations of this user"
+// @Param limit query int false "Maximum number of conversations" default(50)
+// @Param offset query int false "Number of conversations to skip" default(0)
+// @Success 200 {object} models.APIResponse[models.UnembeddedListResponse] "Unembedded conversations"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/conversations/unembedded [get]
+func (auh *AdminUnembeddedHandler) ListUnembedded(c *gin.Context) {
+	limit, offset := pagination(c, 50, 500)
+
+	response, err := auh.conversationService.ListUnembedded(c.Request.Context(), c.Query("user_id"), limit, offset)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to list unembedded conversations", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, response)
+}
+
+// RetryEmbedding embeds a refused conversation again
+// @Summary Retry embedding a conversation
+// @Description Embed a conversation the embedding provider refused and write its vector. Messages in the body
+// @Description replace the stored ones first, so offending content can be removed or shortened. If the provider
+// @Description refuses the text again, the response is 422 EMBEDDING_REJECTED and the conversation stays listed,
+// @Description with any corrected messages kept.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param conversation_id path string true "Conversation ID"
+// @Param request body models.UnembeddedRetryRequest false "Corrected messages"
+// @Success 200 {object} models.APIResponse[models.SaveResponse] "Conversation embedded"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Conversation not found"
+// @Failure 409 {object} models.ErrorResponse "Conversation was not refused"
+// @Failure 422 {object} models.ErrorResponse "Embedding provider refused the text again"
+// @Failure 429 {object} models.ErrorResponse "Embedding budget spent"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/conversations/{conversation_id}/retry-embedding [post]
+func (auh *AdminUnembeddedHandler) RetryEmbedding(c *gin.Context) {
+	conversationID := c.Param("conversation_id")
+
+	var req models.UnembeddedRetryRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+	for i, msg := range req.Messages {
+		if msg.Content == "" || !models.IsValidRole(msg.Role) {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid message", map[string]interface{}{
+				"message_index": i,
+				"reason":        "message content is required and role must be valid",
+				"valid_roles":   models.ValidRoles,
+			})
+			return
+		}
+	}
+
+	saved, err := auh.conversationService.RetryEmbedding(c.Request.Context(), conversationID, req.Messages)
+	if errors.Is(err, service.ErrNotUnembedded) {
+		respondError(c, http.StatusConflict, "NOT_UNEMBEDDED", err.Error(), map[string]interface{}{
+			"conversation_id": conversationID,
+		})
+		return
+	}
+	var rejected *storage.EmbeddingRejectedError
+	if errors.As(err, &rejected) {
+		respondError(c, http.StatusUnprocessableEntity, "EMBEDDING_REJECTED", "the embedding provider refused the conversation's text", map[string]interface{}{
+			"conversation_id": conversationID,
+			"reason":          rejected.Reason,
+			"detail":          rejected.Detail,
+		})
+		return
+	}
+	if respondBudgetExceeded(c, err) {
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to retry embedding", map[string]interface{}{
+			"conversation_id": conversationID,
+			"error":           err.Error(),
+		})
+		return
+	}
+	if saved == nil {
+		respondError(c, http.StatusNotFound, "NOT_FOUND", "conversation not found", map[string]interface{}{
+			"conversation_id": conversationID,
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, saved)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// AdminUsageHandler handles embedding usage reports
+type AdminUsageHandler struct {
+	usage *service.UsageService
+}
+
+// NewAdminUsageHandler creates a new admin usage handler
+func NewAdminUsageHandler(usage *service.UsageService) *AdminUsageHandler {
+	return &AdminUsageHandler{
+		usage: usage,
+	}
+}
+
+// GetUsage reports daily embedding token usage
+// @Summary Get embedding usage
+// @Description Get the embedding requests and tokens billed per UTC day, tenant and model, with totals over the range. Usage is flushed to the database periodically, so the last minute may be missing
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param from query string false "First day (YYYY-MM-DD); defaults to 29 days before to"
+// @Param to query string false "Last day (YYYY-MM-DD); defaults to today"
+// @Param tenant query string false "Only usage of this tenant"
+// @Success 200 {object} models.APIResponse[models.UsageReport] "Usage report"
+// @Failure 400 {object} models.ErrorResponse "Invalid date"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/usage [get]
+func (auh *AdminUsageHandler) GetUsage(c *gin.Context) {
+	report, err := auh.usage.Report(c.Request.Context(), c.Query("from"), c.Query("to"), c.Query("tenant"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDate) {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error(), nil)
+			return
+		}
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get usage", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess[*models.UsageReport](c, http.StatusOK, report)
+}
@@ -0,0 +1,339 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// AdminUserHandler handles operator-facing user registry and per-user maintenance requests
+type AdminUserHandler struct {
+	reindexService      *service.ReindexService
+	userDeletionService *service.UserDeletionService
+	userService         *service.UserService
+}
+
+// NewAdminUserHandler creates a new admin user handler
+func NewAdminUserHandler(reindexService *service.ReindexService, userDeletionService *service.UserDeletionService, userService *service.UserService) *AdminUserHandler {
+	return &AdminUserHandler{
+		reindexService:      reindexService,
+		userDeletionService: userDeletionService,
+		userService:         userService,
+	}
+}
+
+// CreateUser registers an end user
+// @Summary Register a user
+// @Description Register an end user with a display name, retention exemption and home region. Users don't have to be
+// @Description registered to store data: an unregistered user_id is treated as active and follows its tenant's region.
+// @Description With data residency on, requests for a user homed in another region fail with 421 WRONG_REGION.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param request body models.UserCreateRequest true "User to register"
+// @Success 201 {object} models.APIResponse[models.User] "User registered"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "User already registered"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/users [post]
+func (auh *AdminUserHandler) CreateUser(c *gin.Context) {
+	var req models.UserCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	user, err := auh.userService.Create(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrUserExists) {
+		respondError(c, http.StatusConflict, "USER_EXISTS", "user already registered", map[string]interface{}{
+			"user_id": req.UserID,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrUnknownRegion) {
+		respondError(c, http.StatusBadRequest, "UNKNOWN_REGION", "region is not in the region map", map[string]interface{}{
+			"region": req.Region,
+		})
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to register user", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusCreated, user)
+}
+
+// ListUsers lists registered users
+// @Summary List users
+// @Description List registered end users ordered by user ID
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param status query string false "Only users with this status" Enums(active, disabled)
+// @Param limit query int false "Maximum number of users" default(50)
+// @Param offset query int false "Number of users to skip" default(0)
+// @Success 200 {object} models.APIResponse[models.UserListResponse] "Users"
+// @Failure 400 {object} models.ErrorResponse "Invalid status"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/users [get]
+func (auh *AdminUserHandler) ListUsers(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && status != models.UserStatusActive && status != models.UserStatusDisabled {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "status must be active or disabled", map[string]interface{}{
+			"status": status,
+		})
+		return
+	}
+	limit, offset := pagination(c, 50, 500)
+
+	response, err := auh.userService.List(c.Request.Context(), status, limit, offset)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to list users", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, response)
+}
+
+// GetUser retrieves a registered user
+// @Summary Get a user
+// @Description Get a registered end user
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param user_id path string true "User ID"
+// @Success 200 {object} models.APIResponse[models.User] "User"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "User not registered"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/users/{user_id} [get]
+func (auh *AdminUserHandler) GetUser(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	user, err := auh.userService.Get(c.Request.Context(), userID)
+	auh.respondUser(c, userID, user, err, "failed to get user")
+}
+
+// UpdateUser updates a registered user
+// @Summary Update a user
+// @Description Change a registered user's display name, retention exemption or home region; omitted fields are
+// @Description unchanged. Changing the region doesn't move stored data, which has to be exported and imported.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param user_id path string true "User ID"
+// @Param request body models.UserUpdateRequest true "Fields to change"
+// @Success 200 {object} models.APIResponse[models.User] "User updated"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "User not registered"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/users/{user_id} [patch]
+func (auh *AdminUserHandler) UpdateUser(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	var req models.UserUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	user, err := auh.userService.Update(c.Request.Context(), userID, &req)
+	if errors.Is(err, service.ErrUnknownRegion) {
+		respondError(c, http.StatusBadRequest, "UNKNOWN_REGION", "region is not in the region map", map[string]interface{}{
+			"region": *req.Region,
+		})
+		return
+	}
+	auh.respondUser(c, userID, user, err, "failed to update user")
+}
+
+// DisableUser disables a user
+// @Summary Disable a user
+// @Description Disable an end user, registering it if needed. Saving conversations or creating personal info for a
+// @Description disabled user fails with 403 USER_DISABLED; stored data stays searchable until it is deleted.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param user_id path string true "User ID"
+// @Param request body models.UserDisableRequest false "Reason for disabling"
+// @Success 200 {object} models.APIResponse[models.User] "User disabled"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/users/{user_id}/disable [post]
+func (auh *AdminUserHandler) DisableUser(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	var req models.UserDisableRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+
+	user, err := auh.userService.Disable(c.Request.Context(), userID, req.Reason)
+	auh.respondUser(c, userID, user, err, "failed to disable user")
+}
+
+// EnableUser re-enables a disabled user
+// @Summary Enable a user
+// @Description Lift a user's disablement so new conversations and personal info are accepted again
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param user_id path string true "User ID"
+// @Success 200 {object} models.APIResponse[models.User] "User enabled"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "User not registered"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/users/{user_id}/enable [post]
+func (auh *AdminUserHandler) EnableUser(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	user, err := auh.userService.Enable(c.Request.Context(), userID)
+	auh.respondUser(c, userID, user, err, "failed to enable user")
+}
+
+// respondUser writes a user lookup result: 500 on error, 404 if the user isn't registered
+func (auh *AdminUserHandler) respondUser(c *gin.Context, userID string, user *models.User, err error, failure string) {
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", failure, map[string]interface{}{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if user == nil {
+		respondError(c, http.StatusNotFound, "NOT_FOUND", "user not registered", map[string]interface{}{
+			"user_id": userID,
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, user)
+}
+
+// ReindexUser purges and rebuilds a user's vectors
+// @Summary Rebuild a user's vectors
+// @Description Delete all of a user's points from the conversation and personal info collections and re-embed
+// @Description their stored conversations and personal info. Use it to repair a user after a partial failure or
+// @Description an embedding model change without reindexing everything. With dry_run=true nothing is changed and
+// @Description the response lists the vectors that would be deleted and the records that would be re-embedded.
+// @Description Every run is recorded in the job log.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param user_id path string true "User ID"
+// @Param dry_run query bool false "Report what would be affected without executing"
+// @Success 200 {object} models.APIResponse[models.UserReindexResponse] "Reindex result"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/users/{user_id}/reindex [post]
+func (auh *AdminUserHandler) ReindexUser(c *gin.Context) {
+	userID := c.Param("user_id")
+	dryRun := c.Query("dry_run") == "true"
+
+	result, err := auh.reindexService.ReindexUser(c.Request.Context(), userID, dryRun)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to reindex user", map[string]interface{}{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, result)
+}
+
+// DeleteUser deletes all of a user's data after confirmation
+// @Summary Delete a user's data
+// @Description Delete a user's conversations, messages, personal info, sessions, profile, registration and vectors. Deletion takes
+// @Description two calls: without confirmation_token the response lists what would be deleted and returns a token
+// @Description (202), which must be sent back before it expires to execute the deletion. A token only deletes the
+// @Description user it was issued for and is rejected if the user's data changed in between. Both calls are
+// @Description recorded in the job log.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param user_id path string true "User ID"
+// @Param confirmation_token query string false "Token returned by the first call"
+// @Success 200 {object} models.APIResponse[models.UserDeletionResponse] "User data deleted"
+// @Success 202 {object} models.APIResponse[models.UserDeletionResponse] "Confirmation required"
+// @Failure 400 {object} models.ErrorResponse "Invalid confirmation token"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "No data stored for user"
+// @Failure 409 {object} models.ErrorResponse "Scope changed since the token was issued"
+// @Failure 410 {object} models.ErrorResponse "Confirmation token expired"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/users/{user_id} [delete]
+func (auh *AdminUserHandler) DeleteUser(c *gin.Context) {
+	userID := c.Param("user_id")
+	token := c.Query("confirmation_token")
+
+	var result *models.UserDeletionResponse
+	var err error
+	if token == "" {
+		result, err = auh.userDeletionService.RequestDeletion(c.Request.Context(), userID)
+	} else {
+		result, err = auh.userDeletionService.ConfirmDeletion(c.Request.Context(), userID, token)
+	}
+	if errors.Is(err, service.ErrUserNotFound) {
+		respondError(c, http.StatusNotFound, "NOT_FOUND", "no data stored for user", map[string]interface{}{
+			"user_id": userID,
+		})
+		return
+	}
+	if respondConfirmationError(c, err) {
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to delete user data", map[string]interface{}{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if !result.Deleted {
+		respondSuccess(c, http.StatusAccepted, result)
+		return
+	}
+	respondSuccess(c, http.StatusOK, result)
+}
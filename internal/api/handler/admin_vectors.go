@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// AdminVectorsHandler handles vector export and import requests
+type AdminVectorsHandler struct {
+	vectors *service.VectorExportService
+}
+
+// NewAdminVectorsHandler creates a new admin vectors handler
+func NewAdminVectorsHandler(vectors *service.VectorExportService) *AdminVectorsHandler {
+	return &AdminVectorsHandler{vectors: vectors}
+}
+
+// Export starts an export of a collection's vectors
+// @Summary Export stored vectors
+// @Description Start a background job writing every vector of a content type's collection to the blob store for
+// @Description offline experiments: vectors.parquet (id, payload as JSON, embedding), embeddings.npy (an N x D
+// @Description float32 matrix for numpy.load), metadata.jsonl (the id and payload of each .npy row, in order) and
+// @Description manifest.json. The job result lists the keys. Track progress with GET /admin/jobs/{job_id}.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param content_type query string false "Collection to export: conversations, personal_info or documents" default(conversations)
+// @Success 202 {object} models.APIResponse[models.JobStartedResponse] "Job started"
+// @Failure 400 {object} models.ErrorResponse "Unknown content type"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "A vector export or import is already running"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/vectors/export [post]
+func (avh *AdminVectorsHandler) Export(c *gin.Context) {
+	contentType := c.DefaultQuery("content_type", "conversations")
+
+	jobID, err := avh.vectors.StartExport(c.Request.Context(), contentType)
+	if avh.respondStartError(c, contentType, err, "failed to start vector export") {
+		return
+	}
+	respondSuccess(c, http.StatusAccepted, models.JobStartedResponse{JobID: jobID})
+}
+
+// Import starts an import of vectors computed offline
+// @Summary Import vectors computed offline
+// @Description Start a background job replacing the vectors of stored points with vectors read from the blob
+// @Description store: a Parquet file with id and embedding columns, or a .npy float32 matrix whose rows are named
+// @Description by the id fields of ids_key, such as an export's metadata.jsonl. Vectors must have the
+// @Description collection's dimension; rows whose id has no stored point are counted as missing and skipped, and
+// @Description payloads are kept. Imported vectors are served until the record is embedded again. Use dry_run to
+// @Description validate the file first. Track progress with GET /admin/jobs/{job_id}.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param request body models.VectorImportRequest true "File to import"
+// @Success 202 {object} models.APIResponse[models.JobStartedResponse] "Job started"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "A vector export or import is already running"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/vectors/import [post]
+func (avh *AdminVectorsHandler) Import(c *gin.Context) {
+	var req models.VectorImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if strings.HasSuffix(req.Key, ".npy") && req.IDsKey == "" {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "ids_key is required to import a .npy file", map[string]interface{}{
+			"field": "ids_key",
+		})
+		return
+	}
+
+	jobID, err := avh.vectors.StartImport(c.Request.Context(), &req)
+	if avh.respondStartError(c, req.ContentType, err, "failed to start vector import") {
+		return
+	}
+	respondSuccess(c, http.StatusAccepted, models.JobStartedResponse{JobID: jobID})
+}
+
+// respondStartError responds to an error starting a vector job and reports whether there was one
+func (avh *AdminVectorsHandler) respondStartError(c *gin.Context, contentType string, err error, message string) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, service.ErrUnknownContentType):
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "unknown content type", map[string]interface{}{
+			"content_type": contentType,
+		})
+	case errors.Is(err, service.ErrVectorJobRunning):
+		respondError(c, http.StatusConflict, "JOB_RUNNING", "a vector export or import is already running", nil)
+	case respondUnavailable(c, err):
+	default:
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", message, map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	return true
+}
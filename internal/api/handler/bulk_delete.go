@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/filter"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// BulkDeleteHandler handles deleting conversations by filter
+type BulkDeleteHandler struct {
+	bulkDeleteService *service.BulkDeleteService
+}
+
+// NewBulkDeleteHandler creates a new bulk deletion handler
+func NewBulkDeleteHandler(bulkDeleteService *service.BulkDeleteService) *BulkDeleteHandler {
+	return &BulkDeleteHandler{
+		bulkDeleteService: bulkDeleteService,
+	}
+}
+
+// DeleteByFilter deletes the conversations matching a filter after confirmation
+// @Summary Delete conversations by filter
+// @Description Delete every conversation matching a metadata filter, optionally only one user's and only those
+// @Description created in a time range, together with their messages and vectors. Every set criterion must hold
+// @Description and at least one must be set. The filter is matched against the stored metadata, so conversations
+// @Description without a vector are deleted too. Deletion takes two calls: without confirmation_token the
+// @Description response lists what would be deleted and returns a token (202), which must be sent back with the
+// @Description same body before it expires to execute the deletion. A token is rejected if the conversations
+// @Description matched changed in between. With dry_run=true only the preview is returned. Both calls are
+// @Description recorded in the job log.
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param request body models.ConversationBulkDeleteRequest true "Conversations to delete"
+// @Param dry_run query bool false "Report what would be deleted without executing"
+// @Param confirmation_token query string false "Token returned by the first call"
+// @Success 200 {object} models.APIResponse[models.ConversationBulkDeleteResponse] "Conversations deleted"
+// @Success 202 {object} models.APIResponse[models.ConversationBulkDeleteResponse] "Confirmation required"
+// @Failure 400 {object} models.ErrorResponse "Invalid request, filter or confirmation token"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "Scope changed since the token was issued"
+// @Failure 410 {object} models.ErrorResponse "Confirmation token expired"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/conversation/delete-by-filter [post]
+func (bdh *BulkDeleteHandler) DeleteByFilter(c *gin.Context) {
+	var req models.ConversationBulkDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	expr, err := filter.Parse(req.Filter)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_FILTER", "invalid metadata filter", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	req.Expr = expr
+	if req.CreatedAfter != nil && req.CreatedBefore != nil && !req.CreatedAfter.Before(*req.CreatedBefore) {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "created_after must be before created_before", map[string]interface{}{
+			"field": "created_after",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	token := c.Query("confirmation_token")
+
+	var result *models.ConversationBulkDeleteResponse
+	switch {
+	case c.Query("dry_run") == "true":
+		result, err = bdh.bulkDeleteService.Preview(ctx, &req)
+	case token == "":
+		result, err = bdh.bulkDeleteService.RequestDeletion(ctx, &req)
+	default:
+		result, err = bdh.bulkDeleteService.ConfirmDeletion(ctx, &req, token)
+	}
+	if errors.Is(err, service.ErrEmptySelection) {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error(), map[string]interface{}{
+			"fields": []string{"user_id", "filter", "created_after", "created_before"},
+		})
+		return
+	}
+	if respondConfirmationError(c, err) {
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to delete conversations", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if result.Confirmation != nil {
+		respondSuccess(c, http.StatusAccepted, result)
+		return
+	}
+	respondSuccess(c, http.StatusOK, result)
+}
@@ -0,0 +1,484 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// ConversationHandler handles reading, listing, archiving and editing stored conversations
+type ConversationHandler struct {
+	conversationService *service.ConversationService
+}
+
+// NewConversationHandler creates a new conversation handler
+func NewConversationHandler(conversationService *service.ConversationService) *ConversationHandler {
+	return &ConversationHandler{
+		conversationService: conversationService,
+	}
+}
+
+// HeaderResolvedConversationID names the conversation an old conversation ID, left behind by a
+// merge or an import that remapped IDs, resolved to
+const HeaderResolvedConversationID = "X-Resolved-Conversation-ID"
+
+// resolveConversationID returns the ID of the conversation the request's conversation_id refers
+// to, following an alias and naming the resolved conversation in a response header. It writes
+// the error response and returns false if the alias can't be looked up
+func (ch *ConversationHandler) resolveConversationID(c *gin.Context) (string, bool) {
+	conversationID := c.Param("conversation_id")
+
+	resolved, aliased, err := ch.conversationService.ResolveConversationID(c.Request.Context(), conversationID)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return "", false
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to resolve conversation ID", map[string]interface{}{
+			"conversation_id": conversationID,
+			"error":           err.Error(),
+		})
+		return "", false
+	}
+	if aliased {
+		c.Header(HeaderResolvedConversationID, resolved)
+	}
+	return resolved, true
+}
+
+// GetConversation retrieves a conversation
+// @Summary Get a conversation
+// @Description Get a stored conversation with its messages and status. A conversation is searchable once its
+// @Description status is indexed; pending means its vector write is still queued, failed that the vector couldn't
+// @Description be written, and archived that it was taken out of search. An old ID left behind by a merge or an
+// @Description import that remapped IDs returns the conversation that replaced it, named in the
+// @Description X-Resolved-Conversation-ID header; the archive and metadata routes follow such IDs too.
+// @Tags conversations
+// @Produce json
+// @Param conversation_id path string true "Conversation ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} models.APIResponse[models.ConversationResponse] "Conversation"
+// @Success 304 "Not modified since the ETag in If-None-Match"
+// @Failure 404 {object} models.ErrorResponse "Conversation not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Header 200 {string} X-Resolved-Conversation-ID "The conversation an old conversation_id resolved to"
+// @Router /api/rag/conversation/{conversation_id} [get]
+func (ch *ConversationHandler) GetConversation(c *gin.Context) {
+	conversationID, ok := ch.resolveConversationID(c)
+	if !ok {
+		return
+	}
+
+	conversation, err := ch.conversationService.GetConversation(c.Request.Context(), conversationID)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get conversation", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if conversation == nil {
+		respondError(c, http.StatusNotFound, "NOT_FOUND", "conversation not found", map[string]interface{}{
+			"conversation_id": conversationID,
+		})
+		return
+	}
+	if notModified(c, conversation) {
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, conversation)
+}
+
+// GetRelatedConversations finds conversations similar to a conversation
+// @Summary Get related conversations
+// @Description Get the same user's other conversations most similar to a conversation, most similar first, for
+// @Description "see related memories" navigation. The conversation's stored vector is compared directly, so nothing
+// @Description is embedded and no embedding budget is spent. Suppressed conversations are left out, and a
+// @Description conversation that isn't indexed has no related conversations. Old IDs are followed as by the get
+// @Description route.
+// @Tags conversations
+// @Produce json
+// @Param conversation_id path string true "Conversation ID"
+// @Param limit query int false "Maximum number of related conversations" default(5)
+// @Success 200 {object} models.APIResponse[models.RelatedConversationsResponse] "Related conversations"
+// @Failure 404 {object} models.ErrorResponse "Conversation not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Header 200 {string} X-Resolved-Conversation-ID "The conversation an old conversation_id resolved to"
+// @Router /api/rag/conversation/{conversation_id}/related [get]
+func (ch *ConversationHandler) GetRelatedConversations(c *gin.Context) {
+	conversationID, ok := ch.resolveConversationID(c)
+	if !ok {
+		return
+	}
+	limit, _ := pagination(c, 5, 50)
+
+	related, err := ch.conversationService.RelatedConversations(c.Request.Context(), conversationID, limit)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to find related conversations", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if related == nil {
+		respondError(c, http.StatusNotFound, "NOT_FOUND", "conversation not found", map[string]interface{}{
+			"conversation_id": conversationID,
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, related)
+}
+
+// Recommend finds conversations like liked example conversations
+// @Summary Recommend conversations from examples
+// @Description Get the user's conversations most like the liked example conversations and least like the disliked
+// @Description ones, most similar first, e.g. for personalized memory review or to collect reranker training data.
+// @Description The examples' stored vectors are compared directly, so nothing is embedded and no embedding budget
+// @Description is spent. The examples and suppressed conversations are left out.
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param request body models.RecommendRequest true "Example conversations"
+// @Success 200 {object} models.APIResponse[models.RecommendResponse] "Recommended conversations"
+// @Failure 400 {object} models.ErrorResponse "Invalid request, or an example isn't one of the user's indexed conversations"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/conversation/recommend [post]
+func (ch *ConversationHandler) Recommend(c *gin.Context) {
+	var req models.RecommendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	recommendations, err := ch.conversationService.Recommend(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrInvalidExamples) {
+		respondError(c, http.StatusBadRequest, "INVALID_EXAMPLES", err.Error(), map[string]interface{}{
+			"user_id": req.UserID,
+		})
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to recommend conversations", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, recommendations)
+}
+
+// ListConversations lists a user's conversations
+// @Summary List a user's conversations
+// @Description List a user's conversations, newest first, optionally only those in one status, e.g. pending to
+// @Description find those not yet searchable
+// @Tags conversations
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param status query string false "Only conversations in this status" Enums(pending, indexed, failed, archived)
+// @Param limit query int false "Maximum number of conversations" default(50)
+// @Param offset query int false "Number of conversations to skip" default(0)
+// @Success 200 {object} models.APIResponse[models.ConversationListResponse] "Conversations"
+// @Failure 400 {object} models.ErrorResponse "Invalid status"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/users/{user_id}/conversations [get]
+func (ch *ConversationHandler) ListConversations(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && !models.IsValidConversationStatus(status) {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid status", map[string]interface{}{
+			"status":         status,
+			"valid_statuses": models.ConversationStatuses,
+		})
+		return
+	}
+	limit, offset := pagination(c, 50, 500)
+
+	response, err := ch.conversationService.ListConversations(c.Request.Context(), c.Param("user_id"), status, limit, offset)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to list conversations", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, response)
+}
+
+// GetUserStats retrieves a user's conversation stats
+// @Summary Get a user's conversation stats
+// @Description Get a user's conversation count, last conversation time and the embedding tokens spent on their
+// @Description conversations. The stats are kept up to date as conversations are saved and deleted, so reading
+// @Description them doesn't count the user's conversations. The last conversation time is kept when
+// @Description conversations are deleted; a user without conversations has zero stats.
+// @Tags conversations
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} models.APIResponse[models.UserStats] "User stats"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/users/{user_id}/stats [get]
+func (ch *ConversationHandler) GetUserStats(c *gin.Context) {
+	stats, err := ch.conversationService.UserStats(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get user stats", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, stats)
+}
+
+// GetTimeline retrieves a user's conversations grouped by day
+// @Summary Get a user's conversation timeline
+// @Description Get a user's conversations grouped by day, newest day first, with each day's conversation count and
+// @Description a short summary of its newest conversations, for history views. Days without conversations are
+// @Description skipped. Pass next_before as before to get the next, older page; it is left out on the last page.
+// @Tags conversations
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param before query string false "Only days before this date, YYYY-MM-DD; the latest day by default"
+// @Param days query int false "Maximum number of days" default(7)
+// @Param per_day query int false "Maximum number of conversations listed per day" default(10)
+// @Param time_zone query string false "IANA time zone days start in" default(UTC)
+// @Success 200 {object} models.APIResponse[models.TimelineResponse] "Timeline"
+// @Failure 400 {object} models.ErrorResponse "Invalid date or time zone"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/users/{user_id}/timeline [get]
+func (ch *ConversationHandler) GetTimeline(c *gin.Context) {
+	req := models.TimelineRequest{UserID: c.Param("user_id"), Days: 7, PerDay: 10, Loc: time.UTC}
+	if tz := c.Query("time_zone"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid time_zone", map[string]interface{}{
+				"time_zone": tz,
+			})
+			return
+		}
+		req.Loc = loc
+	}
+	if before := c.Query("before"); before != "" {
+		t, err := service.ParseTimelineBefore(before, req.Loc)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "before must be a date formatted YYYY-MM-DD", map[string]interface{}{
+				"before": before,
+			})
+			return
+		}
+		req.Before = t
+	}
+	if days, err := strconv.Atoi(c.Query("days")); err == nil && days > 0 {
+		req.Days = min(days, service.MaxTimelineDays)
+	}
+	if perDay, err := strconv.Atoi(c.Query("per_day")); err == nil && perDay > 0 {
+		req.PerDay = min(perDay, service.MaxTimelinePerDay)
+	}
+
+	timeline, err := ch.conversationService.Timeline(c.Request.Context(), &req)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get timeline", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, timeline)
+}
+
+// UpdateMetadata replaces a conversation's metadata
+// @Summary Update conversation metadata
+// @Description Replace a conversation's metadata, including custom fields used by search filters. Only the
+// @Description vector's payload is updated; the conversation isn't embedded again. session_id can't be changed
+// @Description and is ignored.
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param conversation_id path string true "Conversation ID"
+// @Param request body models.ConversationMetadata true "New metadata"
+// @Success 200 {object} models.APIResponse[models.ConversationResponse] "Conversation with its new metadata"
+// @Failure 400 {object} models.ErrorResponse "Invalid metadata"
+// @Failure 404 {object} models.ErrorResponse "Conversation not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Header 200 {string} X-Resolved-Conversation-ID "The conversation an old conversation_id resolved to"
+// @Router /api/rag/conversation/{conversation_id}/metadata [put]
+func (ch *ConversationHandler) UpdateMetadata(c *gin.Context) {
+	conversationID, ok := ch.resolveConversationID(c)
+	if !ok {
+		return
+	}
+
+	var metadata models.ConversationMetadata
+	if err := c.ShouldBindJSON(&metadata); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := metadata.Validate(); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_METADATA", "invalid conversation metadata", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	conversation, err := ch.conversationService.UpdateMetadata(c.Request.Context(), conversationID, &metadata)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to update metadata", map[string]interface{}{
+			"conversation_id": conversationID,
+			"error":           err.Error(),
+		})
+		return
+	}
+	if conversation == nil {
+		respondError(c, http.StatusNotFound, "NOT_FOUND", "conversation not found", map[string]interface{}{
+			"conversation_id": conversationID,
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, conversation)
+}
+
+// ArchiveConversation archives or unarchives a conversation
+// @Summary Archive a conversation
+// @Description Archive a conversation, removing its vector so search no longer finds it while it stays stored, or
+// @Description unarchive it with archived=false, which embeds it again. An unarchived conversation the embedding
+// @Description provider refuses is kept with status failed.
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param conversation_id path string true "Conversation ID"
+// @Param request body models.ConversationArchiveRequest true "Archive state"
+// @Success 200 {object} models.APIResponse[models.ConversationStatusResponse] "Conversation status"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Conversation not found"
+// @Failure 429 {object} models.ErrorResponse "Embedding budget spent"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Header 200 {string} X-Resolved-Conversation-ID "The conversation an old conversation_id resolved to"
+// @Router /api/rag/conversation/{conversation_id}/archive [put]
+func (ch *ConversationHandler) ArchiveConversation(c *gin.Context) {
+	conversationID, ok := ch.resolveConversationID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ConversationArchiveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	resp, err := ch.conversationService.SetArchived(c.Request.Context(), conversationID, *req.Archived)
+	if respondBudgetExceeded(c, err) {
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to update archive state", map[string]interface{}{
+			"conversation_id": conversationID,
+			"error":           err.Error(),
+		})
+		return
+	}
+	if resp == nil {
+		respondError(c, http.StatusNotFound, "NOT_FOUND", "conversation not found", map[string]interface{}{
+			"conversation_id": conversationID,
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, resp)
+}
+
+// SetAlias makes an old conversation ID resolve to another conversation
+// @Summary Alias an old conversation ID
+// @Description Make an old conversation ID, left behind by a merge or an import that remapped IDs, resolve to the
+// @Description conversation that replaced it, so external references to it stay valid. Reading or changing the
+// @Description old ID then acts on that conversation and names it in the X-Resolved-Conversation-ID header.
+// @Description Aliases of the old ID are moved along to the new conversation. Replaces an earlier alias of the ID.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param alias_id path string true "Old conversation ID"
+// @Param request body models.ConversationAliasRequest true "Conversation the ID resolves to"
+// @Success 200 {object} models.APIResponse[models.ConversationAlias] "Alias saved"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Conversation not found"
+// @Failure 409 {object} models.ErrorResponse "Old ID is a stored conversation"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/conversation-aliases/{alias_id} [put]
+func (ch *ConversationHandler) SetAlias(c *gin.Context) {
+	aliasID := c.Param("alias_id")
+
+	var req models.ConversationAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !models.IsValidAliasReason(req.Reason) {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid reason", map[string]interface{}{
+			"reason":        req.Reason,
+			"valid_reasons": models.AliasReasons,
+		})
+		return
+	}
+
+	alias, err := ch.conversationService.SetAlias(c.Request.Context(), aliasID, req.ConversationID, req.Reason)
+	if errors.Is(err, service.ErrAliasIsConversation) {
+		respondError(c, http.StatusConflict, "ALIAS_CONFLICT", err.Error(), map[string]interface{}{
+			"alias_id": aliasID,
+		})
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to save conversation alias", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if alias == nil {
+		respondError(c, http.StatusNotFound, "NOT_FOUND", "conversation not found", map[string]interface{}{
+			"conversation_id": req.ConversationID,
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, alias)
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/filter"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+	"refo-rag-server/internal/storage"
+)
+
+// DebugHandler handles operator-facing retrieval diagnostics
+type DebugHandler struct {
+	conversationService *service.ConversationService
+	inspector           *service.EmbeddingInspector
+}
+
+// NewDebugHandler creates a new debug handler
+func NewDebugHandler(conversationService *service.ConversationService, inspector *service.EmbeddingInspector) *DebugHandler {
+	return &DebugHandler{
+		conversationService: conversationService,
+		inspector:           inspector,
+	}
+}
+
+// ExplainRetrieval runs a search and returns each pipeline stage's output
+// @Summary Explain a conversation search
+// @Description Run a conversation search with the same parameters as /conversation/search and return every
+// @Description retrieval pipeline stage's intermediate output: the raw and transformed query, each retriever's
+// @Description candidates, the fused set, candidates dropped by filters, normalized scores, per-reranker score
+// @Description deltas and the final selection. Retrieval overrides are always accepted here and echoed in the trace.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param query query string true "Search query"
+// @Param top_k query int false "Result limit (default: 10, max: 100)"
+// @Param min_score query number false "Drop results scoring below this value"
+// @Param filter query string false "Metadata filter, e.g. source = \"slack\" AND priority >= 3"
+// @Param user_id query string false "Restrict results to one user; required when user isolation is enabled"
+// @Param max_age_days query int false "Only match conversations created within this many days; 0 searches all history (default: the tenant's SEARCH_MAX_AGE_DAYS)"
+// @Param candidate_multiplier query number false "Fetch this many times top_k candidates before filtering and reranking (1-10)"
+// @Param hnsw_ef query int false "HNSW search beam size (1-4096)"
+// @Param fusion_weights query string false "Per-retriever fusion weights, e.g. vector=1,keyword=0.5"
+// @Param recency_half_life query string false "Recency reranker half-life, e.g. 72h"
+// @Param importance_half_life query string false "Importance reranker half-life, e.g. 720h"
+// @Success 200 {object} models.APIResponse[models.RetrievalTrace] "Pipeline trace"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/debug/retrieval [get]
+func (dh *DebugHandler) ExplainRetrieval(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("query"))
+	if query == "" {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "query is required", map[string]interface{}{
+			"field": "query",
+		})
+		return
+	}
+
+	req := models.ConversationSearchRequest{
+		Query:  query,
+		UserID: c.Query("user_id"),
+	}
+	if topK := c.Query("top_k"); topK != "" {
+		k, err := strconv.Atoi(topK)
+		if err != nil || k <= 0 || k > 100 {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "top_k must be between 1 and 100", map[string]interface{}{
+				"field": "top_k",
+			})
+			return
+		}
+		req.Limit = k
+	}
+	if minScore := c.Query("min_score"); minScore != "" {
+		score, err := strconv.ParseFloat(minScore, 32)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "min_score must be a number", map[string]interface{}{
+				"field": "min_score",
+			})
+			return
+		}
+		req.MinScore = float32(score)
+	}
+
+	maxAgeDays, ok := parseMaxAgeDays(c)
+	if !ok {
+		return
+	}
+	req.MaxAgeDays = maxAgeDays
+
+	metadataFilter, err := filter.Parse(c.Query("filter"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_FILTER", "invalid metadata filter", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	req.Filter = metadataFilter
+
+	overrides, given, ok := parseRetrievalOverrides(c)
+	if !ok {
+		return
+	}
+	req.Overrides = overrides
+
+	trace, err := dh.conversationService.ExplainSearch(c.Request.Context(), &req)
+	if errors.Is(err, storage.ErrUserScopeRequired) {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "user_id is required", map[string]interface{}{
+			"field":  "user_id",
+			"reason": "searches are scoped to a single user",
+		})
+		return
+	}
+	if respondOverrideRejected(c, err) {
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to explain search", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	trace.Overrides = given
+	respondSuccess(c, http.StatusOK, trace)
+}
+
+// InspectEmbedding returns a text's embedding and its nearest stored points
+// @Summary Inspect an embedding and its nearest neighbors
+// @Description Embed arbitrary text with a collection's model and return the embedding with the stored points
+// @Description nearest to it, including scores, distances and payloads. Use it to debug why a record was or
+// @Description wasn't retrieved for a query without direct Qdrant access.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param request body models.EmbeddingInspectRequest true "Text to inspect"
+// @Success 200 {object} models.APIResponse[models.EmbeddingInspection] "Embedding and neighbors"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/embeddings/inspect [post]
+func (dh *DebugHandler) InspectEmbedding(c *gin.Context) {
+	var req models.EmbeddingInspectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "text is required", map[string]interface{}{
+			"field": "text",
+		})
+		return
+	}
+
+	inspection, err := dh.inspector.Inspect(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrUnknownContentType) {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "unknown content type", map[string]interface{}{
+			"content_type": req.ContentType,
+		})
+		return
+	}
+	if errors.Is(err, storage.ErrUserScopeRequired) {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "user_id is required", map[string]interface{}{
+			"field":  "user_id",
+			"reason": "the collection is scoped to a single user",
+		})
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to inspect embedding", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, inspection)
+}
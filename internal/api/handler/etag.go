@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resourceETag returns a strong ETag of a resource's JSON representation, so any change clients
+// can see, including pinning and suppression which don't touch updated_at, yields a new tag
+func resourceETag(resource interface{}) string {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified sets the ETag header and, when the request's If-None-Match matches it, writes 304
+// and reports true. The resource must not include per-request fields such as timings
+func notModified(c *gin.Context, resource interface{}) bool {
+	etag := resourceETag(resource)
+	if etag == "" {
+		return false
+	}
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.AbortWithStatus(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, comparing weakly
+func etagMatches(header string, etag string) bool {
+	return listsETag(header, etag, false)
+}
+
+// etagStrongMatches reports whether an If-Match header lists etag, comparing strongly as RFC 9110
+// requires: a weak validator never matches, so it can't pass a lost-update check
+func etagStrongMatches(header string, etag string) bool {
+	return listsETag(header, etag, true)
+}
+
+// listsETag reports whether a conditional header lists etag or "*". The strong comparison requires
+// both tags to be strong and identical; the weak one ignores W/ prefixes
+func listsETag(header string, etag string, strong bool) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strong {
+			if !strings.HasPrefix(candidate, "W/") && !strings.HasPrefix(etag, "W/") && candidate == etag {
+				return true
+			}
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
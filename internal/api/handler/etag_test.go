@@ -0,0 +1,32 @@
+package handler
+
+import "testing"
+
+func TestETagComparison(t *testing.T) {
+	const etag = `"abc"`
+	cases := []struct {
+		header string
+		weak   bool
+		strong bool
+	}{
+		{header: "", weak: false, strong: false},
+		{header: `"abc"`, weak: true, strong: true},
+		{header: `W/"abc"`, weak: true, strong: false},
+		{header: `"other", "abc"`, weak: true, strong: true},
+		{header: `"other", W/"abc"`, weak: true, strong: false},
+		{header: `"other"`, weak: false, strong: false},
+		{header: "*", weak: true, strong: true},
+	}
+	for _, tc := range cases {
+		if got := etagMatches(tc.header, etag); got != tc.weak {
+			t.Errorf("etagMatches(%q) = %t, want %t", tc.header, got, tc.weak)
+		}
+		if got := etagStrongMatches(tc.header, etag); got != tc.strong {
+			t.Errorf("etagStrongMatches(%q) = %t, want %t", tc.header, got, tc.strong)
+		}
+	}
+
+	if etagStrongMatches(`W/"abc"`, `W/"abc"`) {
+		t.Error("a weak ETag must never match strongly")
+	}
+}
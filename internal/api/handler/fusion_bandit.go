@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// FusionBanditHandler handles relevance feedback and admin requests of the fusion bandit
+type FusionBanditHandler struct {
+	bandit *service.FusionBandit
+}
+
+// NewFusionBanditHandler creates a new fusion bandit handler
+func NewFusionBanditHandler(bandit *service.FusionBandit) *FusionBanditHandler {
+	return &FusionBanditHandler{
+		bandit: bandit,
+	}
+}
+
+// Feedback rates a search result for the fusion bandit
+// @Summary Rate a search result
+// @Description Report whether a result of a conversation search was relevant. The rating is credited to the fusion
+// @Description weights the search was served with, search_metadata.fusion_arm, so the tenant's searches drift towards
+// @Description the weights whose results are rated relevant. Ratings aren't counted while the tenant is frozen to one
+// @Description arm. Only available when FUSION_BANDIT_ENABLED is set.
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param request body models.SearchFeedbackRequest true "Fusion arm and rating"
+// @Success 200 {object} models.APIResponse[models.SearchFeedbackResponse] "Feedback recorded"
+// @Failure 400 {object} models.ErrorResponse "Invalid request or unknown fusion arm"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/conversation/search/feedback [post]
+func (fbh *FusionBanditHandler) Feedback(c *gin.Context) {
+	var req models.SearchFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	resp, err := fbh.bandit.Feedback(c.Request.Context(), &req)
+	if fbh.respondError(c, err, "failed to record search feedback") {
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, resp)
+}
+
+// GetStatus describes a tenant's fusion bandit
+// @Summary Get a tenant's fusion bandit
+// @Description Get the fusion weight arms a tenant's searches are served with, the relevance feedback each collected,
+// @Description which arms the guardrails demoted, the best arm and whether the tenant is frozen to one arm.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param tenant path string true "Tenant"
+// @Success 200 {object} models.APIResponse[models.FusionBanditStatus] "Fusion bandit status"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/fusion-bandit/tenants/{tenant} [get]
+func (fbh *FusionBanditHandler) GetStatus(c *gin.Context) {
+	status, err := fbh.bandit.Status(c.Request.Context(), c.Param("tenant"))
+	if fbh.respondError(c, err, "failed to get fusion bandit status") {
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, status)
+}
+
+// Freeze pins a tenant's searches to one arm
+// @Summary Freeze a tenant's fusion weights
+// @Description Serve every search of a tenant with one arm, by default its best arm, e.g. once the bandit has
+// @Description converged or to roll back to the baseline. Feedback isn't counted while frozen. Other replicas
+// @Description follow within FUSION_BANDIT_REFRESH.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param tenant path string true "Tenant"
+// @Param request body models.FusionBanditFreezeRequest false "Arm to freeze to"
+// @Success 200 {object} models.APIResponse[models.FusionBanditStatus] "Fusion bandit status"
+// @Failure 400 {object} models.ErrorResponse "Invalid request or unknown fusion arm"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/fusion-bandit/tenants/{tenant}/freeze [post]
+func (fbh *FusionBanditHandler) Freeze(c *gin.Context) {
+	var req models.FusionBanditFreezeRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+
+	status, err := fbh.bandit.Freeze(c.Request.Context(), c.Param("tenant"), req.Arm)
+	if fbh.respondError(c, err, "failed to freeze fusion bandit") {
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, status)
+}
+
+// Unfreeze lets the bandit choose a tenant's arms again
+// @Summary Unfreeze a tenant's fusion weights
+// @Description Let the fusion bandit choose the arms of a tenant's searches again and count their feedback.
+// @Tags admin
+// @Produce json
+// @Security AdminAPIKey
+// @Param tenant path string true "Tenant"
+// @Success 200 {object} models.APIResponse[models.FusionBanditStatus] "Fusion bandit status"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/fusion-bandit/tenants/{tenant}/freeze [delete]
+func (fbh *FusionBanditHandler) Unfreeze(c *gin.Context) {
+	status, err := fbh.bandit.Unfreeze(c.Request.Context(), c.Param("tenant"))
+	if fbh.respondError(c, err, "failed to unfreeze fusion bandit") {
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, status)
+}
+
+// respondError responds to a failed fusion bandit request and reports whether err was set
+func (fbh *FusionBanditHandler) respondError(c *gin.Context, err error, message string) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, service.ErrUnknownFusionArm) {
+		respondError(c, http.StatusBadRequest, "UNKNOWN_FUSION_ARM", "no fusion bandit arm has this name", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return true
+	}
+	if respondUnavailable(c, err) {
+		return true
+	}
+	respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", message, map[string]interface{}{
+		"error": err.Error(),
+	})
+	return true
+}
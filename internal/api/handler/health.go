@@ -7,6 +7,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"refo-rag-server/internal/coord"
+	"refo-rag-server/internal/health"
+	"refo-rag-server/internal/lifecycle"
 	"refo-rag-server/internal/models"
 	"refo-rag-server/internal/storage"
 )
@@ -15,41 +18,97 @@ import (
 type HealthCheckHandler struct {
 	postgresStore storage.PostgresStoreInterface
 	qdrantStore   storage.QdrantStoreInterface
+	readiness     *lifecycle.Readiness
+	monitor       *health.Monitor
+	elector       *coord.Elector
 }
 
 // NewHealthCheckHandler creates a new health check handler
-func NewHealthCheckHandler(postgresStore storage.PostgresStoreInterface, qdrantStore storage.QdrantStoreInterface) *HealthCheckHandler {
+func NewHealthCheckHandler(
+	postgresStore storage.PostgresStoreInterface,
+	qdrantStore storage.QdrantStoreInterface,
+	readiness *lifecycle.Readiness,
+	monitor *health.Monitor,
+	elector *coord.Elector,
+) *HealthCheckHandler {
 	return &HealthCheckHandler{
 		postgresStore: postgresStore,
 		qdrantStore:   qdrantStore,
+		readiness:     readiness,
+		monitor:       monitor,
+		elector:       elector,
 	}
 }
 
+// Ready reports whether startup warm-up has finished and the server can take traffic
+// @Summary Readiness probe
+// @Description Report whether the server has finished warming up and is ready to receive traffic
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.APIResponse[models.ReadinessResponse] "Server is ready"
+// @Failure 503 {object} models.APIResponse[models.ReadinessResponse] "Server is still warming up"
+// @Router /api/rag/health/ready [get]
+func (hch *HealthCheckHandler) Ready(c *gin.Context) {
+	ready, reason := hch.readiness.Status()
+
+	statusCode := http.StatusOK
+	if !ready {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	c.JSON(statusCode, models.APIResponse[models.ReadinessResponse]{
+		Success: ready,
+		Data: models.ReadinessResponse{
+			Ready:  ready,
+			Reason: reason,
+		},
+		Metadata: models.Metadata{},
+	})
+}
+
 // Handle processes health check requests
 // @Summary Health check
 // @Description Check if the RAG server and its dependencies are healthy
 // @Tags health
 // @Produce json
-// @Success 200 {object} models.APIResponse "Server is healthy"
-// @Success 503 {object} models.APIResponse "Service unavailable"
+// @Success 200 {object} models.APIResponse[models.HealthCheckResponse] "Server is healthy"
+// @Success 503 {object} models.APIResponse[models.HealthCheckResponse] "Service unavailable"
 // @Router /api/rag/health [get]
 func (hch *HealthCheckHandler) Handle(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	now := time.Now().UTC()
-	overallStatus := "healthy"
 
-	// Check PostgreSQL
+	// Check PostgreSQL; single failures are damped by the health monitor
 	pgStatus := checkPostgreSQL(ctx, hch.postgresStore)
-	if pgStatus.Status != "healthy" {
-		overallStatus = "unhealthy"
-	}
+	pgStatus.Status = hch.monitor.Record("postgresql", health.CheckResult{
+		Healthy:   pgStatus.Status == "healthy",
+		Error:     pgStatus.Error,
+		LatencyMs: int64(pgStatus.ResponseTimeMs),
+		CheckedAt: now,
+	})
+	pgStatus.Stability = hch.monitor.Stability("postgresql")
 
 	// Check Qdrant
 	qdrantStatus := checkQdrant(ctx, hch.qdrantStore)
-	if qdrantStatus.Status != "healthy" && overallStatus == "healthy" {
-		overallStatus = "unhealthy"
+	qdrantStatus.Status = hch.monitor.Record("qdrant", health.CheckResult{
+		Healthy:   qdrantStatus.Status == "healthy",
+		Error:     qdrantStatus.Error,
+		LatencyMs: int64(qdrantStatus.ResponseTimeMs),
+		CheckedAt: now,
+	})
+	qdrantStatus.Stability = hch.monitor.Stability("qdrant")
+
+	overallStatus := health.StatusHealthy
+	for _, status := range []string{pgStatus.Status, qdrantStatus.Status} {
+		if status == health.StatusUnhealthy {
+			overallStatus = health.StatusUnhealthy
+			break
+		}
+		if status == health.StatusDegraded {
+			overallStatus = health.StatusDegraded
+		}
 	}
 
 	// Check OpenAI (simple check based on last successful call)
@@ -67,14 +126,19 @@ func (hch *HealthCheckHandler) Handle(c *gin.Context) {
 		Version:      "1.0.0",
 		Dependencies: dependencies,
 	}
+	if hch.elector != nil {
+		leadership := hch.elector.Status()
+		healthResp.Leadership = &leadership
+	}
 
+	// Degraded dependencies keep the pod in rotation; only damped failures return 503
 	statusCode := http.StatusOK
-	if overallStatus != "healthy" {
+	if overallStatus == health.StatusUnhealthy {
 		statusCode = http.StatusServiceUnavailable
 	}
 
-	c.JSON(statusCode, models.APIResponse{
-		Success:  overallStatus == "healthy",
+	c.JSON(statusCode, models.APIResponse[models.HealthCheckResponse]{
+		Success:  overallStatus != health.StatusUnhealthy,
 		Data:     healthResp,
 		Metadata: models.Metadata{},
 	})
@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// MemoryHandler handles memory context and pinning requests
+type MemoryHandler struct {
+	memoryService *service.MemoryService
+}
+
+// NewMemoryHandler creates a new memory handler
+func NewMemoryHandler(memoryService *service.MemoryService) *MemoryHandler {
+	return &MemoryHandler{
+		memoryService: memoryService,
+	}
+}
+
+// Retrieve assembles a user's memory context
+// @Summary Retrieve memory context
+// @Description Get the memory context for a chat turn: the user's pinned memories (always included),
+// @Description the user's conversations and personal info most similar to the query, and optionally the session
+// @Description recap. With RETRIEVE_ROUTING=classifier only the collections the query is about are searched and the
+// @Description response reports the routing decision.
+// @Tags memory
+// @Produce json
+// @Param user_id query string true "User ID"
+// @Param query query string false "Query text; similarity results are omitted when empty"
+// @Param top_k query int false "Similarity result limit (default: 5, max: 100)"
+// @Param session_id query string false "Session whose summary and recent messages are included"
+// @Param max_age_days query int false "Only match conversations created within this many days; 0 searches all history (default: the tenant's SEARCH_MAX_AGE_DAYS)"
+// @Param recent_messages query int false "Number of recent session messages to include (default: 0, max: 50)"
+// @Param candidate_multiplier query number false "Fetch this many times top_k candidates before filtering and reranking (1-10); needs the experiment scope"
+// @Param hnsw_ef query int false "HNSW search beam size (1-4096); needs the experiment scope"
+// @Param fusion_weights query string false "Per-retriever fusion weights, e.g. vector=1,keyword=0.5; needs the experiment scope"
+// @Param recency_half_life query string false "Recency reranker half-life, e.g. 72h; needs the experiment scope"
+// @Param importance_half_life query string false "Importance reranker half-life, e.g. 720h; needs the experiment scope"
+// @Param compress query bool false "Reduce similar conversations to their sentences most relevant to the query (default: CONTEXT_COMPRESSION)"
+// @Param read_consistency query string false "Qdrant replicas that must answer: majority, quorum, all or a number; fresher but slower than the configured default on clustered Qdrant"
+// @Success 200 {object} models.APIResponse[models.RetrieveResponse] "Memory context"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 403 {object} models.ErrorResponse "Retrieval overrides without the experiment scope"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/retrieve [get]
+func (mh *MemoryHandler) Retrieve(c *gin.Context) {
+	req := models.RetrieveRequest{
+		UserID:    c.Query("user_id"),
+		Query:     strings.TrimSpace(c.Query("query")),
+		Limit:     5,
+		SessionID: c.Query("session_id"),
+	}
+	if req.UserID == "" {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "user_id is required", map[string]interface{}{
+			"field":  "user_id",
+			"reason": "required field missing",
+		})
+		return
+	}
+	if k, err := strconv.Atoi(c.Query("top_k")); err == nil && k > 0 && k <= 100 {
+		req.Limit = k
+	}
+	if n, err := strconv.Atoi(c.Query("recent_messages")); err == nil && n > 0 {
+		req.RecentMessages = min(n, 50)
+	}
+	maxAgeDays, ok := parseMaxAgeDays(c)
+	if !ok {
+		return
+	}
+	req.MaxAgeDays = maxAgeDays
+	if raw := c.Query("compress"); raw != "" {
+		compress, err := strconv.ParseBool(raw)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "compress must be true or false", map[string]interface{}{
+				"field": "compress",
+			})
+			return
+		}
+		req.Compress = &compress
+	}
+	overrides, _, ok := parseRetrievalOverrides(c)
+	if !ok || !applyReadConsistency(c) {
+		return
+	}
+	req.Overrides = overrides
+
+	resp, err := mh.memoryService.Retrieve(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrSessionNotFound) {
+		respondSessionNotFound(c, req.SessionID)
+		return
+	}
+	if respondOverrideRejected(c, err) {
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to retrieve memory context", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, resp)
+}
+
+// ListPinned lists a user's pinned memories
+// @Summary List pinned memories
+// @Description List the conversations and personal info entries pinned for a user
+// @Tags memory
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} models.APIResponse[models.PinnedMemories] "Pinned memories"
+// @Success 304 "Not modified since the ETag in If-None-Match"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/users/{user_id}/pinned [get]
+func (mh *MemoryHandler) ListPinned(c *gin.Context) {
+	pinned, err := mh.memoryService.Pinned(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to list pinned memories", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if notModified(c, pinned) {
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, pinned)
+}
+
+// PinConversation pins or unpins a conversation
+// @Summary Pin a conversation
+// @Description Pin a conversation so it is always included in the user's memory context, or unpin it
+// @Tags memory
+// @Accept json
+// @Produce json
+// @Param conversation_id path string true "Conversation ID"
+// @Param request body models.PinRequest true "Pin state"
+// @Success 200 {object} models.APIResponse[models.PinResponse] "Pin state updated"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Conversation not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/conversation/{conversation_id}/pin [put]
+func (mh *MemoryHandler) PinConversation(c *gin.Context) {
+	mh.pin(c, c.Param("conversation_id"), "conversation", mh.memoryService.PinConversation)
+}
+
+// PinPersonalInfo pins or unpins a personal info entry
+// @Summary Pin personal information
+// @Description Pin a personal info entry so it is always included in the user's memory context, or unpin it
+// @Tags memory
+// @Accept json
+// @Produce json
+// @Param info_id path string true "Personal info ID"
+// @Param request body models.PinRequest true "Pin state"
+// @Success 200 {object} models.APIResponse[models.PinResponse] "Pin state updated"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Personal info not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/personal-info/{info_id}/pin [put]
+func (mh *MemoryHandler) PinPersonalInfo(c *gin.Context) {
+	mh.pin(c, c.Param("info_id"), "personal_info", mh.memoryService.PinPersonalInfo)
+}
+
+// pin applies a pin request through setPinned and writes the resulting pin state
+func (mh *MemoryHandler) pin(c *gin.Context, id string, memoryType string, setPinned func(ctx context.Context, id string, pinned bool) (bool, error)) {
+	var req models.PinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	found, err := setPinned(c.Request.Context(), id, *req.Pinned)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to update pin state", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !found {
+		respondError(c, http.StatusNotFound, "NOT_FOUND", memoryType+" not found", map[string]interface{}{
+			"id": id,
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, models.PinResponse{
+		ID:     id,
+		Type:   memoryType,
+		Pinned: *req.Pinned,
+	})
+}
+
+// SuppressConversation suppresses or restores a conversation
+// @Summary Suppress a conversation
+// @Description Mark a conversation as wrong, outdated or harmful so it is excluded from retrieval
+// @Description without being deleted, or restore it with suppressed=false
+// @Tags memory
+// @Accept json
+// @Produce json
+// @Param conversation_id path string true "Conversation ID"
+// @Param request body models.SuppressionRequest true "Suppression state"
+// @Success 200 {object} models.APIResponse[models.SuppressionResponse] "Suppression updated"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Conversation not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/conversation/{conversation_id}/suppression [put]
+func (mh *MemoryHandler) SuppressConversation(c *gin.Context) {
+	mh.suppress(c, c.Param("conversation_id"), "conversation", mh.memoryService.SuppressConversation)
+}
+
+// SuppressPersonalInfo suppresses or restores a personal info entry
+// @Summary Suppress personal information
+// @Description Mark a personal info entry as wrong, outdated or harmful so it is excluded from retrieval
+// @Description without being deleted, or restore it with suppressed=false
+// @Tags memory
+// @Accept json
+// @Produce json
+// @Param info_id path string true "Personal info ID"
+// @Param request body models.SuppressionRequest true "Suppression state"
+// @Success 200 {object} models.APIResponse[models.SuppressionResponse] "Suppression updated"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Personal info not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/personal-info/{info_id}/suppression [put]
+func (mh *MemoryHandler) SuppressPersonalInfo(c *gin.Context) {
+	mh.suppress(c, c.Param("info_id"), "personal_info", mh.memoryService.SuppressPersonalInfo)
+}
+
+// ListSuppressed lists a user's suppressed memories
+// @Summary List suppressed memories
+// @Description List the conversations and personal info entries suppressed for a user, with reasons
+// @Tags memory
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} models.APIResponse[models.SuppressedMemories] "Suppressed memories"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/users/{user_id}/suppressed [get]
+func (mh *MemoryHandler) ListSuppressed(c *gin.Context) {
+	suppressed, err := mh.memoryService.Suppressed(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to list suppressed memories", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, suppressed)
+}
+
+// suppress applies a suppression request through setSuppression and writes the resulting state
+func (mh *MemoryHandler) suppress(
+	c *gin.Context,
+	id string,
+	memoryType string,
+	setSuppression func(ctx context.Context, id string, req *models.SuppressionRequest) (*models.SuppressionResponse, error),
+) {
+	var req models.SuppressionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if *req.Suppressed && req.Reason == "" {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "reason is required when suppressing", map[string]interface{}{
+			"valid_reasons": []string{models.SuppressionWrong, models.SuppressionOutdated, models.SuppressionHarmful},
+		})
+		return
+	}
+
+	resp, err := setSuppression(c.Request.Context(), id, &req)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to update suppression", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if resp == nil {
+		respondError(c, http.StatusNotFound, "NOT_FOUND", memoryType+" not found", map[string]interface{}{
+			"id": id,
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, resp)
+}
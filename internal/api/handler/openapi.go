@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/openapi"
+)
+
+// OpenAPIHandler serves the OpenAPI document of the running server
+type OpenAPIHandler struct {
+	routes func() gin.RoutesInfo
+	opts   openapi.Options
+
+	// The routes are fixed once the server serves requests, so the document is built once
+	once sync.Once
+	doc  []byte
+	err  error
+}
+
+// NewOpenAPIHandler creates a new OpenAPI handler describing the routes routes returns
+func NewOpenAPIHandler(routes func() gin.RoutesInfo, opts openapi.Options) *OpenAPIHandler {
+	return &OpenAPIHandler{
+		routes: routes,
+		opts:   opts,
+	}
+}
+
+// Handle serves the OpenAPI document
+// @Summary Get the OpenAPI document
+// @Description Get an OpenAPI 3 description of this deployment's API for generating clients. It lists the routes
+// @Description actually registered, so endpoints disabled by configuration are left out and routes without
+// @Description annotations are still listed. Schemas come from the typed request and response models, with an
+// @Description example for every JSON body; the error envelope, the X-Tenant-ID header and the authentication
+// @Description schemes the deployment accepts are described for every operation. No API key is needed.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{} "OpenAPI 3 document"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/openapi.json [get]
+func (oh *OpenAPIHandler) Handle(c *gin.Context) {
+	oh.once.Do(func() {
+		var doc map[string]interface{}
+		doc, oh.err = openapi.Build(oh.routes(), oh.opts)
+		if oh.err == nil {
+			oh.doc, oh.err = json.Marshal(doc)
+		}
+	})
+	if oh.err != nil {
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to build OpenAPI document", map[string]interface{}{
+			"error": oh.err.Error(),
+		})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", oh.doc)
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/api/middleware"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/retrieval"
+)
+
+// Bounds of the retrieval override parameters
+const (
+	maxCandidateMultiplier = 10
+	maxHNSWEf              = 4096
+)
+
+// overrideParams are the query parameters that override retrieval settings
+var overrideParams = []string{"candidate_multiplier", "hnsw_ef", "fusion_weights", "recency_half_life", "importance_half_life"}
+
+// parseRetrievalOverrides reads the retrieval override parameters of a search. It returns nil
+// overrides when none are given, and the given parameters as they were sent, for traces. It
+// responds and returns false when the caller may not override retrieval or a value is invalid
+func parseRetrievalOverrides(c *gin.Context) (*models.RetrievalOverrides, map[string]string, bool) {
+	given := make(map[string]string)
+	for _, param := range overrideParams {
+		if value := c.Query(param); value != "" {
+			given[param] = value
+		}
+	}
+	if len(given) == 0 {
+		return nil, nil, true
+	}
+
+	if !middleware.ExperimentsAllowed(c) {
+		names := make([]string, 0, len(given))
+		for _, param := range overrideParams {
+			if _, ok := given[param]; ok {
+				names = append(names, param)
+			}
+		}
+		respondError(c, http.StatusForbidden, "INSUFFICIENT_SCOPE", "retrieval overrides need an API key with the experiment scope", map[string]interface{}{
+			"required_scope": models.ScopeExperiment,
+			"parameters":     names,
+		})
+		return nil, nil, false
+	}
+
+	overrides := &models.RetrievalOverrides{}
+	if value, ok := given["candidate_multiplier"]; ok {
+		multiplier, err := strconv.ParseFloat(value, 64)
+		if err != nil || multiplier < 1 || multiplier > maxCandidateMultiplier {
+			respondInvalidOverride(c, "candidate_multiplier", "candidate_multiplier must be between 1 and 10")
+			return nil, nil, false
+		}
+		overrides.CandidateMultiplier = multiplier
+	}
+	if value, ok := given["hnsw_ef"]; ok {
+		ef, err := strconv.Atoi(value)
+		if err != nil || ef < 1 || ef > maxHNSWEf {
+			respondInvalidOverride(c, "hnsw_ef", "hnsw_ef must be between 1 and 4096")
+			return nil, nil, false
+		}
+		overrides.HNSWEf = ef
+	}
+	if value, ok := given["fusion_weights"]; ok {
+		weights, err := parseFusionWeights(value)
+		if err != nil {
+			respondInvalidOverride(c, "fusion_weights", "fusion_weights must be a list of retriever=weight pairs with non-negative weights")
+			return nil, nil, false
+		}
+		overrides.FusionWeights = weights
+	}
+	if value, ok := given["recency_half_life"]; ok {
+		halfLife, err := time.ParseDuration(value)
+		if err != nil || halfLife <= 0 {
+			respondInvalidOverride(c, "recency_half_life", "recency_half_life must be a positive duration, e.g. 72h")
+			return nil, nil, false
+		}
+		overrides.RecencyHalfLife = halfLife
+	}
+	if value, ok := given["importance_half_life"]; ok {
+		halfLife, err := time.ParseDuration(value)
+		if err != nil || halfLife <= 0 {
+			respondInvalidOverride(c, "importance_half_life", "importance_half_life must be a positive duration, e.g. 720h")
+			return nil, nil, false
+		}
+		overrides.ImportanceHalfLife = halfLife
+	}
+
+	return overrides, given, true
+}
+
+// parseFusionWeights parses "name=weight,name=weight" into weights by retriever name
+func parseFusionWeights(value string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, pair := range strings.Split(value, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, errors.New("expected retriever=weight")
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+		if err != nil || parsed < 0 || math.IsInf(parsed, 0) {
+			return nil, errors.New("weight must be a non-negative number")
+		}
+		weights[strings.TrimSpace(name)] = parsed
+	}
+	return weights, nil
+}
+
+// respondInvalidOverride responds 400 to an invalid retrieval override parameter
+func respondInvalidOverride(c *gin.Context, param string, message string) {
+	respondError(c, http.StatusBadRequest, "INVALID_REQUEST", message, map[string]interface{}{
+		"field": param,
+	})
+}
+
+// respondOverrideRejected responds 400 when a search's overrides don't fit the retrieval pipeline
+// and reports whether it did
+func respondOverrideRejected(c *gin.Context, err error) bool {
+	if !errors.Is(err, retrieval.ErrInvalidOverride) {
+		return false
+	}
+	respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "retrieval overrides don't fit the search pipeline", map[string]interface{}{
+		"error": err.Error(),
+	})
+	return true
+}
@@ -1,7 +1,7 @@
 package handler
 
 import (
-	"context"
+	"errors"
 	"net/http"
 	"time"
 
@@ -9,18 +9,19 @@ import (
 	"github.com/google/uuid"
 
 	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
 	"refo-rag-server/internal/storage"
 )
 
 // PersonalInfoHandler handles personal information requests from guardians
 type PersonalInfoHandler struct {
-	personalInfoStore storage.PersonalInfoStore
+	personalInfoService *service.PersonalInfoService
 }
 
 // NewPersonalInfoHandler creates a new personal info handler
-func NewPersonalInfoHandler(personalInfoStore storage.PersonalInfoStore) *PersonalInfoHandler {
+func NewPersonalInfoHandler(personalInfoService *service.PersonalInfoService) *PersonalInfoHandler {
 	return &PersonalInfoHandler{
-		personalInfoStore: personalInfoStore,
+		personalInfoService: personalInfoService,
 	}
 }
 
@@ -31,9 +32,11 @@ func NewPersonalInfoHandler(personalInfoStore storage.PersonalInfoStore) *Person
 // @Accept json
 // @Produce json
 // @Param request body models.PersonalInfoCreateRequest true "Personal info creation request"
-// @Success 201 {object} models.APIResponse "Personal info created successfully"
-// @Failure 400 {object} models.APIResponse "Invalid request"
-// @Failure 500 {object} models.APIResponse "Server error"
+// @Success 201 {object} models.APIResponse[models.PersonalInfoResult] "Personal info created successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 403 {object} models.ErrorResponse "User is disabled"
+// @Failure 429 {object} models.ErrorResponse "Embedding budget spent"
+// @Failure 500 {object} models.ErrorResponse "Server error"
 // @Router /api/rag/personal-info [post]
 func (pih *PersonalInfoHandler) CreatePersonalInfo(c *gin.Context) {
 	startTime := time.Now()
@@ -42,7 +45,7 @@ func (pih *PersonalInfoHandler) CreatePersonalInfo(c *gin.Context) {
 
 	// Bind JSON request body
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INVALID_REQUEST",
@@ -69,8 +72,17 @@ func (pih *PersonalInfoHandler) CreatePersonalInfo(c *gin.Context) {
 	}
 
 	// Save personal info
-	if err := pih.personalInfoStore.SavePersonalInfo(context.Background(), personalInfo); err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+	if err := pih.personalInfoService.CreatePersonalInfo(c.Request.Context(), personalInfo); err != nil {
+		if errors.Is(err, service.ErrUserDisabled) {
+			respondError(c, http.StatusForbidden, "USER_DISABLED", "the user is disabled", map[string]interface{}{
+				"user_id": req.UserID,
+			})
+			return
+		}
+		if respondWrongRegion(c, err) || respondBudgetExceeded(c, err) || respondUnavailable(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INTERNAL_ERROR",
@@ -87,22 +99,14 @@ func (pih *PersonalInfoHandler) CreatePersonalInfo(c *gin.Context) {
 	processingTimeMs := time.Since(startTime).Milliseconds()
 
 	// Build response
-	infoResp := models.PersonalInfoResponse{
-		ID:         personalInfo.ID,
-		UserID:     personalInfo.UserID,
-		Content:    personalInfo.Content,
-		Category:   personalInfo.Category,
-		Importance: personalInfo.Importance,
-		CreatedAt:  personalInfo.CreatedAt.UTC().Format(time.RFC3339),
-		UpdatedAt:  personalInfo.UpdatedAt.UTC().Format(time.RFC3339),
-	}
+	infoResp := personalInfoResponse(personalInfo)
 
-	response := map[string]interface{}{
-		"personal_info":        infoResp,
-		"processing_time_ms":   processingTimeMs,
+	response := models.PersonalInfoResult{
+		PersonalInfo:     infoResp,
+		ProcessingTimeMs: processingTimeMs,
 	}
 
-	c.JSON(http.StatusCreated, models.APIResponse{
+	c.JSON(http.StatusCreated, models.APIResponse[models.PersonalInfoResult]{
 		Success:  true,
 		Data:     response,
 		Metadata: models.Metadata{},
@@ -115,16 +119,18 @@ func (pih *PersonalInfoHandler) CreatePersonalInfo(c *gin.Context) {
 // @Tags personal-info
 // @Produce json
 // @Param info_id path string true "Personal info ID"
-// @Success 200 {object} models.APIResponse "Personal info retrieved successfully"
-// @Failure 404 {object} models.APIResponse "Personal info not found"
-// @Failure 500 {object} models.APIResponse "Server error"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} models.APIResponse[models.PersonalInfoResult] "Personal info retrieved successfully"
+// @Success 304 "Not modified since the ETag in If-None-Match"
+// @Failure 404 {object} models.ErrorResponse "Personal info not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
 // @Router /api/rag/personal-info/{info_id} [get]
 func (pih *PersonalInfoHandler) GetPersonalInfo(c *gin.Context) {
 	startTime := time.Now()
 	infoID := c.Param("info_id")
 
 	if infoID == "" {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INVALID_REQUEST",
@@ -140,9 +146,12 @@ func (pih *PersonalInfoHandler) GetPersonalInfo(c *gin.Context) {
 	}
 
 	// Get personal info
-	personalInfo, err := pih.personalInfoStore.GetPersonalInfo(context.Background(), infoID)
+	personalInfo, err := pih.personalInfoService.GetPersonalInfo(c.Request.Context(), infoID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+		if respondUnavailable(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INTERNAL_ERROR",
@@ -157,39 +166,25 @@ func (pih *PersonalInfoHandler) GetPersonalInfo(c *gin.Context) {
 	}
 
 	if personalInfo == nil {
-		c.JSON(http.StatusNotFound, models.APIResponse{
-			Success: false,
-			Error: &models.ErrorInfo{
-				Code:    "PERSONAL_INFO_NOT_FOUND",
-				Message: "personal information not found",
-				Details: map[string]interface{}{
-					"info_id": infoID,
-				},
-			},
-			Metadata: models.Metadata{},
-		})
+		respondPersonalInfoNotFound(c, infoID)
 		return
 	}
 
 	processingTimeMs := time.Since(startTime).Milliseconds()
 
 	// Build response
-	infoResp := models.PersonalInfoResponse{
-		ID:         personalInfo.ID,
-		UserID:     personalInfo.UserID,
-		Content:    personalInfo.Content,
-		Category:   personalInfo.Category,
-		Importance: personalInfo.Importance,
-		CreatedAt:  personalInfo.CreatedAt.UTC().Format(time.RFC3339),
-		UpdatedAt:  personalInfo.UpdatedAt.UTC().Format(time.RFC3339),
+	infoResp := personalInfoResponse(personalInfo)
+	c.Header("Last-Modified", personalInfo.UpdatedAt.UTC().Format(http.TimeFormat))
+	if notModified(c, infoResp) {
+		return
 	}
 
-	response := map[string]interface{}{
-		"personal_info":        infoResp,
-		"processing_time_ms":   processingTimeMs,
+	response := models.PersonalInfoResult{
+		PersonalInfo:     infoResp,
+		ProcessingTimeMs: processingTimeMs,
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
+	c.JSON(http.StatusOK, models.APIResponse[models.PersonalInfoResult]{
 		Success:  true,
 		Data:     response,
 		Metadata: models.Metadata{},
@@ -202,16 +197,18 @@ func (pih *PersonalInfoHandler) GetPersonalInfo(c *gin.Context) {
 // @Tags personal-info
 // @Produce json
 // @Param user_id path string true "User ID"
-// @Success 200 {object} models.APIResponse "Personal info list retrieved successfully"
-// @Failure 400 {object} models.APIResponse "Invalid request"
-// @Failure 500 {object} models.APIResponse "Server error"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} models.APIResponse[models.PersonalInfoListResult] "Personal info list retrieved successfully"
+// @Success 304 "Not modified since the ETag in If-None-Match"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 500 {object} models.ErrorResponse "Server error"
 // @Router /api/rag/personal-info/user/{user_id} [get]
 func (pih *PersonalInfoHandler) GetPersonalInfoByUser(c *gin.Context) {
 	startTime := time.Now()
 	userID := c.Param("user_id")
 
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INVALID_REQUEST",
@@ -227,9 +224,12 @@ func (pih *PersonalInfoHandler) GetPersonalInfoByUser(c *gin.Context) {
 	}
 
 	// Get all personal info for user
-	personalInfoList, err := pih.personalInfoStore.GetPersonalInfoByUser(context.Background(), userID)
+	personalInfoList, err := pih.personalInfoService.GetPersonalInfoByUser(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+		if respondUnavailable(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INTERNAL_ERROR",
@@ -249,15 +249,7 @@ func (pih *PersonalInfoHandler) GetPersonalInfoByUser(c *gin.Context) {
 	items := make([]models.PersonalInfoResponse, 0)
 	if personalInfoList != nil {
 		for _, info := range personalInfoList {
-			items = append(items, models.PersonalInfoResponse{
-				ID:         info.ID,
-				UserID:     info.UserID,
-				Content:    info.Content,
-				Category:   info.Category,
-				Importance: info.Importance,
-				CreatedAt:  info.CreatedAt.UTC().Format(time.RFC3339),
-				UpdatedAt:  info.UpdatedAt.UTC().Format(time.RFC3339),
-			})
+			items = append(items, personalInfoResponse(info))
 		}
 	}
 
@@ -266,13 +258,16 @@ func (pih *PersonalInfoHandler) GetPersonalInfoByUser(c *gin.Context) {
 		Total:  len(items),
 		UserID: userID,
 	}
+	if notModified(c, listResp) {
+		return
+	}
 
-	response := map[string]interface{}{
-		"personal_info_list":   listResp,
-		"processing_time_ms":   processingTimeMs,
+	response := models.PersonalInfoListResult{
+		PersonalInfoList: listResp,
+		ProcessingTimeMs: processingTimeMs,
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
+	c.JSON(http.StatusOK, models.APIResponse[models.PersonalInfoListResult]{
 		Success:  true,
 		Data:     response,
 		Metadata: models.Metadata{},
@@ -286,18 +281,22 @@ func (pih *PersonalInfoHandler) GetPersonalInfoByUser(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param info_id path string true "Personal info ID"
+// @Param If-Match header string false "ETag the update is based on"
+// @Param If-Unmodified-Since header string false "Only update if unmodified since this HTTP date"
 // @Param request body models.PersonalInfoUpdateRequest true "Personal info update request"
-// @Success 200 {object} models.APIResponse "Personal info updated successfully"
-// @Failure 400 {object} models.APIResponse "Invalid request"
-// @Failure 404 {object} models.APIResponse "Personal info not found"
-// @Failure 500 {object} models.APIResponse "Server error"
+// @Success 200 {object} models.APIResponse[models.PersonalInfoResult] "Personal info updated successfully"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Personal info not found"
+// @Failure 412 {object} models.ErrorResponse "Entry modified since the given version"
+// @Failure 429 {object} models.ErrorResponse "Embedding budget spent"
+// @Failure 500 {object} models.ErrorResponse "Server error"
 // @Router /api/rag/personal-info/{info_id} [put]
 func (pih *PersonalInfoHandler) UpdatePersonalInfo(c *gin.Context) {
 	startTime := time.Now()
 	infoID := c.Param("info_id")
 
 	if infoID == "" {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INVALID_REQUEST",
@@ -316,7 +315,7 @@ func (pih *PersonalInfoHandler) UpdatePersonalInfo(c *gin.Context) {
 
 	// Bind JSON request body
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INVALID_REQUEST",
@@ -331,9 +330,12 @@ func (pih *PersonalInfoHandler) UpdatePersonalInfo(c *gin.Context) {
 	}
 
 	// Get existing personal info
-	personalInfo, err := pih.personalInfoStore.GetPersonalInfo(context.Background(), infoID)
+	personalInfo, err := pih.personalInfoService.GetPersonalInfo(c.Request.Context(), infoID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+		if respondUnavailable(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INTERNAL_ERROR",
@@ -348,20 +350,18 @@ func (pih *PersonalInfoHandler) UpdatePersonalInfo(c *gin.Context) {
 	}
 
 	if personalInfo == nil {
-		c.JSON(http.StatusNotFound, models.APIResponse{
-			Success: false,
-			Error: &models.ErrorInfo{
-				Code:    "PERSONAL_INFO_NOT_FOUND",
-				Message: "personal information not found",
-				Details: map[string]interface{}{
-					"info_id": infoID,
-				},
-			},
-			Metadata: models.Metadata{},
-		})
+		respondPersonalInfoNotFound(c, infoID)
 		return
 	}
 
+	// Reject the update if the client's copy is stale; the write re-checks updated_at so an edit
+	// landing between this check and the update is caught too
+	conditional := hasPreconditions(c)
+	if conditional && !preconditionsHold(c, personalInfoResponse(personalInfo), personalInfo.UpdatedAt) {
+		return
+	}
+	readAt := personalInfo.UpdatedAt
+
 	// Update fields
 	if req.Content != "" {
 		personalInfo.Content = req.Content
@@ -375,8 +375,25 @@ func (pih *PersonalInfoHandler) UpdatePersonalInfo(c *gin.Context) {
 	personalInfo.UpdatedAt = time.Now()
 
 	// Save updated personal info
-	if err := pih.personalInfoStore.UpdatePersonalInfo(context.Background(), personalInfo); err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+	if conditional {
+		err = pih.personalInfoService.UpdatePersonalInfoIfUnchanged(c.Request.Context(), personalInfo, readAt)
+	} else {
+		err = pih.personalInfoService.UpdatePersonalInfo(c.Request.Context(), personalInfo)
+	}
+	if err != nil {
+		if respondWrongRegion(c, err) || respondBudgetExceeded(c, err) || respondUnavailable(c, err) {
+			return
+		}
+		if errors.Is(err, storage.ErrPersonalInfoChanged) {
+			respondError(c, http.StatusPreconditionFailed, "PRECONDITION_FAILED", "the resource was modified since the version the request is based on; fetch it again and retry", nil)
+			return
+		}
+		// Deleted between the read above and the update
+		if errors.Is(err, storage.ErrNotFound) {
+			respondPersonalInfoNotFound(c, infoID)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INTERNAL_ERROR",
@@ -393,22 +410,16 @@ func (pih *PersonalInfoHandler) UpdatePersonalInfo(c *gin.Context) {
 	processingTimeMs := time.Since(startTime).Milliseconds()
 
 	// Build response
-	infoResp := models.PersonalInfoResponse{
-		ID:         personalInfo.ID,
-		UserID:     personalInfo.UserID,
-		Content:    personalInfo.Content,
-		Category:   personalInfo.Category,
-		Importance: personalInfo.Importance,
-		CreatedAt:  personalInfo.CreatedAt.UTC().Format(time.RFC3339),
-		UpdatedAt:  personalInfo.UpdatedAt.UTC().Format(time.RFC3339),
-	}
+	infoResp := personalInfoResponse(personalInfo)
+	c.Header("ETag", resourceETag(infoResp))
+	c.Header("Last-Modified", personalInfo.UpdatedAt.UTC().Format(http.TimeFormat))
 
-	response := map[string]interface{}{
-		"personal_info":        infoResp,
-		"processing_time_ms":   processingTimeMs,
+	response := models.PersonalInfoResult{
+		PersonalInfo:     infoResp,
+		ProcessingTimeMs: processingTimeMs,
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
+	c.JSON(http.StatusOK, models.APIResponse[models.PersonalInfoResult]{
 		Success:  true,
 		Data:     response,
 		Metadata: models.Metadata{},
@@ -421,16 +432,19 @@ func (pih *PersonalInfoHandler) UpdatePersonalInfo(c *gin.Context) {
 // @Tags personal-info
 // @Produce json
 // @Param info_id path string true "Personal info ID"
-// @Success 200 {object} models.APIResponse "Personal info deleted successfully"
-// @Failure 404 {object} models.APIResponse "Personal info not found"
-// @Failure 500 {object} models.APIResponse "Server error"
+// @Param If-Match header string false "ETag the delete is based on"
+// @Param If-Unmodified-Since header string false "Only delete if unmodified since this HTTP date"
+// @Success 200 {object} models.APIResponse[models.PersonalInfoDeleteResponse] "Personal info deleted successfully"
+// @Failure 404 {object} models.ErrorResponse "Personal info not found"
+// @Failure 412 {object} models.ErrorResponse "Entry modified since the given version"
+// @Failure 500 {object} models.ErrorResponse "Server error"
 // @Router /api/rag/personal-info/{info_id} [delete]
 func (pih *PersonalInfoHandler) DeletePersonalInfo(c *gin.Context) {
 	startTime := time.Now()
 	infoID := c.Param("info_id")
 
 	if infoID == "" {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INVALID_REQUEST",
@@ -446,9 +460,12 @@ func (pih *PersonalInfoHandler) DeletePersonalInfo(c *gin.Context) {
 	}
 
 	// Check if personal info exists
-	personalInfo, err := pih.personalInfoStore.GetPersonalInfo(context.Background(), infoID)
+	personalInfo, err := pih.personalInfoService.GetPersonalInfo(c.Request.Context(), infoID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+		if respondUnavailable(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INTERNAL_ERROR",
@@ -463,23 +480,36 @@ func (pih *PersonalInfoHandler) DeletePersonalInfo(c *gin.Context) {
 	}
 
 	if personalInfo == nil {
-		c.JSON(http.StatusNotFound, models.APIResponse{
-			Success: false,
-			Error: &models.ErrorInfo{
-				Code:    "PERSONAL_INFO_NOT_FOUND",
-				Message: "personal information not found",
-				Details: map[string]interface{}{
-					"info_id": infoID,
-				},
-			},
-			Metadata: models.Metadata{},
-		})
+		respondPersonalInfoNotFound(c, infoID)
+		return
+	}
+
+	// Reject the delete if the client's copy is stale
+	conditional := hasPreconditions(c)
+	if conditional && !preconditionsHold(c, personalInfoResponse(personalInfo), personalInfo.UpdatedAt) {
 		return
 	}
 
 	// Delete personal info
-	if err := pih.personalInfoStore.DeletePersonalInfo(context.Background(), infoID); err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+	if conditional {
+		err = pih.personalInfoService.DeletePersonalInfoIfUnchanged(c.Request.Context(), infoID, personalInfo.UpdatedAt)
+	} else {
+		err = pih.personalInfoService.DeletePersonalInfo(c.Request.Context(), infoID)
+	}
+	if errors.Is(err, storage.ErrPersonalInfoChanged) {
+		respondError(c, http.StatusPreconditionFailed, "PRECONDITION_FAILED", "the resource was modified since the version the request is based on; fetch it again and retry", nil)
+		return
+	}
+	// Deleted between the read above and the delete
+	if errors.Is(err, storage.ErrNotFound) {
+		respondPersonalInfoNotFound(c, infoID)
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INTERNAL_ERROR",
@@ -495,14 +525,36 @@ func (pih *PersonalInfoHandler) DeletePersonalInfo(c *gin.Context) {
 
 	processingTimeMs := time.Since(startTime).Milliseconds()
 
-	response := map[string]interface{}{
-		"deleted_info_id":      infoID,
-		"processing_time_ms":   processingTimeMs,
+	response := models.PersonalInfoDeleteResponse{
+		DeletedInfoID:    infoID,
+		ProcessingTimeMs: processingTimeMs,
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
+	c.JSON(http.StatusOK, models.APIResponse[models.PersonalInfoDeleteResponse]{
 		Success:  true,
 		Data:     response,
 		Metadata: models.Metadata{},
 	})
-}
\ No newline at end of file
+}
+
+// personalInfoResponse converts a stored entry to its API representation
+func personalInfoResponse(info *models.PersonalInfo) models.PersonalInfoResponse {
+	return models.PersonalInfoResponse{
+		ID:          info.ID,
+		UserID:      info.UserID,
+		Content:     info.Content,
+		Category:    info.Category,
+		Importance:  info.Importance,
+		Pinned:      info.Pinned,
+		Suppression: info.Suppression,
+		CreatedAt:   info.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:   info.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// respondPersonalInfoNotFound writes the response for a missing personal info entry
+func respondPersonalInfoNotFound(c *gin.Context, infoID string) {
+	respondError(c, http.StatusNotFound, "PERSONAL_INFO_NOT_FOUND", "personal information not found", map[string]interface{}{
+		"info_id": infoID,
+	})
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/filter"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+	"refo-rag-server/internal/storage"
+)
+
+// Playground runs a search through several pipeline configurations side by side
+// @Summary Compare search pipeline configurations
+// @Description Run one conversation search through up to 8 named pipeline configurations, e.g. dense only,
+// @Description hybrid and reranked, and return each configuration's results side by side with final and raw
+// @Description scores, latency and a preview of each conversation. The first configuration is the baseline:
+// @Description every other run reports its overlap with it, the conversations it added and dropped, and each
+// @Description result's rank in the baseline. A configuration replaces the stage lists and reranker weights it
+// @Description sets and keeps the configured pipeline's others; an empty list drops a kind of stage. Retrieval
+// @Description overrides are accepted as on /debug/retrieval. Runs aren't logged as searches.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminAPIKey
+// @Param request body models.PlaygroundRequest true "Query and configurations"
+// @Success 200 {object} models.APIResponse[models.PlaygroundResponse] "Runs in request order"
+// @Failure 400 {object} models.ErrorResponse "Invalid request or configuration"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 429 {object} models.ErrorResponse "Embedding budget spent"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/admin/search/playground [post]
+func (dh *DebugHandler) Playground(c *gin.Context) {
+	var body models.PlaygroundRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	req := models.ConversationSearchRequest{
+		Query:    strings.TrimSpace(body.Query),
+		UserID:   body.UserID,
+		Limit:    body.TopK,
+		MinScore: body.MinScore,
+	}
+	if req.Query == "" {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "query is required", map[string]interface{}{
+			"field": "query",
+		})
+		return
+	}
+	if req.Limit < 0 || req.Limit > 100 {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "top_k must be between 1 and 100", map[string]interface{}{
+			"field": "top_k",
+		})
+		return
+	}
+	metadataFilter, err := filter.Parse(body.Filter)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_FILTER", "invalid metadata filter", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	req.Filter = metadataFilter
+
+	configs := make([]service.PlaygroundConfig, 0, len(body.Configurations))
+	names := make(map[string]bool, len(body.Configurations))
+	for i, config := range body.Configurations {
+		if names[config.Name] {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "configuration names must be unique", map[string]interface{}{
+				"name": config.Name,
+			})
+			return
+		}
+		names[config.Name] = true
+
+		overrides, field, message := playgroundOverrides(config)
+		if message != "" {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST", message, map[string]interface{}{
+				"configuration": i,
+				"field":         field,
+			})
+			return
+		}
+		configs = append(configs, service.PlaygroundConfig{PlaygroundConfiguration: config, Overrides: overrides})
+	}
+
+	resp, err := dh.conversationService.SearchPlayground(c.Request.Context(), &req, configs)
+	if errors.Is(err, service.ErrInvalidPlayground) {
+		respondError(c, http.StatusBadRequest, "INVALID_CONFIGURATION", "a configuration doesn't fit the search pipeline", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, storage.ErrUserScopeRequired) {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "user_id is required", map[string]interface{}{
+			"field":  "user_id",
+			"reason": "searches are scoped to a single user",
+		})
+		return
+	}
+	if respondBudgetExceeded(c, err) {
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to run playground search", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, resp)
+}
+
+// playgroundOverrides checks a configuration's retrieval overrides against the bounds of the
+// search parameters. It returns nil overrides when none are set, or the invalid field and why
+func playgroundOverrides(config models.PlaygroundConfiguration) (*models.RetrievalOverrides, string, string) {
+	overrides := &models.RetrievalOverrides{
+		CandidateMultiplier: config.CandidateMultiplier,
+		HNSWEf:              config.HNSWEf,
+		FusionWeights:       config.FusionWeights,
+	}
+	if config.CandidateMultiplier != 0 && (config.CandidateMultiplier < 1 || config.CandidateMultiplier > maxCandidateMultiplier) {
+		return nil, "candidate_multiplier", "candidate_multiplier must be between 1 and 10"
+	}
+	if config.HNSWEf < 0 || config.HNSWEf > maxHNSWEf {
+		return nil, "hnsw_ef", "hnsw_ef must be between 1 and 4096"
+	}
+	for _, weight := range config.FusionWeights {
+		if weight < 0 {
+			return nil, "fusion_weights", "fusion weights must not be negative"
+		}
+	}
+	if config.RecencyWeight != nil && (*config.RecencyWeight < 0 || *config.RecencyWeight > 1) {
+		return nil, "recency_weight", "recency_weight must be between 0 and 1"
+	}
+	if config.ImportanceWeight != nil && (*config.ImportanceWeight < 0 || *config.ImportanceWeight > 1) {
+		return nil, "importance_weight", "importance_weight must be between 0 and 1"
+	}
+	if config.RecencyHalfLife != "" {
+		halfLife, err := time.ParseDuration(config.RecencyHalfLife)
+		if err != nil || halfLife <= 0 {
+			return nil, "recency_half_life", "recency_half_life must be a positive duration, e.g. 72h"
+		}
+		overrides.RecencyHalfLife = halfLife
+	}
+	if config.ImportanceHalfLife != "" {
+		halfLife, err := time.ParseDuration(config.ImportanceHalfLife)
+		if err != nil || halfLife <= 0 {
+			return nil, "importance_half_life", "importance_half_life must be a positive duration, e.g. 720h"
+		}
+		overrides.ImportanceHalfLife = halfLife
+	}
+
+	if overrides.CandidateMultiplier == 0 && overrides.HNSWEf == 0 && len(overrides.FusionWeights) == 0 &&
+		overrides.RecencyHalfLife == 0 && overrides.ImportanceHalfLife == 0 {
+		return nil, "", ""
+	}
+	return overrides, "", ""
+}
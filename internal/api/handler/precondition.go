@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hasPreconditions reports whether a write carries If-Match or If-Unmodified-Since
+func hasPreconditions(c *gin.Context) bool {
+	return c.GetHeader("If-Match") != "" || c.GetHeader("If-Unmodified-Since") != ""
+}
+
+// preconditionsHold evaluates If-Match against the resource's ETag, comparing strongly, or, when
+// If-Match is absent, If-Unmodified-Since against its last modification, as RFC 9110 orders them.
+// When a precondition fails it writes 412 with the current ETag and reports false
+func preconditionsHold(c *gin.Context, resource interface{}, modifiedAt time.Time) bool {
+	etag := resourceETag(resource)
+
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		if etagStrongMatches(ifMatch, etag) {
+			return true
+		}
+		respondPreconditionFailed(c, etag, modifiedAt)
+		return false
+	}
+
+	if ifUnmodifiedSince := c.GetHeader("If-Unmodified-Since"); ifUnmodifiedSince != "" {
+		since, err := http.ParseTime(ifUnmodifiedSince)
+		if err != nil {
+			// An invalid date is ignored, as RFC 9110 requires
+			return true
+		}
+		// HTTP dates have second precision
+		if modifiedAt.Truncate(time.Second).After(since) {
+			respondPreconditionFailed(c, etag, modifiedAt)
+			return false
+		}
+	}
+
+	return true
+}
+
+// respondPreconditionFailed writes 412 PRECONDITION_FAILED with the resource's current version
+func respondPreconditionFailed(c *gin.Context, etag string, modifiedAt time.Time) {
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+	if !modifiedAt.IsZero() {
+		c.Header("Last-Modified", modifiedAt.UTC().Format(http.TimeFormat))
+	}
+	respondError(c, http.StatusPreconditionFailed, "PRECONDITION_FAILED", "the resource was modified since the version the request is based on; fetch it again and retry", map[string]interface{}{
+		"etag":       etag,
+		"updated_at": modifiedAt.UTC().Format(time.RFC3339),
+	})
+}
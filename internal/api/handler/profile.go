@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// ProfileHandler handles user profile requests
+type ProfileHandler struct {
+	profileService *service.ProfileService
+}
+
+// NewProfileHandler creates a new profile handler
+func NewProfileHandler(profileService *service.ProfileService) *ProfileHandler {
+	return &ProfileHandler{
+		profileService: profileService,
+	}
+}
+
+// GetProfile retrieves a user's synthesized profile
+// @Summary Get a user profile
+// @Description Get a structured long-term profile (preferences, routines, health notes) synthesized from the
+// @Description user's personal info and highest-scored conversations. Profiles are cached and refreshed periodically.
+// @Tags users
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param refresh query bool false "Regenerate the profile instead of serving the cached one"
+// @Success 200 {object} models.APIResponse[models.UserProfile] "User profile"
+// @Failure 404 {object} models.ErrorResponse "No data for user"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/users/{user_id}/profile [get]
+func (ph *ProfileHandler) GetProfile(c *gin.Context) {
+	userID := c.Param("user_id")
+	refresh := c.Query("refresh") == "true"
+
+	profile, err := ph.profileService.GetProfile(c.Request.Context(), userID, refresh)
+	if errors.Is(err, service.ErrProfileEmpty) {
+		respondError(c, http.StatusNotFound, "NOT_FOUND", "no personal info or conversations for user", map[string]interface{}{
+			"user_id": userID,
+		})
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get user profile", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess[*models.UserProfile](c, http.StatusOK, profile)
+}
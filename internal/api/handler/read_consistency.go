@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/storage"
+)
+
+// applyReadConsistency makes the request's vector reads use the read_consistency parameter, if
+// given, instead of QDRANT_READ_CONSISTENCY. It responds and returns false when the value is
+// invalid
+func applyReadConsistency(c *gin.Context) bool {
+	consistency := c.Query("read_consistency")
+	if consistency == "" {
+		return true
+	}
+	if !storage.ValidReadConsistency(consistency) {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "read_consistency must be majority, quorum, all or a positive number of replicas", map[string]interface{}{
+			"field": "read_consistency",
+		})
+		return false
+	}
+	c.Request = c.Request.WithContext(storage.WithReadConsistency(c.Request.Context(), consistency))
+	return true
+}
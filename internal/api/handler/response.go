@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/deadline"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/residency"
+	"refo-rag-server/internal/storage"
+	"refo-rag-server/internal/usage"
+)
+
+// respondError writes an ErrorResponse with the given status and error code
+func respondError(c *gin.Context, status int, code string, message string, details interface{}) {
+	c.JSON(status, models.ErrorResponse{
+		Success: false,
+		Error: &models.ErrorInfo{
+			Code:    code,
+			Message: message,
+			Details: details,
+		},
+		Metadata: models.Metadata{},
+	})
+}
+
+// respondSuccess writes a successful APIResponse with the given status and payload
+func respondSuccess[T any](c *gin.Context, status int, data T) {
+	c.JSON(status, models.APIResponse[T]{
+		Success:  true,
+		Data:     data,
+		Metadata: models.Metadata{},
+	})
+}
+
+// respondBudgetExceeded writes 429 BUDGET_EXCEEDED with a Retry-After of when the budget resets if
+// err is a spent embedding budget, and reports whether it did
+func respondBudgetExceeded(c *gin.Context, err error) bool {
+	var budgetErr *usage.BudgetError
+	if !errors.As(err, &budgetErr) {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(time.Until(budgetErr.ResetsAt).Seconds())+1))
+	respondError(c, http.StatusTooManyRequests, "BUDGET_EXCEEDED", "the embedding budget is spent; try again after it resets", map[string]interface{}{
+		"period":    budgetErr.Period,
+		"limit":     budgetErr.Limit,
+		"used":      budgetErr.Used,
+		"resets_at": budgetErr.ResetsAt.Format(time.RFC3339),
+	})
+	return true
+}
+
+// storageRetryAfterSeconds is the Retry-After hint sent when storage is unavailable
+const storageRetryAfterSeconds = "5"
+
+// respondUnavailable writes 503 STORAGE_UNAVAILABLE with a Retry-After if err is a storage failure
+// that may succeed when retried, or 504 DEADLINE_EXCEEDED if it is the caller's deadline passing,
+// and reports whether it did; other failures stay 500s
+func respondUnavailable(c *gin.Context, err error) bool {
+	if deadline.Expired(c.Request.Context(), err) {
+		metrics.DeadlineExceeded.WithLabelValues(c.FullPath()).Inc()
+		respondError(c, http.StatusGatewayTimeout, "DEADLINE_EXCEEDED", "the request did not finish before its deadline", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return true
+	}
+	if !storage.Retryable(err) {
+		return false
+	}
+
+	c.Header("Retry-After", storageRetryAfterSeconds)
+	respondError(c, http.StatusServiceUnavailable, "STORAGE_UNAVAILABLE", "storage is temporarily unavailable; retry the request", map[string]interface{}{
+		"error": err.Error(),
+	})
+	return true
+}
+
+// respondWrongRegion writes a 421 response if err refuses data homed in another region and
+// reports whether it did
+func respondWrongRegion(c *gin.Context, err error) bool {
+	var regionErr *residency.WrongRegionError
+	if !errors.As(err, &regionErr) {
+		return false
+	}
+
+	respondError(c, http.StatusMisdirectedRequest, "WRONG_REGION", "the requested data is homed in another region", map[string]interface{}{
+		"region":       regionErr.Region,
+		"region_url":   regionErr.URL,
+		"local_region": regionErr.Local,
+	})
+	return true
+}
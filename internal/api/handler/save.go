@@ -1,15 +1,24 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"refo-rag-server/internal/ingest"
 	"refo-rag-server/internal/models"
 	"refo-rag-server/internal/service"
+	"refo-rag-server/internal/usage"
 )
 
+// maxIngestBodyBytes bounds the size of a conversation save request body
+const maxIngestBodyBytes = 10 << 20
+
 // SaveConversationHandler handles conversation save requests
 type SaveConversationHandler struct {
 	conversationService *service.ConversationService
@@ -24,23 +33,94 @@ func NewSaveConversationHandler(conversationService *service.ConversationService
 
 // Handle processes save conversation requests
 // @Summary Save a conversation
-// @Description Save a new conversation with messages and metadata
+// @Description Save a new conversation with messages and metadata. Besides the native format, the body
+// @Description can be an OpenAI chat-completion message array (format=openai), a LINE or KakaoTalk chat
+// @Description export (format=line, format=kakaotalk), or a "Speaker: text" transcript (format=transcript).
+// @Description Saves are eventually consistent: the conversation can be read by ID at once, but its vector may
+// @Description still be queued. With wait_for_indexing the response waits until search finds the conversation
+// @Description and reports consistency=searchable; if it isn't searchable in time the response is 503
+// @Description NOT_SEARCHABLE and the conversation stays stored, to become searchable later.
+// @Description durability picks when the save is acknowledged: fast once the conversation is committed, with the
+// @Description vector written in the background; safe once the vector is written too; queued once the conversation
+// @Description is committed with a job that embeds it, answered with 202 and the job's ID. Only safe saves can
+// @Description wait_for_indexing.
+// @Description lane is interactive, the default, or backfill for history a chat platform replays. When the server
+// @Description shapes ingestion, a save arriving faster than embeddings are let through is queued as if durability
+// @Description were queued and answered with 202 and shaped=true; backfill saves are queued sooner and embedded after
+// @Description interactive ones.
 // @Tags conversations
 // @Accept json
+// @Accept plain
 // @Produce json
 // @Param request body models.ConversationSaveRequest true "Conversation save request"
-// @Success 201 {object} models.APIResponse "Conversation saved successfully"
-// @Failure 400 {object} models.APIResponse "Invalid request"
-// @Failure 500 {object} models.APIResponse "Server error"
+// @Param format query string false "Body format" Enums(native, openai, line, kakaotalk, transcript) default(native)
+// @Param conversation_id query string false "Conversation ID for formats whose body does not carry one"
+// @Param assistant_speakers query string false "Comma-separated chat export speakers stored with the assistant role"
+// @Param tz query string false "IANA time zone of chat export timestamps" default(UTC)
+// @Param wait_for_indexing query bool false "Respond only once the conversation is searchable, as with wait_for_indexing in the body"
+// @Param durability query string false "When the save is acknowledged, as with durability in the body" Enums(fast, safe, queued)
+// @Param lane query string false "Ingestion lane, as with lane in the body" Enums(interactive, backfill)
+// @Success 201 {object} models.APIResponse[models.SaveResponse] "Conversation saved successfully"
+// @Success 202 {object} models.APIResponse[models.SaveResponse] "Conversation stored and queued for embedding"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 409 {object} models.ErrorResponse "Session is closed"
+// @Failure 403 {object} models.ErrorResponse "User is disabled, or conversation_id names another user's conversation"
+// @Failure 429 {object} models.ErrorResponse "Embedding budget spent"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Failure 503 {object} models.ErrorResponse "Stored, but not searchable within the wait"
 // @Router /api/rag/conversation/store [post]
 func (sch *SaveConversationHandler) Handle(c *gin.Context) {
 	startTime := time.Now()
 
-	var req models.ConversationSaveRequest
+	format := c.DefaultQuery("format", ingest.FormatNative)
+	adapter, ok := ingest.Lookup(format)
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error: &models.ErrorInfo{
+				Code:    "UNSUPPORTED_FORMAT",
+				Message: "unsupported transcript format",
+				Details: map[string]interface{}{
+					"provided_format": format,
+					"valid_formats":   ingest.Formats(),
+				},
+			},
+			Metadata: models.Metadata{},
+		})
+		return
+	}
+
+	opts := ingest.Options{
+		ConversationID:    c.Query("conversation_id"),
+		AssistantSpeakers: strings.Split(c.Query("assistant_speakers"), ","),
+	}
+	if tz := c.Query("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Error: &models.ErrorInfo{
+					Code:    "INVALID_REQUEST",
+					Message: "invalid time zone",
+					Details: map[string]interface{}{
+						"tz": tz,
+					},
+				},
+				Metadata: models.Metadata{},
+			})
+			return
+		}
+		opts.Location = loc
+	}
 
-	// Bind JSON request body
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+	// Read and normalize the request body
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIngestBodyBytes))
+	var result *ingest.Result
+	if err == nil {
+		result, err = adapter.Parse(body, opts)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INVALID_REQUEST",
@@ -53,10 +133,20 @@ func (sch *SaveConversationHandler) Handle(c *gin.Context) {
 		})
 		return
 	}
+	req := *result.Request
+	if wait, err := strconv.ParseBool(c.Query("wait_for_indexing")); err == nil && wait {
+		req.WaitForIndexing = true
+	}
+	if durability := c.Query("durability"); durability != "" {
+		req.Durability = durability
+	}
+	if lane := c.Query("lane"); lane != "" {
+		req.Lane = lane
+	}
 
 	// Validate required fields
 	if req.ConversationID == "" || len(req.Messages) == 0 {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INVALID_REQUEST",
@@ -71,10 +161,73 @@ func (sch *SaveConversationHandler) Handle(c *gin.Context) {
 		return
 	}
 
-	// Validate messages have content
+	// Validate custom metadata fields
+	if req.Metadata != nil {
+		if len(req.Metadata.SessionID) > 64 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Error: &models.ErrorInfo{
+					Code:    "INVALID_METADATA",
+					Message: "session_id must be at most 64 characters",
+				},
+				Metadata: models.Metadata{},
+			})
+			return
+		}
+		if err := req.Metadata.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Error: &models.ErrorInfo{
+					Code:    "INVALID_METADATA",
+					Message: "invalid conversation metadata",
+					Details: map[string]interface{}{
+						"error": err.Error(),
+					},
+				},
+				Metadata: models.Metadata{},
+			})
+			return
+		}
+	}
+
+	// Validate messages have content and unique message IDs
+	seenMessageIDs := make(map[string]bool)
 	for i, msg := range req.Messages {
+		if msg.MessageID != "" {
+			if seenMessageIDs[msg.MessageID] || len(msg.MessageID) > 255 {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Success: false,
+					Error: &models.ErrorInfo{
+						Code:    "INVALID_REQUEST",
+						Message: "invalid message_id",
+						Details: map[string]interface{}{
+							"message_index": i,
+							"reason":        "message_id must be unique within the conversation and at most 255 characters",
+						},
+					},
+					Metadata: models.Metadata{},
+				})
+				return
+			}
+			seenMessageIDs[msg.MessageID] = true
+		}
+		if len(msg.Speaker) > 255 || len(msg.DisplayName) > 255 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Error: &models.ErrorInfo{
+					Code:    "INVALID_REQUEST",
+					Message: "speaker fields too long",
+					Details: map[string]interface{}{
+						"message_index": i,
+						"reason":        "speaker and display_name must be at most 255 characters",
+					},
+				},
+				Metadata: models.Metadata{},
+			})
+			return
+		}
 		if msg.Content == "" {
-			c.JSON(http.StatusBadRequest, models.APIResponse{
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
 				Success: false,
 				Error: &models.ErrorInfo{
 					Code:    "INVALID_REQUEST",
@@ -88,15 +241,15 @@ func (sch *SaveConversationHandler) Handle(c *gin.Context) {
 			})
 			return
 		}
-		if msg.Role != "user" && msg.Role != "assistant" {
-			c.JSON(http.StatusBadRequest, models.APIResponse{
+		if !models.IsValidRole(msg.Role) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
 				Success: false,
 				Error: &models.ErrorInfo{
 					Code:    "INVALID_REQUEST",
 					Message: "invalid message role",
 					Details: map[string]interface{}{
 						"message_index": i,
-						"valid_roles":   []string{"user", "assistant"},
+						"valid_roles":   models.ValidRoles,
 						"provided_role": msg.Role,
 					},
 				},
@@ -106,10 +259,65 @@ func (sch *SaveConversationHandler) Handle(c *gin.Context) {
 		}
 	}
 
-	// Save conversation
-	_, err := sch.conversationService.SaveConversation(c.Request.Context(), &req)
+	// Save conversation, metering the embedding tokens it uses
+	ctx, meter := usage.WithMeter(c.Request.Context())
+	saved, err := sch.conversationService.SaveConversation(ctx, &req)
+	if errors.Is(err, service.ErrSessionClosed) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error: &models.ErrorInfo{
+				Code:    "SESSION_CLOSED",
+				Message: "cannot save a conversation into a closed session",
+				Details: map[string]interface{}{
+					"session_id": req.Metadata.SessionID,
+				},
+			},
+			Metadata: models.Metadata{},
+		})
+		return
+	}
+	if errors.Is(err, service.ErrUserDisabled) {
+		respondError(c, http.StatusForbidden, "USER_DISABLED", "the user is disabled", map[string]interface{}{
+			"user_id": req.UserID,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrConversationOwner) {
+		respondError(c, http.StatusForbidden, "CONVERSATION_OWNER_MISMATCH", "the conversation belongs to another user", map[string]interface{}{
+			"conversation_id": req.ConversationID,
+			"user_id":         req.UserID,
+		})
+		return
+	}
+	if respondWrongRegion(c, err) || respondBudgetExceeded(c, err) {
+		return
+	}
+	if errors.Is(err, service.ErrInvalidDurability) {
+		respondError(c, http.StatusBadRequest, "INVALID_DURABILITY", "the requested durability can't be used for this save", map[string]interface{}{
+			"error":              err.Error(),
+			"valid_durabilities": models.ValidDurabilities,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidLane) {
+		respondError(c, http.StatusBadRequest, "INVALID_LANE", "unknown ingestion lane", map[string]interface{}{
+			"error":       err.Error(),
+			"valid_lanes": models.ValidLanes,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrNotSearchable) {
+		respondError(c, http.StatusServiceUnavailable, "NOT_SEARCHABLE", "the conversation was stored but is not searchable yet", map[string]interface{}{
+			"conversation_id": req.ConversationID,
+			"error":           err.Error(),
+		})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+		if respondUnavailable(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INTERNAL_ERROR",
@@ -128,13 +336,26 @@ func (sch *SaveConversationHandler) Handle(c *gin.Context) {
 	// Build save response
 	saveResp := models.SaveResponse{
 		ConversationID:   req.ConversationID,
-		VectorsCreated:   1,
+		VectorsCreated:   saved.VectorsCreated,
 		MessagesStored:   len(req.Messages),
+		MessagesSkipped:  result.Skipped,
 		StoredAt:         time.Now().UTC().Format(time.RFC3339),
 		ProcessingTimeMs: processingTimeMs,
+		Usage:            meter.Usage(),
+		Unembedded:       saved.Unembedded,
+		Status:           saved.Status,
+		Consistency:      saved.Consistency,
+		Durability:       saved.Durability,
+		JobID:            saved.JobID,
+		Shaped:           saved.Shaped,
 	}
 
-	c.JSON(http.StatusCreated, models.APIResponse{
+	// A queued save is accepted but not yet processed
+	status := http.StatusCreated
+	if saved.Durability == models.DurabilityQueued {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, models.APIResponse[models.SaveResponse]{
 		Success:  true,
 		Data:     saveResp,
 		Metadata: models.Metadata{},
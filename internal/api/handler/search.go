@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -8,8 +9,14 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"refo-rag-server/internal/bandit"
+	"refo-rag-server/internal/deadline"
+	"refo-rag-server/internal/filter"
 	"refo-rag-server/internal/models"
 	"refo-rag-server/internal/service"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/storage"
+	"refo-rag-server/internal/usage"
 )
 
 // SearchConversationHandler handles conversation search requests
@@ -31,9 +38,21 @@ func NewSearchConversationHandler(conversationService *service.ConversationServi
 // @Produce json
 // @Param query query string true "Search query"
 // @Param top_k query int false "Result limit (default: 10, max: 100)"
-// @Success 200 {object} models.APIResponse "Search results with metadata"
-// @Failure 400 {object} models.APIResponse "Invalid request"
-// @Failure 500 {object} models.APIResponse "Server error"
+// @Param min_score query number false "Drop results scoring below this value; scores are normalized when a search normalizer is configured"
+// @Param filter query string false "Metadata filter, e.g. source = \"slack\" AND priority >= 3"
+// @Param user_id query string false "Restrict results to one user; required when user isolation is enabled"
+// @Param max_age_days query int false "Only match conversations created within this many days; 0 searches all history (default: the tenant's SEARCH_MAX_AGE_DAYS)"
+// @Param candidate_multiplier query number false "Fetch this many times top_k candidates before filtering and reranking (1-10); needs the experiment scope"
+// @Param hnsw_ef query int false "HNSW search beam size (1-4096); needs the experiment scope"
+// @Param fusion_weights query string false "Per-retriever fusion weights, e.g. vector=1,keyword=0.5; needs the experiment scope"
+// @Param recency_half_life query string false "Recency reranker half-life, e.g. 72h; needs the experiment scope"
+// @Param importance_half_life query string false "Importance reranker half-life, e.g. 720h; needs the experiment scope"
+// @Param read_consistency query string false "Qdrant replicas that must answer: majority, quorum, all or a number; fresher but slower than the configured default on clustered Qdrant"
+// @Success 200 {object} models.APIResponse[models.SearchResponse] "Search results with metadata"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 403 {object} models.ErrorResponse "Retrieval overrides without the experiment scope"
+// @Failure 429 {object} models.ErrorResponse "Embedding budget spent"
+// @Failure 500 {object} models.ErrorResponse "Server error"
 // @Router /api/rag/conversation/search [get]
 func (sch *SearchConversationHandler) Handle(c *gin.Context) {
 	startTime := time.Now()
@@ -52,7 +71,7 @@ func (sch *SearchConversationHandler) Handle(c *gin.Context) {
 
 	// Validate required fields
 	if query == "" {
-		c.JSON(http.StatusBadRequest, models.APIResponse{
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INVALID_REQUEST",
@@ -69,15 +88,91 @@ func (sch *SearchConversationHandler) Handle(c *gin.Context) {
 
 	query = strings.TrimSpace(query)
 
+	// Parse the metadata filter
+	metadataFilter, err := filter.Parse(c.Query("filter"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error: &models.ErrorInfo{
+				Code:    "INVALID_FILTER",
+				Message: "invalid metadata filter",
+				Details: map[string]interface{}{
+					"error": err.Error(),
+				},
+			},
+			Metadata: models.Metadata{},
+		})
+		return
+	}
+
+	// Parse min_score
+	var minScore float64
+	if minScoreStr := c.Query("min_score"); minScoreStr != "" {
+		minScore, err = strconv.ParseFloat(minScoreStr, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Error: &models.ErrorInfo{
+					Code:    "INVALID_REQUEST",
+					Message: "min_score must be a number",
+					Details: map[string]interface{}{
+						"field": "min_score",
+					},
+				},
+				Metadata: models.Metadata{},
+			})
+			return
+		}
+	}
+
+	maxAgeDays, ok := parseMaxAgeDays(c)
+	if !ok {
+		return
+	}
+	overrides, _, ok := parseRetrievalOverrides(c)
+	if !ok || !applyReadConsistency(c) {
+		return
+	}
+
 	req := models.ConversationSearchRequest{
-		Query: query,
-		Limit: topK,
+		Query:      query,
+		UserID:     c.Query("user_id"),
+		Limit:      topK,
+		MinScore:   float32(minScore),
+		Filter:     metadataFilter,
+		Overrides:  overrides,
+		MaxAgeDays: maxAgeDays,
 	}
 
-	// Search conversations
-	results, err := sch.conversationService.SearchConversations(c.Request.Context(), &req)
+	// Search conversations, metering the embedding tokens the query uses, timing its stages and
+	// recording the fusion bandit arm that serves it
+	ctx, meter := usage.WithMeter(c.Request.Context())
+	ctx, breakdown := slowlog.WithBreakdown(ctx)
+	ctx, choice := bandit.WithChoice(ctx)
+	results, err := sch.conversationService.SearchConversations(ctx, &req)
+	if errors.Is(err, storage.ErrUserScopeRequired) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error: &models.ErrorInfo{
+				Code:    "INVALID_REQUEST",
+				Message: "user_id is required",
+				Details: map[string]interface{}{
+					"field":  "user_id",
+					"reason": "searches are scoped to a single user",
+				},
+			},
+			Metadata: models.Metadata{},
+		})
+		return
+	}
+	if respondBudgetExceeded(c, err) || respondOverrideRejected(c, err) {
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+		if respondUnavailable(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
 			Error: &models.ErrorInfo{
 				Code:    "INTERNAL_ERROR",
@@ -102,12 +197,39 @@ func (sch *SearchConversationHandler) Handle(c *gin.Context) {
 			EmbeddingModel: "text-embedding-3-large",
 			VectorDB:       "qdrant",
 			SearchTimeMs:   searchTimeMs,
+			Features:       sch.conversationService.EnabledFeatures(c.Request.Context()),
+			Usage:          meter.Usage(),
+			Latency: models.LatencyBreakdown{
+				EmbedMs:        breakdown.Total(slowlog.Embedding).Milliseconds(),
+				VectorSearchMs: breakdown.Total(slowlog.Qdrant).Milliseconds(),
+				DBFetchMs:      breakdown.Total(slowlog.Postgres, slowlog.SQLite, slowlog.MySQL).Milliseconds(),
+				RerankMs:       breakdown.Total(slowlog.Rerank).Milliseconds(),
+			},
+			FusionArm:     choice.Arm,
+			SkippedStages: deadline.Skipped(ctx),
 		},
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
+	c.JSON(http.StatusOK, models.APIResponse[models.SearchResponse]{
 		Success:  true,
 		Data:     searchResp,
 		Metadata: models.Metadata{},
 	})
 }
+
+// parseMaxAgeDays reads the max_age_days parameter; it returns nil when it is absent, and responds
+// and returns false when it isn't a non-negative number of days
+func parseMaxAgeDays(c *gin.Context) (*int, bool) {
+	raw := c.Query("max_age_days")
+	if raw == "" {
+		return nil, true
+	}
+	days, err := strconv.Atoi(raw)
+	if err != nil || days < 0 {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "max_age_days must be a non-negative number of days", map[string]interface{}{
+			"field": "max_age_days",
+		})
+		return nil, false
+	}
+	return &days, true
+}
@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// SessionHandler handles chat session requests
+type SessionHandler struct {
+	sessionService *service.SessionService
+}
+
+// NewSessionHandler creates a new session handler
+func NewSessionHandler(sessionService *service.SessionService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// CreateSession creates a new session
+// @Summary Create a session
+// @Description Create an open chat session; conversations join it by sending its ID as metadata.session_id
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param request body models.SessionCreateRequest true "Session creation request"
+// @Success 201 {object} models.APIResponse[models.Session] "Session created"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 409 {object} models.ErrorResponse "Session ID already exists"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/sessions [post]
+func (sh *SessionHandler) CreateSession(c *gin.Context) {
+	var req models.SessionCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if len(req.SessionID) > 64 {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "session_id must be at most 64 characters", nil)
+		return
+	}
+
+	session, err := sh.sessionService.CreateSession(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrSessionExists) {
+		respondError(c, http.StatusConflict, "SESSION_EXISTS", "session already exists", map[string]interface{}{
+			"session_id": req.SessionID,
+		})
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to create session", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusCreated, session)
+}
+
+// ListSessions lists sessions, newest first
+// @Summary List sessions
+// @Description List sessions, optionally filtered by user and status
+// @Tags sessions
+// @Produce json
+// @Param user_id query string false "User ID"
+// @Param status query string false "Session status" Enums(open, closed)
+// @Param limit query int false "Page size (default: 20, max: 100)"
+// @Param offset query int false "Page offset"
+// @Success 200 {object} models.APIResponse[models.SessionListResponse] "Session page"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/sessions [get]
+func (sh *SessionHandler) ListSessions(c *gin.Context) {
+	userID := c.Query("user_id")
+	status := c.Query("status")
+	if status != "" && status != models.SessionStatusOpen && status != models.SessionStatusClosed {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "invalid session status", map[string]interface{}{
+			"valid_statuses": []string{models.SessionStatusOpen, models.SessionStatusClosed},
+		})
+		return
+	}
+
+	limit, offset := pagination(c, 20, 100)
+
+	sessions, total, err := sh.sessionService.ListSessions(c.Request.Context(), userID, status, limit, offset)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to list sessions", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, models.SessionListResponse{
+		Sessions: sessions,
+		Total:    total,
+		UserID:   userID,
+		Status:   status,
+		Limit:    limit,
+		Offset:   offset,
+	})
+}
+
+// GetSession retrieves a session
+// @Summary Get a session
+// @Description Get a session with its conversation count and latest summary
+// @Tags sessions
+// @Produce json
+// @Param session_id path string true "Session ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} models.APIResponse[models.Session] "Session"
+// @Success 304 "Not modified since the ETag in If-None-Match"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/sessions/{session_id} [get]
+func (sh *SessionHandler) GetSession(c *gin.Context) {
+	sessionID := c.Param("session_id")
+
+	session, err := sh.sessionService.GetSession(c.Request.Context(), sessionID)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get session", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if session == nil {
+		respondSessionNotFound(c, sessionID)
+		return
+	}
+	if notModified(c, session) {
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, session)
+}
+
+// CloseSession closes a session
+// @Summary Close a session
+// @Description Close a session; later saves into it are rejected
+// @Tags sessions
+// @Produce json
+// @Param session_id path string true "Session ID"
+// @Success 200 {object} models.APIResponse[models.Session] "Closed session"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/sessions/{session_id}/close [post]
+func (sh *SessionHandler) CloseSession(c *gin.Context) {
+	sessionID := c.Param("session_id")
+
+	session, err := sh.sessionService.CloseSession(c.Request.Context(), sessionID)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to close session", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if session == nil {
+		respondSessionNotFound(c, sessionID)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, session)
+}
+
+// GetTranscript retrieves a session's conversations in chronological order
+// @Summary Get a session transcript
+// @Description Get all conversations and messages of a session in chronological order
+// @Tags sessions
+// @Produce json
+// @Param session_id path string true "Session ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} models.APIResponse[models.SessionTranscriptResponse] "Session transcript"
+// @Success 304 "Not modified since the ETag in If-None-Match"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/sessions/{session_id}/transcript [get]
+func (sh *SessionHandler) GetTranscript(c *gin.Context) {
+	sessionID := c.Param("session_id")
+
+	transcript, err := sh.sessionService.GetTranscript(c.Request.Context(), sessionID)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get session transcript", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if transcript == nil {
+		respondSessionNotFound(c, sessionID)
+		return
+	}
+	if notModified(c, transcript) {
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, transcript)
+}
+
+// GetContext retrieves a session's summary and most recent messages
+// @Summary Get session context
+// @Description Get the session's rolling summary and its most recent messages, for callers that send a
+// @Description compact session recap to the chat model instead of the full history
+// @Tags sessions
+// @Produce json
+// @Param session_id path string true "Session ID"
+// @Param recent_messages query int false "Number of most recent messages to include (default: 0, max: 50)"
+// @Success 200 {object} models.APIResponse[models.SessionContextResponse] "Session context"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/sessions/{session_id}/context [get]
+func (sh *SessionHandler) GetContext(c *gin.Context) {
+	sessionID := c.Param("session_id")
+
+	recentMessages, _ := strconv.Atoi(c.Query("recent_messages"))
+	if recentMessages > 50 {
+		recentMessages = 50
+	}
+
+	sessionContext, err := sh.sessionService.GetContext(c.Request.Context(), sessionID, recentMessages)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get session context", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if sessionContext == nil {
+		respondSessionNotFound(c, sessionID)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, sessionContext)
+}
+
+// SummarizeSession regenerates a session's summary
+// @Summary Summarize a session
+// @Description Generate a summary of the session transcript with the chat model and store it on the session
+// @Tags sessions
+// @Produce json
+// @Param session_id path string true "Session ID"
+// @Success 200 {object} models.APIResponse[models.Session] "Session with updated summary"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Failure 422 {object} models.ErrorResponse "Session has no conversations"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/sessions/{session_id}/summarize [post]
+func (sh *SessionHandler) SummarizeSession(c *gin.Context) {
+	sessionID := c.Param("session_id")
+
+	session, err := sh.sessionService.SummarizeSession(c.Request.Context(), sessionID)
+	if errors.Is(err, service.ErrSessionEmpty) {
+		respondError(c, http.StatusUnprocessableEntity, "SESSION_EMPTY", "session has no conversations to summarize", map[string]interface{}{
+			"session_id": sessionID,
+		})
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to summarize session", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if session == nil {
+		respondSessionNotFound(c, sessionID)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, session)
+}
+
+// respondSessionNotFound writes the 404 response for a missing session
+func respondSessionNotFound(c *gin.Context, sessionID string) {
+	respondError(c, http.StatusNotFound, "NOT_FOUND", "session not found", map[string]interface{}{
+		"session_id": sessionID,
+	})
+}
+
+// pagination reads limit and offset query parameters, applying a default and maximum limit
+func pagination(c *gin.Context, defaultLimit int, maxLimit int) (int, int) {
+	limit := defaultLimit
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	offset := 0
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o > 0 {
+		offset = o
+	}
+
+	return limit, offset
+}
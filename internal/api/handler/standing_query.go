@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/filter"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// standingQueryKeepAlive is how often an idle match stream sends a comment so proxies keep it open
+const standingQueryKeepAlive = 15 * time.Second
+
+// StandingQueryHandler handles standing query requests
+type StandingQueryHandler struct {
+	standingQueries *service.StandingQueryService
+}
+
+// NewStandingQueryHandler creates a new standing query handler
+func NewStandingQueryHandler(standingQueries *service.StandingQueryService) *StandingQueryHandler {
+	return &StandingQueryHandler{
+		standingQueries: standingQueries,
+	}
+}
+
+// CreateStandingQuery registers a standing query
+// @Summary Register a standing query
+// @Description Register a search that runs against every conversation the user saves from now on, such as "tell me
+// @Description whenever grandma mentions dizziness". The query is embedded once; each saved conversation with a
+// @Description vector is compared with it, and matches scoring at least the threshold, on the scale of search scores,
+// @Description are posted to STANDING_QUERY_WEBHOOK_URL and sent to the user's match streams. The filter narrows
+// @Description matches with the metadata filter syntax of searches.
+// @Tags standing-queries
+// @Accept json
+// @Produce json
+// @Param request body models.StandingQueryCreateRequest true "Standing query"
+// @Success 201 {object} models.APIResponse[models.StandingQuery] "Standing query registered"
+// @Failure 400 {object} models.ErrorResponse "Invalid request or filter"
+// @Failure 409 {object} models.ErrorResponse "User has the maximum number of standing queries"
+// @Failure 429 {object} models.ErrorResponse "Embedding budget exceeded"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/standing-queries [post]
+func (sqh *StandingQueryHandler) CreateStandingQuery(c *gin.Context) {
+	var req models.StandingQueryCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if _, err := filter.Parse(req.Filter); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_FILTER", "invalid metadata filter", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	query, err := sqh.standingQueries.Create(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrTooManyStandingQueries) {
+		respondError(c, http.StatusConflict, "TOO_MANY_STANDING_QUERIES", err.Error(), map[string]interface{}{
+			"user_id": req.UserID,
+		})
+		return
+	}
+	if respondBudgetExceeded(c, err) {
+		return
+	}
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to create standing query", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusCreated, query)
+}
+
+// ListStandingQueries lists a user's standing queries
+// @Summary List a user's standing queries
+// @Description List a user's standing queries, oldest first, with how often each matched
+// @Tags standing-queries
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} models.APIResponse[models.StandingQueryListResponse] "Standing queries"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/users/{user_id}/standing-queries [get]
+func (sqh *StandingQueryHandler) ListStandingQueries(c *gin.Context) {
+	queries, err := sqh.standingQueries.List(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to list standing queries", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, queries)
+}
+
+// GetStandingQuery retrieves a standing query
+// @Summary Get a standing query
+// @Description Get a standing query with how often it matched
+// @Tags standing-queries
+// @Produce json
+// @Param query_id path string true "Standing query ID"
+// @Success 200 {object} models.APIResponse[models.StandingQuery] "Standing query"
+// @Failure 404 {object} models.ErrorResponse "Standing query not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/standing-queries/{query_id} [get]
+func (sqh *StandingQueryHandler) GetStandingQuery(c *gin.Context) {
+	queryID := c.Param("query_id")
+
+	query, err := sqh.standingQueries.Get(c.Request.Context(), queryID)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get standing query", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if query == nil {
+		respondStandingQueryNotFound(c, queryID)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, query)
+}
+
+// DeleteStandingQuery deletes a standing query
+// @Summary Delete a standing query
+// @Description Delete a standing query; saved conversations are no longer compared with it
+// @Tags standing-queries
+// @Produce json
+// @Param query_id path string true "Standing query ID"
+// @Success 200 {object} models.APIResponse[models.StandingQueryDeleteResponse] "Standing query deleted"
+// @Failure 404 {object} models.ErrorResponse "Standing query not found"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/standing-queries/{query_id} [delete]
+func (sqh *StandingQueryHandler) DeleteStandingQuery(c *gin.Context) {
+	queryID := c.Param("query_id")
+
+	deleted, err := sqh.standingQueries.Delete(c.Request.Context(), queryID)
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to delete standing query", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !deleted {
+		respondStandingQueryNotFound(c, queryID)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, models.StandingQueryDeleteResponse{DeletedQueryID: queryID})
+}
+
+// StreamMatches streams a user's standing query matches
+// @Summary Stream a user's standing query matches
+// @Description Stream the user's standing query matches as server-sent events named "match", each carrying a
+// @Description models.StandingQueryMatch as JSON, until the client disconnects. A stream only sees matches of
+// @Description conversations saved through this replica and misses those found while it is disconnected or too slow
+// @Description to keep up; use the webhook for reliable delivery.
+// @Tags standing-queries
+// @Produce text/event-stream
+// @Param user_id path string true "User ID"
+// @Success 200 {object} models.StandingQueryMatch "Stream of match events"
+// @Router /api/rag/users/{user_id}/standing-queries/stream [get]
+func (sqh *StandingQueryHandler) StreamMatches(c *gin.Context) {
+	matches, unsubscribe := sqh.standingQueries.Subscribe(c.Param("user_id"))
+	defer unsubscribe()
+
+	keepAlive := time.NewTicker(standingQueryKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case match := <-matches:
+			c.SSEvent("match", match)
+		case <-keepAlive.C:
+			io.WriteString(w, ": keep-alive\n\n")
+		}
+		return true
+	})
+}
+
+// respondStandingQueryNotFound writes the 404 for a missing standing query
+func respondStandingQueryNotFound(c *gin.Context, queryID string) {
+	respondError(c, http.StatusNotFound, "NOT_FOUND", "standing query not found", map[string]interface{}{
+		"query_id": queryID,
+	})
+}
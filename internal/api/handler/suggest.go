@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// SuggestHandler handles query suggestion requests
+type SuggestHandler struct {
+	suggestService *service.SuggestService
+}
+
+// NewSuggestHandler creates a new query suggestion handler
+func NewSuggestHandler(suggestService *service.SuggestService) *SuggestHandler {
+	return &SuggestHandler{
+		suggestService: suggestService,
+	}
+}
+
+// Suggest completes a partly typed search
+// @Summary Suggest search queries
+// @Description Complete a partly typed search with the queries the user searched before and found results for,
+// @Description then with words and two-word phrases recurring in their recent conversations. Candidates starting
+// @Description with the prefix come before those with a later word starting with it, then the most frequent. An
+// @Description empty prefix returns the user's top suggestions. A user's candidates are cached for
+// @Description SUGGEST_CACHE_TTL, so new searches and conversations appear within it.
+// @Tags conversations
+// @Produce json
+// @Param user_id query string true "User ID"
+// @Param prefix query string false "Text typed so far"
+// @Param limit query int false "Maximum number of suggestions" default(8)
+// @Success 200 {object} models.APIResponse[models.QuerySuggestResponse] "Suggestions"
+// @Failure 400 {object} models.ErrorResponse "Missing user ID"
+// @Failure 500 {object} models.ErrorResponse "Server error"
+// @Router /api/rag/suggest [get]
+func (sh *SuggestHandler) Suggest(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "user_id is required", map[string]interface{}{
+			"field": "user_id",
+		})
+		return
+	}
+	limit, _ := pagination(c, 8, 20)
+
+	suggestions, err := sh.suggestService.Suggest(c.Request.Context(), models.QuerySuggestRequest{
+		UserID: userID,
+		Prefix: c.Query("prefix"),
+		Limit:  limit,
+	})
+	if err != nil {
+		if respondUnavailable(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to suggest queries", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, suggestions)
+}
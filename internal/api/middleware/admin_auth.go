@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/apikey"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/signing"
+)
+
+// maxSignedBodyBytes bounds the body read to verify a signed request
+const maxSignedBodyBytes = 10 << 20
+
+// AdminAuth guards admin routes with the static API key or a service account key with the admin
+// scope. The key may be sent as "Authorization: Bearer <key>" or "X-API-Key: <key>".
+// When no static key is configured and no admin service account key exists, the admin API is
+// disabled entirely. keyring may be nil to accept only the static key.
+func AdminAuth(apiKey string, keyring *apikey.Keyring) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKey == "" && (keyring == nil || !keyring.HasScope(models.ScopeAdmin)) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Success: false,
+				Error: &models.ErrorInfo{
+					Code:    "ADMIN_DISABLED",
+					Message: "admin API is disabled; set ADMIN_API_KEY to enable it",
+				},
+				Metadata: models.Metadata{},
+			})
+			return
+		}
+
+		provided := extractAPIKey(c.Request)
+		if isStaticKey(provided, apiKey) {
+			c.Next()
+			return
+		}
+		if key := authenticate(keyring, provided); key != nil && key.HasScope(models.ScopeAdmin) {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+			Success: false,
+			Error: &models.ErrorInfo{
+				Code:    "UNAUTHORIZED",
+				Message: "valid admin API key required",
+			},
+			Metadata: models.Metadata{},
+		})
+	}
+}
+
+// adminRoutes are the routes outside /admin and /debug that AdminAuth guards
+var adminRoutes = map[string]bool{
+	"/api/rag/conversation/delete-by-filter": true,
+}
+
+// RequireAPIKey requires a service account key on every route it is installed on: reads need the
+// read scope and writes the write scope. Admin and debug routes and adminRoutes are left to
+// AdminAuth, and the static admin key is accepted everywhere. When verifier is set, a request
+// carrying a signature is authenticated by it instead and may read and write
+func RequireAPIKey(adminKey string, keyring *apikey.Keyring, verifier *signing.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if strings.HasPrefix(path, "/api/rag/admin") || strings.HasPrefix(path, "/api/rag/debug") || adminRoutes[path] {
+			c.Next()
+			return
+		}
+
+		if verifier != nil && signing.Signed(c.Request) {
+			if verifySignature(c, verifier) {
+				c.Next()
+			}
+			return
+		}
+
+		provided := extractAPIKey(c.Request)
+		if isStaticKey(provided, adminKey) {
+			c.Next()
+			return
+		}
+
+		key := authenticate(keyring, provided)
+		if key == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+				Success: false,
+				Error: &models.ErrorInfo{
+					Code:    "UNAUTHORIZED",
+					Message: "valid API key required",
+				},
+				Metadata: models.Metadata{},
+			})
+			return
+		}
+
+		scope := models.ScopeWrite
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			scope = models.ScopeRead
+		}
+		if !key.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Success: false,
+				Error: &models.ErrorInfo{
+					Code:    "INSUFFICIENT_SCOPE",
+					Message: "API key lacks the scope this request needs",
+					Details: map[string]interface{}{
+						"required_scope": scope,
+						"key_id":         key.ID,
+					},
+				},
+				Metadata: models.Metadata{},
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// verifySignature checks a signed request, leaving its body readable by the handler, and aborts
+// with 401 if the signature doesn't hold, or with 503 if its nonce can't be recorded
+func verifySignature(c *gin.Context, verifier *signing.Verifier) bool {
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxSignedBodyBytes))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+				Success: false,
+				Error: &models.ErrorInfo{
+					Code:    "BODY_TOO_LARGE",
+					Message: "request body is too large to verify its signature",
+				},
+				Metadata: models.Metadata{},
+			})
+			return false
+		}
+		c.Request.Body = readCloser{bytes.NewReader(body), c.Request.Body}
+	}
+
+	_, err := verifier.Verify(c.Request, body)
+	if errors.Is(err, signing.ErrUnavailable) {
+		log.Printf("warning: failed to record request signature nonce: %v", err)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Success: false,
+			Error: &models.ErrorInfo{
+				Code:    "SIGNATURE_UNAVAILABLE",
+				Message: "request signatures can't be verified right now; retry later",
+			},
+			Metadata: models.Metadata{},
+		})
+		return false
+	}
+	if err != nil {
+		metrics.SignatureRejections.WithLabelValues(signing.Reason(err)).Inc()
+		c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+			Success: false,
+			Error: &models.ErrorInfo{
+				Code:    "INVALID_SIGNATURE",
+				Message: "request signature is invalid",
+				Details: map[string]interface{}{
+					"reason": err.Error(),
+				},
+			},
+			Metadata: models.Metadata{},
+		})
+		return false
+	}
+	return true
+}
+
+// isStaticKey reports whether provided is the configured static admin key
+func isStaticKey(provided string, apiKey string) bool {
+	return provided != "" && apiKey != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(apiKey)) == 1
+}
+
+// authenticate looks a provided secret up in the keyring, which may be nil
+func authenticate(keyring *apikey.Keyring, provided string) *models.APIKey {
+	if keyring == nil {
+		return nil
+	}
+	return keyring.Authenticate(provided)
+}
+
+// extractAPIKey reads an API key from the Authorization or X-API-Key header
+func extractAPIKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
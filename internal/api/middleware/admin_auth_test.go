@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/apikey"
+	"refo-rag-server/internal/models"
+)
+
+// staticKeys is an API key source holding fixed keys by secret
+type staticKeys map[string]*models.APIKey
+
+func (s staticKeys) LoadAPIKeys(ctx context.Context, now time.Time) (map[string]*models.APIKey, error) {
+	keys := make(map[string]*models.APIKey, len(s))
+	for secret, key := range s {
+		keys[apikey.Hash(secret)] = key
+	}
+	return keys, nil
+}
+
+func TestRequireAPIKeyScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keyring := apikey.NewKeyring(staticKeys{
+		"read-secret":       {ID: "k-read", Scopes: []string{models.ScopeRead}},
+		"write-secret":      {ID: "k-write", Scopes: []string{models.ScopeWrite}},
+		"admin-secret":      {ID: "k-admin", Scopes: []string{models.ScopeAdmin}},
+		"experiment-secret": {ID: "k-experiment", Scopes: []string{models.ScopeExperiment}},
+	})
+	if err := keyring.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Routed as in the API: every route requires a key, admin routes take AdminAuth too
+	router := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	rag := router.Group("/api/rag", RequireAPIKey("static-admin-key", keyring, nil))
+	rag.GET("/conversation/search", ok)
+	rag.POST("/conversation/store", ok)
+	rag.Group("/conversation", AdminAuth("static-admin-key", keyring)).POST("/delete-by-filter", ok)
+	rag.Group("/admin", AdminAuth("static-admin-key", keyring)).POST("/users", ok)
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		want   int
+	}{
+		{"read key searches", http.MethodGet, "/api/rag/conversation/search", "read-secret", http.StatusOK},
+		{"read key can't store", http.MethodPost, "/api/rag/conversation/store", "read-secret", http.StatusForbidden},
+		{"write key stores", http.MethodPost, "/api/rag/conversation/store", "write-secret", http.StatusOK},
+		{"missing key", http.MethodGet, "/api/rag/conversation/search", "", http.StatusUnauthorized},
+		{"admin key deletes by filter", http.MethodPost, "/api/rag/conversation/delete-by-filter", "admin-secret", http.StatusOK},
+		{"static key deletes by filter", http.MethodPost, "/api/rag/conversation/delete-by-filter", "static-admin-key", http.StatusOK},
+		{"write key can't delete by filter", http.MethodPost, "/api/rag/conversation/delete-by-filter", "write-secret", http.StatusUnauthorized},
+		{"experiment key can't delete by filter", http.MethodPost, "/api/rag/conversation/delete-by-filter", "experiment-secret", http.StatusUnauthorized},
+		{"admin key on admin route", http.MethodPost, "/api/rag/admin/users", "admin-secret", http.StatusOK},
+		{"write key on admin route", http.MethodPost, "/api/rag/admin/users", "write-secret", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.key != "" {
+				req.Header.Set("X-API-Key", tc.key)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tc.want, rec.Body)
+			}
+		})
+	}
+}
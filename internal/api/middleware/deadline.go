@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/deadline"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+)
+
+// PropagateDeadline makes a request's context expire at the deadline its caller sent in
+// X-Request-Deadline or Grpc-Timeout, so the time is budgeted across the request's dependency
+// calls. An unparsable deadline is answered with 400 and one already passed with 504
+func PropagateDeadline() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		at, ok, err := deadline.Parse(c.GetHeader(deadline.Header), c.GetHeader(deadline.TimeoutHeader), time.Now())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Error: &models.ErrorInfo{
+					Code:    "INVALID_DEADLINE",
+					Message: "the request deadline can't be parsed",
+					Details: map[string]interface{}{
+						"error": err.Error(),
+					},
+				},
+				Metadata: models.Metadata{},
+			})
+			return
+		}
+		if !ok {
+			c.Next()
+			return
+		}
+		if !time.Now().Before(at) {
+			metrics.DeadlineExceeded.WithLabelValues(c.FullPath()).Inc()
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, models.ErrorResponse{
+				Success: false,
+				Error: &models.ErrorInfo{
+					Code:    "DEADLINE_EXCEEDED",
+					Message: "the request deadline passed before it was served",
+					Details: map[string]interface{}{
+						"deadline": deadline.Format(at),
+					},
+				},
+				Metadata: models.Metadata{},
+			})
+			return
+		}
+
+		ctx, cancel := deadline.With(c.Request.Context(), at)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
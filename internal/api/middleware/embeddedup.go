@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/embeddedup"
+)
+
+// DeduplicateEmbeddings scopes embedding deduplication to each request, so a text embedded
+// several times while serving it is sent to the provider once
+func DeduplicateEmbeddings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(embeddedup.WithScope(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/logging"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/requestid"
+	"refo-rag-server/internal/tracing"
+)
+
+// maxCapturedErrorBody bounds the response body attached to 5xx reports
+const maxCapturedErrorBody = 4096
+
+// Recovery recovers handler panics, reports them, and returns a 500 response
+func Recovery(reporter errreport.Reporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				reporter.CapturePanic(c.Request.Context(), recovered, debug.Stack(), requestEvent(c, http.StatusInternalServerError))
+
+				c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+					Success: false,
+					Error: &models.ErrorInfo{
+						Code:    "INTERNAL_ERROR",
+						Message: "internal server error",
+					},
+					Metadata: models.Metadata{},
+				})
+			}
+		}()
+
+		c.Next()
+	}
+}
+
+// ReportServerErrors reports every 5xx response with its request context. Requests abandoned by
+// their client fail with a cancelled context rather than a server fault, so they are counted
+// instead of reported
+func ReportServerErrors(reporter errreport.Reporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &errorCaptureWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		if errors.Is(c.Request.Context().Err(), context.Canceled) {
+			metrics.RequestsCancelled.WithLabelValues(c.FullPath()).Inc()
+			return
+		}
+
+		status := c.Writer.Status()
+		if status < http.StatusInternalServerError || status == http.StatusServiceUnavailable {
+			return
+		}
+
+		event := requestEvent(c, status)
+
+		// Error details can echo user content, so only the code and message are
+		// attached unless full content logging is enabled
+		var response models.APIResponse[json.RawMessage]
+		if err := json.Unmarshal(writer.body.Bytes(), &response); err == nil && response.Error != nil {
+			event.Tags["error_code"] = response.Error.Code
+			event.Extra["error_message"] = response.Error.Message
+		}
+		if logging.FullContent() {
+			event.Extra["response_body"] = writer.body.String()
+			for _, ginErr := range c.Errors {
+				event.Extra["handler_error"] = ginErr.Error()
+			}
+		}
+
+		err := fmt.Errorf("%s %s returned %d", c.Request.Method, c.FullPath(), status)
+		reporter.CaptureError(c.Request.Context(), err, event)
+	}
+}
+
+// requestEvent builds the error report context for a request
+func requestEvent(c *gin.Context, status int) errreport.Event {
+	return errreport.Event{
+		Tags: map[string]string{
+			"method": c.Request.Method,
+			"route":  c.FullPath(),
+			"status": fmt.Sprintf("%d", status),
+		},
+		Extra: map[string]interface{}{
+			"client_ip":  c.ClientIP(),
+			"request_id": requestid.FromContext(c.Request.Context()),
+			"trace_id":   tracing.TraceID(c.Request.Context()),
+			"query":      logging.Query(c.Request.URL.RawQuery),
+		},
+	}
+}
+
+// errorCaptureWriter keeps the first bytes of the response body for error reports
+type errorCaptureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write records the body prefix while passing the bytes through
+func (w *errorCaptureWriter) Write(data []byte) (int, error) {
+	if w.Status() >= http.StatusInternalServerError && w.body.Len() < maxCapturedErrorBody {
+		remaining := maxCapturedErrorBody - w.body.Len()
+		if len(data) < remaining {
+			remaining = len(data)
+		}
+		w.body.Write(data[:remaining])
+	}
+	return w.ResponseWriter.Write(data)
+}
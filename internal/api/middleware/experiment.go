@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/apikey"
+	"refo-rag-server/internal/models"
+)
+
+// experimentsKey marks a request whose caller may use per-request retrieval overrides
+const experimentsKey = "rag.experiments"
+
+// AllowExperiments marks requests made with the static admin key or a service account key with
+// the experiment scope, so handlers accept their retrieval overrides. It never rejects a request;
+// signed requests are not marked. keyring may be nil to accept only the static key
+func AllowExperiments(adminKey string, keyring *apikey.Keyring) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := extractAPIKey(c.Request)
+		if isStaticKey(provided, adminKey) {
+			c.Set(experimentsKey, true)
+		} else if key := authenticate(keyring, provided); key != nil && key.HasScope(models.ScopeExperiment) {
+			c.Set(experimentsKey, true)
+		}
+		c.Next()
+	}
+}
+
+// ExperimentsAllowed reports whether AllowExperiments marked the request
+func ExperimentsAllowed(c *gin.Context) bool {
+	return c.GetBool(experimentsKey)
+}
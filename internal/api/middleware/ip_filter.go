@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/ipfilter"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+)
+
+// FilterIPs blocks requests whose client address the rules of a route group reject with 403. The
+// client address is the peer address, or the forwarded address when the peer is a trusted proxy
+func FilterIPs(group string, rules *ipfilter.Rules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientIP := c.ClientIP()
+		reason := rules.Check(net.ParseIP(clientIP))
+		if reason == "" {
+			c.Next()
+			return
+		}
+
+		metrics.BlockedRequests.WithLabelValues(group, reason).Inc()
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+			Success: false,
+			Error: &models.ErrorInfo{
+				Code:    "IP_BLOCKED",
+				Message: "requests from this address are not allowed",
+				Details: map[string]interface{}{
+					"client_ip": clientIP,
+					"group":     group,
+					"reason":    reason,
+				},
+			},
+			Metadata: models.Metadata{},
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/loadshed"
+	"refo-rag-server/internal/models"
+)
+
+// ShedLoad caps concurrent requests per endpoint class and returns 503 with Retry-After for
+// requests beyond the cap. Routes are classed by routeClasses, then admin and debug routes are
+// the admin class, other reads the search class and other writes the store class
+func ShedLoad(shedder *loadshed.Shedder) gin.HandlerFunc {
+	retryAfter := strconv.Itoa(max(1, int(shedder.RetryAfter().Seconds())))
+
+	return func(c *gin.Context) {
+		class := endpointClass(c)
+		release, ok := shedder.Acquire(c.Request.Context(), class)
+		if !ok {
+			c.Header("Retry-After", retryAfter)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Success: false,
+				Error: &models.ErrorInfo{
+					Code:    "OVERLOADED",
+					Message: "server is at capacity for this kind of request; retry later",
+					Details: map[string]interface{}{
+						"class": class,
+					},
+				},
+				Metadata: models.Metadata{},
+			})
+			return
+		}
+		defer release()
+
+		c.Next()
+	}
+}
+
+// routeClasses classes the routes their method would misclass: POSTs that only read, carrying
+// their query in the body, load the stores like a search
+var routeClasses = map[string]string{
+	"/api/rag/conversation/recommend":  loadshed.ClassSearch,
+	"/api/rag/admin/search/playground": loadshed.ClassSearch,
+}
+
+// endpointClass classifies a request by its route and method
+func endpointClass(c *gin.Context) string {
+	path := c.FullPath()
+	if class, ok := routeClasses[path]; ok {
+		return class
+	}
+	if strings.HasPrefix(path, "/api/rag/admin") || strings.HasPrefix(path, "/api/rag/debug") {
+		return loadshed.ClassAdmin
+	}
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return loadshed.ClassSearch
+	default:
+		return loadshed.ClassStore
+	}
+}
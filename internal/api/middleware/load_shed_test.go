@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/loadshed"
+)
+
+func TestEndpointClass(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	var class string
+	record := func(c *gin.Context) { class = endpointClass(c) }
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/api/rag/conversation/search"},
+		{http.MethodPost, "/api/rag/conversation/store"},
+		{http.MethodPost, "/api/rag/conversation/recommend"},
+		{http.MethodPut, "/api/rag/conversation/:conversation_id/archive"},
+		{http.MethodPost, "/api/rag/conversation/delete-by-filter"},
+		{http.MethodGet, "/api/rag/admin/users"},
+		{http.MethodPost, "/api/rag/admin/users"},
+		{http.MethodPost, "/api/rag/admin/search/playground"},
+		{http.MethodGet, "/api/rag/debug/retrieval"},
+	}
+	for _, route := range routes {
+		router.Handle(route.method, route.path, record)
+	}
+
+	cases := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/api/rag/conversation/search", loadshed.ClassSearch},
+		{http.MethodPost, "/api/rag/conversation/store", loadshed.ClassStore},
+		{http.MethodPost, "/api/rag/conversation/recommend", loadshed.ClassSearch},
+		{http.MethodPut, "/api/rag/conversation/c-1/archive", loadshed.ClassStore},
+		{http.MethodPost, "/api/rag/conversation/delete-by-filter", loadshed.ClassStore},
+		{http.MethodGet, "/api/rag/admin/users", loadshed.ClassAdmin},
+		{http.MethodPost, "/api/rag/admin/users", loadshed.ClassAdmin},
+		{http.MethodPost, "/api/rag/admin/search/playground", loadshed.ClassSearch},
+		{http.MethodGet, "/api/rag/debug/retrieval", loadshed.ClassAdmin},
+	}
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			class = ""
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.method, tc.path, nil))
+			if class != tc.want {
+				t.Errorf("class = %q, want %q", class, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/i18n"
+	"refo-rag-server/internal/models"
+)
+
+// LocalizeErrors translates the message of error responses to the language the client prefers
+// by Accept-Language. Error codes and details are left untouched so clients can keep branching
+// on them; English requests pass through unbuffered
+func LocalizeErrors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+		if lang == i18n.English {
+			c.Next()
+			return
+		}
+
+		writer := &localizingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+		if !writer.buffered {
+			return
+		}
+
+		body := writer.body.Bytes()
+		var response models.APIResponse[json.RawMessage]
+		if err := json.Unmarshal(body, &response); err == nil && response.Error != nil {
+			response.Error.Message = i18n.Message(lang, response.Error.Code, response.Error.Message)
+			if localized, err := json.Marshal(response); err == nil {
+				body = localized
+				c.Header("Content-Language", lang)
+			}
+		}
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Writer.Write(body)
+	}
+}
+
+// localizingWriter holds back the body of error responses so their message can be translated
+type localizingWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	buffered bool
+}
+
+// Write buffers error response bodies and passes other bodies through
+func (w *localizingWriter) Write(data []byte) (int, error) {
+	if w.Status() >= http.StatusBadRequest {
+		w.buffered = true
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString buffers like Write
+func (w *localizingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
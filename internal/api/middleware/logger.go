@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/logging"
+	"refo-rag-server/internal/requestid"
+)
+
+// Logger writes gin-style access logs with sensitive query parameters masked
+func Logger() gin.HandlerFunc {
+	return gin.LoggerWithConfig(gin.LoggerConfig{
+		Formatter: func(param gin.LogFormatterParams) string {
+			path := param.Path
+			id := "-"
+			if param.Request != nil {
+				path = logging.Path(param.Request.URL.Path, param.Request.URL.RawQuery)
+				if requestID := requestid.FromContext(param.Request.Context()); requestID != "" {
+					id = requestID
+				}
+			}
+
+			return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %s | %-7s %#v\n%s",
+				param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+				param.StatusCode,
+				param.Latency.Round(time.Microsecond),
+				param.ClientIP,
+				id,
+				param.Method,
+				path,
+				param.ErrorMessage,
+			)
+		},
+	})
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+// maintenanceRetryAfterSeconds is the Retry-After hint sent while writes are disabled
+const maintenanceRetryAfterSeconds = "120"
+
+// RejectWritesInMaintenance returns 503 for write routes while maintenance mode is enabled, and
+// always on a standby
+func RejectWritesInMaintenance(mode *service.MaintenanceMode) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !mode.Enabled() {
+			c.Next()
+			return
+		}
+
+		status := mode.Status()
+		if status.Standby {
+			// Retrying won't help; the client has to send the write to the primary
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Success: false,
+				Error: &models.ErrorInfo{
+					Code:    "STANDBY",
+					Message: "this instance is a read-only standby; send writes to the primary",
+				},
+				Metadata: models.Metadata{},
+			})
+			return
+		}
+		c.Header("Retry-After", maintenanceRetryAfterSeconds)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Success: false,
+			Error: &models.ErrorInfo{
+				Code:    "MAINTENANCE_MODE",
+				Message: "server is in read-only maintenance mode; writes are temporarily disabled",
+				Details: map[string]interface{}{
+					"reason": status.Reason,
+					"since":  status.Since,
+				},
+			},
+			Metadata: models.Metadata{},
+		})
+	}
+}
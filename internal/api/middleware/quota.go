@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/usage"
+)
+
+// Soft quota headers
+const (
+	HeaderQuotaPeriod    = "X-Quota-Period"
+	HeaderQuotaLimit     = "X-Quota-Limit"
+	HeaderQuotaRemaining = "X-Quota-Remaining"
+	HeaderQuotaReset     = "X-Quota-Reset"
+	HeaderQuotaWarning   = "X-Quota-Warning"
+)
+
+// QuotaHeaders warns callers once the embedding budget's spend reaches a warning threshold, before
+// the budget refuses their saves and searches: responses carry the period closest to its cap, its
+// limit and remaining tokens, when it resets and the threshold reached, in percent
+func QuotaHeaders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if status, ok := usage.Quota(); ok && status.Warning > 0 {
+			header := c.Writer.Header()
+			header.Set(HeaderQuotaPeriod, status.Period)
+			header.Set(HeaderQuotaLimit, strconv.FormatInt(status.Limit, 10))
+			header.Set(HeaderQuotaRemaining, strconv.FormatInt(status.Remaining, 10))
+			header.Set(HeaderQuotaReset, status.ResetsAt.Format(time.RFC3339))
+			header.Set(HeaderQuotaWarning, strconv.FormatFloat(status.Warning*100, 'f', -1, 64))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/auditlog"
+	"refo-rag-server/internal/logging"
+	"refo-rag-server/internal/requestid"
+	"refo-rag-server/internal/tenant"
+)
+
+// RequestAudit records sampled request/response pairs with sensitive fields masked.
+// Bodies larger than maxBody bytes are truncated in the record but passed through intact.
+func RequestAudit(sink auditlog.Sink, sampler *auditlog.Sampler, maxBody int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" || !sampler.Sample(c.Request.Method, route) {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		truncated := false
+
+		var requestBody []byte
+		if c.Request.Body != nil {
+			limited, err := io.ReadAll(io.LimitReader(c.Request.Body, int64(maxBody)+1))
+			if err == nil {
+				c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(limited), c.Request.Body), c.Request.Body}
+				if len(limited) > maxBody {
+					limited = limited[:maxBody]
+					truncated = true
+				}
+				requestBody = limited
+			}
+		}
+
+		writer := &auditCaptureWriter{ResponseWriter: c.Writer, max: maxBody}
+		c.Writer = writer
+
+		c.Next()
+
+		record := auditlog.Record{
+			Time:           start.UTC(),
+			RequestID:      requestid.FromContext(c.Request.Context()),
+			Tenant:         tenant.FromContext(c.Request.Context()),
+			Method:         c.Request.Method,
+			Route:          route,
+			Path:           logging.Path(c.Request.URL.Path, c.Request.URL.RawQuery),
+			Status:         c.Writer.Status(),
+			LatencyMS:      float64(time.Since(start).Microseconds()) / 1000,
+			RequestHeaders: logging.Headers(c.Request.Header),
+			Truncated:      truncated || writer.truncated,
+		}
+
+		// Truncated bodies are not valid JSON and are masked as a whole
+		record.RequestBody = logging.JSON(requestBody)
+		record.ResponseBody = logging.JSON(writer.body.Bytes())
+
+		if err := sink.Write(record); err != nil {
+			log.Printf("warning: failed to write request audit record: %v", err)
+		}
+	}
+}
+
+// readCloser pairs a replayed body reader with the original body's Close
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// auditCaptureWriter keeps up to max bytes of the response body
+type auditCaptureWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	max       int
+	truncated bool
+}
+
+// Write records the body prefix while passing the bytes through
+func (w *auditCaptureWriter) Write(data []byte) (int, error) {
+	remaining := w.max - w.body.Len()
+	if len(data) > remaining {
+		w.truncated = true
+		if remaining > 0 {
+			w.body.Write(data[:remaining])
+		}
+	} else {
+		w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
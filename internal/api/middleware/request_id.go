@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/requestid"
+	"refo-rag-server/internal/slowlog"
+)
+
+// RequestID assigns each request an ID, echoes it in the X-Request-ID response header,
+// and logs the request when it exceeds the slow request threshold
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		id := requestid.Normalize(c.GetHeader(requestid.Header))
+		c.Request = c.Request.WithContext(requestid.WithRequestID(c.Request.Context(), id))
+		c.Header(requestid.Header, id)
+
+		c.Next()
+
+		operation := c.FullPath()
+		if operation == "" {
+			operation = "unmatched"
+		}
+		slowlog.Observe(c.Request.Context(), slowlog.Request, c.Request.Method+" "+operation, start)
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/residency"
+)
+
+// EnforceResidency refuses requests for a tenant, or for a user named in the path or query,
+// homed in another region with 421 and the home region. Admin and debug routes operate on this
+// region's records and are left through, so a user's region can still be changed
+func EnforceResidency(guard *residency.Guard) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if strings.HasPrefix(path, "/api/rag/admin") || strings.HasPrefix(path, "/api/rag/debug") {
+			c.Next()
+			return
+		}
+
+		userID := c.Param("user_id")
+		if userID == "" {
+			userID = c.Query("user_id")
+		}
+
+		err := guard.CheckUser(c.Request.Context(), userID)
+		if err == nil {
+			c.Next()
+			return
+		}
+
+		var wrong *residency.WrongRegionError
+		if !errors.As(err, &wrong) {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+				Success: false,
+				Error: &models.ErrorInfo{
+					Code:    "INTERNAL_ERROR",
+					Message: "failed to check data residency",
+					Details: map[string]interface{}{
+						"error": err.Error(),
+					},
+				},
+				Metadata: models.Metadata{},
+			})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusMisdirectedRequest, models.ErrorResponse{
+			Success: false,
+			Error: &models.ErrorInfo{
+				Code:    "WRONG_REGION",
+				Message: "the requested data is homed in another region",
+				Details: map[string]interface{}{
+					"region":       wrong.Region,
+					"region_url":   wrong.URL,
+					"local_region": wrong.Local,
+				},
+			},
+			Metadata: models.Metadata{},
+		})
+	}
+}
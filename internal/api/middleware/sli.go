@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/tenant"
+	"refo-rag-server/internal/tracing"
+)
+
+// RecordSLI counts each request as good or bad for its endpoint class's availability SLI and
+// records its latency with the trace as exemplar. Server errors and shed requests are bad; client
+// errors are the caller's and count as good. The request is also counted in the per-tenant metrics
+func RecordSLI() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startTime := time.Now()
+		class := endpointClass(c)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		outcome := "good"
+		if status >= http.StatusInternalServerError {
+			outcome = "bad"
+		}
+		metrics.SLIRequests.WithLabelValues(class, outcome).Inc()
+		metrics.ObserveWithTrace(metrics.SLIRequestDuration.WithLabelValues(class), time.Since(startTime).Seconds(), tracing.TraceID(c.Request.Context()))
+		metrics.ObserveTenantRequest(tenant.FromContext(c.Request.Context()), class, status)
+	}
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/tenant"
+)
+
+// Tenant stores the caller's tenant ID from the X-Tenant-ID header in the request context
+func Tenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := strings.TrimSpace(c.GetHeader(tenant.Header))
+		c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), tenantID))
+		c.Next()
+	}
+}
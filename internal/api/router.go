@@ -5,41 +5,426 @@ import (
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
-	_ "refo-rag-server/docs"
+	"refo-rag-server/docs"
+	"refo-rag-server/internal/adminui"
 	"refo-rag-server/internal/api/handler"
+	"refo-rag-server/internal/api/middleware"
+	"refo-rag-server/internal/apikey"
+	"refo-rag-server/internal/auditlog"
+	"refo-rag-server/internal/cache"
+	"refo-rag-server/internal/coord"
+	"refo-rag-server/internal/envelope"
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/featureflag"
+	"refo-rag-server/internal/health"
+	"refo-rag-server/internal/ipfilter"
+	"refo-rag-server/internal/lifecycle"
+	"refo-rag-server/internal/loadshed"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/openapi"
+	"refo-rag-server/internal/residency"
+	"refo-rag-server/internal/schedule"
 	"refo-rag-server/internal/service"
+	"refo-rag-server/internal/signing"
 	"refo-rag-server/internal/storage"
 )
 
+// Dependencies holds the services and stores the API routes are built from
+type Dependencies struct {
+	ConversationService  *service.ConversationService
+	PersonalInfoService  *service.PersonalInfoService
+	SessionService       *service.SessionService
+	ProfileService       *service.ProfileService
+	MemoryService        *service.MemoryService
+	ReindexService       *service.ReindexService
+	ForgettingService    *service.ForgettingService
+	UserDeletionService  *service.UserDeletionService
+	BulkDeleteService    *service.BulkDeleteService
+	JobLog               *service.JobLog
+	EmbeddingInspector   *service.EmbeddingInspector
+	IndexService         *service.IndexService
+	DeadLetterService    *service.DeadLetterService
+	IntegrityService     *service.IntegrityService
+	DriftService         *service.DriftService
+	Projections          *service.EmbeddingProjections
+	PersonalInfoReindex  *service.PersonalInfoReindexService
+	LegacyBackfill       *service.LegacyBackfillService
+	TopicMapService      *service.TopicMapService
+	InsightsService      *service.InsightsService
+	Doctor               *service.Doctor
+	ConversationImport   *service.ConversationImportService
+	UsageService         *service.UsageService
+	UserService          *service.UserService
+	StandingQueryService *service.StandingQueryService
+	SuggestService       *service.SuggestService
+	APIKeyService        *service.APIKeyService
+	PostgresStore        storage.PostgresStoreInterface
+	QdrantStore          storage.QdrantStoreInterface
+	CollectionManager    *storage.CollectionManager
+	MaintenanceMode      *service.MaintenanceMode
+	FeatureFlags         *featureflag.Store
+	Readiness            *lifecycle.Readiness
+	HealthMonitor        *health.Monitor
+	Elector              *coord.Elector
+	Scheduler            *schedule.Scheduler
+	AdminAPIKey          string
+
+	// AdminUI serves the operator dashboard at /admin
+	AdminUI bool
+
+	// Tracing records each request as a span, joining the caller's trace when it sends one
+	Tracing bool
+
+	// APIKeys authenticates service account keys; RequireAPIKeys makes them mandatory on non-admin routes
+	APIKeys        *apikey.Keyring
+	RequireAPIKeys bool
+
+	// IP allow and deny lists for all routes and for the admin and debug routes; nil disables a list
+	IPRules      *ipfilter.Rules
+	AdminIPRules *ipfilter.Rules
+
+	// Encryption manages the data keys of encrypted conversation content; nil when encryption is off
+	Encryption *envelope.Cipher
+
+	// Residency refuses requests for tenants and users homed in another region; nil disables it
+	Residency *residency.Guard
+
+	// SignatureVerifier accepts HMAC-signed requests in place of an API key; nil disables signing
+	SignatureVerifier *signing.Verifier
+
+	// LoadShedder caps concurrent requests per endpoint class; nil disables load shedding
+	LoadShedder *loadshed.Shedder
+
+	// Middleware from enabled plugins, installed after tenant resolution
+	Middleware []gin.HandlerFunc
+
+	// Request audit logging; disabled when AuditSink is nil
+	AuditSink    auditlog.Sink
+	AuditSampler *auditlog.Sampler
+	AuditMaxBody int
+
+	// AuditLog is the audit log file verified by the admin API; nil when not logging to a file
+	AuditLog *auditlog.WriterSink
+
+	// AnalyticsExports writes usage data to the blob store; nil when no blob store is configured
+	AnalyticsExports *service.AnalyticsExportService
+
+	// VectorExports exports and imports vectors through the blob store; nil when no blob store is configured
+	VectorExports *service.VectorExportService
+
+	// Certificates issues and serves signed deletion certificates; nil when no signing key is configured
+	Certificates *service.DeletionCertificates
+
+	// QueryAdapters keeps per-user query adapters; nil when they are disabled
+	QueryAdapters *service.QueryAdapters
+
+	// Caches are the enabled in-process caches, inspected and flushed through the admin API
+	Caches []cache.Inspector
+
+	// FusionBandit tunes fusion weights from search feedback; nil when it is disabled
+	FusionBandit *service.FusionBandit
+}
+
 // Router configures all API routes
-func Router(conversationService *service.ConversationService, postgresStore storage.PostgresStoreInterface, qdrantStore storage.QdrantStoreInterface) *gin.Engine {
-	router := gin.Default()
+func Router(deps Dependencies) *gin.Engine {
+	reporter := errreport.Default()
+
+	router := gin.New()
+	// Error messages are localized outside Recovery so panics get a localized 500 too
+	router.Use(middleware.RequestID())
+	if deps.Tracing {
+		router.Use(middleware.Trace())
+	}
+	router.Use(middleware.Logger(), middleware.LocalizeErrors(), middleware.Recovery(reporter))
+	if deps.IPRules != nil {
+		router.Use(middleware.FilterIPs("global", deps.IPRules))
+	}
+	router.Use(middleware.Tenant(), middleware.ReportServerErrors(reporter), middleware.DeduplicateEmbeddings())
+	router.Use(deps.Middleware...)
+	if deps.AuditSink != nil {
+		router.Use(middleware.RequestAudit(deps.AuditSink, deps.AuditSampler, deps.AuditMaxBody))
+	}
+
+	// Prometheus metrics
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// Swagger UI
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
+	// Operator dashboard; its pages hold no data and call the admin API with the operator's key
+	if deps.AdminUI {
+		ui := router.Group("/admin")
+		if deps.AdminIPRules != nil {
+			ui.Use(middleware.FilterIPs("admin", deps.AdminIPRules))
+		}
+		ui.GET("/*filepath", gin.WrapH(adminui.Handler("/admin/")))
+	}
+
+	// Write routes are rejected while the server is in maintenance mode
+	writeGuard := middleware.RejectWritesInMaintenance(deps.MaintenanceMode)
+
 	// RAG API routes
 	rag := router.Group("/api/rag")
 	{
 		// Health check endpoint
-		healthHandler := handler.NewHealthCheckHandler(postgresStore, qdrantStore)
+		healthHandler := handler.NewHealthCheckHandler(deps.PostgresStore, deps.QdrantStore, deps.Readiness, deps.HealthMonitor, deps.Elector)
 		rag.GET("/health", healthHandler.Handle)
+		rag.GET("/health/ready", healthHandler.Ready)
+
+		// OpenAPI document of the routes registered here, open so client generators can fetch it
+		openAPIHandler := handler.NewOpenAPIHandler(router.Routes, openapi.Options{
+			Swagger:        docs.SwaggerInfo.ReadDoc(),
+			Prefix:         "/api/rag",
+			AdminPrefixes:  []string{"/api/rag/admin", "/api/rag/debug", "/api/rag/conversation/delete-by-filter"},
+			Public:         []string{"/api/rag/health", "/api/rag/health/ready", "/api/rag/openapi.json"},
+			RequireAPIKeys: deps.RequireAPIKeys,
+			Signatures:     deps.SignatureVerifier != nil,
+		})
+		rag.GET("/openapi.json", openAPIHandler.Handle)
+
+		// Routes registered below need an API key when required; health checks stay open
+		if deps.RequireAPIKeys {
+			rag.Use(middleware.RequireAPIKey(deps.AdminAPIKey, deps.APIKeys, deps.SignatureVerifier))
+		}
+
+		// Callers with the experiment scope may override retrieval settings per request
+		rag.Use(middleware.AllowExperiments(deps.AdminAPIKey, deps.APIKeys))
+
+		// Responses warn when the embedding budget is nearly spent
+		rag.Use(middleware.QuotaHeaders())
+
+		// Routes registered below only serve data homed in this region
+		if deps.Residency != nil {
+			rag.Use(middleware.EnforceResidency(deps.Residency))
+		}
+
+		// Match streams stay open until the client leaves, so they are neither load shed nor counted in the SLIs
+		standingQueryHandler := handler.NewStandingQueryHandler(deps.StandingQueryService)
+		rag.GET("/users/:user_id/standing-queries/stream", standingQueryHandler.StreamMatches)
+
+		// Routes registered below count towards the availability and latency SLIs, shed requests included
+		rag.Use(middleware.RecordSLI())
+
+		// Routes registered below end at the caller's X-Request-Deadline, waiting for a load shedding slot included
+		rag.Use(middleware.PropagateDeadline())
+
+		// Routes registered below are load shed; health checks stay answerable under load
+		if deps.LoadShedder != nil {
+			rag.Use(middleware.ShedLoad(deps.LoadShedder))
+		}
 
 		// Save conversation endpoint
-		saveHandler := handler.NewSaveConversationHandler(conversationService)
-		rag.POST("/conversation/store", saveHandler.Handle)
+		saveHandler := handler.NewSaveConversationHandler(deps.ConversationService)
+		rag.POST("/conversation/store", writeGuard, saveHandler.Handle)
 
 		// Search conversations endpoint
-		searchHandler := handler.NewSearchConversationHandler(conversationService)
+		searchHandler := handler.NewSearchConversationHandler(deps.ConversationService)
 		rag.GET("/conversation/search", searchHandler.Handle)
+		if deps.FusionBandit != nil {
+			rag.POST("/conversation/search/feedback", writeGuard, handler.NewFusionBanditHandler(deps.FusionBandit).Feedback)
+		}
+
+		// Query suggestion endpoint
+		suggestHandler := handler.NewSuggestHandler(deps.SuggestService)
+		rag.GET("/suggest", suggestHandler.Suggest)
+
+		// Conversation status endpoints
+		conversationHandler := handler.NewConversationHandler(deps.ConversationService)
+		rag.GET("/conversation/:conversation_id", conversationHandler.GetConversation)
+		rag.GET("/conversation/:conversation_id/related", conversationHandler.GetRelatedConversations)
+		rag.POST("/conversation/recommend", conversationHandler.Recommend)
+		rag.PUT("/conversation/:conversation_id/archive", writeGuard, conversationHandler.ArchiveConversation)
+		rag.PUT("/conversation/:conversation_id/metadata", writeGuard, conversationHandler.UpdateMetadata)
+		rag.GET("/users/:user_id/conversations", conversationHandler.ListConversations)
+		rag.GET("/users/:user_id/stats", conversationHandler.GetUserStats)
+		rag.GET("/users/:user_id/timeline", conversationHandler.GetTimeline)
 
 		// Personal information endpoints
-		personalInfoHandler := handler.NewPersonalInfoHandler(postgresStore)
-		rag.POST("/personal-info", personalInfoHandler.CreatePersonalInfo)
+		personalInfoHandler := handler.NewPersonalInfoHandler(deps.PersonalInfoService)
+		rag.POST("/personal-info", writeGuard, personalInfoHandler.CreatePersonalInfo)
 		rag.GET("/personal-info/:info_id", personalInfoHandler.GetPersonalInfo)
 		rag.GET("/personal-info/user/:user_id", personalInfoHandler.GetPersonalInfoByUser)
-		rag.PUT("/personal-info/:info_id", personalInfoHandler.UpdatePersonalInfo)
-		rag.DELETE("/personal-info/:info_id", personalInfoHandler.DeletePersonalInfo)
+		rag.PUT("/personal-info/:info_id", writeGuard, personalInfoHandler.UpdatePersonalInfo)
+		rag.DELETE("/personal-info/:info_id", writeGuard, personalInfoHandler.DeletePersonalInfo)
+
+		// Session endpoints
+		sessionHandler := handler.NewSessionHandler(deps.SessionService)
+		rag.POST("/sessions", writeGuard, sessionHandler.CreateSession)
+		rag.GET("/sessions", sessionHandler.ListSessions)
+		rag.GET("/sessions/:session_id", sessionHandler.GetSession)
+		rag.POST("/sessions/:session_id/close", writeGuard, sessionHandler.CloseSession)
+		rag.GET("/sessions/:session_id/transcript", sessionHandler.GetTranscript)
+		rag.GET("/sessions/:session_id/context", sessionHandler.GetContext)
+		rag.POST("/sessions/:session_id/summarize", writeGuard, sessionHandler.SummarizeSession)
+
+		// Memory context, pinning and suppression endpoints
+		memoryHandler := handler.NewMemoryHandler(deps.MemoryService)
+		rag.GET("/retrieve", memoryHandler.Retrieve)
+		rag.GET("/users/:user_id/pinned", memoryHandler.ListPinned)
+		rag.PUT("/conversation/:conversation_id/pin", writeGuard, memoryHandler.PinConversation)
+		rag.PUT("/personal-info/:info_id/pin", writeGuard, memoryHandler.PinPersonalInfo)
+		rag.GET("/users/:user_id/suppressed", memoryHandler.ListSuppressed)
+		rag.PUT("/conversation/:conversation_id/suppression", writeGuard, memoryHandler.SuppressConversation)
+		rag.PUT("/personal-info/:info_id/suppression", writeGuard, memoryHandler.SuppressPersonalInfo)
+
+		// User profile endpoint
+		profileHandler := handler.NewProfileHandler(deps.ProfileService)
+		rag.GET("/users/:user_id/profile", profileHandler.GetProfile)
+
+		// Standing query endpoints
+		rag.POST("/standing-queries", writeGuard, standingQueryHandler.CreateStandingQuery)
+		rag.GET("/standing-queries/:query_id", standingQueryHandler.GetStandingQuery)
+		rag.DELETE("/standing-queries/:query_id", writeGuard, standingQueryHandler.DeleteStandingQuery)
+		rag.GET("/users/:user_id/standing-queries", standingQueryHandler.ListStandingQueries)
+
+		// Admin endpoints
+		// Admin address rules run before authentication so blocked callers can't probe keys
+		adminAuth := []gin.HandlerFunc{middleware.AdminAuth(deps.AdminAPIKey, deps.APIKeys)}
+		if deps.AdminIPRules != nil {
+			adminAuth = append([]gin.HandlerFunc{middleware.FilterIPs("admin", deps.AdminIPRules)}, adminAuth...)
+		}
+		admin := rag.Group("/admin", adminAuth...)
+
+		// Bulk deletion reaches every user's conversations, so it takes the admin key too, which
+		// RequireAPIKey leaves to AdminAuth
+		bulkDeleteHandler := handler.NewBulkDeleteHandler(deps.BulkDeleteService)
+		bulkDelete := rag.Group("/conversation", adminAuth...)
+		bulkDelete.POST("/delete-by-filter", writeGuard, bulkDeleteHandler.DeleteByFilter)
+
+		adminHandler := handler.NewAdminHandler(deps.CollectionManager, deps.MaintenanceMode, deps.FeatureFlags, deps.HealthMonitor)
+		admin.GET("/collections", adminHandler.ListCollections)
+		admin.GET("/maintenance", adminHandler.GetMaintenance)
+		admin.PUT("/maintenance", adminHandler.UpdateMaintenance)
+		admin.GET("/feature-flags", adminHandler.ListFeatureFlags)
+		admin.POST("/feature-flags/reload", adminHandler.ReloadFeatureFlags)
+		admin.GET("/health/history", adminHandler.GetHealthHistory)
+
+		adminCacheHandler := handler.NewAdminCacheHandler(deps.Caches)
+		admin.GET("/caches", adminCacheHandler.ListCaches)
+		admin.POST("/caches/:name/flush", adminCacheHandler.FlushCache)
+		admin.PUT("/conversation-aliases/:alias_id", writeGuard, conversationHandler.SetAlias)
+
+		adminUserHandler := handler.NewAdminUserHandler(deps.ReindexService, deps.UserDeletionService, deps.UserService)
+		admin.POST("/users", writeGuard, adminUserHandler.CreateUser)
+		admin.GET("/users", adminUserHandler.ListUsers)
+		admin.GET("/users/:user_id", adminUserHandler.GetUser)
+		admin.PATCH("/users/:user_id", writeGuard, adminUserHandler.UpdateUser)
+		admin.POST("/users/:user_id/disable", writeGuard, adminUserHandler.DisableUser)
+		admin.POST("/users/:user_id/enable", writeGuard, adminUserHandler.EnableUser)
+		admin.POST("/users/:user_id/reindex", writeGuard, adminUserHandler.ReindexUser)
+		admin.DELETE("/users/:user_id", writeGuard, adminUserHandler.DeleteUser)
+
+		adminTopicHandler := handler.NewAdminTopicHandler(deps.TopicMapService)
+		admin.GET("/users/:user_id/topic-map", adminTopicHandler.GetTopicMap)
+
+		adminInsightsHandler := handler.NewAdminInsightsHandler(deps.InsightsService)
+		admin.GET("/insights", adminInsightsHandler.GetInsights)
+
+		// The self-test's scratch vector is deleted again, so it runs in maintenance mode too
+		adminDoctorHandler := handler.NewAdminDoctorHandler(deps.Doctor)
+		admin.POST("/doctor", adminDoctorHandler.RunDoctor)
+		admin.GET("/migrations", adminDoctorHandler.GetMigrations)
+
+		if deps.QueryAdapters != nil {
+			adminQueryAdapterHandler := handler.NewAdminQueryAdapterHandler(deps.QueryAdapters)
+			admin.GET("/users/:user_id/query-adapters", adminQueryAdapterHandler.ListQueryAdapters)
+			admin.PUT("/users/:user_id/query-adapters", writeGuard, adminQueryAdapterHandler.UploadQueryAdapter)
+			admin.DELETE("/users/:user_id/query-adapters", writeGuard, adminQueryAdapterHandler.DeleteQueryAdapter)
+		}
+
+		if deps.FusionBandit != nil {
+			fusionBanditHandler := handler.NewFusionBanditHandler(deps.FusionBandit)
+			admin.GET("/fusion-bandit/tenants/:tenant", fusionBanditHandler.GetStatus)
+			admin.POST("/fusion-bandit/tenants/:tenant/freeze", writeGuard, fusionBanditHandler.Freeze)
+			admin.DELETE("/fusion-bandit/tenants/:tenant/freeze", writeGuard, fusionBanditHandler.Unfreeze)
+		}
+
+		adminJobHandler := handler.NewAdminJobHandler(deps.JobLog, deps.ForgettingService)
+		admin.GET("/jobs", adminJobHandler.ListJobs)
+		admin.GET("/jobs/:job_id", adminJobHandler.GetJob)
+		admin.POST("/retention/run", writeGuard, adminJobHandler.RunRetention)
+
+		if deps.Certificates != nil {
+			adminCertificateHandler := handler.NewAdminCertificateHandler(deps.Certificates)
+			admin.GET("/deletion-certificates", adminCertificateHandler.ListCertificates)
+			admin.GET("/deletion-certificates/public-key", adminCertificateHandler.GetPublicKey)
+			admin.GET("/deletion-certificates/:certificate_id", adminCertificateHandler.GetCertificate)
+		}
+
+		adminSchedulerHandler := handler.NewAdminSchedulerHandler(deps.Scheduler, deps.Elector)
+		admin.GET("/scheduler/tasks", adminSchedulerHandler.ListTasks)
+		admin.POST("/scheduler/tasks/:name/run", writeGuard, adminSchedulerHandler.RunTask)
+
+		adminIndexHandler := handler.NewAdminIndexHandler(deps.IndexService, deps.IntegrityService, deps.DriftService)
+		admin.GET("/index-health", adminIndexHandler.IndexHealth)
+		admin.POST("/index/optimize", writeGuard, adminIndexHandler.Optimize)
+		admin.POST("/integrity/verify", adminIndexHandler.VerifyIntegrity)
+		admin.POST("/embeddings/drift", adminIndexHandler.CheckDrift)
+
+		adminProjectionHandler := handler.NewAdminProjectionHandler(deps.Projections)
+		admin.GET("/embeddings/projections", adminProjectionHandler.ListProjections)
+		admin.POST("/embeddings/projections/reload", adminProjectionHandler.ReloadProjections)
+		admin.POST("/embeddings/projections/train", adminProjectionHandler.TrainProjection)
+
+		adminPersonalInfoHandler := handler.NewAdminPersonalInfoHandler(deps.PersonalInfoReindex)
+		admin.POST("/personal-info/reindex", writeGuard, adminPersonalInfoHandler.Reindex)
+
+		adminImportHandler := handler.NewAdminImportHandler(deps.ConversationImport)
+		admin.POST("/conversations/import", writeGuard, adminImportHandler.Import)
+
+		if deps.LegacyBackfill != nil {
+			adminLegacyBackfillHandler := handler.NewAdminLegacyBackfillHandler(deps.LegacyBackfill)
+			admin.POST("/conversations/legacy-backfill", writeGuard, adminLegacyBackfillHandler.Backfill)
+		}
+
+		adminUnembeddedHandler := handler.NewAdminUnembeddedHandler(deps.ConversationService)
+		admin.GET("/conversations/unembedded", adminUnembeddedHandler.ListUnembedded)
+		admin.POST("/conversations/:conversation_id/retry-embedding", writeGuard, adminUnembeddedHandler.RetryEmbedding)
+
+		adminDeadLetterHandler := handler.NewAdminDeadLetterHandler(deps.DeadLetterService)
+		admin.GET("/dlq", adminDeadLetterHandler.ListDeadLetters)
+		admin.GET("/dlq/:id", adminDeadLetterHandler.GetDeadLetter)
+		admin.POST("/dlq/:id/retry", writeGuard, adminDeadLetterHandler.RetryDeadLetter)
+		admin.DELETE("/dlq/:id", writeGuard, adminDeadLetterHandler.DiscardDeadLetter)
+
+		adminAPIKeyHandler := handler.NewAdminAPIKeyHandler(deps.APIKeyService)
+		admin.POST("/api-keys", writeGuard, adminAPIKeyHandler.CreateAPIKey)
+		admin.GET("/api-keys", adminAPIKeyHandler.ListAPIKeys)
+		admin.GET("/api-keys/:key_id", adminAPIKeyHandler.GetAPIKey)
+		admin.POST("/api-keys/:key_id/rotate", writeGuard, adminAPIKeyHandler.RotateAPIKey)
+		admin.DELETE("/api-keys/:key_id", writeGuard, adminAPIKeyHandler.RevokeAPIKey)
+
+		if deps.Encryption != nil {
+			adminEncryptionHandler := handler.NewAdminEncryptionHandler(deps.Encryption)
+			admin.GET("/encryption/keys", adminEncryptionHandler.ListDataKeys)
+			admin.POST("/encryption/keys/rotate", writeGuard, adminEncryptionHandler.RotateDataKey)
+			admin.POST("/encryption/rewrap", writeGuard, adminEncryptionHandler.RewrapDataKeys)
+		}
+
+		if deps.AuditLog != nil {
+			adminAuditHandler := handler.NewAdminAuditHandler(deps.AuditLog)
+			admin.GET("/audit/verify", adminAuditHandler.VerifyAuditLog)
+		}
+
+		if deps.AnalyticsExports != nil {
+			adminAnalyticsHandler := handler.NewAdminAnalyticsHandler(deps.AnalyticsExports)
+			admin.POST("/analytics/export", adminAnalyticsHandler.ExportDay)
+		}
+		if deps.VectorExports != nil {
+			adminVectorsHandler := handler.NewAdminVectorsHandler(deps.VectorExports)
+			admin.POST("/vectors/export", adminVectorsHandler.Export)
+			admin.POST("/vectors/import", writeGuard, adminVectorsHandler.Import)
+		}
+
+		adminUsageHandler := handler.NewAdminUsageHandler(deps.UsageService)
+		admin.GET("/usage", adminUsageHandler.GetUsage)
+
+		// Retrieval debugging endpoints, guarded like the admin endpoints
+		debugHandler := handler.NewDebugHandler(deps.ConversationService, deps.EmbeddingInspector)
+		admin.POST("/embeddings/inspect", debugHandler.InspectEmbedding)
+		admin.POST("/search/playground", debugHandler.Playground)
+		debug := rag.Group("/debug", adminAuth...)
+		debug.GET("/retrieval", debugHandler.ExplainRetrieval)
 	}
 
 	return router
@@ -0,0 +1,116 @@
+// Package apikey issues service account keys and authenticates them against a keyring that is
+// reloaded from storage, so keys can be created, rotated and revoked without a redeploy
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/hotstate"
+	"refo-rag-server/internal/models"
+)
+
+// secretPrefix marks a string as a key issued by this server
+const secretPrefix = "rag_"
+
+// displayPrefixLen is how many leading characters of a secret are kept to recognize the key
+const displayPrefixLen = 12
+
+// Generate returns a new random secret, the prefix shown for it and the hash stored for it
+func Generate() (secret string, prefix string, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	secret = secretPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return secret, secret[:displayPrefixLen], Hash(secret), nil
+}
+
+// Hash returns the hex SHA-256 of a secret. Secrets are random, so an unsalted hash is enough to
+// keep them out of the database
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Source loads the keys usable at a point in time, keyed by secret hash
+type Source interface {
+	LoadAPIKeys(ctx context.Context, now time.Time) (map[string]*models.APIKey, error)
+}
+
+// Keyring holds the usable keys in memory for authentication
+type Keyring struct {
+	source Source
+
+	// keys is never modified once stored; Reload replaces it
+	keys hotstate.Value[map[string]*models.APIKey]
+}
+
+// NewKeyring creates an empty keyring; call Reload to load its keys
+func NewKeyring(source Source) *Keyring {
+	k := &Keyring{source: source}
+	k.keys.Store(map[string]*models.APIKey{})
+	return k
+}
+
+// Reload replaces the keys with those currently usable; on error the loaded keys stay in effect
+func (k *Keyring) Reload(ctx context.Context) error {
+	keys, err := k.source.LoadAPIKeys(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to reload API keys: %w", err)
+	}
+
+	k.keys.Store(keys)
+	return nil
+}
+
+// Run reloads the keys every interval until ctx is cancelled, so changes made through another
+// instance take effect here too
+func (k *Keyring) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.Reload(ctx); err != nil {
+				fmt.Printf("warning: %v\n", err)
+				errreport.Background(ctx, "api_key_reload", err)
+			}
+		}
+	}
+}
+
+// Authenticate returns the key a secret belongs to, or nil if it is unknown, revoked or expired
+func (k *Keyring) Authenticate(secret string) *models.APIKey {
+	if secret == "" {
+		return nil
+	}
+
+	key := k.keys.Load()[Hash(secret)]
+
+	if key == nil || !key.Active(time.Now()) {
+		return nil
+	}
+	return key
+}
+
+// HasScope reports whether any loaded key grants scope, e.g. to tell whether the admin API is usable
+func (k *Keyring) HasScope(scope string) bool {
+	now := time.Now()
+	for _, key := range k.keys.Load() {
+		if key.Active(now) && key.HasScope(scope) {
+			return true
+		}
+	}
+	return false
+}
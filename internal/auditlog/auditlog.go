@@ -0,0 +1,202 @@
+package auditlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/models"
+)
+
+// Record is a sampled request/response pair with sensitive fields masked. Records are hash
+// chained: each carries its sequence number, the hash of the record before it and its own hash,
+// keyed by the server's chain key
+type Record struct {
+	Sequence int64  `json:"seq"`
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+	Chain    string `json:"chain,omitempty"` // ChainHMAC for records of a keyed chain
+
+	Time           time.Time         `json:"time"`
+	RequestID      string            `json:"request_id"`
+	Tenant         string            `json:"tenant"`
+	Method         string            `json:"method"`
+	Route          string            `json:"route"`
+	Path           string            `json:"path"`
+	Status         int               `json:"status"`
+	LatencyMS      float64           `json:"latency_ms"`
+	RequestHeaders map[string]string `json:"request_headers,omitempty"`
+	RequestBody    string            `json:"request_body,omitempty"`
+	ResponseBody   string            `json:"response_body,omitempty"`
+	Truncated      bool              `json:"truncated,omitempty"`
+}
+
+// Sink receives audit records
+type Sink interface {
+	Write(record Record) error
+	Close() error
+}
+
+// WriterSink writes records as JSON lines, chaining each to the one written before it
+type WriterSink struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+
+	// key keys the chain's hashes
+	key []byte
+
+	// path is the file written to, whose head is stored next to it; empty for stdout and stderr
+	path     string
+	sequence int64
+	lastHash string
+}
+
+// NewSink opens the sink named by target: "stdout", "stderr", or a file path, chaining records
+// with key. A file's chain continues from its last record, which must be the head stored with it;
+// a log that no longer ends at its head was truncated or rewritten and isn't appended to. A new
+// log, or one holding only records from before the chain was keyed, is given an empty head
+func NewSink(target string, key []byte) (*WriterSink, error) {
+	if len(key) == 0 {
+		return nil, ErrChainKeyRequired
+	}
+	switch target {
+	case "", "stdout":
+		return &WriterSink{encoder: json.NewEncoder(os.Stdout), key: key}, nil
+	case "stderr":
+		return &WriterSink{encoder: json.NewEncoder(os.Stderr), key: key}, nil
+	}
+
+	last, err := lastRecord(target)
+	if err != nil {
+		return nil, err
+	}
+	h, err := readHead(target, key)
+	if err != nil {
+		return nil, fmt.Errorf("%w; verify the audit log or move it aside", err)
+	}
+	if h == nil && last == nil {
+		if err := initHead(target, key); err != nil {
+			return nil, err
+		}
+		h = &head{}
+	}
+	if !headMatches(h, last) {
+		return nil, fmt.Errorf("audit log %s doesn't end at its stored head %s; verify it or move both aside", target, headPath(target))
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %w", target, err)
+	}
+	sink := &WriterSink{encoder: json.NewEncoder(file), closer: file, key: key, path: target}
+	if last != nil {
+		sink.sequence, sink.lastHash = last.Sequence, last.Hash
+	}
+	return sink, nil
+}
+
+// Path returns the file the sink writes to, or "" for stdout and stderr
+func (ws *WriterSink) Path() string {
+	return ws.path
+}
+
+// Head returns the sequence number and hash of the last record written; logging them elsewhere
+// anchors the chain beyond the reach of whoever can write the log
+func (ws *WriterSink) Head() (int64, string) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.sequence, ws.lastHash
+}
+
+// Write appends a record to the sink, linking it to the previous record, and moves a file's
+// stored head to it
+func (ws *WriterSink) Write(record Record) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	record.Sequence = ws.sequence + 1
+	record.PrevHash = ws.lastHash
+	record.Chain = ChainHMAC
+	hash, err := chainHash(record, ws.key)
+	if err != nil {
+		return err
+	}
+	record.Hash = hash
+
+	if err := ws.encoder.Encode(record); err != nil {
+		return err
+	}
+	ws.sequence, ws.lastHash = record.Sequence, record.Hash
+	if ws.path != "" {
+		return writeHead(ws.path, ws.sequence, ws.lastHash, ws.key)
+	}
+	return nil
+}
+
+// Verify checks the chain of the file the sink writes to
+func (ws *WriterSink) Verify() (*models.AuditVerification, error) {
+	if ws.path == "" {
+		return nil, fmt.Errorf("the audit log is not written to a file")
+	}
+	// Held so the log and its head aren't read between a record and its head
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return Verify(ws.path, ws.key)
+}
+
+// Close closes the underlying file, if any
+func (ws *WriterSink) Close() error {
+	if ws.closer == nil {
+		return nil
+	}
+	return ws.closer.Close()
+}
+
+// Sampler decides which requests are recorded
+type Sampler struct {
+	defaultRate float64
+	routeRates  map[string]float64
+}
+
+// NewSampler creates a sampler with a default rate and per-route overrides keyed by "METHOD /route"
+func NewSampler(defaultRate float64, routeRates map[string]float64) *Sampler {
+	return &Sampler{defaultRate: defaultRate, routeRates: routeRates}
+}
+
+// Sample reports whether a request to the route should be recorded
+func (s *Sampler) Sample(method, route string) bool {
+	rate, ok := s.routeRates[method+" "+route]
+	if !ok {
+		rate = s.defaultRate
+	}
+	if rate <= 0 {
+		return false
+	}
+	return rate >= 1 || rand.Float64() < rate
+}
+
+// ParseRouteRates parses "METHOD /route=rate" entries into a rate map
+func ParseRouteRates(entries []string) (map[string]float64, error) {
+	rates := make(map[string]float64, len(entries))
+	for _, entry := range entries {
+		idx := strings.LastIndex(entry, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid route rate %q, expected \"METHOD /route=rate\"", entry)
+		}
+
+		route := strings.Join(strings.Fields(entry[:idx]), " ")
+		rate, err := strconv.ParseFloat(strings.TrimSpace(entry[idx+1:]), 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("invalid sample rate in %q, expected a value between 0 and 1", entry)
+		}
+		rates[route] = rate
+	}
+	return rates, nil
+}
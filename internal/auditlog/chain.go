@@ -0,0 +1,305 @@
+package auditlog
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"refo-rag-server/internal/models"
+)
+
+// ChainHMAC names the keyed hash of chained records. Records without it were written before the
+// chain was keyed or chained at all; they can't be verified and may only precede the chain
+const ChainHMAC = "hmac-sha256"
+
+// ErrChainKeyRequired is returned when a sink or a verification has no chain key
+var ErrChainKeyRequired = errors.New("an audit chain key is required")
+
+// chainHash returns the hex HMAC-SHA256, keyed by the server's chain key, of a record's JSON
+// without its own hash. The JSON includes the previous record's hash, so altering, removing or
+// reordering a record breaks every later hash, and without the key no hash can be recomputed
+func chainHash(record Record, key []byte) (string, error) {
+	record.Hash = ""
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// head anchors the end of a chain: the sequence number and hash of its last record, MACed so it
+// can't be rewritten without the key. It is stored next to the log, so removing records from the
+// end of the log no longer goes unnoticed
+type head struct {
+	Sequence int64  `json:"seq"`
+	Hash     string `json:"hash"`
+	MAC      string `json:"mac"`
+}
+
+// headPath returns the file the head of the audit log at path is stored in
+func headPath(path string) string {
+	return path + ".head"
+}
+
+// headMAC returns the MAC of a chain head
+func headMAC(sequence int64, hash string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("head:" + strconv.FormatInt(sequence, 10) + ":" + hash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// writeHead replaces the stored head of the audit log at path; the head is written to a
+// temporary file and renamed over the old one, so a crash leaves either head intact
+func writeHead(path string, sequence int64, hash string, key []byte) error {
+	data, err := json.Marshal(head{Sequence: sequence, Hash: hash, MAC: headMAC(sequence, hash, key)})
+	if err != nil {
+		return fmt.Errorf("failed to encode audit chain head: %w", err)
+	}
+	tmp := headPath(path) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write audit chain head: %w", err)
+	}
+	if err := os.Rename(tmp, headPath(path)); err != nil {
+		return fmt.Errorf("failed to write audit chain head: %w", err)
+	}
+	return nil
+}
+
+// readHead returns the stored head of the audit log at path, or nil if none was stored
+func readHead(path string, key []byte) (*head, error) {
+	data, err := os.ReadFile(headPath(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit chain head: %w", err)
+	}
+	var h head
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("audit chain head %s is invalid: %w", headPath(path), err)
+	}
+	if !hmac.Equal([]byte(h.MAC), []byte(headMAC(h.Sequence, h.Hash, key))) {
+		return nil, fmt.Errorf("audit chain head %s was not written with this chain key", headPath(path))
+	}
+	return &h, nil
+}
+
+// initHead stores the head of a chain that hasn't started, at sequence 0 with no hash. It marks
+// the log as deliberately empty, so that only a log that doesn't exist yet or holds just records
+// written before the chain was keyed is given one. Any other log without a head had it removed
+func initHead(path string, key []byte) error {
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to open audit log %s: %w", path, err)
+	case info.Size() == 0:
+		return fmt.Errorf("audit log %s is empty and has no stored head %s; verify it or move it aside", path, headPath(path))
+	default:
+		chained, err := hasChainedRecord(path)
+		if err != nil {
+			return err
+		}
+		if chained {
+			return fmt.Errorf("audit log %s has no stored head %s; verify it or move it aside", path, headPath(path))
+		}
+	}
+	return writeHead(path, 0, "", key)
+}
+
+// hasChainedRecord reports whether an audit log file holds a chained record, or a line that
+// isn't a record at all
+func hasChainedRecord(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open audit log %s: %w", path, err)
+	}
+	defer file.Close()
+
+	chained := false
+	if err := eachLine(file, func(_ int, data []byte) bool {
+		var record Record
+		chained = json.Unmarshal(data, &record) != nil || record.Chain == ChainHMAC
+		return !chained
+	}); err != nil {
+		return false, fmt.Errorf("failed to read audit log %s: %w", path, err)
+	}
+	return chained, nil
+}
+
+// headMatches reports whether the last chained record of a log is its stored head. A record
+// written just before a crash may follow the head it didn't get to replace. A log without a
+// head never matches: even an empty chain has one, at sequence 0
+func headMatches(h *head, last *Record) bool {
+	if h == nil {
+		return false
+	}
+	if last == nil {
+		return h.Sequence == 0
+	}
+	if last.Sequence == h.Sequence {
+		return last.Hash == h.Hash
+	}
+	return last.Sequence == h.Sequence+1 && last.PrevHash == h.Hash
+}
+
+// Verify re-computes the chain of an audit log file with the chain key and reports the first
+// record that doesn't link to its predecessor or carries a hash made without the key. The chain
+// must end at the stored head, so records removed from the end are reported too, and a log
+// without a head fails even if it is empty. Records written before the chain was keyed may
+// precede it
+func Verify(path string, key []byte) (*models.AuditVerification, error) {
+	if len(key) == 0 {
+		return nil, ErrChainKeyRequired
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %w", path, err)
+	}
+	defer file.Close()
+
+	result := &models.AuditVerification{Path: path, Valid: true}
+	var previous *Record
+	lastLine := 0
+	err = eachLine(file, func(line int, data []byte) bool {
+		lastLine = line
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			fail(result, line, 0, "line is not a valid audit record")
+			return false
+		}
+
+		if record.Chain != ChainHMAC {
+			if previous != nil {
+				fail(result, line, 0, "unchained record after the start of the chain")
+				return false
+			}
+			result.Unchained++
+			return true
+		}
+
+		expectedSequence, expectedPrev := int64(1), ""
+		if previous != nil {
+			expectedSequence, expectedPrev = previous.Sequence+1, previous.Hash
+		}
+		switch {
+		case record.Sequence != expectedSequence:
+			fail(result, line, record.Sequence, fmt.Sprintf("sequence %d follows %d", record.Sequence, expectedSequence-1))
+			return false
+		case record.PrevHash != expectedPrev:
+			fail(result, line, record.Sequence, "prev_hash does not match the previous record's hash")
+			return false
+		}
+		hash, err := chainHash(record, key)
+		if err != nil || !hmac.Equal([]byte(hash), []byte(record.Hash)) {
+			fail(result, line, record.Sequence, "hash does not match the record's content")
+			return false
+		}
+
+		result.Records++
+		result.LastHash = record.Hash
+		previous = &record
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log %s: %w", path, err)
+	}
+	if !result.Valid {
+		return result, nil
+	}
+
+	h, err := readHead(path, key)
+	if err != nil {
+		fail(result, lastLine, 0, err.Error())
+		return result, nil
+	}
+	if h != nil {
+		result.HeadSequence, result.HeadHash = h.Sequence, h.Hash
+	}
+	if !headMatches(h, previous) {
+		switch {
+		case h == nil:
+			sequence := int64(0)
+			if previous != nil {
+				sequence = previous.Sequence
+			}
+			fail(result, lastLine, sequence, "the chain has no stored head; it was removed")
+		case previous == nil || previous.Sequence < h.Sequence:
+			fail(result, lastLine, h.Sequence, fmt.Sprintf("the chain ends before its head at sequence %d; records were removed from the end", h.Sequence))
+		default:
+			fail(result, lastLine, previous.Sequence, "the chain's last record is not its stored head")
+		}
+	}
+
+	return result, nil
+}
+
+// fail marks a verification failed at a record
+func fail(result *models.AuditVerification, line int, sequence int64, reason string) {
+	result.Valid = false
+	result.FailedLine = line
+	result.FailedSequence = sequence
+	result.FailureReason = reason
+}
+
+// lastRecord returns the last record of an audit log file, or nil if the file is missing, empty
+// or ends with a record written before the chain was keyed
+func lastRecord(path string) (*Record, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var last []byte
+	if err := eachLine(file, func(_ int, data []byte) bool {
+		last = append(last[:0], data...)
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("failed to read audit log %s: %w", path, err)
+	}
+	if last == nil {
+		return nil, nil
+	}
+
+	var record Record
+	if err := json.Unmarshal(last, &record); err != nil {
+		return nil, fmt.Errorf("audit log %s ends with an invalid record; verify or move it aside: %w", path, err)
+	}
+	if record.Chain != ChainHMAC {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// eachLine calls fn with every non-empty line and its 1-based number until fn returns false
+func eachLine(r io.Reader, fn func(line int, data []byte) bool) error {
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if len(data) > 0 && data[len(data)-1] == '\n' {
+			data = data[:len(data)-1]
+		}
+		if len(data) > 0 && !fn(line, data) {
+			return nil
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package auditlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testChainKey = []byte("0123456789abcdef0123456789abcdef")
+
+// writeTestLog writes n chained records to a new audit log and returns its path
+func writeTestLog(t *testing.T, n int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewSink(path, testChainKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if err := sink.Write(Record{Method: "GET", Route: "/api/rag/health", Path: "/api/rag/health", Status: 200}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// rewriteLines replaces an audit log's lines with what edit returns
+func rewriteLines(t *testing.T, path string, edit func(lines [][]byte) [][]byte) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := edit(bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")))
+	if err := os.WriteFile(path, append(bytes.Join(lines, []byte("\n")), '\n'), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyIntactChain(t *testing.T) {
+	path := writeTestLog(t, 3)
+
+	result, err := Verify(path, testChainKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Records != 3 || result.HeadSequence != 3 || result.HeadHash != result.LastHash {
+		t.Fatalf("verification = %+v, want 3 valid records ending at the head", result)
+	}
+
+	// The chain continues across restarts
+	sink, err := NewSink(path, testChainKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(Record{Method: "GET", Route: "/x", Path: "/x", Status: 200}); err != nil {
+		t.Fatal(err)
+	}
+	sink.Close()
+	if result, _ := Verify(path, testChainKey); !result.Valid || result.Records != 4 {
+		t.Fatalf("verification after restart = %+v, want 4 valid records", result)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	cases := []struct {
+		name   string
+		tamper func(t *testing.T, path string)
+		key    []byte
+	}{
+		{
+			name: "altered record",
+			tamper: func(t *testing.T, path string) {
+				rewriteLines(t, path, func(lines [][]byte) [][]byte {
+					lines[1] = bytes.Replace(lines[1], []byte(`"status":200`), []byte(`"status":500`), 1)
+					return lines
+				})
+			},
+		},
+		{
+			name: "truncated tail",
+			tamper: func(t *testing.T, path string) {
+				rewriteLines(t, path, func(lines [][]byte) [][]byte { return lines[:len(lines)-1] })
+			},
+		},
+		{
+			name: "removed head",
+			tamper: func(t *testing.T, path string) {
+				if err := os.Remove(headPath(path)); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name:   "chain rewritten with another key",
+			tamper: func(t *testing.T, path string) {},
+			key:    []byte("another key, 32 characters long!"),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeTestLog(t, 3)
+			tc.tamper(t, path)
+			key := testChainKey
+			if tc.key != nil {
+				key = tc.key
+			}
+
+			result, err := Verify(path, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Valid {
+				t.Fatalf("verification of a tampered log passed: %+v", result)
+			}
+		})
+	}
+}
+
+func TestVerifyEmptyChain(t *testing.T) {
+	path := writeTestLog(t, 0)
+
+	result, err := Verify(path, testChainKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Records != 0 {
+		t.Fatalf("verification of a log initialised empty = %+v, want valid", result)
+	}
+
+	// Emptying a log and removing its head doesn't pass for an empty log
+	for _, path := range []string{path, writeTestLog(t, 3)} {
+		if err := os.Truncate(path, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Remove(headPath(path)); err != nil {
+			t.Fatal(err)
+		}
+		if result, err := Verify(path, testChainKey); err != nil || result.Valid {
+			t.Fatalf("verification of an emptied log without a head = %+v, %v; want a failure", result, err)
+		}
+		if _, err := NewSink(path, testChainKey); err == nil || !strings.Contains(err.Error(), "head") {
+			t.Fatalf("NewSink on an emptied log without a head returned %v, want a missing head", err)
+		}
+	}
+}
+
+func TestNewSinkRefusesTruncatedLog(t *testing.T) {
+	path := writeTestLog(t, 3)
+	rewriteLines(t, path, func(lines [][]byte) [][]byte { return lines[:1] })
+
+	_, err := NewSink(path, testChainKey)
+	if err == nil || !strings.Contains(err.Error(), "head") {
+		t.Fatalf("NewSink on a truncated log returned %v, want a head mismatch", err)
+	}
+}
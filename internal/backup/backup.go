@@ -0,0 +1,148 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"refo-rag-server/internal/manifest"
+	"refo-rag-server/internal/storage"
+)
+
+// Kind is the manifest kind of a backup
+const Kind = "backup"
+
+// DumpFile is the PostgreSQL dump's name inside a backup directory
+const DumpFile = "postgres.dump"
+
+// Options configures backups and restores
+type Options struct {
+	// Dir is the backup directory
+	Dir string
+
+	// Database is the PostgreSQL database name, recorded in the manifest and passed to the tools
+	Database string
+
+	// PGEnv holds the PG* environment variables pg_dump and pg_restore connect with
+	PGEnv []string
+
+	// PGDump and PGRestore are the tool binaries; empty means looking them up in PATH
+	PGDump    string
+	PGRestore string
+}
+
+// Backup dumps the server tables and snapshots every collection into opts.Dir, then writes the
+// manifest with the checksum of every file. Row and point counts are taken right before the dump and snapshots, so writes during
+// the backup show up as a count mismatch on restore; run it in maintenance mode for an exact copy.
+func Backup(ctx context.Context, opts Options, postgres *storage.PostgresStore, collections *storage.CollectionManager) (*manifest.Manifest, error) {
+	if err := os.MkdirAll(opts.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	m := manifest.New(Kind)
+
+	tables, err := postgres.TableRowCounts(ctx, storage.BackupTables)
+	if err != nil {
+		return nil, err
+	}
+	m.Tables = tables
+
+	args := []string{"--format=custom", "--no-owner", "--file", filepath.Join(opts.Dir, DumpFile), "--dbname", opts.Database}
+	for _, table := range storage.BackupTables {
+		args = append(args, "--table", table)
+	}
+	if err := runTool(ctx, toolPath(opts.PGDump, "pg_dump"), args, opts.PGEnv); err != nil {
+		return nil, err
+	}
+	if err := m.AddFile(opts.Dir, DumpFile); err != nil {
+		return nil, err
+	}
+
+	for _, contentType := range collections.ContentTypes() {
+		collection, err := snapshotCollection(ctx, opts.Dir, contentType, collections)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.AddFile(opts.Dir, collection.File); err != nil {
+			return nil, err
+		}
+		m.Collections = append(m.Collections, *collection)
+	}
+
+	if err := m.Write(opts.Dir); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// snapshotCollection downloads a fresh snapshot of one collection into dir
+func snapshotCollection(ctx context.Context, dir string, contentType string, collections *storage.CollectionManager) (*manifest.Collection, error) {
+	cfg, _ := collections.Config(contentType)
+	store, err := collections.Store(contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := store.CountPoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count %s points: %w", cfg.Name, err)
+	}
+
+	name, err := store.CreateSnapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot %s: %w", cfg.Name, err)
+	}
+
+	snapshotFile := contentType + ".snapshot"
+	file, err := os.OpenFile(filepath.Join(dir, snapshotFile), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	err = store.DownloadSnapshot(ctx, name, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s snapshot: %w", cfg.Name, err)
+	}
+
+	// The local copy is the backup; don't leave snapshots accumulating on the server
+	if err := store.DeleteSnapshot(ctx, name); err != nil {
+		fmt.Printf("warning: failed to delete snapshot %s of %s: %v\n", name, cfg.Name, err)
+	}
+
+	return &manifest.Collection{
+		ContentType: contentType,
+		Name:        cfg.Name,
+		Model:       cfg.Model,
+		Dimension:   cfg.Dimension,
+		Distance:    cfg.Distance,
+		Points:      points,
+		File:        snapshotFile,
+	}, nil
+}
+
+// toolPath returns the configured binary or its default name
+func toolPath(configured string, name string) string {
+	if configured != "" {
+		return configured
+	}
+	return name
+}
+
+// runTool runs a PostgreSQL client tool, returning its output on failure
+func runTool(ctx context.Context, path string, args []string, env []string) error {
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = append(os.Environ(), env...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w: %s", filepath.Base(path), err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
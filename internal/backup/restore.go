@@ -0,0 +1,106 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"refo-rag-server/internal/manifest"
+	"refo-rag-server/internal/storage"
+)
+
+// Mismatch is a table or collection whose restored count differs from the manifest
+type Mismatch struct {
+	Name     string
+	Expected int64
+	Actual   int64
+}
+
+// Restore replaces the server tables and collections with the contents of a backup and returns
+// the counts that don't match the manifest. Nothing is changed unless the manifest validates; a
+// collection whose embedding model or dimension differs from the configuration is only restored
+// when force is set.
+func Restore(ctx context.Context, opts Options, force bool, postgres *storage.PostgresStore, collections *storage.CollectionManager) ([]Mismatch, error) {
+	m, err := manifest.Read(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	configured := make(map[string]storage.CollectionConfig)
+	for _, contentType := range collections.ContentTypes() {
+		configured[contentType], _ = collections.Config(contentType)
+	}
+	if err := m.Validate(opts.Dir, Kind, configured, force); err != nil {
+		return nil, fmt.Errorf("invalid backup: %w", err)
+	}
+
+	args := []string{"--clean", "--if-exists", "--no-owner", "--single-transaction", "--dbname", opts.Database, filepath.Join(opts.Dir, DumpFile)}
+	if err := runTool(ctx, toolPath(opts.PGRestore, "pg_restore"), args, opts.PGEnv); err != nil {
+		return nil, err
+	}
+
+	for _, collection := range m.Collections {
+		if err := restoreCollection(ctx, opts.Dir, collection, collections); err != nil {
+			return nil, err
+		}
+	}
+
+	return verify(ctx, m, postgres, collections)
+}
+
+// restoreCollection uploads a collection snapshot into the configured collection
+func restoreCollection(ctx context.Context, dir string, collection manifest.Collection, collections *storage.CollectionManager) error {
+	store, err := collections.Store(collection.ContentType)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(filepath.Join(dir, collection.File))
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot file: %w", err)
+	}
+	defer file.Close()
+
+	if err := store.RestoreSnapshot(ctx, collection.File, file); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", store.Collection(), err)
+	}
+
+	return nil
+}
+
+// verify compares restored row and point counts with the manifest
+func verify(ctx context.Context, m *manifest.Manifest, postgres *storage.PostgresStore, collections *storage.CollectionManager) ([]Mismatch, error) {
+	tables := make([]string, 0, len(m.Tables))
+	for table := range m.Tables {
+		tables = append(tables, table)
+	}
+
+	counts, err := postgres.TableRowCounts(ctx, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []Mismatch
+	for _, table := range tables {
+		if counts[table] != m.Tables[table] {
+			mismatches = append(mismatches, Mismatch{Name: "table " + table, Expected: m.Tables[table], Actual: counts[table]})
+		}
+	}
+
+	for _, collection := range m.Collections {
+		store, err := collections.Store(collection.ContentType)
+		if err != nil {
+			return nil, err
+		}
+		points, err := store.CountPoints(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s points: %w", store.Collection(), err)
+		}
+		if points != collection.Points {
+			mismatches = append(mismatches, Mismatch{Name: "collection " + store.Collection(), Expected: collection.Points, Actual: points})
+		}
+	}
+
+	return mismatches, nil
+}
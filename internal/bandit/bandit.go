@@ -0,0 +1,201 @@
+// Package bandit picks the fusion weights of conversation searches with a multi-armed bandit.
+// Each arm is a named set of weights for the pipeline's retrievers and the recency reranker;
+// relevance feedback on a search's results rewards the arm that served it. Arms are chosen by
+// Thompson sampling on a Beta posterior of each arm's relevance rate, within guardrails: the
+// first arm is the baseline and always keeps a share of searches, and an arm that has proven
+// worse than the baseline stops being chosen.
+package bandit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// RecencyKey names the recency reranker's weight in an arm spec, next to retriever weights
+const RecencyKey = "recency"
+
+// Arm is a set of fusion weights a search can be served with
+type Arm struct {
+	Name string
+
+	// FusionWeights scales each named retriever's contribution to the fused score
+	FusionWeights map[string]float64
+
+	// RecencyWeight replaces the recency reranker's weight; nil keeps the configured one
+	RecencyWeight *float64
+}
+
+// ParseArms parses arms written as "name:retriever=weight,recency=weight;name:...". The first
+// arm is the baseline
+func ParseArms(spec string) ([]Arm, error) {
+	var arms []Arm
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, weights, ok := strings.Cut(part, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("arm %q must be name:retriever=weight,...", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("arm %q is defined twice", name)
+		}
+		seen[name] = true
+
+		arm := Arm{Name: name, FusionWeights: make(map[string]float64)}
+		for _, pair := range strings.Split(weights, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" {
+				return nil, fmt.Errorf("arm %q: expected retriever=weight, got %q", name, pair)
+			}
+			weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || weight < 0 || math.IsInf(weight, 0) {
+				return nil, fmt.Errorf("arm %q: weight of %s must be a non-negative number", name, key)
+			}
+			if key == RecencyKey {
+				if weight > 1 {
+					return nil, fmt.Errorf("arm %q: recency weight must be at most 1", name)
+				}
+				arm.RecencyWeight = &weight
+				continue
+			}
+			arm.FusionWeights[key] = weight
+		}
+		arms = append(arms, arm)
+	}
+	if len(arms) < 2 {
+		return nil, fmt.Errorf("at least two arms are needed, the first being the baseline")
+	}
+	return arms, nil
+}
+
+// Stats is the feedback an arm has collected: trials rated, rewards the relevant ones
+type Stats struct {
+	Trials  int64
+	Rewards int64
+}
+
+// Mean returns the arm's observed relevance rate, 0 before any trial
+func (s Stats) Mean() float64 {
+	if s.Trials == 0 {
+		return 0
+	}
+	return float64(s.Rewards) / float64(s.Trials)
+}
+
+// Guardrails bound how far the bandit strays from the baseline arm
+type Guardrails struct {
+	// BaselineShare is the share of searches always served by the baseline (0 to 1)
+	BaselineShare float64
+
+	// MinTrials is how many trials an arm needs before it can be demoted
+	MinTrials int64
+
+	// MaxRegression is how far an arm's relevance rate may fall below the baseline's before the
+	// arm is demoted and no longer chosen
+	MaxRegression float64
+}
+
+// Demoted reports whether an arm has proven worse than the baseline; the baseline never is
+func Demoted(arms []Arm, stats map[string]Stats, guard Guardrails, i int) bool {
+	if i == 0 {
+		return false
+	}
+	arm := stats[arms[i].Name]
+	if arm.Trials < guard.MinTrials {
+		return false
+	}
+	return arm.Mean() < stats[arms[0].Name].Mean()-guard.MaxRegression
+}
+
+// Choose returns the index of the arm to serve a search with
+func Choose(arms []Arm, stats map[string]Stats, guard Guardrails, rng *rand.Rand) int {
+	if rng.Float64() < guard.BaselineShare {
+		return 0
+	}
+	best, bestDraw := 0, -1.0
+	for i, arm := range arms {
+		if Demoted(arms, stats, guard, i) {
+			continue
+		}
+		s := stats[arm.Name]
+		draw := sampleBeta(rng, float64(1+s.Rewards), float64(1+s.Trials-s.Rewards))
+		if draw > bestDraw {
+			best, bestDraw = i, draw
+		}
+	}
+	return best
+}
+
+// Best returns the index of the arm with the highest posterior mean among those not demoted
+func Best(arms []Arm, stats map[string]Stats, guard Guardrails) int {
+	best, bestMean := 0, -1.0
+	for i, arm := range arms {
+		if Demoted(arms, stats, guard, i) {
+			continue
+		}
+		s := stats[arm.Name]
+		mean := float64(1+s.Rewards) / float64(2+s.Trials)
+		if mean > bestMean {
+			best, bestMean = i, mean
+		}
+	}
+	return best
+}
+
+// sampleBeta draws from Beta(a, b) as the ratio of two gamma draws
+func sampleBeta(rng *rand.Rand, a float64, b float64) float64 {
+	x := sampleGamma(rng, a)
+	y := sampleGamma(rng, b)
+	if x+y == 0 {
+		return 0
+	}
+	return x / (x + y)
+}
+
+// sampleGamma draws from Gamma(shape, 1) with the Marsaglia-Tsang method; shape is at least 1
+// here, since every count is offset by the uniform prior
+func sampleGamma(rng *rand.Rand, shape float64) float64 {
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rng.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rng.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
+
+// Choice records the arm a search was served with, for the response
+type Choice struct {
+	Arm string
+}
+
+type contextKey struct{}
+
+// WithChoice returns a context in which a search records the arm it is served with
+func WithChoice(ctx context.Context) (context.Context, *Choice) {
+	choice := &Choice{}
+	return context.WithValue(ctx, contextKey{}, choice), choice
+}
+
+// Record stores the arm a search was served with in the context's choice, if it has one
+func Record(ctx context.Context, arm string) {
+	if choice, ok := ctx.Value(contextKey{}).(*Choice); ok {
+		choice.Arm = arm
+	}
+}
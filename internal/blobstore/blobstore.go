@@ -0,0 +1,148 @@
+// Package blobstore stores exported files under slash-separated keys. The local directory store
+// also serves mounted object storage buckets
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrNotFound is returned when opening a key that holds no blob
+var ErrNotFound = errors.New("blob not found")
+
+// Writer writes a blob. Close publishes it; Abort discards it instead
+type Writer interface {
+	io.WriteCloser
+	Abort()
+}
+
+// Store holds blobs under slash-separated keys
+type Store interface {
+	// Create starts writing a blob; it replaces any blob under key once the writer is closed
+	// without error, so readers never see a partial blob
+	Create(ctx context.Context, key string) (Writer, error)
+
+	// Open reads a blob, failing with ErrNotFound if there is none under key
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// List returns the keys starting with prefix in sorted order
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// Dir stores blobs as files below a root directory
+type Dir struct {
+	root string
+}
+
+// NewDir creates a store rooted at dir, creating the directory if needed
+func NewDir(dir string) (*Dir, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create blob store directory: %w", err)
+	}
+	return &Dir{root: dir}, nil
+}
+
+// Create writes to a temporary file next to the blob and renames it into place on Close
+func (d *Dir) Create(_ context.Context, key string) (Writer, error) {
+	target, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blob: %w", err)
+	}
+	return &dirWriter{file: file, target: target}, nil
+}
+
+// Open reads the file of a blob
+func (d *Dir) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	target, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	return file, nil
+}
+
+// List walks the root directory, skipping temporary files of unfinished blobs
+func (d *Dir) List(_ context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(d.root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			return nil
+		}
+		rel, err := filepath.Rel(d.root, p)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blobs: %w", err)
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// path maps a key to its file, refusing keys that would escape the root
+func (d *Dir) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean == "/" || clean != "/"+key {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(d.root, filepath.FromSlash(clean[1:])), nil
+}
+
+// dirWriter moves a finished temporary file into place
+type dirWriter struct {
+	file   *os.File
+	target string
+}
+
+func (w *dirWriter) Write(p []byte) (int, error) {
+	return w.file.Write(p)
+}
+
+func (w *dirWriter) Close() error {
+	if err := w.file.Close(); err != nil {
+		os.Remove(w.file.Name())
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Rename(w.file.Name(), w.target); err != nil {
+		os.Remove(w.file.Name())
+		return fmt.Errorf("failed to store blob: %w", err)
+	}
+	return nil
+}
+
+func (w *dirWriter) Abort() {
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
@@ -0,0 +1,41 @@
+// Package bootstrap assembles the server's stores, embedding providers and search pipelines from
+// configuration. cmd/server wires the services on top of what it returns; combinations of
+// settings whose components can't work together are rejected by Check before anything connects
+package bootstrap
+
+import (
+	"fmt"
+
+	"refo-rag-server/internal/config"
+)
+
+// Memory store backends
+const (
+	BackendPostgres = "postgres"
+	BackendSQLite   = "sqlite"
+	BackendMySQL    = "mysql"
+)
+
+// Check rejects combinations of settings that each validate on their own but whose components
+// can't work together
+func Check(cfg *config.Config) error {
+	if cfg.MemoryStoreBackend != BackendPostgres {
+		if cfg.AnalyticsExport.Enabled {
+			return fmt.Errorf("MEMORY_STORE_BACKEND=%s can't be combined with ANALYTICS_EXPORT_ENABLED: analytics exports read conversations from postgres", cfg.MemoryStoreBackend)
+		}
+		// SQLite and MySQL run without a Postgres server, where these features keep their tables
+		switch {
+		case cfg.QueryAdapters:
+			return fmt.Errorf("MEMORY_STORE_BACKEND=%s can't be combined with QUERY_ADAPTERS: query adapters are kept in postgres", cfg.MemoryStoreBackend)
+		case cfg.FusionBanditEnabled:
+			return fmt.Errorf("MEMORY_STORE_BACKEND=%s can't be combined with FUSION_BANDIT_ENABLED: bandit trials are kept in postgres", cfg.MemoryStoreBackend)
+		case cfg.DeletionCertificateKey != "":
+			return fmt.Errorf("MEMORY_STORE_BACKEND=%s can't be combined with DELETION_CERTIFICATE_KEY: certificates are kept in postgres", cfg.MemoryStoreBackend)
+		}
+	}
+	if cfg.Standby && cfg.MemoryStoreBackend != BackendPostgres {
+		return fmt.Errorf("STANDBY_MODE requires MEMORY_STORE_BACKEND=postgres: the standby reads conversations from a postgres replica")
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"refo-rag-server/internal/config"
+	"refo-rag-server/internal/coord"
+	"refo-rag-server/internal/lifecycle"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// MemoryStore keeps conversations and personal information
+type MemoryStore interface {
+	storage.ConversationStore
+	storage.PersonalInfoStore
+	storage.UserStore
+
+	// GetSessionConversations retrieves a session's conversations in chronological order
+	GetSessionConversations(ctx context.Context, sessionID string) ([]*models.Conversation, error)
+
+	// SetContentCipher encrypts conversation content saved from now on
+	SetContentCipher(cipher storage.ContentCipher)
+}
+
+// Relational holds the relational stores. Store keeps every relational record: Postgres, or the
+// SQLite or MySQL database when that backend is configured. Memories, Sessions and Users are Store
+// under the interfaces the services take
+type Relational struct {
+	Store storage.RelationalStore
+
+	// Postgres is Store on the Postgres backend and nil on SQLite and MySQL, which run without a
+	// Postgres server; features that only Postgres supports are rejected by Check
+	Postgres *storage.PostgresStore
+
+	Memories MemoryStore
+
+	Sessions storage.SessionStore
+
+	Users storage.UserStore
+
+	// Locker serializes migrations and elects the leader replica
+	Locker *coord.Locker
+}
+
+// OpenRelational opens the relational store. SQLite opens its database file; Postgres and MySQL
+// connect to their server, waiting for it to come up
+func OpenRelational(cfg *config.Config) (*Relational, error) {
+	switch cfg.MemoryStoreBackend {
+	case BackendSQLite:
+		store, err := storage.NewSQLiteStore(cfg.SQLitePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+		}
+		return &Relational{
+			Store:    store,
+			Memories: store,
+			Sessions: store,
+			Users:    store,
+			Locker:   coord.NewLocalLocker(),
+		}, nil
+
+	case BackendMySQL:
+		// MySQL gets as long as Postgres would to come up
+		var store *storage.MySQLStore
+		err := lifecycle.WaitFor(context.Background(), "MySQL", cfg.StartupRetryPolicy(cfg.PostgresStartupWait), func(ctx context.Context) error {
+			var err error
+			store, err = storage.NewMySQLStore(cfg.MySQLDSN)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to MySQL: %w", err)
+		}
+		return &Relational{
+			Store:    store,
+			Memories: store,
+			Sessions: store,
+			Users:    store,
+			Locker:   coord.NewMySQLLocker(store.GetDB()),
+		}, nil
+	}
+
+	var postgresStore *storage.PostgresStore
+	err := lifecycle.WaitFor(context.Background(), "PostgreSQL", cfg.StartupRetryPolicy(cfg.PostgresStartupWait), func(ctx context.Context) error {
+		store, err := storage.NewPostgresStore(cfg.GetPostgresDSN())
+		if err != nil {
+			return err
+		}
+		postgresStore = store
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+	}
+
+	return &Relational{
+		Store:    postgresStore,
+		Postgres: postgresStore,
+		Memories: postgresStore,
+		Sessions: postgresStore,
+		Users:    postgresStore,
+		Locker:   coord.NewLocker(postgresStore.GetDB()),
+	}, nil
+}
+
+// SetContentCipher encrypts the content the store saves from now on
+func (r *Relational) SetContentCipher(cipher storage.ContentCipher) {
+	r.Store.SetContentCipher(cipher)
+}
+
+// Migrate runs the migrations of the relational database; replicas starting together take turns
+func (r *Relational) Migrate(opts storage.MigrationOptions) error {
+	switch store := r.Store.(type) {
+	case *storage.SQLiteStore:
+		return storage.MigrateSQLite(store.GetDB())
+	case *storage.MySQLStore:
+		return r.Locker.WithLock(context.Background(), "mysql_migrations", func() error {
+			return storage.MigrateMySQL(store.GetDB())
+		})
+	}
+
+	return r.Locker.WithLock(context.Background(), "postgres_migrations", func() error {
+		return storage.Migrate(r.Postgres.GetDB(), opts)
+	})
+}
+
+// CheckSchema fails unless the database schema is current, for a standby that can't migrate its
+// read-only replica and waits for the primary to
+func (r *Relational) CheckSchema(opts storage.MigrationOptions) error {
+	if r.Postgres == nil {
+		return fmt.Errorf("only the schema of a %s database can be planned", BackendPostgres)
+	}
+	plan, err := storage.PlanMigrations(r.Postgres.GetDB(), opts)
+	if err != nil {
+		return err
+	}
+	if len(plan.Pending) > 0 {
+		return fmt.Errorf("%d migration statements are pending; start the primary first so it migrates the database", len(plan.Pending))
+	}
+	return nil
+}
+
+// Close closes the relational database
+func (r *Relational) Close() {
+	if err := r.Store.Close(); err != nil {
+		log.Printf("warning: failed to close database: %v", err)
+	}
+}
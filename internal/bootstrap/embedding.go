@@ -0,0 +1,122 @@
+package bootstrap
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"refo-rag-server/internal/cache"
+	"refo-rag-server/internal/config"
+	"refo-rag-server/internal/projection"
+	"refo-rag-server/internal/storage"
+)
+
+// EmbeddingProjections loads the configured dimensionality reduction of each model, keyed by model,
+// checking that it yields the dimension of every collection embedded with the model
+func EmbeddingProjections(cfg *config.Config, collections *storage.CollectionManager) (map[string]*projection.Projector, error) {
+	specs, err := projection.ParseSpecs(cfg.EmbeddingProjections)
+	if err != nil {
+		return nil, err
+	}
+
+	projectors := make(map[string]*projection.Projector, len(specs))
+	for model, spec := range specs {
+		projector, err := projection.New(spec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load projection of %s: %w", model, err)
+		}
+		if matrix := projector.Matrix(); matrix != nil && matrix.Model != "" && matrix.Model != model {
+			return nil, fmt.Errorf("projection matrix %s was trained on %s, not %s", spec.Path, matrix.Model, model)
+		}
+		projectors[model] = projector
+	}
+
+	for _, contentType := range collections.ContentTypes() {
+		collection, _ := collections.Config(contentType)
+		projector, ok := projectors[collection.Model]
+		if !ok {
+			continue
+		}
+		if projector.OutputDimension() != collection.Dimension {
+			return nil, fmt.Errorf("projection of %s yields %d dimensions, the %s collection has %d",
+				collection.Model, projector.OutputDimension(), contentType, collection.Dimension)
+		}
+		log.Printf("Projecting %s embeddings of %s to %d dimensions (%s)", contentType, collection.Model, collection.Dimension, projector.Spec().Method)
+	}
+
+	return projectors, nil
+}
+
+// EmbeddingProviders creates one OpenAI embedding provider per distinct collection model, keyed
+// by model. With embedding recording on, each records its vectors or is replaced by a replay of
+// them; with an embedding cache, repeated texts are served from it; a model with a projection has
+// its vectors reduced after that
+func EmbeddingProviders(cfg *config.Config, collections *storage.CollectionManager, httpClient *http.Client, projectors map[string]*projection.Projector, embeddingCache *cache.Cache[[]float32]) (map[string]storage.EmbeddingProvider, error) {
+	embeddingPrefixes, err := storage.ParseEmbeddingPrefixes(cfg.EmbeddingPrefixes)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := make(map[string]storage.EmbeddingProvider)
+	for _, contentType := range collections.ContentTypes() {
+		collection, _ := collections.Config(contentType)
+		if _, exists := providers[collection.Model]; exists {
+			continue
+		}
+		provider, err := recordedEmbeddingProvider(cfg, collection.Model, storage.NewOpenAIEmbeddingProvider(
+			cfg.OpenAIAPIKey,
+			collection.Model,
+			collection.Dimension,
+			storage.EmbeddingOptions{
+				Prefixes:   embeddingPrefixes[collection.Model],
+				Normalize:  cfg.EmbeddingNormalize,
+				HTTPClient: httpClient,
+			},
+		))
+		if err != nil {
+			return nil, err
+		}
+		if embeddingCache != nil {
+			provider = storage.NewCachingEmbeddingProvider(provider, collection.Model, embeddingCache)
+		}
+		if projector, ok := projectors[collection.Model]; ok {
+			provider = storage.NewProjectedEmbeddingProvider(provider, projector)
+		}
+		providers[collection.Model] = provider
+	}
+
+	for _, contentType := range []string{storage.ContentTypeConversations, storage.ContentTypePersonalInfo} {
+		collection, ok := collections.Config(contentType)
+		if !ok {
+			return nil, fmt.Errorf("no collection is configured for %s", contentType)
+		}
+		if providers[collection.Model] == nil {
+			return nil, fmt.Errorf("no embedding provider for model %s of %s", collection.Model, contentType)
+		}
+	}
+
+	return providers, nil
+}
+
+// recordedEmbeddingProvider applies the embedding recording mode to the provider of a model
+func recordedEmbeddingProvider(cfg *config.Config, model string, provider storage.EmbeddingProvider) (storage.EmbeddingProvider, error) {
+	path := storage.EmbeddingRecordingPath(cfg.EmbeddingRecordingDir, model)
+	switch cfg.EmbeddingRecording {
+	case storage.EmbeddingRecordingRecord:
+		recorder, err := storage.NewRecordingEmbeddingProvider(provider, path)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Recording embeddings of %s to %s", model, path)
+		return recorder, nil
+	case storage.EmbeddingRecordingReplay:
+		replay, err := storage.NewReplayEmbeddingProvider(path)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Replaying %d recorded embeddings of %s from %s", replay.Len(), model, path)
+		return replay, nil
+	default:
+		return provider, nil
+	}
+}
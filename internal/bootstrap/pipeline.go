@@ -0,0 +1,102 @@
+package bootstrap
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"refo-rag-server/internal/config"
+	"refo-rag-server/internal/retrieval"
+	"refo-rag-server/internal/service"
+	"refo-rag-server/internal/storage"
+)
+
+// SearchPipelines builds the conversation search pipeline from the configured stages and, when a
+// share of searches is sent to a canary, the canary pipeline. adapter may be nil to leave query
+// embeddings unadapted
+func SearchPipelines(
+	cfg *config.Config,
+	conversations storage.ConversationStore,
+	collections *storage.CollectionManager,
+	embeddingProviders map[string]storage.EmbeddingProvider,
+	adapter retrieval.QueryAdapter,
+) (*retrieval.Pipeline, service.CanaryOptions, error) {
+	canary := service.CanaryOptions{Percent: cfg.CanaryPercent}
+
+	calibrations, err := retrieval.ParseCalibrations(cfg.SearchCalibrations)
+	if err != nil {
+		return nil, canary, err
+	}
+
+	location, err := time.LoadLocation(cfg.SearchTimeZone)
+	if err != nil {
+		return nil, canary, fmt.Errorf("invalid SEARCH_TIME_ZONE: %w", err)
+	}
+
+	conversationStore, err := collections.Store(storage.ContentTypeConversations)
+	if err != nil {
+		return nil, canary, err
+	}
+	conversationModel := cfg.Collections[storage.ContentTypeConversations].Model
+	pipeline, err := retrieval.Build(retrieval.Spec{
+		Transformers: cfg.SearchTransformers,
+		Retrievers:   cfg.SearchRetrievers,
+		Fuser:        cfg.SearchFuser,
+		Filters:      cfg.SearchFilters,
+		Normalizer:   cfg.SearchNormalizer,
+		Rerankers:    cfg.SearchRerankers,
+	}, retrieval.Deps{
+		Conversations:      conversations,
+		Vectors:            conversationStore,
+		Embedder:           embeddingProviders[conversationModel],
+		Model:              conversationModel,
+		Calibrations:       calibrations,
+		RecencyWeight:      cfg.SearchRecencyWeight,
+		RecencyHalfLife:    cfg.SearchRecencyHalfLife,
+		ImportanceWeight:   cfg.ImportanceWeight,
+		ImportanceHalfLife: cfg.ImportanceHalfLife,
+		Location:           location,
+		MaxCandidates:      cfg.SearchMaxCandidates,
+		Adapter:            adapter,
+	})
+	if err != nil {
+		return nil, canary, err
+	}
+
+	if cfg.CanaryPercent <= 0 {
+		return pipeline, canary, nil
+	}
+
+	canaryStore, err := collections.Store(cfg.CanaryContentType)
+	if err != nil {
+		return nil, canary, fmt.Errorf("canary: %w", err)
+	}
+	canaryModel := cfg.Collections[cfg.CanaryContentType].Model
+	canary.Pipeline, err = retrieval.Build(retrieval.Spec{
+		Transformers: cfg.CanaryTransformers,
+		Retrievers:   cfg.CanaryRetrievers,
+		Fuser:        cfg.CanaryFuser,
+		Filters:      cfg.CanaryFilters,
+		Normalizer:   cfg.CanaryNormalizer,
+		Rerankers:    cfg.CanaryRerankers,
+	}, retrieval.Deps{
+		Conversations:      conversations,
+		Vectors:            canaryStore,
+		Embedder:           embeddingProviders[canaryModel],
+		Model:              canaryModel,
+		Calibrations:       calibrations,
+		RecencyWeight:      cfg.CanaryRecencyWeight,
+		RecencyHalfLife:    cfg.SearchRecencyHalfLife,
+		ImportanceWeight:   cfg.CanaryImportanceWeight,
+		ImportanceHalfLife: cfg.ImportanceHalfLife,
+		Location:           location,
+		MaxCandidates:      cfg.SearchMaxCandidates,
+		Adapter:            adapter,
+	})
+	if err != nil {
+		return nil, canary, fmt.Errorf("canary: %w", err)
+	}
+	log.Printf("Canary search pipeline serving %.1f%% of users from %s", cfg.CanaryPercent, cfg.CanaryContentType)
+
+	return pipeline, canary, nil
+}
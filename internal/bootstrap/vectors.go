@@ -0,0 +1,85 @@
+package bootstrap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"refo-rag-server/internal/config"
+	"refo-rag-server/internal/coord"
+	"refo-rag-server/internal/lifecycle"
+	"refo-rag-server/internal/storage"
+	"refo-rag-server/internal/tlsutil"
+)
+
+// OpenCollections connects to Qdrant with one collection per configured content type and, waiting
+// for Qdrant to come up, creates or migrates the collections; replicas starting together take turns
+func OpenCollections(cfg *config.Config, locker *coord.Locker) (*storage.CollectionManager, error) {
+	collectionConfigs := make([]storage.CollectionConfig, 0, len(cfg.Collections))
+	for contentType, collection := range cfg.Collections {
+		collectionConfigs = append(collectionConfigs, storage.CollectionConfig{
+			ContentType: contentType,
+			Name:        collection.Name,
+			Model:       collection.Model,
+			Dimension:   collection.Dimension,
+			Distance:    collection.Distance,
+
+			ShardNumber:            collection.ShardNumber,
+			ReplicationFactor:      collection.ReplicationFactor,
+			WriteConsistencyFactor: collection.WriteConsistencyFactor,
+
+			UserIsolation:   collection.UserIsolation,
+			ReadConsistency: collection.ReadConsistency,
+			Datatype:        collection.Datatype,
+			Multivector:     collection.Multivector,
+		})
+	}
+
+	var qdrantTLS *tls.Config
+	if cfg.QdrantTLS {
+		var err error
+		qdrantTLS, err = tlsutil.ClientConfig(tlsutil.ClientOptions{
+			CAFile:     cfg.QdrantCACert,
+			CertFile:   cfg.QdrantClientCert,
+			KeyFile:    cfg.QdrantClientKey,
+			ServerName: cfg.QdrantTLSServerName,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure Qdrant TLS: %w", err)
+		}
+	}
+
+	qdrantClient, err := storage.NewQdrantHTTPClient(qdrantTLS, cfg.EgressOptions(), cfg.QdrantClientOptions())
+	if err != nil {
+		return nil, err
+	}
+	collectionManager, err := storage.NewCollectionManager(cfg.GetQdrantURL(), qdrantClient, collectionConfigs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = lifecycle.WaitFor(context.Background(), "Qdrant", cfg.StartupRetryPolicy(cfg.QdrantStartupWait), func(ctx context.Context) error {
+		return locker.WithLock(ctx, "qdrant_migrations", func() error {
+			err := storage.MigrateCollections(collectionManager, cfg.AutoCreateCollections)
+			if errors.Is(err, storage.ErrCollectionMissing) {
+				// Retrying won't create the collection, so fail fast
+				return lifecycle.Permanent(err)
+			}
+			return err
+		})
+	})
+	if err != nil {
+		collectionManager.Close()
+		return nil, fmt.Errorf("failed to run Qdrant migrations: %w", err)
+	}
+
+	// Requests that find a collection missing re-initialize it, or are counted when that's disabled
+	collectionManager.EnableRecovery(storage.RecoveryOptions{
+		Enabled:    cfg.CollectionRecovery,
+		AutoCreate: cfg.AutoCreateCollections,
+		Cooldown:   cfg.CollectionRecoveryCooldown,
+	})
+
+	return collectionManager, nil
+}
@@ -0,0 +1,181 @@
+// Package cache holds the in-process caches in front of the embedding provider, the search
+// pipeline and the encryption data keys. Each cache is a size-bounded LRU whose entries expire
+// after a fixed lifetime; it counts its hits, misses and evictions and reports them to Prometheus
+// under its name, so operators can tell whether a cache earns its memory and flush it when it
+// serves stale results.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+)
+
+// Cache names
+const (
+	NameEmbedding = "embedding"
+	NameQuery     = "query"
+	NameDataKey   = "data_key"
+)
+
+// Eviction reasons
+const (
+	evictedCapacity = "capacity"
+	evictedExpired  = "expired"
+)
+
+// Inspector is the cache surface the admin endpoints need, regardless of the cached value type
+type Inspector interface {
+	Name() string
+	Stats() models.CacheStats
+	Flush() int
+}
+
+// entry is a cached value with the key it is found under
+type entry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// Cache is a size-bounded LRU cache of values of type V whose entries expire after a fixed TTL.
+// It is safe for concurrent use
+type Cache[V any] struct {
+	name     string
+	capacity int
+	ttl      time.Duration
+
+	mu        sync.Mutex
+	order     *list.List
+	items     map[string]*list.Element
+	hits      uint64
+	misses    uint64
+	evictions uint64
+	expired   uint64
+	flushes   uint64
+	flushedAt *time.Time
+}
+
+// New creates a cache holding up to capacity entries for ttl each
+func New[V any](name string, capacity int, ttl time.Duration) *Cache[V] {
+	metrics.CacheEntries.WithLabelValues(name).Set(0)
+	return &Cache[V]{
+		name:     name,
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Name returns the name the cache reports its metrics under
+func (c *Cache[V]) Name() string {
+	return c.name
+}
+
+// Get returns the unexpired value cached under key
+func (c *Cache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	element, ok := c.items[key]
+	if ok && time.Now().After(element.Value.(*entry[V]).expiresAt) {
+		c.remove(element)
+		c.expired++
+		metrics.CacheEvictions.WithLabelValues(c.name, evictedExpired).Inc()
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		metrics.CacheRequests.WithLabelValues(c.name, "miss").Inc()
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	c.hits++
+	metrics.CacheRequests.WithLabelValues(c.name, "hit").Inc()
+	return element.Value.(*entry[V]).value, true
+}
+
+// Set caches value under key, evicting the least recently used entry when the cache is full
+func (c *Cache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(c.ttl)
+	if element, ok := c.items[key]; ok {
+		element.Value = &entry[V]{key: key, value: value, expiresAt: expiresAt}
+		c.order.MoveToFront(element)
+		return
+	}
+	for c.order.Len() >= c.capacity && c.order.Len() > 0 {
+		c.remove(c.order.Back())
+		c.evictions++
+		metrics.CacheEvictions.WithLabelValues(c.name, evictedCapacity).Inc()
+	}
+	c.items[key] = c.order.PushFront(&entry[V]{key: key, value: value, expiresAt: expiresAt})
+	metrics.CacheEntries.WithLabelValues(c.name).Set(float64(c.order.Len()))
+}
+
+// DeleteFunc drops every entry whose key matches, returning how many were dropped
+func (c *Cache[V]) DeleteFunc(match func(key string) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deleted := 0
+	for key, element := range c.items {
+		if match(key) {
+			c.remove(element)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// Flush drops every entry, returning how many were dropped
+func (c *Cache[V]) Flush() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	flushed := c.order.Len()
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	c.flushes++
+	now := time.Now().UTC()
+	c.flushedAt = &now
+	metrics.CacheEntries.WithLabelValues(c.name).Set(0)
+	return flushed
+}
+
+// Stats returns a snapshot of the cache's counters
+func (c *Cache[V]) Stats() models.CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := models.CacheStats{
+		Name:             c.name,
+		Entries:          c.order.Len(),
+		Capacity:         c.capacity,
+		TTLSeconds:       c.ttl.Seconds(),
+		Hits:             c.hits,
+		Misses:           c.misses,
+		Evictions:        c.evictions + c.expired,
+		ExpiredEvictions: c.expired,
+		Flushes:          c.flushes,
+		FlushedAt:        c.flushedAt,
+	}
+	if lookups := c.hits + c.misses; lookups > 0 {
+		stats.HitRatio = float64(c.hits) / float64(lookups)
+	}
+	return stats
+}
+
+// remove unlinks an entry; the caller holds the lock
+func (c *Cache[V]) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*entry[V]).key)
+	metrics.CacheEntries.WithLabelValues(c.name).Set(float64(c.order.Len()))
+}
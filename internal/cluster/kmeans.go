@@ -0,0 +1,238 @@
+// Package cluster groups embedding vectors by topic. Vectors are compared by cosine similarity,
+// the measure the vector store searches with, so clustering works on unit-length copies and
+// centroids are unit length too (spherical k-means)
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+)
+
+// maxIterations bounds the assignment rounds; clusters of a few thousand embeddings usually settle
+// in well under it
+const maxIterations = 50
+
+// restarts is how many times clustering runs from different seeds, keeping the tightest result;
+// high-dimensional embeddings are spread out enough that a single seeding often puts two centroids
+// in one topic
+const restarts = 4
+
+// maxDefaultClusters caps the clusters DefaultClusters picks
+const maxDefaultClusters = 12
+
+// Result assigns each vector to a cluster
+type Result struct {
+	// Assignments holds the cluster of each vector, in input order
+	Assignments []int
+
+	// Centroids holds the unit-length mean direction of each cluster
+	Centroids [][]float32
+
+	// Similarities holds each vector's cosine similarity to its cluster's centroid
+	Similarities []float64
+}
+
+// DefaultClusters returns a cluster count for n vectors: the rule-of-thumb sqrt(n/2), at least 2
+// and at most 12 so a topic map stays readable
+func DefaultClusters(n int) int {
+	if n < 4 {
+		return 1
+	}
+	k := int(math.Round(math.Sqrt(float64(n) / 2)))
+	return min(max(k, 2), maxDefaultClusters)
+}
+
+// KMeans clusters vectors, all of one dimension, into k groups, keeping the best of a few runs
+// seeded by k-means++. The seeds are fixed, so the same vectors always give the same clusters
+func KMeans(ctx context.Context, vectors [][]float32, k int) (*Result, error) {
+	n := len(vectors)
+	if n == 0 {
+		return nil, fmt.Errorf("no vectors to cluster")
+	}
+	if k <= 0 || k > n {
+		return nil, fmt.Errorf("cluster count must be between 1 and %d, got %d", n, k)
+	}
+
+	d := len(vectors[0])
+	points := make([][]float32, n)
+	for i, vector := range vectors {
+		if len(vector) != d {
+			return nil, fmt.Errorf("vector %d has %d dimensions, expected %d", i, len(vector), d)
+		}
+		points[i] = unit(vector)
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	var best *Result
+	var bestTotal float64
+	for restart := 0; restart < restarts; restart++ {
+		result, err := lloyd(ctx, points, seed(points, k, rng))
+		if err != nil {
+			return nil, err
+		}
+		var total float64
+		for _, similarity := range result.Similarities {
+			total += similarity
+		}
+		if best == nil || total > bestTotal {
+			best, bestTotal = result, total
+		}
+	}
+	return best, nil
+}
+
+// lloyd refines centroids by alternately assigning each point to its most similar centroid and
+// moving each centroid to the mean direction of its points, until no assignment changes
+func lloyd(ctx context.Context, points [][]float32, centroids [][]float32) (*Result, error) {
+	n, d, k := len(points), len(points[0]), len(centroids)
+	assignments := make([]int, n)
+	similarities := make([]float64, n)
+	for i := range assignments {
+		assignments[i] = -1
+	}
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		changed := false
+		for i, point := range points {
+			best, bestSimilarity := 0, math.Inf(-1)
+			for c, centroid := range centroids {
+				if similarity := dot(point, centroid); similarity > bestSimilarity {
+					best, bestSimilarity = c, similarity
+				}
+			}
+			if assignments[i] != best {
+				assignments[i] = best
+				changed = true
+			}
+			similarities[i] = bestSimilarity
+		}
+		if !changed {
+			break
+		}
+
+		sums := make([][]float64, k)
+		counts := make([]int, k)
+		for c := range sums {
+			sums[c] = make([]float64, d)
+		}
+		for i, point := range points {
+			c := assignments[i]
+			counts[c]++
+			for j, value := range point {
+				sums[c][j] += float64(value)
+			}
+		}
+		for c := range centroids {
+			if counts[c] == 0 {
+				// Restart an emptied cluster from the point its current cluster fits worst
+				worst := 0
+				for i := range points {
+					if similarities[i] < similarities[worst] {
+						worst = i
+					}
+				}
+				centroids[c] = points[worst]
+				similarities[worst] = 1
+				continue
+			}
+			centroids[c] = unit64(sums[c])
+		}
+	}
+
+	// Similarities to the final centroids
+	for i, point := range points {
+		similarities[i] = dot(point, centroids[assignments[i]])
+	}
+
+	return &Result{Assignments: assignments, Centroids: centroids, Similarities: similarities}, nil
+}
+
+// seed picks k initial centroids by greedy k-means++: each next one is drawn from a few candidate
+// points, each picked with probability proportional to its squared distance from the nearest
+// centroid so far, keeping the candidate that brings the points closest to their centroids
+func seed(points [][]float32, k int, rng *rand.Rand) [][]float32 {
+	centroids := make([][]float32, 0, k)
+	centroids = append(centroids, points[rng.IntN(len(points))])
+
+	// For unit vectors the squared distance is 2 - 2*similarity
+	distances := make([]float64, len(points))
+	for i, point := range points {
+		distances[i] = math.Max(2-2*dot(point, centroids[0]), 0)
+	}
+	trials := 2 + int(math.Log(float64(k)))
+	for len(centroids) < k {
+		var total float64
+		for _, distance := range distances {
+			total += distance
+		}
+
+		var best []float64
+		bestPotential, bestCandidate := math.Inf(1), 0
+		for trial := 0; trial < trials; trial++ {
+			candidate := rng.IntN(len(points))
+			if total > 0 {
+				target := rng.Float64() * total
+				for i, distance := range distances {
+					target -= distance
+					if target <= 0 && distance > 0 {
+						candidate = i
+						break
+					}
+				}
+			}
+
+			updated := make([]float64, len(points))
+			var potential float64
+			for i, point := range points {
+				updated[i] = math.Min(distances[i], math.Max(2-2*dot(point, points[candidate]), 0))
+				potential += updated[i]
+			}
+			if potential < bestPotential {
+				best, bestPotential, bestCandidate = updated, potential, candidate
+			}
+		}
+		centroids = append(centroids, points[bestCandidate])
+		distances = best
+	}
+	return centroids
+}
+
+// unit returns a unit-length copy of vector; a zero vector stays zero
+func unit(vector []float32) []float32 {
+	var norm float64
+	for _, value := range vector {
+		norm += float64(value) * float64(value)
+	}
+	scaled := make([]float32, len(vector))
+	if norm == 0 {
+		return scaled
+	}
+	scale := 1 / math.Sqrt(norm)
+	for i, value := range vector {
+		scaled[i] = float32(float64(value) * scale)
+	}
+	return scaled
+}
+
+// unit64 returns a unit-length float32 copy of a float64 vector
+func unit64(vector []float64) []float32 {
+	converted := make([]float32, len(vector))
+	for i, value := range vector {
+		converted[i] = float32(value)
+	}
+	return unit(converted)
+}
+
+// dot returns the dot product of two vectors of equal length
+func dot(a []float32, b []float32) float64 {
+	var sum float64
+	for i, value := range a {
+		sum += float64(value) * float64(b[i])
+	}
+	return sum
+}
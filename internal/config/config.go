@@ -2,41 +2,573 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
+
+	"refo-rag-server/internal/bandit"
+	"refo-rag-server/internal/egress"
+	"refo-rag-server/internal/httpclient"
+	"refo-rag-server/internal/lifecycle"
+	"refo-rag-server/internal/loadshed"
+	"refo-rag-server/internal/residency"
+	"refo-rag-server/internal/schedule"
+	"refo-rag-server/internal/usage"
 )
 
+// templatePlaceholder matches the placeholders of embedding text templates
+var templatePlaceholder = regexp.MustCompile(`\{[a-z_]+\}`)
+
 // Config holds all application configuration
 type Config struct {
 	// Server
 	Port int
 	Env  string // development, production
 
+	// Profiles names the profiles applied from ProfilesFile, base first; environment variables
+	// override their settings
+	ProfilesFile string
+	Profiles     []string
+
+	// Listener
+	BindAddress       string // interface to bind; empty binds all interfaces
+	ListenSocket      string // Unix domain socket path; overrides TCP when set
+	ListenSocketMode  os.FileMode
+	SystemdActivation bool
+
+	// TLS termination for the API server
+	TLSCertFile      string
+	TLSKeyFile       string
+	AutocertDomains  []string
+	AutocertCacheDir string
+	AutocertEmail    string
+	HTTPRedirectPort int // plain HTTP port redirecting to HTTPS; 0 disables
+	HTTP2Cleartext   bool
+	ShutdownTimeout  time.Duration
+
+	// Load shedding: concurrent requests per endpoint class (0 is unlimited)
+	LoadShedLimits       map[string]int
+	LoadShedQueueTimeout time.Duration
+	LoadShedRetryAfter   time.Duration
+
+	// In-process event bus running the side effects of saves: events waiting per subscriber,
+	// events each subscriber handles at once, and the bound on one handler call (0 is unlimited)
+	EventQueueSize      int
+	EventWorkers        int
+	EventHandlerTimeout time.Duration
+
 	// PostgreSQL
 	PostgresHost     string
 	PostgresPort     int
 	PostgresUser     string
-	PostgresPassword string
+	PostgresPassword string `secret:"true"`
 	PostgresDB       string
 	PostgresSSLMode  string
 
+	// PostgreSQL TLS (used with sslmode=verify-ca/verify-full)
+	PostgresSSLRootCert string
+	PostgresSSLCert     string
+	PostgresSSLKey      string
+
+	// MemoryStoreBackend keeps conversations, personal information and the other relational
+	// records: postgres, sqlite (the file at SQLitePath) or mysql (MySQLDSN). SQLite and MySQL run
+	// without a Postgres server
+	MemoryStoreBackend string
+	SQLitePath         string
+	MySQLDSN           string `secret:"true"`
+
 	// Qdrant
 	QdrantHost       string
 	QdrantPort       int
 	QdrantCollection string
 
+	// Qdrant TLS
+	QdrantTLS           bool
+	QdrantCACert        string
+	QdrantClientCert    string
+	QdrantClientKey     string
+	QdrantTLSServerName string
+
+	// Qdrant request retries after network errors and 429/502/503/504 responses, the first retry's
+	// backoff, and the bound on each attempt (0 leaves only QdrantTimeout)
+	QdrantMaxRetries     int
+	QdrantRetryBackoff   time.Duration
+	QdrantAttemptTimeout time.Duration
+
+	// Egress: proxy for requests to OpenAI and Qdrant ("" follows HTTP(S)_PROXY, "direct" disables
+	// proxying), hosts that bypass it, and extra trusted roots for TLS-inspecting proxies
+	EgressProxy    string
+	EgressNoProxy  string
+	EgressCABundle string
+
+	// Collections holds per-content-type collection settings keyed by content type
+	Collections map[string]CollectionConfig
+
+	// AutoCreateCollections creates missing collections with default index settings at startup;
+	// when off, a missing collection stops the server
+	AutoCreateCollections bool
+
+	// CollectionRecovery re-initializes a collection that a request finds missing, such as one
+	// recreated or replaced by an alias while the server runs, and retries the request once; at
+	// most once per CollectionRecoveryCooldown per collection
+	CollectionRecovery         bool
+	CollectionRecoveryCooldown time.Duration
+
 	// OpenAI
-	OpenAIAPIKey string
+	OpenAIAPIKey string `secret:"true"`
 	OpenAIModel  string
 	EmbeddingDim int
 
+	// EmbeddingPrefixes holds "model=query_prefix|document_prefix" instructions for asymmetric models
+	EmbeddingPrefixes []string
+
+	// EmbeddingNormalize L2-normalizes every stored and query vector
+	EmbeddingNormalize bool
+
+	// EmbeddingProjections holds "model=truncate:dimension" or "model=pca:path" reductions applied
+	// to a model's vectors before they are stored or searched
+	EmbeddingProjections []string
+
+	// EmbeddingProjectionDir is where trained PCA matrices are written
+	EmbeddingProjectionDir string
+
+	// EmbeddingRecording is off, record (append every embedding to a file per model in
+	// EmbeddingRecordingDir) or replay (serve recorded vectors instead of calling OpenAI)
+	EmbeddingRecording    string
+	EmbeddingRecordingDir string
+
+	// Embedding cache: entries kept (0 disables it) and how long each is served
+	EmbeddingCacheSize int
+	EmbeddingCacheTTL  time.Duration
+
+	// Query cache of search results: entries kept (0 disables it) and how long each is served
+	QueryCacheSize int
+	QueryCacheTTL  time.Duration
+
+	// Chat model used for summaries and other generated text
+	OpenAIChatModel     string
+	OpenAIChatMaxTokens int
+
+	// Session summaries: rolling update after each save and the per-update timeout
+	SessionRollingSummary bool
+	SessionSummaryTimeout time.Duration
+
+	// User profiles: cache lifetime, scheduled refresh and conversations used per profile
+	ProfileCacheTTL         time.Duration
+	ProfileRefresh          ScheduledTask
+	ProfileMaxConversations int
+
+	// Per-user query adapters: applied to query embeddings when enabled, cached per user and model
+	QueryAdapters         bool
+	QueryAdapterCacheTTL  time.Duration
+	QueryAdapterCacheSize int
+
+	// EmbedRoles lists the message roles included in conversation embeddings
+	EmbedRoles []string
+
+	// Embedded text assembly: role labels, message separator and the number of trailing messages kept
+	EmbedRolePrefixes bool
+	EmbedSeparator    string
+	EmbedMaxTurns     int
+
+	// EmbedChunkTurns is the number of messages per chunk of conversations embedded in chunks, for
+	// tenants with the chunking feature flag
+	EmbedChunkTurns int
+
+	// PersonalInfoEmbedTemplate renders the embedded text of personal info entries from their
+	// {content}, {category} and {importance}
+	PersonalInfoEmbedTemplate string
+
+	// PersonalInfoRetrieval is dense (one vector per entry) or multivector (experimental: the entry
+	// and each of its segments embedded separately and searched by MaxSim)
+	PersonalInfoRetrieval string
+
+	// PersonalInfoMultivectorGranularity splits entries into sentences or overlapping phrases;
+	// PersonalInfoMultivectorMaxVectors caps the vectors per entry or query, the whole text included
+	PersonalInfoMultivectorGranularity string
+	PersonalInfoMultivectorMaxVectors  int
+
+	// VectorWriteMode is outbox or rollback: what happens to a new conversation when its vector
+	// write fails
+	VectorWriteMode string
+
+	// SaveDurability is fast, safe or queued: when saves that don't request a durability level
+	// are acknowledged
+	SaveDurability string
+
+	// Search recency: blend weight (0 disables) and half-life of the recency score
+	SearchRecencyWeight   float64
+	SearchRecencyHalfLife time.Duration
+
+	// RetrieveRouting is all to search every memory collection on the retrieve endpoint, or
+	// classifier to search only those the query classifier weighs at least RetrieveRoutingMinWeight
+	RetrieveRouting          string
+	RetrieveRoutingMinWeight float64
+
+	// Plugins lists the compiled-in plugins to enable
+	Plugins []string
+
+	// Search pipeline: registered stage names per kind, in the order they run
+	SearchTransformers []string
+	SearchRetrievers   []string
+	SearchFuser        string
+	SearchFilters      []string
+	SearchNormalizer   string
+	SearchRerankers    []string
+
+	// SearchCalibrations holds "model=slope:intercept" score calibrations for the calibrated normalizer
+	SearchCalibrations []string
+
+	// SnippetFilter strips greetings, filler and boilerplate from returned messages, with the
+	// patterns of SnippetFilterFile or the built-in ones when it is empty
+	SnippetFilter     bool
+	SnippetFilterFile string
+
+	// ContextCompression reduces the conversations /retrieve returns to the ContextCompressionRatio
+	// of their sentences most similar to the query, unless a request sets compress
+	ContextCompression      bool
+	ContextCompressionRatio float64
+
+	// SearchMaxAgeDays limits searches without max_age_days to conversations created within that
+	// many days (0 searches all history); SearchTenantMaxAgeDays overrides it per tenant
+	SearchMaxAgeDays       int
+	SearchTenantMaxAgeDays map[string]int
+
+	// SearchTimeZone is the IANA time zone the temporal transformer reads calendar expressions
+	// such as "yesterday" in
+	SearchTimeZone string
+
+	// SearchMaxCandidates caps the candidates fetched again, doubling each time, when filters
+	// leave a search fewer results than requested (0 fetches once)
+	SearchMaxCandidates int
+
+	// Canary search: CanaryPercent of users are served by a second pipeline over the collection of
+	// CanaryContentType; its stages and blend weights default to the primary pipeline's
+	CanaryPercent          float64
+	CanaryContentType      string
+	CanaryTransformers     []string
+	CanaryRetrievers       []string
+	CanaryFuser            string
+	CanaryFilters          []string
+	CanaryNormalizer       string
+	CanaryRerankers        []string
+	CanaryRecencyWeight    float64
+	CanaryImportanceWeight float64
+
+	// Fusion bandit: primary searches are served with one of FusionBanditArms, chosen from the
+	// tenant's relevance feedback within the guardrails; stats are reread every FusionBanditRefresh
+	FusionBanditEnabled       bool
+	FusionBanditArms          []bandit.Arm
+	FusionBanditBaselineShare float64
+	FusionBanditMinTrials     int
+	FusionBanditMaxRegression float64
+	FusionBanditRefresh       time.Duration
+
+	// Memory importance: scorer (heuristic or llm), decay half-life and search blend weight (0 disables)
+	ImportanceScorer   string
+	ImportanceHalfLife time.Duration
+	ImportanceWeight   float64
+
+	// Forgetting: on schedule, delete conversations whose decayed importance is below the threshold
+	Forget          ScheduledTask
+	ForgetThreshold float64
+	ForgetMinAge    time.Duration
+
+	// Embedding drift: on schedule, re-embed DriftSampleSize random stored conversations and alert
+	// when the mean cosine distance to their stored vectors exceeds DriftThreshold
+	Drift           ScheduledTask
+	DriftSampleSize int
+	DriftThreshold  float64
+
+	// Embedding anomalies: on schedule, compare the conversation vectors of each user active in the
+	// last EmbeddingAnomalyWindow with up to EmbeddingAnomalyBaseline earlier ones, flag shifts over
+	// EmbeddingAnomalyThreshold standard deviations and post them to the anomaly webhook
+	EmbeddingAnomaly                ScheduledTask
+	EmbeddingAnomalyWindow          time.Duration
+	EmbeddingAnomalyBaseline        int
+	EmbeddingAnomalyThreshold       float64
+	EmbeddingAnomalyDistressPhrases []string
+	EmbeddingAnomalyWebhookURL      string `secret:"true"`
+	EmbeddingAnomalyWebhookSecret   string `secret:"true"`
+
+	// Standing queries: searches run against each saved conversation. Users may register up to
+	// StandingQueryMaxPerUser; matches are posted to StandingQueryWebhookURL and sent to open
+	// streams, each holding up to StandingQueryStreamBuffer undelivered matches
+	StandingQueryMaxPerUser    int
+	StandingQueryStreamBuffer  int
+	StandingQueryWebhookURL    string `secret:"true"`
+	StandingQueryWebhookSecret string `secret:"true"`
+
+	// Query suggestions: past searches logged within SuggestLookback and topics of the user's
+	// SuggestConversations most recent conversations, cached per user for SuggestCacheTTL
+	SuggestLookback      time.Duration
+	SuggestConversations int
+	SuggestCacheTTL      time.Duration
+	SuggestCacheSize     int
+
+	// Cross-user insights: topics and question categories are reported only for groups of at
+	// least InsightsKAnonymity users; InsightsCategories replaces the default categories
+	InsightsKAnonymity int
+	InsightsCategories []string
+
+	// Shadow embedding model: a sampled fraction of saves and searches is mirrored to ShadowModel and
+	// its own collection and logged, never served; empty disables shadowing
+	ShadowModel      string
+	ShadowDimension  int
+	ShadowCollection string
+	ShadowSaveRate   float64
+	ShadowQueryRate  float64
+
+	// SearchLogEnabled records every conversation search for usage analytics
+	SearchLogEnabled bool
+
+	// BlobStoreDir is the directory exported files are written to; exports are off when empty
+	BlobStoreDir string
+
+	// Analytics export: on schedule, export each ended day of the last AnalyticsExportLookbackDays
+	// without a complete export as Parquet below the prefix
+	AnalyticsExport             ScheduledTask
+	AnalyticsExportLookbackDays int
+	AnalyticsExportPrefix       string
+
+	// IntegrityVerify reconciles the relational stores with Qdrant on schedule
+	IntegrityVerify ScheduledTask
+
+	// VectorExportPrefix is where vector exports are written below the blob store
+	VectorExportPrefix string
+
 	// Logging
 	LogLevel string
+
+	// Slow operation thresholds; zero disables slow logging for that component
+	SlowPostgresThreshold   time.Duration
+	SlowQdrantThreshold     time.Duration
+	SlowEmbeddingThreshold  time.Duration
+	SlowCompletionThreshold time.Duration
+	SlowRequestThreshold    time.Duration
+
+	// Longest a single call to each dependency may take; zero means no limit
+	PostgresQueryTimeout time.Duration
+	QdrantTimeout        time.Duration
+	EmbeddingTimeout     time.Duration
+	CompletionTimeout    time.Duration
+
+	// Caller deadlines sent in X-Request-Deadline: the share of the time left each dependency
+	// call may take (0 lets it take all of it), and the time that must remain for optional search
+	// stages to run
+	DeadlineSharePostgres   float64
+	DeadlineShareQdrant     float64
+	DeadlineShareEmbedding  float64
+	DeadlineShareCompletion float64
+	DeadlineOptionalMin     time.Duration
+
+	// Sampled request/response audit logging for debugging client integrations
+	RequestAuditEnabled    bool
+	RequestAuditSink       string
+	RequestAuditSampleRate float64
+	RequestAuditRouteRates []string
+	RequestAuditMaxBody    int
+
+	// RequestAuditChainKey keys the HMAC chaining audit records, so nobody without it can rewrite
+	// the chain; required when request audit logging is enabled
+	RequestAuditChainKey string `secret:"true"`
+
+	// LogFullContent logs raw message and personal info content; allowed only in development
+	LogFullContent bool
+
+	// Admin API and the operator dashboard served at /admin
+	AdminAPIKey string `secret:"true"`
+	AdminUI     bool
+
+	// Service account keys, reloaded from Postgres every APIKeyReloadInterval; APIKeysRequired
+	// makes a key mandatory on non-admin routes
+	APIKeysRequired      bool
+	APIKeyReloadInterval time.Duration
+
+	// IP allow and deny lists (CIDR ranges or addresses) for all routes and for admin and debug
+	// routes, and the proxies whose forwarded client address is trusted
+	IPAllow        []string
+	IPDeny         []string
+	AdminIPAllow   []string
+	AdminIPDeny    []string
+	TrustedProxies []string
+
+	// EncryptionMasterKeys enables encryption of conversation content at rest, as "key_id:base64"
+	// entries of 32-byte master keys with the current key first
+	EncryptionMasterKeys []string `secret:"true"`
+	// EncryptionTenants get data keys of their own; every other tenant's content is encrypted with
+	// the default tenant's key. EncryptionKeyCacheSize bounds the tenants whose keys stay unwrapped
+	EncryptionTenants      []string
+	EncryptionKeyCacheSize int
+
+	// Data residency: the region this deployment serves and the map of every region's storage
+	// endpoints and tenant placement. The local region's endpoints replace the POSTGRES_* and
+	// QDRANT_* hosts; residency is off when Region is empty
+	Region        string
+	RegionMapFile string
+	Regions       *residency.Map
+
+	// HMAC request signing as an alternative to API keys, as "client_id:secret" entries. Nonces
+	// are kept in the relational store for SigningMaxSkew and SigningNonceSweep deletes them after
+	SigningClients    []string `secret:"true"`
+	SigningMaxSkew    time.Duration
+	SigningNonceSweep ScheduledTask
+
+	// DeleteConfirmationTTL is how long a bulk deletion's confirmation token stays valid
+	DeleteConfirmationTTL time.Duration
+
+	// DeleteConfirmationKey signs confirmation tokens so any replica sharing it redeems them; empty
+	// generates a key per process
+	DeleteConfirmationKey string `secret:"true"`
+
+	// DeletionCertificateKey is the base64 32-byte Ed25519 seed that signs the certificates issued
+	// after retention runs, user deletions and bulk deletions; empty issues no certificates
+	DeletionCertificateKey string `secret:"true"`
+
+	// MaintenanceMode starts the server in read-only mode
+	MaintenanceMode bool
+
+	// Standby runs a warm standby: it serves searches and reads from a Postgres replica and the
+	// primary's Qdrant cluster, rejects writes, runs no background jobs and can't leave read-only
+	// mode. Promote it by restarting it against the new primary without STANDBY_MODE
+	Standby bool
+
+	// FeatureFlagsFile is an optional JSON file of feature flags and tenant overrides
+	FeatureFlagsFile string
+
+	// Startup dependency wait
+	StartupInitialBackoff time.Duration
+	StartupMaxBackoff     time.Duration
+	PostgresStartupWait   time.Duration
+	QdrantStartupWait     time.Duration
+
+	// Migration guard: off, warn or block statements that hold heavy locks on tables with at
+	// least MigrationLargeTableRows rows
+	MigrationGuard          string
+	MigrationLargeTableRows int64
+
+	// Health history and flap damping
+	HealthHistorySize       int
+	HealthFailureThreshold  int
+	HealthRecoveryThreshold int
+
+	// Replica coordination: this instance's ID and how often it campaigns for leadership of the
+	// background jobs
+	InstanceID             string
+	LeaderElectionInterval time.Duration
+
+	// Work queue shared by replicas: whether this instance consumes it, claim batch size, lease
+	// length and poll interval
+	QueueWorkerEnabled bool
+	QueueBatchSize     int
+	QueueLease         time.Duration
+	QueuePollInterval  time.Duration
+	QueueMaxAttempts   int
+
+	// Ingestion shaping: a leaky bucket per replica draining IngestShaperRate embeddings a second
+	// and holding IngestShaperBurst; saves that find it full are queued, and backfill saves may
+	// fill only IngestShaperBackfillShare of it
+	IngestShaperEnabled       bool
+	IngestShaperRate          float64
+	IngestShaperBurst         int
+	IngestShaperBackfillShare float64
+
+	// Embedding token usage aggregated per day and flushed to Postgres
+	UsageFlushInterval time.Duration
+	EmbeddingBudget    usage.Budget
+
+	// Soft quota: QuotaWarningPercents of a budget cap at which responses carry quota headers and
+	// the leader posts a warning to QuotaWebhookURL, signed with QuotaWebhookSecret when set
+	QuotaWarningPercents []string
+	QuotaWebhookURL      string `secret:"true"`
+	QuotaWebhookSecret   string `secret:"true"`
+
+	// Startup warm-up run before the readiness probe reports ready
+	WarmupEnabled             bool
+	WarmupTimeout             time.Duration
+	WarmupPostgresConnections int
+
+	// Error reporting; reporting is disabled when SentryDSN is empty
+	SentryDSN         string `secret:"true"`
+	SentryEnvironment string
+	SentryRelease     string
+	SentrySampleRate  float64
+
+	// Trace export; tracing is disabled when TracingEndpoint is empty. TracingSampleRate applies to
+	// traces started here; requests joining a caller's trace follow the caller's sampling decision
+	TracingEndpoint    string
+	TracingServiceName string
+	TracingSampleRate  float64
+
+	// Per-tenant metrics label the MetricsTenantTopN busiest tenants and the MetricsTenants by name
+	// and count everyone else as "other"; they are off when neither is set
+	MetricsTenantTopN int
+	MetricsTenants    []string
 }
 
-// Load loads configuration from environment variables
+// CollectionConfig holds vector settings for a single Qdrant collection
+type CollectionConfig struct {
+	Name      string
+	Model     string
+	Dimension int
+	Distance  string
+
+	ShardNumber            int
+	ReplicationFactor      int
+	WriteConsistencyFactor int
+
+	// UserIsolation requires user_id on every point and search of the collection
+	UserIsolation bool
+
+	// ReadConsistency is how many replicas answer a search by default: majority, quorum, all or a
+	// number; "" reads from one
+	ReadConsistency string
+
+	// Datatype is the vector datatype Qdrant stores: float32, float16 (half the memory and payload
+	// at a small recall cost) or uint8 (for models whose components are whole numbers up to 255)
+	Datatype string
+
+	// Multivector stores several vectors per point, compared by MaxSim
+	Multivector bool
+}
+
+// ScheduledTask configures a background task run by the scheduler
+type ScheduledTask struct {
+	Enabled bool
+
+	// Spec is a five-field cron expression in UTC, a macro such as @daily or "@every <duration>"
+	Spec     string
+	Schedule *schedule.Schedule
+
+	// Jitter delays each run by a random duration up to it, spreading load across deployments
+	Jitter time.Duration
+}
+
+// Load loads configuration from environment variables, over the settings of CONFIG_PROFILE in
+// CONFIG_PROFILES_FILE when one is named
 func Load() (*Config, error) {
+	profileSettings = nil
+	var profiles []string
+	profilesPath, profile := os.Getenv("CONFIG_PROFILES_FILE"), os.Getenv("CONFIG_PROFILE")
+	if profile != "" {
+		if profilesPath == "" {
+			return nil, fmt.Errorf("CONFIG_PROFILE requires CONFIG_PROFILES_FILE")
+		}
+		settings, applied, err := loadProfile(profilesPath, profile)
+		if err != nil {
+			return nil, fmt.Errorf("CONFIG_PROFILE: %w", err)
+		}
+		profileSettings, profiles = settings, applied
+	}
+
 	cfg := &Config{
+		ProfilesFile:     profilesPath,
+		Profiles:         profiles,
 		Port:             getEnvAsInt("PORT", 8080),
 		Env:              getEnv("ENVIRONMENT", "development"),
 		PostgresHost:     getEnv("POSTGRES_HOST", "localhost"),
@@ -48,10 +580,433 @@ func Load() (*Config, error) {
 		QdrantHost:       getEnv("QDRANT_HOST", "localhost"),
 		QdrantPort:       getEnvAsInt("QDRANT_PORT", 6334),
 		QdrantCollection: getEnv("QDRANT_COLLECTION", "conversations"),
-		OpenAIAPIKey:     getEnv("OPENAI_API_KEY", ""),
-		OpenAIModel:      getEnv("OPENAI_MODEL", "text-embedding-3-large"),
-		EmbeddingDim:     getEnvAsInt("EMBEDDING_DIM", 3072),
-		LogLevel:         getEnv("LOG_LEVEL", "info"),
+
+		PostgresSSLRootCert: getEnv("POSTGRES_SSLROOTCERT", ""),
+		PostgresSSLCert:     getEnv("POSTGRES_SSLCERT", ""),
+		PostgresSSLKey:      getEnv("POSTGRES_SSLKEY", ""),
+
+		MemoryStoreBackend: getEnv("MEMORY_STORE_BACKEND", "postgres"),
+		SQLitePath:         getEnv("SQLITE_PATH", "./data/rag.db"),
+		MySQLDSN:           getEnv("MYSQL_DSN", ""),
+
+		QdrantTLS:           getEnvAsBool("QDRANT_TLS", false),
+		QdrantCACert:        getEnv("QDRANT_CA_CERT", ""),
+		QdrantClientCert:    getEnv("QDRANT_CLIENT_CERT", ""),
+		QdrantClientKey:     getEnv("QDRANT_CLIENT_KEY", ""),
+		QdrantTLSServerName: getEnv("QDRANT_TLS_SERVER_NAME", ""),
+
+		QdrantMaxRetries:     getEnvAsInt("QDRANT_MAX_RETRIES", 2),
+		QdrantRetryBackoff:   getEnvAsDuration("QDRANT_RETRY_BACKOFF", 200*time.Millisecond),
+		QdrantAttemptTimeout: getEnvAsDuration("QDRANT_ATTEMPT_TIMEOUT", 0),
+
+		EgressProxy:    getEnv("EGRESS_PROXY", ""),
+		EgressNoProxy:  getEnv("EGRESS_NO_PROXY", ""),
+		EgressCABundle: getEnv("EGRESS_CA_BUNDLE", ""),
+
+		OpenAIAPIKey: getEnv("OPENAI_API_KEY", ""),
+		OpenAIModel:  getEnv("OPENAI_MODEL", "text-embedding-3-large"),
+		EmbeddingDim: getEnvAsInt("EMBEDDING_DIM", 3072),
+
+		EmbeddingPrefixes:  getEnvAsList("EMBEDDING_PREFIXES", nil),
+		EmbeddingNormalize: getEnvAsBool("EMBEDDING_NORMALIZE", false),
+
+		EmbeddingProjections:   getEnvAsList("EMBEDDING_PROJECTIONS", nil),
+		EmbeddingProjectionDir: getEnv("EMBEDDING_PROJECTION_DIR", "./data/projections"),
+
+		EmbeddingRecording:    getEnv("EMBEDDING_RECORDING", "off"),
+		EmbeddingRecordingDir: getEnv("EMBEDDING_RECORDING_DIR", "./data/embedding-recordings"),
+
+		EmbeddingCacheSize: getEnvAsInt("EMBEDDING_CACHE_SIZE", 10000),
+		EmbeddingCacheTTL:  getEnvAsDuration("EMBEDDING_CACHE_TTL", time.Hour),
+		QueryCacheSize:     getEnvAsInt("QUERY_CACHE_SIZE", 0),
+		QueryCacheTTL:      getEnvAsDuration("QUERY_CACHE_TTL", 30*time.Second),
+
+		OpenAIChatModel:     getEnv("OPENAI_CHAT_MODEL", "gpt-4o-mini"),
+		OpenAIChatMaxTokens: getEnvAsInt("OPENAI_CHAT_MAX_TOKENS", 512),
+		LogLevel:            getEnv("LOG_LEVEL", "info"),
+
+		SessionRollingSummary: getEnvAsBool("SESSION_ROLLING_SUMMARY", false),
+		SessionSummaryTimeout: getEnvAsDuration("SESSION_SUMMARY_TIMEOUT", 30*time.Second),
+
+		ProfileCacheTTL:         getEnvAsDuration("PROFILE_CACHE_TTL", 24*time.Hour),
+		ProfileRefresh:          getEnvAsScheduledTask("PROFILE_REFRESH", getEnvAsDuration("PROFILE_REFRESH_INTERVAL", time.Hour) > 0, everyInterval("PROFILE_REFRESH_INTERVAL", time.Hour)),
+		ProfileMaxConversations: getEnvAsInt("PROFILE_MAX_CONVERSATIONS", 30),
+
+		QueryAdapters:         getEnvAsBool("QUERY_ADAPTERS", false),
+		QueryAdapterCacheTTL:  getEnvAsDuration("QUERY_ADAPTER_CACHE_TTL", 5*time.Minute),
+		QueryAdapterCacheSize: getEnvAsInt("QUERY_ADAPTER_CACHE_SIZE", 10000),
+
+		LogFullContent: getEnvAsBool("LOG_FULL_CONTENT", false),
+
+		EmbedRoles:        getEnvAsList("EMBED_ROLES", []string{"user", "assistant"}),
+		EmbedRolePrefixes: getEnvAsBool("EMBED_ROLE_PREFIXES", false),
+		EmbedSeparator:    unescape(getEnv("EMBED_SEPARATOR", `\n`)),
+		EmbedMaxTurns:     getEnvAsInt("EMBED_MAX_TURNS", 0),
+		EmbedChunkTurns:   getEnvAsInt("EMBED_CHUNK_TURNS", 6),
+
+		PersonalInfoEmbedTemplate: getEnv("PERSONAL_INFO_EMBED_TEMPLATE", "{content}"),
+		VectorWriteMode:           getEnv("VECTOR_WRITE_MODE", "outbox"),
+		SaveDurability:            getEnv("SAVE_DURABILITY", "safe"),
+
+		PersonalInfoRetrieval:              getEnv("PERSONAL_INFO_RETRIEVAL", "dense"),
+		PersonalInfoMultivectorGranularity: getEnv("PERSONAL_INFO_MULTIVECTOR_GRANULARITY", "sentence"),
+		PersonalInfoMultivectorMaxVectors:  getEnvAsInt("PERSONAL_INFO_MULTIVECTOR_MAX_VECTORS", 16),
+
+		SearchRecencyWeight:   getEnvAsFloat("SEARCH_RECENCY_WEIGHT", 0),
+		SearchRecencyHalfLife: getEnvAsDuration("SEARCH_RECENCY_HALF_LIFE", 30*24*time.Hour),
+
+		RetrieveRouting:          getEnv("RETRIEVE_ROUTING", "all"),
+		RetrieveRoutingMinWeight: getEnvAsFloat("RETRIEVE_ROUTING_MIN_WEIGHT", 0.5),
+
+		Plugins: getEnvAsList("PLUGINS", nil),
+
+		SearchTransformers: getEnvAsList("SEARCH_TRANSFORMERS", nil),
+		SearchRetrievers:   getEnvAsList("SEARCH_RETRIEVERS", []string{"vector"}),
+		SearchFuser:        getEnv("SEARCH_FUSER", "max"),
+		SearchFilters:      getEnvAsList("SEARCH_FILTERS", []string{"suppressed"}),
+		SearchNormalizer:   getEnv("SEARCH_NORMALIZER", "none"),
+		SearchRerankers:    getEnvAsList("SEARCH_RERANKERS", []string{"recency", "importance"}),
+		SearchCalibrations: getEnvAsList("SEARCH_CALIBRATION", nil),
+
+		SearchMaxCandidates: getEnvAsInt("SEARCH_MAX_CANDIDATES", 400),
+		SearchMaxAgeDays:    getEnvAsInt("SEARCH_MAX_AGE_DAYS", 0),
+		SearchTimeZone:      getEnv("SEARCH_TIME_ZONE", "UTC"),
+
+		ContextCompression:      getEnvAsBool("CONTEXT_COMPRESSION", false),
+		ContextCompressionRatio: getEnvAsFloat("CONTEXT_COMPRESSION_RATIO", 0.5),
+
+		SnippetFilter:     getEnvAsBool("SNIPPET_FILTER", false),
+		SnippetFilterFile: getEnv("SNIPPET_FILTER_FILE", ""),
+
+		ImportanceScorer:   getEnv("IMPORTANCE_SCORER", "heuristic"),
+		ImportanceHalfLife: getEnvAsDuration("IMPORTANCE_HALF_LIFE", 180*24*time.Hour),
+		ImportanceWeight:   getEnvAsFloat("SEARCH_IMPORTANCE_WEIGHT", 0),
+
+		Forget:          getEnvAsScheduledTask("FORGET", false, everyInterval("FORGET_INTERVAL", 24*time.Hour)),
+		ForgetThreshold: getEnvAsFloat("FORGET_THRESHOLD", 0.05),
+		ForgetMinAge:    getEnvAsDuration("FORGET_MIN_AGE", 90*24*time.Hour),
+		Drift:           getEnvAsScheduledTask("EMBEDDING_DRIFT", false, everyInterval("EMBEDDING_DRIFT_INTERVAL", 6*time.Hour)),
+		DriftSampleSize: getEnvAsInt("EMBEDDING_DRIFT_SAMPLE_SIZE", 20),
+		DriftThreshold:  getEnvAsFloat("EMBEDDING_DRIFT_THRESHOLD", 0.02),
+
+		EmbeddingAnomaly:                getEnvAsScheduledTask("EMBEDDING_ANOMALY", false, everyInterval("EMBEDDING_ANOMALY_INTERVAL", 24*time.Hour)),
+		EmbeddingAnomalyWindow:          getEnvAsDuration("EMBEDDING_ANOMALY_WINDOW", 24*time.Hour),
+		EmbeddingAnomalyBaseline:        getEnvAsInt("EMBEDDING_ANOMALY_BASELINE", 200),
+		EmbeddingAnomalyThreshold:       getEnvAsFloat("EMBEDDING_ANOMALY_THRESHOLD", 3),
+		EmbeddingAnomalyDistressPhrases: getEnvAsList("EMBEDDING_ANOMALY_DISTRESS_PHRASES", nil),
+		EmbeddingAnomalyWebhookURL:      getEnv("EMBEDDING_ANOMALY_WEBHOOK_URL", ""),
+		EmbeddingAnomalyWebhookSecret:   getEnv("EMBEDDING_ANOMALY_WEBHOOK_SECRET", ""),
+
+		StandingQueryMaxPerUser:    getEnvAsInt("STANDING_QUERY_MAX_PER_USER", 50),
+		StandingQueryStreamBuffer:  getEnvAsInt("STANDING_QUERY_STREAM_BUFFER", 64),
+		StandingQueryWebhookURL:    getEnv("STANDING_QUERY_WEBHOOK_URL", ""),
+		StandingQueryWebhookSecret: getEnv("STANDING_QUERY_WEBHOOK_SECRET", ""),
+		SuggestLookback:            getEnvAsDuration("SUGGEST_LOOKBACK", 30*24*time.Hour),
+		SuggestConversations:       getEnvAsInt("SUGGEST_CONVERSATIONS", 200),
+		SuggestCacheTTL:            getEnvAsDuration("SUGGEST_CACHE_TTL", 10*time.Minute),
+		SuggestCacheSize:           getEnvAsInt("SUGGEST_CACHE_SIZE", 10000),
+
+		InsightsKAnonymity: getEnvAsInt("INSIGHTS_K_ANONYMITY", 10),
+		InsightsCategories: getEnvAsList("INSIGHTS_QUESTION_CATEGORIES", nil),
+
+		SearchLogEnabled: getEnvAsBool("SEARCH_LOG_ENABLED", false),
+		BlobStoreDir:     getEnv("BLOB_STORE_DIR", ""),
+
+		AnalyticsExport:             getEnvAsScheduledTask("ANALYTICS_EXPORT", false, everyInterval("ANALYTICS_EXPORT_INTERVAL", time.Hour)),
+		AnalyticsExportLookbackDays: getEnvAsInt("ANALYTICS_EXPORT_LOOKBACK_DAYS", 7),
+		AnalyticsExportPrefix:       getEnv("ANALYTICS_EXPORT_PREFIX", "analytics"),
+		VectorExportPrefix:          getEnv("VECTOR_EXPORT_PREFIX", "vectors"),
+
+		IntegrityVerify: getEnvAsScheduledTask("INTEGRITY_VERIFY", false, "@weekly"),
+
+		RequestAuditEnabled:    getEnvAsBool("REQUEST_AUDIT_ENABLED", false),
+		RequestAuditSink:       getEnv("REQUEST_AUDIT_SINK", "stdout"),
+		RequestAuditSampleRate: getEnvAsFloat("REQUEST_AUDIT_SAMPLE_RATE", 0.01),
+		RequestAuditRouteRates: getEnvAsList("REQUEST_AUDIT_ROUTE_RATES", nil),
+		RequestAuditMaxBody:    getEnvAsInt("REQUEST_AUDIT_MAX_BODY", 16384),
+		RequestAuditChainKey:   getEnv("REQUEST_AUDIT_CHAIN_KEY", ""),
+
+		SlowPostgresThreshold:   getEnvAsDuration("SLOW_POSTGRES_THRESHOLD", 200*time.Millisecond),
+		SlowQdrantThreshold:     getEnvAsDuration("SLOW_QDRANT_THRESHOLD", 500*time.Millisecond),
+		SlowEmbeddingThreshold:  getEnvAsDuration("SLOW_EMBEDDING_THRESHOLD", 2*time.Second),
+		SlowCompletionThreshold: getEnvAsDuration("SLOW_COMPLETION_THRESHOLD", 10*time.Second),
+		SlowRequestThreshold:    getEnvAsDuration("SLOW_REQUEST_THRESHOLD", 3*time.Second),
+		PostgresQueryTimeout:    getEnvAsDuration("POSTGRES_QUERY_TIMEOUT", 15*time.Second),
+		QdrantTimeout:           getEnvAsDuration("QDRANT_TIMEOUT", 15*time.Second),
+		EmbeddingTimeout:        getEnvAsDuration("EMBEDDING_TIMEOUT", 30*time.Second),
+		CompletionTimeout:       getEnvAsDuration("COMPLETION_TIMEOUT", 60*time.Second),
+		DeadlineSharePostgres:   getEnvAsFloat("REQUEST_DEADLINE_SHARE_POSTGRES", 0.5),
+		DeadlineShareQdrant:     getEnvAsFloat("REQUEST_DEADLINE_SHARE_QDRANT", 0.6),
+		DeadlineShareEmbedding:  getEnvAsFloat("REQUEST_DEADLINE_SHARE_EMBEDDING", 0.4),
+		DeadlineShareCompletion: getEnvAsFloat("REQUEST_DEADLINE_SHARE_COMPLETION", 0.6),
+		DeadlineOptionalMin:     getEnvAsDuration("REQUEST_DEADLINE_OPTIONAL_MIN", 150*time.Millisecond),
+		AdminAPIKey:             getEnv("ADMIN_API_KEY", ""),
+		AdminUI:                 getEnvAsBool("ADMIN_UI_ENABLED", true),
+		APIKeysRequired:         getEnvAsBool("API_KEYS_REQUIRED", false),
+		APIKeyReloadInterval:    getEnvAsDuration("API_KEY_RELOAD_INTERVAL", 30*time.Second),
+		IPAllow:                 getEnvAsList("IP_ALLOW", nil),
+		IPDeny:                  getEnvAsList("IP_DENY", nil),
+		AdminIPAllow:            getEnvAsList("ADMIN_IP_ALLOW", nil),
+		AdminIPDeny:             getEnvAsList("ADMIN_IP_DENY", nil),
+		TrustedProxies:          getEnvAsList("TRUSTED_PROXIES", nil),
+		EncryptionMasterKeys:    getEnvAsList("ENCRYPTION_MASTER_KEYS", nil),
+		EncryptionTenants:       getEnvAsList("ENCRYPTION_TENANTS", nil),
+		EncryptionKeyCacheSize:  getEnvAsInt("ENCRYPTION_KEY_CACHE_SIZE", 1000),
+		Region:                  getEnv("REGION", ""),
+		RegionMapFile:           getEnv("REGION_MAP_FILE", ""),
+		SigningClients:          getEnvAsList("SIGNING_CLIENTS", nil),
+		SigningMaxSkew:          getEnvAsDuration("SIGNING_MAX_SKEW", 5*time.Minute),
+		SigningNonceSweep:       getEnvAsScheduledTask("SIGNING_NONCE_SWEEP", true, "@hourly"),
+		DeleteConfirmationTTL:   getEnvAsDuration("DELETE_CONFIRMATION_TTL", 5*time.Minute),
+		DeleteConfirmationKey:   getEnv("DELETE_CONFIRMATION_KEY", ""),
+		DeletionCertificateKey:  getEnv("DELETION_CERTIFICATE_KEY", ""),
+
+		MaintenanceMode:  getEnvAsBool("MAINTENANCE_MODE", false),
+		Standby:          getEnvAsBool("STANDBY_MODE", false),
+		FeatureFlagsFile: getEnv("FEATURE_FLAGS_FILE", ""),
+
+		StartupInitialBackoff: getEnvAsDuration("STARTUP_RETRY_INITIAL_BACKOFF", time.Second),
+		StartupMaxBackoff:     getEnvAsDuration("STARTUP_RETRY_MAX_BACKOFF", 15*time.Second),
+
+		HealthHistorySize:       getEnvAsInt("HEALTH_HISTORY_SIZE", 20),
+		HealthFailureThreshold:  getEnvAsInt("HEALTH_FAILURE_THRESHOLD", 3),
+		HealthRecoveryThreshold: getEnvAsInt("HEALTH_RECOVERY_THRESHOLD", 2),
+
+		InstanceID:             getEnv("INSTANCE_ID", defaultInstanceID()),
+		LeaderElectionInterval: getEnvAsDuration("LEADER_ELECTION_INTERVAL", 15*time.Second),
+
+		QueueWorkerEnabled: getEnvAsBool("QUEUE_WORKER_ENABLED", true),
+		QueueBatchSize:     getEnvAsInt("QUEUE_BATCH_SIZE", 10),
+		QueueLease:         getEnvAsDuration("QUEUE_LEASE", time.Minute),
+		QueuePollInterval:  getEnvAsDuration("QUEUE_POLL_INTERVAL", 2*time.Second),
+		QueueMaxAttempts:   getEnvAsInt("QUEUE_MAX_ATTEMPTS", 8),
+
+		IngestShaperEnabled:       getEnvAsBool("INGEST_SHAPER_ENABLED", false),
+		IngestShaperRate:          getEnvAsFloat("INGEST_SHAPER_RATE", 10),
+		IngestShaperBurst:         getEnvAsInt("INGEST_SHAPER_BURST", 50),
+		IngestShaperBackfillShare: getEnvAsFloat("INGEST_SHAPER_BACKFILL_SHARE", 0.5),
+
+		UsageFlushInterval: getEnvAsDuration("USAGE_FLUSH_INTERVAL", time.Minute),
+		EmbeddingBudget: usage.Budget{
+			DailyTokens:           int64(getEnvAsInt("EMBEDDING_DAILY_TOKEN_BUDGET", 0)),
+			MonthlyTokens:         int64(getEnvAsInt("EMBEDDING_MONTHLY_TOKEN_BUDGET", 0)),
+			DailyUSD:              getEnvAsFloat("EMBEDDING_DAILY_BUDGET_USD", 0),
+			MonthlyUSD:            getEnvAsFloat("EMBEDDING_MONTHLY_BUDGET_USD", 0),
+			PricePerMillionTokens: getEnvAsFloat("EMBEDDING_PRICE_PER_MILLION_TOKENS", 0.13),
+		},
+		QuotaWarningPercents: getEnvAsList("QUOTA_WARNING_PERCENTS", []string{"80", "95"}),
+		QuotaWebhookURL:      getEnv("QUOTA_WEBHOOK_URL", ""),
+		QuotaWebhookSecret:   getEnv("QUOTA_WEBHOOK_SECRET", ""),
+
+		WarmupEnabled:             getEnvAsBool("WARMUP_ENABLED", false),
+		WarmupTimeout:             getEnvAsDuration("WARMUP_TIMEOUT", 30*time.Second),
+		WarmupPostgresConnections: getEnvAsInt("WARMUP_POSTGRES_CONNECTIONS", 5),
+
+		SentryDSN:        getEnv("SENTRY_DSN", ""),
+		SentryRelease:    getEnv("SENTRY_RELEASE", ""),
+		SentrySampleRate: getEnvAsFloat("SENTRY_SAMPLE_RATE", 1.0),
+
+		TracingEndpoint:    getEnv("TRACING_ENDPOINT", ""),
+		TracingServiceName: getEnv("TRACING_SERVICE_NAME", "rag-server"),
+		TracingSampleRate:  getEnvAsFloat("TRACING_SAMPLE_RATE", 1.0),
+
+		MetricsTenantTopN: getEnvAsInt("METRICS_TENANT_TOP_N", 0),
+		MetricsTenants:    getEnvAsList("METRICS_TENANTS", nil),
+
+		BindAddress:       getEnv("BIND_ADDRESS", ""),
+		ListenSocket:      getEnv("LISTEN_SOCKET", ""),
+		ListenSocketMode:  os.FileMode(getEnvAsOctal("LISTEN_SOCKET_MODE", 0660)),
+		SystemdActivation: getEnvAsBool("SYSTEMD_SOCKET_ACTIVATION", true),
+
+		TLSCertFile:      getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:       getEnv("TLS_KEY_FILE", ""),
+		AutocertDomains:  getEnvAsList("TLS_AUTOCERT_DOMAINS", nil),
+		AutocertCacheDir: getEnv("TLS_AUTOCERT_CACHE_DIR", "./autocert-cache"),
+		AutocertEmail:    getEnv("TLS_AUTOCERT_EMAIL", ""),
+		HTTPRedirectPort: getEnvAsInt("HTTP_REDIRECT_PORT", 0),
+		HTTP2Cleartext:   getEnvAsBool("HTTP2_CLEARTEXT", false),
+		ShutdownTimeout:  getEnvAsDuration("SHUTDOWN_TIMEOUT", 15*time.Second),
+
+		LoadShedLimits: map[string]int{
+			loadshed.ClassSearch: getEnvAsInt("LOAD_SHED_SEARCH_CONCURRENCY", 0),
+			loadshed.ClassStore:  getEnvAsInt("LOAD_SHED_STORE_CONCURRENCY", 0),
+			loadshed.ClassAdmin:  getEnvAsInt("LOAD_SHED_ADMIN_CONCURRENCY", 0),
+		},
+		LoadShedQueueTimeout: getEnvAsDuration("LOAD_SHED_QUEUE_TIMEOUT", 100*time.Millisecond),
+		LoadShedRetryAfter:   getEnvAsDuration("LOAD_SHED_RETRY_AFTER", 2*time.Second),
+
+		EventQueueSize:      getEnvAsInt("EVENT_QUEUE_SIZE", 1024),
+		EventWorkers:        getEnvAsInt("EVENT_WORKERS", 4),
+		EventHandlerTimeout: getEnvAsDuration("EVENT_HANDLER_TIMEOUT", time.Minute),
+	}
+
+	cfg.SentryEnvironment = getEnv("SENTRY_ENVIRONMENT", cfg.Env)
+
+	startupMaxWait := getEnvAsDuration("STARTUP_MAX_WAIT", 2*time.Minute)
+	cfg.PostgresStartupWait = getEnvAsDuration("POSTGRES_STARTUP_MAX_WAIT", startupMaxWait)
+	cfg.QdrantStartupWait = getEnvAsDuration("QDRANT_STARTUP_MAX_WAIT", startupMaxWait)
+
+	defaultGuard := "warn"
+	if cfg.Env == "production" {
+		defaultGuard = "block"
+	}
+	cfg.MigrationGuard = getEnv("MIGRATION_GUARD", defaultGuard)
+	cfg.MigrationLargeTableRows = int64(getEnvAsInt("MIGRATION_LARGE_TABLE_ROWS", 100000))
+
+	// Production collections carry tuned index settings, so a missing one isn't created with defaults
+	cfg.AutoCreateCollections = getEnvAsBool("AUTO_CREATE_COLLECTIONS", cfg.Env != "production")
+	cfg.CollectionRecovery = getEnvAsBool("QDRANT_COLLECTION_RECOVERY", true)
+	cfg.CollectionRecoveryCooldown = getEnvAsDuration("QDRANT_COLLECTION_RECOVERY_COOLDOWN", 30*time.Second)
+
+	distance := getEnv("QDRANT_DISTANCE", "Cosine")
+	// Per-user namespaces apply to user-owned content; documents are shared
+	userIsolation := getEnvAsBool("QDRANT_USER_ISOLATION", false)
+	cfg.Collections = map[string]CollectionConfig{
+		"conversations": {
+			Name:          cfg.QdrantCollection,
+			Model:         cfg.OpenAIModel,
+			Dimension:     cfg.EmbeddingDim,
+			Distance:      distance,
+			UserIsolation: userIsolation,
+		},
+		"personal_info": {
+			Name:      getEnv("QDRANT_PERSONAL_INFO_COLLECTION", "personal_info"),
+			Model:     getEnv("PERSONAL_INFO_EMBEDDING_MODEL", cfg.OpenAIModel),
+			Dimension: getEnvAsInt("PERSONAL_INFO_EMBEDDING_DIM", cfg.EmbeddingDim),
+			Distance:  getEnv("QDRANT_PERSONAL_INFO_DISTANCE", distance),
+
+			UserIsolation: userIsolation,
+		},
+		"documents": {
+			Name:      getEnv("QDRANT_DOCUMENTS_COLLECTION", "documents"),
+			Model:     getEnv("DOCUMENTS_EMBEDDING_MODEL", cfg.OpenAIModel),
+			Dimension: getEnvAsInt("DOCUMENTS_EMBEDDING_DIM", cfg.EmbeddingDim),
+			Distance:  getEnv("QDRANT_DOCUMENTS_DISTANCE", distance),
+		},
+	}
+
+	switch cfg.PersonalInfoRetrieval {
+	case "dense":
+	case "multivector":
+		personalInfo := cfg.Collections["personal_info"]
+		if cfg.PersonalInfoMultivectorGranularity != "sentence" && cfg.PersonalInfoMultivectorGranularity != "phrase" {
+			return nil, fmt.Errorf("PERSONAL_INFO_MULTIVECTOR_GRANULARITY must be sentence or phrase")
+		}
+		if cfg.PersonalInfoMultivectorMaxVectors < 2 {
+			return nil, fmt.Errorf("PERSONAL_INFO_MULTIVECTOR_MAX_VECTORS must be at least 2")
+		}
+		if personalInfo.Distance != "Cosine" {
+			return nil, fmt.Errorf("PERSONAL_INFO_RETRIEVAL multivector requires Cosine distance for personal info")
+		}
+		cfg.Collections["personal_info_multivector"] = CollectionConfig{
+			Name:          getEnv("QDRANT_PERSONAL_INFO_MULTIVECTOR_COLLECTION", personalInfo.Name+"_multivector"),
+			Model:         personalInfo.Model,
+			Dimension:     personalInfo.Dimension,
+			Distance:      personalInfo.Distance,
+			UserIsolation: personalInfo.UserIsolation,
+			Multivector:   true,
+		}
+	default:
+		return nil, fmt.Errorf("PERSONAL_INFO_RETRIEVAL must be dense or multivector")
+	}
+
+	cfg.ShadowModel = getEnv("SHADOW_EMBEDDING_MODEL", "")
+	cfg.ShadowDimension = getEnvAsInt("SHADOW_EMBEDDING_DIM", cfg.EmbeddingDim)
+	cfg.ShadowCollection = getEnv("QDRANT_SHADOW_COLLECTION", cfg.QdrantCollection+"_shadow")
+	cfg.ShadowSaveRate = getEnvAsFloat("SHADOW_SAVE_SAMPLE_RATE", 1)
+	cfg.ShadowQueryRate = getEnvAsFloat("SHADOW_QUERY_SAMPLE_RATE", 0.1)
+	if cfg.ShadowModel != "" {
+		if cfg.ShadowModel == cfg.OpenAIModel {
+			return nil, fmt.Errorf("SHADOW_EMBEDDING_MODEL must differ from OPENAI_MODEL")
+		}
+		if cfg.ShadowSaveRate < 0 || cfg.ShadowSaveRate > 1 || cfg.ShadowQueryRate < 0 || cfg.ShadowQueryRate > 1 {
+			return nil, fmt.Errorf("SHADOW_SAVE_SAMPLE_RATE and SHADOW_QUERY_SAMPLE_RATE must be between 0 and 1")
+		}
+		cfg.Collections["shadow_conversations"] = CollectionConfig{
+			Name:          cfg.ShadowCollection,
+			Model:         cfg.ShadowModel,
+			Dimension:     cfg.ShadowDimension,
+			Distance:      distance,
+			UserIsolation: userIsolation,
+		}
+	}
+
+	cfg.CanaryPercent = getEnvAsFloat("CANARY_PERCENT", 0)
+	cfg.CanaryContentType = getEnv("CANARY_CONTENT_TYPE", "conversations")
+	cfg.CanaryTransformers = getEnvAsList("CANARY_SEARCH_TRANSFORMERS", cfg.SearchTransformers)
+	cfg.CanaryRetrievers = getEnvAsList("CANARY_SEARCH_RETRIEVERS", cfg.SearchRetrievers)
+	cfg.CanaryFuser = getEnv("CANARY_SEARCH_FUSER", cfg.SearchFuser)
+	cfg.CanaryFilters = getEnvAsList("CANARY_SEARCH_FILTERS", cfg.SearchFilters)
+	cfg.CanaryNormalizer = getEnv("CANARY_SEARCH_NORMALIZER", cfg.SearchNormalizer)
+	cfg.CanaryRerankers = getEnvAsList("CANARY_SEARCH_RERANKERS", cfg.SearchRerankers)
+	cfg.CanaryRecencyWeight = getEnvAsFloat("CANARY_SEARCH_RECENCY_WEIGHT", cfg.SearchRecencyWeight)
+	cfg.CanaryImportanceWeight = getEnvAsFloat("CANARY_SEARCH_IMPORTANCE_WEIGHT", cfg.ImportanceWeight)
+	if cfg.CanaryPercent < 0 || cfg.CanaryPercent > 100 {
+		return nil, fmt.Errorf("CANARY_PERCENT must be between 0 and 100")
+	}
+	if cfg.CanaryPercent > 0 {
+		switch cfg.CanaryContentType {
+		case "conversations":
+		case "shadow_conversations":
+			if cfg.ShadowModel == "" {
+				return nil, fmt.Errorf("CANARY_CONTENT_TYPE shadow_conversations requires SHADOW_EMBEDDING_MODEL")
+			}
+		default:
+			return nil, fmt.Errorf("CANARY_CONTENT_TYPE must be conversations or shadow_conversations")
+		}
+		if cfg.CanaryRecencyWeight < 0 || cfg.CanaryRecencyWeight > 1 || cfg.CanaryImportanceWeight < 0 || cfg.CanaryImportanceWeight > 1 {
+			return nil, fmt.Errorf("CANARY_SEARCH_RECENCY_WEIGHT and CANARY_SEARCH_IMPORTANCE_WEIGHT must be between 0 and 1")
+		}
+	}
+
+	cfg.FusionBanditEnabled = getEnvAsBool("FUSION_BANDIT_ENABLED", false)
+	cfg.FusionBanditBaselineShare = getEnvAsFloat("FUSION_BANDIT_BASELINE_SHARE", 0.2)
+	cfg.FusionBanditMinTrials = getEnvAsInt("FUSION_BANDIT_MIN_TRIALS", 30)
+	cfg.FusionBanditMaxRegression = getEnvAsFloat("FUSION_BANDIT_MAX_REGRESSION", 0.05)
+	cfg.FusionBanditRefresh = getEnvAsDuration("FUSION_BANDIT_REFRESH", time.Minute)
+	if cfg.FusionBanditEnabled {
+		arms, err := bandit.ParseArms(getEnv("FUSION_BANDIT_ARMS", ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid FUSION_BANDIT_ARMS: %w", err)
+		}
+		cfg.FusionBanditArms = arms
+		// Negated so that NaN, which compares false, is rejected too
+		if !(cfg.FusionBanditBaselineShare >= 0 && cfg.FusionBanditBaselineShare <= 1) {
+			return nil, fmt.Errorf("FUSION_BANDIT_BASELINE_SHARE must be between 0 and 1")
+		}
+		if cfg.FusionBanditMinTrials < 0 {
+			return nil, fmt.Errorf("FUSION_BANDIT_MIN_TRIALS must not be negative")
+		}
+		if !(cfg.FusionBanditMaxRegression >= 0 && cfg.FusionBanditMaxRegression <= 1) {
+			return nil, fmt.Errorf("FUSION_BANDIT_MAX_REGRESSION must be between 0 and 1")
+		}
+		if cfg.FusionBanditRefresh <= 0 {
+			return nil, fmt.Errorf("FUSION_BANDIT_REFRESH must be positive")
+		}
+	}
+
+	// Apply cluster settings to every collection
+	shardNumber := getEnvAsInt("QDRANT_SHARD_NUMBER", 0)
+	replicationFactor := getEnvAsInt("QDRANT_REPLICATION_FACTOR", 0)
+	writeConsistencyFactor := getEnvAsInt("QDRANT_WRITE_CONSISTENCY_FACTOR", 0)
+	readConsistency := getEnv("QDRANT_READ_CONSISTENCY", "")
+	datatype := getEnv("QDRANT_VECTOR_DATATYPE", "float32")
+	switch datatype {
+	case "float32", "float16", "uint8":
+	default:
+		return nil, fmt.Errorf("QDRANT_VECTOR_DATATYPE must be float32, float16 or uint8")
+	}
+	for contentType, collection := range cfg.Collections {
+		collection.ShardNumber = shardNumber
+		collection.ReplicationFactor = replicationFactor
+		collection.WriteConsistencyFactor = writeConsistencyFactor
+		collection.ReadConsistency = readConsistency
+		collection.Datatype = datatype
+		cfg.Collections[contentType] = collection
+	}
+
+	if err := cfg.applyRegion(); err != nil {
+		return nil, err
 	}
 
 	// Validate required fields
@@ -59,16 +1014,415 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("OPENAI_API_KEY environment variable is required")
 	}
 
+	switch cfg.MemoryStoreBackend {
+	case "postgres":
+	case "sqlite":
+		if cfg.SQLitePath == "" {
+			return nil, fmt.Errorf("SQLITE_PATH is required when MEMORY_STORE_BACKEND is sqlite")
+		}
+	case "mysql":
+		if cfg.MySQLDSN == "" {
+			return nil, fmt.Errorf("MYSQL_DSN is required when MEMORY_STORE_BACKEND is mysql")
+		}
+	default:
+		return nil, fmt.Errorf("MEMORY_STORE_BACKEND must be postgres, sqlite or mysql")
+	}
+
+	switch cfg.EmbeddingRecording {
+	case "off":
+	case "record", "replay":
+		if cfg.EmbeddingRecordingDir == "" {
+			return nil, fmt.Errorf("EMBEDDING_RECORDING_DIR is required when EMBEDDING_RECORDING is %s", cfg.EmbeddingRecording)
+		}
+	default:
+		return nil, fmt.Errorf("EMBEDDING_RECORDING must be off, record or replay")
+	}
+
+	switch cfg.MigrationGuard {
+	case "off", "warn", "block":
+	default:
+		return nil, fmt.Errorf("MIGRATION_GUARD must be off, warn or block")
+	}
+	if cfg.MigrationLargeTableRows < 0 {
+		return nil, fmt.Errorf("MIGRATION_LARGE_TABLE_ROWS must not be negative")
+	}
+
+	if cfg.QdrantMaxRetries < 0 || cfg.QdrantRetryBackoff < 0 || cfg.QdrantAttemptTimeout < 0 {
+		return nil, fmt.Errorf("QDRANT_MAX_RETRIES, QDRANT_RETRY_BACKOFF and QDRANT_ATTEMPT_TIMEOUT must not be negative")
+	}
+	if cfg.CollectionRecoveryCooldown < 0 {
+		return nil, fmt.Errorf("QDRANT_COLLECTION_RECOVERY_COOLDOWN must not be negative")
+	}
+
+	if cfg.PostgresQueryTimeout < 0 || cfg.QdrantTimeout < 0 || cfg.EmbeddingTimeout < 0 || cfg.CompletionTimeout < 0 {
+		return nil, fmt.Errorf("POSTGRES_QUERY_TIMEOUT, QDRANT_TIMEOUT, EMBEDDING_TIMEOUT and COMPLETION_TIMEOUT must not be negative")
+	}
+	for _, share := range []float64{cfg.DeadlineSharePostgres, cfg.DeadlineShareQdrant, cfg.DeadlineShareEmbedding, cfg.DeadlineShareCompletion} {
+		if share < 0 || share > 1 {
+			return nil, fmt.Errorf("REQUEST_DEADLINE_SHARE_POSTGRES, _QDRANT, _EMBEDDING and _COMPLETION must be between 0 and 1")
+		}
+	}
+	if cfg.DeadlineOptionalMin < 0 {
+		return nil, fmt.Errorf("REQUEST_DEADLINE_OPTIONAL_MIN must not be negative")
+	}
+
+	if err := egress.ValidateProxy(cfg.EgressProxy); err != nil {
+		return nil, fmt.Errorf("EGRESS_PROXY must be an http, https or socks5 URL or %q: %v", egress.Direct, err)
+	}
+
+	switch cfg.PostgresSSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		return nil, fmt.Errorf("POSTGRES_SSLMODE %q is not a valid sslmode", cfg.PostgresSSLMode)
+	}
+
+	if cfg.LogFullContent && cfg.Env != "development" {
+		return nil, fmt.Errorf("LOG_FULL_CONTENT is only allowed when ENVIRONMENT=development")
+	}
+	if cfg.Env == "production" {
+		if err := cfg.checkProduction(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, role := range cfg.EmbedRoles {
+		switch role {
+		case "user", "assistant", "system", "tool":
+		default:
+			return nil, fmt.Errorf("EMBED_ROLES contains unknown role %q", role)
+		}
+	}
+
+	if cfg.EmbedMaxTurns < 0 {
+		return nil, fmt.Errorf("EMBED_MAX_TURNS must not be negative")
+	}
+	if cfg.EmbedChunkTurns <= 0 {
+		return nil, fmt.Errorf("EMBED_CHUNK_TURNS must be positive")
+	}
+
+	if !strings.Contains(cfg.PersonalInfoEmbedTemplate, "{content}") {
+		return nil, fmt.Errorf("PERSONAL_INFO_EMBED_TEMPLATE must contain {content}")
+	}
+	for _, placeholder := range templatePlaceholder.FindAllString(cfg.PersonalInfoEmbedTemplate, -1) {
+		switch placeholder {
+		case "{content}", "{category}", "{importance}":
+		default:
+			return nil, fmt.Errorf("PERSONAL_INFO_EMBED_TEMPLATE contains unknown placeholder %s", placeholder)
+		}
+	}
+
+	switch cfg.VectorWriteMode {
+	case "outbox", "rollback":
+	default:
+		return nil, fmt.Errorf("VECTOR_WRITE_MODE must be outbox or rollback")
+	}
+
+	switch cfg.SaveDurability {
+	case "safe":
+	case "fast", "queued":
+		if cfg.VectorWriteMode != "outbox" {
+			return nil, fmt.Errorf("SAVE_DURABILITY=%s requires VECTOR_WRITE_MODE=outbox", cfg.SaveDurability)
+		}
+	default:
+		return nil, fmt.Errorf("SAVE_DURABILITY must be fast, safe or queued")
+	}
+
+	if cfg.ContextCompressionRatio <= 0 || cfg.ContextCompressionRatio > 1 {
+		return nil, fmt.Errorf("CONTEXT_COMPRESSION_RATIO must be above 0 and at most 1")
+	}
+
+	tenantMaxAgeDays, err := parseTenantDays(getEnvAsList("SEARCH_TENANT_MAX_AGE_DAYS", nil))
+	if err != nil {
+		return nil, fmt.Errorf("SEARCH_TENANT_MAX_AGE_DAYS: %w", err)
+	}
+	cfg.SearchTenantMaxAgeDays = tenantMaxAgeDays
+	if cfg.SearchMaxAgeDays < 0 {
+		return nil, fmt.Errorf("SEARCH_MAX_AGE_DAYS must not be negative")
+	}
+
+	if cfg.SearchMaxCandidates < 0 {
+		return nil, fmt.Errorf("SEARCH_MAX_CANDIDATES must not be negative")
+	}
+
+	if cfg.SearchRecencyWeight < 0 || cfg.SearchRecencyWeight > 1 {
+		return nil, fmt.Errorf("SEARCH_RECENCY_WEIGHT must be between 0 and 1")
+	}
+
+	switch cfg.RetrieveRouting {
+	case "all", "classifier":
+	default:
+		return nil, fmt.Errorf("RETRIEVE_ROUTING must be all or classifier")
+	}
+	if cfg.RetrieveRoutingMinWeight <= 0 || cfg.RetrieveRoutingMinWeight > 1 {
+		return nil, fmt.Errorf("RETRIEVE_ROUTING_MIN_WEIGHT must be greater than 0 and at most 1")
+	}
+
+	switch cfg.ImportanceScorer {
+	case "heuristic", "llm":
+	default:
+		return nil, fmt.Errorf("IMPORTANCE_SCORER must be heuristic or llm")
+	}
+
+	if cfg.ImportanceWeight < 0 || cfg.ImportanceWeight > 1 {
+		return nil, fmt.Errorf("SEARCH_IMPORTANCE_WEIGHT must be between 0 and 1")
+	}
+
+	if cfg.DriftSampleSize <= 0 || cfg.DriftSampleSize > 1000 {
+		return nil, fmt.Errorf("EMBEDDING_DRIFT_SAMPLE_SIZE must be between 1 and 1000")
+	}
+	if cfg.DriftThreshold <= 0 || cfg.DriftThreshold > 2 {
+		return nil, fmt.Errorf("EMBEDDING_DRIFT_THRESHOLD must be in (0, 2]")
+	}
+
+	if cfg.EmbeddingAnomalyWindow <= 0 {
+		return nil, fmt.Errorf("EMBEDDING_ANOMALY_WINDOW must be positive")
+	}
+	if cfg.EmbeddingAnomalyBaseline < 20 || cfg.EmbeddingAnomalyBaseline > 2000 {
+		return nil, fmt.Errorf("EMBEDDING_ANOMALY_BASELINE must be between 20 and 2000")
+	}
+	if cfg.EmbeddingAnomalyThreshold <= 0 {
+		return nil, fmt.Errorf("EMBEDDING_ANOMALY_THRESHOLD must be positive")
+	}
+	if cfg.EmbeddingAnomalyWebhookURL != "" {
+		if u, err := url.Parse(cfg.EmbeddingAnomalyWebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("EMBEDDING_ANOMALY_WEBHOOK_URL must be an http or https URL")
+		}
+	}
+
+	if cfg.StandingQueryMaxPerUser <= 0 || cfg.StandingQueryStreamBuffer <= 0 {
+		return nil, fmt.Errorf("STANDING_QUERY_MAX_PER_USER and STANDING_QUERY_STREAM_BUFFER must be positive")
+	}
+	if cfg.StandingQueryWebhookURL != "" {
+		if u, err := url.Parse(cfg.StandingQueryWebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("STANDING_QUERY_WEBHOOK_URL must be an http or https URL")
+		}
+	}
+
+	if cfg.EmbeddingCacheSize < 0 || cfg.QueryCacheSize < 0 {
+		return nil, fmt.Errorf("EMBEDDING_CACHE_SIZE and QUERY_CACHE_SIZE must not be negative")
+	}
+	if cfg.EmbeddingCacheTTL <= 0 || cfg.QueryCacheTTL <= 0 {
+		return nil, fmt.Errorf("EMBEDDING_CACHE_TTL and QUERY_CACHE_TTL must be positive")
+	}
+
+	if cfg.SuggestLookback <= 0 || cfg.SuggestConversations <= 0 || cfg.SuggestCacheTTL <= 0 || cfg.SuggestCacheSize <= 0 {
+		return nil, fmt.Errorf("SUGGEST_LOOKBACK, SUGGEST_CONVERSATIONS, SUGGEST_CACHE_TTL and SUGGEST_CACHE_SIZE must be positive")
+	}
+
+	if cfg.InsightsKAnonymity < 2 {
+		return nil, fmt.Errorf("INSIGHTS_K_ANONYMITY must be at least 2")
+	}
+	if len(cfg.InsightsCategories) == 1 {
+		return nil, fmt.Errorf("INSIGHTS_QUESTION_CATEGORIES must list at least two categories")
+	}
+
+	if cfg.AnalyticsExport.Enabled {
+		if cfg.BlobStoreDir == "" {
+			return nil, fmt.Errorf("ANALYTICS_EXPORT_ENABLED requires BLOB_STORE_DIR")
+		}
+		if cfg.AnalyticsExportLookbackDays <= 0 {
+			return nil, fmt.Errorf("ANALYTICS_EXPORT_LOOKBACK_DAYS must be positive")
+		}
+	}
+	if strings.Trim(cfg.AnalyticsExportPrefix, "/") == "" {
+		return nil, fmt.Errorf("ANALYTICS_EXPORT_PREFIX must not be empty")
+	}
+	cfg.AnalyticsExportPrefix = strings.Trim(cfg.AnalyticsExportPrefix, "/")
+	if strings.Trim(cfg.VectorExportPrefix, "/") == "" {
+		return nil, fmt.Errorf("VECTOR_EXPORT_PREFIX must not be empty")
+	}
+	cfg.VectorExportPrefix = strings.Trim(cfg.VectorExportPrefix, "/")
+
+	for _, task := range []struct {
+		prefix string
+		task   *ScheduledTask
+	}{
+		{"PROFILE_REFRESH", &cfg.ProfileRefresh},
+		{"FORGET", &cfg.Forget},
+		{"EMBEDDING_DRIFT", &cfg.Drift},
+		{"EMBEDDING_ANOMALY", &cfg.EmbeddingAnomaly},
+		{"ANALYTICS_EXPORT", &cfg.AnalyticsExport},
+		{"INTEGRITY_VERIFY", &cfg.IntegrityVerify},
+		{"SIGNING_NONCE_SWEEP", &cfg.SigningNonceSweep},
+	} {
+		sched, err := schedule.Parse(task.task.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s_SCHEDULE: %w", task.prefix, err)
+		}
+		if task.task.Jitter < 0 {
+			return nil, fmt.Errorf("%s_JITTER must not be negative", task.prefix)
+		}
+		task.task.Schedule = sched
+	}
+
+	if cfg.LeaderElectionInterval <= 0 {
+		return nil, fmt.Errorf("LEADER_ELECTION_INTERVAL must be positive")
+	}
+
+	if cfg.QueueBatchSize <= 0 || cfg.QueueLease <= 0 || cfg.QueuePollInterval <= 0 || cfg.QueueMaxAttempts <= 0 {
+		return nil, fmt.Errorf("QUEUE_BATCH_SIZE, QUEUE_LEASE, QUEUE_POLL_INTERVAL and QUEUE_MAX_ATTEMPTS must be positive")
+	}
+
+	if cfg.IngestShaperEnabled {
+		if cfg.IngestShaperRate <= 0 || cfg.IngestShaperBurst <= 0 {
+			return nil, fmt.Errorf("INGEST_SHAPER_RATE and INGEST_SHAPER_BURST must be positive")
+		}
+		if cfg.IngestShaperBackfillShare <= 0 || cfg.IngestShaperBackfillShare > 1 {
+			return nil, fmt.Errorf("INGEST_SHAPER_BACKFILL_SHARE must be above 0 and at most 1")
+		}
+	}
+
+	for class, limit := range cfg.LoadShedLimits {
+		if limit < 0 {
+			return nil, fmt.Errorf("LOAD_SHED_%s_CONCURRENCY must not be negative", strings.ToUpper(class))
+		}
+	}
+	if cfg.LoadShedQueueTimeout < 0 || cfg.LoadShedRetryAfter <= 0 {
+		return nil, fmt.Errorf("LOAD_SHED_QUEUE_TIMEOUT must not be negative and LOAD_SHED_RETRY_AFTER must be positive")
+	}
+	if cfg.EventQueueSize <= 0 || cfg.EventWorkers <= 0 || cfg.EventHandlerTimeout < 0 {
+		return nil, fmt.Errorf("EVENT_QUEUE_SIZE and EVENT_WORKERS must be positive and EVENT_HANDLER_TIMEOUT must not be negative")
+	}
+
+	if cfg.APIKeyReloadInterval <= 0 {
+		return nil, fmt.Errorf("API_KEY_RELOAD_INTERVAL must be positive")
+	}
+
+	if cfg.SigningMaxSkew <= 0 {
+		return nil, fmt.Errorf("SIGNING_MAX_SKEW must be positive")
+	}
+	if len(cfg.SigningClients) > 0 && !cfg.APIKeysRequired {
+		return nil, fmt.Errorf("SIGNING_CLIENTS requires API_KEYS_REQUIRED=true")
+	}
+
+	if cfg.UsageFlushInterval <= 0 {
+		return nil, fmt.Errorf("USAGE_FLUSH_INTERVAL must be positive")
+	}
+
+	budget := cfg.EmbeddingBudget
+	if budget.DailyTokens < 0 || budget.MonthlyTokens < 0 || budget.DailyUSD < 0 || budget.MonthlyUSD < 0 {
+		return nil, fmt.Errorf("EMBEDDING_*_BUDGET settings must not be negative")
+	}
+	if (budget.DailyUSD > 0 || budget.MonthlyUSD > 0) && budget.PricePerMillionTokens <= 0 {
+		return nil, fmt.Errorf("EMBEDDING_PRICE_PER_MILLION_TOKENS must be positive when a dollar budget is set")
+	}
+	for _, value := range cfg.QuotaWarningPercents {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil || percent <= 0 || percent >= 100 {
+			return nil, fmt.Errorf("QUOTA_WARNING_PERCENTS must list percentages between 0 and 100, got %q", value)
+		}
+		cfg.EmbeddingBudget.Warnings = append(cfg.EmbeddingBudget.Warnings, percent/100)
+	}
+	sort.Float64s(cfg.EmbeddingBudget.Warnings)
+	if cfg.QuotaWebhookURL != "" {
+		if u, err := url.Parse(cfg.QuotaWebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("QUOTA_WEBHOOK_URL must be an http or https URL")
+		}
+	}
+
+	if cfg.DeleteConfirmationTTL <= 0 {
+		return nil, fmt.Errorf("DELETE_CONFIRMATION_TTL must be positive")
+	}
+	if cfg.DeleteConfirmationKey != "" && len(cfg.DeleteConfirmationKey) < 32 {
+		return nil, fmt.Errorf("DELETE_CONFIRMATION_KEY must be at least 32 characters")
+	}
+
+	if cfg.RequestAuditSampleRate < 0 || cfg.RequestAuditSampleRate > 1 {
+		return nil, fmt.Errorf("REQUEST_AUDIT_SAMPLE_RATE must be between 0 and 1")
+	}
+	if cfg.RequestAuditEnabled && len(cfg.RequestAuditChainKey) < 32 {
+		return nil, fmt.Errorf("REQUEST_AUDIT_CHAIN_KEY must be set to at least 32 characters when REQUEST_AUDIT_ENABLED is true")
+	}
+
+	if cfg.SentrySampleRate < 0 || cfg.SentrySampleRate > 1 {
+		return nil, fmt.Errorf("SENTRY_SAMPLE_RATE must be between 0 and 1")
+	}
+
+	if cfg.TracingEndpoint != "" {
+		if u, err := url.Parse(cfg.TracingEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("TRACING_ENDPOINT must be an http or https URL")
+		}
+	}
+	if cfg.TracingSampleRate < 0 || cfg.TracingSampleRate > 1 {
+		return nil, fmt.Errorf("TRACING_SAMPLE_RATE must be between 0 and 1")
+	}
+	if cfg.MetricsTenantTopN < 0 {
+		return nil, fmt.Errorf("METRICS_TENANT_TOP_N must not be negative")
+	}
+
+	if len(cfg.EncryptionMasterKeys) > 0 && cfg.EncryptionKeyCacheSize <= 0 {
+		return nil, fmt.Errorf("ENCRYPTION_KEY_CACHE_SIZE must be positive")
+	}
+
+	if (cfg.PostgresSSLCert == "") != (cfg.PostgresSSLKey == "") {
+		return nil, fmt.Errorf("POSTGRES_SSLCERT and POSTGRES_SSLKEY must be set together")
+	}
+
+	// A standby's database is a read-only replica of the primary's, which also owns the collections
+	if cfg.Standby {
+		cfg.AutoCreateCollections = false
+		cfg.QueueWorkerEnabled = false
+		cfg.SearchLogEnabled = false
+	}
+
 	return cfg, nil
 }
 
+// applyRegion loads the region map and points storage at the local region's endpoints
+func (c *Config) applyRegion() error {
+	if c.Region == "" {
+		if c.RegionMapFile != "" {
+			return fmt.Errorf("REGION_MAP_FILE requires REGION")
+		}
+		return nil
+	}
+	if c.RegionMapFile == "" {
+		return fmt.Errorf("REGION requires REGION_MAP_FILE")
+	}
+
+	regions, err := residency.Load(c.RegionMapFile)
+	if err != nil {
+		return err
+	}
+	local, ok := regions.Regions[c.Region]
+	if !ok {
+		return fmt.Errorf("REGION %q is not in the region map (regions: %v)", c.Region, regions.Names())
+	}
+
+	if local.PostgresHost != "" {
+		c.PostgresHost = local.PostgresHost
+	}
+	if local.PostgresPort != 0 {
+		c.PostgresPort = local.PostgresPort
+	}
+	if local.PostgresDB != "" {
+		c.PostgresDB = local.PostgresDB
+	}
+	if local.QdrantHost != "" {
+		c.QdrantHost = local.QdrantHost
+	}
+	if local.QdrantPort != 0 {
+		c.QdrantPort = local.QdrantPort
+	}
+	c.Regions = regions
+	return nil
+}
+
 func getEnv(key, defaultVal string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
 	}
+	if value, exists := profileSettings[key]; exists {
+		return value
+	}
 	return defaultVal
 }
 
+// unescape expands the \n and \t escapes, so separators can be written on one line
+func unescape(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\t`, "\t").Replace(value)
+}
+
 func getEnvAsInt(key string, defaultVal int) int {
 	valStr := getEnv(key, "")
 	if val, err := strconv.Atoi(valStr); err == nil {
@@ -77,9 +1431,100 @@ func getEnvAsInt(key string, defaultVal int) int {
 	return defaultVal
 }
 
+func getEnvAsBool(key string, defaultVal bool) bool {
+	valStr := getEnv(key, "")
+	if val, err := strconv.ParseBool(valStr); err == nil {
+		return val
+	}
+	return defaultVal
+}
+
+func getEnvAsFloat(key string, defaultVal float64) float64 {
+	valStr := getEnv(key, "")
+	if val, err := strconv.ParseFloat(valStr, 64); err == nil {
+		return val
+	}
+	return defaultVal
+}
+
+func getEnvAsOctal(key string, defaultVal uint32) uint32 {
+	valStr := getEnv(key, "")
+	if val, err := strconv.ParseUint(valStr, 8, 32); err == nil {
+		return uint32(val)
+	}
+	return defaultVal
+}
+
+func getEnvAsDuration(key string, defaultVal time.Duration) time.Duration {
+	valStr := getEnv(key, "")
+	if val, err := time.ParseDuration(valStr); err == nil {
+		return val
+	}
+	return defaultVal
+}
+
+// getEnvAsScheduledTask reads a task's <prefix>_ENABLED, <prefix>_SCHEDULE and <prefix>_JITTER;
+// Load parses the schedule
+func getEnvAsScheduledTask(prefix string, enabled bool, spec string) ScheduledTask {
+	return ScheduledTask{
+		Enabled: getEnvAsBool(prefix+"_ENABLED", enabled),
+		Spec:    getEnv(prefix+"_SCHEDULE", spec),
+		Jitter:  getEnvAsDuration(prefix+"_JITTER", 0),
+	}
+}
+
+// everyInterval is the schedule of a task still configured by its former interval setting, which
+// the task's _SCHEDULE replaces
+func everyInterval(key string, defaultVal time.Duration) string {
+	interval := getEnvAsDuration(key, defaultVal)
+	if interval <= 0 {
+		interval = defaultVal
+	}
+	return "@every " + interval.String()
+}
+
+// defaultInstanceID identifies the instance by host name and process ID
+func defaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+func getEnvAsList(key string, defaultVal []string) []string {
+	valStr := getEnv(key, "")
+	if valStr == "" {
+		return defaultVal
+	}
+
+	var values []string
+	for _, item := range strings.Split(valStr, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// parseTenantDays parses "tenant=days" entries into days per tenant
+func parseTenantDays(entries []string) (map[string]int, error) {
+	days := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		tenantID, value, ok := strings.Cut(entry, "=")
+		tenantID = strings.TrimSpace(tenantID)
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || tenantID == "" || err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid entry %q, expected tenant=days", entry)
+		}
+		days[tenantID] = n
+	}
+	return days, nil
+}
+
 // GetPostgresDSN returns PostgreSQL connection string
 func (c *Config) GetPostgresDSN() string {
-	return fmt.Sprintf(
+	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		c.PostgresHost,
 		c.PostgresPort,
@@ -88,9 +1533,75 @@ func (c *Config) GetPostgresDSN() string {
 		c.PostgresDB,
 		c.PostgresSSLMode,
 	)
+
+	if c.PostgresSSLRootCert != "" {
+		dsn += fmt.Sprintf(" sslrootcert=%s", c.PostgresSSLRootCert)
+	}
+	if c.PostgresSSLCert != "" {
+		dsn += fmt.Sprintf(" sslcert=%s sslkey=%s", c.PostgresSSLCert, c.PostgresSSLKey)
+	}
+
+	return dsn
+}
+
+// GetPostgresEnv returns the PG* environment variables that point libpq tools such as pg_dump at the database
+func (c *Config) GetPostgresEnv() []string {
+	env := []string{
+		"PGHOST=" + c.PostgresHost,
+		"PGPORT=" + strconv.Itoa(c.PostgresPort),
+		"PGUSER=" + c.PostgresUser,
+		"PGPASSWORD=" + c.PostgresPassword,
+		"PGDATABASE=" + c.PostgresDB,
+		"PGSSLMODE=" + c.PostgresSSLMode,
+	}
+
+	if c.PostgresSSLRootCert != "" {
+		env = append(env, "PGSSLROOTCERT="+c.PostgresSSLRootCert)
+	}
+	if c.PostgresSSLCert != "" {
+		env = append(env, "PGSSLCERT="+c.PostgresSSLCert, "PGSSLKEY="+c.PostgresSSLKey)
+	}
+
+	return env
+}
+
+// StartupRetryPolicy returns the retry policy for waiting on a dependency at startup
+func (c *Config) StartupRetryPolicy(maxWait time.Duration) lifecycle.RetryPolicy {
+	return lifecycle.RetryPolicy{
+		InitialBackoff: c.StartupInitialBackoff,
+		MaxBackoff:     c.StartupMaxBackoff,
+		MaxWait:        maxWait,
+	}
+}
+
+// GetListenAddr returns the TCP address the API server binds to
+func (c *Config) GetListenAddr() string {
+	return net.JoinHostPort(c.BindAddress, strconv.Itoa(c.Port))
+}
+
+// EgressOptions returns the settings of outbound connections to external services
+func (c *Config) EgressOptions() egress.Options {
+	return egress.Options{
+		Proxy:    c.EgressProxy,
+		NoProxy:  c.EgressNoProxy,
+		CABundle: c.EgressCABundle,
+	}
+}
+
+// QdrantClientOptions returns the retry and timeout settings of the Qdrant HTTP client
+func (c *Config) QdrantClientOptions() httpclient.Options {
+	return httpclient.Options{
+		MaxRetries:     c.QdrantMaxRetries,
+		RetryBackoff:   c.QdrantRetryBackoff,
+		AttemptTimeout: c.QdrantAttemptTimeout,
+	}
 }
 
 // GetQdrantURL returns Qdrant server URL
 func (c *Config) GetQdrantURL() string {
-	return fmt.Sprintf("http://%s:%d", c.QdrantHost, c.QdrantPort)
+	scheme := "http"
+	if c.QdrantTLS {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, c.QdrantHost, c.QdrantPort)
 }
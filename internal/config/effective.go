@@ -0,0 +1,138 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// maskedValue replaces a set secret in the effective configuration
+const maskedValue = "********"
+
+// WriteEffective writes the resolved configuration as indented JSON, fields in declaration order
+// and settings tagged secret masked, so profiles can be reviewed and their outputs diffed
+func (c *Config) WriteEffective(w io.Writer) error {
+	out, err := json.MarshalIndent(effectiveValue(reflect.ValueOf(*c), false), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", out)
+	return err
+}
+
+// effectiveField is a configuration field in declaration order
+type effectiveField struct {
+	name  string
+	value interface{}
+}
+
+// effectiveStruct keeps the declaration order of a struct's fields when encoded
+type effectiveStruct []effectiveField
+
+// MarshalJSON encodes the fields as an object in order
+func (s effectiveStruct) MarshalJSON() ([]byte, error) {
+	buf := []byte{'{'}
+	for i, field := range s {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		name, _ := json.Marshal(field.name)
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.name, err)
+		}
+		buf = append(buf, name...)
+		buf = append(buf, ':')
+		buf = append(buf, value...)
+	}
+	return append(buf, '}'), nil
+}
+
+// effectiveValue converts a configuration value for display: durations as strings, structs by
+// their exported fields, and secrets masked when set
+func effectiveValue(v reflect.Value, secret bool) interface{} {
+	switch v.Type() {
+	case reflect.TypeOf(time.Duration(0)):
+		return time.Duration(v.Int()).String()
+	case reflect.TypeOf(os.FileMode(0)):
+		return fmt.Sprintf("%#o", v.Uint())
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return effectiveValue(v.Elem(), secret)
+	case reflect.Struct:
+		fields := make(effectiveStruct, 0, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() || opaque(field.Type) {
+				continue
+			}
+			fields = append(fields, effectiveField{
+				name:  field.Name,
+				value: effectiveValue(v.Field(i), secret || field.Tag.Get("secret") == "true"),
+			})
+		}
+		return fields
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = effectiveValue(v.Index(i), secret)
+		}
+		return items
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		keys := make([]string, 0, v.Len())
+		values := make(map[string]reflect.Value, v.Len())
+		for _, key := range v.MapKeys() {
+			name := fmt.Sprint(key.Interface())
+			keys = append(keys, name)
+			values[name] = v.MapIndex(key)
+		}
+		sort.Strings(keys)
+		fields := make(effectiveStruct, 0, len(keys))
+		for _, key := range keys {
+			fields = append(fields, effectiveField{name: key, value: effectiveValue(values[key], secret)})
+		}
+		return fields
+	case reflect.Func, reflect.Chan:
+		return nil
+	}
+
+	if secret {
+		if v.IsZero() {
+			return v.Interface()
+		}
+		return maskedValue
+	}
+	return v.Interface()
+}
+
+// opaque reports whether a type is a struct, or a pointer to one, without exported fields, such
+// as a parsed schedule shown by its spec instead
+func opaque(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return false
+		}
+	}
+	return true
+}
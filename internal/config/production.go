@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"net"
+)
+
+// minProductionAdminKey is the shortest admin API key accepted in production
+const minProductionAdminKey = 32
+
+// checkProduction refuses combinations of settings that are unsafe in production even though
+// each is valid on its own
+func (c *Config) checkProduction() error {
+	if !c.APIKeysRequired && c.publicBind() && len(c.IPAllow) == 0 {
+		return fmt.Errorf("ENVIRONMENT=production refuses to serve without API_KEYS_REQUIRED on a public address (BIND_ADDRESS %q): require API keys, bind to a loopback address or LISTEN_SOCKET, or restrict clients with IP_ALLOW", c.BindAddress)
+	}
+	if c.AdminAPIKey != "" && len(c.AdminAPIKey) < minProductionAdminKey {
+		return fmt.Errorf("ADMIN_API_KEY must be at least %d characters when ENVIRONMENT=production", minProductionAdminKey)
+	}
+	return nil
+}
+
+// publicBind reports whether the API server listens on a TCP address reachable from other hosts:
+// all interfaces or a non-loopback one
+func (c *Config) publicBind() bool {
+	if c.ListenSocket != "" {
+		return false
+	}
+	if c.BindAddress == "localhost" {
+		return false
+	}
+	ip := net.ParseIP(c.BindAddress)
+	return ip == nil || !ip.IsLoopback()
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// settingName matches the environment variable names a profile may set
+var settingName = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// profileSettings holds the settings of the profile Load applied; environment variables take
+// precedence over them
+var profileSettings map[string]string
+
+// profilesFile is a file of named profiles, such as dev, staging and prod, each setting
+// environment variables and optionally extending another profile:
+//
+//	{"profiles": {
+//	  "base": {"settings": {"OPENAI_MODEL": "text-embedding-3-small"}},
+//	  "prod": {"extends": "base", "settings": {"ENVIRONMENT": "production", "API_KEYS_REQUIRED": true}}
+//	}}
+type profilesFile struct {
+	Profiles map[string]profileDefinition `json:"profiles"`
+}
+
+// profileDefinition is one profile of a profiles file
+type profileDefinition struct {
+	Extends  string                 `json:"extends"`
+	Settings map[string]interface{} `json:"settings"`
+}
+
+// loadProfile reads the profiles file at path and resolves the settings of profile name, its
+// own settings overriding those of the profiles it extends. It returns the profiles applied,
+// base first
+func loadProfile(path string, name string) (map[string]string, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read profiles file: %w", err)
+	}
+	var file profilesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse profiles file %s: %w", path, err)
+	}
+
+	// Follow extends up to the base profile
+	var chain []string
+	seen := make(map[string]bool)
+	for current := name; current != ""; current = file.Profiles[current].Extends {
+		if seen[current] {
+			return nil, nil, fmt.Errorf("profile %s extends itself through %s", name, strings.Join(chain, " -> "))
+		}
+		if _, ok := file.Profiles[current]; !ok {
+			if current == name {
+				return nil, nil, fmt.Errorf("profile %s is not defined in %s", name, path)
+			}
+			return nil, nil, fmt.Errorf("profile %s extends undefined profile %s", chain[len(chain)-1], current)
+		}
+		seen[current] = true
+		chain = append(chain, current)
+	}
+
+	settings := make(map[string]string)
+	applied := make([]string, 0, len(chain))
+	for i := len(chain) - 1; i >= 0; i-- {
+		for key, value := range file.Profiles[chain[i]].Settings {
+			if !settingName.MatchString(key) {
+				return nil, nil, fmt.Errorf("profile %s: %q is not a setting name", chain[i], key)
+			}
+			if key == "CONFIG_PROFILE" || key == "CONFIG_PROFILES_FILE" {
+				return nil, nil, fmt.Errorf("profile %s: %s can't be set by a profile", chain[i], key)
+			}
+			str, err := settingValue(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("profile %s: %s: %w", chain[i], key, err)
+			}
+			settings[key] = str
+		}
+		applied = append(applied, chain[i])
+	}
+	return settings, applied, nil
+}
+
+// settingValue returns a profile value as the environment variable would hold it: lists are
+// comma-separated
+func settingValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if _, nested := item.([]interface{}); nested {
+				return "", fmt.Errorf("lists can't be nested")
+			}
+			str, err := settingValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, str)
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("value must be a string, number, boolean or list")
+	}
+}
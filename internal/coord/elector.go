@@ -0,0 +1,122 @@
+package coord
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/models"
+)
+
+// Elector elects one replica as leader by holding an advisory lock; background jobs that must
+// run on exactly one instance check IsLeader before each run
+type Elector struct {
+	locker     *Locker
+	name       string
+	instanceID string
+	interval   time.Duration
+
+	mu          sync.RWMutex
+	lock        *Lock
+	leaderSince time.Time
+	lastErr     string
+}
+
+// NewElector creates an elector campaigning for the named lock every interval
+func NewElector(locker *Locker, name string, instanceID string, interval time.Duration) *Elector {
+	return &Elector{
+		locker:     locker,
+		name:       name,
+		instanceID: instanceID,
+		interval:   interval,
+	}
+}
+
+// Run campaigns for leadership until ctx is cancelled, then resigns
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		e.campaign(ctx)
+
+		select {
+		case <-ctx.Done():
+			e.resign()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// campaign verifies a held lock is still alive, or tries to take it
+func (e *Elector) campaign(ctx context.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.lock != nil {
+		err := e.lock.Alive(ctx)
+		if err == nil {
+			return
+		}
+		if ctx.Err() == nil {
+			fmt.Printf("warning: lost leadership of %s: %v\n", e.name, err)
+			errreport.Background(ctx, "leader_lost", err)
+			e.lastErr = err.Error()
+		}
+		e.lock.Release()
+		e.lock = nil
+	}
+
+	lock, err := e.locker.TryLock(ctx, e.name)
+	if err != nil {
+		if ctx.Err() == nil {
+			e.lastErr = err.Error()
+		}
+		return
+	}
+	e.lastErr = ""
+	if lock != nil {
+		e.lock = lock
+		e.leaderSince = time.Now()
+		fmt.Printf("instance %s became leader of %s\n", e.instanceID, e.name)
+	}
+}
+
+// resign releases leadership
+func (e *Elector) resign() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.lock != nil {
+		e.lock.Release()
+		e.lock = nil
+	}
+}
+
+// IsLeader reports whether this instance currently holds leadership
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.lock != nil
+}
+
+// Status reports this instance's leadership for health checks
+func (e *Elector) Status() models.LeadershipStatus {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	status := models.LeadershipStatus{
+		Lock:       e.name,
+		InstanceID: e.instanceID,
+		Leader:     e.lock != nil,
+		Error:      e.lastErr,
+	}
+	if e.lock != nil {
+		status.LeaderSince = e.leaderSince.UTC().Format(time.RFC3339)
+	}
+	return status
+}
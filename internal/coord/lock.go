@@ -0,0 +1,202 @@
+// Package coord coordinates background work across server replicas with Postgres advisory locks,
+// or MySQL named locks on the MySQL backend
+package coord
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// keyPrefix namespaces this server's advisory lock keys
+const keyPrefix = "refo-rag:"
+
+// Locker takes session-level Postgres advisory locks. Each held lock pins one pooled connection,
+// so a crashed holder's locks are released when its connection drops.
+type Locker struct {
+	db *sql.DB
+
+	// dialect holds the lock statements of the database
+	dialect lockDialect
+
+	// local holds the in-process locks of a locker without a database, one semaphore per key
+	mu    sync.Mutex
+	local map[int64]chan struct{}
+}
+
+// lockDialect holds the statements that take and release a session-level lock, and maps a lock
+// key to their argument. Both lock statements report whether they took the lock
+type lockDialect struct {
+	lock    string
+	tryLock string
+	unlock  string
+	arg     func(key int64) interface{}
+}
+
+// postgresLocks are Postgres advisory locks, keyed by the 64-bit key itself
+var postgresLocks = lockDialect{
+	lock:    `SELECT true FROM (SELECT pg_advisory_lock($1)) AS locked`,
+	tryLock: `SELECT pg_try_advisory_lock($1)`,
+	unlock:  `SELECT pg_advisory_unlock($1)`,
+	arg:     func(key int64) interface{} { return key },
+}
+
+// mysqlLocks are MySQL named locks. GET_LOCK takes a string of at most 64 characters, so the key
+// is named in hex; waiting takes a year rather than -1, which MariaDB doesn't read as forever
+var mysqlLocks = lockDialect{
+	lock:    `SELECT COALESCE(GET_LOCK(?, 31536000), 0) = 1`,
+	tryLock: `SELECT COALESCE(GET_LOCK(?, 0), 0) = 1`,
+	unlock:  `SELECT RELEASE_LOCK(?)`,
+	arg:     func(key int64) interface{} { return fmt.Sprintf("%s%016x", keyPrefix, uint64(key)) },
+}
+
+// NewLocker creates a locker on a Postgres database
+func NewLocker(db *sql.DB) *Locker {
+	return &Locker{
+		db:      db,
+		dialect: postgresLocks,
+	}
+}
+
+// NewMySQLLocker creates a locker on a MySQL database, taking named locks in place of advisory
+// locks; they too are released when the holding connection drops
+func NewMySQLLocker(db *sql.DB) *Locker {
+	return &Locker{
+		db:      db,
+		dialect: mysqlLocks,
+	}
+}
+
+// NewLocalLocker creates a locker whose locks are held in process, for a single server on an
+// embedded database: nothing else can contend for them, so it is always the leader
+func NewLocalLocker() *Locker {
+	return &Locker{
+		local: make(map[int64]chan struct{}),
+	}
+}
+
+// Lock is a held advisory lock
+type Lock struct {
+	conn *sql.Conn
+	key  int64
+
+	// unlock releases the lock on conn
+	unlock string
+	arg    interface{}
+
+	// held is the semaphore of an in-process lock, nil for an advisory lock
+	held chan struct{}
+}
+
+// semaphore returns the in-process semaphore of a lock key
+func (l *Locker) semaphore(key int64) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sem, ok := l.local[key]
+	if !ok {
+		sem = make(chan struct{}, 1)
+		l.local[key] = sem
+	}
+	return sem
+}
+
+// lockKey maps a lock name to an advisory lock key
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(keyPrefix + name))
+	return int64(h.Sum64())
+}
+
+// WithLock waits for the named lock, runs fn and releases the lock
+func (l *Locker) WithLock(ctx context.Context, name string, fn func() error) error {
+	if l.db == nil {
+		sem := l.semaphore(lockKey(name))
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return fmt.Errorf("failed to acquire lock %s: %w", name, ctx.Err())
+		}
+		defer func() { <-sem }()
+		return fn()
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection for lock %s: %w", name, err)
+	}
+	lock := l.newLock(conn, lockKey(name))
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, l.dialect.lock, lock.arg).Scan(&acquired); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	if !acquired {
+		conn.Close()
+		return fmt.Errorf("failed to acquire lock %s: timed out", name)
+	}
+	defer lock.Release()
+
+	return fn()
+}
+
+// TryLock takes the named lock if it is free; it returns nil if another session holds it
+func (l *Locker) TryLock(ctx context.Context, name string) (*Lock, error) {
+	if l.db == nil {
+		key := lockKey(name)
+		sem := l.semaphore(key)
+		select {
+		case sem <- struct{}{}:
+			return &Lock{key: key, held: sem}, nil
+		default:
+			return nil, nil
+		}
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection for lock %s: %w", name, err)
+	}
+
+	lock := l.newLock(conn, lockKey(name))
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, l.dialect.tryLock, lock.arg).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to try lock %s: %w", name, err)
+	}
+	if !acquired {
+		conn.Close()
+		return nil, nil
+	}
+
+	return lock, nil
+}
+
+// newLock returns the lock of a key to be taken on conn
+func (l *Locker) newLock(conn *sql.Conn, key int64) *Lock {
+	return &Lock{conn: conn, key: key, unlock: l.dialect.unlock, arg: l.dialect.arg(key)}
+}
+
+// Alive checks that the connection holding the lock is still open; an in-process lock is alive
+// until released
+func (lk *Lock) Alive(ctx context.Context) error {
+	if lk.held != nil {
+		return nil
+	}
+	return lk.conn.PingContext(ctx)
+}
+
+// Release unlocks and returns the connection to the pool
+func (lk *Lock) Release() error {
+	if lk.held != nil {
+		<-lk.held
+		return nil
+	}
+	_, err := lk.conn.ExecContext(context.Background(), lk.unlock, lk.arg)
+	if closeErr := lk.conn.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,184 @@
+// Package deadline carries a caller's end-to-end deadline through a request. The caller sends it
+// in X-Request-Deadline, as an RFC 3339 time or Unix milliseconds, or as a relative timeout in the
+// gRPC format of Grpc-Timeout. The request's context then expires at it, dependency calls get a
+// share of the time left when they start, optional search stages are skipped once too little
+// remains, and outgoing calls forward it, so the caller's latency SLA holds rather than fixed
+// internal timeouts.
+package deadline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/hotstate"
+	"refo-rag-server/internal/metrics"
+)
+
+// Headers carrying a caller's deadline
+const (
+	Header        = "X-Request-Deadline"
+	TimeoutHeader = "Grpc-Timeout"
+)
+
+// ErrInvalid is returned for a deadline header that can't be parsed
+var ErrInvalid = errors.New("invalid request deadline")
+
+// grpcTimeoutUnits are the units of a gRPC timeout
+var grpcTimeoutUnits = map[byte]time.Duration{
+	'H': time.Hour,
+	'M': time.Minute,
+	'S': time.Second,
+	'm': time.Millisecond,
+	'u': time.Microsecond,
+	'n': time.Nanosecond,
+}
+
+// Options tunes how a propagated deadline is spent
+type Options struct {
+	// OptionalStageMin is the time that must remain for optional stages, such as query
+	// transformers and rerankers, to run; below it they are skipped
+	OptionalStageMin time.Duration
+}
+
+var options hotstate.Value[Options]
+
+// Configure sets the options for all requests
+func Configure(o Options) {
+	options.Store(o)
+}
+
+// budget is the deadline a request carries and the optional stages it skipped
+type budget struct {
+	at time.Time
+
+	mu      sync.Mutex
+	skipped []string
+}
+
+type contextKey struct{}
+
+// Parse reads a caller's deadline from the X-Request-Deadline and Grpc-Timeout header values,
+// taking the earlier when both are set. It reports false when neither is
+func Parse(deadlineValue string, timeoutValue string, now time.Time) (time.Time, bool, error) {
+	var at time.Time
+	if value := strings.TrimSpace(deadlineValue); value != "" {
+		parsed, err := parseDeadline(value)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		at = parsed
+	}
+	if value := strings.TrimSpace(timeoutValue); value != "" {
+		timeout, err := parseTimeout(value)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		if expires := now.Add(timeout); at.IsZero() || expires.Before(at) {
+			at = expires
+		}
+	}
+	return at, !at.IsZero(), nil
+}
+
+// parseDeadline parses an RFC 3339 time or Unix milliseconds
+func parseDeadline(value string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil && ms > 0 {
+		return time.UnixMilli(ms), nil
+	}
+	at, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must be an RFC 3339 time or Unix milliseconds", ErrInvalid, Header)
+	}
+	return at, nil
+}
+
+// parseTimeout parses a gRPC timeout: up to 8 digits followed by a unit, e.g. 250m
+func parseTimeout(value string) (time.Duration, error) {
+	unit, ok := grpcTimeoutUnits[value[len(value)-1]]
+	digits := value[:len(value)-1]
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if !ok || err != nil || len(digits) == 0 || len(digits) > 8 || n < 0 {
+		return 0, fmt.Errorf("%w: %s must be up to 8 digits followed by H, M, S, m, u or n", ErrInvalid, TimeoutHeader)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// With returns a context that expires at the caller's deadline and carries it for budgeting
+func With(ctx context.Context, at time.Time) (context.Context, context.CancelFunc) {
+	ctx = context.WithValue(ctx, contextKey{}, &budget{at: at})
+	return context.WithDeadline(ctx, at)
+}
+
+// Detach returns a context with ctx's values but neither its cancellation nor the caller's
+// deadline, for work that must not stop halfway once it has started
+func Detach(ctx context.Context) context.Context {
+	return context.WithValue(context.WithoutCancel(ctx), contextKey{}, (*budget)(nil))
+}
+
+// budgetOf returns the caller's deadline budget a context carries, nil when it carries none or
+// was detached from it
+func budgetOf(ctx context.Context) *budget {
+	b, _ := ctx.Value(contextKey{}).(*budget)
+	return b
+}
+
+// FromContext returns the caller's deadline a context carries
+func FromContext(ctx context.Context) (time.Time, bool) {
+	if b := budgetOf(ctx); b != nil {
+		return b.at, true
+	}
+	return time.Time{}, false
+}
+
+// Remaining returns the time left until the caller's deadline a context carries; it is
+// negative once the deadline passed
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	at, ok := FromContext(ctx)
+	if !ok {
+		return 0, false
+	}
+	return time.Until(at), true
+}
+
+// Skip reports whether an optional stage should be skipped because too little time remains
+// until the caller's deadline, recording the skip when it should. Requests without a deadline
+// run every stage
+func Skip(ctx context.Context, kind string, stage string) bool {
+	b := budgetOf(ctx)
+	if b == nil || time.Until(b.at) >= options.Load().OptionalStageMin {
+		return false
+	}
+
+	b.mu.Lock()
+	b.skipped = append(b.skipped, kind+":"+stage)
+	b.mu.Unlock()
+	metrics.DeadlineSkippedStages.WithLabelValues(kind, stage).Inc()
+	return true
+}
+
+// Skipped returns the optional stages skipped so far, as kind:stage
+func Skipped(ctx context.Context) []string {
+	b := budgetOf(ctx)
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.skipped...)
+}
+
+// Expired reports whether err is the caller's deadline passing, as opposed to an internal timeout
+func Expired(ctx context.Context, err error) bool {
+	at, ok := FromContext(ctx)
+	return ok && errors.Is(err, context.DeadlineExceeded) && !time.Now().Before(at)
+}
+
+// Format renders a deadline for the X-Request-Deadline header of an outgoing call
+func Format(at time.Time) string {
+	return at.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,115 @@
+// Package egress builds the HTTP transports the server uses to reach external services such as
+// OpenAI and Qdrant, so networks that route all outbound traffic through an inspecting proxy can
+// be configured explicitly instead of relying on the process environment
+package egress
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Direct is the proxy setting that disables proxying, ignoring the environment
+const Direct = "direct"
+
+// Options configures outbound connections
+type Options struct {
+	// Proxy is the URL of the proxy for every outbound request, Direct for none, or empty to
+	// follow the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+	Proxy string
+
+	// NoProxy lists hosts reached without the configured proxy, in NO_PROXY syntax
+	NoProxy string
+
+	// CABundle is a PEM file of roots trusted in addition to the system roots, e.g. the
+	// certificate an inspecting proxy signs connections with
+	CABundle string
+}
+
+// ValidateProxy checks a proxy setting
+func ValidateProxy(proxy string) error {
+	if proxy == "" || proxy == Direct {
+		return nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid proxy URL")
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return nil
+	}
+	return fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+}
+
+// Transport returns a transport applying the options on top of tlsConfig, which may be nil
+func (o Options) Transport(tlsConfig *tls.Config) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	switch o.Proxy {
+	case "":
+		transport.Proxy = http.ProxyFromEnvironment
+	case Direct:
+		transport.Proxy = nil
+	default:
+		if err := ValidateProxy(o.Proxy); err != nil {
+			return nil, err
+		}
+		proxyFunc := (&httpproxy.Config{HTTPProxy: o.Proxy, HTTPSProxy: o.Proxy, NoProxy: o.NoProxy}).ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+
+	if tlsConfig != nil {
+		tlsConfig = tlsConfig.Clone()
+	}
+	if o.CABundle != "" {
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		roots, err := o.roots(tlsConfig.RootCAs)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = roots
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// Client returns an HTTP client using Transport
+func (o Options) Client(tlsConfig *tls.Config) (*http.Client, error) {
+	transport, err := o.Transport(tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// roots adds the CA bundle to a pool, or to the system roots when pool is nil
+func (o Options) roots(pool *x509.CertPool) (*x509.CertPool, error) {
+	if pool == nil {
+		system, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load system roots: %w", err)
+		}
+		pool = system
+	} else {
+		pool = pool.Clone()
+	}
+
+	pem, err := os.ReadFile(o.CABundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle %s: %w", o.CABundle, err)
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", o.CABundle)
+	}
+	return pool, nil
+}
@@ -0,0 +1,128 @@
+// Package embeddedup shares embeddings of identical texts within one request. A request scope is
+// attached to the request context; while it is active, embedding the same text with the same
+// provider again, or concurrently, reuses the first call's vector instead of calling the provider
+package embeddedup
+
+import (
+	"context"
+	"sync"
+
+	"refo-rag-server/internal/metrics"
+)
+
+// Sources of deduplicated embeddings, used as metric labels
+const (
+	SourceBatch   = "batch"
+	SourceRequest = "request"
+)
+
+type contextKey struct{}
+
+// scope holds the embedding calls made under one request
+type scope struct {
+	mu    sync.Mutex
+	calls map[callKey]*call
+}
+
+type callKey struct {
+	provider any
+	text     string
+}
+
+// call is an embedding in flight or done; done is closed once vector or err is set
+type call struct {
+	done   chan struct{}
+	vector []float32
+	err    error
+}
+
+// WithScope returns a context whose embedding calls are deduplicated until the request ends
+func WithScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, &scope{calls: make(map[callKey]*call)})
+}
+
+// Do returns the embedding of text by provider, calling embed only if no call in the context's
+// scope embedded it with the same provider already or is embedding it now. provider must be
+// comparable, e.g. a pointer to the provider. Without a scope it always calls embed. Failed calls
+// aren't kept, so a later call retries. The returned vector is the caller's own copy
+func Do(ctx context.Context, provider any, text string, embed func() ([]float32, error)) ([]float32, error) {
+	s, ok := ctx.Value(contextKey{}).(*scope)
+	if !ok {
+		return embed()
+	}
+
+	key := callKey{provider: provider, text: text}
+	s.mu.Lock()
+	if c, ok := s.calls[key]; ok {
+		s.mu.Unlock()
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if c.err != nil {
+			return nil, c.err
+		}
+		metrics.EmbeddingsDeduplicated.WithLabelValues(SourceRequest).Inc()
+		return clone(c.vector), nil
+	}
+	c := &call{done: make(chan struct{})}
+	s.calls[key] = c
+	s.mu.Unlock()
+
+	c.vector, c.err = embed()
+	if c.err != nil {
+		s.mu.Lock()
+		delete(s.calls, key)
+		s.mu.Unlock()
+	}
+	close(c.done)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return clone(c.vector), nil
+}
+
+// Unique returns the distinct texts in order of first appearance and, for every input text, the
+// index of its distinct text
+func Unique(texts []string) ([]string, []int) {
+	first := make(map[string]int, len(texts))
+	unique := make([]string, 0, len(texts))
+	positions := make([]int, len(texts))
+	for i, text := range texts {
+		j, seen := first[text]
+		if !seen {
+			j = len(unique)
+			first[text] = j
+			unique = append(unique, text)
+		}
+		positions[i] = j
+	}
+	if duplicates := len(texts) - len(unique); duplicates > 0 {
+		metrics.EmbeddingsDeduplicated.WithLabelValues(SourceBatch).Add(float64(duplicates))
+	}
+	return unique, positions
+}
+
+// FanOut maps the vectors of distinct texts back to every input position, copying repeated
+// vectors so no two positions share one
+func FanOut(vectors [][]float32, positions []int) [][]float32 {
+	out := make([][]float32, len(positions))
+	used := make([]bool, len(vectors))
+	for i, j := range positions {
+		if used[j] {
+			out[i] = clone(vectors[j])
+			continue
+		}
+		used[j] = true
+		out[i] = vectors[j]
+	}
+	return out
+}
+
+func clone(vector []float32) []float32 {
+	if vector == nil {
+		return nil
+	}
+	return append([]float32(nil), vector...)
+}
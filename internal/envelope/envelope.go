@@ -0,0 +1,291 @@
+// Package envelope encrypts conversation content at rest with per-tenant data keys that are
+// themselves stored wrapped by a master key. Ciphertexts name their tenant and key version, so
+// content stays readable after a data key rotation and regardless of the reading request's tenant.
+// The tenant header isn't authenticated, so only the default tenant and the configured tenants
+// get data keys of their own; content of any other tenant is encrypted with the default tenant's
+package envelope
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"refo-rag-server/internal/cache"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/tenant"
+)
+
+// prefix marks an encrypted value; values without it are stored plaintext and read as is
+const prefix = "enc:v1:"
+
+// keyCacheTTL is how long a tenant's unwrapped data keys are kept before they are reloaded, so a
+// rotation through another instance reaches new content within it
+const keyCacheTTL = time.Hour
+
+// ErrUnknownTenant is returned when rotating the data key of a tenant that isn't configured
+var ErrUnknownTenant = errors.New("tenant is not configured for encryption")
+
+// Store keeps the wrapped data keys
+type Store interface {
+	// ListDataKeys retrieves the data keys of a tenant, or of all tenants when tenant is empty,
+	// ordered by tenant and version
+	ListDataKeys(ctx context.Context, tenant string) ([]*models.DataKey, error)
+
+	// CreateDataKey stores a new data key version; it reports false if the version exists
+	CreateDataKey(ctx context.Context, key *models.DataKey) (bool, error)
+
+	// RewrapDataKey replaces a data key's wrapping if it is still wrapped by previousMasterKeyID
+	RewrapDataKey(ctx context.Context, key *models.DataKey, previousMasterKeyID string) (bool, error)
+}
+
+// tenantKeys are a tenant's unwrapped data keys by version
+type tenantKeys struct {
+	current int
+	aeads   map[int]cipher.AEAD
+}
+
+// Options configures a cipher
+type Options struct {
+	// Tenants get data keys of their own besides the default tenant
+	Tenants []string
+	// CacheSize bounds how many tenants' unwrapped data keys are kept in memory
+	CacheSize int
+}
+
+// Cipher encrypts and decrypts content with the data key of the request's tenant
+type Cipher struct {
+	store   Store
+	masters *MasterKeys
+	tenants map[string]bool
+	cache   *cache.Cache[*tenantKeys]
+}
+
+// NewCipher creates a cipher over the stored data keys
+func NewCipher(store Store, masters *MasterKeys, opts Options) *Cipher {
+	tenants := map[string]bool{tenant.DefaultTenant: true}
+	for _, tenantID := range opts.Tenants {
+		tenants[tenantID] = true
+	}
+	return &Cipher{
+		store:   store,
+		masters: masters,
+		tenants: tenants,
+		cache:   cache.New[*tenantKeys](cache.NameDataKey, opts.CacheSize, keyCacheTTL),
+	}
+}
+
+// Encrypt encrypts a value with the current data key of the context's tenant, creating the
+// tenant's first data key if needed. Tenants that aren't configured use the default tenant's
+// data key, so a client can't make the server create keys by sending new tenant IDs. Empty
+// values stay empty
+func (c *Cipher) Encrypt(ctx context.Context, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	tenantID := tenant.FromContext(ctx)
+	if !c.tenants[tenantID] {
+		tenantID = tenant.DefaultTenant
+	}
+	keys, err := c.keys(ctx, tenantID, false)
+	if err != nil {
+		return "", err
+	}
+	if keys.current == 0 {
+		if keys, err = c.create(ctx, tenantID, 1); err != nil {
+			return "", err
+		}
+	}
+
+	sealed, err := seal(keys.aeads[keys.current], []byte(plaintext), additionalData(tenantID, keys.current))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt content: %w", err)
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString([]byte(tenantID)) + ":" + strconv.Itoa(keys.current) + ":" +
+		base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value written by Encrypt; values that aren't encrypted are returned as is
+func (c *Cipher) Decrypt(ctx context.Context, value string) (string, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return value, nil
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, prefix), ":", 3)
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	tenantBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	version, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+
+	tenantID := string(tenantBytes)
+	keys, err := c.keys(ctx, tenantID, false)
+	if err != nil {
+		return "", err
+	}
+	if keys.aeads[version] == nil {
+		// Possibly rotated through another instance since the keys were cached
+		if keys, err = c.keys(ctx, tenantID, true); err != nil {
+			return "", err
+		}
+	}
+	aead := keys.aeads[version]
+	if aead == nil {
+		return "", fmt.Errorf("data key version %d of tenant %q not found", version, tenantID)
+	}
+
+	plaintext, err := open(aead, sealed, additionalData(tenantID, version))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt content: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// Rotate starts a new data key version for a tenant; new content is encrypted with it while
+// existing content stays readable with the older versions
+func (c *Cipher) Rotate(ctx context.Context, tenantID string) (*models.DataKey, error) {
+	if !c.tenants[tenantID] {
+		return nil, ErrUnknownTenant
+	}
+	keys, err := c.keys(ctx, tenantID, true)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.create(ctx, tenantID, keys.current+1); err != nil {
+		return nil, err
+	}
+
+	stored, err := c.store.ListDataKeys(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return stored[len(stored)-1], nil
+}
+
+// Rewrap wraps every data key not wrapped by the current master key with it, so retired master
+// keys can be removed from the configuration. It returns how many keys were rewrapped
+func (c *Cipher) Rewrap(ctx context.Context) (int, error) {
+	stored, err := c.store.ListDataKeys(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+
+	rewrapped := 0
+	for _, key := range stored {
+		if key.MasterKeyID == c.MasterKeyID() {
+			continue
+		}
+
+		dataKey, err := c.masters.unwrap(key.WrappedKey, key.MasterKeyID)
+		if err != nil {
+			return rewrapped, err
+		}
+		previous := key.MasterKeyID
+		if key.WrappedKey, key.MasterKeyID, err = c.masters.wrap(dataKey); err != nil {
+			return rewrapped, err
+		}
+
+		updated, err := c.store.RewrapDataKey(ctx, key, previous)
+		if err != nil {
+			return rewrapped, err
+		}
+		if updated {
+			rewrapped++
+		}
+	}
+	return rewrapped, nil
+}
+
+// MasterKeyID returns the ID of the master key new data keys are wrapped with
+func (c *Cipher) MasterKeyID() string {
+	return c.masters.current.ID()
+}
+
+// Keys lists the stored data keys and the current master key ID
+func (c *Cipher) Keys(ctx context.Context) (*models.DataKeyListResponse, error) {
+	stored, err := c.store.ListDataKeys(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return &models.DataKeyListResponse{DataKeys: stored, CurrentMasterKeyID: c.MasterKeyID()}, nil
+}
+
+// keys returns a tenant's unwrapped data keys, loading them on first use or when reload is set
+func (c *Cipher) keys(ctx context.Context, tenantID string, reload bool) (*tenantKeys, error) {
+	if !reload {
+		if keys, ok := c.cache.Get(tenantID); ok {
+			return keys, nil
+		}
+	}
+
+	stored, err := c.store.ListDataKeys(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := &tenantKeys{aeads: make(map[int]cipher.AEAD, len(stored))}
+	for _, key := range stored {
+		dataKey, err := c.masters.unwrap(key.WrappedKey, key.MasterKeyID)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := newAEAD(dataKey)
+		if err != nil {
+			return nil, err
+		}
+		keys.aeads[key.Version] = aead
+		keys.current = max(keys.current, key.Version)
+	}
+
+	// Cache only tenants that have keys so a first key created elsewhere is picked up
+	if keys.current > 0 {
+		c.cache.Set(tenantID, keys)
+	}
+	return keys, nil
+}
+
+// create stores a new random data key version for a tenant and returns the reloaded keys. Losing
+// a race to another instance creating the same version is not an error
+func (c *Cipher) create(ctx context.Context, tenantID string, version int) (*tenantKeys, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	wrapped, masterKeyID, err := c.masters.wrap(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.store.CreateDataKey(ctx, &models.DataKey{
+		Tenant:      tenantID,
+		Version:     version,
+		MasterKeyID: masterKeyID,
+		CreatedAt:   time.Now(),
+		WrappedKey:  wrapped,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.keys(ctx, tenantID, true)
+}
+
+// additionalData binds a ciphertext to its tenant and key version
+func additionalData(tenantID string, version int) []byte {
+	return []byte(tenantID + "\n" + strconv.Itoa(version))
+}
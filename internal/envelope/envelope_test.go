@@ -0,0 +1,105 @@
+package envelope
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/tenant"
+)
+
+// memoryStore keeps data keys in memory
+type memoryStore struct {
+	mu   sync.Mutex
+	keys []*models.DataKey
+}
+
+func (s *memoryStore) ListDataKeys(ctx context.Context, tenantID string) ([]*models.DataKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*models.DataKey
+	for _, key := range s.keys {
+		if tenantID == "" || key.Tenant == tenantID {
+			copied := *key
+			out = append(out, &copied)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Tenant != out[j].Tenant {
+			return out[i].Tenant < out[j].Tenant
+		}
+		return out[i].Version < out[j].Version
+	})
+	return out, nil
+}
+
+func (s *memoryStore) CreateDataKey(ctx context.Context, key *models.DataKey) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.keys {
+		if existing.Tenant == key.Tenant && existing.Version == key.Version {
+			return false, nil
+		}
+	}
+	s.keys = append(s.keys, key)
+	return true, nil
+}
+
+func (s *memoryStore) RewrapDataKey(ctx context.Context, key *models.DataKey, previousMasterKeyID string) (bool, error) {
+	return false, nil
+}
+
+func newTestCipher(t *testing.T, store Store, tenants ...string) *Cipher {
+	t.Helper()
+	masters, err := ParseMasterKeys([]string{"k1:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewCipher(store, masters, Options{Tenants: tenants, CacheSize: 2})
+}
+
+func TestEncryptCreatesKeysOnlyForConfiguredTenants(t *testing.T) {
+	store := &memoryStore{}
+	c := newTestCipher(t, store, "acme")
+
+	for _, tenantID := range []string{"acme", "spoofed-1", "spoofed-2", tenant.DefaultTenant} {
+		ctx := tenant.WithTenant(context.Background(), tenantID)
+		value, err := c.Encrypt(ctx, "secret of "+tenantID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Any tenant reads the content back, whichever key encrypted it
+		plaintext, err := c.Decrypt(context.Background(), value)
+		if err != nil || plaintext != "secret of "+tenantID {
+			t.Fatalf("Decrypt(Encrypt(%q)) = %q, %v", tenantID, plaintext, err)
+		}
+	}
+
+	stored, _ := store.ListDataKeys(context.Background(), "")
+	var tenants []string
+	for _, key := range stored {
+		tenants = append(tenants, key.Tenant)
+	}
+	if len(tenants) != 2 || tenants[0] != "acme" || tenants[1] != tenant.DefaultTenant {
+		t.Fatalf("data keys were created for %v, want acme and %s only", tenants, tenant.DefaultTenant)
+	}
+
+	if _, err := c.Rotate(context.Background(), "spoofed-1"); err != ErrUnknownTenant {
+		t.Fatalf("Rotate of an unconfigured tenant returned %v, want ErrUnknownTenant", err)
+	}
+}
+
+func TestKeyCacheIsBounded(t *testing.T) {
+	c := newTestCipher(t, &memoryStore{}, "a", "b", "c")
+
+	for _, tenantID := range []string{"a", "b", "c"} {
+		if _, err := c.Encrypt(tenant.WithTenant(context.Background(), tenantID), "content"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if entries := c.cache.Stats().Entries; entries != 2 {
+		t.Fatalf("key cache holds %d tenants, want its capacity of 2", entries)
+	}
+}
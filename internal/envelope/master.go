@@ -0,0 +1,144 @@
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// MasterKey wraps and unwraps tenant data keys. It stands in for a KMS key: an implementation
+// backed by a KMS calls its encrypt and decrypt APIs instead of holding key material
+type MasterKey interface {
+	ID() string
+	Wrap(dataKey []byte) ([]byte, error)
+	Unwrap(wrapped []byte) ([]byte, error)
+}
+
+// MasterKeys is the current master key and the retired ones still needed to unwrap data keys
+// wrapped before a rotation
+type MasterKeys struct {
+	current MasterKey
+	byID    map[string]MasterKey
+}
+
+// NewMasterKeys creates a master key set; the first key is current
+func NewMasterKeys(keys ...MasterKey) (*MasterKeys, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one master key is required")
+	}
+
+	byID := make(map[string]MasterKey, len(keys))
+	for _, key := range keys {
+		if _, exists := byID[key.ID()]; exists {
+			return nil, fmt.Errorf("master key %q listed twice", key.ID())
+		}
+		byID[key.ID()] = key
+	}
+	return &MasterKeys{current: keys[0], byID: byID}, nil
+}
+
+// ParseMasterKeys parses "key_id:base64" entries of 32-byte local master keys, current first
+func ParseMasterKeys(entries []string) (*MasterKeys, error) {
+	keys := make([]MasterKey, 0, len(entries))
+	for _, entry := range entries {
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid master key entry, expected \"key_id:base64\"")
+		}
+		material, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("master key %q is not valid base64", id)
+		}
+		key, err := NewLocalMasterKey(id, material)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return NewMasterKeys(keys...)
+}
+
+// wrap wraps a data key with the current master key and returns the ID it was wrapped with
+func (m *MasterKeys) wrap(dataKey []byte) ([]byte, string, error) {
+	wrapped, err := m.current.Wrap(dataKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to wrap data key with %q: %w", m.current.ID(), err)
+	}
+	return wrapped, m.current.ID(), nil
+}
+
+// unwrap unwraps a data key with the master key it was wrapped with
+func (m *MasterKeys) unwrap(wrapped []byte, masterKeyID string) ([]byte, error) {
+	key, ok := m.byID[masterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %q is not configured", masterKeyID)
+	}
+	dataKey, err := key.Unwrap(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key with %q: %w", masterKeyID, err)
+	}
+	return dataKey, nil
+}
+
+// LocalMasterKey is an AES-256-GCM master key held in process memory
+type LocalMasterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewLocalMasterKey creates a master key from 32 bytes of key material
+func NewLocalMasterKey(id string, material []byte) (*LocalMasterKey, error) {
+	if len(material) != 32 {
+		return nil, fmt.Errorf("master key %q must be 32 bytes, got %d", id, len(material))
+	}
+	aead, err := newAEAD(material)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalMasterKey{id: id, aead: aead}, nil
+}
+
+// ID returns the key's identifier, recorded with every data key it wraps
+func (k *LocalMasterKey) ID() string {
+	return k.id
+}
+
+// Wrap encrypts a data key
+func (k *LocalMasterKey) Wrap(dataKey []byte) ([]byte, error) {
+	return seal(k.aead, dataKey, []byte(k.id))
+}
+
+// Unwrap decrypts a data key wrapped by this master key
+func (k *LocalMasterKey) Unwrap(wrapped []byte) ([]byte, error) {
+	return open(k.aead, wrapped, []byte(k.id))
+}
+
+// newAEAD creates an AES-GCM cipher
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext under a random nonce, which is prepended to the result
+func seal(aead cipher.AEAD, plaintext []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts the output of seal
+func open(aead cipher.AEAD, sealed []byte, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -0,0 +1,104 @@
+// Package erasure signs the deletion certificates issued after purges, which institutional
+// customers keep as proof that data was erased. A certificate is signed with Ed25519 over its
+// JSON encoding with the signature left out, so anyone holding the public key can verify it
+// without the ability to forge one
+package erasure
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"refo-rag-server/internal/models"
+)
+
+// Algorithm names the signature scheme of certificates
+const Algorithm = "Ed25519"
+
+var (
+	ErrUnknownKey       = errors.New("certificate was signed with a different key")
+	ErrInvalidSignature = errors.New("certificate signature does not match")
+)
+
+// Signer signs deletion certificates with a private key
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+// ParseKey creates a signer from the base64 encoding of a 32-byte Ed25519 seed
+func ParseKey(encoded string) (*Signer, error) {
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("deletion certificate key is not base64: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("deletion certificate key must be a %d-byte Ed25519 seed, got %d bytes", ed25519.SeedSize, len(seed))
+	}
+	return NewSigner(ed25519.NewKeyFromSeed(seed)), nil
+}
+
+// NewSigner creates a signer for a private key
+func NewSigner(key ed25519.PrivateKey) *Signer {
+	return &Signer{key: key, keyID: KeyID(key.Public().(ed25519.PublicKey))}
+}
+
+// KeyID identifies a public key by the first 8 bytes of its SHA-256, in hex
+func KeyID(public ed25519.PublicKey) string {
+	sum := sha256.Sum256(public)
+	return hex.EncodeToString(sum[:8])
+}
+
+// PublicKey returns the key certificates signed by s are verified with
+func (s *Signer) PublicKey() *models.DeletionCertificateKey {
+	return &models.DeletionCertificateKey{
+		KeyID:     s.keyID,
+		Algorithm: Algorithm,
+		PublicKey: base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey)),
+	}
+}
+
+// Sign sets the certificate's key ID and signature
+func (s *Signer) Sign(cert *models.DeletionCertificate) error {
+	cert.KeyID = s.keyID
+	payload, err := Payload(cert)
+	if err != nil {
+		return err
+	}
+	cert.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, payload))
+	return nil
+}
+
+// Verify checks that a certificate was signed with public and hasn't changed since
+func Verify(cert *models.DeletionCertificate, public ed25519.PublicKey) error {
+	if cert.KeyID != KeyID(public) {
+		return ErrUnknownKey
+	}
+	signature, err := base64.StdEncoding.DecodeString(cert.Signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	payload, err := Payload(cert)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(public, payload, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Payload returns the signed bytes of a certificate: its JSON encoding without the signature
+func Payload(cert *models.DeletionCertificate) ([]byte, error) {
+	unsigned := *cert
+	unsigned.Signature = ""
+	payload, err := json.Marshal(&unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode deletion certificate: %w", err)
+	}
+	return payload, nil
+}
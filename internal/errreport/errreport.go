@@ -0,0 +1,73 @@
+package errreport
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"refo-rag-server/internal/hotstate"
+)
+
+// Event carries the context attached to a reported error
+type Event struct {
+	// Tags are low-cardinality labels such as route, method, or worker name
+	Tags map[string]string
+
+	// Extra holds free-form diagnostic data such as a truncated response body
+	Extra map[string]interface{}
+}
+
+// Reporter sends errors and panics to an external error tracker
+type Reporter interface {
+	// CaptureError reports an error
+	CaptureError(ctx context.Context, err error, event Event)
+
+	// CapturePanic reports a recovered panic with its stack trace
+	CapturePanic(ctx context.Context, recovered interface{}, stack []byte, event Event)
+
+	// Flush waits for buffered events to be delivered
+	Flush(timeout time.Duration) bool
+}
+
+// LogReporter writes reports to the standard logger; it is the default
+type LogReporter struct{}
+
+// CaptureError logs the error with its tags
+func (LogReporter) CaptureError(ctx context.Context, err error, event Event) {
+	log.Printf("error: %v tags=%v", err, event.Tags)
+}
+
+// CapturePanic logs the panic with its stack trace
+func (LogReporter) CapturePanic(ctx context.Context, recovered interface{}, stack []byte, event Event) {
+	log.Printf("panic: %v tags=%v\n%s", recovered, event.Tags, stack)
+}
+
+// Flush is a no-op for the log reporter
+func (LogReporter) Flush(timeout time.Duration) bool {
+	return true
+}
+
+var reporter = hotstate.New[Reporter](LogReporter{})
+
+// SetDefault replaces the process-wide reporter
+func SetDefault(r Reporter) {
+	reporter.Store(r)
+}
+
+// Default returns the process-wide reporter
+func Default() Reporter {
+	return reporter.Load()
+}
+
+// Capture reports an error through the default reporter
+func Capture(ctx context.Context, err error, event Event) {
+	Default().CaptureError(ctx, err, event)
+}
+
+// Background reports a failure from a background worker or asynchronous side effect
+func Background(ctx context.Context, worker string, err error) {
+	Capture(ctx, fmt.Errorf("%s: %w", worker, err), Event{
+		Tags: map[string]string{"worker": worker},
+	})
+}
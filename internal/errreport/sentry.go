@@ -0,0 +1,72 @@
+package errreport
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"refo-rag-server/internal/tenant"
+)
+
+// SentryOptions configures the Sentry reporter
+type SentryOptions struct {
+	DSN         string
+	Environment string
+	Release     string
+	SampleRate  float64
+}
+
+// SentryReporter reports errors and panics to Sentry
+type SentryReporter struct{}
+
+// NewSentryReporter initializes the Sentry SDK
+func NewSentryReporter(opts SentryOptions) (*SentryReporter, error) {
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:         opts.DSN,
+		Environment: opts.Environment,
+		Release:     opts.Release,
+		SampleRate:  opts.SampleRate,
+		// Request bodies and user data are attached explicitly, never by default
+		SendDefaultPII: false,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize sentry: %w", err)
+	}
+	return &SentryReporter{}, nil
+}
+
+// CaptureError sends an error event to Sentry
+func (sr *SentryReporter) CaptureError(ctx context.Context, err error, event Event) {
+	sentry.WithScope(func(scope *sentry.Scope) {
+		applyEvent(ctx, scope, event)
+		sentry.CaptureException(err)
+	})
+}
+
+// CapturePanic sends a fatal-level event for a recovered panic
+func (sr *SentryReporter) CapturePanic(ctx context.Context, recovered interface{}, stack []byte, event Event) {
+	sentry.WithScope(func(scope *sentry.Scope) {
+		applyEvent(ctx, scope, event)
+		scope.SetLevel(sentry.LevelFatal)
+		scope.SetExtra("stack", string(stack))
+		sentry.CurrentHub().Recover(recovered)
+	})
+}
+
+// Flush waits for queued events to be sent
+func (sr *SentryReporter) Flush(timeout time.Duration) bool {
+	return sentry.Flush(timeout)
+}
+
+// applyEvent copies the event's tags, extras, and tenant onto the Sentry scope
+func applyEvent(ctx context.Context, scope *sentry.Scope, event Event) {
+	scope.SetTag("tenant", tenant.FromContext(ctx))
+	for key, value := range event.Tags {
+		scope.SetTag(key, value)
+	}
+	for key, value := range event.Extra {
+		scope.SetExtra(key, value)
+	}
+}
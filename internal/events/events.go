@@ -0,0 +1,220 @@
+// Package events decouples side effects from the request path. Services publish what happened,
+// such as a saved conversation, and subscribers such as shadow indexing or session summaries
+// react in the background. The in-process Bus delivers events to subscribers registered at
+// startup; the Publisher interface is what services depend on, so an external broker can replace
+// it without touching them
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+)
+
+// Topics
+const (
+	// ConversationSaved is published with a ConversationSavedEvent once a conversation is stored
+	ConversationSaved = "conversation.saved"
+)
+
+// ConversationSavedEvent is the payload of ConversationSaved. Subscribers must not modify it
+type ConversationSavedEvent struct {
+	Conversation *models.Conversation
+
+	// EmbeddedText is the text the conversation's vector was created from; empty if it has none
+	EmbeddedText string
+
+	// Vector is the conversation's document embedding; nil if it has none
+	Vector []float32
+
+	// VectorPayload is the payload stored with the vector
+	VectorPayload map[string]interface{}
+}
+
+// Delivery outcomes, used as metric labels
+const (
+	outcomeDelivered = "delivered"
+	outcomeFailed    = "failed"
+	outcomeDropped   = "dropped"
+	outcomePanicked  = "panicked"
+)
+
+// ErrClosed is returned by Close when called twice
+var ErrClosed = errors.New("event bus is closed")
+
+// Event is a published fact about something that already happened
+type Event struct {
+	Topic   string
+	Time    time.Time
+	Payload any
+}
+
+// Handler reacts to an event. It runs in the background with the publishing request's values,
+// such as the tenant and request ID, but not its cancellation
+type Handler func(ctx context.Context, event Event) error
+
+// Publisher publishes events; publishing never blocks or fails the caller
+type Publisher interface {
+	Publish(ctx context.Context, topic string, payload any)
+}
+
+// Options configures a Bus
+type Options struct {
+	// QueueSize bounds the events waiting for each subscriber; further events are dropped and
+	// counted until it catches up
+	QueueSize int
+
+	// Workers is the number of events each subscriber handles concurrently
+	Workers int
+
+	// HandlerTimeout bounds one handler call; zero means no limit
+	HandlerTimeout time.Duration
+}
+
+// Bus delivers events in process. Subscribe at startup, before events are published
+type Bus struct {
+	opts Options
+
+	mu            sync.RWMutex
+	subscriptions map[string][]*subscription
+	closed        bool
+	wg            sync.WaitGroup
+}
+
+// subscription is one subscriber's queue of a topic
+type subscription struct {
+	topic   string
+	name    string
+	handler Handler
+	queue   chan delivery
+}
+
+// delivery is an event queued for a subscriber with the context it was published under
+type delivery struct {
+	ctx   context.Context
+	event Event
+}
+
+// NewBus creates an in-process bus
+func NewBus(opts Options) *Bus {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	return &Bus{opts: opts, subscriptions: make(map[string][]*subscription)}
+}
+
+// Subscribe registers a handler for a topic under a name used in logs and metrics, and starts
+// its workers
+func (b *Bus) Subscribe(topic string, name string, handler Handler) {
+	sub := &subscription{
+		topic:   topic,
+		name:    name,
+		handler: handler,
+		queue:   make(chan delivery, b.opts.QueueSize),
+	}
+
+	b.mu.Lock()
+	b.subscriptions[topic] = append(b.subscriptions[topic], sub)
+	b.mu.Unlock()
+
+	for i := 0; i < b.opts.Workers; i++ {
+		b.wg.Add(1)
+		go b.work(sub)
+	}
+}
+
+// Publish queues an event for every subscriber of its topic. A subscriber whose queue is full
+// misses the event; publishing after Close is a no-op
+func (b *Bus) Publish(ctx context.Context, topic string, payload any) {
+	event := Event{Topic: topic, Time: time.Now(), Payload: payload}
+	ctx = context.WithoutCancel(ctx)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+	metrics.EventsPublished.WithLabelValues(topic).Inc()
+
+	for _, sub := range b.subscriptions[topic] {
+		select {
+		case sub.queue <- delivery{ctx: ctx, event: event}:
+		default:
+			metrics.EventDeliveries.WithLabelValues(topic, sub.name, outcomeDropped).Inc()
+			fmt.Printf("warning: event subscriber %s is behind, dropped %s event\n", sub.name, topic)
+		}
+	}
+}
+
+// Close stops accepting events and waits until the queued ones are handled or ctx is done
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	b.closed = true
+	for _, subs := range b.subscriptions {
+		for _, sub := range subs {
+			close(sub.queue)
+		}
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("event handlers did not finish: %w", ctx.Err())
+	}
+}
+
+// work handles a subscriber's events until its queue is closed
+func (b *Bus) work(sub *subscription) {
+	defer b.wg.Done()
+	for d := range sub.queue {
+		b.handle(sub, d)
+	}
+}
+
+// handle runs one handler call, counting its outcome and reporting failures and panics
+func (b *Bus) handle(sub *subscription, d delivery) {
+	ctx := d.ctx
+	if b.opts.HandlerTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.opts.HandlerTimeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			metrics.EventDeliveries.WithLabelValues(sub.topic, sub.name, outcomePanicked).Inc()
+			errreport.Default().CapturePanic(ctx, recovered, debug.Stack(), errreport.Event{
+				Tags: map[string]string{"event_topic": sub.topic, "event_subscriber": sub.name},
+			})
+		}
+	}()
+
+	if err := sub.handler(ctx, d.event); err != nil {
+		metrics.EventDeliveries.WithLabelValues(sub.topic, sub.name, outcomeFailed).Inc()
+		fmt.Printf("warning: event subscriber %s failed to handle %s: %v\n", sub.name, sub.topic, err)
+		errreport.Background(ctx, "event_"+sub.name, err)
+		return
+	}
+	metrics.EventDeliveries.WithLabelValues(sub.topic, sub.name, outcomeDelivered).Inc()
+}
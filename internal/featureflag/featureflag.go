@@ -0,0 +1,136 @@
+package featureflag
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"refo-rag-server/internal/hotstate"
+)
+
+// Retrieval features that can be rolled out gradually
+const (
+	HybridSearch = "hybrid_search"
+	Reranking    = "reranking"
+	Chunking     = "chunking"
+)
+
+// Flag is a feature flag with a global default and per-tenant overrides
+type Flag struct {
+	Enabled     bool            `json:"enabled"`
+	Description string          `json:"description,omitempty"`
+	Tenants     map[string]bool `json:"tenants,omitempty"`
+}
+
+// fileFormat is the on-disk JSON layout of the flags file
+type fileFormat struct {
+	Flags map[string]Flag `json:"flags"`
+}
+
+// Store holds feature flags loaded from defaults and an optional JSON file
+type Store struct {
+	path     string
+	defaults map[string]Flag
+	loaded   hotstate.Value[loadedFlags]
+}
+
+// loadedFlags is the flags of one load; it is never modified once stored
+type loadedFlags struct {
+	flags    map[string]Flag
+	loadedAt time.Time
+}
+
+// DefaultFlags returns the built-in flags, all disabled
+func DefaultFlags() map[string]Flag {
+	return map[string]Flag{
+		HybridSearch: {Description: "combine dense vector search with keyword search"},
+		Reranking:    {Description: "rerank retrieved candidates before returning them"},
+		Chunking:     {Description: "split long conversations into separately embedded chunks"},
+	}
+}
+
+// NewStore creates a flag store and loads the flags file when a path is given
+func NewStore(path string, defaults map[string]Flag) (*Store, error) {
+	s := &Store{
+		path:     path,
+		defaults: defaults,
+	}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload re-reads the flags file, replacing the current flags atomically
+// On error the previously loaded flags stay in effect.
+func (s *Store) Reload() error {
+	flags := make(map[string]Flag, len(s.defaults))
+	for name, flag := range s.defaults {
+		flags[name] = flag
+	}
+
+	if s.path != "" {
+		data, err := os.ReadFile(s.path)
+		if err != nil {
+			return fmt.Errorf("failed to read feature flags file: %w", err)
+		}
+
+		var file fileFormat
+		if err := json.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("failed to parse feature flags file: %w", err)
+		}
+
+		for name, flag := range file.Flags {
+			if flag.Description == "" {
+				flag.Description = flags[name].Description
+			}
+			flags[name] = flag
+		}
+	}
+
+	s.loaded.Store(loadedFlags{flags: flags, loadedAt: time.Now()})
+	return nil
+}
+
+// Enabled reports whether a flag is on for the tenant
+// Tenant overrides take precedence over the global default; unknown flags are off.
+func (s *Store) Enabled(name string, tenantID string) bool {
+	return s.loaded.Load().enabled(name, tenantID)
+}
+
+// EnabledFor returns the sorted names of all flags enabled for the tenant, all from one load
+func (s *Store) EnabledFor(tenantID string) []string {
+	loaded := s.loaded.Load()
+	enabled := make([]string, 0, len(loaded.flags))
+	for name := range loaded.flags {
+		if loaded.enabled(name, tenantID) {
+			enabled = append(enabled, name)
+		}
+	}
+	sort.Strings(enabled)
+	return enabled
+}
+
+// Snapshot returns a copy of all flags and the time they were loaded
+func (s *Store) Snapshot() (map[string]Flag, time.Time) {
+	loaded := s.loaded.Load()
+	flags := make(map[string]Flag, len(loaded.flags))
+	for name, flag := range loaded.flags {
+		flags[name] = flag
+	}
+	return flags, loaded.loadedAt
+}
+
+// enabled reports whether a flag of this load is on for the tenant
+func (l loadedFlags) enabled(name string, tenantID string) bool {
+	flag, ok := l.flags[name]
+	if !ok {
+		return false
+	}
+	if enabled, ok := flag.Tenants[tenantID]; ok {
+		return enabled
+	}
+	return flag.Enabled
+}
@@ -0,0 +1,133 @@
+// Package filter implements the metadata filter DSL used to narrow searches.
+//
+// Filters are boolean expressions over metadata fields:
+//
+//	source = "slack" AND priority >= 3
+//	tags IN ("billing", "refund") OR NOT archived = true
+//	due < "2024-06-01T00:00:00Z" AND EXISTS assignee
+//
+// Field names are dotted identifiers, string values are double-quoted, and
+// AND binds tighter than OR.
+package filter
+
+import (
+	"fmt"
+)
+
+// Operators supported in comparisons
+const (
+	OpEq     = "="
+	OpNe     = "!="
+	OpGt     = ">"
+	OpGte    = ">="
+	OpLt     = "<"
+	OpLte    = "<="
+	OpIn     = "IN"
+	OpExists = "EXISTS"
+)
+
+// Kinds of expression nodes
+const (
+	KindAnd        = "and"
+	KindOr         = "or"
+	KindNot        = "not"
+	KindComparison = "comparison"
+)
+
+// Limits that keep filters cheap to parse and evaluate
+const (
+	MaxFilterLength = 4096
+	MaxConditions   = 64
+	MaxInValues     = 100
+)
+
+// Expr is a node of a parsed filter expression
+type Expr struct {
+	Kind     string
+	Children []*Expr
+
+	// Comparison fields
+	Field  string
+	Op     string
+	Value  interface{}
+	Values []interface{}
+}
+
+// Fields returns the distinct field names referenced by the expression
+func (e *Expr) Fields() []string {
+	seen := make(map[string]bool)
+	var fields []string
+	e.walk(func(node *Expr) {
+		if node.Kind == KindComparison && !seen[node.Field] {
+			seen[node.Field] = true
+			fields = append(fields, node.Field)
+		}
+	})
+	return fields
+}
+
+// walk visits every node in the expression
+func (e *Expr) walk(visit func(*Expr)) {
+	visit(e)
+	for _, child := range e.Children {
+		child.walk(visit)
+	}
+}
+
+// And combines expressions with AND, skipping nil entries
+func And(exprs ...*Expr) *Expr {
+	var children []*Expr
+	for _, expr := range exprs {
+		if expr != nil {
+			children = append(children, expr)
+		}
+	}
+	switch len(children) {
+	case 0:
+		return nil
+	case 1:
+		return children[0]
+	}
+	return &Expr{Kind: KindAnd, Children: children}
+}
+
+// Eq builds an equality comparison
+func Eq(field string, value interface{}) *Expr {
+	return &Expr{Kind: KindComparison, Field: field, Op: OpEq, Value: value}
+}
+
+// Parse parses a filter expression. An empty string yields a nil expression.
+func Parse(input string) (*Expr, error) {
+	if len(input) > MaxFilterLength {
+		return nil, fmt.Errorf("filter exceeds %d characters", MaxFilterLength)
+	}
+
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	p := &parser{tokens: tokens, end: len([]rune(input))}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.peek().text, p.peek().pos)
+	}
+
+	conditions := 0
+	expr.walk(func(node *Expr) {
+		if node.Kind == KindComparison {
+			conditions++
+		}
+	})
+	if conditions > MaxConditions {
+		return nil, fmt.Errorf("filter has %d conditions, at most %d are allowed", conditions, MaxConditions)
+	}
+
+	return expr, nil
+}
@@ -0,0 +1,156 @@
+package filter
+
+import (
+	"time"
+)
+
+// Match evaluates the expression against a record's fields the way Qdrant evaluates the
+// translated filter against a payload, so records selected outside of a vector search agree
+// with what the same filter finds in one. A list field matches when any of its items does, a
+// missing field fails every comparison but NOT and !=, and strings are ordered as RFC 3339
+// datetimes. A nil expression matches everything.
+func (e *Expr) Match(fields map[string]interface{}) bool {
+	if e == nil {
+		return true
+	}
+
+	switch e.Kind {
+	case KindAnd:
+		for _, child := range e.Children {
+			if !child.Match(fields) {
+				return false
+			}
+		}
+		return true
+	case KindOr:
+		for _, child := range e.Children {
+			if child.Match(fields) {
+				return true
+			}
+		}
+		return false
+	case KindNot:
+		for _, child := range e.Children {
+			if child.Match(fields) {
+				return false
+			}
+		}
+		return true
+	}
+
+	value, ok := fields[e.Field]
+	switch e.Op {
+	case OpEq:
+		return ok && anyItem(value, func(item interface{}) bool { return equal(item, e.Value) })
+	case OpNe:
+		return !ok || !anyItem(value, func(item interface{}) bool { return equal(item, e.Value) })
+	case OpIn:
+		return ok && anyItem(value, func(item interface{}) bool {
+			for _, want := range e.Values {
+				if equal(item, want) {
+					return true
+				}
+			}
+			return false
+		})
+	case OpExists:
+		return ok && !isEmpty(value)
+	}
+
+	return ok && anyItem(value, func(item interface{}) bool {
+		cmp, comparable := compare(item, e.Value)
+		if !comparable {
+			return false
+		}
+		switch e.Op {
+		case OpGt:
+			return cmp > 0
+		case OpGte:
+			return cmp >= 0
+		case OpLt:
+			return cmp < 0
+		default:
+			return cmp <= 0
+		}
+	})
+}
+
+// anyItem reports whether match holds for a scalar value or any item of a list
+func anyItem(value interface{}, match func(interface{}) bool) bool {
+	if items, ok := value.([]interface{}); ok {
+		for _, item := range items {
+			if match(item) {
+				return true
+			}
+		}
+		return false
+	}
+	return match(value)
+}
+
+// isEmpty reports whether a field value counts as absent: null or an empty list
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	items, ok := value.([]interface{})
+	return ok && len(items) == 0
+}
+
+// equal compares a field value with a filter literal; numbers compare by value whatever their type
+func equal(value interface{}, literal interface{}) bool {
+	if a, ok := number(value); ok {
+		b, ok := number(literal)
+		return ok && a == b
+	}
+	return value == literal
+}
+
+// compare orders a field value against a filter literal: numbers by value and strings as
+// datetimes. It reports false if the two can't be ordered
+func compare(value interface{}, literal interface{}) (int, bool) {
+	if a, ok := number(value); ok {
+		b, ok := number(literal)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case a < b:
+			return -1, true
+		case a > b:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return 0, false
+	}
+	bound, ok := literal.(string)
+	if !ok {
+		return 0, false
+	}
+	a, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, false
+	}
+	b, err := time.Parse(time.RFC3339, bound)
+	if err != nil {
+		return 0, false
+	}
+	return a.Compare(b), true
+}
+
+// number converts the numeric types of parsed literals and decoded JSON to float64
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
@@ -0,0 +1,285 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Token types
+const (
+	tokIdent = iota
+	tokString
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	typ  int
+	text string
+	pos  int
+}
+
+// tokenize splits a filter expression into tokens
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case r == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			tokens = append(tokens, token{tokString, sb.String(), start})
+		case r == '=' || r == '!' || r == '<' || r == '>':
+			start := i
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				op += "="
+				i++
+			}
+			i++
+			if op == "!" {
+				return nil, fmt.Errorf("unexpected '!' at position %d", start)
+			}
+			tokens = append(tokens, token{tokOp, op, start})
+		case r == '-' || unicode.IsDigit(r):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E' ||
+				((runes[i] == '-' || runes[i] == '+') && (runes[i-1] == 'e' || runes[i-1] == 'E'))) {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, string(runes[start:i]), start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, string(runes[start:i]), start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	end    int // Input length, reported as the position of the end of the filter
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{typ: -1, text: "end of filter", pos: p.end}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+// keyword reports whether the next token is the given case-insensitive keyword
+func (p *parser) keyword(word string) bool {
+	tok := p.peek()
+	return tok.typ == tokIdent && strings.EqualFold(tok.text, word)
+}
+
+func (p *parser) parseOr() (*Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	children := []*Expr{left}
+	for p.keyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, right)
+	}
+	if len(children) == 1 {
+		return left, nil
+	}
+	return &Expr{Kind: KindOr, Children: children}, nil
+}
+
+func (p *parser) parseAnd() (*Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	children := []*Expr{left}
+	for p.keyword("AND") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, right)
+	}
+	if len(children) == 1 {
+		return left, nil
+	}
+	return &Expr{Kind: KindAnd, Children: children}, nil
+}
+
+func (p *parser) parseNot() (*Expr, error) {
+	if p.keyword("NOT") {
+		p.next()
+		child, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &Expr{Kind: KindNot, Children: []*Expr{child}}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (*Expr, error) {
+	tok := p.peek()
+	if tok.typ == tokLParen {
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().typ != tokRParen {
+			return nil, fmt.Errorf("missing closing parenthesis for position %d", tok.pos)
+		}
+		return expr, nil
+	}
+
+	if p.keyword("EXISTS") {
+		p.next()
+		field := p.next()
+		if field.typ != tokIdent {
+			return nil, fmt.Errorf("expected field name after EXISTS at position %d", field.pos)
+		}
+		return &Expr{Kind: KindComparison, Field: field.text, Op: OpExists}, nil
+	}
+
+	field := p.next()
+	if field.typ != tokIdent || isKeyword(field.text) {
+		return nil, fmt.Errorf("expected field name at position %d, got %q", field.pos, field.text)
+	}
+
+	if p.keyword("IN") {
+		p.next()
+		if p.next().typ != tokLParen {
+			return nil, fmt.Errorf("expected '(' after IN for field %q", field.text)
+		}
+		var values []interface{}
+		for {
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			sep := p.next()
+			if sep.typ == tokRParen {
+				break
+			}
+			if sep.typ != tokComma {
+				return nil, fmt.Errorf("expected ',' or ')' in IN list for field %q", field.text)
+			}
+		}
+		if len(values) > MaxInValues {
+			return nil, fmt.Errorf("IN list for field %q has more than %d values", field.text, MaxInValues)
+		}
+		return &Expr{Kind: KindComparison, Field: field.text, Op: OpIn, Values: values}, nil
+	}
+
+	op := p.next()
+	if op.typ != tokOp {
+		return nil, fmt.Errorf("expected operator after field %q", field.text)
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if op.text != OpEq && op.text != OpNe {
+		if _, isBool := value.(bool); isBool {
+			return nil, fmt.Errorf("operator %s cannot compare booleans", op.text)
+		}
+	}
+	return &Expr{Kind: KindComparison, Field: field.text, Op: op.text, Value: value}, nil
+}
+
+// parseValue parses a string, number, or boolean literal
+func (p *parser) parseValue() (interface{}, error) {
+	tok := p.next()
+	switch tok.typ {
+	case tokString:
+		return tok.text, nil
+	case tokNumber:
+		if n, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+			return n, nil
+		}
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos)
+		}
+		return f, nil
+	case tokIdent:
+		switch strings.ToLower(tok.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return nil, fmt.Errorf("expected value at position %d, got %q", tok.pos, tok.text)
+}
+
+// isKeyword reports whether an identifier is a reserved word
+func isKeyword(text string) bool {
+	switch strings.ToUpper(text) {
+	case "AND", "OR", "NOT", "IN", "EXISTS":
+		return true
+	}
+	return false
+}
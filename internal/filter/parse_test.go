@@ -0,0 +1,177 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// render writes an expression as an s-expression, so parse results compare as strings
+func render(expr *Expr) string {
+	if expr == nil {
+		return "<nil>"
+	}
+	if expr.Kind != KindComparison {
+		parts := []string{expr.Kind}
+		for _, child := range expr.Children {
+			parts = append(parts, render(child))
+		}
+		return "(" + strings.Join(parts, " ") + ")"
+	}
+	switch expr.Op {
+	case OpExists:
+		return "(EXISTS " + expr.Field + ")"
+	case OpIn:
+		values := make([]string, 0, len(expr.Values))
+		for _, value := range expr.Values {
+			values = append(values, renderValue(value))
+		}
+		return "(IN " + expr.Field + " " + strings.Join(values, " ") + ")"
+	}
+	return "(" + expr.Op + " " + expr.Field + " " + renderValue(expr.Value) + ")"
+}
+
+// renderValue writes a literal so that its type shows: strings quoted, floats with a point
+func renderValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	}
+	return fmt.Sprint(value)
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{``, `<nil>`},
+		{"  \t\n", `<nil>`},
+		{`source = "slack"`, `(= source "slack")`},
+		{`source != "slack"`, `(!= source "slack")`},
+		{`priority >= 3`, `(>= priority 3)`},
+		{`priority > 3 AND priority <= 5 AND priority < 6`, `(and (> priority 3) (<= priority 5) (< priority 6))`},
+		{`score = 0.5`, `(= score 0.5)`},
+		{`score = -2`, `(= score -2)`},
+		{`score = 1.5e3`, `(= score 1500.0)`},
+		{`score = -2E-2`, `(= score -0.02)`},
+		{`archived = true`, `(= archived true)`},
+		{`archived != FALSE`, `(!= archived false)`},
+		{`due < "2024-06-01T00:00:00Z"`, `(< due "2024-06-01T00:00:00Z")`},
+		{`team.lead.name = "ana"`, `(= team.lead.name "ana")`},
+		{`größe = "groß"`, `(= größe "groß")`},
+		{`and_or = 1`, `(= and_or 1)`},
+
+		// AND binds tighter than OR, NOT tighter than both
+		{`a = 1 OR b = 2 AND c = 3`, `(or (= a 1) (and (= b 2) (= c 3)))`},
+		{`(a = 1 OR b = 2) AND c = 3`, `(and (or (= a 1) (= b 2)) (= c 3))`},
+		{`NOT a = 1 AND b = 2`, `(and (not (= a 1)) (= b 2))`},
+		{`NOT (a = 1 AND b = 2)`, `(not (and (= a 1) (= b 2)))`},
+		{`NOT NOT a = 1`, `(not (not (= a 1)))`},
+		{`a = 1 and b = 2 or not c = 3`, `(or (and (= a 1) (= b 2)) (not (= c 3)))`},
+		{`((a = 1))`, `(= a 1)`},
+		{`(a = 1 OR (b = 2 AND (c = 3 OR NOT EXISTS d)))`, `(or (= a 1) (and (= b 2) (or (= c 3) (not (EXISTS d)))))`},
+
+		{`tags IN ("billing", "refund")`, `(IN tags "billing" "refund")`},
+		{`x in (1, 2.5, true, "s")`, `(IN x 1 2.5 true "s")`},
+		{`EXISTS assignee`, `(EXISTS assignee)`},
+		{`exists assignee AND NOT EXISTS closed_at`, `(and (EXISTS assignee) (not (EXISTS closed_at)))`},
+
+		// Escapes and characters that mean something outside a string
+		{`note = "say \"hi\" \\ ok"`, `(= note "say \"hi\" \\ ok")`},
+		{`note = "\n"`, `(= note "n")`},
+		{`note = "(a = 1) AND OR, 'x'"`, `(= note "(a = 1) AND OR, 'x'")`},
+		{`note = "x'); DROP TABLE conversations; --"`, `(= note "x'); DROP TABLE conversations; --")`},
+		{`note = ""`, `(= note "")`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			expr, err := Parse(tc.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tc.input, err)
+			}
+			if got := render(expr); got != tc.want {
+				t.Errorf("Parse(%q) = %s, want %s", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	conditions := make([]string, MaxConditions+1)
+	for i := range conditions {
+		conditions[i] = fmt.Sprintf("f%d = %d", i, i)
+	}
+	values := make([]string, MaxInValues+1)
+	for i := range values {
+		values[i] = strconv.Itoa(i)
+	}
+
+	cases := []struct {
+		name  string
+		input string
+		want  string // substring of the error
+	}{
+		{"missing value", `source =`, "expected value at position 8"},
+		{"missing operator", `source "slack"`, `expected operator after field "source"`},
+		{"missing field", `= 1`, "expected field name at position 0"},
+		{"keyword as field", `AND = 1`, `expected field name at position 0, got "AND"`},
+		{"unterminated string", `a = "open`, "unterminated string at position 4"},
+		{"escaped closing quote", `a = "open\"`, "unterminated string at position 4"},
+		{"bare bang", `a ! 1`, "unexpected '!' at position 2"},
+		{"dangling AND", `a = 1 AND`, "expected field name at position 9"},
+		{"dangling NOT", `NOT`, "expected field name at position 3"},
+		{"unclosed parenthesis", `(a = 1`, "missing closing parenthesis for position 0"},
+		{"extra parenthesis", `a = 1)`, `unexpected ")" at position 5`},
+		{"trailing condition", `a = 1 b = 2`, `unexpected "b" at position 6`},
+		{"IN without list", `a IN 1`, `expected '(' after IN for field "a"`},
+		{"IN without comma", `a IN (1 2)`, `expected ',' or ')' in IN list for field "a"`},
+		{"empty IN list", `a IN ()`, "expected value at position 6"},
+		{"unclosed IN list", `a IN (1,`, "expected value at position 8"},
+		{"boolean range", `a > true`, "operator > cannot compare booleans"},
+		{"EXISTS without field", `EXISTS "x"`, "expected field name after EXISTS at position 7"},
+		{"bad number", `a = 1.2.3`, `invalid number "1.2.3" at position 4`},
+		{"identifier value", `a = b`, `expected value at position 4, got "b"`},
+		{"unknown character", `a = @`, "unexpected character '@' at position 4"},
+		{"single quotes", `a = 'x'`, `unexpected character '\'' at position 4`},
+		{"too long", `a = "` + strings.Repeat("x", MaxFilterLength) + `"`, fmt.Sprintf("filter exceeds %d characters", MaxFilterLength)},
+		{"too many conditions", strings.Join(conditions, " OR "), fmt.Sprintf("filter has %d conditions, at most %d are allowed", MaxConditions+1, MaxConditions)},
+		{"too many IN values", "a IN (" + strings.Join(values, ", ") + ")", fmt.Sprintf(`IN list for field "a" has more than %d values`, MaxInValues)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := Parse(tc.input)
+			if err == nil {
+				t.Fatalf("Parse(%q) = %s, want an error", tc.input, render(expr))
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Parse(%q) error = %q, want it to contain %q", tc.input, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseAcceptsLimits(t *testing.T) {
+	conditions := make([]string, MaxConditions)
+	for i := range conditions {
+		conditions[i] = fmt.Sprintf("f%d = %d", i, i)
+	}
+	if _, err := Parse(strings.Join(conditions, " OR ")); err != nil {
+		t.Errorf("filter with %d conditions: %v", MaxConditions, err)
+	}
+
+	values := make([]string, MaxInValues)
+	for i := range values {
+		values[i] = strconv.Itoa(i)
+	}
+	if _, err := Parse("a IN (" + strings.Join(values, ", ") + ")"); err != nil {
+		t.Errorf("IN list of %d values: %v", MaxInValues, err)
+	}
+}
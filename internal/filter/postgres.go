@@ -0,0 +1,130 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// rfc3339Pattern matches the strings Postgres can cast to timestamptz that also parse as RFC 3339,
+// so range comparisons skip other strings instead of failing the query
+const rfc3339Pattern = `^\d{4}-(0[1-9]|1[0-2])-(0[1-9]|[12]\d|3[01])T([01]\d|2[0-3]):[0-5]\d:[0-5]\d(\.\d+)?(Z|[+-]\d{2}:\d{2})$`
+
+// Postgres translates the expression into a SQL condition over a JSONB column with the same
+// semantics as Match. locate returns the JSON path of a field within the column, e.g. the
+// "custom" object for custom conversation metadata. Values are bound as parameters numbered
+// after args, which the returned slice extends. Equality and IN compile to containment, so a GIN
+// index on the column with jsonb_path_ops serves them. A nil expression yields "TRUE".
+func Postgres(expr *Expr, column string, locate func(field string) []string, args []interface{}) (string, []interface{}) {
+	if expr == nil {
+		return "TRUE", args
+	}
+	t := &postgresTranslator{column: column, locate: locate, args: args}
+	return t.condition(expr), t.args
+}
+
+// postgresTranslator collects the parameters of a condition as it is built
+type postgresTranslator struct {
+	column string
+	locate func(field string) []string
+	args   []interface{}
+}
+
+// bind adds a parameter and returns its placeholder
+func (t *postgresTranslator) bind(value interface{}) string {
+	t.args = append(t.args, value)
+	return fmt.Sprintf("$%d", len(t.args))
+}
+
+// condition translates a node; the result is never NULL, so NOT negates it as Match does
+func (t *postgresTranslator) condition(expr *Expr) string {
+	switch expr.Kind {
+	case KindAnd:
+		return t.join(expr.Children, " AND ")
+	case KindOr:
+		return t.join(expr.Children, " OR ")
+	case KindNot:
+		return "NOT " + t.join(expr.Children, " OR ")
+	}
+
+	switch expr.Op {
+	case OpEq:
+		return "(" + t.contains(expr.Field, expr.Value) + ")"
+	case OpNe:
+		return "NOT (" + t.contains(expr.Field, expr.Value) + ")"
+	case OpIn:
+		if len(expr.Values) == 0 {
+			return "FALSE"
+		}
+		matches := make([]string, 0, len(expr.Values))
+		for _, value := range expr.Values {
+			matches = append(matches, t.contains(expr.Field, value))
+		}
+		return "(" + strings.Join(matches, " OR ") + ")"
+	case OpExists:
+		value := t.value(expr.Field)
+		return fmt.Sprintf("COALESCE(jsonb_typeof(%s) <> 'null' AND %s <> '[]'::jsonb, FALSE)", value, value)
+	}
+
+	return t.compare(expr)
+}
+
+// join translates children and combines them with op
+func (t *postgresTranslator) join(children []*Expr, op string) string {
+	conditions := make([]string, 0, len(children))
+	for _, child := range children {
+		conditions = append(conditions, t.condition(child))
+	}
+	return "(" + strings.Join(conditions, op) + ")"
+}
+
+// value returns the expression reading a field; it is NULL when the field is missing
+func (t *postgresTranslator) value(field string) string {
+	path := t.locate(field)
+	keys := make([]string, 0, len(path))
+	for _, key := range path {
+		keys = append(keys, t.bind(key))
+	}
+	return fmt.Sprintf("jsonb_extract_path(%s, %s)", t.column, strings.Join(keys, ", "))
+}
+
+// contains matches a field equal to value or a list field with an item equal to it. A list only
+// contains a scalar at the top level of a JSONB value, so both forms are tested
+func (t *postgresTranslator) contains(field string, value interface{}) string {
+	path := t.locate(field)
+	scalar := t.bind(containment(path, value))
+	list := t.bind(containment(path, []interface{}{value}))
+	return fmt.Sprintf("%s IS NOT NULL AND (%s @> %s::jsonb OR %s @> %s::jsonb)", t.column, t.column, scalar, t.column, list)
+}
+
+// containment nests value under path as a JSON document
+func containment(path []string, value interface{}) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		value = map[string]interface{}{path[i]: value}
+	}
+	document, _ := json.Marshal(value)
+	return string(document)
+}
+
+// compare translates a range comparison: numbers compare by value and RFC 3339 strings as
+// datetimes, and any other pairing fails as in Match
+func (t *postgresTranslator) compare(expr *Expr) string {
+	var test string
+	switch literal := expr.Value.(type) {
+	case int64, int, float64:
+		test = fmt.Sprintf("CASE WHEN jsonb_typeof(item) = 'number' THEN item::numeric %s %s::numeric ELSE FALSE END", expr.Op, t.bind(literal))
+	case string:
+		if _, err := time.Parse(time.RFC3339, literal); err != nil {
+			return "FALSE"
+		}
+		test = fmt.Sprintf("CASE WHEN jsonb_typeof(item) = 'string' AND item #>> '{}' ~ %s THEN (item #>> '{}')::timestamptz %s %s::timestamptz ELSE FALSE END",
+			t.bind(rfc3339Pattern), expr.Op, t.bind(literal))
+	default:
+		return "FALSE"
+	}
+
+	value := t.value(expr.Field)
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM jsonb_array_elements(CASE jsonb_typeof(%s) WHEN 'array' THEN %s ELSE jsonb_build_array(%s) END) AS items(item) WHERE %s)",
+		value, value, value, test)
+}
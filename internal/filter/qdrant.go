@@ -0,0 +1,63 @@
+package filter
+
+// Qdrant translates the expression into a Qdrant filter object. Field names are
+// prefixed with keyPrefix, e.g. "meta." for custom conversation metadata.
+func Qdrant(expr *Expr, keyPrefix string) map[string]interface{} {
+	if expr == nil {
+		return nil
+	}
+
+	condition := qdrantCondition(expr, keyPrefix)
+	if _, isLeaf := condition["key"]; isLeaf {
+		return map[string]interface{}{"must": []interface{}{condition}}
+	}
+	return condition
+}
+
+// qdrantCondition translates a node into a Qdrant condition or nested filter
+func qdrantCondition(expr *Expr, keyPrefix string) map[string]interface{} {
+	switch expr.Kind {
+	case KindAnd:
+		return map[string]interface{}{"must": qdrantChildren(expr.Children, keyPrefix)}
+	case KindOr:
+		return map[string]interface{}{"should": qdrantChildren(expr.Children, keyPrefix)}
+	case KindNot:
+		return map[string]interface{}{"must_not": qdrantChildren(expr.Children, keyPrefix)}
+	}
+
+	key := keyPrefix + expr.Field
+	switch expr.Op {
+	case OpEq:
+		return qdrantMatch(key, expr.Value)
+	case OpNe:
+		return map[string]interface{}{"must_not": []interface{}{qdrantMatch(key, expr.Value)}}
+	case OpIn:
+		return map[string]interface{}{"key": key, "match": map[string]interface{}{"any": expr.Values}}
+	case OpExists:
+		return map[string]interface{}{"must_not": []interface{}{
+			map[string]interface{}{"is_empty": map[string]interface{}{"key": key}},
+		}}
+	}
+
+	// Range comparisons; string values are compared as RFC 3339 datetimes by Qdrant
+	bound := map[string]string{OpGt: "gt", OpGte: "gte", OpLt: "lt", OpLte: "lte"}[expr.Op]
+	return map[string]interface{}{"key": key, "range": map[string]interface{}{bound: expr.Value}}
+}
+
+// qdrantMatch builds an exact-match condition; floats use a closed range since Qdrant
+// only matches keywords, integers, and booleans
+func qdrantMatch(key string, value interface{}) map[string]interface{} {
+	if f, ok := value.(float64); ok {
+		return map[string]interface{}{"key": key, "range": map[string]interface{}{"gte": f, "lte": f}}
+	}
+	return map[string]interface{}{"key": key, "match": map[string]interface{}{"value": value}}
+}
+
+// qdrantChildren translates each child expression
+func qdrantChildren(children []*Expr, keyPrefix string) []interface{} {
+	conditions := make([]interface{}, 0, len(children))
+	for _, child := range children {
+		conditions = append(conditions, qdrantCondition(child, keyPrefix))
+	}
+	return conditions
+}
@@ -0,0 +1,112 @@
+package filter
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// translateCases are the filters whose translations are kept in testdata
+var translateCases = []string{
+	`source = "slack"`,
+	`priority != 3`,
+	`score = 0.5`,
+	`archived = false`,
+	`tags IN ("billing", "refund")`,
+	`EXISTS assignee`,
+	`priority >= 3 AND priority < 10`,
+	`score > 0.25`,
+	`due <= "2024-06-01T00:00:00Z"`,
+	`due > "2024-06-01T09:30:00.5+02:00"`,
+	`due > "June 1st"`,
+	`a < "b"`,
+	`source = "slack" OR (priority > 3 AND NOT EXISTS closed_at)`,
+	`NOT (type = "chat" OR conversation_score >= 0.8)`,
+	`team.lead = "ana"`,
+	`note = "say \"hi\" \\ ok"`,
+	`note = "x'); DROP TABLE conversations; --"`,
+	`note = "<b>&amp;</b>"`,
+}
+
+// locateField places reserved fields at the top of the metadata column and others under
+// "custom", as models.MetadataFieldPath does
+func locateField(field string) []string {
+	switch field {
+	case "source", "session_id", "type", "conversation_score":
+		return []string{field}
+	}
+	return []string{"custom", field}
+}
+
+func TestPostgresGolden(t *testing.T) {
+	var out strings.Builder
+	for _, input := range translateCases {
+		expr, err := Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", input, err)
+		}
+		// A leading argument shows the placeholders numbered after the caller's own
+		sql, args := Postgres(expr, "metadata", locateField, []interface{}{"user-1"})
+		fmt.Fprintf(&out, "-- %s\n%s\n", input, sql)
+		for i, arg := range args {
+			fmt.Fprintf(&out, "$%d = %#v\n", i+1, arg)
+		}
+		out.WriteString("\n")
+	}
+	checkGolden(t, "postgres.golden", out.String())
+}
+
+func TestQdrantGolden(t *testing.T) {
+	var out strings.Builder
+	for _, input := range translateCases {
+		expr, err := Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", input, err)
+		}
+		data, err := json.MarshalIndent(Qdrant(expr, "meta."), "", "  ")
+		if err != nil {
+			t.Fatalf("failed to encode the filter of %q: %v", input, err)
+		}
+		fmt.Fprintf(&out, "// %s\n%s\n\n", input, data)
+	}
+	checkGolden(t, "qdrant.golden", out.String())
+}
+
+func TestTranslateNil(t *testing.T) {
+	args := []interface{}{"user-1"}
+	sql, got := Postgres(nil, "metadata", locateField, args)
+	if sql != "TRUE" || len(got) != 1 {
+		t.Errorf("Postgres(nil) = %q with %d args, want TRUE with the caller's 1", sql, len(got))
+	}
+	if q := Qdrant(nil, "meta."); q != nil {
+		t.Errorf("Qdrant(nil) = %v, want nil", q)
+	}
+}
+
+// checkGolden compares got with testdata/name, rewriting the file instead with -update
+func checkGolden(t *testing.T, name string, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s, run with -update to create it: %v", path, err)
+	}
+	if got != string(want) {
+		t.Errorf("translation differs from %s, run with -update if the change is intended\ngot:\n%s", path, got)
+	}
+}
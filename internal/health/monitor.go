@@ -0,0 +1,205 @@
+package health
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Damped dependency states
+const (
+	StatusHealthy   = "healthy"
+	StatusDegraded  = "degraded"
+	StatusUnhealthy = "unhealthy"
+)
+
+// Stability indicators derived from recent history
+const (
+	StabilityStable   = "stable"
+	StabilityFlapping = "flapping"
+	StabilityDown     = "down"
+)
+
+// maxTransitions bounds the transitions kept per dependency
+const maxTransitions = 50
+
+// CheckResult is a single raw health check outcome
+type CheckResult struct {
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMs int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Transition records a change in a dependency's damped status
+type Transition struct {
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	At   time.Time `json:"at"`
+}
+
+// DependencyHistory is a snapshot of one dependency's health history
+type DependencyHistory struct {
+	Name         string        `json:"name"`
+	Status       string        `json:"status"`
+	Stability    string        `json:"stability"`
+	SuccessRatio float64       `json:"success_ratio"`
+	Checks       []CheckResult `json:"checks"`
+	Transitions  []Transition  `json:"transitions"`
+}
+
+// Options configures history length and damping thresholds
+type Options struct {
+	// HistorySize is the number of raw checks kept per dependency
+	HistorySize int
+
+	// FailureThreshold is the number of consecutive failures before a dependency is unhealthy
+	FailureThreshold int
+
+	// RecoveryThreshold is the number of consecutive successes before it is healthy again
+	RecoveryThreshold int
+}
+
+type dependencyState struct {
+	status               string
+	checks               []CheckResult
+	transitions          []Transition
+	consecutiveFailures  int
+	consecutiveSuccesses int
+}
+
+// Monitor keeps a short in-memory history of dependency checks and damps status changes
+type Monitor struct {
+	mu   sync.Mutex
+	opts Options
+	deps map[string]*dependencyState
+}
+
+// NewMonitor creates a health monitor
+func NewMonitor(opts Options) *Monitor {
+	if opts.HistorySize <= 0 {
+		opts.HistorySize = 20
+	}
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 1
+	}
+	if opts.RecoveryThreshold <= 0 {
+		opts.RecoveryThreshold = 1
+	}
+
+	return &Monitor{
+		opts: opts,
+		deps: make(map[string]*dependencyState),
+	}
+}
+
+// Record adds a check result and returns the dependency's damped status
+// A single failure only degrades a healthy dependency; it becomes unhealthy after
+// FailureThreshold consecutive failures and healthy again after RecoveryThreshold successes.
+func (m *Monitor) Record(name string, result CheckResult) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.deps[name]
+	if !ok {
+		state = &dependencyState{status: StatusHealthy}
+		m.deps[name] = state
+	}
+
+	state.checks = append(state.checks, result)
+	if len(state.checks) > m.opts.HistorySize {
+		state.checks = state.checks[len(state.checks)-m.opts.HistorySize:]
+	}
+
+	next := state.status
+	if result.Healthy {
+		state.consecutiveSuccesses++
+		state.consecutiveFailures = 0
+		if state.status == StatusDegraded || state.consecutiveSuccesses >= m.opts.RecoveryThreshold {
+			next = StatusHealthy
+		}
+	} else {
+		state.consecutiveFailures++
+		state.consecutiveSuccesses = 0
+		if state.consecutiveFailures >= m.opts.FailureThreshold {
+			next = StatusUnhealthy
+		} else if state.status == StatusHealthy {
+			next = StatusDegraded
+		}
+	}
+
+	if next != state.status {
+		state.transitions = append(state.transitions, Transition{From: state.status, To: next, At: result.CheckedAt})
+		if len(state.transitions) > maxTransitions {
+			state.transitions = state.transitions[len(state.transitions)-maxTransitions:]
+		}
+		state.status = next
+	}
+
+	return state.status
+}
+
+// Stability returns the stability indicator for a dependency
+func (m *Monitor) Stability(name string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.deps[name]
+	if !ok {
+		return StabilityStable
+	}
+	return stability(state)
+}
+
+// History returns a snapshot of every dependency's history, sorted by name
+func (m *Monitor) History() []DependencyHistory {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	histories := make([]DependencyHistory, 0, len(m.deps))
+	for name, state := range m.deps {
+		histories = append(histories, DependencyHistory{
+			Name:         name,
+			Status:       state.status,
+			Stability:    stability(state),
+			SuccessRatio: successRatio(state.checks),
+			Checks:       append([]CheckResult(nil), state.checks...),
+			Transitions:  append([]Transition(nil), state.transitions...),
+		})
+	}
+	sort.Slice(histories, func(i, j int) bool { return histories[i].Name < histories[j].Name })
+
+	return histories
+}
+
+// stability classifies a dependency by how often its raw checks flipped recently
+func stability(state *dependencyState) string {
+	if state.status == StatusUnhealthy {
+		return StabilityDown
+	}
+
+	flips := 0
+	for i := 1; i < len(state.checks); i++ {
+		if state.checks[i].Healthy != state.checks[i-1].Healthy {
+			flips++
+		}
+	}
+	if flips >= 3 {
+		return StabilityFlapping
+	}
+	return StabilityStable
+}
+
+// successRatio returns the fraction of healthy checks
+func successRatio(checks []CheckResult) float64 {
+	if len(checks) == 0 {
+		return 1
+	}
+	healthy := 0
+	for _, check := range checks {
+		if check.Healthy {
+			healthy++
+		}
+	}
+	return float64(healthy) / float64(len(checks))
+}
@@ -0,0 +1,51 @@
+// Package hotstate holds state that every request reads and a reload replaces, such as hot
+// config, flags and key sets. Readers load an immutable snapshot without locking, so a request
+// sees one consistent version for as long as it holds it, and a reload never blocks or tears a
+// read in progress
+package hotstate
+
+import "sync/atomic"
+
+// Value holds the current snapshot of T. The zero Value holds T's zero value. Snapshots are
+// shared between goroutines, so nothing may modify one once stored, including maps and slices it
+// refers to: build a new T and Store it instead
+type Value[T any] struct {
+	p atomic.Pointer[T]
+}
+
+// New returns a Value holding initial
+func New[T any](initial T) *Value[T] {
+	v := &Value[T]{}
+	v.Store(initial)
+	return v
+}
+
+// Load returns the current snapshot
+func (v *Value[T]) Load() T {
+	if p := v.p.Load(); p != nil {
+		return *p
+	}
+	var zero T
+	return zero
+}
+
+// Store replaces the snapshot; readers that loaded the previous one keep it
+func (v *Value[T]) Store(next T) {
+	v.p.Store(&next)
+}
+
+// Update replaces the snapshot with fn applied to the current one and returns the result. fn runs
+// again if another update lands in between, so it must not have side effects
+func (v *Value[T]) Update(fn func(T) T) T {
+	for {
+		current := v.p.Load()
+		var base T
+		if current != nil {
+			base = *current
+		}
+		next := fn(base)
+		if v.p.CompareAndSwap(current, &next) {
+			return next
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package hotstate
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// snapshot stands in for a reloaded config: every field is derived from version, so a snapshot
+// mixing two versions, or one modified after it was stored, fails consistent
+type snapshot struct {
+	version int
+	limits  map[string]int
+	names   []string
+	total   int
+}
+
+// snapshotKeys are the keys of every snapshot's limits
+var snapshotKeys = []string{"postgres", "qdrant", "openai", "mysql", "sqlite"}
+
+func newSnapshot(version int) snapshot {
+	s := snapshot{version: version, limits: make(map[string]int, len(snapshotKeys))}
+	for _, key := range snapshotKeys {
+		s.limits[key] = version
+		s.names = append(s.names, fmt.Sprintf("%s-%d", key, version))
+		s.total += version
+	}
+	return s
+}
+
+// consistent returns an error describing how s isn't a whole snapshot of one version
+func (s snapshot) consistent() error {
+	if len(s.limits) != len(snapshotKeys) || len(s.names) != len(snapshotKeys) {
+		return fmt.Errorf("version %d has %d limits and %d names", s.version, len(s.limits), len(s.names))
+	}
+	total := 0
+	for i, key := range snapshotKeys {
+		if s.limits[key] != s.version {
+			return fmt.Errorf("version %d has limit %s of version %d", s.version, key, s.limits[key])
+		}
+		if want := fmt.Sprintf("%s-%d", key, s.version); s.names[i] != want {
+			return fmt.Errorf("version %d has name %q", s.version, s.names[i])
+		}
+		total += s.limits[key]
+	}
+	if total != s.total {
+		return fmt.Errorf("version %d has total %d, want %d", s.version, s.total, total)
+	}
+	return nil
+}
+
+// readUntil loads snapshots until stop is closed, reporting the first inconsistent one. It yields
+// between loads so writers get to run on a single CPU
+func readUntil(v *Value[snapshot], stop <-chan struct{}, loads *atomic.Int64) error {
+	for {
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+		if err := v.Load().consistent(); err != nil {
+			return err
+		}
+		loads.Add(1)
+		runtime.Gosched()
+	}
+}
+
+func TestZeroValue(t *testing.T) {
+	var v Value[snapshot]
+	if got := v.Load(); got.version != 0 || got.limits != nil {
+		t.Errorf("Load of the zero Value = %+v, want the zero snapshot", got)
+	}
+	got := v.Update(func(s snapshot) snapshot { return newSnapshot(s.version + 1) })
+	if got.version != 1 || v.Load().version != 1 {
+		t.Errorf("Update of the zero Value = version %d, stored %d; want 1", got.version, v.Load().version)
+	}
+}
+
+// TestConcurrentLoadStoreUpdate loads snapshots while Store and Update replace them, checking
+// that no load sees a half-applied snapshot and that no Update is lost to another
+func TestConcurrentLoadStoreUpdate(t *testing.T) {
+	const (
+		readers = 8
+		updates = 500
+		stores  = 500
+	)
+
+	v := New(newSnapshot(0))
+	stop := make(chan struct{})
+	var loads atomic.Int64
+
+	var readersDone sync.WaitGroup
+	errs := make(chan error, readers)
+	for i := 0; i < readers; i++ {
+		readersDone.Add(1)
+		go func() {
+			defer readersDone.Done()
+			errs <- readUntil(v, stop, &loads)
+		}()
+	}
+
+	// Stores overwrite the version Updates increment, so TestUpdateLosesNothing checks the count;
+	// here every Update must still return
+	var updated atomic.Int64
+	var writers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for j := 0; j < updates/4; j++ {
+				v.Update(func(s snapshot) snapshot { return newSnapshot(s.version + 1) })
+				updated.Add(1)
+				runtime.Gosched()
+			}
+		}()
+	}
+	writers.Add(1)
+	go func() {
+		defer writers.Done()
+		for j := 0; j < stores; j++ {
+			v.Store(newSnapshot(1_000_000 + j))
+			runtime.Gosched()
+		}
+	}()
+
+	writers.Wait()
+	close(stop)
+	readersDone.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := v.Load().consistent(); err != nil {
+		t.Fatal(err)
+	}
+	if updated.Load() != updates {
+		t.Errorf("%d updates returned, want %d", updated.Load(), updates)
+	}
+	if loads.Load() == 0 {
+		t.Error("no snapshot was loaded while writers ran")
+	}
+}
+
+// TestUpdateLosesNothing runs concurrent Updates and checks that each one landed
+func TestUpdateLosesNothing(t *testing.T) {
+	const writers, updates = 8, 1000
+
+	v := New(newSnapshot(0))
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < updates; j++ {
+				v.Update(func(s snapshot) snapshot { return newSnapshot(s.version + 1) })
+			}
+		}()
+	}
+	wg.Wait()
+
+	got := v.Load()
+	if err := got.consistent(); err != nil {
+		t.Fatal(err)
+	}
+	if got.version != writers*updates {
+		t.Errorf("version after %d updates = %d", writers*updates, got.version)
+	}
+}
+
+// TestReloadUnderLoad swaps in a new config while requests read it. A request keeps the snapshot
+// it loaded for its whole duration, unchanged by reloads, and later loads never go back to an
+// older version
+func TestReloadUnderLoad(t *testing.T) {
+	const (
+		requests = 8
+		reloads  = 1000
+	)
+
+	v := New(newSnapshot(1))
+	stop := make(chan struct{})
+	var served atomic.Int64
+
+	var wg sync.WaitGroup
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- serveRequests(v, stop, &served)
+		}()
+	}
+
+	for version := 2; version <= reloads; version++ {
+		v.Store(newSnapshot(version))
+		runtime.Gosched()
+	}
+	close(stop)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := v.Load(); got.version != reloads {
+		t.Errorf("version after the last reload = %d, want %d", got.version, reloads)
+	}
+	if served.Load() == 0 {
+		t.Error("no request was served while the config reloaded")
+	}
+}
+
+// serveRequests stands in for a handler: it loads the config once per request, reads it at the
+// start and at the end, and checks versions never go backwards between requests
+func serveRequests(v *Value[snapshot], stop <-chan struct{}, served *atomic.Int64) error {
+	last := 0
+	for {
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+
+		config := v.Load()
+		if err := config.consistent(); err != nil {
+			return err
+		}
+		if config.version < last {
+			return fmt.Errorf("loaded version %d after version %d", config.version, last)
+		}
+		last = config.version
+
+		// A reload landing mid-request doesn't touch the snapshot the request holds
+		runtime.Gosched()
+		if err := config.consistent(); err != nil {
+			return fmt.Errorf("snapshot changed during a request: %w", err)
+		}
+		if config.limits["postgres"] != last {
+			return fmt.Errorf("snapshot of version %d changed to limit %d during a request", last, config.limits["postgres"])
+		}
+		served.Add(1)
+	}
+}
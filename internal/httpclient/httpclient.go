@@ -0,0 +1,177 @@
+// Package httpclient provides the HTTP client shared by outbound integrations such as Qdrant. It
+// retries transient failures with backoff, bounds each attempt, records metrics and trace spans,
+// forwards the request ID and trace context and logs retries, so each integration doesn't
+// implement these on its own
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"refo-rag-server/internal/deadline"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/requestid"
+	"refo-rag-server/internal/tracing"
+)
+
+// maxRetryAfter caps how long a Retry-After header can delay a retry
+const maxRetryAfter = 30 * time.Second
+
+// Options configures a client for one dependency
+type Options struct {
+	// Name labels the dependency in metrics and logs, e.g. "qdrant"
+	Name string
+
+	// Transport sends the requests; nil uses http.DefaultTransport
+	Transport http.RoundTripper
+
+	// MaxRetries is how often a request is sent again after a network error or a 429, 502, 503
+	// or 504 response. Only requests whose body can be replayed are retried
+	MaxRetries int
+
+	// RetryBackoff is the delay before the first retry; it doubles for each further retry, with
+	// jitter, unless the response asks for a longer Retry-After
+	RetryBackoff time.Duration
+
+	// AttemptTimeout bounds each attempt, including reading the response body; zero means only
+	// the request context limits it
+	AttemptTimeout time.Duration
+}
+
+// New creates a client for a dependency
+func New(opts Options) *http.Client {
+	if opts.Transport == nil {
+		opts.Transport = http.DefaultTransport
+	}
+	// Each attempt is its own span, so retries show in the trace
+	opts.Transport = tracing.Transport(opts.Name, opts.Transport)
+	return &http.Client{Transport: &transport{opts: opts}}
+}
+
+// transport wraps a round tripper with the client's behavior
+type transport struct {
+	opts Options
+}
+
+// RoundTrip sends a request, retrying transient failures
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if id := requestid.FromContext(ctx); id != "" && req.Header.Get(requestid.Header) == "" {
+		req = req.Clone(ctx)
+		req.Header.Set(requestid.Header, id)
+	}
+	// The caller's deadline travels on, so dependencies that honor it can stop early too
+	if at, ok := deadline.FromContext(ctx); ok && req.Header.Get(deadline.Header) == "" {
+		req = req.Clone(ctx)
+		req.Header.Set(deadline.Header, deadline.Format(at))
+	}
+
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to replay %s request body: %w", t.opts.Name, err)
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+
+		resp, err := t.attempt(req)
+		if attempt >= t.opts.MaxRetries || !replayable || !retryable(ctx, resp, err) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt, resp)
+		reason := "network error"
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		fmt.Printf("warning: %s request %s %s failed (%s), retrying in %s (%d/%d)\n",
+			t.opts.Name, req.Method, req.URL.Path, reason, delay.Round(time.Millisecond), attempt+1, t.opts.MaxRetries)
+		metrics.OutboundRetries.WithLabelValues(t.opts.Name).Inc()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// attempt sends a request once, bounded by the attempt timeout, and records its outcome
+func (t *transport) attempt(req *http.Request) (*http.Response, error) {
+	cancel := context.CancelFunc(func() {})
+	if t.opts.AttemptTimeout > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), t.opts.AttemptTimeout)
+		req = req.WithContext(ctx)
+	}
+
+	start := time.Now()
+	resp, err := t.opts.Transport.RoundTrip(req)
+	metrics.OutboundRequestDuration.WithLabelValues(t.opts.Name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		cancel()
+		metrics.OutboundRequests.WithLabelValues(t.opts.Name, req.Method, "error").Inc()
+		return nil, err
+	}
+	metrics.OutboundRequests.WithLabelValues(t.opts.Name, req.Method, strconv.Itoa(resp.StatusCode/100)+"xx").Inc()
+
+	// The attempt's deadline also covers reading the body, so release it when the body is closed
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// retryable reports whether a failed attempt is worth repeating
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		// An attempt that ran into its own timeout is retried; the caller's deadline is not
+		return !errors.Is(err, context.Canceled)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before the retry following attempt
+func (t *transport) backoff(attempt int, resp *http.Response) time.Duration {
+	delay := t.opts.RetryBackoff << attempt
+	delay = delay/2 + rand.N(delay/2+1)
+
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			if after := time.Duration(seconds) * time.Second; after > delay {
+				delay = min(after, maxRetryAfter)
+			}
+		}
+	}
+	return delay
+}
+
+// cancelBody releases an attempt's context once the response body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
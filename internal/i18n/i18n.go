@@ -0,0 +1,80 @@
+// Package i18n localizes the user-facing messages of API errors. Error codes are never
+// translated; clients branch on them. Messages are looked up by their English text first and
+// fall back to a generic message for the error code, so a handler message without a
+// translation still reads in the client's language.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported languages
+const (
+	English = "en"
+	Korean  = "ko"
+)
+
+// catalog holds the translations of one language
+type catalog struct {
+	// messages translates handler messages by their English text
+	messages map[string]string
+
+	// codes translates the generic message of each error code
+	codes map[string]string
+}
+
+var catalogs = map[string]catalog{
+	Korean: korean,
+}
+
+// Negotiate returns the supported language an Accept-Language header prefers, or English when
+// it names none. Only the primary subtag is compared, so ko-KR selects Korean
+func Negotiate(acceptLanguage string) string {
+	type preference struct {
+		lang    string
+		quality float64
+	}
+
+	var preferences []preference
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, translated := catalogs[primary]; quality > 0 && (primary == English || translated) {
+			preferences = append(preferences, preference{lang: primary, quality: quality})
+		}
+	}
+	if len(preferences) == 0 {
+		return English
+	}
+
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+	return preferences[0].lang
+}
+
+// Message localizes an error message: its own translation, else the translation of its code's
+// generic message, else the message unchanged
+func Message(lang string, code string, message string) string {
+	c, ok := catalogs[lang]
+	if !ok {
+		return message
+	}
+	if translated, ok := c.messages[message]; ok {
+		return translated
+	}
+	if translated, ok := c.codes[code]; ok {
+		return translated
+	}
+	return message
+}
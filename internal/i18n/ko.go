@@ -0,0 +1,128 @@
+package i18n
+
+var korean = catalog{
+	codes: map[string]string{
+		"INTERNAL_ERROR":              "서버 오류가 발생했습니다. 잠시 후 다시 시도해 주세요",
+		"INVALID_REQUEST":             "요청이 올바르지 않습니다",
+		"NOT_FOUND":                   "요청한 항목을 찾을 수 없습니다",
+		"PERSONAL_INFO_NOT_FOUND":     "개인 정보를 찾을 수 없습니다",
+		"PRECONDITION_FAILED":         "다른 사용자가 먼저 수정했습니다. 최신 내용을 다시 불러온 뒤 시도해 주세요",
+		"INVALID_METADATA":            "대화 메타데이터가 올바르지 않습니다",
+		"INVALID_FILTER":              "메타데이터 필터가 올바르지 않습니다",
+		"UNSUPPORTED_FORMAT":          "지원하지 않는 형식입니다",
+		"UNAUTHORIZED":                "유효한 관리자 API 키가 필요합니다",
+		"ADMIN_DISABLED":              "관리자 API가 비활성화되어 있습니다",
+		"SESSION_EXISTS":              "이미 존재하는 세션입니다",
+		"SESSION_EMPTY":               "세션에 요약할 대화가 없습니다",
+		"SESSION_CLOSED":              "종료된 세션에는 대화를 저장할 수 없습니다",
+		"JOB_RUNNING":                 "같은 작업이 이미 실행 중입니다",
+		"SCOPE_CHANGED":               "확인 토큰이 발급된 뒤 삭제 범위가 변경되었습니다",
+		"INVALID_CONFIRMATION":        "확인 토큰이 올바르지 않습니다",
+		"CONFIRMATION_EXPIRED":        "확인 토큰이 만료되었습니다",
+		"FEATURE_FLAGS_RELOAD_FAILED": "기능 플래그를 다시 불러오지 못했습니다",
+		"MAINTENANCE_MODE":            "서버 점검 중입니다. 잠시 동안 저장과 수정이 제한됩니다",
+		"OVERLOADED":                  "요청이 많아 처리할 수 없습니다. 잠시 후 다시 시도해 주세요",
+		"BUDGET_EXCEEDED":             "임베딩 사용 한도를 초과했습니다. 한도가 초기화된 뒤 다시 시도해 주세요",
+		"USER_DISABLED":               "비활성화된 사용자입니다",
+		"USER_EXISTS":                 "이미 등록된 사용자입니다",
+		"INSUFFICIENT_SCOPE":          "API 키에 이 요청을 수행할 권한이 없습니다",
+		"IP_BLOCKED":                  "이 주소에서는 요청할 수 없습니다",
+		"INVALID_SIGNATURE":           "요청 서명이 올바르지 않습니다",
+		"API_KEY_INACTIVE":            "폐기되었거나 만료되었거나 이미 교체된 API 키입니다",
+		"WRONG_REGION":                "요청한 데이터는 다른 리전에 저장되어 있습니다",
+		"UNKNOWN_REGION":              "리전 목록에 없는 리전입니다",
+	},
+	messages: map[string]string{
+		"Invalid request body":                                          "요청 본문이 올바르지 않습니다",
+		"invalid request body":                                          "요청 본문이 올바르지 않습니다",
+		"user_id is required":                                           "user_id가 필요합니다",
+		"info_id is required":                                           "info_id가 필요합니다",
+		"text is required":                                              "text가 필요합니다",
+		"query is required":                                             "검색어가 필요합니다",
+		"Query text cannot be empty":                                    "검색어를 입력해 주세요",
+		"reason is required when suppressing":                           "숨김 처리할 때는 사유가 필요합니다",
+		"conversation_id and messages are required":                     "conversation_id와 messages가 필요합니다",
+		"message content cannot be empty":                               "메시지 내용을 입력해 주세요",
+		"session_id must be at most 64 characters":                      "session_id는 64자 이하여야 합니다",
+		"min_score must be a number":                                    "min_score는 숫자여야 합니다",
+		"top_k must be between 1 and 100":                               "top_k는 1에서 100 사이여야 합니다",
+		"speaker fields too long":                                       "화자 정보가 너무 깁니다",
+		"invalid metadata filter":                                       "메타데이터 필터가 올바르지 않습니다",
+		"invalid conversation metadata":                                 "대화 메타데이터가 올바르지 않습니다",
+		"invalid message role":                                          "메시지 역할이 올바르지 않습니다",
+		"invalid message_id":                                            "message_id가 올바르지 않습니다",
+		"invalid session status":                                        "세션 상태가 올바르지 않습니다",
+		"invalid time zone":                                             "시간대가 올바르지 않습니다",
+		"invalid target":                                                "대상이 올바르지 않습니다",
+		"unknown content type":                                          "알 수 없는 콘텐츠 유형입니다",
+		"unsupported transcript format":                                 "지원하지 않는 대화 기록 형식입니다",
+		"dead letter ID must be an integer":                             "데드 레터 ID는 정수여야 합니다",
+		"personal information not found":                                "개인 정보를 찾을 수 없습니다",
+		"session not found":                                             "세션을 찾을 수 없습니다",
+		"job not found":                                                 "작업을 찾을 수 없습니다",
+		"dead letter not found":                                         "데드 레터를 찾을 수 없습니다",
+		"the user is disabled":                                          "비활성화된 사용자입니다",
+		"user already registered":                                       "이미 등록된 사용자입니다",
+		"user not registered":                                           "등록되지 않은 사용자입니다",
+		"no data stored for user":                                       "사용자에 대해 저장된 데이터가 없습니다",
+		"no personal info or conversations for user":                    "사용자의 개인 정보나 대화가 없습니다",
+		"session already exists":                                        "이미 존재하는 세션입니다",
+		"session has no conversations to summarize":                     "세션에 요약할 대화가 없습니다",
+		"cannot save a conversation into a closed session":              "종료된 세션에는 대화를 저장할 수 없습니다",
+		"an optimization job is already running":                        "최적화 작업이 이미 실행 중입니다",
+		"an integrity verification job is already running":              "무결성 검증 작업이 이미 실행 중입니다",
+		"an embedding drift check is already running":                   "임베딩 드리프트 검사가 이미 실행 중입니다",
+		"a personal info reindex is already running":                    "개인 정보 재색인 작업이 이미 실행 중입니다",
+		"unsupported export format":                                     "지원하지 않는 내보내기 형식입니다",
+		"export is too large":                                           "내보내기 파일이 너무 큽니다",
+		"Invalid export":                                                "내보내기 파일이 올바르지 않습니다",
+		"a conversation import is already running":                      "대화 가져오기 작업이 이미 실행 중입니다",
+		"failed to start conversation import":                           "대화 가져오기 작업을 시작하지 못했습니다",
+		"the job can't be resumed":                                      "이 작업은 이어서 실행할 수 없습니다",
+		"an analytics export is already running":                        "분석 데이터 내보내기가 이미 실행 중입니다",
+		"only days that have ended can be exported":                     "지난 날짜만 내보낼 수 있습니다",
+		"date must be formatted as YYYY-MM-DD":                          "date는 YYYY-MM-DD 형식이어야 합니다",
+		"a vector export or import is already running":                  "벡터 내보내기 또는 가져오기가 이미 실행 중입니다",
+		"ids_key is required to import a .npy file":                     ".npy 파일을 가져오려면 ids_key가 필요합니다",
+		"retrieval overrides need an API key with the experiment scope": "검색 파라미터를 재정의하려면 experiment 권한이 있는 API 키가 필요합니다",
+		"retrieval overrides don't fit the search pipeline":             "검색 파라미터 재정의가 검색 파이프라인과 맞지 않습니다",
+		"candidate_multiplier must be between 1 and 10":                 "candidate_multiplier는 1에서 10 사이여야 합니다",
+		"hnsw_ef must be between 1 and 4096":                            "hnsw_ef는 1에서 4096 사이여야 합니다",
+		"fusion_weights must be a list of retriever=weight pairs with non-negative weights":             "fusion_weights는 음수가 아닌 가중치를 가진 retriever=weight 목록이어야 합니다",
+		"recency_half_life must be a positive duration, e.g. 72h":                                       "recency_half_life는 72h처럼 양의 기간이어야 합니다",
+		"importance_half_life must be a positive duration, e.g. 720h":                                   "importance_half_life는 720h처럼 양의 기간이어야 합니다",
+		"valid admin API key required":                                                                  "유효한 관리자 API 키가 필요합니다",
+		"valid API key required":                                                                        "유효한 API 키가 필요합니다",
+		"API key lacks the scope this request needs":                                                    "API 키에 이 요청을 수행할 권한이 없습니다",
+		"requests from this address are not allowed":                                                    "이 주소에서는 요청할 수 없습니다",
+		"request signature is invalid":                                                                  "요청 서명이 올바르지 않습니다",
+		"the requested data is homed in another region":                                                 "요청한 데이터는 다른 리전에 저장되어 있습니다",
+		"region is not in the region map":                                                               "리전 목록에 없는 리전입니다",
+		"API key not found":                                                                             "API 키를 찾을 수 없습니다",
+		"admin API is disabled; set ADMIN_API_KEY to enable it":                                         "관리자 API가 비활성화되어 있습니다. ADMIN_API_KEY를 설정해 활성화하세요",
+		"server is in read-only maintenance mode; writes are temporarily disabled":                      "서버 점검 중입니다. 잠시 동안 저장과 수정이 제한됩니다",
+		"server is at capacity for this kind of request; retry later":                                   "요청이 많아 처리할 수 없습니다. 잠시 후 다시 시도해 주세요",
+		"the embedding budget is spent; try again after it resets":                                      "임베딩 사용 한도를 초과했습니다. 한도가 초기화된 뒤 다시 시도해 주세요",
+		"the resource was modified since the version the request is based on; fetch it again and retry": "다른 사용자가 먼저 수정했습니다. 최신 내용을 다시 불러온 뒤 시도해 주세요",
+		"failed to save conversation":                                                                   "대화를 저장하지 못했습니다",
+		"failed to search conversations":                                                                "대화를 검색하지 못했습니다",
+		"failed to create personal information":                                                         "개인 정보를 추가하지 못했습니다",
+		"failed to get personal information":                                                            "개인 정보를 불러오지 못했습니다",
+		"failed to update personal information":                                                         "개인 정보를 수정하지 못했습니다",
+		"failed to delete personal information":                                                         "개인 정보를 삭제하지 못했습니다",
+		"failed to create session":                                                                      "세션을 만들지 못했습니다",
+		"failed to get session":                                                                         "세션을 불러오지 못했습니다",
+		"failed to list sessions":                                                                       "세션 목록을 불러오지 못했습니다",
+		"failed to close session":                                                                       "세션을 종료하지 못했습니다",
+		"failed to summarize session":                                                                   "세션을 요약하지 못했습니다",
+		"failed to get session transcript":                                                              "세션 대화 기록을 불러오지 못했습니다",
+		"failed to get session context":                                                                 "세션 문맥을 불러오지 못했습니다",
+		"failed to retrieve memory context":                                                             "기억 문맥을 불러오지 못했습니다",
+		"failed to list pinned memories":                                                                "고정된 기억을 불러오지 못했습니다",
+		"failed to list suppressed memories":                                                            "숨긴 기억을 불러오지 못했습니다",
+		"failed to update pin state":                                                                    "고정 상태를 변경하지 못했습니다",
+		"failed to update suppression":                                                                  "숨김 상태를 변경하지 못했습니다",
+		"failed to get user profile":                                                                    "사용자 프로필을 불러오지 못했습니다",
+		"internal server error":                                                                         "서버 오류가 발생했습니다. 잠시 후 다시 시도해 주세요",
+	},
+}
@@ -0,0 +1,120 @@
+// Package importance scores stored memories for long-term value and decays the scores over time
+package importance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"refo-rag-server/internal/models"
+)
+
+// Scorer names accepted by NewScorer
+const (
+	ScorerHeuristic = "heuristic"
+	ScorerLLM       = "llm"
+)
+
+// Default is the importance of memories saved before scoring existed
+const Default = 0.5
+
+// Scorer rates a conversation's long-term importance between 0 and 1
+type Scorer interface {
+	Score(ctx context.Context, conversation *models.Conversation) (float64, error)
+}
+
+// Completer generates text from a system prompt and a prompt
+type Completer interface {
+	Complete(ctx context.Context, systemPrompt string, prompt string) (string, error)
+}
+
+// NewScorer returns the named scorer; the LLM scorer falls back to the heuristic when the model fails
+func NewScorer(name string, completer Completer) (Scorer, error) {
+	switch name {
+	case "", ScorerHeuristic:
+		return Heuristic{}, nil
+	case ScorerLLM:
+		return &LLM{completer: completer, fallback: Heuristic{}}, nil
+	default:
+		return nil, fmt.Errorf("unknown importance scorer %q", name)
+	}
+}
+
+// Decay halves an importance score every halfLife of age; a non-positive halfLife disables decay
+func Decay(score float64, age time.Duration, halfLife time.Duration) float64 {
+	if halfLife <= 0 || age <= 0 {
+		return score
+	}
+	return score * math.Pow(0.5, float64(age)/float64(halfLife))
+}
+
+// Heuristic scores conversations from how much the user said, how long the exchange was,
+// and the client-provided conversation_score (read on a 0-10 scale)
+type Heuristic struct{}
+
+// Score implements Scorer
+func (Heuristic) Score(_ context.Context, conversation *models.Conversation) (float64, error) {
+	userChars := 0
+	for _, msg := range conversation.Messages {
+		if msg.Role == models.RoleUser {
+			userChars += utf8.RuneCountInString(msg.Content)
+		}
+	}
+
+	score := 0.2
+	score += 0.3 * math.Min(float64(userChars)/1000, 1)
+	score += 0.1 * math.Min(float64(len(conversation.Messages))/10, 1)
+
+	var metadata models.ConversationMetadata
+	if err := json.Unmarshal([]byte(conversation.Metadata), &metadata); err == nil && metadata.ConversationScore != nil {
+		score += 0.4 * clamp(float64(*metadata.ConversationScore)/10)
+	}
+
+	return clamp(score), nil
+}
+
+// llmImportancePrompt instructs the model how to rate a conversation
+const llmImportancePrompt = `You rate how important a conversation between a user and an assistant is to remember long-term.
+High: lasting facts about the user (health, family, preferences, routines), commitments and decisions.
+Low: small talk, one-off questions, greetings. Reply with a single number between 0 and 1 and nothing else.`
+
+// maxLLMInputChars bounds the conversation text sent for rating
+const maxLLMInputChars = 8000
+
+// LLM scores conversations with a chat model
+type LLM struct {
+	completer Completer
+	fallback  Scorer
+}
+
+// Score implements Scorer
+func (l *LLM) Score(ctx context.Context, conversation *models.Conversation) (float64, error) {
+	var b strings.Builder
+	for _, msg := range conversation.Messages {
+		b.WriteString(msg.Role + ": " + msg.Content + "\n")
+		if b.Len() > maxLLMInputChars {
+			break
+		}
+	}
+
+	output, err := l.completer.Complete(ctx, llmImportancePrompt, b.String())
+	if err == nil {
+		score, parseErr := strconv.ParseFloat(strings.TrimSpace(output), 64)
+		if parseErr == nil && !math.IsNaN(score) {
+			return clamp(score), nil
+		}
+		err = fmt.Errorf("unexpected model output %q", output)
+	}
+
+	fallback, _ := l.fallback.Score(ctx, conversation)
+	return fallback, fmt.Errorf("failed to rate importance, used heuristic: %w", err)
+}
+
+func clamp(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,350 @@
+package ingest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"refo-rag-server/internal/models"
+)
+
+// Supported bulk import formats
+const (
+	FormatDialogflow = "dialogflow"
+	FormatWebhook    = "webhook"
+	FormatCSV        = "csv"
+)
+
+// maxSessionIDLength is the longest session ID the sessions table stores; longer platform
+// session IDs are replaced by a UUID derived from them
+const maxSessionIDLength = 64
+
+// importNamespace derives stable conversation and message IDs from platform identifiers, so
+// importing the same export again updates the conversations instead of duplicating them
+var importNamespace = uuid.MustParse("6f0b7c2e-3d1a-4c55-9a8e-2b7e4f1d9c30")
+
+// BulkResult is the conversations read from a platform export
+type BulkResult struct {
+	Requests []*models.ConversationSaveRequest
+	Records  int // Source messages read
+	Skipped  int // Messages without text, a user or a known role
+	Users    int
+	Sessions int
+}
+
+// BulkImporter converts an assistant platform's export, which holds many users' sessions, into
+// one save request per session
+type BulkImporter interface {
+	Import(r io.Reader, opts Options) (*BulkResult, error)
+}
+
+var bulkImporters = map[string]BulkImporter{
+	FormatDialogflow: dialogflowImporter{},
+	FormatWebhook:    webhookImporter{},
+	FormatCSV:        csvImporter{},
+}
+
+// LookupBulk returns the importer for a bulk format name
+func LookupBulk(format string) (BulkImporter, bool) {
+	importer, ok := bulkImporters[strings.ToLower(format)]
+	return importer, ok
+}
+
+// BulkFormats returns the supported bulk format names in sorted order
+func BulkFormats() []string {
+	formats := make([]string, 0, len(bulkImporters))
+	for format := range bulkImporters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// bulkRecord is one message of a platform export
+type bulkRecord struct {
+	UserID    string
+	SessionID string
+	Timestamp *time.Time
+	Role      string
+	Speaker   string
+	Content   string
+}
+
+// bulkSession accumulates the messages of one user's session in export order
+type bulkSession struct {
+	userID    string
+	sessionID string
+	messages  []models.Message
+}
+
+// bulkCollector groups export records into sessions
+type bulkCollector struct {
+	format   string
+	opts     Options
+	sessions map[string]*bulkSession
+	order    []*bulkSession
+	records  int
+	skipped  int
+}
+
+func newBulkCollector(format string, opts Options) *bulkCollector {
+	return &bulkCollector{format: format, opts: opts, sessions: map[string]*bulkSession{}}
+}
+
+// add files a record under its session. Records without a user fall back to the options' user;
+// records without a session are grouped per user and day
+func (b *bulkCollector) add(rec bulkRecord) {
+	b.records++
+	rec.Content = strings.TrimSpace(rec.Content)
+	if rec.UserID == "" {
+		rec.UserID = b.opts.UserID
+	}
+	if rec.Content == "" || rec.UserID == "" || !models.IsValidRole(rec.Role) {
+		b.skipped++
+		return
+	}
+	if rec.SessionID == "" && rec.Timestamp != nil {
+		rec.SessionID = rec.UserID + "-" + rec.Timestamp.In(b.opts.location()).Format("2006-01-02")
+	}
+
+	key := rec.UserID + "\x00" + rec.SessionID
+	session, ok := b.sessions[key]
+	if !ok {
+		session = &bulkSession{userID: rec.UserID, sessionID: rec.SessionID}
+		b.sessions[key] = session
+		b.order = append(b.order, session)
+	}
+	session.messages = append(session.messages, models.Message{
+		Role:        rec.Role,
+		Content:     rec.Content,
+		Timestamp:   rec.Timestamp,
+		Speaker:     rec.Speaker,
+		DisplayName: rec.Speaker,
+	})
+}
+
+// skip counts a source message that can't be imported
+func (b *bulkCollector) skip() {
+	b.records++
+	b.skipped++
+}
+
+// finish builds one save request per session, ordered by when the sessions started
+func (b *bulkCollector) finish() (*BulkResult, error) {
+	if len(b.order) == 0 {
+		return nil, fmt.Errorf("export contains no messages")
+	}
+
+	result := &BulkResult{Records: b.records, Skipped: b.skipped, Sessions: len(b.order)}
+	users := map[string]bool{}
+	for _, session := range b.order {
+		users[session.userID] = true
+		result.Requests = append(result.Requests, b.request(session))
+	}
+	result.Users = len(users)
+
+	sort.SliceStable(result.Requests, func(i, j int) bool {
+		a, b := result.Requests[i].CreatedAt, result.Requests[j].CreatedAt
+		return a != nil && (b == nil || a.Before(*b))
+	})
+	return result, nil
+}
+
+// request converts a session into a save request with IDs derived from the platform's user and
+// session IDs
+func (b *bulkCollector) request(session *bulkSession) *models.ConversationSaveRequest {
+	source := b.format + "\x00" + session.userID + "\x00" + session.sessionID
+	conversationID := uuid.NewSHA1(importNamespace, []byte(source)).String()
+
+	messages := session.messages
+	if timestamped(messages) {
+		sort.SliceStable(messages, func(i, j int) bool {
+			return messages[i].Timestamp.Before(*messages[j].Timestamp)
+		})
+	}
+	for i := range messages {
+		messages[i].MessageID = uuid.NewSHA1(importNamespace, []byte(conversationID+"\x00"+strconv.Itoa(i))).String()
+	}
+
+	metadata := &models.ConversationMetadata{Source: b.format}
+	if session.sessionID != "" {
+		metadata.SessionID = session.sessionID
+		if len(metadata.SessionID) > maxSessionIDLength {
+			metadata.SessionID = uuid.NewSHA1(importNamespace, []byte(session.sessionID)).String()
+		}
+	}
+
+	req := &models.ConversationSaveRequest{
+		ConversationID: conversationID,
+		UserID:         session.userID,
+		Messages:       messages,
+		Metadata:       metadata,
+	}
+	for _, msg := range messages {
+		if msg.Timestamp != nil && (req.CreatedAt == nil || msg.Timestamp.Before(*req.CreatedAt)) {
+			req.CreatedAt = msg.Timestamp
+		}
+	}
+	return req
+}
+
+// timestamped reports whether every message has a timestamp
+func timestamped(messages []models.Message) bool {
+	for _, msg := range messages {
+		if msg.Timestamp == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// roleFor maps a platform role or speaker name to a message role; names that aren't roles are
+// speakers, mapped via the options
+func (o Options) roleFor(value string) (role string, speaker string) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "user", "human", "customer", "end_user", "end-user", "enduser":
+		return models.RoleUser, ""
+	case "assistant", "bot", "agent", "ai", "virtual_agent", "virtual-agent":
+		return models.RoleAssistant, ""
+	case "system":
+		return models.RoleSystem, ""
+	case "":
+		return models.RoleUser, ""
+	}
+	speaker = strings.TrimSpace(value)
+	return o.roleForSpeaker(speaker), speaker
+}
+
+// readJSONRecords decodes a JSON array of objects or newline-delimited JSON objects
+func readJSONRecords(r io.Reader, fn func(record map[string]interface{}) error) error {
+	reader := bufio.NewReader(r)
+	first, err := firstByte(reader)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	if first == '[' {
+		if _, err := decoder.Token(); err != nil {
+			return fmt.Errorf("invalid JSON: %w", err)
+		}
+	}
+
+	for i := 0; first != '[' || decoder.More(); i++ {
+		var record map[string]interface{}
+		err := decoder.Decode(&record)
+		if err == io.EOF && first != '[' {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("record %d: %w", i, err)
+		}
+		if err := fn(record); err != nil {
+			return fmt.Errorf("record %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// firstByte skips a byte order mark and whitespace and returns the next byte without consuming it
+func firstByte(reader *bufio.Reader) (byte, error) {
+	if bom, err := reader.Peek(3); err == nil && string(bom) == "\ufeff" {
+		reader.Discard(3)
+	}
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return b, reader.UnreadByte()
+		}
+	}
+}
+
+// field returns the value at a dotted path in a JSON object
+func field(record map[string]interface{}, path string) interface{} {
+	var value interface{} = record
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// stringField returns the first non-empty string or number among the paths
+func stringField(record map[string]interface{}, paths ...string) string {
+	for _, path := range paths {
+		switch value := field(record, path).(type) {
+		case string:
+			if value != "" {
+				return value
+			}
+		case json.Number:
+			return value.String()
+		}
+	}
+	return ""
+}
+
+// timeField parses the first timestamp among the paths
+func timeField(record map[string]interface{}, loc *time.Location, paths ...string) (*time.Time, error) {
+	for _, path := range paths {
+		switch value := field(record, path).(type) {
+		case string:
+			if value != "" {
+				return parseTimestamp(value, loc)
+			}
+		case json.Number:
+			return parseTimestamp(value.String(), loc)
+		}
+	}
+	return nil, nil
+}
+
+// timestampLayouts are the accepted textual timestamp layouts; layouts without a zone are read
+// in the options' time zone
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+}
+
+// parseTimestamp reads an RFC 3339 or similar timestamp, or Unix seconds or milliseconds
+func parseTimestamp(value string, loc *time.Location) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if unix, err := strconv.ParseFloat(value, 64); err == nil {
+		var ts time.Time
+		if unix >= 1e12 {
+			ts = time.UnixMilli(int64(unix))
+		} else {
+			ts = time.Unix(0, int64(unix*float64(time.Second)))
+		}
+		ts = ts.UTC()
+		return &ts, nil
+	}
+	for _, layout := range timestampLayouts {
+		if ts, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return &ts, nil
+		}
+	}
+	return nil, fmt.Errorf("unrecognized timestamp %q", value)
+}
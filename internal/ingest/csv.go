@@ -0,0 +1,91 @@
+package ingest
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvColumns maps header names, compared case-insensitively, to the record field they fill
+var csvColumns = map[string]string{
+	"user_id":         "user",
+	"userid":          "user",
+	"user":            "user",
+	"session_id":      "session",
+	"sessionid":       "session",
+	"session":         "session",
+	"conversation_id": "session",
+	"timestamp":       "time",
+	"time":            "time",
+	"date":            "time",
+	"created_at":      "time",
+	"role":            "role",
+	"speaker":         "role",
+	"sender":          "role",
+	"from":            "role",
+	"content":         "text",
+	"message":         "text",
+	"text":            "text",
+}
+
+// csvImporter reads CSV transcripts with a header row and one message per row. The user, session,
+// timestamp, role or speaker, and text columns are found by their header names
+type csvImporter struct{}
+
+// Import converts CSV transcript rows into one conversation per user session
+func (csvImporter) Import(r io.Reader, opts Options) (*BulkResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("export contains no messages")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if f, ok := csvColumns[name]; ok {
+			if _, seen := columns[f]; !seen {
+				columns[f] = i
+			}
+		}
+	}
+	if _, ok := columns["text"]; !ok {
+		return nil, fmt.Errorf("CSV header has no content, message or text column")
+	}
+
+	collector := newBulkCollector(FormatCSV, opts)
+	loc := opts.location()
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		value := func(f string) string {
+			i, ok := columns[f]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		rec := bulkRecord{UserID: value("user"), SessionID: value("session"), Content: value("text")}
+		if ts := value("time"); ts != "" {
+			if rec.Timestamp, err = parseTimestamp(ts, loc); err != nil {
+				line, _ := reader.FieldPos(columns["time"])
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		rec.Role, rec.Speaker = opts.roleFor(value("role"))
+		collector.add(rec)
+	}
+	return collector.finish()
+}
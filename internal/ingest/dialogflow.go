@@ -0,0 +1,92 @@
+package ingest
+
+import (
+	"io"
+	"strings"
+
+	"refo-rag-server/internal/models"
+)
+
+// dialogflowImporter reads Dialogflow ES or CX interaction logs: detect-intent request/response
+// pairs or webhook requests, as a JSON array or one JSON object per line. Cloud Logging entries
+// are unwrapped from their jsonPayload. Each interaction yields the user's query and the agent's
+// reply, grouped by the last segment of the session path
+type dialogflowImporter struct{}
+
+// Import converts Dialogflow interactions into one conversation per session
+func (dialogflowImporter) Import(r io.Reader, opts Options) (*BulkResult, error) {
+	collector := newBulkCollector(FormatDialogflow, opts)
+	loc := opts.location()
+
+	err := readJSONRecords(r, func(record map[string]interface{}) error {
+		if payload, ok := record["jsonPayload"].(map[string]interface{}); ok {
+			record = payload
+		}
+
+		timestamp, err := timeField(record, loc, "timestamp", "responseTime", "createTime", "receiveTimestamp")
+		if err != nil {
+			return err
+		}
+		sessionID := dialogflowSession(stringField(record, "session", "sessionId", "queryResult.session", "detectIntentResponse.session"))
+		userID := stringField(record,
+			"originalDetectIntentRequest.payload.userId",
+			"originalDetectIntentRequest.payload.user.userId",
+			"queryParams.payload.userId",
+			"payload.userId",
+			"userId",
+		)
+
+		result, _ := field(record, "queryResult").(map[string]interface{})
+		if result == nil {
+			result, _ = field(record, "detectIntentResponse.queryResult").(map[string]interface{})
+		}
+		if result == nil {
+			collector.skip()
+			return nil
+		}
+
+		record = result
+		query := stringField(record, "queryText", "text", "transcript")
+		collector.add(bulkRecord{UserID: userID, SessionID: sessionID, Timestamp: timestamp, Role: models.RoleUser, Content: query})
+
+		reply := stringField(record, "fulfillmentText")
+		if reply == "" {
+			reply = dialogflowMessages(record)
+		}
+		collector.add(bulkRecord{UserID: userID, SessionID: sessionID, Timestamp: timestamp, Role: models.RoleAssistant, Content: reply})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collector.finish()
+}
+
+// dialogflowSession returns the session ID at the end of a session resource path
+func dialogflowSession(session string) string {
+	if i := strings.LastIndex(session, "/sessions/"); i >= 0 {
+		session = session[i+len("/sessions/"):]
+	}
+	return strings.Trim(session, "/")
+}
+
+// dialogflowMessages joins the text responses of ES fulfillmentMessages or CX responseMessages
+func dialogflowMessages(result map[string]interface{}) string {
+	var parts []string
+	for _, key := range []string{"fulfillmentMessages", "responseMessages"} {
+		messages, _ := result[key].([]interface{})
+		for _, message := range messages {
+			object, ok := message.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			texts, _ := field(object, "text.text").([]interface{})
+			for _, text := range texts {
+				if s, ok := text.(string); ok && strings.TrimSpace(s) != "" {
+					parts = append(parts, s)
+				}
+			}
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,175 @@
+package ingest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"refo-rag-server/internal/models"
+)
+
+// Supported ingest formats
+const (
+	FormatNative     = "native"
+	FormatOpenAI     = "openai"
+	FormatLINE       = "line"
+	FormatKakaoTalk  = "kakaotalk"
+	FormatTranscript = "transcript"
+)
+
+// Options carries request parameters that text formats cannot express in the body
+type Options struct {
+	// ConversationID is used when the body doesn't carry one
+	ConversationID string
+
+	// UserID is used for bulk import messages that don't name their user
+	UserID string
+
+	// AssistantSpeakers lists speaker names whose messages are stored with the assistant role
+	AssistantSpeakers []string
+
+	// Location is the time zone of timestamps in chat exports; nil means UTC
+	Location *time.Location
+}
+
+// Result is a normalized save request plus the number of source messages that were dropped
+type Result struct {
+	Request *models.ConversationSaveRequest
+	Skipped int
+}
+
+// Adapter converts a client transcript into the internal save request
+type Adapter interface {
+	Parse(body []byte, opts Options) (*Result, error)
+}
+
+var adapters = map[string]Adapter{
+	FormatNative:     nativeAdapter{},
+	FormatOpenAI:     openAIAdapter{},
+	FormatLINE:       lineAdapter{},
+	FormatKakaoTalk:  kakaoTalkAdapter{},
+	FormatTranscript: transcriptAdapter{},
+}
+
+// Lookup returns the adapter for a format name
+func Lookup(format string) (Adapter, bool) {
+	adapter, ok := adapters[strings.ToLower(format)]
+	return adapter, ok
+}
+
+// Formats returns the supported format names in sorted order
+func Formats() []string {
+	formats := make([]string, 0, len(adapters))
+	for format := range adapters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// location returns the configured time zone or UTC
+func (o Options) location() *time.Location {
+	if o.Location == nil {
+		return time.UTC
+	}
+	return o.Location
+}
+
+// roleForSpeaker maps a chat export speaker to a message role
+func (o Options) roleForSpeaker(speaker string) string {
+	for _, assistant := range o.AssistantSpeakers {
+		if strings.EqualFold(strings.TrimSpace(assistant), speaker) {
+			return models.RoleAssistant
+		}
+	}
+	return models.RoleUser
+}
+
+// newResult builds a result, falling back to the conversation ID from the options
+func newResult(conversationID string, messages []models.Message, metadata *models.ConversationMetadata, skipped int, opts Options) (*Result, error) {
+	if conversationID == "" {
+		conversationID = opts.ConversationID
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("transcript contains no messages")
+	}
+	return &Result{
+		Request: &models.ConversationSaveRequest{
+			ConversationID: conversationID,
+			Messages:       messages,
+			Metadata:       metadata,
+		},
+		Skipped: skipped,
+	}, nil
+}
+
+// chatBuilder accumulates chat export messages, joining continuation lines
+type chatBuilder struct {
+	opts     Options
+	messages []models.Message
+	skipped  int
+}
+
+// add starts a new message from a speaker
+func (b *chatBuilder) add(speaker string, timestamp time.Time, content string) {
+	ts := timestamp
+	b.messages = append(b.messages, models.Message{
+		Role:        b.opts.roleForSpeaker(speaker),
+		Content:     content,
+		Timestamp:   &ts,
+		Speaker:     speaker,
+		DisplayName: speaker,
+	})
+}
+
+// continueLast appends a continuation line to the previous message
+func (b *chatBuilder) continueLast(line string) bool {
+	if len(b.messages) == 0 {
+		return false
+	}
+	last := &b.messages[len(b.messages)-1]
+	last.Content += "\n" + line
+	return true
+}
+
+// finish trims message content and drops empty messages
+func (b *chatBuilder) finish() []models.Message {
+	messages := make([]models.Message, 0, len(b.messages))
+	for _, msg := range b.messages {
+		msg.Content = strings.TrimSpace(msg.Content)
+		if strings.Contains(msg.Content, "\n") && len(msg.Content) >= 2 &&
+			strings.HasPrefix(msg.Content, `"`) && strings.HasSuffix(msg.Content, `"`) {
+			// Chat apps quote multi-line messages in exports
+			msg.Content = msg.Content[1 : len(msg.Content)-1]
+		}
+		if msg.Content == "" {
+			b.skipped++
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// splitLines splits a text export into lines, dropping a byte order mark and carriage returns
+func splitLines(body []byte) []string {
+	text := strings.TrimPrefix(string(body), "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(text, "\n")
+}
+
+// clock converts a 12- or 24-hour clock reading to 24-hour time
+func clock(hour, minute int, meridiem string) (int, int) {
+	switch strings.ToUpper(meridiem) {
+	case "PM", "오후", "午後":
+		if hour < 12 {
+			hour += 12
+		}
+	case "AM", "오전", "午前":
+		if hour == 12 {
+			hour = 0
+		}
+	}
+	return hour, minute
+}
@@ -0,0 +1,133 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// kakaoDateRe matches PC export day separators such as
+	// "--------------- 2024년 1월 15일 월요일 ---------------"
+	kakaoDateRe = regexp.MustCompile(`^-{3,}\s*(.+?)\s*-{3,}$`)
+
+	// kakaoKoreanDateRe extracts the date from a Korean day separator
+	kakaoKoreanDateRe = regexp.MustCompile(`(\d{4})년 (\d{1,2})월 (\d{1,2})일`)
+
+	// kakaoMessageRe matches PC export messages such as "[Alice] [오후 2:30] Hello"
+	kakaoMessageRe = regexp.MustCompile(`^\[([^\]]+)\] \[(?:(오전|오후|AM|PM) ?)?(\d{1,2}):(\d{2})(?: ?(AM|PM))?\] (.*)$`)
+
+	// kakaoMobileRe matches mobile export messages such as "2024년 1월 15일 오후 2:30, Alice : Hello"
+	kakaoMobileRe = regexp.MustCompile(`^(\d{4})년 (\d{1,2})월 (\d{1,2})일 (오전|오후) (\d{1,2}):(\d{2}), (.+?) : (.*)$`)
+)
+
+// kakaoTalkAdapter parses KakaoTalk PC and mobile text exports and CSV exports
+type kakaoTalkAdapter struct{}
+
+// Parse converts a KakaoTalk export into messages; speakers are mapped to roles via the options
+func (kakaoTalkAdapter) Parse(body []byte, opts Options) (*Result, error) {
+	trimmed := strings.TrimPrefix(string(bytes.TrimSpace(body)), "\ufeff")
+	if strings.HasPrefix(trimmed, "Date,User,Message") {
+		return parseKakaoCSV(trimmed, opts)
+	}
+
+	builder := &chatBuilder{opts: opts}
+	loc := opts.location()
+
+	var day time.Time
+	for _, line := range splitLines(body) {
+		if m := kakaoDateRe.FindStringSubmatch(line); m != nil {
+			if parsed, ok := parseKakaoDay(m[1], loc); ok {
+				day = parsed
+			}
+			continue
+		}
+
+		if m := kakaoMessageRe.FindStringSubmatch(line); m != nil && !day.IsZero() {
+			hour, _ := strconv.Atoi(m[3])
+			minute, _ := strconv.Atoi(m[4])
+			meridiem := m[2]
+			if meridiem == "" {
+				meridiem = m[5]
+			}
+			hour, minute = clock(hour, minute, meridiem)
+			timestamp := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+			builder.add(m[1], timestamp, m[6])
+			continue
+		}
+
+		if m := kakaoMobileRe.FindStringSubmatch(line); m != nil {
+			year, _ := strconv.Atoi(m[1])
+			month, _ := strconv.Atoi(m[2])
+			date, _ := strconv.Atoi(m[3])
+			hour, _ := strconv.Atoi(m[5])
+			minute, _ := strconv.Atoi(m[6])
+			hour, minute = clock(hour, minute, m[4])
+			day = time.Date(year, time.Month(month), date, 0, 0, 0, 0, loc)
+			builder.add(m[7], time.Date(year, time.Month(month), date, hour, minute, 0, 0, loc), m[8])
+			continue
+		}
+
+		// Header lines before the first day are ignored; later lines continue a multi-line message
+		if !day.IsZero() && line != "" {
+			builder.continueLast(line)
+		}
+	}
+
+	return newResult("", builder.finish(), nil, builder.skipped, opts)
+}
+
+// parseKakaoDay parses Korean and English day separators
+func parseKakaoDay(text string, loc *time.Location) (time.Time, bool) {
+	if m := kakaoKoreanDateRe.FindStringSubmatch(text); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		date, _ := strconv.Atoi(m[3])
+		return time.Date(year, time.Month(month), date, 0, 0, 0, 0, loc), true
+	}
+	if parsed, err := time.ParseInLocation("Monday, January 2, 2006", text, loc); err == nil {
+		return parsed, true
+	}
+	return time.Time{}, false
+}
+
+// parseKakaoCSV parses the "Date,User,Message" CSV export
+func parseKakaoCSV(text string, opts Options) (*Result, error) {
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.FieldsPerRecord = -1
+
+	// Skip the header row
+	if _, err := reader.Read(); err != nil {
+		return nil, fmt.Errorf("invalid KakaoTalk CSV export: %w", err)
+	}
+
+	builder := &chatBuilder{opts: opts}
+	loc := opts.location()
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid KakaoTalk CSV export: %w", err)
+		}
+		if len(record) < 3 {
+			builder.skipped++
+			continue
+		}
+
+		timestamp, err := time.ParseInLocation("2006-01-02 15:04:05", strings.TrimSpace(record[0]), loc)
+		if err != nil {
+			builder.skipped++
+			continue
+		}
+		builder.add(strings.TrimSpace(record[1]), timestamp, record[2])
+	}
+
+	return newResult("", builder.finish(), nil, builder.skipped, opts)
+}
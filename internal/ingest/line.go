@@ -0,0 +1,63 @@
+package ingest
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	// lineDateRe matches day headers such as "2024/01/15(Mon)" or "2024.01.15 Monday"
+	lineDateRe = regexp.MustCompile(`^(\d{4})[/.](\d{1,2})[/.](\d{1,2})`)
+
+	// lineMessageRe matches "10:25\tAlice\tHello" with an optional AM/PM marker
+	lineMessageRe = regexp.MustCompile(`^(?:(AM|PM|午前|午後) ?)?(\d{1,2}):(\d{2})(?: ?(AM|PM))?\t([^\t]+)\t(.*)$`)
+
+	// lineEventRe matches timestamped lines without a speaker, such as join notices
+	lineEventRe = regexp.MustCompile(`^(?:(AM|PM|午前|午後) ?)?\d{1,2}:\d{2}(?: ?(AM|PM))?\t[^\t]*$`)
+)
+
+// lineAdapter parses LINE "Save chat history" text exports
+type lineAdapter struct{}
+
+// Parse converts a LINE export into messages; speakers are mapped to roles via the options
+func (lineAdapter) Parse(body []byte, opts Options) (*Result, error) {
+	builder := &chatBuilder{opts: opts}
+	loc := opts.location()
+
+	var day time.Time
+	for _, line := range splitLines(body) {
+		if m := lineDateRe.FindStringSubmatch(line); m != nil {
+			year, _ := strconv.Atoi(m[1])
+			month, _ := strconv.Atoi(m[2])
+			date, _ := strconv.Atoi(m[3])
+			day = time.Date(year, time.Month(month), date, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if m := lineMessageRe.FindStringSubmatch(line); m != nil && !day.IsZero() {
+			hour, _ := strconv.Atoi(m[2])
+			minute, _ := strconv.Atoi(m[3])
+			meridiem := m[1]
+			if meridiem == "" {
+				meridiem = m[4]
+			}
+			hour, minute = clock(hour, minute, meridiem)
+			timestamp := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+			builder.add(m[5], timestamp, m[6])
+			continue
+		}
+
+		if lineEventRe.MatchString(line) {
+			builder.skipped++
+			continue
+		}
+
+		// Header lines before the first day are ignored; later lines continue a multi-line message
+		if !day.IsZero() && line != "" {
+			builder.continueLast(line)
+		}
+	}
+
+	return newResult("", builder.finish(), nil, builder.skipped, opts)
+}
@@ -0,0 +1,23 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"refo-rag-server/internal/models"
+)
+
+// nativeAdapter parses the server's own ConversationSaveRequest JSON
+type nativeAdapter struct{}
+
+// Parse decodes a ConversationSaveRequest
+func (nativeAdapter) Parse(body []byte, opts Options) (*Result, error) {
+	var req models.ConversationSaveRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+	if req.ConversationID == "" {
+		req.ConversationID = opts.ConversationID
+	}
+	return &Result{Request: &req}, nil
+}
@@ -0,0 +1,107 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"refo-rag-server/internal/models"
+)
+
+// openAIMessage is a chat-completion message
+type openAIMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+	Name    string          `json:"name,omitempty"`
+}
+
+// openAIRequest wraps chat-completion messages with conversation fields
+type openAIRequest struct {
+	ConversationID string                       `json:"conversation_id"`
+	Messages       []openAIMessage              `json:"messages"`
+	Metadata       *models.ConversationMetadata `json:"metadata,omitempty"`
+}
+
+// openAIAdapter parses OpenAI chat-completion message arrays, either bare or wrapped
+// in an object with conversation_id and metadata
+type openAIAdapter struct{}
+
+// Parse converts chat-completion messages into the internal model
+func (openAIAdapter) Parse(body []byte, opts Options) (*Result, error) {
+	var req openAIRequest
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &req.Messages); err != nil {
+			return nil, fmt.Errorf("invalid OpenAI message array: %w", err)
+		}
+	} else if err := json.Unmarshal(trimmed, &req); err != nil {
+		return nil, fmt.Errorf("invalid OpenAI chat request: %w", err)
+	}
+
+	skipped := 0
+	messages := make([]models.Message, 0, len(req.Messages))
+	for i, msg := range req.Messages {
+		content, err := openAIContent(msg.Content)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+
+		role := normalizeOpenAIRole(msg.Role)
+		// Assistant turns that only carry tool calls have no text to store
+		if !models.IsValidRole(role) || content == "" {
+			skipped++
+			continue
+		}
+
+		messages = append(messages, models.Message{
+			Role:        role,
+			Content:     content,
+			Speaker:     msg.Name,
+			DisplayName: msg.Name,
+		})
+	}
+
+	return newResult(req.ConversationID, messages, req.Metadata, skipped, opts)
+}
+
+// normalizeOpenAIRole maps legacy and alias roles onto user, assistant, system, and tool
+func normalizeOpenAIRole(role string) string {
+	switch strings.ToLower(role) {
+	case "developer":
+		return "system"
+	case "function":
+		return "tool"
+	default:
+		return strings.ToLower(role)
+	}
+}
+
+// openAIContent extracts text from string or content-part array content
+func openAIContent(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return strings.TrimSpace(text), nil
+	}
+
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", fmt.Errorf("content must be a string or an array of content parts")
+	}
+
+	var texts []string
+	for _, part := range parts {
+		// Images, audio, and files have no text to embed
+		if part.Type == "text" && strings.TrimSpace(part.Text) != "" {
+			texts = append(texts, strings.TrimSpace(part.Text))
+		}
+	}
+	return strings.Join(texts, "\n"), nil
+}
@@ -0,0 +1,78 @@
+package ingest
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"refo-rag-server/internal/models"
+)
+
+// transcriptLineRe matches "Speaker: text" with an optional "[timestamp]" prefix
+var transcriptLineRe = regexp.MustCompile(`^(?:\[([^\]]+)\]\s*)?([^:\[\]]{1,100}):\s?(.*)$`)
+
+// transcriptTimeLayouts lists the accepted timestamp formats in generic transcripts
+var transcriptTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// transcriptRoles maps well-known speaker labels to roles
+var transcriptRoles = map[string]string{
+	"user":      models.RoleUser,
+	"human":     models.RoleUser,
+	"assistant": models.RoleAssistant,
+	"ai":        models.RoleAssistant,
+	"bot":       models.RoleAssistant,
+	"system":    models.RoleSystem,
+	"tool":      models.RoleTool,
+}
+
+// transcriptAdapter parses generic "Speaker: text" transcripts
+type transcriptAdapter struct{}
+
+// Parse converts a generic transcript into messages
+func (transcriptAdapter) Parse(body []byte, opts Options) (*Result, error) {
+	builder := &chatBuilder{opts: opts}
+	loc := opts.location()
+
+	for _, line := range splitLines(body) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		m := transcriptLineRe.FindStringSubmatch(line)
+		if m == nil {
+			if !builder.continueLast(line) {
+				builder.skipped++
+			}
+			continue
+		}
+
+		timestamp := time.Time{}
+		if m[1] != "" {
+			for _, layout := range transcriptTimeLayouts {
+				if parsed, err := time.ParseInLocation(layout, strings.TrimSpace(m[1]), loc); err == nil {
+					timestamp = parsed
+					break
+				}
+			}
+		}
+
+		speaker := strings.TrimSpace(m[2])
+		builder.add(speaker, timestamp, m[3])
+
+		last := &builder.messages[len(builder.messages)-1]
+		if role, ok := transcriptRoles[strings.ToLower(speaker)]; ok {
+			last.Role = role
+			last.Speaker = ""
+			last.DisplayName = ""
+		}
+		if timestamp.IsZero() {
+			last.Timestamp = nil
+		}
+	}
+
+	return newResult("", builder.finish(), nil, builder.skipped, opts)
+}
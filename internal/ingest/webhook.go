@@ -0,0 +1,38 @@
+package ingest
+
+import (
+	"io"
+)
+
+// webhookImporter reads generic chat webhook logs: a JSON array or one JSON object per line, each
+// one message with its user, session, time, sender and text. Common field spellings are accepted,
+// e.g. user_id/userId/user, session_id/sessionId/conversation_id, timestamp/time/created_at,
+// role/sender/from and text/message/content
+type webhookImporter struct{}
+
+// Import converts webhook log messages into one conversation per user session
+func (webhookImporter) Import(r io.Reader, opts Options) (*BulkResult, error) {
+	collector := newBulkCollector(FormatWebhook, opts)
+	loc := opts.location()
+
+	err := readJSONRecords(r, func(record map[string]interface{}) error {
+		timestamp, err := timeField(record, loc, "timestamp", "time", "created_at", "createdAt", "ts")
+		if err != nil {
+			return err
+		}
+		role, speaker := opts.roleFor(stringField(record, "role", "sender", "from", "speaker", "author"))
+		collector.add(bulkRecord{
+			UserID:    stringField(record, "user_id", "userId", "user.id", "user"),
+			SessionID: stringField(record, "session_id", "sessionId", "session", "conversation_id", "conversationId", "thread_id"),
+			Timestamp: timestamp,
+			Role:      role,
+			Speaker:   speaker,
+			Content:   stringField(record, "text", "message", "content", "body", "message.text"),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collector.finish()
+}
@@ -0,0 +1,72 @@
+// Package insights counts conversations across users into aggregate groups, such as topics or
+// question categories, and releases only groups that are k-anonymous: a group is reported only if
+// at least k distinct users contributed to it, so no released count describes a small set of
+// people. Smaller groups are suppressed, and only how many were suppressed is reported
+package insights
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MinK is the smallest anonymity threshold accepted; a group of one user is that user's data
+const MinK = 2
+
+// Counter tallies conversations and distinct users per group
+type Counter struct {
+	groups map[string]*tally
+}
+
+// tally is the count of one group
+type tally struct {
+	conversations int
+	users         map[string]struct{}
+}
+
+// Group is a released aggregate
+type Group struct {
+	Key           string
+	Users         int
+	Conversations int
+}
+
+// NewCounter creates an empty counter
+func NewCounter() *Counter {
+	return &Counter{groups: make(map[string]*tally)}
+}
+
+// Add counts a conversation of userID in group
+func (c *Counter) Add(group string, userID string) {
+	t, ok := c.groups[group]
+	if !ok {
+		t = &tally{users: make(map[string]struct{})}
+		c.groups[group] = t
+	}
+	t.conversations++
+	t.users[userID] = struct{}{}
+}
+
+// Release returns the groups with at least k distinct users, most conversations first, and the
+// number of groups suppressed for having fewer
+func (c *Counter) Release(k int) ([]Group, int, error) {
+	if k < MinK {
+		return nil, 0, fmt.Errorf("anonymity threshold must be at least %d, got %d", MinK, k)
+	}
+
+	released := make([]Group, 0, len(c.groups))
+	suppressed := 0
+	for key, t := range c.groups {
+		if len(t.users) < k {
+			suppressed++
+			continue
+		}
+		released = append(released, Group{Key: key, Users: len(t.users), Conversations: t.conversations})
+	}
+	sort.Slice(released, func(a, b int) bool {
+		if released[a].Conversations != released[b].Conversations {
+			return released[a].Conversations > released[b].Conversations
+		}
+		return released[a].Key < released[b].Key
+	})
+	return released, suppressed, nil
+}
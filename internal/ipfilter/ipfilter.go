@@ -0,0 +1,94 @@
+// Package ipfilter decides whether a client address may reach a group of routes from CIDR allow
+// and deny lists
+package ipfilter
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Reasons a request is blocked
+const (
+	ReasonDenied     = "denied"
+	ReasonNotAllowed = "not_allowed"
+)
+
+// Rules are the allow and deny lists of a route group. A denied address is always blocked; when
+// the allow list is not empty, an address must also be on it
+type Rules struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// NewRules parses allow and deny entries, each a CIDR range or a single address. It returns nil
+// when both lists are empty, i.e. nothing is filtered
+func NewRules(allow []string, deny []string) (*Rules, error) {
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil, nil
+	}
+
+	allowNets, err := parseNets(allow)
+	if err != nil {
+		return nil, err
+	}
+	denyNets, err := parseNets(deny)
+	if err != nil {
+		return nil, err
+	}
+	return &Rules{allow: allowNets, deny: denyNets}, nil
+}
+
+// Check returns why an address is blocked, or "" if it may pass. A nil address, e.g. of a peer on
+// the Unix socket, is on no list: it is only blocked when an allow list is set
+func (r *Rules) Check(ip net.IP) string {
+	if ip == nil {
+		if len(r.allow) > 0 {
+			return ReasonNotAllowed
+		}
+		return ""
+	}
+	if contains(r.deny, ip) {
+		return ReasonDenied
+	}
+	if len(r.allow) > 0 && !contains(r.allow, ip) {
+		return ReasonNotAllowed
+	}
+	return ""
+}
+
+// contains reports whether any of the networks contains ip
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNets parses CIDR ranges, treating a bare address as a range of one
+func parseNets(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR range %q", entry)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
@@ -0,0 +1,49 @@
+package ipfilter
+
+import (
+	"net"
+	"testing"
+)
+
+func TestRulesCheck(t *testing.T) {
+	cases := []struct {
+		name  string
+		allow []string
+		deny  []string
+		ip    string
+		want  string
+	}{
+		{name: "allow-only, allowed", allow: []string{"10.0.0.0/8"}, ip: "10.1.2.3", want: ""},
+		{name: "allow-only, not allowed", allow: []string{"10.0.0.0/8"}, ip: "192.168.0.1", want: ReasonNotAllowed},
+		{name: "allow-only, single address", allow: []string{"192.168.0.1"}, ip: "192.168.0.1", want: ""},
+		{name: "allow-only, IPv6", allow: []string{"fd00::/8"}, ip: "fd00::1", want: ""},
+		{name: "allow-only, nil IP", allow: []string{"10.0.0.0/8"}, ip: "", want: ReasonNotAllowed},
+		{name: "deny-only, denied", deny: []string{"203.0.113.0/24"}, ip: "203.0.113.7", want: ReasonDenied},
+		{name: "deny-only, other address", deny: []string{"203.0.113.0/24"}, ip: "198.51.100.1", want: ""},
+		{name: "deny-only, nil IP", deny: []string{"203.0.113.0/24"}, ip: "", want: ""},
+		{name: "deny wins over allow", allow: []string{"10.0.0.0/8"}, deny: []string{"10.0.0.1"}, ip: "10.0.0.1", want: ReasonDenied},
+		{name: "both lists, nil IP", allow: []string{"10.0.0.0/8"}, deny: []string{"10.0.0.1"}, ip: "", want: ReasonNotAllowed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rules, err := NewRules(tc.allow, tc.deny)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rules.Check(net.ParseIP(tc.ip)); got != tc.want {
+				t.Errorf("Check(%q) = %q, want %q", tc.ip, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewRules(t *testing.T) {
+	if rules, err := NewRules(nil, nil); rules != nil || err != nil {
+		t.Errorf("NewRules with empty lists = %v, %v; want nil rules", rules, err)
+	}
+	for _, entry := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0"} {
+		if _, err := NewRules([]string{entry}, nil); err == nil {
+			t.Errorf("NewRules accepted %q", entry)
+		}
+	}
+}
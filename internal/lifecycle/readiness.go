@@ -0,0 +1,38 @@
+package lifecycle
+
+import "sync"
+
+// Readiness tracks whether the server should receive traffic
+type Readiness struct {
+	mu     sync.RWMutex
+	ready  bool
+	reason string
+}
+
+// NewReadiness creates a readiness tracker in the not-ready state
+func NewReadiness(reason string) *Readiness {
+	return &Readiness{reason: reason}
+}
+
+// MarkReady flips the readiness probe to ready
+func (r *Readiness) MarkReady() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ready = true
+	r.reason = ""
+}
+
+// MarkNotReady flips the readiness probe to not ready with a reason
+func (r *Readiness) MarkNotReady(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ready = false
+	r.reason = reason
+}
+
+// Status returns whether the server is ready and, if not, why
+func (r *Readiness) Status() (bool, string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.ready, r.reason
+}
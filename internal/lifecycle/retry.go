@@ -0,0 +1,81 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// RetryPolicy bounds how long startup waits for a dependency
+type RetryPolicy struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	MaxWait        time.Duration
+}
+
+// WaitFor calls fn until it succeeds, backing off exponentially between attempts
+// It gives up once the policy's MaxWait has elapsed and returns the last error, or at once when
+// fn returns an error wrapped by Permanent.
+func WaitFor(ctx context.Context, name string, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	deadline := time.Now().Add(policy.MaxWait)
+	backoff := policy.InitialBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			if attempt > 1 {
+				log.Printf("%s is reachable after %d attempts", name, attempt)
+			}
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("%s not reachable after %d attempts over %s: %w", name, attempt, policy.MaxWait, err)
+		}
+
+		wait := backoff
+		if wait > remaining {
+			wait = remaining
+		}
+		log.Printf("Waiting for %s (attempt %d failed: %v); retrying in %s", name, attempt, err, wait.Round(time.Millisecond))
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped waiting for %s: %w", name, ctx.Err())
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+// permanentError is an error that waiting longer doesn't fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks an error that waiting longer doesn't fix, so WaitFor returns it without retrying
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
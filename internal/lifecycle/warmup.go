@@ -0,0 +1,39 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// WarmupStep is a single unit of startup warm-up work
+type WarmupStep struct {
+	Name string
+	Run  func(ctx context.Context) error
+
+	// Required steps abort the warm-up on failure; others are logged and skipped
+	Required bool
+}
+
+// RunWarmup executes the steps in order within the timeout, logging each step's duration
+func RunWarmup(ctx context.Context, timeout time.Duration, steps []WarmupStep) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	for _, step := range steps {
+		stepStart := time.Now()
+		if err := step.Run(ctx); err != nil {
+			if step.Required {
+				return fmt.Errorf("warm-up step %q failed: %w", step.Name, err)
+			}
+			log.Printf("Warm-up step %q failed after %s: %v", step.Name, time.Since(stepStart).Round(time.Millisecond), err)
+			continue
+		}
+		log.Printf("Warm-up step %q completed in %s", step.Name, time.Since(stepStart).Round(time.Millisecond))
+	}
+
+	log.Printf("Warm-up completed in %s", time.Since(start).Round(time.Millisecond))
+	return nil
+}
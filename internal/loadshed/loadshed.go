@@ -0,0 +1,90 @@
+// Package loadshed caps the requests served concurrently per endpoint class. Requests beyond a
+// class's cap wait briefly for a slot and are otherwise refused, so a traffic spike is turned
+// away at the edge instead of piling connections onto Postgres and Qdrant.
+package loadshed
+
+import (
+	"context"
+	"time"
+
+	"refo-rag-server/internal/metrics"
+)
+
+// Endpoint classes
+const (
+	ClassSearch = "search"
+	ClassStore  = "store"
+	ClassAdmin  = "admin"
+)
+
+// Options configures a Shedder
+type Options struct {
+	// Limits is the concurrency cap of each class; a missing or non-positive cap is unlimited
+	Limits map[string]int
+
+	// QueueTimeout is how long a request waits for a slot before it is shed; 0 sheds at once
+	QueueTimeout time.Duration
+
+	// RetryAfter is the Retry-After hint sent with shed requests
+	RetryAfter time.Duration
+}
+
+// Shedder holds the concurrency slots of every capped class
+type Shedder struct {
+	slots map[string]chan struct{}
+	opts  Options
+}
+
+// New creates a shedder with one slot pool per capped class
+func New(opts Options) *Shedder {
+	slots := make(map[string]chan struct{}, len(opts.Limits))
+	for class, limit := range opts.Limits {
+		if limit > 0 {
+			slots[class] = make(chan struct{}, limit)
+		}
+	}
+	return &Shedder{slots: slots, opts: opts}
+}
+
+// RetryAfter returns the Retry-After hint for shed requests
+func (s *Shedder) RetryAfter() time.Duration {
+	return s.opts.RetryAfter
+}
+
+// Acquire takes a slot of class, waiting up to the queue timeout. It returns the function that
+// releases the slot, or false if the request should be shed
+func (s *Shedder) Acquire(ctx context.Context, class string) (func(), bool) {
+	slots, ok := s.slots[class]
+	if !ok {
+		return func() {}, true
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return s.release(class, slots), true
+	default:
+	}
+
+	if s.opts.QueueTimeout > 0 {
+		timer := time.NewTimer(s.opts.QueueTimeout)
+		defer timer.Stop()
+		select {
+		case slots <- struct{}{}:
+			return s.release(class, slots), true
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+
+	metrics.RequestsShed.WithLabelValues(class).Inc()
+	return nil, false
+}
+
+// release counts an acquired slot and returns the function that frees it
+func (s *Shedder) release(class string, slots chan struct{}) func() {
+	metrics.InflightRequests.WithLabelValues(class).Inc()
+	return func() {
+		<-slots
+		metrics.InflightRequests.WithLabelValues(class).Dec()
+	}
+}
@@ -0,0 +1,114 @@
+package loadshed
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClassThresholds(t *testing.T) {
+	cases := []struct {
+		name  string
+		class string
+		limit int
+		held  int // slots taken before the checked request
+		want  bool
+	}{
+		{name: "under the cap", class: ClassSearch, limit: 3, held: 2, want: true},
+		{name: "at the cap", class: ClassSearch, limit: 3, held: 3, want: false},
+		{name: "single slot taken", class: ClassStore, limit: 1, held: 1, want: false},
+		{name: "single slot free", class: ClassStore, limit: 1, held: 0, want: true},
+		{name: "zero cap is unlimited", class: ClassAdmin, limit: 0, held: 100, want: true},
+		{name: "negative cap is unlimited", class: ClassAdmin, limit: -1, held: 100, want: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := New(Options{Limits: map[string]int{tc.class: tc.limit}})
+			ctx := context.Background()
+			for i := range tc.held {
+				if _, ok := s.Acquire(ctx, tc.class); !ok {
+					t.Fatalf("request %d was shed under the cap of %d", i+1, tc.limit)
+				}
+			}
+			release, ok := s.Acquire(ctx, tc.class)
+			if ok != tc.want {
+				t.Fatalf("request with %d slots held admitted = %v, want %v", tc.held, ok, tc.want)
+			}
+			if ok {
+				release()
+			}
+		})
+	}
+}
+
+func TestClassesAreCappedSeparately(t *testing.T) {
+	s := New(Options{Limits: map[string]int{ClassSearch: 1, ClassStore: 1}})
+	ctx := context.Background()
+
+	if _, ok := s.Acquire(ctx, ClassStore); !ok {
+		t.Fatal("first store request was shed")
+	}
+	if _, ok := s.Acquire(ctx, ClassStore); ok {
+		t.Fatal("store request over the cap was admitted")
+	}
+	if _, ok := s.Acquire(ctx, ClassSearch); !ok {
+		t.Error("search request was shed because the store class is full")
+	}
+	if _, ok := s.Acquire(ctx, "unknown"); !ok {
+		t.Error("request of an uncapped class was shed")
+	}
+}
+
+func TestReleaseFreesSlot(t *testing.T) {
+	s := New(Options{Limits: map[string]int{ClassSearch: 1}})
+	ctx := context.Background()
+
+	release, ok := s.Acquire(ctx, ClassSearch)
+	if !ok {
+		t.Fatal("first request was shed")
+	}
+	release()
+	if _, ok := s.Acquire(ctx, ClassSearch); !ok {
+		t.Error("request was shed after the slot was released")
+	}
+}
+
+func TestQueueTimeout(t *testing.T) {
+	s := New(Options{Limits: map[string]int{ClassSearch: 1}, QueueTimeout: 20 * time.Millisecond})
+	ctx := context.Background()
+	release, _ := s.Acquire(ctx, ClassSearch)
+
+	// A full class sheds once the queue timeout passes
+	start := time.Now()
+	if _, ok := s.Acquire(ctx, ClassSearch); ok {
+		t.Fatal("request was admitted to a full class")
+	}
+	if waited := time.Since(start); waited < 20*time.Millisecond {
+		t.Errorf("request was shed after %v, before the queue timeout", waited)
+	}
+
+	// A slot freed while the request waits admits it
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		release()
+	}()
+	s.opts.QueueTimeout = 5 * time.Second
+	if _, ok := s.Acquire(ctx, ClassSearch); !ok {
+		t.Error("request was shed although a slot was freed while it waited")
+	}
+}
+
+func TestCancelledWaitIsShed(t *testing.T) {
+	s := New(Options{Limits: map[string]int{ClassStore: 1}, QueueTimeout: 5 * time.Second})
+	s.Acquire(context.Background(), ClassStore)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if _, ok := s.Acquire(ctx, ClassStore); ok {
+		t.Fatal("cancelled request was admitted to a full class")
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("cancelled request waited %v for a slot", waited)
+	}
+}
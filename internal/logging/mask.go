@@ -0,0 +1,185 @@
+package logging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// fullContent enables logging of raw message and personal info content; development only
+var fullContent atomic.Bool
+
+// contentParams lists query parameters whose values carry user content
+var contentParams = map[string]bool{
+	"query":   true,
+	"q":       true,
+	"text":    true,
+	"content": true,
+}
+
+// secretParams lists query parameters whose values carry credentials
+var secretParams = map[string]bool{
+	"api_key": true,
+	"apikey":  true,
+	"token":   true,
+	"key":     true,
+}
+
+// SetFullContent toggles full content logging
+func SetFullContent(enabled bool) {
+	fullContent.Store(enabled)
+}
+
+// FullContent reports whether full content logging is enabled
+func FullContent() bool {
+	return fullContent.Load()
+}
+
+// Content masks user-provided text for logs, traces, and error reports.
+// The result keeps the length and a short hash so identical inputs can be correlated.
+func Content(text string) string {
+	if fullContent.Load() {
+		return text
+	}
+	return fmt.Sprintf("[redacted len=%d sha256=%s]", len(text), shortHash(text))
+}
+
+// Secret masks a credential; secrets are never logged, even in development
+func Secret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return fmt.Sprintf("[secret sha256=%s]", shortHash(value))
+}
+
+// Truncate shortens text to max bytes when full content logging is disabled
+func Truncate(text string, max int) string {
+	if fullContent.Load() || len(text) <= max {
+		return text
+	}
+	return text[:max] + "...(truncated)"
+}
+
+// Query returns the raw query string with sensitive parameter values masked
+func Query(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "[unparseable query]"
+	}
+
+	for name, vals := range values {
+		lower := strings.ToLower(name)
+		for i, val := range vals {
+			switch {
+			case secretParams[lower]:
+				vals[i] = Secret(val)
+			case contentParams[lower]:
+				vals[i] = Content(val)
+			}
+		}
+	}
+	return values.Encode()
+}
+
+// Path returns a request path with its query string masked
+func Path(path, rawQuery string) string {
+	if rawQuery == "" {
+		return path
+	}
+	return path + "?" + Query(rawQuery)
+}
+
+// shortHash returns the first 12 hex characters of the SHA-256 of text
+func shortHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// contentFields lists JSON fields whose values carry user content
+var contentFields = map[string]bool{
+	"content":  true,
+	"question": true,
+	"answer":   true,
+	"query":    true,
+	"text":     true,
+}
+
+// secretFields lists JSON fields and headers whose values carry credentials
+var secretFields = map[string]bool{
+	"api_key":       true,
+	"apikey":        true,
+	"token":         true,
+	"password":      true,
+	"authorization": true,
+	"x-api-key":     true,
+	"cookie":        true,
+	"set-cookie":    true,
+}
+
+// JSON masks content and credential fields in a JSON document. Bodies that are not
+// valid JSON are masked as a whole.
+func JSON(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return Content(string(body))
+	}
+
+	masked, err := json.Marshal(maskValue("", doc))
+	if err != nil {
+		return Content(string(body))
+	}
+	return string(masked)
+}
+
+// Headers returns a copy of the headers with credentials masked
+func Headers(headers http.Header) map[string]string {
+	masked := make(map[string]string, len(headers))
+	for name, values := range headers {
+		value := strings.Join(values, ", ")
+		if secretFields[strings.ToLower(name)] {
+			value = Secret(value)
+		}
+		masked[name] = value
+	}
+	return masked
+}
+
+// maskValue walks a decoded JSON value and masks sensitive fields
+func maskValue(key string, value interface{}) interface{} {
+	lower := strings.ToLower(key)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = maskValue(k, child)
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = maskValue(key, child)
+		}
+		return v
+	case string:
+		if secretFields[lower] {
+			return Secret(v)
+		}
+		if contentFields[lower] {
+			return Content(v)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,166 @@
+// Package manifest defines the versioned manifest written next to every export and checked by
+// every import, so data moved between environments with different schemas or embedding settings
+// is rejected instead of being loaded into the wrong place.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"refo-rag-server/internal/storage"
+)
+
+// FormatVersion is the version of the manifest layout written by this build
+const FormatVersion = 1
+
+// FileName is the manifest's name inside an export directory
+const FileName = "manifest.json"
+
+// ErrChecksumMismatch is returned when an exported file doesn't match its recorded checksum
+var ErrChecksumMismatch = errors.New("file checksum mismatch")
+
+// Manifest describes an export: its format and schema versions, the embedding settings of its
+// vectors, record counts, and a checksum of every file
+type Manifest struct {
+	FormatVersion int              `json:"format_version"`
+	SchemaVersion int              `json:"schema_version"`
+	Kind          string           `json:"kind"`
+	CreatedAt     time.Time        `json:"created_at"`
+	Tables        map[string]int64 `json:"tables,omitempty"`
+	Collections   []Collection     `json:"collections,omitempty"`
+	Files         []File           `json:"files"`
+}
+
+// Collection describes the vectors of one content type in an export
+type Collection struct {
+	ContentType string `json:"content_type"`
+	Name        string `json:"name"`
+	Model       string `json:"model"`
+	Dimension   int    `json:"dimension"`
+	Distance    string `json:"distance"`
+	Points      int64  `json:"points"`
+	File        string `json:"file,omitempty"`
+}
+
+// File is an exported file and its checksum
+type File struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// New creates a manifest of the given kind for this build's format and schema
+func New(kind string) *Manifest {
+	return &Manifest{
+		FormatVersion: FormatVersion,
+		SchemaVersion: storage.SchemaVersion,
+		Kind:          kind,
+		CreatedAt:     time.Now().UTC(),
+		Files:         []File{},
+	}
+}
+
+// AddFile records the size and checksum of a file in dir
+func (m *Manifest) AddFile(dir string, name string) error {
+	size, sum, err := checksum(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	m.Files = append(m.Files, File{Name: name, Size: size, SHA256: sum})
+	return nil
+}
+
+// Write stores the manifest in dir
+func (m *Manifest) Write(dir string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, FileName), data, 0600); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return nil
+}
+
+// Read loads the manifest of an export directory
+func Read(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+
+	return m, nil
+}
+
+// Validate checks that an export in dir can be imported by this build: the manifest is of the
+// expected kind and a known format, its schema isn't newer than this build's, every file matches
+// its checksum, and every collection uses the configured embedding model and dimension. Set
+// allowEmbeddingMismatch to skip the last check.
+func (m *Manifest) Validate(dir string, kind string, configured map[string]storage.CollectionConfig, allowEmbeddingMismatch bool) error {
+	if m.Kind != kind {
+		return fmt.Errorf("manifest is for a %q export, expected %q", m.Kind, kind)
+	}
+	if m.FormatVersion < 1 || m.FormatVersion > FormatVersion {
+		return fmt.Errorf("unsupported manifest format version %d (this build reads up to %d)", m.FormatVersion, FormatVersion)
+	}
+	if m.SchemaVersion > storage.SchemaVersion {
+		return fmt.Errorf("export has schema version %d, newer than this build's %d", m.SchemaVersion, storage.SchemaVersion)
+	}
+
+	for _, file := range m.Files {
+		size, sum, err := checksum(filepath.Join(dir, file.Name))
+		if err != nil {
+			return err
+		}
+		if size != file.Size || sum != file.SHA256 {
+			return fmt.Errorf("%w: %s", ErrChecksumMismatch, file.Name)
+		}
+	}
+
+	for _, collection := range m.Collections {
+		cfg, ok := configured[collection.ContentType]
+		if !ok {
+			return fmt.Errorf("export contains unknown content type %q", collection.ContentType)
+		}
+		if allowEmbeddingMismatch {
+			continue
+		}
+		if cfg.Model != collection.Model || cfg.Dimension != collection.Dimension {
+			return fmt.Errorf("%s vectors were exported with %s/%d but the collection is configured with %s/%d",
+				collection.ContentType, collection.Model, collection.Dimension, cfg.Model, cfg.Dimension)
+		}
+	}
+
+	return nil
+}
+
+// checksum returns a file's size and SHA-256
+func checksum(path string) (int64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,487 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds all server metrics
+var Registry = prometheus.NewRegistry()
+
+// SlowOperations counts Postgres queries, Qdrant calls, embedding requests, and HTTP
+// requests that exceeded their slow threshold
+var SlowOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "slow_operations_total",
+	Help:      "Operations that exceeded their slow threshold.",
+}, []string{"component", "operation"})
+
+// OperationDuration records the latency of dependency calls
+var OperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "rag",
+	Name:      "operation_duration_seconds",
+	Help:      "Latency of Postgres queries, Qdrant calls, and embedding requests.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"component", "operation"})
+
+// QueueDepth reports the number of work queue items waiting or leased
+var QueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "rag",
+	Name:      "queue_depth",
+	Help:      "Work queue items waiting or leased.",
+}, []string{"kind"})
+
+// QueueLag reports the age of the oldest due work queue item no worker holds
+var QueueLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "rag",
+	Name:      "queue_consumer_lag_seconds",
+	Help:      "Age of the oldest due work queue item not leased by a worker.",
+}, []string{"kind"})
+
+// QueueProcessed counts processed work queue items by outcome
+var QueueProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "queue_items_processed_total",
+	Help:      "Work queue items processed, by kind and outcome.",
+}, []string{"kind", "outcome"})
+
+// InvalidEmbeddings counts vectors rejected before being written to a collection
+var InvalidEmbeddings = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "invalid_embeddings_total",
+	Help:      "Vectors rejected before storage for a wrong dimension, non-finite components or zero norm.",
+}, []string{"collection", "reason"})
+
+// QdrantCollectionMissing counts requests that found their collection missing in Qdrant, by whether
+// re-initializing the collection recovered it (recovered, failed, throttled within the cooldown, or
+// disabled)
+var QdrantCollectionMissing = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "qdrant_collection_missing_total",
+	Help:      "Qdrant requests that found their collection missing, by collection and recovery outcome.",
+}, []string{"collection", "outcome"})
+
+// EmbeddingRejections counts texts the embedding provider refused for a content policy or their length
+var EmbeddingRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "embedding_rejections_total",
+	Help:      "Texts the embedding provider refused, by model and reason (policy or length).",
+}, []string{"model", "reason"})
+
+// DanglingVectors counts search hits whose conversation no longer exists in Postgres
+var DanglingVectors = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "dangling_vectors_total",
+	Help:      "Search hits referencing a conversation missing from Postgres.",
+})
+
+// EmbeddingTokens counts the tokens billed for embedding calls
+var EmbeddingTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "embedding_tokens_total",
+	Help:      "Tokens billed by the embedding provider, by model.",
+}, []string{"model"})
+
+// EmbeddingRequests counts embedding calls
+var EmbeddingRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "embedding_requests_total",
+	Help:      "Embedding provider calls, by model.",
+}, []string{"model"})
+
+// EmbeddingsDeduplicated counts embeddings reused instead of requested again from the provider
+var EmbeddingsDeduplicated = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "embeddings_deduplicated_total",
+	Help:      "Embeddings of texts repeated within one batch or request that weren't sent to the provider again, by source.",
+}, []string{"source"})
+
+// EmbeddingBudgetRejections counts embedding calls refused because the budget was spent
+var EmbeddingBudgetRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "embedding_budget_rejections_total",
+	Help:      "Embedding calls refused because the daily or monthly token budget was spent, by period.",
+}, []string{"period"})
+
+// QuotaWarnings counts embedding budget warnings posted to the quota webhook
+var QuotaWarnings = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "quota_warnings_total",
+	Help:      "Embedding budget warnings posted to the quota webhook, by period and outcome (sent, error).",
+}, []string{"period", "outcome"})
+
+// RetrieveRoutes counts the query classifier's decisions on the retrieve endpoint
+var RetrieveRoutes = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "retrieve_routes_total",
+	Help:      "Retrieve queries the classifier routed to or away from each collection, by target and searched.",
+}, []string{"target", "searched"})
+
+// RequestsCancelled counts requests whose client disconnected or gave up before the response
+var RequestsCancelled = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "requests_cancelled_total",
+	Help:      "Requests abandoned by their client before the response was written, by route; their dependency calls are aborted.",
+}, []string{"route"})
+
+// RequestsShed counts requests refused by load shedding
+var RequestsShed = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "requests_shed_total",
+	Help:      "Requests refused with 503 because their endpoint class was at its concurrency cap, by class.",
+}, []string{"class"})
+
+// DeadlineSkippedStages counts the optional search stages skipped because too little time
+// remained until the caller's deadline
+var DeadlineSkippedStages = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "deadline_skipped_stages_total",
+	Help:      "Optional search stages skipped because too little time remained until the caller's X-Request-Deadline, by kind and stage.",
+}, []string{"kind", "stage"})
+
+// DeadlineExceeded counts the requests that ran out of time before the caller's deadline
+var DeadlineExceeded = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "deadline_exceeded_total",
+	Help:      "Requests answered with 504 because the caller's X-Request-Deadline passed, by route.",
+}, []string{"route"})
+
+// IngestShaped counts the saves checked by the ingestion shaper, by whether its bucket had room
+var IngestShaped = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "ingest_shaped_total",
+	Help:      "Saves checked by the ingestion shaper, by lane and decision (admitted, full).",
+}, []string{"lane", "decision"})
+
+// IngestShaperWait observes how long embeddings waited for room in the ingestion shaper
+var IngestShaperWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "rag",
+	Name:      "ingest_shaper_wait_seconds",
+	Help:      "Time embeddings waited for room in the ingestion shaper's bucket, by lane.",
+	Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60},
+}, []string{"lane"})
+
+// IngestShaperLevel tracks how full the ingestion shaper's bucket is, in embeddings
+var IngestShaperLevel = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "rag",
+	Name:      "ingest_shaper_level",
+	Help:      "Embeddings in the ingestion shaper's leaky bucket, draining at the configured rate.",
+})
+
+// InflightRequests tracks the requests holding a concurrency slot
+var InflightRequests = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "rag",
+	Name:      "inflight_requests",
+	Help:      "Requests being served in capped endpoint classes, by class.",
+}, []string{"class"})
+
+// SignatureRejections counts signed requests whose signature failed verification
+var SignatureRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "signature_rejections_total",
+	Help:      "Signed requests rejected with 401, by reason (malformed, unknown_client, stale, replayed, bad_signature).",
+}, []string{"reason"})
+
+// BlockedRequests counts requests refused by the IP allow and deny lists
+var BlockedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "blocked_requests_total",
+	Help:      "Requests refused with 403 by IP filtering, by route group (global, admin) and reason (denied, not_allowed).",
+}, []string{"group", "reason"})
+
+// EmbeddingDrift reports the cosine distance between stored vectors and the live model's vectors
+// of the same text, measured on the last drift sample
+var EmbeddingDrift = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "rag",
+	Name:      "embedding_drift_distance",
+	Help:      "Cosine distance between stored and re-embedded vectors of the last drift sample, by stat (mean, max).",
+}, []string{"stat"})
+
+// EmbeddingDriftAlerts counts drift samples whose mean distance exceeded the alert threshold
+var EmbeddingDriftAlerts = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "embedding_drift_alerts_total",
+	Help:      "Drift samples whose mean cosine distance exceeded the alert threshold.",
+})
+
+// EmbeddingAnomalies counts shifts in users' conversation vectors flagged by the anomaly scan
+var EmbeddingAnomalies = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "embedding_anomalies_total",
+	Help:      "Anomalies flagged in users' conversation vectors, by kind (topic_shift, novel_topic, distress) and outcome (sent, error, logged).",
+}, []string{"kind", "outcome"})
+
+// StandingQueryMatches counts saved conversations matching a standing query, by delivery channel
+// (webhook, stream) and outcome
+var StandingQueryMatches = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "standing_query_matches_total",
+	Help:      "Standing query match deliveries, by channel (webhook, stream) and outcome (sent, error, dropped).",
+}, []string{"channel", "outcome"})
+
+// ConversationChunks counts the chunks of conversations embedded in chunks, by whether their vector
+// was embedded or reused from the conversation's stored chunks
+var ConversationChunks = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "conversation_chunks_total",
+	Help:      "Chunks of conversations embedded in chunks, by outcome (embedded, reused).",
+}, []string{"outcome"})
+
+// CacheRequests counts lookups in the in-process caches, by cache and result
+var CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "cache_requests_total",
+	Help:      "Lookups in the in-process caches, by cache (embedding, query) and result (hit, miss).",
+}, []string{"cache", "result"})
+
+// CacheEvictions counts entries dropped from the in-process caches, by cache and reason
+var CacheEvictions = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "cache_evictions_total",
+	Help:      "Entries evicted from the in-process caches, by cache and reason (capacity, expired).",
+}, []string{"cache", "reason"})
+
+// CacheEntries reports the entries held by each in-process cache
+var CacheEntries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "rag",
+	Name:      "cache_entries",
+	Help:      "Entries held by the in-process caches, by cache.",
+}, []string{"cache"})
+
+// FusionBanditChoices counts the searches served by each fusion bandit arm, by why it was chosen
+var FusionBanditChoices = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "fusion_bandit_choices_total",
+	Help:      "Searches served by each fusion bandit arm, by reason (sampled, frozen, baseline).",
+}, []string{"arm", "reason"})
+
+// FusionBanditFeedback counts the relevance ratings credited to each fusion bandit arm
+var FusionBanditFeedback = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "fusion_bandit_feedback_total",
+	Help:      "Relevance ratings credited to each fusion bandit arm, by outcome (relevant, irrelevant).",
+}, []string{"arm", "outcome"})
+
+// EventsPublished counts events published on the in-process event bus
+var EventsPublished = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "events_published_total",
+	Help:      "Events published on the in-process event bus, by topic.",
+}, []string{"topic"})
+
+// EventDeliveries counts event deliveries to subscribers by outcome (delivered, failed, dropped,
+// panicked); dropped events found the subscriber's queue full
+var EventDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "event_deliveries_total",
+	Help:      "Event deliveries to subscribers, by topic, subscriber and outcome (delivered, failed, dropped, panicked).",
+}, []string{"topic", "subscriber", "outcome"})
+
+// ShadowOperations counts saves and searches mirrored to the shadow embedding model, by outcome
+// (ok, error, dropped)
+var ShadowOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "shadow_operations_total",
+	Help:      "Saves and searches mirrored to the shadow embedding model, by operation and outcome (ok, error, dropped).",
+}, []string{"operation", "outcome"})
+
+// ShadowOverlap records the fraction of served search results the shadow model also returned,
+// among served results that have a shadow vector
+var ShadowOverlap = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Namespace: "rag",
+	Name:      "shadow_search_overlap_ratio",
+	Help:      "Fraction of served search results also returned by the shadow embedding model.",
+	Buckets:   prometheus.LinearBuckets(0.1, 0.1, 10),
+})
+
+// SearchRequests counts conversation searches by the pipeline variant that served them (primary,
+// canary) and outcome (ok, error)
+var SearchRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "search_requests_total",
+	Help:      "Conversation searches, by pipeline variant (primary, canary) and outcome (ok, error).",
+}, []string{"variant", "outcome"})
+
+// SearchDuration records the latency of conversation searches by pipeline variant
+var SearchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "rag",
+	Name:      "search_duration_seconds",
+	Help:      "Latency of conversation searches, by pipeline variant.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"variant"})
+
+// SearchTopScore records the score of the best result of conversation searches by pipeline variant
+var SearchTopScore = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "rag",
+	Name:      "search_top_score",
+	Help:      "Score of the best result of conversation searches that returned results, by pipeline variant.",
+	Buckets:   prometheus.LinearBuckets(0.1, 0.1, 10),
+}, []string{"variant"})
+
+// SearchFetchRetries counts extra candidate fetches of searches whose filters left too few results
+var SearchFetchRetries = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "search_fetch_retries_total",
+	Help:      "Searches fetched again with a larger candidate set because filters left fewer results than requested.",
+})
+
+// OutboundRequests counts attempts of outgoing HTTP requests to integrations by dependency and
+// outcome (a status class such as 2xx, or error)
+var OutboundRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "outbound_requests_total",
+	Help:      "Outgoing HTTP request attempts to integrations, by dependency, method and outcome (status class or error).",
+}, []string{"dependency", "method", "outcome"})
+
+// OutboundRequestDuration records the latency of outgoing HTTP request attempts by dependency
+var OutboundRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "rag",
+	Name:      "outbound_request_duration_seconds",
+	Help:      "Latency of outgoing HTTP request attempts until response headers, by dependency.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"dependency"})
+
+// OutboundRetries counts outgoing HTTP requests sent again after a transient failure
+var OutboundRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "outbound_retries_total",
+	Help:      "Outgoing HTTP requests retried after a network error or a retryable status, by dependency.",
+}, []string{"dependency"})
+
+// ScheduledTaskRuns counts runs of scheduled tasks by outcome (succeeded, failed, or skipped
+// because the previous run was still going)
+var ScheduledTaskRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Name:      "scheduled_task_runs_total",
+	Help:      "Scheduled task runs, by task and outcome (succeeded, failed, skipped).",
+}, []string{"task", "outcome"})
+
+// ScheduledTaskDuration records how long scheduled task runs take
+var ScheduledTaskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "rag",
+	Name:      "scheduled_task_duration_seconds",
+	Help:      "Duration of scheduled task runs, by task.",
+	Buckets:   prometheus.ExponentialBuckets(0.1, 4, 10),
+}, []string{"task"})
+
+// SLIRequests counts API requests by endpoint class and whether they were served (good) or failed
+// with a server error or were shed (bad); the availability SLI is good over all
+var SLIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Subsystem: "sli",
+	Name:      "requests_total",
+	Help:      "API requests, by endpoint class (search, store, admin) and outcome (good, bad: 5xx or shed).",
+}, []string{"class", "outcome"})
+
+// SLIRequestDuration records API request latency by endpoint class, with buckets around the
+// latency objectives and the trace ID of sampled requests as exemplars
+var SLIRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "rag",
+	Subsystem: "sli",
+	Name:      "request_duration_seconds",
+	Help:      "Latency of API requests, by endpoint class (search, store, admin).",
+	Buckets:   []float64{0.025, 0.05, 0.1, 0.25, 0.5, 0.75, 1, 1.5, 2, 3, 5, 10},
+}, []string{"class"})
+
+// SLIVectorBacklogAge reports the age of the oldest queued vector write, done or not; conversations
+// saved since are stored but not yet searchable
+var SLIVectorBacklogAge = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "rag",
+	Subsystem: "sli",
+	Name:      "vector_write_backlog_age_seconds",
+	Help:      "Age of the oldest conversation whose queued vector write hasn't completed.",
+})
+
+// SLIOutboxLag reports the longest any due work queue item has waited for a worker, over all kinds
+var SLIOutboxLag = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "rag",
+	Subsystem: "sli",
+	Name:      "outbox_lag_seconds",
+	Help:      "Age of the oldest due work queue item not leased by a worker, over all kinds.",
+})
+
+// SLIDeadLetters reports the queue items that exhausted their attempts and wait for an operator
+var SLIDeadLetters = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "rag",
+	Subsystem: "sli",
+	Name:      "dead_letters",
+	Help:      "Dead-lettered work queue items awaiting retry or discard, by kind.",
+}, []string{"kind"})
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		SlowOperations,
+		OperationDuration,
+		QueueDepth,
+		QueueLag,
+		QueueProcessed,
+		InvalidEmbeddings,
+		QdrantCollectionMissing,
+		EmbeddingRejections,
+		DanglingVectors,
+		EmbeddingTokens,
+		EmbeddingRequests,
+		EmbeddingsDeduplicated,
+		EmbeddingBudgetRejections,
+		QuotaWarnings,
+		RetrieveRoutes,
+		RequestsShed,
+		DeadlineSkippedStages,
+		DeadlineExceeded,
+		IngestShaped,
+		IngestShaperWait,
+		IngestShaperLevel,
+		RequestsCancelled,
+		InflightRequests,
+		SignatureRejections,
+		BlockedRequests,
+		EmbeddingDrift,
+		EmbeddingDriftAlerts,
+		EmbeddingAnomalies,
+		StandingQueryMatches,
+		ConversationChunks,
+		CacheRequests,
+		CacheEvictions,
+		CacheEntries,
+		FusionBanditChoices,
+		FusionBanditFeedback,
+		ShadowOperations,
+		EventsPublished,
+		EventDeliveries,
+		ShadowOverlap,
+		SearchRequests,
+		SearchDuration,
+		SearchTopScore,
+		SearchFetchRetries,
+		OutboundRequests,
+		OutboundRequestDuration,
+		OutboundRetries,
+		ScheduledTaskRuns,
+		ScheduledTaskDuration,
+		SLIRequests,
+		SLIRequestDuration,
+		SLIVectorBacklogAge,
+		SLIOutboxLag,
+		SLIDeadLetters,
+	)
+}
+
+// Handler serves the metrics in Prometheus text format, or in OpenMetrics format with exemplars
+// when the scraper asks for it
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry, EnableOpenMetrics: true})
+}
+
+// ObserveWithTrace records a latency, attaching traceID as an exemplar when it is set
+func ObserveWithTrace(observer prometheus.Observer, seconds float64, traceID string) {
+	if exemplars, ok := observer.(prometheus.ExemplarObserver); ok && traceID != "" {
+		exemplars.ObserveWithExemplar(seconds, prometheus.Labels{"trace_id": traceID})
+		return
+	}
+	observer.Observe(seconds)
+}
@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TenantOther labels the tenants that don't get a label of their own
+const TenantOther = "other"
+
+// tenantRankInterval is how often the busiest tenants are re-ranked
+const tenantRankInterval = time.Minute
+
+// maxRankedTenants bounds the tenants whose volume is tracked for ranking; tenants first seen
+// beyond it stay in the other bucket until a restart
+const maxRankedTenants = 10000
+
+// TenantRequests counts API requests per tenant; tenants beyond the labelled ones are counted as other
+var TenantRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Subsystem: "tenant",
+	Name:      "requests_total",
+	Help:      "API requests, by tenant (the busiest, pinned ones, or other), endpoint class and status class (2xx, 4xx, 5xx).",
+}, []string{"tenant", "class", "status"})
+
+// TenantEmbeddingTokens counts the embedding tokens billed per tenant
+var TenantEmbeddingTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rag",
+	Subsystem: "tenant",
+	Name:      "embedding_tokens_total",
+	Help:      "Tokens billed by the embedding provider, by tenant (the busiest, pinned ones, or other) and model.",
+}, []string{"tenant", "model"})
+
+func init() {
+	Registry.MustRegister(TenantRequests, TenantEmbeddingTokens)
+}
+
+// TenantLabels decides which tenants are labelled by name: pinned tenants always are, and so are
+// the topN tenants with the most requests since startup; everyone else shares TenantOther. The
+// ranking is refreshed every minute, so a tenant keeps its series while it stays among the busiest
+type TenantLabels struct {
+	pinned map[string]bool
+	topN   int
+
+	mu       sync.Mutex
+	counts   map[string]int64
+	top      map[string]bool
+	rankedAt time.Time
+}
+
+var tenantLabels *TenantLabels
+
+// SetTenantLabels enables the per-tenant metrics with topN ranked tenants plus the pinned ones.
+// Call it before serving; with neither set the per-tenant metrics aren't recorded
+func SetTenantLabels(pinned []string, topN int) {
+	if len(pinned) == 0 && topN <= 0 {
+		tenantLabels = nil
+		return
+	}
+	labels := &TenantLabels{
+		pinned:   make(map[string]bool, len(pinned)),
+		topN:     max(topN, 0),
+		counts:   make(map[string]int64),
+		top:      make(map[string]bool),
+		rankedAt: time.Now(),
+	}
+	for _, tenantID := range pinned {
+		labels.pinned[tenantID] = true
+	}
+	tenantLabels = labels
+}
+
+// tenantLabel adds requests to a tenant's volume and returns its label, or false if per-tenant
+// metrics are off
+func tenantLabel(tenantID string, requests int64) (string, bool) {
+	if tenantLabels == nil {
+		return "", false
+	}
+	return tenantLabels.label(tenantID, requests), true
+}
+
+// label adds requests to a tenant's volume and returns its label
+func (tl *TenantLabels) label(tenantID string, requests int64) string {
+	if tl.pinned[tenantID] {
+		return tenantID
+	}
+	if tl.topN == 0 {
+		return TenantOther
+	}
+
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	if _, tracked := tl.counts[tenantID]; tracked || len(tl.counts) < maxRankedTenants {
+		tl.counts[tenantID] += requests
+	}
+	// While fewer tenants than topN are labelled, new ones are labelled as they arrive
+	if len(tl.top) < tl.topN {
+		tl.top[tenantID] = true
+	}
+	if now := time.Now(); now.Sub(tl.rankedAt) >= tenantRankInterval {
+		tl.rank()
+		tl.rankedAt = now
+	}
+
+	if tl.top[tenantID] {
+		return tenantID
+	}
+	return TenantOther
+}
+
+// rank keeps the topN tenants with the most requests
+func (tl *TenantLabels) rank() {
+	tenants := make([]string, 0, len(tl.counts))
+	for tenantID := range tl.counts {
+		tenants = append(tenants, tenantID)
+	}
+	sort.Slice(tenants, func(a, b int) bool {
+		if tl.counts[tenants[a]] != tl.counts[tenants[b]] {
+			return tl.counts[tenants[a]] > tl.counts[tenants[b]]
+		}
+		return tenants[a] < tenants[b]
+	})
+
+	tl.top = make(map[string]bool, tl.topN)
+	for _, tenantID := range tenants[:min(tl.topN, len(tenants))] {
+		tl.top[tenantID] = true
+	}
+}
+
+// ObserveTenantRequest counts a finished API request of a tenant
+func ObserveTenantRequest(tenantID string, class string, status int) {
+	label, ok := tenantLabel(tenantID, 1)
+	if !ok {
+		return
+	}
+	TenantRequests.WithLabelValues(label, class, statusClass(status)).Inc()
+}
+
+// AddTenantEmbeddingTokens counts the embedding tokens billed for a tenant
+func AddTenantEmbeddingTokens(tenantID string, model string, tokens int) {
+	label, ok := tenantLabel(tenantID, 0)
+	if !ok {
+		return
+	}
+	TenantEmbeddingTokens.WithLabelValues(label, model).Add(float64(tokens))
+}
+
+// statusClass returns the class of an HTTP status, such as 2xx
+func statusClass(status int) string {
+	return strconv.Itoa(status/100) + "xx"
+}
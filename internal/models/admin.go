@@ -0,0 +1,135 @@
+package models
+
+import (
+	"time"
+
+	"refo-rag-server/internal/health"
+)
+
+// CollectionStatusResponse describes a managed collection's configured and live settings
+type CollectionStatusResponse struct {
+	ContentType string                `json:"content_type"`
+	Name        string                `json:"name"`
+	Model       string                `json:"model"`
+	Dimension   int                   `json:"dimension"`
+	Distance    string                `json:"distance"`
+	Datatype    string                `json:"datatype"`
+	Configured  ClusterSettings       `json:"configured"`
+	Live        *LiveCollectionStatus `json:"live,omitempty"`
+	Error       string                `json:"error,omitempty"`
+}
+
+// ClusterSettings represents Qdrant sharding and replication parameters
+type ClusterSettings struct {
+	ShardNumber            int `json:"shard_number,omitempty"`
+	ReplicationFactor      int `json:"replication_factor,omitempty"`
+	WriteConsistencyFactor int `json:"write_consistency_factor,omitempty"`
+}
+
+// LiveCollectionStatus represents the state reported by Qdrant for a collection
+type LiveCollectionStatus struct {
+	Status      string          `json:"status"`
+	PointsCount int64           `json:"points_count"`
+	Datatype    string          `json:"datatype"`
+	Cluster     ClusterSettings `json:"cluster"`
+}
+
+// CollectionListResponse represents the admin collection listing
+type CollectionListResponse struct {
+	Collections []CollectionStatusResponse `json:"collections"`
+}
+
+// MaintenanceStatus represents the read-only maintenance mode state
+type MaintenanceStatus struct {
+	Enabled bool   `json:"enabled"`
+	Standby bool   `json:"standby,omitempty"` // a standby stays read-only; writes go to the primary
+	Reason  string `json:"reason,omitempty"`
+	Since   string `json:"since,omitempty"`
+}
+
+// HealthHistoryResponse represents the recent health checks of every dependency
+type HealthHistoryResponse struct {
+	Dependencies []health.DependencyHistory `json:"dependencies"`
+}
+
+// MaintenanceUpdateRequest represents a request to toggle maintenance mode
+type MaintenanceUpdateRequest struct {
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Reason  string `json:"reason"`
+}
+
+// FeatureFlagResponse represents a feature flag and its tenant overrides
+type FeatureFlagResponse struct {
+	Name        string          `json:"name"`
+	Enabled     bool            `json:"enabled"`
+	Description string          `json:"description,omitempty"`
+	Tenants     map[string]bool `json:"tenants,omitempty"`
+}
+
+// FeatureFlagListResponse represents the loaded feature flags
+type FeatureFlagListResponse struct {
+	Flags    []FeatureFlagResponse `json:"flags"`
+	LoadedAt string                `json:"loaded_at"`
+}
+
+// ReindexCounts summarizes a reindex of one kind of memory. In a dry run the counts are what would
+// be indexed or skipped, and IDs lists the records that would be re-embedded.
+type ReindexCounts struct {
+	VectorsDeleted int64    `json:"vectors_deleted"`
+	Indexed        int      `json:"indexed"`
+	Skipped        int      `json:"skipped"`
+	Failed         int      `json:"failed"`
+	FailedIDs      []string `json:"failed_ids"`
+	IDs            []string `json:"ids,omitempty"`
+	TextBytes      int64    `json:"text_bytes"`
+}
+
+// UserReindexResponse represents the result of rebuilding a user's vectors
+type UserReindexResponse struct {
+	JobID         string        `json:"job_id"`
+	DryRun        bool          `json:"dry_run"`
+	UserID        string        `json:"user_id"`
+	Conversations ReindexCounts `json:"conversations"`
+	PersonalInfo  ReindexCounts `json:"personal_info"`
+	DurationMs    int64         `json:"duration_ms"`
+}
+
+// UserDataCounts counts the records stored for a user
+type UserDataCounts struct {
+	Conversations       int64 `json:"conversations"`
+	Messages            int64 `json:"messages"`
+	PersonalInfo        int64 `json:"personal_info"`
+	Sessions            int64 `json:"sessions"`
+	Profiles            int64 `json:"profiles"`
+	Account             int64 `json:"account"` // the users registry row
+	SearchLogs          int64 `json:"search_logs"`
+	QueryAdapters       int64 `json:"query_adapters"`
+	StandingQueries     int64 `json:"standing_queries"`
+	ConversationVectors int64 `json:"conversation_vectors"`
+	PersonalInfoVectors int64 `json:"personal_info_vectors"`
+}
+
+// Empty reports whether nothing is stored for the user
+func (c UserDataCounts) Empty() bool {
+	return c == UserDataCounts{}
+}
+
+// Confirmation is a token that must be echoed back to execute a bulk deletion
+type Confirmation struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UserDeletionResponse represents a requested or executed deletion of all of a user's data. Until
+// the deletion is confirmed, Scope is what would be deleted and Confirmation holds the token.
+type UserDeletionResponse struct {
+	JobID        string         `json:"job_id"`
+	UserID       string         `json:"user_id"`
+	Deleted      bool           `json:"deleted"`
+	Scope        UserDataCounts `json:"scope"`
+	Confirmation *Confirmation  `json:"confirmation,omitempty"`
+	DurationMs   int64          `json:"duration_ms"`
+
+	// CertificateID identifies the deletion certificate of an executed deletion
+	CertificateID string `json:"certificate_id,omitempty"`
+}
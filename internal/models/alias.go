@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Reasons a conversation ID became an alias
+const (
+	AliasReasonMerge       = "merge"        // the conversation was merged into another
+	AliasReasonImportRemap = "import_remap" // an import stored the conversation under a new ID
+)
+
+// AliasReasons lists the valid alias reasons
+var AliasReasons = []string{AliasReasonMerge, AliasReasonImportRemap}
+
+// IsValidAliasReason reports whether reason is a known alias reason
+func IsValidAliasReason(reason string) bool {
+	for _, r := range AliasReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// ConversationAlias is an old conversation ID that resolves to the conversation that replaced it
+type ConversationAlias struct {
+	AliasID        string    `json:"alias_id"`
+	ConversationID string    `json:"conversation_id"`
+	Reason         string    `json:"reason"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ConversationAliasRequest represents a request to make an old conversation ID resolve to another conversation
+type ConversationAliasRequest struct {
+	ConversationID string `json:"conversation_id" binding:"required"`
+	Reason         string `json:"reason" binding:"required"` // merge or import_remap
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// AnalyticsMessage is a message row of an analytics export
+type AnalyticsMessage struct {
+	ConversationID string
+	MessageID      string
+	Position       int
+	Role           string
+	Content        string
+	Speaker        string
+	DisplayName    string
+	CreatedAt      time.Time
+}
+
+// AnalyticsExportFile is a file written by an analytics export
+type AnalyticsExportFile struct {
+	Table string `json:"table"`
+	Key   string `json:"key"`
+	Rows  int64  `json:"rows"`
+}
+
+// AnalyticsExport reports the export of one day's usage data; it is also stored as the day's
+// manifest next to the exported files
+type AnalyticsExport struct {
+	Date          string                `json:"date"`
+	SchemaVersion int                   `json:"schema_version"`
+	Files         []AnalyticsExportFile `json:"files"`
+	ExportedAt    time.Time             `json:"exported_at"`
+	DurationMs    int64                 `json:"duration_ms"`
+}
+
+// AnalyticsExportRequest represents a request to export one day's usage data
+type AnalyticsExportRequest struct {
+	// Date is the UTC day to export as YYYY-MM-DD
+	Date string `json:"date" binding:"required"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// API key scopes; admin implies write and write implies read
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+
+	// ScopeExperiment permits per-request retrieval overrides. It grants no access by itself and is
+	// implied by admin
+	ScopeExperiment = "experiment"
+)
+
+// scopeRanks orders scopes so a broader scope grants the narrower ones
+var scopeRanks = map[string]int{ScopeRead: 1, ScopeWrite: 2, ScopeAdmin: 3}
+
+// APIKey is a service account credential. Only a hash of the secret is stored; the secret itself
+// is returned once, when the key is created or rotated
+type APIKey struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Prefix string   `json:"prefix"` // leading characters of the secret, to recognize a key
+	Scopes []string `json:"scopes"`
+
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+
+	// RotatedTo is the ID of the key that replaced this one
+	RotatedTo string `json:"rotated_to,omitempty"`
+}
+
+// Active reports whether the key is neither revoked nor expired at now
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil && !k.RevokedAt.After(now) {
+		return false
+	}
+	return k.ExpiresAt == nil || k.ExpiresAt.After(now)
+}
+
+// HasScope reports whether the key grants scope, directly or through a broader scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, granted := range k.Scopes {
+		if scope == ScopeExperiment {
+			if granted == ScopeExperiment || granted == ScopeAdmin {
+				return true
+			}
+			continue
+		}
+		if scopeRanks[granted] >= scopeRanks[scope] {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyCreateRequest represents a request to create a service account key
+type APIKeyCreateRequest struct {
+	Name      string     `json:"name" binding:"required,max=255"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=read write admin experiment"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// APIKeyRotateRequest represents a request to replace a key with a new secret
+type APIKeyRotateRequest struct {
+	// GracePeriodSeconds keeps the old key valid for a while so clients can switch over
+	GracePeriodSeconds int `json:"grace_period_seconds,omitempty" binding:"min=0"`
+}
+
+// APIKeySecret is a newly issued key with its secret, which is not shown again
+type APIKeySecret struct {
+	APIKey *APIKey `json:"api_key"`
+	Secret string  `json:"secret"`
+}
+
+// APIKeyListResponse represents the service account keys
+type APIKeyListResponse struct {
+	APIKeys []*APIKey `json:"api_keys"`
+	Total   int       `json:"total"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// AuditVerification reports whether an audit log's hash chain is intact
+type AuditVerification struct {
+	Path  string `json:"path"`
+	Valid bool   `json:"valid"`
+
+	// Records is the number of chained records verified; Unchained counts records written before
+	// chaining was introduced, which precede the chain and can't be verified
+	Records   int64 `json:"records"`
+	Unchained int64 `json:"unchained"`
+
+	// LastHash is the hash of the last verified record
+	LastHash string `json:"last_hash,omitempty"`
+
+	// HeadSequence and HeadHash are the head stored with the log, which the chain must end at so
+	// records removed from the end are detected. Compare them with values logged or noted earlier
+	// to detect the log and its head being replaced together
+	HeadSequence int64  `json:"head_sequence,omitempty"`
+	HeadHash     string `json:"head_hash,omitempty"`
+
+	// The first record that breaks the chain, when Valid is false
+	FailedLine     int    `json:"failed_line,omitempty"`
+	FailedSequence int64  `json:"failed_sequence,omitempty"`
+	FailureReason  string `json:"failure_reason,omitempty"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"refo-rag-server/internal/filter"
+)
+
+// ConversationBulkDeleteRequest selects the conversations a bulk deletion removes. Every set
+// criterion must hold; at least one must be set
+type ConversationBulkDeleteRequest struct {
+	UserID        string     `json:"user_id,omitempty"`        // Only this user's conversations; every user's when empty
+	Filter        string     `json:"filter,omitempty"`         // Metadata filter, as in searches
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`  // Only conversations created at or after this time
+	CreatedBefore *time.Time `json:"created_before,omitempty"` // Only conversations created before this time
+
+	Expr *filter.Expr `json:"-"` // Parsed Filter; nil matches everything
+}
+
+// Empty reports whether the request sets no criterion, so it would select every conversation
+func (r *ConversationBulkDeleteRequest) Empty() bool {
+	return r.UserID == "" && r.Expr == nil && r.CreatedAfter == nil && r.CreatedBefore == nil
+}
+
+// ConversationBulkDeleteResponse represents a previewed or executed bulk deletion
+type ConversationBulkDeleteResponse struct {
+	JobID           string   `json:"job_id"`
+	DryRun          bool     `json:"dry_run"`
+	Conversations   int      `json:"conversations"`
+	ConversationIDs []string `json:"conversation_ids"`
+	DurationMs      int64    `json:"duration_ms"`
+
+	// CertificateID identifies the deletion certificate of an executed deletion that deleted anything
+	CertificateID string `json:"certificate_id,omitempty"`
+
+	// Confirmation is set when the deletion was requested without a confirmation token; nothing was deleted
+	Confirmation *Confirmation `json:"confirmation,omitempty"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// CacheStats is a snapshot of an in-process cache's counters since the process started
+type CacheStats struct {
+	Name       string  `json:"name"`
+	Entries    int     `json:"entries"`
+	Capacity   int     `json:"capacity"`
+	TTLSeconds float64 `json:"ttl_seconds"`
+	Hits       uint64  `json:"hits"`
+	Misses     uint64  `json:"misses"`
+
+	// HitRatio is hits over lookups; 0 before the first lookup
+	HitRatio float64 `json:"hit_ratio"`
+
+	// Evictions counts entries dropped to make room or because they had expired;
+	// ExpiredEvictions counts the latter
+	Evictions        uint64 `json:"evictions"`
+	ExpiredEvictions uint64 `json:"expired_evictions"`
+
+	// Flushes counts the times the cache was emptied on request, last at FlushedAt
+	Flushes   uint64     `json:"flushes"`
+	FlushedAt *time.Time `json:"flushed_at,omitempty"`
+}
+
+// CacheStatsResponse lists the stats of the enabled caches
+type CacheStatsResponse struct {
+	Caches []CacheStats `json:"caches"`
+}
+
+// CacheFlushResponse reports a cache flush
+type CacheFlushResponse struct {
+	Name    string `json:"name"`
+	Flushed int    `json:"flushed"`
+}
@@ -1,23 +1,85 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"refo-rag-server/internal/filter"
+)
 
 // Conversation represents a conversation record in the system
 type Conversation struct {
 	ID        string    `json:"id"`
 	UserID    string    `json:"user_id"`
+	SessionID string    `json:"session_id,omitempty"`
 	Question  string    `json:"question"`
 	Answer    string    `json:"answer"`
 	Metadata  string    `json:"metadata"` // JSON string for flexible metadata
+	Messages  []Message `json:"messages,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// Importance is the long-term importance score (0-1) assigned at save time, before decay
+	Importance float64 `json:"importance"`
+
+	// Pinned conversations are always included in retrieval context and never forgotten
+	Pinned bool `json:"pinned"`
+
+	// Suppression is set when the conversation is excluded from retrieval
+	Suppression *Suppression `json:"suppression,omitempty"`
+
+	// ContentHash is the hex SHA-256 of the text last embedded, empty if it was never embedded
+	ContentHash string `json:"content_hash,omitempty"`
+
+	// Unembedded is set when the embedding provider refused the conversation's text; the
+	// conversation is stored without a vector until it is retried
+	Unembedded *Unembedded `json:"unembedded,omitempty"`
+
+	// Status tracks whether the conversation's vector is written: pending, indexed, failed or archived
+	Status string `json:"status"`
+}
+
+// Unembedded records why the embedding provider refused a conversation's text
+type Unembedded struct {
+	Reason     string    `json:"reason"` // "policy" or "length"
+	Detail     string    `json:"detail,omitempty"`
+	RejectedAt time.Time `json:"rejected_at"`
+}
+
+// ConversationChunk is a run of a conversation's messages embedded on its own. The vectors of a
+// conversation's chunks are pooled into its vector, and an edit only re-embeds the chunks it changed
+type ConversationChunk struct {
+	Position    int       `json:"position"`
+	ContentHash string    `json:"content_hash"` // hex SHA-256 of the chunk's embedded text
+	Model       string    `json:"model"`        // embedding model the vector comes from
+	Dimension   int       `json:"dimension"`
+	Vector      []float32 `json:"vector"`
+}
+
+// LastMessageAt returns the timestamp of the most recent message, or CreatedAt if there are none
+func (c *Conversation) LastMessageAt() time.Time {
+	latest := c.CreatedAt
+	for _, msg := range c.Messages {
+		if msg.Timestamp != nil && msg.Timestamp.After(latest) {
+			latest = *msg.Timestamp
+		}
+	}
+	return latest
 }
 
 // ConversationSearchRequest represents a request to search conversations
 type ConversationSearchRequest struct {
-	Query  string `json:"query"`
-	UserID string `json:"user_id"`
-	Limit  int    `json:"limit"`
+	Query    string       `json:"query"`
+	UserID   string       `json:"user_id"`
+	Limit    int          `json:"limit"`
+	MinScore float32      `json:"min_score"` // Results scoring below it are dropped; 0 keeps all
+	Filter   *filter.Expr `json:"-"`         // Parsed metadata filter; nil matches everything
+
+	// Overrides tune retrieval for this search; only callers with the experiment scope may set them
+	Overrides *RetrievalOverrides `json:"-"`
+
+	// MaxAgeDays limits results to conversations created within this many days; nil applies the
+	// tenant's default and 0 searches all history
+	MaxAgeDays *int `json:"-"`
 }
 
 // ConversationSearchResult represents a search result with similarity score
@@ -29,20 +91,85 @@ type ConversationSearchResult struct {
 	Messages          []Message `json:"messages"`
 }
 
+// RelatedConversationsResponse represents the conversations most similar to a conversation,
+// most similar first
+type RelatedConversationsResponse struct {
+	ConversationID string                     `json:"conversation_id"`
+	UserID         string                     `json:"user_id"`
+	Related        []ConversationSearchResult `json:"related"`
+}
+
+// RecommendRequest represents a request for conversations like the liked examples and unlike the
+// disliked ones
+type RecommendRequest struct {
+	UserID   string   `json:"user_id" binding:"required"`
+	Liked    []string `json:"liked" binding:"required,min=1,max=20"`
+	Disliked []string `json:"disliked,omitempty" binding:"max=20"`
+
+	// Limit defaults to 10
+	Limit int `json:"limit,omitempty" binding:"omitempty,min=1,max=100"`
+}
+
+// RecommendResponse represents the conversations recommended from examples, most similar first
+type RecommendResponse struct {
+	UserID          string                     `json:"user_id"`
+	Recommendations []ConversationSearchResult `json:"recommendations"`
+}
+
+// Message roles
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleSystem    = "system"
+	RoleTool      = "tool"
+)
+
+// ValidRoles lists the accepted message roles
+var ValidRoles = []string{RoleUser, RoleAssistant, RoleSystem, RoleTool}
+
+// IsValidRole reports whether role is an accepted message role
+func IsValidRole(role string) bool {
+	for _, valid := range ValidRoles {
+		if role == valid {
+			return true
+		}
+	}
+	return false
+}
+
 // Message represents a single message in a conversation
 type Message struct {
-	Role    string `json:"role"` // "user" or "assistant"
-	Content string `json:"content"`
+	MessageID   string     `json:"message_id,omitempty"`
+	Role        string     `json:"role"` // "user", "assistant", "system", or "tool"
+	Content     string     `json:"content"`
+	Timestamp   *time.Time `json:"timestamp,omitempty"`
+	Speaker     string     `json:"speaker,omitempty"`      // Stable speaker identity, e.g. a user or agent ID
+	DisplayName string     `json:"display_name,omitempty"` // Human-readable speaker name
 }
 
 // ConversationSaveRequest represents a request to save a conversation
 type ConversationSaveRequest struct {
-	ConversationID string    `json:"conversation_id"`
-	Messages       []Message `json:"messages"`
-	Metadata       *Metadata `json:"metadata,omitempty"`
+	ConversationID string                `json:"conversation_id"`
+	UserID         string                `json:"user_id,omitempty"`
+	Messages       []Message             `json:"messages"`
+	Metadata       *ConversationMetadata `json:"metadata,omitempty"`
+
+	// WaitForIndexing holds the response until the conversation's vector is searchable
+	WaitForIndexing bool `json:"wait_for_indexing,omitempty"`
+
+	// Durability is when the save is acknowledged: fast, safe or queued; empty uses the
+	// server's default
+	Durability string `json:"durability,omitempty"`
+
+	// Lane is the ingestion lane of the save: interactive, the default, or backfill for replayed
+	// or imported history. When the ingestion shaper is on, backfill saves yield to interactive ones
+	Lane string `json:"lane,omitempty"`
+
+	// CreatedAt backdates imported conversations; API clients can't set it
+	CreatedAt *time.Time `json:"-"`
 }
 
-// Metadata represents conversation metadata
+// Metadata represents response envelope metadata
 type Metadata struct {
 	Source            string `json:"source,omitempty"`
 	SessionID         string `json:"session_id,omitempty"`
@@ -52,21 +179,36 @@ type Metadata struct {
 
 // ConversationResponse represents a conversation response
 type ConversationResponse struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	Question  string    `json:"question"`
-	Answer    string    `json:"answer"`
-	Metadata  string    `json:"metadata"`
-	Score     float32   `json:"score,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          string       `json:"id"`
+	UserID      string       `json:"user_id"`
+	SessionID   string       `json:"session_id,omitempty"`
+	Question    string       `json:"question"`
+	Answer      string       `json:"answer"`
+	Metadata    string       `json:"metadata"`
+	Messages    []Message    `json:"messages,omitempty"`
+	Score       float32      `json:"score,omitempty"`
+	Importance  float64      `json:"importance"`
+	Pinned      bool         `json:"pinned"`
+	Suppression *Suppression `json:"suppression,omitempty"`
+	Unembedded  *Unembedded  `json:"unembedded,omitempty"`
+	Status      string       `json:"status"`
+	CreatedAt   time.Time    `json:"created_at"`
 }
 
-// APIResponse represents a standard API response wrapper
-type APIResponse struct {
-	Success  bool        `json:"success"`
-	Data     interface{} `json:"data,omitempty"`
-	Error    *ErrorInfo  `json:"error,omitempty"`
-	Metadata Metadata    `json:"metadata"`
+// APIResponse represents a standard API response wrapper; T is the type of its data, so the
+// generated API spec describes the payload of every endpoint
+type APIResponse[T any] struct {
+	Success  bool       `json:"success"`
+	Data     T          `json:"data,omitempty"`
+	Error    *ErrorInfo `json:"error,omitempty"`
+	Metadata Metadata   `json:"metadata"`
+}
+
+// ErrorResponse represents the API response of a failed request
+type ErrorResponse struct {
+	Success  bool       `json:"success"`
+	Error    *ErrorInfo `json:"error"`
+	Metadata Metadata   `json:"metadata"`
 }
 
 // ErrorInfo represents error details in API response
@@ -86,9 +228,33 @@ type SearchResponse struct {
 
 // SearchMetadata represents search-specific metadata
 type SearchMetadata struct {
-	EmbeddingModel string `json:"embedding_model"`
-	VectorDB       string `json:"vector_db"`
-	SearchTimeMs   int64  `json:"search_time_ms"`
+	EmbeddingModel string   `json:"embedding_model"`
+	VectorDB       string   `json:"vector_db"`
+	SearchTimeMs   int64    `json:"search_time_ms"`
+	Features       []string `json:"features,omitempty"`
+
+	// Usage is the tokens billed for embedding the query; absent when no embedding call was made
+	Usage *EmbeddingUsage `json:"usage,omitempty"`
+
+	// Latency splits the search time by stage
+	Latency LatencyBreakdown `json:"latency"`
+
+	// FusionArm is the fusion bandit arm the search was served with; send it back with relevance
+	// feedback on the results. Absent when the bandit is off or the search set its own weights
+	FusionArm string `json:"fusion_arm,omitempty"`
+
+	// SkippedStages lists the optional stages skipped, as kind:name, because too little time
+	// remained until the caller's X-Request-Deadline
+	SkippedStages []string `json:"skipped_stages,omitempty"`
+}
+
+// LatencyBreakdown is the time a search spent in each stage. Stages of the same kind add up, and
+// time outside them (request parsing, fusion, filtering) isn't counted
+type LatencyBreakdown struct {
+	EmbedMs        int64 `json:"embed_ms"`
+	VectorSearchMs int64 `json:"vector_search_ms"`
+	DBFetchMs      int64 `json:"db_fetch_ms"`
+	RerankMs       int64 `json:"rerank_ms"`
 }
 
 // SaveResponse represents the response for save API
@@ -96,8 +262,106 @@ type SaveResponse struct {
 	ConversationID   string `json:"conversation_id"`
 	VectorsCreated   int    `json:"vectors_created"`
 	MessagesStored   int    `json:"messages_stored"`
+	MessagesSkipped  int    `json:"messages_skipped,omitempty"`
 	StoredAt         string `json:"stored_at"`
 	ProcessingTimeMs int64  `json:"processing_time_ms"`
+
+	// Usage is the tokens billed for embedding the conversation; absent when no embedding call was made
+	Usage *EmbeddingUsage `json:"usage,omitempty"`
+
+	// Unembedded is set when the embedding provider refused the text; the conversation is stored
+	// but not searchable until an admin retries it
+	Unembedded *Unembedded `json:"unembedded,omitempty"`
+
+	// Status is indexed once the conversation is searchable and pending while its vector write
+	// is queued
+	Status string `json:"status"`
+
+	// Consistency is searchable when the save waited for the vector to be searchable and eventual
+	// otherwise: the conversation is readable by ID at once but may take a moment to show up in
+	// search
+	Consistency string `json:"consistency"`
+
+	// Durability is the durability level the save was acknowledged at
+	Durability string `json:"durability"`
+
+	// JobID is the work queue item that embeds and indexes a queued save
+	JobID int64 `json:"job_id,omitempty"`
+
+	// Shaped is set when the save was queued because embeddings were arriving faster than the
+	// ingestion shaper lets through
+	Shaped bool `json:"shaped,omitempty"`
+}
+
+// Read-your-writes guarantees of a save response
+const (
+	ConsistencySearchable = "searchable"
+	ConsistencyEventual   = "eventual"
+)
+
+// Write durability levels of a save
+const (
+	// DurabilityFast acknowledges once the conversation is committed to the conversation store;
+	// the vector is written in the background, with the outbox as fallback
+	DurabilityFast = "fast"
+
+	// DurabilitySafe acknowledges once both the conversation and its vector are written
+	DurabilitySafe = "safe"
+
+	// DurabilityQueued acknowledges once the conversation is committed with a queued job that
+	// embeds and indexes it
+	DurabilityQueued = "queued"
+)
+
+// ValidDurabilities lists the accepted write durability levels
+var ValidDurabilities = []string{DurabilityFast, DurabilitySafe, DurabilityQueued}
+
+// IsValidDurability reports whether durability is an accepted write durability level
+func IsValidDurability(durability string) bool {
+	for _, valid := range ValidDurabilities {
+		if durability == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// Ingestion lanes of a save
+const (
+	// LaneInteractive is a save made as the conversation happens
+	LaneInteractive = "interactive"
+
+	// LaneBackfill is a save of past conversations, e.g. a chat platform replaying its backlog
+	LaneBackfill = "backfill"
+)
+
+// ValidLanes lists the accepted ingestion lanes
+var ValidLanes = []string{LaneInteractive, LaneBackfill}
+
+// IsValidLane reports whether lane is an accepted ingestion lane
+func IsValidLane(lane string) bool {
+	for _, valid := range ValidLanes {
+		if lane == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// UnembeddedListResponse is a page of conversations stored without a vector because the
+// embedding provider refused their text
+type UnembeddedListResponse struct {
+	Conversations []ConversationResponse `json:"conversations"`
+	Total         int                    `json:"total"`
+	UserID        string                 `json:"user_id,omitempty"`
+	Limit         int                    `json:"limit"`
+	Offset        int                    `json:"offset"`
+}
+
+// UnembeddedRetryRequest retries embedding a conversation, optionally with corrected messages
+// replacing the stored ones
+type UnembeddedRetryRequest struct {
+	Messages []Message `json:"messages,omitempty"`
 }
 
 // HealthCheckResponse represents health check response
@@ -106,6 +370,22 @@ type HealthCheckResponse struct {
 	Timestamp    string             `json:"timestamp"`
 	Version      string             `json:"version"`
 	Dependencies DependenciesStatus `json:"dependencies"`
+	Leadership   *LeadershipStatus  `json:"leadership,omitempty"`
+}
+
+// LeadershipStatus reports whether this instance runs the background jobs
+type LeadershipStatus struct {
+	Lock        string `json:"lock"`
+	InstanceID  string `json:"instance_id"`
+	Leader      bool   `json:"leader"`
+	LeaderSince string `json:"leader_since,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ReadinessResponse represents the readiness probe response
+type ReadinessResponse struct {
+	Ready  bool   `json:"ready"`
+	Reason string `json:"reason,omitempty"`
 }
 
 // DependenciesStatus represents the status of dependencies
@@ -123,6 +403,7 @@ type QdrantStatus struct {
 	TotalVectors   int    `json:"total_vectors,omitempty"`
 	Error          string `json:"error,omitempty"`
 	LastSuccess    string `json:"last_success,omitempty"`
+	Stability      string `json:"stability,omitempty"`
 }
 
 // PostgreSQLStatus represents PostgreSQL dependency status
@@ -131,6 +412,7 @@ type PostgreSQLStatus struct {
 	ResponseTimeMs int             `json:"response_time_ms,omitempty"`
 	Connections    ConnectionsInfo `json:"connections,omitempty"`
 	Error          string          `json:"error,omitempty"`
+	Stability      string          `json:"stability,omitempty"`
 }
 
 // ConnectionsInfo represents database connections info
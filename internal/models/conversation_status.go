@@ -0,0 +1,74 @@
+package models
+
+// Conversation statuses track whether a conversation's vector is written
+const (
+	// ConversationStatusPending conversations are stored but their vector isn't written yet; a
+	// queued write or a retry is on its way
+	ConversationStatusPending = "pending"
+
+	// ConversationStatusIndexed conversations are searchable, or have no text to embed
+	ConversationStatusIndexed = "indexed"
+
+	// ConversationStatusFailed conversations have no vector: the embedding provider refused their
+	// text or every queued write failed
+	ConversationStatusFailed = "failed"
+
+	// ConversationStatusArchived conversations are kept without a vector until unarchived
+	ConversationStatusArchived = "archived"
+)
+
+// ConversationStatuses lists the conversation statuses
+var ConversationStatuses = []string{
+	ConversationStatusPending,
+	ConversationStatusIndexed,
+	ConversationStatusFailed,
+	ConversationStatusArchived,
+}
+
+// conversationTransitions lists the statuses each status can move to
+var conversationTransitions = map[string][]string{
+	ConversationStatusPending:  {ConversationStatusIndexed, ConversationStatusFailed, ConversationStatusArchived},
+	ConversationStatusIndexed:  {ConversationStatusPending, ConversationStatusFailed, ConversationStatusArchived},
+	ConversationStatusFailed:   {ConversationStatusPending, ConversationStatusIndexed, ConversationStatusArchived},
+	ConversationStatusArchived: {ConversationStatusPending},
+}
+
+// IsValidConversationStatus reports whether status is a conversation status
+func IsValidConversationStatus(status string) bool {
+	_, ok := conversationTransitions[status]
+	return ok
+}
+
+// ConversationStatusesTo returns the statuses a conversation can move to status from
+func ConversationStatusesTo(status string) []string {
+	from := []string{}
+	for _, candidate := range ConversationStatuses {
+		for _, to := range conversationTransitions[candidate] {
+			if to == status {
+				from = append(from, candidate)
+			}
+		}
+	}
+	return from
+}
+
+// ConversationArchiveRequest archives or unarchives a conversation
+type ConversationArchiveRequest struct {
+	Archived *bool `json:"archived" binding:"required"`
+}
+
+// ConversationStatusResponse is the status of a conversation after an update
+type ConversationStatusResponse struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// ConversationListResponse is a page of a user's conversations
+type ConversationListResponse struct {
+	Conversations []ConversationResponse `json:"conversations"`
+	Total         int                    `json:"total"`
+	UserID        string                 `json:"user_id"`
+	Status        string                 `json:"status,omitempty"`
+	Limit         int                    `json:"limit"`
+	Offset        int                    `json:"offset"`
+}
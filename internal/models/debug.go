@@ -0,0 +1,123 @@
+package models
+
+import "time"
+
+// RetrievalOverrides tune the retrieval of one search; zero fields keep the configured values
+type RetrievalOverrides struct {
+	// CandidateMultiplier makes retrievers fetch this many times the result limit before
+	// filtering and reranking
+	CandidateMultiplier float64
+
+	// HNSWEf is the size of Qdrant's HNSW search beam; larger is more exact and slower
+	HNSWEf int
+
+	// FusionWeights scales each named retriever's contribution to the fused score
+	FusionWeights map[string]float64
+
+	// RecencyHalfLife and ImportanceHalfLife replace the decay half-lives of the rerankers
+	RecencyHalfLife    time.Duration
+	ImportanceHalfLife time.Duration
+
+	// RecencyWeight replaces the recency reranker's blend weight; nil keeps the configured one
+	RecencyWeight *float64
+}
+
+// RetrievalTrace records every stage of one search pipeline run
+type RetrievalTrace struct {
+	RawQuery     string                `json:"raw_query"`
+	Transformers []QueryTransformTrace `json:"transformers"`
+	Retrievers   []RetrieverTrace      `json:"retrievers"`
+	Fuser        string                `json:"fuser"`
+	Fused        []TracedCandidate     `json:"fused"`
+	Missing      []string              `json:"missing"` // Candidates whose conversation no longer exists
+	Filtered     []FilteredCandidate   `json:"filtered"`
+	Normalizer   string                `json:"normalizer"`
+	Normalized   []TracedCandidate     `json:"normalized"`
+	Rerankers    []RerankTrace         `json:"rerankers"`
+	MinScore     float32               `json:"min_score"`
+
+	// FetchRounds is how many times candidates were fetched; searches whose filters left fewer
+	// results than the limit fetch again with more candidates, and only the last round is traced
+	FetchRounds int `json:"fetch_rounds"`
+
+	// Overrides lists the retrieval overrides the search ran with, as given
+	Overrides map[string]string `json:"overrides,omitempty"`
+
+	// Skipped lists the optional stages skipped to meet the caller's deadline, as kind:name
+	Skipped []string `json:"skipped,omitempty"`
+
+	Final      []TracedCandidate `json:"final"`
+	DurationMs int64             `json:"duration_ms"`
+}
+
+// QueryTransformTrace is the query text after a transformer ran
+type QueryTransformTrace struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+// RetrieverTrace is the candidate set a retriever returned
+type RetrieverTrace struct {
+	Name       string            `json:"name"`
+	Candidates []TracedCandidate `json:"candidates"`
+	DurationMs int64             `json:"duration_ms"`
+}
+
+// TracedCandidate is a candidate's scores at one point of the pipeline
+type TracedCandidate struct {
+	ConversationID string  `json:"conversation_id"`
+	Score          float32 `json:"score"`
+	RawScore       float32 `json:"raw_score,omitempty"` // Fused score before normalization
+}
+
+// FilteredCandidate is a candidate dropped by a filter
+type FilteredCandidate struct {
+	ConversationID string  `json:"conversation_id"`
+	Score          float32 `json:"score"`
+	Filter         string  `json:"filter"`
+}
+
+// RerankTrace is the score change each candidate received from a reranker
+type RerankTrace struct {
+	Name   string       `json:"name"`
+	Deltas []ScoreDelta `json:"deltas"`
+}
+
+// ScoreDelta is a candidate's score before and after a reranker
+type ScoreDelta struct {
+	ConversationID string  `json:"conversation_id"`
+	Before         float32 `json:"before"`
+	After          float32 `json:"after"`
+	Delta          float32 `json:"delta"`
+}
+
+// EmbeddingInspectRequest asks for the embedding of a text and its nearest stored points
+type EmbeddingInspectRequest struct {
+	Text          string `json:"text"`
+	ContentType   string `json:"content_type"` // Collection to search; defaults to conversations
+	UserID        string `json:"user_id"`      // Restricts neighbors to one user; required when user isolation is enabled
+	Limit         int    `json:"limit"`        // Number of neighbors (default: 10, max: 100)
+	InputType     string `json:"input_type"`   // Embed as a search query (default) or a stored document
+	OmitEmbedding bool   `json:"omit_embedding"`
+}
+
+// EmbeddingInspection is a text's embedding and the stored points nearest to it
+type EmbeddingInspection struct {
+	ContentType string         `json:"content_type"`
+	Collection  string         `json:"collection"`
+	Model       string         `json:"model"`
+	Dimension   int            `json:"dimension"`
+	Distance    string         `json:"distance"`
+	Norm        float64        `json:"norm"`
+	Embedding   []float32      `json:"embedding,omitempty"`
+	Neighbors   []NearestPoint `json:"neighbors"`
+}
+
+// NearestPoint is a stored point returned for an embedding; Distance is derived from the
+// collection's metric so that smaller is always closer
+type NearestPoint struct {
+	ID       interface{}            `json:"id"`
+	Score    float32                `json:"score"`
+	Distance float64                `json:"distance"`
+	Payload  map[string]interface{} `json:"payload"`
+}
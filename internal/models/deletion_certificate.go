@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// DeletionCertificate records an executed purge: what was removed, when and under which policy.
+// Signature is the base64 Ed25519 signature of the certificate's JSON encoding without it, made
+// with the key identified by KeyID, so the holder of the public key can check it wasn't altered
+type DeletionCertificate struct {
+	ID     string `json:"id"`
+	JobID  string `json:"job_id"`
+	Kind   string `json:"kind"`             // Job kind of the purge: retention, user_delete or conversation_bulk_delete
+	Target string `json:"target,omitempty"` // The user whose data was removed, if the purge was limited to one
+
+	// Policy describes the rule or request the records were removed under
+	Policy string `json:"policy"`
+
+	Removed         UserDataCounts `json:"removed"`
+	ConversationIDs []string       `json:"conversation_ids,omitempty"` // Removed conversations, when listed by the purge
+	DeletedAt       time.Time      `json:"deleted_at"`
+
+	KeyID     string `json:"key_id"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// DeletionCertificateListResponse represents a page of deletion certificates
+type DeletionCertificateListResponse struct {
+	Certificates []*DeletionCertificate `json:"certificates"`
+}
+
+// DeletionCertificateKey is the public key deletion certificates are verified with
+type DeletionCertificateKey struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"` // Base64 of the raw 32-byte Ed25519 public key
+}
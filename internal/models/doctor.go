@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Doctor check outcomes
+const (
+	DoctorPass = "pass"
+	DoctorFail = "fail"
+
+	// DoctorSkip marks a check that didn't run: it wasn't requested or one it depends on failed
+	DoctorSkip = "skip"
+)
+
+// DoctorRequest selects the self-test checks to run
+type DoctorRequest struct {
+	// RoundTrip saves, searches and deletes a scratch vector; without it the self-test only reads
+	RoundTrip bool
+}
+
+// DoctorReport is the result of a self-test of the server's dependencies and bindings
+type DoctorReport struct {
+	// Passed is set unless a check failed
+	Passed      bool          `json:"passed"`
+	Checks      []DoctorCheck `json:"checks"`
+	GeneratedAt time.Time     `json:"generated_at"`
+	DurationMs  int64         `json:"duration_ms"`
+}
+
+// DoctorCheck is one self-test check
+type DoctorCheck struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// MigrationStatus lists the schema migrations the server would apply to its database on start
+type MigrationStatus struct {
+	// Backend is the relational backend, as MEMORY_STORE_BACKEND names it
+	Backend string `json:"backend"`
+
+	// SchemaVersion is the version of the Postgres layout; SQLite and MySQL have none
+	SchemaVersion int `json:"schema_version,omitempty"`
+
+	// Guard is the migration guard mode the server runs migrations under
+	Guard   string          `json:"guard"`
+	Pending []string        `json:"pending"`
+	Risks   []MigrationRisk `json:"risks"`
+
+	// Blocking counts the risks without a lock-friendly rewrite, which the block guard refuses
+	Blocking int `json:"blocking"`
+}
+
+// MigrationRisk is a pending statement that holds a heavy lock on a large table
+type MigrationRisk struct {
+	Statement string `json:"statement"`
+	Table     string `json:"table"`
+	Rows      int64  `json:"rows"` // Estimated from planner statistics
+	Reason    string `json:"reason"`
+
+	// Rewrite is the lock-friendly statement run instead; risks with a rewrite don't block
+	Rewrite string `json:"rewrite,omitempty"`
+}
@@ -0,0 +1,32 @@
+package models
+
+// EmbeddingDriftReport is the result of re-embedding a sample of stored conversations with the
+// live model and comparing the new vectors with the stored ones
+type EmbeddingDriftReport struct {
+	Sampled  int `json:"sampled"`
+	Compared int `json:"compared"`
+
+	// Skipped counts sampled conversations without text, without a vector, or whose text changed
+	// since it was embedded, so a new vector would differ for reasons other than the model
+	Skipped int `json:"skipped"`
+
+	// Failed counts conversations the live model failed to embed
+	Failed int `json:"failed"`
+
+	// MeanDistance and MaxDistance are cosine distances (1 - cosine similarity) between the
+	// stored and re-embedded vectors of the compared conversations
+	MeanDistance float64 `json:"mean_distance"`
+	MaxDistance  float64 `json:"max_distance"`
+
+	// Alert is set when MeanDistance exceeds Threshold
+	Threshold  float64                `json:"threshold"`
+	Alert      bool                   `json:"alert"`
+	Samples    []EmbeddingDriftSample `json:"samples"`
+	DurationMs int64                  `json:"duration_ms"`
+}
+
+// EmbeddingDriftSample is the drift measured for one conversation
+type EmbeddingDriftSample struct {
+	ConversationID string  `json:"conversation_id"`
+	Distance       float64 `json:"distance"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// EmbeddingAnomalyEvent is the event of embedding anomaly webhooks
+const EmbeddingAnomalyEvent = "embedding_anomaly.detected"
+
+// Embedding anomaly kinds
+const (
+	// AnomalyTopicShift: the recent conversations as a whole moved away from the user's usual topics
+	AnomalyTopicShift = "topic_shift"
+
+	// AnomalyNovelTopic: several recent conversations are unlike any of the user's earlier ones
+	AnomalyNovelTopic = "novel_topic"
+
+	// AnomalyDistress: the recent conversations moved towards the configured distress phrases
+	AnomalyDistress = "distress"
+)
+
+// EmbeddingAnomaly is a sudden shift in the distribution of a user's conversation vectors, posted
+// to the anomaly webhook
+type EmbeddingAnomaly struct {
+	Event  string `json:"event"`
+	UserID string `json:"user_id"`
+	Kind   string `json:"kind"`
+
+	// Score is how many baseline standard deviations the recent conversations are from the user's
+	// baseline; the anomaly is flagged when it exceeds Threshold
+	Score     float64 `json:"score"`
+	Threshold float64 `json:"threshold"`
+
+	// Recent and Baseline count the conversations compared
+	Recent   int `json:"recent"`
+	Baseline int `json:"baseline"`
+
+	// ConversationIDs lists the recent conversations that stand out most, at most ten
+	ConversationIDs []string  `json:"conversation_ids"`
+	WindowStart     time.Time `json:"window_start"`
+	DetectedAt      time.Time `json:"detected_at"`
+}
+
+// EmbeddingAnomalyReport is the result of a scheduled embedding anomaly scan
+type EmbeddingAnomalyReport struct {
+	// Users counts the users with conversations in the window; Scanned those with enough
+	// conversations in the window and before it to compare
+	Users   int `json:"users"`
+	Scanned int `json:"scanned"`
+
+	// Failed counts users whose vectors couldn't be read
+	Failed int `json:"failed"`
+
+	WindowStart time.Time          `json:"window_start"`
+	Anomalies   []EmbeddingAnomaly `json:"anomalies"`
+	DurationMs  int64              `json:"duration_ms"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// EmbeddingProjection describes how one model's embeddings are reduced before they are stored and
+// searched
+type EmbeddingProjection struct {
+	Model string `json:"model"`
+
+	// Method is truncate (keep the leading components) or pca (a trained matrix)
+	Method          string `json:"method"`
+	OutputDimension int    `json:"output_dimension"`
+
+	// The rest describe the PCA matrix
+	Path              string     `json:"path,omitempty"`
+	InputDimension    int        `json:"input_dimension,omitempty"`
+	ExplainedVariance float64    `json:"explained_variance,omitempty"`
+	Samples           int        `json:"samples,omitempty"`
+	TrainedAt         *time.Time `json:"trained_at,omitempty"`
+	LoadedAt          *time.Time `json:"loaded_at,omitempty"`
+}
+
+// EmbeddingProjectionListResponse lists the configured projections
+type EmbeddingProjectionListResponse struct {
+	Projections []EmbeddingProjection `json:"projections"`
+}
+
+// EmbeddingProjectionTrainRequest starts training a PCA matrix
+type EmbeddingProjectionTrainRequest struct {
+	// Model defaults to the conversations collection's model
+	Model     string `json:"model"`
+	Dimension int    `json:"dimension" binding:"required,min=1"`
+
+	// Samples is how many stored conversations are embedded to train on; defaults to 5000
+	Samples int `json:"samples" binding:"omitempty,min=1,max=50000"`
+}
+
+// EmbeddingProjectionTrainResult is the result of a training job. The matrix is written to a new
+// file; it takes effect once configured, or copied over the loaded file and reloaded, and the
+// collections of the model are reindexed
+type EmbeddingProjectionTrainResult struct {
+	Model             string  `json:"model"`
+	Path              string  `json:"path"`
+	InputDimension    int     `json:"input_dimension"`
+	OutputDimension   int     `json:"output_dimension"`
+	Samples           int     `json:"samples"`
+	ExplainedVariance float64 `json:"explained_variance"`
+	DurationMs        int64   `json:"duration_ms"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// DataKey is a tenant's conversation encryption key, stored wrapped by a master key. A tenant's
+// newest version encrypts new content; older versions stay to decrypt content written with them
+type DataKey struct {
+	Tenant      string    `json:"tenant"`
+	Version     int       `json:"version"`
+	MasterKeyID string    `json:"master_key_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	WrappedKey  []byte    `json:"-"`
+}
+
+// DataKeyListResponse represents the tenant data keys
+type DataKeyListResponse struct {
+	DataKeys           []*DataKey `json:"data_keys"`
+	CurrentMasterKeyID string     `json:"current_master_key_id"`
+}
+
+// DataKeyRotateRequest represents a request to start a new data key version for a tenant
+type DataKeyRotateRequest struct {
+	Tenant string `json:"tenant" binding:"required,max=255"`
+}
+
+// DataKeyRewrapResponse reports a rewrap of data keys under the current master key
+type DataKeyRewrapResponse struct {
+	Rewrapped   int    `json:"rewrapped"`
+	MasterKeyID string `json:"master_key_id"`
+}
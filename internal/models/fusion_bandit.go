@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// FusionBanditArmStats is the relevance feedback a tenant's searches served with an arm collected
+type FusionBanditArmStats struct {
+	Arm     string
+	Trials  int64
+	Rewards int64
+}
+
+// FusionBanditTenant is a tenant's bandit state besides its arm stats
+type FusionBanditTenant struct {
+	Tenant string
+
+	// FrozenArm pins the tenant's searches to one arm; empty lets the bandit choose
+	FrozenArm string
+	FrozenAt  *time.Time
+}
+
+// SearchFeedbackRequest rates one result of a conversation search
+type SearchFeedbackRequest struct {
+	// FusionArm is search_metadata.fusion_arm of the rated search
+	FusionArm string `json:"fusion_arm" binding:"required"`
+
+	// Relevant is whether the result answered the search
+	Relevant *bool `json:"relevant" binding:"required"`
+}
+
+// SearchFeedbackResponse acknowledges relevance feedback
+type SearchFeedbackResponse struct {
+	FusionArm string `json:"fusion_arm"`
+	Relevant  bool   `json:"relevant"`
+
+	// Counted is false while the tenant is frozen to one arm; the feedback isn't credited then
+	Counted bool `json:"counted"`
+}
+
+// FusionBanditArmStatus describes an arm and the feedback it collected for a tenant
+type FusionBanditArmStatus struct {
+	Name          string             `json:"name"`
+	FusionWeights map[string]float64 `json:"fusion_weights"`
+	RecencyWeight *float64           `json:"recency_weight,omitempty"`
+	Baseline      bool               `json:"baseline"`
+	Trials        int64              `json:"trials"`
+	Rewards       int64              `json:"rewards"`
+
+	// RelevanceRate is rewards over trials, 0 before any feedback
+	RelevanceRate float64 `json:"relevance_rate"`
+
+	// Demoted is set once the arm has proven worse than the baseline; it is no longer chosen
+	Demoted bool `json:"demoted"`
+}
+
+// FusionBanditStatus describes a tenant's fusion bandit
+type FusionBanditStatus struct {
+	Tenant string                  `json:"tenant"`
+	Arms   []FusionBanditArmStatus `json:"arms"`
+
+	// BestArm is the arm with the best relevance estimate among those not demoted
+	BestArm string `json:"best_arm"`
+
+	// FrozenArm serves every search of the tenant while set
+	FrozenArm string     `json:"frozen_arm,omitempty"`
+	FrozenAt  *time.Time `json:"frozen_at,omitempty"`
+
+	// BaselineShare, MinTrials and MaxRegression are the guardrails the bandit runs within
+	BaselineShare float64 `json:"baseline_share"`
+	MinTrials     int64   `json:"min_trials"`
+	MaxRegression float64 `json:"max_regression"`
+}
+
+// FusionBanditFreezeRequest pins a tenant's searches to one arm
+type FusionBanditFreezeRequest struct {
+	// Arm defaults to the tenant's best arm
+	Arm string `json:"arm"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// IndexHealthResponse reports vector index and database table health
+type IndexHealthResponse struct {
+	Healthy     bool                    `json:"healthy"`
+	Warnings    []string                `json:"warnings"`
+	Collections []CollectionIndexHealth `json:"collections"`
+	Tables      []TableStats            `json:"tables"`
+}
+
+// CollectionIndexHealth describes a collection's index coverage and optimizer state
+type CollectionIndexHealth struct {
+	ContentType         string  `json:"content_type"`
+	Name                string  `json:"name"`
+	Status              string  `json:"status"`
+	OptimizerStatus     string  `json:"optimizer_status"`
+	PointsCount         int64   `json:"points_count"`
+	IndexedVectorsCount int64   `json:"indexed_vectors_count"`
+	IndexedPercent      float64 `json:"indexed_percent"`
+	SegmentsCount       int64   `json:"segments_count"`
+	OnDisk              bool    `json:"on_disk"`
+	// MemoryEstimateBytes approximates the RAM held by the vectors and HNSW graph
+	MemoryEstimateBytes int64  `json:"memory_estimate_bytes"`
+	Error               string `json:"error,omitempty"`
+}
+
+// TableStats describes a Postgres table's size and indexes
+type TableStats struct {
+	Name          string       `json:"name"`
+	TotalBytes    int64        `json:"total_bytes"`
+	TableBytes    int64        `json:"table_bytes"`
+	IndexBytes    int64        `json:"index_bytes"`
+	EstimatedRows int64        `json:"estimated_rows"`
+	DeadRows      int64        `json:"dead_rows"`
+	LastVacuum    *time.Time   `json:"last_vacuum,omitempty"`
+	Indexes       []IndexStats `json:"indexes"`
+}
+
+// IndexStats describes a Postgres index
+type IndexStats struct {
+	Name  string `json:"name"`
+	Bytes int64  `json:"bytes"`
+	Scans int64  `json:"scans"`
+}
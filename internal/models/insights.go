@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// InsightsRequest selects the conversations cross-user insights are computed over
+type InsightsRequest struct {
+	// Days is how far back conversations are sampled
+	Days int
+
+	// MaxConversations caps the sampled conversations
+	MaxConversations int
+
+	// Clusters is the number of topics; 0 picks one from the sample size
+	Clusters int
+
+	// Label names topics with the chat model
+	Label bool
+}
+
+// Insights are aggregate statistics across users. Every reported group has at least KAnonymity
+// distinct users; smaller groups are withheld and only counted. No user or conversation ID and
+// no conversation text is reported
+type Insights struct {
+	Since time.Time `json:"since"`
+
+	// Conversations and Users count the sample; each user contributes a bounded number of
+	// conversations, so a few heavy users don't dominate it
+	Conversations int  `json:"conversations"`
+	Users         int  `json:"users"`
+	Truncated     bool `json:"truncated"`
+
+	KAnonymity int  `json:"k_anonymity"`
+	Labeled    bool `json:"labeled"`
+
+	Topics               []InsightGroup `json:"topics"`
+	SuppressedTopics     int            `json:"suppressed_topics"`
+	Categories           []InsightGroup `json:"categories"`
+	SuppressedCategories int            `json:"suppressed_categories"`
+
+	GeneratedAt time.Time `json:"generated_at"`
+	DurationMs  int64     `json:"duration_ms"`
+}
+
+// InsightGroup is one released topic or question category
+type InsightGroup struct {
+	// ID numbers topics, largest first; categories have none
+	ID int `json:"id,omitempty"`
+
+	// Label names a category, or a topic once labeled; Summary describes a labeled topic
+	Label   string `json:"label,omitempty"`
+	Summary string `json:"summary,omitempty"`
+
+	Users         int     `json:"users"`
+	Conversations int     `json:"conversations"`
+	Share         float64 `json:"share"` // of the sampled conversations
+}
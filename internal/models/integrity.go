@@ -0,0 +1,48 @@
+package models
+
+// Integrity issues found by content hash verification
+const (
+	// IntegrityVectorDrift means the vector was embedded from different text than is stored now
+	IntegrityVectorDrift = "vector_drift"
+
+	// IntegrityContentDrift means the stored text changed since it was last embedded
+	IntegrityContentDrift = "content_drift"
+
+	// IntegrityMissingVector means a conversation with embeddable text has no vector
+	IntegrityMissingVector = "missing_vector"
+)
+
+// IntegrityReport is the result of comparing stored conversations against their vectors
+type IntegrityReport struct {
+	UserID  string `json:"user_id,omitempty"`
+	Checked int    `json:"checked"`
+	Matched int    `json:"matched"`
+
+	// Unhashed counts vectors written before content hashes were recorded
+	Unhashed int `json:"unhashed"`
+
+	// NoText counts conversations with nothing to embed
+	NoText int `json:"no_text"`
+
+	// IssueCounts counts every issue found by kind; Issues lists at most the first MaxIssues
+	IssueCounts map[string]int   `json:"issue_counts"`
+	Issues      []IntegrityIssue `json:"issues"`
+	Truncated   bool             `json:"truncated"`
+	DurationMs  int64            `json:"duration_ms"`
+}
+
+// IntegrityIssue describes one conversation whose content and vector disagree
+type IntegrityIssue struct {
+	ConversationID string `json:"conversation_id"`
+	UserID         string `json:"user_id"`
+	Issue          string `json:"issue"`
+
+	// ContentHash is the hash of the text the conversation embeds today
+	ContentHash string `json:"content_hash"`
+
+	// StoredHash is the hash recorded in Postgres when the conversation was last embedded
+	StoredHash string `json:"stored_hash,omitempty"`
+
+	// VectorHash is the hash in the vector payload
+	VectorHash string `json:"vector_hash,omitempty"`
+}
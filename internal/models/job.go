@@ -0,0 +1,170 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Job kinds
+const (
+	JobKindUserReindex  = "user_reindex"
+	JobKindRetention    = "retention"
+	JobKindUserDelete   = "user_delete"
+	JobKindOptimize     = "optimize"
+	JobKindIntegrity    = "integrity_verify"
+	JobKindAnalytics    = "analytics_export"
+	JobKindVectorExport = "vector_export"
+	JobKindVectorImport = "vector_import"
+	JobKindDrift        = "embedding_drift"
+	JobKindAnomaly      = "embedding_anomaly"
+
+	JobKindPersonalInfoReindex = "personal_info_reindex"
+	JobKindConversationImport  = "conversation_import"
+	JobKindBulkDelete          = "conversation_bulk_delete"
+	JobKindProjectionTrain     = "embedding_projection_train"
+	JobKindLegacyBackfill      = "legacy_backfill"
+)
+
+// Job statuses
+const (
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// Job records an administrative operation, its scope and its outcome
+type Job struct {
+	ID         string          `json:"id"`
+	Kind       string          `json:"kind"`
+	Target     string          `json:"target,omitempty"`
+	DryRun     bool            `json:"dry_run"`
+	Status     string          `json:"status"`
+	Result     json.RawMessage `json:"result,omitempty" swaggertype:"object"`
+	Error      string          `json:"error,omitempty"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// JobListResponse represents a page of jobs
+type JobListResponse struct {
+	Jobs []*Job `json:"jobs"`
+}
+
+// RetentionRunResponse represents the result of applying the retention policy
+type RetentionRunResponse struct {
+	JobID           string   `json:"job_id"`
+	DryRun          bool     `json:"dry_run"`
+	Conversations   int      `json:"conversations"`
+	ConversationIDs []string `json:"conversation_ids"`
+	TextBytes       int64    `json:"text_bytes"`
+	DurationMs      int64    `json:"duration_ms"`
+
+	// CertificateID identifies the deletion certificate of an executed run that deleted anything
+	CertificateID string `json:"certificate_id,omitempty"`
+
+	// Confirmation is set when a run was requested without a confirmation token; nothing was deleted
+	Confirmation *Confirmation `json:"confirmation,omitempty"`
+}
+
+// OptimizeResult reports what an index optimization job triggered
+type OptimizeResult struct {
+	Collections []string `json:"collections"` // Collections whose optimizers were triggered
+	Tables      []string `json:"tables"`      // Tables vacuumed and analyzed
+	Errors      []string `json:"errors,omitempty"`
+	DurationMs  int64    `json:"duration_ms"`
+}
+
+// PersonalInfoReindexRequest starts re-embedding every personal info entry
+type PersonalInfoReindexRequest struct {
+	// ResumeJobID continues an earlier reindex job that failed or was interrupted from the last
+	// entry it finished, instead of starting over
+	ResumeJobID string `json:"resume_job_id,omitempty"`
+}
+
+// PersonalInfoReindexProgress is the result of a personal info reindex job, updated as it runs
+type PersonalInfoReindexProgress struct {
+	Total     int64    `json:"total"`     // Entries stored when the job started
+	Processed int64    `json:"processed"` // Entries embedded or failed so far, including those of the resumed job
+	Indexed   int64    `json:"indexed"`
+	Failed    int64    `json:"failed"`
+	FailedIDs []string `json:"failed_ids"` // The first failures, up to 100
+
+	// Cursor is the ID of the last entry processed; a resumed job continues after it
+	Cursor string `json:"cursor,omitempty"`
+
+	// ResumedFrom is the ID of the job this one continued
+	ResumedFrom string `json:"resumed_from,omitempty"`
+
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// LegacyBackfillRequest starts upgrading conversations saved before messages and user IDs were
+// stored, whose turns were all concatenated into the question
+type LegacyBackfillRequest struct {
+	// DefaultUserID is given to conversations whose user is named neither in their metadata nor by
+	// their session; without it they keep an empty user ID
+	DefaultUserID string `json:"default_user_id,omitempty"`
+
+	// DryRun reports how conversations would be split and attributed without changing them
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// ResumeJobID continues an earlier backfill job that failed or was interrupted from the last
+	// conversation it finished, instead of starting over
+	ResumeJobID string `json:"resume_job_id,omitempty"`
+}
+
+// LegacyBackfillProgress is the result of a legacy backfill job, updated as it runs
+type LegacyBackfillProgress struct {
+	DryRun    bool  `json:"dry_run"`
+	Total     int64 `json:"total"`     // Legacy conversations when the job started
+	Processed int64 `json:"processed"` // Conversations upgraded or failed so far, including those of the resumed job
+
+	Split   int64 `json:"split"`   // Conversations whose text was split at speaker labels into turns
+	Unsplit int64 `json:"unsplit"` // Conversations without speaker labels, stored as one user message and the answer
+
+	Attributed   int64 `json:"attributed"`   // Conversations given a user ID
+	Unattributed int64 `json:"unattributed"` // Conversations left without a user ID
+
+	Reembedded int64    `json:"reembedded"`
+	Failed     int64    `json:"failed"`
+	FailedIDs  []string `json:"failed_ids"` // The first failures, up to 100
+
+	// Samples are the first conversations of a dry run as they would be stored
+	Samples []LegacyBackfillSample `json:"samples,omitempty"`
+
+	// Cursor is the ID of the last conversation processed; a resumed job continues after it
+	Cursor string `json:"cursor,omitempty"`
+
+	// ResumedFrom is the ID of the job this one continued
+	ResumedFrom string `json:"resumed_from,omitempty"`
+
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// LegacyBackfillSample is a legacy conversation as a backfill would store it
+type LegacyBackfillSample struct {
+	ConversationID string    `json:"conversation_id"`
+	UserID         string    `json:"user_id"`
+	Messages       []Message `json:"messages"`
+}
+
+// ConversationImportProgress is the result of a conversation import job, updated as it runs
+type ConversationImportProgress struct {
+	Format   string `json:"format"`
+	Records  int    `json:"records"` // Messages read from the export
+	Skipped  int    `json:"skipped"` // Messages without text, a user or a known role
+	Users    int    `json:"users"`
+	Sessions int    `json:"sessions"`
+
+	// Conversations is the number of conversations to save, one per user session
+	Conversations int      `json:"conversations"`
+	Imported      int      `json:"imported"`
+	Failed        int      `json:"failed"`
+	FailedIDs     []string `json:"failed_ids"` // The first failures, up to 100
+	DurationMs    int64    `json:"duration_ms"`
+}
+
+// JobStartedResponse identifies a job running in the background
+type JobStartedResponse struct {
+	JobID string `json:"job_id"`
+}
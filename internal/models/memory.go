@@ -0,0 +1,108 @@
+package models
+
+import "time"
+
+// PinnedMemories holds the memories pinned for a user
+type PinnedMemories struct {
+	Conversations []ConversationResponse `json:"conversations"`
+	PersonalInfo  []PersonalInfoResponse `json:"personal_info"`
+}
+
+// RetrieveRequest represents a request for a user's memory context
+type RetrieveRequest struct {
+	UserID         string
+	Query          string
+	Limit          int
+	SessionID      string
+	RecentMessages int
+
+	// Overrides tune retrieval of the similar conversations
+	Overrides *RetrievalOverrides
+
+	// MaxAgeDays limits the similar conversations to those created within this many days; nil
+	// applies the tenant's default and 0 searches all history
+	MaxAgeDays *int
+
+	// Compress reduces the similar conversations to their sentences most relevant to the query;
+	// nil applies the configured default
+	Compress *bool
+}
+
+// RetrieveResponse is the memory context assembled for a chat turn: pinned memories that always
+// apply, conversations and personal info similar to the query, and optionally the current
+// session's recap
+type RetrieveResponse struct {
+	UserID       string                     `json:"user_id"`
+	Query        string                     `json:"query,omitempty"`
+	Pinned       PinnedMemories             `json:"pinned"`
+	Results      []ConversationSearchResult `json:"results"`
+	PersonalInfo []PersonalInfoSearchResult `json:"personal_info"`
+	Session      *SessionContextResponse    `json:"session,omitempty"`
+
+	// Routing reports how the query classifier weighed the collections, when routing is enabled
+	Routing *RetrieveRouting `json:"routing,omitempty"`
+
+	// Compression reports how many sentences of the similar conversations were kept, when they
+	// were compressed
+	Compression *ContextCompression `json:"compression,omitempty"`
+}
+
+// ContextCompression counts the sentences of the similar conversations before and after
+// extractive compression
+type ContextCompression struct {
+	SentencesTotal int `json:"sentences_total"`
+	SentencesKept  int `json:"sentences_kept"`
+}
+
+// RetrieveRouting is the query classifier's decision for a retrieval. Result scores of each
+// collection are multiplied by its weight
+type RetrieveRouting struct {
+	Weights  map[string]float64 `json:"weights"`
+	Searched []string           `json:"searched"`
+}
+
+// PinRequest represents a request to pin or unpin a memory
+type PinRequest struct {
+	Pinned *bool `json:"pinned" binding:"required"`
+}
+
+// PinResponse represents the pin state of a memory after an update
+type PinResponse struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"` // "conversation" or "personal_info"
+	Pinned bool   `json:"pinned"`
+}
+
+// Suppression reasons
+const (
+	SuppressionWrong    = "wrong"
+	SuppressionOutdated = "outdated"
+	SuppressionHarmful  = "harmful"
+)
+
+// Suppression records why a memory was excluded from retrieval; suppressed memories are kept for audit
+type Suppression struct {
+	Reason       string    `json:"reason"`
+	Note         string    `json:"note,omitempty"`
+	SuppressedAt time.Time `json:"suppressed_at"`
+}
+
+// SuppressionRequest represents a request to suppress or restore a memory
+type SuppressionRequest struct {
+	Suppressed *bool  `json:"suppressed" binding:"required"`
+	Reason     string `json:"reason,omitempty" binding:"omitempty,oneof=wrong outdated harmful"` // Required when suppressing
+	Note       string `json:"note,omitempty" binding:"max=1000"`
+}
+
+// SuppressionResponse represents the suppression state of a memory after an update
+type SuppressionResponse struct {
+	ID          string       `json:"id"`
+	Type        string       `json:"type"` // "conversation" or "personal_info"
+	Suppression *Suppression `json:"suppression"`
+}
+
+// SuppressedMemories holds the suppressed memories of a user
+type SuppressedMemories struct {
+	Conversations []ConversationResponse `json:"conversations"`
+	PersonalInfo  []PersonalInfoResponse `json:"personal_info"`
+}
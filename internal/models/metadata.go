@@ -0,0 +1,220 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"time"
+)
+
+// Limits on custom conversation metadata
+const (
+	MaxCustomFields       = 32
+	MaxCustomKeyLength    = 64
+	MaxCustomStringLength = 1024
+	MaxCustomListLength   = 32
+	MaxCustomBytes        = 16 * 1024
+)
+
+// Custom metadata field type hints
+const (
+	FieldTypeString     = "string"
+	FieldTypeInteger    = "integer"
+	FieldTypeNumber     = "number"
+	FieldTypeBoolean    = "boolean"
+	FieldTypeDatetime   = "datetime"
+	FieldTypeStringList = "string_list"
+)
+
+// customKeyPattern restricts custom field names to identifiers usable in filters
+var customKeyPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+// reservedMetadataKeys are well-known fields that custom fields may not shadow
+var reservedMetadataKeys = map[string]bool{
+	"source":             true,
+	"session_id":         true,
+	"type":               true,
+	"conversation_score": true,
+}
+
+// ConversationMetadata is the metadata attached to a saved conversation
+type ConversationMetadata struct {
+	Source            string `json:"source,omitempty"`
+	SessionID         string `json:"session_id,omitempty"`
+	Type              string `json:"type,omitempty"`
+	ConversationScore *int   `json:"conversation_score,omitempty"`
+
+	// Custom holds client-defined fields: strings, numbers, booleans, or lists of strings
+	Custom map[string]interface{} `json:"custom,omitempty"`
+
+	// FieldTypes optionally declares custom field types: string, integer, number,
+	// boolean, datetime (RFC 3339), or string_list
+	FieldTypes map[string]string `json:"field_types,omitempty"`
+}
+
+// Validate checks custom fields against their type hints and size limits and
+// normalizes values: integers are stored as int64 and datetimes as UTC RFC 3339
+func (m *ConversationMetadata) Validate() error {
+	if len(m.Custom) > MaxCustomFields {
+		return fmt.Errorf("metadata has %d custom fields, at most %d are allowed", len(m.Custom), MaxCustomFields)
+	}
+
+	for key := range m.FieldTypes {
+		if _, ok := m.Custom[key]; !ok {
+			return fmt.Errorf("field_types declares %q which is not a custom field", key)
+		}
+	}
+
+	for key, value := range m.Custom {
+		if len(key) > MaxCustomKeyLength || !customKeyPattern.MatchString(key) {
+			return fmt.Errorf("custom field name %q must be an identifier of at most %d characters", key, MaxCustomKeyLength)
+		}
+		if reservedMetadataKeys[key] {
+			return fmt.Errorf("custom field name %q is reserved", key)
+		}
+
+		normalized, err := normalizeCustomValue(key, value, m.FieldTypes[key])
+		if err != nil {
+			return err
+		}
+		m.Custom[key] = normalized
+	}
+
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	if len(encoded) > MaxCustomBytes {
+		return fmt.Errorf("metadata is %d bytes, at most %d are allowed", len(encoded), MaxCustomBytes)
+	}
+
+	return nil
+}
+
+// Payload returns the well-known and custom fields as a flat map for the vector payload
+func (m *ConversationMetadata) Payload() map[string]interface{} {
+	payload := make(map[string]interface{}, len(m.Custom)+4)
+	for key, value := range m.Custom {
+		payload[key] = value
+	}
+	if m.Source != "" {
+		payload["source"] = m.Source
+	}
+	if m.SessionID != "" {
+		payload["session_id"] = m.SessionID
+	}
+	if m.Type != "" {
+		payload["type"] = m.Type
+	}
+	if m.ConversationScore != nil {
+		payload["conversation_score"] = *m.ConversationScore
+	}
+	return payload
+}
+
+// MetadataFieldPath returns where a field of Payload is kept in the stored metadata JSON:
+// well-known fields at the top level and custom fields under "custom"
+func MetadataFieldPath(field string) []string {
+	if reservedMetadataKeys[field] {
+		return []string{field}
+	}
+	return []string{"custom", field}
+}
+
+// normalizeCustomValue validates a custom value against its type hint, inferring the type when none is given
+func normalizeCustomValue(key string, value interface{}, hint string) (interface{}, error) {
+	switch hint {
+	case "":
+		return inferCustomValue(key, value)
+	case FieldTypeString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("custom field %q must be a string", key)
+		}
+		return checkCustomString(key, s)
+	case FieldTypeInteger:
+		f, ok := value.(float64)
+		if !ok || f != math.Trunc(f) || math.Abs(f) > 1<<53 {
+			return nil, fmt.Errorf("custom field %q must be an integer", key)
+		}
+		return int64(f), nil
+	case FieldTypeNumber:
+		f, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("custom field %q must be a number", key)
+		}
+		return f, nil
+	case FieldTypeBoolean:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("custom field %q must be a boolean", key)
+		}
+		return b, nil
+	case FieldTypeDatetime:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("custom field %q must be an RFC 3339 datetime string", key)
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("custom field %q must be an RFC 3339 datetime string", key)
+		}
+		return t.UTC().Format(time.RFC3339), nil
+	case FieldTypeStringList:
+		return checkCustomList(key, value)
+	default:
+		return nil, fmt.Errorf("custom field %q has unknown type %q", key, hint)
+	}
+}
+
+// inferCustomValue accepts any supported value shape
+func inferCustomValue(key string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return checkCustomString(key, v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= 1<<53 {
+			return int64(v), nil
+		}
+		return v, nil
+	case bool:
+		return v, nil
+	case []interface{}:
+		return checkCustomList(key, v)
+	default:
+		return nil, fmt.Errorf("custom field %q must be a string, number, boolean, or list of strings", key)
+	}
+}
+
+// checkCustomString enforces the string length limit
+func checkCustomString(key, s string) (interface{}, error) {
+	if len(s) > MaxCustomStringLength {
+		return nil, fmt.Errorf("custom field %q exceeds %d characters", key, MaxCustomStringLength)
+	}
+	return s, nil
+}
+
+// checkCustomList validates a list of strings
+func checkCustomList(key string, value interface{}) (interface{}, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("custom field %q must be a list of strings", key)
+	}
+	if len(items) > MaxCustomListLength {
+		return nil, fmt.Errorf("custom field %q has more than %d items", key, MaxCustomListLength)
+	}
+
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("custom field %q must be a list of strings", key)
+		}
+		if len(s) > MaxCustomStringLength {
+			return nil, fmt.Errorf("custom field %q has an item exceeding %d characters", key, MaxCustomStringLength)
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
@@ -4,13 +4,15 @@ import "time"
 
 // PersonalInfo represents personal information provided by guardians
 type PersonalInfo struct {
-	ID          string    `json:"id"`
-	UserID      string    `json:"user_id"`
-	Content     string    `json:"content"`      // 보호자가 입력한 텍스트
-	Category    string    `json:"category"`     // e.g., "medical", "contact", "emergency", "allergy"
-	Importance  string    `json:"importance"`   // "high", "medium", "low"
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string       `json:"id"`
+	UserID      string       `json:"user_id"`
+	Content     string       `json:"content"`               // 보호자가 입력한 텍스트
+	Category    string       `json:"category"`              // e.g., "medical", "contact", "emergency", "allergy"
+	Importance  string       `json:"importance"`            // "high", "medium", "low"
+	Pinned      bool         `json:"pinned"`                // always included in retrieval context
+	Suppression *Suppression `json:"suppression,omitempty"` // set when excluded from retrieval
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
 }
 
 // PersonalInfoCreateRequest represents a request to create personal info
@@ -30,18 +32,59 @@ type PersonalInfoUpdateRequest struct {
 
 // PersonalInfoResponse represents a personal info response
 type PersonalInfoResponse struct {
-	ID         string `json:"id"`
-	UserID     string `json:"user_id"`
-	Content    string `json:"content"`
-	Category   string `json:"category"`
-	Importance string `json:"importance"`
-	CreatedAt  string `json:"created_at"`
-	UpdatedAt  string `json:"updated_at"`
+	ID          string       `json:"id"`
+	UserID      string       `json:"user_id"`
+	Content     string       `json:"content"`
+	Category    string       `json:"category"`
+	Importance  string       `json:"importance"`
+	Pinned      bool         `json:"pinned"`
+	Suppression *Suppression `json:"suppression,omitempty"`
+	CreatedAt   string       `json:"created_at"`
+	UpdatedAt   string       `json:"updated_at"`
+}
+
+// PersonalInfoSearchResult is a personal info entry similar to a query
+type PersonalInfoSearchResult struct {
+	PersonalInfoResponse
+	Score float32 `json:"score"`
 }
 
 // PersonalInfoListResponse represents a list of personal info items
 type PersonalInfoListResponse struct {
-	Items       []PersonalInfoResponse `json:"items"`
-	Total       int                    `json:"total"`
-	UserID      string                 `json:"user_id"`
-}
\ No newline at end of file
+	Items  []PersonalInfoResponse `json:"items"`
+	Total  int                    `json:"total"`
+	UserID string                 `json:"user_id"`
+}
+
+// PersonalInfoResult represents a created, read or updated personal info entry
+type PersonalInfoResult struct {
+	PersonalInfo     PersonalInfoResponse `json:"personal_info"`
+	ProcessingTimeMs int64                `json:"processing_time_ms"`
+}
+
+// PersonalInfoListResult represents a user's personal info entries
+type PersonalInfoListResult struct {
+	PersonalInfoList PersonalInfoListResponse `json:"personal_info_list"`
+	ProcessingTimeMs int64                    `json:"processing_time_ms"`
+}
+
+// PersonalInfoDeleteResponse represents a deleted personal info entry
+type PersonalInfoDeleteResponse struct {
+	DeletedInfoID    string `json:"deleted_info_id"`
+	ProcessingTimeMs int64  `json:"processing_time_ms"`
+}
+
+// Response converts the entry to its API representation
+func (p *PersonalInfo) Response() PersonalInfoResponse {
+	return PersonalInfoResponse{
+		ID:          p.ID,
+		UserID:      p.UserID,
+		Content:     p.Content,
+		Category:    p.Category,
+		Importance:  p.Importance,
+		Pinned:      p.Pinned,
+		Suppression: p.Suppression,
+		CreatedAt:   p.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:   p.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
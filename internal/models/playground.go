@@ -0,0 +1,91 @@
+package models
+
+// PlaygroundRequest runs one search through several pipeline configurations
+type PlaygroundRequest struct {
+	Query    string  `json:"query" binding:"required"`
+	UserID   string  `json:"user_id,omitempty"`
+	TopK     int     `json:"top_k,omitempty"`
+	MinScore float32 `json:"min_score,omitempty"`
+
+	// Filter is a metadata filter, e.g. source = "slack" AND priority >= 3
+	Filter string `json:"filter,omitempty"`
+
+	// Configurations are run in order; the first is the baseline the others are compared with
+	Configurations []PlaygroundConfiguration `json:"configurations" binding:"required,min=1,max=8,dive"`
+}
+
+// PlaygroundConfiguration is a named variant of the search pipeline. Stage lists left out are
+// the configured pipeline's; an empty list runs without that kind of stage, e.g. "rerankers": []
+type PlaygroundConfiguration struct {
+	Name string `json:"name" binding:"required"`
+
+	Transformers []string `json:"transformers"`
+	Retrievers   []string `json:"retrievers"`
+	Fuser        string   `json:"fuser,omitempty"`
+	Filters      []string `json:"filters"`
+	Normalizer   string   `json:"normalizer,omitempty"`
+	Rerankers    []string `json:"rerankers"`
+
+	// Reranker blend weights replace the configured ones when set
+	RecencyWeight    *float64 `json:"recency_weight,omitempty"`
+	ImportanceWeight *float64 `json:"importance_weight,omitempty"`
+
+	// Retrieval overrides, as the search endpoint's parameters of the same names
+	CandidateMultiplier float64            `json:"candidate_multiplier,omitempty"`
+	HNSWEf              int                `json:"hnsw_ef,omitempty"`
+	FusionWeights       map[string]float64 `json:"fusion_weights,omitempty"`
+	RecencyHalfLife     string             `json:"recency_half_life,omitempty"`
+	ImportanceHalfLife  string             `json:"importance_half_life,omitempty"`
+}
+
+// PlaygroundResponse holds each configuration's results, in request order
+type PlaygroundResponse struct {
+	Query string          `json:"query"`
+	Runs  []PlaygroundRun `json:"runs"`
+}
+
+// PlaygroundRun is one configuration's search
+type PlaygroundRun struct {
+	Name string `json:"name"`
+
+	// Stages lists the pipeline stages the configuration resolved to
+	Stages PlaygroundStages `json:"stages"`
+
+	Results   []PlaygroundResult `json:"results"`
+	LatencyMs int64              `json:"latency_ms"`
+
+	// Overlap is the share of the baseline's results this run also returned; Added and Dropped
+	// list the conversations only this run or only the baseline returned
+	Overlap float64  `json:"overlap"`
+	Added   []string `json:"added"`
+	Dropped []string `json:"dropped"`
+
+	// Error is set instead of results when the configuration failed
+	Error string `json:"error,omitempty"`
+}
+
+// PlaygroundStages names the stages of a playground run
+type PlaygroundStages struct {
+	Transformers []string `json:"transformers"`
+	Retrievers   []string `json:"retrievers"`
+	Fuser        string   `json:"fuser"`
+	Filters      []string `json:"filters"`
+	Normalizer   string   `json:"normalizer"`
+	Rerankers    []string `json:"rerankers"`
+}
+
+// PlaygroundResult is a ranked conversation of a playground run
+type PlaygroundResult struct {
+	Rank           int     `json:"rank"`
+	ConversationID string  `json:"conversation_id"`
+	Score          float32 `json:"score"`
+
+	// RawScore is the fused score before normalization and reranking
+	RawScore float32 `json:"raw_score"`
+
+	// BaselineRank is the conversation's rank in the baseline run, 0 when the baseline missed it
+	BaselineRank int `json:"baseline_rank"`
+
+	// Preview is the start of the conversation's question
+	Preview string `json:"preview"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// UserProfile is a synthesized long-term summary of a user built from personal info and conversations
+type UserProfile struct {
+	UserID      string         `json:"user_id"`
+	Preferences []string       `json:"preferences"`
+	Routines    []string       `json:"routines"`
+	HealthNotes []string       `json:"health_notes"`
+	Sources     ProfileSources `json:"sources"`
+	GeneratedAt time.Time      `json:"generated_at"`
+}
+
+// ProfileSources counts the records a profile was synthesized from
+type ProfileSources struct {
+	PersonalInfoCount int `json:"personal_info_count"`
+	ConversationCount int `json:"conversation_count"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// QueryAdapter personalizes a user's query embeddings for one model. A query vector q becomes
+// scale*q + bias, component by component, scaled back to unit length; without a scale it is q + bias
+type QueryAdapter struct {
+	UserID string    `json:"user_id"`
+	Model  string    `json:"model"`
+	Bias   []float32 `json:"bias"`
+	Scale  []float32 `json:"scale,omitempty"`
+
+	// Samples is how many feedback events the adapter was trained on
+	Samples   int        `json:"samples,omitempty"`
+	TrainedAt *time.Time `json:"trained_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// QueryAdapterUploadRequest uploads an adapter trained offline, replacing the user's adapter for
+// the model
+type QueryAdapterUploadRequest struct {
+	// Model defaults to the conversations collection's model
+	Model string `json:"model"`
+
+	// Bias and Scale have the dimension of the model's stored vectors, after any projection
+	Bias  []float32 `json:"bias" binding:"required"`
+	Scale []float32 `json:"scale"`
+
+	Samples   int        `json:"samples" binding:"min=0"`
+	TrainedAt *time.Time `json:"trained_at"`
+}
+
+// QueryAdapterSummary describes an adapter without its vectors
+type QueryAdapterSummary struct {
+	Model     string `json:"model"`
+	Dimension int    `json:"dimension"`
+
+	// BiasNorm is the length of the bias, against unit-length query vectors
+	BiasNorm  float64    `json:"bias_norm"`
+	Scaled    bool       `json:"scaled"`
+	Samples   int        `json:"samples,omitempty"`
+	TrainedAt *time.Time `json:"trained_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// QueryAdapterListResponse lists a user's adapters
+type QueryAdapterListResponse struct {
+	UserID   string                `json:"user_id"`
+	Adapters []QueryAdapterSummary `json:"adapters"`
+}
+
+// QueryAdapterDeleteResponse identifies a deleted adapter
+type QueryAdapterDeleteResponse struct {
+	UserID string `json:"user_id"`
+	Model  string `json:"model"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Work queue item kinds
+const (
+	QueueKindConversationVector = "conversation_vector"
+
+	// QueueKindConversationVectorBackfill embeds conversations saved in the backfill lane; workers
+	// claim its items only when no other work is due
+	QueueKindConversationVectorBackfill = "conversation_vector_backfill"
+)
+
+// QueueItem is a unit of background work leased by one worker at a time
+type QueueItem struct {
+	ID          int64           `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload" swaggertype:"object"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	AvailableAt time.Time       `json:"available_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// QueueKindStats reports the backlog of one queue item kind
+type QueueKindStats struct {
+	Kind string `json:"kind"`
+
+	// Depth counts items waiting or leased
+	Depth int64 `json:"depth"`
+
+	// Leased counts items currently held by a worker
+	Leased int64 `json:"leased"`
+
+	// LagSeconds is the age of the oldest item that is due and not leased
+	LagSeconds float64 `json:"lag_seconds"`
+
+	// OldestSeconds is the age of the oldest item, due, leased or waiting for a retry
+	OldestSeconds float64 `json:"oldest_seconds"`
+}
+
+// ConversationVectorJob asks a worker to embed a conversation and write its vector
+type ConversationVectorJob struct {
+	ConversationID string `json:"conversation_id"`
+}
+
+// DeadLetter is a queue item that failed on every attempt, kept for inspection and replay
+type DeadLetter struct {
+	ID        int64           `json:"id"`
+	Kind      string          `json:"kind"`
+	Payload   json.RawMessage `json:"payload" swaggertype:"object"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error"`
+	CreatedAt time.Time       `json:"created_at"`
+	FailedAt  time.Time       `json:"failed_at"`
+}
+
+// DeadLetterListResponse represents a page of dead letters
+type DeadLetterListResponse struct {
+	DeadLetters []*DeadLetter `json:"dead_letters"`
+	Total       int           `json:"total"`
+	Kind        string        `json:"kind,omitempty"`
+	Limit       int           `json:"limit"`
+	Offset      int           `json:"offset"`
+}
+
+// DeadLetterRequeueResponse represents a dead letter put back on the work queue
+type DeadLetterRequeueResponse struct {
+	RequeuedID int64 `json:"requeued_id"`
+}
+
+// DeadLetterDiscardResponse represents a deleted dead letter
+type DeadLetterDiscardResponse struct {
+	DiscardedID int64 `json:"discarded_id"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Scheduled task run outcomes
+const (
+	ScheduledRunSucceeded = "succeeded"
+	ScheduledRunFailed    = "failed"
+	ScheduledRunSkipped   = "skipped"
+)
+
+// ScheduledTaskStatus reports a scheduled task's configuration and its runs on this replica
+type ScheduledTaskStatus struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	Enabled  bool   `json:"enabled"`
+	JitterMs int64  `json:"jitter_ms"`
+	Running  bool   `json:"running"`
+
+	// NextRunAt is when the task runs next if this replica is the leader then
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms,omitempty"`
+
+	// LastOutcome is succeeded or failed for the last finished run, or skipped when the last due
+	// run found the previous one still going
+	LastOutcome string `json:"last_outcome,omitempty"`
+	LastError   string `json:"last_error,omitempty"`
+
+	Runs     int64 `json:"runs"`
+	Failures int64 `json:"failures"`
+	Skipped  int64 `json:"skipped"`
+}
+
+// ScheduledTasksResponse lists the scheduled tasks; only the leader replica runs them on schedule
+type ScheduledTasksResponse struct {
+	Leader bool                  `json:"leader"`
+	Tasks  []ScheduledTaskStatus `json:"tasks"`
+}
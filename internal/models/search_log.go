@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// SearchLog records a conversation search for usage analytics
+type SearchLog struct {
+	ID         int64   `json:"id"`
+	Tenant     string  `json:"tenant"`
+	UserID     string  `json:"user_id,omitempty"`
+	Query      string  `json:"query"`
+	Results    int     `json:"results"`
+	TopScore   float32 `json:"top_score"`
+	DurationMs int64   `json:"duration_ms"`
+
+	// Variant is the retrieval pipeline that served the search: primary or canary
+	Variant   string    `json:"variant"`
+	CreatedAt time.Time `json:"created_at"`
+}
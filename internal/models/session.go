@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Session statuses
+const (
+	SessionStatusOpen   = "open"
+	SessionStatusClosed = "closed"
+)
+
+// Session groups the conversations of a single chat session
+type Session struct {
+	ID                string     `json:"id"`
+	UserID            string     `json:"user_id"`
+	Title             string     `json:"title,omitempty"`
+	Status            string     `json:"status"`
+	Summary           string     `json:"summary,omitempty"`
+	SummaryUpdatedAt  *time.Time `json:"summary_updated_at,omitempty"`
+	ConversationCount int        `json:"conversation_count"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	ClosedAt          *time.Time `json:"closed_at,omitempty"`
+}
+
+// SessionCreateRequest represents a request to create a session
+type SessionCreateRequest struct {
+	SessionID string `json:"session_id,omitempty"` // Optional client-chosen ID; generated when empty
+	UserID    string `json:"user_id" binding:"required"`
+	Title     string `json:"title,omitempty" binding:"max=255"`
+}
+
+// SessionListResponse represents a page of sessions
+type SessionListResponse struct {
+	Sessions []*Session `json:"sessions"`
+	Total    int        `json:"total"`
+	UserID   string     `json:"user_id,omitempty"`
+	Status   string     `json:"status,omitempty"`
+	Limit    int        `json:"limit"`
+	Offset   int        `json:"offset"`
+}
+
+// SessionTranscriptResponse represents a session with its conversations in chronological order
+type SessionTranscriptResponse struct {
+	Session       *Session               `json:"session"`
+	Conversations []ConversationResponse `json:"conversations"`
+	MessageCount  int                    `json:"message_count"`
+}
+
+// SessionContextResponse represents the compact context of a session for prompt building
+type SessionContextResponse struct {
+	SessionID         string     `json:"session_id"`
+	UserID            string     `json:"user_id"`
+	Summary           string     `json:"summary"`
+	SummaryUpdatedAt  *time.Time `json:"summary_updated_at,omitempty"`
+	ConversationCount int        `json:"conversation_count"`
+	RecentMessages    []Message  `json:"recent_messages"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// StandingQueryMatchEvent is the event of standing query matches
+const StandingQueryMatchEvent = "standing_query.matched"
+
+// DefaultStandingQueryThreshold is the similarity a conversation needs to match a standing query
+// registered without a threshold
+const DefaultStandingQueryThreshold = 0.75
+
+// StandingQuery is a search registered to run against every conversation the user saves from now
+// on, such as "tell me whenever grandma mentions dizziness"
+type StandingQuery struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	Query  string `json:"query"`
+	Filter string `json:"filter,omitempty"`
+
+	// Threshold is the similarity between the query and a conversation's vector, on the scale of
+	// search scores, at or above which the conversation matches
+	Threshold float32 `json:"threshold"`
+
+	MatchCount    int64      `json:"match_count"`
+	LastMatchedAt *time.Time `json:"last_matched_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+
+	// Vector is the embedded query, computed once when the query is registered
+	Vector []float32 `json:"-"`
+}
+
+// StandingQueryCreateRequest registers a standing query
+type StandingQueryCreateRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	Query  string `json:"query" binding:"required,max=1000"`
+
+	// Filter restricts matches with the metadata filter syntax of searches
+	Filter string `json:"filter,omitempty"`
+
+	// Threshold defaults to DefaultStandingQueryThreshold
+	Threshold *float32 `json:"threshold,omitempty" binding:"omitempty,gt=0,lte=1"`
+}
+
+// StandingQueryListResponse represents a user's standing queries
+type StandingQueryListResponse struct {
+	UserID          string           `json:"user_id"`
+	StandingQueries []*StandingQuery `json:"standing_queries"`
+}
+
+// StandingQueryDeleteResponse identifies a deleted standing query
+type StandingQueryDeleteResponse struct {
+	DeletedQueryID string `json:"deleted_query_id"`
+}
+
+// StandingQueryMatch reports a saved conversation that matched a standing query. It is posted to
+// the standing query webhook and sent to the user's match streams
+type StandingQueryMatch struct {
+	Event           string    `json:"event"`
+	StandingQueryID string    `json:"standing_query_id"`
+	UserID          string    `json:"user_id"`
+	Query           string    `json:"query"`
+	ConversationID  string    `json:"conversation_id"`
+	Score           float32   `json:"score"`
+	MatchedAt       time.Time `json:"matched_at"`
+}
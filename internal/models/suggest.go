@@ -0,0 +1,33 @@
+package models
+
+// Query suggestion sources
+const (
+	// SuggestionSourceQuery suggests a query the user searched before and found results for
+	SuggestionSourceQuery = "query"
+
+	// SuggestionSourceTopic suggests a word or phrase recurring in the user's conversations
+	SuggestionSourceTopic = "topic"
+)
+
+// QuerySuggestRequest asks for completions of a partly typed search
+type QuerySuggestRequest struct {
+	UserID string `json:"user_id"`
+	Prefix string `json:"prefix"`
+	Limit  int    `json:"limit"`
+}
+
+// QuerySuggestion is a completion offered for a partly typed search
+type QuerySuggestion struct {
+	Text   string `json:"text"`
+	Source string `json:"source"`
+
+	// Count is how often the query was searched, or how many conversations mention the topic
+	Count int `json:"count"`
+}
+
+// QuerySuggestResponse represents the completions of a prefix, past queries first
+type QuerySuggestResponse struct {
+	UserID      string            `json:"user_id"`
+	Prefix      string            `json:"prefix"`
+	Suggestions []QuerySuggestion `json:"suggestions"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// TimelineRequest asks for a user's conversations grouped by day, going back in time
+type TimelineRequest struct {
+	UserID string `json:"user_id"`
+
+	// Before is the start of the day after the newest day returned; zero starts at the latest
+	// conversation
+	Before time.Time `json:"before"`
+
+	Days   int            `json:"days"`
+	PerDay int            `json:"per_day"`
+	Loc    *time.Location `json:"-"` // Days start at midnight in this location
+}
+
+// TimelineEntry summarizes a conversation in a timeline
+type TimelineEntry struct {
+	ConversationID string    `json:"conversation_id"`
+	Summary        string    `json:"summary"` // the start of the conversation's first user message
+	MessageCount   int       `json:"message_count"`
+	Status         string    `json:"status"`
+	Pinned         bool      `json:"pinned"`
+	Suppressed     bool      `json:"suppressed"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TimelineDay is a day of a user's timeline with its newest conversations
+type TimelineDay struct {
+	Date          string          `json:"date"`  // YYYY-MM-DD in the timeline's time zone
+	Count         int             `json:"count"` // conversations on the day, including those not listed
+	Conversations []TimelineEntry `json:"conversations"`
+}
+
+// TimelineResponse represents a page of a user's timeline, newest day first. Days without
+// conversations are skipped
+type TimelineResponse struct {
+	UserID   string        `json:"user_id"`
+	TimeZone string        `json:"time_zone"`
+	Days     []TimelineDay `json:"days"`
+
+	// NextBefore is the before value of the next, older page; empty when there are no older days
+	NextBefore string `json:"next_before,omitempty"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// TopicMapRequest shapes a topic map
+type TopicMapRequest struct {
+	// Clusters is the number of topics; 0 picks one from the number of conversations
+	Clusters int
+
+	// Representatives is the number of conversations listed per topic
+	Representatives int
+
+	// MaxConversations bounds the most recent conversations clustered
+	MaxConversations int
+
+	// Label names the topics with the chat model
+	Label bool
+}
+
+// TopicMap groups a user's conversations into topics by their stored vectors
+type TopicMap struct {
+	UserID string `json:"user_id"`
+
+	// Conversations is how many conversations were clustered: the user's most recent ones with a
+	// stored vector, excluding suppressed ones
+	Conversations int `json:"conversations"`
+
+	// Truncated is set when the user has more conversations than were clustered
+	Truncated bool `json:"truncated"`
+
+	// Labeled is set when the chat model named the topics; labels are empty otherwise
+	Labeled bool `json:"labeled"`
+
+	Topics      []Topic   `json:"topics"`
+	GeneratedAt time.Time `json:"generated_at"`
+	DurationMs  int64     `json:"duration_ms"`
+}
+
+// Topic is a cluster of similar conversations, largest first
+type Topic struct {
+	ID      int    `json:"id"`
+	Label   string `json:"label,omitempty"`
+	Summary string `json:"summary,omitempty"`
+
+	Size int `json:"size"`
+
+	// Share is the fraction of the clustered conversations in the topic
+	Share float64 `json:"share"`
+
+	// Cohesion is the mean cosine similarity of the topic's conversations to its centroid
+	Cohesion float64 `json:"cohesion"`
+
+	FirstAt time.Time `json:"first_at"`
+	LastAt  time.Time `json:"last_at"`
+
+	// Representatives are the conversations closest to the topic's centroid
+	Representatives []TopicConversation `json:"representatives"`
+}
+
+// TopicConversation is a conversation representing a topic
+type TopicConversation struct {
+	ConversationID string    `json:"conversation_id"`
+	Excerpt        string    `json:"excerpt"`
+	Similarity     float64   `json:"similarity"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+// UsageRecord is the embedding usage of one tenant and model on one UTC day
+type UsageRecord struct {
+	Day          string `json:"day"` // YYYY-MM-DD
+	Tenant       string `json:"tenant,omitempty"`
+	Model        string `json:"model"`
+	Requests     int64  `json:"requests"`
+	PromptTokens int64  `json:"prompt_tokens"`
+	TotalTokens  int64  `json:"total_tokens"`
+}
+
+// UsageReport lists daily embedding usage over a date range with its totals
+type UsageReport struct {
+	From         string        `json:"from"`
+	To           string        `json:"to"`
+	Records      []UsageRecord `json:"records"`
+	Requests     int64         `json:"requests"`
+	PromptTokens int64         `json:"prompt_tokens"`
+	TotalTokens  int64         `json:"total_tokens"`
+}
+
+// QuotaWarningEvent is the event of quota warning webhooks
+const QuotaWarningEvent = "embedding_budget.warning"
+
+// QuotaWarning is posted to the quota webhook when a budget period's spend first reaches a
+// warning threshold
+type QuotaWarning struct {
+	Event     string  `json:"event"`
+	Period    string  `json:"period"` // day or month
+	Threshold float64 `json:"threshold"`
+	Limit     int64   `json:"limit"`
+	Used      int64   `json:"used"`
+	Remaining int64   `json:"remaining"`
+	ResetsAt  string  `json:"resets_at"`
+	SentAt    string  `json:"sent_at"`
+}
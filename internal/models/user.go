@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// User statuses
+const (
+	UserStatusActive   = "active"
+	UserStatusDisabled = "disabled"
+)
+
+// User is a registered end user. Users don't have to be registered: a user_id without a record
+// is treated as active, so registering is only needed to name, disable or exempt a user
+type User struct {
+	ID             string     `json:"id"`
+	DisplayName    string     `json:"display_name,omitempty"`
+	Status         string     `json:"status"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+
+	// RetentionExempt protects the user's conversations from retention runs, e.g. under a legal hold
+	RetentionExempt bool `json:"retention_exempt"`
+
+	// Region is the home region of the user's data; empty follows the tenant's region
+	Region string `json:"region,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserCreateRequest represents a request to register a user
+type UserCreateRequest struct {
+	UserID          string `json:"user_id" binding:"required,max=255"`
+	DisplayName     string `json:"display_name,omitempty" binding:"max=255"`
+	RetentionExempt bool   `json:"retention_exempt,omitempty"`
+	Region          string `json:"region,omitempty" binding:"max=64"`
+}
+
+// UserUpdateRequest represents a partial update of a user; omitted fields are unchanged
+type UserUpdateRequest struct {
+	DisplayName     *string `json:"display_name,omitempty" binding:"omitempty,max=255"`
+	RetentionExempt *bool   `json:"retention_exempt,omitempty"`
+	Region          *string `json:"region,omitempty" binding:"omitempty,max=64"`
+}
+
+// UserDisableRequest represents a request to disable a user
+type UserDisableRequest struct {
+	Reason string `json:"reason,omitempty" binding:"max=500"`
+}
+
+// UserListResponse represents a page of registered users
+type UserListResponse struct {
+	Users  []*User `json:"users"`
+	Total  int     `json:"total"`
+	Status string  `json:"status,omitempty"`
+	Limit  int     `json:"limit"`
+	Offset int     `json:"offset"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// UserStats are a user's conversation aggregates, kept up to date as conversations are written
+// and deleted so they never require counting the user's rows
+type UserStats struct {
+	UserID             string     `json:"user_id"`
+	ConversationCount  int64      `json:"conversation_count"`
+	LastConversationAt *time.Time `json:"last_conversation_at,omitempty"` // last activity; kept when conversations are deleted
+	TotalTokens        int64      `json:"total_tokens"`                   // embedding tokens spent on the user's conversations
+}
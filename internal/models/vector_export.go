@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// VectorPoint is a stored vector with its payload, identified by the ID of the record it embeds
+type VectorPoint struct {
+	ID      string
+	Vector  []float32
+	Payload map[string]interface{}
+}
+
+// VectorExport reports an export of a collection's vectors; it is also stored as the export's
+// manifest next to the exported files
+type VectorExport struct {
+	ContentType string    `json:"content_type"`
+	Collection  string    `json:"collection"`
+	Model       string    `json:"model"`
+	Dimension   int       `json:"dimension"`
+	Points      int64     `json:"points"`
+	ParquetKey  string    `json:"parquet_key"`
+	NumPyKey    string    `json:"npy_key"`
+	MetadataKey string    `json:"metadata_key"`
+	ExportedAt  time.Time `json:"exported_at"`
+	DurationMs  int64     `json:"duration_ms"`
+}
+
+// VectorImportRequest represents a request to load vectors computed offline from the blob store
+type VectorImportRequest struct {
+	// ContentType selects the collection: conversations, personal_info or documents
+	ContentType string `json:"content_type" binding:"required"`
+
+	// Key is a Parquet file with id and embedding columns, or a .npy float32 matrix
+	Key string `json:"key" binding:"required"`
+
+	// IDsKey is a JSON lines file whose id fields name the rows of a .npy matrix, in order
+	IDsKey string `json:"ids_key,omitempty"`
+
+	// DryRun validates the file and counts matching points without writing
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// VectorImport reports an import of vectors computed offline
+type VectorImport struct {
+	ContentType string `json:"content_type"`
+	Key         string `json:"key"`
+	DryRun      bool   `json:"dry_run"`
+	Rows        int64  `json:"rows"`
+	Updated     int64  `json:"updated"`
+
+	// Missing counts rows whose ID has no stored point; they are skipped
+	Missing    int64 `json:"missing"`
+	DurationMs int64 `json:"duration_ms"`
+}
@@ -0,0 +1,524 @@
+// Package openapi describes the running server's API as an OpenAPI 3 document. Operations come
+// from the routes actually registered, so endpoints disabled by configuration are left out and
+// endpoints without swag annotations still appear. Their parameters, bodies and responses are taken
+// from the swag-generated Swagger 2.0 document, whose schemas swag derives from the typed models;
+// the error envelope is reflected from models.ErrorResponse itself. Security schemes follow the
+// authentication middleware, and every JSON body carries an example built from its schema.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/deadline"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/signing"
+	"refo-rag-server/internal/tenant"
+)
+
+// Version is the OpenAPI version of the generated document
+const Version = "3.0.3"
+
+// Security scheme names
+const (
+	SchemeAdminAPIKey = "AdminAPIKey"
+	SchemeAPIKey      = "APIKey"
+	SchemeBearer      = "BearerAuth"
+	SchemeSignature   = "RequestSignature"
+)
+
+// schemaPrefix is where component schemas are referenced
+const schemaPrefix = "#/components/schemas/"
+
+// object is a JSON object of the document
+type object = map[string]interface{}
+
+// Options describes how the routes are served
+type Options struct {
+	// Swagger is the swag-generated Swagger 2.0 document of the annotated handlers
+	Swagger string
+
+	// Prefix limits the document to routes under it, leaving out e.g. the metrics and UI routes
+	Prefix string
+
+	// AdminPrefixes are the path prefixes that need an admin key
+	AdminPrefixes []string
+
+	// Public are the paths that never need a key
+	Public []string
+
+	// RequireAPIKeys is set when routes outside the admin API need a service account key
+	RequireAPIKeys bool
+
+	// Signatures is set when a request signature may stand in for a service account key
+	Signatures bool
+}
+
+// swaggerDocument is the part of a Swagger 2.0 document converted
+type swaggerDocument struct {
+	Info        object                       `json:"info"`
+	Paths       map[string]map[string]object `json:"paths"`
+	Definitions map[string]object            `json:"definitions"`
+}
+
+// builder converts the operations of one document
+type builder struct {
+	opts     Options
+	schemas  object
+	errorRef object
+}
+
+// Build returns the OpenAPI document of routes
+func Build(routes gin.RoutesInfo, opts Options) (map[string]interface{}, error) {
+	var swagger swaggerDocument
+	if err := json.Unmarshal([]byte(opts.Swagger), &swagger); err != nil {
+		return nil, fmt.Errorf("failed to parse swagger document: %w", err)
+	}
+
+	schemas := make(object, len(swagger.Definitions))
+	for name, definition := range swagger.Definitions {
+		schemas[name] = convertRefs(definition)
+	}
+	b := &builder{
+		opts:    opts,
+		schemas: schemas,
+		// Reflected rather than taken from swag so the envelope matches what handlers send
+		errorRef: newReflector(schemas).ref(reflect.TypeOf(models.ErrorResponse{})),
+	}
+
+	sorted := make(gin.RoutesInfo, 0, len(routes))
+	for _, route := range routes {
+		if strings.HasPrefix(route.Path, opts.Prefix) {
+			sorted = append(sorted, route)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	paths := object{}
+	for _, route := range sorted {
+		path := templatePath(route.Path)
+		method := strings.ToLower(route.Method)
+
+		var operation object
+		if documented, ok := swagger.Paths[path][method]; ok {
+			operation = b.operation(documented)
+		} else {
+			operation = b.undocumented(route.Method, path)
+		}
+		operation["operationId"] = operationID(method, strings.TrimPrefix(path, opts.Prefix))
+		operation["parameters"] = append(asList(operation["parameters"]),
+			object{"$ref": "#/components/parameters/TenantID"},
+			object{"$ref": "#/components/parameters/RequestDeadline"},
+		)
+		b.secure(path, operation)
+		b.addErrors(operation)
+
+		item, _ := paths[path].(object)
+		if item == nil {
+			item = object{}
+			paths[path] = item
+		}
+		item[method] = operation
+	}
+
+	return object{
+		"openapi": Version,
+		"info":    swagger.Info,
+		"paths":   paths,
+		"components": object{
+			"schemas":         schemas,
+			"securitySchemes": b.securitySchemes(),
+			"parameters": object{
+				"TenantID": object{
+					"name":        tenant.Header,
+					"in":          "header",
+					"description": "Tenant the request acts for; the default tenant when absent",
+					"schema":      object{"type": "string"},
+				},
+				"RequestDeadline": object{
+					"name":        deadline.Header,
+					"in":          "header",
+					"description": "When the caller stops waiting, as an RFC 3339 time or Unix milliseconds; dependency calls are budgeted against it and optional search stages skipped when it is near. Grpc-Timeout is accepted instead",
+					"schema":      object{"type": "string"},
+				},
+			},
+		},
+	}, nil
+}
+
+// operation converts a Swagger 2.0 operation
+func (b *builder) operation(op object) object {
+	out := object{}
+	for _, key := range []string{"summary", "description", "tags", "security", "deprecated"} {
+		if value, ok := op[key]; ok {
+			out[key] = value
+		}
+	}
+	consumes := firstString(op["consumes"], "application/json")
+	produces := firstString(op["produces"], "application/json")
+
+	var parameters []interface{}
+	form := object{"type": "object", "properties": object{}}
+	var formRequired []interface{}
+	for _, raw := range asList(op["parameters"]) {
+		param, _ := raw.(object)
+		switch param["in"] {
+		case "body":
+			schema, _ := convertRefs(param["schema"]).(object)
+			out["requestBody"] = object{
+				"description": param["description"],
+				"required":    param["required"] == true,
+				"content":     object{consumes: b.media(schema)},
+			}
+		case "formData":
+			form["properties"].(object)[param["name"].(string)] = parameterSchema(param)
+			if param["required"] == true {
+				formRequired = append(formRequired, param["name"])
+			}
+		default:
+			parameters = append(parameters, convertParameter(param))
+		}
+	}
+	if len(form["properties"].(object)) > 0 {
+		if len(formRequired) > 0 {
+			form["required"] = formRequired
+		}
+		if consumes != "application/x-www-form-urlencoded" {
+			consumes = "multipart/form-data"
+		}
+		out["requestBody"] = object{"required": true, "content": object{consumes: object{"schema": form}}}
+	}
+	if parameters != nil {
+		out["parameters"] = parameters
+	}
+
+	responses := object{}
+	for code, raw := range asObject(op["responses"]) {
+		response, _ := raw.(object)
+		converted := object{"description": response["description"]}
+		if schema, ok := convertRefs(response["schema"]).(object); ok {
+			media := b.media(schema)
+			status := statusCode(code)
+			if status >= http.StatusBadRequest && b.isErrorEnvelope(schema) {
+				media["example"] = errorExample(status, fmt.Sprint(response["description"]))
+			} else if example, ok := media["example"].(object); ok && status < http.StatusBadRequest {
+				succeeded(example)
+			}
+			converted["content"] = object{produces: media}
+		}
+		if headers := asObject(response["headers"]); len(headers) > 0 {
+			convertedHeaders := object{}
+			for name, rawHeader := range headers {
+				header, _ := rawHeader.(object)
+				convertedHeaders[name] = object{"description": header["description"], "schema": parameterSchema(header)}
+			}
+			converted["headers"] = convertedHeaders
+		}
+		responses[code] = converted
+	}
+	out["responses"] = responses
+	return out
+}
+
+// undocumented describes a registered route without swag annotations by its path alone
+func (b *builder) undocumented(method string, path string) object {
+	var parameters []interface{}
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") {
+			parameters = append(parameters, object{
+				"name":     strings.Trim(segment, "{}"),
+				"in":       "path",
+				"required": true,
+				"schema":   object{"type": "string"},
+			})
+		}
+	}
+
+	op := object{
+		"summary":        method + " " + path,
+		"description":    "This route has no annotations yet, so only its path, authentication and error envelope are described.",
+		"tags":           []interface{}{b.tag(path)},
+		"x-undocumented": true,
+		"responses": object{
+			"200": object{
+				"description": "Success",
+				"content":     object{"application/json": object{"schema": object{"type": "object"}}},
+			},
+		},
+	}
+	if parameters != nil {
+		op["parameters"] = parameters
+	}
+	return op
+}
+
+// tag groups an undocumented route with the documented routes it most likely belongs to
+func (b *builder) tag(path string) string {
+	if b.isAdmin(path) {
+		return "admin"
+	}
+	segment, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(path, b.opts.Prefix), "/"), "/")
+	if segment == "" {
+		return "default"
+	}
+	return segment
+}
+
+// secure sets the keys an operation is served with, matching the authentication middleware
+func (b *builder) secure(path string, op object) {
+	switch {
+	case b.isAdmin(path):
+		op["security"] = []interface{}{object{SchemeAdminAPIKey: []interface{}{}}, object{SchemeBearer: []interface{}{}}}
+	case b.opts.RequireAPIKeys && !b.isPublic(path):
+		security := []interface{}{object{SchemeAPIKey: []interface{}{}}, object{SchemeBearer: []interface{}{}}}
+		if b.opts.Signatures {
+			security = append(security, object{SchemeSignature: []interface{}{}})
+		}
+		op["security"] = security
+	default:
+		delete(op, "security")
+	}
+}
+
+// addErrors adds the error responses every operation may return besides its own
+func (b *builder) addErrors(op object) {
+	responses, _ := op["responses"].(object)
+	add := func(status int, description string) {
+		code := fmt.Sprint(status)
+		if _, ok := responses[code]; ok {
+			return
+		}
+		responses[code] = object{
+			"description": description,
+			"content":     object{"application/json": object{"schema": b.errorRef, "example": errorExample(status, description)}},
+		}
+	}
+	if op["security"] != nil {
+		add(http.StatusUnauthorized, "Missing or invalid API key")
+		add(http.StatusForbidden, "API key lacks the scope this request needs")
+	}
+	if _, ok := responses["default"]; !ok {
+		responses["default"] = object{
+			"description": "Error envelope; error.code tells failures apart",
+			"content":     object{"application/json": object{"schema": b.errorRef}},
+		}
+	}
+}
+
+// securitySchemes describes the ways a request may authenticate
+func (b *builder) securitySchemes() object {
+	schemes := object{
+		SchemeAdminAPIKey: object{
+			"type":        "apiKey",
+			"in":          "header",
+			"name":        "X-API-Key",
+			"description": "ADMIN_API_KEY or a service account key with the admin scope",
+		},
+		SchemeBearer: object{
+			"type":        "http",
+			"scheme":      "bearer",
+			"description": "Any key accepted in X-API-Key, sent as Authorization: Bearer <key>",
+		},
+	}
+	if b.opts.RequireAPIKeys {
+		schemes[SchemeAPIKey] = object{
+			"type":        "apiKey",
+			"in":          "header",
+			"name":        "X-API-Key",
+			"description": "Service account key; reads need the read scope and writes the write scope",
+		}
+	}
+	if b.opts.Signatures {
+		schemes[SchemeSignature] = object{
+			"type": "apiKey",
+			"in":   "header",
+			"name": signing.HeaderSignature,
+			"description": fmt.Sprintf("HMAC signature of the request, sent with %s, %s and %s",
+				signing.HeaderClientID, signing.HeaderTimestamp, signing.HeaderNonce),
+		}
+	}
+	return schemes
+}
+
+// media describes a body with its schema and an example of it
+func (b *builder) media(schema object) object {
+	media := object{"schema": schema}
+	if example := b.example(schema, 0); example != nil {
+		media["example"] = example
+	}
+	return media
+}
+
+// isErrorEnvelope reports whether schema is the error envelope
+func (b *builder) isErrorEnvelope(schema object) bool {
+	return schema["$ref"] == b.errorRef["$ref"]
+}
+
+func (b *builder) isAdmin(path string) bool {
+	for _, prefix := range b.opts.AdminPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *builder) isPublic(path string) bool {
+	for _, public := range b.opts.Public {
+		if path == public {
+			return true
+		}
+	}
+	return false
+}
+
+// errorExample is the error envelope of a failed request
+func errorExample(status int, message string) models.ErrorResponse {
+	code, ok := errorCodes[status]
+	if !ok {
+		code = strings.ToUpper(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+	}
+	return models.ErrorResponse{
+		Success:  false,
+		Error:    &models.ErrorInfo{Code: code, Message: message},
+		Metadata: models.Metadata{},
+	}
+}
+
+// succeeded turns the example of a models.APIResponse envelope into that of a successful request
+func succeeded(example object) {
+	if _, ok := example["success"]; !ok {
+		return
+	}
+	example["success"] = true
+	example["metadata"] = models.Metadata{}
+	delete(example, "error")
+}
+
+// errorCodes are the error codes most often sent with a status
+var errorCodes = map[int]string{
+	http.StatusBadRequest:          "INVALID_REQUEST",
+	http.StatusUnauthorized:        "UNAUTHORIZED",
+	http.StatusForbidden:           "INSUFFICIENT_SCOPE",
+	http.StatusInternalServerError: "INTERNAL_ERROR",
+	http.StatusServiceUnavailable:  "OVERLOADED",
+}
+
+// templatePath converts gin path parameters, :name and *name, to OpenAPI templates
+func templatePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// operationID names an operation after its method and path, e.g. getConversationByConversationId
+func operationID(method string, path string) string {
+	var id strings.Builder
+	id.WriteString(method)
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") {
+			id.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			id.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return id.String()
+}
+
+// convertParameter converts a Swagger 2.0 query, path or header parameter
+func convertParameter(param object) object {
+	out := object{"name": param["name"], "in": param["in"], "schema": parameterSchema(param)}
+	if description, ok := param["description"]; ok {
+		out["description"] = description
+	}
+	if param["required"] == true || param["in"] == "path" {
+		out["required"] = true
+	}
+	if param["collectionFormat"] == "csv" {
+		out["style"], out["explode"] = "form", false
+	}
+	return out
+}
+
+// parameterSchema moves a Swagger 2.0 parameter's type keywords into a schema
+func parameterSchema(param object) object {
+	schema := object{}
+	for _, key := range []string{"type", "format", "items", "enum", "default", "minimum", "maximum", "maxLength", "minLength"} {
+		if value, ok := param[key]; ok {
+			schema[key] = value
+		}
+	}
+	if schema["type"] == "file" {
+		schema["type"], schema["format"] = "string", "binary"
+	}
+	return schema
+}
+
+// convertRefs returns value with its Swagger 2.0 definition references pointed at component schemas
+func convertRefs(value interface{}) interface{} {
+	switch value := value.(type) {
+	case object:
+		out := make(object, len(value))
+		for key, item := range value {
+			if ref, ok := item.(string); ok && key == "$ref" {
+				out[key] = schemaPrefix + strings.TrimPrefix(ref, "#/definitions/")
+				continue
+			}
+			out[key] = convertRefs(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = convertRefs(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+func statusCode(code string) int {
+	var status int
+	fmt.Sscanf(code, "%d", &status)
+	return status
+}
+
+func firstString(value interface{}, fallback string) string {
+	if list := asList(value); len(list) > 0 {
+		if first, ok := list[0].(string); ok {
+			return first
+		}
+	}
+	return fallback
+}
+
+func asList(value interface{}) []interface{} {
+	list, _ := value.([]interface{})
+	return list
+}
+
+func asObject(value interface{}) object {
+	obj, _ := value.(object)
+	return obj
+}
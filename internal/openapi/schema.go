@@ -0,0 +1,176 @@
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// maxExampleDepth bounds how many references an example follows, ending recursive schemas
+const maxExampleDepth = 8
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// reflector derives component schemas from Go types the way encoding/json serializes them
+type reflector struct {
+	schemas object
+
+	// reflected are the schemas derived so far, which replace swag's
+	reflected map[string]bool
+}
+
+func newReflector(schemas object) *reflector {
+	return &reflector{schemas: schemas, reflected: make(map[string]bool)}
+}
+
+// ref stores the schema of a named struct type under the name swag gives it, e.g.
+// models.ErrorResponse, and returns a reference to it
+func (r *reflector) ref(t reflect.Type) object {
+	name := path.Base(t.PkgPath()) + "." + t.Name()
+	ref := object{"$ref": schemaPrefix + name}
+	if r.reflected[name] {
+		return ref
+	}
+	// Marked before the fields so recursive types end in a reference
+	r.reflected[name] = true
+	r.schemas[name] = r.structSchema(t)
+	return ref
+}
+
+// schema returns the schema of t
+func (r *reflector) schema(t reflect.Type) object {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return object{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		return r.ref(t)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return object{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return object{"type": "integer", "format": "int64"}
+	case reflect.Float32:
+		return object{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return object{"type": "number"}
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return object{"type": "string", "format": "byte"}
+		}
+		return object{"type": "array", "items": r.schema(t.Elem())}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": r.schema(t.Elem())}
+	case reflect.Struct:
+		return r.structSchema(t)
+	default:
+		// Interfaces hold any JSON value
+		return object{}
+	}
+}
+
+// structSchema returns the schema of a struct's JSON fields; fields without omitempty are required
+func (r *reflector) structSchema(t reflect.Type) object {
+	properties := object{}
+	var required []interface{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = r.schema(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := object{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// example builds an example value of schema, following references into the component schemas
+func (b *builder) example(schema object, depth int) interface{} {
+	if depth > maxExampleDepth {
+		return nil
+	}
+	if example, ok := schema["example"]; ok {
+		return example
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		target, _ := b.schemas[strings.TrimPrefix(ref, schemaPrefix)].(object)
+		if target == nil {
+			return nil
+		}
+		return b.example(target, depth+1)
+	}
+	if all := asList(schema["allOf"]); len(all) > 0 {
+		merged := object{}
+		for _, part := range all {
+			partExample, _ := b.example(asObject(part), depth).(object)
+			for key, value := range partExample {
+				merged[key] = value
+			}
+		}
+		return merged
+	}
+	if enum := asList(schema["enum"]); len(enum) > 0 {
+		return enum[0]
+	}
+	if value, ok := schema["default"]; ok {
+		return value
+	}
+
+	switch schema["type"] {
+	case "object":
+		example := object{}
+		for name, property := range asObject(schema["properties"]) {
+			if value := b.example(asObject(property), depth); value != nil {
+				example[name] = value
+			}
+		}
+		if additional := asObject(schema["additionalProperties"]); additional != nil && len(example) == 0 {
+			if value := b.example(additional, depth); value != nil {
+				example["key"] = value
+			}
+		}
+		return example
+	case "array":
+		if item := b.example(asObject(schema["items"]), depth); item != nil {
+			return []interface{}{item}
+		}
+		return []interface{}{}
+	case "string":
+		switch schema["format"] {
+		case "date-time":
+			return "2024-01-15T09:30:00Z"
+		case "binary":
+			return nil
+		}
+		return "string"
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Package example is a sample plugin. It is compiled into the server only with the
+// example_plugin build tag (go build -tags example_plugin ./cmd/server) and shows each hook:
+// a save request preprocessor, an HTTP middleware and a retrieval filter stage.
+package example
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/plugin"
+	"refo-rag-server/internal/retrieval"
+)
+
+func init() {
+	plugin.Register(examplePlugin{})
+
+	// Enabled with SEARCH_FILTERS=suppressed,example_min_score
+	retrieval.Register(retrieval.KindFilter, "example_min_score", func(retrieval.Deps) (interface{}, error) {
+		threshold, _ := strconv.ParseFloat(os.Getenv("EXAMPLE_MIN_SCORE"), 32)
+		return minScoreFilter{threshold: float32(threshold)}, nil
+	})
+}
+
+// examplePlugin trims message content and tags responses; enabled with PLUGINS=example
+type examplePlugin struct{}
+
+func (examplePlugin) Name() string {
+	return "example"
+}
+
+// Preprocess trims surrounding whitespace from message content
+func (examplePlugin) Preprocess(_ context.Context, req *models.ConversationSaveRequest) error {
+	for i := range req.Messages {
+		req.Messages[i].Content = strings.TrimSpace(req.Messages[i].Content)
+	}
+	return nil
+}
+
+// Middleware marks responses served with the plugin enabled
+func (examplePlugin) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("X-Example-Plugin", "enabled")
+		c.Next()
+	}
+}
+
+// minScoreFilter drops candidates scoring below a threshold
+type minScoreFilter struct {
+	threshold float32
+}
+
+func (f minScoreFilter) Keep(_ *retrieval.Query, candidate retrieval.Candidate) bool {
+	return candidate.Score >= f.threshold
+}
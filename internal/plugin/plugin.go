@@ -0,0 +1,106 @@
+// Package plugin lets deployments extend the server without forking it. Plugins register
+// themselves from an init function in a package compiled in with a build tag and are
+// enabled by name with the PLUGINS setting. Retrieval stages are registered directly with
+// the retrieval package and selected with the SEARCH_* settings.
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"refo-rag-server/internal/models"
+)
+
+// Plugin is a named extension; it provides hooks by also implementing Preprocessor or Middleware
+type Plugin interface {
+	Name() string
+}
+
+// Preprocessor rewrites a validated conversation save request before it is stored and embedded
+type Preprocessor interface {
+	Preprocess(ctx context.Context, req *models.ConversationSaveRequest) error
+}
+
+// Middleware provides an HTTP middleware installed on every route after tenant resolution
+type Middleware interface {
+	Middleware() gin.HandlerFunc
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Plugin{}
+)
+
+// Register makes a plugin available under its name; registering a name twice panics
+func Register(p Plugin) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[p.Name()]; exists {
+		panic(fmt.Sprintf("plugin: %q registered twice", p.Name()))
+	}
+	registry[p.Name()] = p
+}
+
+// Names returns the registered plugin names in sorted order
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return registeredNames()
+}
+
+// registeredNames lists the registered plugin names; the caller holds registryMu
+func registeredNames() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Set is the enabled plugins, in the order they were configured
+type Set []Plugin
+
+// Enable resolves the configured plugin names; a name that isn't compiled in is an error
+func Enable(names []string) (Set, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	set := make(Set, 0, len(names))
+	for _, name := range names {
+		p, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown plugin %q (compiled in: %v)", name, registeredNames())
+		}
+		set = append(set, p)
+	}
+	return set, nil
+}
+
+// Preprocessors returns the enabled plugins' save request preprocessors
+func (s Set) Preprocessors() []Preprocessor {
+	var preprocessors []Preprocessor
+	for _, p := range s {
+		if preprocessor, ok := p.(Preprocessor); ok {
+			preprocessors = append(preprocessors, preprocessor)
+		}
+	}
+	return preprocessors
+}
+
+// Middleware returns the enabled plugins' HTTP middleware
+func (s Set) Middleware() []gin.HandlerFunc {
+	var handlers []gin.HandlerFunc
+	for _, p := range s {
+		if middleware, ok := p.(Middleware); ok {
+			handlers = append(handlers, middleware.Middleware())
+		}
+	}
+	return handlers
+}
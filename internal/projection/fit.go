@@ -0,0 +1,205 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// fitIterations is how many rounds of subspace iteration refine the components; as in randomized
+// PCA, a few rounds capture nearly all the variance the exact components would
+const fitIterations = 5
+
+// Fit trains a PCA matrix reducing samples, all of one dimension, to dimension components. It
+// needs more samples than components. The principal subspace is found by subspace iteration on
+// the sample covariance; reducing 3072 dimensions to 1024 takes a few CPU minutes. Training runs on
+// half the CPUs, so a server fitting a matrix keeps serving
+func Fit(ctx context.Context, samples [][]float32, dimension int) (*Matrix, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no samples to fit a projection to")
+	}
+	n, d := len(samples), len(samples[0])
+	if dimension <= 0 || dimension >= d {
+		return nil, fmt.Errorf("projected dimension must be between 1 and %d, got %d", d-1, dimension)
+	}
+	if n <= dimension {
+		return nil, fmt.Errorf("fitting %d components needs more than %d samples, got %d", dimension, dimension, n)
+	}
+
+	mean := make([]float64, d)
+	for i, sample := range samples {
+		if len(sample) != d {
+			return nil, fmt.Errorf("sample %d has %d dimensions, expected %d", i, len(sample), d)
+		}
+		for j, value := range sample {
+			mean[j] += float64(value)
+		}
+	}
+	for j := range mean {
+		mean[j] /= float64(n)
+	}
+
+	// columns holds the centered samples by dimension, so each covariance entry is a dot product
+	// of two contiguous slices
+	columns := make([][]float32, d)
+	for j := range columns {
+		columns[j] = make([]float32, n)
+		for i, sample := range samples {
+			columns[j][i] = float32(float64(sample[j]) - mean[j])
+		}
+	}
+
+	covariance := make([][]float64, d)
+	for i := range covariance {
+		covariance[i] = make([]float64, d)
+	}
+	parallelFor(d, func(i int) {
+		for j := i; j < d; j++ {
+			var sum float64
+			for s, value := range columns[i] {
+				sum += float64(value) * float64(columns[j][s])
+			}
+			covariance[i][j] = sum / float64(n-1)
+			covariance[j][i] = covariance[i][j]
+		}
+	})
+	columns = nil
+
+	var total float64
+	for i := range covariance {
+		total += covariance[i][i]
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("samples have no variance")
+	}
+
+	basis := make([][]float64, dimension)
+	for k := range basis {
+		basis[k] = make([]float64, d)
+		for j := range basis[k] {
+			basis[k][j] = rand.NormFloat64()
+		}
+	}
+	if err := orthonormalize(basis); err != nil {
+		return nil, err
+	}
+	for iteration := 0; iteration < fitIterations; iteration++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		basis = multiply(covariance, basis)
+		if err := orthonormalize(basis); err != nil {
+			return nil, err
+		}
+	}
+
+	// Order the components by the variance they capture
+	variances := make([]float64, dimension)
+	product := multiply(covariance, basis)
+	for k := range basis {
+		variances[k] = dot(basis[k], product[k])
+	}
+	order := make([]int, dimension)
+	for k := range order {
+		order[k] = k
+	}
+	sort.Slice(order, func(a, b int) bool { return variances[order[a]] > variances[order[b]] })
+
+	matrix := &Matrix{
+		InputDimension:  d,
+		OutputDimension: dimension,
+		Mean:            make([]float32, d),
+		Components:      make([][]float32, dimension),
+		Samples:         n,
+		TrainedAt:       time.Now().UTC(),
+	}
+	for j, value := range mean {
+		matrix.Mean[j] = float32(value)
+	}
+	var captured float64
+	for k, index := range order {
+		matrix.Components[k] = make([]float32, d)
+		for j, value := range basis[index] {
+			matrix.Components[k][j] = float32(value)
+		}
+		captured += variances[index]
+	}
+	matrix.ExplainedVariance = captured / total
+	return matrix, nil
+}
+
+// multiply returns the product of a symmetric matrix with each vector
+func multiply(symmetric [][]float64, vectors [][]float64) [][]float64 {
+	products := make([][]float64, len(vectors))
+	parallelFor(len(vectors), func(k int) {
+		products[k] = make([]float64, len(symmetric))
+		for i, row := range symmetric {
+			products[k][i] = dot(row, vectors[k])
+		}
+	})
+	return products
+}
+
+// orthonormalize makes vectors an orthonormal basis of their span in place, by Gram-Schmidt with
+// each vector projected out twice to keep the basis orthogonal to working precision
+func orthonormalize(vectors [][]float64) error {
+	const chunk = 256
+	d := len(vectors[0])
+	coefficients := make([]float64, len(vectors))
+	for k, vector := range vectors {
+		before := math.Sqrt(dot(vector, vector))
+		for pass := 0; pass < 2; pass++ {
+			parallelFor(k, func(i int) {
+				coefficients[i] = dot(vectors[i], vector)
+			})
+			parallelFor((d+chunk-1)/chunk, func(c int) {
+				lo, hi := c*chunk, min((c+1)*chunk, d)
+				for i := 0; i < k; i++ {
+					for j := lo; j < hi; j++ {
+						vector[j] -= coefficients[i] * vectors[i][j]
+					}
+				}
+			})
+		}
+		norm := math.Sqrt(dot(vector, vector))
+		if norm <= 1e-10*before {
+			return fmt.Errorf("samples span fewer than %d dimensions", len(vectors))
+		}
+		for j := range vector {
+			vector[j] /= norm
+		}
+	}
+	return nil
+}
+
+// dot returns the dot product of two vectors of equal length
+func dot(a []float64, b []float64) float64 {
+	var sum float64
+	for i, value := range a {
+		sum += value * b[i]
+	}
+	return sum
+}
+
+// parallelFor calls fn for 0 to n-1 on half the CPUs
+func parallelFor(n int, fn func(i int)) {
+	workers := min(max(runtime.GOMAXPROCS(0)/2, 1), n)
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1) - 1); i < n; i = int(next.Add(1) - 1) {
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,247 @@
+// Package projection reduces the dimensionality of embeddings before they are stored or searched.
+// Matryoshka-trained models such as text-embedding-3 keep most of their quality when a vector is
+// truncated to its leading components; other models need a PCA matrix trained on their own
+// vectors. Either way every projected vector is scaled back to unit length, and the same
+// projection must apply to stored vectors and queries, so changing it means reindexing
+package projection
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Projection methods
+const (
+	MethodTruncate = "truncate"
+	MethodPCA      = "pca"
+)
+
+// Spec configures the projection of one model's embeddings
+type Spec struct {
+	Method string
+
+	// Dimension is the truncated dimension; PCA takes it from the matrix
+	Dimension int
+
+	// Path is the file of a PCA matrix
+	Path string
+}
+
+// ParseSpecs parses "model=truncate:dimension" and "model=pca:path" entries keyed by model
+func ParseSpecs(entries []string) (map[string]Spec, error) {
+	specs := make(map[string]Spec, len(entries))
+	for _, entry := range entries {
+		model, params, ok := strings.Cut(entry, "=")
+		method, arg, ok2 := strings.Cut(params, ":")
+		model, arg = strings.TrimSpace(model), strings.TrimSpace(arg)
+		if !ok || !ok2 || model == "" || arg == "" {
+			return nil, fmt.Errorf("invalid embedding projection %q, expected model=truncate:dimension or model=pca:path", entry)
+		}
+		switch strings.TrimSpace(method) {
+		case MethodTruncate:
+			dimension, err := strconv.Atoi(arg)
+			if err != nil || dimension <= 0 {
+				return nil, fmt.Errorf("invalid truncated dimension in embedding projection %q", entry)
+			}
+			specs[model] = Spec{Method: MethodTruncate, Dimension: dimension}
+		case MethodPCA:
+			specs[model] = Spec{Method: MethodPCA, Path: arg}
+		default:
+			return nil, fmt.Errorf("unknown method in embedding projection %q, expected truncate or pca", entry)
+		}
+	}
+	return specs, nil
+}
+
+// Matrix is a trained PCA projection: a vector is centered on Mean and multiplied by Components
+type Matrix struct {
+	Model           string `json:"model"`
+	InputDimension  int    `json:"input_dimension"`
+	OutputDimension int    `json:"output_dimension"`
+
+	Mean []float32 `json:"mean"`
+
+	// Components holds OutputDimension orthonormal rows of InputDimension, by decreasing variance
+	Components [][]float32 `json:"components"`
+
+	// ExplainedVariance is the share of the training vectors' variance the components keep
+	ExplainedVariance float64   `json:"explained_variance"`
+	Samples           int       `json:"samples"`
+	TrainedAt         time.Time `json:"trained_at"`
+}
+
+// validate checks that the matrix's shape matches its declared dimensions
+func (m *Matrix) validate() error {
+	if m.InputDimension <= 0 || m.OutputDimension <= 0 || m.OutputDimension > m.InputDimension {
+		return fmt.Errorf("invalid dimensions %d to %d", m.InputDimension, m.OutputDimension)
+	}
+	if len(m.Mean) != m.InputDimension || len(m.Components) != m.OutputDimension {
+		return fmt.Errorf("mean or components don't match dimensions %d to %d", m.InputDimension, m.OutputDimension)
+	}
+	for i, row := range m.Components {
+		if len(row) != m.InputDimension {
+			return fmt.Errorf("component %d has %d dimensions, expected %d", i, len(row), m.InputDimension)
+		}
+	}
+	return nil
+}
+
+// LoadMatrix reads a PCA matrix written by Save
+func LoadMatrix(path string) (*Matrix, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read projection matrix: %w", err)
+	}
+	matrix := &Matrix{}
+	if err := json.Unmarshal(data, matrix); err != nil {
+		return nil, fmt.Errorf("failed to decode projection matrix %s: %w", path, err)
+	}
+	if err := matrix.validate(); err != nil {
+		return nil, fmt.Errorf("projection matrix %s: %w", path, err)
+	}
+	return matrix, nil
+}
+
+// MatrixPath returns the file under dir of a matrix of model trained at trainedAt
+func MatrixPath(dir string, model string, trainedAt time.Time) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(model)
+	return filepath.Join(dir, name+"-"+trainedAt.UTC().Format("20060102T150405Z")+".json")
+}
+
+// Save writes the matrix to path, replacing any file there only once it is completely written
+func (m *Matrix) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create projection directory: %w", err)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode projection matrix: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0640); err != nil {
+		return fmt.Errorf("failed to write projection matrix: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write projection matrix: %w", err)
+	}
+	return nil
+}
+
+// Projector applies a model's projection. A PCA matrix can be reloaded from its file while
+// serving; the reloaded matrix must keep the dimensions of the one it replaces
+type Projector struct {
+	spec Spec
+
+	reloading sync.Mutex
+	matrix    atomic.Pointer[Matrix]
+	loadedAt  atomic.Pointer[time.Time]
+}
+
+// New creates the projector of a spec, loading its PCA matrix
+func New(spec Spec) (*Projector, error) {
+	p := &Projector{spec: spec}
+	if spec.Method == MethodPCA {
+		matrix, err := LoadMatrix(spec.Path)
+		if err != nil {
+			return nil, err
+		}
+		p.store(matrix)
+	}
+	return p, nil
+}
+
+// store makes matrix the one applied
+func (p *Projector) store(matrix *Matrix) {
+	now := time.Now().UTC()
+	p.matrix.Store(matrix)
+	p.loadedAt.Store(&now)
+}
+
+// Spec returns the projector's configuration
+func (p *Projector) Spec() Spec {
+	return p.spec
+}
+
+// Matrix returns the PCA matrix applied, or nil for truncation
+func (p *Projector) Matrix() *Matrix {
+	return p.matrix.Load()
+}
+
+// LoadedAt returns when the PCA matrix was last loaded, or nil for truncation
+func (p *Projector) LoadedAt() *time.Time {
+	return p.loadedAt.Load()
+}
+
+// OutputDimension returns the dimension of projected vectors
+func (p *Projector) OutputDimension() int {
+	if matrix := p.matrix.Load(); matrix != nil {
+		return matrix.OutputDimension
+	}
+	return p.spec.Dimension
+}
+
+// Reload reads the PCA matrix from its file again, keeping the current one if that fails. It
+// does nothing for truncation
+func (p *Projector) Reload() error {
+	if p.spec.Method != MethodPCA {
+		return nil
+	}
+	p.reloading.Lock()
+	defer p.reloading.Unlock()
+
+	matrix, err := LoadMatrix(p.spec.Path)
+	if err != nil {
+		return err
+	}
+	current := p.matrix.Load()
+	if matrix.InputDimension != current.InputDimension || matrix.OutputDimension != current.OutputDimension {
+		return fmt.Errorf("projection matrix %s maps %d to %d dimensions, the loaded one %d to %d",
+			p.spec.Path, matrix.InputDimension, matrix.OutputDimension, current.InputDimension, current.OutputDimension)
+	}
+	p.store(matrix)
+	return nil
+}
+
+// Apply returns the projection of vector, scaled to unit length
+func (p *Projector) Apply(vector []float32) ([]float32, error) {
+	var projected []float32
+	if matrix := p.matrix.Load(); matrix != nil {
+		if len(vector) != matrix.InputDimension {
+			return nil, fmt.Errorf("cannot project a %d-dimension vector with a %d-dimension PCA matrix", len(vector), matrix.InputDimension)
+		}
+		projected = make([]float32, matrix.OutputDimension)
+		for i, component := range matrix.Components {
+			var sum float64
+			for j, value := range vector {
+				sum += float64(component[j]) * float64(value-matrix.Mean[j])
+			}
+			projected[i] = float32(sum)
+		}
+	} else {
+		if len(vector) < p.spec.Dimension {
+			return nil, fmt.Errorf("cannot truncate a %d-dimension vector to %d dimensions", len(vector), p.spec.Dimension)
+		}
+		projected = append([]float32(nil), vector[:p.spec.Dimension]...)
+	}
+
+	var norm float64
+	for _, value := range projected {
+		norm += float64(value) * float64(value)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range projected {
+			projected[i] *= scale
+		}
+	}
+	return projected, nil
+}
@@ -0,0 +1,111 @@
+// Package queryroute decides which memory collections a retrieval query targets. A query such as
+// "what is her phone number" is about stored personal information, while "what did we talk about
+// yesterday" is about past conversations; searching only the relevant collections cuts noise and
+// latency. The classifier is lexical and runs in microseconds, so it adds nothing to a request
+package queryroute
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Targets a query can be routed to
+const (
+	TargetConversations = "conversations"
+	TargetPersonalInfo  = "personal_info"
+)
+
+// Targets lists every target in a fixed order
+var Targets = []string{TargetConversations, TargetPersonalInfo}
+
+// cues are phrases that suggest a target; matching is case-insensitive on word boundaries
+var cues = map[string][]string{
+	TargetPersonalInfo: {
+		"phone", "phone number", "address", "email", "birthday", "born", "age", "name",
+		"allergy", "allergic", "allergies", "medication", "medicine", "diagnosis", "condition",
+		"blood type", "doctor", "hospital", "emergency", "contact", "guardian", "insurance",
+		"likes", "dislikes", "favorite", "prefers", "preference",
+		"전화", "전화번호", "연락처", "주소", "이메일", "생일", "나이", "이름",
+		"알레르기", "처방", "복용", "진단", "질환", "혈액형", "병원", "응급", "보호자", "보험",
+		"좋아하는", "싫어하는", "선호",
+	},
+	TargetConversations: {
+		"we talked", "we discussed", "talk about", "talked about", "discussed", "you said", "i said", "you told",
+		"i told", "last time", "earlier", "yesterday", "last week", "previously", "before",
+		"remember when", "conversation", "mentioned", "asked about",
+		"대화", "얘기", "이야기", "말했", "물어봤", "지난번", "저번", "어제", "지난주", "전에", "아까",
+	},
+}
+
+// Classifier weighs the targets of a query by the cues it contains
+type Classifier struct {
+	// minWeight is the weight below which a target is skipped
+	minWeight float64
+}
+
+// NewClassifier creates a classifier skipping targets weighted below minWeight (0 to 1)
+func NewClassifier(minWeight float64) *Classifier {
+	return &Classifier{minWeight: minWeight}
+}
+
+// Route is the outcome of classifying a query
+type Route struct {
+	// Weights holds every target's weight; the most likely target weighs 1
+	Weights map[string]float64
+
+	// Selected lists the targets to search, in Targets order
+	Selected []string
+}
+
+// Classify weighs each target by its cue matches with add-one smoothing and scales the weights so
+// the largest is 1. A query without cues weighs all targets equally, so all are searched
+func (c *Classifier) Classify(query string) Route {
+	normalized := " " + normalize(query) + " "
+
+	hits := make(map[string]int, len(Targets))
+	best := 0.0
+	raw := make(map[string]float64, len(Targets))
+	for _, target := range Targets {
+		for _, cue := range cues[target] {
+			if matches(normalized, cue) {
+				hits[target]++
+			}
+		}
+		raw[target] = float64(1 + hits[target])
+		best = max(best, raw[target])
+	}
+
+	route := Route{Weights: make(map[string]float64, len(Targets))}
+	for _, target := range Targets {
+		weight := raw[target] / best
+		route.Weights[target] = weight
+		if weight >= c.minWeight {
+			route.Selected = append(route.Selected, target)
+		}
+	}
+	return route
+}
+
+// matches reports whether a cue occurs in normalized text. Latin cues must match whole words;
+// Hangul cues must start a word but may be followed by particles, as in "전화번호는"
+func matches(normalized string, cue string) bool {
+	if isHangul(cue) {
+		return strings.Contains(normalized, " "+cue)
+	}
+	return strings.Contains(normalized, " "+cue+" ")
+}
+
+// normalize lowercases text and replaces punctuation with spaces
+func normalize(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	}), " ")
+}
+
+// isHangul reports whether text starts with a Hangul letter
+func isHangul(text string) bool {
+	for _, r := range text {
+		return unicode.Is(unicode.Hangul, r)
+	}
+	return false
+}
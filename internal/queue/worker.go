@@ -0,0 +1,256 @@
+// Package queue processes the Postgres work queue shared by all replicas. Each item is leased
+// to one worker at a time; the worker extends the lease while it runs, and an item whose worker
+// died becomes available again when its lease expires.
+package queue
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// Handler processes one queue item; an error releases the item for a later retry until it
+// runs out of attempts and is dead-lettered
+type Handler func(ctx context.Context, item *models.QueueItem) error
+
+// Options tunes a worker
+type Options struct {
+	// Owner identifies the worker in leases; it must be unique across replicas
+	Owner string
+
+	// Lease is how long a claimed item stays reserved without a heartbeat
+	Lease time.Duration
+
+	// PollInterval is the wait between claims when the queue is empty
+	PollInterval time.Duration
+
+	// BatchSize is the number of items claimed at once
+	BatchSize int
+
+	// MaxBackoff caps the exponential delay before a failed item is retried
+	MaxBackoff time.Duration
+
+	// MaxAttempts is the number of attempts after which a failing item is dead-lettered
+	MaxAttempts int
+
+	// Deferred are kinds claimed only to fill a batch the other kinds left short, so their items
+	// wait while other work is due
+	Deferred []string
+}
+
+// Worker claims and processes queue items
+type Worker struct {
+	store       storage.QueueStore
+	handlers    map[string]Handler
+	deadLetters map[string]Handler
+	opts        Options
+}
+
+// NewWorker creates a worker; register handlers with Handle before calling Run
+func NewWorker(store storage.QueueStore, opts Options) *Worker {
+	if opts.Lease <= 0 {
+		opts.Lease = time.Minute
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 2 * time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 10
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Hour
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	return &Worker{
+		store:       store,
+		handlers:    make(map[string]Handler),
+		deadLetters: make(map[string]Handler),
+		opts:        opts,
+	}
+}
+
+// Handle registers the handler for an item kind
+func (w *Worker) Handle(kind string, handler Handler) {
+	w.handlers[kind] = handler
+}
+
+// OnDeadLetter registers a handler run once an item of a kind is dead-lettered, to record that
+// its work won't happen; its error is only logged
+func (w *Worker) OnDeadLetter(kind string, handler Handler) {
+	w.deadLetters[kind] = handler
+}
+
+// Run processes items until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	var kinds, deferred []string
+	for kind := range w.handlers {
+		if slices.Contains(w.opts.Deferred, kind) {
+			deferred = append(deferred, kind)
+		} else {
+			kinds = append(kinds, kind)
+		}
+	}
+
+	for {
+		items := w.claim(ctx, kinds, w.opts.BatchSize)
+		if len(items) < w.opts.BatchSize {
+			items = append(items, w.claim(ctx, deferred, w.opts.BatchSize-len(items))...)
+		}
+
+		for _, item := range items {
+			w.process(ctx, item)
+		}
+
+		if len(items) == w.opts.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.opts.PollInterval):
+		}
+	}
+}
+
+// claim leases up to limit due items of kinds, logging a failure as an empty claim
+func (w *Worker) claim(ctx context.Context, kinds []string, limit int) []*models.QueueItem {
+	if len(kinds) == 0 {
+		return nil
+	}
+	items, err := w.store.ClaimQueueItems(ctx, w.opts.Owner, kinds, limit, w.opts.Lease)
+	if err != nil && ctx.Err() == nil {
+		fmt.Printf("warning: failed to claim queue items: %v\n", err)
+		errreport.Background(ctx, "queue_claim", err)
+	}
+	return items
+}
+
+// process runs an item's handler while heartbeating its lease, then completes or releases it
+func (w *Worker) process(ctx context.Context, item *models.QueueItem) {
+	itemCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go w.heartbeat(itemCtx, cancel, item)
+
+	err := w.handlers[item.Kind](itemCtx, item)
+
+	// Record the outcome even when shutting down
+	ctx = context.WithoutCancel(ctx)
+	if err == nil {
+		metrics.QueueProcessed.WithLabelValues(item.Kind, "succeeded").Inc()
+		if err := w.store.CompleteQueueItem(ctx, item.ID, w.opts.Owner); err != nil {
+			fmt.Printf("warning: failed to complete queue item %d: %v\n", item.ID, err)
+			errreport.Background(ctx, "queue_complete", err)
+		}
+		return
+	}
+
+	fmt.Printf("warning: %s queue item %d failed (attempt %d): %v\n", item.Kind, item.ID, item.Attempts, err)
+	if item.Attempts >= w.opts.MaxAttempts {
+		metrics.QueueProcessed.WithLabelValues(item.Kind, "dead_lettered").Inc()
+		if err := w.store.DeadLetterQueueItem(ctx, item.ID, w.opts.Owner, err.Error()); err != nil {
+			fmt.Printf("warning: failed to dead-letter queue item %d: %v\n", item.ID, err)
+			errreport.Background(ctx, "queue_dead_letter", err)
+			return
+		}
+		if handler, ok := w.deadLetters[item.Kind]; ok {
+			if err := handler(ctx, item); err != nil {
+				fmt.Printf("warning: failed to handle dead-lettered queue item %d: %v\n", item.ID, err)
+			}
+		}
+		return
+	}
+
+	metrics.QueueProcessed.WithLabelValues(item.Kind, "failed").Inc()
+	retryAt := time.Now().Add(backoff(item.Attempts, w.opts.MaxBackoff))
+	if err := w.store.RetryQueueItem(ctx, item.ID, w.opts.Owner, err.Error(), retryAt); err != nil {
+		fmt.Printf("warning: failed to release queue item %d: %v\n", item.ID, err)
+		errreport.Background(ctx, "queue_release", err)
+	}
+}
+
+// heartbeat extends an item's lease until ctx ends, cancelling the item if the lease was lost
+func (w *Worker) heartbeat(ctx context.Context, cancel context.CancelFunc, item *models.QueueItem) {
+	ticker := time.NewTicker(w.opts.Lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			held, err := w.store.ExtendQueueLease(ctx, item.ID, w.opts.Owner, w.opts.Lease)
+			if err != nil {
+				// Keep working; the next heartbeat may succeed before the lease runs out
+				fmt.Printf("warning: failed to extend lease of queue item %d: %v\n", item.ID, err)
+				continue
+			}
+			if !held {
+				fmt.Printf("warning: lost lease of queue item %d\n", item.ID)
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// backoff is the delay before retrying an item after its nth attempt
+func backoff(attempts int, max time.Duration) time.Duration {
+	delay := time.Duration(math.Pow(2, float64(attempts))) * time.Second
+	if delay <= 0 || delay > max {
+		return max
+	}
+	return delay
+}
+
+// ReportMetrics publishes queue depth and consumer lag, and the outbox and dead letter SLIs, every
+// interval until ctx is cancelled
+func ReportMetrics(ctx context.Context, store storage.QueueStore, deadLetters storage.DeadLetterStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		stats, err := store.QueueStats(ctx)
+		if err == nil {
+			metrics.QueueDepth.Reset()
+			metrics.QueueLag.Reset()
+			var lag, backlogAge float64
+			for _, s := range stats {
+				metrics.QueueDepth.WithLabelValues(s.Kind).Set(float64(s.Depth))
+				metrics.QueueLag.WithLabelValues(s.Kind).Set(s.LagSeconds)
+				lag = max(lag, s.LagSeconds)
+				if s.Kind == models.QueueKindConversationVector || s.Kind == models.QueueKindConversationVectorBackfill {
+					backlogAge = max(backlogAge, s.OldestSeconds)
+				}
+			}
+			metrics.SLIOutboxLag.Set(lag)
+			metrics.SLIVectorBacklogAge.Set(backlogAge)
+		} else if ctx.Err() == nil {
+			fmt.Printf("warning: failed to get queue stats: %v\n", err)
+		}
+
+		counts, err := deadLetters.CountDeadLetters(ctx)
+		if err == nil {
+			metrics.SLIDeadLetters.Reset()
+			for kind, count := range counts {
+				metrics.SLIDeadLetters.WithLabelValues(kind).Set(float64(count))
+			}
+		} else if ctx.Err() == nil {
+			fmt.Printf("warning: failed to count dead letters: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
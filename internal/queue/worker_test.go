@@ -0,0 +1,286 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+const testKind = "test_job"
+
+// testPayload is the payload of the items the tests enqueue
+type testPayload struct {
+	Job string `json:"job"`
+}
+
+func openStore(t *testing.T) *storage.SQLiteStore {
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "rag.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := storage.MigrateSQLite(store.GetDB()); err != nil {
+		t.Fatalf("migrate sqlite: %v", err)
+	}
+	return store
+}
+
+// drain claims and processes items until none of testKind is left in the queue, waiting out
+// retry delays
+func drain(t *testing.T, w *Worker, store *storage.SQLiteStore) {
+	t.Helper()
+	ctx := context.Background()
+	deadline := time.Now().Add(5 * time.Second)
+	for queueDepth(t, store) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("queue was not drained within 5s")
+		}
+		items := w.claim(ctx, []string{testKind}, w.opts.BatchSize)
+		for _, item := range items {
+			w.process(ctx, item)
+		}
+		if len(items) == 0 {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+}
+
+// queueDepth counts the items of testKind waiting or leased
+func queueDepth(t *testing.T, store *storage.SQLiteStore) int64 {
+	t.Helper()
+	stats, err := store.QueueStats(context.Background())
+	if err != nil {
+		t.Fatalf("QueueStats: %v", err)
+	}
+	for _, s := range stats {
+		if s.Kind == testKind {
+			return s.Depth
+		}
+	}
+	return 0
+}
+
+// deadLetters lists the dead letters of testKind
+func deadLetters(t *testing.T, store *storage.SQLiteStore) []*models.DeadLetter {
+	t.Helper()
+	letters, _, err := store.ListDeadLetters(context.Background(), testKind, 10, 0)
+	if err != nil {
+		t.Fatalf("ListDeadLetters: %v", err)
+	}
+	return letters
+}
+
+func TestLeaseExpiryReclaim(t *testing.T) {
+	ctx := context.Background()
+	store := openStore(t)
+	if err := store.Enqueue(ctx, testKind, testPayload{Job: "reclaim"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	// A worker claims the item with a short lease and dies
+	dead, err := store.ClaimQueueItems(ctx, "worker-dead", []string{testKind}, 10, 50*time.Millisecond)
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("ClaimQueueItems = %d, %v; want the item", len(dead), err)
+	}
+
+	w := NewWorker(store, Options{Owner: "worker-live", Lease: time.Minute})
+	var handled []*models.QueueItem
+	w.Handle(testKind, func(ctx context.Context, item *models.QueueItem) error {
+		handled = append(handled, item)
+		return nil
+	})
+
+	if items := w.claim(ctx, []string{testKind}, 10); len(items) != 0 {
+		t.Fatalf("claimed %d items still leased to another worker", len(items))
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	items := w.claim(ctx, []string{testKind}, 10)
+	if len(items) != 1 {
+		t.Fatalf("claimed %d items after the lease expired, want 1", len(items))
+	}
+	if items[0].Attempts != 2 {
+		t.Errorf("reclaimed item has %d attempts, want 2", items[0].Attempts)
+	}
+	w.process(ctx, items[0])
+
+	if len(handled) != 1 {
+		t.Fatalf("handler ran %d times, want 1", len(handled))
+	}
+	var payload testPayload
+	if err := json.Unmarshal(handled[0].Payload, &payload); err != nil || payload.Job != "reclaim" {
+		t.Errorf("handler got payload %s, want the enqueued one", handled[0].Payload)
+	}
+	if depth := queueDepth(t, store); depth != 0 {
+		t.Errorf("%d items left after the reclaimed item completed", depth)
+	}
+
+	// The dead worker can no longer touch the item
+	if held, err := store.ExtendQueueLease(ctx, dead[0].ID, "worker-dead", time.Minute); err != nil || held {
+		t.Errorf("ExtendQueueLease by the expired owner = %v, %v; want false", held, err)
+	}
+}
+
+func TestLostLeaseCancelsHandler(t *testing.T) {
+	ctx := context.Background()
+	store := openStore(t)
+	if err := store.Enqueue(ctx, testKind, testPayload{Job: "lost"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	w := NewWorker(store, Options{Owner: "worker-1", Lease: 30 * time.Millisecond})
+	w.Handle(testKind, func(ctx context.Context, item *models.QueueItem) error {
+		// The lease is lost while the handler runs
+		if err := store.DeleteQueueItem(context.Background(), item.ID); err != nil {
+			t.Errorf("DeleteQueueItem: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return errors.New("handler was not cancelled")
+		}
+	})
+
+	items := w.claim(ctx, []string{testKind}, 10)
+	if len(items) != 1 {
+		t.Fatalf("claimed %d items, want 1", len(items))
+	}
+	start := time.Now()
+	w.process(ctx, items[0])
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("handler ran %v after its lease was lost", elapsed)
+	}
+	if letters := deadLetters(t, store); len(letters) != 0 {
+		t.Errorf("an item whose lease was lost was dead-lettered: %+v", letters)
+	}
+}
+
+func TestMaxAttempts(t *testing.T) {
+	cases := []struct {
+		name        string
+		maxAttempts int
+		failures    int // attempts that fail before the handler succeeds
+		wantCalls   int
+		wantDead    bool
+	}{
+		{name: "succeeds first time", maxAttempts: 3, failures: 0, wantCalls: 1},
+		{name: "succeeds after retries", maxAttempts: 3, failures: 2, wantCalls: 3},
+		{name: "exhausts attempts", maxAttempts: 3, failures: 10, wantCalls: 3, wantDead: true},
+		{name: "single attempt", maxAttempts: 1, failures: 10, wantCalls: 1, wantDead: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := openStore(t)
+			if err := store.Enqueue(ctx, testKind, testPayload{Job: tc.name}); err != nil {
+				t.Fatalf("Enqueue: %v", err)
+			}
+
+			// A tiny backoff makes a failed item due again at once
+			w := NewWorker(store, Options{Owner: "worker-1", MaxAttempts: tc.maxAttempts, MaxBackoff: time.Millisecond})
+			calls := 0
+			w.Handle(testKind, func(ctx context.Context, item *models.QueueItem) error {
+				calls++
+				if item.Attempts != calls {
+					t.Errorf("call %d saw %d attempts", calls, item.Attempts)
+				}
+				if calls <= tc.failures {
+					return errors.New("embedding provider unavailable")
+				}
+				return nil
+			})
+			deadLettered := 0
+			w.OnDeadLetter(testKind, func(ctx context.Context, item *models.QueueItem) error {
+				deadLettered++
+				return nil
+			})
+
+			drain(t, w, store)
+
+			if calls != tc.wantCalls {
+				t.Errorf("handler ran %d times, want %d", calls, tc.wantCalls)
+			}
+			letters := deadLetters(t, store)
+			if !tc.wantDead {
+				if len(letters) != 0 || deadLettered != 0 {
+					t.Errorf("got %d dead letters and %d dead letter callbacks, want none", len(letters), deadLettered)
+				}
+				return
+			}
+			if len(letters) != 1 {
+				t.Fatalf("got %d dead letters, want 1", len(letters))
+			}
+			if letters[0].Attempts != tc.maxAttempts || letters[0].LastError != "embedding provider unavailable" {
+				t.Errorf("dead letter = %d attempts, error %q; want %d attempts and the handler's error",
+					letters[0].Attempts, letters[0].LastError, tc.maxAttempts)
+			}
+			if deadLettered != 1 {
+				t.Errorf("dead letter callback ran %d times, want 1", deadLettered)
+			}
+		})
+	}
+}
+
+func TestDeadLetterRequeue(t *testing.T) {
+	ctx := context.Background()
+	store := openStore(t)
+	if err := store.Enqueue(ctx, testKind, testPayload{Job: "requeue"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	w := NewWorker(store, Options{Owner: "worker-1", MaxAttempts: 2, MaxBackoff: time.Millisecond})
+	healthy := false
+	calls := 0
+	w.Handle(testKind, func(ctx context.Context, item *models.QueueItem) error {
+		calls++
+		if !healthy {
+			return errors.New("embedding provider unavailable")
+		}
+		return nil
+	})
+
+	drain(t, w, store)
+	letters := deadLetters(t, store)
+	if len(letters) != 1 {
+		t.Fatalf("got %d dead letters, want 1", len(letters))
+	}
+
+	// Once the outage is over, an operator requeues the dead letter with fresh attempts
+	healthy = true
+	if requeued, err := store.RequeueDeadLetter(ctx, letters[0].ID); err != nil || !requeued {
+		t.Fatalf("RequeueDeadLetter = %v, %v", requeued, err)
+	}
+	if letters := deadLetters(t, store); len(letters) != 0 {
+		t.Fatalf("%d dead letters left after the requeue", len(letters))
+	}
+
+	items := w.claim(ctx, []string{testKind}, 10)
+	if len(items) != 1 {
+		t.Fatalf("claimed %d items after the requeue, want 1", len(items))
+	}
+	if items[0].Attempts != 1 {
+		t.Errorf("requeued item has %d attempts, want 1", items[0].Attempts)
+	}
+	var payload testPayload
+	if err := json.Unmarshal(items[0].Payload, &payload); err != nil || payload.Job != "requeue" {
+		t.Errorf("requeued item has payload %s, want the original", items[0].Payload)
+	}
+	w.process(ctx, items[0])
+
+	if calls != 3 {
+		t.Errorf("handler ran %d times, want 2 failures and 1 success", calls)
+	}
+	if depth := queueDepth(t, store); depth != 0 {
+		t.Errorf("%d items left after the requeued item completed", depth)
+	}
+	if letters := deadLetters(t, store); len(letters) != 0 {
+		t.Errorf("requeued item was dead-lettered again: %+v", letters)
+	}
+}
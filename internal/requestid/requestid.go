@@ -0,0 +1,46 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Header is the HTTP header carrying the request ID
+const Header = "X-Request-ID"
+
+// maxLength bounds client-supplied request IDs so they stay log-friendly
+const maxLength = 128
+
+type contextKey struct{}
+
+// New generates a request ID
+func New() string {
+	return uuid.NewString()
+}
+
+// Normalize returns a client-supplied request ID if it is usable, or a new one otherwise
+func Normalize(id string) string {
+	id = strings.TrimSpace(id)
+	if id == "" || len(id) > maxLength || strings.ContainsAny(id, " \t\r\n") {
+		return New()
+	}
+	return id
+}
+
+// WithRequestID returns a context carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored in the context, or an empty string
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if id, ok := ctx.Value(contextKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
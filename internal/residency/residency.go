@@ -0,0 +1,168 @@
+// Package residency pins tenants and users to a home region. Each region runs its own deployment
+// against its own Postgres and Qdrant endpoints, listed in a region map; a deployment serves only
+// the data homed in its region and points clients elsewhere for the rest
+package residency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/tenant"
+)
+
+// ErrWrongRegion is matched by every *WrongRegionError
+var ErrWrongRegion = errors.New("data is homed in another region")
+
+// WrongRegionError reports a request for data homed in another region
+type WrongRegionError struct {
+	// Region is the home region of the data
+	Region string
+
+	// URL is the base URL of the home region's deployment, if the region map lists one
+	URL string
+
+	// Local is the region of the deployment that refused the request
+	Local string
+}
+
+func (e *WrongRegionError) Error() string {
+	return fmt.Sprintf("data is homed in region %q", e.Region)
+}
+
+func (e *WrongRegionError) Is(target error) bool {
+	return target == ErrWrongRegion
+}
+
+// Region holds the storage endpoints of a region; zero fields keep the configured defaults
+type Region struct {
+	PostgresHost string `json:"postgres_host,omitempty"`
+	PostgresPort int    `json:"postgres_port,omitempty"`
+	PostgresDB   string `json:"postgres_db,omitempty"`
+	QdrantHost   string `json:"qdrant_host,omitempty"`
+	QdrantPort   int    `json:"qdrant_port,omitempty"`
+
+	// URL is the region's public base URL, returned to clients sent there
+	URL string `json:"url,omitempty"`
+}
+
+// Map is the on-disk JSON layout of the region map
+type Map struct {
+	Regions map[string]Region `json:"regions"`
+
+	// Tenants maps tenants to their home region; unlisted tenants live in DefaultRegion
+	Tenants map[string]string `json:"tenants,omitempty"`
+
+	DefaultRegion string `json:"default_region,omitempty"`
+}
+
+// Load reads and validates a region map file
+func Load(path string) (*Map, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read region map: %w", err)
+	}
+
+	var m Map
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse region map: %w", err)
+	}
+	if len(m.Regions) == 0 {
+		return nil, fmt.Errorf("region map lists no regions")
+	}
+	if m.DefaultRegion != "" && !m.Has(m.DefaultRegion) {
+		return nil, fmt.Errorf("region map default_region %q is not a listed region", m.DefaultRegion)
+	}
+	for tenantID, region := range m.Tenants {
+		if !m.Has(region) {
+			return nil, fmt.Errorf("region map puts tenant %q in unlisted region %q", tenantID, region)
+		}
+	}
+	return &m, nil
+}
+
+// Has reports whether the map lists a region
+func (m *Map) Has(region string) bool {
+	_, ok := m.Regions[region]
+	return ok
+}
+
+// Names returns the listed regions in sorted order
+func (m *Map) Names() []string {
+	names := make([]string, 0, len(m.Regions))
+	for name := range m.Regions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TenantRegion returns the home region of a tenant, or "" if it has none
+func (m *Map) TenantRegion(tenantID string) string {
+	if region, ok := m.Tenants[tenantID]; ok {
+		return region
+	}
+	return m.DefaultRegion
+}
+
+// UserStore looks up registered users and their home region
+type UserStore interface {
+	GetUser(ctx context.Context, id string) (*models.User, error)
+}
+
+// Guard refuses access to data homed outside the local region
+type Guard struct {
+	local   string
+	regions *Map
+	users   UserStore
+}
+
+// NewGuard creates a guard for the deployment of the local region
+func NewGuard(local string, regions *Map, users UserStore) *Guard {
+	return &Guard{local: local, regions: regions, users: users}
+}
+
+// Local returns the region this deployment serves
+func (g *Guard) Local() string {
+	return g.local
+}
+
+// Has reports whether the region map lists a region
+func (g *Guard) Has(region string) bool {
+	return g.regions.Has(region)
+}
+
+// CheckTenant returns a *WrongRegionError if the request's tenant is homed in another region
+func (g *Guard) CheckTenant(ctx context.Context) error {
+	return g.CheckRegion(g.regions.TenantRegion(tenant.FromContext(ctx)))
+}
+
+// CheckUser returns a *WrongRegionError if the tenant or the registered user is homed in another
+// region; a user without a region follows its tenant
+func (g *Guard) CheckUser(ctx context.Context, userID string) error {
+	if err := g.CheckTenant(ctx); err != nil || userID == "" {
+		return err
+	}
+
+	user, err := g.users.GetUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to look up user region: %w", err)
+	}
+	if user == nil {
+		return nil
+	}
+	return g.CheckRegion(user.Region)
+}
+
+// CheckRegion returns a *WrongRegionError for a home region other than the local one; "" is
+// treated as local
+func (g *Guard) CheckRegion(region string) error {
+	if region == "" || region == g.local {
+		return nil
+	}
+	return &WrongRegionError{Region: region, URL: g.regions.Regions[region].URL, Local: g.local}
+}
@@ -0,0 +1,119 @@
+package retrieval
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register(KindNormalizer, "none", func(Deps) (interface{}, error) { return noneNormalizer{}, nil })
+	Register(KindNormalizer, "minmax", func(Deps) (interface{}, error) { return minMaxNormalizer{}, nil })
+	Register(KindNormalizer, "zscore", func(Deps) (interface{}, error) { return zScoreNormalizer{}, nil })
+	Register(KindNormalizer, "calibrated", func(deps Deps) (interface{}, error) {
+		calibration, ok := deps.Calibrations[deps.Model]
+		if !ok {
+			return nil, fmt.Errorf("no calibration configured for embedding model %q", deps.Model)
+		}
+		return calibration, nil
+	})
+}
+
+// Calibration maps a model's raw similarity scores to [0, 1] with a logistic curve fitted offline
+type Calibration struct {
+	Slope     float64
+	Intercept float64
+}
+
+// ParseCalibrations parses "model=slope:intercept" entries
+func ParseCalibrations(entries []string) (map[string]Calibration, error) {
+	calibrations := make(map[string]Calibration, len(entries))
+	for _, entry := range entries {
+		model, params, ok := strings.Cut(entry, "=")
+		slopeStr, interceptStr, ok2 := strings.Cut(params, ":")
+		if !ok || !ok2 || strings.TrimSpace(model) == "" {
+			return nil, fmt.Errorf("invalid calibration %q, expected model=slope:intercept", entry)
+		}
+		slope, err := strconv.ParseFloat(strings.TrimSpace(slopeStr), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calibration slope in %q: %w", entry, err)
+		}
+		intercept, err := strconv.ParseFloat(strings.TrimSpace(interceptStr), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calibration intercept in %q: %w", entry, err)
+		}
+		calibrations[strings.TrimSpace(model)] = Calibration{Slope: slope, Intercept: intercept}
+	}
+	return calibrations, nil
+}
+
+// Normalize applies the calibration curve to each score
+func (c Calibration) Normalize(candidates []Candidate) {
+	for i := range candidates {
+		candidates[i].Score = float32(sigmoid(c.Slope*float64(candidates[i].Score) + c.Intercept))
+	}
+}
+
+// noneNormalizer keeps raw scores
+type noneNormalizer struct{}
+
+func (noneNormalizer) Normalize([]Candidate) {}
+
+// minMaxNormalizer rescales scores so the best candidate scores 1 and the worst 0
+type minMaxNormalizer struct{}
+
+func (minMaxNormalizer) Normalize(candidates []Candidate) {
+	if len(candidates) == 0 {
+		return
+	}
+
+	lo, hi := candidates[0].Score, candidates[0].Score
+	for _, candidate := range candidates[1:] {
+		lo = min(lo, candidate.Score)
+		hi = max(hi, candidate.Score)
+	}
+
+	for i := range candidates {
+		if hi == lo {
+			candidates[i].Score = 1
+			continue
+		}
+		candidates[i].Score = (candidates[i].Score - lo) / (hi - lo)
+	}
+}
+
+// zScoreNormalizer standardizes scores against the candidate set and squashes them to (0, 1)
+type zScoreNormalizer struct{}
+
+func (zScoreNormalizer) Normalize(candidates []Candidate) {
+	if len(candidates) == 0 {
+		return
+	}
+
+	var sum float64
+	for _, candidate := range candidates {
+		sum += float64(candidate.Score)
+	}
+	mean := sum / float64(len(candidates))
+
+	var variance float64
+	for _, candidate := range candidates {
+		d := float64(candidate.Score) - mean
+		variance += d * d
+	}
+	stddev := math.Sqrt(variance / float64(len(candidates)))
+
+	for i := range candidates {
+		z := 0.0
+		if stddev > 0 {
+			z = (float64(candidates[i].Score) - mean) / stddev
+		}
+		candidates[i].Score = float32(sigmoid(z))
+	}
+}
+
+// sigmoid is the logistic function
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
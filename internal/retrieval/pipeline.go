@@ -0,0 +1,444 @@
+// Package retrieval runs conversation searches as a pipeline of stages: query transformers,
+// retrievers, a fuser, filters, a score normalizer and rerankers. Transformers and rerankers are
+// optional: they are skipped when too little time remains until the caller's deadline
+package retrieval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"refo-rag-server/internal/deadline"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/storage"
+)
+
+// Query is a search request as it moves through the pipeline
+type Query struct {
+	// Text is the query text; transformers may rewrite it
+	Text string
+
+	UserID string
+	Limit  int
+
+	// MinScore drops candidates whose final score is below it; 0 keeps all
+	MinScore float32
+
+	// VectorFilter is the vector store filter the retrievers apply
+	VectorFilter map[string]interface{}
+
+	// Now is the reference time for age-based rerankers
+	Now time.Time
+
+	// Overrides replace configured retrieval settings for this query
+	Overrides models.RetrievalOverrides
+
+	// fetchLimit replaces the candidate limit while the pipeline widens a search whose filters
+	// left too few candidates
+	fetchLimit int
+}
+
+// ErrInvalidOverride is returned when a query's overrides don't fit the pipeline
+var ErrInvalidOverride = errors.New("invalid retrieval override")
+
+// CandidateLimit returns how many candidates each retriever should fetch
+func (q *Query) CandidateLimit() int {
+	if q.fetchLimit > 0 {
+		return q.fetchLimit
+	}
+	if q.Overrides.CandidateMultiplier <= 1 || q.Limit <= 0 {
+		return q.Limit
+	}
+	return int(math.Ceil(float64(q.Limit) * q.Overrides.CandidateMultiplier))
+}
+
+// Candidate is a conversation retrieved for a query
+type Candidate struct {
+	ConversationID string
+	Score          float32
+
+	// RawScore is the fused score before normalization
+	RawScore float32
+
+	// Conversation is loaded after fusion; it is nil in retriever output
+	Conversation *models.Conversation
+}
+
+// QueryTransformer rewrites a query before retrieval
+type QueryTransformer interface {
+	Transform(ctx context.Context, query *Query) error
+}
+
+// Retriever returns candidates for a query, best first
+type Retriever interface {
+	Retrieve(ctx context.Context, query *Query) ([]Candidate, error)
+}
+
+// Fuser merges the candidate lists of several retrievers into one
+type Fuser interface {
+	Fuse(lists [][]Candidate) []Candidate
+}
+
+// WeightedFuser is a Fuser that can scale each retriever's contribution; queries with fusion
+// weight overrides need one
+type WeightedFuser interface {
+	Fuser
+	FuseWeighted(lists [][]Candidate, weights []float64) []Candidate
+}
+
+// Filter drops candidates after their conversations are loaded
+type Filter interface {
+	Keep(query *Query, candidate Candidate) bool
+}
+
+// Normalizer maps fused scores to a comparable range in place
+type Normalizer interface {
+	Normalize(candidates []Candidate)
+}
+
+// Reranker rescores loaded candidates
+type Reranker interface {
+	Rerank(ctx context.Context, query *Query, candidates []Candidate) ([]Candidate, error)
+}
+
+// named pairs a stage with the name it was configured under
+type named[T any] struct {
+	name  string
+	stage T
+}
+
+// Pipeline is a configured sequence of retrieval stages
+type Pipeline struct {
+	transformers  []named[QueryTransformer]
+	retrievers    []named[Retriever]
+	fuser         named[Fuser]
+	filters       []named[Filter]
+	normalizer    named[Normalizer]
+	rerankers     []named[Reranker]
+	conversations storage.ConversationStore
+
+	// spec and deps are what the pipeline was built from
+	spec Spec
+	deps Deps
+}
+
+// Spec returns the stages the pipeline was built from
+func (p *Pipeline) Spec() Spec {
+	return p.spec
+}
+
+// WeightsFusion reports whether the pipeline's fuser supports fusion weight overrides
+func (p *Pipeline) WeightsFusion() bool {
+	_, ok := p.fuser.stage.(WeightedFuser)
+	return ok
+}
+
+// Deps returns the stores and settings the pipeline was built with, to build variants of it
+func (p *Pipeline) Deps() Deps {
+	return p.deps
+}
+
+// Run executes the pipeline and returns the loaded candidates, best first
+func (p *Pipeline) Run(ctx context.Context, query *Query) ([]Candidate, error) {
+	return p.run(ctx, query, nil)
+}
+
+// Explain executes the pipeline and records every stage's intermediate output
+func (p *Pipeline) Explain(ctx context.Context, query *Query) ([]Candidate, *models.RetrievalTrace, error) {
+	start := time.Now()
+	trace := &models.RetrievalTrace{
+		RawQuery:     query.Text,
+		Transformers: []models.QueryTransformTrace{},
+		Retrievers:   []models.RetrieverTrace{},
+		Fuser:        p.fuser.name,
+		Missing:      []string{},
+		Filtered:     []models.FilteredCandidate{},
+		Normalizer:   p.normalizer.name,
+		Rerankers:    []models.RerankTrace{},
+		MinScore:     query.MinScore,
+	}
+
+	candidates, err := p.run(ctx, query, trace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	trace.Final = traced(candidates)
+	trace.DurationMs = time.Since(start).Milliseconds()
+	return candidates, trace, nil
+}
+
+// run executes the stages, recording them in trace unless it is nil
+func (p *Pipeline) run(ctx context.Context, query *Query, trace *models.RetrievalTrace) ([]Candidate, error) {
+	weights, err := p.fusionWeights(query)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, transformer := range p.transformers {
+		if deadline.Skip(ctx, KindTransformer, transformer.name) {
+			if trace != nil {
+				trace.Skipped = append(trace.Skipped, KindTransformer+":"+transformer.name)
+			}
+			continue
+		}
+		if err := transformer.stage.Transform(ctx, query); err != nil {
+			return nil, fmt.Errorf("failed to transform query: %w", err)
+		}
+		if trace != nil {
+			trace.Transformers = append(trace.Transformers, models.QueryTransformTrace{Name: transformer.name, Query: query.Text})
+		}
+	}
+
+	candidates, missing, err := p.gather(ctx, query, weights, trace)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		// Vectors pointing at deleted conversations; the integrity job and reindexing clean them up
+		metrics.DanglingVectors.Add(float64(len(missing)))
+		fmt.Printf("warning: search returned %d vectors without a stored conversation: %v\n", len(missing), missing)
+	}
+	if len(candidates) == 0 {
+		return []Candidate{}, nil
+	}
+
+	p.normalizer.stage.Normalize(candidates)
+	if trace != nil {
+		trace.Normalized = traced(candidates)
+	}
+
+	for _, reranker := range p.rerankers {
+		if deadline.Skip(ctx, KindReranker, reranker.name) {
+			if trace != nil {
+				trace.Skipped = append(trace.Skipped, KindReranker+":"+reranker.name)
+			}
+			continue
+		}
+		var before map[string]float32
+		if trace != nil {
+			before = make(map[string]float32, len(candidates))
+			for _, candidate := range candidates {
+				before[candidate.ConversationID] = candidate.Score
+			}
+		}
+
+		start := time.Now()
+		candidates, err = reranker.stage.Rerank(ctx, query, candidates)
+		slowlog.Observe(ctx, slowlog.Rerank, reranker.name, start)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rerank candidates: %w", err)
+		}
+
+		if trace != nil {
+			rerank := models.RerankTrace{Name: reranker.name, Deltas: make([]models.ScoreDelta, 0, len(candidates))}
+			for _, candidate := range candidates {
+				rerank.Deltas = append(rerank.Deltas, models.ScoreDelta{
+					ConversationID: candidate.ConversationID,
+					Before:         before[candidate.ConversationID],
+					After:          candidate.Score,
+					Delta:          candidate.Score - before[candidate.ConversationID],
+				})
+			}
+			trace.Rerankers = append(trace.Rerankers, rerank)
+		}
+	}
+
+	if query.MinScore != 0 {
+		kept := candidates[:0]
+		for _, candidate := range candidates {
+			if candidate.Score >= query.MinScore {
+				kept = append(kept, candidate)
+			}
+		}
+		candidates = kept
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	if query.Limit > 0 && len(candidates) > query.Limit {
+		candidates = candidates[:query.Limit]
+	}
+
+	return candidates, nil
+}
+
+// gather retrieves, fuses, loads and filters candidates. When missing conversations or filters
+// leave fewer than the query's limit, it fetches again with twice as many candidates until the
+// limit is met, the retrievers run out of candidates or MaxCandidates is reached. Only the last
+// round is traced
+func (p *Pipeline) gather(ctx context.Context, query *Query, weights []float64, trace *models.RetrievalTrace) ([]Candidate, []string, error) {
+	fetch := query.CandidateLimit()
+	defer func() { query.fetchLimit = 0 }()
+	for round := 1; ; round++ {
+		query.fetchLimit = fetch
+		candidates, missing, exhausted, err := p.fetch(ctx, query, weights, trace)
+		if err != nil {
+			return nil, nil, err
+		}
+		if trace != nil {
+			trace.FetchRounds = round
+		}
+
+		if exhausted || query.Limit <= 0 || len(candidates) >= query.Limit || fetch >= p.deps.MaxCandidates {
+			return candidates, missing, nil
+		}
+		fetch = min(fetch*2, p.deps.MaxCandidates)
+		metrics.SearchFetchRetries.Inc()
+	}
+}
+
+// fetch runs one round of gather. exhausted is set when no retriever filled the candidate limit,
+// so fetching more would find nothing new
+func (p *Pipeline) fetch(ctx context.Context, query *Query, weights []float64, trace *models.RetrievalTrace) (candidates []Candidate, missing []string, exhausted bool, err error) {
+	if trace != nil {
+		trace.Retrievers = trace.Retrievers[:0]
+		trace.Missing = trace.Missing[:0]
+		trace.Filtered = trace.Filtered[:0]
+	}
+
+	exhausted = true
+	lists := make([][]Candidate, 0, len(p.retrievers))
+	for _, retriever := range p.retrievers {
+		start := time.Now()
+		retrieved, err := retriever.stage.Retrieve(ctx, query)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		lists = append(lists, retrieved)
+		if len(retrieved) >= query.CandidateLimit() {
+			exhausted = false
+		}
+		if trace != nil {
+			trace.Retrievers = append(trace.Retrievers, models.RetrieverTrace{
+				Name:       retriever.name,
+				Candidates: traced(retrieved),
+				DurationMs: time.Since(start).Milliseconds(),
+			})
+		}
+	}
+
+	if weights != nil {
+		candidates = p.fuser.stage.(WeightedFuser).FuseWeighted(lists, weights)
+	} else {
+		candidates = p.fuser.stage.Fuse(lists)
+	}
+	for i := range candidates {
+		candidates[i].RawScore = candidates[i].Score
+	}
+	if trace != nil {
+		trace.Fused = traced(candidates)
+	}
+	if len(candidates) == 0 {
+		return candidates, nil, exhausted, nil
+	}
+
+	candidates, missing, err = p.load(ctx, candidates)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if trace != nil {
+		trace.Missing = append(trace.Missing, missing...)
+	}
+
+	kept := candidates[:0]
+	for _, candidate := range candidates {
+		if filter := p.rejectedBy(query, candidate); filter != "" {
+			if trace != nil {
+				trace.Filtered = append(trace.Filtered, models.FilteredCandidate{
+					ConversationID: candidate.ConversationID,
+					Score:          candidate.Score,
+					Filter:         filter,
+				})
+			}
+			continue
+		}
+		kept = append(kept, candidate)
+	}
+	return kept, missing, exhausted, nil
+}
+
+// fusionWeights resolves a query's fusion weight overrides to one weight per retriever, or nil
+// if the query has none
+func (p *Pipeline) fusionWeights(query *Query) ([]float64, error) {
+	overrides := query.Overrides.FusionWeights
+	if len(overrides) == 0 {
+		return nil, nil
+	}
+	if _, ok := p.fuser.stage.(WeightedFuser); !ok {
+		return nil, fmt.Errorf("%w: fuser %q does not support fusion weights", ErrInvalidOverride, p.fuser.name)
+	}
+
+	weights := make([]float64, len(p.retrievers))
+	matched := 0
+	for i, retriever := range p.retrievers {
+		weights[i] = 1
+		if weight, ok := overrides[retriever.name]; ok {
+			weights[i] = weight
+			matched++
+		}
+	}
+	if matched < len(overrides) {
+		names := make([]string, 0, len(p.retrievers))
+		for _, retriever := range p.retrievers {
+			names = append(names, retriever.name)
+		}
+		return nil, fmt.Errorf("%w: fusion weights name a retriever outside the pipeline (retrievers: %v)", ErrInvalidOverride, names)
+	}
+	return weights, nil
+}
+
+// rejectedBy returns the name of the first filter that drops a candidate, or "" if all keep it
+func (p *Pipeline) rejectedBy(query *Query, candidate Candidate) string {
+	for _, filter := range p.filters {
+		if !filter.stage.Keep(query, candidate) {
+			return filter.name
+		}
+	}
+	return ""
+}
+
+// load attaches stored conversations to candidates in rank order, dropping and returning the IDs
+// of candidates whose conversation is gone
+func (p *Pipeline) load(ctx context.Context, candidates []Candidate) ([]Candidate, []string, error) {
+	ids := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.ConversationID)
+	}
+
+	conversations, missing, err := p.conversations.GetConversationsByIDs(ctx, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get conversations: %w", err)
+	}
+
+	loaded := make([]Candidate, 0, len(conversations))
+	for i, j := 0, 0; i < len(candidates) && j < len(conversations); i++ {
+		if candidates[i].ConversationID != conversations[j].ID {
+			continue
+		}
+		candidate := candidates[i]
+		candidate.Conversation = conversations[j]
+		loaded = append(loaded, candidate)
+		j++
+	}
+	return loaded, missing, nil
+}
+
+// traced copies candidate scores for a trace
+func traced(candidates []Candidate) []models.TracedCandidate {
+	out := make([]models.TracedCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		out = append(out, models.TracedCandidate{
+			ConversationID: candidate.ConversationID,
+			Score:          candidate.Score,
+			RawScore:       candidate.RawScore,
+		})
+	}
+	return out
+}
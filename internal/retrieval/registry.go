@@ -0,0 +1,188 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/storage"
+)
+
+// Deps holds the stores and settings stages are built from
+type Deps struct {
+	Conversations storage.ConversationStore
+	Vectors       storage.VectorStore
+	Embedder      storage.EmbeddingProvider
+
+	// Model is the embedding model of the searched collection
+	Model string
+
+	// Calibrations holds the score calibration of each embedding model
+	Calibrations map[string]Calibration
+
+	// RecencyWeight blends a recency score into the similarity score (0 disables, 1 ranks by recency only)
+	RecencyWeight float64
+
+	// RecencyHalfLife is the age at which a conversation's recency score halves
+	RecencyHalfLife time.Duration
+
+	// ImportanceWeight blends the decayed importance score into the similarity score (0 disables)
+	ImportanceWeight float64
+
+	// ImportanceHalfLife is the age at which a conversation's importance halves
+	ImportanceHalfLife time.Duration
+
+	// Location is the time zone calendar expressions in queries are read in; nil is UTC
+	Location *time.Location
+
+	// MaxCandidates caps the candidates fetched per retriever when a search is fetched again
+	// because filters left fewer results than its limit (0 never fetches again)
+	MaxCandidates int
+
+	// Adapter personalizes query embeddings per user; nil leaves them unchanged
+	Adapter QueryAdapter
+}
+
+// QueryAdapter personalizes a user's query embedding for an embedding model
+type QueryAdapter interface {
+	Adapt(ctx context.Context, userID string, model string, vector []float32) []float32
+}
+
+// Spec names the stages of a pipeline in the order they run
+type Spec struct {
+	Transformers []string
+	Retrievers   []string
+	Fuser        string
+	Filters      []string
+	Normalizer   string
+	Rerankers    []string
+}
+
+// Stage kinds
+const (
+	KindTransformer = "transformer"
+	KindRetriever   = "retriever"
+	KindFuser       = "fuser"
+	KindFilter      = "filter"
+	KindNormalizer  = "normalizer"
+	KindReranker    = "reranker"
+)
+
+// Factory builds a stage; the result must implement the interface of the stage kind
+type Factory func(deps Deps) (interface{}, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]map[string]Factory{
+		KindTransformer: {},
+		KindRetriever:   {},
+		KindFuser:       {},
+		KindFilter:      {},
+		KindNormalizer:  {},
+		KindReranker:    {},
+	}
+)
+
+// Register makes a stage available under a name; registering a name twice replaces the stage
+func Register(kind string, name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	stages, ok := registry[kind]
+	if !ok {
+		panic(fmt.Sprintf("retrieval: unknown stage kind %q", kind))
+	}
+	stages[name] = factory
+}
+
+// Stages returns the registered stage names of a kind in sorted order
+func Stages(kind string) []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry[kind]))
+	for name := range registry[kind] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Build resolves the named stages of a spec into a pipeline
+func Build(spec Spec, deps Deps) (*Pipeline, error) {
+	if len(spec.Retrievers) == 0 {
+		return nil, fmt.Errorf("retrieval pipeline needs at least one retriever")
+	}
+	if spec.Fuser == "" {
+		spec.Fuser = "max"
+	}
+	if spec.Normalizer == "" {
+		spec.Normalizer = "none"
+	}
+
+	p := &Pipeline{conversations: deps.Conversations, spec: spec, deps: deps}
+	for _, name := range spec.Transformers {
+		stage, err := build[QueryTransformer](KindTransformer, name, deps)
+		if err != nil {
+			return nil, err
+		}
+		p.transformers = append(p.transformers, named[QueryTransformer]{name, stage})
+	}
+	for _, name := range spec.Retrievers {
+		stage, err := build[Retriever](KindRetriever, name, deps)
+		if err != nil {
+			return nil, err
+		}
+		p.retrievers = append(p.retrievers, named[Retriever]{name, stage})
+	}
+	fuser, err := build[Fuser](KindFuser, spec.Fuser, deps)
+	if err != nil {
+		return nil, err
+	}
+	p.fuser = named[Fuser]{spec.Fuser, fuser}
+	for _, name := range spec.Filters {
+		stage, err := build[Filter](KindFilter, name, deps)
+		if err != nil {
+			return nil, err
+		}
+		p.filters = append(p.filters, named[Filter]{name, stage})
+	}
+	normalizer, err := build[Normalizer](KindNormalizer, spec.Normalizer, deps)
+	if err != nil {
+		return nil, err
+	}
+	p.normalizer = named[Normalizer]{spec.Normalizer, normalizer}
+	for _, name := range spec.Rerankers {
+		stage, err := build[Reranker](KindReranker, name, deps)
+		if err != nil {
+			return nil, err
+		}
+		p.rerankers = append(p.rerankers, named[Reranker]{name, stage})
+	}
+
+	return p, nil
+}
+
+// build looks up a registered stage and checks it implements the stage kind's interface
+func build[T any](kind string, name string, deps Deps) (T, error) {
+	var zero T
+
+	registryMu.RLock()
+	factory, ok := registry[kind][name]
+	registryMu.RUnlock()
+	if !ok {
+		return zero, fmt.Errorf("unknown %s %q (registered: %v)", kind, name, Stages(kind))
+	}
+
+	built, err := factory(deps)
+	if err != nil {
+		return zero, fmt.Errorf("failed to build %s %q: %w", kind, name, err)
+	}
+	stage, ok := built.(T)
+	if !ok {
+		return zero, fmt.Errorf("%s %q does not implement the %s interface", kind, name, kind)
+	}
+	return stage, nil
+}
@@ -0,0 +1,186 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"refo-rag-server/internal/importance"
+	"refo-rag-server/internal/storage"
+)
+
+// rrfK dampens the weight of top ranks in reciprocal rank fusion
+const rrfK = 60
+
+func init() {
+	Register(KindRetriever, "vector", func(deps Deps) (interface{}, error) {
+		if deps.Vectors == nil || deps.Embedder == nil {
+			return nil, fmt.Errorf("vector retriever needs a vector store and an embedding provider")
+		}
+		return vectorRetriever{vectors: deps.Vectors, embedder: deps.Embedder, adapter: deps.Adapter, model: deps.Model}, nil
+	})
+	Register(KindFuser, "max", func(Deps) (interface{}, error) { return maxFuser{}, nil })
+	Register(KindFuser, "rrf", func(Deps) (interface{}, error) { return rrfFuser{}, nil })
+	Register(KindFilter, "suppressed", func(Deps) (interface{}, error) { return suppressedFilter{}, nil })
+	Register(KindReranker, "recency", func(deps Deps) (interface{}, error) {
+		return recencyReranker{weight: deps.RecencyWeight, halfLife: deps.RecencyHalfLife}, nil
+	})
+	Register(KindReranker, "importance", func(deps Deps) (interface{}, error) {
+		return importanceReranker{weight: deps.ImportanceWeight, halfLife: deps.ImportanceHalfLife}, nil
+	})
+}
+
+// vectorRetriever embeds the query text, adapts it to the user and searches the vector store
+type vectorRetriever struct {
+	vectors  storage.VectorStore
+	embedder storage.EmbeddingProvider
+	adapter  QueryAdapter
+	model    string
+}
+
+func (r vectorRetriever) Retrieve(ctx context.Context, query *Query) ([]Candidate, error) {
+	embedding, err := r.embedder.EmbedQuery(ctx, query.Text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create query embedding: %w", err)
+	}
+	if r.adapter != nil {
+		embedding = r.adapter.Adapt(ctx, query.UserID, r.model, embedding)
+	}
+
+	results, err := r.vectors.SearchVectors(ctx, embedding, storage.SearchOptions{
+		Limit:  query.CandidateLimit(),
+		Filter: query.VectorFilter,
+		UserID: query.UserID,
+		HNSWEf: query.Overrides.HNSWEf,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search vectors: %w", err)
+	}
+
+	candidates := make([]Candidate, 0, len(results))
+	for _, result := range results {
+		candidates = append(candidates, Candidate{ConversationID: result.ConversationID, Score: result.Score})
+	}
+	return candidates, nil
+}
+
+// maxFuser keeps each conversation once with its best score across retrievers
+type maxFuser struct{}
+
+func (f maxFuser) Fuse(lists [][]Candidate) []Candidate {
+	if len(lists) == 1 {
+		return lists[0]
+	}
+	return f.FuseWeighted(lists, nil)
+}
+
+// FuseWeighted scales each list's scores by its weight before keeping the best
+func (maxFuser) FuseWeighted(lists [][]Candidate, weights []float64) []Candidate {
+	index := make(map[string]int)
+	var fused []Candidate
+	for l, list := range lists {
+		for _, candidate := range list {
+			if weights != nil {
+				candidate.Score = float32(float64(candidate.Score) * weights[l])
+			}
+			i, seen := index[candidate.ConversationID]
+			if !seen {
+				index[candidate.ConversationID] = len(fused)
+				fused = append(fused, candidate)
+				continue
+			}
+			if candidate.Score > fused[i].Score {
+				fused[i].Score = candidate.Score
+			}
+		}
+	}
+	return fused
+}
+
+// rrfFuser scores conversations by reciprocal rank fusion, ignoring the retrievers' raw scores
+type rrfFuser struct{}
+
+func (f rrfFuser) Fuse(lists [][]Candidate) []Candidate {
+	return f.FuseWeighted(lists, nil)
+}
+
+// FuseWeighted scales each list's reciprocal rank contributions by its weight
+func (rrfFuser) FuseWeighted(lists [][]Candidate, weights []float64) []Candidate {
+	index := make(map[string]int)
+	var fused []Candidate
+	for l, list := range lists {
+		weight := 1.0
+		if weights != nil {
+			weight = weights[l]
+		}
+		for rank, candidate := range list {
+			score := float32(weight / float64(rrfK+rank+1))
+			i, seen := index[candidate.ConversationID]
+			if !seen {
+				index[candidate.ConversationID] = len(fused)
+				candidate.Score = score
+				fused = append(fused, candidate)
+				continue
+			}
+			fused[i].Score += score
+		}
+	}
+	return fused
+}
+
+// suppressedFilter drops suppressed conversations whose vector payload wasn't updated
+type suppressedFilter struct{}
+
+func (suppressedFilter) Keep(_ *Query, candidate Candidate) bool {
+	return candidate.Conversation.Suppression == nil
+}
+
+// recencyReranker blends an exponential decay of the latest message's age into the score
+type recencyReranker struct {
+	weight   float64
+	halfLife time.Duration
+}
+
+func (r recencyReranker) Rerank(_ context.Context, query *Query, candidates []Candidate) ([]Candidate, error) {
+	if query.Overrides.RecencyHalfLife > 0 {
+		r.halfLife = query.Overrides.RecencyHalfLife
+	}
+	if query.Overrides.RecencyWeight != nil {
+		r.weight = *query.Overrides.RecencyWeight
+	}
+	if r.weight <= 0 || r.halfLife <= 0 {
+		return candidates, nil
+	}
+
+	for i, candidate := range candidates {
+		age := query.Now.Sub(candidate.Conversation.LastMessageAt())
+		if age < 0 {
+			age = 0
+		}
+		recency := math.Pow(0.5, float64(age)/float64(r.halfLife))
+		candidates[i].Score = float32((1-r.weight)*float64(candidate.Score) + r.weight*recency)
+	}
+	return candidates, nil
+}
+
+// importanceReranker blends a conversation's decayed importance into the score
+type importanceReranker struct {
+	weight   float64
+	halfLife time.Duration
+}
+
+func (r importanceReranker) Rerank(_ context.Context, query *Query, candidates []Candidate) ([]Candidate, error) {
+	if query.Overrides.ImportanceHalfLife > 0 {
+		r.halfLife = query.Overrides.ImportanceHalfLife
+	}
+	if r.weight <= 0 {
+		return candidates, nil
+	}
+
+	for i, candidate := range candidates {
+		decayed := importance.Decay(candidate.Conversation.Importance, query.Now.Sub(candidate.Conversation.UpdatedAt), r.halfLife)
+		candidates[i].Score = float32((1-r.weight)*float64(candidate.Score) + r.weight*decayed)
+	}
+	return candidates, nil
+}
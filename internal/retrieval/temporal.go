@@ -0,0 +1,50 @@
+package retrieval
+
+import (
+	"context"
+	"time"
+
+	"refo-rag-server/internal/temporal"
+)
+
+func init() {
+	Register(KindTransformer, "temporal", func(deps Deps) (interface{}, error) {
+		location := deps.Location
+		if location == nil {
+			location = time.UTC
+		}
+		return temporalTransformer{location: location}, nil
+	})
+}
+
+// temporalTransformer turns a calendar expression in the query, such as "last Tuesday", into a
+// range filter on the conversation's created_at and removes it from the text that is embedded
+type temporalTransformer struct {
+	location *time.Location
+}
+
+func (t temporalTransformer) Transform(_ context.Context, query *Query) error {
+	now := query.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	match, ok := temporal.Find(query.Text, now.In(t.location))
+	if !ok {
+		return nil
+	}
+
+	query.Text = temporal.Strip(query.Text, match)
+	condition := map[string]interface{}{
+		"key": "created_at",
+		"range": map[string]interface{}{
+			"gte": match.Range.Start.Unix(),
+			"lt":  match.Range.End.Unix(),
+		},
+	}
+	if query.VectorFilter == nil {
+		query.VectorFilter = map[string]interface{}{}
+	}
+	must, _ := query.VectorFilter["must"].([]interface{})
+	query.VectorFilter["must"] = append(must, condition)
+	return nil
+}
@@ -0,0 +1,203 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a task runs: a cron expression evaluated in UTC, or a fixed interval
+type Schedule struct {
+	spec string
+
+	// every is the interval of an @every schedule; zero for cron expressions
+	every time.Duration
+
+	// Bit sets of the minutes, hours, days of the month, months and weekdays that match
+	minute, hour, dom, month, dow uint64
+
+	// domAny and dowAny are set when the field is *; when both day fields are restricted, a day
+	// matching either one matches, as in cron
+	domAny, dowAny bool
+}
+
+// macros are the cron shorthands Parse accepts
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field is the range of one cron field
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = [5]field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// horizon bounds the search for the next run, so a schedule matching no real date (such as
+// February 30th) is rejected instead of searched forever
+const horizon = 5 * 366 * 24 * time.Hour
+
+// Parse parses a five-field cron expression (minute hour day-of-month month day-of-week, in UTC)
+// with *, lists, ranges and steps, a macro such as @daily, or "@every <duration>"
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || every < time.Second {
+			return nil, fmt.Errorf("schedule %q: @every needs a duration of at least 1s", spec)
+		}
+		return &Schedule{spec: spec, every: every}, nil
+	}
+
+	expr := spec
+	if strings.HasPrefix(spec, "@") {
+		macro, ok := macros[spec]
+		if !ok {
+			return nil, fmt.Errorf("schedule %q: unknown macro", spec)
+		}
+		expr = macro
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("schedule %q: expected 5 fields (minute hour day-of-month month day-of-week)", spec)
+	}
+
+	s := &Schedule{spec: spec}
+	sets := [5]*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, part := range parts {
+		bits, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", spec, err)
+		}
+		*sets[i] = bits
+	}
+	// Sunday is both 0 and 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = parts[2] == "*"
+	s.dowAny = parts[4] == "*"
+
+	if s.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("schedule %q never runs", spec)
+	}
+	return s, nil
+}
+
+// parseField parses a comma-separated list of *, values, ranges and steps into a bit set
+func parseField(value string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(value, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepPart, f.name)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = fieldValue(from, f); err != nil {
+				return 0, err
+			}
+			if hi, err = fieldValue(to, f); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangePart, f.name)
+			}
+		default:
+			var err error
+			if lo, err = fieldValue(rangePart, f); err != nil {
+				return 0, err
+			}
+			// "5/15" runs from 5 to the end of the range
+			if !hasStep {
+				hi = lo
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// fieldValue parses one number of a cron field and checks its range
+func fieldValue(value string, f field) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("%s must be between %d and %d, got %q", f.name, f.min, f.max, value)
+	}
+	return n, nil
+}
+
+// Next returns the first run time after t, or the zero time if the schedule doesn't run within
+// the next five years
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(horizon)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether t's day matches the day-of-month and day-of-week fields
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// String returns the schedule as it was given
+func (s *Schedule) String() string {
+	return s.spec
+}
@@ -0,0 +1,197 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+)
+
+// Errors of running a task on demand
+var (
+	ErrUnknownTask  = errors.New("no such scheduled task")
+	ErrTaskDisabled = errors.New("scheduled task is disabled")
+	ErrTaskRunning  = errors.New("scheduled task is already running")
+)
+
+// Task is a job run on a schedule
+type Task struct {
+	Name     string
+	Schedule *Schedule
+
+	// Enabled tasks run on schedule and on demand; disabled ones are only listed
+	Enabled bool
+
+	// Jitter delays each scheduled run by a random duration up to it
+	Jitter time.Duration
+
+	Run func(ctx context.Context) error
+}
+
+// Scheduler runs tasks on their schedules while shouldRun reports true, typically on the leader
+// replica only. A task whose previous run is still going when it is due is skipped rather than
+// run twice
+type Scheduler struct {
+	shouldRun func() bool
+
+	mu    sync.Mutex
+	ctx   context.Context
+	tasks []*entry
+}
+
+// entry is a registered task and its run state
+type entry struct {
+	task   Task
+	status models.ScheduledTaskStatus
+}
+
+// New creates a scheduler; a nil shouldRun runs tasks on every replica
+func New(shouldRun func() bool) *Scheduler {
+	return &Scheduler{shouldRun: shouldRun, ctx: context.Background()}
+}
+
+// Add registers a task; register tasks before calling Run
+func (s *Scheduler) Add(task Task) {
+	s.tasks = append(s.tasks, &entry{
+		task: task,
+		status: models.ScheduledTaskStatus{
+			Name:     task.Name,
+			Schedule: task.Schedule.String(),
+			Enabled:  task.Enabled,
+			JitterMs: task.Jitter.Milliseconds(),
+		},
+	})
+}
+
+// Run runs the enabled tasks on schedule until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, e := range s.tasks {
+		if !e.task.Enabled {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, e)
+		}()
+	}
+	wg.Wait()
+}
+
+// loop waits for each of a task's run times and starts the run
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	for {
+		next := e.task.Schedule.Next(time.Now())
+		if next.IsZero() {
+			return
+		}
+		s.mu.Lock()
+		e.status.NextRunAt = &next
+		s.mu.Unlock()
+
+		wait := time.Until(next)
+		if e.task.Jitter > 0 {
+			wait += rand.N(e.task.Jitter)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if s.shouldRun != nil && !s.shouldRun() {
+			continue
+		}
+		if err := s.start(ctx, e); errors.Is(err, ErrTaskRunning) {
+			fmt.Printf("warning: scheduled task %s skipped, its previous run is still going\n", e.task.Name)
+		}
+	}
+}
+
+// RunNow starts a task on this replica without waiting for its schedule
+func (s *Scheduler) RunNow(name string) error {
+	for _, e := range s.tasks {
+		if e.task.Name != name {
+			continue
+		}
+		if !e.task.Enabled {
+			return ErrTaskDisabled
+		}
+		s.mu.Lock()
+		ctx := s.ctx
+		s.mu.Unlock()
+		return s.start(ctx, e)
+	}
+	return ErrUnknownTask
+}
+
+// start runs a task in the background unless it is running already
+func (s *Scheduler) start(ctx context.Context, e *entry) error {
+	s.mu.Lock()
+	if e.status.Running {
+		e.status.Skipped++
+		e.status.LastOutcome = models.ScheduledRunSkipped
+		s.mu.Unlock()
+		metrics.ScheduledTaskRuns.WithLabelValues(e.task.Name, models.ScheduledRunSkipped).Inc()
+		return ErrTaskRunning
+	}
+	startedAt := time.Now()
+	e.status.Running = true
+	e.status.LastStartedAt = &startedAt
+	s.mu.Unlock()
+
+	go func() {
+		err := e.task.Run(ctx)
+		finishedAt := time.Now()
+
+		outcome := models.ScheduledRunSucceeded
+		if err != nil {
+			outcome = models.ScheduledRunFailed
+			if ctx.Err() == nil {
+				fmt.Printf("warning: scheduled task %s failed: %v\n", e.task.Name, err)
+				errreport.Background(ctx, "scheduled_"+e.task.Name, err)
+			}
+		}
+		metrics.ScheduledTaskRuns.WithLabelValues(e.task.Name, outcome).Inc()
+		metrics.ScheduledTaskDuration.WithLabelValues(e.task.Name).Observe(finishedAt.Sub(startedAt).Seconds())
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		e.status.Running = false
+		e.status.LastFinishedAt = &finishedAt
+		e.status.LastDurationMs = finishedAt.Sub(startedAt).Milliseconds()
+		e.status.LastOutcome = outcome
+		e.status.LastError = ""
+		if err != nil {
+			e.status.LastError = err.Error()
+			e.status.Failures++
+		}
+		e.status.Runs++
+	}()
+	return nil
+}
+
+// Status reports every task's configuration and runs, in registration order
+func (s *Scheduler) Status() []models.ScheduledTaskStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]models.ScheduledTaskStatus, 0, len(s.tasks))
+	for _, e := range s.tasks {
+		statuses = append(statuses, e.status)
+	}
+	return statuses
+}
@@ -0,0 +1,96 @@
+// Package seed loads a demo dataset through the service layer so a fresh installation has
+// users, sessions, conversations and personal info to search
+package seed
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/service"
+)
+
+//go:embed demo.json
+var demoData []byte
+
+// dataset is the layout of demo.json
+type dataset struct {
+	Users []struct {
+		UserID       string `json:"user_id"`
+		PersonalInfo []struct {
+			ID         string `json:"id"`
+			Category   string `json:"category"`
+			Importance string `json:"importance"`
+			Content    string `json:"content"`
+		} `json:"personal_info"`
+		Conversations []struct {
+			ID        string           `json:"id"`
+			SessionID string           `json:"session_id"`
+			Messages  []models.Message `json:"messages"`
+		} `json:"conversations"`
+	} `json:"users"`
+}
+
+// Result counts what a seed run stored
+type Result struct {
+	Users         int
+	Conversations int
+	PersonalInfo  int
+}
+
+// Load stores the demo dataset. Records have fixed IDs, so running it again updates conversations
+// in place and skips personal info that already exists.
+func Load(ctx context.Context, conversations *service.ConversationService, personalInfo *service.PersonalInfoService) (*Result, error) {
+	var data dataset
+	if err := json.Unmarshal(demoData, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode demo dataset: %w", err)
+	}
+
+	result := &Result{}
+	for _, user := range data.Users {
+		result.Users++
+
+		for _, info := range user.PersonalInfo {
+			existing, err := personalInfo.GetPersonalInfo(ctx, info.ID)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				continue
+			}
+
+			now := time.Now()
+			err = personalInfo.CreatePersonalInfo(ctx, &models.PersonalInfo{
+				ID:         info.ID,
+				UserID:     user.UserID,
+				Content:    info.Content,
+				Category:   info.Category,
+				Importance: info.Importance,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to seed personal info %s: %w", info.ID, err)
+			}
+			result.PersonalInfo++
+		}
+
+		for _, conv := range user.Conversations {
+			_, err := conversations.SaveConversation(ctx, &models.ConversationSaveRequest{
+				ConversationID: conv.ID,
+				UserID:         user.UserID,
+				Messages:       conv.Messages,
+				Metadata:       &models.ConversationMetadata{Source: "seed", SessionID: conv.SessionID},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to seed conversation %s: %w", conv.ID, err)
+			}
+			result.Conversations++
+		}
+	}
+
+	return result, nil
+}
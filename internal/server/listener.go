@@ -0,0 +1,103 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// systemdListenFDsStart is the first file descriptor passed by systemd socket activation
+const systemdListenFDsStart = 3
+
+// ListenOptions selects where the API server accepts connections
+type ListenOptions struct {
+	// Addr is a TCP host:port; the host may be empty to bind all interfaces
+	Addr string
+
+	// SocketPath binds a Unix domain socket instead of TCP when set
+	SocketPath string
+	SocketMode os.FileMode
+
+	// SystemdActivation uses the socket passed by systemd when available
+	SystemdActivation bool
+}
+
+// Listen creates the listener described by the options
+// Precedence is systemd socket activation, then Unix socket, then TCP.
+func Listen(opts ListenOptions) (net.Listener, string, error) {
+	if opts.SystemdActivation {
+		listener, err := systemdListener()
+		if err != nil {
+			return nil, "", err
+		}
+		if listener != nil {
+			return listener, "systemd:" + listener.Addr().String(), nil
+		}
+	}
+
+	if opts.SocketPath != "" {
+		return unixListener(opts.SocketPath, opts.SocketMode)
+	}
+
+	listener, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to listen on %s: %w", opts.Addr, err)
+	}
+	return listener, listener.Addr().String(), nil
+}
+
+// unixListener binds a Unix domain socket, replacing a stale socket file if present
+func unixListener(path string, mode os.FileMode) (net.Listener, string, error) {
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, "", fmt.Errorf("refusing to replace non-socket file %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, "", fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to listen on unix socket %s: %w", path, err)
+	}
+
+	if mode != 0 {
+		if err := os.Chmod(path, mode); err != nil {
+			listener.Close()
+			return nil, "", fmt.Errorf("failed to set socket permissions on %s: %w", path, err)
+		}
+	}
+
+	return listener, "unix:" + path, nil
+}
+
+// systemdListener returns the first socket passed via LISTEN_FDS, or nil when not socket-activated
+func systemdListener() (net.Listener, error) {
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, nil
+	}
+
+	// Unset so child processes don't inherit the activation environment
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	file := os.NewFile(uintptr(systemdListenFDsStart), "systemd-listener")
+	if file == nil {
+		return nil, fmt.Errorf("systemd passed an invalid listen file descriptor")
+	}
+	defer file.Close()
+
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use systemd listen socket: %w", err)
+	}
+	return listener, nil
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Options configures how the API server listens and terminates TLS
+type Options struct {
+	// Addr is the address the API server listens on, e.g. ":8080"; for TLS it
+	// also determines the port that HTTP requests are redirected to
+	Addr string
+
+	// TLSCertFile and TLSKeyFile serve HTTPS with a static certificate
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// AutocertDomains enables ACME (Let's Encrypt) certificates for these hosts
+	AutocertDomains  []string
+	AutocertCacheDir string
+	AutocertEmail    string
+
+	// HTTPRedirectAddr starts a plain HTTP listener that redirects to HTTPS
+	// (and answers ACME http-01 challenges when autocert is enabled)
+	HTTPRedirectAddr string
+
+	// H2C enables unencrypted HTTP/2 when TLS is not configured
+	H2C bool
+}
+
+// TLSEnabled reports whether the server terminates TLS itself
+func (o Options) TLSEnabled() bool {
+	return o.TLSCertFile != "" || len(o.AutocertDomains) > 0
+}
+
+// Server wraps the API http.Server and its optional redirect listener
+type Server struct {
+	opts           Options
+	httpServer     *http.Server
+	redirectServer *http.Server
+	certManager    *autocert.Manager
+}
+
+// New creates a server for the given handler
+func New(handler http.Handler, opts Options) (*Server, error) {
+	if opts.TLSCertFile != "" && len(opts.AutocertDomains) > 0 {
+		return nil, fmt.Errorf("static TLS certificates and autocert cannot be combined")
+	}
+	if (opts.TLSCertFile == "") != (opts.TLSKeyFile == "") {
+		return nil, fmt.Errorf("TLS certificate and key must be configured together")
+	}
+	if opts.HTTPRedirectAddr != "" && !opts.TLSEnabled() {
+		return nil, fmt.Errorf("HTTP to HTTPS redirect requires TLS to be configured")
+	}
+
+	s := &Server{
+		opts: opts,
+		httpServer: &http.Server{
+			Addr:              opts.Addr,
+			Handler:           handler,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	if opts.TLSEnabled() {
+		protocols.SetHTTP2(true)
+	} else if opts.H2C {
+		protocols.SetUnencryptedHTTP2(true)
+	}
+	s.httpServer.Protocols = protocols
+
+	if len(opts.AutocertDomains) > 0 {
+		s.certManager = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(opts.AutocertDomains...),
+			Cache:      autocert.DirCache(opts.AutocertCacheDir),
+			Email:      opts.AutocertEmail,
+		}
+		s.httpServer.TLSConfig = s.certManager.TLSConfig()
+	} else if opts.TLSCertFile != "" {
+		s.httpServer.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	if opts.HTTPRedirectAddr != "" {
+		var redirect http.Handler = http.HandlerFunc(redirectToHTTPS(opts.Addr))
+		if s.certManager != nil {
+			redirect = s.certManager.HTTPHandler(redirect)
+		}
+		s.redirectServer = &http.Server{
+			Addr:              opts.HTTPRedirectAddr,
+			Handler:           redirect,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
+
+	return s, nil
+}
+
+// Serve accepts connections on the listener until the server is shut down
+func (s *Server) Serve(listener net.Listener) error {
+	if s.redirectServer != nil {
+		go func() {
+			log.Printf("Redirecting HTTP on %s to HTTPS", s.opts.HTTPRedirectAddr)
+			if err := s.redirectServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("HTTP redirect listener failed: %v", err)
+			}
+		}()
+	}
+
+	var err error
+	if s.opts.TLSEnabled() {
+		// Certificates come from TLSConfig when autocert is enabled
+		err = s.httpServer.ServeTLS(listener, s.opts.TLSCertFile, s.opts.TLSKeyFile)
+	} else {
+		err = s.httpServer.Serve(listener)
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown gracefully stops the API server and the redirect listener
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.redirectServer != nil {
+		if err := s.redirectServer.Shutdown(ctx); err != nil {
+			log.Printf("HTTP redirect listener shutdown failed: %v", err)
+		}
+	}
+	return s.httpServer.Shutdown(ctx)
+}
+
+// redirectToHTTPS returns a handler that redirects requests to the HTTPS listener
+func redirectToHTTPS(tlsAddr string) func(http.ResponseWriter, *http.Request) {
+	_, tlsPort, _ := net.SplitHostPort(tlsAddr)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]")
+
+		if tlsPort != "" && tlsPort != "443" {
+			host = net.JoinHostPort(host, tlsPort)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"refo-rag-server/internal/analytics"
+	"refo-rag-server/internal/models"
+)
+
+var (
+	ErrExportRunning = errors.New("an analytics export is already running")
+	ErrExportFuture  = errors.New("only days that have ended can be exported")
+)
+
+// AnalyticsExportService runs analytics exports as logged jobs, on demand and on a schedule
+type AnalyticsExportService struct {
+	exporter *analytics.Exporter
+	jobs     *JobLog
+
+	// exporting is set while an export runs
+	exporting atomic.Bool
+}
+
+// NewAnalyticsExportService creates a new analytics export service
+func NewAnalyticsExportService(exporter *analytics.Exporter, jobs *JobLog) *AnalyticsExportService {
+	return &AnalyticsExportService{
+		exporter: exporter,
+		jobs:     jobs,
+	}
+}
+
+// StartExport starts a background job exporting a UTC day, replacing any earlier export of it.
+// It returns the job ID; the export report is the job's result.
+func (as *AnalyticsExportService) StartExport(ctx context.Context, day time.Time) (string, error) {
+	if !day.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1).Before(time.Now()) {
+		return "", ErrExportFuture
+	}
+	if !as.exporting.CompareAndSwap(false, true) {
+		return "", ErrExportRunning
+	}
+
+	jobID, err := as.jobs.Start(ctx, models.JobKindAnalytics, day.UTC().Format(analytics.DateLayout), func(ctx context.Context) (interface{}, error) {
+		defer as.exporting.Store(false)
+		return as.exporter.Export(ctx, day)
+	})
+	if err != nil {
+		as.exporting.Store(false)
+		return "", err
+	}
+	return jobID, nil
+}
+
+// ExportMissing exports every ended day of the last lookbackDays that has no complete export yet,
+// oldest first, for scheduled exports
+func (as *AnalyticsExportService) ExportMissing(ctx context.Context, lookbackDays int) error {
+	if !as.exporting.CompareAndSwap(false, true) {
+		return ErrExportRunning
+	}
+	defer as.exporting.Store(false)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for offset := lookbackDays; offset >= 1; offset-- {
+		day := today.AddDate(0, 0, -offset)
+		exported, err := as.exporter.Exported(ctx, day)
+		if err != nil {
+			return err
+		}
+		if exported {
+			continue
+		}
+
+		_, err = as.jobs.Run(ctx, models.JobKindAnalytics, day.Format(analytics.DateLayout), false, func(ctx context.Context) (interface{}, error) {
+			return as.exporter.Export(ctx, day)
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("exported analytics for %s\n", day.Format(analytics.DateLayout))
+	}
+	return nil
+}
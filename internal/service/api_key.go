@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"refo-rag-server/internal/apikey"
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+var (
+	ErrInvalidExpiry  = errors.New("expires_at must be in the future")
+	ErrAPIKeyInactive = errors.New("API key is revoked, expired or already rotated")
+)
+
+// APIKeyService manages service account keys. Changes take effect on this instance immediately
+// and on other instances when their keyring next reloads
+type APIKeyService struct {
+	store   storage.APIKeyStore
+	keyring *apikey.Keyring
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(store storage.APIKeyStore, keyring *apikey.Keyring) *APIKeyService {
+	return &APIKeyService{store: store, keyring: keyring}
+}
+
+// Create issues a new key and returns it with its secret
+func (aks *APIKeyService) Create(ctx context.Context, req *models.APIKeyCreateRequest) (*models.APIKeySecret, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidExpiry
+	}
+
+	secret, key, hash, err := newAPIKey(req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := aks.store.CreateAPIKey(ctx, key, hash); err != nil {
+		return nil, err
+	}
+
+	aks.reload(ctx)
+	return &models.APIKeySecret{APIKey: key, Secret: secret}, nil
+}
+
+// Get retrieves a key, or nil if it doesn't exist
+func (aks *APIKeyService) Get(ctx context.Context, id string) (*models.APIKey, error) {
+	return aks.store.GetAPIKey(ctx, id)
+}
+
+// List retrieves the keys, optionally including revoked ones
+func (aks *APIKeyService) List(ctx context.Context, includeRevoked bool) (*models.APIKeyListResponse, error) {
+	keys, err := aks.store.ListAPIKeys(ctx, includeRevoked)
+	if err != nil {
+		return nil, err
+	}
+	return &models.APIKeyListResponse{APIKeys: keys, Total: len(keys)}, nil
+}
+
+// Rotate issues a successor with the same name, scopes and expiry and lets the old key expire
+// after the grace period. It returns nil if the key doesn't exist and ErrAPIKeyInactive if it can
+// no longer be used
+func (aks *APIKeyService) Rotate(ctx context.Context, id string, gracePeriod time.Duration) (*models.APIKeySecret, error) {
+	old, err := aks.store.GetAPIKey(ctx, id)
+	if err != nil || old == nil {
+		return nil, err
+	}
+	if old.RotatedTo != "" || !old.Active(time.Now()) {
+		return nil, ErrAPIKeyInactive
+	}
+
+	secret, key, hash, err := newAPIKey(old.Name, old.Scopes, old.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := aks.store.RotateAPIKey(ctx, id, key, hash, key.CreatedAt.Add(gracePeriod))
+	if err != nil {
+		return nil, err
+	}
+	if rotated == nil {
+		// Revoked or rotated concurrently
+		return nil, ErrAPIKeyInactive
+	}
+
+	aks.reload(ctx)
+	return &models.APIKeySecret{APIKey: key, Secret: secret}, nil
+}
+
+// Revoke revokes a key immediately, or returns nil if it doesn't exist
+func (aks *APIKeyService) Revoke(ctx context.Context, id string) (*models.APIKey, error) {
+	key, err := aks.store.RevokeAPIKey(ctx, id, time.Now())
+	if err != nil || key == nil {
+		return nil, err
+	}
+
+	aks.reload(ctx)
+	return key, nil
+}
+
+// reload refreshes the local keyring after a change; a failure only delays the change until the
+// next periodic reload
+func (aks *APIKeyService) reload(ctx context.Context) {
+	if err := aks.keyring.Reload(ctx); err != nil {
+		fmt.Printf("warning: %v\n", err)
+		errreport.Background(ctx, "api_key_reload", err)
+	}
+}
+
+// newAPIKey generates a key record and its secret
+func newAPIKey(name string, scopes []string, expiresAt *time.Time) (string, *models.APIKey, string, error) {
+	secret, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	key := &models.APIKey{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Prefix:    prefix,
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	return secret, key, hash, nil
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// bulkDeleteBatchSize bounds the conversations loaded per page while matching a bulk deletion
+const bulkDeleteBatchSize = 500
+
+// ErrEmptySelection is returned for a bulk deletion that sets no criterion and would delete everything
+var ErrEmptySelection = errors.New("a bulk deletion needs a user, filter or creation time")
+
+// BulkDeleteService deletes the conversations matching a selection in two phases: a request that
+// lists the matching conversations and issues a confirmation token, and a confirmation that
+// deletes them with their vectors. Conversations are matched on their stored metadata, so those
+// without a vector are found too
+type BulkDeleteService struct {
+	conversationStore storage.ConversationStore
+	vectorStore       storage.VectorStore
+	jobs              *JobLog
+	tokens            *ConfirmationTokens
+	certificates      *DeletionCertificates
+}
+
+// bulkDeleteScope is what a confirmation token is bound to: the selection and what it matched
+type bulkDeleteScope struct {
+	Selection *models.ConversationBulkDeleteRequest `json:"selection"`
+	IDs       []string                              `json:"ids"`
+}
+
+// NewBulkDeleteService creates a new bulk deletion service
+func NewBulkDeleteService(
+	conversationStore storage.ConversationStore,
+	vectorStore storage.VectorStore,
+	jobs *JobLog,
+	tokens *ConfirmationTokens,
+) *BulkDeleteService {
+	return &BulkDeleteService{
+		conversationStore: conversationStore,
+		vectorStore:       vectorStore,
+		jobs:              jobs,
+		tokens:            tokens,
+	}
+}
+
+// SetCertificates makes executed deletions that delete anything issue a deletion certificate
+func (bds *BulkDeleteService) SetCertificates(certificates *DeletionCertificates) {
+	bds.certificates = certificates
+}
+
+// Preview reports the conversations a bulk deletion selects, recorded as a dry-run job
+func (bds *BulkDeleteService) Preview(ctx context.Context, req *models.ConversationBulkDeleteRequest) (*models.ConversationBulkDeleteResponse, error) {
+	if req.Empty() {
+		return nil, ErrEmptySelection
+	}
+	return bds.run(ctx, req, nil, true)
+}
+
+// RequestDeletion previews a bulk deletion and, if it selects anything, issues the token that
+// confirms it
+func (bds *BulkDeleteService) RequestDeletion(ctx context.Context, req *models.ConversationBulkDeleteRequest) (*models.ConversationBulkDeleteResponse, error) {
+	result, err := bds.Preview(ctx, req)
+	if err != nil || result.Conversations == 0 {
+		return result, err
+	}
+
+	scope := bulkDeleteScope{Selection: req, IDs: result.ConversationIDs}
+	if result.Confirmation, err = bds.tokens.Issue(models.JobKindBulkDelete, req.UserID, scope); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ConfirmDeletion deletes the selected conversations if the token was issued for the same
+// selection and it still matches the same conversations
+func (bds *BulkDeleteService) ConfirmDeletion(ctx context.Context, req *models.ConversationBulkDeleteRequest, token string) (*models.ConversationBulkDeleteResponse, error) {
+	if req.Empty() {
+		return nil, ErrEmptySelection
+	}
+
+	ids, err := bds.Match(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := bds.tokens.Redeem(token, models.JobKindBulkDelete, req.UserID, bulkDeleteScope{Selection: req, IDs: ids}); err != nil {
+		return nil, err
+	}
+
+	return bds.run(ctx, req, ids, false)
+}
+
+// run records a bulk deletion job that lists the selected conversations or deletes the
+// conversations in ids
+func (bds *BulkDeleteService) run(ctx context.Context, req *models.ConversationBulkDeleteRequest, ids []string, dryRun bool) (*models.ConversationBulkDeleteResponse, error) {
+	startTime := time.Now()
+	result := &models.ConversationBulkDeleteResponse{DryRun: dryRun, ConversationIDs: []string{}}
+
+	jobID, err := bds.jobs.Run(ctx, models.JobKindBulkDelete, req.UserID, dryRun, func(ctx context.Context) (interface{}, error) {
+		err := bds.delete(ctx, req, ids, dryRun, result)
+		result.DurationMs = time.Since(startTime).Milliseconds()
+		return result, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.JobID = jobID
+	if !dryRun && result.Conversations > 0 && bds.certificates != nil {
+		result.CertificateID = bds.certificates.Issue(ctx, &models.DeletionCertificate{
+			JobID:           jobID,
+			Kind:            models.JobKindBulkDelete,
+			Target:          req.UserID,
+			Policy:          bulkDeletePolicy(req),
+			Removed:         models.UserDataCounts{Conversations: int64(result.Conversations)},
+			ConversationIDs: result.ConversationIDs,
+		})
+	}
+	return result, nil
+}
+
+// bulkDeletePolicy describes a bulk deletion's selection for its deletion certificate
+func bulkDeletePolicy(req *models.ConversationBulkDeleteRequest) string {
+	selection, err := json.Marshal(req)
+	if err != nil {
+		return "bulk deletion"
+	}
+	return "bulk deletion: " + string(selection)
+}
+
+// delete deletes the conversations in ids and their vectors, adding each to result; a dry run
+// adds the conversations req selects instead
+func (bds *BulkDeleteService) delete(ctx context.Context, req *models.ConversationBulkDeleteRequest, ids []string, dryRun bool, result *models.ConversationBulkDeleteResponse) error {
+	if dryRun {
+		matched, err := bds.Match(ctx, req)
+		if err != nil {
+			return err
+		}
+		result.ConversationIDs = append(result.ConversationIDs, matched...)
+		result.Conversations = len(matched)
+		return nil
+	}
+
+	for _, id := range ids {
+		if err := bds.conversationStore.DeleteConversation(ctx, id); err != nil {
+			return err
+		}
+		if err := bds.vectorStore.DeleteVector(ctx, id); err != nil {
+			// Log error but continue - the conversation is already gone from the database
+			fmt.Printf("warning: failed to delete bulk deleted conversation vector %s: %v\n", id, err)
+			errreport.Background(ctx, "conversation_vector_delete", err)
+		}
+		result.ConversationIDs = append(result.ConversationIDs, id)
+		result.Conversations++
+	}
+	return nil
+}
+
+// Match returns the IDs of the conversations req selects, in ID order. Stores that evaluate the
+// selection in the database do so; others are paged through and matched here
+func (bds *BulkDeleteService) Match(ctx context.Context, req *models.ConversationBulkDeleteRequest) ([]string, error) {
+	if matcher, ok := bds.conversationStore.(storage.ConversationMatcher); ok {
+		return bds.matchInStore(ctx, matcher, req)
+	}
+
+	matched := []string{}
+	afterID := ""
+	for {
+		ids, err := bds.conversationStore.ListConversationIDs(ctx, req.UserID, afterID, bulkDeleteBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list conversations: %w", err)
+		}
+		if len(ids) == 0 {
+			return matched, nil
+		}
+
+		conversations, _, err := bds.conversationStore.GetConversationsByIDs(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get conversations: %w", err)
+		}
+		for _, conv := range conversations {
+			if bulkDeleteSelects(req, conv) {
+				matched = append(matched, conv.ID)
+			}
+		}
+
+		if len(ids) < bulkDeleteBatchSize {
+			return matched, nil
+		}
+		afterID = ids[len(ids)-1]
+	}
+}
+
+// matchInStore pages through the IDs of the conversations the store selects for req
+func (bds *BulkDeleteService) matchInStore(ctx context.Context, matcher storage.ConversationMatcher, req *models.ConversationBulkDeleteRequest) ([]string, error) {
+	query := storage.ConversationQuery{
+		UserID:        req.UserID,
+		Filter:        req.Expr,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+	}
+
+	matched := []string{}
+	afterID := ""
+	for {
+		ids, err := matcher.MatchConversationIDs(ctx, query, afterID, bulkDeleteBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to match conversations: %w", err)
+		}
+		matched = append(matched, ids...)
+		if len(ids) < bulkDeleteBatchSize {
+			return matched, nil
+		}
+		afterID = ids[len(ids)-1]
+	}
+}
+
+// bulkDeleteSelects reports whether a conversation meets every criterion of a bulk deletion;
+// the filter sees the same metadata fields as in searches
+func bulkDeleteSelects(req *models.ConversationBulkDeleteRequest, conv *models.Conversation) bool {
+	if req.CreatedAfter != nil && conv.CreatedAt.Before(*req.CreatedAfter) {
+		return false
+	}
+	if req.CreatedBefore != nil && !conv.CreatedAt.Before(*req.CreatedBefore) {
+		return false
+	}
+	if req.Expr == nil {
+		return true
+	}
+
+	fields := map[string]interface{}{}
+	if metadata := storedMetadata(conv); metadata != nil {
+		fields = metadata.Payload()
+	}
+	return req.Expr.Match(fields)
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"time"
+
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/retrieval"
+)
+
+// Search pipeline variants
+const (
+	VariantPrimary = "primary"
+	VariantCanary  = "canary"
+)
+
+// CanaryOptions routes a share of searches to a canary retrieval pipeline, so a change of
+// collection, model or ranking can be validated on live traffic
+type CanaryOptions struct {
+	// Pipeline serves canary searches; nil disables the canary
+	Pipeline *retrieval.Pipeline
+
+	// Percent of users, 0 to 100, served by the canary
+	Percent float64
+}
+
+// searchPipeline picks the pipeline serving a search and names its variant. Users are assigned by
+// a hash of their ID so each one sees consistent results; searches without a user are sampled.
+func (cs *ConversationService) searchPipeline(req *models.ConversationSearchRequest) (*retrieval.Pipeline, string) {
+	canary := cs.opts.Canary
+	if canary.Pipeline == nil || canary.Percent <= 0 {
+		return cs.pipeline, VariantPrimary
+	}
+
+	var bucket float64
+	if req.UserID == "" {
+		bucket = rand.Float64() * 100
+	} else {
+		hash := fnv.New32a()
+		hash.Write([]byte(req.UserID))
+		bucket = float64(hash.Sum32()%10000) / 100
+	}
+	if bucket < canary.Percent {
+		return canary.Pipeline, VariantCanary
+	}
+	return cs.pipeline, VariantPrimary
+}
+
+// observeSearch records a finished search in the per-variant search metrics
+func observeSearch(variant string, candidates []retrieval.Candidate, err error, startTime time.Time) {
+	if err != nil {
+		metrics.SearchRequests.WithLabelValues(variant, "error").Inc()
+		return
+	}
+	metrics.SearchRequests.WithLabelValues(variant, "ok").Inc()
+	metrics.SearchDuration.WithLabelValues(variant).Observe(time.Since(startTime).Seconds())
+	if len(candidates) > 0 {
+		metrics.SearchTopScore.WithLabelValues(variant).Observe(float64(candidates[0].Score))
+	}
+}
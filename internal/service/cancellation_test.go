@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/retrieval"
+	"refo-rag-server/internal/storage"
+)
+
+// abortBound is how long an entry point may keep running once its context is done
+const abortBound = 2 * time.Second
+
+// blockedWait is how long an entry point must keep waiting on a blocked dependency before it is
+// cancelled, showing that the dependency holds it
+const blockedWait = 200 * time.Millisecond
+
+// openSQLite opens a migrated SQLite store in a scratch directory
+func openSQLite(t *testing.T) *storage.SQLiteStore {
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "rag.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := storage.MigrateSQLite(store.GetDB()); err != nil {
+		t.Fatalf("migrate sqlite: %v", err)
+	}
+	return store
+}
+
+// lockSQLite holds the database's write lock in a transaction until the test ends
+func lockSQLite(t *testing.T, store *storage.SQLiteStore) {
+	tx, err := store.GetDB().BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	if _, err := tx.Exec(`UPDATE conversations SET importance = importance`); err != nil {
+		t.Fatalf("lock database: %v", err)
+	}
+}
+
+// blockingQdrant returns a Qdrant store whose server holds every request until the client goes away
+func blockingQdrant(t *testing.T) *storage.QdrantStore {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		server.Close()
+	})
+	qs, err := storage.NewQdrantStore(server.URL, "conversations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return qs
+}
+
+// queryEmbedder embeds queries as reindexEmbedder embeds documents
+type queryEmbedder struct {
+	reindexEmbedder
+}
+
+func (e *queryEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	return e.EmbedDocument(ctx, text)
+}
+
+// checkBlockedAborts runs call, checks that it is still waiting after blockedWait, cancels its
+// context and checks that call returns a cancellation error within abortBound
+func checkBlockedAborts(t *testing.T, call func(ctx context.Context) error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- call(ctx) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("call returned while its dependency was blocked: %v", err)
+	case <-time.After(blockedWait):
+	}
+
+	cancelled := time.Now()
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("call returned %v, want a context.Canceled error", err)
+		}
+		if elapsed := time.Since(cancelled); elapsed > abortBound {
+			t.Errorf("call took %v to return after cancellation", elapsed)
+		}
+	case <-time.After(abortBound):
+		t.Fatalf("call still running %v after cancellation", abortBound)
+	}
+}
+
+func TestSaveConversationAbortsOnCancel(t *testing.T) {
+	store := openSQLite(t)
+	cs := NewConversationService(store, nil, &reindexVectorStore{}, &reindexEmbedder{}, nil, nil, nil, ConversationOptions{})
+	lockSQLite(t, store)
+
+	checkBlockedAborts(t, func(ctx context.Context) error {
+		_, err := cs.SaveConversation(ctx, &models.ConversationSaveRequest{
+			UserID:   "user-1",
+			Messages: []models.Message{{Role: models.RoleUser, Content: "where is the lighthouse?"}},
+		})
+		return err
+	})
+}
+
+func TestSearchConversationsAbortsOnCancel(t *testing.T) {
+	store := openSQLite(t)
+	vectors := blockingQdrant(t)
+	pipeline, err := retrieval.Build(retrieval.Spec{Retrievers: []string{"vector"}, Fuser: "max", Normalizer: "none"}, retrieval.Deps{
+		Conversations: store,
+		Vectors:       vectors,
+		Embedder:      &queryEmbedder{},
+	})
+	if err != nil {
+		t.Fatalf("build pipeline: %v", err)
+	}
+	cs := NewConversationService(store, nil, vectors, &queryEmbedder{}, pipeline, nil, nil, ConversationOptions{})
+
+	checkBlockedAborts(t, func(ctx context.Context) error {
+		_, err := cs.SearchConversations(ctx, &models.ConversationSearchRequest{
+			UserID: "user-1",
+			Query:  "where is the lighthouse?",
+			Limit:  5,
+		})
+		return err
+	})
+}
+
+func TestConfirmUserDeletionAbortsOnCancel(t *testing.T) {
+	store := openSQLite(t)
+	conv := &models.Conversation{ID: "c1", UserID: "user-1", Question: "where is the lighthouse?", Metadata: "{}", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := store.SaveConversation(context.Background(), conv); err != nil {
+		t.Fatalf("SaveConversation: %v", err)
+	}
+	vectors := &reindexVectorStore{}
+	uds := NewUserDeletionService(store, vectors, vectors, NewConfirmationTokens(time.Minute, nil), NewJobLog(store))
+	requested, err := uds.RequestDeletion(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("RequestDeletion: %v", err)
+	}
+	lockSQLite(t, store)
+
+	checkBlockedAborts(t, func(ctx context.Context) error {
+		_, err := uds.ConfirmDeletion(ctx, "user-1", requested.Confirmation.Token)
+		return err
+	})
+}
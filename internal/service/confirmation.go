@@ -0,0 +1,137 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"refo-rag-server/internal/models"
+)
+
+// Confirmation errors returned to handlers
+var (
+	ErrConfirmationInvalid = errors.New("confirmation token is invalid")
+	ErrConfirmationExpired = errors.New("confirmation token has expired")
+	ErrScopeChanged        = errors.New("scope changed since the confirmation token was issued")
+)
+
+// ConfirmationTokens issues tokens that bind a bulk deletion to the scope an operator reviewed. A
+// token only executes the operation and target it was issued for, within its TTL, and only while
+// the scope is unchanged. Tokens are signed rather than stored, so any replica holding the same key
+// redeems a token another issued. Executing the deletion changes its scope, so a replayed token
+// fails with ErrScopeChanged instead of deleting again
+type ConfirmationTokens struct {
+	ttl time.Duration
+	key []byte
+}
+
+// confirmationClaims is what a token binds; Scope is the fingerprint of the reviewed scope
+type confirmationClaims struct {
+	Operation string `json:"op"`
+	Target    string `json:"target"`
+	Scope     string `json:"scope"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// NewConfirmationTokens creates tokens that expire after ttl, signed with key. Replicas must share
+// the key; an empty key is generated per process, so only the replica that issued a token
+// redeems it
+func NewConfirmationTokens(ttl time.Duration, key []byte) *ConfirmationTokens {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	return &ConfirmationTokens{
+		ttl: ttl,
+		key: key,
+	}
+}
+
+// Issue creates a token for an operation on a target with the given scope
+func (ct *ConfirmationTokens) Issue(operation string, target string, scope interface{}) (*models.Confirmation, error) {
+	fingerprint, err := scopeFingerprint(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// Whole seconds, so the expiry returned is the one the token carries
+	expiresAt := time.Now().Add(ct.ttl).Truncate(time.Second)
+	claims, err := json.Marshal(confirmationClaims{
+		Operation: operation,
+		Target:    target,
+		Scope:     fingerprint,
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode confirmation token: %w", err)
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(claims)
+	token := payload + "." + base64.RawURLEncoding.EncodeToString(ct.sign(payload))
+
+	return &models.Confirmation{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// Redeem accepts a token if it was issued for this operation, target and scope and hasn't expired
+func (ct *ConfirmationTokens) Redeem(token string, operation string, target string, scope interface{}) error {
+	fingerprint, err := scopeFingerprint(scope)
+	if err != nil {
+		return err
+	}
+
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrConfirmationInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, ct.sign(payload)) {
+		return ErrConfirmationInvalid
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return ErrConfirmationInvalid
+	}
+	var claims confirmationClaims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return ErrConfirmationInvalid
+	}
+
+	if claims.Operation != operation || claims.Target != target {
+		return ErrConfirmationInvalid
+	}
+	if time.Now().After(time.Unix(claims.ExpiresAt, 0)) {
+		return ErrConfirmationExpired
+	}
+	if claims.Scope != fingerprint {
+		return ErrScopeChanged
+	}
+
+	return nil
+}
+
+// sign returns the HMAC of a token's encoded claims
+func (ct *ConfirmationTokens) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, ct.key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// scopeFingerprint hashes a scope so a token can be matched against a recomputed one
+func scopeFingerprint(scope interface{}) (string, error) {
+	data, err := json.Marshal(scope)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode confirmation scope: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
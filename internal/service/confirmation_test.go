@@ -0,0 +1,80 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"refo-rag-server/internal/models"
+)
+
+var confirmationKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestConfirmationRedeemedByAnotherReplica(t *testing.T) {
+	issuer := NewConfirmationTokens(time.Minute, confirmationKey)
+	redeemer := NewConfirmationTokens(time.Minute, confirmationKey)
+	scope := []string{"c1", "c2"}
+
+	confirmation, err := issuer.Issue(models.JobKindBulkDelete, "u1", scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := redeemer.Redeem(confirmation.Token, models.JobKindBulkDelete, "u1", scope); err != nil {
+		t.Errorf("Redeem on another replica = %v, want nil", err)
+	}
+}
+
+func TestConfirmationRejected(t *testing.T) {
+	tokens := NewConfirmationTokens(time.Minute, confirmationKey)
+	scope := []string{"c1", "c2"}
+	confirmation, err := tokens.Issue(models.JobKindBulkDelete, "u1", scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, signature, _ := strings.Cut(confirmation.Token, ".")
+
+	// A token whose claims name another user, signed with the real signature
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"op":"`+models.JobKindBulkDelete+`","target":"u2","scope":"x","exp":9999999999}`)) + "." + signature
+
+	cases := []struct {
+		name      string
+		tokens    *ConfirmationTokens
+		token     string
+		operation string
+		target    string
+		scope     interface{}
+		want      error
+	}{
+		{"another key", NewConfirmationTokens(time.Minute, []byte("another key of at least 32 characters")), confirmation.Token, models.JobKindBulkDelete, "u1", scope, ErrConfirmationInvalid},
+		{"unkeyed process", NewConfirmationTokens(time.Minute, nil), confirmation.Token, models.JobKindBulkDelete, "u1", scope, ErrConfirmationInvalid},
+		{"forged claims", tokens, forged, models.JobKindBulkDelete, "u2", "x", ErrConfirmationInvalid},
+		{"missing signature", tokens, payload, models.JobKindBulkDelete, "u1", scope, ErrConfirmationInvalid},
+		{"garbage", tokens, "not a token", models.JobKindBulkDelete, "u1", scope, ErrConfirmationInvalid},
+		{"other operation", tokens, confirmation.Token, models.JobKindUserDelete, "u1", scope, ErrConfirmationInvalid},
+		{"other target", tokens, confirmation.Token, models.JobKindBulkDelete, "u2", scope, ErrConfirmationInvalid},
+		{"changed scope", tokens, confirmation.Token, models.JobKindBulkDelete, "u1", []string{"c1"}, ErrScopeChanged},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.tokens.Redeem(c.token, c.operation, c.target, c.scope); !errors.Is(err, c.want) {
+				t.Errorf("Redeem = %v, want %v", err, c.want)
+			}
+		})
+	}
+}
+
+func TestConfirmationExpires(t *testing.T) {
+	tokens := NewConfirmationTokens(time.Nanosecond, confirmationKey)
+	confirmation, err := tokens.Issue(models.JobKindUserDelete, "u1", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !confirmation.ExpiresAt.Before(time.Now()) {
+		time.Sleep(time.Until(confirmation.ExpiresAt) + time.Second)
+	}
+	if err := tokens.Redeem(confirmation.Token, models.JobKindUserDelete, "u1", 3); !errors.Is(err, ErrConfirmationExpired) {
+		t.Errorf("Redeem of an expired token = %v, want ErrConfirmationExpired", err)
+	}
+}
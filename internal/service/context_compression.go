@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/snippet"
+	"refo-rag-server/internal/storage"
+)
+
+// compressionBatchSize bounds the sentences embedded per call, below the provider's input limit
+const compressionBatchSize = 512
+
+// ContextCompressor shortens retrieved conversations to the sentences most similar to the query
+type ContextCompressor struct {
+	embedder storage.EmbeddingProvider
+	ratio    float64
+}
+
+// NewContextCompressor creates a compressor keeping ratio (above 0, at most 1) of each
+// conversation's sentences, embedded with the conversation collection's provider
+func NewContextCompressor(embedder storage.EmbeddingProvider, ratio float64) *ContextCompressor {
+	return &ContextCompressor{embedder: embedder, ratio: ratio}
+}
+
+// sentenceRef locates a sentence within the messages of a result
+type sentenceRef struct {
+	message int
+	index   int
+	text    string
+	score   float64
+}
+
+// Compress replaces the messages of results with their sentences most similar to the query. Every
+// conversation keeps at least one sentence; kept sentences stay in their original order and
+// messages left without any are dropped
+func (cc *ContextCompressor) Compress(ctx context.Context, query string, results []models.ConversationSearchResult) (*models.ContextCompression, error) {
+	refs := make([][]sentenceRef, len(results))
+	var texts []string
+	for i, result := range results {
+		for m, msg := range result.Messages {
+			for s, sentence := range snippet.SplitSentences(msg.Content) {
+				refs[i] = append(refs[i], sentenceRef{message: m, index: s, text: sentence})
+				texts = append(texts, sentence)
+			}
+		}
+	}
+	compression := &models.ContextCompression{SentencesTotal: len(texts), SentencesKept: len(texts)}
+	if len(texts) == 0 {
+		return compression, nil
+	}
+
+	queryVector, err := cc.embedder.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	vectors := make([][]float32, 0, len(texts))
+	for batch := range slices.Chunk(texts, compressionBatchSize) {
+		embedded, err := cc.embedder.EmbedBatch(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed sentences: %w", err)
+		}
+		if len(embedded) != len(batch) {
+			return nil, fmt.Errorf("embedded %d of %d sentences", len(embedded), len(batch))
+		}
+		vectors = append(vectors, embedded...)
+	}
+
+	compression.SentencesKept = 0
+	next := 0
+	for i := range results {
+		for j := range refs[i] {
+			refs[i][j].score = 1 - cosineDistance(queryVector, vectors[next])
+			next++
+		}
+		kept := cc.selectSentences(refs[i])
+		results[i].Messages = rebuildMessages(results[i].Messages, kept)
+		compression.SentencesKept += len(kept)
+	}
+	return compression, nil
+}
+
+// selectSentences returns the best ratio of a conversation's sentences, at least one, in their
+// original order
+func (cc *ContextCompressor) selectSentences(refs []sentenceRef) []sentenceRef {
+	if len(refs) == 0 {
+		return nil
+	}
+	keep := max(1, int(math.Ceil(float64(len(refs))*cc.ratio)))
+	if keep >= len(refs) {
+		return refs
+	}
+
+	ranked := append([]sentenceRef(nil), refs...)
+	sort.SliceStable(ranked, func(a, b int) bool {
+		return ranked[a].score > ranked[b].score
+	})
+	kept := ranked[:keep]
+	sort.Slice(kept, func(a, b int) bool {
+		if kept[a].message != kept[b].message {
+			return kept[a].message < kept[b].message
+		}
+		return kept[a].index < kept[b].index
+	})
+	return kept
+}
+
+// rebuildMessages joins the kept sentences of each message back into its content
+func rebuildMessages(messages []models.Message, kept []sentenceRef) []models.Message {
+	sentences := make(map[int][]string, len(messages))
+	for _, ref := range kept {
+		sentences[ref.message] = append(sentences[ref.message], ref.text)
+	}
+
+	rebuilt := make([]models.Message, 0, len(sentences))
+	for m, msg := range messages {
+		if len(sentences[m]) == 0 {
+			continue
+		}
+		msg.Content = strings.Join(sentences[m], " ")
+		rebuilt = append(rebuilt, msg)
+	}
+	return rebuilt
+}
@@ -2,59 +2,275 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
+	"refo-rag-server/internal/cache"
+	"refo-rag-server/internal/deadline"
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/events"
+	"refo-rag-server/internal/featureflag"
+	"refo-rag-server/internal/filter"
+	"refo-rag-server/internal/importance"
 	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/plugin"
+	"refo-rag-server/internal/retrieval"
+	"refo-rag-server/internal/shaper"
+	"refo-rag-server/internal/snippet"
 	"refo-rag-server/internal/storage"
+	"refo-rag-server/internal/tenant"
+	"refo-rag-server/internal/usage"
 )
 
+// Conversation metadata is stored under this payload key so filters can't reach system fields
+const (
+	metadataPayloadKey    = "meta"
+	metadataPayloadPrefix = metadataPayloadKey + "."
+)
+
+// contentHashPayloadKey holds the hash of the embedded text in the vector payload
+const contentHashPayloadKey = "content_hash"
+
+// ErrConversationOwner is returned when a save names a stored conversation of another user
+var ErrConversationOwner = errors.New("conversation belongs to another user")
+
+// ConversationOptions tunes conversation retrieval
+type ConversationOptions struct {
+	// EmbedText controls how messages are assembled into the embedded text
+	EmbedText EmbedTextOptions
+
+	// EmbeddingModel and EmbeddingDimension identify the vectors conversations are embedded as;
+	// stored chunk vectors are reused only when they were embedded the same way
+	EmbeddingModel     string
+	EmbeddingDimension int
+
+	// ImportanceScorer rates conversations at save time; nil assigns importance.Default
+	ImportanceScorer importance.Scorer
+
+	// Preprocessors rewrite save requests before they are stored, in order
+	Preprocessors []plugin.Preprocessor
+
+	// VectorWriteMode selects what happens when a new conversation's vector can't be written:
+	// VectorWriteOutbox (the default) or VectorWriteRollback
+	VectorWriteMode string
+
+	// Durability is the durability level of saves that don't request one; empty means
+	// models.DurabilitySafe. Durabilities other than safe need VectorWriteOutbox
+	Durability string
+
+	// SearchLog records every search for usage analytics; nil disables search logging
+	SearchLog storage.SearchLogStore
+
+	// Shadow mirrors sampled saves and searches to an alternate embedding model; nil disables it
+	Shadow *ShadowEvaluator
+
+	// Canary serves a share of searches from a second retrieval pipeline
+	Canary CanaryOptions
+
+	// Indexing reports the conversation collection's optimizer state to saves that wait for their
+	// vector to be searchable; nil skips the optimizer check
+	Indexing storage.IndexInspector
+
+	// MaxAge limits searches that don't set max_age_days to recent conversations
+	MaxAge MaxAgeDefaults
+
+	// SnippetFilter strips greetings, filler and boilerplate from the messages searches return;
+	// nil returns messages as stored
+	SnippetFilter *snippet.Filter
+
+	// Events receives a ConversationSaved event for every stored conversation; its subscribers run
+	// the side effects of a save. nil publishes nothing
+	Events events.Publisher
+
+	// QueryCache keeps the candidates of recent searches, dropping a user's on each of their saves;
+	// nil searches every time
+	QueryCache *cache.Cache[[]retrieval.Candidate]
+
+	// FusionBandit chooses the fusion weights of primary searches without overrides; nil serves
+	// them with the configured weights
+	FusionBandit *FusionBandit
+
+	// Shaper paces the embeddings of saves and queued vector jobs; saves it has no room for are
+	// queued. nil embeds every save at once
+	Shaper *shaper.Shaper
+}
+
+// MaxAgeDefaults holds the maximum conversation age, in days, of searches that don't set one
+type MaxAgeDefaults struct {
+	// Days applies to tenants without a default of their own; 0 searches all history
+	Days int
+
+	// Tenants holds per-tenant defaults
+	Tenants map[string]int
+}
+
+// For returns the default maximum age of a tenant's searches
+func (d MaxAgeDefaults) For(tenantID string) int {
+	if days, ok := d.Tenants[tenantID]; ok {
+		return days
+	}
+	return d.Days
+}
+
+// Vector write failure modes
+const (
+	// VectorWriteOutbox commits the conversation together with a queued vector write, so a queue
+	// worker writes the vector if the inline write fails
+	VectorWriteOutbox = "outbox"
+
+	// VectorWriteRollback writes the vector inside the conversation's transaction and rolls the
+	// conversation back if the write fails
+	VectorWriteRollback = "rollback"
+)
+
+// outboxDelay holds back a conversation's queued vector write while the inline write runs; the
+// queued item is deleted once the inline write succeeds
+const outboxDelay = time.Minute
+
 // ConversationService handles conversation business logic
 type ConversationService struct {
 	conversationStore storage.ConversationStore
+	sessions          *SessionService
 	vectorStore       storage.VectorStore
 	embeddingProvider storage.EmbeddingProvider
+	pipeline          *retrieval.Pipeline
+	queue             storage.QueueStore
+	featureFlags      *featureflag.Store
+	opts              ConversationOptions
 }
 
 // NewConversationService creates a new conversation service
 func NewConversationService(
 	conversationStore storage.ConversationStore,
+	sessions *SessionService,
 	vectorStore storage.VectorStore,
 	embeddingProvider storage.EmbeddingProvider,
+	pipeline *retrieval.Pipeline,
+	queue storage.QueueStore,
+	featureFlags *featureflag.Store,
+	opts ConversationOptions,
 ) *ConversationService {
 	return &ConversationService{
 		conversationStore: conversationStore,
+		sessions:          sessions,
 		vectorStore:       vectorStore,
 		embeddingProvider: embeddingProvider,
+		pipeline:          pipeline,
+		queue:             queue,
+		featureFlags:      featureFlags,
+		opts:              opts,
+	}
+}
+
+// checkOwner fails with ErrConversationOwner if a stored conversation with the ID belongs to
+// another user
+func (cs *ConversationService) checkOwner(ctx context.Context, id string, userID string) error {
+	existing, err := cs.conversationStore.GetConversation(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get conversation: %w", err)
 	}
+	if existing != nil && existing.UserID != userID {
+		return ErrConversationOwner
+	}
+	return nil
+}
+
+// featureEnabled reports whether a retrieval feature is enabled for the request's tenant
+func (cs *ConversationService) featureEnabled(ctx context.Context, flag string) bool {
+	if cs.featureFlags == nil {
+		return false
+	}
+	return cs.featureFlags.Enabled(flag, tenant.FromContext(ctx))
+}
+
+// EnabledFeatures returns the feature flags enabled for the request's tenant
+func (cs *ConversationService) EnabledFeatures(ctx context.Context) []string {
+	if cs.featureFlags == nil {
+		return []string{}
+	}
+	return cs.featureFlags.EnabledFor(tenant.FromContext(ctx))
 }
 
 // SaveConversation saves a new conversation and its embedding
 func (cs *ConversationService) SaveConversation(ctx context.Context, req *models.ConversationSaveRequest) (*models.SaveResponse, error) {
-	// Use provided conversation ID or generate a new one
+	for _, preprocessor := range cs.opts.Preprocessors {
+		if err := preprocessor.Preprocess(ctx, req); err != nil {
+			return nil, fmt.Errorf("failed to preprocess conversation: %w", err)
+		}
+	}
+	durability, err := cs.saveDurability(req)
+	if err != nil {
+		return nil, err
+	}
+	lane, err := saveLane(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use provided conversation ID or generate a new one; a provided ID may only edit the
+	// user's own conversation
 	conversationID := req.ConversationID
 	if conversationID == "" {
 		conversationID = uuid.New().String()
+	} else if err := cs.checkOwner(ctx, conversationID, req.UserID); err != nil {
+		return nil, err
 	}
 
-	// Combine messages into a single text for embedding
-	var textToEmbed string
-	for _, msg := range req.Messages {
-		textToEmbed += msg.Content + " "
+	// Imported conversations keep their original creation time
+	now := time.Now()
+	createdAt := now
+	if req.CreatedAt != nil {
+		createdAt = *req.CreatedAt
 	}
 
-	// Create embedding from the combined messages
-	embedding, err := cs.embeddingProvider.Embed(ctx, textToEmbed)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create embedding: %w", err)
+	// Link the conversation to its session, creating the session on first use
+	sessionID := ""
+	if req.Metadata != nil && req.Metadata.SessionID != "" {
+		sessionID = req.Metadata.SessionID
+		if _, err := cs.sessions.ensureOpenSession(ctx, sessionID, req.UserID, createdAt); err != nil {
+			return nil, err
+		}
 	}
 
-	// Save conversation to PostgreSQL
-	now := time.Now()
+	// Assign message IDs and timestamps the client didn't provide
+	messages := normalizeMessages(req.Messages, createdAt)
 
+	// Create embedding from the combined messages; conversations with only excluded
+	// roles are stored without a vector, as are conversations whose text the embedding
+	// provider refuses, until an admin retries them
+	textToEmbed := cs.embedText(messages)
+	var embedding []float32
+	var chunks []models.ConversationChunk
+	var unembedded *models.Unembedded
+	// Saves arriving faster than the ingestion shaper lets through are queued too
+	shaped := false
+	if textToEmbed != "" && durability != models.DurabilityQueued {
+		durability, shaped, err = cs.shapeSave(ctx, req, lane, durability)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Queued saves are embedded by the queue worker
+	if textToEmbed != "" && durability != models.DurabilityQueued {
+		// A save with a client-provided ID may edit a stored conversation, whose unchanged chunks
+		// aren't embedded again
+		var err error
+		embedding, chunks, err = cs.embedConversation(ctx, conversationID, req.UserID, messages, textToEmbed, req.ConversationID != "")
+		if unembedded = unembeddedFrom(err, now); unembedded != nil {
+			fmt.Printf("warning: embedding provider refused conversation %s (%s), stored without a vector\n", conversationID, unembedded.Reason)
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to create embedding: %w", err)
+		}
+	}
+
+	// Save conversation to PostgreSQL
 	metadataStr := "{}"
 	if req.Metadata != nil {
 		metadataBytes, err := json.Marshal(req.Metadata)
@@ -66,111 +282,577 @@ func (cs *ConversationService) SaveConversation(ctx context.Context, req *models
 
 	conversation := &models.Conversation{
 		ID:        conversationID,
-		Question:  textToEmbed,
+		UserID:    req.UserID,
+		SessionID: sessionID,
+		Question:  joinRole(messages, models.RoleUser),
+		Answer:    joinRole(messages, models.RoleAssistant),
 		Metadata:  metadataStr,
-		CreatedAt: now,
+		Messages:  messages,
+		CreatedAt: createdAt,
 		UpdatedAt: now,
-	}
 
-	if err := cs.conversationStore.SaveConversation(ctx, conversation); err != nil {
-		return nil, fmt.Errorf("failed to save conversation: %w", err)
+		Unembedded: unembedded,
 	}
+	if embedding != nil {
+		conversation.ContentHash = contentHash(textToEmbed)
+	}
+	conversation.Importance = cs.scoreImportance(ctx, conversation)
 
-	// Save embedding to Qdrant
-	metadata := map[string]interface{}{
-		"created_at": now.Unix(),
+	var vectorsCreated int
+	var jobID int64
+	switch durability {
+	case models.DurabilityQueued:
+		jobID, err = cs.queueConversation(ctx, conversation, lane)
+	case models.DurabilityFast:
+		err = cs.storeConversationFast(ctx, conversation, embedding, req.Metadata)
+	default:
+		vectorsCreated, err = cs.storeConversation(ctx, conversation, embedding, req.Metadata)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cs.saveChunks(ctx, conversationID, chunks)
+	cs.invalidateSearches(ctx, req.UserID)
+	if cs.opts.Events != nil {
+		saved := events.ConversationSavedEvent{Conversation: conversation}
+		if embedding != nil {
+			saved.EmbeddedText = textToEmbed
+			saved.Vector = embedding
+			saved.VectorPayload = vectorPayload(conversation, req.Metadata)
+		}
+		cs.opts.Events.Publish(ctx, events.ConversationSaved, saved)
 	}
 
-	if err := cs.vectorStore.SaveVector(ctx, conversationID, embedding, metadata); err != nil {
-		// Log error but continue - we've already saved to PostgreSQL
-		fmt.Printf("warning: failed to save vector to qdrant: %v\n", err)
+	consistency := models.ConsistencyEventual
+	if req.WaitForIndexing && embedding != nil {
+		if err := cs.waitSearchable(ctx, conversation); err != nil {
+			return nil, err
+		}
+		consistency = models.ConsistencySearchable
 	}
 
 	return &models.SaveResponse{
 		ConversationID:   conversationID,
-		VectorsCreated:   1,
+		VectorsCreated:   vectorsCreated,
 		MessagesStored:   len(req.Messages),
 		StoredAt:         now.UTC().Format(time.RFC3339),
 		ProcessingTimeMs: 0, // Will be set by handler
+		Unembedded:       unembedded,
+		Status:           conversation.Status,
+		Consistency:      consistency,
+		Durability:       durability,
+		JobID:            jobID,
+		Shaped:           shaped,
 	}, nil
 }
 
+// storeConversation saves a conversation and writes its vector under the configured vector write
+// mode, returning the number of vectors written. The conversation's status is set to match
+func (cs *ConversationService) storeConversation(ctx context.Context, conv *models.Conversation, embedding []float32, metadata *models.ConversationMetadata) (int, error) {
+	if embedding == nil {
+		// Nothing to write unless the embedding provider refused the text
+		conv.Status = models.ConversationStatusIndexed
+		if conv.Unembedded != nil {
+			conv.Status = models.ConversationStatusFailed
+		}
+		if err := cs.conversationStore.SaveConversation(ctx, conv); err != nil {
+			return 0, fmt.Errorf("failed to save conversation: %w", err)
+		}
+		return 0, nil
+	}
+	payload := vectorPayload(conv, metadata)
+
+	if cs.opts.VectorWriteMode == VectorWriteRollback {
+		// The conversation only commits once its vector is written
+		conv.Status = models.ConversationStatusIndexed
+		var vectorErr error
+		err := cs.conversationStore.SaveConversationThen(ctx, conv, func(ctx context.Context) error {
+			vectorErr = cs.vectorStore.SaveVector(ctx, conv.ID, embedding, payload)
+			return vectorErr
+		})
+		if vectorErr != nil {
+			return 0, fmt.Errorf("failed to save vector, conversation rolled back: %w", vectorErr)
+		}
+		if err != nil {
+			// The vector was written before the commit failed; don't leave it dangling
+			if deleteErr := cs.vectorStore.DeleteVector(context.WithoutCancel(ctx), conv.ID); deleteErr != nil {
+				fmt.Printf("warning: failed to delete vector of unsaved conversation %s: %v\n", conv.ID, deleteErr)
+				errreport.Background(ctx, "conversation_vector_cleanup", deleteErr)
+			}
+			return 0, fmt.Errorf("failed to save conversation: %w", err)
+		}
+		return 1, nil
+	}
+
+	conv.Status = models.ConversationStatusPending
+	job := models.ConversationVectorJob{ConversationID: conv.ID}
+	jobID, err := cs.conversationStore.SaveConversationWithJob(ctx, conv, models.QueueKindConversationVector, job, time.Now().Add(outboxDelay))
+	if err != nil {
+		return 0, fmt.Errorf("failed to save conversation: %w", err)
+	}
+
+	vectorsCreated, indexed := cs.writeOutboxVector(ctx, conv.ID, embedding, payload, jobID)
+	if indexed {
+		conv.Status = models.ConversationStatusIndexed
+	}
+	return vectorsCreated, nil
+}
+
+// writeOutboxVector writes the vector of a conversation committed with an outbox item, marks the
+// conversation indexed and deletes the item, which is left to retry the write if any step fails.
+// It returns the number of vectors written and whether the conversation was marked indexed
+func (cs *ConversationService) writeOutboxVector(ctx context.Context, id string, embedding []float32, payload map[string]interface{}, jobID int64) (int, bool) {
+	if err := cs.vectorStore.SaveVector(ctx, id, embedding, payload); err != nil {
+		// The conversation is committed; a queue worker retries the write from the outbox
+		fmt.Printf("warning: failed to save vector to qdrant, queued for retry: %v\n", err)
+		errreport.Background(ctx, "conversation_vector_save", err)
+		return 0, false
+	}
+	if _, err := cs.setStatus(context.WithoutCancel(ctx), id, models.ConversationStatusIndexed); err != nil {
+		// The queued item rewrites the vector and marks the conversation indexed when it comes due
+		fmt.Printf("warning: failed to mark conversation %s indexed: %v\n", id, err)
+		return 1, false
+	}
+	if cs.queue != nil {
+		if err := cs.queue.DeleteQueueItem(context.WithoutCancel(ctx), jobID); err != nil {
+			// Harmless: the worker rewrites the same vector when the item comes due
+			fmt.Printf("warning: failed to delete outbox item %d: %v\n", jobID, err)
+		}
+	}
+	return 1, true
+}
+
 // SearchConversations searches for similar conversations
 func (cs *ConversationService) SearchConversations(ctx context.Context, req *models.ConversationSearchRequest) ([]models.ConversationSearchResult, error) {
+	startTime := time.Now()
+	query := cs.pipelineQuery(ctx, req)
+	pipeline, variant := cs.searchPipeline(req)
+	cacheKey := cs.queryCacheKey(ctx, req, cs.chooseFusionArm(ctx, req, query, variant))
+	candidates, cached := cs.cachedSearch(cacheKey)
+	var err error
+	if !cached {
+		candidates, err = pipeline.Run(ctx, query)
+	}
+	observeSearch(variant, candidates, err, startTime)
+	if err != nil {
+		return nil, err
+	}
+	cs.logSearch(ctx, req, variant, candidates, startTime)
+	if !cached {
+		// A search that skipped optional stages to meet its deadline isn't served again
+		if len(deadline.Skipped(ctx)) == 0 {
+			cs.cacheSearch(cacheKey, candidates)
+		}
+		// Overridden searches aren't comparable with the shadow model's default retrieval
+		if cs.opts.Shadow != nil && req.Overrides == nil {
+			cs.opts.Shadow.Search(ctx, req, query, candidates)
+		}
+	}
+
+	// Convert to response format with scores and messages
+	responses := make([]models.ConversationSearchResult, 0, len(candidates))
+	for _, candidate := range candidates {
+		responses = append(responses, searchResult(candidate.Conversation, candidate.Score))
+	}
+	cs.filterSnippets(responses)
+
+	return responses, nil
+}
+
+// searchResult converts a found conversation to a search result with its score and messages
+func searchResult(conv *models.Conversation, score float32) models.ConversationSearchResult {
+	// Parse metadata to extract conversation_score
+	var conversationScore *int
+	if conv.Metadata != "" && conv.Metadata != "{}" {
+		var metadata models.ConversationMetadata
+		if err := json.Unmarshal([]byte(conv.Metadata), &metadata); err == nil {
+			conversationScore = metadata.ConversationScore
+		}
+	}
+
+	return models.ConversationSearchResult{
+		ConversationID:    conv.ID,
+		Score:             score,
+		ConversationScore: conversationScore,
+		Timestamp:         conv.LastMessageAt(),
+		Messages:          conversationMessages(conv),
+	}
+}
+
+// filterSnippets strips greetings, filler and boilerplate from the messages of results when a
+// snippet filter is configured
+func (cs *ConversationService) filterSnippets(results []models.ConversationSearchResult) {
+	if cs.opts.SnippetFilter == nil {
+		return
+	}
+	messages := make([][]models.Message, len(results))
+	for i := range results {
+		messages[i] = results[i].Messages
+	}
+	for i, filtered := range cs.opts.SnippetFilter.Apply(messages) {
+		results[i].Messages = filtered
+	}
+}
+
+// logSearch records a finished search; a failure to record it doesn't fail the search
+func (cs *ConversationService) logSearch(ctx context.Context, req *models.ConversationSearchRequest, variant string, candidates []retrieval.Candidate, startTime time.Time) {
+	if cs.opts.SearchLog == nil {
+		return
+	}
+
+	entry := &models.SearchLog{
+		Tenant:     tenant.FromContext(ctx),
+		UserID:     req.UserID,
+		Query:      req.Query,
+		Results:    len(candidates),
+		DurationMs: time.Since(startTime).Milliseconds(),
+		Variant:    variant,
+		CreatedAt:  startTime,
+	}
+	if len(candidates) > 0 {
+		entry.TopScore = candidates[0].Score
+	}
+	if err := cs.opts.SearchLog.LogSearch(ctx, entry); err != nil {
+		fmt.Printf("warning: failed to log search: %v\n", err)
+		errreport.Background(ctx, "search_log", err)
+	}
+}
+
+// ExplainSearch runs a conversation search and returns every pipeline stage's intermediate output
+func (cs *ConversationService) ExplainSearch(ctx context.Context, req *models.ConversationSearchRequest) (*models.RetrievalTrace, error) {
+	_, trace, err := cs.pipeline.Explain(ctx, cs.pipelineQuery(ctx, req))
+	return trace, err
+}
+
+// pipelineQuery converts a search request to a retrieval pipeline query, applying the tenant's
+// default maximum age when the request sets none
+func (cs *ConversationService) pipelineQuery(ctx context.Context, req *models.ConversationSearchRequest) *retrieval.Query {
 	// Set default limit
 	limit := req.Limit
 	if limit <= 0 || limit > 100 {
 		limit = 10
 	}
 
-	// Create embedding from the query
-	queryEmbedding, err := cs.embeddingProvider.Embed(ctx, req.Query)
+	maxAgeDays := cs.opts.MaxAge.For(tenant.FromContext(ctx))
+	if req.MaxAgeDays != nil {
+		maxAgeDays = *req.MaxAgeDays
+	}
+
+	now := time.Now()
+	query := &retrieval.Query{
+		Text:         req.Query,
+		UserID:       req.UserID,
+		Limit:        limit,
+		MinScore:     req.MinScore,
+		VectorFilter: searchFilter(req, maxAgeDays, now),
+		Now:          now,
+	}
+	if req.Overrides != nil {
+		query.Overrides = *req.Overrides
+	}
+	return query
+}
+
+// suppressedCondition matches points of suppressed memories
+var suppressedCondition = map[string]interface{}{"key": "suppressed", "match": map[string]interface{}{"value": true}}
+
+// ReindexUser replaces all of a user's conversation vectors by re-embedding their stored conversations.
+// A dry run only reports the vectors that would be deleted and the conversations that would be embedded.
+func (cs *ConversationService) ReindexUser(ctx context.Context, userID string, dryRun bool) (*models.ReindexCounts, error) {
+	// Load first so a database failure doesn't leave the user without vectors
+	conversations, err := cs.conversationStore.GetConversationsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversations: %w", err)
+	}
+
+	vectors, err := cs.vectorStore.CountUserVectors(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count conversation vectors: %w", err)
+	}
+
+	if !dryRun {
+		// Once the vectors are deleted the reindex must finish, or the user is left with a partly
+		// empty index; a cancelled request stops it only before that
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ctx = deadline.Detach(ctx)
+		if err := cs.vectorStore.DeleteUserVectors(ctx, userID); err != nil {
+			return nil, fmt.Errorf("failed to delete conversation vectors: %w", err)
+		}
+	}
+
+	counts := &models.ReindexCounts{VectorsDeleted: vectors, FailedIDs: []string{}}
+	for _, conv := range conversations {
+		textToEmbed := cs.embedText(conversationMessages(conv))
+		if textToEmbed == "" || conv.Status == models.ConversationStatusArchived {
+			counts.Skipped++
+			continue
+		}
+		counts.TextBytes += int64(len(textToEmbed))
+
+		if dryRun {
+			counts.Indexed++
+			counts.IDs = append(counts.IDs, conv.ID)
+			continue
+		}
+
+		if err := cs.reembedConversation(ctx, conv, textToEmbed); err != nil {
+			fmt.Printf("warning: failed to reindex conversation %s: %v\n", conv.ID, err)
+			counts.Failed++
+			counts.FailedIDs = append(counts.FailedIDs, conv.ID)
+			continue
+		}
+		counts.Indexed++
+	}
+
+	return counts, nil
+}
+
+// reembedConversation embeds a stored conversation's text again and replaces its vector. A
+// conversation the embedding provider refuses is marked unembedded instead, without an error
+func (cs *ConversationService) reembedConversation(ctx context.Context, conv *models.Conversation, textToEmbed string) error {
+	// Stored chunks may come from the model or text options being replaced, so none is reused
+	embedding, chunks, err := cs.embedConversation(ctx, conv.ID, conv.UserID, conversationMessages(conv), textToEmbed, false)
+	if unembedded := unembeddedFrom(err, time.Now()); unembedded != nil {
+		// Listed for an admin to fix and retry
+		if markErr := cs.markUnembedded(ctx, conv.ID, unembedded); markErr != nil {
+			fmt.Printf("warning: failed to mark conversation %s unembedded: %v\n", conv.ID, markErr)
+		}
+		return nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create query embedding: %w", err)
+		return err
 	}
+	if err := cs.saveStoredVector(ctx, conv, textToEmbed, embedding); err != nil {
+		return err
+	}
+	cs.saveChunks(ctx, conv.ID, chunks)
+	return nil
+}
 
-	// Search in Qdrant
-	searchResults, err := cs.vectorStore.SearchVectors(ctx, queryEmbedding, limit)
+// HandleVectorJob embeds a queued conversation and writes its vector; conversations deleted
+// since they were queued are skipped
+func (cs *ConversationService) HandleVectorJob(ctx context.Context, item *models.QueueItem) error {
+	var job models.ConversationVectorJob
+	if err := json.Unmarshal(item.Payload, &job); err != nil {
+		return fmt.Errorf("invalid conversation vector job: %w", err)
+	}
+
+	conv, err := cs.conversationStore.GetConversation(ctx, job.ConversationID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search vectors: %w", err)
+		return fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if conv == nil || conv.Status == models.ConversationStatusArchived {
+		return nil
+	}
+
+	textToEmbed := cs.embedText(conversationMessages(conv))
+	if textToEmbed == "" {
+		_, err := cs.setStatus(ctx, conv.ID, models.ConversationStatusIndexed)
+		return err
+	}
+
+	if err := cs.waitShaper(ctx, item.Kind); err != nil {
+		return err
+	}
+	embedding, chunks, err := cs.embedConversation(ctx, conv.ID, conv.UserID, conversationMessages(conv), textToEmbed, true)
+	if unembedded := unembeddedFrom(err, time.Now()); unembedded != nil {
+		// Retrying the same text fails the same way; leave it for an admin to fix
+		return cs.markUnembedded(ctx, conv.ID, unembedded)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create embedding: %w", err)
+	}
+	if err := cs.saveStoredVector(ctx, conv, textToEmbed, embedding); err != nil {
+		return fmt.Errorf("failed to save vector: %w", err)
+	}
+	cs.saveChunks(ctx, conv.ID, chunks)
+	return nil
+}
+
+// saveStoredVector writes the vector of a stored conversation re-embedded from text, marks it
+// indexed, records the text's hash if it differs from the one stored with the conversation and
+// clears an earlier embedding refusal. A conversation archived meanwhile loses the vector again
+func (cs *ConversationService) saveStoredVector(ctx context.Context, conv *models.Conversation, text string, embedding []float32) error {
+	previousHash := conv.ContentHash
+	conv.ContentHash = contentHash(text)
+	if err := cs.vectorStore.SaveVector(ctx, conv.ID, embedding, vectorPayload(conv, storedMetadata(conv))); err != nil {
+		return err
+	}
+	indexed, err := cs.setStatus(ctx, conv.ID, models.ConversationStatusIndexed)
+	if err != nil {
+		return err
+	}
+	if !indexed {
+		return cs.vectorStore.DeleteVector(ctx, conv.ID)
 	}
+	conv.Status = models.ConversationStatusIndexed
+	if conv.ContentHash != previousHash {
+		if err := cs.conversationStore.SetConversationContentHash(ctx, conv.ID, conv.ContentHash); err != nil {
+			return err
+		}
+	}
+	if conv.Unembedded != nil {
+		if _, err := cs.conversationStore.SetConversationUnembedded(ctx, conv.ID, nil); err != nil {
+			return err
+		}
+		conv.Unembedded = nil
+	}
+	return nil
+}
 
-	if len(searchResults) == 0 {
-		return []models.ConversationSearchResult{}, nil
+// embedDocument embeds the text of one of a user's conversations and adds the tokens spent to
+// the user's stats; failing to update the stats doesn't fail the embedding
+func (cs *ConversationService) embedDocument(ctx context.Context, userID string, text string) ([]float32, error) {
+	meterCtx, meter := usage.WithMeter(ctx)
+	embedding, err := cs.embeddingProvider.EmbedDocument(meterCtx, text)
+	if spent := meter.Usage(); spent != nil {
+		if statsErr := cs.conversationStore.AddUserTokens(ctx, userID, int64(spent.TotalTokens)); statsErr != nil {
+			fmt.Printf("warning: failed to add embedding tokens to stats of user %s: %v\n", userID, statsErr)
+			errreport.Background(ctx, "user_stats_tokens", statsErr)
+		}
 	}
+	return embedding, err
+}
+
+// contentHash returns the hex SHA-256 of embedded text; it is stored with the conversation and
+// in the vector payload so drift between them can be detected
+func contentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
 
-	// Extract conversation IDs from search results
-	conversationIDs := make([]string, 0, len(searchResults))
-	scoreMap := make(map[string]float32)
+// storedMetadata parses a stored conversation's metadata; it is nil when absent or invalid
+func storedMetadata(conv *models.Conversation) *models.ConversationMetadata {
+	if conv.Metadata == "" || conv.Metadata == "{}" {
+		return nil
+	}
+	metadata := &models.ConversationMetadata{}
+	if err := json.Unmarshal([]byte(conv.Metadata), metadata); err != nil {
+		return nil
+	}
+	return metadata
+}
 
-	for _, result := range searchResults {
-		conversationIDs = append(conversationIDs, result.ConversationID)
-		scoreMap[result.ConversationID] = result.Score
+// conversationTextBytes returns the size of a conversation's message content
+func conversationTextBytes(conv *models.Conversation) int64 {
+	var size int64
+	for _, msg := range conversationMessages(conv) {
+		size += int64(len(msg.Content))
 	}
+	return size
+}
 
-	// Get conversations from PostgreSQL
-	conversations, err := cs.conversationStore.GetConversationsByIDs(ctx, conversationIDs)
+// embedText assembles the embedded text of messages; it is empty when no message qualifies
+func (cs *ConversationService) embedText(messages []models.Message) string {
+	return cs.opts.EmbedText.Build(messages)
+}
+
+// vectorPayload builds the vector payload of a conversation
+func vectorPayload(conv *models.Conversation, metadata *models.ConversationMetadata) map[string]interface{} {
+	payload := map[string]interface{}{
+		"created_at":      conv.CreatedAt.Unix(),
+		"last_message_at": conv.LastMessageAt().Unix(),
+		"user_id":         conv.UserID,
+		"importance":      conv.Importance,
+		"suppressed":      conv.Suppression != nil,
+	}
+	if conv.SessionID != "" {
+		payload["session_id"] = conv.SessionID
+	}
+	if conv.ContentHash != "" {
+		payload[contentHashPayloadKey] = conv.ContentHash
+	}
+	if metadata != nil {
+		payload[metadataPayloadKey] = metadata.Payload()
+	}
+	return payload
+}
+
+// searchFilter builds the vector search filter from the metadata filter and, when maxAgeDays is
+// positive, a range on the created_at payload, excluding suppressed conversations; the vector
+// store scopes it to the request's user
+func searchFilter(req *models.ConversationSearchRequest, maxAgeDays int, now time.Time) map[string]interface{} {
+	searchFilter := map[string]interface{}{
+		"must_not": []interface{}{suppressedCondition},
+	}
+	var must []interface{}
+	if metadataFilter := filter.Qdrant(req.Filter, metadataPayloadPrefix); metadataFilter != nil {
+		must = append(must, metadataFilter)
+	}
+	if maxAgeDays > 0 {
+		since := now.AddDate(0, 0, -maxAgeDays).Unix()
+		must = append(must, map[string]interface{}{"key": "created_at", "range": map[string]interface{}{"gte": since}})
+	}
+	if len(must) > 0 {
+		searchFilter["must"] = must
+	}
+	return searchFilter
+}
+
+// scoreImportance rates a conversation's long-term importance, falling back to the default on failure
+func (cs *ConversationService) scoreImportance(ctx context.Context, conversation *models.Conversation) float64 {
+	if cs.opts.ImportanceScorer == nil {
+		return importance.Default
+	}
+
+	score, err := cs.opts.ImportanceScorer.Score(ctx, conversation)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get conversations: %w", err)
+		fmt.Printf("warning: failed to score importance of conversation %s: %v\n", conversation.ID, err)
+		errreport.Background(ctx, "conversation_importance", err)
 	}
+	return score
+}
 
-	// Convert to response format with scores and messages
-	var responses []models.ConversationSearchResult
-	for _, conv := range conversations {
-		// Create message array from question and answer
-		messages := []models.Message{}
-		if conv.Question != "" {
-			messages = append(messages, models.Message{
-				Role:    "user",
-				Content: conv.Question,
-			})
-		}
-		if conv.Answer != "" {
-			messages = append(messages, models.Message{
-				Role:    "assistant",
-				Content: conv.Answer,
-			})
-		}
-
-		// Parse metadata to extract conversation_score
-		var conversationScore *int
-		if conv.Metadata != "" && conv.Metadata != "{}" {
-			var metadata models.Metadata
-			if err := json.Unmarshal([]byte(conv.Metadata), &metadata); err == nil {
-				conversationScore = metadata.ConversationScore
-			}
+// normalizeMessages assigns message IDs and timestamps to messages that don't carry them
+func normalizeMessages(messages []models.Message, now time.Time) []models.Message {
+	normalized := make([]models.Message, len(messages))
+	for i, msg := range messages {
+		if msg.MessageID == "" {
+			msg.MessageID = uuid.New().String()
+		}
+		if msg.Timestamp == nil {
+			timestamp := now
+			msg.Timestamp = &timestamp
 		}
+		normalized[i] = msg
+	}
+	return normalized
+}
 
-		responses = append(responses, models.ConversationSearchResult{
-			ConversationID:    conv.ID,
-			Score:             scoreMap[conv.ID],
-			ConversationScore: conversationScore,
-			Timestamp:         conv.CreatedAt,
-			Messages:          messages,
-		})
+// joinRole concatenates the content of all messages with the given role
+func joinRole(messages []models.Message, role string) string {
+	var parts []string
+	for _, msg := range messages {
+		if msg.Role == role {
+			parts = append(parts, msg.Content)
+		}
 	}
+	return strings.Join(parts, "\n")
+}
 
-	return responses, nil
+// conversationMessages returns the stored messages, or rebuilds them from the question and
+// answer columns for conversations saved before messages were stored individually
+func conversationMessages(conv *models.Conversation) []models.Message {
+	if len(conv.Messages) > 0 {
+		return conv.Messages
+	}
+
+	messages := []models.Message{}
+	if conv.Question != "" {
+		messages = append(messages, models.Message{
+			Role:    models.RoleUser,
+			Content: conv.Question,
+		})
+	}
+	if conv.Answer != "" {
+		messages = append(messages, models.Message{
+			Role:    models.RoleAssistant,
+			Content: conv.Answer,
+		})
+	}
+	return messages
 }
 
 // GetConversation retrieves a single conversation by ID
@@ -184,12 +866,163 @@ func (cs *ConversationService) GetConversation(ctx context.Context, id string) (
 		return nil, nil
 	}
 
-	return &models.ConversationResponse{
-		ID:        conversation.ID,
-		UserID:    conversation.UserID,
-		Question:  conversation.Question,
-		Answer:    conversation.Answer,
-		Metadata:  conversation.Metadata,
-		CreatedAt: conversation.CreatedAt,
-	}, nil
+	resp := conversationResponse(conversation)
+	return &resp, nil
+}
+
+// SetPinned pins or unpins a conversation; it reports false if the conversation doesn't exist
+func (cs *ConversationService) SetPinned(ctx context.Context, id string, pinned bool) (bool, error) {
+	return cs.conversationStore.SetConversationPinned(ctx, id, pinned)
+}
+
+// SetSuppression suppresses a conversation, or restores it when suppression is nil; it reports
+// false if the conversation doesn't exist
+func (cs *ConversationService) SetSuppression(ctx context.Context, id string, suppression *models.Suppression) (bool, error) {
+	found, err := cs.conversationStore.SetConversationSuppression(ctx, id, suppression)
+	if err != nil || !found {
+		return found, err
+	}
+
+	if err := cs.vectorStore.UpdatePayload(ctx, id, map[string]interface{}{"suppressed": suppression != nil}, nil); err != nil {
+		// Log error but continue - search drops suppressed conversations after loading them
+		fmt.Printf("warning: failed to update suppression payload of conversation %s: %v\n", id, err)
+		errreport.Background(ctx, "conversation_suppression_payload", err)
+	}
+
+	return true, nil
+}
+
+// GetSuppressed retrieves a user's suppressed conversations
+func (cs *ConversationService) GetSuppressed(ctx context.Context, userID string) ([]models.ConversationResponse, error) {
+	conversations, err := cs.conversationStore.GetSuppressedConversations(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suppressed conversations: %w", err)
+	}
+
+	responses := make([]models.ConversationResponse, 0, len(conversations))
+	for _, conv := range conversations {
+		responses = append(responses, conversationResponse(conv))
+	}
+	return responses, nil
+}
+
+// GetPinned retrieves a user's pinned conversations
+func (cs *ConversationService) GetPinned(ctx context.Context, userID string) ([]models.ConversationResponse, error) {
+	conversations, err := cs.conversationStore.GetPinnedConversations(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pinned conversations: %w", err)
+	}
+
+	responses := make([]models.ConversationResponse, 0, len(conversations))
+	for _, conv := range conversations {
+		responses = append(responses, conversationResponse(conv))
+	}
+	return responses, nil
+}
+
+// conversationResponse converts a stored conversation to its API representation
+func conversationResponse(conv *models.Conversation) models.ConversationResponse {
+	return models.ConversationResponse{
+		ID:          conv.ID,
+		UserID:      conv.UserID,
+		SessionID:   conv.SessionID,
+		Question:    conv.Question,
+		Answer:      conv.Answer,
+		Metadata:    conv.Metadata,
+		Messages:    conversationMessages(conv),
+		Importance:  conv.Importance,
+		Pinned:      conv.Pinned,
+		Suppression: conv.Suppression,
+		Unembedded:  conv.Unembedded,
+		Status:      conv.Status,
+		CreatedAt:   conv.CreatedAt,
+	}
+}
+
+// integrityBatchSize is the number of conversations verified per page
+const integrityBatchSize = 200
+
+// maxIntegrityIssues caps the issues listed in an integrity report; all are still counted
+const maxIntegrityIssues = 1000
+
+// VerifyIntegrity re-hashes the text every stored conversation embeds, optionally of one user,
+// and compares it with the hash recorded at embed time and the hash in the vector payload
+func (cs *ConversationService) VerifyIntegrity(ctx context.Context, userID string) (*models.IntegrityReport, error) {
+	start := time.Now()
+	report := &models.IntegrityReport{
+		UserID:      userID,
+		IssueCounts: map[string]int{},
+		Issues:      []models.IntegrityIssue{},
+	}
+
+	afterID := ""
+	for {
+		ids, err := cs.conversationStore.ListConversationIDs(ctx, userID, afterID, integrityBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		afterID = ids[len(ids)-1]
+
+		conversations, _, err := cs.conversationStore.GetConversationsByIDs(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get conversations: %w", err)
+		}
+		payloads, err := cs.vectorStore.GetPayloads(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get vector payloads: %w", err)
+		}
+
+		for _, conv := range conversations {
+			cs.verifyConversation(report, conv, payloads[conv.ID])
+		}
+	}
+
+	report.DurationMs = time.Since(start).Milliseconds()
+	return report, nil
+}
+
+// verifyConversation compares one conversation's hashes and records the outcome in report
+func (cs *ConversationService) verifyConversation(report *models.IntegrityReport, conv *models.Conversation, payload map[string]interface{}) {
+	report.Checked++
+
+	text := cs.embedText(conversationMessages(conv))
+	if text == "" {
+		report.NoText++
+		return
+	}
+
+	issue := models.IntegrityIssue{
+		ConversationID: conv.ID,
+		UserID:         conv.UserID,
+		ContentHash:    contentHash(text),
+		StoredHash:     conv.ContentHash,
+	}
+	if payload != nil {
+		issue.VectorHash, _ = payload[contentHashPayloadKey].(string)
+	}
+
+	switch {
+	case payload == nil:
+		issue.Issue = models.IntegrityMissingVector
+	case conv.ContentHash != "" && conv.ContentHash != issue.ContentHash:
+		issue.Issue = models.IntegrityContentDrift
+	case issue.VectorHash == "":
+		report.Unhashed++
+		return
+	case issue.VectorHash == issue.ContentHash:
+		report.Matched++
+		return
+	default:
+		issue.Issue = models.IntegrityVectorDrift
+	}
+
+	report.IssueCounts[issue.Issue]++
+	if len(report.Issues) < maxIntegrityIssues {
+		report.Issues = append(report.Issues, issue)
+	} else {
+		report.Truncated = true
+	}
 }
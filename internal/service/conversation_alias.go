@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+)
+
+// ErrAliasIsConversation is returned when the ID to alias is itself a stored conversation
+var ErrAliasIsConversation = errors.New("alias ID is a stored conversation")
+
+// SetAlias makes an old conversation ID, left behind by a merge or an import that remapped IDs,
+// resolve to the conversation that replaced it. It returns nil if that conversation doesn't exist
+func (cs *ConversationService) SetAlias(ctx context.Context, aliasID string, conversationID string, reason string) (*models.ConversationAlias, error) {
+	if aliasID == conversationID {
+		return nil, ErrAliasIsConversation
+	}
+
+	stored, err := cs.conversationStore.GetConversation(ctx, aliasID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if stored != nil {
+		return nil, ErrAliasIsConversation
+	}
+
+	target, err := cs.conversationStore.GetConversation(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if target == nil {
+		return nil, nil
+	}
+
+	alias := &models.ConversationAlias{
+		AliasID:        aliasID,
+		ConversationID: conversationID,
+		Reason:         reason,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := cs.conversationStore.SaveConversationAlias(ctx, alias); err != nil {
+		return nil, err
+	}
+
+	return alias, nil
+}
+
+// ResolveConversationID returns the ID of the conversation id refers to: id itself, or the
+// conversation it is an alias of, which it reports. A stored conversation takes precedence over
+// an alias with the same ID
+func (cs *ConversationService) ResolveConversationID(ctx context.Context, id string) (string, bool, error) {
+	alias, err := cs.conversationStore.GetConversationAlias(ctx, id)
+	if err != nil {
+		return "", false, err
+	}
+	if alias == nil {
+		return id, false, nil
+	}
+
+	stored, err := cs.conversationStore.GetConversation(ctx, id)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if stored != nil {
+		return id, false, nil
+	}
+
+	return alias.ConversationID, true, nil
+}
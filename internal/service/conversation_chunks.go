@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/featureflag"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// embedConversation embeds the text of a conversation's messages. With chunking enabled for the
+// tenant and a conversation store that keeps chunks, the text is embedded in chunks whose vectors
+// are pooled into the conversation's vector; when reuse is set, chunks whose content hash matches
+// one of the conversation's stored chunks embedded by the same model at the same dimension keep
+// its vector, so an edit only embeds the chunks it changed. The chunks are returned for saving
+// once the conversation is stored, nil otherwise
+func (cs *ConversationService) embedConversation(ctx context.Context, id string, userID string, messages []models.Message, text string, reuse bool) ([]float32, []models.ConversationChunk, error) {
+	chunkStore, ok := cs.conversationStore.(storage.ChunkStore)
+	if !ok || !cs.featureEnabled(ctx, featureflag.Chunking) {
+		embedding, err := cs.embedDocument(ctx, userID, text)
+		return embedding, nil, err
+	}
+
+	stored := map[string][]float32{}
+	if reuse {
+		previous, err := chunkStore.GetConversationChunks(ctx, id)
+		if err != nil {
+			// Every chunk is embedded again instead
+			fmt.Printf("warning: failed to get chunks of conversation %s: %v\n", id, err)
+		}
+		for _, chunk := range previous {
+			if cs.reusable(chunk) {
+				stored[chunk.ContentHash] = chunk.Vector
+			}
+		}
+	}
+
+	texts := cs.opts.EmbedText.Chunks(messages)
+	chunks := make([]models.ConversationChunk, len(texts))
+	for i, chunkText := range texts {
+		hash := contentHash(chunkText)
+		vector, found := stored[hash]
+		if found {
+			metrics.ConversationChunks.WithLabelValues("reused").Inc()
+		} else {
+			var err error
+			if vector, err = cs.embedDocument(ctx, userID, chunkText); err != nil {
+				return nil, nil, err
+			}
+			metrics.ConversationChunks.WithLabelValues("embedded").Inc()
+		}
+		chunks[i] = models.ConversationChunk{
+			Position:    i,
+			ContentHash: hash,
+			Model:       cs.opts.EmbeddingModel,
+			Dimension:   len(vector),
+			Vector:      vector,
+		}
+	}
+	return poolChunks(chunks), chunks, nil
+}
+
+// reusable reports whether a stored chunk's vector was embedded by the current model at the
+// current dimension; chunks stored before either was recorded are embedded again
+func (cs *ConversationService) reusable(chunk models.ConversationChunk) bool {
+	return chunk.Model != "" && chunk.Model == cs.opts.EmbeddingModel &&
+		chunk.Dimension == cs.opts.EmbeddingDimension && len(chunk.Vector) == chunk.Dimension
+}
+
+// saveChunks stores the chunks a stored conversation was embedded from; a failure only costs the
+// next edit re-embedding every chunk, so it doesn't fail the save
+func (cs *ConversationService) saveChunks(ctx context.Context, id string, chunks []models.ConversationChunk) {
+	chunkStore, ok := cs.conversationStore.(storage.ChunkStore)
+	if !ok || chunks == nil {
+		return
+	}
+	if err := chunkStore.ReplaceConversationChunks(ctx, id, chunks); err != nil {
+		fmt.Printf("warning: failed to save chunks of conversation %s: %v\n", id, err)
+		errreport.Background(ctx, "conversation_chunks_save", err)
+	}
+}
+
+// poolChunks averages the vectors of chunks into a unit-length conversation vector
+func poolChunks(chunks []models.ConversationChunk) []float32 {
+	if len(chunks) == 0 {
+		return nil
+	}
+	pooled := make([]float32, len(chunks[0].Vector))
+	for _, chunk := range chunks {
+		for i := range min(len(pooled), len(chunk.Vector)) {
+			pooled[i] += chunk.Vector[i]
+		}
+	}
+	storage.NormalizeL2(pooled)
+	return pooled
+}
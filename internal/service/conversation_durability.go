@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+)
+
+// ErrInvalidDurability is returned for a save durability that is unknown or can't be honored
+var ErrInvalidDurability = errors.New("invalid save durability")
+
+// saveDurability resolves the durability level a save request is acknowledged at
+func (cs *ConversationService) saveDurability(req *models.ConversationSaveRequest) (string, error) {
+	durability := req.Durability
+	if durability == "" {
+		durability = cs.opts.Durability
+	}
+	if durability == "" {
+		durability = models.DurabilitySafe
+	}
+	if !models.IsValidDurability(durability) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidDurability, durability)
+	}
+	if durability == models.DurabilitySafe {
+		return durability, nil
+	}
+	// Without an outbox item nothing would write the vector once the request has been answered
+	if cs.opts.VectorWriteMode == VectorWriteRollback {
+		return "", fmt.Errorf("%w: %s needs the %s vector write mode", ErrInvalidDurability, durability, VectorWriteOutbox)
+	}
+	if req.WaitForIndexing {
+		return "", fmt.Errorf("%w: wait_for_indexing needs %s durability", ErrInvalidDurability, models.DurabilitySafe)
+	}
+	return durability, nil
+}
+
+// storeConversationFast saves a conversation with its outbox item and writes the vector in the
+// background, so the save is acknowledged as soon as the conversation is committed. A write that
+// fails or is cut short by a shutdown is retried from the outbox
+func (cs *ConversationService) storeConversationFast(ctx context.Context, conv *models.Conversation, embedding []float32, metadata *models.ConversationMetadata) error {
+	if embedding == nil {
+		_, err := cs.storeConversation(ctx, conv, embedding, metadata)
+		return err
+	}
+
+	conv.Status = models.ConversationStatusPending
+	job := models.ConversationVectorJob{ConversationID: conv.ID}
+	jobID, err := cs.conversationStore.SaveConversationWithJob(ctx, conv, models.QueueKindConversationVector, job, time.Now().Add(outboxDelay))
+	if err != nil {
+		return fmt.Errorf("failed to save conversation: %w", err)
+	}
+
+	// The payload is built now: conv belongs to the response once this returns
+	payload := vectorPayload(conv, metadata)
+	go cs.writeOutboxVector(context.WithoutCancel(ctx), conv.ID, embedding, payload, jobID)
+	return nil
+}
+
+// queueConversation saves a conversation that hasn't been embedded together with a work queue
+// item of its lane, due at once, that embeds and indexes it, returning the item's ID
+func (cs *ConversationService) queueConversation(ctx context.Context, conv *models.Conversation, lane string) (int64, error) {
+	conv.Status = models.ConversationStatusPending
+	job := models.ConversationVectorJob{ConversationID: conv.ID}
+	jobID, err := cs.conversationStore.SaveConversationWithJob(ctx, conv, vectorQueueKind(lane), job, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to save conversation: %w", err)
+	}
+	return jobID, nil
+}
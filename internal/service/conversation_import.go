@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"refo-rag-server/internal/ingest"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/usage"
+)
+
+// Conversation import progress reporting
+const (
+	conversationImportReportEvery = 50
+	maxImportFailedIDs            = 100
+)
+
+// ErrConversationImportRunning is returned when a conversation import is already in progress
+var ErrConversationImportRunning = errors.New("a conversation import is already running")
+
+// ConversationImportService saves the conversations read from an assistant platform's export,
+// so a new deployment starts with its users' history. Conversation IDs are derived from the
+// platform's user and session IDs, so importing an export again updates what it imported before
+type ConversationImportService struct {
+	conversations *ConversationService
+	jobs          *JobLog
+
+	// running is set while an import runs
+	running atomic.Bool
+}
+
+// NewConversationImportService creates a new conversation import service
+func NewConversationImportService(conversations *ConversationService, jobs *JobLog) *ConversationImportService {
+	return &ConversationImportService{
+		conversations: conversations,
+		jobs:          jobs,
+	}
+}
+
+// Start saves the parsed export's conversations in the background and returns the job ID
+func (cis *ConversationImportService) Start(ctx context.Context, format string, export *ingest.BulkResult) (string, error) {
+	if !cis.running.CompareAndSwap(false, true) {
+		return "", ErrConversationImportRunning
+	}
+
+	progress := &models.ConversationImportProgress{
+		Format:        format,
+		Records:       export.Records,
+		Skipped:       export.Skipped,
+		Users:         export.Users,
+		Sessions:      export.Sessions,
+		Conversations: len(export.Requests),
+		FailedIDs:     []string{},
+	}
+	jobID, err := cis.jobs.StartTracked(ctx, models.JobKindConversationImport, format, func(ctx context.Context, tracker *JobProgress) (interface{}, error) {
+		defer cis.running.Store(false)
+		return cis.run(ctx, export.Requests, progress, tracker)
+	})
+	if err != nil {
+		cis.running.Store(false)
+		return "", err
+	}
+	return jobID, nil
+}
+
+// run saves the conversations in order, reporting progress periodically
+func (cis *ConversationImportService) run(ctx context.Context, requests []*models.ConversationSaveRequest, progress *models.ConversationImportProgress, tracker *JobProgress) (*models.ConversationImportProgress, error) {
+	startTime := time.Now()
+
+	for i, req := range requests {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+
+		// Imported history mustn't hold up live saves
+		if req.Lane == "" {
+			req.Lane = models.LaneBackfill
+		}
+		_, err := cis.conversations.SaveConversation(ctx, req)
+		var budgetErr *usage.BudgetError
+		if errors.As(err, &budgetErr) {
+			// Every further conversation would fail too; importing the export again once the budget resets finishes it
+			progress.DurationMs = time.Since(startTime).Milliseconds()
+			tracker.Report(ctx, progress)
+			return progress, err
+		}
+		if err != nil {
+			fmt.Printf("warning: failed to import conversation %s of user %s: %v\n", req.ConversationID, req.UserID, err)
+			progress.Failed++
+			if len(progress.FailedIDs) < maxImportFailedIDs {
+				progress.FailedIDs = append(progress.FailedIDs, req.ConversationID)
+			}
+		} else {
+			progress.Imported++
+		}
+
+		if (i+1)%conversationImportReportEvery == 0 {
+			progress.DurationMs = time.Since(startTime).Milliseconds()
+			tracker.Report(ctx, progress)
+		}
+	}
+
+	progress.DurationMs = time.Since(startTime).Milliseconds()
+	return progress, nil
+}
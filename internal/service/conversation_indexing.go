@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+)
+
+// ErrNotSearchable is returned by saves waiting for indexing when the conversation was stored but
+// its vector isn't searchable in time; it becomes searchable later without another save
+var ErrNotSearchable = errors.New("conversation is stored but not searchable yet")
+
+// Bounds of a save's wait for its vector to become searchable
+const (
+	indexingWait = 10 * time.Second
+	indexingPoll = 100 * time.Millisecond
+)
+
+// waitSearchable waits until a saved conversation's vector can be read back from the vector store
+// and the collection's optimizer is healthy, so a search right after the save finds it
+func (cs *ConversationService) waitSearchable(ctx context.Context, conv *models.Conversation) error {
+	if conv.Status != models.ConversationStatusIndexed {
+		return fmt.Errorf("%w: its vector write is queued", ErrNotSearchable)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, indexingWait)
+	defer cancel()
+
+	for {
+		reason, err := cs.searchable(ctx, conv.ID)
+		if err == nil && reason == "" {
+			return nil
+		}
+		if err != nil {
+			reason = err.Error()
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s", ErrNotSearchable, reason)
+		case <-time.After(indexingPoll):
+		}
+	}
+}
+
+// searchable returns why a conversation's vector isn't searchable yet, or "" once it is
+func (cs *ConversationService) searchable(ctx context.Context, id string) (string, error) {
+	payloads, err := cs.vectorStore.GetPayloads(ctx, []string{id})
+	if err != nil {
+		return "", fmt.Errorf("failed to read back vector: %w", err)
+	}
+	if _, ok := payloads[id]; !ok {
+		return "vector not visible yet", nil
+	}
+
+	if cs.opts.Indexing == nil {
+		return "", nil
+	}
+	info, err := cs.opts.Indexing.GetIndexInfo(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get index info: %w", err)
+	}
+	if !info.OptimizerOK {
+		return "collection optimizer failed: " + info.OptimizerError, nil
+	}
+	if info.Status == "red" {
+		return "collection status is red", nil
+	}
+	return "", nil
+}
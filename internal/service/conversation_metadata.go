@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"refo-rag-server/internal/models"
+)
+
+// UpdateMetadata replaces a conversation's metadata and the copy in its vector payload, without
+// embedding the conversation again. The session a conversation belongs to can't be changed, so
+// metadata.SessionID is ignored. It returns nil if the conversation doesn't exist. A failed
+// payload update leaves the stored metadata changed; repeating the update applies it to the
+// vector
+func (cs *ConversationService) UpdateMetadata(ctx context.Context, id string, metadata *models.ConversationMetadata) (*models.ConversationResponse, error) {
+	conv, err := cs.conversationStore.GetConversation(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if conv == nil {
+		return nil, nil
+	}
+
+	metadata.SessionID = conv.SessionID
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	found, err := cs.conversationStore.SetConversationMetadata(ctx, id, string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update metadata: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	conv.Metadata = string(data)
+
+	// Only indexed conversations have a vector; a queued write reads the new metadata
+	if conv.Status == models.ConversationStatusIndexed {
+		var set map[string]interface{}
+		var unset []string
+		if payload := metadata.Payload(); len(payload) > 0 {
+			set = map[string]interface{}{metadataPayloadKey: payload}
+		} else {
+			unset = []string{metadataPayloadKey}
+		}
+		if err := cs.vectorStore.UpdatePayload(ctx, id, set, unset); err != nil {
+			return nil, fmt.Errorf("failed to update vector payload: %w", err)
+		}
+	}
+
+	resp := conversationResponse(conv)
+	return &resp, nil
+}
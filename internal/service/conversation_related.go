@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// ErrInvalidExamples is returned by recommendations when an example conversation doesn't exist,
+// belongs to another user or has no vector
+var ErrInvalidExamples = errors.New("example conversations must be the user's indexed conversations")
+
+// defaultRecommendLimit is the number of recommendations returned when the request sets no limit
+const defaultRecommendLimit = 10
+
+// RelatedConversations finds up to limit of the user's other conversations most similar to a
+// conversation, comparing with its stored vector so nothing is embedded. Suppressed conversations
+// are left out; a conversation without a vector has none. It returns nil if the conversation
+// doesn't exist
+func (cs *ConversationService) RelatedConversations(ctx context.Context, id string, limit int) (*models.RelatedConversationsResponse, error) {
+	conv, err := cs.conversationStore.GetConversation(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if conv == nil {
+		return nil, nil
+	}
+
+	resp := &models.RelatedConversationsResponse{ConversationID: id, UserID: conv.UserID, Related: []models.ConversationSearchResult{}}
+	if conv.Status != models.ConversationStatusIndexed {
+		return resp, nil
+	}
+
+	related, err := cs.recommend(ctx, conv.UserID, []string{id}, nil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find related conversations: %w", err)
+	}
+	resp.Related = related
+	return resp, nil
+}
+
+// Recommend finds the user's conversations most like the liked example conversations and least
+// like the disliked ones, comparing their stored vectors so nothing is embedded. The examples and
+// suppressed conversations are left out. It returns ErrInvalidExamples if an example isn't one of
+// the user's indexed conversations
+func (cs *ConversationService) Recommend(ctx context.Context, req *models.RecommendRequest) (*models.RecommendResponse, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultRecommendLimit
+	}
+
+	examples := append(append([]string{}, req.Liked...), req.Disliked...)
+	found, missing, err := cs.conversationStore.GetConversationsByIDs(ctx, examples)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get example conversations: %w", err)
+	}
+	invalid := missing
+	for _, conv := range found {
+		if conv.UserID != req.UserID || conv.Status != models.ConversationStatusIndexed {
+			invalid = append(invalid, conv.ID)
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidExamples, strings.Join(invalid, ", "))
+	}
+
+	recommendations, err := cs.recommend(ctx, req.UserID, req.Liked, req.Disliked, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recommend conversations: %w", err)
+	}
+	return &models.RecommendResponse{UserID: req.UserID, Recommendations: recommendations}, nil
+}
+
+// recommend finds up to limit of the user's unsuppressed conversations recommended from the
+// positive and negative examples, with their messages
+func (cs *ConversationService) recommend(ctx context.Context, userID string, positive []string, negative []string, limit int) ([]models.ConversationSearchResult, error) {
+	results := []models.ConversationSearchResult{}
+	hits, err := cs.vectorStore.RecommendVectors(ctx, positive, negative, storage.SearchOptions{
+		Limit:  limit,
+		Filter: map[string]interface{}{"must_not": []interface{}{suppressedCondition}},
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 {
+		return results, nil
+	}
+
+	ids := make([]string, 0, len(hits))
+	scores := make(map[string]float32, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ConversationID)
+		scores[hit.ConversationID] = hit.Score
+	}
+
+	// A vector can briefly outlive its conversation, which is then missing here, and a suppression
+	// may not have reached the payload yet; both are dropped as searches drop them
+	convs, _, err := cs.conversationStore.GetConversationsByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recommended conversations: %w", err)
+	}
+	for _, conv := range convs {
+		if conv.Suppression == nil {
+			results = append(results, searchResult(conv, scores[conv.ID]))
+		}
+	}
+	cs.filterSnippets(results)
+	return results, nil
+}
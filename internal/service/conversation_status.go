@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+)
+
+// setStatus moves a conversation to status if the status can be reached from its current one,
+// or it is already there; it reports false if the conversation doesn't exist or can't move
+func (cs *ConversationService) setStatus(ctx context.Context, id string, status string) (bool, error) {
+	from := append(models.ConversationStatusesTo(status), status)
+	moved, err := cs.conversationStore.SetConversationStatus(ctx, id, status, from)
+	if err != nil {
+		return false, fmt.Errorf("failed to set conversation status: %w", err)
+	}
+	return moved, nil
+}
+
+// markUnembedded records that the embedding provider refused a stored conversation's text and
+// marks it failed
+func (cs *ConversationService) markUnembedded(ctx context.Context, id string, unembedded *models.Unembedded) error {
+	if _, err := cs.conversationStore.SetConversationUnembedded(ctx, id, unembedded); err != nil {
+		return fmt.Errorf("failed to mark conversation unembedded: %w", err)
+	}
+	_, err := cs.setStatus(ctx, id, models.ConversationStatusFailed)
+	return err
+}
+
+// HandleDeadVectorJob marks the conversation of a vector job that failed on every attempt as
+// failed; retrying the dead letter marks it indexed once the vector is written
+func (cs *ConversationService) HandleDeadVectorJob(ctx context.Context, item *models.QueueItem) error {
+	var job models.ConversationVectorJob
+	if err := json.Unmarshal(item.Payload, &job); err != nil {
+		return fmt.Errorf("invalid conversation vector job: %w", err)
+	}
+	_, err := cs.setStatus(ctx, job.ConversationID, models.ConversationStatusFailed)
+	return err
+}
+
+// ListConversations retrieves a page of a user's conversations, newest first, optionally in one
+// status
+func (cs *ConversationService) ListConversations(ctx context.Context, userID string, status string, limit int, offset int) (*models.ConversationListResponse, error) {
+	conversations, total, err := cs.conversationStore.ListUserConversations(ctx, userID, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+
+	response := &models.ConversationListResponse{
+		Conversations: make([]models.ConversationResponse, 0, len(conversations)),
+		Total:         total,
+		UserID:        userID,
+		Status:        status,
+		Limit:         limit,
+		Offset:        offset,
+	}
+	for _, conv := range conversations {
+		response.Conversations = append(response.Conversations, conversationResponse(conv))
+	}
+	return response, nil
+}
+
+// UserStats retrieves a user's conversation aggregates; a user without conversations has zero stats
+func (cs *ConversationService) UserStats(ctx context.Context, userID string) (*models.UserStats, error) {
+	stats, err := cs.conversationStore.GetUserStats(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user stats: %w", err)
+	}
+	return stats, nil
+}
+
+// SetArchived archives a conversation, deleting its vector so searches no longer find it, or
+// unarchives it by embedding it again. It returns nil if the conversation doesn't exist. An
+// unarchived conversation whose text the embedding provider refuses is marked failed; one that
+// can't be embedded for another reason stays archived
+func (cs *ConversationService) SetArchived(ctx context.Context, id string, archived bool) (*models.ConversationStatusResponse, error) {
+	if archived {
+		return cs.archive(ctx, id)
+	}
+
+	conv, err := cs.conversationStore.GetConversation(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if conv == nil {
+		return nil, nil
+	}
+	if conv.Status != models.ConversationStatusArchived {
+		return &models.ConversationStatusResponse{ID: id, Status: conv.Status}, nil
+	}
+
+	// Pending while it is embedded, so a concurrent archive wins over the vector written here
+	if _, err := cs.setStatus(ctx, id, models.ConversationStatusPending); err != nil {
+		return nil, err
+	}
+	status, err := cs.reindexUnarchived(ctx, conv)
+	if err != nil {
+		if _, restoreErr := cs.conversationStore.SetConversationStatus(context.WithoutCancel(ctx), id, models.ConversationStatusArchived, []string{models.ConversationStatusPending}); restoreErr != nil {
+			fmt.Printf("warning: failed to restore archived status of conversation %s: %v\n", id, restoreErr)
+		}
+		return nil, err
+	}
+	return &models.ConversationStatusResponse{ID: id, Status: status}, nil
+}
+
+// archive marks a conversation archived and deletes its vector
+func (cs *ConversationService) archive(ctx context.Context, id string) (*models.ConversationStatusResponse, error) {
+	found, err := cs.setStatus(ctx, id, models.ConversationStatusArchived)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+	if err := cs.vectorStore.DeleteVector(ctx, id); err != nil {
+		// Archiving again retries the deletion
+		return nil, fmt.Errorf("failed to delete vector: %w", err)
+	}
+	return &models.ConversationStatusResponse{ID: id, Status: models.ConversationStatusArchived}, nil
+}
+
+// reindexUnarchived embeds an unarchived conversation and writes its vector, returning its new
+// status
+func (cs *ConversationService) reindexUnarchived(ctx context.Context, conv *models.Conversation) (string, error) {
+	textToEmbed := cs.embedText(conversationMessages(conv))
+	if textToEmbed == "" {
+		if _, err := cs.setStatus(ctx, conv.ID, models.ConversationStatusIndexed); err != nil {
+			return "", err
+		}
+		return models.ConversationStatusIndexed, nil
+	}
+
+	embedding, chunks, err := cs.embedConversation(ctx, conv.ID, conv.UserID, conversationMessages(conv), textToEmbed, true)
+	if unembedded := unembeddedFrom(err, time.Now()); unembedded != nil {
+		if err := cs.markUnembedded(ctx, conv.ID, unembedded); err != nil {
+			return "", err
+		}
+		return models.ConversationStatusFailed, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create embedding: %w", err)
+	}
+	if err := cs.saveStoredVector(ctx, conv, textToEmbed, embedding); err != nil {
+		return "", fmt.Errorf("failed to save vector: %w", err)
+	}
+	cs.saveChunks(ctx, conv.ID, chunks)
+	return conv.Status, nil
+}
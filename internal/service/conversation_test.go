@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"refo-rag-server/internal/deadline"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// reindexConversationStore serves a user's conversations and accepts the writes a reindex makes
+type reindexConversationStore struct {
+	storage.ConversationStore
+	conversations []*models.Conversation
+}
+
+func (s *reindexConversationStore) GetConversationsByUser(ctx context.Context, userID string) ([]*models.Conversation, error) {
+	return s.conversations, nil
+}
+
+func (s *reindexConversationStore) SetConversationStatus(ctx context.Context, id string, status string, from []string) (bool, error) {
+	return true, ctx.Err()
+}
+
+func (s *reindexConversationStore) SetConversationContentHash(ctx context.Context, id string, hash string) error {
+	return ctx.Err()
+}
+
+// reindexVectorStore records the vectors a reindex deletes and writes; onDelete runs when the
+// user's vectors are deleted
+type reindexVectorStore struct {
+	storage.VectorStore
+	onDelete func()
+
+	mu      sync.Mutex
+	deleted bool
+	saved   []string
+}
+
+func (s *reindexVectorStore) CountUserVectors(ctx context.Context, userID string) (int64, error) {
+	return 3, nil
+}
+
+func (s *reindexVectorStore) DeleteUserVectors(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	s.deleted = true
+	s.mu.Unlock()
+	if s.onDelete != nil {
+		s.onDelete()
+	}
+	return ctx.Err()
+}
+
+func (s *reindexVectorStore) SaveVector(ctx context.Context, conversationID string, vector []float32, metadata map[string]interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved = append(s.saved, conversationID)
+	return nil
+}
+
+// reindexEmbedder embeds any text as a fixed vector, failing like a real provider once its context ends
+type reindexEmbedder struct {
+	storage.EmbeddingProvider
+}
+
+func (e *reindexEmbedder) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return []float32{1, 0, 0}, nil
+}
+
+func newReindexTestService(vectors *reindexVectorStore) *ConversationService {
+	store := &reindexConversationStore{}
+	for _, id := range []string{"c1", "c2", "c3"} {
+		store.conversations = append(store.conversations, &models.Conversation{
+			ID:        id,
+			UserID:    "user-1",
+			Question:  "question " + id,
+			Answer:    "answer " + id,
+			Status:    models.ConversationStatusIndexed,
+			CreatedAt: time.Now(),
+		})
+	}
+	return NewConversationService(store, nil, vectors, &reindexEmbedder{}, nil, nil, nil, ConversationOptions{})
+}
+
+func TestReindexUserCancelledBeforeDelete(t *testing.T) {
+	vectors := &reindexVectorStore{}
+	cs := newReindexTestService(vectors)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := cs.ReindexUser(ctx, "user-1", false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ReindexUser error = %v, want context.Canceled", err)
+	}
+	if vectors.deleted {
+		t.Error("a cancelled reindex deleted the user's vectors")
+	}
+}
+
+func TestReindexUserFinishesOnceVectorsAreDeleted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx, cancelDeadline := deadline.With(ctx, time.Now().Add(time.Hour))
+	defer cancelDeadline()
+
+	// The client disconnects right after the vectors are deleted
+	vectors := &reindexVectorStore{onDelete: cancel}
+	cs := newReindexTestService(vectors)
+
+	counts, err := cs.ReindexUser(ctx, "user-1", false)
+	if err != nil {
+		t.Fatalf("ReindexUser error = %v", err)
+	}
+	if counts.Indexed != 3 || counts.Failed != 0 {
+		t.Errorf("indexed %d and failed %d conversations, want 3 and 0", counts.Indexed, counts.Failed)
+	}
+	if len(vectors.saved) != 3 {
+		t.Errorf("saved vectors of %v, want all 3 conversations", vectors.saved)
+	}
+}
+
+// ownedConversationStore holds one stored conversation
+type ownedConversationStore struct {
+	storage.ConversationStore
+	stored *models.Conversation
+}
+
+func (s *ownedConversationStore) GetConversation(ctx context.Context, id string) (*models.Conversation, error) {
+	if s.stored != nil && s.stored.ID == id {
+		return s.stored, nil
+	}
+	return nil, nil
+}
+
+func TestSaveConversationRejectsAnotherUsersConversation(t *testing.T) {
+	store := &ownedConversationStore{stored: &models.Conversation{ID: "c1", UserID: "user-2"}}
+	cs := NewConversationService(store, nil, nil, nil, nil, nil, nil, ConversationOptions{})
+
+	_, err := cs.SaveConversation(context.Background(), &models.ConversationSaveRequest{
+		ConversationID: "c1",
+		UserID:         "user-1",
+		Messages:       []models.Message{{Role: models.RoleUser, Content: "hello"}},
+	})
+	if !errors.Is(err, ErrConversationOwner) {
+		t.Fatalf("SaveConversation error = %v, want ErrConversationOwner", err)
+	}
+}
+
+func TestStoredChunksReusedOnlyForTheSameModelAndDimension(t *testing.T) {
+	cs := NewConversationService(nil, nil, nil, nil, nil, nil, nil, ConversationOptions{
+		EmbeddingModel:     "model-a",
+		EmbeddingDimension: 3,
+	})
+	vector := []float32{1, 0, 0}
+
+	cases := []struct {
+		name  string
+		chunk models.ConversationChunk
+		want  bool
+	}{
+		{"same model and dimension", models.ConversationChunk{Model: "model-a", Dimension: 3, Vector: vector}, true},
+		{"another model", models.ConversationChunk{Model: "model-b", Dimension: 3, Vector: vector}, false},
+		{"another dimension", models.ConversationChunk{Model: "model-a", Dimension: 2, Vector: vector[:2]}, false},
+		{"stored before models were recorded", models.ConversationChunk{Vector: vector}, false},
+	}
+	for _, tc := range cases {
+		if got := cs.reusable(tc.chunk); got != tc.want {
+			t.Errorf("%s: reusable = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"refo-rag-server/internal/models"
+)
+
+// Timeline limits
+const (
+	MaxTimelineDays   = 31
+	MaxTimelinePerDay = 50
+)
+
+// timelineSummaryRunes is the length a timeline entry's summary is cut to
+const timelineSummaryRunes = 160
+
+// timelineDateLayout formats the days of a timeline and its page cursor
+const timelineDateLayout = "2006-01-02"
+
+// Timeline retrieves a page of a user's conversations grouped by day, newest day first, with up to
+// PerDay of each day's newest conversations and the day's total count. Days without conversations
+// are skipped, so a page spans Days days that have some, however far apart
+func (cs *ConversationService) Timeline(ctx context.Context, req *models.TimelineRequest) (*models.TimelineResponse, error) {
+	resp := &models.TimelineResponse{UserID: req.UserID, TimeZone: req.Loc.String(), Days: []models.TimelineDay{}}
+
+	cursor := req.Before
+	if cursor.IsZero() {
+		cursor = time.Now()
+	}
+	for len(resp.Days) < req.Days {
+		conversations, err := cs.conversationStore.ListUserConversationsBefore(ctx, req.UserID, cursor, req.PerDay)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list conversations: %w", err)
+		}
+		if len(conversations) == 0 {
+			return resp, nil
+		}
+
+		// The newest conversation left decides the day; those older than its midnight are on later pages
+		created := conversations[0].CreatedAt.In(req.Loc)
+		dayStart := time.Date(created.Year(), created.Month(), created.Day(), 0, 0, 0, 0, req.Loc)
+		day := models.TimelineDay{Date: dayStart.Format(timelineDateLayout), Conversations: []models.TimelineEntry{}}
+		for _, conv := range conversations {
+			if conv.CreatedAt.Before(dayStart) {
+				break
+			}
+			day.Conversations = append(day.Conversations, timelineEntry(conv))
+		}
+
+		// Only a full page can leave some of the day's conversations unlisted
+		day.Count = len(day.Conversations)
+		if day.Count == req.PerDay {
+			if day.Count, err = cs.conversationStore.CountUserConversationsBetween(ctx, req.UserID, dayStart, cursor); err != nil {
+				return nil, fmt.Errorf("failed to count conversations: %w", err)
+			}
+		}
+
+		resp.Days = append(resp.Days, day)
+		cursor = dayStart
+	}
+
+	older, err := cs.conversationStore.ListUserConversationsBefore(ctx, req.UserID, cursor, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	if len(older) > 0 {
+		resp.NextBefore = cursor.Format(timelineDateLayout)
+	}
+	return resp, nil
+}
+
+// ParseTimelineBefore parses a timeline page cursor, a date, as the midnight starting it in loc
+func ParseTimelineBefore(value string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation(timelineDateLayout, value, loc)
+}
+
+// timelineEntry summarizes a conversation by the start of its first user message
+func timelineEntry(conv *models.Conversation) models.TimelineEntry {
+	messages := conversationMessages(conv)
+	summary := ""
+	for _, msg := range messages {
+		if msg.Role == models.RoleUser {
+			summary = msg.Content
+			break
+		}
+	}
+	if summary == "" && len(messages) > 0 {
+		summary = messages[0].Content
+	}
+
+	return models.TimelineEntry{
+		ConversationID: conv.ID,
+		Summary:        truncateRunes(strings.Join(strings.Fields(summary), " "), timelineSummaryRunes),
+		MessageCount:   len(messages),
+		Status:         conv.Status,
+		Pinned:         conv.Pinned,
+		Suppressed:     conv.Suppression != nil,
+		CreatedAt:      conv.CreatedAt,
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// ErrNotUnembedded is returned when retrying the embedding of a conversation the embedding
+// provider didn't refuse
+var ErrNotUnembedded = errors.New("conversation is not awaiting an embedding retry")
+
+// unembeddedFrom returns the refusal to record for an embedding error, or nil if the embedding
+// provider didn't refuse the text itself
+func unembeddedFrom(err error, at time.Time) *models.Unembedded {
+	var rejected *storage.EmbeddingRejectedError
+	if !errors.As(err, &rejected) {
+		return nil
+	}
+	return &models.Unembedded{
+		Reason:     rejected.Reason,
+		Detail:     rejected.Detail,
+		RejectedAt: at,
+	}
+}
+
+// ListUnembedded retrieves a page of conversations stored without a vector because the embedding
+// provider refused their text, optionally of one user
+func (cs *ConversationService) ListUnembedded(ctx context.Context, userID string, limit int, offset int) (*models.UnembeddedListResponse, error) {
+	conversations, total, err := cs.conversationStore.ListUnembeddedConversations(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unembedded conversations: %w", err)
+	}
+
+	response := &models.UnembeddedListResponse{
+		Conversations: make([]models.ConversationResponse, 0, len(conversations)),
+		Total:         total,
+		UserID:        userID,
+		Limit:         limit,
+		Offset:        offset,
+	}
+	for _, conv := range conversations {
+		response.Conversations = append(response.Conversations, conversationResponse(conv))
+	}
+	return response, nil
+}
+
+// RetryEmbedding embeds a conversation the embedding provider refused and writes its vector,
+// replacing its messages first when corrected ones are given. It returns nil if the conversation
+// doesn't exist. A conversation refused again is kept without a vector with the new refusal
+// recorded, and the error matches storage.ErrEmbeddingRejected
+func (cs *ConversationService) RetryEmbedding(ctx context.Context, id string, messages []models.Message) (*models.SaveResponse, error) {
+	conv, err := cs.conversationStore.GetConversation(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if conv == nil {
+		return nil, nil
+	}
+	if conv.Unembedded == nil {
+		return nil, ErrNotUnembedded
+	}
+
+	now := time.Now()
+	if len(messages) > 0 {
+		conv.Messages = normalizeMessages(messages, conv.CreatedAt)
+		conv.Question = joinRole(conv.Messages, models.RoleUser)
+		conv.Answer = joinRole(conv.Messages, models.RoleAssistant)
+	}
+	conv.UpdatedAt = now
+
+	textToEmbed := cs.embedText(conversationMessages(conv))
+	var embedding []float32
+	var chunks []models.ConversationChunk
+	if textToEmbed != "" {
+		embedding, chunks, err = cs.embedConversation(ctx, conv.ID, conv.UserID, conversationMessages(conv), textToEmbed, true)
+		if unembedded := unembeddedFrom(err, now); unembedded != nil {
+			// Keep the corrected messages so the next fix starts from them
+			conv.Unembedded = unembedded
+			conv.Status = models.ConversationStatusFailed
+			if saveErr := cs.conversationStore.SaveConversation(ctx, conv); saveErr != nil {
+				return nil, fmt.Errorf("failed to save conversation: %w", saveErr)
+			}
+			return nil, fmt.Errorf("failed to create embedding: %w", err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create embedding: %w", err)
+		}
+	}
+
+	conv.Unembedded = nil
+	conv.ContentHash = ""
+	if embedding != nil {
+		conv.ContentHash = contentHash(textToEmbed)
+	}
+	vectorsCreated, err := cs.storeConversation(ctx, conv, embedding, storedMetadata(conv))
+	if err != nil {
+		return nil, err
+	}
+	cs.saveChunks(ctx, conv.ID, chunks)
+
+	return &models.SaveResponse{
+		ConversationID: conv.ID,
+		VectorsCreated: vectorsCreated,
+		MessagesStored: len(conv.Messages),
+		StoredAt:       now.UTC().Format(time.RFC3339),
+		Status:         conv.Status,
+		Consistency:    models.ConsistencyEventual,
+	}, nil
+}
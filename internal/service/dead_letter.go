@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// DeadLetterService inspects and replays work queue items that exhausted their attempts
+type DeadLetterService struct {
+	store storage.DeadLetterStore
+}
+
+// NewDeadLetterService creates a new dead letter service
+func NewDeadLetterService(store storage.DeadLetterStore) *DeadLetterService {
+	return &DeadLetterService{store: store}
+}
+
+// List retrieves a page of dead letters, newest first; an empty kind lists all kinds
+func (dls *DeadLetterService) List(ctx context.Context, kind string, limit int, offset int) (*models.DeadLetterListResponse, error) {
+	deadLetters, total, err := dls.store.ListDeadLetters(ctx, kind, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	return &models.DeadLetterListResponse{
+		DeadLetters: deadLetters,
+		Total:       total,
+		Kind:        kind,
+		Limit:       limit,
+		Offset:      offset,
+	}, nil
+}
+
+// Get retrieves a dead letter, or nil if it doesn't exist
+func (dls *DeadLetterService) Get(ctx context.Context, id int64) (*models.DeadLetter, error) {
+	deadLetter, err := dls.store.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+	return deadLetter, nil
+}
+
+// Retry puts a dead letter back on the work queue with its attempts reset; it reports false if
+// the dead letter doesn't exist
+func (dls *DeadLetterService) Retry(ctx context.Context, id int64) (bool, error) {
+	found, err := dls.store.RequeueDeadLetter(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to retry dead letter: %w", err)
+	}
+	return found, nil
+}
+
+// Discard deletes a dead letter; it reports false if the dead letter doesn't exist
+func (dls *DeadLetterService) Discard(ctx context.Context, id int64) (bool, error) {
+	found, err := dls.store.DeleteDeadLetter(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to discard dead letter: %w", err)
+	}
+	return found, nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"refo-rag-server/internal/erasure"
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// DeletionCertificates issues and keeps the signed certificates of executed purges
+type DeletionCertificates struct {
+	store  storage.DeletionCertificateStore
+	signer *erasure.Signer
+}
+
+// NewDeletionCertificates creates a certificate issuer signing with signer
+func NewDeletionCertificates(store storage.DeletionCertificateStore, signer *erasure.Signer) *DeletionCertificates {
+	return &DeletionCertificates{
+		store:  store,
+		signer: signer,
+	}
+}
+
+// Issue signs and stores a certificate for a purge that has completed and returns its ID. The
+// records are already gone when it is called, so a failure is logged and reported rather than
+// failing the purge; the job log still shows what was deleted
+func (dc *DeletionCertificates) Issue(ctx context.Context, cert *models.DeletionCertificate) string {
+	cert.ID = uuid.New().String()
+	cert.DeletedAt = time.Now().UTC()
+
+	err := dc.signer.Sign(cert)
+	if err == nil {
+		err = dc.store.SaveDeletionCertificate(context.WithoutCancel(ctx), cert)
+	}
+	if err != nil {
+		fmt.Printf("warning: failed to issue deletion certificate for %s job %s: %v\n", cert.Kind, cert.JobID, err)
+		errreport.Background(ctx, "deletion_certificate", err)
+		return ""
+	}
+	return cert.ID
+}
+
+// Get retrieves a certificate by ID; it returns nil if the certificate doesn't exist
+func (dc *DeletionCertificates) Get(ctx context.Context, id string) (*models.DeletionCertificate, error) {
+	return dc.store.GetDeletionCertificate(ctx, id)
+}
+
+// List retrieves the most recent certificates, optionally of one kind and target
+func (dc *DeletionCertificates) List(ctx context.Context, kind string, target string, limit int) ([]*models.DeletionCertificate, error) {
+	return dc.store.ListDeletionCertificates(ctx, kind, target, limit)
+}
+
+// PublicKey returns the key certificates are verified with
+func (dc *DeletionCertificates) PublicKey() *models.DeletionCertificateKey {
+	return dc.signer.PublicKey()
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// DoctorUserID is the scratch namespace the round-trip check writes to; its points are deleted
+// before the check returns
+const DoctorUserID = "__doctor__"
+
+// doctorProbe is the text embedded by the embedding and round-trip checks
+const doctorProbe = "ragctl doctor self-test"
+
+// Doctor self-tests the server's dependencies: connectivity to the relational database, Qdrant and
+// the model providers, the schema and collection layout against the configuration, and a write and search
+// of a scratch vector. It is the first report to ask for when something is wrong
+type Doctor struct {
+	// store is the relational database of the configured backend, named after MEMORY_STORE_BACKEND
+	store       storage.RelationalStore
+	backend     string
+	migrations  storage.MigrationOptions
+	collections *storage.CollectionManager
+	embedders   map[string]storage.EmbeddingProvider
+	completion  storage.CompletionProvider
+}
+
+// NewDoctor creates a doctor for the relational store of a backend; embedders are keyed by model
+// name
+func NewDoctor(
+	store storage.RelationalStore,
+	backend string,
+	migrations storage.MigrationOptions,
+	collections *storage.CollectionManager,
+	embedders map[string]storage.EmbeddingProvider,
+	completion storage.CompletionProvider,
+) *Doctor {
+	return &Doctor{
+		store:       store,
+		backend:     backend,
+		migrations:  migrations,
+		collections: collections,
+		embedders:   embedders,
+		completion:  completion,
+	}
+}
+
+// doctorRun collects the checks of one self-test
+type doctorRun struct {
+	checks []models.DoctorCheck
+}
+
+// run runs a check, recording its outcome and duration; fn returns the detail to report
+func (r *doctorRun) run(name string, fn func() (string, error)) bool {
+	start := time.Now()
+	detail, err := fn()
+	check := models.DoctorCheck{
+		Name:       name,
+		Status:     models.DoctorPass,
+		Detail:     detail,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		check.Status = models.DoctorFail
+		check.Detail = err.Error()
+	}
+	r.checks = append(r.checks, check)
+	return err == nil
+}
+
+// skip records a check that can't run
+func (r *doctorRun) skip(name string, reason string) {
+	r.checks = append(r.checks, models.DoctorCheck{Name: name, Status: models.DoctorSkip, Detail: reason})
+}
+
+// Run runs the requested checks. Checks that depend on a failed one are skipped rather than
+// failed, so the first failure in the report is the one to look at
+func (d *Doctor) Run(ctx context.Context, req models.DoctorRequest) *models.DoctorReport {
+	start := time.Now()
+	r := &doctorRun{}
+
+	if r.run(d.backend, func() (string, error) {
+		return "", d.store.Ping(ctx)
+	}) {
+		r.run("schema", func() (string, error) {
+			return d.checkSchema()
+		})
+	} else {
+		r.skip("schema", d.backend+" is unreachable")
+	}
+
+	// Collections that exist with the configured vector size and distance
+	usable := make(map[string]bool)
+	for _, contentType := range d.collections.ContentTypes() {
+		usable[contentType] = r.run("collection "+contentType, func() (string, error) {
+			return d.checkCollection(ctx, contentType)
+		})
+	}
+
+	// Models that embed to the dimension of every collection bound to them
+	embedding := make(map[string][]float32)
+	for _, model := range d.models() {
+		r.run("embedding "+model, func() (string, error) {
+			vector, detail, err := d.checkEmbedder(ctx, model)
+			if err == nil {
+				embedding[model] = vector
+			}
+			return detail, err
+		})
+	}
+
+	if d.completion != nil {
+		r.run("completion", func() (string, error) {
+			if _, err := d.completion.Complete(ctx, "Reply with OK.", "ping"); err != nil {
+				return "", err
+			}
+			return "chat model responded", nil
+		})
+	}
+
+	conversations, _ := d.collections.Config(storage.ContentTypeConversations)
+	switch {
+	case !req.RoundTrip:
+		r.skip("round trip", "not requested")
+	case !usable[storage.ContentTypeConversations]:
+		r.skip("round trip", "the conversations collection failed its check")
+	case embedding[conversations.Model] == nil:
+		r.skip("round trip", "the conversations model failed its check")
+	default:
+		r.run("round trip", func() (string, error) {
+			return d.roundTrip(ctx, embedding[conversations.Model])
+		})
+	}
+
+	report := &models.DoctorReport{
+		Passed:      true,
+		Checks:      r.checks,
+		GeneratedAt: time.Now(),
+		DurationMs:  time.Since(start).Milliseconds(),
+	}
+	for _, check := range r.checks {
+		if check.Status == models.DoctorFail {
+			report.Passed = false
+		}
+	}
+	return report
+}
+
+// checkSchema verifies that no migration of the backend is pending
+func (d *Doctor) checkSchema() (string, error) {
+	status, err := d.Migrations()
+	if err != nil {
+		return "", err
+	}
+	schema := d.backend + " schema"
+	if status.SchemaVersion > 0 {
+		schema = fmt.Sprintf("schema version %d", status.SchemaVersion)
+	}
+	if len(status.Pending) > 0 {
+		return "", fmt.Errorf("%d migration statements pending for %s; the server applies them on start", len(status.Pending), schema)
+	}
+	return schema, nil
+}
+
+// Migrations plans the schema migrations the server would apply to the database on start. Only
+// the Postgres layout has a schema version and lock risks; SQLite and MySQL number their
+// migrations instead
+func (d *Doctor) Migrations() (*models.MigrationStatus, error) {
+	var plan *storage.MigrationPlan
+	var err error
+	status := &models.MigrationStatus{Backend: d.backend, Guard: d.migrations.Guard}
+	switch store := d.store.(type) {
+	case *storage.PostgresStore:
+		plan, err = storage.PlanMigrations(store.GetDB(), d.migrations)
+		status.SchemaVersion = storage.SchemaVersion
+	case *storage.SQLiteStore:
+		plan, err = storage.PlanSQLiteMigrations(store.GetDB())
+	case *storage.MySQLStore:
+		plan, err = storage.PlanMySQLMigrations(store.GetDB())
+	default:
+		return nil, fmt.Errorf("migrations of a %s database can't be planned", d.backend)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan migrations: %w", err)
+	}
+
+	status.Pending = plan.Pending
+	status.Risks = make([]models.MigrationRisk, 0, len(plan.Risks))
+	for _, risk := range plan.Risks {
+		status.Risks = append(status.Risks, models.MigrationRisk(risk))
+	}
+	status.Blocking = len(plan.Blocking())
+	return status, nil
+}
+
+// checkCollection verifies a collection exists with its configured vector size and distance
+func (d *Doctor) checkCollection(ctx context.Context, contentType string) (string, error) {
+	cfg, _ := d.collections.Config(contentType)
+	store, err := d.collections.Store(contentType)
+	if err != nil {
+		return "", err
+	}
+	info, err := store.GetCollectionInfo(ctx)
+	if err != nil {
+		return "", fmt.Errorf("collection %s: %w", cfg.Name, err)
+	}
+
+	var mismatches []string
+	if info.VectorSize != cfg.Dimension {
+		mismatches = append(mismatches, fmt.Sprintf("stores %d-dimensional vectors but %d is configured", info.VectorSize, cfg.Dimension))
+	}
+	if info.Distance != cfg.Distance {
+		mismatches = append(mismatches, fmt.Sprintf("uses %s distance but %s is configured", info.Distance, cfg.Distance))
+	}
+	if info.Status == "red" {
+		mismatches = append(mismatches, "status is red")
+	}
+	if len(mismatches) > 0 {
+		return "", fmt.Errorf("collection %s %s", cfg.Name, strings.Join(mismatches, ", "))
+	}
+	return fmt.Sprintf("%s: %d x %s %s, %d points, %s", cfg.Name, info.VectorSize, info.Datatype, info.Distance, info.PointsCount, info.Status), nil
+}
+
+// models returns the embedding models bound to collections, sorted
+func (d *Doctor) models() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, contentType := range d.collections.ContentTypes() {
+		cfg, _ := d.collections.Config(contentType)
+		if !seen[cfg.Model] {
+			seen[cfg.Model] = true
+			names = append(names, cfg.Model)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkEmbedder embeds the probe with a model and verifies the vector fits every collection bound
+// to it
+func (d *Doctor) checkEmbedder(ctx context.Context, model string) ([]float32, string, error) {
+	embedder, ok := d.embedders[model]
+	if !ok {
+		return nil, "", fmt.Errorf("no embedding provider for model %q", model)
+	}
+	vector, err := embedder.EmbedDocument(ctx, doctorProbe)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create embedding: %w", err)
+	}
+
+	var bound []string
+	for _, contentType := range d.collections.ContentTypes() {
+		cfg, _ := d.collections.Config(contentType)
+		if cfg.Model != model {
+			continue
+		}
+		if len(vector) != cfg.Dimension {
+			return nil, "", fmt.Errorf("model returns %d dimensions but collection %s expects %d", len(vector), cfg.Name, cfg.Dimension)
+		}
+		bound = append(bound, cfg.Name)
+	}
+	return vector, fmt.Sprintf("%d dimensions, bound to %s", len(vector), strings.Join(bound, ", ")), nil
+}
+
+// roundTrip saves a scratch vector to the conversations collection, finds it by searching the
+// scratch namespace, and deletes the namespace again
+func (d *Doctor) roundTrip(ctx context.Context, vector []float32) (string, error) {
+	store, err := d.collections.Store(storage.ContentTypeConversations)
+	if err != nil {
+		return "", err
+	}
+
+	conversationID := "doctor-" + uuid.NewString()
+	tripErr := store.SaveVector(ctx, conversationID, vector, map[string]interface{}{"user_id": DoctorUserID})
+	var results []models.ConversationSearchResult
+	if tripErr == nil {
+		results, tripErr = store.SearchVectors(ctx, vector, storage.SearchOptions{Limit: 1, UserID: DoctorUserID})
+	}
+
+	// Clean up whatever was written, including points left behind by an interrupted run
+	if err := store.DeleteUserVectors(ctx, DoctorUserID); err != nil {
+		return "", fmt.Errorf("failed to delete scratch vectors: %w", err)
+	}
+	if tripErr != nil {
+		return "", tripErr
+	}
+	if len(results) == 0 || results[0].ConversationID != conversationID {
+		return "", fmt.Errorf("saved scratch vector was not found by search")
+	}
+	return fmt.Sprintf("saved, found (score %.3f) and deleted in %s", results[0].Score, store.Collection()), nil
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+)
+
+// ErrDriftRunning is returned when an embedding drift check is already in progress
+var ErrDriftRunning = errors.New("an embedding drift check is already running")
+
+// MeasureDrift re-embeds a random sample of up to sampleSize stored conversations with the live
+// model and measures the cosine distance to their stored vectors. Conversations whose text changed
+// since they were embedded are skipped, so only a change in the model's output is measured.
+func (cs *ConversationService) MeasureDrift(ctx context.Context, sampleSize int) (*models.EmbeddingDriftReport, error) {
+	start := time.Now()
+	report := &models.EmbeddingDriftReport{Samples: []models.EmbeddingDriftSample{}}
+
+	ids, err := cs.sampleConversationIDs(ctx, sampleSize)
+	if err != nil {
+		return nil, err
+	}
+	report.Sampled = len(ids)
+
+	conversations, _, err := cs.conversationStore.GetConversationsByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversations: %w", err)
+	}
+	payloads, err := cs.vectorStore.GetPayloads(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vector payloads: %w", err)
+	}
+	vectors, err := cs.vectorStore.GetVectors(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vectors: %w", err)
+	}
+	report.Skipped = len(ids) - len(conversations)
+
+	var total float64
+	for _, conv := range conversations {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		text := cs.embedText(conversationMessages(conv))
+		stored, ok := vectors[conv.ID]
+		storedHash, _ := payloads[conv.ID][contentHashPayloadKey].(string)
+		if text == "" || !ok || storedHash != contentHash(text) {
+			report.Skipped++
+			continue
+		}
+
+		embedding, err := cs.embeddingProvider.EmbedDocument(ctx, text)
+		if err != nil {
+			fmt.Printf("warning: failed to re-embed conversation %s for drift check: %v\n", conv.ID, err)
+			report.Failed++
+			continue
+		}
+		if len(embedding) != len(stored) {
+			return nil, fmt.Errorf("the live model returns %d dimensions, stored vectors have %d", len(embedding), len(stored))
+		}
+
+		distance := cosineDistance(stored, embedding)
+		report.Samples = append(report.Samples, models.EmbeddingDriftSample{ConversationID: conv.ID, Distance: distance})
+		report.Compared++
+		total += distance
+		report.MaxDistance = math.Max(report.MaxDistance, distance)
+	}
+	if report.Compared > 0 {
+		report.MeanDistance = total / float64(report.Compared)
+	}
+
+	report.DurationMs = time.Since(start).Milliseconds()
+	return report, nil
+}
+
+// sampleConversationIDs picks up to n distinct conversations, each the first at or after a random
+// UUID in ID order
+func (cs *ConversationService) sampleConversationIDs(ctx context.Context, n int) ([]string, error) {
+	seen := make(map[string]bool, n)
+	ids := make([]string, 0, n)
+	for attempt := 0; attempt < 2*n && len(ids) < n; attempt++ {
+		page, err := cs.conversationStore.ListConversationIDs(ctx, "", uuid.NewString(), 1)
+		if err == nil && len(page) == 0 {
+			// Past the last ID; wrap around to the first
+			page, err = cs.conversationStore.ListConversationIDs(ctx, "", "", 1)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			break
+		}
+		if !seen[page[0]] {
+			seen[page[0]] = true
+			ids = append(ids, page[0])
+		}
+	}
+	return ids, nil
+}
+
+// cosineDistance returns 1 - the cosine similarity of two vectors of equal length
+func cosineDistance(a []float32, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 1
+	}
+	return 1 - dot/(math.Sqrt(normA)*math.Sqrt(normB))
+}
+
+// DriftService checks stored vectors against the live embedding model, on demand and on a schedule
+type DriftService struct {
+	conversations *ConversationService
+	jobs          *JobLog
+	sampleSize    int
+	threshold     float64
+
+	// checking is set while a drift check runs
+	checking atomic.Bool
+}
+
+// NewDriftService creates a drift service sampling sampleSize conversations per check and
+// alerting when their mean cosine distance exceeds threshold
+func NewDriftService(conversations *ConversationService, jobs *JobLog, sampleSize int, threshold float64) *DriftService {
+	return &DriftService{
+		conversations: conversations,
+		jobs:          jobs,
+		sampleSize:    sampleSize,
+		threshold:     threshold,
+	}
+}
+
+// StartCheck starts a background drift check. It returns the job ID; the report is the job's result.
+func (ds *DriftService) StartCheck(ctx context.Context) (string, error) {
+	if !ds.checking.CompareAndSwap(false, true) {
+		return "", ErrDriftRunning
+	}
+
+	jobID, err := ds.jobs.Start(ctx, models.JobKindDrift, "conversations", func(ctx context.Context) (interface{}, error) {
+		defer ds.checking.Store(false)
+		return ds.check(ctx)
+	})
+	if err != nil {
+		ds.checking.Store(false)
+		return "", err
+	}
+	return jobID, nil
+}
+
+// Check measures drift as a logged job and waits for it, for scheduled checks
+func (ds *DriftService) Check(ctx context.Context) error {
+	if !ds.checking.CompareAndSwap(false, true) {
+		return ErrDriftRunning
+	}
+	defer ds.checking.Store(false)
+
+	_, err := ds.jobs.Run(ctx, models.JobKindDrift, "conversations", false, func(ctx context.Context) (interface{}, error) {
+		return ds.check(ctx)
+	})
+	return err
+}
+
+// check measures drift, publishes it as metrics and raises an alert above the threshold
+func (ds *DriftService) check(ctx context.Context) (*models.EmbeddingDriftReport, error) {
+	report, err := ds.conversations.MeasureDrift(ctx, ds.sampleSize)
+	if err != nil {
+		return nil, err
+	}
+	report.Threshold = ds.threshold
+	if report.Compared == 0 {
+		return report, nil
+	}
+
+	metrics.EmbeddingDrift.WithLabelValues("mean").Set(report.MeanDistance)
+	metrics.EmbeddingDrift.WithLabelValues("max").Set(report.MaxDistance)
+	if report.MeanDistance > ds.threshold {
+		report.Alert = true
+		metrics.EmbeddingDriftAlerts.Inc()
+		err := fmt.Errorf("mean cosine distance %.4f of %d re-embedded conversations exceeds %.4f; the embedding model may have changed", report.MeanDistance, report.Compared, ds.threshold)
+		fmt.Printf("warning: embedding drift: %v\n", err)
+		errreport.Background(ctx, "embedding_drift", err)
+	}
+	return report, nil
+}
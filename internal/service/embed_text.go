@@ -0,0 +1,118 @@
+package service
+
+import (
+	"strings"
+
+	"refo-rag-server/internal/models"
+)
+
+// rolePrefixes label each message when EmbedTextOptions.RolePrefixes is set
+var rolePrefixes = map[string]string{
+	models.RoleUser:      "User: ",
+	models.RoleAssistant: "Assistant: ",
+	models.RoleSystem:    "System: ",
+	models.RoleTool:      "Tool: ",
+}
+
+// EmbedTextOptions controls how a conversation's messages are assembled into the text that is
+// embedded. Changing them changes the text of every conversation, so reindex afterwards.
+type EmbedTextOptions struct {
+	// Roles lists the message roles included; empty means user and assistant. Set it to user
+	// alone to embed only what the user said.
+	Roles []string
+
+	// RolePrefixes labels each message with its role, e.g. "User: "
+	RolePrefixes bool
+
+	// Separator joins the messages; empty means a newline
+	Separator string
+
+	// MaxTurns keeps only the last MaxTurns included messages; 0 keeps all
+	MaxTurns int
+
+	// ChunkTurns is the number of included messages per chunk when conversations are embedded in
+	// chunks; 0 makes the whole text one chunk
+	ChunkTurns int
+}
+
+// Build assembles the embedded text of messages; it is empty when no message qualifies
+func (o EmbedTextOptions) Build(messages []models.Message) string {
+	return o.join(o.parts(messages))
+}
+
+// Chunks splits the embedded text of messages into the texts of runs of ChunkTurns included
+// messages, oldest first. Joined, the chunks hold the same messages as Build's text, so editing a
+// message only changes the chunk holding it
+func (o EmbedTextOptions) Chunks(messages []models.Message) []string {
+	parts := o.parts(messages)
+	size := o.ChunkTurns
+	if size <= 0 {
+		size = len(parts)
+	}
+
+	var chunks []string
+	for start := 0; start < len(parts); start += size {
+		chunks = append(chunks, o.join(parts[start:min(start+size, len(parts))]))
+	}
+	return chunks
+}
+
+// parts returns the labeled content of the included messages, keeping the last MaxTurns
+func (o EmbedTextOptions) parts(messages []models.Message) []string {
+	parts := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		content := strings.TrimSpace(msg.Content)
+		if content == "" || !o.includes(msg.Role) {
+			continue
+		}
+		if o.RolePrefixes {
+			content = rolePrefixes[msg.Role] + content
+		}
+		parts = append(parts, content)
+	}
+
+	if o.MaxTurns > 0 && len(parts) > o.MaxTurns {
+		parts = parts[len(parts)-o.MaxTurns:]
+	}
+	return parts
+}
+
+// join joins message parts with the separator
+func (o EmbedTextOptions) join(parts []string) string {
+	separator := o.Separator
+	if separator == "" {
+		separator = "\n"
+	}
+	return strings.Join(parts, separator)
+}
+
+// PersonalInfoTemplate renders the embedded text of a personal info entry. The placeholders
+// {content}, {category} and {importance} are replaced by the entry's fields; an empty template
+// embeds the content alone. Context such as the category helps short entries like phone numbers
+// match queries about them. Changing it changes every entry's text, so reindex afterwards.
+type PersonalInfoTemplate string
+
+// Build renders the embedded text of an entry
+func (t PersonalInfoTemplate) Build(personalInfo *models.PersonalInfo) string {
+	if t == "" {
+		return personalInfo.Content
+	}
+	return strings.NewReplacer(
+		"{content}", personalInfo.Content,
+		"{category}", personalInfo.Category,
+		"{importance}", personalInfo.Importance,
+	).Replace(string(t))
+}
+
+// includes reports whether messages with the role are part of the embedded text
+func (o EmbedTextOptions) includes(role string) bool {
+	if len(o.Roles) == 0 {
+		return role == models.RoleUser || role == models.RoleAssistant
+	}
+	for _, included := range o.Roles {
+		if role == included {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,450 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// ErrAnomalyScanRunning is returned when an embedding anomaly scan is already in progress
+var ErrAnomalyScanRunning = errors.New("an embedding anomaly scan is already running")
+
+// anomalyUserBatchSize is the number of active users listed per page
+const anomalyUserBatchSize = 500
+
+// Anomaly scan limits
+const (
+	// minAnomalyBaseline is the fewest earlier conversations a user's baseline needs; fewer don't
+	// say what is usual for them
+	minAnomalyBaseline = 20
+
+	// minAnomalyRecent is the fewest conversations in the window worth comparing
+	minAnomalyRecent = 2
+
+	// maxAnomalyRecent caps the conversations of the window compared per user
+	maxAnomalyRecent = 500
+
+	// minNovelConversations is how many conversations unlike the baseline make a novel topic
+	// rather than a one-off question
+	minNovelConversations = 2
+
+	// maxAnomalyConversations caps the conversation IDs of an event
+	maxAnomalyConversations = 10
+
+	// minAnomalySpread floors baseline standard deviations, so a user whose conversations are all
+	// alike isn't flagged for the slightest variation
+	minAnomalySpread = 0.01
+)
+
+// AnomalyOptions configures the embedding anomaly scan
+type AnomalyOptions struct {
+	// Window is how far back a user's recent conversations go; scheduling the scan at the same
+	// interval compares each conversation once
+	Window time.Duration
+
+	// BaselineSize caps the conversations before the window that make up a user's baseline
+	BaselineSize int
+
+	// Threshold is how many baseline standard deviations away the recent conversations must be to
+	// be flagged; lower is more sensitive
+	Threshold float64
+
+	// DistressPhrases are embedded as anchors; recent conversations moving towards them are
+	// flagged. Empty disables distress detection
+	DistressPhrases []string
+}
+
+// WebhookPoster posts an event as JSON to a webhook
+type WebhookPoster interface {
+	Post(ctx context.Context, event interface{}) error
+}
+
+// AnomalyService watches the distribution of each active user's conversation vectors and flags
+// sudden shifts: the recent conversations moving away from the user's usual topics, several of
+// them unlike anything before, or moving towards configured distress phrases. Each user is
+// compared with their own earlier conversations, so what is usual differs per user
+type AnomalyService struct {
+	conversations *ConversationService
+	users         storage.ActiveUserStore
+	jobs          *JobLog
+	opts          AnomalyOptions
+	notifier      WebhookPoster
+
+	// anchors holds the embedded distress phrases once embedded
+	mu      sync.Mutex
+	anchors [][]float32
+
+	// scanning is set while a scan runs
+	scanning atomic.Bool
+}
+
+// NewAnomalyService creates an anomaly service scanning the users of users
+func NewAnomalyService(conversations *ConversationService, users storage.ActiveUserStore, jobs *JobLog, opts AnomalyOptions) *AnomalyService {
+	return &AnomalyService{
+		conversations: conversations,
+		users:         users,
+		jobs:          jobs,
+		opts:          opts,
+	}
+}
+
+// SetNotifier posts flagged anomalies to notifier; without one they are only logged and counted
+func (as *AnomalyService) SetNotifier(notifier WebhookPoster) {
+	as.notifier = notifier
+}
+
+// Scan scans the users active in the window as a logged job and waits for it, for scheduled scans
+func (as *AnomalyService) Scan(ctx context.Context) error {
+	if !as.scanning.CompareAndSwap(false, true) {
+		return ErrAnomalyScanRunning
+	}
+	defer as.scanning.Store(false)
+
+	_, err := as.jobs.Run(ctx, models.JobKindAnomaly, "conversations", false, func(ctx context.Context) (interface{}, error) {
+		return as.scan(ctx)
+	})
+	return err
+}
+
+// scan compares the recent conversations of every user active in the window with their baseline
+// and reports what it flagged
+func (as *AnomalyService) scan(ctx context.Context) (*models.EmbeddingAnomalyReport, error) {
+	start := time.Now()
+	report := &models.EmbeddingAnomalyReport{
+		WindowStart: start.UTC().Add(-as.opts.Window),
+		Anomalies:   []models.EmbeddingAnomaly{},
+	}
+
+	anchors, err := as.distressAnchors(ctx)
+	if err != nil {
+		// Scanned again for distress once the phrases can be embedded
+		fmt.Printf("warning: failed to embed distress phrases, scanning without them: %v\n", err)
+	}
+
+	afterUserID := ""
+	for {
+		userIDs, err := as.users.ListActiveUsers(ctx, report.WindowStart, afterUserID, anomalyUserBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, userID := range userIDs {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			report.Users++
+
+			recent, baseline, err := as.loadWindow(ctx, userID, report.WindowStart)
+			if err != nil {
+				fmt.Printf("warning: failed to load conversation vectors of user %s for anomaly scan: %v\n", userID, err)
+				report.Failed++
+				continue
+			}
+			if len(recent.vectors) < minAnomalyRecent || len(baseline) < minAnomalyBaseline {
+				continue
+			}
+			report.Scanned++
+
+			for _, anomaly := range detectAnomalies(recent, baseline, anchors, as.opts.Threshold) {
+				anomaly.Event = models.EmbeddingAnomalyEvent
+				anomaly.UserID = userID
+				anomaly.WindowStart = report.WindowStart
+				anomaly.DetectedAt = time.Now().UTC()
+				as.notify(ctx, anomaly)
+				report.Anomalies = append(report.Anomalies, anomaly)
+			}
+		}
+
+		if len(userIDs) < anomalyUserBatchSize {
+			break
+		}
+		afterUserID = userIDs[len(userIDs)-1]
+	}
+
+	report.DurationMs = time.Since(start).Milliseconds()
+	return report, nil
+}
+
+// notify posts an anomaly to the webhook, or just logs it without one. Events carry IDs and
+// scores only, never conversation content
+func (as *AnomalyService) notify(ctx context.Context, anomaly models.EmbeddingAnomaly) {
+	fmt.Printf("warning: embedding anomaly %s for user %s: score %.2f exceeds %.2f over %d recent conversations\n",
+		anomaly.Kind, anomaly.UserID, anomaly.Score, anomaly.Threshold, anomaly.Recent)
+	if as.notifier == nil {
+		metrics.EmbeddingAnomalies.WithLabelValues(anomaly.Kind, "logged").Inc()
+		return
+	}
+	if err := as.notifier.Post(ctx, anomaly); err != nil {
+		metrics.EmbeddingAnomalies.WithLabelValues(anomaly.Kind, "error").Inc()
+		fmt.Printf("warning: failed to post embedding anomaly for user %s: %v\n", anomaly.UserID, err)
+		return
+	}
+	metrics.EmbeddingAnomalies.WithLabelValues(anomaly.Kind, "sent").Inc()
+}
+
+// distressAnchors returns the distress phrases embedded as queries, embedding them on first use
+func (as *AnomalyService) distressAnchors(ctx context.Context) ([][]float32, error) {
+	if len(as.opts.DistressPhrases) == 0 {
+		return nil, nil
+	}
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	if as.anchors != nil {
+		return as.anchors, nil
+	}
+
+	anchors := make([][]float32, 0, len(as.opts.DistressPhrases))
+	for _, phrase := range as.opts.DistressPhrases {
+		embedding, err := as.conversations.embeddingProvider.EmbedQuery(ctx, phrase)
+		if err != nil {
+			return nil, err
+		}
+		anchors = append(anchors, unitVector(embedding))
+	}
+	as.anchors = anchors
+	return anchors, nil
+}
+
+// anomalyWindow holds a user's recent conversation vectors, unit length, with their IDs
+type anomalyWindow struct {
+	ids     []string
+	vectors [][]float32
+}
+
+// loadWindow loads the vectors of a user's unsuppressed conversations created since windowStart,
+// newest first, and of up to BaselineSize conversations before it
+func (as *AnomalyService) loadWindow(ctx context.Context, userID string, windowStart time.Time) (*anomalyWindow, [][]float32, error) {
+	cs := as.conversations
+	recent := &anomalyWindow{}
+	var baseline [][]float32
+	for offset := 0; ; offset += topicMapBatchSize {
+		page, _, err := cs.conversationStore.ListUserConversations(ctx, userID, "", topicMapBatchSize, offset)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		ids := make([]string, 0, len(page))
+		for _, conv := range page {
+			if conv.Suppression == nil && conv.Unembedded == nil {
+				ids = append(ids, conv.ID)
+			}
+		}
+		stored, err := cs.vectorStore.GetVectors(ctx, ids)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get conversation vectors: %w", err)
+		}
+
+		for _, conv := range page {
+			vector, ok := stored[conv.ID]
+			if !ok || conv.Suppression != nil || conv.Unembedded != nil {
+				continue
+			}
+			if !conv.CreatedAt.Before(windowStart) {
+				if len(recent.vectors) < maxAnomalyRecent {
+					recent.ids = append(recent.ids, conv.ID)
+					recent.vectors = append(recent.vectors, unitVector(vector))
+				}
+				continue
+			}
+			if len(baseline) == as.opts.BaselineSize {
+				return recent, baseline, nil
+			}
+			baseline = append(baseline, unitVector(vector))
+		}
+		if len(page) < topicMapBatchSize {
+			return recent, baseline, nil
+		}
+	}
+}
+
+// detectAnomalies compares recent vectors with a baseline, all unit length, and returns the
+// anomalies whose score exceeds threshold. Each score is a z-score against the baseline:
+//   - topic shift: the mean similarity of the recent vectors to the baseline centroid, against
+//     the baseline's own (leave-one-out) similarities, scaled by the standard error of the mean
+//   - novel topic: each recent vector's similarity to its nearest baseline vector, against the
+//     baseline vectors' nearest neighbors; the score is that of the minNovelConversations-th most
+//     novel conversation, so a single outlier isn't a topic
+//   - distress: each recent vector's best similarity to an anchor, against the baseline's; a
+//     single conversation suffices
+func detectAnomalies(recent *anomalyWindow, baseline [][]float32, anchors [][]float32, threshold float64) []models.EmbeddingAnomaly {
+	var anomalies []models.EmbeddingAnomaly
+	flag := func(kind string, score float64, scores []float64, ascending bool) {
+		if score <= threshold {
+			return
+		}
+		anomalies = append(anomalies, models.EmbeddingAnomaly{
+			Kind:            kind,
+			Score:           score,
+			Threshold:       threshold,
+			Recent:          len(recent.vectors),
+			Baseline:        len(baseline),
+			ConversationIDs: standouts(recent.ids, scores, ascending),
+		})
+	}
+
+	// Topic shift
+	sum := make([]float64, len(baseline[0]))
+	for _, vector := range baseline {
+		for i, value := range vector {
+			sum[i] += float64(value)
+		}
+	}
+	var sumNorm float64
+	for _, value := range sum {
+		sumNorm += value * value
+	}
+	baselineSimilarities := make([]float64, len(baseline))
+	for i, vector := range baseline {
+		// Similarity to the centroid of the other baseline vectors, unbiased by the vector itself
+		projection := dot64(vector, sum)
+		if norm := math.Sqrt(sumNorm - 2*projection + 1); norm > 0 {
+			baselineSimilarities[i] = (projection - 1) / norm
+		}
+	}
+	if sumNorm > 0 {
+		recentSimilarities := make([]float64, len(recent.vectors))
+		for j, vector := range recent.vectors {
+			recentSimilarities[j] = dot64(vector, sum) / math.Sqrt(sumNorm)
+		}
+		mean, spread := meanSpread(baselineSimilarities)
+		recentMean, _ := meanSpread(recentSimilarities)
+		score := (mean - recentMean) / (spread / math.Sqrt(float64(len(recent.vectors))))
+		flag(models.AnomalyTopicShift, score, recentSimilarities, true)
+	}
+
+	// Novel topic
+	baselineNearest := make([]float64, len(baseline))
+	for i := range baselineNearest {
+		baselineNearest[i] = math.Inf(-1)
+	}
+	for i := range baseline {
+		for j := i + 1; j < len(baseline); j++ {
+			similarity := dot32(baseline[i], baseline[j])
+			baselineNearest[i] = math.Max(baselineNearest[i], similarity)
+			baselineNearest[j] = math.Max(baselineNearest[j], similarity)
+		}
+	}
+	recentNearest := make([]float64, len(recent.vectors))
+	for j, vector := range recent.vectors {
+		recentNearest[j] = math.Inf(-1)
+		for _, other := range baseline {
+			recentNearest[j] = math.Max(recentNearest[j], dot32(vector, other))
+		}
+	}
+	if len(recent.vectors) >= minNovelConversations {
+		mean, spread := meanSpread(baselineNearest)
+		novelty := make([]float64, len(recentNearest))
+		for j, similarity := range recentNearest {
+			novelty[j] = (mean - similarity) / spread
+		}
+		sorted := append([]float64(nil), novelty...)
+		sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+		flag(models.AnomalyNovelTopic, sorted[minNovelConversations-1], recentNearest, true)
+	}
+
+	// Distress; anchors of another dimension come from a different model and are skipped
+	if len(anchors) > 0 && len(anchors[0]) == len(baseline[0]) {
+		closeness := func(vector []float32) float64 {
+			best := math.Inf(-1)
+			for _, anchor := range anchors {
+				best = math.Max(best, dot32(vector, anchor))
+			}
+			return best
+		}
+		baselineCloseness := make([]float64, len(baseline))
+		for i, vector := range baseline {
+			baselineCloseness[i] = closeness(vector)
+		}
+		recentCloseness := make([]float64, len(recent.vectors))
+		score := math.Inf(-1)
+		mean, spread := meanSpread(baselineCloseness)
+		for j, vector := range recent.vectors {
+			recentCloseness[j] = closeness(vector)
+			score = math.Max(score, (recentCloseness[j]-mean)/spread)
+		}
+		flag(models.AnomalyDistress, score, recentCloseness, false)
+	}
+
+	return anomalies
+}
+
+// standouts returns the IDs of the conversations standing out most by their scores, lowest first
+// if ascending, capped at maxAnomalyConversations
+func standouts(ids []string, scores []float64, ascending bool) []string {
+	order := make([]int, len(ids))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		if ascending {
+			return scores[order[a]] < scores[order[b]]
+		}
+		return scores[order[a]] > scores[order[b]]
+	})
+
+	standouts := make([]string, 0, min(len(order), maxAnomalyConversations))
+	for _, i := range order[:cap(standouts)] {
+		standouts = append(standouts, ids[i])
+	}
+	return standouts
+}
+
+// meanSpread returns the mean and standard deviation of values, the deviation at least
+// minAnomalySpread
+func meanSpread(values []float64) (float64, float64) {
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+	var variance float64
+	for _, value := range values {
+		variance += (value - mean) * (value - mean)
+	}
+	return mean, math.Max(math.Sqrt(variance/float64(len(values))), minAnomalySpread)
+}
+
+// unitVector returns a unit-length copy of vector; a zero vector stays zero
+func unitVector(vector []float32) []float32 {
+	var norm float64
+	for _, value := range vector {
+		norm += float64(value) * float64(value)
+	}
+	scaled := make([]float32, len(vector))
+	if norm == 0 {
+		return scaled
+	}
+	scale := 1 / math.Sqrt(norm)
+	for i, value := range vector {
+		scaled[i] = float32(float64(value) * scale)
+	}
+	return scaled
+}
+
+// dot32 returns the dot product of two vectors of equal length
+func dot32(a []float32, b []float32) float64 {
+	var sum float64
+	for i, value := range a {
+		sum += float64(value) * float64(b[i])
+	}
+	return sum
+}
+
+// dot64 returns the dot product of a vector with a float64 vector of equal length
+func dot64(a []float32, b []float64) float64 {
+	var sum float64
+	for i, value := range a {
+		sum += float64(value) * b[i]
+	}
+	return sum
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/projection"
+	"refo-rag-server/internal/storage"
+)
+
+// ErrProjectionTraining is returned when an embedding projection is already being trained
+var ErrProjectionTraining = errors.New("an embedding projection is already being trained")
+
+// ErrUnknownEmbeddingModel is returned for a model no collection embeds with
+var ErrUnknownEmbeddingModel = errors.New("no collection uses this embedding model")
+
+// defaultProjectionSamples is how many conversations a projection is trained on by default
+const defaultProjectionSamples = 5000
+
+// projectionEmbedBatch is how many sampled texts are embedded per request when training
+const projectionEmbedBatch = 100
+
+// EmbeddingProjections reports, reloads and trains the dimensionality reduction of embeddings
+type EmbeddingProjections struct {
+	conversations *ConversationService
+	providers     map[string]storage.EmbeddingProvider
+	projectors    map[string]*projection.Projector
+	jobs          *JobLog
+	dir           string
+	defaultModel  string
+
+	// training is set while a matrix is being trained
+	training atomic.Bool
+}
+
+// NewEmbeddingProjections creates the projection service for the providers and projectors of
+// each model. Trained matrices are written to dir, and training defaults to defaultModel
+func NewEmbeddingProjections(conversations *ConversationService, providers map[string]storage.EmbeddingProvider, projectors map[string]*projection.Projector, jobs *JobLog, dir string, defaultModel string) *EmbeddingProjections {
+	return &EmbeddingProjections{
+		conversations: conversations,
+		providers:     providers,
+		projectors:    projectors,
+		jobs:          jobs,
+		dir:           dir,
+		defaultModel:  defaultModel,
+	}
+}
+
+// List describes the projection of each model that has one
+func (ep *EmbeddingProjections) List() []models.EmbeddingProjection {
+	projections := make([]models.EmbeddingProjection, 0, len(ep.projectors))
+	for model, projector := range ep.projectors {
+		projections = append(projections, describeProjection(model, projector))
+	}
+	sort.Slice(projections, func(a, b int) bool { return projections[a].Model < projections[b].Model })
+	return projections
+}
+
+// Reload reads every PCA matrix from its file again. Vectors stored under the previous matrix
+// aren't comparable with queries projected by a new one, so a matrix is replaced only together
+// with a reindex of its collections
+func (ep *EmbeddingProjections) Reload() ([]models.EmbeddingProjection, error) {
+	for model, projector := range ep.projectors {
+		if err := projector.Reload(); err != nil {
+			return nil, fmt.Errorf("failed to reload projection of %s: %w", model, err)
+		}
+	}
+	return ep.List(), nil
+}
+
+// StartTraining starts a background job fitting a PCA matrix to full vectors of sampled stored
+// conversations and writing it to a new file. It returns the job ID; the job's result describes
+// the matrix
+func (ep *EmbeddingProjections) StartTraining(ctx context.Context, req *models.EmbeddingProjectionTrainRequest) (string, error) {
+	model := req.Model
+	if model == "" {
+		model = ep.defaultModel
+	}
+	provider, ok := ep.providers[model]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownEmbeddingModel, model)
+	}
+	samples := req.Samples
+	if samples == 0 {
+		samples = defaultProjectionSamples
+	}
+
+	if !ep.training.CompareAndSwap(false, true) {
+		return "", ErrProjectionTraining
+	}
+	jobID, err := ep.jobs.Start(ctx, models.JobKindProjectionTrain, model, func(ctx context.Context) (interface{}, error) {
+		defer ep.training.Store(false)
+		return ep.train(ctx, model, storage.Unprojected(provider), req.Dimension, samples)
+	})
+	if err != nil {
+		ep.training.Store(false)
+		return "", err
+	}
+	return jobID, nil
+}
+
+// train embeds up to samples stored conversations with the full model and fits a matrix to them
+func (ep *EmbeddingProjections) train(ctx context.Context, model string, provider storage.EmbeddingProvider, dimension int, samples int) (*models.EmbeddingProjectionTrainResult, error) {
+	start := time.Now()
+
+	texts, err := ep.conversations.sampleEmbedTexts(ctx, samples)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, 0, len(texts))
+	for offset := 0; offset < len(texts); offset += projectionEmbedBatch {
+		batch, err := provider.EmbedBatch(ctx, texts[offset:min(offset+projectionEmbedBatch, len(texts))])
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed training samples: %w", err)
+		}
+		for _, vector := range batch {
+			if vector != nil {
+				vectors = append(vectors, vector)
+			}
+		}
+	}
+
+	matrix, err := projection.Fit(ctx, vectors, dimension)
+	if err != nil {
+		return nil, err
+	}
+	matrix.Model = model
+
+	path := projection.MatrixPath(ep.dir, model, matrix.TrainedAt)
+	if err := matrix.Save(path); err != nil {
+		return nil, err
+	}
+
+	return &models.EmbeddingProjectionTrainResult{
+		Model:             model,
+		Path:              path,
+		InputDimension:    matrix.InputDimension,
+		OutputDimension:   matrix.OutputDimension,
+		Samples:           matrix.Samples,
+		ExplainedVariance: matrix.ExplainedVariance,
+		DurationMs:        time.Since(start).Milliseconds(),
+	}, nil
+}
+
+// sampleEmbedTexts returns the embedded text of up to n random stored conversations
+func (cs *ConversationService) sampleEmbedTexts(ctx context.Context, n int) ([]string, error) {
+	ids, err := cs.sampleConversationIDs(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+
+	texts := make([]string, 0, len(ids))
+	for offset := 0; offset < len(ids); offset += projectionEmbedBatch {
+		conversations, _, err := cs.conversationStore.GetConversationsByIDs(ctx, ids[offset:min(offset+projectionEmbedBatch, len(ids))])
+		if err != nil {
+			return nil, fmt.Errorf("failed to get conversations: %w", err)
+		}
+		for _, conv := range conversations {
+			if text := cs.embedText(conversationMessages(conv)); text != "" {
+				texts = append(texts, text)
+			}
+		}
+	}
+	return texts, nil
+}
+
+// describeProjection describes a model's projection
+func describeProjection(model string, projector *projection.Projector) models.EmbeddingProjection {
+	spec := projector.Spec()
+	described := models.EmbeddingProjection{
+		Model:           model,
+		Method:          spec.Method,
+		OutputDimension: projector.OutputDimension(),
+	}
+	if matrix := projector.Matrix(); matrix != nil {
+		trainedAt := matrix.TrainedAt
+		described.Path = spec.Path
+		described.InputDimension = matrix.InputDimension
+		described.ExplainedVariance = matrix.ExplainedVariance
+		described.Samples = matrix.Samples
+		described.TrainedAt = &trainedAt
+		described.LoadedAt = projector.LoadedAt()
+	}
+	return described
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// forgetBatchSize bounds the conversations deleted per query
+const forgetBatchSize = 500
+
+// ForgettingPolicy decides when a conversation is forgotten
+type ForgettingPolicy struct {
+	// Threshold is the decayed importance below which a conversation is deleted
+	Threshold float64
+
+	// HalfLife is the age at which a conversation's importance halves
+	HalfLife time.Duration
+
+	// MinAge protects conversations updated more recently than this, whatever their score
+	MinAge time.Duration
+}
+
+// String describes the policy for deletion certificates
+func (p ForgettingPolicy) String() string {
+	return fmt.Sprintf("retention: decayed importance below %g with a half-life of %s, not updated for %s", p.Threshold, p.HalfLife, p.MinAge)
+}
+
+// ForgettingService deletes conversations whose decayed importance fell below the policy threshold
+type ForgettingService struct {
+	conversationStore storage.ConversationStore
+	vectorStore       storage.VectorStore
+	jobs              *JobLog
+	tokens            *ConfirmationTokens
+	policy            ForgettingPolicy
+	certificates      *DeletionCertificates
+}
+
+// NewForgettingService creates a new forgetting service
+func NewForgettingService(
+	conversationStore storage.ConversationStore,
+	vectorStore storage.VectorStore,
+	jobs *JobLog,
+	tokens *ConfirmationTokens,
+	policy ForgettingPolicy,
+) *ForgettingService {
+	return &ForgettingService{
+		conversationStore: conversationStore,
+		vectorStore:       vectorStore,
+		jobs:              jobs,
+		tokens:            tokens,
+		policy:            policy,
+	}
+}
+
+// SetCertificates makes executed runs that delete anything issue a deletion certificate
+func (fs *ForgettingService) SetCertificates(certificates *DeletionCertificates) {
+	fs.certificates = certificates
+}
+
+// Run applies the forgetting policy as a logged retention job; a dry run only reports the
+// conversations that would be deleted
+func (fs *ForgettingService) Run(ctx context.Context, dryRun bool) (*models.RetentionRunResponse, error) {
+	startTime := time.Now()
+
+	var result *models.RetentionRunResponse
+	jobID, err := fs.jobs.Run(ctx, models.JobKindRetention, "", dryRun, func(ctx context.Context) (interface{}, error) {
+		var err error
+		result, err = fs.Forget(ctx, dryRun)
+		result.DurationMs = time.Since(startTime).Milliseconds()
+		return result, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.JobID = jobID
+	if !dryRun && result.Conversations > 0 && fs.certificates != nil {
+		result.CertificateID = fs.certificates.Issue(ctx, &models.DeletionCertificate{
+			JobID:           jobID,
+			Kind:            models.JobKindRetention,
+			Policy:          fs.policy.String(),
+			Removed:         models.UserDataCounts{Conversations: int64(result.Conversations)},
+			ConversationIDs: result.ConversationIDs,
+		})
+	}
+	return result, nil
+}
+
+// RequestRun previews a retention run and, if it would delete anything, issues the token that confirms it
+func (fs *ForgettingService) RequestRun(ctx context.Context) (*models.RetentionRunResponse, error) {
+	result, err := fs.Run(ctx, true)
+	if err != nil || result.Conversations == 0 {
+		return result, err
+	}
+
+	if result.Confirmation, err = fs.tokens.Issue(models.JobKindRetention, "", result.ConversationIDs); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ConfirmRun applies the retention policy if the token was issued for a preview of the same
+// conversations
+func (fs *ForgettingService) ConfirmRun(ctx context.Context, token string) (*models.RetentionRunResponse, error) {
+	preview, err := fs.Forget(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.tokens.Redeem(token, models.JobKindRetention, "", preview.ConversationIDs); err != nil {
+		return nil, err
+	}
+
+	return fs.Run(ctx, false)
+}
+
+// Forget deletes every conversation the policy marks as forgettable and reports what was deleted.
+// In a dry run nothing is deleted and the report lists what would be.
+func (fs *ForgettingService) Forget(ctx context.Context, dryRun bool) (*models.RetentionRunResponse, error) {
+	result := &models.RetentionRunResponse{DryRun: dryRun, ConversationIDs: []string{}}
+	before := time.Now().Add(-fs.policy.MinAge)
+
+	offset := 0
+	for {
+		ids, err := fs.conversationStore.ListForgettableConversations(ctx, fs.policy.Threshold, fs.policy.HalfLife, before, forgetBatchSize, offset)
+		if err != nil {
+			return result, err
+		}
+
+		conversations, _, err := fs.conversationStore.GetConversationsByIDs(ctx, ids)
+		if err != nil {
+			return result, err
+		}
+		for _, conv := range conversations {
+			result.TextBytes += conversationTextBytes(conv)
+		}
+
+		for _, id := range ids {
+			if !dryRun {
+				if err := fs.conversationStore.DeleteConversation(ctx, id); err != nil {
+					return result, err
+				}
+				if err := fs.vectorStore.DeleteVector(ctx, id); err != nil {
+					// Log error but continue - the conversation is already gone from PostgreSQL
+					fmt.Printf("warning: failed to delete forgotten conversation vector %s: %v\n", id, err)
+					errreport.Background(ctx, "conversation_vector_delete", err)
+				}
+			}
+			result.ConversationIDs = append(result.ConversationIDs, id)
+			result.Conversations++
+		}
+
+		// Deleted rows drop out of the next page; previewed rows have to be skipped
+		if dryRun {
+			offset += len(ids)
+		}
+		if len(ids) < forgetBatchSize {
+			return result, nil
+		}
+	}
+}
+
+// RunScheduled applies the forgetting policy, the scheduled form of Run
+func (fs *ForgettingService) RunScheduled(ctx context.Context) error {
+	result, err := fs.Run(ctx, false)
+	if result != nil && result.Conversations > 0 {
+		fmt.Printf("forgot %d low-importance conversations\n", result.Conversations)
+	}
+	return err
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/bandit"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/retrieval"
+	"refo-rag-server/internal/storage"
+	"refo-rag-server/internal/tenant"
+)
+
+// ErrUnknownFusionArm is returned for an arm the fusion bandit isn't configured with
+var ErrUnknownFusionArm = errors.New("unknown fusion bandit arm")
+
+// FusionBanditOptions configures the fusion bandit
+type FusionBanditOptions struct {
+	// Arms are the fusion weights searches are served with; the first is the baseline
+	Arms []bandit.Arm
+
+	// Guardrails bound how far the bandit strays from the baseline
+	Guardrails bandit.Guardrails
+
+	// Refresh is how long a tenant's stats are used before they are read again; feedback and
+	// freezes on another replica take effect within it
+	Refresh time.Duration
+}
+
+// FusionBandit tunes each tenant's fusion weights online: it serves searches with one of the
+// configured arms and credits relevance feedback on their results to that arm, so searches drift
+// towards the weights whose results users find relevant
+type FusionBandit struct {
+	store storage.FusionBanditStore
+	opts  FusionBanditOptions
+
+	mu     sync.Mutex
+	rng    *rand.Rand
+	cached map[string]cachedFusionBandit
+}
+
+// cachedFusionBandit is a tenant's bandit state as last read
+type cachedFusionBandit struct {
+	stats     map[string]bandit.Stats
+	tenant    *models.FusionBanditTenant
+	expiresAt time.Time
+}
+
+// NewFusionBandit creates a fusion bandit over arms that must fit pipeline: their fusion weights
+// need a weighted fuser and name only its retrievers, and recency weights need its recency
+// reranker. The guardrails' share and regression are rates between 0 and 1
+func NewFusionBandit(store storage.FusionBanditStore, pipeline *retrieval.Pipeline, opts FusionBanditOptions) (*FusionBandit, error) {
+	spec := pipeline.Spec()
+	for _, arm := range opts.Arms {
+		for name := range arm.FusionWeights {
+			if !slices.Contains(spec.Retrievers, name) {
+				return nil, fmt.Errorf("fusion bandit arm %s weighs %s, which is not a search retriever (%v)", arm.Name, name, spec.Retrievers)
+			}
+		}
+		if len(arm.FusionWeights) > 0 && !pipeline.WeightsFusion() {
+			return nil, fmt.Errorf("fusion bandit arm %s needs a fuser that supports fusion weights, not %s", arm.Name, spec.Fuser)
+		}
+		if arm.RecencyWeight != nil && !slices.Contains(spec.Rerankers, "recency") {
+			return nil, fmt.Errorf("fusion bandit arm %s sets a recency weight, but the recency reranker is not configured", arm.Name)
+		}
+	}
+	guard := opts.Guardrails
+	if !(guard.BaselineShare >= 0 && guard.BaselineShare <= 1) {
+		return nil, fmt.Errorf("fusion bandit baseline share must be between 0 and 1, not %v", guard.BaselineShare)
+	}
+	if guard.MinTrials < 0 || !(guard.MaxRegression >= 0 && guard.MaxRegression <= 1) {
+		return nil, fmt.Errorf("fusion bandit min trials must not be negative and max regression must be between 0 and 1")
+	}
+	if opts.Refresh <= 0 {
+		opts.Refresh = time.Minute
+	}
+	return &FusionBandit{
+		store:  store,
+		opts:   opts,
+		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		cached: make(map[string]cachedFusionBandit),
+	}, nil
+}
+
+// Choose returns the arm to serve one of the request tenant's searches with and its retrieval
+// overrides. A frozen tenant always gets its frozen arm; when the tenant's stats can't be read,
+// the baseline serves the search
+func (fb *FusionBandit) Choose(ctx context.Context) (string, models.RetrievalOverrides) {
+	state, err := fb.state(ctx, tenant.FromContext(ctx))
+	if err != nil {
+		fmt.Printf("warning: failed to read fusion bandit state, serving the baseline: %v\n", err)
+		return fb.serve(fb.opts.Arms[0], "baseline")
+	}
+	if i := fb.armIndex(state.tenant.FrozenArm); i >= 0 {
+		return fb.serve(fb.opts.Arms[i], "frozen")
+	}
+
+	fb.mu.Lock()
+	i := bandit.Choose(fb.opts.Arms, state.stats, fb.opts.Guardrails, fb.rng)
+	fb.mu.Unlock()
+	return fb.serve(fb.opts.Arms[i], "sampled")
+}
+
+// serve counts a choice and returns the arm's name and overrides
+func (fb *FusionBandit) serve(arm bandit.Arm, reason string) (string, models.RetrievalOverrides) {
+	metrics.FusionBanditChoices.WithLabelValues(arm.Name, reason).Inc()
+	overrides := models.RetrievalOverrides{RecencyWeight: arm.RecencyWeight}
+	if len(arm.FusionWeights) > 0 {
+		overrides.FusionWeights = arm.FusionWeights
+	}
+	return arm.Name, overrides
+}
+
+// Feedback credits a relevance rating of a search's result to the arm that served it. Ratings of
+// a frozen tenant aren't counted, since the bandit didn't choose the arm. It fails with
+// ErrUnknownFusionArm
+func (fb *FusionBandit) Feedback(ctx context.Context, req *models.SearchFeedbackRequest) (*models.SearchFeedbackResponse, error) {
+	if fb.armIndex(req.FusionArm) < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFusionArm, req.FusionArm)
+	}
+	tenantID := tenant.FromContext(ctx)
+	resp := &models.SearchFeedbackResponse{FusionArm: req.FusionArm, Relevant: *req.Relevant}
+
+	state, err := fb.state(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if state.tenant.FrozenArm != "" {
+		return resp, nil
+	}
+	if err := fb.store.AddFusionBanditFeedback(ctx, tenantID, req.FusionArm, *req.Relevant); err != nil {
+		return nil, err
+	}
+	outcome := "irrelevant"
+	if *req.Relevant {
+		outcome = "relevant"
+	}
+	metrics.FusionBanditFeedback.WithLabelValues(req.FusionArm, outcome).Inc()
+	fb.forget(tenantID)
+	resp.Counted = true
+	return resp, nil
+}
+
+// Status describes a tenant's arms, the feedback they collected and whether the tenant is frozen
+func (fb *FusionBandit) Status(ctx context.Context, tenantID string) (*models.FusionBanditStatus, error) {
+	state, err := fb.read(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	guard := fb.opts.Guardrails
+	status := &models.FusionBanditStatus{
+		Tenant:        tenantID,
+		Arms:          make([]models.FusionBanditArmStatus, 0, len(fb.opts.Arms)),
+		BestArm:       fb.opts.Arms[bandit.Best(fb.opts.Arms, state.stats, guard)].Name,
+		FrozenArm:     state.tenant.FrozenArm,
+		FrozenAt:      state.tenant.FrozenAt,
+		BaselineShare: guard.BaselineShare,
+		MinTrials:     guard.MinTrials,
+		MaxRegression: guard.MaxRegression,
+	}
+	for i, arm := range fb.opts.Arms {
+		stats := state.stats[arm.Name]
+		status.Arms = append(status.Arms, models.FusionBanditArmStatus{
+			Name:          arm.Name,
+			FusionWeights: arm.FusionWeights,
+			RecencyWeight: arm.RecencyWeight,
+			Baseline:      i == 0,
+			Trials:        stats.Trials,
+			Rewards:       stats.Rewards,
+			RelevanceRate: stats.Mean(),
+			Demoted:       bandit.Demoted(fb.opts.Arms, state.stats, guard, i),
+		})
+	}
+	return status, nil
+}
+
+// Freeze pins a tenant's searches to an arm, by default its best one, and returns the tenant's
+// status. It fails with ErrUnknownFusionArm
+func (fb *FusionBandit) Freeze(ctx context.Context, tenantID string, arm string) (*models.FusionBanditStatus, error) {
+	if arm == "" {
+		state, err := fb.read(ctx, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		arm = fb.opts.Arms[bandit.Best(fb.opts.Arms, state.stats, fb.opts.Guardrails)].Name
+	}
+	if fb.armIndex(arm) < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFusionArm, arm)
+	}
+	if err := fb.store.SetFusionBanditFrozenArm(ctx, tenantID, arm); err != nil {
+		return nil, err
+	}
+	fb.forget(tenantID)
+	return fb.Status(ctx, tenantID)
+}
+
+// Unfreeze lets the bandit choose a tenant's arms again and returns the tenant's status
+func (fb *FusionBandit) Unfreeze(ctx context.Context, tenantID string) (*models.FusionBanditStatus, error) {
+	if err := fb.store.SetFusionBanditFrozenArm(ctx, tenantID, ""); err != nil {
+		return nil, err
+	}
+	fb.forget(tenantID)
+	return fb.Status(ctx, tenantID)
+}
+
+// chooseFusionArm serves a primary search without overrides with an arm of the fusion bandit,
+// setting its overrides on query, and returns the variant extended by the arm for the query cache
+func (cs *ConversationService) chooseFusionArm(ctx context.Context, req *models.ConversationSearchRequest, query *retrieval.Query, variant string) string {
+	if cs.opts.FusionBandit == nil || req.Overrides != nil || variant != VariantPrimary {
+		return variant
+	}
+	arm, overrides := cs.opts.FusionBandit.Choose(ctx)
+	query.Overrides = overrides
+	bandit.Record(ctx, arm)
+	return variant + "/" + arm
+}
+
+// state returns a tenant's cached bandit state, reading it when it is missing or stale
+func (fb *FusionBandit) state(ctx context.Context, tenantID string) (cachedFusionBandit, error) {
+	fb.mu.Lock()
+	cached, ok := fb.cached[tenantID]
+	fb.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached, nil
+	}
+
+	cached, err := fb.read(ctx, tenantID)
+	if err != nil {
+		return cachedFusionBandit{}, err
+	}
+	fb.mu.Lock()
+	fb.cached[tenantID] = cached
+	fb.mu.Unlock()
+	return cached, nil
+}
+
+// read reads a tenant's bandit state from the store
+func (fb *FusionBandit) read(ctx context.Context, tenantID string) (cachedFusionBandit, error) {
+	arms, err := fb.store.GetFusionBanditArms(ctx, tenantID)
+	if err != nil {
+		return cachedFusionBandit{}, err
+	}
+	state, err := fb.store.GetFusionBanditTenant(ctx, tenantID)
+	if err != nil {
+		return cachedFusionBandit{}, err
+	}
+
+	stats := make(map[string]bandit.Stats, len(arms))
+	for _, arm := range arms {
+		stats[arm.Arm] = bandit.Stats{Trials: arm.Trials, Rewards: arm.Rewards}
+	}
+	return cachedFusionBandit{stats: stats, tenant: state, expiresAt: time.Now().Add(fb.opts.Refresh)}, nil
+}
+
+// forget drops a tenant's cached state so its next search reads it again
+func (fb *FusionBandit) forget(tenantID string) {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	delete(fb.cached, tenantID)
+}
+
+// armIndex returns the index of the named arm, or -1 if no arm has the name
+func (fb *FusionBandit) armIndex(name string) int {
+	for i, arm := range fb.opts.Arms {
+		if arm.Name == name {
+			return i
+		}
+	}
+	return -1
+}
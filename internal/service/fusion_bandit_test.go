@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"refo-rag-server/internal/bandit"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/retrieval"
+	"refo-rag-server/internal/tenant"
+)
+
+// memoryFusionBanditStore keeps fusion bandit state in memory
+type memoryFusionBanditStore struct {
+	mu     sync.Mutex
+	stats  map[string]map[string]*models.FusionBanditArmStats
+	frozen map[string]string
+	err    error
+}
+
+func newMemoryFusionBanditStore() *memoryFusionBanditStore {
+	return &memoryFusionBanditStore{
+		stats:  make(map[string]map[string]*models.FusionBanditArmStats),
+		frozen: make(map[string]string),
+	}
+}
+
+func (s *memoryFusionBanditStore) AddFusionBanditFeedback(ctx context.Context, tenantID string, arm string, relevant bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stats[tenantID] == nil {
+		s.stats[tenantID] = make(map[string]*models.FusionBanditArmStats)
+	}
+	stats := s.stats[tenantID][arm]
+	if stats == nil {
+		stats = &models.FusionBanditArmStats{Arm: arm}
+		s.stats[tenantID][arm] = stats
+	}
+	stats.Trials++
+	if relevant {
+		stats.Rewards++
+	}
+	return nil
+}
+
+func (s *memoryFusionBanditStore) GetFusionBanditArms(ctx context.Context, tenantID string) ([]models.FusionBanditArmStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	var arms []models.FusionBanditArmStats
+	for _, stats := range s.stats[tenantID] {
+		arms = append(arms, *stats)
+	}
+	return arms, nil
+}
+
+func (s *memoryFusionBanditStore) GetFusionBanditTenant(ctx context.Context, tenantID string) (*models.FusionBanditTenant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &models.FusionBanditTenant{Tenant: tenantID, FrozenArm: s.frozen[tenantID]}, nil
+}
+
+func (s *memoryFusionBanditStore) SetFusionBanditFrozenArm(ctx context.Context, tenantID string, arm string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.frozen[tenantID] = arm
+	return nil
+}
+
+// set replaces the stats of a tenant's arm
+func (s *memoryFusionBanditStore) set(tenantID string, arm string, trials int64, rewards int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stats[tenantID] == nil {
+		s.stats[tenantID] = make(map[string]*models.FusionBanditArmStats)
+	}
+	s.stats[tenantID][arm] = &models.FusionBanditArmStats{Arm: arm, Trials: trials, Rewards: rewards}
+}
+
+// testFusionArms are a baseline and two arms that reweigh the vector retriever and recency
+func testFusionArms() []bandit.Arm {
+	fresh := 0.4
+	return []bandit.Arm{
+		{Name: "baseline", FusionWeights: map[string]float64{"vector": 1}},
+		{Name: "fresh", FusionWeights: map[string]float64{"vector": 1}, RecencyWeight: &fresh},
+		{Name: "damped", FusionWeights: map[string]float64{"vector": 0.5}},
+	}
+}
+
+// testFusionPipeline builds a pipeline the test arms fit: a vector retriever, a fuser that
+// takes weights and the recency reranker
+func testFusionPipeline(t *testing.T) *retrieval.Pipeline {
+	t.Helper()
+	deps := retrieval.Deps{Vectors: &reindexVectorStore{}, Embedder: &reindexEmbedder{}}
+	pipeline, err := retrieval.Build(retrieval.Spec{Retrievers: []string{"vector"}, Fuser: "rrf", Rerankers: []string{"recency"}}, deps)
+	if err != nil {
+		t.Fatalf("build pipeline: %v", err)
+	}
+	return pipeline
+}
+
+// newTestFusionBandit creates a fusion bandit whose choices follow seed
+func newTestFusionBandit(t *testing.T, store *memoryFusionBanditStore, guard bandit.Guardrails, seed int64) *FusionBandit {
+	t.Helper()
+	fb, err := NewFusionBandit(store, testFusionPipeline(t), FusionBanditOptions{Arms: testFusionArms(), Guardrails: guard, Refresh: time.Hour})
+	if err != nil {
+		t.Fatalf("NewFusionBandit: %v", err)
+	}
+	fb.rng = rand.New(rand.NewSource(seed))
+	return fb
+}
+
+// chooseMany counts the arms n searches of a tenant are served with
+func chooseMany(fb *FusionBandit, tenantID string, n int) map[string]int {
+	ctx := tenant.WithTenant(context.Background(), tenantID)
+	counts := make(map[string]int)
+	for range n {
+		arm, _ := fb.Choose(ctx)
+		counts[arm]++
+	}
+	return counts
+}
+
+func TestFusionBanditChoose(t *testing.T) {
+	cases := []struct {
+		name  string
+		guard bandit.Guardrails
+		stats map[string][2]int64 // trials and rewards of each arm
+		check func(t *testing.T, counts map[string]int)
+	}{
+		{
+			name:  "baseline share of one serves only the baseline",
+			guard: bandit.Guardrails{BaselineShare: 1},
+			stats: map[string][2]int64{"fresh": {1000, 1000}},
+			check: func(t *testing.T, counts map[string]int) {
+				if counts["baseline"] != 1000 {
+					t.Errorf("baseline served %d of 1000 searches, want all", counts["baseline"])
+				}
+			},
+		},
+		{
+			name:  "no feedback explores every arm",
+			guard: bandit.Guardrails{},
+			check: func(t *testing.T, counts map[string]int) {
+				for _, arm := range []string{"baseline", "fresh", "damped"} {
+					if counts[arm] < 250 || counts[arm] > 420 {
+						t.Errorf("%s served %d of 1000 searches, want about a third", arm, counts[arm])
+					}
+				}
+			},
+		},
+		{
+			name:  "proven arm takes the searches the baseline share leaves",
+			guard: bandit.Guardrails{BaselineShare: 0.2},
+			stats: map[string][2]int64{"baseline": {500, 100}, "fresh": {500, 400}, "damped": {500, 150}},
+			check: func(t *testing.T, counts map[string]int) {
+				if counts["baseline"] < 170 || counts["baseline"] > 230 {
+					t.Errorf("baseline served %d of 1000 searches, want about its share of 200", counts["baseline"])
+				}
+				if counts["fresh"] != 1000-counts["baseline"] {
+					t.Errorf("fresh served %d of 1000 searches, want all but the baseline's %d", counts["fresh"], counts["baseline"])
+				}
+			},
+		},
+		{
+			name:  "demoted arm is never chosen",
+			guard: bandit.Guardrails{MinTrials: 30, MaxRegression: 0.05},
+			stats: map[string][2]int64{"baseline": {30, 15}, "fresh": {30, 10}, "damped": {10, 3}},
+			check: func(t *testing.T, counts map[string]int) {
+				if counts["fresh"] != 0 {
+					t.Errorf("demoted arm fresh served %d searches", counts["fresh"])
+				}
+				// damped has too few trials to be demoted, so it is still explored
+				if counts["damped"] == 0 {
+					t.Error("damped was never chosen before reaching the minimum trials")
+				}
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMemoryFusionBanditStore()
+			for arm, s := range tc.stats {
+				store.set("acme", arm, s[0], s[1])
+			}
+			fb := newTestFusionBandit(t, store, tc.guard, 1)
+			tc.check(t, chooseMany(fb, "acme", 1000))
+		})
+	}
+}
+
+func TestFusionBanditChooseIsSeeded(t *testing.T) {
+	store := newMemoryFusionBanditStore()
+	store.set("acme", "baseline", 40, 20)
+	store.set("acme", "fresh", 40, 24)
+	ctx := tenant.WithTenant(context.Background(), "acme")
+
+	first := newTestFusionBandit(t, store, bandit.Guardrails{BaselineShare: 0.1}, 7)
+	second := newTestFusionBandit(t, store, bandit.Guardrails{BaselineShare: 0.1}, 7)
+	for i := range 200 {
+		a, _ := first.Choose(ctx)
+		b, _ := second.Choose(ctx)
+		if a != b {
+			t.Fatalf("search %d was served with %s and %s by bandits with the same seed", i, a, b)
+		}
+	}
+}
+
+func TestFusionBanditChooseOverrides(t *testing.T) {
+	store := newMemoryFusionBanditStore()
+	fb := newTestFusionBandit(t, store, bandit.Guardrails{}, 1)
+	ctx := tenant.WithTenant(context.Background(), "acme")
+
+	if err := store.SetFusionBanditFrozenArm(ctx, "acme", "fresh"); err != nil {
+		t.Fatal(err)
+	}
+	for range 20 {
+		arm, overrides := fb.Choose(ctx)
+		if arm != "fresh" {
+			t.Fatalf("frozen tenant was served with %s, want fresh", arm)
+		}
+		if overrides.RecencyWeight == nil || *overrides.RecencyWeight != 0.4 || overrides.FusionWeights["vector"] != 1 {
+			t.Fatalf("fresh served with overrides %+v, want its weights", overrides)
+		}
+	}
+
+	// A tenant whose stats can't be read gets the baseline
+	store.err = errors.New("connection refused")
+	if arm, _ := fb.Choose(tenant.WithTenant(context.Background(), "globex")); arm != "baseline" {
+		t.Errorf("search was served with %s when the stats couldn't be read, want the baseline", arm)
+	}
+}
+
+func TestFusionBanditFeedback(t *testing.T) {
+	store := newMemoryFusionBanditStore()
+	fb := newTestFusionBandit(t, store, bandit.Guardrails{MinTrials: 20, MaxRegression: 0.1}, 3)
+	ctx := tenant.WithTenant(context.Background(), "acme")
+
+	rate := func(arm string, relevant bool) *models.SearchFeedbackResponse {
+		t.Helper()
+		resp, err := fb.Feedback(ctx, &models.SearchFeedbackRequest{FusionArm: arm, Relevant: &relevant})
+		if err != nil {
+			t.Fatalf("Feedback(%s): %v", arm, err)
+		}
+		return resp
+	}
+
+	// The choices read the stats before any feedback is cached
+	chooseMany(fb, "acme", 10)
+
+	// fresh is found relevant three times in four, damped once in four, the baseline half the time
+	for i := range 40 {
+		if resp := rate("fresh", i%4 != 0); !resp.Counted {
+			t.Fatalf("feedback of an unfrozen tenant wasn't counted: %+v", resp)
+		}
+		rate("damped", i%4 == 0)
+		rate("baseline", i%2 == 0)
+	}
+
+	status, err := fb.Status(ctx, "acme")
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	want := map[string]models.FusionBanditArmStatus{
+		"baseline": {Trials: 40, Rewards: 20, RelevanceRate: 0.5},
+		"fresh":    {Trials: 40, Rewards: 30, RelevanceRate: 0.75},
+		"damped":   {Trials: 40, Rewards: 10, RelevanceRate: 0.25, Demoted: true},
+	}
+	for _, arm := range status.Arms {
+		w := want[arm.Name]
+		if arm.Trials != w.Trials || arm.Rewards != w.Rewards || arm.RelevanceRate != w.RelevanceRate || arm.Demoted != w.Demoted {
+			t.Errorf("arm %s = %d trials, %d rewards, rate %v, demoted %v; want %d, %d, %v, %v",
+				arm.Name, arm.Trials, arm.Rewards, arm.RelevanceRate, arm.Demoted, w.Trials, w.Rewards, w.RelevanceRate, w.Demoted)
+		}
+	}
+	if status.BestArm != "fresh" {
+		t.Errorf("best arm is %s, want fresh", status.BestArm)
+	}
+
+	// Feedback drops the cached stats, so the next searches drift to fresh
+	counts := chooseMany(fb, "acme", 1000)
+	if counts["damped"] != 0 {
+		t.Errorf("demoted arm damped served %d searches", counts["damped"])
+	}
+	if counts["fresh"] < 900 {
+		t.Errorf("fresh served %d of 1000 searches after its feedback, want most", counts["fresh"])
+	}
+
+	// Other tenants learn separately
+	if status, err := fb.Status(ctx, "globex"); err != nil || status.Arms[1].Trials != 0 {
+		t.Errorf("another tenant's status = %+v, %v; want no trials", status, err)
+	}
+}
+
+func TestFusionBanditFeedbackNotCounted(t *testing.T) {
+	store := newMemoryFusionBanditStore()
+	fb := newTestFusionBandit(t, store, bandit.Guardrails{}, 1)
+	ctx := tenant.WithTenant(context.Background(), "acme")
+	relevant := true
+
+	_, err := fb.Feedback(ctx, &models.SearchFeedbackRequest{FusionArm: "unknown", Relevant: &relevant})
+	if !errors.Is(err, ErrUnknownFusionArm) {
+		t.Errorf("feedback on an unknown arm returned %v, want ErrUnknownFusionArm", err)
+	}
+
+	if _, err := fb.Freeze(ctx, "acme", "damped"); err != nil {
+		t.Fatalf("Freeze: %v", err)
+	}
+	resp, err := fb.Feedback(ctx, &models.SearchFeedbackRequest{FusionArm: "damped", Relevant: &relevant})
+	if err != nil || resp.Counted {
+		t.Errorf("feedback of a frozen tenant = %+v, %v; want it not counted", resp, err)
+	}
+	if arms, _ := store.GetFusionBanditArms(ctx, "acme"); len(arms) != 0 {
+		t.Errorf("frozen tenant's feedback was stored: %+v", arms)
+	}
+}
+
+func TestNewFusionBanditRejectsGuardrails(t *testing.T) {
+	pipeline := testFusionPipeline(t)
+	nan := 0.0
+	nan /= nan
+	for _, guard := range []bandit.Guardrails{
+		{BaselineShare: -0.1},
+		{BaselineShare: 1.5},
+		{BaselineShare: nan},
+		{MinTrials: -1},
+		{MaxRegression: -0.1},
+		{MaxRegression: 2},
+	} {
+		if _, err := NewFusionBandit(newMemoryFusionBanditStore(), pipeline, FusionBanditOptions{Arms: testFusionArms(), Guardrails: guard}); err == nil {
+			t.Errorf("NewFusionBandit accepted guardrails %+v", guard)
+		}
+	}
+}
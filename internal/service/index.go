@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// Index health warning thresholds
+const (
+	// minIndexedPercent is the index coverage below which a collection counts as mostly unindexed
+	minIndexedPercent = 50
+
+	// defaultHNSWM is Qdrant's default HNSW graph degree, used when the collection doesn't report one
+	defaultHNSWM = 16
+)
+
+// ErrOptimizeRunning is returned when an optimization job is already in progress
+var ErrOptimizeRunning = errors.New("an optimization job is already running")
+
+// IndexService reports on and maintains vector indexes and database tables
+type IndexService struct {
+	collections *storage.CollectionManager
+	jobs        *JobLog
+
+	// tables is nil without a Postgres server; optimizing and reporting then skip the tables
+	tables storage.TableMaintenanceStore
+
+	// optimizing is set while an optimization job runs
+	optimizing atomic.Bool
+}
+
+// NewIndexService creates a new index service
+func NewIndexService(collections *storage.CollectionManager, tables storage.TableMaintenanceStore, jobs *JobLog) *IndexService {
+	return &IndexService{
+		collections: collections,
+		tables:      tables,
+		jobs:        jobs,
+	}
+}
+
+// StartOptimize starts a background job that triggers the optimizers of every collection and
+// vacuums and analyzes the Postgres tables. It returns the job ID.
+func (is *IndexService) StartOptimize(ctx context.Context, qdrant bool, postgres bool) (string, error) {
+	if !is.optimizing.CompareAndSwap(false, true) {
+		return "", ErrOptimizeRunning
+	}
+
+	target := "all"
+	switch {
+	case qdrant && !postgres:
+		target = "qdrant"
+	case postgres && !qdrant:
+		target = "postgres"
+	}
+
+	jobID, err := is.jobs.Start(ctx, models.JobKindOptimize, target, func(ctx context.Context) (interface{}, error) {
+		defer is.optimizing.Store(false)
+		return is.optimize(ctx, qdrant, postgres)
+	})
+	if err != nil {
+		is.optimizing.Store(false)
+		return "", err
+	}
+	return jobID, nil
+}
+
+// optimize triggers collection optimizers and vacuums tables, continuing past individual failures
+func (is *IndexService) optimize(ctx context.Context, qdrant bool, postgres bool) (*models.OptimizeResult, error) {
+	start := time.Now()
+	result := &models.OptimizeResult{Collections: []string{}, Tables: []string{}}
+	var errs []error
+
+	if qdrant {
+		for _, contentType := range is.collections.ContentTypes() {
+			store, err := is.collections.Store(contentType)
+			if err == nil {
+				err = store.TriggerOptimizers(ctx)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("collection %s: %w", contentType, err))
+				continue
+			}
+			result.Collections = append(result.Collections, store.Collection())
+		}
+	}
+
+	if postgres && is.tables != nil {
+		for _, table := range storage.BackupTables {
+			if err := is.tables.VacuumAnalyze(ctx, table); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			result.Tables = append(result.Tables, table)
+		}
+	}
+
+	for _, err := range errs {
+		result.Errors = append(result.Errors, err.Error())
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, errors.Join(errs...)
+}
+
+// Health collects collection index coverage, optimizer state and table sizes, with warnings
+func (is *IndexService) Health(ctx context.Context) (*models.IndexHealthResponse, error) {
+	resp := &models.IndexHealthResponse{
+		Warnings:    []string{},
+		Collections: []models.CollectionIndexHealth{},
+	}
+
+	for _, contentType := range is.collections.ContentTypes() {
+		collection, _ := is.collections.Config(contentType)
+		health := models.CollectionIndexHealth{ContentType: contentType, Name: collection.Name}
+
+		store, err := is.collections.Store(contentType)
+		var info *storage.IndexInfo
+		if err == nil {
+			info, err = store.GetIndexInfo(ctx)
+		}
+		if err != nil {
+			health.Error = err.Error()
+			resp.Warnings = append(resp.Warnings, fmt.Sprintf("collection %s: failed to get index info: %v", collection.Name, err))
+			resp.Collections = append(resp.Collections, health)
+			continue
+		}
+
+		health.Status = info.Status
+		health.OptimizerStatus = "ok"
+		if !info.OptimizerOK {
+			health.OptimizerStatus = info.OptimizerError
+		}
+		health.PointsCount = info.PointsCount
+		health.IndexedVectorsCount = info.IndexedVectorsCount
+		health.SegmentsCount = info.SegmentsCount
+		health.OnDisk = info.OnDisk
+		health.IndexedPercent = 100
+		if info.PointsCount > 0 {
+			health.IndexedPercent = 100 * float64(info.IndexedVectorsCount) / float64(info.PointsCount)
+		}
+		health.MemoryEstimateBytes = memoryEstimate(info, collection.Dimension)
+
+		resp.Warnings = append(resp.Warnings, indexWarnings(collection, info, health.IndexedPercent)...)
+		resp.Collections = append(resp.Collections, health)
+	}
+
+	if is.tables != nil {
+		tables, err := is.tables.TableStats(ctx, storage.BackupTables)
+		if err != nil {
+			return nil, err
+		}
+		resp.Tables = tables
+	}
+
+	resp.Healthy = len(resp.Warnings) == 0
+	return resp, nil
+}
+
+// indexWarnings flags optimizer failures, stalled optimizers and mostly unindexed collections
+func indexWarnings(collection storage.CollectionConfig, info *storage.IndexInfo, indexedPercent float64) []string {
+	var warnings []string
+	if !info.OptimizerOK {
+		warnings = append(warnings, fmt.Sprintf("collection %s: optimizer failed: %s", collection.Name, info.OptimizerError))
+	}
+	switch info.Status {
+	case "red":
+		warnings = append(warnings, fmt.Sprintf("collection %s: status is red", collection.Name))
+	case "grey":
+		warnings = append(warnings, fmt.Sprintf("collection %s: optimizers are pending and not running; trigger an optimization", collection.Name))
+	}
+
+	// Qdrant leaves segments below the indexing threshold unindexed, so small collections are expected to be
+	if indexedPercent < minIndexedPercent && info.PointsCount > 2*unindexedPointsAllowance(info, collection.Dimension) {
+		warnings = append(warnings, fmt.Sprintf("collection %s: only %.0f%% of %d vectors are indexed; searches fall back to full scans",
+			collection.Name, indexedPercent, info.PointsCount))
+	}
+	return warnings
+}
+
+// unindexedPointsAllowance is the number of points that fit in a segment below the indexing threshold
+func unindexedPointsAllowance(info *storage.IndexInfo, dimension int) int64 {
+	if info.IndexingThresholdKB <= 0 || dimension <= 0 {
+		return 0
+	}
+	return info.IndexingThresholdKB * 1024 / int64(dimension*4)
+}
+
+// memoryEstimate approximates the RAM held by float32 vectors and the HNSW graph links
+func memoryEstimate(info *storage.IndexInfo, dimension int) int64 {
+	m := info.HNSWM
+	if m <= 0 {
+		m = defaultHNSWM
+	}
+
+	graph := info.IndexedVectorsCount * int64(m) * 2 * 4
+	if info.OnDisk {
+		return graph
+	}
+	return info.PointsCount*int64(dimension)*4 + graph
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"refo-rag-server/internal/models"
+)
+
+// ErrInvalidLane is returned for a save lane that is unknown
+var ErrInvalidLane = errors.New("invalid save lane")
+
+// saveLane resolves the ingestion lane of a save request
+func saveLane(req *models.ConversationSaveRequest) (string, error) {
+	if req.Lane == "" {
+		return models.LaneInteractive, nil
+	}
+	if !models.IsValidLane(req.Lane) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidLane, req.Lane)
+	}
+	return req.Lane, nil
+}
+
+// shapeSave pours a save's embedding into the ingestion shaper's bucket and returns the
+// durability to save at, reporting whether the save is queued because the bucket is full. A save
+// waiting to be searchable, or one whose queued vector nothing would write, waits for room instead
+func (cs *ConversationService) shapeSave(ctx context.Context, req *models.ConversationSaveRequest, lane string, durability string) (string, bool, error) {
+	if cs.opts.Shaper == nil || cs.opts.Shaper.Admit(lane) {
+		return durability, false, nil
+	}
+	if req.WaitForIndexing || cs.opts.VectorWriteMode == VectorWriteRollback {
+		return durability, false, cs.opts.Shaper.Wait(ctx, lane)
+	}
+	return models.DurabilityQueued, true, nil
+}
+
+// waitShaper waits for room in the ingestion shaper's bucket before a queued vector job of kind
+// is embedded
+func (cs *ConversationService) waitShaper(ctx context.Context, kind string) error {
+	if cs.opts.Shaper == nil {
+		return nil
+	}
+	lane := models.LaneInteractive
+	if kind == models.QueueKindConversationVectorBackfill {
+		lane = models.LaneBackfill
+	}
+	return cs.opts.Shaper.Wait(ctx, lane)
+}
+
+// vectorQueueKind is the work queue item kind that embeds a queued save of lane
+func vectorQueueKind(lane string) string {
+	if lane == models.LaneBackfill {
+		return models.QueueKindConversationVectorBackfill
+	}
+	return models.QueueKindConversationVector
+}
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/cluster"
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/insights"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// ErrInsufficientUsers is returned when the sampled conversations come from fewer users than the
+// anonymity threshold, so no group could be released
+var ErrInsufficientUsers = errors.New("too few users for anonymous insights")
+
+// Insights limits
+const (
+	DefaultInsightsDays          = 30
+	MaxInsightsDays              = 365
+	DefaultInsightsConversations = 2000
+	MaxInsightsConversations     = 10000
+	MaxInsightsClusters          = 30
+)
+
+// insightsScrollBatch is the number of points read per page
+const insightsScrollBatch = 256
+
+// maxInsightsPerUser bounds the conversations one user contributes to the sample
+const maxInsightsPerUser = 20
+
+// insightRepresentatives is the number of conversations, each of a different user, shown to the
+// chat model to label a topic
+const insightRepresentatives = 5
+
+// DefaultInsightCategories are the question categories conversations are assigned to when none
+// are configured
+var DefaultInsightCategories = []string{
+	"health and medication",
+	"family and friends",
+	"daily routine and schedule",
+	"meals and food",
+	"memories and the past",
+	"feelings and mood",
+	"hobbies and entertainment",
+	"help with technology",
+}
+
+// insightLabelPrompt instructs the model how to name topics shared by many users without
+// describing any one of them
+const insightLabelPrompt = `You name topics that many users discuss with a personal assistant.
+You receive numbered topics, each with excerpts of conversations of different users.
+Return only a JSON object with a "topics" array holding, for every topic, an object with the
+integer field "id", a generic "label" of two to five words and a one-sentence "summary" of what
+the conversations have in common. Never include names, places, dates, numbers or any detail that
+belongs to one person. Write in English.`
+
+// InsightsOptions configures cross-user insights
+type InsightsOptions struct {
+	// KAnonymity is the fewest distinct users a released group needs
+	KAnonymity int
+
+	// Categories are the question categories conversations are assigned to, by the similarity of
+	// their vectors to each category's embedded name
+	Categories []string
+}
+
+// InsightsService computes aggregate statistics across users: the common topics of recent
+// conversations and the share of each question category, released only for groups of at least
+// KAnonymity users. It reads vectors across all users, so it is for admins only
+type InsightsService struct {
+	points        storage.PointScroller
+	conversations *ConversationService
+	completion    storage.CompletionProvider
+	opts          InsightsOptions
+
+	// categoryAnchors holds the embedded category names once embedded
+	mu              sync.Mutex
+	categoryAnchors [][]float32
+}
+
+// NewInsightsService creates an insights service sampling the conversation vectors of points;
+// completion may be nil to leave topics unlabeled
+func NewInsightsService(points storage.PointScroller, conversations *ConversationService, completion storage.CompletionProvider, opts InsightsOptions) *InsightsService {
+	if opts.KAnonymity < insights.MinK {
+		opts.KAnonymity = 10
+	}
+	if len(opts.Categories) == 0 {
+		opts.Categories = DefaultInsightCategories
+	}
+	return &InsightsService{
+		points:        points,
+		conversations: conversations,
+		completion:    completion,
+		opts:          opts,
+	}
+}
+
+// insightSample is the sampled conversations with their owners and unit-length vectors
+type insightSample struct {
+	ids     []string
+	users   []string
+	vectors [][]float32
+}
+
+// Insights samples the unsuppressed conversations of the last req.Days across users, clusters
+// them into topics and assigns each to a question category. Groups with fewer than KAnonymity
+// users are withheld. It fails with ErrInsufficientUsers if the whole sample has fewer users
+func (is *InsightsService) Insights(ctx context.Context, req models.InsightsRequest) (*models.Insights, error) {
+	start := time.Now()
+	if req.Days <= 0 {
+		req.Days = DefaultInsightsDays
+	}
+	if req.MaxConversations <= 0 {
+		req.MaxConversations = DefaultInsightsConversations
+	}
+	since := start.UTC().AddDate(0, 0, -req.Days)
+
+	sample, truncated, err := is.sample(ctx, since, req.MaxConversations)
+	if err != nil {
+		return nil, err
+	}
+	users := make(map[string]struct{})
+	for _, userID := range sample.users {
+		users[userID] = struct{}{}
+	}
+	if len(users) < is.opts.KAnonymity {
+		return nil, fmt.Errorf("%w: %d users, at least %d needed", ErrInsufficientUsers, len(users), is.opts.KAnonymity)
+	}
+
+	result := &models.Insights{
+		Since:         since,
+		Conversations: len(sample.ids),
+		Users:         len(users),
+		Truncated:     truncated,
+		KAnonymity:    is.opts.KAnonymity,
+		Topics:        []models.InsightGroup{},
+		Categories:    []models.InsightGroup{},
+	}
+
+	topics, members, suppressed, err := is.topics(ctx, sample, req.Clusters)
+	if err != nil {
+		return nil, err
+	}
+	result.Topics, result.SuppressedTopics = topics, suppressed
+	if req.Label && is.completion != nil && len(topics) > 0 {
+		if err := is.labelTopics(ctx, sample, result.Topics, members); err != nil {
+			fmt.Printf("warning: failed to label insight topics: %v\n", err)
+			errreport.Background(ctx, "insights_label", err)
+		} else {
+			result.Labeled = true
+		}
+	}
+
+	result.Categories, result.SuppressedCategories, err = is.categories(ctx, sample)
+	if err != nil {
+		return nil, err
+	}
+
+	result.GeneratedAt = time.Now().UTC()
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// sample reads up to limit conversation vectors created since since, at most maxInsightsPerUser
+// of each user, and reports whether matching conversations were left out
+func (is *InsightsService) sample(ctx context.Context, since time.Time, limit int) (*insightSample, bool, error) {
+	filter := map[string]interface{}{
+		"must": []interface{}{
+			map[string]interface{}{"key": "created_at", "range": map[string]interface{}{"gte": since.Unix()}},
+		},
+		"must_not": []interface{}{suppressedCondition},
+	}
+
+	sample := &insightSample{}
+	perUser := make(map[string]int)
+	truncated := false
+	var offset *uint64
+	for {
+		points, next, err := is.points.ScrollPointsMatching(ctx, filter, offset, insightsScrollBatch)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to sample conversation vectors: %w", err)
+		}
+		for _, point := range points {
+			userID, _ := point.Payload["user_id"].(string)
+			if userID == "" || len(point.Vector) == 0 {
+				continue
+			}
+			if len(sample.ids) == limit || perUser[userID] == maxInsightsPerUser {
+				truncated = true
+				continue
+			}
+			perUser[userID]++
+			sample.ids = append(sample.ids, point.ID)
+			sample.users = append(sample.users, userID)
+			sample.vectors = append(sample.vectors, unitVector(point.Vector))
+		}
+		if next == nil || len(sample.ids) == limit {
+			return sample, truncated || next != nil, nil
+		}
+		offset = next
+	}
+}
+
+// topics clusters the sample and releases the k-anonymous clusters, largest first, with the
+// sample indexes of each, closest to its center first
+func (is *InsightsService) topics(ctx context.Context, sample *insightSample, k int) ([]models.InsightGroup, [][]int, int, error) {
+	if k <= 0 {
+		k = cluster.DefaultClusters(len(sample.vectors))
+	}
+	k = min(k, len(sample.vectors))
+	clusters, err := cluster.KMeans(ctx, sample.vectors, k)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to cluster conversations: %w", err)
+	}
+
+	counter := insights.NewCounter()
+	members := make(map[string][]int, k)
+	for i, c := range clusters.Assignments {
+		key := strconv.Itoa(c)
+		counter.Add(key, sample.users[i])
+		members[key] = append(members[key], i)
+	}
+	released, suppressed, err := counter.Release(is.opts.KAnonymity)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	topics := make([]models.InsightGroup, 0, len(released))
+	topicMembers := make([][]int, 0, len(released))
+	for i, group := range released {
+		topics = append(topics, models.InsightGroup{
+			ID:            i + 1,
+			Users:         group.Users,
+			Conversations: group.Conversations,
+			Share:         float64(group.Conversations) / float64(len(sample.ids)),
+		})
+		indexes := members[group.Key]
+		sort.SliceStable(indexes, func(a, b int) bool {
+			return clusters.Similarities[indexes[a]] > clusters.Similarities[indexes[b]]
+		})
+		topicMembers = append(topicMembers, indexes)
+	}
+	return topics, topicMembers, suppressed, nil
+}
+
+// categories assigns each sampled conversation to its most similar question category and
+// releases the k-anonymous categories
+func (is *InsightsService) categories(ctx context.Context, sample *insightSample) ([]models.InsightGroup, int, error) {
+	anchors, err := is.anchors(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to embed question categories: %w", err)
+	}
+	if len(anchors[0]) != len(sample.vectors[0]) {
+		return nil, 0, fmt.Errorf("question categories embed to %d dimensions, conversation vectors have %d", len(anchors[0]), len(sample.vectors[0]))
+	}
+
+	counter := insights.NewCounter()
+	for i, vector := range sample.vectors {
+		best, bestSimilarity := 0, dot32(vector, anchors[0])
+		for c := 1; c < len(anchors); c++ {
+			if similarity := dot32(vector, anchors[c]); similarity > bestSimilarity {
+				best, bestSimilarity = c, similarity
+			}
+		}
+		counter.Add(is.opts.Categories[best], sample.users[i])
+	}
+	released, suppressed, err := counter.Release(is.opts.KAnonymity)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	categories := make([]models.InsightGroup, 0, len(released))
+	for _, group := range released {
+		categories = append(categories, models.InsightGroup{
+			Label:         group.Key,
+			Users:         group.Users,
+			Conversations: group.Conversations,
+			Share:         float64(group.Conversations) / float64(len(sample.ids)),
+		})
+	}
+	return categories, suppressed, nil
+}
+
+// anchors returns the category names embedded as queries, embedding them on first use
+func (is *InsightsService) anchors(ctx context.Context) ([][]float32, error) {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	if is.categoryAnchors != nil {
+		return is.categoryAnchors, nil
+	}
+
+	anchors := make([][]float32, 0, len(is.opts.Categories))
+	for _, category := range is.opts.Categories {
+		embedding, err := is.conversations.embeddingProvider.EmbedQuery(ctx, category)
+		if err != nil {
+			return nil, err
+		}
+		anchors = append(anchors, unitVector(embedding))
+	}
+	is.categoryAnchors = anchors
+	return anchors, nil
+}
+
+// labelTopics names the released topics with the chat model from excerpts of conversations of
+// different users closest to each topic's center. The excerpts go to the model only; the
+// response carries the labels alone
+func (is *InsightsService) labelTopics(ctx context.Context, sample *insightSample, topics []models.InsightGroup, members [][]int) error {
+	excerptIDs := make([][]string, len(topics))
+	var ids []string
+	for t := range topics {
+		seen := make(map[string]bool, insightRepresentatives)
+		for _, i := range members[t] {
+			if len(excerptIDs[t]) == insightRepresentatives {
+				break
+			}
+			if seen[sample.users[i]] {
+				continue
+			}
+			seen[sample.users[i]] = true
+			excerptIDs[t] = append(excerptIDs[t], sample.ids[i])
+			ids = append(ids, sample.ids[i])
+		}
+	}
+
+	conversations, _, err := is.conversations.conversationStore.GetConversationsByIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get conversations: %w", err)
+	}
+	byID := make(map[string]*models.Conversation, len(conversations))
+	for _, conv := range conversations {
+		byID[conv.ID] = conv
+	}
+
+	var b strings.Builder
+	for t, topic := range topics {
+		fmt.Fprintf(&b, "Topic %d:\n", topic.ID)
+		for _, id := range excerptIDs[t] {
+			if conv, ok := byID[id]; ok {
+				fmt.Fprintf(&b, "- %s\n", conversationExcerpt(conv))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	output, err := is.completion.Complete(ctx, insightLabelPrompt, b.String())
+	if err != nil {
+		return err
+	}
+
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return fmt.Errorf("no JSON object in model output")
+	}
+	var labels struct {
+		Topics []struct {
+			ID      int    `json:"id"`
+			Label   string `json:"label"`
+			Summary string `json:"summary"`
+		} `json:"topics"`
+	}
+	if err := json.Unmarshal([]byte(output[start:end+1]), &labels); err != nil {
+		return fmt.Errorf("failed to parse topic labels: %w", err)
+	}
+
+	for _, label := range labels.Topics {
+		if label.ID < 1 || label.ID > len(topics) {
+			continue
+		}
+		topics[label.ID-1].Label = strings.TrimSpace(label.Label)
+		topics[label.ID-1].Summary = strings.TrimSpace(label.Summary)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// ErrUnknownContentType is returned for a content type without a configured collection
+var ErrUnknownContentType = errors.New("unknown content type")
+
+// EmbeddingInspector embeds arbitrary text and looks up its nearest stored points, to debug
+// retrieval without direct Qdrant access
+type EmbeddingInspector struct {
+	collections *storage.CollectionManager
+	embedders   map[string]storage.EmbeddingProvider
+}
+
+// NewEmbeddingInspector creates an embedding inspector; embedders are keyed by model name
+func NewEmbeddingInspector(collections *storage.CollectionManager, embedders map[string]storage.EmbeddingProvider) *EmbeddingInspector {
+	return &EmbeddingInspector{
+		collections: collections,
+		embedders:   embedders,
+	}
+}
+
+// Inspect embeds the request text with the collection's model and returns its nearest points
+func (ei *EmbeddingInspector) Inspect(ctx context.Context, req *models.EmbeddingInspectRequest) (*models.EmbeddingInspection, error) {
+	contentType := req.ContentType
+	if contentType == "" {
+		contentType = storage.ContentTypeConversations
+	}
+	limit := req.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	collection, ok := ei.collections.Config(contentType)
+	if !ok {
+		return nil, ErrUnknownContentType
+	}
+	store, err := ei.collections.Store(contentType)
+	if err != nil {
+		return nil, err
+	}
+	embedder, ok := ei.embedders[collection.Model]
+	if !ok {
+		return nil, fmt.Errorf("no embedding provider for model %q", collection.Model)
+	}
+
+	embed := embedder.EmbedQuery
+	if req.InputType == "document" {
+		embed = embedder.EmbedDocument
+	}
+	embedding, err := embed(ctx, req.Text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding: %w", err)
+	}
+
+	neighbors, err := store.NearestPoints(ctx, embedding, limit, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range neighbors {
+		neighbors[i].Distance = distance(collection.Distance, neighbors[i].Score)
+	}
+
+	var sumSquares float64
+	for _, v := range embedding {
+		sumSquares += float64(v) * float64(v)
+	}
+
+	inspection := &models.EmbeddingInspection{
+		ContentType: contentType,
+		Collection:  collection.Name,
+		Model:       collection.Model,
+		Dimension:   len(embedding),
+		Distance:    collection.Distance,
+		Norm:        math.Sqrt(sumSquares),
+		Neighbors:   neighbors,
+	}
+	if !req.OmitEmbedding {
+		inspection.Embedding = embedding
+	}
+	return inspection, nil
+}
+
+// distance converts a Qdrant score to a distance: similarity metrics are inverted, distance
+// metrics are returned as is
+func distance(metric string, score float32) float64 {
+	switch metric {
+	case "Cosine":
+		return 1 - float64(score)
+	case "Dot":
+		return -float64(score)
+	default:
+		return float64(score)
+	}
+}
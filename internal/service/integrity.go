@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"refo-rag-server/internal/models"
+)
+
+// ErrVerifyRunning is returned when an integrity verification job is already in progress
+var ErrVerifyRunning = errors.New("an integrity verification job is already running")
+
+// IntegrityService runs content hash verification as background jobs
+type IntegrityService struct {
+	conversations *ConversationService
+	jobs          *JobLog
+
+	// verifying is set while a verification job runs
+	verifying atomic.Bool
+}
+
+// NewIntegrityService creates a new integrity service
+func NewIntegrityService(conversations *ConversationService, jobs *JobLog) *IntegrityService {
+	return &IntegrityService{
+		conversations: conversations,
+		jobs:          jobs,
+	}
+}
+
+// StartVerify starts a background job comparing stored conversations with their vectors,
+// optionally of one user. It returns the job ID; the report is the job's result.
+func (is *IntegrityService) StartVerify(ctx context.Context, userID string) (string, error) {
+	if !is.verifying.CompareAndSwap(false, true) {
+		return "", ErrVerifyRunning
+	}
+
+	target := userID
+	if target == "" {
+		target = "all"
+	}
+
+	jobID, err := is.jobs.Start(ctx, models.JobKindIntegrity, target, func(ctx context.Context) (interface{}, error) {
+		defer is.verifying.Store(false)
+		return is.conversations.VerifyIntegrity(ctx, userID)
+	})
+	if err != nil {
+		is.verifying.Store(false)
+		return "", err
+	}
+	return jobID, nil
+}
+
+// Verify compares every stored conversation with its vector as a logged job and waits for it, for
+// scheduled reconciliation. Mismatches are reported as an error
+func (is *IntegrityService) Verify(ctx context.Context) error {
+	if !is.verifying.CompareAndSwap(false, true) {
+		return ErrVerifyRunning
+	}
+	defer is.verifying.Store(false)
+
+	var report *models.IntegrityReport
+	jobID, err := is.jobs.Run(ctx, models.JobKindIntegrity, "all", false, func(ctx context.Context) (interface{}, error) {
+		var err error
+		report, err = is.conversations.VerifyIntegrity(ctx, "")
+		return report, err
+	})
+	if err != nil {
+		return err
+	}
+	if len(report.IssueCounts) > 0 {
+		return fmt.Errorf("conversations disagree with their vectors (%v), see job %s", report.IssueCounts, jobID)
+	}
+	return nil
+}
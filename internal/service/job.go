@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// JobLog records administrative operations so their scope and outcome can be reviewed later
+type JobLog struct {
+	store storage.JobStore
+}
+
+// NewJobLog creates a new job log
+func NewJobLog(store storage.JobStore) *JobLog {
+	return &JobLog{
+		store: store,
+	}
+}
+
+// Run records a job, executes fn and stores its result. The operation is not started if the job
+// can't be recorded. It returns the job ID along with fn's error.
+func (jl *JobLog) Run(ctx context.Context, kind string, target string, dryRun bool, fn func(ctx context.Context) (interface{}, error)) (string, error) {
+	job, err := jl.create(ctx, kind, target, dryRun)
+	if err != nil {
+		return "", err
+	}
+
+	result, runErr := fn(ctx)
+	jl.finish(ctx, job, result, runErr)
+
+	return job.ID, runErr
+}
+
+// Start records a job and executes fn in the background, detached from the request's
+// cancellation. It returns the job ID once the job is recorded.
+func (jl *JobLog) Start(ctx context.Context, kind string, target string, fn func(ctx context.Context) (interface{}, error)) (string, error) {
+	return jl.StartTracked(ctx, kind, target, func(ctx context.Context, _ *JobProgress) (interface{}, error) {
+		return fn(ctx)
+	})
+}
+
+// JobProgress records the partial result of a running job
+type JobProgress struct {
+	jobs *JobLog
+	job  *models.Job
+}
+
+// Report stores result as the job's result while it runs, so it can be followed through the jobs
+// API and a later job can resume from it. A failure is logged; the job carries on
+func (jp *JobProgress) Report(ctx context.Context, result interface{}) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	if err := jp.jobs.store.UpdateJobProgress(ctx, jp.job.ID, data); err != nil {
+		fmt.Printf("warning: failed to record progress of %s job %s: %v\n", jp.job.Kind, jp.job.ID, err)
+		errreport.Background(ctx, "job_progress", err)
+	}
+}
+
+// StartTracked starts a job like Start, passing fn a handle to report its progress
+func (jl *JobLog) StartTracked(ctx context.Context, kind string, target string, fn func(ctx context.Context, progress *JobProgress) (interface{}, error)) (string, error) {
+	job, err := jl.create(ctx, kind, target, false)
+	if err != nil {
+		return "", err
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		result, runErr := fn(ctx, &JobProgress{jobs: jl, job: job})
+		if runErr != nil {
+			fmt.Printf("warning: %s job %s failed: %v\n", kind, job.ID, runErr)
+			errreport.Background(ctx, "job_"+kind, runErr)
+		}
+		jl.finish(ctx, job, result, runErr)
+	}()
+
+	return job.ID, nil
+}
+
+// create records a started job
+func (jl *JobLog) create(ctx context.Context, kind string, target string, dryRun bool) (*models.Job, error) {
+	job := &models.Job{
+		ID:        uuid.New().String(),
+		Kind:      kind,
+		Target:    target,
+		DryRun:    dryRun,
+		Status:    models.JobStatusRunning,
+		StartedAt: time.Now(),
+	}
+	if err := jl.store.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// finish records a job's outcome and result
+func (jl *JobLog) finish(ctx context.Context, job *models.Job, result interface{}, runErr error) {
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	job.Status = models.JobStatusSucceeded
+	if runErr != nil {
+		job.Status = models.JobStatusFailed
+		job.Error = runErr.Error()
+	}
+	if result != nil {
+		if data, err := json.Marshal(result); err == nil {
+			job.Result = data
+		}
+	}
+
+	// Record the outcome even if the request was cancelled mid-run
+	if err := jl.store.FinishJob(context.WithoutCancel(ctx), job); err != nil {
+		fmt.Printf("warning: failed to record %s job %s: %v\n", job.Kind, job.ID, err)
+		errreport.Background(ctx, "job_finish", err)
+	}
+}
+
+// Get retrieves a job by ID; it returns nil if the job doesn't exist
+func (jl *JobLog) Get(ctx context.Context, id string) (*models.Job, error) {
+	return jl.store.GetJob(ctx, id)
+}
+
+// List retrieves the most recent jobs, optionally of one kind
+func (jl *JobLog) List(ctx context.Context, kind string, limit int) ([]*models.Job, error) {
+	return jl.store.ListJobs(ctx, kind, limit)
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+	"refo-rag-server/internal/usage"
+)
+
+// Legacy backfill batching
+const (
+	legacyBackfillBatch   = 100
+	legacyBackfillSamples = 5
+)
+
+// ErrLegacyBackfillRunning is returned when a legacy backfill is already in progress
+var ErrLegacyBackfillRunning = errors.New("a legacy backfill is already running")
+
+// legacySpeakerLabel matches a speaker label at the start of a line, e.g. "User:" or "A:"
+var legacySpeakerLabel = regexp.MustCompile(`(?im)^[ \t]*(user|human|customer|question|q|질문|사용자|assistant|ai|bot|agent|answer|a|답변)[ \t]*[:：][ \t]*`)
+
+// legacyAssistantLabels are the speaker labels of assistant turns; all others are user turns
+var legacyAssistantLabels = map[string]bool{
+	"assistant": true,
+	"ai":        true,
+	"bot":       true,
+	"agent":     true,
+	"answer":    true,
+	"a":         true,
+	"답변":        true,
+}
+
+// legacyUserMetadataKeys are the metadata keys legacy clients stored the user ID under
+var legacyUserMetadataKeys = []string{"user_id", "userId", "user"}
+
+// LegacyBackfillService upgrades conversations saved before messages and user IDs were stored:
+// their concatenated text is split into turns, they are attributed to a user and re-embedded.
+// Progress is recorded in the job's result after every batch
+type LegacyBackfillService struct {
+	store         storage.LegacyConversationStore
+	conversations *ConversationService
+	jobs          *JobLog
+
+	// running is set while a backfill runs
+	running atomic.Bool
+}
+
+// NewLegacyBackfillService creates a new legacy backfill service
+func NewLegacyBackfillService(store storage.LegacyConversationStore, conversations *ConversationService, jobs *JobLog) *LegacyBackfillService {
+	return &LegacyBackfillService{
+		store:         store,
+		conversations: conversations,
+		jobs:          jobs,
+	}
+}
+
+// Start starts upgrading the legacy conversations in the background and returns the job ID. When
+// req.ResumeJobID is set, the job continues after the last conversation that job finished; it
+// must be a legacy backfill with the same dry run setting that failed or was interrupted by a
+// restart
+func (lbs *LegacyBackfillService) Start(ctx context.Context, req *models.LegacyBackfillRequest) (string, error) {
+	if !lbs.running.CompareAndSwap(false, true) {
+		return "", ErrLegacyBackfillRunning
+	}
+
+	progress := &models.LegacyBackfillProgress{DryRun: req.DryRun, FailedIDs: []string{}}
+	if req.ResumeJobID != "" {
+		resumed, err := lbs.resumable(ctx, req.ResumeJobID, req.DryRun)
+		if err != nil {
+			lbs.running.Store(false)
+			return "", err
+		}
+		progress = resumed
+		progress.ResumedFrom = req.ResumeJobID
+	}
+
+	defaultUserID := req.DefaultUserID
+	jobID, err := lbs.jobs.StartTracked(ctx, models.JobKindLegacyBackfill, "conversations", func(ctx context.Context, tracker *JobProgress) (interface{}, error) {
+		defer lbs.running.Store(false)
+		return lbs.run(ctx, progress, defaultUserID, tracker)
+	})
+	if err != nil {
+		lbs.running.Store(false)
+		return "", err
+	}
+	return jobID, nil
+}
+
+// resumable loads the progress of an unfinished backfill job. A job still marked running is
+// accepted since this process isn't running it, so it was interrupted
+func (lbs *LegacyBackfillService) resumable(ctx context.Context, jobID string, dryRun bool) (*models.LegacyBackfillProgress, error) {
+	job, err := lbs.jobs.Get(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.Kind != models.JobKindLegacyBackfill {
+		return nil, fmt.Errorf("%w: %s is not a legacy backfill job", ErrResumeJobInvalid, jobID)
+	}
+	if job.Status == models.JobStatusSucceeded {
+		return nil, fmt.Errorf("%w: job %s already finished", ErrResumeJobInvalid, jobID)
+	}
+
+	progress := &models.LegacyBackfillProgress{}
+	if len(job.Result) > 0 {
+		if err := json.Unmarshal(job.Result, progress); err != nil {
+			return nil, fmt.Errorf("%w: job %s has an unreadable result: %v", ErrResumeJobInvalid, jobID, err)
+		}
+	}
+	if progress.DryRun != dryRun {
+		return nil, fmt.Errorf("%w: job %s has dry_run %t", ErrResumeJobInvalid, jobID, progress.DryRun)
+	}
+	if progress.FailedIDs == nil {
+		progress.FailedIDs = []string{}
+	}
+	return progress, nil
+}
+
+// run upgrades the legacy conversations after the progress cursor in ID order, reporting after
+// every batch. Upgraded conversations no longer match, but the cursor still advances past them
+// so a dry run, which changes nothing, ends too
+func (lbs *LegacyBackfillService) run(ctx context.Context, progress *models.LegacyBackfillProgress, defaultUserID string, tracker *JobProgress) (*models.LegacyBackfillProgress, error) {
+	startTime := time.Now()
+	elapsed := time.Duration(progress.DurationMs) * time.Millisecond
+
+	total, err := lbs.store.CountLegacyConversations(ctx)
+	if err != nil {
+		return progress, err
+	}
+	if progress.Total == 0 {
+		// A resumed job keeps the total it started with, since upgraded conversations no longer count
+		progress.Total = total
+	}
+
+	// Session owners, looked up once per session
+	sessionUsers := make(map[string]string)
+
+	for {
+		batch, err := lbs.store.ListLegacyConversationsAfter(ctx, progress.Cursor, legacyBackfillBatch)
+		if err != nil {
+			return progress, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, conv := range batch {
+			if err := ctx.Err(); err != nil {
+				return progress, err
+			}
+
+			err := lbs.upgrade(ctx, conv, progress, defaultUserID, sessionUsers)
+			if err != nil {
+				fmt.Printf("warning: failed to backfill legacy conversation %s: %v\n", conv.ID, err)
+				progress.Failed++
+				if len(progress.FailedIDs) < maxReindexFailedIDs {
+					progress.FailedIDs = append(progress.FailedIDs, conv.ID)
+				}
+			}
+			progress.Processed++
+			progress.Cursor = conv.ID
+
+			var budgetErr *usage.BudgetError
+			if errors.As(err, &budgetErr) {
+				// Every further conversation would fail too; stop so the job can be resumed once the
+				// budget resets. This one may already be stored, so it is listed as failed for a
+				// user reindex rather than retried
+				tracker.Report(ctx, progress)
+				return progress, err
+			}
+		}
+
+		progress.DurationMs = (elapsed + time.Since(startTime)).Milliseconds()
+		tracker.Report(ctx, progress)
+	}
+
+	progress.DurationMs = (elapsed + time.Since(startTime)).Milliseconds()
+	return progress, nil
+}
+
+// upgrade splits a legacy conversation into messages, attributes it to a user, stores it and
+// re-embeds it; a dry run only counts and samples the result
+func (lbs *LegacyBackfillService) upgrade(ctx context.Context, conv *models.Conversation, progress *models.LegacyBackfillProgress, defaultUserID string, sessionUsers map[string]string) error {
+	if len(conv.Messages) == 0 {
+		messages, split := splitLegacyTurns(conv.Question, conv.Answer)
+		if split {
+			progress.Split++
+		} else {
+			progress.Unsplit++
+		}
+		conv.Messages = normalizeMessages(messages, conv.CreatedAt)
+		conv.Question = joinRole(conv.Messages, models.RoleUser)
+		conv.Answer = joinRole(conv.Messages, models.RoleAssistant)
+	}
+
+	if conv.UserID == "" {
+		userID, err := lbs.legacyUserID(ctx, conv, sessionUsers)
+		if err != nil {
+			return err
+		}
+		if userID == "" {
+			userID = defaultUserID
+		}
+		conv.UserID = userID
+	}
+	if conv.UserID != "" {
+		progress.Attributed++
+	} else {
+		progress.Unattributed++
+	}
+
+	if progress.DryRun {
+		if len(progress.Samples) < legacyBackfillSamples {
+			progress.Samples = append(progress.Samples, models.LegacyBackfillSample{
+				ConversationID: conv.ID,
+				UserID:         conv.UserID,
+				Messages:       conv.Messages,
+			})
+		}
+		return nil
+	}
+
+	if err := lbs.store.UpgradeLegacyConversation(ctx, conv); err != nil {
+		return err
+	}
+
+	textToEmbed := lbs.conversations.embedText(conv.Messages)
+	if textToEmbed == "" || conv.Status == models.ConversationStatusArchived {
+		return nil
+	}
+	if err := lbs.conversations.reembedConversation(ctx, conv, textToEmbed); err != nil {
+		return err
+	}
+	progress.Reembedded++
+	return nil
+}
+
+// legacyUserID returns the user a legacy conversation's metadata names, else the owner of its
+// session, else an empty ID
+func (lbs *LegacyBackfillService) legacyUserID(ctx context.Context, conv *models.Conversation, sessionUsers map[string]string) (string, error) {
+	if conv.Metadata != "" {
+		var metadata map[string]interface{}
+		if err := json.Unmarshal([]byte(conv.Metadata), &metadata); err == nil {
+			for _, key := range legacyUserMetadataKeys {
+				if userID, ok := metadata[key].(string); ok && strings.TrimSpace(userID) != "" {
+					return strings.TrimSpace(userID), nil
+				}
+			}
+		}
+	}
+
+	if conv.SessionID == "" || lbs.conversations.sessions == nil {
+		return "", nil
+	}
+	if userID, ok := sessionUsers[conv.SessionID]; ok {
+		return userID, nil
+	}
+	session, err := lbs.conversations.sessions.GetSession(ctx, conv.SessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get session %s: %w", conv.SessionID, err)
+	}
+	userID := ""
+	if session != nil {
+		userID = session.UserID
+	}
+	sessionUsers[conv.SessionID] = userID
+	return userID, nil
+}
+
+// splitLegacyTurns splits the concatenated text of a legacy conversation into messages at speaker
+// labels, reporting whether any label was found. Text before the first label is a user turn;
+// without labels the question is one user message. A separate answer becomes the last message
+func splitLegacyTurns(question string, answer string) ([]models.Message, bool) {
+	var messages []models.Message
+	add := func(role string, content string) {
+		if content = strings.TrimSpace(content); content != "" {
+			messages = append(messages, models.Message{Role: role, Content: content})
+		}
+	}
+
+	labels := legacySpeakerLabel.FindAllStringSubmatchIndex(question, -1)
+	if len(labels) == 0 {
+		add(models.RoleUser, question)
+	} else {
+		add(models.RoleUser, question[:labels[0][0]])
+		for i, label := range labels {
+			end := len(question)
+			if i+1 < len(labels) {
+				end = labels[i+1][0]
+			}
+			role := models.RoleUser
+			if legacyAssistantLabels[strings.ToLower(question[label[2]:label[3]])] {
+				role = models.RoleAssistant
+			}
+			add(role, question[label[1]:end])
+		}
+	}
+	add(models.RoleAssistant, answer)
+
+	return messages, len(labels) > 0
+}
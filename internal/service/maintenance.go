@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/models"
+)
+
+// ErrStandby is returned when maintenance mode is disabled on a standby, which never takes writes
+var ErrStandby = errors.New("this instance is a standby and stays read-only")
+
+// MaintenanceMode tracks whether the server is in read-only maintenance mode
+type MaintenanceMode struct {
+	mu      sync.RWMutex
+	enabled bool
+	standby bool
+	reason  string
+	since   time.Time
+}
+
+// NewMaintenanceMode creates a maintenance mode toggle with the given initial state
+func NewMaintenanceMode(enabled bool, reason string) *MaintenanceMode {
+	mm := &MaintenanceMode{}
+	if enabled {
+		mm.Enable(reason)
+	}
+	return mm
+}
+
+// NewStandbyMode creates the permanent read-only mode of a standby instance
+func NewStandbyMode() *MaintenanceMode {
+	mm := &MaintenanceMode{standby: true}
+	mm.Enable("standby")
+	return mm
+}
+
+// Enable switches the server to read-only mode
+func (mm *MaintenanceMode) Enable(reason string) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	if !mm.enabled {
+		mm.since = time.Now()
+	}
+	mm.enabled = true
+	mm.reason = reason
+}
+
+// Disable returns the server to normal read-write operation, unless it is a standby
+func (mm *MaintenanceMode) Disable() error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	if mm.standby {
+		return ErrStandby
+	}
+	mm.enabled = false
+	mm.reason = ""
+	mm.since = time.Time{}
+	return nil
+}
+
+// Enabled reports whether writes are currently rejected
+func (mm *MaintenanceMode) Enabled() bool {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	return mm.enabled
+}
+
+// Status returns the current maintenance state
+func (mm *MaintenanceMode) Status() models.MaintenanceStatus {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	status := models.MaintenanceStatus{
+		Enabled: mm.enabled,
+		Standby: mm.standby,
+		Reason:  mm.reason,
+	}
+	if mm.enabled {
+		status.Since = mm.since.UTC().Format(time.RFC3339)
+	}
+	return status
+}
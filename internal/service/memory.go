@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/queryroute"
+)
+
+// ErrSessionNotFound is returned when a requested session doesn't exist or belongs to another user
+var ErrSessionNotFound = errors.New("session not found")
+
+// MemoryService assembles the memory context a chat caller sends to the model
+type MemoryService struct {
+	conversations *ConversationService
+	personalInfo  *PersonalInfoService
+	sessions      *SessionService
+	classifier    *queryroute.Classifier
+
+	// compressor shortens similar conversations to their relevant sentences; compressByDefault
+	// applies it to requests that don't choose
+	compressor        *ContextCompressor
+	compressByDefault bool
+}
+
+// NewMemoryService creates a new memory service; classifier may be nil to search conversations
+// and personal info for every query
+func NewMemoryService(conversations *ConversationService, personalInfo *PersonalInfoService, sessions *SessionService, classifier *queryroute.Classifier) *MemoryService {
+	return &MemoryService{
+		conversations: conversations,
+		personalInfo:  personalInfo,
+		sessions:      sessions,
+		classifier:    classifier,
+	}
+}
+
+// SetCompressor enables context compression, applied to requests that don't ask for or against it
+// when byDefault is set; nil disables it
+func (ms *MemoryService) SetCompressor(compressor *ContextCompressor, byDefault bool) {
+	ms.compressor = compressor
+	ms.compressByDefault = byDefault
+}
+
+// Pinned retrieves all of a user's pinned memories
+func (ms *MemoryService) Pinned(ctx context.Context, userID string) (*models.PinnedMemories, error) {
+	conversations, err := ms.conversations.GetPinned(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	personalInfo, err := ms.personalInfo.GetPinned(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pinned := &models.PinnedMemories{
+		Conversations: conversations,
+		PersonalInfo:  make([]models.PersonalInfoResponse, 0, len(personalInfo)),
+	}
+	for _, info := range personalInfo {
+		pinned.PersonalInfo = append(pinned.PersonalInfo, info.Response())
+	}
+	return pinned, nil
+}
+
+// Retrieve returns the user's pinned memories, the conversations and personal info entries most
+// similar to the query, and the session recap when a session is given. Pinned memories are
+// included regardless of similarity and are not repeated among the search results. With a
+// classifier, only the collections the query is about are searched.
+func (ms *MemoryService) Retrieve(ctx context.Context, req *models.RetrieveRequest) (*models.RetrieveResponse, error) {
+	pinned, err := ms.Pinned(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.RetrieveResponse{
+		UserID:       req.UserID,
+		Query:        req.Query,
+		Pinned:       *pinned,
+		Results:      []models.ConversationSearchResult{},
+		PersonalInfo: []models.PersonalInfoSearchResult{},
+	}
+
+	if req.Query != "" {
+		if err := ms.search(ctx, req, resp); err != nil {
+			return nil, err
+		}
+		ms.compress(ctx, req, resp)
+	}
+
+	if req.SessionID != "" {
+		session, err := ms.sessions.GetContext(ctx, req.SessionID, req.RecentMessages)
+		if err != nil {
+			return nil, err
+		}
+		if session == nil || session.UserID != req.UserID {
+			return nil, ErrSessionNotFound
+		}
+		resp.Session = session
+	}
+
+	return resp, nil
+}
+
+// search fills in the conversations and personal info similar to the query, searching the routed
+// collections concurrently
+func (ms *MemoryService) search(ctx context.Context, req *models.RetrieveRequest, resp *models.RetrieveResponse) error {
+	weights := map[string]float64{queryroute.TargetConversations: 1, queryroute.TargetPersonalInfo: 1}
+	targets := queryroute.Targets
+	if ms.classifier != nil {
+		route := ms.classifier.Classify(req.Query)
+		weights, targets = route.Weights, route.Selected
+		resp.Routing = &models.RetrieveRouting{Weights: route.Weights, Searched: route.Selected}
+		for _, target := range queryroute.Targets {
+			metrics.RetrieveRoutes.WithLabelValues(target, strconv.FormatBool(slices.Contains(targets, target))).Inc()
+		}
+	}
+
+	var (
+		wg             sync.WaitGroup
+		conversations  []models.ConversationSearchResult
+		personalInfo   []models.PersonalInfoSearchResult
+		convErr, piErr error
+	)
+	if slices.Contains(targets, queryroute.TargetConversations) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conversations, convErr = ms.conversations.SearchConversations(ctx, &models.ConversationSearchRequest{
+				Query:      req.Query,
+				UserID:     req.UserID,
+				Limit:      req.Limit,
+				Overrides:  req.Overrides,
+				MaxAgeDays: req.MaxAgeDays,
+			})
+		}()
+	}
+	if slices.Contains(targets, queryroute.TargetPersonalInfo) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			personalInfo, piErr = ms.personalInfo.Search(ctx, req.UserID, req.Query, req.Limit)
+		}()
+	}
+	wg.Wait()
+	if convErr != nil {
+		return convErr
+	}
+	if piErr != nil {
+		return piErr
+	}
+
+	pinnedIDs := make(map[string]bool, len(resp.Pinned.Conversations)+len(resp.Pinned.PersonalInfo))
+	for _, conv := range resp.Pinned.Conversations {
+		pinnedIDs[conv.ID] = true
+	}
+	for _, info := range resp.Pinned.PersonalInfo {
+		pinnedIDs[info.ID] = true
+	}
+	for _, result := range conversations {
+		if !pinnedIDs[result.ConversationID] {
+			result.Score *= float32(weights[queryroute.TargetConversations])
+			resp.Results = append(resp.Results, result)
+		}
+	}
+	for _, result := range personalInfo {
+		if !pinnedIDs[result.ID] {
+			result.Score *= float32(weights[queryroute.TargetPersonalInfo])
+			resp.PersonalInfo = append(resp.PersonalInfo, result)
+		}
+	}
+	return nil
+}
+
+// compress shortens the similar conversations when the request or the default asks for it. A
+// failure returns them uncompressed rather than failing the retrieval
+func (ms *MemoryService) compress(ctx context.Context, req *models.RetrieveRequest, resp *models.RetrieveResponse) {
+	enabled := ms.compressByDefault
+	if req.Compress != nil {
+		enabled = *req.Compress
+	}
+	if !enabled || ms.compressor == nil || len(resp.Results) == 0 {
+		return
+	}
+
+	compressed := make([]models.ConversationSearchResult, len(resp.Results))
+	copy(compressed, resp.Results)
+	compression, err := ms.compressor.Compress(ctx, req.Query, compressed)
+	if err != nil {
+		fmt.Printf("warning: failed to compress memory context: %v\n", err)
+		return
+	}
+	resp.Results = compressed
+	resp.Compression = compression
+}
+
+// PinConversation pins or unpins a conversation; it reports false if the conversation doesn't exist
+func (ms *MemoryService) PinConversation(ctx context.Context, id string, pinned bool) (bool, error) {
+	return ms.conversations.SetPinned(ctx, id, pinned)
+}
+
+// PinPersonalInfo pins or unpins a personal info entry; it reports false if the entry doesn't exist
+func (ms *MemoryService) PinPersonalInfo(ctx context.Context, id string, pinned bool) (bool, error) {
+	return ms.personalInfo.SetPinned(ctx, id, pinned)
+}
+
+// SuppressConversation suppresses a conversation with the given reason, or restores it when
+// suppressed is false; it returns nil if the conversation doesn't exist
+func (ms *MemoryService) SuppressConversation(ctx context.Context, id string, req *models.SuppressionRequest) (*models.SuppressionResponse, error) {
+	suppression := newSuppression(req)
+	found, err := ms.conversations.SetSuppression(ctx, id, suppression)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &models.SuppressionResponse{ID: id, Type: "conversation", Suppression: suppression}, nil
+}
+
+// SuppressPersonalInfo suppresses a personal info entry with the given reason, or restores it when
+// suppressed is false; it returns nil if the entry doesn't exist
+func (ms *MemoryService) SuppressPersonalInfo(ctx context.Context, id string, req *models.SuppressionRequest) (*models.SuppressionResponse, error) {
+	suppression := newSuppression(req)
+	found, err := ms.personalInfo.SetSuppression(ctx, id, suppression)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &models.SuppressionResponse{ID: id, Type: "personal_info", Suppression: suppression}, nil
+}
+
+// Suppressed retrieves all of a user's suppressed memories
+func (ms *MemoryService) Suppressed(ctx context.Context, userID string) (*models.SuppressedMemories, error) {
+	conversations, err := ms.conversations.GetSuppressed(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	personalInfo, err := ms.personalInfo.GetSuppressed(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	suppressed := &models.SuppressedMemories{
+		Conversations: conversations,
+		PersonalInfo:  make([]models.PersonalInfoResponse, 0, len(personalInfo)),
+	}
+	for _, info := range personalInfo {
+		suppressed.PersonalInfo = append(suppressed.PersonalInfo, info.Response())
+	}
+	return suppressed, nil
+}
+
+// newSuppression builds the suppression for a request; nil restores the memory
+func newSuppression(req *models.SuppressionRequest) *models.Suppression {
+	if !*req.Suppressed {
+		return nil
+	}
+	return &models.Suppression{
+		Reason:       req.Reason,
+		Note:         req.Note,
+		SuppressedAt: time.Now(),
+	}
+}
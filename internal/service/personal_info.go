@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/deadline"
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// PersonalInfoService handles personal information business logic
+type PersonalInfoService struct {
+	personalInfoStore storage.PersonalInfoStore
+	vectorStore       storage.VectorStore
+	embeddingProvider storage.EmbeddingProvider
+	users             *UserService
+	embedText         PersonalInfoTemplate
+
+	// multiVectors, when set, also stores entries as multivectors and serves searches from them
+	multiVectors       storage.MultiVectorStore
+	multiVectorOptions MultiVectorOptions
+
+	// adapters personalizes dense query embeddings of model
+	adapters *QueryAdapters
+	model    string
+}
+
+// NewPersonalInfoService creates a new personal info service; users may be nil to skip the
+// disabled user check
+func NewPersonalInfoService(
+	personalInfoStore storage.PersonalInfoStore,
+	vectorStore storage.VectorStore,
+	embeddingProvider storage.EmbeddingProvider,
+	users *UserService,
+	embedText PersonalInfoTemplate,
+) *PersonalInfoService {
+	return &PersonalInfoService{
+		personalInfoStore: personalInfoStore,
+		vectorStore:       vectorStore,
+		embeddingProvider: embeddingProvider,
+		users:             users,
+		embedText:         embedText,
+	}
+}
+
+// SetQueryAdapters applies users' adapters for model, the personal info collection's embedding
+// model, to dense searches; multivector searches compare segments and stay unadapted
+func (pis *PersonalInfoService) SetQueryAdapters(adapters *QueryAdapters, model string) {
+	pis.adapters = adapters
+	pis.model = model
+}
+
+// CreatePersonalInfo saves a personal info entry and indexes it in the personal info collection;
+// it fails with ErrUserDisabled for disabled users
+func (pis *PersonalInfoService) CreatePersonalInfo(ctx context.Context, personalInfo *models.PersonalInfo) error {
+	if pis.users != nil {
+		if err := pis.users.CheckActive(ctx, personalInfo.UserID); err != nil {
+			return err
+		}
+	}
+
+	if err := pis.personalInfoStore.SavePersonalInfo(ctx, personalInfo); err != nil {
+		return err
+	}
+
+	if err := pis.indexPersonalInfo(ctx, personalInfo); err != nil {
+		// Log error but continue - we've already saved to PostgreSQL
+		fmt.Printf("warning: failed to index personal info %s: %v\n", personalInfo.ID, err)
+		errreport.Background(ctx, "personal_info_index", err)
+	}
+	return nil
+}
+
+// GetPersonalInfo retrieves a personal info entry by ID
+func (pis *PersonalInfoService) GetPersonalInfo(ctx context.Context, id string) (*models.PersonalInfo, error) {
+	return pis.personalInfoStore.GetPersonalInfo(ctx, id)
+}
+
+// GetPersonalInfoByUser retrieves all personal info entries for a user
+func (pis *PersonalInfoService) GetPersonalInfoByUser(ctx context.Context, userID string) ([]*models.PersonalInfo, error) {
+	return pis.personalInfoStore.GetPersonalInfoByUser(ctx, userID)
+}
+
+// UpdatePersonalInfo updates a personal info entry and re-indexes its vector
+func (pis *PersonalInfoService) UpdatePersonalInfo(ctx context.Context, personalInfo *models.PersonalInfo) error {
+	if err := pis.personalInfoStore.UpdatePersonalInfo(ctx, personalInfo); err != nil {
+		return err
+	}
+	pis.reindexUpdated(ctx, personalInfo)
+	return nil
+}
+
+// UpdatePersonalInfoIfUnchanged updates a personal info entry like UpdatePersonalInfo, but only if
+// it wasn't modified since it was read with the given updated_at; otherwise it returns
+// storage.ErrPersonalInfoChanged
+func (pis *PersonalInfoService) UpdatePersonalInfoIfUnchanged(ctx context.Context, personalInfo *models.PersonalInfo, readAt time.Time) error {
+	if err := pis.personalInfoStore.UpdatePersonalInfoIfUnchanged(ctx, personalInfo, readAt); err != nil {
+		return err
+	}
+	pis.reindexUpdated(ctx, personalInfo)
+	return nil
+}
+
+// reindexUpdated re-embeds an updated entry; a failure is logged since the update is already saved
+func (pis *PersonalInfoService) reindexUpdated(ctx context.Context, personalInfo *models.PersonalInfo) {
+	if err := pis.indexPersonalInfo(ctx, personalInfo); err != nil {
+		// Log error but continue - we've already saved to PostgreSQL
+		fmt.Printf("warning: failed to index personal info %s: %v\n", personalInfo.ID, err)
+		errreport.Background(ctx, "personal_info_index", err)
+	}
+}
+
+// SetPinned pins or unpins a personal info entry; it reports false if the entry doesn't exist
+func (pis *PersonalInfoService) SetPinned(ctx context.Context, id string, pinned bool) (bool, error) {
+	return pis.personalInfoStore.SetPersonalInfoPinned(ctx, id, pinned)
+}
+
+// GetPinned retrieves a user's pinned personal info entries
+func (pis *PersonalInfoService) GetPinned(ctx context.Context, userID string) ([]*models.PersonalInfo, error) {
+	return pis.personalInfoStore.GetPinnedPersonalInfo(ctx, userID)
+}
+
+// SetSuppression suppresses a personal info entry, or restores it when suppression is nil; it
+// reports false if the entry doesn't exist
+func (pis *PersonalInfoService) SetSuppression(ctx context.Context, id string, suppression *models.Suppression) (bool, error) {
+	found, err := pis.personalInfoStore.SetPersonalInfoSuppression(ctx, id, suppression)
+	if err != nil || !found {
+		return found, err
+	}
+
+	if err := pis.vectorStore.UpdatePayload(ctx, id, map[string]interface{}{"suppressed": suppression != nil}, nil); err != nil {
+		fmt.Printf("warning: failed to update suppression payload of personal info %s: %v\n", id, err)
+		errreport.Background(ctx, "personal_info_suppression_payload", err)
+	}
+	if pis.multiVectors != nil {
+		if err := pis.multiVectors.UpdatePayload(ctx, id, map[string]interface{}{"suppressed": suppression != nil}, nil); err != nil {
+			fmt.Printf("warning: failed to update suppression payload of personal info multivector %s: %v\n", id, err)
+			errreport.Background(ctx, "personal_info_suppression_payload", err)
+		}
+	}
+
+	return true, nil
+}
+
+// GetSuppressed retrieves a user's suppressed personal info entries
+func (pis *PersonalInfoService) GetSuppressed(ctx context.Context, userID string) ([]*models.PersonalInfo, error) {
+	return pis.personalInfoStore.GetSuppressedPersonalInfo(ctx, userID)
+}
+
+// DeletePersonalInfo deletes a personal info entry and its vector
+func (pis *PersonalInfoService) DeletePersonalInfo(ctx context.Context, id string) error {
+	if err := pis.personalInfoStore.DeletePersonalInfo(ctx, id); err != nil {
+		return err
+	}
+	pis.deleteVector(ctx, id)
+	return nil
+}
+
+// DeletePersonalInfoIfUnchanged deletes a personal info entry like DeletePersonalInfo, but only if
+// it wasn't modified since it was read with the given updated_at; otherwise it returns
+// storage.ErrPersonalInfoChanged
+func (pis *PersonalInfoService) DeletePersonalInfoIfUnchanged(ctx context.Context, id string, readAt time.Time) error {
+	if err := pis.personalInfoStore.DeletePersonalInfoIfUnchanged(ctx, id, readAt); err != nil {
+		return err
+	}
+	pis.deleteVector(ctx, id)
+	return nil
+}
+
+// deleteVector removes a deleted entry's vector; a failure is logged since the entry is already gone
+func (pis *PersonalInfoService) deleteVector(ctx context.Context, id string) {
+	if err := pis.vectorStore.DeleteVector(ctx, id); err != nil {
+		// Log error but continue - the entry is already gone from PostgreSQL
+		fmt.Printf("warning: failed to delete personal info vector from qdrant: %v\n", err)
+		errreport.Background(ctx, "personal_info_vector_delete", err)
+	}
+}
+
+// Search returns a user's unsuppressed entries most similar to a query, best first
+func (pis *PersonalInfoService) Search(ctx context.Context, userID string, query string, limit int) ([]models.PersonalInfoSearchResult, error) {
+	opts := storage.SearchOptions{
+		Limit:  limit,
+		Filter: map[string]interface{}{"must_not": []interface{}{suppressedCondition}},
+		UserID: userID,
+	}
+	if pis.multiVectors != nil {
+		return pis.searchMultiVector(ctx, query, opts)
+	}
+
+	embedding, err := pis.embeddingProvider.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create query embedding: %w", err)
+	}
+	embedding = pis.adapters.Adapt(ctx, userID, pis.model, embedding)
+
+	hits, err := pis.vectorStore.SearchVectors(ctx, embedding, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search personal info vectors: %w", err)
+	}
+	return pis.searchResults(ctx, hits)
+}
+
+// searchMultiVector searches the multivector collection with the segments of query. MaxSim sums
+// each query vector's best similarity, so scores are averaged over the query vectors to stay
+// within the range of a single cosine similarity
+func (pis *PersonalInfoService) searchMultiVector(ctx context.Context, query string, opts storage.SearchOptions) ([]models.PersonalInfoSearchResult, error) {
+	vectors, err := pis.embedSegments(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create query embeddings: %w", err)
+	}
+
+	hits, err := pis.multiVectors.SearchMultiVector(ctx, vectors, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search personal info multivectors: %w", err)
+	}
+	for i := range hits {
+		hits[i].Score /= float32(len(vectors))
+	}
+	return pis.searchResults(ctx, hits)
+}
+
+// searchResults loads the unsuppressed entries of search hits, in the hits' order
+func (pis *PersonalInfoService) searchResults(ctx context.Context, hits []models.ConversationSearchResult) ([]models.PersonalInfoSearchResult, error) {
+	ids := make([]string, 0, len(hits))
+	scores := make(map[string]float32, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ConversationID)
+		scores[hit.ConversationID] = hit.Score
+	}
+	entries, err := pis.personalInfoStore.GetPersonalInfoByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.PersonalInfoSearchResult, 0, len(entries))
+	for _, entry := range entries {
+		// The vector payload may lag a suppression; the stored entry is authoritative
+		if entry.Suppression != nil {
+			continue
+		}
+		results = append(results, models.PersonalInfoSearchResult{PersonalInfoResponse: entry.Response(), Score: scores[entry.ID]})
+	}
+	return results, nil
+}
+
+// ReindexUser replaces all of a user's personal info vectors by re-embedding their stored entries.
+// A dry run only reports the vectors that would be deleted and the entries that would be embedded.
+func (pis *PersonalInfoService) ReindexUser(ctx context.Context, userID string, dryRun bool) (*models.ReindexCounts, error) {
+	// Load first so a database failure doesn't leave the user without vectors
+	personalInfoList, err := pis.personalInfoStore.GetPersonalInfoByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors, err := pis.vectorStore.CountUserVectors(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count personal info vectors: %w", err)
+	}
+
+	if !dryRun {
+		// Once the vectors are deleted the reindex must finish, or the user is left with a partly
+		// empty index; a cancelled request stops it only before that
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ctx = deadline.Detach(ctx)
+		if err := pis.vectorStore.DeleteUserVectors(ctx, userID); err != nil {
+			return nil, fmt.Errorf("failed to delete personal info vectors: %w", err)
+		}
+	}
+
+	counts := &models.ReindexCounts{VectorsDeleted: vectors, FailedIDs: []string{}}
+	for _, personalInfo := range personalInfoList {
+		counts.TextBytes += int64(len(pis.embedText.Build(personalInfo)))
+
+		if dryRun {
+			counts.Indexed++
+			counts.IDs = append(counts.IDs, personalInfo.ID)
+			continue
+		}
+
+		if err := pis.indexPersonalInfo(ctx, personalInfo); err != nil {
+			fmt.Printf("warning: failed to reindex personal info %s: %v\n", personalInfo.ID, err)
+			counts.Failed++
+			counts.FailedIDs = append(counts.FailedIDs, personalInfo.ID)
+			continue
+		}
+		counts.Indexed++
+	}
+
+	return counts, nil
+}
+
+// indexPersonalInfo embeds a personal info entry and writes it to the vector store
+func (pis *PersonalInfoService) indexPersonalInfo(ctx context.Context, personalInfo *models.PersonalInfo) error {
+	text := pis.embedText.Build(personalInfo)
+	embedding, err := pis.embeddingProvider.EmbedDocument(ctx, text)
+	if err != nil {
+		return fmt.Errorf("failed to embed personal info: %w", err)
+	}
+
+	metadata := map[string]interface{}{
+		"user_id":    personalInfo.UserID,
+		"category":   personalInfo.Category,
+		"importance": personalInfo.Importance,
+		"created_at": personalInfo.CreatedAt.Unix(),
+		"suppressed": personalInfo.Suppression != nil,
+	}
+
+	if err := pis.vectorStore.SaveVector(ctx, personalInfo.ID, embedding, metadata); err != nil {
+		return fmt.Errorf("failed to save personal info vector: %w", err)
+	}
+
+	if pis.multiVectors != nil {
+		vectors, err := pis.embedSegments(ctx, text)
+		if err != nil {
+			return fmt.Errorf("failed to embed personal info segments: %w", err)
+		}
+		if err := pis.multiVectors.SaveMultiVector(ctx, personalInfo.ID, vectors, metadata); err != nil {
+			return fmt.Errorf("failed to save personal info multivector: %w", err)
+		}
+	}
+
+	return nil
+}
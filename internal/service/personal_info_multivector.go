@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"refo-rag-server/internal/snippet"
+	"refo-rag-server/internal/storage"
+)
+
+// Multivector segment granularities
+const (
+	MultiVectorSentences = "sentence"
+	MultiVectorPhrases   = "phrase"
+)
+
+// phraseWords and phraseStride size the overlapping word windows of phrase segmentation
+const (
+	phraseWords  = 4
+	phraseStride = 2
+)
+
+// MultiVectorOptions configures experimental multivector retrieval of personal info
+type MultiVectorOptions struct {
+	// Granularity splits text into sentences or overlapping phrases
+	Granularity string
+
+	// MaxVectors caps the vectors of an entry or query, the whole text included
+	MaxVectors int
+}
+
+// SetMultiVector also stores each entry as the vectors of its whole text and its segments, and
+// searches by MaxSim over the segments of the query instead of a single dense vector. Entries
+// indexed before it was set aren't found until personal info is reindexed
+func (pis *PersonalInfoService) SetMultiVector(store storage.MultiVectorStore, opts MultiVectorOptions) {
+	pis.multiVectors = store
+	pis.multiVectorOptions = opts
+}
+
+// segments splits text into the texts embedded as its multivector: the whole text first, then
+// each sentence or phrase, capped at MaxVectors
+func (opts MultiVectorOptions) segments(text string) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	segments := []string{text}
+
+	var parts []string
+	if opts.Granularity == MultiVectorPhrases {
+		words := strings.Fields(text)
+		for start := 0; start < len(words); start += phraseStride {
+			end := min(start+phraseWords, len(words))
+			parts = append(parts, strings.Join(words[start:end], " "))
+			if end == len(words) {
+				break
+			}
+		}
+	} else {
+		parts = snippet.SplitSentences(text)
+	}
+
+	// A text of a single segment is already covered by the whole text
+	if len(parts) < 2 {
+		return segments
+	}
+	for _, part := range parts {
+		if len(segments) == opts.MaxVectors {
+			break
+		}
+		segments = append(segments, part)
+	}
+	return segments
+}
+
+// embedSegments embeds the segments of text without the model's instructions, since the whole
+// text and its fragments are compared with each other the same way for entries and queries
+func (pis *PersonalInfoService) embedSegments(ctx context.Context, text string) ([][]float32, error) {
+	segments := pis.multiVectorOptions.segments(text)
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("no text to embed")
+	}
+	embeddings, err := pis.embeddingProvider.EmbedBatch(ctx, segments)
+	if err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, 0, len(embeddings))
+	for _, embedding := range embeddings {
+		if embedding != nil {
+			vectors = append(vectors, embedding)
+		}
+	}
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("no segment could be embedded")
+	}
+	return vectors, nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/usage"
+)
+
+// Personal info reindex batching
+const (
+	personalInfoReindexBatch = 100
+	maxReindexFailedIDs      = 100
+)
+
+// ErrPersonalInfoReindexRunning is returned when a personal info reindex is already in progress
+var ErrPersonalInfoReindexRunning = errors.New("a personal info reindex is already running")
+
+// ErrResumeJobInvalid is returned when the job to resume isn't an unfinished personal info reindex
+var ErrResumeJobInvalid = errors.New("job can't be resumed")
+
+// PersonalInfoReindexService re-embeds every personal info entry, e.g. after the embedding text of
+// entries changed. Progress is recorded in the job's result after every batch
+type PersonalInfoReindexService struct {
+	personalInfo *PersonalInfoService
+	jobs         *JobLog
+
+	// running is set while a reindex runs
+	running atomic.Bool
+}
+
+// NewPersonalInfoReindexService creates a new personal info reindex service
+func NewPersonalInfoReindexService(personalInfo *PersonalInfoService, jobs *JobLog) *PersonalInfoReindexService {
+	return &PersonalInfoReindexService{
+		personalInfo: personalInfo,
+		jobs:         jobs,
+	}
+}
+
+// Start starts re-embedding every personal info entry in the background and returns the job ID.
+// When resumeJobID is set, the job continues after the last entry that job finished; it must be a
+// personal info reindex that failed or was interrupted by a restart.
+func (prs *PersonalInfoReindexService) Start(ctx context.Context, resumeJobID string) (string, error) {
+	if !prs.running.CompareAndSwap(false, true) {
+		return "", ErrPersonalInfoReindexRunning
+	}
+
+	progress := &models.PersonalInfoReindexProgress{FailedIDs: []string{}}
+	if resumeJobID != "" {
+		resumed, err := prs.resumable(ctx, resumeJobID)
+		if err != nil {
+			prs.running.Store(false)
+			return "", err
+		}
+		progress = resumed
+		progress.ResumedFrom = resumeJobID
+	}
+
+	jobID, err := prs.jobs.StartTracked(ctx, models.JobKindPersonalInfoReindex, "personal_info", func(ctx context.Context, tracker *JobProgress) (interface{}, error) {
+		defer prs.running.Store(false)
+		return prs.run(ctx, progress, tracker)
+	})
+	if err != nil {
+		prs.running.Store(false)
+		return "", err
+	}
+	return jobID, nil
+}
+
+// resumable loads the progress of an unfinished reindex job. A job still marked running is
+// accepted since this process isn't running it, so it was interrupted
+func (prs *PersonalInfoReindexService) resumable(ctx context.Context, jobID string) (*models.PersonalInfoReindexProgress, error) {
+	job, err := prs.jobs.Get(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.Kind != models.JobKindPersonalInfoReindex {
+		return nil, fmt.Errorf("%w: %s is not a personal info reindex job", ErrResumeJobInvalid, jobID)
+	}
+	if job.Status == models.JobStatusSucceeded {
+		return nil, fmt.Errorf("%w: job %s already finished", ErrResumeJobInvalid, jobID)
+	}
+
+	progress := &models.PersonalInfoReindexProgress{}
+	if len(job.Result) > 0 {
+		if err := json.Unmarshal(job.Result, progress); err != nil {
+			return nil, fmt.Errorf("%w: job %s has an unreadable result: %v", ErrResumeJobInvalid, jobID, err)
+		}
+	}
+	if progress.FailedIDs == nil {
+		progress.FailedIDs = []string{}
+	}
+	return progress, nil
+}
+
+// run re-embeds the entries after the progress cursor in ID order, reporting after every batch
+func (prs *PersonalInfoReindexService) run(ctx context.Context, progress *models.PersonalInfoReindexProgress, tracker *JobProgress) (*models.PersonalInfoReindexProgress, error) {
+	startTime := time.Now()
+	elapsed := time.Duration(progress.DurationMs) * time.Millisecond
+
+	total, err := prs.personalInfo.personalInfoStore.CountPersonalInfo(ctx)
+	if err != nil {
+		return progress, err
+	}
+	progress.Total = total
+
+	for {
+		batch, err := prs.personalInfo.personalInfoStore.ListPersonalInfoAfter(ctx, progress.Cursor, personalInfoReindexBatch)
+		if err != nil {
+			return progress, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, personalInfo := range batch {
+			if err := ctx.Err(); err != nil {
+				return progress, err
+			}
+
+			err := prs.personalInfo.indexPersonalInfo(ctx, personalInfo)
+			var budgetErr *usage.BudgetError
+			if errors.As(err, &budgetErr) {
+				// Every further entry would fail too; stop so the job can be resumed once the budget resets
+				tracker.Report(ctx, progress)
+				return progress, err
+			}
+			if err != nil {
+				fmt.Printf("warning: failed to reindex personal info %s: %v\n", personalInfo.ID, err)
+				progress.Failed++
+				if len(progress.FailedIDs) < maxReindexFailedIDs {
+					progress.FailedIDs = append(progress.FailedIDs, personalInfo.ID)
+				}
+			} else {
+				progress.Indexed++
+			}
+			progress.Processed++
+			progress.Cursor = personalInfo.ID
+		}
+
+		progress.DurationMs = (elapsed + time.Since(startTime)).Milliseconds()
+		tracker.Report(ctx, progress)
+	}
+
+	progress.DurationMs = (elapsed + time.Since(startTime)).Milliseconds()
+	return progress, nil
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// ErrProfileEmpty is returned when a user has no personal info or conversations to build a profile from
+var ErrProfileEmpty = errors.New("no personal info or conversations for user")
+
+// profileRefreshBatch bounds the profiles refreshed per scheduled run
+const profileRefreshBatch = 50
+
+// userProfilePrompt instructs the model how to synthesize a user profile
+const userProfilePrompt = `You build a long-term memory profile of a user for a personal assistant.
+You receive notes entered by the user's guardians and excerpts of the user's conversations.
+Return only a JSON object with the string array fields "preferences", "routines" and "health_notes".
+Each entry is one short, self-contained statement. Guardian notes take precedence over conversations
+when they conflict. Only include facts stated in the input, write in the language of the input, and
+use empty arrays when nothing is known.`
+
+// importanceRank orders personal info importance levels, most important first
+var importanceRank = map[string]int{"high": 0, "medium": 1, "low": 2}
+
+// ProfileOptions tunes user profile synthesis
+type ProfileOptions struct {
+	// CacheTTL is how long a synthesized profile is served before it is regenerated
+	CacheTTL time.Duration
+
+	// MaxConversations bounds the conversations included in synthesis
+	MaxConversations int
+
+	// ReadOnly serves synthesized profiles without caching them, for a standby whose database is
+	// a read-only replica
+	ReadOnly bool
+}
+
+// ProfileService synthesizes and caches user profiles
+type ProfileService struct {
+	profileStore      storage.ProfileStore
+	personalInfoStore storage.PersonalInfoStore
+	conversationStore storage.ConversationStore
+	completion        storage.CompletionProvider
+	opts              ProfileOptions
+}
+
+// NewProfileService creates a new profile service
+func NewProfileService(
+	profileStore storage.ProfileStore,
+	personalInfoStore storage.PersonalInfoStore,
+	conversationStore storage.ConversationStore,
+	completion storage.CompletionProvider,
+	opts ProfileOptions,
+) *ProfileService {
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = 24 * time.Hour
+	}
+	if opts.MaxConversations <= 0 {
+		opts.MaxConversations = 30
+	}
+	return &ProfileService{
+		profileStore:      profileStore,
+		personalInfoStore: personalInfoStore,
+		conversationStore: conversationStore,
+		completion:        completion,
+		opts:              opts,
+	}
+}
+
+// GetProfile returns the user's cached profile, synthesizing it when missing, expired or refresh is set.
+// A stale cached profile is served if synthesis fails.
+func (ps *ProfileService) GetProfile(ctx context.Context, userID string, refresh bool) (*models.UserProfile, error) {
+	cached, err := ps.profileStore.GetUserProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && !refresh && time.Since(cached.GeneratedAt) < ps.opts.CacheTTL {
+		return cached, nil
+	}
+
+	profile, err := ps.SynthesizeProfile(ctx, userID)
+	if err != nil && cached != nil && !errors.Is(err, ErrProfileEmpty) {
+		fmt.Printf("warning: serving stale profile for user %s: %v\n", userID, err)
+		errreport.Background(ctx, "user_profile_synthesis", err)
+		return cached, nil
+	}
+
+	return profile, err
+}
+
+// SynthesizeProfile builds a user's profile with the chat model and caches it, unless read-only
+func (ps *ProfileService) SynthesizeProfile(ctx context.Context, userID string) (*models.UserProfile, error) {
+	allPersonalInfo, err := ps.personalInfoStore.GetPersonalInfoByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var personalInfo []*models.PersonalInfo
+	for _, info := range allPersonalInfo {
+		if info.Suppression == nil {
+			personalInfo = append(personalInfo, info)
+		}
+	}
+
+	conversations, err := ps.conversationStore.GetTopConversationsByUser(ctx, userID, ps.opts.MaxConversations)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(personalInfo) == 0 && len(conversations) == 0 {
+		return nil, ErrProfileEmpty
+	}
+
+	output, err := ps.completion.Complete(ctx, userProfilePrompt, profileInput(personalInfo, conversations))
+	if err != nil {
+		return nil, fmt.Errorf("failed to synthesize profile: %w", err)
+	}
+
+	profile, err := parseProfile(output)
+	if err != nil {
+		return nil, err
+	}
+	profile.UserID = userID
+	profile.Sources = models.ProfileSources{
+		PersonalInfoCount: len(personalInfo),
+		ConversationCount: len(conversations),
+	}
+	profile.GeneratedAt = time.Now()
+
+	if ps.opts.ReadOnly {
+		return profile, nil
+	}
+	if err := ps.profileStore.SaveUserProfile(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// RefreshStaleProfiles regenerates cached profiles older than the cache TTL and reports how many were refreshed
+func (ps *ProfileService) RefreshStaleProfiles(ctx context.Context) (int, error) {
+	userIDs, err := ps.profileStore.ListStaleProfiles(ctx, time.Now().Add(-ps.opts.CacheTTL), profileRefreshBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	refreshed := 0
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return refreshed, ctx.Err()
+		}
+		if _, err := ps.SynthesizeProfile(ctx, userID); err != nil {
+			fmt.Printf("warning: failed to refresh profile for user %s: %v\n", userID, err)
+			errreport.Background(ctx, "user_profile_refresh", err)
+			continue
+		}
+		refreshed++
+	}
+
+	return refreshed, nil
+}
+
+// profileInput renders personal info, most important first, followed by conversation excerpts
+func profileInput(personalInfo []*models.PersonalInfo, conversations []*models.Conversation) string {
+	sorted := make([]*models.PersonalInfo, len(personalInfo))
+	copy(sorted, personalInfo)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return importanceRank[sorted[i].Importance] < importanceRank[sorted[j].Importance]
+	})
+
+	var b strings.Builder
+	b.WriteString("Guardian notes:\n")
+	for _, info := range sorted {
+		fmt.Fprintf(&b, "- [%s, %s] %s\n", info.Category, info.Importance, info.Content)
+	}
+
+	b.WriteString("\nConversation excerpts:\n")
+	b.WriteString(formatTranscript(conversations, maxSummaryInputChars))
+
+	return b.String()
+}
+
+// parseProfile decodes the model's JSON output, tolerating surrounding text such as code fences
+func parseProfile(output string) (*models.UserProfile, error) {
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("failed to parse profile: no JSON object in model output")
+	}
+
+	profile := &models.UserProfile{}
+	if err := json.Unmarshal([]byte(output[start:end+1]), profile); err != nil {
+		return nil, fmt.Errorf("failed to parse profile: %w", err)
+	}
+
+	if profile.Preferences == nil {
+		profile.Preferences = []string{}
+	}
+	if profile.Routines == nil {
+		profile.Routines = []string{}
+	}
+	if profile.HealthNotes == nil {
+		profile.HealthNotes = []string{}
+	}
+
+	return profile, nil
+}
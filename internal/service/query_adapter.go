@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// ErrInvalidQueryAdapter is returned for an adapter that doesn't fit its model's vectors
+var ErrInvalidQueryAdapter = errors.New("invalid query adapter")
+
+// maxQueryAdapterBias caps the length of a bias: query vectors have unit length, so a longer bias
+// would outweigh the query itself
+const maxQueryAdapterBias = 1
+
+// QueryAdapterOptions configures the cache of adapters applied to queries
+type QueryAdapterOptions struct {
+	// CacheTTL is how long an adapter, or a user's lack of one, is served before it is read again;
+	// an upload or deletion on another replica takes effect within it
+	CacheTTL time.Duration
+
+	// CacheSize caps the cached users and models
+	CacheSize int
+}
+
+// QueryAdapters keeps per-user adapters trained offline from search feedback and applies them to
+// query embeddings, so frequent users' searches lean towards what they found relevant before.
+// Stored vectors are left alone; only queries move
+type QueryAdapters struct {
+	store        storage.QueryAdapterStore
+	dimensions   map[string]int
+	defaultModel string
+	opts         QueryAdapterOptions
+
+	mu     sync.Mutex
+	cached map[queryAdapterKey]cachedQueryAdapter
+}
+
+// queryAdapterKey identifies a user's adapter for a model
+type queryAdapterKey struct {
+	userID string
+	model  string
+}
+
+// cachedQueryAdapter is a looked up adapter, nil if the user has none
+type cachedQueryAdapter struct {
+	adapter   *models.QueryAdapter
+	expiresAt time.Time
+}
+
+// NewQueryAdapters creates the adapter service for models with the given vector dimensions;
+// uploads default to defaultModel
+func NewQueryAdapters(store storage.QueryAdapterStore, dimensions map[string]int, defaultModel string, opts QueryAdapterOptions) *QueryAdapters {
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = 5 * time.Minute
+	}
+	if opts.CacheSize <= 0 {
+		opts.CacheSize = 10000
+	}
+	return &QueryAdapters{
+		store:        store,
+		dimensions:   dimensions,
+		defaultModel: defaultModel,
+		opts:         opts,
+		cached:       make(map[queryAdapterKey]cachedQueryAdapter),
+	}
+}
+
+// List describes a user's adapters
+func (qa *QueryAdapters) List(ctx context.Context, userID string) (*models.QueryAdapterListResponse, error) {
+	adapters, err := qa.store.ListQueryAdapters(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.QueryAdapterListResponse{UserID: userID, Adapters: make([]models.QueryAdapterSummary, 0, len(adapters))}
+	for _, adapter := range adapters {
+		resp.Adapters = append(resp.Adapters, summarizeQueryAdapter(adapter))
+	}
+	return resp, nil
+}
+
+// Upload validates an adapter against its model's dimension and replaces the user's adapter for
+// the model. It fails with ErrUnknownEmbeddingModel or ErrInvalidQueryAdapter
+func (qa *QueryAdapters) Upload(ctx context.Context, userID string, req *models.QueryAdapterUploadRequest) (*models.QueryAdapterSummary, error) {
+	model := req.Model
+	if model == "" {
+		model = qa.defaultModel
+	}
+	dimension, ok := qa.dimensions[model]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEmbeddingModel, model)
+	}
+	if err := validateQueryAdapter(req.Bias, req.Scale, dimension); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQueryAdapter, err)
+	}
+
+	adapter := &models.QueryAdapter{
+		UserID:    userID,
+		Model:     model,
+		Bias:      req.Bias,
+		Scale:     req.Scale,
+		Samples:   req.Samples,
+		TrainedAt: req.TrainedAt,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if err := qa.store.SaveQueryAdapter(ctx, adapter); err != nil {
+		return nil, err
+	}
+	qa.forget(userID, model)
+
+	summary := summarizeQueryAdapter(adapter)
+	return &summary, nil
+}
+
+// Delete removes a user's adapter for a model, defaulting to the default model; it returns nil if
+// there was none
+func (qa *QueryAdapters) Delete(ctx context.Context, userID string, model string) (*models.QueryAdapterDeleteResponse, error) {
+	if model == "" {
+		model = qa.defaultModel
+	}
+	found, err := qa.store.DeleteQueryAdapter(ctx, userID, model)
+	if err != nil {
+		return nil, err
+	}
+	qa.forget(userID, model)
+	if !found {
+		return nil, nil
+	}
+	return &models.QueryAdapterDeleteResponse{UserID: userID, Model: model}, nil
+}
+
+// Adapt returns a user's query vector with their adapter for model applied, or the vector
+// unchanged if they have none. Personalization never fails a search: an adapter that can't be
+// read or doesn't fit the vector is logged and skipped
+func (qa *QueryAdapters) Adapt(ctx context.Context, userID string, model string, vector []float32) []float32 {
+	if qa == nil || userID == "" {
+		return vector
+	}
+
+	adapter, err := qa.lookup(ctx, userID, model)
+	if err != nil {
+		fmt.Printf("warning: failed to load query adapter of user %s: %v\n", userID, err)
+		return vector
+	}
+	if adapter == nil {
+		return vector
+	}
+
+	adapted, err := applyQueryAdapter(adapter, vector)
+	if err != nil {
+		fmt.Printf("warning: skipped query adapter of user %s for %s: %v\n", userID, model, err)
+		return vector
+	}
+	return adapted
+}
+
+// lookup returns a user's adapter for model from the cache, reading it on a miss
+func (qa *QueryAdapters) lookup(ctx context.Context, userID string, model string) (*models.QueryAdapter, error) {
+	key := queryAdapterKey{userID: userID, model: model}
+	now := time.Now()
+
+	qa.mu.Lock()
+	entry, ok := qa.cached[key]
+	qa.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.adapter, nil
+	}
+
+	adapter, err := qa.store.GetQueryAdapter(ctx, userID, model)
+	if err != nil {
+		return nil, err
+	}
+
+	qa.mu.Lock()
+	defer qa.mu.Unlock()
+	if len(qa.cached) >= qa.opts.CacheSize {
+		for k, e := range qa.cached {
+			if now.After(e.expiresAt) {
+				delete(qa.cached, k)
+			}
+		}
+		// Still full of live entries: evict arbitrary ones, which are read again when next needed
+		for k := range qa.cached {
+			if len(qa.cached) < qa.opts.CacheSize {
+				break
+			}
+			delete(qa.cached, k)
+		}
+	}
+	qa.cached[key] = cachedQueryAdapter{adapter: adapter, expiresAt: now.Add(qa.opts.CacheTTL)}
+	return adapter, nil
+}
+
+// forget drops a cached adapter so the next query reads the stored one
+func (qa *QueryAdapters) forget(userID string, model string) {
+	qa.mu.Lock()
+	delete(qa.cached, queryAdapterKey{userID: userID, model: model})
+	qa.mu.Unlock()
+}
+
+// validateQueryAdapter checks an adapter's vectors against the dimension of its model's vectors
+func validateQueryAdapter(bias []float32, scale []float32, dimension int) error {
+	if len(bias) != dimension {
+		return fmt.Errorf("bias has %d dimensions, the model's vectors %d", len(bias), dimension)
+	}
+	if len(scale) != 0 && len(scale) != dimension {
+		return fmt.Errorf("scale has %d dimensions, the model's vectors %d", len(scale), dimension)
+	}
+	var norm float64
+	for i, value := range bias {
+		if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+			return fmt.Errorf("bias component %d is not a finite number", i)
+		}
+		norm += float64(value) * float64(value)
+	}
+	if math.Sqrt(norm) > maxQueryAdapterBias {
+		return fmt.Errorf("bias is longer than %d", maxQueryAdapterBias)
+	}
+	for i, value := range scale {
+		if !(value > 0) || math.IsInf(float64(value), 0) {
+			return fmt.Errorf("scale component %d is not a positive finite number", i)
+		}
+	}
+	return nil
+}
+
+// applyQueryAdapter returns scale*vector + bias, scaled to unit length
+func applyQueryAdapter(adapter *models.QueryAdapter, vector []float32) ([]float32, error) {
+	if len(adapter.Bias) != len(vector) || (len(adapter.Scale) > 0 && len(adapter.Scale) != len(vector)) {
+		return nil, fmt.Errorf("adapter has %d dimensions, the query vector %d", len(adapter.Bias), len(vector))
+	}
+
+	adapted := make([]float32, len(vector))
+	var norm float64
+	for i, value := range vector {
+		if len(adapter.Scale) > 0 {
+			value *= adapter.Scale[i]
+		}
+		adapted[i] = value + adapter.Bias[i]
+		norm += float64(adapted[i]) * float64(adapted[i])
+	}
+	if norm == 0 {
+		return nil, fmt.Errorf("adapted vector is zero")
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range adapted {
+		adapted[i] *= scale
+	}
+	return adapted, nil
+}
+
+// summarizeQueryAdapter describes an adapter without its vectors
+func summarizeQueryAdapter(adapter *models.QueryAdapter) models.QueryAdapterSummary {
+	var norm float64
+	for _, value := range adapter.Bias {
+		norm += float64(value) * float64(value)
+	}
+	return models.QueryAdapterSummary{
+		Model:     adapter.Model,
+		Dimension: len(adapter.Bias),
+		BiasNorm:  math.Sqrt(norm),
+		Scaled:    len(adapter.Scale) > 0,
+		Samples:   adapter.Samples,
+		TrainedAt: adapter.TrainedAt,
+		UpdatedAt: adapter.UpdatedAt,
+	}
+}
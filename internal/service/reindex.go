@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"refo-rag-server/internal/models"
+)
+
+// ReindexService rebuilds vectors from the records stored in PostgreSQL
+type ReindexService struct {
+	conversations *ConversationService
+	personalInfo  *PersonalInfoService
+	jobs          *JobLog
+}
+
+// NewReindexService creates a new reindex service
+func NewReindexService(conversations *ConversationService, personalInfo *PersonalInfoService, jobs *JobLog) *ReindexService {
+	return &ReindexService{
+		conversations: conversations,
+		personalInfo:  personalInfo,
+		jobs:          jobs,
+	}
+}
+
+// ReindexUser purges all of a user's vectors and re-embeds their conversations and personal info.
+// A dry run reports what would be deleted and re-embedded without changing anything. Both are
+// recorded in the job log.
+func (rs *ReindexService) ReindexUser(ctx context.Context, userID string, dryRun bool) (*models.UserReindexResponse, error) {
+	startTime := time.Now()
+	resp := &models.UserReindexResponse{
+		DryRun: dryRun,
+		UserID: userID,
+	}
+
+	jobID, err := rs.jobs.Run(ctx, models.JobKindUserReindex, userID, dryRun, func(ctx context.Context) (interface{}, error) {
+		conversations, err := rs.conversations.ReindexUser(ctx, userID, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		resp.Conversations = *conversations
+
+		personalInfo, err := rs.personalInfo.ReindexUser(ctx, userID, dryRun)
+		if err != nil {
+			return resp, err
+		}
+		resp.PersonalInfo = *personalInfo
+
+		resp.DurationMs = time.Since(startTime).Milliseconds()
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp.JobID = jobID
+	return resp, nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"refo-rag-server/internal/filter"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/retrieval"
+	"refo-rag-server/internal/tenant"
+)
+
+// queryCacheKey returns the query cache key of a search: the tenant and user come first so a
+// user's searches can be dropped together, then the pipeline variant and the request as sent
+func (cs *ConversationService) queryCacheKey(ctx context.Context, req *models.ConversationSearchRequest, variant string) string {
+	if cs.opts.QueryCache == nil {
+		return ""
+	}
+	// The filter, overrides and maximum age aren't part of the request's JSON
+	request, err := json.Marshal(struct {
+		*models.ConversationSearchRequest
+		Filter     *filter.Expr
+		Overrides  *models.RetrievalOverrides
+		MaxAgeDays *int
+	}{req, req.Filter, req.Overrides, req.MaxAgeDays})
+	if err != nil {
+		return ""
+	}
+	return queryCachePrefix(tenant.FromContext(ctx), req.UserID) + variant + "\x00" + string(request)
+}
+
+// queryCachePrefix returns the key prefix of a user's cached searches
+func queryCachePrefix(tenantID string, userID string) string {
+	return tenantID + "\x00" + userID + "\x00"
+}
+
+// cachedSearch returns the candidates a search found before, if they are still cached
+func (cs *ConversationService) cachedSearch(key string) ([]retrieval.Candidate, bool) {
+	if key == "" {
+		return nil, false
+	}
+	return cs.opts.QueryCache.Get(key)
+}
+
+// cacheSearch keeps the candidates a search found for repeats of it
+func (cs *ConversationService) cacheSearch(key string, candidates []retrieval.Candidate) {
+	if key == "" {
+		return
+	}
+	cs.opts.QueryCache.Set(key, candidates)
+}
+
+// invalidateSearches drops the cached searches a user's new conversation could change: the user's
+// own and those across all users of the tenant
+func (cs *ConversationService) invalidateSearches(ctx context.Context, userID string) {
+	if cs.opts.QueryCache == nil {
+		return
+	}
+	tenantID := tenant.FromContext(ctx)
+	own, all := queryCachePrefix(tenantID, userID), queryCachePrefix(tenantID, "")
+	cs.opts.QueryCache.DeleteFunc(func(key string) bool {
+		return strings.HasPrefix(key, own) || strings.HasPrefix(key, all)
+	})
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/retrieval"
+	"refo-rag-server/internal/storage"
+	"refo-rag-server/internal/usage"
+)
+
+// ErrInvalidPlayground is returned when a playground configuration names stages that can't be built
+var ErrInvalidPlayground = errors.New("invalid playground configuration")
+
+// playgroundPreviewRunes bounds the question preview of playground results
+const playgroundPreviewRunes = 160
+
+// PlaygroundConfig is a playground configuration with its overrides parsed
+type PlaygroundConfig struct {
+	models.PlaygroundConfiguration
+	Overrides *models.RetrievalOverrides
+}
+
+// SearchPlayground runs one search through each configuration in turn and compares every run's
+// results with the first's. The runs aren't logged as searches or mirrored to the shadow model.
+// A configuration that fails at search time reports its error in its run; one that can't be built
+// fails the request with ErrInvalidPlayground
+func (cs *ConversationService) SearchPlayground(ctx context.Context, req *models.ConversationSearchRequest, configs []PlaygroundConfig) (*models.PlaygroundResponse, error) {
+	pipelines := make([]*retrieval.Pipeline, len(configs))
+	for i, config := range configs {
+		pipeline, err := cs.playgroundPipeline(config.PlaygroundConfiguration)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPlayground, config.Name, err)
+		}
+		pipelines[i] = pipeline
+	}
+
+	resp := &models.PlaygroundResponse{Query: req.Query, Runs: make([]models.PlaygroundRun, 0, len(configs))}
+	var baseline map[string]int
+	for i, config := range configs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		spec := pipelines[i].Spec()
+		run := models.PlaygroundRun{
+			Name: config.Name,
+			Stages: models.PlaygroundStages{
+				Transformers: nonNil(spec.Transformers),
+				Retrievers:   nonNil(spec.Retrievers),
+				Fuser:        spec.Fuser,
+				Filters:      nonNil(spec.Filters),
+				Normalizer:   spec.Normalizer,
+				Rerankers:    nonNil(spec.Rerankers),
+			},
+			Results: []models.PlaygroundResult{},
+			Added:   []string{},
+			Dropped: []string{},
+		}
+
+		query := cs.pipelineQuery(ctx, req)
+		if config.Overrides != nil {
+			query.Overrides = *config.Overrides
+		}
+		start := time.Now()
+		candidates, err := pipelines[i].Run(ctx, query)
+		run.LatencyMs = time.Since(start).Milliseconds()
+		if err != nil {
+			if errors.Is(err, retrieval.ErrInvalidOverride) {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPlayground, config.Name, err)
+			}
+			// Every other configuration would fail the same way
+			var budgetErr *usage.BudgetError
+			if errors.Is(err, storage.ErrUserScopeRequired) || errors.As(err, &budgetErr) {
+				return nil, err
+			}
+			run.Error = err.Error()
+			resp.Runs = append(resp.Runs, run)
+			continue
+		}
+
+		ranks := make(map[string]int, len(candidates))
+		for rank, candidate := range candidates {
+			ranks[candidate.ConversationID] = rank + 1
+			run.Results = append(run.Results, models.PlaygroundResult{
+				Rank:           rank + 1,
+				ConversationID: candidate.ConversationID,
+				Score:          candidate.Score,
+				RawScore:       candidate.RawScore,
+				BaselineRank:   baseline[candidate.ConversationID],
+				Preview:        truncateRunes(candidate.Conversation.Question, playgroundPreviewRunes),
+			})
+		}
+
+		if baseline == nil {
+			baseline = ranks
+			run.Overlap = 1
+			for j := range run.Results {
+				run.Results[j].BaselineRank = run.Results[j].Rank
+			}
+		} else {
+			run.Overlap, run.Added, run.Dropped = compareRanks(baseline, ranks, run.Results, resp.Runs[0].Results)
+		}
+		resp.Runs = append(resp.Runs, run)
+	}
+
+	return resp, nil
+}
+
+// playgroundPipeline builds the pipeline of a configuration from the configured pipeline's stages
+// and settings, replacing those the configuration sets
+func (cs *ConversationService) playgroundPipeline(config models.PlaygroundConfiguration) (*retrieval.Pipeline, error) {
+	spec := cs.pipeline.Spec()
+	if config.Transformers != nil {
+		spec.Transformers = config.Transformers
+	}
+	if config.Retrievers != nil {
+		spec.Retrievers = config.Retrievers
+	}
+	if config.Fuser != "" {
+		spec.Fuser = config.Fuser
+	}
+	if config.Filters != nil {
+		spec.Filters = config.Filters
+	}
+	if config.Normalizer != "" {
+		spec.Normalizer = config.Normalizer
+	}
+	if config.Rerankers != nil {
+		spec.Rerankers = config.Rerankers
+	}
+
+	deps := cs.pipeline.Deps()
+	if config.RecencyWeight != nil {
+		deps.RecencyWeight = *config.RecencyWeight
+	}
+	if config.ImportanceWeight != nil {
+		deps.ImportanceWeight = *config.ImportanceWeight
+	}
+	return retrieval.Build(spec, deps)
+}
+
+// compareRanks returns the share of the baseline's results a run also returned, and the
+// conversations only the run and only the baseline returned, in rank order
+func compareRanks(baseline, ranks map[string]int, results, baselineResults []models.PlaygroundResult) (float64, []string, []string) {
+	added := []string{}
+	for _, result := range results {
+		if _, ok := baseline[result.ConversationID]; !ok {
+			added = append(added, result.ConversationID)
+		}
+	}
+	dropped := []string{}
+	for _, result := range baselineResults {
+		if _, ok := ranks[result.ConversationID]; !ok {
+			dropped = append(dropped, result.ConversationID)
+		}
+	}
+
+	if len(baseline) == 0 {
+		if len(ranks) == 0 {
+			return 1, added, dropped
+		}
+		return 0, added, dropped
+	}
+	return float64(len(baseline)-len(dropped)) / float64(len(baseline)), added, dropped
+}
+
+// nonNil returns names, or an empty list in place of nil so it encodes as []
+func nonNil(names []string) []string {
+	if names == nil {
+		return []string{}
+	}
+	return names
+}
+
+// truncateRunes shortens text to at most max runes, marking a cut with an ellipsis
+func truncateRunes(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	return string([]rune(text)[:max]) + "…"
+}
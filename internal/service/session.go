@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"refo-rag-server/internal/events"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// Session errors returned to handlers
+var (
+	ErrSessionExists = errors.New("session already exists")
+	ErrSessionClosed = errors.New("session is closed")
+	ErrSessionEmpty  = errors.New("session has no conversations")
+)
+
+// maxSummaryInputChars bounds the transcript sent for summarization; the most recent turns are kept
+const maxSummaryInputChars = 24000
+
+// sessionSummaryPrompt instructs the model how to summarize a session
+const sessionSummaryPrompt = `You summarize chat sessions between a user and an assistant for later recall.
+Write a concise summary of at most 10 sentences covering the topics discussed, facts the user
+shared about themselves, decisions made, and open follow-ups. Write in the language of the conversation.
+Do not invent details that are not in the transcript.`
+
+// rollingSummaryPrompt instructs the model how to fold new turns into an existing summary
+const rollingSummaryPrompt = `You maintain a running summary of a chat session between a user and an assistant.
+You receive the current summary (possibly empty) and the newest messages. Return an updated summary of at
+most 10 sentences that keeps still-relevant facts, preferences, decisions and open follow-ups from the
+current summary and adds what is new. Write in the language of the conversation. Do not invent details.
+Return only the summary text.`
+
+// SessionOptions tunes session summarization
+type SessionOptions struct {
+	// RollingSummary updates the session summary in the background after each saved conversation
+	RollingSummary bool
+
+	// SummaryTimeout bounds a single background summary update
+	SummaryTimeout time.Duration
+}
+
+// SessionService handles session business logic
+type SessionService struct {
+	sessionStore storage.SessionStore
+	completion   storage.CompletionProvider
+	opts         SessionOptions
+
+	// summaryLocks serializes summary updates per session so concurrent saves don't drop turns
+	summaryLocks sync.Map
+}
+
+// NewSessionService creates a new session service
+func NewSessionService(sessionStore storage.SessionStore, completion storage.CompletionProvider, opts SessionOptions) *SessionService {
+	if opts.SummaryTimeout <= 0 {
+		opts.SummaryTimeout = 30 * time.Second
+	}
+	return &SessionService{
+		sessionStore: sessionStore,
+		completion:   completion,
+		opts:         opts,
+	}
+}
+
+// CreateSession creates an open session
+func (ss *SessionService) CreateSession(ctx context.Context, req *models.SessionCreateRequest) (*models.Session, error) {
+	return ss.createSession(ctx, req, time.Now())
+}
+
+// createSession creates a session that started at createdAt
+func (ss *SessionService) createSession(ctx context.Context, req *models.SessionCreateRequest, createdAt time.Time) (*models.Session, error) {
+	sessionID := req.SessionID
+	if sessionID == "" {
+		sessionID = uuid.New().String()
+	}
+
+	session := &models.Session{
+		ID:        sessionID,
+		UserID:    req.UserID,
+		Title:     req.Title,
+		Status:    models.SessionStatusOpen,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+
+	created, err := ss.sessionStore.CreateSession(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrSessionExists
+	}
+
+	return session, nil
+}
+
+// GetSession retrieves a session by ID
+func (ss *SessionService) GetSession(ctx context.Context, id string) (*models.Session, error) {
+	return ss.sessionStore.GetSession(ctx, id)
+}
+
+// ListSessions retrieves a page of sessions
+func (ss *SessionService) ListSessions(ctx context.Context, userID string, status string, limit int, offset int) ([]*models.Session, int, error) {
+	return ss.sessionStore.ListSessions(ctx, userID, status, limit, offset)
+}
+
+// CloseSession closes a session; it returns nil if the session doesn't exist
+func (ss *SessionService) CloseSession(ctx context.Context, id string) (*models.Session, error) {
+	session, err := ss.sessionStore.GetSession(ctx, id)
+	if err != nil || session == nil {
+		return nil, err
+	}
+
+	if err := ss.sessionStore.CloseSession(ctx, id, time.Now()); err != nil {
+		return nil, err
+	}
+	ss.summaryLocks.Delete(id)
+
+	return ss.sessionStore.GetSession(ctx, id)
+}
+
+// EnsureOpenSession returns the session a conversation is saved into, creating it if needed.
+// Saving into a closed session fails with ErrSessionClosed.
+func (ss *SessionService) EnsureOpenSession(ctx context.Context, id string, userID string) (*models.Session, error) {
+	return ss.ensureOpenSession(ctx, id, userID, time.Now())
+}
+
+// ensureOpenSession is EnsureOpenSession for a conversation created at createdAt, which starts the
+// session if it doesn't exist yet
+func (ss *SessionService) ensureOpenSession(ctx context.Context, id string, userID string, createdAt time.Time) (*models.Session, error) {
+	session, err := ss.sessionStore.GetSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if session == nil {
+		session, err = ss.createSession(ctx, &models.SessionCreateRequest{SessionID: id, UserID: userID}, createdAt)
+		if errors.Is(err, ErrSessionExists) {
+			// Created concurrently by another save
+			return ss.sessionStore.GetSession(ctx, id)
+		}
+		return session, err
+	}
+
+	if session.Status == models.SessionStatusClosed {
+		return nil, ErrSessionClosed
+	}
+
+	return session, nil
+}
+
+// GetTranscript retrieves a session with its conversations; it returns nil if the session doesn't exist
+func (ss *SessionService) GetTranscript(ctx context.Context, id string) (*models.SessionTranscriptResponse, error) {
+	session, err := ss.sessionStore.GetSession(ctx, id)
+	if err != nil || session == nil {
+		return nil, err
+	}
+
+	conversations, err := ss.sessionStore.GetSessionConversations(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	transcript := &models.SessionTranscriptResponse{
+		Session:       session,
+		Conversations: make([]models.ConversationResponse, 0, len(conversations)),
+	}
+	for _, conv := range conversations {
+		resp := conversationResponse(conv)
+		transcript.MessageCount += len(resp.Messages)
+		transcript.Conversations = append(transcript.Conversations, resp)
+	}
+
+	return transcript, nil
+}
+
+// SummarizeSession regenerates a session's summary from its full transcript; it returns nil
+// if the session doesn't exist
+func (ss *SessionService) SummarizeSession(ctx context.Context, id string) (*models.Session, error) {
+	session, err := ss.sessionStore.GetSession(ctx, id)
+	if err != nil || session == nil {
+		return nil, err
+	}
+
+	conversations, err := ss.sessionStore.GetSessionConversations(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	conversations = unsuppressed(conversations)
+	if len(conversations) == 0 {
+		return nil, ErrSessionEmpty
+	}
+
+	lock := ss.summaryLock(id)
+	lock.Lock()
+	defer lock.Unlock()
+
+	summary, err := ss.completion.Complete(ctx, sessionSummaryPrompt, formatTranscript(conversations, maxSummaryInputChars))
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize session: %w", err)
+	}
+
+	now := time.Now()
+	if err := ss.sessionStore.UpdateSessionSummary(ctx, id, summary, now); err != nil {
+		return nil, err
+	}
+
+	session.Summary = summary
+	session.SummaryUpdatedAt = &now
+	return session, nil
+}
+
+// GetContext returns a session's rolling summary with its most recent messages; it returns nil
+// if the session doesn't exist
+func (ss *SessionService) GetContext(ctx context.Context, id string, recentMessages int) (*models.SessionContextResponse, error) {
+	session, err := ss.sessionStore.GetSession(ctx, id)
+	if err != nil || session == nil {
+		return nil, err
+	}
+
+	resp := &models.SessionContextResponse{
+		SessionID:         session.ID,
+		UserID:            session.UserID,
+		Summary:           session.Summary,
+		SummaryUpdatedAt:  session.SummaryUpdatedAt,
+		ConversationCount: session.ConversationCount,
+		RecentMessages:    []models.Message{},
+	}
+	if recentMessages <= 0 {
+		return resp, nil
+	}
+
+	conversations, err := ss.sessionStore.GetSessionConversations(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []models.Message
+	for _, conv := range unsuppressed(conversations) {
+		messages = append(messages, conversationMessages(conv)...)
+	}
+	if len(messages) > recentMessages {
+		messages = messages[len(messages)-recentMessages:]
+	}
+	resp.RecentMessages = append(resp.RecentMessages, messages...)
+
+	return resp, nil
+}
+
+// OnConversationSaved folds a newly saved conversation into its session summary; subscribe it to
+// events.ConversationSaved. It is a no-op unless rolling summaries are enabled.
+func (ss *SessionService) OnConversationSaved(ctx context.Context, event events.Event) error {
+	saved, ok := event.Payload.(events.ConversationSavedEvent)
+	if !ok {
+		return fmt.Errorf("unexpected %s payload %T", event.Topic, event.Payload)
+	}
+	if !ss.opts.RollingSummary || saved.Conversation.SessionID == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ss.opts.SummaryTimeout)
+	defer cancel()
+	if err := ss.UpdateRollingSummary(ctx, saved.Conversation); err != nil {
+		return fmt.Errorf("failed to update rolling summary for session %s: %w", saved.Conversation.SessionID, err)
+	}
+	return nil
+}
+
+// UpdateRollingSummary folds a conversation's messages into its session summary
+func (ss *SessionService) UpdateRollingSummary(ctx context.Context, conversation *models.Conversation) error {
+	lock := ss.summaryLock(conversation.SessionID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	session, err := ss.sessionStore.GetSession(ctx, conversation.SessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return nil
+	}
+
+	prompt := "Current summary:\n" + session.Summary + "\n\nNew messages:\n" +
+		formatTranscript([]*models.Conversation{conversation}, maxSummaryInputChars)
+
+	summary, err := ss.completion.Complete(ctx, rollingSummaryPrompt, prompt)
+	if err != nil {
+		return fmt.Errorf("failed to update session summary: %w", err)
+	}
+
+	return ss.sessionStore.UpdateSessionSummary(ctx, session.ID, summary, time.Now())
+}
+
+// summaryLock returns the mutex guarding a session's summary
+func (ss *SessionService) summaryLock(id string) *sync.Mutex {
+	lock, _ := ss.summaryLocks.LoadOrStore(id, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
+// unsuppressed returns the conversations that are not suppressed
+func unsuppressed(conversations []*models.Conversation) []*models.Conversation {
+	kept := make([]*models.Conversation, 0, len(conversations))
+	for _, conv := range conversations {
+		if conv.Suppression == nil {
+			kept = append(kept, conv)
+		}
+	}
+	return kept
+}
+
+// formatTranscript renders conversations as "role (speaker): content" lines, dropping the
+// oldest lines when the transcript exceeds maxChars
+func formatTranscript(conversations []*models.Conversation, maxChars int) string {
+	var lines []string
+	for _, conv := range conversations {
+		for _, msg := range conversationMessages(conv) {
+			label := msg.Role
+			if msg.DisplayName != "" {
+				label += " (" + msg.DisplayName + ")"
+			}
+			lines = append(lines, label+": "+msg.Content)
+		}
+	}
+
+	total := 0
+	start := len(lines)
+	for start > 0 {
+		size := len(lines[start-1]) + 1
+		if total+size > maxChars {
+			break
+		}
+		total += size
+		start--
+	}
+
+	if start == len(lines) && len(lines) > 0 {
+		// A single oversized message; keep its tail, starting on a rune boundary
+		last := lines[len(lines)-1]
+		cut := len(last) - maxChars
+		for cut < len(last) && !utf8.RuneStart(last[cut]) {
+			cut++
+		}
+		return last[cut:]
+	}
+
+	return strings.Join(lines[start:], "\n")
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"refo-rag-server/internal/events"
+	"refo-rag-server/internal/logging"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/retrieval"
+	"refo-rag-server/internal/storage"
+)
+
+// shadowTimeout bounds one shadow embedding and its vector write or search
+const shadowTimeout = 30 * time.Second
+
+// maxShadowInflight caps concurrent shadow work; samples beyond it are dropped rather than queued
+const maxShadowInflight = 16
+
+// ShadowOptions selects the traffic a shadow model sees
+type ShadowOptions struct {
+	// Model names the shadow embedding model in logs
+	Model string
+
+	// SaveRate and QueryRate are the fractions of saves and searches mirrored to the shadow model
+	SaveRate  float64
+	QueryRate float64
+}
+
+// ShadowEvaluator mirrors a sample of saves and searches to an alternate embedding model and
+// its own collection, off the request path. Saves build the shadow collection; searches are run
+// against it and logged next to the results that were served, so a model migration can be judged
+// on live traffic before cutover. Shadow results are never returned to clients.
+type ShadowEvaluator struct {
+	embedder storage.EmbeddingProvider
+	vectors  storage.VectorStore
+	opts     ShadowOptions
+	inflight chan struct{}
+}
+
+// NewShadowEvaluator creates a shadow evaluator writing to and searching vectors
+func NewShadowEvaluator(embedder storage.EmbeddingProvider, vectors storage.VectorStore, opts ShadowOptions) *ShadowEvaluator {
+	return &ShadowEvaluator{
+		embedder: embedder,
+		vectors:  vectors,
+		opts:     opts,
+		inflight: make(chan struct{}, maxShadowInflight),
+	}
+}
+
+// Save embeds a saved conversation's text with the shadow model and writes it to the shadow
+// collection, if the save is sampled
+func (se *ShadowEvaluator) Save(ctx context.Context, conv *models.Conversation, text string, payload map[string]interface{}) {
+	if rand.Float64() >= se.opts.SaveRate {
+		return
+	}
+	se.run(ctx, "save", func(ctx context.Context) error {
+		embedding, err := se.embedder.EmbedDocument(ctx, text)
+		if err != nil {
+			return fmt.Errorf("failed to create shadow embedding: %w", err)
+		}
+		return se.vectors.SaveVector(ctx, conv.ID, embedding, payload)
+	})
+}
+
+// OnConversationSaved mirrors a newly saved conversation with a vector to the shadow collection,
+// if it is sampled; subscribe it to events.ConversationSaved
+func (se *ShadowEvaluator) OnConversationSaved(ctx context.Context, event events.Event) error {
+	saved, ok := event.Payload.(events.ConversationSavedEvent)
+	if !ok {
+		return fmt.Errorf("unexpected %s payload %T", event.Topic, event.Payload)
+	}
+	if saved.EmbeddedText != "" {
+		se.Save(ctx, saved.Conversation, saved.EmbeddedText, saved.VectorPayload)
+	}
+	return nil
+}
+
+// Search runs a search against the shadow collection, if it is sampled, and logs how its results
+// compare with the served candidates
+func (se *ShadowEvaluator) Search(ctx context.Context, req *models.ConversationSearchRequest, query *retrieval.Query, served []retrieval.Candidate) {
+	if rand.Float64() >= se.opts.QueryRate {
+		return
+	}
+	servedIDs := make([]string, 0, len(served))
+	for _, candidate := range served {
+		servedIDs = append(servedIDs, candidate.Conversation.ID)
+	}
+
+	se.run(ctx, "search", func(ctx context.Context) error {
+		start := time.Now()
+		embedding, err := se.embedder.EmbedQuery(ctx, req.Query)
+		if err != nil {
+			return fmt.Errorf("failed to create shadow embedding: %w", err)
+		}
+		results, err := se.vectors.SearchVectors(ctx, embedding, storage.SearchOptions{
+			Limit:  query.Limit,
+			Filter: query.VectorFilter,
+			UserID: query.UserID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to search shadow collection: %w", err)
+		}
+
+		// Served conversations saved before shadowing began have no shadow vector; comparing
+		// against them would understate agreement
+		indexed, err := se.vectors.GetPayloads(ctx, servedIDs)
+		if err != nil {
+			return fmt.Errorf("failed to read shadow collection: %w", err)
+		}
+
+		shadowIDs := make(map[string]bool, len(results))
+		for _, result := range results {
+			shadowIDs[result.ConversationID] = true
+		}
+		covered, overlap := 0, 0
+		for _, id := range servedIDs {
+			if indexed[id] == nil {
+				continue
+			}
+			covered++
+			if shadowIDs[id] {
+				overlap++
+			}
+		}
+		topMatch := len(results) > 0 && len(servedIDs) > 0 && results[0].ConversationID == servedIDs[0]
+
+		if covered > 0 {
+			metrics.ShadowOverlap.Observe(float64(overlap) / float64(covered))
+		}
+		fmt.Printf("shadow search: model=%s user=%s query=%s served=%d shadow=%d covered=%d overlap=%d top_match=%t shadow_ms=%d\n",
+			se.opts.Model, req.UserID, logging.Content(req.Query), len(servedIDs), len(results), covered, overlap, topMatch,
+			time.Since(start).Milliseconds())
+		return nil
+	})
+}
+
+// run performs shadow work in the background, detached from the request's cancellation
+func (se *ShadowEvaluator) run(ctx context.Context, op string, fn func(ctx context.Context) error) {
+	select {
+	case se.inflight <- struct{}{}:
+	default:
+		metrics.ShadowOperations.WithLabelValues(op, "dropped").Inc()
+		return
+	}
+
+	go func() {
+		defer func() { <-se.inflight }()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shadowTimeout)
+		defer cancel()
+
+		if err := fn(ctx); err != nil {
+			metrics.ShadowOperations.WithLabelValues(op, "error").Inc()
+			fmt.Printf("warning: shadow %s with %s failed: %v\n", op, se.opts.Model, err)
+			return
+		}
+		metrics.ShadowOperations.WithLabelValues(op, "ok").Inc()
+	}()
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/events"
+	"refo-rag-server/internal/filter"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// ErrTooManyStandingQueries is returned when registering a standing query for a user who already
+// has the maximum
+var ErrTooManyStandingQueries = errors.New("user has too many standing queries")
+
+// StandingQueryOptions configures standing queries
+type StandingQueryOptions struct {
+	// MaxPerUser caps the standing queries a user may register
+	MaxPerUser int
+
+	// StreamBuffer bounds the matches waiting for a slow stream; further matches skip it
+	StreamBuffer int
+}
+
+// StandingQueryService keeps searches registered to run against every conversation the user saves
+// from now on. Each query is embedded once when registered; a saved conversation is compared with
+// its user's query vectors using the vector it was stored with, so matching embeds nothing.
+// Matches are posted to the webhook and sent to the user's open streams on this replica
+type StandingQueryService struct {
+	store    storage.StandingQueryStore
+	embedder storage.EmbeddingProvider
+	opts     StandingQueryOptions
+	notifier WebhookPoster
+
+	// streams holds the open match streams of each user
+	mu      sync.Mutex
+	streams map[string]map[chan *models.StandingQueryMatch]struct{}
+}
+
+// NewStandingQueryService creates a standing query service embedding queries with embedder, which
+// must be the conversations collection's model
+func NewStandingQueryService(store storage.StandingQueryStore, embedder storage.EmbeddingProvider, opts StandingQueryOptions) *StandingQueryService {
+	return &StandingQueryService{
+		store:    store,
+		embedder: embedder,
+		opts:     opts,
+		streams:  make(map[string]map[chan *models.StandingQueryMatch]struct{}),
+	}
+}
+
+// SetNotifier posts matches to notifier; without one they only reach streams
+func (sqs *StandingQueryService) SetNotifier(notifier WebhookPoster) {
+	sqs.notifier = notifier
+}
+
+// Create embeds and registers a standing query
+func (sqs *StandingQueryService) Create(ctx context.Context, req *models.StandingQueryCreateRequest) (*models.StandingQuery, error) {
+	existing, err := sqs.store.ListStandingQueries(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if sqs.opts.MaxPerUser > 0 && len(existing) >= sqs.opts.MaxPerUser {
+		return nil, ErrTooManyStandingQueries
+	}
+
+	vector, err := sqs.embedder.EmbedQuery(ctx, req.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding: %w", err)
+	}
+
+	threshold := float32(models.DefaultStandingQueryThreshold)
+	if req.Threshold != nil {
+		threshold = *req.Threshold
+	}
+
+	query := &models.StandingQuery{
+		ID:        uuid.New().String(),
+		UserID:    req.UserID,
+		Query:     req.Query,
+		Filter:    req.Filter,
+		Threshold: threshold,
+		CreatedAt: time.Now().UTC(),
+		Vector:    vector,
+	}
+	if err := sqs.store.CreateStandingQuery(ctx, query); err != nil {
+		return nil, err
+	}
+
+	return query, nil
+}
+
+// Get retrieves a standing query, or nil if it doesn't exist
+func (sqs *StandingQueryService) Get(ctx context.Context, id string) (*models.StandingQuery, error) {
+	return sqs.store.GetStandingQuery(ctx, id)
+}
+
+// List retrieves a user's standing queries, oldest first
+func (sqs *StandingQueryService) List(ctx context.Context, userID string) (*models.StandingQueryListResponse, error) {
+	queries, err := sqs.store.ListStandingQueries(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.StandingQueryListResponse{UserID: userID, StandingQueries: queries}, nil
+}
+
+// Delete deletes a standing query; it reports false if it doesn't exist
+func (sqs *StandingQueryService) Delete(ctx context.Context, id string) (bool, error) {
+	return sqs.store.DeleteStandingQuery(ctx, id)
+}
+
+// Subscribe opens a stream of a user's matches found on this replica. Call the returned function
+// to close it
+func (sqs *StandingQueryService) Subscribe(userID string) (<-chan *models.StandingQueryMatch, func()) {
+	stream := make(chan *models.StandingQueryMatch, max(sqs.opts.StreamBuffer, 1))
+
+	sqs.mu.Lock()
+	if sqs.streams[userID] == nil {
+		sqs.streams[userID] = make(map[chan *models.StandingQueryMatch]struct{})
+	}
+	sqs.streams[userID][stream] = struct{}{}
+	sqs.mu.Unlock()
+
+	return stream, func() {
+		sqs.mu.Lock()
+		defer sqs.mu.Unlock()
+		delete(sqs.streams[userID], stream)
+		if len(sqs.streams[userID]) == 0 {
+			delete(sqs.streams, userID)
+		}
+	}
+}
+
+// OnConversationSaved compares a newly saved conversation with its user's standing queries and
+// delivers the matches; subscribe it to events.ConversationSaved
+func (sqs *StandingQueryService) OnConversationSaved(ctx context.Context, event events.Event) error {
+	saved, ok := event.Payload.(events.ConversationSavedEvent)
+	if !ok {
+		return fmt.Errorf("unexpected %s payload %T", event.Topic, event.Payload)
+	}
+	if saved.Vector == nil {
+		return nil
+	}
+	conv := saved.Conversation
+
+	queries, err := sqs.store.ListStandingQueries(ctx, conv.UserID)
+	if err != nil || len(queries) == 0 {
+		return err
+	}
+
+	fields := map[string]interface{}{}
+	if metadata := storedMetadata(conv); metadata != nil {
+		fields = metadata.Payload()
+	}
+
+	for _, query := range queries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// A filter that no longer parses, after a syntax change, matches nothing rather than everything
+		expr, err := filter.Parse(query.Filter)
+		if err != nil || !expr.Match(fields) {
+			continue
+		}
+
+		vector, err := sqs.queryVector(ctx, query, len(saved.Vector))
+		if err != nil {
+			return fmt.Errorf("failed to embed standing query %s: %w", query.ID, err)
+		}
+		score := float32(1 - cosineDistance(vector, saved.Vector))
+		if score < query.Threshold {
+			continue
+		}
+
+		sqs.deliver(ctx, &models.StandingQueryMatch{
+			Event:           models.StandingQueryMatchEvent,
+			StandingQueryID: query.ID,
+			UserID:          conv.UserID,
+			Query:           query.Query,
+			ConversationID:  conv.ID,
+			Score:           score,
+			MatchedAt:       time.Now().UTC(),
+		})
+	}
+	return nil
+}
+
+// queryVector returns a standing query's vector, embedding the query again if the conversations'
+// embedding model changed dimension since it was registered
+func (sqs *StandingQueryService) queryVector(ctx context.Context, query *models.StandingQuery, dimension int) ([]float32, error) {
+	if len(query.Vector) == dimension {
+		return query.Vector, nil
+	}
+
+	vector, err := sqs.embedder.EmbedQuery(ctx, query.Query)
+	if err != nil {
+		return nil, err
+	}
+	if err := sqs.store.UpdateStandingQueryVector(ctx, query.ID, vector); err != nil {
+		// Embedded again on the next save
+		fmt.Printf("warning: failed to store re-embedded standing query %s: %v\n", query.ID, err)
+	}
+	return vector, nil
+}
+
+// deliver records a match, sends it to the user's streams and posts it to the webhook
+func (sqs *StandingQueryService) deliver(ctx context.Context, match *models.StandingQueryMatch) {
+	if err := sqs.store.RecordStandingQueryMatch(ctx, match.StandingQueryID, match.MatchedAt); err != nil {
+		fmt.Printf("warning: failed to record match of standing query %s: %v\n", match.StandingQueryID, err)
+	}
+
+	sqs.mu.Lock()
+	for stream := range sqs.streams[match.UserID] {
+		select {
+		case stream <- match:
+			metrics.StandingQueryMatches.WithLabelValues("stream", "sent").Inc()
+		default:
+			metrics.StandingQueryMatches.WithLabelValues("stream", "dropped").Inc()
+		}
+	}
+	sqs.mu.Unlock()
+
+	if sqs.notifier == nil {
+		return
+	}
+	if err := sqs.notifier.Post(ctx, match); err != nil {
+		metrics.StandingQueryMatches.WithLabelValues("webhook", "error").Inc()
+		fmt.Printf("warning: failed to post match of standing query %s: %v\n", match.StandingQueryID, err)
+		errreport.Background(ctx, "standing_query_webhook", err)
+		return
+	}
+	metrics.StandingQueryMatches.WithLabelValues("webhook", "sent").Inc()
+}
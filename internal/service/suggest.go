@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+	"refo-rag-server/internal/tenant"
+)
+
+// suggestMaxQueries caps the logged searches read to build a user's suggestions
+const suggestMaxQueries = 1000
+
+// suggestMinTopicConversations is how many conversations must mention a word or phrase before it
+// is suggested, so one-off remarks don't surface as topics
+const suggestMinTopicConversations = 2
+
+// suggestMaxTopics caps the topics kept per user, most mentioned first
+const suggestMaxTopics = 500
+
+// suggestStopwords are words too common to suggest on their own
+var suggestStopwords = map[string]struct{}{
+	"the": {}, "and": {}, "for": {}, "are": {}, "but": {}, "not": {}, "you": {}, "all": {}, "any": {},
+	"can": {}, "had": {}, "her": {}, "was": {}, "one": {}, "our": {}, "out": {}, "has": {}, "him": {},
+	"his": {}, "how": {}, "its": {}, "may": {}, "who": {}, "did": {}, "get": {}, "got": {}, "let": {},
+	"she": {}, "too": {}, "use": {}, "yes": {}, "what": {}, "when": {}, "where": {}, "which": {},
+	"with": {}, "this": {}, "that": {}, "these": {}, "those": {}, "there": {}, "their": {}, "they": {},
+	"them": {}, "then": {}, "than": {}, "have": {}, "from": {}, "were": {}, "been": {}, "will": {},
+	"would": {}, "could": {}, "should": {}, "about": {}, "into": {}, "your": {}, "just": {}, "like": {},
+	"also": {}, "some": {}, "very": {}, "much": {}, "more": {}, "does": {}, "doing": {}, "done": {},
+	"okay": {}, "yeah": {}, "sure": {}, "thanks": {}, "thank": {}, "please": {}, "hello": {},
+	"그리고": {}, "그래서": {}, "그런데": {}, "하지만": {}, "그럼": {}, "네": {}, "아니요": {}, "감사합니다": {},
+	"안녕하세요": {}, "오늘": {}, "지금": {}, "정말": {}, "너무": {}, "조금": {}, "그냥": {}, "이제": {},
+	"있어요": {}, "없어요": {}, "해요": {}, "했어요": {}, "합니다": {}, "있습니다": {}, "좋아요": {},
+}
+
+// SuggestOptions configures query suggestions
+type SuggestOptions struct {
+	// Lookback is how far back logged searches are suggested
+	Lookback time.Duration
+
+	// Conversations is how many of the user's most recent conversations topics are drawn from
+	Conversations int
+
+	// CacheTTL is how long a user's suggestions are served before they are built again
+	CacheTTL time.Duration
+
+	// CacheSize caps the cached users
+	CacheSize int
+}
+
+// SuggestService completes partly typed searches from the queries a user searched before and
+// found results for, and from words and phrases recurring in their recent conversations. A
+// user's candidates are built once per CacheTTL, so typing doesn't read the database per key
+type SuggestService struct {
+	searches      storage.SearchQueryStore
+	conversations storage.ConversationStore
+	opts          SuggestOptions
+
+	mu     sync.Mutex
+	cached map[suggestKey]cachedSuggestions
+}
+
+// suggestKey identifies a user's suggestions
+type suggestKey struct {
+	tenant string
+	userID string
+}
+
+// cachedSuggestions holds a user's candidates, each source ranked by count
+type cachedSuggestions struct {
+	queries   []models.QuerySuggestion
+	topics    []models.QuerySuggestion
+	expiresAt time.Time
+}
+
+// NewSuggestService creates a suggestion service reading logged searches from searches and
+// topics from conversations
+func NewSuggestService(searches storage.SearchQueryStore, conversations storage.ConversationStore, opts SuggestOptions) *SuggestService {
+	if opts.Lookback <= 0 {
+		opts.Lookback = 30 * 24 * time.Hour
+	}
+	if opts.Conversations <= 0 {
+		opts.Conversations = 200
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = 10 * time.Minute
+	}
+	if opts.CacheSize <= 0 {
+		opts.CacheSize = 10000
+	}
+	return &SuggestService{
+		searches:      searches,
+		conversations: conversations,
+		opts:          opts,
+		cached:        make(map[suggestKey]cachedSuggestions),
+	}
+}
+
+// Suggest returns up to req.Limit completions of req.Prefix for a user: past queries first, then
+// topics, each preferring candidates that start with the prefix over those with a later word
+// starting with it, then the most frequent. An empty prefix returns the user's top candidates
+func (ss *SuggestService) Suggest(ctx context.Context, req models.QuerySuggestRequest) (*models.QuerySuggestResponse, error) {
+	candidates, err := ss.lookup(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := normalizeSuggestion(req.Prefix)
+	resp := &models.QuerySuggestResponse{UserID: req.UserID, Prefix: req.Prefix, Suggestions: []models.QuerySuggestion{}}
+	seen := make(map[string]struct{})
+	for _, source := range [][]models.QuerySuggestion{candidates.queries, candidates.topics} {
+		var leading, inner []models.QuerySuggestion
+		for _, candidate := range source {
+			if _, ok := seen[candidate.Text]; ok {
+				continue
+			}
+			switch {
+			case strings.HasPrefix(candidate.Text, normalized):
+				leading = append(leading, candidate)
+			case strings.Contains(candidate.Text, " "+normalized):
+				inner = append(inner, candidate)
+			}
+		}
+		for _, candidate := range append(leading, inner...) {
+			if len(resp.Suggestions) == req.Limit {
+				return resp, nil
+			}
+			resp.Suggestions = append(resp.Suggestions, candidate)
+			seen[candidate.Text] = struct{}{}
+		}
+	}
+	return resp, nil
+}
+
+// lookup returns a user's candidates from the cache, building them on a miss
+func (ss *SuggestService) lookup(ctx context.Context, userID string) (cachedSuggestions, error) {
+	key := suggestKey{tenant: tenant.FromContext(ctx), userID: userID}
+	now := time.Now()
+
+	ss.mu.Lock()
+	entry, ok := ss.cached[key]
+	ss.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry, nil
+	}
+
+	queries, err := ss.searches.ListSearchQueries(ctx, key.tenant, userID, now.Add(-ss.opts.Lookback), suggestMaxQueries)
+	if err != nil {
+		return cachedSuggestions{}, err
+	}
+	conversations, _, err := ss.conversations.ListUserConversations(ctx, userID, "", ss.opts.Conversations, 0)
+	if err != nil {
+		return cachedSuggestions{}, err
+	}
+	entry = cachedSuggestions{
+		queries:   rankQueries(queries),
+		topics:    rankTopics(conversations),
+		expiresAt: now.Add(ss.opts.CacheTTL),
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if len(ss.cached) >= ss.opts.CacheSize {
+		for k, e := range ss.cached {
+			if now.After(e.expiresAt) {
+				delete(ss.cached, k)
+			}
+		}
+		// Still full of live entries: evict arbitrary ones, which are built again when next needed
+		for k := range ss.cached {
+			if len(ss.cached) < ss.opts.CacheSize {
+				break
+			}
+			delete(ss.cached, k)
+		}
+	}
+	ss.cached[key] = entry
+	return entry, nil
+}
+
+// rankQueries counts the distinct normalized queries, most searched first
+func rankQueries(queries []string) []models.QuerySuggestion {
+	counts := make(map[string]int)
+	for _, query := range queries {
+		if normalized := normalizeSuggestion(query); normalized != "" {
+			counts[normalized]++
+		}
+	}
+	return rankSuggestions(counts, models.SuggestionSourceQuery, len(counts))
+}
+
+// rankTopics counts the conversations mentioning each word and two-word phrase of the user's
+// messages, skipping suppressed conversations, and keeps those mentioned in several
+func rankTopics(conversations []*models.Conversation) []models.QuerySuggestion {
+	counts := make(map[string]int)
+	for _, conv := range conversations {
+		if conv.Suppression != nil {
+			continue
+		}
+
+		texts := []string{conv.Question}
+		for _, msg := range conv.Messages {
+			if msg.Role == models.RoleUser {
+				texts = append(texts, msg.Content)
+			}
+		}
+
+		mentioned := make(map[string]struct{})
+		for _, text := range texts {
+			for _, term := range topicTerms(text) {
+				mentioned[term] = struct{}{}
+			}
+		}
+		for term := range mentioned {
+			counts[term]++
+		}
+	}
+
+	for term, count := range counts {
+		if count < suggestMinTopicConversations {
+			delete(counts, term)
+		}
+	}
+	return rankSuggestions(counts, models.SuggestionSourceTopic, suggestMaxTopics)
+}
+
+// topicTerms returns the salient words of text and the phrases of two adjacent salient words
+func topicTerms(text string) []string {
+	var terms []string
+	previous := ""
+	for _, word := range strings.Fields(normalizeSuggestion(stripPunctuation(text))) {
+		if !salientWord(word) {
+			previous = ""
+			continue
+		}
+		terms = append(terms, word)
+		if previous != "" {
+			terms = append(terms, previous+" "+word)
+		}
+		previous = word
+	}
+	return terms
+}
+
+// salientWord reports whether a word is worth suggesting: not a stopword or number, and at least
+// three letters, or two for Hangul words, which pack more into each syllable
+func salientWord(word string) bool {
+	if _, ok := suggestStopwords[word]; ok {
+		return false
+	}
+	minRunes := 3
+	if isHangulWord(word) {
+		minRunes = 2
+	}
+	if utf8.RuneCountInString(word) < minRunes {
+		return false
+	}
+	return strings.IndexFunc(word, unicode.IsLetter) >= 0
+}
+
+// isHangulWord reports whether word starts with a Hangul letter
+func isHangulWord(word string) bool {
+	r, _ := utf8.DecodeRuneInString(word)
+	return unicode.Is(unicode.Hangul, r)
+}
+
+// rankSuggestions orders counted candidates by count, then text, and keeps up to limit
+func rankSuggestions(counts map[string]int, source string, limit int) []models.QuerySuggestion {
+	suggestions := make([]models.QuerySuggestion, 0, len(counts))
+	for text, count := range counts {
+		suggestions = append(suggestions, models.QuerySuggestion{Text: text, Source: source, Count: count})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Count != suggestions[j].Count {
+			return suggestions[i].Count > suggestions[j].Count
+		}
+		return suggestions[i].Text < suggestions[j].Text
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// normalizeSuggestion lowercases text and collapses its whitespace, so queries differing only in
+// case or spacing count as one
+func normalizeSuggestion(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// stripPunctuation replaces everything but letters, digits and apostrophes with spaces
+func stripPunctuation(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' {
+			return r
+		}
+		return ' '
+	}, text)
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"refo-rag-server/internal/cluster"
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// ErrTopicMapEmpty is returned when a user has no embedded conversations to cluster
+var ErrTopicMapEmpty = errors.New("no embedded conversations for user")
+
+// topicMapBatchSize is the number of conversations loaded per page
+const topicMapBatchSize = 200
+
+// Topic map limits
+const (
+	DefaultTopicMapConversations   = 1000
+	MaxTopicMapConversations       = 5000
+	MaxTopicMapClusters            = 30
+	DefaultTopicMapRepresentatives = 3
+	MaxTopicMapRepresentatives     = 10
+)
+
+// topicExcerptRunes bounds a representative's excerpt in the response and the labeling prompt
+const topicExcerptRunes = 300
+
+// topicLabelPrompt instructs the model how to name clusters of conversations
+const topicLabelPrompt = `You name the topics of a user's conversations with a personal assistant.
+You receive numbered topics, each with excerpts of its most typical conversations.
+Return only a JSON object with a "topics" array holding, for every topic, an object with the
+integer field "id", a "label" of two to five words and a one-sentence "summary". Describe what
+the conversations are about, write in the language of the excerpts, and don't invent details.`
+
+// TopicMapService clusters a user's conversation vectors into a map of topics
+type TopicMapService struct {
+	conversations *ConversationService
+	completion    storage.CompletionProvider
+}
+
+// NewTopicMapService creates a topic map service; completion may be nil to leave topics unlabeled
+func NewTopicMapService(conversations *ConversationService, completion storage.CompletionProvider) *TopicMapService {
+	return &TopicMapService{
+		conversations: conversations,
+		completion:    completion,
+	}
+}
+
+// TopicMap clusters the stored vectors of a user's most recent conversations with k-means and
+// describes each cluster by its closest conversations. Labeling failures leave the topics
+// unlabeled rather than failing the map. It fails with ErrTopicMapEmpty if no conversation has a
+// vector
+func (tms *TopicMapService) TopicMap(ctx context.Context, userID string, opts models.TopicMapRequest) (*models.TopicMap, error) {
+	start := time.Now()
+	if opts.MaxConversations <= 0 {
+		opts.MaxConversations = DefaultTopicMapConversations
+	}
+	if opts.Representatives <= 0 {
+		opts.Representatives = DefaultTopicMapRepresentatives
+	}
+
+	conversations, vectors, truncated, err := tms.loadVectors(ctx, userID, opts.MaxConversations)
+	if err != nil {
+		return nil, err
+	}
+	if len(conversations) == 0 {
+		return nil, ErrTopicMapEmpty
+	}
+
+	k := opts.Clusters
+	if k <= 0 {
+		k = cluster.DefaultClusters(len(vectors))
+	}
+	k = min(k, len(vectors))
+	clusters, err := cluster.KMeans(ctx, vectors, k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cluster conversations: %w", err)
+	}
+
+	topicMap := &models.TopicMap{
+		UserID:        userID,
+		Conversations: len(conversations),
+		Truncated:     truncated,
+		Topics:        buildTopics(conversations, clusters, k, opts.Representatives),
+	}
+
+	if opts.Label && tms.completion != nil {
+		if err := tms.labelTopics(ctx, topicMap.Topics); err != nil {
+			fmt.Printf("warning: failed to label topic map of user %s: %v\n", userID, err)
+			errreport.Background(ctx, "topic_map_label", err)
+		} else {
+			topicMap.Labeled = true
+		}
+	}
+
+	topicMap.GeneratedAt = time.Now().UTC()
+	topicMap.DurationMs = time.Since(start).Milliseconds()
+	return topicMap, nil
+}
+
+// loadVectors loads up to limit of a user's most recent unsuppressed conversations that have a
+// stored vector, with their vectors, and reports whether older conversations were left out
+func (tms *TopicMapService) loadVectors(ctx context.Context, userID string, limit int) ([]*models.Conversation, [][]float32, bool, error) {
+	cs := tms.conversations
+	var conversations []*models.Conversation
+	var vectors [][]float32
+	for offset := 0; ; offset += topicMapBatchSize {
+		page, _, err := cs.conversationStore.ListUserConversations(ctx, userID, "", topicMapBatchSize, offset)
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		ids := make([]string, 0, len(page))
+		for _, conv := range page {
+			if conv.Suppression == nil && conv.Unembedded == nil {
+				ids = append(ids, conv.ID)
+			}
+		}
+		stored, err := cs.vectorStore.GetVectors(ctx, ids)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("failed to get conversation vectors: %w", err)
+		}
+
+		for _, conv := range page {
+			vector, ok := stored[conv.ID]
+			if !ok || conv.Suppression != nil || conv.Unembedded != nil {
+				continue
+			}
+			if len(conversations) == limit {
+				return conversations, vectors, true, nil
+			}
+			conversations = append(conversations, conv)
+			vectors = append(vectors, vector)
+		}
+		if len(page) < topicMapBatchSize {
+			return conversations, vectors, false, nil
+		}
+	}
+}
+
+// buildTopics describes each cluster, largest first
+func buildTopics(conversations []*models.Conversation, clusters *cluster.Result, k int, representatives int) []models.Topic {
+	members := make([][]int, k)
+	for i, c := range clusters.Assignments {
+		members[c] = append(members[c], i)
+	}
+
+	topics := make([]models.Topic, 0, k)
+	for _, indexes := range members {
+		if len(indexes) == 0 {
+			continue
+		}
+		sort.SliceStable(indexes, func(a, b int) bool {
+			return clusters.Similarities[indexes[a]] > clusters.Similarities[indexes[b]]
+		})
+
+		topic := models.Topic{
+			Size:            len(indexes),
+			Share:           float64(len(indexes)) / float64(len(conversations)),
+			FirstAt:         conversations[indexes[0]].CreatedAt,
+			LastAt:          conversations[indexes[0]].CreatedAt,
+			Representatives: []models.TopicConversation{},
+		}
+		var similarity float64
+		for rank, i := range indexes {
+			conv := conversations[i]
+			similarity += clusters.Similarities[i]
+			if conv.CreatedAt.Before(topic.FirstAt) {
+				topic.FirstAt = conv.CreatedAt
+			}
+			if conv.CreatedAt.After(topic.LastAt) {
+				topic.LastAt = conv.CreatedAt
+			}
+			if rank < representatives {
+				topic.Representatives = append(topic.Representatives, models.TopicConversation{
+					ConversationID: conv.ID,
+					Excerpt:        conversationExcerpt(conv),
+					Similarity:     clusters.Similarities[i],
+					CreatedAt:      conv.CreatedAt,
+				})
+			}
+		}
+		topic.Cohesion = similarity / float64(len(indexes))
+		topics = append(topics, topic)
+	}
+
+	sort.SliceStable(topics, func(a, b int) bool { return topics[a].Size > topics[b].Size })
+	for i := range topics {
+		topics[i].ID = i + 1
+	}
+	return topics
+}
+
+// conversationExcerpt returns the start of a conversation's messages on one line
+func conversationExcerpt(conv *models.Conversation) string {
+	var parts []string
+	for _, msg := range conversationMessages(conv) {
+		parts = append(parts, msg.Role+": "+strings.Join(strings.Fields(msg.Content), " "))
+	}
+	return truncateRunes(strings.Join(parts, " / "), topicExcerptRunes)
+}
+
+// labelTopics names the topics with the chat model from their representatives' excerpts
+func (tms *TopicMapService) labelTopics(ctx context.Context, topics []models.Topic) error {
+	var b strings.Builder
+	for _, topic := range topics {
+		fmt.Fprintf(&b, "Topic %d (%d conversations):\n", topic.ID, topic.Size)
+		for _, representative := range topic.Representatives {
+			fmt.Fprintf(&b, "- %s\n", representative.Excerpt)
+		}
+		b.WriteString("\n")
+	}
+
+	output, err := tms.completion.Complete(ctx, topicLabelPrompt, b.String())
+	if err != nil {
+		return err
+	}
+
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return fmt.Errorf("no JSON object in model output")
+	}
+	var labels struct {
+		Topics []struct {
+			ID      int    `json:"id"`
+			Label   string `json:"label"`
+			Summary string `json:"summary"`
+		} `json:"topics"`
+	}
+	if err := json.Unmarshal([]byte(output[start:end+1]), &labels); err != nil {
+		return fmt.Errorf("failed to parse topic labels: %w", err)
+	}
+
+	for _, label := range labels.Topics {
+		if label.ID < 1 || label.ID > len(topics) {
+			continue
+		}
+		topics[label.ID-1].Label = strings.TrimSpace(label.Label)
+		topics[label.ID-1].Summary = strings.TrimSpace(label.Summary)
+	}
+	return nil
+}
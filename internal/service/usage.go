@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// ErrInvalidDate is returned when a usage date isn't YYYY-MM-DD or the range is reversed
+var ErrInvalidDate = errors.New("invalid date")
+
+// defaultUsageDays is the range reported when no start date is given
+const defaultUsageDays = 30
+
+// UsageService reports aggregated embedding token usage
+type UsageService struct {
+	store storage.UsageStore
+}
+
+// NewUsageService creates a new usage service
+func NewUsageService(store storage.UsageStore) *UsageService {
+	return &UsageService{store: store}
+}
+
+// Report returns the daily usage between two UTC dates inclusive, optionally of one tenant. An
+// empty to is today and an empty from is defaultUsageDays before to
+func (us *UsageService) Report(ctx context.Context, from string, to string, tenant string) (*models.UsageReport, error) {
+	end := time.Now().UTC()
+	if to != "" {
+		parsed, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return nil, fmt.Errorf("%w: to %q", ErrInvalidDate, to)
+		}
+		end = parsed
+	}
+	start := end.AddDate(0, 0, -(defaultUsageDays - 1))
+	if from != "" {
+		parsed, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return nil, fmt.Errorf("%w: from %q", ErrInvalidDate, from)
+		}
+		start = parsed
+	}
+	if start.After(end) {
+		return nil, fmt.Errorf("%w: from is after to", ErrInvalidDate)
+	}
+
+	report := &models.UsageReport{From: start.Format(time.DateOnly), To: end.Format(time.DateOnly)}
+	records, err := us.store.ListUsage(ctx, report.From, report.To, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	report.Records = records
+	for _, record := range records {
+		report.Requests += record.Requests
+		report.PromptTokens += record.PromptTokens
+		report.TotalTokens += record.TotalTokens
+	}
+	return report, nil
+}
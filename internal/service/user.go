@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/residency"
+	"refo-rag-server/internal/storage"
+)
+
+var (
+	ErrUserExists    = errors.New("user already registered")
+	ErrUserDisabled  = errors.New("user is disabled")
+	ErrUnknownRegion = errors.New("region is not in the region map")
+)
+
+// UserService manages the registry of end users. Disabling a user blocks new conversations and
+// personal info for it, a retention exemption keeps its conversations out of retention runs, and
+// deleting a user's data also removes its registry entry. With data residency on, a user's
+// region pins its data to that region's deployment
+type UserService struct {
+	store     storage.AccountStore
+	residency *residency.Guard
+}
+
+// NewUserService creates a new user service
+func NewUserService(store storage.AccountStore) *UserService {
+	return &UserService{store: store}
+}
+
+// SetResidency enables region checks; nil disables them
+func (us *UserService) SetResidency(guard *residency.Guard) {
+	us.residency = guard
+}
+
+// Create registers a user; it fails with ErrUserExists if the user is already registered
+func (us *UserService) Create(ctx context.Context, req *models.UserCreateRequest) (*models.User, error) {
+	if err := us.checkRegion(req.Region); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &models.User{
+		ID:              req.UserID,
+		DisplayName:     req.DisplayName,
+		Status:          models.UserStatusActive,
+		RetentionExempt: req.RetentionExempt,
+		Region:          req.Region,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	created, err := us.store.CreateUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrUserExists
+	}
+	return user, nil
+}
+
+// Get retrieves a registered user, or nil if the user isn't registered
+func (us *UserService) Get(ctx context.Context, userID string) (*models.User, error) {
+	return us.store.GetUser(ctx, userID)
+}
+
+// List retrieves a page of registered users, optionally of one status
+func (us *UserService) List(ctx context.Context, status string, limit int, offset int) (*models.UserListResponse, error) {
+	users, total, err := us.store.ListUsers(ctx, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UserListResponse{
+		Users:  users,
+		Total:  total,
+		Status: status,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// Update applies a partial update to a registered user, or returns nil if the user isn't registered
+func (us *UserService) Update(ctx context.Context, userID string, req *models.UserUpdateRequest) (*models.User, error) {
+	if req.Region != nil {
+		if err := us.checkRegion(*req.Region); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := us.store.GetUser(ctx, userID)
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	if req.DisplayName != nil {
+		user.DisplayName = *req.DisplayName
+	}
+	if req.RetentionExempt != nil {
+		user.RetentionExempt = *req.RetentionExempt
+	}
+	if req.Region != nil {
+		user.Region = *req.Region
+	}
+	user.UpdatedAt = time.Now()
+
+	updated, err := us.store.UpdateUser(ctx, user)
+	if err != nil || !updated {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Disable blocks new data for a user, registering it if needed
+func (us *UserService) Disable(ctx context.Context, userID string, reason string) (*models.User, error) {
+	return us.store.DisableUser(ctx, userID, reason, time.Now())
+}
+
+// Enable lifts a user's disablement, or returns nil if the user isn't registered
+func (us *UserService) Enable(ctx context.Context, userID string) (*models.User, error) {
+	return us.store.EnableUser(ctx, userID, time.Now())
+}
+
+// CheckActive returns ErrUserDisabled if the user is registered and disabled, or a
+// *residency.WrongRegionError if the user is homed in another region
+func (us *UserService) CheckActive(ctx context.Context, userID string) error {
+	user, err := us.store.GetUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check user status: %w", err)
+	}
+	if user == nil {
+		return nil
+	}
+	if user.Status == models.UserStatusDisabled {
+		return ErrUserDisabled
+	}
+	if us.residency != nil {
+		return us.residency.CheckRegion(user.Region)
+	}
+	return nil
+}
+
+// Preprocess rejects conversation saves for disabled users; it lets the service run as a save
+// preprocessor ahead of plugins
+func (us *UserService) Preprocess(ctx context.Context, req *models.ConversationSaveRequest) error {
+	return us.CheckActive(ctx, req.UserID)
+}
+
+// checkRegion returns ErrUnknownRegion for a region missing from the region map; "" is always
+// accepted, and any region is while residency is off
+func (us *UserService) checkRegion(region string) error {
+	if region == "" || us.residency == nil || us.residency.Has(region) {
+		return nil
+	}
+	return ErrUnknownRegion
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+)
+
+// ErrUserNotFound is returned when nothing is stored for a user
+var ErrUserNotFound = errors.New("no data stored for user")
+
+// UserDeletionService deletes all of a user's data in two phases: a request that reports the scope
+// and issues a confirmation token, and a confirmation that executes it
+type UserDeletionService struct {
+	userStore           storage.UserStore
+	conversationVectors storage.VectorStore
+	personalInfoVectors storage.VectorStore
+	tokens              *ConfirmationTokens
+	jobs                *JobLog
+	certificates        *DeletionCertificates
+}
+
+// NewUserDeletionService creates a new user deletion service
+func NewUserDeletionService(
+	userStore storage.UserStore,
+	conversationVectors storage.VectorStore,
+	personalInfoVectors storage.VectorStore,
+	tokens *ConfirmationTokens,
+	jobs *JobLog,
+) *UserDeletionService {
+	return &UserDeletionService{
+		userStore:           userStore,
+		conversationVectors: conversationVectors,
+		personalInfoVectors: personalInfoVectors,
+		tokens:              tokens,
+		jobs:                jobs,
+	}
+}
+
+// SetCertificates makes executed deletions issue a deletion certificate
+func (uds *UserDeletionService) SetCertificates(certificates *DeletionCertificates) {
+	uds.certificates = certificates
+}
+
+// Scope counts everything that deleting a user would remove
+func (uds *UserDeletionService) Scope(ctx context.Context, userID string) (*models.UserDataCounts, error) {
+	counts, err := uds.userStore.CountUserData(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if counts.ConversationVectors, err = uds.conversationVectors.CountUserVectors(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to count conversation vectors: %w", err)
+	}
+	if counts.PersonalInfoVectors, err = uds.personalInfoVectors.CountUserVectors(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to count personal info vectors: %w", err)
+	}
+
+	return counts, nil
+}
+
+// RequestDeletion reports what deleting a user would remove and issues the token that confirms it.
+// The request is recorded as a dry-run job. It fails with ErrUserNotFound if nothing is stored.
+func (uds *UserDeletionService) RequestDeletion(ctx context.Context, userID string) (*models.UserDeletionResponse, error) {
+	startTime := time.Now()
+	resp := &models.UserDeletionResponse{UserID: userID}
+
+	jobID, err := uds.jobs.Run(ctx, models.JobKindUserDelete, userID, true, func(ctx context.Context) (interface{}, error) {
+		scope, err := uds.Scope(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if scope.Empty() {
+			return nil, ErrUserNotFound
+		}
+		resp.Scope = *scope
+
+		if resp.Confirmation, err = uds.tokens.Issue(models.JobKindUserDelete, userID, scope); err != nil {
+			return nil, err
+		}
+
+		resp.DurationMs = time.Since(startTime).Milliseconds()
+		return resp.Scope, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp.JobID = jobID
+	return resp, nil
+}
+
+// ConfirmDeletion deletes all of a user's records and vectors if the token was issued for this user
+// and the scope hasn't changed since
+func (uds *UserDeletionService) ConfirmDeletion(ctx context.Context, userID string, token string) (*models.UserDeletionResponse, error) {
+	startTime := time.Now()
+
+	scope, err := uds.Scope(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uds.tokens.Redeem(token, models.JobKindUserDelete, userID, scope); err != nil {
+		return nil, err
+	}
+
+	resp := &models.UserDeletionResponse{UserID: userID}
+	jobID, err := uds.jobs.Run(ctx, models.JobKindUserDelete, userID, false, func(ctx context.Context) (interface{}, error) {
+		deleted, err := uds.userStore.DeleteUserData(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		deleted.ConversationVectors = scope.ConversationVectors
+		deleted.PersonalInfoVectors = scope.PersonalInfoVectors
+		resp.Scope = *deleted
+
+		// Orphaned vectors are never returned since search results are loaded from PostgreSQL,
+		// but the job is marked failed so the operator can retry
+		if err := uds.conversationVectors.DeleteUserVectors(ctx, userID); err != nil {
+			return resp.Scope, fmt.Errorf("failed to delete conversation vectors: %w", err)
+		}
+		if err := uds.personalInfoVectors.DeleteUserVectors(ctx, userID); err != nil {
+			return resp.Scope, fmt.Errorf("failed to delete personal info vectors: %w", err)
+		}
+
+		resp.Deleted = true
+		resp.DurationMs = time.Since(startTime).Milliseconds()
+		return resp.Scope, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp.JobID = jobID
+	if uds.certificates != nil {
+		resp.CertificateID = uds.certificates.Issue(ctx, &models.DeletionCertificate{
+			JobID:   jobID,
+			Kind:    models.JobKindUserDelete,
+			Target:  userID,
+			Policy:  "erasure request: all data stored for the user",
+			Removed: resp.Scope,
+		})
+	}
+	return resp, nil
+}
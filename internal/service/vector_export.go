@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/storage"
+	"refo-rag-server/internal/vectorio"
+)
+
+// ErrVectorJobRunning is returned while a vector export or import is running
+var ErrVectorJobRunning = errors.New("a vector export or import is already running")
+
+// VectorExportService exports stored vectors for offline experiments and imports vectors
+// computed offline, as logged jobs
+type VectorExportService struct {
+	vectors     *vectorio.IO
+	collections *storage.CollectionManager
+	jobs        *JobLog
+
+	// running is set while an export or import runs; an import during an export would make the
+	// export a mix of old and new vectors
+	running atomic.Bool
+}
+
+// NewVectorExportService creates a new vector export service
+func NewVectorExportService(vectors *vectorio.IO, collections *storage.CollectionManager, jobs *JobLog) *VectorExportService {
+	return &VectorExportService{
+		vectors:     vectors,
+		collections: collections,
+		jobs:        jobs,
+	}
+}
+
+// StartExport starts a background job exporting every vector of a content type's collection.
+// It returns the job ID; the export report is the job's result.
+func (vs *VectorExportService) StartExport(ctx context.Context, contentType string) (string, error) {
+	collection, ok := vs.collections.Config(contentType)
+	if !ok {
+		return "", ErrUnknownContentType
+	}
+	store, err := vs.collections.Store(contentType)
+	if err != nil {
+		return "", err
+	}
+
+	return vs.start(ctx, models.JobKindVectorExport, contentType, func(ctx context.Context) (interface{}, error) {
+		return vs.vectors.Export(ctx, contentType, collection.Model, store)
+	})
+}
+
+// StartImport starts a background job replacing stored vectors with vectors from the blob store.
+// It returns the job ID; the import report is the job's result.
+func (vs *VectorExportService) StartImport(ctx context.Context, req *models.VectorImportRequest) (string, error) {
+	store, err := vs.collections.Store(req.ContentType)
+	if err != nil {
+		return "", ErrUnknownContentType
+	}
+
+	return vs.start(ctx, models.JobKindVectorImport, req.ContentType+":"+req.Key, func(ctx context.Context) (interface{}, error) {
+		return vs.vectors.Import(ctx, req, store)
+	})
+}
+
+// start runs fn as a job unless another export or import is running
+func (vs *VectorExportService) start(ctx context.Context, kind string, target string, fn func(ctx context.Context) (interface{}, error)) (string, error) {
+	if !vs.running.CompareAndSwap(false, true) {
+		return "", ErrVectorJobRunning
+	}
+
+	jobID, err := vs.jobs.Start(ctx, kind, target, func(ctx context.Context) (interface{}, error) {
+		defer vs.running.Store(false)
+		return fn(ctx)
+	})
+	if err != nil {
+		vs.running.Store(false)
+		return "", err
+	}
+	return jobID, nil
+}
@@ -0,0 +1,135 @@
+// Package shaper smooths bursts of ingestion into a steady embedding rate with a leaky bucket.
+// Every embedding pours one unit into the bucket, which drains at the sustainable rate. A save
+// that finds the bucket full is absorbed into the work queue instead of being embedded at once,
+// and the queue worker waits for room before each embedding, so a chat platform replaying its
+// backlog doesn't turn into a storm of rate limited embedding calls. Backfill may fill only part
+// of the bucket and waits while interactive embeddings wait, so interactive saves go first.
+package shaper
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+)
+
+// Options configures a Shaper
+type Options struct {
+	// Rate is the embeddings per second the bucket drains
+	Rate float64
+
+	// Burst is the embeddings the bucket holds; saves arriving while it is full are queued
+	Burst int
+
+	// BackfillShare is the share of the bucket backfill embeddings may fill, keeping the rest for
+	// interactive ones; backfill always gets room for at least one embedding
+	BackfillShare float64
+}
+
+// Shaper is a leaky bucket shared by the saves and the queue worker of one replica
+type Shaper struct {
+	opts Options
+
+	// now and after are the shaper's clock; tests replace them
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+
+	mu      sync.Mutex
+	level   float64
+	drained time.Time
+
+	// waiting counts the embeddings blocked in Wait, by lane
+	waiting map[string]int
+}
+
+// New creates a shaper with an empty bucket
+func New(opts Options) *Shaper {
+	return &Shaper{
+		opts:    opts,
+		now:     time.Now,
+		after:   time.After,
+		drained: time.Now(),
+		waiting: make(map[string]int),
+	}
+}
+
+// Admit pours an embedding of lane into the bucket if it has room, reporting whether the
+// embedding may run now; otherwise the caller should queue it
+func (s *Shaper) Admit(lane string) bool {
+	s.mu.Lock()
+	admitted := s.take(lane, s.now()) == 0
+	s.mu.Unlock()
+
+	decision := "admitted"
+	if !admitted {
+		decision = "full"
+	}
+	metrics.IngestShaped.WithLabelValues(lane, decision).Inc()
+	return admitted
+}
+
+// Wait blocks until the bucket has room for an embedding of lane and pours it in. It fails only
+// when ctx ends first
+func (s *Shaper) Wait(ctx context.Context, lane string) error {
+	start := s.now()
+	s.mu.Lock()
+	s.waiting[lane]++
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.waiting[lane]--
+		s.mu.Unlock()
+		metrics.IngestShaperWait.WithLabelValues(lane).Observe(s.now().Sub(start).Seconds())
+	}()
+
+	for {
+		s.mu.Lock()
+		delay := s.take(lane, s.now())
+		s.mu.Unlock()
+		if delay == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.after(delay):
+		}
+	}
+}
+
+// take drains the bucket up to now and pours in an embedding of lane if it fits, returning 0;
+// otherwise it returns how long until it may fit. Backfill doesn't fit while an interactive
+// embedding waits
+func (s *Shaper) take(lane string, now time.Time) time.Duration {
+	s.level = math.Max(0, s.level-now.Sub(s.drained).Seconds()*s.opts.Rate)
+	s.drained = now
+	defer func() { metrics.IngestShaperLevel.Set(s.level) }()
+
+	if lane == models.LaneBackfill && s.waiting[models.LaneInteractive] > 0 {
+		return s.delay(1)
+	}
+	capacity := s.capacity(lane)
+	if s.level+1 <= capacity {
+		s.level++
+		return 0
+	}
+	return s.delay(s.level + 1 - capacity)
+}
+
+// capacity is how full embeddings of lane may fill the bucket
+func (s *Shaper) capacity(lane string) float64 {
+	burst := float64(s.opts.Burst)
+	if lane == models.LaneBackfill {
+		return math.Max(1, burst*s.opts.BackfillShare)
+	}
+	return burst
+}
+
+// delay is how long the bucket takes to drain units embeddings, at least a millisecond
+func (s *Shaper) delay(units float64) time.Duration {
+	return max(time.Millisecond, time.Duration(units/s.opts.Rate*float64(time.Second)))
+}
@@ -0,0 +1,204 @@
+package shaper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"refo-rag-server/internal/models"
+)
+
+// fakeClock is a clock that only moves when a test advances it or a waiter sleeps on it
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// After moves the clock past d at once, so a waiter sleeps no real time
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.Advance(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+// newTestShaper creates a shaper with an empty bucket running on clock
+func newTestShaper(opts Options, clock *fakeClock) *Shaper {
+	s := New(opts)
+	s.now, s.after = clock.Now, clock.After
+	s.drained = clock.Now()
+	return s
+}
+
+// admitAll admits embeddings of lane until the bucket is full and returns how many it admitted
+func admitAll(s *Shaper, lane string) int {
+	admitted := 0
+	for admitted < 1000 && s.Admit(lane) {
+		admitted++
+	}
+	return admitted
+}
+
+func TestDrainRate(t *testing.T) {
+	cases := []struct {
+		name    string
+		rate    float64
+		burst   int
+		elapsed time.Duration
+		want    int // embeddings admitted into the full bucket after elapsed
+	}{
+		{name: "no time passed", rate: 10, burst: 5, elapsed: 0, want: 0},
+		{name: "one drained", rate: 10, burst: 5, elapsed: 100 * time.Millisecond, want: 1},
+		{name: "part of one drained", rate: 10, burst: 5, elapsed: 99 * time.Millisecond, want: 0},
+		{name: "three drained", rate: 10, burst: 5, elapsed: 300 * time.Millisecond, want: 3},
+		{name: "drains no further than empty", rate: 10, burst: 5, elapsed: time.Hour, want: 5},
+		{name: "slow rate", rate: 0.5, burst: 2, elapsed: 2 * time.Second, want: 1},
+		{name: "fast rate", rate: 1000, burst: 50, elapsed: 20 * time.Millisecond, want: 20},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(1700000000, 0)}
+			s := newTestShaper(Options{Rate: tc.rate, Burst: tc.burst, BackfillShare: 1}, clock)
+
+			if got := admitAll(s, models.LaneInteractive); got != tc.burst {
+				t.Fatalf("empty bucket admitted %d embeddings, want the burst of %d", got, tc.burst)
+			}
+			clock.Advance(tc.elapsed)
+			if got := admitAll(s, models.LaneInteractive); got != tc.want {
+				t.Errorf("after %v admitted %d embeddings, want %d", tc.elapsed, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBackfillShare(t *testing.T) {
+	cases := []struct {
+		name         string
+		burst        int
+		share        float64
+		wantBackfill int
+	}{
+		{name: "share of the bucket", burst: 10, share: 0.3, wantBackfill: 3},
+		{name: "rounded down", burst: 10, share: 0.25, wantBackfill: 2},
+		{name: "at least one", burst: 10, share: 0.01, wantBackfill: 1},
+		{name: "no share still one", burst: 10, share: 0, wantBackfill: 1},
+		{name: "whole bucket", burst: 10, share: 1, wantBackfill: 10},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(1700000000, 0)}
+			s := newTestShaper(Options{Rate: 1, Burst: tc.burst, BackfillShare: tc.share}, clock)
+
+			if got := admitAll(s, models.LaneBackfill); got != tc.wantBackfill {
+				t.Errorf("backfill filled %d of the bucket, want %d", got, tc.wantBackfill)
+			}
+			// Interactive embeddings fill the rest
+			if got := admitAll(s, models.LaneInteractive); got != tc.burst-tc.wantBackfill {
+				t.Errorf("interactive embeddings got %d more, want %d", got, tc.burst-tc.wantBackfill)
+			}
+		})
+	}
+}
+
+func TestBackfillYieldsToWaitingInteractive(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := newTestShaper(Options{Rate: 10, Burst: 10, BackfillShare: 1}, clock)
+
+	s.waiting[models.LaneInteractive] = 1
+	if s.Admit(models.LaneBackfill) {
+		t.Fatal("backfill was admitted into an empty bucket while an interactive embedding waited")
+	}
+	if !s.Admit(models.LaneInteractive) {
+		t.Fatal("interactive embedding was not admitted into an empty bucket")
+	}
+	s.waiting[models.LaneInteractive] = 0
+	if !s.Admit(models.LaneBackfill) {
+		t.Fatal("backfill was not admitted once no interactive embedding waited")
+	}
+}
+
+func TestWaitAndAdmit(t *testing.T) {
+	cases := []struct {
+		name     string
+		lane     string
+		rate     float64
+		burst    int
+		share    float64
+		wantWait time.Duration // how long Wait takes on a bucket filled by the lane
+	}{
+		{name: "interactive waits one drain", lane: models.LaneInteractive, rate: 10, burst: 5, share: 0.5, wantWait: 100 * time.Millisecond},
+		{name: "slow drain", lane: models.LaneInteractive, rate: 2, burst: 1, share: 1, wantWait: 500 * time.Millisecond},
+		{name: "backfill waits one drain", lane: models.LaneBackfill, rate: 4, burst: 8, share: 0.5, wantWait: 250 * time.Millisecond},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(1700000000, 0)}
+			s := newTestShaper(Options{Rate: tc.rate, Burst: tc.burst, BackfillShare: tc.share}, clock)
+			admitAll(s, tc.lane)
+
+			// A save finding the bucket full is rejected, to be queued
+			if s.Admit(tc.lane) {
+				t.Fatal("Admit succeeded on a full bucket")
+			}
+
+			// The queue worker waits for room instead
+			start := clock.Now()
+			if err := s.Wait(context.Background(), tc.lane); err != nil {
+				t.Fatalf("Wait: %v", err)
+			}
+			if waited := clock.Now().Sub(start); waited != tc.wantWait {
+				t.Errorf("Wait took %v, want %v", waited, tc.wantWait)
+			}
+			if s.Admit(tc.lane) {
+				t.Error("Wait did not pour its embedding into the bucket")
+			}
+			if s.waiting[tc.lane] != 0 {
+				t.Errorf("%d embeddings still counted as waiting", s.waiting[tc.lane])
+			}
+		})
+	}
+}
+
+func TestWaitOnEmptyBucket(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := newTestShaper(Options{Rate: 1, Burst: 3, BackfillShare: 1}, clock)
+
+	start := clock.Now()
+	if err := s.Wait(context.Background(), models.LaneInteractive); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if waited := clock.Now().Sub(start); waited != 0 {
+		t.Errorf("Wait on an empty bucket took %v", waited)
+	}
+}
+
+func TestWaitCancelled(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	s := newTestShaper(Options{Rate: 1, Burst: 1, BackfillShare: 1}, clock)
+	admitAll(s, models.LaneInteractive)
+
+	// The clock never moves, so only the context can end the wait
+	s.after = func(time.Duration) <-chan time.Time { return nil }
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Wait(ctx, models.LaneInteractive); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait on a full bucket with a cancelled context returned %v, want context.Canceled", err)
+	}
+	if s.waiting[models.LaneInteractive] != 0 {
+		t.Errorf("%d embeddings still counted as waiting", s.waiting[models.LaneInteractive])
+	}
+}
@@ -0,0 +1,116 @@
+package slowlog
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/hotstate"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/requestid"
+)
+
+// Components whose operations are timed
+const (
+	Postgres   = "postgres"
+	SQLite     = "sqlite" // Embedded relational store; shares the Postgres threshold
+	MySQL      = "mysql"  // Alternative relational store; shares the Postgres threshold
+	Qdrant     = "qdrant"
+	Embedding  = "embedding"
+	Completion = "completion"
+	Rerank     = "rerank" // In-process reranking of search candidates; never logged as slow
+	Request    = "http"
+)
+
+// Thresholds sets the duration above which an operation is logged as slow; zero disables
+type Thresholds struct {
+	Postgres   time.Duration
+	Qdrant     time.Duration
+	Embedding  time.Duration
+	Completion time.Duration
+	Request    time.Duration
+}
+
+var thresholds hotstate.Value[Thresholds]
+
+// Configure sets the slow thresholds for all components
+func Configure(t Thresholds) {
+	thresholds.Store(t)
+}
+
+// threshold returns the configured threshold for a component
+func threshold(component string) time.Duration {
+	thresholds := thresholds.Load()
+	switch component {
+	case Postgres, SQLite, MySQL:
+		return thresholds.Postgres
+	case Qdrant:
+		return thresholds.Qdrant
+	case Embedding:
+		return thresholds.Embedding
+	case Completion:
+		return thresholds.Completion
+	case Request:
+		return thresholds.Request
+	}
+	return 0
+}
+
+// Observe records the duration of an operation started at start and logs it when slow.
+// It is meant to be deferred: defer slowlog.Observe(ctx, slowlog.Postgres, "get_conversation", time.Now())
+func Observe(ctx context.Context, component, operation string, start time.Time) {
+	duration := time.Since(start)
+	if breakdown, ok := ctx.Value(breakdownKey{}).(*Breakdown); ok && component != Request {
+		breakdown.add(component, duration)
+	}
+	if component != Request {
+		metrics.OperationDuration.WithLabelValues(component, operation).Observe(duration.Seconds())
+	}
+
+	limit := threshold(component)
+	if limit <= 0 || duration < limit {
+		return
+	}
+
+	metrics.SlowOperations.WithLabelValues(component, operation).Inc()
+
+	id := requestid.FromContext(ctx)
+	if id == "" {
+		id = "-"
+	}
+	log.Printf("slow %s operation: op=%s duration=%s threshold=%s request_id=%s",
+		component, operation, duration.Round(time.Millisecond), limit, id)
+}
+
+// Breakdown accumulates the time one request spent in each component
+type Breakdown struct {
+	mu        sync.Mutex
+	durations map[string]time.Duration
+}
+
+type breakdownKey struct{}
+
+// WithBreakdown returns a context whose observed operations are added to the returned breakdown
+func WithBreakdown(ctx context.Context) (context.Context, *Breakdown) {
+	breakdown := &Breakdown{durations: make(map[string]time.Duration)}
+	return context.WithValue(ctx, breakdownKey{}, breakdown), breakdown
+}
+
+// Total returns the time spent in the given components so far
+func (b *Breakdown) Total(components ...string) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var total time.Duration
+	for _, component := range components {
+		total += b.durations[component]
+	}
+	return total
+}
+
+func (b *Breakdown) add(component string, duration time.Duration) {
+	b.mu.Lock()
+	b.durations[component] += duration
+	b.mu.Unlock()
+}
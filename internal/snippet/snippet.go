@@ -0,0 +1,219 @@
+// Package snippet strips low-information text from the messages searches return. Greetings,
+// thanks and filler carry no memory, and assistant boilerplate repeated across results spends the
+// caller's context budget on the same sentence over and over; removing them leaves more room for
+// the content that answers the query
+package snippet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"refo-rag-server/internal/models"
+)
+
+// minRepeatedSentence is the length below which a repeated sentence is kept; short replies such
+// as "Yes." repeat by chance rather than as boilerplate
+const minRepeatedSentence = 20
+
+// Patterns configures a Filter
+type Patterns struct {
+	// Drop matches whole messages that carry nothing worth remembering, such as greetings
+	Drop []string `json:"drop"`
+
+	// Strip matches boilerplate removed from within messages
+	Strip []string `json:"strip"`
+
+	// RepeatMin strips an assistant sentence found in at least this many results of one search;
+	// 0 keeps repeated sentences
+	RepeatMin int `json:"repeat_min"`
+}
+
+// DefaultPatterns returns the built-in greeting, filler and boilerplate patterns in English and Korean
+func DefaultPatterns() Patterns {
+	return Patterns{
+		Drop: []string{
+			`(?i)^(hi|hello|hey|good (morning|afternoon|evening))( there)?[.!~]*$`,
+			`(?i)^(ok(ay)?|sure|got it|alright|thanks|thank you( so much| very much)?|you're welcome|bye|goodbye)[.!~]*$`,
+			`^(안녕하세요|안녕|네|넵|알겠습니다|감사합니다|고마워요|고맙습니다|천만에요)[.!~]*$`,
+		},
+		Strip: []string{
+			`(?i)\b(is there )?anything else (i can|i could) (help|assist) you with( today)?\?`,
+			`(?i)\bi hope (this|that) helps[.!]?`,
+			`(?i)\bfeel free to (ask|reach out)[^.!?]*[.!?]`,
+			`더 궁금한 점이 있으시면[^.!?]*[.!?]`,
+		},
+		RepeatMin: 3,
+	}
+}
+
+// LoadPatterns reads patterns from a JSON file; an empty path returns the defaults
+func LoadPatterns(path string) (Patterns, error) {
+	if path == "" {
+		return DefaultPatterns(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Patterns{}, fmt.Errorf("failed to read snippet filter file: %w", err)
+	}
+	var patterns Patterns
+	if err := json.Unmarshal(data, &patterns); err != nil {
+		return Patterns{}, fmt.Errorf("failed to parse snippet filter file: %w", err)
+	}
+	return patterns, nil
+}
+
+// Filter removes greetings, filler and boilerplate from search results
+type Filter struct {
+	drop      []*regexp.Regexp
+	strip     []*regexp.Regexp
+	repeatMin int
+}
+
+// New compiles a filter from patterns
+func New(patterns Patterns) (*Filter, error) {
+	if patterns.RepeatMin < 0 {
+		return nil, fmt.Errorf("snippet filter repeat_min must not be negative")
+	}
+	f := &Filter{repeatMin: patterns.RepeatMin}
+	for _, pattern := range patterns.Drop {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snippet drop pattern %q: %w", pattern, err)
+		}
+		f.drop = append(f.drop, re)
+	}
+	for _, pattern := range patterns.Strip {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snippet strip pattern %q: %w", pattern, err)
+		}
+		f.strip = append(f.strip, re)
+	}
+	return f, nil
+}
+
+// Apply filters the messages of each result of one search and returns them as new slices. A result
+// whose messages would all be removed keeps them unfiltered, so no result comes back empty
+func (f *Filter) Apply(results [][]models.Message) [][]models.Message {
+	repeated := f.repeatedSentences(results)
+
+	filtered := make([][]models.Message, len(results))
+	for i, messages := range results {
+		kept := make([]models.Message, 0, len(messages))
+		for _, msg := range messages {
+			content := f.clean(msg, repeated)
+			if content == "" {
+				continue
+			}
+			msg.Content = content
+			kept = append(kept, msg)
+		}
+		if len(kept) == 0 {
+			kept = messages
+		}
+		filtered[i] = kept
+	}
+	return filtered
+}
+
+// clean returns a message's content without boilerplate, or "" if nothing worth keeping is left
+func (f *Filter) clean(msg models.Message, repeated map[string]bool) string {
+	content := strings.TrimSpace(msg.Content)
+	if f.dropped(content) {
+		return ""
+	}
+
+	for _, re := range f.strip {
+		content = re.ReplaceAllString(content, "")
+	}
+	if msg.Role == models.RoleAssistant && len(repeated) > 0 {
+		sentences := SplitSentences(content)
+		kept := sentences[:0]
+		for _, sentence := range sentences {
+			if !repeated[normalize(sentence)] {
+				kept = append(kept, sentence)
+			}
+		}
+		content = strings.Join(kept, " ")
+	}
+
+	content = strings.Join(strings.Fields(content), " ")
+	if f.dropped(content) {
+		return ""
+	}
+	return content
+}
+
+// dropped reports whether a whole message is empty or matches a drop pattern
+func (f *Filter) dropped(content string) bool {
+	if content == "" {
+		return true
+	}
+	for _, re := range f.drop {
+		if re.MatchString(content) {
+			return true
+		}
+	}
+	return false
+}
+
+// repeatedSentences returns the normalized assistant sentences found in at least repeatMin results
+func (f *Filter) repeatedSentences(results [][]models.Message) map[string]bool {
+	if f.repeatMin <= 0 || len(results) < f.repeatMin {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	for _, messages := range results {
+		seen := make(map[string]bool)
+		for _, msg := range messages {
+			if msg.Role != models.RoleAssistant {
+				continue
+			}
+			for _, sentence := range SplitSentences(msg.Content) {
+				key := normalize(sentence)
+				if len(key) >= minRepeatedSentence && !seen[key] {
+					seen[key] = true
+					counts[key]++
+				}
+			}
+		}
+	}
+
+	repeated := make(map[string]bool)
+	for key, count := range counts {
+		if count >= f.repeatMin {
+			repeated[key] = true
+		}
+	}
+	return repeated
+}
+
+// SplitSentences splits text after sentence-ending punctuation followed by a space
+func SplitSentences(text string) []string {
+	var sentences []string
+	runes := []rune(text)
+	start := 0
+	for i, r := range runes {
+		if !strings.ContainsRune(".!?。", r) || (i+1 < len(runes) && !unicode.IsSpace(runes[i+1])) {
+			continue
+		}
+		if sentence := strings.TrimSpace(string(runes[start : i+1])); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = i + 1
+	}
+	if rest := strings.TrimSpace(string(runes[start:])); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+// normalize lowercases a sentence and collapses its whitespace for comparison
+func normalize(sentence string) string {
+	return strings.ToLower(strings.Join(strings.Fields(sentence), " "))
+}
@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sashabaranov/go-openai"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/timeouts"
+)
+
+// abortBound is how long a call may keep running once its context is done
+const abortBound = 2 * time.Second
+
+// blockingServer starts a server that holds every request until the client goes away, and
+// reports each request it received on the returned channel
+func blockingServer(t *testing.T) (*httptest.Server, <-chan struct{}) {
+	received := make(chan struct{}, 16)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		server.Close()
+	})
+	return server, received
+}
+
+// blockingQdrant returns a Qdrant store whose server never answers
+func blockingQdrant(t *testing.T) (*QdrantStore, <-chan struct{}) {
+	server, received := blockingServer(t)
+	qs, err := NewQdrantStore(server.URL, "conversations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs.client = server.Client()
+	return qs, received
+}
+
+// blockingOpenAI returns an OpenAI client whose server never answers
+func blockingOpenAI(t *testing.T) (*openai.Client, <-chan struct{}) {
+	server, received := blockingServer(t)
+	config := openai.DefaultConfig("test-key")
+	config.BaseURL = server.URL + "/v1"
+	config.HTTPClient = server.Client()
+	return openai.NewClientWithConfig(config), received
+}
+
+// noTimeouts clears the dependency timeouts so only the caller's context ends a call
+func noTimeouts(t *testing.T) {
+	timeouts.Configure(timeouts.Limits{})
+	t.Cleanup(func() { timeouts.Configure(timeouts.Limits{}) })
+}
+
+// checkAborts runs call, cancels its context once the server holds the request, and checks that
+// call returns a cancellation error within abortBound
+func checkAborts(t *testing.T, received <-chan struct{}, call func(ctx context.Context) error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- call(ctx) }()
+
+	select {
+	case <-received:
+	case err := <-done:
+		t.Fatalf("call returned before reaching the server: %v", err)
+	case <-time.After(abortBound):
+		t.Fatal("call never reached the server")
+	}
+
+	cancelled := time.Now()
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("call returned %v, want a context.Canceled error", err)
+		}
+		if elapsed := time.Since(cancelled); elapsed > abortBound {
+			t.Errorf("call took %v to return after cancellation", elapsed)
+		}
+	case <-time.After(abortBound):
+		t.Fatalf("call still running %v after cancellation", abortBound)
+	}
+}
+
+func TestQdrantCallsAbortOnCancel(t *testing.T) {
+	noTimeouts(t)
+	vector := []float32{0.6, 0.8}
+
+	calls := []struct {
+		name string
+		call func(qs *QdrantStore, ctx context.Context) error
+	}{
+		{"save", func(qs *QdrantStore, ctx context.Context) error {
+			return qs.SaveVector(ctx, "conversation-1", vector, map[string]interface{}{"user_id": "u1"})
+		}},
+		{"search", func(qs *QdrantStore, ctx context.Context) error {
+			_, err := qs.SearchVectors(ctx, vector, SearchOptions{UserID: "u1", Limit: 5})
+			return err
+		}},
+		{"delete", func(qs *QdrantStore, ctx context.Context) error {
+			return qs.DeleteVector(ctx, "conversation-1")
+		}},
+		{"delete user", func(qs *QdrantStore, ctx context.Context) error {
+			return qs.DeleteUserVectors(ctx, "u1")
+		}},
+	}
+	for _, c := range calls {
+		t.Run(c.name, func(t *testing.T) {
+			qs, received := blockingQdrant(t)
+			checkAborts(t, received, func(ctx context.Context) error { return c.call(qs, ctx) })
+		})
+	}
+}
+
+func TestDeleteUserVectorsTimesOut(t *testing.T) {
+	timeouts.Configure(timeouts.Limits{Qdrant: 50 * time.Millisecond})
+	t.Cleanup(func() { timeouts.Configure(timeouts.Limits{}) })
+
+	qs, _ := blockingQdrant(t)
+	done := make(chan error, 1)
+	go func() { done <- qs.DeleteUserVectors(context.Background(), "u1") }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("DeleteUserVectors against a stalled server = %v, want a deadline error", err)
+		}
+	case <-time.After(abortBound):
+		t.Fatalf("DeleteUserVectors still running %v with a 50ms Qdrant timeout", abortBound)
+	}
+}
+
+func TestOpenAIEmbeddingAbortsOnCancel(t *testing.T) {
+	noTimeouts(t)
+
+	t.Run("embed", func(t *testing.T) {
+		client, received := blockingOpenAI(t)
+		provider := &OpenAIEmbeddingProvider{client: client, model: openai.SmallEmbedding3, dimension: 2}
+		checkAborts(t, received, func(ctx context.Context) error {
+			_, err := provider.Embed(ctx, "where is the lighthouse?")
+			return err
+		})
+	})
+	t.Run("embed batch", func(t *testing.T) {
+		client, received := blockingOpenAI(t)
+		provider := &OpenAIEmbeddingProvider{client: client, model: openai.SmallEmbedding3, dimension: 2}
+		checkAborts(t, received, func(ctx context.Context) error {
+			_, err := provider.EmbedBatch(ctx, []string{"where is the lighthouse?", "past the harbour wall"})
+			return err
+		})
+	})
+}
+
+func TestOpenAIChatAbortsOnCancel(t *testing.T) {
+	noTimeouts(t)
+
+	client, received := blockingOpenAI(t)
+	provider := &OpenAICompletionProvider{client: client, model: openai.GPT4oMini, maxTokens: 64}
+	checkAborts(t, received, func(ctx context.Context) error {
+		_, err := provider.Complete(ctx, "answer briefly", "where is the lighthouse?")
+		return err
+	})
+}
+
+// blockedWait is how long a call against a locked row must keep waiting before it is cancelled,
+// showing that the lock holds it
+const blockedWait = 200 * time.Millisecond
+
+// rowLocks are the statements that lock a conversation's row on each backend; SQLite locks the
+// whole database for writing
+var rowLocks = map[string]string{
+	"sqlite":   `UPDATE conversations SET importance = importance WHERE id = ?`,
+	"postgres": `UPDATE conversations SET importance = importance WHERE id = $1`,
+	"mysql":    `UPDATE conversations SET importance = importance WHERE id = ?`,
+}
+
+// lockConversation holds the row of a conversation locked in a transaction until the test ends
+func lockConversation(t *testing.T, db *sql.DB, backend string, id string) {
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	if _, err := tx.Exec(rowLocks[backend], id); err != nil {
+		t.Fatalf("lock conversation: %v", err)
+	}
+}
+
+// aborted reports whether err is a call ending because its context was cancelled. Postgres
+// reports a cancelled statement as query_canceled rather than the context's error
+func aborted(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "57014" {
+		return true
+	}
+	return errors.Is(err, context.Canceled)
+}
+
+// checkBlockedAborts runs call, checks that it is still waiting after blockedWait, cancels its
+// context and checks that call returns an abort error within abortBound
+func checkBlockedAborts(t *testing.T, call func(ctx context.Context) error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- call(ctx) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("call returned while the row was locked: %v", err)
+	case <-time.After(blockedWait):
+	}
+
+	cancelled := time.Now()
+	cancel()
+	select {
+	case err := <-done:
+		if !aborted(err) {
+			t.Errorf("call returned %v, want a cancellation error", err)
+		}
+		if elapsed := time.Since(cancelled); elapsed > abortBound {
+			t.Errorf("call took %v to return after cancellation", elapsed)
+		}
+	case <-time.After(abortBound):
+		t.Fatalf("call still running %v after cancellation", abortBound)
+	}
+}
+
+func TestRelationalCallsAbortOnCancel(t *testing.T) {
+	noTimeouts(t)
+
+	calls := []struct {
+		name string
+		call func(store RelationalStore, conv *models.Conversation, ctx context.Context) error
+	}{
+		{"save", func(store RelationalStore, conv *models.Conversation, ctx context.Context) error {
+			return store.SaveConversation(ctx, conv)
+		}},
+		{"delete", func(store RelationalStore, conv *models.Conversation, ctx context.Context) error {
+			return store.DeleteConversation(ctx, conv.ID)
+		}},
+		{"delete user", func(store RelationalStore, conv *models.Conversation, ctx context.Context) error {
+			_, err := store.DeleteUserData(ctx, conv.UserID)
+			return err
+		}},
+	}
+	for _, backend := range relationalBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			for _, c := range calls {
+				t.Run(c.name, func(t *testing.T) {
+					store := backend.open(t)
+					conv := newConversation("cancel-"+uuid.NewString(), "", conformanceTime)
+					if err := store.SaveConversation(context.Background(), conv); err != nil {
+						t.Fatalf("SaveConversation: %v", err)
+					}
+
+					lockConversation(t, store.(interface{ GetDB() *sql.DB }).GetDB(), backend.name, conv.ID)
+					checkBlockedAborts(t, func(ctx context.Context) error { return c.call(store, conv, ctx) })
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// Content types routed to their own Qdrant collections
+const (
+	ContentTypeConversations = "conversations"
+	ContentTypePersonalInfo  = "personal_info"
+	ContentTypeDocuments     = "documents"
+
+	// ContentTypeShadowConversations holds conversations embedded by the shadow model under evaluation
+	ContentTypeShadowConversations = "shadow_conversations"
+
+	// ContentTypePersonalInfoMultivector holds personal info as multivectors for late-interaction search
+	ContentTypePersonalInfoMultivector = "personal_info_multivector"
+)
+
+// payloadIDKeys maps each content type to the payload key holding its record ID
+var payloadIDKeys = map[string]string{
+	ContentTypeConversations: "conversation_id",
+	ContentTypePersonalInfo:  "info_id",
+	ContentTypeDocuments:     "document_id",
+
+	ContentTypeShadowConversations:     "conversation_id",
+	ContentTypePersonalInfoMultivector: "info_id",
+}
+
+// validDistances lists the distance metrics supported by Qdrant
+var validDistances = map[string]bool{
+	"Cosine":    true,
+	"Dot":       true,
+	"Euclid":    true,
+	"Manhattan": true,
+}
+
+// CollectionConfig describes a logical collection and its vector settings
+type CollectionConfig struct {
+	ContentType string
+	Name        string
+	Model       string
+	Dimension   int
+	Distance    string
+
+	// Cluster settings applied when the collection is created; zero means Qdrant's default
+	ShardNumber            int
+	ReplicationFactor      int
+	WriteConsistencyFactor int
+
+	// UserIsolation requires a user_id on every point and every search of the collection
+	UserIsolation bool
+
+	// ReadConsistency is the default read consistency of searches and point reads; "" is
+	// Qdrant's default of one replica
+	ReadConsistency string
+
+	// Datatype is the vector datatype of a new collection: float32 (""), float16 or uint8
+	Datatype string
+
+	// Multivector stores several vectors per point, scored by MaxSim
+	Multivector bool
+}
+
+// CollectionManager owns one QdrantStore per logical collection
+type CollectionManager struct {
+	configs map[string]CollectionConfig
+	stores  map[string]*QdrantStore
+}
+
+// NewCollectionManager validates the collection configs and creates a store for each
+func NewCollectionManager(baseURL string, client *http.Client, configs []CollectionConfig) (*CollectionManager, error) {
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	cm := &CollectionManager{
+		configs: make(map[string]CollectionConfig),
+		stores:  make(map[string]*QdrantStore),
+	}
+
+	names := make(map[string]string)
+	for _, cfg := range configs {
+		if cfg.ContentType == "" || cfg.Name == "" {
+			return nil, fmt.Errorf("collection config requires content type and name")
+		}
+		if _, exists := cm.configs[cfg.ContentType]; exists {
+			return nil, fmt.Errorf("duplicate collection config for content type %q", cfg.ContentType)
+		}
+		if other, exists := names[cfg.Name]; exists {
+			return nil, fmt.Errorf("collection %q is used by both %q and %q", cfg.Name, other, cfg.ContentType)
+		}
+		if cfg.Dimension <= 0 {
+			return nil, fmt.Errorf("collection %q has invalid dimension %d", cfg.Name, cfg.Dimension)
+		}
+		if cfg.Distance == "" {
+			cfg.Distance = "Cosine"
+		}
+		if !validDistances[cfg.Distance] {
+			return nil, fmt.Errorf("collection %q has unsupported distance %q", cfg.Name, cfg.Distance)
+		}
+		if cfg.ShardNumber < 0 || cfg.ReplicationFactor < 0 || cfg.WriteConsistencyFactor < 0 {
+			return nil, fmt.Errorf("collection %q has negative cluster settings", cfg.Name)
+		}
+		if cfg.ReadConsistency != "" && !ValidReadConsistency(cfg.ReadConsistency) {
+			return nil, fmt.Errorf("collection %q has invalid read consistency %q", cfg.Name, cfg.ReadConsistency)
+		}
+		if !ValidDatatype(cfg.Datatype) {
+			return nil, fmt.Errorf("collection %q has unsupported datatype %q", cfg.Name, cfg.Datatype)
+		}
+		if cfg.ReplicationFactor > 0 && cfg.WriteConsistencyFactor > cfg.ReplicationFactor {
+			return nil, fmt.Errorf("collection %q write_consistency_factor %d exceeds replication_factor %d",
+				cfg.Name, cfg.WriteConsistencyFactor, cfg.ReplicationFactor)
+		}
+
+		idKey, ok := payloadIDKeys[cfg.ContentType]
+		if !ok {
+			return nil, fmt.Errorf("unknown content type %q", cfg.ContentType)
+		}
+
+		names[cfg.Name] = cfg.ContentType
+		cm.configs[cfg.ContentType] = cfg
+		cm.stores[cfg.ContentType] = &QdrantStore{
+			baseURL:         baseURL,
+			collection:      cfg.Name,
+			distance:        cfg.Distance,
+			idKey:           idKey,
+			dimension:       cfg.Dimension,
+			client:          client,
+			userIsolation:   cfg.UserIsolation,
+			readConsistency: cfg.ReadConsistency,
+			datatype:        cfg.Datatype,
+			multivector:     cfg.Multivector,
+			cluster: clusterSettings{
+				ShardNumber:            cfg.ShardNumber,
+				ReplicationFactor:      cfg.ReplicationFactor,
+				WriteConsistencyFactor: cfg.WriteConsistencyFactor,
+			},
+		}
+	}
+
+	return cm, nil
+}
+
+// Store returns the vector store for a content type
+func (cm *CollectionManager) Store(contentType string) (*QdrantStore, error) {
+	store, ok := cm.stores[contentType]
+	if !ok {
+		return nil, fmt.Errorf("no collection configured for content type %q", contentType)
+	}
+	return store, nil
+}
+
+// Config returns the collection config for a content type
+func (cm *CollectionManager) Config(contentType string) (CollectionConfig, bool) {
+	cfg, ok := cm.configs[contentType]
+	return cfg, ok
+}
+
+// ContentTypes returns the configured content types in sorted order
+func (cm *CollectionManager) ContentTypes() []string {
+	types := make([]string, 0, len(cm.configs))
+	for contentType := range cm.configs {
+		types = append(types, contentType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// InitializeAll creates every configured collection that doesn't exist yet, or with create unset
+// fails with ErrCollectionMissing on the first that doesn't
+func (cm *CollectionManager) InitializeAll(ctx context.Context, create bool) error {
+	for _, contentType := range cm.ContentTypes() {
+		cfg := cm.configs[contentType]
+		if err := cm.stores[contentType].InitializeCollection(ctx, cfg.Dimension, create); err != nil {
+			return fmt.Errorf("failed to initialize %s collection: %w", contentType, err)
+		}
+	}
+	return nil
+}
+
+// Close closes all collection stores
+func (cm *CollectionManager) Close() error {
+	for _, store := range cm.stores {
+		if err := store.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
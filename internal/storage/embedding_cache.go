@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"slices"
+
+	"refo-rag-server/internal/cache"
+)
+
+// CachingEmbeddingProvider serves repeated texts of another provider from a cache shared by every
+// model, so a popular query is embedded once per cache lifetime instead of once per search.
+// Failed embeddings aren't cached
+type CachingEmbeddingProvider struct {
+	inner EmbeddingProvider
+	model string
+	cache *cache.Cache[[]float32]
+}
+
+// NewCachingEmbeddingProvider caches the vectors inner returns for model in c
+func NewCachingEmbeddingProvider(inner EmbeddingProvider, model string, c *cache.Cache[[]float32]) *CachingEmbeddingProvider {
+	return &CachingEmbeddingProvider{inner: inner, model: model, cache: c}
+}
+
+// Embed converts text to a vector, from the cache if it was embedded before
+func (cep *CachingEmbeddingProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	return cep.cached(embeddingKindText, text, func() ([]float32, error) { return cep.inner.Embed(ctx, text) })
+}
+
+// EmbedQuery converts search text to a vector, from the cache if it was embedded before
+func (cep *CachingEmbeddingProvider) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	return cep.cached(embeddingKindQuery, text, func() ([]float32, error) { return cep.inner.EmbedQuery(ctx, text) })
+}
+
+// EmbedDocument converts stored content to a vector, from the cache if it was embedded before
+func (cep *CachingEmbeddingProvider) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	return cep.cached(embeddingKindDocument, text, func() ([]float32, error) { return cep.inner.EmbedDocument(ctx, text) })
+}
+
+// EmbedBatch converts multiple texts to vectors, embedding only those missing from the cache in
+// one batch
+func (cep *CachingEmbeddingProvider) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	var missing []int
+	for i, text := range texts {
+		if vector, ok := cep.cache.Get(cep.key(embeddingKindText, text)); ok {
+			vectors[i] = slices.Clone(vector)
+		} else {
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	batch := make([]string, len(missing))
+	for j, i := range missing {
+		batch[j] = texts[i]
+	}
+	embedded, err := cep.inner.EmbedBatch(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range missing {
+		if j >= len(embedded) || embedded[j] == nil {
+			continue
+		}
+		vectors[i] = embedded[j]
+		cep.cache.Set(cep.key(embeddingKindText, texts[i]), slices.Clone(embedded[j]))
+	}
+	return vectors, nil
+}
+
+// cached returns the cached vector of a text, embedding and caching it on a miss. Callers get
+// their own copy, so changing it doesn't change the cache
+func (cep *CachingEmbeddingProvider) cached(kind string, text string, embed func() ([]float32, error)) ([]float32, error) {
+	key := cep.key(kind, text)
+	if vector, ok := cep.cache.Get(key); ok {
+		return slices.Clone(vector), nil
+	}
+	vector, err := embed()
+	if err != nil {
+		return nil, err
+	}
+	cep.cache.Set(key, slices.Clone(vector))
+	return vector, nil
+}
+
+// key returns the cache key of a text embedded by the provider's model; only a hash of the text
+// is kept
+func (cep *CachingEmbeddingProvider) key(kind string, text string) string {
+	return cep.model + "\x00" + hashEmbeddingInput(kind, text)
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EmbeddingPrefixes are the instructions asymmetric embedding models such as e5 and bge expect
+// before queries and documents, e.g. "query:" and "passage:"
+type EmbeddingPrefixes struct {
+	Query    string
+	Document string
+}
+
+// ParseEmbeddingPrefixes parses "model=query_prefix|document_prefix" entries; either prefix may
+// be empty
+func ParseEmbeddingPrefixes(entries []string) (map[string]EmbeddingPrefixes, error) {
+	prefixes := make(map[string]EmbeddingPrefixes, len(entries))
+	for _, entry := range entries {
+		model, params, ok := strings.Cut(entry, "=")
+		query, document, ok2 := strings.Cut(params, "|")
+		if !ok || !ok2 || strings.TrimSpace(model) == "" {
+			return nil, fmt.Errorf("invalid embedding prefixes %q, expected model=query_prefix|document_prefix", entry)
+		}
+		prefixes[strings.TrimSpace(model)] = EmbeddingPrefixes{
+			Query:    strings.TrimSpace(query),
+			Document: strings.TrimSpace(document),
+		}
+	}
+	return prefixes, nil
+}
+
+// withPrefix prepends an instruction prefix to text, separated by a space
+func withPrefix(prefix string, text string) string {
+	if prefix == "" {
+		return text
+	}
+	return prefix + " " + text
+}
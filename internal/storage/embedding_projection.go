@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"refo-rag-server/internal/projection"
+)
+
+// ProjectedEmbeddingProvider reduces the vectors of another provider to fewer dimensions, so
+// stored vectors and queries are projected alike
+type ProjectedEmbeddingProvider struct {
+	inner     EmbeddingProvider
+	projector *projection.Projector
+}
+
+// NewProjectedEmbeddingProvider projects the vectors of inner with projector
+func NewProjectedEmbeddingProvider(inner EmbeddingProvider, projector *projection.Projector) *ProjectedEmbeddingProvider {
+	return &ProjectedEmbeddingProvider{inner: inner, projector: projector}
+}
+
+// Unprojected returns the provider of the full vectors a provider projects, or provider itself if
+// it doesn't project them
+func Unprojected(provider EmbeddingProvider) EmbeddingProvider {
+	if projected, ok := provider.(*ProjectedEmbeddingProvider); ok {
+		return projected.inner
+	}
+	return provider
+}
+
+// Embed converts text to a vector with the wrapped provider and projects it
+func (pep *ProjectedEmbeddingProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	return pep.project(pep.inner.Embed(ctx, text))
+}
+
+// EmbedQuery converts search text to a vector with the wrapped provider and projects it
+func (pep *ProjectedEmbeddingProvider) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	return pep.project(pep.inner.EmbedQuery(ctx, text))
+}
+
+// EmbedDocument converts stored content to a vector with the wrapped provider and projects it
+func (pep *ProjectedEmbeddingProvider) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	return pep.project(pep.inner.EmbedDocument(ctx, text))
+}
+
+// EmbedBatch converts multiple texts to vectors with the wrapped provider and projects them
+func (pep *ProjectedEmbeddingProvider) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, err := pep.inner.EmbedBatch(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	projected := make([][]float32, len(vectors))
+	for i, vector := range vectors {
+		if vector == nil {
+			continue
+		}
+		if projected[i], err = pep.projector.Apply(vector); err != nil {
+			return nil, fmt.Errorf("failed to project embedding: %w", err)
+		}
+	}
+	return projected, nil
+}
+
+// project projects one returned vector
+func (pep *ProjectedEmbeddingProvider) project(vector []float32, err error) ([]float32, error) {
+	if err != nil {
+		return nil, err
+	}
+	projected, err := pep.projector.Apply(vector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to project embedding: %w", err)
+	}
+	return projected, nil
+}
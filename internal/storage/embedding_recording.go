@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Embedding recording modes
+const (
+	EmbeddingRecordingOff    = "off"
+	EmbeddingRecordingRecord = "record"
+	EmbeddingRecordingReplay = "replay"
+)
+
+// ErrEmbeddingNotRecorded is returned by a replay provider for an input that was never recorded
+var ErrEmbeddingNotRecorded = errors.New("no recorded embedding for input")
+
+// Kinds of embedding call, recorded with each input because queries and documents of the same
+// text can embed differently
+const (
+	embeddingKindText     = "text"
+	embeddingKindQuery    = "query"
+	embeddingKindDocument = "document"
+)
+
+// embeddingRecord is one line of a recording file. Only a hash of the input is kept, so
+// recordings of production traffic hold no user text
+type embeddingRecord struct {
+	Kind      string    `json:"kind"`
+	InputHash string    `json:"input_sha256"`
+	Vector    []float32 `json:"vector"`
+}
+
+// EmbeddingRecordingPath returns the recording file of a model under dir
+func EmbeddingRecordingPath(dir string, model string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(model)
+	return filepath.Join(dir, name+".jsonl")
+}
+
+// hashEmbeddingInput returns the key an input is recorded under
+func hashEmbeddingInput(kind string, text string) string {
+	sum := sha256.Sum256([]byte(kind + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordingEmbeddingProvider embeds with another provider and appends every returned vector to a
+// recording file
+type RecordingEmbeddingProvider struct {
+	inner EmbeddingProvider
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewRecordingEmbeddingProvider records the embeddings of inner to path, appending to any
+// recording already there. The file stays open for the life of the process
+func NewRecordingEmbeddingProvider(inner EmbeddingProvider, path string) (*RecordingEmbeddingProvider, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create embedding recording directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedding recording: %w", err)
+	}
+	return &RecordingEmbeddingProvider{inner: inner, file: file}, nil
+}
+
+// Embed converts text to a vector with the wrapped provider and records it
+func (rep *RecordingEmbeddingProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	vector, err := rep.inner.Embed(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	rep.record(embeddingKindText, []string{text}, [][]float32{vector})
+	return vector, nil
+}
+
+// EmbedQuery converts search text to a vector with the wrapped provider and records it
+func (rep *RecordingEmbeddingProvider) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	vector, err := rep.inner.EmbedQuery(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	rep.record(embeddingKindQuery, []string{text}, [][]float32{vector})
+	return vector, nil
+}
+
+// EmbedDocument converts stored content to a vector with the wrapped provider and records it
+func (rep *RecordingEmbeddingProvider) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	vector, err := rep.inner.EmbedDocument(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	rep.record(embeddingKindDocument, []string{text}, [][]float32{vector})
+	return vector, nil
+}
+
+// EmbedBatch converts multiple texts to vectors with the wrapped provider and records them
+func (rep *RecordingEmbeddingProvider) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, err := rep.inner.EmbedBatch(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	rep.record(embeddingKindText, texts, vectors)
+	return vectors, nil
+}
+
+// record appends one line per embedded text in a single write. A failed write is logged rather than
+// failing the embedding, so recording never breaks the requests it observes
+func (rep *RecordingEmbeddingProvider) record(kind string, texts []string, vectors [][]float32) {
+	var buf []byte
+	for i, text := range texts {
+		if i >= len(vectors) || vectors[i] == nil {
+			continue
+		}
+		line, err := json.Marshal(embeddingRecord{Kind: kind, InputHash: hashEmbeddingInput(kind, text), Vector: vectors[i]})
+		if err != nil {
+			log.Printf("Failed to encode embedding record: %v", err)
+			return
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	if _, err := rep.file.Write(buf); err != nil {
+		log.Printf("Failed to write embedding record: %v", err)
+	}
+}
+
+// ReplayEmbeddingProvider serves vectors from a recording instead of calling an embedding model
+type ReplayEmbeddingProvider struct {
+	vectors map[string][]float32
+}
+
+// NewReplayEmbeddingProvider loads the recording at path. Inputs recorded more than once are
+// served their last vector
+func NewReplayEmbeddingProvider(path string) (*ReplayEmbeddingProvider, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no embedding recording at %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedding recording: %w", err)
+	}
+	defer file.Close()
+
+	vectors := make(map[string][]float32)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record embeddingRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid embedding record on line %d of %s: %w", line, path, err)
+		}
+		vectors[record.InputHash] = record.Vector
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read embedding recording: %w", err)
+	}
+
+	return &ReplayEmbeddingProvider{vectors: vectors}, nil
+}
+
+// Len returns the number of recorded inputs
+func (rp *ReplayEmbeddingProvider) Len() int {
+	return len(rp.vectors)
+}
+
+// Embed returns the recorded vector of text
+func (rp *ReplayEmbeddingProvider) Embed(_ context.Context, text string) ([]float32, error) {
+	return rp.lookup(embeddingKindText, text)
+}
+
+// EmbedQuery returns the recorded query vector of text
+func (rp *ReplayEmbeddingProvider) EmbedQuery(_ context.Context, text string) ([]float32, error) {
+	return rp.lookup(embeddingKindQuery, text)
+}
+
+// EmbedDocument returns the recorded document vector of text
+func (rp *ReplayEmbeddingProvider) EmbedDocument(_ context.Context, text string) ([]float32, error) {
+	return rp.lookup(embeddingKindDocument, text)
+}
+
+// EmbedBatch returns the recorded vectors of texts, failing if any of them wasn't recorded
+func (rp *ReplayEmbeddingProvider) EmbedBatch(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector, err := rp.lookup(embeddingKindText, text)
+		if err != nil {
+			return nil, err
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+// lookup returns a copy of a recorded vector, so callers can't alter the recording
+func (rp *ReplayEmbeddingProvider) lookup(kind string, text string) ([]float32, error) {
+	hash := hashEmbeddingInput(kind, text)
+	vector, ok := rp.vectors[hash]
+	if !ok {
+		return nil, fmt.Errorf("%w (%s %s)", ErrEmbeddingNotRecorded, kind, hash)
+	}
+	return append([]float32(nil), vector...), nil
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+
+	"refo-rag-server/internal/metrics"
+)
+
+// ErrEmbeddingRejected is returned when the embedding provider refuses the input text itself;
+// sending the same text again fails the same way
+var ErrEmbeddingRejected = errors.New("embedding input rejected")
+
+// Embedding input rejection reasons, used as metric labels
+const (
+	EmbeddingRejectedPolicy = "policy"
+	EmbeddingRejectedLength = "length"
+)
+
+// EmbeddingRejectedError describes why the embedding provider refused a text; it matches
+// ErrEmbeddingRejected
+type EmbeddingRejectedError struct {
+	Reason string
+	Detail string
+}
+
+func (e *EmbeddingRejectedError) Error() string {
+	return fmt.Sprintf("%s (%s): %s", ErrEmbeddingRejected, e.Reason, e.Detail)
+}
+
+func (e *EmbeddingRejectedError) Unwrap() error {
+	return ErrEmbeddingRejected
+}
+
+// Markers of input rejections in OpenAI and Azure OpenAI error codes and messages
+var (
+	policyRejectionMarkers = []string{"content_policy_violation", "content_filter", "responsibleaipolicyviolation", "content management policy"}
+	lengthRejectionMarkers = []string{"context_length_exceeded", "maximum context length", "maximum input length", "too many tokens"}
+)
+
+// classifyEmbeddingError turns a 400 response refusing the input for a content policy or its
+// length into an EmbeddingRejectedError; other errors are returned unchanged
+func classifyEmbeddingError(model string, err error) error {
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusBadRequest {
+		return err
+	}
+
+	text := strings.ToLower(fmt.Sprintf("%v %s", apiErr.Code, apiErr.Message))
+	if apiErr.InnerError != nil {
+		text += " " + strings.ToLower(apiErr.InnerError.Code)
+	}
+
+	reason := ""
+	switch {
+	case containsAny(text, policyRejectionMarkers):
+		reason = EmbeddingRejectedPolicy
+	case containsAny(text, lengthRejectionMarkers):
+		reason = EmbeddingRejectedLength
+	default:
+		return err
+	}
+
+	metrics.EmbeddingRejections.WithLabelValues(model, reason).Inc()
+	return &EmbeddingRejectedError{Reason: reason, Detail: apiErr.Message}
+}
+
+// containsAny reports whether s contains any of the substrings
+func containsAny(s string, substrings []string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+	return false
+}
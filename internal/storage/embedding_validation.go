@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInvalidEmbedding is returned when a vector is rejected before it is written
+var ErrInvalidEmbedding = errors.New("invalid embedding")
+
+// Embedding rejection reasons, used as metric labels
+const (
+	EmbeddingInvalidDimension = "dimension"
+	EmbeddingInvalidNonFinite = "non_finite"
+	EmbeddingInvalidZeroNorm  = "zero_norm"
+)
+
+// EmbeddingError describes why a vector was rejected; it matches ErrInvalidEmbedding
+type EmbeddingError struct {
+	Reason string
+	Detail string
+}
+
+func (e *EmbeddingError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInvalidEmbedding, e.Detail)
+}
+
+func (e *EmbeddingError) Unwrap() error {
+	return ErrInvalidEmbedding
+}
+
+// ValidateEmbedding checks that a vector has the expected dimension, only finite components and a
+// non-zero norm; a dimension of 0 skips the dimension check. A vector failing any check would
+// score meaninglessly under cosine distance.
+func ValidateEmbedding(vector []float32, dimension int) error {
+	if len(vector) == 0 || (dimension > 0 && len(vector) != dimension) {
+		return &EmbeddingError{
+			Reason: EmbeddingInvalidDimension,
+			Detail: fmt.Sprintf("dimension %d, expected %d", len(vector), dimension),
+		}
+	}
+
+	var sumSquares float64
+	for i, v := range vector {
+		f := float64(v)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return &EmbeddingError{
+				Reason: EmbeddingInvalidNonFinite,
+				Detail: fmt.Sprintf("component %d is %v", i, v),
+			}
+		}
+		sumSquares += f * f
+	}
+	if sumSquares == 0 {
+		return &EmbeddingError{Reason: EmbeddingInvalidZeroNorm, Detail: "vector has zero norm"}
+	}
+
+	return nil
+}
+
+// NormalizeL2 scales a vector to unit length in place, as dot-product distance and mixing models
+// require; a zero vector is left as is
+func NormalizeL2(vector []float32) {
+	var sumSquares float64
+	for _, v := range vector {
+		sumSquares += float64(v) * float64(v)
+	}
+	if sumSquares == 0 {
+		return
+	}
+	norm := math.Sqrt(sumSquares)
+	for i, v := range vector {
+		vector[i] = float32(float64(v) / norm)
+	}
+}
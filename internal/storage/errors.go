@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// Kinds of storage failures, matched with errors.Is; errors returned by the stores wrap one of them
+// where the kind is known, so callers can tell a missing record or a lost race from a backend
+// outage without parsing messages
+var (
+	// ErrNotFound is returned when the record a write targets doesn't exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict is returned when a write collides with a concurrent one or with existing data
+	ErrConflict = errors.New("conflict")
+
+	// ErrUnavailable is returned when the backend can't be reached or is overloaded; the operation
+	// may succeed when retried
+	ErrUnavailable = errors.New("storage unavailable")
+)
+
+// ErrPersonalInfoNotFound is returned when updating or deleting a personal info entry that doesn't
+// exist
+var ErrPersonalInfoNotFound error = &kindError{kind: ErrNotFound, msg: "personal info not found"}
+
+// kindError is an error of one of the kinds above with a message of its own
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string { return e.msg }
+
+func (e *kindError) Unwrap() error { return e.kind }
+
+// QdrantStatusError is an unexpected HTTP status from Qdrant. It matches ErrNotFound for 404,
+// ErrConflict for 409 and ErrUnavailable for 429 and 5xx
+type QdrantStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *QdrantStatusError) Error() string {
+	return fmt.Sprintf("qdrant returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// Is maps the status to a storage error kind
+func (e *QdrantStatusError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrUnavailable:
+		return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+	}
+	return false
+}
+
+// qdrantStatusError reads the body of an unexpected Qdrant response into a QdrantStatusError
+func qdrantStatusError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	return &QdrantStatusError{StatusCode: resp.StatusCode, Body: string(body)}
+}
+
+// Retryable reports whether err is a storage failure that may succeed when retried: one that
+// matches ErrUnavailable, a timeout, a lost or refused connection, or a database error of the
+// connection, resource or operator-intervention classes. Other failures are permanent
+func Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrUnavailable) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		class := string(pqErr.Code.Class())
+		return class == "08" || class == "53" || class == "57" || pqErr.Code == "40001" || pqErr.Code == "40P01"
+	}
+
+	// Too many connections, lock wait timeout and deadlock
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1040 || mysqlErr.Number == 1205 || mysqlErr.Number == 1213
+	}
+
+	// SQLite reports a locked database only in the message
+	return strings.Contains(err.Error(), "database is locked")
+}
@@ -7,8 +7,20 @@ import (
 	"time"
 )
 
-// Migrate creates all necessary tables
-func Migrate(db *sql.DB) error {
+// SchemaVersion identifies the table layout Migrate produces; bump it whenever Migrate changes a
+// table so exports record which layout they were taken from
+const SchemaVersion = 28
+
+// Migrate creates all necessary tables. Unless the guard is off, pending statements that would
+// hold a heavy lock on a large table are logged or refused, and index builds on large tables run
+// concurrently instead
+func Migrate(db *sql.DB, opts MigrationOptions) error {
+	m := &migrator{db: db, opts: opts, plan: &MigrationPlan{}}
+	return m.migrate()
+}
+
+// migrate runs or, in a dry run, plans every migration block
+func (m *migrator) migrate() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -43,7 +55,7 @@ func Migrate(db *sql.DB) error {
 	EXECUTE FUNCTION update_conversations_updated_at();
 	`
 
-	_, err := db.ExecContext(ctx, createTableSQL)
+	err := m.exec(ctx, createTableSQL)
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
@@ -79,22 +91,566 @@ func Migrate(db *sql.DB) error {
 	EXECUTE FUNCTION update_personal_info_updated_at();
 	`
 
-	_, err = db.ExecContext(ctx, createPersonalInfoTableSQL)
+	err = m.exec(ctx, createPersonalInfoTableSQL)
 	if err != nil {
 		return fmt.Errorf("failed to run personal_info migrations: %w", err)
 	}
 
+	// Create messages table holding individual conversation messages
+	createMessagesTableSQL := `
+	CREATE TABLE IF NOT EXISTS messages (
+		id BIGSERIAL PRIMARY KEY,
+		conversation_id VARCHAR(36) NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+		message_id VARCHAR(255) NOT NULL,
+		position INTEGER NOT NULL,
+		role VARCHAR(20) NOT NULL,
+		content TEXT NOT NULL,
+		speaker VARCHAR(255),
+		display_name VARCHAR(255),
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (conversation_id, position),
+		UNIQUE (conversation_id, message_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_messages_conversation ON messages(conversation_id, position);
+	CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages(created_at DESC);
+	`
+
+	err = m.exec(ctx, createMessagesTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run messages migrations: %w", err)
+	}
+
+	// Create sessions table and link conversations to sessions
+	createSessionsTableSQL := `
+	CREATE TABLE IF NOT EXISTS sessions (
+		id VARCHAR(64) PRIMARY KEY,
+		user_id VARCHAR(255) NOT NULL,
+		title VARCHAR(255),
+		status VARCHAR(20) NOT NULL DEFAULT 'open',
+		summary TEXT,
+		summary_updated_at TIMESTAMP WITH TIME ZONE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		closed_at TIMESTAMP WITH TIME ZONE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_sessions_user_created ON sessions(user_id, created_at DESC);
+
+	ALTER TABLE conversations ADD COLUMN IF NOT EXISTS session_id VARCHAR(64);
+	CREATE INDEX IF NOT EXISTS idx_conversations_session ON conversations(session_id, created_at);
+
+	-- Link conversations saved before sessions existed through their metadata
+	UPDATE conversations
+	SET session_id = metadata->>'session_id'
+	WHERE session_id IS NULL
+		AND metadata->>'session_id' IS NOT NULL
+		AND length(metadata->>'session_id') <= 64;
+
+	INSERT INTO sessions (id, user_id, created_at, updated_at)
+	SELECT session_id, MIN(user_id), MIN(created_at), MAX(updated_at)
+	FROM conversations
+	WHERE session_id IS NOT NULL
+	GROUP BY session_id
+	ON CONFLICT (id) DO NOTHING;
+	`
+
+	err = m.exec(ctx, createSessionsTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run sessions migrations: %w", err)
+	}
+
+	// Long-term importance score used for ranking and forgetting
+	addImportanceSQL := `
+	ALTER TABLE conversations ADD COLUMN IF NOT EXISTS importance REAL NOT NULL DEFAULT 0.5;
+	CREATE INDEX IF NOT EXISTS idx_conversations_updated_at ON conversations(updated_at);
+	`
+
+	err = m.exec(ctx, addImportanceSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run importance migrations: %w", err)
+	}
+
+	// Pinned memories are always included in retrieval context
+	addPinnedSQL := `
+	ALTER TABLE conversations ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE personal_info ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;
+	CREATE INDEX IF NOT EXISTS idx_conversations_pinned ON conversations(user_id) WHERE pinned;
+	CREATE INDEX IF NOT EXISTS idx_personal_info_pinned ON personal_info(user_id) WHERE pinned;
+	`
+
+	err = m.exec(ctx, addPinnedSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run pinned memory migrations: %w", err)
+	}
+
+	// Suppressed memories are excluded from retrieval but kept for audit
+	addSuppressionSQL := `
+	ALTER TABLE conversations ADD COLUMN IF NOT EXISTS suppressed_at TIMESTAMP WITH TIME ZONE;
+	ALTER TABLE conversations ADD COLUMN IF NOT EXISTS suppression_reason VARCHAR(20);
+	ALTER TABLE conversations ADD COLUMN IF NOT EXISTS suppression_note TEXT;
+	ALTER TABLE personal_info ADD COLUMN IF NOT EXISTS suppressed_at TIMESTAMP WITH TIME ZONE;
+	ALTER TABLE personal_info ADD COLUMN IF NOT EXISTS suppression_reason VARCHAR(20);
+	ALTER TABLE personal_info ADD COLUMN IF NOT EXISTS suppression_note TEXT;
+	CREATE INDEX IF NOT EXISTS idx_conversations_suppressed ON conversations(user_id) WHERE suppressed_at IS NOT NULL;
+	CREATE INDEX IF NOT EXISTS idx_personal_info_suppressed ON personal_info(user_id) WHERE suppressed_at IS NOT NULL;
+	`
+
+	err = m.exec(ctx, addSuppressionSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run suppression migrations: %w", err)
+	}
+
+	// Create user profile cache table
+	createUserProfilesTableSQL := `
+	CREATE TABLE IF NOT EXISTS user_profiles (
+		user_id VARCHAR(255) PRIMARY KEY,
+		profile JSONB NOT NULL,
+		generated_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_user_profiles_generated_at ON user_profiles(generated_at);
+	`
+
+	err = m.exec(ctx, createUserProfilesTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run user profiles migrations: %w", err)
+	}
+
+	// Create admin job log table
+	createAdminJobsTableSQL := `
+	CREATE TABLE IF NOT EXISTS admin_jobs (
+		id VARCHAR(255) PRIMARY KEY,
+		kind VARCHAR(64) NOT NULL,
+		target VARCHAR(255) NOT NULL DEFAULT '',
+		dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+		status VARCHAR(32) NOT NULL,
+		result JSONB,
+		error TEXT NOT NULL DEFAULT '',
+		started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		finished_at TIMESTAMP WITH TIME ZONE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_admin_jobs_kind_started_at ON admin_jobs(kind, started_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_admin_jobs_started_at ON admin_jobs(started_at DESC);
+	`
+
+	err = m.exec(ctx, createAdminJobsTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run admin jobs migrations: %w", err)
+	}
+
+	// Create work queue table, shared by all replicas; workers lease items with SKIP LOCKED
+	createWorkQueueTableSQL := `
+	CREATE TABLE IF NOT EXISTS work_queue (
+		id BIGSERIAL PRIMARY KEY,
+		kind VARCHAR(64) NOT NULL,
+		payload JSONB NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		available_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		lease_owner VARCHAR(255),
+		lease_expires_at TIMESTAMP WITH TIME ZONE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_work_queue_kind_available_at ON work_queue(kind, available_at);
+	`
+
+	err = m.exec(ctx, createWorkQueueTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run work queue migrations: %w", err)
+	}
+
+	// Create dead letter table for queue items that exhausted their attempts
+	createDeadLettersTableSQL := `
+	CREATE TABLE IF NOT EXISTS dead_letters (
+		id BIGINT PRIMARY KEY,
+		kind VARCHAR(64) NOT NULL,
+		payload JSONB NOT NULL,
+		attempts INTEGER NOT NULL,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		failed_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_dead_letters_kind_failed_at ON dead_letters(kind, failed_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_dead_letters_failed_at ON dead_letters(failed_at DESC);
+	`
+
+	err = m.exec(ctx, createDeadLettersTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run dead letter migrations: %w", err)
+	}
+
+	// SHA-256 of the text last embedded for each conversation, compared against the vector payload
+	addContentHashSQL := `
+	ALTER TABLE conversations ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64) NOT NULL DEFAULT '';
+	`
+
+	err = m.exec(ctx, addContentHashSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run content hash migrations: %w", err)
+	}
+
+	// Embedding tokens per UTC day, tenant and model, added to by the usage aggregator
+	createUsageSQL := `
+	CREATE TABLE IF NOT EXISTS embedding_usage (
+		day DATE NOT NULL,
+		tenant VARCHAR(255) NOT NULL DEFAULT '',
+		model VARCHAR(255) NOT NULL,
+		requests BIGINT NOT NULL DEFAULT 0,
+		prompt_tokens BIGINT NOT NULL DEFAULT 0,
+		total_tokens BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (day, tenant, model)
+	);
+	`
+
+	err = m.exec(ctx, createUsageSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run usage migrations: %w", err)
+	}
+
+	// Registry of end users; user_ids without a row are implicitly active
+	createUsersSQL := `
+	CREATE TABLE IF NOT EXISTS users (
+		id VARCHAR(255) PRIMARY KEY,
+		display_name VARCHAR(255) NOT NULL DEFAULT '',
+		status VARCHAR(20) NOT NULL DEFAULT 'active',
+		disabled_reason TEXT NOT NULL DEFAULT '',
+		disabled_at TIMESTAMP,
+		retention_exempt BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_users_status ON users(status);
+
+	-- Home region of the user's data; empty follows the tenant's region
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS region VARCHAR(64) NOT NULL DEFAULT '';
+	`
+
+	err = m.exec(ctx, createUsersSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run users migrations: %w", err)
+	}
+
+	// Service account keys; only a SHA-256 hash of each secret is stored
+	createAPIKeysSQL := `
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id VARCHAR(36) PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		prefix VARCHAR(16) NOT NULL,
+		secret_hash CHAR(64) NOT NULL UNIQUE,
+		scopes TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		expires_at TIMESTAMP,
+		revoked_at TIMESTAMP,
+		rotated_to VARCHAR(36) NOT NULL DEFAULT ''
+	);
+	`
+
+	err = m.exec(ctx, createAPIKeysSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run api_keys migrations: %w", err)
+	}
+
+	// Per-tenant conversation encryption keys, wrapped by a master key
+	createDataKeysSQL := `
+	CREATE TABLE IF NOT EXISTS tenant_data_keys (
+		tenant VARCHAR(255) NOT NULL,
+		version INTEGER NOT NULL,
+		master_key_id VARCHAR(255) NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		wrapped_key BYTEA NOT NULL,
+		PRIMARY KEY (tenant, version)
+	);
+	`
+
+	err = m.exec(ctx, createDataKeysSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run tenant_data_keys migrations: %w", err)
+	}
+
+	// Conversation searches, kept for usage analytics when search logging is on
+	createSearchLogsSQL := `
+	CREATE TABLE IF NOT EXISTS search_logs (
+		id BIGSERIAL PRIMARY KEY,
+		tenant VARCHAR(255) NOT NULL DEFAULT '',
+		user_id VARCHAR(255) NOT NULL DEFAULT '',
+		query TEXT NOT NULL,
+		results INTEGER NOT NULL,
+		top_score REAL NOT NULL DEFAULT 0,
+		duration_ms BIGINT NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_search_logs_created_at ON search_logs(created_at);
+	CREATE INDEX IF NOT EXISTS idx_search_logs_user_id ON search_logs(user_id);
+
+	-- Retrieval pipeline that served the search: primary or canary
+	ALTER TABLE search_logs ADD COLUMN IF NOT EXISTS variant VARCHAR(32) NOT NULL DEFAULT '';
+	`
+
+	err = m.exec(ctx, createSearchLogsSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run search_logs migrations: %w", err)
+	}
+
+	// Conversations stored without a vector because the embedding provider refused their text
+	addUnembeddedSQL := `
+	ALTER TABLE conversations ADD COLUMN IF NOT EXISTS unembedded_at TIMESTAMP WITH TIME ZONE;
+	ALTER TABLE conversations ADD COLUMN IF NOT EXISTS unembedded_reason VARCHAR(20);
+	ALTER TABLE conversations ADD COLUMN IF NOT EXISTS unembedded_detail TEXT;
+
+	CREATE INDEX IF NOT EXISTS idx_conversations_unembedded_at ON conversations(unembedded_at DESC) WHERE unembedded_at IS NOT NULL;
+	`
+
+	err = m.exec(ctx, addUnembeddedSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run unembedded conversation migrations: %w", err)
+	}
+
+	// Whether each conversation's vector is written; conversations from before statuses were
+	// tracked are indexed unless the embedding provider refused them
+	addConversationStatusSQL := `
+	ALTER TABLE conversations ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'indexed';
+
+	UPDATE conversations SET status = 'failed' WHERE unembedded_at IS NOT NULL AND status = 'indexed';
+
+	CREATE INDEX IF NOT EXISTS idx_conversations_user_status ON conversations(user_id, status, created_at DESC);
+	`
+
+	err = m.exec(ctx, addConversationStatusSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run conversation status migrations: %w", err)
+	}
+
+	// Per-user conversation aggregates, kept by a trigger so reads never count a user's rows. A
+	// conversation given another user ID, as the legacy backfill does, moves between the users'
+	// counts. The last conversation time is last activity and stays when conversations are
+	// deleted; tokens are added by the service as it embeds. The backfill only runs while the
+	// table is empty
+	createUserStatsSQL := `
+	CREATE TABLE IF NOT EXISTS user_stats (
+		user_id VARCHAR(255) PRIMARY KEY,
+		conversation_count BIGINT NOT NULL DEFAULT 0,
+		last_conversation_at TIMESTAMP WITH TIME ZONE,
+		total_tokens BIGINT NOT NULL DEFAULT 0
+	);
+
+	CREATE OR REPLACE FUNCTION update_user_stats()
+	RETURNS TRIGGER AS $$
+	BEGIN
+		IF TG_OP = 'UPDATE' AND OLD.user_id IS NOT DISTINCT FROM NEW.user_id THEN
+			RETURN NEW;
+		END IF;
+		IF TG_OP IN ('UPDATE', 'DELETE') THEN
+			UPDATE user_stats SET conversation_count = GREATEST(conversation_count - 1, 0) WHERE user_id = OLD.user_id;
+		END IF;
+		IF TG_OP IN ('INSERT', 'UPDATE') THEN
+			INSERT INTO user_stats (user_id, conversation_count, last_conversation_at)
+			VALUES (NEW.user_id, 1, NEW.created_at)
+			ON CONFLICT (user_id) DO UPDATE SET
+				conversation_count = user_stats.conversation_count + 1,
+				last_conversation_at = GREATEST(COALESCE(user_stats.last_conversation_at, EXCLUDED.last_conversation_at), EXCLUDED.last_conversation_at);
+			RETURN NEW;
+		END IF;
+		RETURN OLD;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	DROP TRIGGER IF EXISTS conversations_user_stats_trigger ON conversations;
+	CREATE TRIGGER conversations_user_stats_trigger
+	AFTER INSERT OR DELETE OR UPDATE OF user_id ON conversations
+	FOR EACH ROW
+	EXECUTE FUNCTION update_user_stats();
+
+	INSERT INTO user_stats (user_id, conversation_count, last_conversation_at)
+	SELECT user_id, COUNT(*), MAX(created_at) FROM conversations
+	WHERE NOT EXISTS (SELECT 1 FROM user_stats)
+	GROUP BY user_id
+	ON CONFLICT (user_id) DO NOTHING;
+	`
+
+	err = m.exec(ctx, createUserStatsSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run user_stats migrations: %w", err)
+	}
+
+	// Old conversation IDs that resolve to the conversation that replaced them after a merge or
+	// an import that remapped IDs
+	createIDAliasesSQL := `
+	CREATE TABLE IF NOT EXISTS id_aliases (
+		alias_id VARCHAR(36) PRIMARY KEY,
+		conversation_id VARCHAR(36) NOT NULL,
+		reason VARCHAR(20) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_id_aliases_conversation_id ON id_aliases(conversation_id);
+	`
+
+	err = m.exec(ctx, createIDAliasesSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run id_aliases migrations: %w", err)
+	}
+
+	// Signed certificates of executed purges, kept as proof of erasure; the certificate is stored
+	// exactly as signed
+	createDeletionCertificatesSQL := `
+	CREATE TABLE IF NOT EXISTS deletion_certificates (
+		id VARCHAR(36) PRIMARY KEY,
+		job_id VARCHAR(255) NOT NULL,
+		kind VARCHAR(64) NOT NULL,
+		target VARCHAR(255) NOT NULL DEFAULT '',
+		certificate JSONB NOT NULL,
+		deleted_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_deletion_certificates_deleted_at ON deletion_certificates(deleted_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_deletion_certificates_target ON deletion_certificates(target, deleted_at DESC);
+	`
+
+	err = m.exec(ctx, createDeletionCertificatesSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run deletion_certificates migrations: %w", err)
+	}
+
+	// Per-user query adapters trained offline from search feedback, one per embedding model
+	createUserQueryAdaptersSQL := `
+	CREATE TABLE IF NOT EXISTS user_query_adapters (
+		user_id VARCHAR(255) NOT NULL,
+		model VARCHAR(255) NOT NULL,
+		adapter JSONB NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		PRIMARY KEY (user_id, model)
+	);
+	`
+
+	err = m.exec(ctx, createUserQueryAdaptersSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run user_query_adapters migrations: %w", err)
+	}
+
+	// Containment index for metadata filters evaluated in SQL; jsonb_path_ops only serves @>, which
+	// is what equality and IN comparisons compile to
+	addConversationMetadataIndexSQL := `
+	CREATE INDEX IF NOT EXISTS idx_conversations_metadata ON conversations USING GIN (metadata jsonb_path_ops);
+	`
+
+	err = m.exec(ctx, addConversationMetadataIndexSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run conversation metadata index migrations: %w", err)
+	}
+
+	// Searches registered to run against each newly saved conversation, with their query vectors
+	createStandingQueriesSQL := `
+	CREATE TABLE IF NOT EXISTS standing_queries (
+		id VARCHAR(36) PRIMARY KEY,
+		user_id VARCHAR(255) NOT NULL,
+		query TEXT NOT NULL,
+		filter TEXT NOT NULL DEFAULT '',
+		threshold REAL NOT NULL,
+		vector JSONB NOT NULL,
+		match_count BIGINT NOT NULL DEFAULT 0,
+		last_matched_at TIMESTAMP WITH TIME ZONE,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_standing_queries_user_id ON standing_queries(user_id);
+	`
+
+	err = m.exec(ctx, createStandingQueriesSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run standing_queries migrations: %w", err)
+	}
+
+	// Content hashes and vectors of the chunks of conversations embedded in chunks
+	createConversationChunksSQL := `
+	CREATE TABLE IF NOT EXISTS conversation_chunks (
+		conversation_id VARCHAR(36) NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		content_hash VARCHAR(64) NOT NULL,
+		vector JSONB NOT NULL,
+		PRIMARY KEY (conversation_id, position)
+	);
+
+	-- The model and dimension a chunk was embedded with; chunks without them are never reused
+	ALTER TABLE conversation_chunks ADD COLUMN IF NOT EXISTS model VARCHAR(255) NOT NULL DEFAULT '';
+	ALTER TABLE conversation_chunks ADD COLUMN IF NOT EXISTS dimension INTEGER NOT NULL DEFAULT 0;
+	`
+
+	err = m.exec(ctx, createConversationChunksSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run conversation_chunks migrations: %w", err)
+	}
+
+	// Relevance feedback per tenant and fusion bandit arm, and tenants pinned to one arm
+	createFusionBanditSQL := `
+	CREATE TABLE IF NOT EXISTS fusion_bandit_arms (
+		tenant VARCHAR(255) NOT NULL,
+		arm VARCHAR(255) NOT NULL,
+		trials BIGINT NOT NULL DEFAULT 0,
+		rewards BIGINT NOT NULL DEFAULT 0,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		PRIMARY KEY (tenant, arm)
+	);
+
+	CREATE TABLE IF NOT EXISTS fusion_bandit_tenants (
+		tenant VARCHAR(255) PRIMARY KEY,
+		frozen_arm VARCHAR(255) NOT NULL DEFAULT '',
+		frozen_at TIMESTAMP WITH TIME ZONE
+	);
+	`
+
+	err = m.exec(ctx, createFusionBanditSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run fusion bandit migrations: %w", err)
+	}
+
+	// Nonces of signed requests, kept until their signatures expire so every replica rejects replays
+	createSignatureNoncesSQL := `
+	CREATE TABLE IF NOT EXISTS signature_nonces (
+		client_id VARCHAR(255) NOT NULL,
+		nonce VARCHAR(128) NOT NULL,
+		expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		PRIMARY KEY (client_id, nonce)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_signature_nonces_expires_at ON signature_nonces(expires_at);
+	`
+
+	err = m.exec(ctx, createSignatureNoncesSQL)
+	if err != nil {
+		return fmt.Errorf("failed to run signature_nonces migrations: %w", err)
+	}
+
 	return nil
 }
 
-// MigrateQdrant initializes Qdrant collection for vector storage
-func MigrateQdrant(qdrantStore *QdrantStore, vectorSize int) error {
+// MigrateQdrant initializes Qdrant collection for vector storage, creating it if it is missing
+// and autoCreate is set
+func MigrateQdrant(qdrantStore *QdrantStore, vectorSize int, autoCreate bool) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	if err := qdrantStore.InitializeCollection(ctx, vectorSize); err != nil {
+	if err := qdrantStore.InitializeCollection(ctx, vectorSize, autoCreate); err != nil {
 		return fmt.Errorf("failed to initialize Qdrant collection: %w", err)
 	}
 
 	return nil
 }
+
+// MigrateCollections initializes every collection managed by the collection manager, creating
+// missing ones if autoCreate is set
+func MigrateCollections(manager *CollectionManager, autoCreate bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := manager.InitializeAll(ctx, autoCreate); err != nil {
+		return fmt.Errorf("failed to initialize Qdrant collections: %w", err)
+	}
+
+	return nil
+}
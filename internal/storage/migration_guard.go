@@ -0,0 +1,342 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Migration guard modes
+const (
+	MigrationGuardOff   = "off"   // Run migrations without analyzing them
+	MigrationGuardWarn  = "warn"  // Log lock-heavy statements and run them
+	MigrationGuardBlock = "block" // Refuse to run lock-heavy statements
+)
+
+// concurrentIndexTimeout bounds an index build that runs concurrently with traffic
+const concurrentIndexTimeout = 2 * time.Hour
+
+// MigrationOptions configures how Migrate treats statements that lock large tables
+type MigrationOptions struct {
+	// Guard is one of the migration guard modes
+	Guard string
+
+	// LargeTableRows is the estimated row count from which holding a heavy lock on a table while
+	// a statement runs would stall traffic
+	LargeTableRows int64
+}
+
+// MigrationRisk is a pending statement that holds a heavy lock on a large table
+type MigrationRisk struct {
+	Statement string `json:"statement"`
+	Table     string `json:"table"`
+	Rows      int64  `json:"rows"` // Estimated from planner statistics
+	Reason    string `json:"reason"`
+
+	// Rewrite is the lock-friendly statement run instead; risks with a rewrite don't block
+	Rewrite string `json:"rewrite,omitempty"`
+}
+
+// MigrationPlan lists the statements Migrate would run that change the schema or data, and which
+// of them are lock-heavy
+type MigrationPlan struct {
+	Pending []string        `json:"pending"`
+	Risks   []MigrationRisk `json:"risks"`
+}
+
+// Blocking returns the risks the block guard refuses to run
+func (p *MigrationPlan) Blocking() []MigrationRisk {
+	var blocking []MigrationRisk
+	for _, risk := range p.Risks {
+		if risk.Rewrite == "" {
+			blocking = append(blocking, risk)
+		}
+	}
+	return blocking
+}
+
+// PlanMigrations reports what Migrate would do to the current database without changing it
+func PlanMigrations(db *sql.DB, opts MigrationOptions) (*MigrationPlan, error) {
+	m := &migrator{db: db, opts: opts, plan: &MigrationPlan{Pending: []string{}, Risks: []MigrationRisk{}}, dryRun: true}
+	if err := m.migrate(); err != nil {
+		return nil, err
+	}
+	return m.plan, nil
+}
+
+var (
+	createTableRe     = regexp.MustCompile(`(?is)^CREATE TABLE IF NOT EXISTS (\w+)`)
+	createIndexRe     = regexp.MustCompile(`(?is)^CREATE (UNIQUE )?INDEX (CONCURRENTLY )?IF NOT EXISTS (\w+) ON (\w+)(.*)$`)
+	addColumnRe       = regexp.MustCompile(`(?is)^ALTER TABLE (\w+) ADD COLUMN IF NOT EXISTS (\w+) (.*)$`)
+	alterColumnTypeRe = regexp.MustCompile(`(?is)^ALTER TABLE (\w+) ALTER COLUMN \w+ (SET DATA )?TYPE`)
+	addConstraintRe   = regexp.MustCompile(`(?is)^ALTER TABLE (\w+) ADD (CONSTRAINT|FOREIGN KEY|PRIMARY KEY|UNIQUE|CHECK)`)
+	dataChangeRe      = regexp.MustCompile(`(?is)^(?:UPDATE (\w+)|DELETE FROM (\w+)|INSERT INTO \w+.*?\bFROM (\w+))`)
+
+	// volatileDefaultRe matches column defaults Postgres evaluates per row, which rewrites the table
+	volatileDefaultRe = regexp.MustCompile(`(?is)\bDEFAULT\b.*\b(random|gen_random_uuid|uuid_generate_v4|clock_timestamp|nextval)\s*\(`)
+)
+
+// migrator runs migration blocks, analyzing their statements first unless the guard is off
+type migrator struct {
+	db     *sql.DB
+	opts   MigrationOptions
+	plan   *MigrationPlan
+	dryRun bool
+}
+
+// exec analyzes a block of statements and runs it. Pending index builds on large tables run
+// concurrently beforehand, so the block's own statement finds the index and does nothing
+func (m *migrator) exec(ctx context.Context, block string) error {
+	if m.opts.Guard == MigrationGuardOff && !m.dryRun {
+		_, err := m.db.ExecContext(ctx, block)
+		return err
+	}
+
+	blockPending := false
+	var risks []MigrationRisk
+	var dataChanges []string
+	for _, stmt := range splitStatements(block) {
+		if dataChangeRe.MatchString(stmt) {
+			dataChanges = append(dataChanges, stmt)
+			continue
+		}
+		pending, risk, err := m.analyze(ctx, stmt)
+		if err != nil {
+			return err
+		}
+		if pending {
+			blockPending = true
+			m.plan.Pending = append(m.plan.Pending, stmt)
+		}
+		if risk != nil {
+			risks = append(risks, *risk)
+		}
+	}
+
+	// Data changes backfill the block's new columns and tables; they only do work while the
+	// block's schema changes are pending
+	if blockPending {
+		for _, stmt := range dataChanges {
+			m.plan.Pending = append(m.plan.Pending, stmt)
+			match := dataChangeRe.FindStringSubmatch(stmt)
+			table := match[1] + match[2] + match[3]
+			risk, err := m.largeTableRisk(ctx, stmt, table, "backfill updates every row of a large table in one transaction")
+			if err != nil {
+				return err
+			}
+			if risk != nil {
+				risks = append(risks, *risk)
+			}
+		}
+	}
+	m.plan.Risks = append(m.plan.Risks, risks...)
+	if m.dryRun {
+		return nil
+	}
+
+	for _, risk := range risks {
+		if risk.Rewrite != "" {
+			log.Printf("Migration: %s on %s (~%d rows); running %q instead", risk.Reason, risk.Table, risk.Rows, risk.Rewrite)
+			if err := m.buildConcurrently(risk); err != nil {
+				return err
+			}
+			continue
+		}
+		if m.opts.Guard == MigrationGuardBlock {
+			return fmt.Errorf("migration guard refused %q: %s on %s (~%d rows); apply it in a maintenance window or set MIGRATION_GUARD=warn", risk.Statement, risk.Reason, risk.Table, risk.Rows)
+		}
+		log.Printf("warning: migration %q: %s on %s (~%d rows)", risk.Statement, risk.Reason, risk.Table, risk.Rows)
+	}
+
+	_, err := m.db.ExecContext(ctx, block)
+	return err
+}
+
+// analyze reports whether a statement would change the database and the risk of running it
+func (m *migrator) analyze(ctx context.Context, stmt string) (bool, *MigrationRisk, error) {
+	if match := createTableRe.FindStringSubmatch(stmt); match != nil {
+		exists, err := m.relationExists(ctx, match[1])
+		return !exists, nil, err
+	}
+
+	if match := createIndexRe.FindStringSubmatch(stmt); match != nil {
+		unique, concurrent, index, table, rest := match[1], match[2], match[3], match[4], match[5]
+		valid, exists, err := m.indexState(ctx, index)
+		if err != nil || (exists && valid) {
+			return false, nil, err
+		}
+		if concurrent != "" {
+			return true, nil, nil
+		}
+		rows, err := m.estimatedRows(ctx, table)
+		if err != nil {
+			return true, nil, err
+		}
+		reason := "index build blocks writes to the table until it finishes"
+		if exists {
+			// An interrupted concurrent build leaves an invalid index behind; rebuild it the same way
+			reason = "index is invalid after an interrupted concurrent build"
+		} else if rows < m.opts.LargeTableRows {
+			return true, nil, nil
+		}
+		return true, &MigrationRisk{
+			Statement: stmt,
+			Table:     table,
+			Rows:      rows,
+			Reason:    reason,
+			Rewrite:   fmt.Sprintf("CREATE %sINDEX CONCURRENTLY IF NOT EXISTS %s ON %s%s", unique, index, table, rest),
+		}, nil
+	}
+
+	if match := addColumnRe.FindStringSubmatch(stmt); match != nil {
+		exists, err := m.columnExists(ctx, match[1], match[2])
+		if err != nil || exists {
+			return false, nil, err
+		}
+		if !volatileDefaultRe.MatchString(match[3]) {
+			// Adding a column with a constant default only changes the catalog
+			return true, nil, nil
+		}
+		risk, err := m.largeTableRisk(ctx, stmt, match[1], "column with a volatile default rewrites the table under an exclusive lock")
+		return true, risk, err
+	}
+
+	if match := alterColumnTypeRe.FindStringSubmatch(stmt); match != nil {
+		risk, err := m.largeTableRisk(ctx, stmt, match[1], "changing a column type rewrites the table under an exclusive lock")
+		return true, risk, err
+	}
+
+	if match := addConstraintRe.FindStringSubmatch(stmt); match != nil && !strings.Contains(strings.ToUpper(stmt), "NOT VALID") {
+		risk, err := m.largeTableRisk(ctx, stmt, match[1], "constraint is validated against every row while writes are blocked; add it NOT VALID and validate separately")
+		return true, risk, err
+	}
+
+	// Functions and triggers are replaced on every start and only lock briefly
+	return false, nil, nil
+}
+
+// largeTableRisk returns a risk without a rewrite when the table is large
+func (m *migrator) largeTableRisk(ctx context.Context, stmt string, table string, reason string) (*MigrationRisk, error) {
+	rows, err := m.estimatedRows(ctx, table)
+	if err != nil || rows < m.opts.LargeTableRows {
+		return nil, err
+	}
+	return &MigrationRisk{Statement: stmt, Table: table, Rows: rows, Reason: reason}, nil
+}
+
+// buildConcurrently runs a concurrent index build, dropping an invalid index an interrupted build
+// left behind first. Concurrent builds can't run inside a transaction, so each statement runs alone
+func (m *migrator) buildConcurrently(risk MigrationRisk) error {
+	ctx, cancel := context.WithTimeout(context.Background(), concurrentIndexTimeout)
+	defer cancel()
+
+	index := createIndexRe.FindStringSubmatch(risk.Rewrite)[3]
+	valid, exists, err := m.indexState(ctx, index)
+	if err != nil {
+		return err
+	}
+	if exists && !valid {
+		if _, err := m.db.ExecContext(ctx, "DROP INDEX CONCURRENTLY IF EXISTS "+index); err != nil {
+			return fmt.Errorf("failed to drop invalid index %s: %w", index, err)
+		}
+	}
+	if _, err := m.db.ExecContext(ctx, risk.Rewrite); err != nil {
+		return fmt.Errorf("failed to build index %s concurrently: %w", index, err)
+	}
+	return nil
+}
+
+// relationExists reports whether a table or index exists in the current schema
+func (m *migrator) relationExists(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	err := m.db.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, name).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up %s: %w", name, err)
+	}
+	return exists, nil
+}
+
+// indexState reports whether an index exists and whether it is valid
+func (m *migrator) indexState(ctx context.Context, name string) (valid bool, exists bool, err error) {
+	err = m.db.QueryRowContext(ctx, `
+		SELECT i.indisvalid FROM pg_index i
+		WHERE i.indexrelid = to_regclass($1)
+	`, name).Scan(&valid)
+	if err == sql.ErrNoRows {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, fmt.Errorf("failed to look up index %s: %w", name, err)
+	}
+	return valid, true, nil
+}
+
+// columnExists reports whether a table in the current schema has a column
+func (m *migrator) columnExists(ctx context.Context, table string, column string) (bool, error) {
+	var exists bool
+	err := m.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2
+		)
+	`, table, column).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up column %s.%s: %w", table, column, err)
+	}
+	return exists, nil
+}
+
+// estimatedRows returns the planner's row estimate of a table, or 0 if it doesn't exist
+func (m *migrator) estimatedRows(ctx context.Context, table string) (int64, error) {
+	var rows int64
+	err := m.db.QueryRowContext(ctx, `
+		SELECT COALESCE((SELECT GREATEST(reltuples, 0)::bigint FROM pg_class WHERE oid = to_regclass($1)), 0)
+	`, table).Scan(&rows)
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate rows of %s: %w", table, err)
+	}
+	return rows, nil
+}
+
+// splitStatements splits a block of SQL into statements with whitespace collapsed, keeping
+// semicolons inside quotes and dollar-quoted function bodies, and dropping comments
+func splitStatements(block string) []string {
+	var statements []string
+	var current strings.Builder
+	inQuote, inDollar := false, false
+
+	flush := func() {
+		if stmt := strings.Join(strings.Fields(current.String()), " "); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(block); i++ {
+		switch {
+		case !inQuote && !inDollar && strings.HasPrefix(block[i:], "--"):
+			for i < len(block) && block[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+			continue
+		case !inQuote && strings.HasPrefix(block[i:], "$$"):
+			inDollar = !inDollar
+			current.WriteString("$$")
+			i++
+			continue
+		case !inDollar && block[i] == '\'':
+			inQuote = !inQuote
+		case !inQuote && !inDollar && block[i] == ';':
+			flush()
+			continue
+		}
+		current.WriteByte(block[i])
+	}
+	flush()
+	return statements
+}
@@ -0,0 +1,834 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// MySQLStore implements RelationalStore on MySQL 8 or MariaDB 10.6+, for deployments that must
+// keep all relational data there. Timestamps are stored in UTC. Triggers on conversations keep
+// user_stats current as in the Postgres schema, but none touches updated_at: it is what the caller
+// last saved
+type MySQLStore struct {
+	db *sql.DB
+
+	// cipher encrypts conversation content at rest; nil stores it as plaintext
+	cipher ContentCipher
+}
+
+// NewMySQLStore connects to MySQL with a go-sql-driver DSN such as
+// user:password@tcp(host:3306)/rag. Times are read and written in UTC, and updates report the
+// rows they matched rather than the rows they changed, as Postgres does
+func NewMySQLStore(dsn string) (*MySQLStore, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MySQL DSN: %w", err)
+	}
+	cfg.ParseTime = true
+	cfg.Loc = time.UTC
+	cfg.ClientFoundRows = true
+	if cfg.Params == nil {
+		cfg.Params = map[string]string{}
+	}
+	cfg.Params["time_zone"] = "'+00:00'"
+
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db := sql.OpenDB(connector)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
+
+	return &MySQLStore{db: db}, nil
+}
+
+// SetContentCipher encrypts the question, answer and message content of conversations saved from
+// now on, as PostgresStore.SetContentCipher does
+func (ms *MySQLStore) SetContentCipher(cipher ContentCipher) {
+	ms.cipher = cipher
+}
+
+// SaveConversation saves a conversation and its messages to MySQL
+func (ms *MySQLStore) SaveConversation(ctx context.Context, conv *models.Conversation) error {
+	return ms.SaveConversationThen(ctx, conv, nil)
+}
+
+// SaveConversationThen saves a conversation and its messages, then runs beforeCommit inside the
+// transaction; the save is rolled back if beforeCommit fails
+func (ms *MySQLStore) SaveConversationThen(ctx context.Context, conv *models.Conversation, beforeCommit func(ctx context.Context) error) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "save_conversation", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	tx, err := ms.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := ms.saveConversation(ctx, tx, conv); err != nil {
+		return err
+	}
+
+	if beforeCommit != nil {
+		if err := beforeCommit(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit conversation: %w", err)
+	}
+
+	return nil
+}
+
+// SaveConversationWithJob saves a conversation and its messages and enqueues a work queue item in
+// the same transaction, returning the item's ID
+func (ms *MySQLStore) SaveConversationWithJob(ctx context.Context, conv *models.Conversation, kind string, payload interface{}, availableAt time.Time) (int64, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "save_conversation", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal queue payload: %w", err)
+	}
+
+	tx, err := ms.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := ms.saveConversation(ctx, tx, conv); err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO work_queue (kind, payload, last_error, available_at, created_at)
+		VALUES (?, ?, '', ?, ?)
+	`
+
+	result, err := tx.ExecContext(ctx, query, kind, string(data), availableAt, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue %s item: %w", kind, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue %s item: %w", kind, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit conversation: %w", err)
+	}
+
+	return id, nil
+}
+
+// saveConversation upserts a conversation row and replaces its messages
+func (ms *MySQLStore) saveConversation(ctx context.Context, tx *sql.Tx, conv *models.Conversation) error {
+	question, err := encryptContent(ctx, ms.cipher, conv.Question)
+	if err != nil {
+		return err
+	}
+	answer, err := encryptContent(ctx, ms.cipher, conv.Answer)
+	if err != nil {
+		return err
+	}
+
+	unembeddedAt, unembeddedReason, unembeddedDetail := unembeddedArgs(conv.Unembedded)
+	query := `
+		INSERT INTO conversations (id, user_id, session_id, question, answer, metadata, created_at, updated_at, importance, content_hash,
+			unembedded_at, unembedded_reason, unembedded_detail, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			session_id = VALUES(session_id),
+			question = VALUES(question),
+			answer = VALUES(answer),
+			metadata = VALUES(metadata),
+			updated_at = VALUES(updated_at),
+			importance = VALUES(importance),
+			content_hash = VALUES(content_hash),
+			unembedded_at = VALUES(unembedded_at),
+			unembedded_reason = VALUES(unembedded_reason),
+			unembedded_detail = VALUES(unembedded_detail),
+			status = VALUES(status)
+	`
+
+	_, err = tx.ExecContext(
+		ctx,
+		query,
+		conv.ID,
+		conv.UserID,
+		nullString(conv.SessionID),
+		question,
+		answer,
+		conv.Metadata,
+		conv.CreatedAt,
+		conv.UpdatedAt,
+		conv.Importance,
+		conv.ContentHash,
+		unembeddedAt,
+		unembeddedReason,
+		unembeddedDetail,
+		conversationStatus(conv),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to save conversation: %w", err)
+	}
+
+	return ms.saveMessages(ctx, tx, conv.ID, conv.Messages)
+}
+
+// saveMessages replaces the stored messages of a conversation
+func (ms *MySQLStore) saveMessages(ctx context.Context, tx *sql.Tx, conversationID string, messages []models.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM messages WHERE conversation_id = ?`, conversationID); err != nil {
+		return fmt.Errorf("failed to replace messages: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO messages (conversation_id, message_id, position, role, content, speaker, display_name, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare message insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, msg := range messages {
+		createdAt := time.Now()
+		if msg.Timestamp != nil {
+			createdAt = *msg.Timestamp
+		}
+
+		content, err := encryptContent(ctx, ms.cipher, msg.Content)
+		if err != nil {
+			return err
+		}
+
+		_, err = stmt.ExecContext(
+			ctx,
+			conversationID,
+			msg.MessageID,
+			i,
+			msg.Role,
+			content,
+			nullString(msg.Speaker),
+			nullString(msg.DisplayName),
+			createdAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save message %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// getMessages loads the messages of the given conversations keyed by conversation ID
+func (ms *MySQLStore) getMessages(ctx context.Context, conversationIDs []string) (map[string][]models.Message, error) {
+	placeholders, args := mysqlIn(conversationIDs)
+	query := `
+		SELECT conversation_id, message_id, role, content, speaker, display_name, created_at
+		FROM messages
+		WHERE conversation_id IN (` + placeholders + `)
+		ORDER BY conversation_id, position
+	`
+
+	rows, err := ms.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make(map[string][]models.Message)
+	for rows.Next() {
+		var (
+			conversationID string
+			msg            models.Message
+			speaker        sql.NullString
+			displayName    sql.NullString
+			createdAt      time.Time
+		)
+		if err := rows.Scan(&conversationID, &msg.MessageID, &msg.Role, &msg.Content, &speaker, &displayName, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		if msg.Content, err = decryptContent(ctx, ms.cipher, msg.Content); err != nil {
+			return nil, err
+		}
+		msg.Speaker = speaker.String
+		msg.DisplayName = displayName.String
+		msg.Timestamp = &createdAt
+		messages[conversationID] = append(messages[conversationID], msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// mysqlIn returns a placeholder list and its arguments for an IN clause, in place of Postgres'
+// ANY($1); values must not be empty
+func mysqlIn(values []string) (string, []interface{}) {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", "), args
+}
+
+// mysqlInserted reports whether an INSERT added its row, false if the key exists, in place of
+// Postgres' ON CONFLICT DO NOTHING
+func mysqlInserted(_ sql.Result, err error) (bool, error) {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDupEntry {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetConversation retrieves a conversation by ID from MySQL
+func (ms *MySQLStore) GetConversation(ctx context.Context, id string) (*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_conversation", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE id = ?
+	`
+
+	conv, err := scanConversation(ms.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if err := decryptConversation(ctx, ms.cipher, conv); err != nil {
+		return nil, err
+	}
+
+	messages, err := ms.getMessages(ctx, []string{conv.ID})
+	if err != nil {
+		return nil, err
+	}
+	conv.Messages = messages[conv.ID]
+
+	return conv, nil
+}
+
+// GetConversationsByIDs retrieves conversations in the order of ids, each once; IDs with no stored
+// conversation are returned as missing
+func (ms *MySQLStore) GetConversationsByIDs(ctx context.Context, ids []string) ([]*models.Conversation, []string, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_conversations_by_ids", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	if len(ids) == 0 {
+		return []*models.Conversation{}, []string{}, nil
+	}
+
+	placeholders, args := mysqlIn(ids)
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE id IN (` + placeholders + `)
+	`
+
+	found, err := ms.queryConversations(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byID := make(map[string]*models.Conversation, len(found))
+	for _, conv := range found {
+		byID[conv.ID] = conv
+	}
+
+	conversations := make([]*models.Conversation, 0, len(found))
+	missing := []string{}
+	for _, id := range ids {
+		conv, ok := byID[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		if conv == nil {
+			// Listed twice; already added
+			continue
+		}
+		conversations = append(conversations, conv)
+		byID[id] = nil
+	}
+
+	return conversations, missing, nil
+}
+
+// GetConversationsByUser retrieves all of a user's conversations from MySQL
+func (ms *MySQLStore) GetConversationsByUser(ctx context.Context, userID string) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_conversations_by_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+	`
+
+	return ms.queryConversations(ctx, query, userID)
+}
+
+// GetPinnedConversations retrieves a user's pinned, unsuppressed conversations, newest first
+func (ms *MySQLStore) GetPinnedConversations(ctx context.Context, userID string) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_pinned_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = ? AND pinned AND suppressed_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	return ms.queryConversations(ctx, query, userID)
+}
+
+// GetSuppressedConversations retrieves a user's suppressed conversations, most recently suppressed first
+func (ms *MySQLStore) GetSuppressedConversations(ctx context.Context, userID string) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_suppressed_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = ? AND suppressed_at IS NOT NULL
+		ORDER BY suppressed_at DESC
+	`
+
+	return ms.queryConversations(ctx, query, userID)
+}
+
+// GetTopConversationsByUser retrieves a user's unsuppressed conversations ordered by an integer
+// conversation_score in their metadata, then recency
+func (ms *MySQLStore) GetTopConversationsByUser(ctx context.Context, userID string, limit int) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_top_conversations_by_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = ? AND suppressed_at IS NULL
+		ORDER BY
+			CASE WHEN JSON_UNQUOTE(JSON_EXTRACT(metadata, '$.conversation_score')) REGEXP '^-?[0-9]+$'
+				THEN CAST(JSON_UNQUOTE(JSON_EXTRACT(metadata, '$.conversation_score')) AS SIGNED) END IS NULL,
+			CASE WHEN JSON_UNQUOTE(JSON_EXTRACT(metadata, '$.conversation_score')) REGEXP '^-?[0-9]+$'
+				THEN CAST(JSON_UNQUOTE(JSON_EXTRACT(metadata, '$.conversation_score')) AS SIGNED) END DESC,
+			updated_at DESC
+		LIMIT ?
+	`
+
+	return ms.queryConversations(ctx, query, userID, limit)
+}
+
+// GetSessionConversations retrieves a session's conversations in chronological order
+func (ms *MySQLStore) GetSessionConversations(ctx context.Context, sessionID string) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_session_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE session_id = ?
+		ORDER BY created_at ASC
+	`
+
+	return ms.queryConversations(ctx, query, sessionID)
+}
+
+// queryConversations runs a conversation query and attaches each conversation's messages
+func (ms *MySQLStore) queryConversations(ctx context.Context, query string, args ...interface{}) ([]*models.Conversation, error) {
+	rows, err := ms.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conversations: %w", err)
+	}
+	defer rows.Close()
+
+	conversations := []*models.Conversation{}
+	var ids []string
+	for rows.Next() {
+		conv, err := scanConversation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan conversation: %w", err)
+		}
+		if err := decryptConversation(ctx, ms.cipher, conv); err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, conv)
+		ids = append(ids, conv.ID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating conversations: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return conversations, nil
+	}
+
+	messages, err := ms.getMessages(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, conv := range conversations {
+		conv.Messages = messages[conv.ID]
+	}
+
+	return conversations, nil
+}
+
+// DeleteConversation deletes a conversation and its messages
+func (ms *MySQLStore) DeleteConversation(ctx context.Context, id string) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "delete_conversation", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	if _, err := ms.db.ExecContext(ctx, `DELETE FROM conversations WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+
+	return nil
+}
+
+// ListForgettableConversations returns unpinned conversations last updated before the given time
+// whose importance, halved every halfLife since the last update, has fallen below the threshold.
+// Conversations of users exempt from retention are never forgettable
+func (ms *MySQLStore) ListForgettableConversations(ctx context.Context, threshold float64, halfLife time.Duration, before time.Time, limit int, offset int) ([]string, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "list_forgettable_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT id FROM conversations
+		WHERE updated_at < ?
+			AND NOT pinned
+			AND user_id NOT IN (SELECT id FROM users WHERE retention_exempt)
+			AND CASE WHEN ? > 0
+				THEN importance * POW(0.5, TIMESTAMPDIFF(MICROSECOND, updated_at, UTC_TIMESTAMP(6)) / 1e6 / ?)
+				ELSE importance END < ?
+		ORDER BY updated_at ASC, id ASC
+		LIMIT ? OFFSET ?
+	`
+
+	seconds := halfLife.Seconds()
+	rows, err := ms.db.QueryContext(ctx, query, before, seconds, seconds, threshold, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query forgettable conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating forgettable conversations: %w", err)
+	}
+
+	return ids, nil
+}
+
+// SetConversationPinned pins or unpins a conversation; it reports false if the conversation doesn't exist
+func (ms *MySQLStore) SetConversationPinned(ctx context.Context, id string, pinned bool) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "set_conversation_pinned", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	result, err := ms.db.ExecContext(ctx, `UPDATE conversations SET pinned = ? WHERE id = ?`, pinned, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to pin conversation: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// SetConversationSuppression suppresses a conversation, or restores it when suppression is nil;
+// it reports false if the conversation doesn't exist
+func (ms *MySQLStore) SetConversationSuppression(ctx context.Context, id string, suppression *models.Suppression) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "set_conversation_suppression", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	at, reason, note := suppressionArgs(suppression)
+	query := `
+		UPDATE conversations
+		SET suppressed_at = ?, suppression_reason = ?, suppression_note = ?
+		WHERE id = ?
+	`
+
+	result, err := ms.db.ExecContext(ctx, query, at, reason, note, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to update conversation suppression: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// ListConversationIDs returns up to limit conversation IDs greater than afterID in ID order,
+// optionally of one user
+func (ms *MySQLStore) ListConversationIDs(ctx context.Context, userID string, afterID string, limit int) ([]string, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "list_conversation_ids", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT id FROM conversations
+		WHERE id > ? AND (? = '' OR user_id = ?)
+		ORDER BY id
+		LIMIT ?
+	`
+
+	rows, err := ms.db.QueryContext(ctx, query, afterID, userID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversation IDs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating conversation IDs: %w", err)
+	}
+
+	return ids, nil
+}
+
+// SetConversationContentHash records the hash of the text last embedded for a conversation
+func (ms *MySQLStore) SetConversationContentHash(ctx context.Context, id string, contentHash string) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "set_conversation_content_hash", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	if _, err := ms.db.ExecContext(ctx, `UPDATE conversations SET content_hash = ? WHERE id = ?`, contentHash, id); err != nil {
+		return fmt.Errorf("failed to set conversation content hash: %w", err)
+	}
+	return nil
+}
+
+// SetConversationMetadata replaces a conversation's metadata JSON; it reports false if the
+// conversation doesn't exist
+func (ms *MySQLStore) SetConversationMetadata(ctx context.Context, id string, metadata string) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "set_conversation_metadata", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	result, err := ms.db.ExecContext(ctx, `UPDATE conversations SET metadata = ? WHERE id = ?`, metadata, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to set conversation metadata: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// SetConversationUnembedded marks a conversation whose text the embedding provider refused, or
+// clears the mark when unembedded is nil; it reports false if the conversation doesn't exist
+func (ms *MySQLStore) SetConversationUnembedded(ctx context.Context, id string, unembedded *models.Unembedded) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "set_conversation_unembedded", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	at, reason, detail := unembeddedArgs(unembedded)
+	query := `
+		UPDATE conversations
+		SET unembedded_at = ?, unembedded_reason = ?, unembedded_detail = ?
+		WHERE id = ?
+	`
+
+	result, err := ms.db.ExecContext(ctx, query, at, reason, detail, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to update conversation embedding status: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// ListUnembeddedConversations retrieves a page of conversations the embedding provider refused,
+// most recently refused first, optionally of one user, and their total count
+func (ms *MySQLStore) ListUnembeddedConversations(ctx context.Context, userID string, limit int, offset int) ([]*models.Conversation, int, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "list_unembedded_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM conversations WHERE unembedded_at IS NOT NULL AND (? = '' OR user_id = ?)`
+	if err := ms.db.QueryRowContext(ctx, countQuery, userID, userID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count unembedded conversations: %w", err)
+	}
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE unembedded_at IS NOT NULL AND (? = '' OR user_id = ?)
+		ORDER BY unembedded_at DESC, id
+		LIMIT ? OFFSET ?
+	`
+
+	conversations, err := ms.queryConversations(ctx, query, userID, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return conversations, total, nil
+}
+
+// SetConversationStatus moves a conversation to status if its current status is one of from; it
+// reports false if the conversation doesn't exist or is in another status
+func (ms *MySQLStore) SetConversationStatus(ctx context.Context, id string, status string, from []string) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "set_conversation_status", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	if len(from) == 0 {
+		return false, nil
+	}
+	placeholders, fromArgs := mysqlIn(from)
+	query := `UPDATE conversations SET status = ? WHERE id = ? AND status IN (` + placeholders + `)`
+
+	result, err := ms.db.ExecContext(ctx, query, append([]interface{}{status, id}, fromArgs...)...)
+	if err != nil {
+		return false, fmt.Errorf("failed to update conversation status: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// ListUserConversations retrieves a page of a user's conversations, newest first, optionally in
+// one status, and their total count
+func (ms *MySQLStore) ListUserConversations(ctx context.Context, userID string, status string, limit int, offset int) ([]*models.Conversation, int, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "list_user_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	// The unfiltered total is the user's maintained stats rather than a count of their rows
+	countQuery, countArgs := `SELECT COALESCE((SELECT conversation_count FROM user_stats WHERE user_id = ?), 0)`, []interface{}{userID}
+	if status != "" {
+		countQuery, countArgs = `SELECT COUNT(*) FROM conversations WHERE user_id = ? AND status = ?`, []interface{}{userID, status}
+	}
+	var total int
+	if err := ms.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count conversations: %w", err)
+	}
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = ? AND (? = '' OR status = ?)
+		ORDER BY created_at DESC, id
+		LIMIT ? OFFSET ?
+	`
+
+	conversations, err := ms.queryConversations(ctx, query, userID, status, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return conversations, total, nil
+}
+
+// ListUserConversationsBefore retrieves up to limit of a user's conversations created before a
+// time, newest first
+func (ms *MySQLStore) ListUserConversationsBefore(ctx context.Context, userID string, before time.Time, limit int) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "list_user_conversations_before", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = ? AND created_at < ?
+		ORDER BY created_at DESC, id
+		LIMIT ?
+	`
+
+	return ms.queryConversations(ctx, query, userID, before.UTC(), limit)
+}
+
+// CountUserConversationsBetween counts a user's conversations created at or after from and
+// before to
+func (ms *MySQLStore) CountUserConversationsBetween(ctx context.Context, userID string, from time.Time, to time.Time) (int, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "count_user_conversations_between", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `SELECT COUNT(*) FROM conversations WHERE user_id = ? AND created_at >= ? AND created_at < ?`
+
+	var count int
+	if err := ms.db.QueryRowContext(ctx, query, userID, from.UTC(), to.UTC()).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count conversations: %w", err)
+	}
+	return count, nil
+}
+
+// Close closes the database
+func (ms *MySQLStore) Close() error {
+	return ms.db.Close()
+}
+
+// GetDB returns the database connection
+func (ms *MySQLStore) GetDB() *sql.DB {
+	return ms.db
+}
+
+// Ping checks the database connection
+func (ms *MySQLStore) Ping(ctx context.Context) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "ping", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	return ms.db.PingContext(ctx)
+}
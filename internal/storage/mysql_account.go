@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// CreateUser registers a user; it reports false if the user is already registered
+func (ms *MySQLStore) CreateUser(ctx context.Context, user *models.User) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "create_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		INSERT INTO users (id, display_name, status, retention_exempt, region, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	created, err := mysqlInserted(ms.db.ExecContext(ctx, query, user.ID, user.DisplayName, user.Status, user.RetentionExempt, user.Region, user.CreatedAt, user.UpdatedAt))
+	if err != nil {
+		return false, fmt.Errorf("failed to create user: %w", err)
+	}
+	return created, nil
+}
+
+// GetUser retrieves a registered user, or nil if the user isn't registered
+func (ms *MySQLStore) GetUser(ctx context.Context, id string) (*models.User, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	user, err := scanUser(ms.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// ListUsers retrieves a page of registered users in ID order, optionally of one status
+func (ms *MySQLStore) ListUsers(ctx context.Context, status string, limit int, offset int) ([]*models.User, int, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "list_users", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	var total int
+	if err := ms.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE (? = '' OR status = ?)`, status, status).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE (? = '' OR status = ?) ORDER BY id LIMIT ? OFFSET ?`
+	rows, err := ms.db.QueryContext(ctx, query, status, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return users, total, nil
+}
+
+// UpdateUser saves a registered user's display name, retention exemption and region; it reports
+// false if the user isn't registered
+func (ms *MySQLStore) UpdateUser(ctx context.Context, user *models.User) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "update_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `UPDATE users SET display_name = ?, retention_exempt = ?, region = ?, updated_at = ? WHERE id = ?`
+	result, err := ms.db.ExecContext(ctx, query, user.DisplayName, user.RetentionExempt, user.Region, user.UpdatedAt, user.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update user: %w", err)
+	}
+	return rowsAffected(result)
+}
+
+// DisableUser disables a user, registering it first if needed, and returns the stored record.
+// MySQL has no RETURNING, so the record is read back in the same transaction
+func (ms *MySQLStore) DisableUser(ctx context.Context, id string, reason string, at time.Time) (*models.User, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "disable_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	tx, err := ms.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO users (id, status, disabled_reason, disabled_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			status = VALUES(status),
+			disabled_reason = VALUES(disabled_reason),
+			disabled_at = VALUES(disabled_at),
+			updated_at = VALUES(updated_at)
+	`
+	if _, err := tx.ExecContext(ctx, query, id, models.UserStatusDisabled, reason, at, at, at); err != nil {
+		return nil, fmt.Errorf("failed to disable user: %w", err)
+	}
+
+	user, err := scanUser(tx.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read disabled user: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return user, nil
+}
+
+// EnableUser re-enables a registered user and returns the stored record, or nil if the user
+// isn't registered
+func (ms *MySQLStore) EnableUser(ctx context.Context, id string, at time.Time) (*models.User, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "enable_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	tx, err := ms.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE users SET status = ?, disabled_reason = '', disabled_at = NULL, updated_at = ? WHERE id = ?`
+	result, err := tx.ExecContext(ctx, query, models.UserStatusActive, at, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable user: %w", err)
+	}
+	if found, err := rowsAffected(result); err != nil || !found {
+		return nil, err
+	}
+
+	user, err := scanUser(tx.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read enabled user: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return user, nil
+}
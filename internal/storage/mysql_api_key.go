@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// CreateAPIKey stores a new API key under the hash of its secret
+func (ms *MySQLStore) CreateAPIKey(ctx context.Context, key *models.APIKey, secretHash string) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "create_api_key", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	if err := mysqlInsertAPIKey(ctx, ms.db, key, secretHash); err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+	return nil
+}
+
+// GetAPIKey retrieves an API key, or nil if it doesn't exist
+func (ms *MySQLStore) GetAPIKey(ctx context.Context, id string) (*models.APIKey, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_api_key", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	key, err := scanAPIKey(ms.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys retrieves API keys newest first, leaving out revoked keys unless includeRevoked is set
+func (ms *MySQLStore) ListAPIKeys(ctx context.Context, includeRevoked bool) ([]*models.APIKey, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "list_api_keys", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE (? OR revoked_at IS NULL) ORDER BY created_at DESC`
+	rows, err := ms.db.QueryContext(ctx, query, includeRevoked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// LoadAPIKeys retrieves the keys that are neither revoked nor expired at now, keyed by secret hash
+func (ms *MySQLStore) LoadAPIKeys(ctx context.Context, now time.Time) (map[string]*models.APIKey, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "load_api_keys", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT ` + apiKeyColumns + `, secret_hash FROM api_keys
+		WHERE (revoked_at IS NULL OR revoked_at > ?) AND (expires_at IS NULL OR expires_at > ?)
+	`
+	rows, err := ms.db.QueryContext(ctx, query, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[string]*models.APIKey)
+	for rows.Next() {
+		var hash string
+		key, err := scanAPIKey(rows, &hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys[hash] = key
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RotateAPIKey stores replacement as the successor of the key id and makes the old key expire at
+// oldExpiresAt, unless it expires sooner. It returns the updated old key, or nil if it doesn't
+// exist or is already revoked or rotated. The old key is locked while it is checked and updated,
+// so concurrent rotations of one key can't both succeed
+func (ms *MySQLStore) RotateAPIKey(ctx context.Context, id string, replacement *models.APIKey, secretHash string, oldExpiresAt time.Time) (*models.APIKey, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "rotate_api_key", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	tx, err := ms.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked string
+	err = tx.QueryRowContext(ctx, `
+		SELECT id FROM api_keys
+		WHERE id = ? AND revoked_at IS NULL AND rotated_to = ''
+		FOR UPDATE
+	`, id).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate API key: %w", err)
+	}
+
+	query := `
+		UPDATE api_keys SET
+			expires_at = LEAST(COALESCE(expires_at, ?), ?),
+			rotated_to = ?
+		WHERE id = ?
+	`
+	if _, err := tx.ExecContext(ctx, query, oldExpiresAt, oldExpiresAt, replacement.ID, id); err != nil {
+		return nil, fmt.Errorf("failed to rotate API key: %w", err)
+	}
+
+	old, err := scanAPIKey(tx.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rotated API key: %w", err)
+	}
+
+	if err := mysqlInsertAPIKey(ctx, tx, replacement, secretHash); err != nil {
+		return nil, fmt.Errorf("failed to create replacement API key: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit API key rotation: %w", err)
+	}
+	return old, nil
+}
+
+// RevokeAPIKey revokes an API key at the given time and returns it, or nil if it doesn't exist.
+// Revoking a revoked key keeps its original revocation time
+func (ms *MySQLStore) RevokeAPIKey(ctx context.Context, id string, at time.Time) (*models.APIKey, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "revoke_api_key", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	tx, err := ms.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ?`, at, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if found, err := rowsAffected(result); err != nil || !found {
+		return nil, err
+	}
+
+	key, err := scanAPIKey(tx.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revoked API key: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return key, nil
+}
+
+// mysqlInsertAPIKey inserts an API key like insertAPIKey, with MySQL parameters
+func mysqlInsertAPIKey(ctx context.Context, db execer, key *models.APIKey, secretHash string) error {
+	query := `
+		INSERT INTO api_keys (id, name, prefix, secret_hash, scopes, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	var expiresAt sql.NullTime
+	if key.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *key.ExpiresAt, Valid: true}
+	}
+	_, err := db.ExecContext(ctx, query, key.ID, key.Name, key.Prefix, secretHash, strings.Join(key.Scopes, ","), key.CreatedAt, expiresAt)
+	return err
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// ListDataKeys retrieves the data keys of a tenant, or of all tenants when tenant is empty,
+// ordered by tenant and version
+func (ms *MySQLStore) ListDataKeys(ctx context.Context, tenant string) ([]*models.DataKey, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "list_data_keys", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT tenant, version, master_key_id, created_at, wrapped_key
+		FROM tenant_data_keys
+		WHERE (? = '' OR tenant = ?)
+		ORDER BY tenant, version
+	`
+	rows, err := ms.db.QueryContext(ctx, query, tenant, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query data keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*models.DataKey{}
+	for rows.Next() {
+		key := &models.DataKey{}
+		if err := rows.Scan(&key.Tenant, &key.Version, &key.MasterKeyID, &key.CreatedAt, &key.WrappedKey); err != nil {
+			return nil, fmt.Errorf("failed to scan data key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating data keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// CreateDataKey stores a new data key version; it reports false if the version exists
+func (ms *MySQLStore) CreateDataKey(ctx context.Context, key *models.DataKey) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "create_data_key", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		INSERT INTO tenant_data_keys (tenant, version, master_key_id, created_at, wrapped_key)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	created, err := mysqlInserted(ms.db.ExecContext(ctx, query, key.Tenant, key.Version, key.MasterKeyID, key.CreatedAt, key.WrappedKey))
+	if err != nil {
+		return false, fmt.Errorf("failed to create data key: %w", err)
+	}
+	return created, nil
+}
+
+// RewrapDataKey replaces a data key's wrapping if it is still wrapped by previousMasterKeyID
+func (ms *MySQLStore) RewrapDataKey(ctx context.Context, key *models.DataKey, previousMasterKeyID string) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "rewrap_data_key", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		UPDATE tenant_data_keys SET wrapped_key = ?, master_key_id = ?
+		WHERE tenant = ? AND version = ? AND master_key_id = ?
+	`
+	result, err := ms.db.ExecContext(ctx, query, key.WrappedKey, key.MasterKeyID, key.Tenant, key.Version, previousMasterKeyID)
+	if err != nil {
+		return false, fmt.Errorf("failed to rewrap data key: %w", err)
+	}
+	return rowsAffected(result)
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// CreateJob records a started job
+func (ms *MySQLStore) CreateJob(ctx context.Context, job *models.Job) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "create_job", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		INSERT INTO admin_jobs (id, kind, target, dry_run, status, error, started_at)
+		VALUES (?, ?, ?, ?, ?, '', ?)
+	`
+
+	if _, err := ms.db.ExecContext(ctx, query, job.ID, job.Kind, job.Target, job.DryRun, job.Status, job.StartedAt.UTC()); err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return nil
+}
+
+// FinishJob records a job's final status and result
+func (ms *MySQLStore) FinishJob(ctx context.Context, job *models.Job) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "finish_job", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	var result interface{}
+	if len(job.Result) > 0 {
+		result = string(job.Result)
+	}
+	var finishedAt interface{}
+	if job.FinishedAt != nil {
+		finishedAt = job.FinishedAt.UTC()
+	}
+
+	query := `
+		UPDATE admin_jobs
+		SET status = ?, result = ?, error = ?, finished_at = ?
+		WHERE id = ?
+	`
+
+	if _, err := ms.db.ExecContext(ctx, query, job.Status, result, job.Error, finishedAt, job.ID); err != nil {
+		return fmt.Errorf("failed to finish job: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateJobProgress replaces the partial result of a running job
+func (ms *MySQLStore) UpdateJobProgress(ctx context.Context, id string, result []byte) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "update_job_progress", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		UPDATE admin_jobs
+		SET result = ?
+		WHERE id = ? AND status = ?
+	`
+
+	if _, err := ms.db.ExecContext(ctx, query, string(result), id, models.JobStatusRunning); err != nil {
+		return fmt.Errorf("failed to update job progress: %w", err)
+	}
+
+	return nil
+}
+
+// GetJob retrieves a job by ID
+func (ms *MySQLStore) GetJob(ctx context.Context, id string) (*models.Job, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_job", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `SELECT ` + jobColumns + ` FROM admin_jobs WHERE id = ?`
+
+	job, err := scanJob(ms.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return job, nil
+}
+
+// ListJobs retrieves the most recent jobs, optionally of one kind
+func (ms *MySQLStore) ListJobs(ctx context.Context, kind string, limit int) ([]*models.Job, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "list_jobs", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT ` + jobColumns + `
+		FROM admin_jobs
+		WHERE ? = '' OR kind = ?
+		ORDER BY started_at DESC
+		LIMIT ?
+	`
+
+	rows, err := ms.db.QueryContext(ctx, query, kind, kind, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []*models.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating jobs: %w", err)
+	}
+
+	return jobs, nil
+}
@@ -0,0 +1,361 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlMigrations are the schema changes of the MySQL/MariaDB store, in order. The
+// schema_migrations table records which have been applied, so append new migrations and never
+// edit one that has shipped. MySQL commits each DDL statement on its own, so a migration that
+// fails halfway is resumed statement by statement: every statement must be safe to rerun. MySQL
+// has no IF NOT EXISTS for columns and indexes, so adding one that already exists is skipped
+// instead. The tables mirror the Postgres layout
+var mysqlMigrations = []string{
+	// 1: conversations, messages, personal info and the work queue
+	`
+	CREATE TABLE IF NOT EXISTS conversations (
+		id VARCHAR(36) PRIMARY KEY,
+		user_id VARCHAR(255) NOT NULL,
+		session_id VARCHAR(64),
+		question MEDIUMTEXT NOT NULL,
+		answer MEDIUMTEXT,
+		metadata JSON,
+		created_at DATETIME(6) NOT NULL,
+		updated_at DATETIME(6) NOT NULL,
+		importance DOUBLE NOT NULL DEFAULT 0.5,
+		pinned BOOLEAN NOT NULL DEFAULT FALSE,
+		suppressed_at DATETIME(6),
+		suppression_reason VARCHAR(20),
+		suppression_note TEXT,
+		content_hash VARCHAR(64) NOT NULL DEFAULT '',
+		INDEX idx_conversations_user_created (user_id, created_at),
+		INDEX idx_conversations_session (session_id, created_at),
+		INDEX idx_conversations_updated_at (updated_at)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+	CREATE TABLE IF NOT EXISTS messages (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		conversation_id VARCHAR(36) NOT NULL,
+		message_id VARCHAR(255) NOT NULL,
+		position INT NOT NULL,
+		role VARCHAR(20) NOT NULL,
+		content MEDIUMTEXT NOT NULL,
+		speaker VARCHAR(255),
+		display_name VARCHAR(255),
+		created_at DATETIME(6) NOT NULL,
+		UNIQUE KEY uq_messages_position (conversation_id, position),
+		UNIQUE KEY uq_messages_message_id (conversation_id, message_id),
+		CONSTRAINT fk_messages_conversation FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+	CREATE TABLE IF NOT EXISTS personal_info (
+		id VARCHAR(36) PRIMARY KEY,
+		user_id VARCHAR(255) NOT NULL,
+		content TEXT NOT NULL,
+		category VARCHAR(50) NOT NULL,
+		importance VARCHAR(20) NOT NULL,
+		pinned BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at DATETIME(6) NOT NULL,
+		updated_at DATETIME(6) NOT NULL,
+		suppressed_at DATETIME(6),
+		suppression_reason VARCHAR(20),
+		suppression_note TEXT,
+		INDEX idx_personal_info_user_created (user_id, created_at)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+	CREATE TABLE IF NOT EXISTS work_queue (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		kind VARCHAR(64) NOT NULL,
+		payload JSON NOT NULL,
+		attempts INT NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL,
+		available_at DATETIME(6) NOT NULL,
+		lease_owner VARCHAR(255),
+		lease_expires_at DATETIME(6),
+		created_at DATETIME(6) NOT NULL,
+		INDEX idx_work_queue_kind_available_at (kind, available_at)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+	`,
+
+	// 2: conversations stored without a vector because the embedding provider refused their text
+	`
+	ALTER TABLE conversations
+		ADD COLUMN unembedded_at DATETIME(6),
+		ADD COLUMN unembedded_reason VARCHAR(20),
+		ADD COLUMN unembedded_detail TEXT;
+
+	CREATE INDEX idx_conversations_unembedded_at ON conversations(unembedded_at);
+	`,
+
+	// 3: whether each conversation's vector is written
+	`
+	ALTER TABLE conversations ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'indexed';
+
+	UPDATE conversations SET status = 'failed' WHERE unembedded_at IS NOT NULL AND status = 'indexed';
+
+	CREATE INDEX idx_conversations_user_status ON conversations(user_id, status, created_at);
+	`,
+
+	// 4: per-user conversation aggregates, kept by triggers like the Postgres table. The backfill
+	// overwrites the counts, so it is right however far an earlier run got
+	`
+	CREATE TABLE IF NOT EXISTS user_stats (
+		user_id VARCHAR(255) PRIMARY KEY,
+		conversation_count BIGINT NOT NULL DEFAULT 0,
+		last_conversation_at DATETIME(6),
+		total_tokens BIGINT NOT NULL DEFAULT 0
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+	DROP TRIGGER IF EXISTS conversations_user_stats_insert;
+	CREATE TRIGGER conversations_user_stats_insert AFTER INSERT ON conversations FOR EACH ROW
+		INSERT INTO user_stats (user_id, conversation_count, last_conversation_at)
+		VALUES (NEW.user_id, 1, NEW.created_at)
+		ON DUPLICATE KEY UPDATE
+			conversation_count = conversation_count + 1,
+			last_conversation_at = GREATEST(COALESCE(last_conversation_at, NEW.created_at), NEW.created_at);
+
+	DROP TRIGGER IF EXISTS conversations_user_stats_delete;
+	CREATE TRIGGER conversations_user_stats_delete AFTER DELETE ON conversations FOR EACH ROW
+		UPDATE user_stats SET conversation_count = GREATEST(conversation_count - 1, 0) WHERE user_id = OLD.user_id;
+
+	INSERT INTO user_stats (user_id, conversation_count, last_conversation_at)
+	SELECT user_id, COUNT(*), MAX(created_at) FROM conversations GROUP BY user_id
+	ON DUPLICATE KEY UPDATE
+		conversation_count = VALUES(conversation_count),
+		last_conversation_at = VALUES(last_conversation_at);
+	`,
+
+	// 5: old conversation IDs that resolve to the conversation that replaced them
+	`
+	CREATE TABLE IF NOT EXISTS id_aliases (
+		alias_id VARCHAR(36) PRIMARY KEY,
+		conversation_id VARCHAR(36) NOT NULL,
+		reason VARCHAR(20) NOT NULL,
+		created_at DATETIME(6) NOT NULL,
+		INDEX idx_id_aliases_conversation_id (conversation_id)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+	`,
+
+	// 6: the rest of the relational surface, so the server runs on MySQL without a Postgres server
+	`
+	CREATE TABLE IF NOT EXISTS sessions (
+		id VARCHAR(64) PRIMARY KEY,
+		user_id VARCHAR(255) NOT NULL,
+		title TEXT,
+		status VARCHAR(20) NOT NULL DEFAULT 'open',
+		summary MEDIUMTEXT,
+		summary_updated_at DATETIME(6),
+		created_at DATETIME(6) NOT NULL,
+		updated_at DATETIME(6) NOT NULL,
+		closed_at DATETIME(6),
+		INDEX idx_sessions_user_created (user_id, created_at)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+	CREATE TABLE IF NOT EXISTS user_profiles (
+		user_id VARCHAR(255) PRIMARY KEY,
+		profile JSON NOT NULL,
+		generated_at DATETIME(6) NOT NULL,
+		INDEX idx_user_profiles_generated_at (generated_at)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+	CREATE TABLE IF NOT EXISTS admin_jobs (
+		id VARCHAR(36) PRIMARY KEY,
+		kind VARCHAR(64) NOT NULL,
+		target VARCHAR(255) NOT NULL DEFAULT '',
+		dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+		status VARCHAR(20) NOT NULL,
+		result JSON,
+		error TEXT NOT NULL,
+		started_at DATETIME(6) NOT NULL,
+		finished_at DATETIME(6),
+		INDEX idx_admin_jobs_kind_started_at (kind, started_at),
+		INDEX idx_admin_jobs_started_at (started_at)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+	CREATE TABLE IF NOT EXISTS dead_letters (
+		id BIGINT PRIMARY KEY,
+		kind VARCHAR(64) NOT NULL,
+		payload JSON NOT NULL,
+		attempts INT NOT NULL,
+		last_error TEXT NOT NULL,
+		created_at DATETIME(6) NOT NULL,
+		failed_at DATETIME(6) NOT NULL,
+		INDEX idx_dead_letters_kind_failed_at (kind, failed_at),
+		INDEX idx_dead_letters_failed_at (failed_at)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+	CREATE TABLE IF NOT EXISTS users (
+		id VARCHAR(255) PRIMARY KEY,
+		display_name VARCHAR(255) NOT NULL DEFAULT '',
+		status VARCHAR(20) NOT NULL DEFAULT 'active',
+		disabled_reason VARCHAR(255) NOT NULL DEFAULT '',
+		disabled_at DATETIME(6),
+		retention_exempt BOOLEAN NOT NULL DEFAULT FALSE,
+		region VARCHAR(64) NOT NULL DEFAULT '',
+		created_at DATETIME(6) NOT NULL,
+		updated_at DATETIME(6) NOT NULL,
+		INDEX idx_users_status (status)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+	CREATE TABLE IF NOT EXISTS embedding_usage (
+		day DATE NOT NULL,
+		tenant VARCHAR(255) NOT NULL DEFAULT '',
+		model VARCHAR(255) NOT NULL,
+		requests BIGINT NOT NULL DEFAULT 0,
+		prompt_tokens BIGINT NOT NULL DEFAULT 0,
+		total_tokens BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (day, tenant, model)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id VARCHAR(36) PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		prefix VARCHAR(32) NOT NULL,
+		secret_hash VARCHAR(128) NOT NULL,
+		scopes VARCHAR(255) NOT NULL,
+		created_at DATETIME(6) NOT NULL,
+		expires_at DATETIME(6),
+		revoked_at DATETIME(6),
+		rotated_to VARCHAR(36) NOT NULL DEFAULT '',
+		UNIQUE KEY uq_api_keys_secret_hash (secret_hash)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+	CREATE TABLE IF NOT EXISTS tenant_data_keys (
+		tenant VARCHAR(255) NOT NULL,
+		version INT NOT NULL,
+		master_key_id VARCHAR(255) NOT NULL,
+		created_at DATETIME(6) NOT NULL,
+		wrapped_key VARBINARY(512) NOT NULL,
+		PRIMARY KEY (tenant, version)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+	CREATE TABLE IF NOT EXISTS search_logs (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		tenant VARCHAR(255) NOT NULL DEFAULT '',
+		user_id VARCHAR(255) NOT NULL DEFAULT '',
+		query MEDIUMTEXT NOT NULL,
+		results INT NOT NULL,
+		top_score DOUBLE NOT NULL DEFAULT 0,
+		duration_ms BIGINT NOT NULL,
+		variant VARCHAR(64) NOT NULL DEFAULT '',
+		created_at DATETIME(6) NOT NULL,
+		INDEX idx_search_logs_created_at (created_at),
+		INDEX idx_search_logs_user_id (user_id)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+
+	CREATE TABLE IF NOT EXISTS standing_queries (
+		id VARCHAR(36) PRIMARY KEY,
+		user_id VARCHAR(255) NOT NULL,
+		query TEXT NOT NULL,
+		filter TEXT NOT NULL,
+		threshold DOUBLE NOT NULL,
+		vector JSON NOT NULL,
+		match_count BIGINT NOT NULL DEFAULT 0,
+		last_matched_at DATETIME(6),
+		created_at DATETIME(6) NOT NULL,
+		INDEX idx_standing_queries_user_id (user_id)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+	`,
+
+	// 7: nonces of signed requests, kept until their signatures expire so every replica rejects
+	// replays
+	`
+	CREATE TABLE IF NOT EXISTS signature_nonces (
+		client_id VARCHAR(255) NOT NULL,
+		nonce VARCHAR(128) NOT NULL,
+		expires_at DATETIME(6) NOT NULL,
+		PRIMARY KEY (client_id, nonce),
+		INDEX idx_signature_nonces_expires_at (expires_at)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
+	`,
+}
+
+// MigrateMySQL applies the MySQL migrations the database hasn't applied yet
+func MigrateMySQL(db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			applied_at DATETIME(6) NOT NULL
+		) ENGINE=InnoDB
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var version int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version > len(mysqlMigrations) {
+		return fmt.Errorf("database schema version %d is newer than this server's %d", version, len(mysqlMigrations))
+	}
+
+	for i := version; i < len(mysqlMigrations); i++ {
+		for _, stmt := range splitStatements(mysqlMigrations[i]) {
+			if _, err := db.ExecContext(ctx, stmt); err != nil && !mysqlAlreadyApplied(err) {
+				return fmt.Errorf("failed to run MySQL migration %d: %w", i+1, err)
+			}
+		}
+		if _, err := db.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, i+1, time.Now()); err != nil {
+			return fmt.Errorf("failed to record MySQL migration %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// PlanMySQLMigrations reports the statements MigrateMySQL would run, without changing the database
+func PlanMySQLMigrations(db *sql.DB) (*MigrationPlan, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// A database the server never migrated has no schema_migrations table and every migration due
+	var version int
+	err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil && !mysqlNoSuchTable(err) {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version > len(mysqlMigrations) {
+		return nil, fmt.Errorf("database schema version %d is newer than this server's %d", version, len(mysqlMigrations))
+	}
+
+	plan := &MigrationPlan{Pending: []string{}, Risks: []MigrationRisk{}}
+	for _, migration := range mysqlMigrations[version:] {
+		plan.Pending = append(plan.Pending, splitStatements(migration)...)
+	}
+	return plan, nil
+}
+
+// mysqlNoSuchTable reports whether a statement failed because a table it reads doesn't exist
+func mysqlNoSuchTable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrNoSuchTable
+}
+
+// mysqlAlreadyApplied reports whether a statement failed because the column or index it adds
+// exists, left by an earlier run of a migration that failed halfway
+func mysqlAlreadyApplied(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	return mysqlErr.Number == mysqlErrDupFieldName || mysqlErr.Number == mysqlErrDupKeyName
+}
+
+// MySQL error numbers of adding a column or an index that exists, of inserting a row whose key
+// exists, and of reading a table that doesn't exist
+const (
+	mysqlErrDupFieldName = 1060
+	mysqlErrDupKeyName   = 1061
+	mysqlErrDupEntry     = 1062
+	mysqlErrNoSuchTable  = 1146
+)
@@ -0,0 +1,361 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// SavePersonalInfo saves a new personal information entry to MySQL
+func (ms *MySQLStore) SavePersonalInfo(ctx context.Context, personalInfo *models.PersonalInfo) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "save_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		INSERT INTO personal_info (id, user_id, content, category, importance, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			content = VALUES(content),
+			category = VALUES(category),
+			importance = VALUES(importance),
+			updated_at = VALUES(updated_at)
+	`
+
+	_, err := ms.db.ExecContext(
+		ctx,
+		query,
+		personalInfo.ID,
+		personalInfo.UserID,
+		personalInfo.Content,
+		personalInfo.Category,
+		personalInfo.Importance,
+		personalInfo.CreatedAt,
+		personalInfo.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to save personal info: %w", err)
+	}
+
+	return nil
+}
+
+// GetPersonalInfo retrieves a personal information entry by ID from MySQL
+func (ms *MySQLStore) GetPersonalInfo(ctx context.Context, id string) (*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT ` + personalInfoColumns + `
+		FROM personal_info
+		WHERE id = ?
+	`
+
+	personalInfo, err := scanPersonalInfo(ms.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get personal info: %w", err)
+	}
+
+	return personalInfo, nil
+}
+
+// GetPersonalInfoByUser retrieves all personal information entries for a user from MySQL
+func (ms *MySQLStore) GetPersonalInfoByUser(ctx context.Context, userID string) ([]*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_personal_info_by_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT ` + personalInfoColumns + `
+		FROM personal_info
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+	`
+
+	return ms.queryPersonalInfo(ctx, query, userID)
+}
+
+// GetPersonalInfoByIDs retrieves personal information entries in the order of ids, skipping those
+// that don't exist
+func (ms *MySQLStore) GetPersonalInfoByIDs(ctx context.Context, ids []string) ([]*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_personal_info_by_ids", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	if len(ids) == 0 {
+		return []*models.PersonalInfo{}, nil
+	}
+
+	placeholders, args := mysqlIn(ids)
+	query := `
+		SELECT ` + personalInfoColumns + `
+		FROM personal_info
+		WHERE id IN (` + placeholders + `)
+	`
+
+	found, err := ms.queryPersonalInfo(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*models.PersonalInfo, len(found))
+	for _, personalInfo := range found {
+		byID[personalInfo.ID] = personalInfo
+	}
+
+	ordered := make([]*models.PersonalInfo, 0, len(found))
+	for _, id := range ids {
+		if personalInfo := byID[id]; personalInfo != nil {
+			ordered = append(ordered, personalInfo)
+			byID[id] = nil
+		}
+	}
+	return ordered, nil
+}
+
+// GetPinnedPersonalInfo retrieves a user's pinned, unsuppressed personal information entries, newest first
+func (ms *MySQLStore) GetPinnedPersonalInfo(ctx context.Context, userID string) ([]*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_pinned_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT ` + personalInfoColumns + `
+		FROM personal_info
+		WHERE user_id = ? AND pinned AND suppressed_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	return ms.queryPersonalInfo(ctx, query, userID)
+}
+
+// GetSuppressedPersonalInfo retrieves a user's suppressed personal information, most recently suppressed first
+func (ms *MySQLStore) GetSuppressedPersonalInfo(ctx context.Context, userID string) ([]*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_suppressed_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT ` + personalInfoColumns + `
+		FROM personal_info
+		WHERE user_id = ? AND suppressed_at IS NOT NULL
+		ORDER BY suppressed_at DESC
+	`
+
+	return ms.queryPersonalInfo(ctx, query, userID)
+}
+
+// ListPersonalInfoAfter retrieves up to limit entries of all users with IDs after afterID, in ID order
+func (ms *MySQLStore) ListPersonalInfoAfter(ctx context.Context, afterID string, limit int) ([]*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "list_personal_info_after", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT ` + personalInfoColumns + `
+		FROM personal_info
+		WHERE id > ?
+		ORDER BY id
+		LIMIT ?
+	`
+
+	return ms.queryPersonalInfo(ctx, query, afterID, limit)
+}
+
+// CountPersonalInfo counts the personal information entries of all users
+func (ms *MySQLStore) CountPersonalInfo(ctx context.Context) (int64, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "count_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	var count int64
+	if err := ms.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM personal_info`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count personal info: %w", err)
+	}
+	return count, nil
+}
+
+// queryPersonalInfo runs a personal info query selecting the standard columns
+func (ms *MySQLStore) queryPersonalInfo(ctx context.Context, query string, args ...interface{}) ([]*models.PersonalInfo, error) {
+	rows, err := ms.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query personal info: %w", err)
+	}
+	defer rows.Close()
+
+	var personalInfoList []*models.PersonalInfo
+	for rows.Next() {
+		personalInfo, err := scanPersonalInfo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan personal info: %w", err)
+		}
+		personalInfoList = append(personalInfoList, personalInfo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating personal info: %w", err)
+	}
+
+	return personalInfoList, nil
+}
+
+// UpdatePersonalInfo updates an existing personal information entry in MySQL
+func (ms *MySQLStore) UpdatePersonalInfo(ctx context.Context, personalInfo *models.PersonalInfo) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "update_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		UPDATE personal_info
+		SET content = ?, category = ?, importance = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	result, err := ms.db.ExecContext(
+		ctx,
+		query,
+		personalInfo.Content,
+		personalInfo.Category,
+		personalInfo.Importance,
+		personalInfo.UpdatedAt,
+		personalInfo.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update personal info: %w", err)
+	}
+
+	found, err := rowsAffected(result)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrPersonalInfoNotFound
+	}
+
+	return nil
+}
+
+// UpdatePersonalInfoIfUnchanged updates an entry only if its updated_at still equals readAt, the
+// value the caller read; otherwise it returns ErrPersonalInfoChanged
+func (ms *MySQLStore) UpdatePersonalInfoIfUnchanged(ctx context.Context, personalInfo *models.PersonalInfo, readAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "update_personal_info_if_unchanged", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		UPDATE personal_info
+		SET content = ?, category = ?, importance = ?, updated_at = ?
+		WHERE id = ? AND updated_at = ?
+	`
+
+	result, err := ms.db.ExecContext(ctx, query,
+		personalInfo.Content,
+		personalInfo.Category,
+		personalInfo.Importance,
+		personalInfo.UpdatedAt,
+		personalInfo.ID,
+		readAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update personal info: %w", err)
+	}
+
+	found, err := rowsAffected(result)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrPersonalInfoChanged
+	}
+	return nil
+}
+
+// SetPersonalInfoPinned pins or unpins a personal information entry; it reports false if the entry doesn't exist
+func (ms *MySQLStore) SetPersonalInfoPinned(ctx context.Context, id string, pinned bool) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "set_personal_info_pinned", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	result, err := ms.db.ExecContext(ctx, `UPDATE personal_info SET pinned = ? WHERE id = ?`, pinned, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to pin personal info: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// SetPersonalInfoSuppression suppresses a personal information entry, or restores it when suppression
+// is nil; it reports false if the entry doesn't exist
+func (ms *MySQLStore) SetPersonalInfoSuppression(ctx context.Context, id string, suppression *models.Suppression) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "set_personal_info_suppression", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	at, reason, note := suppressionArgs(suppression)
+	query := `
+		UPDATE personal_info
+		SET suppressed_at = ?, suppression_reason = ?, suppression_note = ?
+		WHERE id = ?
+	`
+
+	result, err := ms.db.ExecContext(ctx, query, at, reason, note, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to update personal info suppression: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// DeletePersonalInfo deletes a personal information entry from MySQL
+func (ms *MySQLStore) DeletePersonalInfo(ctx context.Context, id string) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "delete_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	result, err := ms.db.ExecContext(ctx, `DELETE FROM personal_info WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete personal info: %w", err)
+	}
+
+	found, err := rowsAffected(result)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrPersonalInfoNotFound
+	}
+
+	return nil
+}
+
+// DeletePersonalInfoIfUnchanged deletes an entry only if its updated_at still equals readAt;
+// otherwise it returns ErrPersonalInfoChanged
+func (ms *MySQLStore) DeletePersonalInfoIfUnchanged(ctx context.Context, id string, readAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "delete_personal_info_if_unchanged", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	result, err := ms.db.ExecContext(ctx, `DELETE FROM personal_info WHERE id = ? AND updated_at = ?`, id, readAt)
+	if err != nil {
+		return fmt.Errorf("failed to delete personal info: %w", err)
+	}
+
+	found, err := rowsAffected(result)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrPersonalInfoChanged
+	}
+	return nil
+}
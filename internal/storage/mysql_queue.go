@@ -0,0 +1,384 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// Enqueue adds an item to the work queue, available immediately
+func (ms *MySQLStore) Enqueue(ctx context.Context, kind string, payload interface{}) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "enqueue", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal queue payload: %w", err)
+	}
+
+	now := time.Now()
+	query := `INSERT INTO work_queue (kind, payload, last_error, available_at, created_at) VALUES (?, ?, '', ?, ?)`
+	if _, err := ms.db.ExecContext(ctx, query, kind, string(data), now, now); err != nil {
+		return fmt.Errorf("failed to enqueue %s item: %w", kind, err)
+	}
+
+	return nil
+}
+
+// DeleteQueueItem removes an item regardless of its lease, e.g. once its work was done inline
+func (ms *MySQLStore) DeleteQueueItem(ctx context.Context, id int64) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "delete_queue_item", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	if _, err := ms.db.ExecContext(ctx, `DELETE FROM work_queue WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete queue item: %w", err)
+	}
+	return nil
+}
+
+// ClaimQueueItems leases up to limit due items of the given kinds to owner. Items leased by
+// another worker are skipped unless their lease expired, and rows another worker is claiming are
+// skipped rather than waited for, so replicas never process the same item concurrently. MySQL has
+// no UPDATE ... RETURNING, so the items are locked, leased and read back in one transaction
+func (ms *MySQLStore) ClaimQueueItems(ctx context.Context, owner string, kinds []string, limit int, lease time.Duration) ([]*models.QueueItem, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "claim_queue_items", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+
+	tx, err := ms.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	placeholders, args := mysqlIn(kinds)
+	query := `
+		SELECT id FROM work_queue
+		WHERE kind IN (` + placeholders + `) AND available_at <= ?
+			AND (lease_expires_at IS NULL OR lease_expires_at < ?)
+		ORDER BY available_at, id
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.QueryContext(ctx, query, append(args, now, now, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim queue items: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan queue item: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read queue items: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders, args = mysqlIn(ids)
+	query = `
+		UPDATE work_queue
+		SET lease_owner = ?, lease_expires_at = ?, attempts = attempts + 1
+		WHERE id IN (` + placeholders + `)
+	`
+	if _, err := tx.ExecContext(ctx, query, append([]interface{}{owner, now.Add(lease)}, args...)...); err != nil {
+		return nil, fmt.Errorf("failed to claim queue items: %w", err)
+	}
+
+	query = `
+		SELECT id, kind, payload, attempts, last_error, available_at, created_at
+		FROM work_queue
+		WHERE id IN (` + placeholders + `)
+		ORDER BY available_at, id
+	`
+	rows, err = tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read claimed queue items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*models.QueueItem
+	for rows.Next() {
+		item := &models.QueueItem{}
+		var payload []byte
+		if err := rows.Scan(&item.ID, &item.Kind, &payload, &item.Attempts, &item.LastError, &item.AvailableAt, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan queue item: %w", err)
+		}
+		item.Payload = payload
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read queue items: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit queue claim: %w", err)
+	}
+	return items, nil
+}
+
+// ExtendQueueLease extends an item's lease; it reports false if owner no longer holds it
+func (ms *MySQLStore) ExtendQueueLease(ctx context.Context, id int64, owner string, lease time.Duration) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "extend_queue_lease", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `UPDATE work_queue SET lease_expires_at = ? WHERE id = ? AND lease_owner = ?`
+
+	result, err := ms.db.ExecContext(ctx, query, time.Now().Add(lease), id, owner)
+	if err != nil {
+		return false, fmt.Errorf("failed to extend queue lease: %w", err)
+	}
+	return rowsAffected(result)
+}
+
+// CompleteQueueItem removes a processed item held by owner
+func (ms *MySQLStore) CompleteQueueItem(ctx context.Context, id int64, owner string) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "complete_queue_item", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	if _, err := ms.db.ExecContext(ctx, `DELETE FROM work_queue WHERE id = ? AND lease_owner = ?`, id, owner); err != nil {
+		return fmt.Errorf("failed to complete queue item: %w", err)
+	}
+	return nil
+}
+
+// RetryQueueItem releases a failed item held by owner, making it available again at retryAt
+func (ms *MySQLStore) RetryQueueItem(ctx context.Context, id int64, owner string, lastError string, retryAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "retry_queue_item", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		UPDATE work_queue
+		SET lease_owner = NULL, lease_expires_at = NULL, last_error = ?, available_at = ?
+		WHERE id = ? AND lease_owner = ?
+	`
+
+	if _, err := ms.db.ExecContext(ctx, query, lastError, retryAt, id, owner); err != nil {
+		return fmt.Errorf("failed to release queue item: %w", err)
+	}
+	return nil
+}
+
+// QueueStats reports the backlog of each queue item kind
+func (ms *MySQLStore) QueueStats(ctx context.Context) ([]models.QueueKindStats, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "queue_stats", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT kind, COUNT(*),
+			SUM(lease_expires_at >= ?),
+			COALESCE(TIMESTAMPDIFF(MICROSECOND, MIN(CASE
+				WHEN available_at <= ? AND (lease_expires_at IS NULL OR lease_expires_at < ?) THEN available_at
+			END), ?) / 1e6, 0),
+			COALESCE(TIMESTAMPDIFF(MICROSECOND, MIN(created_at), ?) / 1e6, 0)
+		FROM work_queue
+		GROUP BY kind
+		ORDER BY kind
+	`
+
+	now := time.Now()
+	rows, err := ms.db.QueryContext(ctx, query, now, now, now, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []models.QueueKindStats{}
+	for rows.Next() {
+		var s models.QueueKindStats
+		var leased sql.NullInt64
+		if err := rows.Scan(&s.Kind, &s.Depth, &leased, &s.LagSeconds, &s.OldestSeconds); err != nil {
+			return nil, fmt.Errorf("failed to scan queue stats: %w", err)
+		}
+		s.Leased = leased.Int64
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read queue stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// DeadLetterQueueItem moves a queue item held by owner to the dead letter table
+func (ms *MySQLStore) DeadLetterQueueItem(ctx context.Context, id int64, owner string, lastError string) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "dead_letter_queue_item", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	tx, err := ms.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO dead_letters (id, kind, payload, attempts, last_error, created_at, failed_at)
+		SELECT id, kind, payload, attempts, ?, created_at, ?
+		FROM work_queue
+		WHERE id = ? AND lease_owner = ?
+	`
+	if _, err := tx.ExecContext(ctx, query, lastError, time.Now(), id, owner); err != nil {
+		return fmt.Errorf("failed to dead-letter queue item: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM work_queue WHERE id = ? AND lease_owner = ?`, id, owner); err != nil {
+		return fmt.Errorf("failed to remove dead-lettered queue item: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListDeadLetters retrieves a page of dead letters, newest first, optionally of one kind
+func (ms *MySQLStore) ListDeadLetters(ctx context.Context, kind string, limit int, offset int) ([]*models.DeadLetter, int, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "list_dead_letters", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	var total int
+	if err := ms.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM dead_letters WHERE ? = '' OR kind = ?`, kind, kind).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count dead letters: %w", err)
+	}
+
+	query := `SELECT ` + deadLetterColumns + ` FROM dead_letters
+		WHERE ? = '' OR kind = ?
+		ORDER BY failed_at DESC, id DESC
+		LIMIT ? OFFSET ?`
+
+	rows, err := ms.db.QueryContext(ctx, query, kind, kind, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	deadLetters := []*models.DeadLetter{}
+	for rows.Next() {
+		deadLetter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read dead letters: %w", err)
+	}
+
+	return deadLetters, total, nil
+}
+
+// GetDeadLetter retrieves a dead letter by ID
+func (ms *MySQLStore) GetDeadLetter(ctx context.Context, id int64) (*models.DeadLetter, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_dead_letter", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `SELECT ` + deadLetterColumns + ` FROM dead_letters WHERE id = ?`
+
+	deadLetter, err := scanDeadLetter(ms.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+	return deadLetter, nil
+}
+
+// RequeueDeadLetter moves a dead letter back to the work queue with its attempts reset; it
+// reports false if the dead letter doesn't exist
+func (ms *MySQLStore) RequeueDeadLetter(ctx context.Context, id int64) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "requeue_dead_letter", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	tx, err := ms.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO work_queue (kind, payload, last_error, available_at, created_at)
+		SELECT kind, payload, '', ?, created_at FROM dead_letters WHERE id = ?
+	`, time.Now(), id)
+	if err != nil {
+		return false, fmt.Errorf("failed to requeue dead letter: %w", err)
+	}
+	if found, err := rowsAffected(result); err != nil || !found {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM dead_letters WHERE id = ?`, id); err != nil {
+		return false, fmt.Errorf("failed to remove requeued dead letter: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// DeleteDeadLetter discards a dead letter; it reports false if it doesn't exist
+func (ms *MySQLStore) DeleteDeadLetter(ctx context.Context, id int64) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "delete_dead_letter", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	result, err := ms.db.ExecContext(ctx, `DELETE FROM dead_letters WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	return rowsAffected(result)
+}
+
+// CountDeadLetters counts the dead letters of each kind
+func (ms *MySQLStore) CountDeadLetters(ctx context.Context) (map[string]int64, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "count_dead_letters", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	rows, err := ms.db.QueryContext(ctx, `SELECT kind, COUNT(*) FROM dead_letters GROUP BY kind`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var kind string
+		var count int64
+		if err := rows.Scan(&kind, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter count: %w", err)
+		}
+		counts[kind] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dead letter counts: %w", err)
+	}
+
+	return counts, nil
+}
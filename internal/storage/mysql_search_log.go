@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// LogSearch stores a search log entry, encrypting the query like conversation content
+func (ms *MySQLStore) LogSearch(ctx context.Context, entry *models.SearchLog) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "log_search", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query, err := encryptContent(ctx, ms.cipher, entry.Query)
+	if err != nil {
+		return err
+	}
+
+	result, err := ms.db.ExecContext(ctx, `
+		INSERT INTO search_logs (tenant, user_id, query, results, top_score, duration_ms, variant, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.Tenant, entry.UserID, query, entry.Results, entry.TopScore, entry.DurationMs, entry.Variant, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to log search: %w", err)
+	}
+	if entry.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to read search log ID: %w", err)
+	}
+	return nil
+}
+
+// ListSearchQueries retrieves up to limit decrypted queries of a user's searches in a tenant that
+// found results, logged at or after since, newest first
+func (ms *MySQLStore) ListSearchQueries(ctx context.Context, tenant string, userID string, since time.Time, limit int) ([]string, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "list_search_queries", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	rows, err := ms.db.QueryContext(ctx, `
+		SELECT query
+		FROM search_logs
+		WHERE user_id = ? AND tenant = ? AND results > 0 AND created_at >= ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`, userID, tenant, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list search queries: %w", err)
+	}
+	defer rows.Close()
+
+	queries := []string{}
+	for rows.Next() {
+		var query string
+		if err := rows.Scan(&query); err != nil {
+			return nil, fmt.Errorf("failed to scan search query: %w", err)
+		}
+		if query, err = decryptContent(ctx, ms.cipher, query); err != nil {
+			return nil, err
+		}
+		queries = append(queries, query)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search queries: %w", err)
+	}
+
+	return queries, nil
+}
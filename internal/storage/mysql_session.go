@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// CreateSession inserts a session; it reports false if a session with the same ID already exists
+func (ms *MySQLStore) CreateSession(ctx context.Context, session *models.Session) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "create_session", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		INSERT INTO sessions (id, user_id, title, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	created, err := mysqlInserted(ms.db.ExecContext(
+		ctx,
+		query,
+		session.ID,
+		session.UserID,
+		nullString(session.Title),
+		session.Status,
+		session.CreatedAt,
+		session.UpdatedAt,
+	))
+	if err != nil {
+		return false, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetSession retrieves a session by ID
+func (ms *MySQLStore) GetSession(ctx context.Context, id string) (*models.Session, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_session", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `SELECT ` + sessionColumns + ` FROM sessions s WHERE s.id = ?`
+
+	session, err := scanSession(ms.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.Summary, err = decryptContent(ctx, ms.cipher, session.Summary); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// ListSessions retrieves a page of sessions, newest first, optionally filtered by user and status
+func (ms *MySQLStore) ListSessions(ctx context.Context, userID string, status string, limit int, offset int) ([]*models.Session, int, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "list_sessions", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	where := `WHERE (? = '' OR s.user_id = ?) AND (? = '' OR s.status = ?)`
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM sessions s ` + where
+	if err := ms.db.QueryRowContext(ctx, countQuery, userID, userID, status, status).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+
+	query := `SELECT ` + sessionColumns + ` FROM sessions s ` + where + `
+		ORDER BY s.created_at DESC
+		LIMIT ? OFFSET ?`
+
+	rows, err := ms.db.QueryContext(ctx, query, userID, userID, status, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan session: %w", err)
+		}
+		if session.Summary, err = decryptContent(ctx, ms.cipher, session.Summary); err != nil {
+			return nil, 0, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating sessions: %w", err)
+	}
+
+	return sessions, total, nil
+}
+
+// CloseSession marks a session closed
+func (ms *MySQLStore) CloseSession(ctx context.Context, id string, closedAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "close_session", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		UPDATE sessions
+		SET status = ?, closed_at = ?, updated_at = ?
+		WHERE id = ? AND status <> ?
+	`
+
+	status := models.SessionStatusClosed
+	if _, err := ms.db.ExecContext(ctx, query, status, closedAt, closedAt, id, status); err != nil {
+		return fmt.Errorf("failed to close session: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSessionSummary stores a session's summary, encrypted when a content cipher is set
+func (ms *MySQLStore) UpdateSessionSummary(ctx context.Context, id string, summary string, updatedAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "update_session_summary", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	summary, err := encryptContent(ctx, ms.cipher, summary)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE sessions
+		SET summary = ?, summary_updated_at = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	if _, err := ms.db.ExecContext(ctx, query, summary, updatedAt, updatedAt, id); err != nil {
+		return fmt.Errorf("failed to update session summary: %w", err)
+	}
+
+	return nil
+}
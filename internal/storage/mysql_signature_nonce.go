@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// ClaimSignatureNonce records a client's nonce until expiresAt; it reports false if the nonce is
+// already recorded and unexpired at now, which makes the request carrying it a replay
+func (ms *MySQLStore) ClaimSignatureNonce(ctx context.Context, clientID string, nonce string, now time.Time, expiresAt time.Time) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "claim_signature_nonce", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	// An expired record left for the sweep is cleared first; of two replicas claiming the same
+	// nonce, the insert of the second fails on the key
+	_, err := ms.db.ExecContext(ctx, `DELETE FROM signature_nonces WHERE client_id = ? AND nonce = ? AND expires_at < ?`, clientID, nonce, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to clear expired signature nonce: %w", err)
+	}
+
+	query := `
+		INSERT INTO signature_nonces (client_id, nonce, expires_at)
+		VALUES (?, ?, ?)
+	`
+	claimed, err := mysqlInserted(ms.db.ExecContext(ctx, query, clientID, nonce, expiresAt))
+	if err != nil {
+		return false, fmt.Errorf("failed to claim signature nonce: %w", err)
+	}
+	return claimed, nil
+}
+
+// DeleteExpiredSignatureNonces deletes the nonces that expired before a time and returns how
+// many were deleted
+func (ms *MySQLStore) DeleteExpiredSignatureNonces(ctx context.Context, before time.Time) (int64, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "delete_expired_signature_nonces", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	result, err := ms.db.ExecContext(ctx, `DELETE FROM signature_nonces WHERE expires_at < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired signature nonces: %w", err)
+	}
+	return result.RowsAffected()
+}
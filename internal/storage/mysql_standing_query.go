@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// CreateStandingQuery inserts a standing query with its vector
+func (ms *MySQLStore) CreateStandingQuery(ctx context.Context, query *models.StandingQuery) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "create_standing_query", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	vector, err := json.Marshal(query.Vector)
+	if err != nil {
+		return fmt.Errorf("failed to encode standing query vector: %w", err)
+	}
+
+	_, err = ms.db.ExecContext(ctx, `
+		INSERT INTO standing_queries (id, user_id, query, filter, threshold, vector, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, query.ID, query.UserID, query.Query, query.Filter, query.Threshold, string(vector), query.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create standing query: %w", err)
+	}
+
+	return nil
+}
+
+// GetStandingQuery retrieves a standing query by ID
+func (ms *MySQLStore) GetStandingQuery(ctx context.Context, id string) (*models.StandingQuery, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_standing_query", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query, err := scanStandingQuery(ms.db.QueryRowContext(ctx, `SELECT `+standingQueryColumns+` FROM standing_queries WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get standing query: %w", err)
+	}
+
+	return query, nil
+}
+
+// ListStandingQueries retrieves a user's standing queries with their vectors, oldest first
+func (ms *MySQLStore) ListStandingQueries(ctx context.Context, userID string) ([]*models.StandingQuery, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "list_standing_queries", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	rows, err := ms.db.QueryContext(ctx, `SELECT `+standingQueryColumns+` FROM standing_queries WHERE user_id = ? ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list standing queries: %w", err)
+	}
+	defer rows.Close()
+
+	queries := []*models.StandingQuery{}
+	for rows.Next() {
+		query, err := scanStandingQuery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan standing query: %w", err)
+		}
+		queries = append(queries, query)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating standing queries: %w", err)
+	}
+
+	return queries, nil
+}
+
+// DeleteStandingQuery deletes a standing query
+func (ms *MySQLStore) DeleteStandingQuery(ctx context.Context, id string) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "delete_standing_query", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	result, err := ms.db.ExecContext(ctx, `DELETE FROM standing_queries WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete standing query: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// UpdateStandingQueryVector replaces a standing query's vector
+func (ms *MySQLStore) UpdateStandingQueryVector(ctx context.Context, id string, vector []float32) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "update_standing_query_vector", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	data, err := json.Marshal(vector)
+	if err != nil {
+		return fmt.Errorf("failed to encode standing query vector: %w", err)
+	}
+	if _, err := ms.db.ExecContext(ctx, `UPDATE standing_queries SET vector = ? WHERE id = ?`, string(data), id); err != nil {
+		return fmt.Errorf("failed to update standing query vector: %w", err)
+	}
+
+	return nil
+}
+
+// RecordStandingQueryMatch counts a match of a standing query
+func (ms *MySQLStore) RecordStandingQueryMatch(ctx context.Context, id string, matchedAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "record_standing_query_match", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	_, err := ms.db.ExecContext(ctx, `
+		UPDATE standing_queries
+		SET match_count = match_count + 1, last_matched_at = GREATEST(COALESCE(last_matched_at, ?), ?)
+		WHERE id = ?
+	`, matchedAt, matchedAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to record standing query match: %w", err)
+	}
+
+	return nil
+}
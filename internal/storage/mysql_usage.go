@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// AddUsage adds usage records to the stored daily totals in one transaction
+func (ms *MySQLStore) AddUsage(ctx context.Context, records []models.UsageRecord) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "add_usage", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	tx, err := ms.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO embedding_usage (day, tenant, model, requests, prompt_tokens, total_tokens)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			requests = requests + VALUES(requests),
+			prompt_tokens = prompt_tokens + VALUES(prompt_tokens),
+			total_tokens = total_tokens + VALUES(total_tokens)
+	`
+
+	for _, record := range records {
+		if _, err := tx.ExecContext(ctx, query, record.Day, record.Tenant, record.Model, record.Requests, record.PromptTokens, record.TotalTokens); err != nil {
+			return fmt.Errorf("failed to add usage: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit usage: %w", err)
+	}
+
+	return nil
+}
+
+// ListUsage retrieves the daily totals between two dates inclusive, oldest first, optionally of
+// one tenant. Days are stored as DATE and formatted back to YYYY-MM-DD
+func (ms *MySQLStore) ListUsage(ctx context.Context, from string, to string, tenant string) ([]models.UsageRecord, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "list_usage", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT DATE_FORMAT(day, '%Y-%m-%d'), tenant, model, requests, prompt_tokens, total_tokens
+		FROM embedding_usage
+		WHERE day BETWEEN ? AND ? AND (? = '' OR tenant = ?)
+		ORDER BY day, tenant, model
+	`
+
+	rows, err := ms.db.QueryContext(ctx, query, from, to, tenant, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list usage: %w", err)
+	}
+	defer rows.Close()
+
+	records := []models.UsageRecord{}
+	for rows.Next() {
+		var record models.UsageRecord
+		if err := rows.Scan(&record.Day, &record.Tenant, &record.Model, &record.Requests, &record.PromptTokens, &record.TotalTokens); err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating usage: %w", err)
+	}
+
+	return records, nil
+}
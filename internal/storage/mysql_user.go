@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// CountUserData counts the records stored for a user. Query adapters are kept in Postgres only
+func (ms *MySQLStore) CountUserData(ctx context.Context, userID string) (*models.UserDataCounts, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "count_user_data", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	query := `
+		SELECT
+			COALESCE((SELECT conversation_count FROM user_stats WHERE user_id = ?), 0),
+			(SELECT COUNT(*) FROM messages m JOIN conversations c ON c.id = m.conversation_id WHERE c.user_id = ?),
+			(SELECT COUNT(*) FROM personal_info WHERE user_id = ?),
+			(SELECT COUNT(*) FROM sessions WHERE user_id = ?),
+			(SELECT COUNT(*) FROM user_profiles WHERE user_id = ?),
+			(SELECT COUNT(*) FROM users WHERE id = ?),
+			(SELECT COUNT(*) FROM search_logs WHERE user_id = ?),
+			(SELECT COUNT(*) FROM standing_queries WHERE user_id = ?)
+	`
+
+	counts := &models.UserDataCounts{}
+	err := ms.db.QueryRowContext(ctx, query, userID, userID, userID, userID, userID, userID, userID, userID).Scan(
+		&counts.Conversations,
+		&counts.Messages,
+		&counts.PersonalInfo,
+		&counts.Sessions,
+		&counts.Profiles,
+		&counts.Account,
+		&counts.SearchLogs,
+		&counts.StandingQueries,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count user data: %w", err)
+	}
+
+	return counts, nil
+}
+
+// DeleteUserData deletes all records stored for a user in one transaction and reports what was deleted
+func (ms *MySQLStore) DeleteUserData(ctx context.Context, userID string) (*models.UserDataCounts, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "delete_user_data", time.Now())
+
+	tx, err := ms.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	counts := &models.UserDataCounts{}
+
+	// Messages are removed with their conversations
+	err = tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM messages m JOIN conversations c ON c.id = m.conversation_id WHERE c.user_id = ?`,
+		userID,
+	).Scan(&counts.Messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count user messages: %w", err)
+	}
+
+	// Aliases are removed with the conversations they resolve to
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM id_aliases WHERE conversation_id IN (SELECT id FROM conversations WHERE user_id = ?)`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete user conversation aliases: %w", err)
+	}
+
+	deletes := []struct {
+		query string
+		count *int64
+	}{
+		{`DELETE FROM conversations WHERE user_id = ?`, &counts.Conversations},
+		{`DELETE FROM personal_info WHERE user_id = ?`, &counts.PersonalInfo},
+		{`DELETE FROM sessions WHERE user_id = ?`, &counts.Sessions},
+		{`DELETE FROM user_profiles WHERE user_id = ?`, &counts.Profiles},
+		{`DELETE FROM users WHERE id = ?`, &counts.Account},
+		{`DELETE FROM search_logs WHERE user_id = ?`, &counts.SearchLogs},
+		{`DELETE FROM standing_queries WHERE user_id = ?`, &counts.StandingQueries},
+	}
+	for _, d := range deletes {
+		result, err := tx.ExecContext(ctx, d.query, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete user data: %w", err)
+		}
+		if *d.count, err = result.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+	}
+
+	// The user's stats go with their data
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_stats WHERE user_id = ?`, userID); err != nil {
+		return nil, fmt.Errorf("failed to delete user stats: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return counts, nil
+}
+
+// GetUserStats retrieves a user's conversation aggregates, kept by triggers on conversations; a
+// user without a stats row has zero stats
+func (ms *MySQLStore) GetUserStats(ctx context.Context, userID string) (*models.UserStats, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "get_user_stats", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	stats := &models.UserStats{UserID: userID}
+	var lastConversationAt sql.NullTime
+	err := ms.db.QueryRowContext(ctx,
+		`SELECT conversation_count, last_conversation_at, total_tokens FROM user_stats WHERE user_id = ?`,
+		userID,
+	).Scan(&stats.ConversationCount, &lastConversationAt, &stats.TotalTokens)
+	if err == sql.ErrNoRows {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user stats: %w", err)
+	}
+	if lastConversationAt.Valid {
+		stats.LastConversationAt = &lastConversationAt.Time
+	}
+
+	return stats, nil
+}
+
+// AddUserTokens adds embedding tokens spent on a user's conversations to the user's stats
+func (ms *MySQLStore) AddUserTokens(ctx context.Context, userID string, tokens int64) error {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "add_user_tokens", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	_, err := ms.db.ExecContext(ctx, `
+		INSERT INTO user_stats (user_id, total_tokens) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE total_tokens = total_tokens + VALUES(total_tokens)
+	`, userID, tokens)
+	if err != nil {
+		return fmt.Errorf("failed to add user tokens: %w", err)
+	}
+
+	return nil
+}
+
+// ListActiveUsers retrieves up to limit users, in ID order after afterUserID, whose last
+// conversation was created at or after since
+func (ms *MySQLStore) ListActiveUsers(ctx context.Context, since time.Time, afterUserID string, limit int) ([]string, error) {
+	defer slowlog.Observe(ctx, slowlog.MySQL, "list_active_users", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.MySQL)
+	defer cancel()
+
+	rows, err := ms.db.QueryContext(ctx, `
+		SELECT user_id FROM user_stats
+		WHERE last_conversation_at >= ? AND user_id > ?
+		ORDER BY user_id
+		LIMIT ?
+	`, since, afterUserID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active users: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
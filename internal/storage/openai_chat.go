@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// OpenAICompletionProvider implements CompletionProvider using the OpenAI chat completions API
+type OpenAICompletionProvider struct {
+	client    *openai.Client
+	model     string
+	maxTokens int
+}
+
+// NewOpenAICompletionProvider creates a new OpenAI completion provider; a nil httpClient uses the
+// default client
+func NewOpenAICompletionProvider(apiKey string, model string, maxTokens int, httpClient *http.Client) *OpenAICompletionProvider {
+	config := openai.DefaultConfig(apiKey)
+	if httpClient != nil {
+		config.HTTPClient = httpClient
+	}
+	return &OpenAICompletionProvider{
+		client:    openai.NewClientWithConfig(config),
+		model:     model,
+		maxTokens: maxTokens,
+	}
+}
+
+// Complete generates a response to the prompt under the given system instructions
+func (ocp *OpenAICompletionProvider) Complete(ctx context.Context, systemPrompt string, prompt string) (string, error) {
+	defer slowlog.Observe(ctx, slowlog.Completion, "chat_completion", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Completion)
+	defer cancel()
+
+	resp, err := ocp.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: ocp.model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: prompt},
+		},
+		MaxTokens:   ocp.maxTokens,
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create completion: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no completion returned")
+	}
+
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
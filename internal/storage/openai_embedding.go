@@ -3,8 +3,16 @@ package storage
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/sashabaranov/go-openai"
+
+	"refo-rag-server/internal/embeddedup"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+	"refo-rag-server/internal/usage"
 )
 
 // OpenAIEmbeddingProvider implements EmbeddingProvider using OpenAI API
@@ -12,58 +20,126 @@ type OpenAIEmbeddingProvider struct {
 	client    *openai.Client
 	model     openai.EmbeddingModel
 	dimension int
+	opts      EmbeddingOptions
+}
+
+// EmbeddingOptions adjusts the text sent to an embedding model and the vectors it returns
+type EmbeddingOptions struct {
+	// Prefixes are prepended to queries and documents by EmbedQuery and EmbedDocument
+	Prefixes EmbeddingPrefixes
+
+	// Normalize scales every vector to unit length
+	Normalize bool
+
+	// HTTPClient sends the API requests; nil uses the default client
+	HTTPClient *http.Client
 }
 
 // NewOpenAIEmbeddingProvider creates a new OpenAI embedding provider
-func NewOpenAIEmbeddingProvider(apiKey string, model string, dimension int) *OpenAIEmbeddingProvider {
-	client := openai.NewClient(apiKey)
+func NewOpenAIEmbeddingProvider(apiKey string, model string, dimension int, opts EmbeddingOptions) *OpenAIEmbeddingProvider {
+	config := openai.DefaultConfig(apiKey)
+	if opts.HTTPClient != nil {
+		config.HTTPClient = opts.HTTPClient
+	}
+	client := openai.NewClientWithConfig(config)
 	return &OpenAIEmbeddingProvider{
 		client:    client,
 		model:     openai.EmbeddingModel(model),
 		dimension: dimension,
+		opts:      opts,
 	}
 }
 
-// Embed converts text to a vector using OpenAI
+// EmbedQuery converts search text to a vector, with the model's query prefix
+func (oaep *OpenAIEmbeddingProvider) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	return oaep.Embed(ctx, withPrefix(oaep.opts.Prefixes.Query, text))
+}
+
+// EmbedDocument converts stored content to a vector, with the model's document prefix
+func (oaep *OpenAIEmbeddingProvider) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	return oaep.Embed(ctx, withPrefix(oaep.opts.Prefixes.Document, text))
+}
+
+// Embed converts text to a vector using OpenAI; within a request scope a text already embedded is
+// reused
 func (oaep *OpenAIEmbeddingProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	return embeddedup.Do(ctx, oaep, text, func() ([]float32, error) {
+		return oaep.embed(ctx, text)
+	})
+}
+
+// embed calls OpenAI to convert one text to a vector
+func (oaep *OpenAIEmbeddingProvider) embed(ctx context.Context, text string) ([]float32, error) {
+	defer slowlog.Observe(ctx, slowlog.Embedding, "embed", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Embedding)
+	defer cancel()
+
+	if err := usage.CheckBudget(); err != nil {
+		return nil, err
+	}
+
 	resp, err := oaep.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
 		Input: []string{text},
 		Model: oaep.model,
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to create embedding: %w", err)
+		return nil, fmt.Errorf("failed to create embedding: %w", classifyEmbeddingError(string(oaep.model), err))
 	}
+	oaep.recordUsage(ctx, resp.Usage)
 
 	if len(resp.Data) == 0 {
 		return nil, fmt.Errorf("no embedding data returned from openai")
 	}
 
-	return resp.Data[0].Embedding, nil
+	return oaep.finish(resp.Data[0].Embedding), nil
 }
 
-// EmbedBatch converts multiple texts to vectors using OpenAI
+// EmbedBatch converts multiple texts to vectors using OpenAI; repeated texts are sent once
 func (oaep *OpenAIEmbeddingProvider) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	defer slowlog.Observe(ctx, slowlog.Embedding, "embed_batch", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Embedding)
+	defer cancel()
+
+	if err := usage.CheckBudget(); err != nil {
+		return nil, err
+	}
+
+	unique, positions := embeddedup.Unique(texts)
 	resp, err := oaep.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
-		Input: texts,
+		Input: unique,
 		Model: oaep.model,
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to create batch embeddings: %w", err)
+		return nil, fmt.Errorf("failed to create batch embeddings: %w", classifyEmbeddingError(string(oaep.model), err))
 	}
+	oaep.recordUsage(ctx, resp.Usage)
 
 	if len(resp.Data) == 0 {
 		return nil, fmt.Errorf("no embedding data returned from openai")
 	}
 
 	// Sort embeddings by index to ensure correct order
-	embeddings := make([][]float32, len(texts))
+	embeddings := make([][]float32, len(unique))
 	for _, data := range resp.Data {
 		if data.Index < len(embeddings) {
-			embeddings[data.Index] = data.Embedding
+			embeddings[data.Index] = oaep.finish(data.Embedding)
 		}
 	}
 
-	return embeddings, nil
-}
\ No newline at end of file
+	return embeddedup.FanOut(embeddings, positions), nil
+}
+
+// finish applies the configured post-processing to a returned vector
+func (oaep *OpenAIEmbeddingProvider) finish(vector []float32) []float32 {
+	if oaep.opts.Normalize {
+		NormalizeL2(vector)
+	}
+	return vector
+}
+
+// recordUsage reports the tokens OpenAI billed for a call
+func (oaep *OpenAIEmbeddingProvider) recordUsage(ctx context.Context, u openai.Usage) {
+	usage.Record(ctx, string(oaep.model), models.EmbeddingUsage{PromptTokens: u.PromptTokens, TotalTokens: u.TotalTokens})
+}
@@ -4,15 +4,27 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/lib/pq"
 
 	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// Connection pool limits
+const (
+	maxOpenConns = 25
+	maxIdleConns = 5
 )
 
 // PostgresStore implements ConversationStore
 type PostgresStore struct {
 	db *sql.DB
+
+	// cipher encrypts conversation content at rest; nil stores it as plaintext
+	cipher ContentCipher
 }
 
 // NewPostgresStore creates a new PostgreSQL store
@@ -24,119 +36,552 @@ func NewPostgresStore(dsn string) (*PostgresStore, error) {
 
 	// Test the connection
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
 	// Set connection pool settings
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
 
 	return &PostgresStore{db: db}, nil
 }
 
-// SaveConversation saves a new conversation to PostgreSQL
+// SetContentCipher encrypts the question, answer and message content of conversations, session
+// summaries and user profiles saved from now on. Reads decrypt encrypted content and return plaintext content as is, so encryption can be
+// enabled on an existing database
+func (ps *PostgresStore) SetContentCipher(cipher ContentCipher) {
+	ps.cipher = cipher
+}
+
+// SaveConversation saves a conversation and its messages to PostgreSQL
 func (ps *PostgresStore) SaveConversation(ctx context.Context, conv *models.Conversation) error {
+	return ps.SaveConversationThen(ctx, conv, nil)
+}
+
+// SaveConversationThen saves a conversation and its messages, then runs beforeCommit inside the
+// transaction; the save is rolled back if beforeCommit fails
+func (ps *PostgresStore) SaveConversationThen(ctx context.Context, conv *models.Conversation, beforeCommit func(ctx context.Context) error) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "save_conversation", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := ps.saveConversation(ctx, tx, conv); err != nil {
+		return err
+	}
+
+	if beforeCommit != nil {
+		if err := beforeCommit(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit conversation: %w", err)
+	}
+
+	return nil
+}
+
+// SaveConversationWithJob saves a conversation and its messages and enqueues a work queue item
+// in the same transaction, so the item exists if and only if the conversation was saved. The item
+// becomes available at availableAt; it returns the item's ID.
+func (ps *PostgresStore) SaveConversationWithJob(ctx context.Context, conv *models.Conversation, kind string, payload interface{}, availableAt time.Time) (int64, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "save_conversation", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := ps.saveConversation(ctx, tx, conv); err != nil {
+		return 0, err
+	}
+
+	id, err := enqueue(ctx, tx, kind, payload, availableAt)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit conversation: %w", err)
+	}
+
+	return id, nil
+}
+
+// saveConversation upserts a conversation row and replaces its messages
+func (ps *PostgresStore) saveConversation(ctx context.Context, tx *sql.Tx, conv *models.Conversation) error {
+	question, err := ps.encrypt(ctx, conv.Question)
+	if err != nil {
+		return err
+	}
+	answer, err := ps.encrypt(ctx, conv.Answer)
+	if err != nil {
+		return err
+	}
+
+	unembeddedAt, unembeddedReason, unembeddedDetail := unembeddedArgs(conv.Unembedded)
 	query := `
-		INSERT INTO conversations (id, user_id, question, answer, metadata, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO conversations (id, user_id, session_id, question, answer, metadata, created_at, updated_at, importance, content_hash,
+			unembedded_at, unembedded_reason, unembedded_detail, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		ON CONFLICT (id) DO UPDATE SET
+			session_id = EXCLUDED.session_id,
+			question = EXCLUDED.question,
 			answer = EXCLUDED.answer,
 			metadata = EXCLUDED.metadata,
-			updated_at = EXCLUDED.updated_at
+			updated_at = EXCLUDED.updated_at,
+			importance = EXCLUDED.importance,
+			content_hash = EXCLUDED.content_hash,
+			unembedded_at = EXCLUDED.unembedded_at,
+			unembedded_reason = EXCLUDED.unembedded_reason,
+			unembedded_detail = EXCLUDED.unembedded_detail,
+			status = EXCLUDED.status
 	`
 
-	_, err := ps.db.ExecContext(
+	_, err = tx.ExecContext(
 		ctx,
 		query,
 		conv.ID,
 		conv.UserID,
-		conv.Question,
-		conv.Answer,
+		nullString(conv.SessionID),
+		question,
+		answer,
 		conv.Metadata,
 		conv.CreatedAt,
 		conv.UpdatedAt,
+		conv.Importance,
+		conv.ContentHash,
+		unembeddedAt,
+		unembeddedReason,
+		unembeddedDetail,
+		conversationStatus(conv),
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to save conversation: %w", err)
 	}
 
+	return ps.saveMessages(ctx, tx, conv.ID, conv.Messages)
+}
+
+// saveMessages replaces the stored messages of a conversation
+func (ps *PostgresStore) saveMessages(ctx context.Context, tx *sql.Tx, conversationID string, messages []models.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM messages WHERE conversation_id = $1`, conversationID); err != nil {
+		return fmt.Errorf("failed to replace messages: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO messages (conversation_id, message_id, position, role, content, speaker, display_name, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare message insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, msg := range messages {
+		createdAt := time.Now()
+		if msg.Timestamp != nil {
+			createdAt = *msg.Timestamp
+		}
+
+		content, err := ps.encrypt(ctx, msg.Content)
+		if err != nil {
+			return err
+		}
+
+		_, err = stmt.ExecContext(
+			ctx,
+			conversationID,
+			msg.MessageID,
+			i,
+			msg.Role,
+			content,
+			nullString(msg.Speaker),
+			nullString(msg.DisplayName),
+			createdAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save message %d: %w", i, err)
+		}
+	}
+
 	return nil
 }
 
-// GetConversation retrieves a conversation by ID from PostgreSQL
-func (ps *PostgresStore) GetConversation(ctx context.Context, id string) (*models.Conversation, error) {
+// getMessages loads the messages of the given conversations keyed by conversation ID
+func (ps *PostgresStore) getMessages(ctx context.Context, conversationIDs []string) (map[string][]models.Message, error) {
 	query := `
-		SELECT id, user_id, question, answer, metadata, created_at, updated_at
-		FROM conversations
-		WHERE id = $1
+		SELECT conversation_id, message_id, role, content, speaker, display_name, created_at
+		FROM messages
+		WHERE conversation_id = ANY($1)
+		ORDER BY conversation_id, position
 	`
 
+	rows, err := ps.db.QueryContext(ctx, query, pq.Array(conversationIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make(map[string][]models.Message)
+	for rows.Next() {
+		var (
+			conversationID string
+			msg            models.Message
+			speaker        sql.NullString
+			displayName    sql.NullString
+			createdAt      time.Time
+		)
+		if err := rows.Scan(&conversationID, &msg.MessageID, &msg.Role, &msg.Content, &speaker, &displayName, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		if msg.Content, err = ps.decrypt(ctx, msg.Content); err != nil {
+			return nil, err
+		}
+		msg.Speaker = speaker.String
+		msg.DisplayName = displayName.String
+		msg.Timestamp = &createdAt
+		messages[conversationID] = append(messages[conversationID], msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// encrypt encrypts content with the configured cipher, if any
+func (ps *PostgresStore) encrypt(ctx context.Context, plaintext string) (string, error) {
+	return encryptContent(ctx, ps.cipher, plaintext)
+}
+
+// decrypt decrypts content written by encrypt; plaintext content is returned as is
+func (ps *PostgresStore) decrypt(ctx context.Context, value string) (string, error) {
+	return decryptContent(ctx, ps.cipher, value)
+}
+
+// decryptConversation decrypts a scanned conversation's question and answer in place
+func (ps *PostgresStore) decryptConversation(ctx context.Context, conv *models.Conversation) error {
+	return decryptConversation(ctx, ps.cipher, conv)
+}
+
+// encryptContent encrypts content with cipher; a nil cipher stores it as plaintext
+func encryptContent(ctx context.Context, cipher ContentCipher, plaintext string) (string, error) {
+	if cipher == nil {
+		return plaintext, nil
+	}
+	value, err := cipher.Encrypt(ctx, plaintext)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt conversation content: %w", err)
+	}
+	return value, nil
+}
+
+// decryptContent decrypts content written by encryptContent; plaintext content is returned as is
+func decryptContent(ctx context.Context, cipher ContentCipher, value string) (string, error) {
+	if cipher == nil {
+		return value, nil
+	}
+	plaintext, err := cipher.Decrypt(ctx, value)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt conversation content: %w", err)
+	}
+	return plaintext, nil
+}
+
+// decryptConversation decrypts a scanned conversation's question and answer in place
+func decryptConversation(ctx context.Context, cipher ContentCipher, conv *models.Conversation) error {
+	var err error
+	if conv.Question, err = decryptContent(ctx, cipher, conv.Question); err != nil {
+		return err
+	}
+	conv.Answer, err = decryptContent(ctx, cipher, conv.Answer)
+	return err
+}
+
+// nullString maps an empty string to SQL NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// conversationColumns is the column list shared by conversation queries
+const conversationColumns = `id, user_id, session_id, question, answer, metadata, created_at, updated_at,
+		importance, pinned, suppressed_at, suppression_reason, suppression_note, content_hash,
+		unembedded_at, unembedded_reason, unembedded_detail, status`
+
+// personalInfoColumns is the column list shared by personal info queries
+const personalInfoColumns = `id, user_id, content, category, importance, pinned, created_at, updated_at,
+		suppressed_at, suppression_reason, suppression_note`
+
+// scanConversation scans a row selected with conversationColumns; messages are loaded separately
+func scanConversation(row rowScanner) (*models.Conversation, error) {
 	conv := &models.Conversation{}
-	err := ps.db.QueryRowContext(ctx, query, id).Scan(
+	var sessionID sql.NullString
+	var suppression suppressionColumns
+	var unembedded unembeddedColumns
+
+	err := row.Scan(
 		&conv.ID,
 		&conv.UserID,
+		&sessionID,
 		&conv.Question,
 		&conv.Answer,
 		&conv.Metadata,
 		&conv.CreatedAt,
 		&conv.UpdatedAt,
+		&conv.Importance,
+		&conv.Pinned,
+		&suppression.at,
+		&suppression.reason,
+		&suppression.note,
+		&conv.ContentHash,
+		&unembedded.at,
+		&unembedded.reason,
+		&unembedded.detail,
+		&conv.Status,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	conv.SessionID = sessionID.String
+	conv.Suppression = suppression.model()
+	conv.Unembedded = unembedded.model()
+	return conv, nil
+}
+
+// scanPersonalInfo scans a row selected with personalInfoColumns
+func scanPersonalInfo(row rowScanner) (*models.PersonalInfo, error) {
+	personalInfo := &models.PersonalInfo{}
+	var suppression suppressionColumns
+
+	err := row.Scan(
+		&personalInfo.ID,
+		&personalInfo.UserID,
+		&personalInfo.Content,
+		&personalInfo.Category,
+		&personalInfo.Importance,
+		&personalInfo.Pinned,
+		&personalInfo.CreatedAt,
+		&personalInfo.UpdatedAt,
+		&suppression.at,
+		&suppression.reason,
+		&suppression.note,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	personalInfo.Suppression = suppression.model()
+	return personalInfo, nil
+}
 
+// GetConversation retrieves a conversation by ID from PostgreSQL
+func (ps *PostgresStore) GetConversation(ctx context.Context, id string) (*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_conversation", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE id = $1
+	`
+
+	conv, err := scanConversation(ps.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get conversation: %w", err)
 	}
+	if err := ps.decryptConversation(ctx, conv); err != nil {
+		return nil, err
+	}
+
+	messages, err := ps.getMessages(ctx, []string{conv.ID})
+	if err != nil {
+		return nil, err
+	}
+	conv.Messages = messages[conv.ID]
 
 	return conv, nil
 }
 
-// GetConversationsByIDs retrieves multiple conversations by IDs from PostgreSQL
-func (ps *PostgresStore) GetConversationsByIDs(ctx context.Context, ids []string) ([]*models.Conversation, error) {
+// GetConversationsByIDs retrieves multiple conversations by IDs from PostgreSQL. Conversations
+// are returned in the order of ids, each once; IDs with no stored conversation are returned as missing.
+func (ps *PostgresStore) GetConversationsByIDs(ctx context.Context, ids []string) ([]*models.Conversation, []string, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_conversations_by_ids", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
 	if len(ids) == 0 {
-		return []*models.Conversation{}, nil
+		return []*models.Conversation{}, []string{}, nil
 	}
 
 	query := `
-		SELECT id, user_id, question, answer, metadata, created_at, updated_at
+		SELECT ` + conversationColumns + `
 		FROM conversations
 		WHERE id = ANY($1)
+	`
+
+	found, err := ps.queryConversations(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byID := make(map[string]*models.Conversation, len(found))
+	for _, conv := range found {
+		byID[conv.ID] = conv
+	}
+
+	conversations := make([]*models.Conversation, 0, len(found))
+	missing := []string{}
+	for _, id := range ids {
+		conv, ok := byID[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		if conv == nil {
+			// Listed twice; already added
+			continue
+		}
+		conversations = append(conversations, conv)
+		byID[id] = nil
+	}
+
+	return conversations, missing, nil
+}
+
+// GetConversationsByUser retrieves all of a user's conversations from PostgreSQL
+func (ps *PostgresStore) GetConversationsByUser(ctx context.Context, userID string) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_conversations_by_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = $1
 		ORDER BY created_at DESC
 	`
 
-	rows, err := ps.db.QueryContext(ctx, query, pq.Array(ids))
+	return ps.queryConversations(ctx, query, userID)
+}
+
+// queryConversations runs a conversation query and attaches each conversation's messages
+func (ps *PostgresStore) queryConversations(ctx context.Context, query string, args ...interface{}) ([]*models.Conversation, error) {
+	rows, err := ps.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query conversations: %w", err)
 	}
 	defer rows.Close()
 
-	var conversations []*models.Conversation
+	conversations := []*models.Conversation{}
+	var ids []string
 	for rows.Next() {
-		conv := &models.Conversation{}
-		err := rows.Scan(
-			&conv.ID,
-			&conv.UserID,
-			&conv.Question,
-			&conv.Answer,
-			&conv.Metadata,
-			&conv.CreatedAt,
-			&conv.UpdatedAt,
-		)
+		conv, err := scanConversation(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan conversation: %w", err)
 		}
+		if err := ps.decryptConversation(ctx, conv); err != nil {
+			return nil, err
+		}
 		conversations = append(conversations, conv)
+		ids = append(ids, conv.ID)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating conversations: %w", err)
 	}
 
+	if len(ids) == 0 {
+		return conversations, nil
+	}
+
+	messages, err := ps.getMessages(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, conv := range conversations {
+		conv.Messages = messages[conv.ID]
+	}
+
 	return conversations, nil
 }
 
+// DeleteConversation deletes a conversation and its messages
+func (ps *PostgresStore) DeleteConversation(ctx context.Context, id string) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "delete_conversation", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	if _, err := ps.db.ExecContext(ctx, `DELETE FROM conversations WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+
+	return nil
+}
+
+// ListForgettableConversations returns unpinned conversations of users not exempt from retention,
+// last updated before the given time, whose importance, halved every halfLife since the last update, has fallen below the threshold
+func (ps *PostgresStore) ListForgettableConversations(ctx context.Context, threshold float64, halfLife time.Duration, before time.Time, limit int, offset int) ([]string, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "list_forgettable_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT id FROM conversations
+		WHERE updated_at < $3
+			AND NOT pinned
+			AND user_id NOT IN (SELECT id FROM users WHERE retention_exempt)
+			AND CASE WHEN $2::float8 > 0
+				THEN importance * power(0.5, EXTRACT(EPOCH FROM (NOW() - updated_at)) / $2::float8)
+				ELSE importance END < $1
+		ORDER BY updated_at ASC, id ASC
+		LIMIT $4 OFFSET $5
+	`
+
+	rows, err := ps.db.QueryContext(ctx, query, threshold, halfLife.Seconds(), before, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query forgettable conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating forgettable conversations: %w", err)
+	}
+
+	return ids, nil
+}
+
 // Close closes the database connection
 func (ps *PostgresStore) Close() error {
 	return ps.db.Close()
@@ -149,11 +594,48 @@ func (ps *PostgresStore) GetDB() *sql.DB {
 
 // Ping checks the database connection
 func (ps *PostgresStore) Ping(ctx context.Context) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "ping", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
 	return ps.db.PingContext(ctx)
 }
 
+// MaxIdleConns returns the number of idle connections the pool keeps open
+func (ps *PostgresStore) MaxIdleConns() int {
+	return maxIdleConns
+}
+
+// WarmConnections opens up to n pooled connections so the first requests don't pay connection setup
+func (ps *PostgresStore) WarmConnections(ctx context.Context, n int) error {
+	conns := make([]*sql.Conn, 0, n)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	for i := 0; i < n; i++ {
+		conn, err := ps.db.Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to open connection %d: %w", i+1, err)
+		}
+		conns = append(conns, conn)
+
+		if err := conn.PingContext(ctx); err != nil {
+			return fmt.Errorf("failed to ping connection %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
 // SavePersonalInfo saves a new personal information entry to PostgreSQL
 func (ps *PostgresStore) SavePersonalInfo(ctx context.Context, personalInfo *models.PersonalInfo) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "save_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
 	query := `
 		INSERT INTO personal_info (id, user_id, content, category, importance, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
@@ -185,23 +667,17 @@ func (ps *PostgresStore) SavePersonalInfo(ctx context.Context, personalInfo *mod
 
 // GetPersonalInfo retrieves a personal information entry by ID from PostgreSQL
 func (ps *PostgresStore) GetPersonalInfo(ctx context.Context, id string) (*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
 	query := `
-		SELECT id, user_id, content, category, importance, created_at, updated_at
+		SELECT ` + personalInfoColumns + `
 		FROM personal_info
 		WHERE id = $1
 	`
 
-	personalInfo := &models.PersonalInfo{}
-	err := ps.db.QueryRowContext(ctx, query, id).Scan(
-		&personalInfo.ID,
-		&personalInfo.UserID,
-		&personalInfo.Content,
-		&personalInfo.Category,
-		&personalInfo.Importance,
-		&personalInfo.CreatedAt,
-		&personalInfo.UpdatedAt,
-	)
-
+	personalInfo, err := scanPersonalInfo(ps.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -214,14 +690,90 @@ func (ps *PostgresStore) GetPersonalInfo(ctx context.Context, id string) (*model
 
 // GetPersonalInfoByUser retrieves all personal information entries for a user from PostgreSQL
 func (ps *PostgresStore) GetPersonalInfoByUser(ctx context.Context, userID string) ([]*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_personal_info_by_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
 	query := `
-		SELECT id, user_id, content, category, importance, created_at, updated_at
+		SELECT ` + personalInfoColumns + `
 		FROM personal_info
 		WHERE user_id = $1
 		ORDER BY created_at DESC
 	`
 
-	rows, err := ps.db.QueryContext(ctx, query, userID)
+	return ps.queryPersonalInfo(ctx, query, userID)
+}
+
+// GetPersonalInfoByIDs retrieves personal information entries in the order of ids, skipping those
+// that don't exist
+func (ps *PostgresStore) GetPersonalInfoByIDs(ctx context.Context, ids []string) ([]*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_personal_info_by_ids", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	if len(ids) == 0 {
+		return []*models.PersonalInfo{}, nil
+	}
+
+	query := `
+		SELECT ` + personalInfoColumns + `
+		FROM personal_info
+		WHERE id = ANY($1)
+	`
+
+	found, err := ps.queryPersonalInfo(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*models.PersonalInfo, len(found))
+	for _, personalInfo := range found {
+		byID[personalInfo.ID] = personalInfo
+	}
+
+	ordered := make([]*models.PersonalInfo, 0, len(found))
+	for _, id := range ids {
+		if personalInfo := byID[id]; personalInfo != nil {
+			ordered = append(ordered, personalInfo)
+			byID[id] = nil
+		}
+	}
+	return ordered, nil
+}
+
+// ListPersonalInfoAfter retrieves up to limit entries of all users with IDs after afterID, in ID order
+func (ps *PostgresStore) ListPersonalInfoAfter(ctx context.Context, afterID string, limit int) ([]*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "list_personal_info_after", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT ` + personalInfoColumns + `
+		FROM personal_info
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+
+	return ps.queryPersonalInfo(ctx, query, afterID, limit)
+}
+
+// CountPersonalInfo counts the personal information entries of all users
+func (ps *PostgresStore) CountPersonalInfo(ctx context.Context) (int64, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "count_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	var count int64
+	if err := ps.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM personal_info`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count personal info: %w", err)
+	}
+	return count, nil
+}
+
+// queryPersonalInfo runs a personal info query selecting the standard columns
+func (ps *PostgresStore) queryPersonalInfo(ctx context.Context, query string, args ...interface{}) ([]*models.PersonalInfo, error) {
+	rows, err := ps.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query personal info: %w", err)
 	}
@@ -229,16 +781,7 @@ func (ps *PostgresStore) GetPersonalInfoByUser(ctx context.Context, userID strin
 
 	var personalInfoList []*models.PersonalInfo
 	for rows.Next() {
-		personalInfo := &models.PersonalInfo{}
-		err := rows.Scan(
-			&personalInfo.ID,
-			&personalInfo.UserID,
-			&personalInfo.Content,
-			&personalInfo.Category,
-			&personalInfo.Importance,
-			&personalInfo.CreatedAt,
-			&personalInfo.UpdatedAt,
-		)
+		personalInfo, err := scanPersonalInfo(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan personal info: %w", err)
 		}
@@ -254,6 +797,10 @@ func (ps *PostgresStore) GetPersonalInfoByUser(ctx context.Context, userID strin
 
 // UpdatePersonalInfo updates an existing personal information entry in PostgreSQL
 func (ps *PostgresStore) UpdatePersonalInfo(ctx context.Context, personalInfo *models.PersonalInfo) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "update_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
 	query := `
 		UPDATE personal_info
 		SET content = $1, category = $2, importance = $3, updated_at = $4
@@ -280,7 +827,7 @@ func (ps *PostgresStore) UpdatePersonalInfo(ctx context.Context, personalInfo *m
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("personal info not found")
+		return ErrPersonalInfoNotFound
 	}
 
 	return nil
@@ -288,6 +835,10 @@ func (ps *PostgresStore) UpdatePersonalInfo(ctx context.Context, personalInfo *m
 
 // DeletePersonalInfo deletes a personal information entry from PostgreSQL
 func (ps *PostgresStore) DeletePersonalInfo(ctx context.Context, id string) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "delete_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
 	query := `DELETE FROM personal_info WHERE id = $1`
 
 	result, err := ps.db.ExecContext(ctx, query, id)
@@ -301,7 +852,7 @@ func (ps *PostgresStore) DeletePersonalInfo(ctx context.Context, id string) erro
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("personal info not found")
+		return ErrPersonalInfoNotFound
 	}
 
 	return nil
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// SaveConversationAlias makes an old conversation ID resolve to another conversation, replacing
+// the ID's earlier alias. Aliases that resolved to the old ID are moved along, so no alias ever
+// resolves to another alias
+func (ps *PostgresStore) SaveConversationAlias(ctx context.Context, alias *models.ConversationAlias) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "save_conversation_alias", time.Now())
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE id_aliases SET conversation_id = $2 WHERE conversation_id = $1`, alias.AliasID, alias.ConversationID); err != nil {
+		return fmt.Errorf("failed to move conversation aliases: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO id_aliases (alias_id, conversation_id, reason, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (alias_id) DO UPDATE SET
+			conversation_id = EXCLUDED.conversation_id,
+			reason = EXCLUDED.reason,
+			created_at = EXCLUDED.created_at
+	`, alias.AliasID, alias.ConversationID, alias.Reason, alias.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save conversation alias: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetConversationAlias retrieves the alias of an old conversation ID, or nil if it isn't one
+func (ps *PostgresStore) GetConversationAlias(ctx context.Context, aliasID string) (*models.ConversationAlias, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_conversation_alias", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	alias := &models.ConversationAlias{}
+	err := ps.db.QueryRowContext(ctx,
+		`SELECT alias_id, conversation_id, reason, created_at FROM id_aliases WHERE alias_id = $1`,
+		aliasID,
+	).Scan(&alias.AliasID, &alias.ConversationID, &alias.Reason, &alias.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation alias: %w", err)
+	}
+
+	return alias, nil
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+)
+
+// ExportAnalytics reads the three tables in one read-only repeatable read transaction, so rows
+// committed while the export runs can't show up in one table and be missing from another
+func (ps *PostgresStore) ExportAnalytics(ctx context.Context, from time.Time, to time.Time, sink AnalyticsSink) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "export_analytics", time.Now())
+
+	tx, err := ps.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := ps.exportConversations(ctx, tx, from, to, sink); err != nil {
+		return err
+	}
+	if err := ps.exportMessages(ctx, tx, from, to, sink); err != nil {
+		return err
+	}
+	if err := ps.exportSearchLogs(ctx, tx, from, to, sink); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (ps *PostgresStore) exportConversations(ctx context.Context, tx *sql.Tx, from time.Time, to time.Time, sink AnalyticsSink) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+conversationColumns+`
+		FROM conversations
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY created_at, id
+	`, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to query conversations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		conv, err := scanConversation(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan conversation: %w", err)
+		}
+		if err := ps.decryptConversation(ctx, conv); err != nil {
+			return err
+		}
+		if err := sink.Conversation(conv); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating conversations: %w", err)
+	}
+	return nil
+}
+
+func (ps *PostgresStore) exportMessages(ctx context.Context, tx *sql.Tx, from time.Time, to time.Time, sink AnalyticsSink) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT conversation_id, message_id, position, role, content, speaker, display_name, created_at
+		FROM messages
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY created_at, id
+	`, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to query messages: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			msg         models.AnalyticsMessage
+			speaker     sql.NullString
+			displayName sql.NullString
+		)
+		if err := rows.Scan(&msg.ConversationID, &msg.MessageID, &msg.Position, &msg.Role, &msg.Content, &speaker, &displayName, &msg.CreatedAt); err != nil {
+			return fmt.Errorf("failed to scan message: %w", err)
+		}
+		if msg.Content, err = ps.decrypt(ctx, msg.Content); err != nil {
+			return err
+		}
+		msg.Speaker = speaker.String
+		msg.DisplayName = displayName.String
+		if err := sink.Message(&msg); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating messages: %w", err)
+	}
+	return nil
+}
+
+func (ps *PostgresStore) exportSearchLogs(ctx context.Context, tx *sql.Tx, from time.Time, to time.Time, sink AnalyticsSink) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, tenant, user_id, query, results, top_score, duration_ms, variant, created_at
+		FROM search_logs
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY created_at, id
+	`, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to query search logs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.SearchLog
+		if err := rows.Scan(&entry.ID, &entry.Tenant, &entry.UserID, &entry.Query, &entry.Results, &entry.TopScore, &entry.DurationMs, &entry.Variant, &entry.CreatedAt); err != nil {
+			return fmt.Errorf("failed to scan search log: %w", err)
+		}
+		if entry.Query, err = ps.decrypt(ctx, entry.Query); err != nil {
+			return err
+		}
+		if err := sink.Search(&entry); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating search logs: %w", err)
+	}
+	return nil
+}
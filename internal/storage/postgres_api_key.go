@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// apiKeyColumns is the column list shared by API key queries
+const apiKeyColumns = `id, name, prefix, scopes, created_at, expires_at, revoked_at, rotated_to`
+
+// CreateAPIKey stores a new API key under the hash of its secret
+func (ps *PostgresStore) CreateAPIKey(ctx context.Context, key *models.APIKey, secretHash string) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "create_api_key", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	if err := insertAPIKey(ctx, ps.db, key, secretHash); err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+	return nil
+}
+
+// GetAPIKey retrieves an API key, or nil if it doesn't exist
+func (ps *PostgresStore) GetAPIKey(ctx context.Context, id string) (*models.APIKey, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_api_key", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	key, err := scanAPIKey(ps.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys retrieves API keys newest first, leaving out revoked keys unless includeRevoked is set
+func (ps *PostgresStore) ListAPIKeys(ctx context.Context, includeRevoked bool) ([]*models.APIKey, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "list_api_keys", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE ($1 OR revoked_at IS NULL) ORDER BY created_at DESC`
+	rows, err := ps.db.QueryContext(ctx, query, includeRevoked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// LoadAPIKeys retrieves the keys that are neither revoked nor expired at now, keyed by secret hash
+func (ps *PostgresStore) LoadAPIKeys(ctx context.Context, now time.Time) (map[string]*models.APIKey, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "load_api_keys", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT ` + apiKeyColumns + `, secret_hash FROM api_keys
+		WHERE (revoked_at IS NULL OR revoked_at > $1) AND (expires_at IS NULL OR expires_at > $1)
+	`
+	rows, err := ps.db.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[string]*models.APIKey)
+	for rows.Next() {
+		var hash string
+		key, err := scanAPIKey(rows, &hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys[hash] = key
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RotateAPIKey stores replacement as the successor of the key id and makes the old key expire at
+// oldExpiresAt, unless it expires sooner. It returns the updated old key, or nil if it doesn't
+// exist or is already revoked or rotated
+func (ps *PostgresStore) RotateAPIKey(ctx context.Context, id string, replacement *models.APIKey, secretHash string, oldExpiresAt time.Time) (*models.APIKey, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "rotate_api_key", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE api_keys SET
+			expires_at = LEAST(COALESCE(expires_at, $2), $2),
+			rotated_to = $3
+		WHERE id = $1 AND revoked_at IS NULL AND rotated_to = ''
+		RETURNING ` + apiKeyColumns
+
+	old, err := scanAPIKey(tx.QueryRowContext(ctx, query, id, oldExpiresAt, replacement.ID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate API key: %w", err)
+	}
+
+	if err := insertAPIKey(ctx, tx, replacement, secretHash); err != nil {
+		return nil, fmt.Errorf("failed to create replacement API key: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit API key rotation: %w", err)
+	}
+	return old, nil
+}
+
+// RevokeAPIKey revokes an API key at the given time and returns it, or nil if it doesn't exist.
+// Revoking a revoked key keeps its original revocation time
+func (ps *PostgresStore) RevokeAPIKey(ctx context.Context, id string, at time.Time) (*models.APIKey, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "revoke_api_key", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1 RETURNING ` + apiKeyColumns
+	key, err := scanAPIKey(ps.db.QueryRowContext(ctx, query, id, at))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return key, nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertAPIKey(ctx context.Context, db execer, key *models.APIKey, secretHash string) error {
+	query := `
+		INSERT INTO api_keys (id, name, prefix, secret_hash, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	var expiresAt sql.NullTime
+	if key.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *key.ExpiresAt, Valid: true}
+	}
+	_, err := db.ExecContext(ctx, query, key.ID, key.Name, key.Prefix, secretHash, strings.Join(key.Scopes, ","), key.CreatedAt, expiresAt)
+	return err
+}
+
+// scanAPIKey scans the API key columns followed by any extra columns into extra
+func scanAPIKey(row rowScanner, extra ...interface{}) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	var scopes string
+	var expiresAt, revokedAt sql.NullTime
+
+	dest := []interface{}{
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&scopes,
+		&key.CreatedAt,
+		&expiresAt,
+		&revokedAt,
+		&key.RotatedTo,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Scopes = strings.Split(scopes, ",")
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// BackupTables lists the tables holding server data, in dependency order
+var BackupTables = []string{"users", "sessions", "conversations", "user_stats", "id_aliases", "messages", "conversation_chunks", "personal_info", "user_profiles", "admin_jobs", "work_queue", "dead_letters", "embedding_usage", "api_keys", "tenant_data_keys", "search_logs", "deletion_certificates", "user_query_adapters", "standing_queries", "fusion_bandit_arms", "fusion_bandit_tenants"}
+
+// TableRowCounts counts the rows of each table in one snapshot
+func (ps *PostgresStore) TableRowCounts(ctx context.Context, tables []string) (map[string]int64, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "table_row_counts", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Repeatable read so all counts see the same snapshot
+	if _, err := tx.ExecContext(ctx, `SET TRANSACTION ISOLATION LEVEL REPEATABLE READ READ ONLY`); err != nil {
+		return nil, fmt.Errorf("failed to set transaction isolation: %w", err)
+	}
+
+	counts := make(map[string]int64, len(tables))
+	for _, table := range tables {
+		var count int64
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+pq.QuoteIdentifier(table)).Scan(&count); err != nil {
+			return nil, fmt.Errorf("failed to count %s rows: %w", table, err)
+		}
+		counts[table] = count
+	}
+
+	return counts, nil
+}
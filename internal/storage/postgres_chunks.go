@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// GetConversationChunks retrieves a conversation's chunks with their vectors in position order
+func (ps *PostgresStore) GetConversationChunks(ctx context.Context, conversationID string) ([]models.ConversationChunk, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_conversation_chunks", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	rows, err := ps.db.QueryContext(ctx, `
+		SELECT position, content_hash, model, dimension, vector FROM conversation_chunks
+		WHERE conversation_id = $1
+		ORDER BY position
+	`, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation chunks: %w", err)
+	}
+	defer rows.Close()
+
+	chunks := []models.ConversationChunk{}
+	for rows.Next() {
+		var chunk models.ConversationChunk
+		var vector []byte
+		if err := rows.Scan(&chunk.Position, &chunk.ContentHash, &chunk.Model, &chunk.Dimension, &vector); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation chunk: %w", err)
+		}
+		if err := json.Unmarshal(vector, &chunk.Vector); err != nil {
+			return nil, fmt.Errorf("failed to decode conversation chunk vector: %w", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating conversation chunks: %w", err)
+	}
+
+	return chunks, nil
+}
+
+// ReplaceConversationChunks replaces a stored conversation's chunks in one transaction
+func (ps *PostgresStore) ReplaceConversationChunks(ctx context.Context, conversationID string, chunks []models.ConversationChunk) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "replace_conversation_chunks", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM conversation_chunks WHERE conversation_id = $1`, conversationID); err != nil {
+		return fmt.Errorf("failed to delete conversation chunks: %w", err)
+	}
+	for _, chunk := range chunks {
+		vector, err := json.Marshal(chunk.Vector)
+		if err != nil {
+			return fmt.Errorf("failed to encode conversation chunk vector: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO conversation_chunks (conversation_id, position, content_hash, model, dimension, vector)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, conversationID, chunk.Position, chunk.ContentHash, chunk.Model, chunk.Dimension, vector)
+		if err != nil {
+			return fmt.Errorf("failed to save conversation chunk: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
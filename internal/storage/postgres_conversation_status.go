@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// conversationStatus returns the status to store for a conversation; conversations saved without
+// one are indexed
+func conversationStatus(conv *models.Conversation) string {
+	if conv.Status == "" {
+		return models.ConversationStatusIndexed
+	}
+	return conv.Status
+}
+
+// SetConversationStatus moves a conversation to status if its current status is one of from; it
+// reports false if the conversation doesn't exist or is in another status
+func (ps *PostgresStore) SetConversationStatus(ctx context.Context, id string, status string, from []string) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "set_conversation_status", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `UPDATE conversations SET status = $2 WHERE id = $1 AND status = ANY($3)`
+
+	result, err := ps.db.ExecContext(ctx, query, id, status, pq.Array(from))
+	if err != nil {
+		return false, fmt.Errorf("failed to update conversation status: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// ListUserConversations retrieves a page of a user's conversations, newest first, optionally in
+// one status, and their total count
+func (ps *PostgresStore) ListUserConversations(ctx context.Context, userID string, status string, limit int, offset int) ([]*models.Conversation, int, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "list_user_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	// The unfiltered total is the user's maintained stats rather than a count of their rows
+	countQuery, countArgs := `SELECT COALESCE((SELECT conversation_count FROM user_stats WHERE user_id = $1), 0)`, []interface{}{userID}
+	if status != "" {
+		countQuery, countArgs = `SELECT COUNT(*) FROM conversations WHERE user_id = $1 AND status = $2`, []interface{}{userID, status}
+	}
+	var total int
+	if err := ps.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count conversations: %w", err)
+	}
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC, id
+		LIMIT $3 OFFSET $4
+	`
+
+	conversations, err := ps.queryConversations(ctx, query, userID, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return conversations, total, nil
+}
+
+// ListUserConversationsBefore retrieves up to limit of a user's conversations created before a
+// time, newest first
+func (ps *PostgresStore) ListUserConversationsBefore(ctx context.Context, userID string, before time.Time, limit int) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "list_user_conversations_before", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = $1 AND created_at < $2
+		ORDER BY created_at DESC, id
+		LIMIT $3
+	`
+
+	return ps.queryConversations(ctx, query, userID, before.UTC(), limit)
+}
+
+// CountUserConversationsBetween counts a user's conversations created at or after from and
+// before to
+func (ps *PostgresStore) CountUserConversationsBetween(ctx context.Context, userID string, from time.Time, to time.Time) (int, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "count_user_conversations_between", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `SELECT COUNT(*) FROM conversations WHERE user_id = $1 AND created_at >= $2 AND created_at < $3`
+
+	var count int
+	if err := ps.db.QueryRowContext(ctx, query, userID, from.UTC(), to.UTC()).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count conversations: %w", err)
+	}
+	return count, nil
+}
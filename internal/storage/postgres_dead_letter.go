@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// deadLetterColumns lists the dead_letters columns in the order scanDeadLetter reads them
+const deadLetterColumns = `id, kind, payload, attempts, last_error, created_at, failed_at`
+
+// DeadLetterQueueItem moves a queue item held by owner to the dead letter table
+func (ps *PostgresStore) DeadLetterQueueItem(ctx context.Context, id int64, owner string, lastError string) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "dead_letter_queue_item", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO dead_letters (id, kind, payload, attempts, last_error, created_at, failed_at)
+		SELECT id, kind, payload, attempts, $3, created_at, NOW()
+		FROM work_queue
+		WHERE id = $1 AND lease_owner = $2
+	`
+	if _, err := tx.ExecContext(ctx, query, id, owner, lastError); err != nil {
+		return fmt.Errorf("failed to dead-letter queue item: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM work_queue WHERE id = $1 AND lease_owner = $2`, id, owner); err != nil {
+		return fmt.Errorf("failed to remove dead-lettered queue item: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListDeadLetters retrieves a page of dead letters, newest first, optionally of one kind
+func (ps *PostgresStore) ListDeadLetters(ctx context.Context, kind string, limit int, offset int) ([]*models.DeadLetter, int, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "list_dead_letters", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	var total int
+	if err := ps.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM dead_letters WHERE $1 = '' OR kind = $1`, kind).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count dead letters: %w", err)
+	}
+
+	query := `SELECT ` + deadLetterColumns + ` FROM dead_letters
+		WHERE $1 = '' OR kind = $1
+		ORDER BY failed_at DESC, id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := ps.db.QueryContext(ctx, query, kind, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	deadLetters := []*models.DeadLetter{}
+	for rows.Next() {
+		deadLetter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read dead letters: %w", err)
+	}
+
+	return deadLetters, total, nil
+}
+
+// GetDeadLetter retrieves a dead letter by ID
+func (ps *PostgresStore) GetDeadLetter(ctx context.Context, id int64) (*models.DeadLetter, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_dead_letter", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `SELECT ` + deadLetterColumns + ` FROM dead_letters WHERE id = $1`
+
+	deadLetter, err := scanDeadLetter(ps.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+	return deadLetter, nil
+}
+
+// RequeueDeadLetter moves a dead letter back to the work queue with its attempts reset; it
+// reports false if the dead letter doesn't exist
+func (ps *PostgresStore) RequeueDeadLetter(ctx context.Context, id int64) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "requeue_dead_letter", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO work_queue (kind, payload, available_at, created_at)
+		SELECT kind, payload, NOW(), created_at FROM dead_letters WHERE id = $1
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to requeue dead letter: %w", err)
+	}
+	if found, err := rowsAffected(result); err != nil || !found {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM dead_letters WHERE id = $1`, id); err != nil {
+		return false, fmt.Errorf("failed to remove requeued dead letter: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// DeleteDeadLetter discards a dead letter; it reports false if it doesn't exist
+func (ps *PostgresStore) DeleteDeadLetter(ctx context.Context, id int64) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "delete_dead_letter", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	result, err := ps.db.ExecContext(ctx, `DELETE FROM dead_letters WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	return rowsAffected(result)
+}
+
+// CountDeadLetters counts the dead letters of each kind
+func (ps *PostgresStore) CountDeadLetters(ctx context.Context) (map[string]int64, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "count_dead_letters", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	rows, err := ps.db.QueryContext(ctx, `SELECT kind, COUNT(*) FROM dead_letters GROUP BY kind`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var kind string
+		var count int64
+		if err := rows.Scan(&kind, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter count: %w", err)
+		}
+		counts[kind] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dead letter counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// scanDeadLetter reads a dead letter row selected with deadLetterColumns
+func scanDeadLetter(row rowScanner) (*models.DeadLetter, error) {
+	deadLetter := &models.DeadLetter{}
+	var payload []byte
+	if err := row.Scan(&deadLetter.ID, &deadLetter.Kind, &payload, &deadLetter.Attempts, &deadLetter.LastError, &deadLetter.CreatedAt, &deadLetter.FailedAt); err != nil {
+		return nil, err
+	}
+	deadLetter.Payload = payload
+	return deadLetter, nil
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// SaveDeletionCertificate stores a signed certificate
+func (ps *PostgresStore) SaveDeletionCertificate(ctx context.Context, cert *models.DeletionCertificate) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "save_deletion_certificate", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	document, err := json.Marshal(cert)
+	if err != nil {
+		return fmt.Errorf("failed to encode deletion certificate: %w", err)
+	}
+
+	query := `
+		INSERT INTO deletion_certificates (id, job_id, kind, target, certificate, deleted_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	if _, err := ps.db.ExecContext(ctx, query, cert.ID, cert.JobID, cert.Kind, cert.Target, document, cert.DeletedAt); err != nil {
+		return fmt.Errorf("failed to save deletion certificate: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeletionCertificate retrieves a certificate by ID
+func (ps *PostgresStore) GetDeletionCertificate(ctx context.Context, id string) (*models.DeletionCertificate, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_deletion_certificate", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	var document []byte
+	err := ps.db.QueryRowContext(ctx, `SELECT certificate FROM deletion_certificates WHERE id = $1`, id).Scan(&document)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deletion certificate: %w", err)
+	}
+
+	return decodeDeletionCertificate(document)
+}
+
+// ListDeletionCertificates retrieves the most recent certificates, optionally of one kind and target
+func (ps *PostgresStore) ListDeletionCertificates(ctx context.Context, kind string, target string, limit int) ([]*models.DeletionCertificate, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "list_deletion_certificates", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT certificate
+		FROM deletion_certificates
+		WHERE ($1 = '' OR kind = $1) AND ($2 = '' OR target = $2)
+		ORDER BY deleted_at DESC
+		LIMIT $3
+	`
+
+	rows, err := ps.db.QueryContext(ctx, query, kind, target, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deletion certificates: %w", err)
+	}
+	defer rows.Close()
+
+	certificates := []*models.DeletionCertificate{}
+	for rows.Next() {
+		var document []byte
+		if err := rows.Scan(&document); err != nil {
+			return nil, fmt.Errorf("failed to scan deletion certificate: %w", err)
+		}
+		cert, err := decodeDeletionCertificate(document)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, cert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deletion certificates: %w", err)
+	}
+
+	return certificates, nil
+}
+
+func decodeDeletionCertificate(document []byte) (*models.DeletionCertificate, error) {
+	cert := &models.DeletionCertificate{}
+	if err := json.Unmarshal(document, cert); err != nil {
+		return nil, fmt.Errorf("failed to decode deletion certificate: %w", err)
+	}
+	return cert, nil
+}
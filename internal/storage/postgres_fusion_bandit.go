@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// AddFusionBanditFeedback counts one rated trial of a tenant's arm, rewarded if relevant
+func (ps *PostgresStore) AddFusionBanditFeedback(ctx context.Context, tenant string, arm string, relevant bool) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "add_fusion_bandit_feedback", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	reward := 0
+	if relevant {
+		reward = 1
+	}
+	_, err := ps.db.ExecContext(ctx, `
+		INSERT INTO fusion_bandit_arms (tenant, arm, trials, rewards, updated_at)
+		VALUES ($1, $2, 1, $3, $4)
+		ON CONFLICT (tenant, arm) DO UPDATE SET
+			trials = fusion_bandit_arms.trials + 1,
+			rewards = fusion_bandit_arms.rewards + EXCLUDED.rewards,
+			updated_at = EXCLUDED.updated_at
+	`, tenant, arm, reward, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to add fusion bandit feedback: %w", err)
+	}
+
+	return nil
+}
+
+// GetFusionBanditArms retrieves the stats of a tenant's arms that collected feedback
+func (ps *PostgresStore) GetFusionBanditArms(ctx context.Context, tenant string) ([]models.FusionBanditArmStats, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_fusion_bandit_arms", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	rows, err := ps.db.QueryContext(ctx, `SELECT arm, trials, rewards FROM fusion_bandit_arms WHERE tenant = $1 ORDER BY arm`, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fusion bandit arms: %w", err)
+	}
+	defer rows.Close()
+
+	arms := []models.FusionBanditArmStats{}
+	for rows.Next() {
+		var arm models.FusionBanditArmStats
+		if err := rows.Scan(&arm.Arm, &arm.Trials, &arm.Rewards); err != nil {
+			return nil, fmt.Errorf("failed to scan fusion bandit arm: %w", err)
+		}
+		arms = append(arms, arm)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating fusion bandit arms: %w", err)
+	}
+
+	return arms, nil
+}
+
+// GetFusionBanditTenant retrieves a tenant's bandit state; a tenant without one is returned
+// unfrozen
+func (ps *PostgresStore) GetFusionBanditTenant(ctx context.Context, tenant string) (*models.FusionBanditTenant, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_fusion_bandit_tenant", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	state := &models.FusionBanditTenant{Tenant: tenant}
+	var frozenAt sql.NullTime
+	err := ps.db.QueryRowContext(ctx,
+		`SELECT frozen_arm, frozen_at FROM fusion_bandit_tenants WHERE tenant = $1`, tenant,
+	).Scan(&state.FrozenArm, &frozenAt)
+	if err == sql.ErrNoRows {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fusion bandit tenant: %w", err)
+	}
+	if frozenAt.Valid {
+		state.FrozenAt = &frozenAt.Time
+	}
+
+	return state, nil
+}
+
+// SetFusionBanditFrozenArm pins a tenant's searches to arm, or unpins them when arm is empty
+func (ps *PostgresStore) SetFusionBanditFrozenArm(ctx context.Context, tenant string, arm string) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "set_fusion_bandit_frozen_arm", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	var frozenAt *time.Time
+	if arm != "" {
+		now := time.Now().UTC()
+		frozenAt = &now
+	}
+	_, err := ps.db.ExecContext(ctx, `
+		INSERT INTO fusion_bandit_tenants (tenant, frozen_arm, frozen_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (tenant) DO UPDATE SET frozen_arm = EXCLUDED.frozen_arm, frozen_at = EXCLUDED.frozen_at
+	`, tenant, arm, frozenAt)
+	if err != nil {
+		return fmt.Errorf("failed to set fusion bandit frozen arm: %w", err)
+	}
+
+	return nil
+}
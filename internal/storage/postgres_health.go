@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// TableStats reports the on-disk size, estimated rows and index sizes of each table
+func (ps *PostgresStore) TableStats(ctx context.Context, tables []string) ([]models.TableStats, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "table_stats", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	stats := make([]models.TableStats, 0, len(tables))
+	for _, table := range tables {
+		ts := models.TableStats{Name: table, Indexes: []models.IndexStats{}}
+		err := ps.db.QueryRowContext(ctx, `
+			SELECT pg_total_relation_size(c.oid), pg_relation_size(c.oid), pg_indexes_size(c.oid),
+				GREATEST(c.reltuples, 0)::bigint, COALESCE(s.n_dead_tup, 0),
+				GREATEST(s.last_vacuum, s.last_autovacuum)
+			FROM pg_class c
+			LEFT JOIN pg_stat_user_tables s ON s.relid = c.oid
+			WHERE c.oid = to_regclass($1)
+		`, table).Scan(&ts.TotalBytes, &ts.TableBytes, &ts.IndexBytes, &ts.EstimatedRows, &ts.DeadRows, &ts.LastVacuum)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s table stats: %w", table, err)
+		}
+
+		rows, err := ps.db.QueryContext(ctx, `
+			SELECT indexrelname, pg_relation_size(indexrelid), idx_scan
+			FROM pg_stat_user_indexes
+			WHERE relid = to_regclass($1)
+			ORDER BY indexrelname
+		`, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s index stats: %w", table, err)
+		}
+		for rows.Next() {
+			var index models.IndexStats
+			if err := rows.Scan(&index.Name, &index.Bytes, &index.Scans); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan %s index stats: %w", table, err)
+			}
+			ts.Indexes = append(ts.Indexes, index)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s index stats: %w", table, err)
+		}
+
+		stats = append(stats, ts)
+	}
+
+	return stats, nil
+}
+
+// VacuumAnalyze vacuums a table and refreshes its planner statistics; it can't run in a transaction
+func (ps *PostgresStore) VacuumAnalyze(ctx context.Context, table string) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "vacuum_analyze", time.Now())
+
+	if _, err := ps.db.ExecContext(ctx, `VACUUM (ANALYZE) `+pq.QuoteIdentifier(table)); err != nil {
+		return fmt.Errorf("failed to vacuum %s: %w", table, err)
+	}
+	return nil
+}
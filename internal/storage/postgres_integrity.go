@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// ListConversationIDs returns up to limit conversation IDs greater than afterID in ID order,
+// optionally of one user; pass the last ID returned to get the next page
+func (ps *PostgresStore) ListConversationIDs(ctx context.Context, userID string, afterID string, limit int) ([]string, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "list_conversation_ids", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT id FROM conversations
+		WHERE id > $1 AND ($2 = '' OR user_id = $2)
+		ORDER BY id
+		LIMIT $3
+	`
+
+	rows, err := ps.db.QueryContext(ctx, query, afterID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversation IDs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating conversation IDs: %w", err)
+	}
+
+	return ids, nil
+}
+
+// SetConversationContentHash records the hash of the text last embedded for a conversation
+func (ps *PostgresStore) SetConversationContentHash(ctx context.Context, id string, contentHash string) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "set_conversation_content_hash", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	if _, err := ps.db.ExecContext(ctx, `UPDATE conversations SET content_hash = $2 WHERE id = $1`, id, contentHash); err != nil {
+		return fmt.Errorf("failed to set conversation content hash: %w", err)
+	}
+	return nil
+}
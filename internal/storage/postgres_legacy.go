@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// legacyConversationCondition matches conversations without a user ID or stored messages
+const legacyConversationCondition = `(user_id = '' OR NOT EXISTS (SELECT 1 FROM messages m WHERE m.conversation_id = conversations.id))`
+
+// CountLegacyConversations counts the conversations without a user ID or stored messages
+func (ps *PostgresStore) CountLegacyConversations(ctx context.Context) (int64, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "count_legacy_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	var count int64
+	if err := ps.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM conversations WHERE `+legacyConversationCondition).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count legacy conversations: %w", err)
+	}
+
+	return count, nil
+}
+
+// ListLegacyConversationsAfter retrieves a page of conversations without a user ID or stored
+// messages in ID order, starting after afterID
+func (ps *PostgresStore) ListLegacyConversationsAfter(ctx context.Context, afterID string, limit int) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "list_legacy_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE id > $1 AND ` + legacyConversationCondition + `
+		ORDER BY id
+		LIMIT $2
+	`
+
+	return ps.queryConversations(ctx, query, afterID, limit)
+}
+
+// UpgradeLegacyConversation stores a legacy conversation's user ID, question, answer and messages
+// in one transaction
+func (ps *PostgresStore) UpgradeLegacyConversation(ctx context.Context, conv *models.Conversation) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "upgrade_legacy_conversation", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	question, err := ps.encrypt(ctx, conv.Question)
+	if err != nil {
+		return err
+	}
+	answer, err := ps.encrypt(ctx, conv.Answer)
+	if err != nil {
+		return err
+	}
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE conversations SET user_id = $2, question = $3, answer = $4, updated_at = $5
+		WHERE id = $1
+	`, conv.ID, conv.UserID, question, answer, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to upgrade legacy conversation: %w", err)
+	}
+
+	if err := ps.saveMessages(ctx, tx, conv.ID, conv.Messages); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit legacy conversation: %w", err)
+	}
+
+	return nil
+}
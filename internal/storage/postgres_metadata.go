@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/filter"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// SetConversationMetadata replaces a conversation's metadata JSON; it reports false if the
+// conversation doesn't exist
+func (ps *PostgresStore) SetConversationMetadata(ctx context.Context, id string, metadata string) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "set_conversation_metadata", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	result, err := ps.db.ExecContext(ctx, `UPDATE conversations SET metadata = $2 WHERE id = $1`, id, metadata)
+	if err != nil {
+		return false, fmt.Errorf("failed to set conversation metadata: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// MatchConversationIDs returns up to limit IDs of the conversations query selects greater than
+// afterID in ID order. The metadata filter runs in SQL, where the GIN index on metadata serves
+// equality and IN comparisons
+func (ps *PostgresStore) MatchConversationIDs(ctx context.Context, query ConversationQuery, afterID string, limit int) ([]string, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "match_conversation_ids", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	args := []interface{}{afterID, query.UserID, query.CreatedAfter, query.CreatedBefore, limit}
+	condition, args := filter.Postgres(query.Filter, "metadata", models.MetadataFieldPath, args)
+
+	rows, err := ps.db.QueryContext(ctx, `
+		SELECT id FROM conversations
+		WHERE id > $1 AND ($2 = '' OR user_id = $2)
+			AND ($3::timestamptz IS NULL OR created_at >= $3)
+			AND ($4::timestamptz IS NULL OR created_at < $4)
+			AND `+condition+`
+		ORDER BY id
+		LIMIT $5
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match conversations: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating matched conversation IDs: %w", err)
+	}
+
+	return ids, nil
+}
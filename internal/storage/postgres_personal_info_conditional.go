@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// ErrPersonalInfoChanged is returned by conditional writes when the entry was updated or deleted
+// after the caller read it; it is an ErrConflict
+var ErrPersonalInfoChanged error = &kindError{kind: ErrConflict, msg: "personal info changed since it was read"}
+
+// UpdatePersonalInfoIfUnchanged updates an entry only if its updated_at still equals readAt, the
+// value the caller read; otherwise it returns ErrPersonalInfoChanged
+func (ps *PostgresStore) UpdatePersonalInfoIfUnchanged(ctx context.Context, personalInfo *models.PersonalInfo, readAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "update_personal_info_if_unchanged", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		UPDATE personal_info
+		SET content = $1, category = $2, importance = $3, updated_at = $4
+		WHERE id = $5 AND updated_at = $6
+	`
+
+	result, err := ps.db.ExecContext(ctx, query,
+		personalInfo.Content,
+		personalInfo.Category,
+		personalInfo.Importance,
+		personalInfo.UpdatedAt,
+		personalInfo.ID,
+		readAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update personal info: %w", err)
+	}
+
+	found, err := rowsAffected(result)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrPersonalInfoChanged
+	}
+	return nil
+}
+
+// DeletePersonalInfoIfUnchanged deletes an entry only if its updated_at still equals readAt;
+// otherwise it returns ErrPersonalInfoChanged
+func (ps *PostgresStore) DeletePersonalInfoIfUnchanged(ctx context.Context, id string, readAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "delete_personal_info_if_unchanged", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	result, err := ps.db.ExecContext(ctx, `DELETE FROM personal_info WHERE id = $1 AND updated_at = $2`, id, readAt)
+	if err != nil {
+		return fmt.Errorf("failed to delete personal info: %w", err)
+	}
+
+	found, err := rowsAffected(result)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrPersonalInfoChanged
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// SetConversationPinned pins or unpins a conversation; it reports false if the conversation doesn't exist
+func (ps *PostgresStore) SetConversationPinned(ctx context.Context, id string, pinned bool) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "set_conversation_pinned", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	result, err := ps.db.ExecContext(ctx, `UPDATE conversations SET pinned = $2 WHERE id = $1`, id, pinned)
+	if err != nil {
+		return false, fmt.Errorf("failed to pin conversation: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// GetPinnedConversations retrieves a user's pinned, unsuppressed conversations, newest first
+func (ps *PostgresStore) GetPinnedConversations(ctx context.Context, userID string) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_pinned_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = $1 AND pinned AND suppressed_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	return ps.queryConversations(ctx, query, userID)
+}
+
+// SetPersonalInfoPinned pins or unpins a personal information entry; it reports false if the entry doesn't exist
+func (ps *PostgresStore) SetPersonalInfoPinned(ctx context.Context, id string, pinned bool) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "set_personal_info_pinned", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	result, err := ps.db.ExecContext(ctx, `UPDATE personal_info SET pinned = $2 WHERE id = $1`, id, pinned)
+	if err != nil {
+		return false, fmt.Errorf("failed to pin personal info: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// GetPinnedPersonalInfo retrieves a user's pinned, unsuppressed personal information entries, newest first
+func (ps *PostgresStore) GetPinnedPersonalInfo(ctx context.Context, userID string) ([]*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_pinned_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT ` + personalInfoColumns + `
+		FROM personal_info
+		WHERE user_id = $1 AND pinned AND suppressed_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	return ps.queryPersonalInfo(ctx, query, userID)
+}
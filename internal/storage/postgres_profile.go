@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// GetUserProfile retrieves a user's cached profile
+func (ps *PostgresStore) GetUserProfile(ctx context.Context, userID string) (*models.UserProfile, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_user_profile", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `SELECT profile FROM user_profiles WHERE user_id = $1`
+
+	var data []byte
+	err := ps.db.QueryRowContext(ctx, query, userID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+
+	// An encrypted profile is stored as a JSON string of its encrypted JSON
+	var encrypted string
+	if json.Unmarshal(data, &encrypted) == nil {
+		plaintext, err := ps.decrypt(ctx, encrypted)
+		if err != nil {
+			return nil, err
+		}
+		data = []byte(plaintext)
+	}
+
+	profile := &models.UserProfile{}
+	if err := json.Unmarshal(data, profile); err != nil {
+		return nil, fmt.Errorf("failed to decode user profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+// SaveUserProfile inserts or replaces a user's cached profile, encrypted when a content cipher is set
+func (ps *PostgresStore) SaveUserProfile(ctx context.Context, profile *models.UserProfile) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "save_user_profile", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("failed to encode user profile: %w", err)
+	}
+	if ps.cipher != nil {
+		encrypted, err := ps.encrypt(ctx, string(data))
+		if err != nil {
+			return err
+		}
+		if data, err = json.Marshal(encrypted); err != nil {
+			return fmt.Errorf("failed to encode user profile: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO user_profiles (user_id, profile, generated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			profile = EXCLUDED.profile,
+			generated_at = EXCLUDED.generated_at
+	`
+
+	if _, err := ps.db.ExecContext(ctx, query, profile.UserID, data, profile.GeneratedAt); err != nil {
+		return fmt.Errorf("failed to save user profile: %w", err)
+	}
+
+	return nil
+}
+
+// ListStaleProfiles returns users whose cached profile was generated before the given time, oldest first
+func (ps *PostgresStore) ListStaleProfiles(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "list_stale_profiles", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT user_id FROM user_profiles
+		WHERE generated_at < $1
+		ORDER BY generated_at ASC
+		LIMIT $2
+	`
+
+	rows, err := ps.db.QueryContext(ctx, query, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stale profiles: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan user id: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stale profiles: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+// GetTopConversationsByUser retrieves a user's unsuppressed conversations ordered by conversation_score, then recency
+func (ps *PostgresStore) GetTopConversationsByUser(ctx context.Context, userID string, limit int) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_top_conversations_by_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = $1 AND suppressed_at IS NULL
+		ORDER BY
+			CASE WHEN metadata->>'conversation_score' ~ '^-?[0-9]+$'
+				THEN (metadata->>'conversation_score')::int END DESC NULLS LAST,
+			updated_at DESC
+		LIMIT $2
+	`
+
+	return ps.queryConversations(ctx, query, userID, limit)
+}
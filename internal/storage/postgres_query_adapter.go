@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// GetQueryAdapter retrieves a user's adapter for a model
+func (ps *PostgresStore) GetQueryAdapter(ctx context.Context, userID string, model string) (*models.QueryAdapter, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_query_adapter", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	var data []byte
+	err := ps.db.QueryRowContext(ctx,
+		`SELECT adapter FROM user_query_adapters WHERE user_id = $1 AND model = $2`,
+		userID, model,
+	).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get query adapter: %w", err)
+	}
+
+	return decodeQueryAdapter(data)
+}
+
+// ListQueryAdapters retrieves all of a user's adapters, ordered by model
+func (ps *PostgresStore) ListQueryAdapters(ctx context.Context, userID string) ([]*models.QueryAdapter, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "list_query_adapters", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	rows, err := ps.db.QueryContext(ctx, `SELECT adapter FROM user_query_adapters WHERE user_id = $1 ORDER BY model`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list query adapters: %w", err)
+	}
+	defer rows.Close()
+
+	adapters := []*models.QueryAdapter{}
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan query adapter: %w", err)
+		}
+		adapter, err := decodeQueryAdapter(data)
+		if err != nil {
+			return nil, err
+		}
+		adapters = append(adapters, adapter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating query adapters: %w", err)
+	}
+
+	return adapters, nil
+}
+
+// SaveQueryAdapter inserts or replaces a user's adapter for its model
+func (ps *PostgresStore) SaveQueryAdapter(ctx context.Context, adapter *models.QueryAdapter) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "save_query_adapter", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	data, err := json.Marshal(adapter)
+	if err != nil {
+		return fmt.Errorf("failed to encode query adapter: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_query_adapters (user_id, model, adapter, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, model) DO UPDATE SET
+			adapter = EXCLUDED.adapter,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := ps.db.ExecContext(ctx, query, adapter.UserID, adapter.Model, data, adapter.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save query adapter: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteQueryAdapter deletes a user's adapter for a model
+func (ps *PostgresStore) DeleteQueryAdapter(ctx context.Context, userID string, model string) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "delete_query_adapter", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	result, err := ps.db.ExecContext(ctx, `DELETE FROM user_query_adapters WHERE user_id = $1 AND model = $2`, userID, model)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete query adapter: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// decodeQueryAdapter decodes a stored adapter document
+func decodeQueryAdapter(data []byte) (*models.QueryAdapter, error) {
+	adapter := &models.QueryAdapter{}
+	if err := json.Unmarshal(data, adapter); err != nil {
+		return nil, fmt.Errorf("failed to decode query adapter: %w", err)
+	}
+	return adapter, nil
+}
@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// Enqueue adds an item to the work queue, available immediately
+func (ps *PostgresStore) Enqueue(ctx context.Context, kind string, payload interface{}) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "enqueue", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	_, err := enqueue(ctx, ps.db, kind, payload, time.Now())
+	return err
+}
+
+// enqueue inserts a work queue item available at availableAt and returns its ID
+func enqueue(ctx context.Context, db queryRower, kind string, payload interface{}, availableAt time.Time) (int64, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal queue payload: %w", err)
+	}
+
+	query := `
+		INSERT INTO work_queue (kind, payload, available_at, created_at)
+		VALUES ($1, $2, $3, NOW())
+		RETURNING id
+	`
+
+	var id int64
+	if err := db.QueryRowContext(ctx, query, kind, data, availableAt).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to enqueue %s item: %w", kind, err)
+	}
+
+	return id, nil
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// DeleteQueueItem removes an item regardless of its lease, e.g. once its work was done inline
+func (ps *PostgresStore) DeleteQueueItem(ctx context.Context, id int64) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "delete_queue_item", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	if _, err := ps.db.ExecContext(ctx, `DELETE FROM work_queue WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete queue item: %w", err)
+	}
+	return nil
+}
+
+// ClaimQueueItems leases up to limit due items of the given kinds to owner. Items leased by
+// another worker are skipped unless their lease expired, so replicas never process the same
+// item concurrently.
+func (ps *PostgresStore) ClaimQueueItems(ctx context.Context, owner string, kinds []string, limit int, lease time.Duration) ([]*models.QueueItem, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "claim_queue_items", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		UPDATE work_queue
+		SET lease_owner = $1, lease_expires_at = NOW() + $2 * INTERVAL '1 millisecond', attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM work_queue
+			WHERE kind = ANY($3) AND available_at <= NOW()
+				AND (lease_expires_at IS NULL OR lease_expires_at < NOW())
+			ORDER BY available_at, id
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, kind, payload, attempts, last_error, available_at, created_at
+	`
+
+	rows, err := ps.db.QueryContext(ctx, query, owner, lease.Milliseconds(), pq.Array(kinds), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim queue items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*models.QueueItem
+	for rows.Next() {
+		item := &models.QueueItem{}
+		var payload []byte
+		if err := rows.Scan(&item.ID, &item.Kind, &payload, &item.Attempts, &item.LastError, &item.AvailableAt, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan queue item: %w", err)
+		}
+		item.Payload = payload
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read queue items: %w", err)
+	}
+
+	return items, nil
+}
+
+// ExtendQueueLease extends an item's lease; it reports false if owner no longer holds it
+func (ps *PostgresStore) ExtendQueueLease(ctx context.Context, id int64, owner string, lease time.Duration) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "extend_queue_lease", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		UPDATE work_queue
+		SET lease_expires_at = NOW() + $3 * INTERVAL '1 millisecond'
+		WHERE id = $1 AND lease_owner = $2
+	`
+
+	result, err := ps.db.ExecContext(ctx, query, id, owner, lease.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to extend queue lease: %w", err)
+	}
+	return rowsAffected(result)
+}
+
+// CompleteQueueItem removes a processed item held by owner
+func (ps *PostgresStore) CompleteQueueItem(ctx context.Context, id int64, owner string) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "complete_queue_item", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	if _, err := ps.db.ExecContext(ctx, `DELETE FROM work_queue WHERE id = $1 AND lease_owner = $2`, id, owner); err != nil {
+		return fmt.Errorf("failed to complete queue item: %w", err)
+	}
+	return nil
+}
+
+// RetryQueueItem releases a failed item held by owner, making it available again at retryAt
+func (ps *PostgresStore) RetryQueueItem(ctx context.Context, id int64, owner string, lastError string, retryAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "retry_queue_item", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		UPDATE work_queue
+		SET lease_owner = NULL, lease_expires_at = NULL, last_error = $3, available_at = $4
+		WHERE id = $1 AND lease_owner = $2
+	`
+
+	if _, err := ps.db.ExecContext(ctx, query, id, owner, lastError, retryAt); err != nil {
+		return fmt.Errorf("failed to release queue item: %w", err)
+	}
+	return nil
+}
+
+// QueueStats reports the backlog of each queue item kind
+func (ps *PostgresStore) QueueStats(ctx context.Context) ([]models.QueueKindStats, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "queue_stats", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT kind, COUNT(*),
+			COUNT(*) FILTER (WHERE lease_expires_at >= NOW()),
+			COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(available_at) FILTER (
+				WHERE available_at <= NOW() AND (lease_expires_at IS NULL OR lease_expires_at < NOW())
+			)), 0),
+			COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(created_at)), 0)
+		FROM work_queue
+		GROUP BY kind
+		ORDER BY kind
+	`
+
+	rows, err := ps.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []models.QueueKindStats{}
+	for rows.Next() {
+		var s models.QueueKindStats
+		if err := rows.Scan(&s.Kind, &s.Depth, &s.Leased, &s.LagSeconds, &s.OldestSeconds); err != nil {
+			return nil, fmt.Errorf("failed to scan queue stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read queue stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// rowsAffected reports whether a statement changed any row
+func rowsAffected(result sql.Result) (bool, error) {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return n > 0, nil
+}
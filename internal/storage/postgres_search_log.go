@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// LogSearch stores a search log entry, encrypting the query like conversation content
+func (ps *PostgresStore) LogSearch(ctx context.Context, entry *models.SearchLog) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "log_search", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query, err := ps.encrypt(ctx, entry.Query)
+	if err != nil {
+		return err
+	}
+
+	err = ps.db.QueryRowContext(ctx, `
+		INSERT INTO search_logs (tenant, user_id, query, results, top_score, duration_ms, variant, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, entry.Tenant, entry.UserID, query, entry.Results, entry.TopScore, entry.DurationMs, entry.Variant, entry.CreatedAt).Scan(&entry.ID)
+	if err != nil {
+		return fmt.Errorf("failed to log search: %w", err)
+	}
+	return nil
+}
+
+// ListSearchQueries retrieves up to limit decrypted queries of a user's searches in a tenant that
+// found results, logged at or after since, newest first
+func (ps *PostgresStore) ListSearchQueries(ctx context.Context, tenant string, userID string, since time.Time, limit int) ([]string, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "list_search_queries", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	rows, err := ps.db.QueryContext(ctx, `
+		SELECT query
+		FROM search_logs
+		WHERE user_id = $1 AND tenant = $2 AND results > 0 AND created_at >= $3
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
+	`, userID, tenant, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list search queries: %w", err)
+	}
+	defer rows.Close()
+
+	queries := []string{}
+	for rows.Next() {
+		var query string
+		if err := rows.Scan(&query); err != nil {
+			return nil, fmt.Errorf("failed to scan search query: %w", err)
+		}
+		if query, err = ps.decrypt(ctx, query); err != nil {
+			return nil, err
+		}
+		queries = append(queries, query)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search queries: %w", err)
+	}
+
+	return queries, nil
+}
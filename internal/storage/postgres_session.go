@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// sessionColumns is the column list shared by session queries
+const sessionColumns = `
+	s.id, s.user_id, s.title, s.status, s.summary, s.summary_updated_at,
+	(SELECT COUNT(*) FROM conversations c WHERE c.session_id = s.id),
+	s.created_at, s.updated_at, s.closed_at
+`
+
+// CreateSession inserts a session; it reports false if a session with the same ID already exists
+func (ps *PostgresStore) CreateSession(ctx context.Context, session *models.Session) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "create_session", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		INSERT INTO sessions (id, user_id, title, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	result, err := ps.db.ExecContext(
+		ctx,
+		query,
+		session.ID,
+		session.UserID,
+		nullString(session.Title),
+		session.Status,
+		session.CreatedAt,
+		session.UpdatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// GetSession retrieves a session by ID
+func (ps *PostgresStore) GetSession(ctx context.Context, id string) (*models.Session, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_session", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `SELECT ` + sessionColumns + ` FROM sessions s WHERE s.id = $1`
+
+	session, err := scanSession(ps.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.Summary, err = ps.decrypt(ctx, session.Summary); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// ListSessions retrieves a page of sessions, newest first, optionally filtered by user and status
+func (ps *PostgresStore) ListSessions(ctx context.Context, userID string, status string, limit int, offset int) ([]*models.Session, int, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "list_sessions", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	where := `WHERE ($1 = '' OR s.user_id = $1) AND ($2 = '' OR s.status = $2)`
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM sessions s ` + where
+	if err := ps.db.QueryRowContext(ctx, countQuery, userID, status).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+
+	query := `SELECT ` + sessionColumns + ` FROM sessions s ` + where + `
+		ORDER BY s.created_at DESC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := ps.db.QueryContext(ctx, query, userID, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan session: %w", err)
+		}
+		if session.Summary, err = ps.decrypt(ctx, session.Summary); err != nil {
+			return nil, 0, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating sessions: %w", err)
+	}
+
+	return sessions, total, nil
+}
+
+// CloseSession marks a session closed
+func (ps *PostgresStore) CloseSession(ctx context.Context, id string, closedAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "close_session", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		UPDATE sessions
+		SET status = $2, closed_at = $3, updated_at = $3
+		WHERE id = $1 AND status <> $2
+	`
+
+	if _, err := ps.db.ExecContext(ctx, query, id, models.SessionStatusClosed, closedAt); err != nil {
+		return fmt.Errorf("failed to close session: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSessionSummary stores a session's summary, encrypted when a content cipher is set
+func (ps *PostgresStore) UpdateSessionSummary(ctx context.Context, id string, summary string, updatedAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "update_session_summary", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	summary, err := ps.encrypt(ctx, summary)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE sessions
+		SET summary = $2, summary_updated_at = $3, updated_at = $3
+		WHERE id = $1
+	`
+
+	if _, err := ps.db.ExecContext(ctx, query, id, summary, updatedAt); err != nil {
+		return fmt.Errorf("failed to update session summary: %w", err)
+	}
+
+	return nil
+}
+
+// GetSessionConversations retrieves a session's conversations in chronological order
+func (ps *PostgresStore) GetSessionConversations(ctx context.Context, sessionID string) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_session_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE session_id = $1
+		ORDER BY created_at ASC
+	`
+
+	return ps.queryConversations(ctx, query, sessionID)
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSession scans a row selected with sessionColumns
+func scanSession(row rowScanner) (*models.Session, error) {
+	session := &models.Session{}
+	var (
+		title            sql.NullString
+		summary          sql.NullString
+		summaryUpdatedAt sql.NullTime
+		closedAt         sql.NullTime
+	)
+
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&title,
+		&session.Status,
+		&summary,
+		&summaryUpdatedAt,
+		&session.ConversationCount,
+		&session.CreatedAt,
+		&session.UpdatedAt,
+		&closedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	session.Title = title.String
+	session.Summary = summary.String
+	if summaryUpdatedAt.Valid {
+		session.SummaryUpdatedAt = &summaryUpdatedAt.Time
+	}
+	if closedAt.Valid {
+		session.ClosedAt = &closedAt.Time
+	}
+
+	return session, nil
+}
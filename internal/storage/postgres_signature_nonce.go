@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// ClaimSignatureNonce records a client's nonce until expiresAt; it reports false if the nonce is
+// already recorded and unexpired at now, which makes the request carrying it a replay
+func (ps *PostgresStore) ClaimSignatureNonce(ctx context.Context, clientID string, nonce string, now time.Time, expiresAt time.Time) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "claim_signature_nonce", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	// An expired record left for the sweep is taken over rather than counted as a replay
+	query := `
+		INSERT INTO signature_nonces (client_id, nonce, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (client_id, nonce) DO UPDATE SET expires_at = EXCLUDED.expires_at
+		WHERE signature_nonces.expires_at < $4
+	`
+	result, err := ps.db.ExecContext(ctx, query, clientID, nonce, expiresAt, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim signature nonce: %w", err)
+	}
+	return rowsAffected(result)
+}
+
+// DeleteExpiredSignatureNonces deletes the nonces that expired before a time and returns how
+// many were deleted
+func (ps *PostgresStore) DeleteExpiredSignatureNonces(ctx context.Context, before time.Time) (int64, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "delete_expired_signature_nonces", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	result, err := ps.db.ExecContext(ctx, `DELETE FROM signature_nonces WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired signature nonces: %w", err)
+	}
+	return result.RowsAffected()
+}
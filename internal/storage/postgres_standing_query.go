@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// standingQueryColumns is the column list shared by standing query queries
+const standingQueryColumns = `id, user_id, query, filter, threshold, vector, match_count, last_matched_at, created_at`
+
+// CreateStandingQuery inserts a standing query with its vector
+func (ps *PostgresStore) CreateStandingQuery(ctx context.Context, query *models.StandingQuery) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "create_standing_query", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	vector, err := json.Marshal(query.Vector)
+	if err != nil {
+		return fmt.Errorf("failed to encode standing query vector: %w", err)
+	}
+
+	_, err = ps.db.ExecContext(ctx, `
+		INSERT INTO standing_queries (id, user_id, query, filter, threshold, vector, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, query.ID, query.UserID, query.Query, query.Filter, query.Threshold, vector, query.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create standing query: %w", err)
+	}
+
+	return nil
+}
+
+// GetStandingQuery retrieves a standing query by ID
+func (ps *PostgresStore) GetStandingQuery(ctx context.Context, id string) (*models.StandingQuery, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_standing_query", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query, err := scanStandingQuery(ps.db.QueryRowContext(ctx, `SELECT `+standingQueryColumns+` FROM standing_queries WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get standing query: %w", err)
+	}
+
+	return query, nil
+}
+
+// ListStandingQueries retrieves a user's standing queries with their vectors, oldest first
+func (ps *PostgresStore) ListStandingQueries(ctx context.Context, userID string) ([]*models.StandingQuery, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "list_standing_queries", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	rows, err := ps.db.QueryContext(ctx, `SELECT `+standingQueryColumns+` FROM standing_queries WHERE user_id = $1 ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list standing queries: %w", err)
+	}
+	defer rows.Close()
+
+	queries := []*models.StandingQuery{}
+	for rows.Next() {
+		query, err := scanStandingQuery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan standing query: %w", err)
+		}
+		queries = append(queries, query)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating standing queries: %w", err)
+	}
+
+	return queries, nil
+}
+
+// DeleteStandingQuery deletes a standing query
+func (ps *PostgresStore) DeleteStandingQuery(ctx context.Context, id string) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "delete_standing_query", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	result, err := ps.db.ExecContext(ctx, `DELETE FROM standing_queries WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete standing query: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// UpdateStandingQueryVector replaces a standing query's vector
+func (ps *PostgresStore) UpdateStandingQueryVector(ctx context.Context, id string, vector []float32) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "update_standing_query_vector", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	data, err := json.Marshal(vector)
+	if err != nil {
+		return fmt.Errorf("failed to encode standing query vector: %w", err)
+	}
+	if _, err := ps.db.ExecContext(ctx, `UPDATE standing_queries SET vector = $2 WHERE id = $1`, id, data); err != nil {
+		return fmt.Errorf("failed to update standing query vector: %w", err)
+	}
+
+	return nil
+}
+
+// RecordStandingQueryMatch counts a match of a standing query
+func (ps *PostgresStore) RecordStandingQueryMatch(ctx context.Context, id string, matchedAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "record_standing_query_match", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	_, err := ps.db.ExecContext(ctx, `
+		UPDATE standing_queries
+		SET match_count = match_count + 1, last_matched_at = GREATEST(last_matched_at, $2)
+		WHERE id = $1
+	`, id, matchedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record standing query match: %w", err)
+	}
+
+	return nil
+}
+
+// scanStandingQuery scans a row of standingQueryColumns
+func scanStandingQuery(row rowScanner) (*models.StandingQuery, error) {
+	query := &models.StandingQuery{}
+	var vector []byte
+	var lastMatchedAt sql.NullTime
+	err := row.Scan(
+		&query.ID,
+		&query.UserID,
+		&query.Query,
+		&query.Filter,
+		&query.Threshold,
+		&vector,
+		&query.MatchCount,
+		&lastMatchedAt,
+		&query.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(vector, &query.Vector); err != nil {
+		return nil, fmt.Errorf("failed to decode standing query vector: %w", err)
+	}
+	if lastMatchedAt.Valid {
+		query.LastMatchedAt = &lastMatchedAt.Time
+	}
+	return query, nil
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// suppressionColumns holds the nullable suppression columns of a memory row
+type suppressionColumns struct {
+	at     sql.NullTime
+	reason sql.NullString
+	note   sql.NullString
+}
+
+// model returns the suppression, or nil if the memory isn't suppressed
+func (sc suppressionColumns) model() *models.Suppression {
+	if !sc.at.Valid {
+		return nil
+	}
+	return &models.Suppression{
+		Reason:       sc.reason.String,
+		Note:         sc.note.String,
+		SuppressedAt: sc.at.Time,
+	}
+}
+
+// suppressionArgs returns the column values for a suppression; nil clears it
+func suppressionArgs(suppression *models.Suppression) (sql.NullTime, sql.NullString, sql.NullString) {
+	if suppression == nil {
+		return sql.NullTime{}, sql.NullString{}, sql.NullString{}
+	}
+	return sql.NullTime{Time: suppression.SuppressedAt, Valid: true}, nullString(suppression.Reason), nullString(suppression.Note)
+}
+
+// SetConversationSuppression suppresses a conversation, or restores it when suppression is nil;
+// it reports false if the conversation doesn't exist
+func (ps *PostgresStore) SetConversationSuppression(ctx context.Context, id string, suppression *models.Suppression) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "set_conversation_suppression", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	at, reason, note := suppressionArgs(suppression)
+	query := `
+		UPDATE conversations
+		SET suppressed_at = $2, suppression_reason = $3, suppression_note = $4
+		WHERE id = $1
+	`
+
+	result, err := ps.db.ExecContext(ctx, query, id, at, reason, note)
+	if err != nil {
+		return false, fmt.Errorf("failed to update conversation suppression: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// GetSuppressedConversations retrieves a user's suppressed conversations, most recently suppressed first
+func (ps *PostgresStore) GetSuppressedConversations(ctx context.Context, userID string) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_suppressed_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = $1 AND suppressed_at IS NOT NULL
+		ORDER BY suppressed_at DESC
+	`
+
+	return ps.queryConversations(ctx, query, userID)
+}
+
+// SetPersonalInfoSuppression suppresses a personal information entry, or restores it when suppression
+// is nil; it reports false if the entry doesn't exist
+func (ps *PostgresStore) SetPersonalInfoSuppression(ctx context.Context, id string, suppression *models.Suppression) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "set_personal_info_suppression", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	at, reason, note := suppressionArgs(suppression)
+	query := `
+		UPDATE personal_info
+		SET suppressed_at = $2, suppression_reason = $3, suppression_note = $4
+		WHERE id = $1
+	`
+
+	result, err := ps.db.ExecContext(ctx, query, id, at, reason, note)
+	if err != nil {
+		return false, fmt.Errorf("failed to update personal info suppression: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// GetSuppressedPersonalInfo retrieves a user's suppressed personal information, most recently suppressed first
+func (ps *PostgresStore) GetSuppressedPersonalInfo(ctx context.Context, userID string) ([]*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "get_suppressed_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT ` + personalInfoColumns + `
+		FROM personal_info
+		WHERE user_id = $1 AND suppressed_at IS NOT NULL
+		ORDER BY suppressed_at DESC
+	`
+
+	return ps.queryPersonalInfo(ctx, query, userID)
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// unembeddedColumns holds the nullable columns recording an embedding provider refusal
+type unembeddedColumns struct {
+	at     sql.NullTime
+	reason sql.NullString
+	detail sql.NullString
+}
+
+// model returns the refusal, or nil if the conversation isn't marked unembedded
+func (uc unembeddedColumns) model() *models.Unembedded {
+	if !uc.at.Valid {
+		return nil
+	}
+	return &models.Unembedded{
+		Reason:     uc.reason.String,
+		Detail:     uc.detail.String,
+		RejectedAt: uc.at.Time,
+	}
+}
+
+// unembeddedArgs returns the column values for a refusal, with the time in UTC; nil clears it
+func unembeddedArgs(unembedded *models.Unembedded) (sql.NullTime, sql.NullString, sql.NullString) {
+	if unembedded == nil {
+		return sql.NullTime{}, sql.NullString{}, sql.NullString{}
+	}
+	return sql.NullTime{Time: unembedded.RejectedAt.UTC(), Valid: true}, nullString(unembedded.Reason), nullString(unembedded.Detail)
+}
+
+// SetConversationUnembedded marks a conversation whose text the embedding provider refused, or
+// clears the mark when unembedded is nil; it reports false if the conversation doesn't exist
+func (ps *PostgresStore) SetConversationUnembedded(ctx context.Context, id string, unembedded *models.Unembedded) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "set_conversation_unembedded", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	at, reason, detail := unembeddedArgs(unembedded)
+	query := `
+		UPDATE conversations
+		SET unembedded_at = $2, unembedded_reason = $3, unembedded_detail = $4
+		WHERE id = $1
+	`
+
+	result, err := ps.db.ExecContext(ctx, query, id, at, reason, detail)
+	if err != nil {
+		return false, fmt.Errorf("failed to update conversation embedding status: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// ListUnembeddedConversations retrieves a page of conversations the embedding provider refused,
+// most recently refused first, optionally of one user, and their total count
+func (ps *PostgresStore) ListUnembeddedConversations(ctx context.Context, userID string, limit int, offset int) ([]*models.Conversation, int, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "list_unembedded_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM conversations WHERE unembedded_at IS NOT NULL AND ($1 = '' OR user_id = $1)`
+	if err := ps.db.QueryRowContext(ctx, countQuery, userID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count unembedded conversations: %w", err)
+	}
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE unembedded_at IS NOT NULL AND ($1 = '' OR user_id = $1)
+		ORDER BY unembedded_at DESC, id
+		LIMIT $2 OFFSET $3
+	`
+
+	conversations, err := ps.queryConversations(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return conversations, total, nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// AddUsage adds usage records to the stored daily totals in one transaction
+func (ps *PostgresStore) AddUsage(ctx context.Context, records []models.UsageRecord) error {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "add_usage", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO embedding_usage (day, tenant, model, requests, prompt_tokens, total_tokens)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (day, tenant, model) DO UPDATE SET
+			requests = embedding_usage.requests + EXCLUDED.requests,
+			prompt_tokens = embedding_usage.prompt_tokens + EXCLUDED.prompt_tokens,
+			total_tokens = embedding_usage.total_tokens + EXCLUDED.total_tokens
+	`
+
+	for _, record := range records {
+		if _, err := tx.ExecContext(ctx, query, record.Day, record.Tenant, record.Model, record.Requests, record.PromptTokens, record.TotalTokens); err != nil {
+			return fmt.Errorf("failed to add usage: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit usage: %w", err)
+	}
+
+	return nil
+}
+
+// ListUsage retrieves the daily totals between two dates inclusive, oldest first, optionally of one tenant
+func (ps *PostgresStore) ListUsage(ctx context.Context, from string, to string, tenant string) ([]models.UsageRecord, error) {
+	defer slowlog.Observe(ctx, slowlog.Postgres, "list_usage", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+	defer cancel()
+
+	query := `
+		SELECT to_char(day, 'YYYY-MM-DD'), tenant, model, requests, prompt_tokens, total_tokens
+		FROM embedding_usage
+		WHERE day BETWEEN $1 AND $2 AND ($3 = '' OR tenant = $3)
+		ORDER BY day, tenant, model
+	`
+
+	rows, err := ps.db.QueryContext(ctx, query, from, to, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list usage: %w", err)
+	}
+	defer rows.Close()
+
+	records := []models.UsageRecord{}
+	for rows.Next() {
+		var record models.UsageRecord
+		if err := rows.Scan(&record.Day, &record.Tenant, &record.Model, &record.Requests, &record.PromptTokens, &record.TotalTokens); err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating usage: %w", err)
+	}
+
+	return records, nil
+}
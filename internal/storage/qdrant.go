@@ -3,21 +3,67 @@ package storage
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"hash/fnv"
 	"io"
 	"net/http"
 	"time"
 
+	"refo-rag-server/internal/egress"
+	"refo-rag-server/internal/httpclient"
+	"refo-rag-server/internal/metrics"
 	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
 )
 
+// ErrCollectionMissing is returned when a collection doesn't exist and auto-creation is disabled
+var ErrCollectionMissing = errors.New("collection does not exist and auto-creation is disabled")
+
 // QdrantStore implements VectorStore using REST API
 type QdrantStore struct {
 	baseURL    string
 	collection string
+	distance   string
+	idKey      string
+	dimension  int
+	cluster    clusterSettings
 	client     *http.Client
+
+	// userIsolation requires a user_id on every point and scopes every search to one user
+	userIsolation bool
+
+	// readConsistency is the read consistency of searches and point reads; "" is Qdrant's default
+	readConsistency string
+
+	// datatype is the vector datatype the collection is created with and vectors are sent in; an
+	// existing collection's own datatype takes over when it is initialized
+	datatype string
+
+	// multivector creates the collection with several vectors per point, compared by MaxSim
+	multivector bool
+}
+
+// clusterSettings holds the sharding and replication parameters for collection creation
+type clusterSettings struct {
+	ShardNumber            int
+	ReplicationFactor      int
+	WriteConsistencyFactor int
+}
+
+// CollectionInfo describes the live state of a Qdrant collection
+type CollectionInfo struct {
+	Status                 string `json:"status"`
+	PointsCount            int64  `json:"points_count"`
+	ShardNumber            int    `json:"shard_number"`
+	ReplicationFactor      int    `json:"replication_factor"`
+	WriteConsistencyFactor int    `json:"write_consistency_factor"`
+	Datatype               string `json:"datatype"`
+	VectorSize             int    `json:"vector_size"`
+	Distance               string `json:"distance"`
 }
 
 // NewQdrantStore creates a new Qdrant vector store
@@ -25,12 +71,35 @@ func NewQdrantStore(baseURL string, collection string) (*QdrantStore, error) {
 	return &QdrantStore{
 		baseURL:    baseURL,
 		collection: collection,
+		distance:   "Cosine",
+		idKey:      payloadIDKeys[ContentTypeConversations],
 		client:     &http.Client{},
 	}, nil
 }
 
-// CollectionExists checks if a collection exists in Qdrant
+// NewQdrantHTTPClient creates the HTTP client used for Qdrant, optionally with TLS, routed
+// through the configured egress proxy and retrying transient failures per opts
+func NewQdrantHTTPClient(tlsConfig *tls.Config, egressOpts egress.Options, opts httpclient.Options) (*http.Client, error) {
+	transport, err := egressOpts.Transport(tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure Qdrant HTTP client: %w", err)
+	}
+	opts.Name = "qdrant"
+	opts.Transport = transport
+	return httpclient.New(opts), nil
+}
+
+// Collection returns the name of the collection backing this store
+func (qs *QdrantStore) Collection() string {
+	return qs.collection
+}
+
+// CollectionExists checks if a collection, or an alias of one, exists in Qdrant
 func (qs *QdrantStore) CollectionExists(ctx context.Context) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "collection_exists", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
 	url := fmt.Sprintf("%s/collections", qs.baseURL)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -44,8 +113,7 @@ func (qs *QdrantStore) CollectionExists(ctx context.Context) (bool, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return false, fmt.Errorf("qdrant returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return false, qdrantStatusError(resp)
 	}
 
 	// Parse response
@@ -68,11 +136,26 @@ func (qs *QdrantStore) CollectionExists(ctx context.Context) (bool, error) {
 		}
 	}
 
-	return false, nil
+	// The name may be an alias, such as one pointed at a rebuilt collection
+	target, err := qs.aliasTarget(ctx)
+	if err != nil {
+		return false, err
+	}
+	if target != "" {
+		fmt.Printf("Collection '%s' is an alias of '%s'\n", qs.collection, target)
+	}
+	return target != "", nil
 }
 
-// InitializeCollection creates the collection if it doesn't exist
-func (qs *QdrantStore) InitializeCollection(ctx context.Context, vectorSize int) error {
+// InitializeCollection creates the collection if it doesn't exist and create is set; otherwise a
+// missing collection fails with ErrCollectionMissing
+func (qs *QdrantStore) InitializeCollection(ctx context.Context, vectorSize int, create bool) error {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "create_collection", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	qs.dimension = vectorSize
+
 	// First, check if collection already exists
 	exists, err := qs.CollectionExists(ctx)
 	if err != nil {
@@ -81,15 +164,40 @@ func (qs *QdrantStore) InitializeCollection(ctx context.Context, vectorSize int)
 
 	if exists {
 		fmt.Printf("Collection '%s' already exists, skipping creation\n", qs.collection)
+		if err := qs.adoptDatatype(ctx); err != nil {
+			return err
+		}
+		if qs.userIsolation {
+			return qs.ensureUserIndex(ctx)
+		}
 		return nil
 	}
+	if !create {
+		return fmt.Errorf("%w: %s", ErrCollectionMissing, qs.collection)
+	}
 
 	// Prepare collection creation request
+	vectors := map[string]interface{}{
+		"size":     vectorSize,
+		"distance": qs.distance,
+	}
+	if qs.datatype != "" && qs.datatype != DatatypeFloat32 {
+		vectors["datatype"] = qs.datatype
+	}
+	if qs.multivector {
+		vectors["multivector_config"] = map[string]interface{}{"comparator": "max_sim"}
+	}
 	createRequest := map[string]interface{}{
-		"vectors": map[string]interface{}{
-			"size":     vectorSize,
-			"distance": "Cosine",
-		},
+		"vectors": vectors,
+	}
+	if qs.cluster.ShardNumber > 0 {
+		createRequest["shard_number"] = qs.cluster.ShardNumber
+	}
+	if qs.cluster.ReplicationFactor > 0 {
+		createRequest["replication_factor"] = qs.cluster.ReplicationFactor
+	}
+	if qs.cluster.WriteConsistencyFactor > 0 {
+		createRequest["write_consistency_factor"] = qs.cluster.WriteConsistencyFactor
 	}
 
 	body, err := json.Marshal(createRequest)
@@ -113,51 +221,140 @@ func (qs *QdrantStore) InitializeCollection(ctx context.Context, vectorSize int)
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("qdrant returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return qdrantStatusError(resp)
 	}
 
 	fmt.Printf("Successfully created collection '%s'\n", qs.collection)
+	if qs.userIsolation {
+		return qs.ensureUserIndex(ctx)
+	}
 	return nil
 }
 
+// GetCollectionInfo fetches the collection's status, cluster and vector parameters from Qdrant
+func (qs *QdrantStore) GetCollectionInfo(ctx context.Context) (*CollectionInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "get_collection", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/collections/%s", qs.baseURL, qs.collection)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create collection info request: %w", err)
+	}
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute collection info request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, qdrantStatusError(resp)
+	}
+
+	var infoResp struct {
+		Result struct {
+			Status      string `json:"status"`
+			PointsCount int64  `json:"points_count"`
+			Config      struct {
+				Params struct {
+					ShardNumber            int `json:"shard_number"`
+					ReplicationFactor      int `json:"replication_factor"`
+					WriteConsistencyFactor int `json:"write_consistency_factor"`
+					Vectors                struct {
+						Size     int    `json:"size"`
+						Distance string `json:"distance"`
+						Datatype string `json:"datatype"`
+					} `json:"vectors"`
+				} `json:"params"`
+			} `json:"config"`
+		} `json:"result"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&infoResp); err != nil {
+		return nil, fmt.Errorf("failed to decode collection info response: %w", err)
+	}
+
+	params := infoResp.Result.Config.Params
+	return &CollectionInfo{
+		Status:                 infoResp.Result.Status,
+		PointsCount:            infoResp.Result.PointsCount,
+		ShardNumber:            params.ShardNumber,
+		ReplicationFactor:      params.ReplicationFactor,
+		WriteConsistencyFactor: params.WriteConsistencyFactor,
+		Datatype:               datatypeOrDefault(params.Vectors.Datatype),
+		VectorSize:             params.Vectors.Size,
+		Distance:               params.Vectors.Distance,
+	}, nil
+}
+
+// adoptDatatype sends vectors in the datatype the existing collection stores, warning if it
+// differs from the configured one: rounding vectors for a float32 collection would lose precision
+// for nothing, and a datatype can't be changed without recreating the collection
+func (qs *QdrantStore) adoptDatatype(ctx context.Context) error {
+	info, err := qs.GetCollectionInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read collection datatype: %w", err)
+	}
+	if configured := datatypeOrDefault(qs.datatype); info.Datatype != configured {
+		fmt.Printf("warning: collection '%s' stores %s vectors but %s is configured; sending %s until it is recreated\n",
+			qs.collection, info.Datatype, configured, info.Datatype)
+	}
+	qs.datatype = info.Datatype
+	return nil
+}
+
+// datatypeOrDefault returns datatype, or float32 if it is unset
+func datatypeOrDefault(datatype string) string {
+	if datatype == "" {
+		return DatatypeFloat32
+	}
+	return datatype
+}
+
 // SaveVector saves an embedding vector to Qdrant
 func (qs *QdrantStore) SaveVector(ctx context.Context, conversationID string, vector []float32, metadata map[string]interface{}) error {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "upsert_points", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	// Reject corrupt vectors; one stored NaN or zero vector skews every cosine search it matches
+	if err := ValidateEmbedding(vector, qs.dimension); err != nil {
+		var embeddingErr *EmbeddingError
+		if errors.As(err, &embeddingErr) {
+			metrics.InvalidEmbeddings.WithLabelValues(qs.collection, embeddingErr.Reason).Inc()
+		}
+		fmt.Printf("warning: rejected vector for %s in collection %s: %v\n", conversationID, qs.collection, err)
+		return err
+	}
+
 	pointID := hashConversationID(conversationID)
 
 	// Prepare payload with metadata
 	payload := make(map[string]interface{})
-	payload["conversation_id"] = conversationID
+	payload[qs.idKey] = conversationID
 	for key, value := range metadata {
 		payload[key] = value
 	}
-
-	// Prepare the point
-	point := map[string]interface{}{
-		"id":      pointID,
-		"vector":  vector,
-		"payload": payload,
+	if err := qs.checkPointNamespace(payload); err != nil {
+		return err
 	}
 
 	// Create request body
-	requestBody := map[string]interface{}{
-		"points": []map[string]interface{}{point},
-	}
-
-	body, err := json.Marshal(requestBody)
-	if err != nil {
+	body := newRequestBody()
+	defer body.release()
+	if err := body.encodePoints([]qdrantPoint{{ID: pointID, Vector: vector, Payload: payload}}, qs.datatype); err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	// Make HTTP request
 	url := fmt.Sprintf("%s/collections/%s/points?wait=true", qs.baseURL, qs.collection)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(body))
+	req, err := body.newRequest(ctx, http.MethodPut, url)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-
 	resp, err := qs.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to execute request: %w", err)
@@ -165,36 +362,48 @@ func (qs *QdrantStore) SaveVector(ctx context.Context, conversationID string, ve
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("qdrant returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return qdrantStatusError(resp)
 	}
 
 	return nil
 }
 
 // SearchVectors searches for similar vectors in Qdrant
-func (qs *QdrantStore) SearchVectors(ctx context.Context, queryVector []float32, limit int) ([]models.ConversationSearchResult, error) {
+func (qs *QdrantStore) SearchVectors(ctx context.Context, queryVector []float32, opts SearchOptions) ([]models.ConversationSearchResult, error) {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "search_points", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	filter, err := qs.scopedFilter(opts.UserID, opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+
 	// Prepare search request
 	searchRequest := map[string]interface{}{
-		"vector":       queryVector,
-		"limit":        limit,
+		"limit":        opts.Limit,
 		"with_payload": true,
 	}
+	if filter != nil {
+		searchRequest["filter"] = filter
+	}
+	if opts.HNSWEf > 0 {
+		searchRequest["params"] = map[string]interface{}{"hnsw_ef": opts.HNSWEf}
+	}
 
-	body, err := json.Marshal(searchRequest)
-	if err != nil {
+	body := newRequestBody()
+	defer body.release()
+	if err := body.encodeSearch(queryVector, qs.datatype, searchRequest); err != nil {
 		return nil, fmt.Errorf("failed to marshal search request: %w", err)
 	}
 
 	// Make HTTP request
-	url := fmt.Sprintf("%s/collections/%s/points/search", qs.baseURL, qs.collection)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	url := fmt.Sprintf("%s/collections/%s/points/search%s", qs.baseURL, qs.collection, qs.readQuery(ctx))
+	req, err := body.newRequest(ctx, http.MethodPost, url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-
 	resp, err := qs.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
@@ -202,34 +411,107 @@ func (qs *QdrantStore) SearchVectors(ctx context.Context, queryVector []float32,
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("qdrant returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, qdrantStatusError(resp)
 	}
 
 	// Parse response
 	var searchResp struct {
-		Result []struct {
-			ID      uint64                 `json:"id"`
-			Score   float32                `json:"score"`
-			Payload map[string]interface{} `json:"payload"`
-		} `json:"result"`
+		Result []searchHit `json:"result"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	// Convert results
+	return qs.searchResults(searchResp.Result, opts.UserID), nil
+}
+
+// RecommendVectors searches for the vectors most similar to the positive conversations' stored
+// vectors and away from the negative ones', excluding the examples themselves. Qdrant reads the
+// vectors, so they aren't sent or embedded again; an example without a vector leaves no results
+func (qs *QdrantStore) RecommendVectors(ctx context.Context, positive []string, negative []string, opts SearchOptions) ([]models.ConversationSearchResult, error) {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "recommend_points", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	filter, err := qs.scopedFilter(opts.UserID, opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendRequest := map[string]interface{}{
+		"positive":     hashConversationIDs(positive),
+		"negative":     hashConversationIDs(negative),
+		"limit":        opts.Limit,
+		"with_payload": true,
+	}
+	if filter != nil {
+		recommendRequest["filter"] = filter
+	}
+	if opts.HNSWEf > 0 {
+		recommendRequest["params"] = map[string]interface{}{"hnsw_ef": opts.HNSWEf}
+	}
+
+	body, err := json.Marshal(recommendRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal recommend request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/points/recommend%s", qs.baseURL, qs.collection, qs.readQuery(ctx))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// Qdrant answers 404 both for a missing collection and for a point it doesn't have
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if collectionMissing(errBody) {
+			return nil, &QdrantStatusError{StatusCode: resp.StatusCode, Body: string(errBody)}
+		}
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, qdrantStatusError(resp)
+	}
+
+	var recommendResp struct {
+		Result []searchHit `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&recommendResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return qs.searchResults(recommendResp.Result, opts.UserID), nil
+}
+
+// searchHit is a scored point returned by a search
+type searchHit struct {
+	ID      uint64                 `json:"id"`
+	Score   float32                `json:"score"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+// searchResults converts search hits to results, skipping points without a record ID or outside
+// the user's namespace
+func (qs *QdrantStore) searchResults(hits []searchHit, userID string) []models.ConversationSearchResult {
 	var searchResults []models.ConversationSearchResult
-	for _, item := range searchResp.Result {
+	for _, item := range hits {
 		conversationID := ""
-		if val, ok := item.Payload["conversation_id"]; ok {
+		if val, ok := item.Payload[qs.idKey]; ok {
 			if strVal, ok := val.(string); ok {
 				conversationID = strVal
 			}
 		}
 
-		if conversationID == "" {
+		if conversationID == "" || !inNamespace(userID, item.Payload) {
 			continue
 		}
 
@@ -241,12 +523,15 @@ func (qs *QdrantStore) SearchVectors(ctx context.Context, queryVector []float32,
 		}
 		searchResults = append(searchResults, result)
 	}
-
-	return searchResults, nil
+	return searchResults
 }
 
 // DeleteVector deletes a vector from Qdrant
 func (qs *QdrantStore) DeleteVector(ctx context.Context, conversationID string) error {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "delete_points", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
 	pointID := hashConversationID(conversationID)
 
 	// Prepare delete request
@@ -275,8 +560,61 @@ func (qs *QdrantStore) DeleteVector(ctx context.Context, conversationID string)
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("qdrant returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return qdrantStatusError(resp)
+	}
+
+	return nil
+}
+
+// UpdatePayload merges set into a point's payload and removes the unset keys in one request,
+// leaving the vector and other fields unchanged
+func (qs *QdrantStore) UpdatePayload(ctx context.Context, conversationID string, set map[string]interface{}, unset []string) error {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "update_payload", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	if err := qs.checkPayloadUpdate(set, unset); err != nil {
+		return err
+	}
+
+	points := []uint64{hashConversationID(conversationID)}
+	operations := make([]map[string]interface{}, 0, 2)
+	if len(set) > 0 {
+		operations = append(operations, map[string]interface{}{
+			"set_payload": map[string]interface{}{"payload": set, "points": points},
+		})
+	}
+	if len(unset) > 0 {
+		operations = append(operations, map[string]interface{}{
+			"delete_payload": map[string]interface{}{"keys": unset, "points": points},
+		})
+	}
+	if len(operations) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"operations": operations})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload request: %w", err)
+	}
+
+	// The operations apply in order, and waiting makes the change visible to the next search
+	url := fmt.Sprintf("%s/collections/%s/points/batch?wait=true", qs.baseURL, qs.collection)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return qdrantStatusError(resp)
 	}
 
 	return nil
@@ -288,6 +626,15 @@ func (qs *QdrantStore) Close() error {
 	return nil
 }
 
+// hashConversationIDs converts string IDs to their point IDs
+func hashConversationIDs(ids []string) []uint64 {
+	points := make([]uint64, len(ids))
+	for i, id := range ids {
+		points[i] = hashConversationID(id)
+	}
+	return points
+}
+
 // hashConversationID converts a string ID to a uint64 hash using FNV-1a
 func hashConversationID(id string) uint64 {
 	h := fnv.New64a()
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// Qdrant read consistency levels: how many replicas of a clustered collection must answer a read.
+// A positive number of replicas is accepted too; "" reads from one replica, Qdrant's default
+const (
+	ReadConsistencyMajority = "majority"
+	ReadConsistencyQuorum   = "quorum"
+	ReadConsistencyAll      = "all"
+)
+
+// ValidReadConsistency reports whether value is a read consistency Qdrant accepts
+func ValidReadConsistency(value string) bool {
+	switch value {
+	case ReadConsistencyMajority, ReadConsistencyQuorum, ReadConsistencyAll:
+		return true
+	}
+	n, err := strconv.Atoi(value)
+	return err == nil && n > 0
+}
+
+type readConsistencyKey struct{}
+
+// WithReadConsistency returns a context whose vector searches and point reads use the given read
+// consistency instead of the collection's configured one
+func WithReadConsistency(ctx context.Context, consistency string) context.Context {
+	return context.WithValue(ctx, readConsistencyKey{}, consistency)
+}
+
+// readQuery returns the query string of a read, carrying the read consistency of the context or
+// else of the store
+func (qs *QdrantStore) readQuery(ctx context.Context) string {
+	consistency, _ := ctx.Value(readConsistencyKey{}).(string)
+	if consistency == "" {
+		consistency = qs.readConsistency
+	}
+	if consistency == "" {
+		return ""
+	}
+	return "?" + url.Values{"consistency": {consistency}}.Encode()
+}
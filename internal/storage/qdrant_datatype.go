@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Vector datatypes a collection can store; Qdrant keeps float32 unless told otherwise
+const (
+	DatatypeFloat32 = "float32"
+	DatatypeFloat16 = "float16"
+	DatatypeUint8   = "uint8"
+)
+
+// ValidDatatype reports whether value is a vector datatype Qdrant accepts; "" is float32
+func ValidDatatype(value string) bool {
+	switch value {
+	case "", DatatypeFloat32, DatatypeFloat16, DatatypeUint8:
+		return true
+	}
+	return false
+}
+
+// maxFloat16 is the largest finite half-precision value
+const maxFloat16 = 65504
+
+// roundFloat16 rounds x to the nearest half-precision value, ties to even, or returns false if it
+// overflows. Half precision keeps 11 significant bits down to 2^-14 and a fixed step of 2^-24 below
+func roundFloat16(x float64) (float64, bool) {
+	if x == 0 {
+		return 0, true
+	}
+	_, exp := math.Frexp(x)
+	step := math.Ldexp(1, max(exp-11, -24))
+	rounded := math.RoundToEven(x/step) * step
+	if math.Abs(rounded) > maxFloat16 {
+		return 0, false
+	}
+	return rounded, true
+}
+
+// appendFloat16 appends the shortest decimal that Qdrant reads back as the half-precision value x,
+// which is already rounded. Five significant digits always suffice; most components need fewer,
+// which is where the payload shrinks
+func appendFloat16(dst []byte, x float64) []byte {
+	for digits := 1; digits < 5; digits++ {
+		candidate := strconv.AppendFloat(dst, x, 'g', digits, 64)
+		parsed, err := strconv.ParseFloat(string(candidate[len(dst):]), 64)
+		if err != nil {
+			break
+		}
+		if back, ok := roundFloat16(parsed); ok && back == x {
+			return candidate
+		}
+	}
+	return strconv.AppendFloat(dst, x, 'g', 5, 64)
+}
+
+// writeComponent writes one vector component as the collection stores it: float16 components are
+// rounded to half precision and uint8 ones to whole numbers, since Qdrant would convert them
+// anyway and the shorter text is what makes the transport cheaper
+func writeComponent(buf *bytes.Buffer, datatype string, index int, component float32) error {
+	switch datatype {
+	case DatatypeFloat16:
+		rounded, ok := roundFloat16(float64(component))
+		if !ok {
+			return fmt.Errorf("vector component %d overflows float16", index)
+		}
+		buf.Write(appendFloat16(buf.AvailableBuffer(), rounded))
+	case DatatypeUint8:
+		if component < 0 || component > math.MaxUint8 {
+			return fmt.Errorf("vector component %d is outside the uint8 range", index)
+		}
+		buf.Write(strconv.AppendUint(buf.AvailableBuffer(), uint64(math.RoundToEven(float64(component))), 10))
+	default:
+		buf.Write(strconv.AppendFloat(buf.AvailableBuffer(), float64(component), 'g', -1, 32))
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledBuffer is the capacity above which a request buffer is dropped instead of pooled, so one
+// large import batch doesn't pin its memory for the life of the process
+const maxPooledBuffer = 4 << 20
+
+// requestBuffers holds the buffers upsert and search bodies are encoded into. A 3072-dimension
+// vector is about 40KB of JSON; encoding it with encoding/json into a fresh slice per request
+// allocated the body several times over as the slice grew
+var requestBuffers = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// requestBody is a pooled request buffer. The HTTP transport may still be writing a body after the
+// response arrived and may replay it on retries, so the buffer goes back to the pool only once the
+// caller and every body handed to the transport are done with it
+type requestBody struct {
+	buf  *bytes.Buffer
+	refs atomic.Int32
+}
+
+// newRequestBody returns an empty pooled buffer, referenced by the caller
+func newRequestBody() *requestBody {
+	buf := requestBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	rb := &requestBody{buf: buf}
+	rb.refs.Store(1)
+	return rb
+}
+
+// release drops one reference, returning the buffer to the pool with the last
+func (rb *requestBody) release() {
+	if rb.refs.Add(-1) != 0 {
+		return
+	}
+	if rb.buf.Cap() <= maxPooledBuffer {
+		requestBuffers.Put(rb.buf)
+	}
+}
+
+// reader returns a body reading the buffer from the start, referencing it until closed
+func (rb *requestBody) reader() io.ReadCloser {
+	rb.refs.Add(1)
+	return &bodyReader{Reader: bytes.NewReader(rb.buf.Bytes()), body: rb}
+}
+
+// bodyReader is one read of a pooled request buffer
+type bodyReader struct {
+	*bytes.Reader
+	body   *requestBody
+	closed sync.Once
+}
+
+// Close releases the reader's reference; the transport closes every body it is given
+func (br *bodyReader) Close() error {
+	br.closed.Do(br.body.release)
+	return nil
+}
+
+// newRequest creates a JSON request sending the buffer, replayable for retries
+func (rb *requestBody) newRequest(ctx context.Context, method string, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = rb.reader()
+	req.GetBody = func() (io.ReadCloser, error) { return rb.reader(), nil }
+	req.ContentLength = int64(rb.buf.Len())
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// qdrantPoint is a point to upsert
+type qdrantPoint struct {
+	ID      uint64
+	Vector  []float32
+	Payload map[string]interface{}
+
+	// Vectors is the point's multivector, sent instead of Vector when set
+	Vectors [][]float32
+}
+
+// encodePoints writes the body of an upsert of points with vectors in the given datatype
+func (rb *requestBody) encodePoints(points []qdrantPoint, datatype string) error {
+	buf := rb.buf
+	payloads := json.NewEncoder(buf)
+
+	buf.WriteString(`{"points":[`)
+	for i, point := range points {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`{"id":`)
+		buf.Write(strconv.AppendUint(buf.AvailableBuffer(), point.ID, 10))
+		buf.WriteString(`,"vector":`)
+		var err error
+		if point.Vectors != nil {
+			err = writeMultiVector(buf, point.Vectors, datatype)
+		} else {
+			err = writeVector(buf, point.Vector, datatype)
+		}
+		if err != nil {
+			return err
+		}
+		buf.WriteString(`,"payload":`)
+		if err := payloads.Encode(point.Payload); err != nil {
+			return fmt.Errorf("failed to encode payload: %w", err)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteString(`]}`)
+	return nil
+}
+
+// encodeSearch writes the body of a search for vector in the given datatype; the other fields of
+// the request are encoded after it
+func (rb *requestBody) encodeSearch(vector []float32, datatype string, fields map[string]interface{}) error {
+	rb.buf.WriteString(`{"vector":`)
+	if err := writeVector(rb.buf, vector, datatype); err != nil {
+		return err
+	}
+	return rb.encodeFields(fields)
+}
+
+// encodeMultiVectorQuery writes the body of a Query API request for a multivector in the given
+// datatype, followed by the other fields of the request
+func (rb *requestBody) encodeMultiVectorQuery(vectors [][]float32, datatype string, fields map[string]interface{}) error {
+	rb.buf.WriteString(`{"query":`)
+	if err := writeMultiVector(rb.buf, vectors, datatype); err != nil {
+		return err
+	}
+	return rb.encodeFields(fields)
+}
+
+// encodeFields writes the fields following a request's vector and closes the request
+func (rb *requestBody) encodeFields(fields map[string]interface{}) error {
+	buf := rb.buf
+	for key, value := range fields {
+		buf.WriteByte(',')
+		buf.Write(strconv.AppendQuote(buf.AvailableBuffer(), key))
+		buf.WriteByte(':')
+		if err := json.NewEncoder(buf).Encode(value); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", key, err)
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// writeMultiVector writes a multivector as a JSON array of vectors
+func writeMultiVector(buf *bytes.Buffer, vectors [][]float32, datatype string) error {
+	buf.WriteByte('[')
+	for i, vector := range vectors {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeVector(buf, vector, datatype); err != nil {
+			return fmt.Errorf("vector %d: %w", i, err)
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+// writeVector writes a vector as a JSON array, formatting each component in place at the precision
+// of the collection's datatype
+func writeVector(buf *bytes.Buffer, vector []float32, datatype string) error {
+	buf.WriteByte('[')
+	for i, component := range vector {
+		if math.IsNaN(float64(component)) || math.IsInf(float64(component), 0) {
+			return fmt.Errorf("vector component %d is not finite", i)
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeComponent(buf, datatype, i, component); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// benchmarkDimension is the dimension of the largest embedding models served
+const benchmarkDimension = 3072
+
+// benchmarkVector returns a unit-length vector of the given dimension
+func benchmarkVector(dimension int) []float32 {
+	rng := rand.New(rand.NewPCG(1, 2))
+	vector := make([]float32, dimension)
+	for i := range vector {
+		vector[i] = rng.Float32()*2 - 1
+	}
+	NormalizeL2(vector)
+	return vector
+}
+
+// benchmarkPayload is the payload of a typical conversation point
+func benchmarkPayload() map[string]interface{} {
+	return map[string]interface{}{
+		"conversation_id": "5f0c6a1e-8f43-4b2a-9d1e-3c5b7a9e2f10",
+		"user_id":         "user-1",
+		"session_id":      "session-1",
+		"created_at":      int64(1700000000),
+		"importance":      0.5,
+		"suppressed":      false,
+	}
+}
+
+// BenchmarkEncodePoints compares encoding an upsert of one point into a pooled buffer with
+// marshaling it with encoding/json into a fresh slice, as every save did before
+func BenchmarkEncodePoints(b *testing.B) {
+	vector := benchmarkVector(benchmarkDimension)
+	payload := benchmarkPayload()
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			body := newRequestBody()
+			if err := body.encodePoints([]qdrantPoint{{ID: 42, Vector: vector, Payload: payload}}, DatatypeFloat32); err != nil {
+				b.Fatal(err)
+			}
+			body.release()
+		}
+	})
+
+	b.Run("encoding_json", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_, err := json.Marshal(map[string]interface{}{
+				"points": []map[string]interface{}{{"id": uint64(42), "vector": vector, "payload": payload}},
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkEncodeSearch measures encoding a search request for one query vector
+func BenchmarkEncodeSearch(b *testing.B) {
+	vector := benchmarkVector(benchmarkDimension)
+	fields := map[string]interface{}{
+		"limit":        10,
+		"with_payload": true,
+		"filter":       userFilter("user-1"),
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		body := newRequestBody()
+		if err := body.encodeSearch(vector, DatatypeFloat32, fields); err != nil {
+			b.Fatal(err)
+		}
+		body.release()
+	}
+}
+
+// BenchmarkSaveVectorParallel saves points concurrently against a stub Qdrant, the load of a high
+// save rate, so the allocations per save include the HTTP round trip
+func BenchmarkSaveVectorParallel(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"result":{"status":"completed"},"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	qs, err := NewQdrantStore(server.URL, "conversations")
+	if err != nil {
+		b.Fatal(err)
+	}
+	qs.client = server.Client()
+	vector := benchmarkVector(benchmarkDimension)
+	payload := benchmarkPayload()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(vector) * 4))
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := qs.SaveVector(context.Background(), "conversation-1", vector, payload); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// IndexInfo describes a collection's segments, index coverage and optimizer state
+type IndexInfo struct {
+	Status              string
+	OptimizerOK         bool
+	OptimizerError      string
+	PointsCount         int64
+	IndexedVectorsCount int64
+	SegmentsCount       int64
+	IndexingThresholdKB int64
+	HNSWM               int
+	OnDisk              bool
+}
+
+// GetIndexInfo retrieves a collection's index and optimizer state
+func (qs *QdrantStore) GetIndexInfo(ctx context.Context) (*IndexInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "get_index_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/collections/%s", qs.baseURL, qs.collection)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create collection info request: %w", err)
+	}
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute collection info request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, qdrantStatusError(resp)
+	}
+
+	var infoResp struct {
+		Result struct {
+			Status              string          `json:"status"`
+			OptimizerStatus     json.RawMessage `json:"optimizer_status"`
+			PointsCount         int64           `json:"points_count"`
+			IndexedVectorsCount int64           `json:"indexed_vectors_count"`
+			SegmentsCount       int64           `json:"segments_count"`
+			Config              struct {
+				Params struct {
+					Vectors struct {
+						OnDisk bool `json:"on_disk"`
+					} `json:"vectors"`
+				} `json:"params"`
+				HNSWConfig struct {
+					M int `json:"m"`
+				} `json:"hnsw_config"`
+				OptimizerConfig struct {
+					IndexingThreshold int64 `json:"indexing_threshold"`
+				} `json:"optimizer_config"`
+			} `json:"config"`
+		} `json:"result"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&infoResp); err != nil {
+		return nil, fmt.Errorf("failed to decode collection info response: %w", err)
+	}
+
+	result := infoResp.Result
+	info := &IndexInfo{
+		Status:              result.Status,
+		OptimizerOK:         true,
+		PointsCount:         result.PointsCount,
+		IndexedVectorsCount: result.IndexedVectorsCount,
+		SegmentsCount:       result.SegmentsCount,
+		IndexingThresholdKB: result.Config.OptimizerConfig.IndexingThreshold,
+		HNSWM:               result.Config.HNSWConfig.M,
+		OnDisk:              result.Config.Params.Vectors.OnDisk,
+	}
+
+	// optimizer_status is "ok" or {"error": "..."}
+	var optimizerError struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(result.OptimizerStatus, &optimizerError); err == nil && optimizerError.Error != "" {
+		info.OptimizerOK = false
+		info.OptimizerError = optimizerError.Error
+	}
+
+	return info, nil
+}
+
+// TriggerOptimizers asks Qdrant to re-evaluate the collection's optimizers, which merges small
+// segments, vacuums deleted points and builds missing indexes
+func (qs *QdrantStore) TriggerOptimizers(ctx context.Context) error {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "trigger_optimizers", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	// An empty optimizer config update restarts optimizers, including ones that are pending (grey)
+	body := []byte(`{"optimizers_config":{}}`)
+	url := fmt.Sprintf("%s/collections/%s", qs.baseURL, qs.collection)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return qdrantStatusError(resp)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// NearestPoints returns the stored points closest to a vector with their scores and payloads,
+// scoped to a user when userID is set
+func (qs *QdrantStore) NearestPoints(ctx context.Context, vector []float32, limit int, userID string) ([]models.NearestPoint, error) {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "nearest_points", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	filter, err := qs.scopedFilter(userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	searchRequest := map[string]interface{}{
+		"vector":       vector,
+		"limit":        limit,
+		"with_payload": true,
+	}
+	if filter != nil {
+		searchRequest["filter"] = filter
+	}
+
+	body, err := json.Marshal(searchRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal search request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/points/search", qs.baseURL, qs.collection)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, qdrantStatusError(resp)
+	}
+
+	var searchResp struct {
+		Result []models.NearestPoint `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	points := make([]models.NearestPoint, 0, len(searchResp.Result))
+	for _, point := range searchResp.Result {
+		if inNamespace(userID, point.Payload) {
+			points = append(points, point)
+		}
+	}
+	return points, nil
+}
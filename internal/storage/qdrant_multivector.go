@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// SaveMultiVector saves the vectors of a record as one multivector point; the collection must be
+// created with Multivector
+func (qs *QdrantStore) SaveMultiVector(ctx context.Context, id string, vectors [][]float32, metadata map[string]interface{}) error {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "upsert_points", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	if len(vectors) == 0 {
+		return fmt.Errorf("multivector of %s has no vectors", id)
+	}
+	for _, vector := range vectors {
+		if err := ValidateEmbedding(vector, qs.dimension); err != nil {
+			var embeddingErr *EmbeddingError
+			if errors.As(err, &embeddingErr) {
+				metrics.InvalidEmbeddings.WithLabelValues(qs.collection, embeddingErr.Reason).Inc()
+			}
+			fmt.Printf("warning: rejected multivector for %s in collection %s: %v\n", id, qs.collection, err)
+			return err
+		}
+	}
+
+	payload := make(map[string]interface{}, len(metadata)+1)
+	payload[qs.idKey] = id
+	for key, value := range metadata {
+		payload[key] = value
+	}
+	if err := qs.checkPointNamespace(payload); err != nil {
+		return err
+	}
+
+	body := newRequestBody()
+	defer body.release()
+	if err := body.encodePoints([]qdrantPoint{{ID: hashConversationID(id), Vectors: vectors, Payload: payload}}, qs.datatype); err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/points?wait=true", qs.baseURL, qs.collection)
+	req, err := body.newRequest(ctx, http.MethodPut, url)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return qdrantStatusError(resp)
+	}
+
+	return nil
+}
+
+// SearchMultiVector searches a multivector collection with the Query API. A point's score is the
+// sum over the query vectors of each one's best cosine similarity among the point's vectors
+func (qs *QdrantStore) SearchMultiVector(ctx context.Context, queryVectors [][]float32, opts SearchOptions) ([]models.ConversationSearchResult, error) {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "query_points", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	filter, err := qs.scopedFilter(opts.UserID, opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	queryRequest := map[string]interface{}{
+		"limit":        opts.Limit,
+		"with_payload": true,
+	}
+	if filter != nil {
+		queryRequest["filter"] = filter
+	}
+	if opts.HNSWEf > 0 {
+		queryRequest["params"] = map[string]interface{}{"hnsw_ef": opts.HNSWEf}
+	}
+
+	body := newRequestBody()
+	defer body.release()
+	if err := body.encodeMultiVectorQuery(queryVectors, qs.datatype, queryRequest); err != nil {
+		return nil, fmt.Errorf("failed to marshal query request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/points/query%s", qs.baseURL, qs.collection, qs.readQuery(ctx))
+	req, err := body.newRequest(ctx, http.MethodPost, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, qdrantStatusError(resp)
+	}
+
+	var queryResp struct {
+		Result struct {
+			Points []searchHit `json:"points"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&queryResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return qs.searchResults(queryResp.Result.Points, opts.UserID), nil
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// userIDPayloadKey is the payload field that namespaces points by user
+const userIDPayloadKey = "user_id"
+
+// ErrUserScopeRequired is returned when a user-isolated collection is written or searched without a user ID
+var ErrUserScopeRequired = errors.New("user_id is required for this collection")
+
+// ErrUserIDInvalid is returned for a user ID that isn't valid UTF-8. JSON would carry it to Qdrant
+// with its bad bytes replaced, into a namespace it could share with other malformed IDs
+var ErrUserIDInvalid = errors.New("user_id must be valid UTF-8")
+
+// checkUserID rejects a user ID that can't be sent to Qdrant intact
+func checkUserID(userID string) error {
+	if !utf8.ValidString(userID) {
+		return ErrUserIDInvalid
+	}
+	return nil
+}
+
+// checkPointNamespace verifies that a point written to a user-isolated collection carries its user ID
+func (qs *QdrantStore) checkPointNamespace(payload map[string]interface{}) error {
+	if !qs.userIsolation {
+		return nil
+	}
+	userID, ok := payload[userIDPayloadKey].(string)
+	if !ok || userID == "" {
+		return ErrUserScopeRequired
+	}
+	return checkUserID(userID)
+}
+
+// checkPayloadUpdate rejects payload updates that would move a point to another user's namespace
+// or out of any
+func (qs *QdrantStore) checkPayloadUpdate(set map[string]interface{}, unset []string) error {
+	if !qs.userIsolation {
+		return nil
+	}
+	_, changed := set[userIDPayloadKey]
+	if changed || slices.Contains(unset, userIDPayloadKey) {
+		return fmt.Errorf("user_id of a point in a user-isolated collection cannot be changed")
+	}
+	return nil
+}
+
+// scopedFilter restricts a search filter to a user's points. The caller's filter is nested under
+// must, so nothing it contains can widen the search beyond the user.
+func (qs *QdrantStore) scopedFilter(userID string, filter map[string]interface{}) (map[string]interface{}, error) {
+	if userID == "" {
+		if qs.userIsolation {
+			return nil, ErrUserScopeRequired
+		}
+		return filter, nil
+	}
+	if err := checkUserID(userID); err != nil {
+		return nil, err
+	}
+
+	scoped := userFilter(userID)
+	if filter != nil {
+		scoped["must"] = append(scoped["must"].([]interface{}), filter)
+	}
+	return scoped, nil
+}
+
+// userFilter matches a user's points
+func userFilter(userID string) map[string]interface{} {
+	return map[string]interface{}{
+		"must": []interface{}{
+			map[string]interface{}{"key": userIDPayloadKey, "match": map[string]interface{}{"value": userID}},
+		},
+	}
+}
+
+// inNamespace reports whether a returned point belongs to the searched user
+func inNamespace(userID string, payload map[string]interface{}) bool {
+	if userID == "" {
+		return true
+	}
+	owner, _ := payload[userIDPayloadKey].(string)
+	return owner == userID
+}
+
+// ensureUserIndex creates the keyword payload index used to filter points by user
+func (qs *QdrantStore) ensureUserIndex(ctx context.Context) error {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "create_payload_index", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	body, err := json.Marshal(map[string]interface{}{
+		"field_name":   userIDPayloadKey,
+		"field_schema": "keyword",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload index request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/index?wait=true", qs.baseURL, qs.collection)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return qdrantStatusError(resp)
+	}
+
+	return nil
+}
+
+// DeleteUserVectors deletes all of a user's points
+func (qs *QdrantStore) DeleteUserVectors(ctx context.Context, userID string) error {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "delete_user_points", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	if userID == "" {
+		return ErrUserScopeRequired
+	}
+	if err := checkUserID(userID); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"filter": userFilter(userID)})
+	if err != nil {
+		return fmt.Errorf("failed to marshal delete request: %w", err)
+	}
+
+	// Wait so that points re-written right after the delete aren't removed with it
+	url := fmt.Sprintf("%s/collections/%s/points/delete?wait=true", qs.baseURL, qs.collection)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return qdrantStatusError(resp)
+	}
+
+	return nil
+}
+
+// CountUserVectors counts a user's points
+func (qs *QdrantStore) CountUserVectors(ctx context.Context, userID string) (int64, error) {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "count_user_points", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	if userID == "" {
+		return 0, ErrUserScopeRequired
+	}
+	if err := checkUserID(userID); err != nil {
+		return 0, err
+	}
+
+	return qs.countPoints(ctx, userFilter(userID))
+}
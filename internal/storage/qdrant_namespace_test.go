@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"unicode/utf8"
+
+	"refo-rag-server/internal/models"
+)
+
+// matchesFilter evaluates the must, should and must_not clauses and the match conditions of a
+// Qdrant filter against a payload. Conditions it doesn't model match every point, the widest
+// reading a caller could hope for
+func matchesFilter(filter map[string]interface{}, payload map[string]interface{}) bool {
+	for _, condition := range conditions(filter["must"]) {
+		if !matchesCondition(condition, payload) {
+			return false
+		}
+	}
+	if should := conditions(filter["should"]); len(should) > 0 {
+		matched := false
+		for _, condition := range should {
+			matched = matched || matchesCondition(condition, payload)
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, condition := range conditions(filter["must_not"]) {
+		// Only field conditions exclude; a nested filter under must_not is read as excluding nothing
+		if _, isField := condition["key"]; isField && matchesCondition(condition, payload) {
+			return false
+		}
+	}
+	return true
+}
+
+func conditions(clause interface{}) []map[string]interface{} {
+	var out []map[string]interface{}
+	switch c := clause.(type) {
+	case []interface{}:
+		for _, item := range c {
+			if condition, ok := item.(map[string]interface{}); ok {
+				out = append(out, condition)
+			}
+		}
+	case map[string]interface{}:
+		out = append(out, c)
+	}
+	return out
+}
+
+func matchesCondition(condition map[string]interface{}, payload map[string]interface{}) bool {
+	key, hasKey := condition["key"].(string)
+	if !hasKey {
+		// A nested filter
+		return matchesFilter(condition, payload)
+	}
+	match, ok := condition["match"].(map[string]interface{})
+	if !ok {
+		return true
+	}
+	value, ok := match["value"]
+	if !ok {
+		return true
+	}
+	return reflect.DeepEqual(payload[key], value)
+}
+
+// filterRecorder is a Qdrant stub that records the filter of each search it receives and answers
+// with one point of another user, which the store must drop
+type filterRecorder struct {
+	filters chan map[string]interface{}
+	otherID string
+}
+
+func (fr *filterRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Filter map[string]interface{} `json:"filter"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fr.filters <- request.Filter
+
+	hits := []map[string]interface{}{{
+		"id":      1,
+		"score":   1,
+		"payload": map[string]interface{}{"conversation_id": "c1", userIDPayloadKey: fr.otherID},
+	}}
+	var result interface{} = hits
+	if r.URL.Path == "/collections/conversations/points/query" {
+		result = map[string]interface{}{"points": hits}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+}
+
+// FuzzUserFilter checks that no caller filter can widen a search of a user-isolated collection
+// beyond the searched user's points, through every search the store sends to Qdrant
+func FuzzUserFilter(f *testing.F) {
+	f.Add("user-1", []byte(``))
+	f.Add("user-1", []byte(`{"must":[{"key":"session_id","match":{"value":"s1"}}]}`))
+	f.Add("user-1", []byte(`{"should":[{"key":"user_id","match":{"value":"user-2"}}]}`))
+	f.Add("user-1", []byte(`{"must_not":[{"key":"user_id","match":{"value":"user-1"}}]}`))
+	f.Add("user-1", []byte(`{"must":[{"should":[{"key":"user_id","match":{"value":"user-2"}},{"is_empty":{"key":"user_id"}}]}]}`))
+	f.Add("", []byte(`{"must":[{"key":"user_id","match":{"value":"user-2"}}]}`))
+	f.Add("user-1", []byte(`{"must":"user_id","should":null,"user_id":"user-2"}`))
+
+	recorder := &filterRecorder{filters: make(chan map[string]interface{}, 1)}
+	server := httptest.NewServer(recorder)
+	f.Cleanup(server.Close)
+	qs, err := NewQdrantStore(server.URL, "conversations")
+	if err != nil {
+		f.Fatal(err)
+	}
+	qs.userIsolation = true
+
+	f.Fuzz(func(t *testing.T, userID string, filterJSON []byte) {
+		var filter map[string]interface{}
+		if json.Unmarshal(filterJSON, &filter) != nil {
+			filter = nil
+		}
+		recorder.otherID = userID + "-other"
+		opts := SearchOptions{Limit: 5, Filter: filter, UserID: userID}
+
+		searches := map[string]func(ctx context.Context) ([]models.ConversationSearchResult, error){
+			"SearchVectors": func(ctx context.Context) ([]models.ConversationSearchResult, error) {
+				return qs.SearchVectors(ctx, []float32{0.6, 0.8}, opts)
+			},
+			"RecommendVectors": func(ctx context.Context) ([]models.ConversationSearchResult, error) {
+				return qs.RecommendVectors(ctx, []string{"c2"}, nil, opts)
+			},
+			"SearchMultiVector": func(ctx context.Context) ([]models.ConversationSearchResult, error) {
+				return qs.SearchMultiVector(ctx, [][]float32{{0.6, 0.8}}, opts)
+			},
+			"NearestPoints": func(ctx context.Context) ([]models.ConversationSearchResult, error) {
+				// NearestPoints takes no caller filter, only the user
+				_, err := qs.NearestPoints(ctx, []float32{0.6, 0.8}, 5, userID)
+				return nil, err
+			},
+		}
+		for name, search := range searches {
+			results, err := search(context.Background())
+			if userID == "" || !utf8.ValidString(userID) {
+				if !errors.Is(err, ErrUserScopeRequired) && !errors.Is(err, ErrUserIDInvalid) {
+					t.Fatalf("%s(%q) returned %v, want ErrUserScopeRequired or ErrUserIDInvalid", name, userID, err)
+				}
+				if len(recorder.filters) != 0 {
+					t.Fatalf("%s(%q) reached Qdrant", name, userID)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%s(%q) error = %v", name, userID, err)
+			}
+			if len(results) != 0 {
+				t.Fatalf("%s returned another user's point: %v", name, results)
+			}
+			checkScopedFilter(t, name, userID, <-recorder.filters)
+		}
+	})
+}
+
+// checkScopedFilter checks that a filter sent to Qdrant matches only userID's points
+func checkScopedFilter(t *testing.T, name string, userID string, sent map[string]interface{}) {
+	t.Helper()
+
+	// The user's condition is the first of the only top-level clause
+	if len(sent) != 1 {
+		t.Fatalf("%s sent a filter with top-level keys %v, want only must", name, reflect.ValueOf(sent).MapKeys())
+	}
+	must, ok := sent["must"].([]interface{})
+	if !ok || len(must) == 0 || !reflect.DeepEqual(must[0], userFilter(userID)["must"].([]interface{})[0]) {
+		t.Fatalf("%s sent filter %v, which doesn't start with the user's condition", name, sent)
+	}
+
+	// However the caller's filter reads, another user's point never matches
+	other := map[string]interface{}{userIDPayloadKey: userID + "-other"}
+	if matchesFilter(sent, other) {
+		t.Fatalf("%s sent filter %v, which matches another user's point", name, sent)
+	}
+	if inNamespace(userID, other) {
+		t.Fatalf("another user's point is in %q's namespace", userID)
+	}
+}
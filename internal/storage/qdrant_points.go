@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// GetPayloads retrieves the payloads of the points stored for the given IDs, keyed by ID;
+// IDs without a point are absent from the result
+func (qs *QdrantStore) GetPayloads(ctx context.Context, conversationIDs []string) (map[string]map[string]interface{}, error) {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "retrieve_points", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	points, err := qs.retrievePoints(ctx, conversationIDs, false)
+	if err != nil {
+		return nil, err
+	}
+
+	payloads := make(map[string]map[string]interface{}, len(points))
+	for _, point := range points {
+		if id, ok := point.Payload[qs.idKey].(string); ok {
+			payloads[id] = point.Payload
+		}
+	}
+	return payloads, nil
+}
+
+// GetVectors retrieves the vectors of the points stored for the given IDs, keyed by ID;
+// IDs without a point are absent from the result
+func (qs *QdrantStore) GetVectors(ctx context.Context, conversationIDs []string) (map[string][]float32, error) {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "retrieve_vectors", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	points, err := qs.retrievePoints(ctx, conversationIDs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make(map[string][]float32, len(points))
+	for _, point := range points {
+		if id, ok := point.Payload[qs.idKey].(string); ok {
+			vectors[id] = point.Vector
+		}
+	}
+	return vectors, nil
+}
+
+// retrievedPoint is a point returned by the retrieve endpoint
+type retrievedPoint struct {
+	Vector  []float32              `json:"vector"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+// retrievePoints fetches the points stored for the given IDs with their payloads and, optionally,
+// their vectors
+func (qs *QdrantStore) retrievePoints(ctx context.Context, conversationIDs []string, withVector bool) ([]retrievedPoint, error) {
+	if len(conversationIDs) == 0 {
+		return nil, nil
+	}
+
+	pointIDs := make([]uint64, 0, len(conversationIDs))
+	for _, id := range conversationIDs {
+		pointIDs = append(pointIDs, hashConversationID(id))
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"ids":          pointIDs,
+		"with_payload": true,
+		"with_vector":  withVector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/points%s", qs.baseURL, qs.collection, qs.readQuery(ctx))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, qdrantStatusError(resp)
+	}
+
+	var retrieveResp struct {
+		Result []retrievedPoint `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&retrieveResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return retrieveResp.Result, nil
+}
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/slowlog"
+)
+
+// recoveryTimeout bounds one re-initialization of a missing collection
+const recoveryTimeout = 30 * time.Second
+
+// RecoveryOptions configures how a collection found missing under a running server, such as one
+// recreated or replaced by an alias, is recovered
+type RecoveryOptions struct {
+	// Enabled re-initializes a collection once when a request finds it missing and retries the
+	// request; disabled, the request fails and is only counted
+	Enabled bool
+
+	// AutoCreate creates the collection again if it is still missing when re-initialized
+	AutoCreate bool
+
+	// Cooldown is the minimum time between re-initializations of a collection; requests that find
+	// it missing within the cooldown reuse the last outcome
+	Cooldown time.Duration
+}
+
+// recoveringKey marks the requests of a re-initialization, so they don't start another
+type recoveringKey struct{}
+
+// collectionRecovery is a round tripper that recovers collections found missing by requests
+// under /collections/{name}/
+type collectionRecovery struct {
+	next   http.RoundTripper
+	stores map[string]*QdrantStore
+	opts   RecoveryOptions
+
+	mu     sync.Mutex
+	states map[string]*recoveryState
+}
+
+// recoveryState is the last re-initialization of one collection
+type recoveryState struct {
+	mu  sync.Mutex
+	at  time.Time
+	err error
+}
+
+// EnableRecovery installs collection recovery in front of the stores' HTTP client. Call it at
+// startup, before the stores serve requests
+func (cm *CollectionManager) EnableRecovery(opts RecoveryOptions) {
+	recovery := &collectionRecovery{
+		stores: make(map[string]*QdrantStore, len(cm.stores)),
+		opts:   opts,
+		states: make(map[string]*recoveryState),
+	}
+	var client *http.Client
+	for _, store := range cm.stores {
+		if client == nil {
+			wrapped := *store.client
+			recovery.next = wrapped.Transport
+			if recovery.next == nil {
+				recovery.next = http.DefaultTransport
+			}
+			wrapped.Transport = recovery
+			client = &wrapped
+		}
+		recovery.stores[store.collection] = store
+		store.client = client
+	}
+}
+
+// RoundTrip sends a request and, if it finds its collection missing, recovers the collection and
+// sends the request once more
+func (cr *collectionRecovery) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := cr.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusNotFound || req.Context().Value(recoveringKey{}) != nil {
+		return resp, err
+	}
+	store := cr.storeFor(req.URL.Path)
+	if store == nil {
+		return resp, nil
+	}
+
+	body, readErr := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil || !collectionMissing(body) {
+		return resp, nil
+	}
+
+	if !cr.opts.Enabled {
+		metrics.QdrantCollectionMissing.WithLabelValues(store.collection, "disabled").Inc()
+		return resp, nil
+	}
+	if cr.reinitialize(req.Context(), store) != nil {
+		return resp, nil
+	}
+
+	retry := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return resp, nil
+		}
+		replay, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = replay
+	}
+	return cr.next.RoundTrip(retry)
+}
+
+// storeFor returns the store of the collection a request path is under, or nil for requests on
+// the collection itself and other paths
+func (cr *collectionRecovery) storeFor(path string) *QdrantStore {
+	rest, ok := strings.CutPrefix(path, "/collections/")
+	if !ok {
+		return nil
+	}
+	name, sub, ok := strings.Cut(rest, "/")
+	if !ok || sub == "" {
+		return nil
+	}
+	return cr.stores[name]
+}
+
+// collectionMissing reports whether a 404 response body is Qdrant's missing collection error
+func collectionMissing(body []byte) bool {
+	var errResp struct {
+		Status struct {
+			Error string `json:"error"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(body, &errResp); err != nil {
+		return false
+	}
+	return strings.Contains(errResp.Status.Error, "Collection") && strings.Contains(errResp.Status.Error, "doesn't exist")
+}
+
+// reinitialize re-initializes a missing collection, at most once per cooldown; concurrent requests
+// wait for one re-initialization and share its outcome
+func (cr *collectionRecovery) reinitialize(ctx context.Context, store *QdrantStore) error {
+	cr.mu.Lock()
+	state, ok := cr.states[store.collection]
+	if !ok {
+		state = &recoveryState{}
+		cr.states[store.collection] = state
+	}
+	cr.mu.Unlock()
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if !state.at.IsZero() && time.Since(state.at) < cr.opts.Cooldown {
+		metrics.QdrantCollectionMissing.WithLabelValues(store.collection, "throttled").Inc()
+		return state.err
+	}
+
+	// The re-initialization serves every waiting request, so the first one's cancellation doesn't
+	// abort it
+	ctx, cancel := context.WithTimeout(context.WithValue(context.WithoutCancel(ctx), recoveringKey{}, true), recoveryTimeout)
+	defer cancel()
+
+	err := store.InitializeCollection(ctx, store.dimension, cr.opts.AutoCreate)
+	state.at, state.err = time.Now(), err
+	if err != nil {
+		metrics.QdrantCollectionMissing.WithLabelValues(store.collection, "failed").Inc()
+		err = fmt.Errorf("collection %s disappeared and could not be recovered: %w", store.collection, err)
+		fmt.Printf("warning: %v\n", err)
+		errreport.Background(ctx, "qdrant_collection_missing", err)
+		return err
+	}
+	metrics.QdrantCollectionMissing.WithLabelValues(store.collection, "recovered").Inc()
+	fmt.Printf("warning: collection %s disappeared and was re-initialized; retrying the request\n", store.collection)
+	return nil
+}
+
+// aliasTarget returns the collection the store's collection name is an alias of, or "" if it
+// isn't an alias
+func (qs *QdrantStore) aliasTarget(ctx context.Context) (string, error) {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "list_aliases", time.Now())
+
+	url := fmt.Sprintf("%s/aliases", qs.baseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create aliases list request: %w", err)
+	}
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute aliases list request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", qdrantStatusError(resp)
+	}
+
+	var aliasResp struct {
+		Result struct {
+			Aliases []struct {
+				AliasName      string `json:"alias_name"`
+				CollectionName string `json:"collection_name"`
+			} `json:"aliases"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&aliasResp); err != nil {
+		return "", fmt.Errorf("failed to decode aliases list response: %w", err)
+	}
+
+	for _, alias := range aliasResp.Result.Aliases {
+		if alias.AliasName == qs.collection {
+			return alias.CollectionName, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// ScrollPoints pages through every point with its vector and payload. Pass a nil offset for the
+// first page and the returned offset for the next; it returns a nil offset after the last page
+func (qs *QdrantStore) ScrollPoints(ctx context.Context, offset *uint64, limit int) ([]models.VectorPoint, *uint64, error) {
+	return qs.ScrollPointsMatching(ctx, nil, offset, limit)
+}
+
+// ScrollPointsMatching pages through the points matching a Qdrant filter, every point if it is
+// nil, like ScrollPoints. Points come in point ID order, which hashes the record ID, so any page
+// is a random sample of the matching points. It is deliberately not scoped to a user, even in a
+// user-isolated collection: it serves admin-only jobs such as insights that read across users, and
+// must not be reached from a user-facing request
+func (qs *QdrantStore) ScrollPointsMatching(ctx context.Context, filter map[string]interface{}, offset *uint64, limit int) ([]models.VectorPoint, *uint64, error) {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "scroll_points", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	scrollRequest := map[string]interface{}{
+		"limit":        limit,
+		"with_payload": true,
+		"with_vector":  true,
+	}
+	if filter != nil {
+		scrollRequest["filter"] = filter
+	}
+	if offset != nil {
+		scrollRequest["offset"] = *offset
+	}
+
+	body, err := json.Marshal(scrollRequest)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/points/scroll", qs.baseURL, qs.collection)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, qdrantStatusError(resp)
+	}
+
+	var scrollResp struct {
+		Result struct {
+			Points []struct {
+				Vector  []float32              `json:"vector"`
+				Payload map[string]interface{} `json:"payload"`
+			} `json:"points"`
+			NextPageOffset *uint64 `json:"next_page_offset"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&scrollResp); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	points := make([]models.VectorPoint, 0, len(scrollResp.Result.Points))
+	for _, point := range scrollResp.Result.Points {
+		id, _ := point.Payload[qs.idKey].(string)
+		points = append(points, models.VectorPoint{ID: id, Vector: point.Vector, Payload: point.Payload})
+	}
+	return points, scrollResp.Result.NextPageOffset, nil
+}
+
+// UpsertPoints writes a batch of points, replacing the vectors and payloads of existing ones.
+// Every vector is validated first; one invalid vector rejects the whole batch
+func (qs *QdrantStore) UpsertPoints(ctx context.Context, points []models.VectorPoint) error {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "upsert_points", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	if len(points) == 0 {
+		return nil
+	}
+
+	batch := make([]qdrantPoint, 0, len(points))
+	for _, point := range points {
+		if err := ValidateEmbedding(point.Vector, qs.dimension); err != nil {
+			var embeddingErr *EmbeddingError
+			if errors.As(err, &embeddingErr) {
+				metrics.InvalidEmbeddings.WithLabelValues(qs.collection, embeddingErr.Reason).Inc()
+			}
+			return fmt.Errorf("invalid vector for %s: %w", point.ID, err)
+		}
+
+		payload := make(map[string]interface{}, len(point.Payload)+1)
+		for key, value := range point.Payload {
+			payload[key] = value
+		}
+		payload[qs.idKey] = point.ID
+		if err := qs.checkPointNamespace(payload); err != nil {
+			return err
+		}
+
+		batch = append(batch, qdrantPoint{ID: hashConversationID(point.ID), Vector: point.Vector, Payload: payload})
+	}
+
+	body := newRequestBody()
+	defer body.release()
+	if err := body.encodePoints(batch, qs.datatype); err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/points?wait=true", qs.baseURL, qs.collection)
+	req, err := body.newRequest(ctx, http.MethodPut, url)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return qdrantStatusError(resp)
+	}
+	return nil
+}
+
+// Dimension returns the vector size of the collection
+func (qs *QdrantStore) Dimension() int {
+	return qs.dimension
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"time"
+
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// CreateSnapshot takes a snapshot of the collection on the Qdrant server and returns its name
+func (qs *QdrantStore) CreateSnapshot(ctx context.Context) (string, error) {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "create_snapshot", time.Now())
+
+	url := fmt.Sprintf("%s/collections/%s/snapshots?wait=true", qs.baseURL, qs.collection)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", qdrantStatusError(resp)
+	}
+
+	var snapshotResp struct {
+		Result struct {
+			Name string `json:"name"`
+		} `json:"result"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&snapshotResp); err != nil {
+		return "", fmt.Errorf("failed to decode snapshot response: %w", err)
+	}
+
+	return snapshotResp.Result.Name, nil
+}
+
+// DownloadSnapshot streams a collection snapshot from the Qdrant server
+func (qs *QdrantStore) DownloadSnapshot(ctx context.Context, name string, w io.Writer) error {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "download_snapshot", time.Now())
+
+	url := fmt.Sprintf("%s/collections/%s/snapshots/%s", qs.baseURL, qs.collection, url.PathEscape(name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return qdrantStatusError(resp)
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to download snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteSnapshot removes a collection snapshot from the Qdrant server
+func (qs *QdrantStore) DeleteSnapshot(ctx context.Context, name string) error {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "delete_snapshot", time.Now())
+
+	url := fmt.Sprintf("%s/collections/%s/snapshots/%s?wait=true", qs.baseURL, qs.collection, url.PathEscape(name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return qdrantStatusError(resp)
+	}
+
+	return nil
+}
+
+// RestoreSnapshot uploads a snapshot and replaces the collection with its contents
+func (qs *QdrantStore) RestoreSnapshot(ctx context.Context, filename string, snapshot io.Reader) error {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "restore_snapshot", time.Now())
+
+	// Stream the multipart body so large snapshots aren't held in memory
+	pr, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+	go func() {
+		part, err := form.CreateFormFile("snapshot", filename)
+		if err == nil {
+			_, err = io.Copy(part, snapshot)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	url := fmt.Sprintf("%s/collections/%s/snapshots/upload?wait=true&priority=snapshot", qs.baseURL, qs.collection)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, pr)
+	if err != nil {
+		pr.CloseWithError(err)
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return qdrantStatusError(resp)
+	}
+
+	return nil
+}
+
+// CountPoints returns the exact number of points in the collection
+func (qs *QdrantStore) CountPoints(ctx context.Context) (int64, error) {
+	defer slowlog.Observe(ctx, slowlog.Qdrant, "count_points", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.Qdrant)
+	defer cancel()
+
+	return qs.countPoints(ctx, nil)
+}
+
+// countPoints returns the exact number of points matching a filter; nil matches everything
+func (qs *QdrantStore) countPoints(ctx context.Context, filter map[string]interface{}) (int64, error) {
+	countRequest := map[string]interface{}{"exact": true}
+	if filter != nil {
+		countRequest["filter"] = filter
+	}
+
+	body, err := json.Marshal(countRequest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal count request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/points/count", qs.baseURL, qs.collection)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := qs.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, qdrantStatusError(resp)
+	}
+
+	var countResp struct {
+		Result struct {
+			Count int64 `json:"count"`
+		} `json:"result"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&countResp); err != nil {
+		return 0, fmt.Errorf("failed to decode count response: %w", err)
+	}
+
+	return countResp.Result.Count, nil
+}
@@ -0,0 +1,711 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"refo-rag-server/internal/models"
+)
+
+// The conformance tests run the same checks against every backend. SQLite runs on a scratch file;
+// Postgres and MySQL run when TEST_POSTGRES_DSN or TEST_MYSQL_DSN names a database they may write
+// to. Records get fresh IDs, so a shared database can be reused across runs
+
+// relationalBackend opens a migrated store of one backend
+type relationalBackend struct {
+	name string
+	open func(t *testing.T) RelationalStore
+}
+
+// memoryBackend opens a migrated memory store of one backend
+type memoryBackend struct {
+	name string
+	open func(t *testing.T) memoryStore
+}
+
+// memoryStore is the conversation and personal information part of the relational surface
+type memoryStore interface {
+	ConversationStore
+	PersonalInfoStore
+	UserStore
+	GetSessionConversations(ctx context.Context, sessionID string) ([]*models.Conversation, error)
+}
+
+func openSQLiteStore(t *testing.T) *SQLiteStore {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "rag.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := MigrateSQLite(store.GetDB()); err != nil {
+		t.Fatalf("migrate sqlite: %v", err)
+	}
+	return store
+}
+
+func openPostgresStore(t *testing.T) *PostgresStore {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	store, err := NewPostgresStore(dsn)
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := Migrate(store.GetDB(), MigrationOptions{Guard: MigrationGuardOff}); err != nil {
+		t.Fatalf("migrate postgres: %v", err)
+	}
+	return store
+}
+
+func openMySQLStore(t *testing.T) *MySQLStore {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN is not set")
+	}
+	store, err := NewMySQLStore(dsn)
+	if err != nil {
+		t.Fatalf("open mysql: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := MigrateMySQL(store.GetDB()); err != nil {
+		t.Fatalf("migrate mysql: %v", err)
+	}
+	return store
+}
+
+var relationalBackends = []relationalBackend{
+	{"sqlite", func(t *testing.T) RelationalStore { return openSQLiteStore(t) }},
+	{"postgres", func(t *testing.T) RelationalStore { return openPostgresStore(t) }},
+	{"mysql", func(t *testing.T) RelationalStore { return openMySQLStore(t) }},
+}
+
+var memoryBackends = []memoryBackend{
+	{"sqlite", func(t *testing.T) memoryStore { return openSQLiteStore(t) }},
+	{"postgres", func(t *testing.T) memoryStore { return openPostgresStore(t) }},
+	{"mysql", func(t *testing.T) memoryStore { return openMySQLStore(t) }},
+}
+
+// conformanceTime is a fixed, second-aligned time every backend round-trips exactly
+var conformanceTime = time.Date(2026, 3, 14, 9, 26, 53, 0, time.UTC)
+
+func TestMemoryStoreConformance(t *testing.T) {
+	for _, backend := range memoryBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			store := backend.open(t)
+			t.Run("conversations", func(t *testing.T) { checkConversations(t, store) })
+			t.Run("personal info", func(t *testing.T) { checkPersonalInfo(t, store) })
+			t.Run("user data", func(t *testing.T) { checkUserData(t, store) })
+		})
+	}
+}
+
+func TestRelationalConformance(t *testing.T) {
+	for _, backend := range relationalBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			store := backend.open(t)
+			t.Run("sessions", func(t *testing.T) { checkSessions(t, store) })
+			t.Run("jobs", func(t *testing.T) { checkJobs(t, store) })
+			t.Run("queue", func(t *testing.T) { checkQueue(t, store) })
+			t.Run("accounts", func(t *testing.T) { checkAccounts(t, store) })
+			t.Run("api keys", func(t *testing.T) { checkAPIKeys(t, store) })
+			t.Run("usage", func(t *testing.T) { checkUsage(t, store) })
+			t.Run("signature nonces", func(t *testing.T) { checkSignatureNonces(t, store) })
+			t.Run("migration plan", func(t *testing.T) { checkMigrationPlan(t, store) })
+		})
+	}
+}
+
+func newConversation(userID string, sessionID string, at time.Time) *models.Conversation {
+	return &models.Conversation{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		SessionID: sessionID,
+		Question:  "where is the lighthouse?",
+		Answer:    "past the harbour wall",
+		Metadata:  `{"source":"conformance"}`,
+		Messages: []models.Message{
+			{MessageID: "m1", Role: "user", Content: "where is the lighthouse?"},
+			{MessageID: "m2", Role: "assistant", Content: "past the harbour wall"},
+		},
+		CreatedAt: at,
+		UpdatedAt: at,
+	}
+}
+
+func checkConversations(t *testing.T, store memoryStore) {
+	ctx := context.Background()
+	userID := "conformance-" + uuid.NewString()
+	sessionID := uuid.NewString()
+
+	first := newConversation(userID, sessionID, conformanceTime)
+	second := newConversation(userID, sessionID, conformanceTime.Add(time.Minute))
+	for _, conv := range []*models.Conversation{second, first} {
+		if err := store.SaveConversation(ctx, conv); err != nil {
+			t.Fatalf("SaveConversation: %v", err)
+		}
+	}
+
+	got, err := store.GetConversation(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("GetConversation: %v", err)
+	}
+	if got == nil || got.Question != first.Question || got.Answer != first.Answer || got.UserID != userID || got.SessionID != sessionID {
+		t.Fatalf("GetConversation = %+v, want %+v", got, first)
+	}
+	if !got.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, first.CreatedAt)
+	}
+	if len(got.Messages) != 2 || got.Messages[1].Content != "past the harbour wall" {
+		t.Errorf("Messages = %+v", got.Messages)
+	}
+
+	missing, err := store.GetConversation(ctx, uuid.NewString())
+	if err != nil || missing != nil {
+		t.Errorf("GetConversation of a missing ID = %v, %v; want nil, nil", missing, err)
+	}
+
+	session, err := store.GetSessionConversations(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("GetSessionConversations: %v", err)
+	}
+	if len(session) != 2 || session[0].ID != first.ID || session[1].ID != second.ID {
+		t.Errorf("GetSessionConversations returned %d conversations, want first then second", len(session))
+	}
+
+	if ok, err := store.SetConversationPinned(ctx, first.ID, true); err != nil || !ok {
+		t.Fatalf("SetConversationPinned = %v, %v", ok, err)
+	}
+	pinned, err := store.GetPinnedConversations(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetPinnedConversations: %v", err)
+	}
+	if len(pinned) != 1 || pinned[0].ID != first.ID {
+		t.Errorf("GetPinnedConversations returned %d conversations, want the first", len(pinned))
+	}
+
+	if err := store.DeleteConversation(ctx, second.ID); err != nil {
+		t.Fatalf("DeleteConversation: %v", err)
+	}
+	remaining, err := store.GetConversationsByUser(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetConversationsByUser: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != first.ID {
+		t.Errorf("GetConversationsByUser returned %d conversations after a deletion, want 1", len(remaining))
+	}
+}
+
+func newPersonalInfo(userID string, content string, at time.Time) *models.PersonalInfo {
+	return &models.PersonalInfo{
+		ID:         uuid.NewString(),
+		UserID:     userID,
+		Content:    content,
+		Category:   "allergy",
+		Importance: "high",
+		CreatedAt:  at,
+		UpdatedAt:  at,
+	}
+}
+
+func checkPersonalInfo(t *testing.T, store memoryStore) {
+	ctx := context.Background()
+	userID := "conformance-" + uuid.NewString()
+
+	first := newPersonalInfo(userID, "allergic to penicillin", conformanceTime)
+	second := newPersonalInfo(userID, "allergic to peanuts", conformanceTime.Add(time.Minute))
+	for _, info := range []*models.PersonalInfo{first, second} {
+		if err := store.SavePersonalInfo(ctx, info); err != nil {
+			t.Fatalf("SavePersonalInfo: %v", err)
+		}
+	}
+
+	got, err := store.GetPersonalInfo(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("GetPersonalInfo: %v", err)
+	}
+	if got == nil || got.UserID != userID || got.Content != first.Content || got.Category != first.Category || got.Importance != first.Importance {
+		t.Fatalf("GetPersonalInfo = %+v, want %+v", got, first)
+	}
+	if !got.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, first.CreatedAt)
+	}
+	if missing, err := store.GetPersonalInfo(ctx, uuid.NewString()); err != nil || missing != nil {
+		t.Errorf("GetPersonalInfo of a missing ID = %v, %v; want nil, nil", missing, err)
+	}
+
+	byIDs, err := store.GetPersonalInfoByIDs(ctx, []string{second.ID, uuid.NewString(), first.ID})
+	if err != nil {
+		t.Fatalf("GetPersonalInfoByIDs: %v", err)
+	}
+	if len(byIDs) != 2 || byIDs[0].ID != second.ID || byIDs[1].ID != first.ID {
+		t.Errorf("GetPersonalInfoByIDs returned %d entries, want second then first", len(byIDs))
+	}
+	if byUser, err := store.GetPersonalInfoByUser(ctx, userID); err != nil || len(byUser) != 2 {
+		t.Errorf("GetPersonalInfoByUser = %d entries, %v; want 2", len(byUser), err)
+	}
+
+	missing := newPersonalInfo(userID, "unsaved", conformanceTime)
+	if err := store.UpdatePersonalInfo(ctx, missing); !errors.Is(err, ErrPersonalInfoNotFound) {
+		t.Errorf("UpdatePersonalInfo of a missing entry = %v, want ErrPersonalInfoNotFound", err)
+	}
+	if err := store.DeletePersonalInfo(ctx, missing.ID); !errors.Is(err, ErrPersonalInfoNotFound) {
+		t.Errorf("DeletePersonalInfo of a missing entry = %v, want ErrPersonalInfoNotFound", err)
+	}
+
+	// A write conditioned on a stale read fails; one conditioned on the stored updated_at
+	// succeeds. Postgres stamps updated_at itself, so the stored value is read back
+	readAt := got.UpdatedAt
+	edit := *got
+	edit.Content = "allergic to penicillin and amoxicillin"
+	edit.UpdatedAt = conformanceTime.Add(time.Hour)
+	if err := store.UpdatePersonalInfoIfUnchanged(ctx, &edit, readAt.Add(-time.Second)); !errors.Is(err, ErrPersonalInfoChanged) {
+		t.Errorf("UpdatePersonalInfoIfUnchanged with a stale read = %v, want ErrPersonalInfoChanged", err)
+	}
+	if err := store.UpdatePersonalInfoIfUnchanged(ctx, &edit, readAt); err != nil {
+		t.Fatalf("UpdatePersonalInfoIfUnchanged: %v", err)
+	}
+	if got, err = store.GetPersonalInfo(ctx, first.ID); err != nil || got.Content != edit.Content {
+		t.Fatalf("GetPersonalInfo after an update = %+v, %v; want content %q", got, err, edit.Content)
+	}
+	if err := store.DeletePersonalInfoIfUnchanged(ctx, first.ID, readAt); !errors.Is(err, ErrPersonalInfoChanged) {
+		t.Errorf("DeletePersonalInfoIfUnchanged with the read before the update = %v, want ErrPersonalInfoChanged", err)
+	}
+
+	if ok, err := store.SetPersonalInfoPinned(ctx, second.ID, true); err != nil || !ok {
+		t.Fatalf("SetPersonalInfoPinned = %v, %v", ok, err)
+	}
+	if ok, err := store.SetPersonalInfoPinned(ctx, uuid.NewString(), true); err != nil || ok {
+		t.Errorf("SetPersonalInfoPinned of a missing entry = %v, %v; want false", ok, err)
+	}
+	pinned, err := store.GetPinnedPersonalInfo(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetPinnedPersonalInfo: %v", err)
+	}
+	if len(pinned) != 1 || pinned[0].ID != second.ID || !pinned[0].Pinned {
+		t.Errorf("GetPinnedPersonalInfo returned %d entries, want the second", len(pinned))
+	}
+
+	suppression := &models.Suppression{Reason: "outdated", Note: "moved away", SuppressedAt: conformanceTime}
+	if ok, err := store.SetPersonalInfoSuppression(ctx, first.ID, suppression); err != nil || !ok {
+		t.Fatalf("SetPersonalInfoSuppression = %v, %v", ok, err)
+	}
+	suppressed, err := store.GetSuppressedPersonalInfo(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetSuppressedPersonalInfo: %v", err)
+	}
+	if len(suppressed) != 1 || suppressed[0].ID != first.ID || suppressed[0].Suppression == nil ||
+		suppressed[0].Suppression.Reason != "outdated" || !suppressed[0].Suppression.SuppressedAt.Equal(conformanceTime) {
+		t.Errorf("GetSuppressedPersonalInfo = %+v, want the first suppressed as outdated", suppressed)
+	}
+	if ok, err := store.SetPersonalInfoSuppression(ctx, first.ID, nil); err != nil || !ok {
+		t.Fatalf("SetPersonalInfoSuppression restore = %v, %v", ok, err)
+	}
+	if suppressed, err = store.GetSuppressedPersonalInfo(ctx, userID); err != nil || len(suppressed) != 0 {
+		t.Errorf("GetSuppressedPersonalInfo after a restore = %d entries, %v; want none", len(suppressed), err)
+	}
+
+	if got, err = store.GetPersonalInfo(ctx, first.ID); err != nil {
+		t.Fatalf("GetPersonalInfo: %v", err)
+	}
+	if err := store.DeletePersonalInfoIfUnchanged(ctx, first.ID, got.UpdatedAt); err != nil {
+		t.Fatalf("DeletePersonalInfoIfUnchanged: %v", err)
+	}
+	if err := store.DeletePersonalInfo(ctx, second.ID); err != nil {
+		t.Fatalf("DeletePersonalInfo: %v", err)
+	}
+	if byUser, err := store.GetPersonalInfoByUser(ctx, userID); err != nil || len(byUser) != 0 {
+		t.Errorf("GetPersonalInfoByUser after deletions = %d entries, %v; want none", len(byUser), err)
+	}
+}
+
+func checkUserData(t *testing.T, store memoryStore) {
+	ctx := context.Background()
+	userID := "conformance-" + uuid.NewString()
+	for i := 0; i < 2; i++ {
+		if err := store.SaveConversation(ctx, newConversation(userID, "", conformanceTime.Add(time.Duration(i)*time.Minute))); err != nil {
+			t.Fatalf("SaveConversation: %v", err)
+		}
+	}
+
+	counts, err := store.CountUserData(ctx, userID)
+	if err != nil {
+		t.Fatalf("CountUserData: %v", err)
+	}
+	if counts.Conversations != 2 || counts.Messages != 4 {
+		t.Errorf("CountUserData = %d conversations and %d messages, want 2 and 4", counts.Conversations, counts.Messages)
+	}
+
+	deleted, err := store.DeleteUserData(ctx, userID)
+	if err != nil {
+		t.Fatalf("DeleteUserData: %v", err)
+	}
+	if deleted.Conversations != 2 {
+		t.Errorf("DeleteUserData deleted %d conversations, want 2", deleted.Conversations)
+	}
+	if counts, err = store.CountUserData(ctx, userID); err != nil || counts.Conversations != 0 || counts.Messages != 0 {
+		t.Errorf("CountUserData after deletion = %+v, %v; want nothing left", counts, err)
+	}
+}
+
+func checkSessions(t *testing.T, store RelationalStore) {
+	ctx := context.Background()
+	session := &models.Session{
+		ID:        uuid.NewString(),
+		UserID:    "conformance-" + uuid.NewString(),
+		Title:     "harbour",
+		Status:    models.SessionStatusOpen,
+		CreatedAt: conformanceTime,
+		UpdatedAt: conformanceTime,
+	}
+	if created, err := store.CreateSession(ctx, session); err != nil || !created {
+		t.Fatalf("CreateSession = %v, %v", created, err)
+	}
+	if created, err := store.CreateSession(ctx, session); err != nil || created {
+		t.Errorf("CreateSession of an existing ID = %v, %v; want false", created, err)
+	}
+	if err := store.SaveConversation(ctx, newConversation(session.UserID, session.ID, conformanceTime)); err != nil {
+		t.Fatalf("SaveConversation: %v", err)
+	}
+
+	summaryAt := conformanceTime.Add(time.Hour)
+	if err := store.UpdateSessionSummary(ctx, session.ID, "asked about the lighthouse", summaryAt); err != nil {
+		t.Fatalf("UpdateSessionSummary: %v", err)
+	}
+	if err := store.CloseSession(ctx, session.ID, summaryAt); err != nil {
+		t.Fatalf("CloseSession: %v", err)
+	}
+
+	got, err := store.GetSession(ctx, session.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetSession = %v, %v", got, err)
+	}
+	if got.Status != models.SessionStatusClosed || got.ClosedAt == nil || !got.ClosedAt.Equal(summaryAt) {
+		t.Errorf("GetSession status %q closed at %v, want closed at %v", got.Status, got.ClosedAt, summaryAt)
+	}
+	if got.Summary != "asked about the lighthouse" || got.ConversationCount != 1 {
+		t.Errorf("GetSession summary %q with %d conversations, want the summary and 1", got.Summary, got.ConversationCount)
+	}
+
+	sessions, total, err := store.ListSessions(ctx, session.UserID, models.SessionStatusClosed, 10, 0)
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if total != 1 || len(sessions) != 1 || sessions[0].ID != session.ID {
+		t.Errorf("ListSessions returned %d of %d sessions, want the closed one", len(sessions), total)
+	}
+}
+
+func checkJobs(t *testing.T, store RelationalStore) {
+	ctx := context.Background()
+	kind := "conformance-" + uuid.NewString()
+	job := &models.Job{
+		ID:        uuid.NewString(),
+		Kind:      kind,
+		Target:    "conversations",
+		Status:    models.JobStatusRunning,
+		StartedAt: conformanceTime,
+	}
+	if err := store.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	if err := store.UpdateJobProgress(ctx, job.ID, []byte(`{"done":1}`)); err != nil {
+		t.Fatalf("UpdateJobProgress: %v", err)
+	}
+
+	finishedAt := conformanceTime.Add(time.Minute)
+	job.Status = models.JobStatusSucceeded
+	job.Result = []byte(`{"done":2}`)
+	job.FinishedAt = &finishedAt
+	if err := store.FinishJob(ctx, job); err != nil {
+		t.Fatalf("FinishJob: %v", err)
+	}
+
+	got, err := store.GetJob(ctx, job.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetJob = %v, %v", got, err)
+	}
+	if got.Status != models.JobStatusSucceeded || got.FinishedAt == nil || !got.FinishedAt.Equal(finishedAt) {
+		t.Errorf("GetJob status %q finished at %v, want succeeded at %v", got.Status, got.FinishedAt, finishedAt)
+	}
+	if !jsonEqual(t, got.Result, job.Result) {
+		t.Errorf("GetJob result %s, want %s", got.Result, job.Result)
+	}
+
+	jobs, err := store.ListJobs(ctx, kind, 10)
+	if err != nil {
+		t.Fatalf("ListJobs: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != job.ID {
+		t.Errorf("ListJobs returned %d jobs, want the one created", len(jobs))
+	}
+}
+
+func checkQueue(t *testing.T, store RelationalStore) {
+	ctx := context.Background()
+	kind := "conformance-" + uuid.NewString()
+	for _, id := range []string{"a", "b"} {
+		if err := store.Enqueue(ctx, kind, map[string]string{"id": id}); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	claimed, err := store.ClaimQueueItems(ctx, "worker-1", []string{kind}, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimQueueItems: %v", err)
+	}
+	if len(claimed) != 2 {
+		t.Fatalf("ClaimQueueItems leased %d items, want 2", len(claimed))
+	}
+	if again, err := store.ClaimQueueItems(ctx, "worker-2", []string{kind}, 10, time.Minute); err != nil || len(again) != 0 {
+		t.Errorf("ClaimQueueItems of leased items = %d, %v; want none", len(again), err)
+	}
+	if extended, err := store.ExtendQueueLease(ctx, claimed[0].ID, "worker-2", time.Minute); err != nil || extended {
+		t.Errorf("ExtendQueueLease by another owner = %v, %v; want false", extended, err)
+	}
+	if extended, err := store.ExtendQueueLease(ctx, claimed[0].ID, "worker-1", time.Minute); err != nil || !extended {
+		t.Errorf("ExtendQueueLease by the owner = %v, %v; want true", extended, err)
+	}
+
+	if err := store.CompleteQueueItem(ctx, claimed[0].ID, "worker-1"); err != nil {
+		t.Fatalf("CompleteQueueItem: %v", err)
+	}
+	if err := store.RetryQueueItem(ctx, claimed[1].ID, "worker-1", "timeout", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("RetryQueueItem: %v", err)
+	}
+	retried, err := store.ClaimQueueItems(ctx, "worker-2", []string{kind}, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimQueueItems after a retry: %v", err)
+	}
+	if len(retried) != 1 || retried[0].ID != claimed[1].ID || retried[0].Attempts < 1 || retried[0].LastError != "timeout" {
+		t.Fatalf("ClaimQueueItems after a retry = %+v, want the retried item", retried)
+	}
+
+	if err := store.DeadLetterQueueItem(ctx, retried[0].ID, "worker-2", "gave up"); err != nil {
+		t.Fatalf("DeadLetterQueueItem: %v", err)
+	}
+	letters, total, err := store.ListDeadLetters(ctx, kind, 10, 0)
+	if err != nil {
+		t.Fatalf("ListDeadLetters: %v", err)
+	}
+	if total != 1 || len(letters) != 1 || letters[0].LastError != "gave up" {
+		t.Fatalf("ListDeadLetters returned %d of %d, want the dead item", len(letters), total)
+	}
+	if !jsonEqual(t, letters[0].Payload, []byte(`{"id":"b"}`)) {
+		t.Errorf("dead letter payload %s, want the enqueued payload", letters[0].Payload)
+	}
+
+	if requeued, err := store.RequeueDeadLetter(ctx, letters[0].ID); err != nil || !requeued {
+		t.Fatalf("RequeueDeadLetter = %v, %v", requeued, err)
+	}
+	requeued, err := store.ClaimQueueItems(ctx, "worker-1", []string{kind}, 10, time.Minute)
+	if err != nil || len(requeued) != 1 {
+		t.Fatalf("ClaimQueueItems after a requeue = %d, %v; want 1", len(requeued), err)
+	}
+	if err := store.CompleteQueueItem(ctx, requeued[0].ID, "worker-1"); err != nil {
+		t.Fatalf("CompleteQueueItem: %v", err)
+	}
+	if letters, total, err := store.ListDeadLetters(ctx, kind, 10, 0); err != nil || total != 0 || len(letters) != 0 {
+		t.Errorf("ListDeadLetters after a requeue = %d, %v; want none", total, err)
+	}
+}
+
+func checkAccounts(t *testing.T, store RelationalStore) {
+	ctx := context.Background()
+	user := &models.User{
+		ID:          "conformance-" + uuid.NewString(),
+		DisplayName: "Keeper",
+		Status:      models.UserStatusActive,
+		CreatedAt:   conformanceTime,
+		UpdatedAt:   conformanceTime,
+	}
+	if created, err := store.CreateUser(ctx, user); err != nil || !created {
+		t.Fatalf("CreateUser = %v, %v", created, err)
+	}
+	if created, err := store.CreateUser(ctx, user); err != nil || created {
+		t.Errorf("CreateUser of an existing ID = %v, %v; want false", created, err)
+	}
+
+	disabledAt := conformanceTime.Add(time.Hour)
+	disabled, err := store.DisableUser(ctx, user.ID, "abuse", disabledAt)
+	if err != nil || disabled == nil {
+		t.Fatalf("DisableUser = %v, %v", disabled, err)
+	}
+	if disabled.Status != models.UserStatusDisabled || disabled.DisabledReason != "abuse" || disabled.DisabledAt == nil || !disabled.DisabledAt.Equal(disabledAt) {
+		t.Errorf("DisableUser = %+v, want disabled for abuse at %v", disabled, disabledAt)
+	}
+
+	enabled, err := store.EnableUser(ctx, user.ID, disabledAt.Add(time.Hour))
+	if err != nil || enabled == nil {
+		t.Fatalf("EnableUser = %v, %v", enabled, err)
+	}
+	if enabled.Status != models.UserStatusActive || enabled.DisabledAt != nil {
+		t.Errorf("EnableUser = %+v, want active", enabled)
+	}
+
+	got, err := store.GetUser(ctx, user.ID)
+	if err != nil || got == nil || got.DisplayName != "Keeper" {
+		t.Errorf("GetUser = %+v, %v; want the created user", got, err)
+	}
+	if missing, err := store.GetUser(ctx, uuid.NewString()); err != nil || missing != nil {
+		t.Errorf("GetUser of a missing ID = %v, %v; want nil, nil", missing, err)
+	}
+}
+
+func checkAPIKeys(t *testing.T, store RelationalStore) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	key := &models.APIKey{
+		ID:        uuid.NewString(),
+		Name:      "conformance",
+		Prefix:    "rk_conf",
+		Scopes:    []string{"read", "write"},
+		CreatedAt: now,
+	}
+	hash := "hash-" + key.ID
+	if err := store.CreateAPIKey(ctx, key, hash); err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+
+	loaded, err := store.LoadAPIKeys(ctx, now)
+	if err != nil {
+		t.Fatalf("LoadAPIKeys: %v", err)
+	}
+	if got := loaded[hash]; got == nil || got.ID != key.ID || len(got.Scopes) != 2 {
+		t.Fatalf("LoadAPIKeys[%s] = %+v, want the created key", hash, got)
+	}
+
+	replacement := &models.APIKey{ID: uuid.NewString(), Name: "conformance", Prefix: "rk_conf", Scopes: key.Scopes, CreatedAt: now}
+	oldExpiresAt := now.Add(time.Hour)
+	old, err := store.RotateAPIKey(ctx, key.ID, replacement, "hash-"+replacement.ID, oldExpiresAt)
+	if err != nil || old == nil {
+		t.Fatalf("RotateAPIKey = %v, %v", old, err)
+	}
+	if old.RotatedTo != replacement.ID || old.ExpiresAt == nil || !old.ExpiresAt.Equal(oldExpiresAt) {
+		t.Errorf("RotateAPIKey = %+v, want rotated to %s expiring at %v", old, replacement.ID, oldExpiresAt)
+	}
+	if again, err := store.RotateAPIKey(ctx, key.ID, replacement, "hash-"+replacement.ID, oldExpiresAt); err != nil || again != nil {
+		t.Errorf("RotateAPIKey of a rotated key = %v, %v; want nil, nil", again, err)
+	}
+
+	revoked, err := store.RevokeAPIKey(ctx, replacement.ID, now)
+	if err != nil || revoked == nil || revoked.RevokedAt == nil {
+		t.Fatalf("RevokeAPIKey = %v, %v", revoked, err)
+	}
+	if loaded, err = store.LoadAPIKeys(ctx, now.Add(time.Minute)); err != nil {
+		t.Fatalf("LoadAPIKeys: %v", err)
+	}
+	if _, ok := loaded["hash-"+replacement.ID]; ok {
+		t.Errorf("LoadAPIKeys returned a revoked key")
+	}
+	if _, ok := loaded[hash]; !ok {
+		t.Errorf("LoadAPIKeys left out a rotated key before it expires")
+	}
+}
+
+func checkUsage(t *testing.T, store RelationalStore) {
+	ctx := context.Background()
+	tenant := "conformance-" + uuid.NewString()
+	record := models.UsageRecord{Day: "2026-03-14", Tenant: tenant, Model: "embed-small", Requests: 2, PromptTokens: 30, TotalTokens: 30}
+	for i := 0; i < 2; i++ {
+		if err := store.AddUsage(ctx, []models.UsageRecord{record}); err != nil {
+			t.Fatalf("AddUsage: %v", err)
+		}
+	}
+
+	records, err := store.ListUsage(ctx, "2026-03-01", "2026-03-31", tenant)
+	if err != nil {
+		t.Fatalf("ListUsage: %v", err)
+	}
+	if len(records) != 1 || records[0].Requests != 4 || records[0].TotalTokens != 60 {
+		t.Errorf("ListUsage = %+v, want one record summing both additions", records)
+	}
+}
+
+func checkSignatureNonces(t *testing.T, store RelationalStore) {
+	ctx := context.Background()
+	client := "conformance-" + uuid.NewString()
+	now := time.Now().Truncate(time.Second)
+	expiresAt := now.Add(5 * time.Minute)
+
+	claim := func(clientID string, at time.Time, want bool) {
+		t.Helper()
+		claimed, err := store.ClaimSignatureNonce(ctx, clientID, "nonce-0001", at, at.Add(5*time.Minute))
+		if err != nil {
+			t.Fatalf("ClaimSignatureNonce: %v", err)
+		}
+		if claimed != want {
+			t.Errorf("ClaimSignatureNonce(%s, %s) = %v, want %v", clientID, at.Format(time.RFC3339), claimed, want)
+		}
+	}
+	claim(client, now, true)
+	claim(client, now.Add(time.Minute), false)
+	claim(client+"-other", now, true)
+	// Once expired, the nonce may be claimed again before the sweep deletes it
+	claim(client, expiresAt.Add(time.Second), true)
+
+	deleted, err := store.DeleteExpiredSignatureNonces(ctx, expiresAt.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("DeleteExpiredSignatureNonces: %v", err)
+	}
+	if deleted < 1 {
+		t.Errorf("DeleteExpiredSignatureNonces = %d, want the other client's expired nonce deleted", deleted)
+	}
+	claim(client, expiresAt.Add(2*time.Minute), false)
+}
+
+func checkMigrationPlan(t *testing.T, store RelationalStore) {
+	var plan *MigrationPlan
+	var err error
+	switch store := store.(type) {
+	case *PostgresStore:
+		plan, err = PlanMigrations(store.GetDB(), MigrationOptions{Guard: MigrationGuardOff})
+	case *SQLiteStore:
+		plan, err = PlanSQLiteMigrations(store.GetDB())
+	case *MySQLStore:
+		plan, err = PlanMySQLMigrations(store.GetDB())
+	}
+	if err != nil {
+		t.Fatalf("plan migrations: %v", err)
+	}
+	if len(plan.Pending) != 0 {
+		t.Errorf("migrated database plans %d pending statements, want none: %v", len(plan.Pending), plan.Pending)
+	}
+}
+
+func TestPlanSQLiteMigrationsOfNewDatabase(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "rag.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer store.Close()
+
+	plan, err := PlanSQLiteMigrations(store.GetDB())
+	if err != nil {
+		t.Fatalf("PlanSQLiteMigrations: %v", err)
+	}
+	if len(plan.Pending) != len(sqliteMigrations) {
+		t.Errorf("new database plans %d migrations, want all %d", len(plan.Pending), len(sqliteMigrations))
+	}
+}
+
+// jsonEqual compares two JSON documents ignoring formatting, which Postgres JSONB doesn't keep
+func jsonEqual(t *testing.T, a []byte, b []byte) bool {
+	var x, y interface{}
+	if err := json.Unmarshal(a, &x); err != nil {
+		t.Fatalf("decode %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &y); err != nil {
+		t.Fatalf("decode %s: %v", b, err)
+	}
+	return reflect.DeepEqual(x, y)
+}
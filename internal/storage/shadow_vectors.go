@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"refo-rag-server/internal/errreport"
+)
+
+// ShadowedVectorStore serves reads and writes from a primary vector store and repeats deletions on
+// a shadow store, so vectors written to a secondary collection, for evaluation or by an
+// experimental retrieval mode, are removed with the records and users they embed
+type ShadowedVectorStore struct {
+	VectorStore
+	shadow VectorStore
+}
+
+// NewShadowedVectorStore wraps primary so its deletions also apply to shadow
+func NewShadowedVectorStore(primary VectorStore, shadow VectorStore) *ShadowedVectorStore {
+	return &ShadowedVectorStore{VectorStore: primary, shadow: shadow}
+}
+
+// DeleteVector deletes a vector from both stores; a shadow failure is reported but not returned
+func (s *ShadowedVectorStore) DeleteVector(ctx context.Context, conversationID string) error {
+	if err := s.VectorStore.DeleteVector(ctx, conversationID); err != nil {
+		return err
+	}
+	if err := s.shadow.DeleteVector(ctx, conversationID); err != nil {
+		fmt.Printf("warning: failed to delete shadow vector %s: %v\n", conversationID, err)
+		errreport.Background(ctx, "shadow_vector_delete", err)
+	}
+	return nil
+}
+
+// DeleteUserVectors deletes a user's vectors from both stores; unlike a single vector, a user's
+// shadow vectors failing to delete fails the call so a user deletion job can be retried
+func (s *ShadowedVectorStore) DeleteUserVectors(ctx context.Context, userID string) error {
+	if err := s.VectorStore.DeleteUserVectors(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.shadow.DeleteUserVectors(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete shadow vectors: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,882 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// sqliteBusyTimeout is how long a write waits for another connection's write to finish
+const sqliteBusyTimeout = 5 * time.Second
+
+// sqliteBusyPoll is how long SQLite itself waits for a lock before the call checks its context and
+// tries again; SQLite's own wait ignores cancellation
+const sqliteBusyPoll = 50 * time.Millisecond
+
+// SQLiteStore implements the relational stores on an embedded SQLite database file, for
+// single-node installs without a Postgres server. Timestamps are written in UTC so their text
+// form sorts and compares in time order
+type SQLiteStore struct {
+	db sqliteDB
+
+	// cipher encrypts conversation content at rest; nil stores it as plaintext
+	cipher ContentCipher
+}
+
+// sqliteDB retries the statements and transactions that find the database locked by another
+// connection's write for up to sqliteBusyTimeout, and gives up as soon as the caller's context
+// ends. Transactions begin immediate, so a transaction waits for the lock when it begins rather
+// than failing when its first write finds the lock taken
+type sqliteDB struct {
+	*sql.DB
+}
+
+// ExecContext executes a statement, retrying while the database is locked
+func (db sqliteDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := retryBusy(ctx, func() error {
+		var err error
+		result, err = db.DB.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+// QueryContext runs a query, retrying while the database is locked
+func (db sqliteDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := retryBusy(ctx, func() error {
+		var err error
+		rows, err = db.DB.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRowContext runs a query returning at most one row, retrying while the database is locked
+func (db sqliteDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	var row *sql.Row
+	retryBusy(ctx, func() error {
+		row = db.DB.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+	return row
+}
+
+// BeginTx begins a transaction, retrying while another connection holds the write lock
+func (db sqliteDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	var tx *sql.Tx
+	err := retryBusy(ctx, func() error {
+		var err error
+		tx, err = db.DB.BeginTx(ctx, opts)
+		return err
+	})
+	return tx, err
+}
+
+// retryBusy runs fn until it doesn't find the database locked, sqliteBusyTimeout passes or ctx ends
+func retryBusy(ctx context.Context, fn func() error) error {
+	giveUp := time.Now().Add(sqliteBusyTimeout)
+	for {
+		err := fn()
+		if !sqliteBusy(err) || time.Now().After(giveUp) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// sqliteBusy reports whether a call failed because another connection holds a lock it needs
+func sqliteBusy(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code()&0xff == sqlite3.SQLITE_BUSY
+}
+
+// NewSQLiteStore opens or creates the SQLite database at path in write-ahead log mode, so reads
+// don't wait for writes. Unlike the Postgres schema no trigger stamps updated_at: it is what
+// the caller last saved
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(%d)&_time_format=sqlite&_txlock=immediate",
+		path, sqliteBusyPoll.Milliseconds())
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
+
+	return &SQLiteStore{db: sqliteDB{db}}, nil
+}
+
+// SetContentCipher encrypts the question, answer and message content of conversations saved from
+// now on, as PostgresStore.SetContentCipher does
+func (ss *SQLiteStore) SetContentCipher(cipher ContentCipher) {
+	ss.cipher = cipher
+}
+
+// SaveConversation saves a conversation and its messages to SQLite
+func (ss *SQLiteStore) SaveConversation(ctx context.Context, conv *models.Conversation) error {
+	return ss.SaveConversationThen(ctx, conv, nil)
+}
+
+// SaveConversationThen saves a conversation and its messages, then runs beforeCommit inside the
+// transaction; the save is rolled back if beforeCommit fails
+func (ss *SQLiteStore) SaveConversationThen(ctx context.Context, conv *models.Conversation, beforeCommit func(ctx context.Context) error) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "save_conversation", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := ss.saveConversation(ctx, tx, conv); err != nil {
+		return err
+	}
+
+	if beforeCommit != nil {
+		if err := beforeCommit(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit conversation: %w", err)
+	}
+
+	return nil
+}
+
+// SaveConversationWithJob saves a conversation and its messages and enqueues a work queue item in
+// the same transaction, returning the item's ID
+func (ss *SQLiteStore) SaveConversationWithJob(ctx context.Context, conv *models.Conversation, kind string, payload interface{}, availableAt time.Time) (int64, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "save_conversation", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal queue payload: %w", err)
+	}
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := ss.saveConversation(ctx, tx, conv); err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO work_queue (kind, payload, available_at, created_at)
+		VALUES (?1, ?2, ?3, ?4)
+		RETURNING id
+	`
+
+	var id int64
+	if err := tx.QueryRowContext(ctx, query, kind, string(data), availableAt.UTC(), time.Now().UTC()).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to enqueue %s item: %w", kind, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit conversation: %w", err)
+	}
+
+	return id, nil
+}
+
+// saveConversation upserts a conversation row and replaces its messages
+func (ss *SQLiteStore) saveConversation(ctx context.Context, tx *sql.Tx, conv *models.Conversation) error {
+	question, err := encryptContent(ctx, ss.cipher, conv.Question)
+	if err != nil {
+		return err
+	}
+	answer, err := encryptContent(ctx, ss.cipher, conv.Answer)
+	if err != nil {
+		return err
+	}
+
+	unembeddedAt, unembeddedReason, unembeddedDetail := unembeddedArgs(conv.Unembedded)
+	query := `
+		INSERT INTO conversations (id, user_id, session_id, question, answer, metadata, created_at, updated_at, importance, content_hash,
+			unembedded_at, unembedded_reason, unembedded_detail, status)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13, ?14)
+		ON CONFLICT (id) DO UPDATE SET
+			session_id = excluded.session_id,
+			question = excluded.question,
+			answer = excluded.answer,
+			metadata = excluded.metadata,
+			updated_at = excluded.updated_at,
+			importance = excluded.importance,
+			content_hash = excluded.content_hash,
+			unembedded_at = excluded.unembedded_at,
+			unembedded_reason = excluded.unembedded_reason,
+			unembedded_detail = excluded.unembedded_detail,
+			status = excluded.status
+	`
+
+	_, err = tx.ExecContext(
+		ctx,
+		query,
+		conv.ID,
+		conv.UserID,
+		nullString(conv.SessionID),
+		question,
+		answer,
+		conv.Metadata,
+		conv.CreatedAt.UTC(),
+		conv.UpdatedAt.UTC(),
+		conv.Importance,
+		conv.ContentHash,
+		unembeddedAt,
+		unembeddedReason,
+		unembeddedDetail,
+		conversationStatus(conv),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to save conversation: %w", err)
+	}
+
+	return ss.saveMessages(ctx, tx, conv.ID, conv.Messages)
+}
+
+// saveMessages replaces the stored messages of a conversation
+func (ss *SQLiteStore) saveMessages(ctx context.Context, tx *sql.Tx, conversationID string, messages []models.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM messages WHERE conversation_id = ?1`, conversationID); err != nil {
+		return fmt.Errorf("failed to replace messages: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO messages (conversation_id, message_id, position, role, content, speaker, display_name, created_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare message insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, msg := range messages {
+		createdAt := time.Now()
+		if msg.Timestamp != nil {
+			createdAt = *msg.Timestamp
+		}
+
+		content, err := encryptContent(ctx, ss.cipher, msg.Content)
+		if err != nil {
+			return err
+		}
+
+		_, err = stmt.ExecContext(
+			ctx,
+			conversationID,
+			msg.MessageID,
+			i,
+			msg.Role,
+			content,
+			nullString(msg.Speaker),
+			nullString(msg.DisplayName),
+			createdAt.UTC(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save message %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// getMessages loads the messages of the given conversations keyed by conversation ID
+func (ss *SQLiteStore) getMessages(ctx context.Context, conversationIDs []string) (map[string][]models.Message, error) {
+	query := `
+		SELECT conversation_id, message_id, role, content, speaker, display_name, created_at
+		FROM messages
+		WHERE conversation_id IN (SELECT value FROM json_each(?1))
+		ORDER BY conversation_id, position
+	`
+
+	rows, err := ss.db.QueryContext(ctx, query, sqliteArray(conversationIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make(map[string][]models.Message)
+	for rows.Next() {
+		var (
+			conversationID string
+			msg            models.Message
+			speaker        sql.NullString
+			displayName    sql.NullString
+			createdAt      time.Time
+		)
+		if err := rows.Scan(&conversationID, &msg.MessageID, &msg.Role, &msg.Content, &speaker, &displayName, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		if msg.Content, err = decryptContent(ctx, ss.cipher, msg.Content); err != nil {
+			return nil, err
+		}
+		msg.Speaker = speaker.String
+		msg.DisplayName = displayName.String
+		msg.Timestamp = &createdAt
+		messages[conversationID] = append(messages[conversationID], msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// sqliteArray encodes values as a JSON array, which queries expand with json_each in place of
+// Postgres' ANY($1)
+func sqliteArray(values []string) string {
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+// GetConversation retrieves a conversation by ID from SQLite
+func (ss *SQLiteStore) GetConversation(ctx context.Context, id string) (*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_conversation", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE id = ?1
+	`
+
+	conv, err := scanConversation(ss.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if err := decryptConversation(ctx, ss.cipher, conv); err != nil {
+		return nil, err
+	}
+
+	messages, err := ss.getMessages(ctx, []string{conv.ID})
+	if err != nil {
+		return nil, err
+	}
+	conv.Messages = messages[conv.ID]
+
+	return conv, nil
+}
+
+// GetConversationsByIDs retrieves conversations in the order of ids, each once; IDs with no stored
+// conversation are returned as missing
+func (ss *SQLiteStore) GetConversationsByIDs(ctx context.Context, ids []string) ([]*models.Conversation, []string, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_conversations_by_ids", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	if len(ids) == 0 {
+		return []*models.Conversation{}, []string{}, nil
+	}
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE id IN (SELECT value FROM json_each(?1))
+	`
+
+	found, err := ss.queryConversations(ctx, query, sqliteArray(ids))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byID := make(map[string]*models.Conversation, len(found))
+	for _, conv := range found {
+		byID[conv.ID] = conv
+	}
+
+	conversations := make([]*models.Conversation, 0, len(found))
+	missing := []string{}
+	for _, id := range ids {
+		conv, ok := byID[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		if conv == nil {
+			// Listed twice; already added
+			continue
+		}
+		conversations = append(conversations, conv)
+		byID[id] = nil
+	}
+
+	return conversations, missing, nil
+}
+
+// GetConversationsByUser retrieves all of a user's conversations from SQLite
+func (ss *SQLiteStore) GetConversationsByUser(ctx context.Context, userID string) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_conversations_by_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = ?1
+		ORDER BY created_at DESC
+	`
+
+	return ss.queryConversations(ctx, query, userID)
+}
+
+// GetPinnedConversations retrieves a user's pinned, unsuppressed conversations, newest first
+func (ss *SQLiteStore) GetPinnedConversations(ctx context.Context, userID string) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_pinned_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = ?1 AND pinned AND suppressed_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	return ss.queryConversations(ctx, query, userID)
+}
+
+// GetSuppressedConversations retrieves a user's suppressed conversations, most recently suppressed first
+func (ss *SQLiteStore) GetSuppressedConversations(ctx context.Context, userID string) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_suppressed_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = ?1 AND suppressed_at IS NOT NULL
+		ORDER BY suppressed_at DESC
+	`
+
+	return ss.queryConversations(ctx, query, userID)
+}
+
+// GetTopConversationsByUser retrieves a user's unsuppressed conversations ordered by an integer
+// conversation_score in their metadata, then recency
+func (ss *SQLiteStore) GetTopConversationsByUser(ctx context.Context, userID string, limit int) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_top_conversations_by_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = ?1 AND suppressed_at IS NULL
+		ORDER BY
+			CASE WHEN json_valid(metadata)
+				AND ltrim(json_extract(metadata, '$.conversation_score'), '-') <> ''
+				AND ltrim(json_extract(metadata, '$.conversation_score'), '-') NOT GLOB '*[^0-9]*'
+				THEN CAST(json_extract(metadata, '$.conversation_score') AS INTEGER) END DESC NULLS LAST,
+			updated_at DESC
+		LIMIT ?2
+	`
+
+	return ss.queryConversations(ctx, query, userID, limit)
+}
+
+// GetSessionConversations retrieves a session's conversations in chronological order
+func (ss *SQLiteStore) GetSessionConversations(ctx context.Context, sessionID string) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_session_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE session_id = ?1
+		ORDER BY created_at ASC
+	`
+
+	return ss.queryConversations(ctx, query, sessionID)
+}
+
+// queryConversations runs a conversation query and attaches each conversation's messages
+func (ss *SQLiteStore) queryConversations(ctx context.Context, query string, args ...interface{}) ([]*models.Conversation, error) {
+	rows, err := ss.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conversations: %w", err)
+	}
+	defer rows.Close()
+
+	conversations := []*models.Conversation{}
+	var ids []string
+	for rows.Next() {
+		conv, err := scanConversation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan conversation: %w", err)
+		}
+		if err := decryptConversation(ctx, ss.cipher, conv); err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, conv)
+		ids = append(ids, conv.ID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating conversations: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return conversations, nil
+	}
+
+	messages, err := ss.getMessages(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, conv := range conversations {
+		conv.Messages = messages[conv.ID]
+	}
+
+	return conversations, nil
+}
+
+// DeleteConversation deletes a conversation and its messages
+func (ss *SQLiteStore) DeleteConversation(ctx context.Context, id string) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "delete_conversation", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	if _, err := ss.db.ExecContext(ctx, `DELETE FROM conversations WHERE id = ?1`, id); err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+
+	return nil
+}
+
+// ListForgettableConversations returns unpinned conversations last updated before the given time
+// whose importance, halved every halfLife since the last update, has fallen below the threshold.
+// Conversations of users exempt from retention are never forgettable
+func (ss *SQLiteStore) ListForgettableConversations(ctx context.Context, threshold float64, halfLife time.Duration, before time.Time, limit int, offset int) ([]string, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "list_forgettable_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT id FROM conversations
+		WHERE updated_at < ?3
+			AND NOT pinned
+			AND user_id NOT IN (SELECT id FROM users WHERE retention_exempt)
+			AND CASE WHEN ?2 > 0
+				THEN importance * power(0.5, (julianday('now') - julianday(updated_at)) * 86400.0 / ?2)
+				ELSE importance END < ?1
+		ORDER BY updated_at ASC, id ASC
+		LIMIT ?4 OFFSET ?5
+	`
+
+	rows, err := ss.db.QueryContext(ctx, query, threshold, halfLife.Seconds(), before.UTC(), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query forgettable conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating forgettable conversations: %w", err)
+	}
+
+	return ids, nil
+}
+
+// SetConversationPinned pins or unpins a conversation; it reports false if the conversation doesn't exist
+func (ss *SQLiteStore) SetConversationPinned(ctx context.Context, id string, pinned bool) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "set_conversation_pinned", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	result, err := ss.db.ExecContext(ctx, `UPDATE conversations SET pinned = ?2 WHERE id = ?1`, id, pinned)
+	if err != nil {
+		return false, fmt.Errorf("failed to pin conversation: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// SetConversationSuppression suppresses a conversation, or restores it when suppression is nil;
+// it reports false if the conversation doesn't exist
+func (ss *SQLiteStore) SetConversationSuppression(ctx context.Context, id string, suppression *models.Suppression) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "set_conversation_suppression", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	at, reason, note := sqliteSuppressionArgs(suppression)
+	query := `
+		UPDATE conversations
+		SET suppressed_at = ?2, suppression_reason = ?3, suppression_note = ?4
+		WHERE id = ?1
+	`
+
+	result, err := ss.db.ExecContext(ctx, query, id, at, reason, note)
+	if err != nil {
+		return false, fmt.Errorf("failed to update conversation suppression: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// sqliteSuppressionArgs returns the column values for a suppression with the time in UTC
+func sqliteSuppressionArgs(suppression *models.Suppression) (sql.NullTime, sql.NullString, sql.NullString) {
+	at, reason, note := suppressionArgs(suppression)
+	at.Time = at.Time.UTC()
+	return at, reason, note
+}
+
+// ListConversationIDs returns up to limit conversation IDs greater than afterID in ID order,
+// optionally of one user
+func (ss *SQLiteStore) ListConversationIDs(ctx context.Context, userID string, afterID string, limit int) ([]string, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "list_conversation_ids", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT id FROM conversations
+		WHERE id > ?1 AND (?2 = '' OR user_id = ?2)
+		ORDER BY id
+		LIMIT ?3
+	`
+
+	rows, err := ss.db.QueryContext(ctx, query, afterID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversation IDs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating conversation IDs: %w", err)
+	}
+
+	return ids, nil
+}
+
+// SetConversationContentHash records the hash of the text last embedded for a conversation
+func (ss *SQLiteStore) SetConversationContentHash(ctx context.Context, id string, contentHash string) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "set_conversation_content_hash", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	if _, err := ss.db.ExecContext(ctx, `UPDATE conversations SET content_hash = ?2 WHERE id = ?1`, id, contentHash); err != nil {
+		return fmt.Errorf("failed to set conversation content hash: %w", err)
+	}
+	return nil
+}
+
+// SetConversationMetadata replaces a conversation's metadata JSON; it reports false if the
+// conversation doesn't exist
+func (ss *SQLiteStore) SetConversationMetadata(ctx context.Context, id string, metadata string) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "set_conversation_metadata", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	result, err := ss.db.ExecContext(ctx, `UPDATE conversations SET metadata = ?2 WHERE id = ?1`, id, metadata)
+	if err != nil {
+		return false, fmt.Errorf("failed to set conversation metadata: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// SetConversationUnembedded marks a conversation whose text the embedding provider refused, or
+// clears the mark when unembedded is nil; it reports false if the conversation doesn't exist
+func (ss *SQLiteStore) SetConversationUnembedded(ctx context.Context, id string, unembedded *models.Unembedded) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "set_conversation_unembedded", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	at, reason, detail := unembeddedArgs(unembedded)
+	query := `
+		UPDATE conversations
+		SET unembedded_at = ?2, unembedded_reason = ?3, unembedded_detail = ?4
+		WHERE id = ?1
+	`
+
+	result, err := ss.db.ExecContext(ctx, query, id, at, reason, detail)
+	if err != nil {
+		return false, fmt.Errorf("failed to update conversation embedding status: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// ListUnembeddedConversations retrieves a page of conversations the embedding provider refused,
+// most recently refused first, optionally of one user, and their total count
+func (ss *SQLiteStore) ListUnembeddedConversations(ctx context.Context, userID string, limit int, offset int) ([]*models.Conversation, int, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "list_unembedded_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM conversations WHERE unembedded_at IS NOT NULL AND (?1 = '' OR user_id = ?1)`
+	if err := ss.db.QueryRowContext(ctx, countQuery, userID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count unembedded conversations: %w", err)
+	}
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE unembedded_at IS NOT NULL AND (?1 = '' OR user_id = ?1)
+		ORDER BY unembedded_at DESC, id
+		LIMIT ?2 OFFSET ?3
+	`
+
+	conversations, err := ss.queryConversations(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return conversations, total, nil
+}
+
+// SetConversationStatus moves a conversation to status if its current status is one of from; it
+// reports false if the conversation doesn't exist or is in another status
+func (ss *SQLiteStore) SetConversationStatus(ctx context.Context, id string, status string, from []string) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "set_conversation_status", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `UPDATE conversations SET status = ?2 WHERE id = ?1 AND status IN (SELECT value FROM json_each(?3))`
+
+	result, err := ss.db.ExecContext(ctx, query, id, status, sqliteArray(from))
+	if err != nil {
+		return false, fmt.Errorf("failed to update conversation status: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// ListUserConversations retrieves a page of a user's conversations, newest first, optionally in
+// one status, and their total count
+func (ss *SQLiteStore) ListUserConversations(ctx context.Context, userID string, status string, limit int, offset int) ([]*models.Conversation, int, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "list_user_conversations", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	// The unfiltered total is the user's maintained stats rather than a count of their rows
+	countQuery, countArgs := `SELECT COALESCE((SELECT conversation_count FROM user_stats WHERE user_id = ?1), 0)`, []interface{}{userID}
+	if status != "" {
+		countQuery, countArgs = `SELECT COUNT(*) FROM conversations WHERE user_id = ?1 AND status = ?2`, []interface{}{userID, status}
+	}
+	var total int
+	if err := ss.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count conversations: %w", err)
+	}
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = ?1 AND (?2 = '' OR status = ?2)
+		ORDER BY created_at DESC, id
+		LIMIT ?3 OFFSET ?4
+	`
+
+	conversations, err := ss.queryConversations(ctx, query, userID, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return conversations, total, nil
+}
+
+// ListUserConversationsBefore retrieves up to limit of a user's conversations created before a
+// time, newest first
+func (ss *SQLiteStore) ListUserConversationsBefore(ctx context.Context, userID string, before time.Time, limit int) ([]*models.Conversation, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "list_user_conversations_before", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT ` + conversationColumns + `
+		FROM conversations
+		WHERE user_id = ?1 AND created_at < ?2
+		ORDER BY created_at DESC, id
+		LIMIT ?3
+	`
+
+	return ss.queryConversations(ctx, query, userID, before.UTC(), limit)
+}
+
+// CountUserConversationsBetween counts a user's conversations created at or after from and
+// before to
+func (ss *SQLiteStore) CountUserConversationsBetween(ctx context.Context, userID string, from time.Time, to time.Time) (int, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "count_user_conversations_between", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `SELECT COUNT(*) FROM conversations WHERE user_id = ?1 AND created_at >= ?2 AND created_at < ?3`
+
+	var count int
+	if err := ss.db.QueryRowContext(ctx, query, userID, from.UTC(), to.UTC()).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count conversations: %w", err)
+	}
+	return count, nil
+}
+
+// Close closes the database
+func (ss *SQLiteStore) Close() error {
+	return ss.db.Close()
+}
+
+// GetDB returns the database connection
+func (ss *SQLiteStore) GetDB() *sql.DB {
+	return ss.db.DB
+}
+
+// Ping checks the database connection
+func (ss *SQLiteStore) Ping(ctx context.Context) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "ping", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	return ss.db.PingContext(ctx)
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// CreateUser registers a user; it reports false if the user is already registered
+func (ss *SQLiteStore) CreateUser(ctx context.Context, user *models.User) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "create_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		INSERT INTO users (id, display_name, status, retention_exempt, region, created_at, updated_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	result, err := ss.db.ExecContext(ctx, query, user.ID, user.DisplayName, user.Status, user.RetentionExempt, user.Region, user.CreatedAt.UTC(), user.UpdatedAt.UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to create user: %w", err)
+	}
+	return rowsAffected(result)
+}
+
+// GetUser retrieves a registered user, or nil if the user isn't registered
+func (ss *SQLiteStore) GetUser(ctx context.Context, id string) (*models.User, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	user, err := scanUser(ss.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// ListUsers retrieves a page of registered users in ID order, optionally of one status
+func (ss *SQLiteStore) ListUsers(ctx context.Context, status string, limit int, offset int) ([]*models.User, int, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "list_users", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	var total int
+	if err := ss.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE (?1 = '' OR status = ?1)`, status).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE (?1 = '' OR status = ?1) ORDER BY id LIMIT ?2 OFFSET ?3`
+	rows, err := ss.db.QueryContext(ctx, query, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return users, total, nil
+}
+
+// UpdateUser saves a registered user's display name, retention exemption and region; it reports
+// false if the user isn't registered
+func (ss *SQLiteStore) UpdateUser(ctx context.Context, user *models.User) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "update_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `UPDATE users SET display_name = ?2, retention_exempt = ?3, region = ?4, updated_at = ?5 WHERE id = ?1`
+	result, err := ss.db.ExecContext(ctx, query, user.ID, user.DisplayName, user.RetentionExempt, user.Region, user.UpdatedAt.UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to update user: %w", err)
+	}
+	return rowsAffected(result)
+}
+
+// DisableUser disables a user, registering it first if needed, and returns the stored record
+func (ss *SQLiteStore) DisableUser(ctx context.Context, id string, reason string, at time.Time) (*models.User, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "disable_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		INSERT INTO users (id, status, disabled_reason, disabled_at, created_at, updated_at)
+		VALUES (?1, ?2, ?3, ?4, ?4, ?4)
+		ON CONFLICT (id) DO UPDATE SET
+			status = excluded.status,
+			disabled_reason = excluded.disabled_reason,
+			disabled_at = excluded.disabled_at,
+			updated_at = excluded.updated_at
+		RETURNING ` + userColumns
+
+	user, err := scanUser(ss.db.QueryRowContext(ctx, query, id, models.UserStatusDisabled, reason, at.UTC()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to disable user: %w", err)
+	}
+	return user, nil
+}
+
+// EnableUser re-enables a registered user and returns the stored record, or nil if the user
+// isn't registered
+func (ss *SQLiteStore) EnableUser(ctx context.Context, id string, at time.Time) (*models.User, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "enable_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		UPDATE users SET status = ?2, disabled_reason = '', disabled_at = NULL, updated_at = ?3
+		WHERE id = ?1
+		RETURNING ` + userColumns
+
+	user, err := scanUser(ss.db.QueryRowContext(ctx, query, id, models.UserStatusActive, at.UTC()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable user: %w", err)
+	}
+	return user, nil
+}
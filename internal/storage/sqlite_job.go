@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// CreateJob records a started job
+func (ss *SQLiteStore) CreateJob(ctx context.Context, job *models.Job) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "create_job", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		INSERT INTO admin_jobs (id, kind, target, dry_run, status, started_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+	`
+
+	if _, err := ss.db.ExecContext(ctx, query, job.ID, job.Kind, job.Target, job.DryRun, job.Status, job.StartedAt.UTC()); err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return nil
+}
+
+// FinishJob records a job's final status and result
+func (ss *SQLiteStore) FinishJob(ctx context.Context, job *models.Job) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "finish_job", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	var result interface{}
+	if len(job.Result) > 0 {
+		result = string(job.Result)
+	}
+	var finishedAt interface{}
+	if job.FinishedAt != nil {
+		finishedAt = job.FinishedAt.UTC()
+	}
+
+	query := `
+		UPDATE admin_jobs
+		SET status = ?2, result = ?3, error = ?4, finished_at = ?5
+		WHERE id = ?1
+	`
+
+	if _, err := ss.db.ExecContext(ctx, query, job.ID, job.Status, result, job.Error, finishedAt); err != nil {
+		return fmt.Errorf("failed to finish job: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateJobProgress replaces the partial result of a running job
+func (ss *SQLiteStore) UpdateJobProgress(ctx context.Context, id string, result []byte) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "update_job_progress", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		UPDATE admin_jobs
+		SET result = ?2
+		WHERE id = ?1 AND status = ?3
+	`
+
+	if _, err := ss.db.ExecContext(ctx, query, id, string(result), models.JobStatusRunning); err != nil {
+		return fmt.Errorf("failed to update job progress: %w", err)
+	}
+
+	return nil
+}
+
+// GetJob retrieves a job by ID
+func (ss *SQLiteStore) GetJob(ctx context.Context, id string) (*models.Job, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_job", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `SELECT ` + jobColumns + ` FROM admin_jobs WHERE id = ?1`
+
+	job, err := scanJob(ss.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return job, nil
+}
+
+// ListJobs retrieves the most recent jobs, optionally of one kind
+func (ss *SQLiteStore) ListJobs(ctx context.Context, kind string, limit int) ([]*models.Job, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "list_jobs", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT ` + jobColumns + `
+		FROM admin_jobs
+		WHERE ?1 = '' OR kind = ?1
+		ORDER BY started_at DESC
+		LIMIT ?2
+	`
+
+	rows, err := ss.db.QueryContext(ctx, query, kind, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []*models.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating jobs: %w", err)
+	}
+
+	return jobs, nil
+}
@@ -0,0 +1,336 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// sqliteMigrations are the schema changes of the embedded SQLite store, in order. The database's
+// user_version records how many have been applied, so append new migrations and never edit one
+// that has shipped. The tables mirror the Postgres layout, with timestamps stored as UTC text and
+// JSON as text
+var sqliteMigrations = []string{
+	// 1: conversations, messages, personal info and the work queue
+	`
+	CREATE TABLE conversations (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		session_id TEXT,
+		question TEXT NOT NULL,
+		answer TEXT,
+		metadata TEXT,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		importance REAL NOT NULL DEFAULT 0.5,
+		pinned BOOLEAN NOT NULL DEFAULT 0,
+		suppressed_at TIMESTAMP,
+		suppression_reason TEXT,
+		suppression_note TEXT,
+		content_hash TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE INDEX idx_conversations_user_created ON conversations(user_id, created_at DESC);
+	CREATE INDEX idx_conversations_session ON conversations(session_id, created_at);
+	CREATE INDEX idx_conversations_updated_at ON conversations(updated_at);
+
+	CREATE TABLE messages (
+		id INTEGER PRIMARY KEY,
+		conversation_id TEXT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+		message_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		role TEXT NOT NULL,
+		content TEXT NOT NULL,
+		speaker TEXT,
+		display_name TEXT,
+		created_at TIMESTAMP NOT NULL,
+		UNIQUE (conversation_id, position),
+		UNIQUE (conversation_id, message_id)
+	);
+
+	CREATE TABLE personal_info (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		content TEXT NOT NULL,
+		category TEXT NOT NULL,
+		importance TEXT NOT NULL,
+		pinned BOOLEAN NOT NULL DEFAULT 0,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		suppressed_at TIMESTAMP,
+		suppression_reason TEXT,
+		suppression_note TEXT
+	);
+
+	CREATE INDEX idx_personal_info_user_created ON personal_info(user_id, created_at DESC);
+
+	CREATE TABLE work_queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		available_at TIMESTAMP NOT NULL,
+		lease_owner TEXT,
+		lease_expires_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX idx_work_queue_kind_available_at ON work_queue(kind, available_at);
+	`,
+
+	// 2: conversations stored without a vector because the embedding provider refused their text
+	`
+	ALTER TABLE conversations ADD COLUMN unembedded_at TIMESTAMP;
+	ALTER TABLE conversations ADD COLUMN unembedded_reason TEXT;
+	ALTER TABLE conversations ADD COLUMN unembedded_detail TEXT;
+
+	CREATE INDEX idx_conversations_unembedded_at ON conversations(unembedded_at DESC) WHERE unembedded_at IS NOT NULL;
+	`,
+
+	// 3: whether each conversation's vector is written
+	`
+	ALTER TABLE conversations ADD COLUMN status TEXT NOT NULL DEFAULT 'indexed';
+
+	UPDATE conversations SET status = 'failed' WHERE unembedded_at IS NOT NULL AND status = 'indexed';
+
+	CREATE INDEX idx_conversations_user_status ON conversations(user_id, status, created_at DESC);
+	`,
+
+	// 4: per-user conversation aggregates, kept by triggers like the Postgres table
+	`
+	CREATE TABLE user_stats (
+		user_id TEXT PRIMARY KEY,
+		conversation_count INTEGER NOT NULL DEFAULT 0,
+		last_conversation_at TIMESTAMP,
+		total_tokens INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE TRIGGER conversations_user_stats_insert AFTER INSERT ON conversations
+	BEGIN
+		INSERT INTO user_stats (user_id, conversation_count, last_conversation_at)
+		VALUES (NEW.user_id, 1, NEW.created_at)
+		ON CONFLICT (user_id) DO UPDATE SET
+			conversation_count = conversation_count + 1,
+			last_conversation_at = max(COALESCE(last_conversation_at, excluded.last_conversation_at), excluded.last_conversation_at);
+	END;
+
+	CREATE TRIGGER conversations_user_stats_delete AFTER DELETE ON conversations
+	BEGIN
+		UPDATE user_stats SET conversation_count = max(conversation_count - 1, 0) WHERE user_id = OLD.user_id;
+	END;
+
+	INSERT INTO user_stats (user_id, conversation_count, last_conversation_at)
+	SELECT user_id, COUNT(*), MAX(created_at) FROM conversations GROUP BY user_id;
+	`,
+
+	// 5: old conversation IDs that resolve to the conversation that replaced them
+	`
+	CREATE TABLE id_aliases (
+		alias_id TEXT PRIMARY KEY,
+		conversation_id TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX idx_id_aliases_conversation_id ON id_aliases(conversation_id);
+	`,
+
+	// 6: the rest of the relational surface, so the server runs on SQLite without a Postgres server
+	`
+	CREATE TABLE sessions (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		title TEXT,
+		status TEXT NOT NULL DEFAULT 'open',
+		summary TEXT,
+		summary_updated_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		closed_at TIMESTAMP
+	);
+
+	CREATE INDEX idx_sessions_user_created ON sessions(user_id, created_at DESC);
+
+	CREATE TABLE user_profiles (
+		user_id TEXT PRIMARY KEY,
+		profile TEXT NOT NULL,
+		generated_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX idx_user_profiles_generated_at ON user_profiles(generated_at);
+
+	CREATE TABLE admin_jobs (
+		id TEXT PRIMARY KEY,
+		kind TEXT NOT NULL,
+		target TEXT NOT NULL DEFAULT '',
+		dry_run BOOLEAN NOT NULL DEFAULT 0,
+		status TEXT NOT NULL,
+		result TEXT,
+		error TEXT NOT NULL DEFAULT '',
+		started_at TIMESTAMP NOT NULL,
+		finished_at TIMESTAMP
+	);
+
+	CREATE INDEX idx_admin_jobs_kind_started_at ON admin_jobs(kind, started_at DESC);
+	CREATE INDEX idx_admin_jobs_started_at ON admin_jobs(started_at DESC);
+
+	CREATE TABLE dead_letters (
+		id INTEGER PRIMARY KEY,
+		kind TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		attempts INTEGER NOT NULL,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		failed_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX idx_dead_letters_kind_failed_at ON dead_letters(kind, failed_at DESC);
+	CREATE INDEX idx_dead_letters_failed_at ON dead_letters(failed_at DESC);
+
+	CREATE TABLE users (
+		id TEXT PRIMARY KEY,
+		display_name TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'active',
+		disabled_reason TEXT NOT NULL DEFAULT '',
+		disabled_at TIMESTAMP,
+		retention_exempt BOOLEAN NOT NULL DEFAULT 0,
+		region TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX idx_users_status ON users(status);
+
+	CREATE TABLE embedding_usage (
+		day TEXT NOT NULL,
+		tenant TEXT NOT NULL DEFAULT '',
+		model TEXT NOT NULL,
+		requests INTEGER NOT NULL DEFAULT 0,
+		prompt_tokens INTEGER NOT NULL DEFAULT 0,
+		total_tokens INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (day, tenant, model)
+	);
+
+	CREATE TABLE api_keys (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		prefix TEXT NOT NULL,
+		secret_hash TEXT NOT NULL UNIQUE,
+		scopes TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP,
+		revoked_at TIMESTAMP,
+		rotated_to TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE tenant_data_keys (
+		tenant TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		master_key_id TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		wrapped_key BLOB NOT NULL,
+		PRIMARY KEY (tenant, version)
+	);
+
+	CREATE TABLE search_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		tenant TEXT NOT NULL DEFAULT '',
+		user_id TEXT NOT NULL DEFAULT '',
+		query TEXT NOT NULL,
+		results INTEGER NOT NULL,
+		top_score REAL NOT NULL DEFAULT 0,
+		duration_ms INTEGER NOT NULL,
+		variant TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX idx_search_logs_created_at ON search_logs(created_at);
+	CREATE INDEX idx_search_logs_user_id ON search_logs(user_id);
+
+	CREATE TABLE standing_queries (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		query TEXT NOT NULL,
+		filter TEXT NOT NULL DEFAULT '',
+		threshold REAL NOT NULL,
+		vector TEXT NOT NULL,
+		match_count INTEGER NOT NULL DEFAULT 0,
+		last_matched_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX idx_standing_queries_user_id ON standing_queries(user_id);
+	`,
+
+	// 7: nonces of signed requests, kept until their signatures expire
+	`
+	CREATE TABLE signature_nonces (
+		client_id TEXT NOT NULL,
+		nonce TEXT NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (client_id, nonce)
+	);
+
+	CREATE INDEX idx_signature_nonces_expires_at ON signature_nonces(expires_at);
+	`,
+}
+
+// MigrateSQLite applies the SQLite migrations the database hasn't applied yet, each in its own
+// transaction together with the version bump
+func MigrateSQLite(db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var version int
+	if err := db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("database schema version %d is newer than this server's %d", version, len(sqliteMigrations))
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to run SQLite migration %d: %w", i+1, err)
+		}
+		// PRAGMA doesn't take parameters; the version is an integer we control
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record SQLite migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit SQLite migration %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// PlanSQLiteMigrations reports the migrations MigrateSQLite would apply, one pending entry per
+// migration since a migration runs as a single script, without changing the database
+func PlanSQLiteMigrations(db *sql.DB) (*MigrationPlan, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var version int
+	if err := db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&version); err != nil {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version > len(sqliteMigrations) {
+		return nil, fmt.Errorf("database schema version %d is newer than this server's %d", version, len(sqliteMigrations))
+	}
+
+	plan := &MigrationPlan{Pending: []string{}, Risks: []MigrationRisk{}}
+	for _, migration := range sqliteMigrations[version:] {
+		plan.Pending = append(plan.Pending, strings.Join(strings.Fields(migration), " "))
+	}
+	return plan, nil
+}
@@ -0,0 +1,360 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// SavePersonalInfo saves a new personal information entry to SQLite
+func (ss *SQLiteStore) SavePersonalInfo(ctx context.Context, personalInfo *models.PersonalInfo) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "save_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		INSERT INTO personal_info (id, user_id, content, category, importance, created_at, updated_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+		ON CONFLICT (id) DO UPDATE SET
+			content = excluded.content,
+			category = excluded.category,
+			importance = excluded.importance,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := ss.db.ExecContext(
+		ctx,
+		query,
+		personalInfo.ID,
+		personalInfo.UserID,
+		personalInfo.Content,
+		personalInfo.Category,
+		personalInfo.Importance,
+		personalInfo.CreatedAt.UTC(),
+		personalInfo.UpdatedAt.UTC(),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to save personal info: %w", err)
+	}
+
+	return nil
+}
+
+// GetPersonalInfo retrieves a personal information entry by ID from SQLite
+func (ss *SQLiteStore) GetPersonalInfo(ctx context.Context, id string) (*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT ` + personalInfoColumns + `
+		FROM personal_info
+		WHERE id = ?1
+	`
+
+	personalInfo, err := scanPersonalInfo(ss.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get personal info: %w", err)
+	}
+
+	return personalInfo, nil
+}
+
+// GetPersonalInfoByUser retrieves all personal information entries for a user from SQLite
+func (ss *SQLiteStore) GetPersonalInfoByUser(ctx context.Context, userID string) ([]*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_personal_info_by_user", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT ` + personalInfoColumns + `
+		FROM personal_info
+		WHERE user_id = ?1
+		ORDER BY created_at DESC
+	`
+
+	return ss.queryPersonalInfo(ctx, query, userID)
+}
+
+// GetPersonalInfoByIDs retrieves personal information entries in the order of ids, skipping those
+// that don't exist
+func (ss *SQLiteStore) GetPersonalInfoByIDs(ctx context.Context, ids []string) ([]*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_personal_info_by_ids", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	if len(ids) == 0 {
+		return []*models.PersonalInfo{}, nil
+	}
+
+	query := `
+		SELECT ` + personalInfoColumns + `
+		FROM personal_info
+		WHERE id IN (SELECT value FROM json_each(?1))
+	`
+
+	found, err := ss.queryPersonalInfo(ctx, query, sqliteArray(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*models.PersonalInfo, len(found))
+	for _, personalInfo := range found {
+		byID[personalInfo.ID] = personalInfo
+	}
+
+	ordered := make([]*models.PersonalInfo, 0, len(found))
+	for _, id := range ids {
+		if personalInfo := byID[id]; personalInfo != nil {
+			ordered = append(ordered, personalInfo)
+			byID[id] = nil
+		}
+	}
+	return ordered, nil
+}
+
+// GetPinnedPersonalInfo retrieves a user's pinned, unsuppressed personal information entries, newest first
+func (ss *SQLiteStore) GetPinnedPersonalInfo(ctx context.Context, userID string) ([]*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_pinned_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT ` + personalInfoColumns + `
+		FROM personal_info
+		WHERE user_id = ?1 AND pinned AND suppressed_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	return ss.queryPersonalInfo(ctx, query, userID)
+}
+
+// GetSuppressedPersonalInfo retrieves a user's suppressed personal information, most recently suppressed first
+func (ss *SQLiteStore) GetSuppressedPersonalInfo(ctx context.Context, userID string) ([]*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_suppressed_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT ` + personalInfoColumns + `
+		FROM personal_info
+		WHERE user_id = ?1 AND suppressed_at IS NOT NULL
+		ORDER BY suppressed_at DESC
+	`
+
+	return ss.queryPersonalInfo(ctx, query, userID)
+}
+
+// ListPersonalInfoAfter retrieves up to limit entries of all users with IDs after afterID, in ID order
+func (ss *SQLiteStore) ListPersonalInfoAfter(ctx context.Context, afterID string, limit int) ([]*models.PersonalInfo, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "list_personal_info_after", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT ` + personalInfoColumns + `
+		FROM personal_info
+		WHERE id > ?1
+		ORDER BY id
+		LIMIT ?2
+	`
+
+	return ss.queryPersonalInfo(ctx, query, afterID, limit)
+}
+
+// CountPersonalInfo counts the personal information entries of all users
+func (ss *SQLiteStore) CountPersonalInfo(ctx context.Context) (int64, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "count_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	var count int64
+	if err := ss.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM personal_info`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count personal info: %w", err)
+	}
+	return count, nil
+}
+
+// queryPersonalInfo runs a personal info query selecting the standard columns
+func (ss *SQLiteStore) queryPersonalInfo(ctx context.Context, query string, args ...interface{}) ([]*models.PersonalInfo, error) {
+	rows, err := ss.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query personal info: %w", err)
+	}
+	defer rows.Close()
+
+	var personalInfoList []*models.PersonalInfo
+	for rows.Next() {
+		personalInfo, err := scanPersonalInfo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan personal info: %w", err)
+		}
+		personalInfoList = append(personalInfoList, personalInfo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating personal info: %w", err)
+	}
+
+	return personalInfoList, nil
+}
+
+// UpdatePersonalInfo updates an existing personal information entry in SQLite
+func (ss *SQLiteStore) UpdatePersonalInfo(ctx context.Context, personalInfo *models.PersonalInfo) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "update_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		UPDATE personal_info
+		SET content = ?1, category = ?2, importance = ?3, updated_at = ?4
+		WHERE id = ?5
+	`
+
+	result, err := ss.db.ExecContext(
+		ctx,
+		query,
+		personalInfo.Content,
+		personalInfo.Category,
+		personalInfo.Importance,
+		personalInfo.UpdatedAt.UTC(),
+		personalInfo.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update personal info: %w", err)
+	}
+
+	found, err := rowsAffected(result)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrPersonalInfoNotFound
+	}
+
+	return nil
+}
+
+// UpdatePersonalInfoIfUnchanged updates an entry only if its updated_at still equals readAt, the
+// value the caller read; otherwise it returns ErrPersonalInfoChanged
+func (ss *SQLiteStore) UpdatePersonalInfoIfUnchanged(ctx context.Context, personalInfo *models.PersonalInfo, readAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "update_personal_info_if_unchanged", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		UPDATE personal_info
+		SET content = ?1, category = ?2, importance = ?3, updated_at = ?4
+		WHERE id = ?5 AND updated_at = ?6
+	`
+
+	result, err := ss.db.ExecContext(ctx, query,
+		personalInfo.Content,
+		personalInfo.Category,
+		personalInfo.Importance,
+		personalInfo.UpdatedAt.UTC(),
+		personalInfo.ID,
+		readAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update personal info: %w", err)
+	}
+
+	found, err := rowsAffected(result)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrPersonalInfoChanged
+	}
+	return nil
+}
+
+// SetPersonalInfoPinned pins or unpins a personal information entry; it reports false if the entry doesn't exist
+func (ss *SQLiteStore) SetPersonalInfoPinned(ctx context.Context, id string, pinned bool) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "set_personal_info_pinned", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	result, err := ss.db.ExecContext(ctx, `UPDATE personal_info SET pinned = ?2 WHERE id = ?1`, id, pinned)
+	if err != nil {
+		return false, fmt.Errorf("failed to pin personal info: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// SetPersonalInfoSuppression suppresses a personal information entry, or restores it when suppression
+// is nil; it reports false if the entry doesn't exist
+func (ss *SQLiteStore) SetPersonalInfoSuppression(ctx context.Context, id string, suppression *models.Suppression) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "set_personal_info_suppression", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	at, reason, note := sqliteSuppressionArgs(suppression)
+	query := `
+		UPDATE personal_info
+		SET suppressed_at = ?2, suppression_reason = ?3, suppression_note = ?4
+		WHERE id = ?1
+	`
+
+	result, err := ss.db.ExecContext(ctx, query, id, at, reason, note)
+	if err != nil {
+		return false, fmt.Errorf("failed to update personal info suppression: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// DeletePersonalInfo deletes a personal information entry from SQLite
+func (ss *SQLiteStore) DeletePersonalInfo(ctx context.Context, id string) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "delete_personal_info", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	result, err := ss.db.ExecContext(ctx, `DELETE FROM personal_info WHERE id = ?1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete personal info: %w", err)
+	}
+
+	found, err := rowsAffected(result)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrPersonalInfoNotFound
+	}
+
+	return nil
+}
+
+// DeletePersonalInfoIfUnchanged deletes an entry only if its updated_at still equals readAt;
+// otherwise it returns ErrPersonalInfoChanged
+func (ss *SQLiteStore) DeletePersonalInfoIfUnchanged(ctx context.Context, id string, readAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "delete_personal_info_if_unchanged", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	result, err := ss.db.ExecContext(ctx, `DELETE FROM personal_info WHERE id = ?1 AND updated_at = ?2`, id, readAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to delete personal info: %w", err)
+	}
+
+	found, err := rowsAffected(result)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrPersonalInfoChanged
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// GetUserProfile retrieves a user's cached profile
+func (ss *SQLiteStore) GetUserProfile(ctx context.Context, userID string) (*models.UserProfile, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_user_profile", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	var data string
+	err := ss.db.QueryRowContext(ctx, `SELECT profile FROM user_profiles WHERE user_id = ?1`, userID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+
+	// An encrypted profile is stored as a JSON string of its encrypted JSON, as in Postgres
+	var encrypted string
+	if json.Unmarshal([]byte(data), &encrypted) == nil {
+		if data, err = decryptContent(ctx, ss.cipher, encrypted); err != nil {
+			return nil, err
+		}
+	}
+
+	profile := &models.UserProfile{}
+	if err := json.Unmarshal([]byte(data), profile); err != nil {
+		return nil, fmt.Errorf("failed to decode user profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+// SaveUserProfile inserts or replaces a user's cached profile, encrypted when a content cipher is set
+func (ss *SQLiteStore) SaveUserProfile(ctx context.Context, profile *models.UserProfile) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "save_user_profile", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("failed to encode user profile: %w", err)
+	}
+	if ss.cipher != nil {
+		encrypted, err := encryptContent(ctx, ss.cipher, string(data))
+		if err != nil {
+			return err
+		}
+		if data, err = json.Marshal(encrypted); err != nil {
+			return fmt.Errorf("failed to encode user profile: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO user_profiles (user_id, profile, generated_at)
+		VALUES (?1, ?2, ?3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			profile = excluded.profile,
+			generated_at = excluded.generated_at
+	`
+
+	if _, err := ss.db.ExecContext(ctx, query, profile.UserID, string(data), profile.GeneratedAt.UTC()); err != nil {
+		return fmt.Errorf("failed to save user profile: %w", err)
+	}
+
+	return nil
+}
+
+// ListStaleProfiles returns users whose cached profile was generated before the given time, oldest first
+func (ss *SQLiteStore) ListStaleProfiles(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "list_stale_profiles", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT user_id FROM user_profiles
+		WHERE generated_at < ?1
+		ORDER BY generated_at ASC
+		LIMIT ?2
+	`
+
+	rows, err := ss.db.QueryContext(ctx, query, before.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stale profiles: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan user id: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stale profiles: %w", err)
+	}
+
+	return userIDs, nil
+}
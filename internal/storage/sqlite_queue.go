@@ -0,0 +1,334 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// Enqueue adds an item to the work queue, available immediately
+func (ss *SQLiteStore) Enqueue(ctx context.Context, kind string, payload interface{}) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "enqueue", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal queue payload: %w", err)
+	}
+
+	now := time.Now().UTC()
+	query := `INSERT INTO work_queue (kind, payload, available_at, created_at) VALUES (?1, ?2, ?3, ?3)`
+	if _, err := ss.db.ExecContext(ctx, query, kind, string(data), now); err != nil {
+		return fmt.Errorf("failed to enqueue %s item: %w", kind, err)
+	}
+
+	return nil
+}
+
+// DeleteQueueItem removes an item regardless of its lease, e.g. once its work was done inline
+func (ss *SQLiteStore) DeleteQueueItem(ctx context.Context, id int64) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "delete_queue_item", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	if _, err := ss.db.ExecContext(ctx, `DELETE FROM work_queue WHERE id = ?1`, id); err != nil {
+		return fmt.Errorf("failed to delete queue item: %w", err)
+	}
+	return nil
+}
+
+// ClaimQueueItems leases up to limit due items of the given kinds to owner. Items leased by
+// another worker are skipped unless their lease expired. SQLite runs one write at a time, so the
+// update needs no SKIP LOCKED for two workers never to claim the same item
+func (ss *SQLiteStore) ClaimQueueItems(ctx context.Context, owner string, kinds []string, limit int, lease time.Duration) ([]*models.QueueItem, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "claim_queue_items", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	now := time.Now().UTC()
+	query := `
+		UPDATE work_queue
+		SET lease_owner = ?1, lease_expires_at = ?2, attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM work_queue
+			WHERE kind IN (SELECT value FROM json_each(?3)) AND available_at <= ?4
+				AND (lease_expires_at IS NULL OR lease_expires_at < ?4)
+			ORDER BY available_at, id
+			LIMIT ?5
+		)
+		RETURNING id, kind, payload, attempts, last_error, available_at, created_at
+	`
+
+	rows, err := ss.db.QueryContext(ctx, query, owner, now.Add(lease), sqliteArray(kinds), now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim queue items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*models.QueueItem
+	for rows.Next() {
+		item := &models.QueueItem{}
+		var payload string
+		if err := rows.Scan(&item.ID, &item.Kind, &payload, &item.Attempts, &item.LastError, &item.AvailableAt, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan queue item: %w", err)
+		}
+		item.Payload = json.RawMessage(payload)
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read queue items: %w", err)
+	}
+
+	return items, nil
+}
+
+// ExtendQueueLease extends an item's lease; it reports false if owner no longer holds it
+func (ss *SQLiteStore) ExtendQueueLease(ctx context.Context, id int64, owner string, lease time.Duration) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "extend_queue_lease", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `UPDATE work_queue SET lease_expires_at = ?3 WHERE id = ?1 AND lease_owner = ?2`
+
+	result, err := ss.db.ExecContext(ctx, query, id, owner, time.Now().UTC().Add(lease))
+	if err != nil {
+		return false, fmt.Errorf("failed to extend queue lease: %w", err)
+	}
+	return rowsAffected(result)
+}
+
+// CompleteQueueItem removes a processed item held by owner
+func (ss *SQLiteStore) CompleteQueueItem(ctx context.Context, id int64, owner string) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "complete_queue_item", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	if _, err := ss.db.ExecContext(ctx, `DELETE FROM work_queue WHERE id = ?1 AND lease_owner = ?2`, id, owner); err != nil {
+		return fmt.Errorf("failed to complete queue item: %w", err)
+	}
+	return nil
+}
+
+// RetryQueueItem releases a failed item held by owner, making it available again at retryAt
+func (ss *SQLiteStore) RetryQueueItem(ctx context.Context, id int64, owner string, lastError string, retryAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "retry_queue_item", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		UPDATE work_queue
+		SET lease_owner = NULL, lease_expires_at = NULL, last_error = ?3, available_at = ?4
+		WHERE id = ?1 AND lease_owner = ?2
+	`
+
+	if _, err := ss.db.ExecContext(ctx, query, id, owner, lastError, retryAt.UTC()); err != nil {
+		return fmt.Errorf("failed to release queue item: %w", err)
+	}
+	return nil
+}
+
+// QueueStats reports the backlog of each queue item kind
+func (ss *SQLiteStore) QueueStats(ctx context.Context) ([]models.QueueKindStats, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "queue_stats", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT kind, COUNT(*),
+			COUNT(*) FILTER (WHERE lease_expires_at >= ?1),
+			COALESCE((julianday(?1) - julianday(MIN(available_at) FILTER (
+				WHERE available_at <= ?1 AND (lease_expires_at IS NULL OR lease_expires_at < ?1)
+			))) * 86400.0, 0),
+			COALESCE((julianday(?1) - julianday(MIN(created_at))) * 86400.0, 0)
+		FROM work_queue
+		GROUP BY kind
+		ORDER BY kind
+	`
+
+	rows, err := ss.db.QueryContext(ctx, query, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []models.QueueKindStats{}
+	for rows.Next() {
+		var s models.QueueKindStats
+		if err := rows.Scan(&s.Kind, &s.Depth, &s.Leased, &s.LagSeconds, &s.OldestSeconds); err != nil {
+			return nil, fmt.Errorf("failed to scan queue stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read queue stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// DeadLetterQueueItem moves a queue item held by owner to the dead letter table
+func (ss *SQLiteStore) DeadLetterQueueItem(ctx context.Context, id int64, owner string, lastError string) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "dead_letter_queue_item", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO dead_letters (id, kind, payload, attempts, last_error, created_at, failed_at)
+		SELECT id, kind, payload, attempts, ?3, created_at, ?4
+		FROM work_queue
+		WHERE id = ?1 AND lease_owner = ?2
+	`
+	if _, err := tx.ExecContext(ctx, query, id, owner, lastError, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to dead-letter queue item: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM work_queue WHERE id = ?1 AND lease_owner = ?2`, id, owner); err != nil {
+		return fmt.Errorf("failed to remove dead-lettered queue item: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListDeadLetters retrieves a page of dead letters, newest first, optionally of one kind
+func (ss *SQLiteStore) ListDeadLetters(ctx context.Context, kind string, limit int, offset int) ([]*models.DeadLetter, int, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "list_dead_letters", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	var total int
+	if err := ss.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM dead_letters WHERE ?1 = '' OR kind = ?1`, kind).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count dead letters: %w", err)
+	}
+
+	query := `SELECT ` + deadLetterColumns + ` FROM dead_letters
+		WHERE ?1 = '' OR kind = ?1
+		ORDER BY failed_at DESC, id DESC
+		LIMIT ?2 OFFSET ?3`
+
+	rows, err := ss.db.QueryContext(ctx, query, kind, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	deadLetters := []*models.DeadLetter{}
+	for rows.Next() {
+		deadLetter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read dead letters: %w", err)
+	}
+
+	return deadLetters, total, nil
+}
+
+// GetDeadLetter retrieves a dead letter by ID
+func (ss *SQLiteStore) GetDeadLetter(ctx context.Context, id int64) (*models.DeadLetter, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_dead_letter", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `SELECT ` + deadLetterColumns + ` FROM dead_letters WHERE id = ?1`
+
+	deadLetter, err := scanDeadLetter(ss.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+	return deadLetter, nil
+}
+
+// RequeueDeadLetter moves a dead letter back to the work queue with its attempts reset; it
+// reports false if the dead letter doesn't exist
+func (ss *SQLiteStore) RequeueDeadLetter(ctx context.Context, id int64) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "requeue_dead_letter", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO work_queue (kind, payload, available_at, created_at)
+		SELECT kind, payload, ?2, created_at FROM dead_letters WHERE id = ?1
+	`, id, time.Now().UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to requeue dead letter: %w", err)
+	}
+	if found, err := rowsAffected(result); err != nil || !found {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM dead_letters WHERE id = ?1`, id); err != nil {
+		return false, fmt.Errorf("failed to remove requeued dead letter: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// DeleteDeadLetter discards a dead letter; it reports false if it doesn't exist
+func (ss *SQLiteStore) DeleteDeadLetter(ctx context.Context, id int64) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "delete_dead_letter", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	result, err := ss.db.ExecContext(ctx, `DELETE FROM dead_letters WHERE id = ?1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	return rowsAffected(result)
+}
+
+// CountDeadLetters counts the dead letters of each kind
+func (ss *SQLiteStore) CountDeadLetters(ctx context.Context) (map[string]int64, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "count_dead_letters", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	rows, err := ss.db.QueryContext(ctx, `SELECT kind, COUNT(*) FROM dead_letters GROUP BY kind`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var kind string
+		var count int64
+		if err := rows.Scan(&kind, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter count: %w", err)
+		}
+		counts[kind] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dead letter counts: %w", err)
+	}
+
+	return counts, nil
+}
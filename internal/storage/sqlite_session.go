@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// CreateSession inserts a session; it reports false if a session with the same ID already exists
+func (ss *SQLiteStore) CreateSession(ctx context.Context, session *models.Session) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "create_session", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		INSERT INTO sessions (id, user_id, title, status, created_at, updated_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	result, err := ss.db.ExecContext(
+		ctx,
+		query,
+		session.ID,
+		session.UserID,
+		nullString(session.Title),
+		session.Status,
+		session.CreatedAt.UTC(),
+		session.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// GetSession retrieves a session by ID
+func (ss *SQLiteStore) GetSession(ctx context.Context, id string) (*models.Session, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_session", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `SELECT ` + sessionColumns + ` FROM sessions s WHERE s.id = ?1`
+
+	session, err := scanSession(ss.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.Summary, err = decryptContent(ctx, ss.cipher, session.Summary); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// ListSessions retrieves a page of sessions, newest first, optionally filtered by user and status
+func (ss *SQLiteStore) ListSessions(ctx context.Context, userID string, status string, limit int, offset int) ([]*models.Session, int, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "list_sessions", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	where := `WHERE (?1 = '' OR s.user_id = ?1) AND (?2 = '' OR s.status = ?2)`
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM sessions s ` + where
+	if err := ss.db.QueryRowContext(ctx, countQuery, userID, status).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+
+	query := `SELECT ` + sessionColumns + ` FROM sessions s ` + where + `
+		ORDER BY s.created_at DESC
+		LIMIT ?3 OFFSET ?4`
+
+	rows, err := ss.db.QueryContext(ctx, query, userID, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan session: %w", err)
+		}
+		if session.Summary, err = decryptContent(ctx, ss.cipher, session.Summary); err != nil {
+			return nil, 0, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating sessions: %w", err)
+	}
+
+	return sessions, total, nil
+}
+
+// CloseSession marks a session closed
+func (ss *SQLiteStore) CloseSession(ctx context.Context, id string, closedAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "close_session", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		UPDATE sessions
+		SET status = ?2, closed_at = ?3, updated_at = ?3
+		WHERE id = ?1 AND status <> ?2
+	`
+
+	if _, err := ss.db.ExecContext(ctx, query, id, models.SessionStatusClosed, closedAt.UTC()); err != nil {
+		return fmt.Errorf("failed to close session: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSessionSummary stores a session's summary, encrypted when a content cipher is set
+func (ss *SQLiteStore) UpdateSessionSummary(ctx context.Context, id string, summary string, updatedAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "update_session_summary", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	summary, err := encryptContent(ctx, ss.cipher, summary)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE sessions
+		SET summary = ?2, summary_updated_at = ?3, updated_at = ?3
+		WHERE id = ?1
+	`
+
+	if _, err := ss.db.ExecContext(ctx, query, id, summary, updatedAt.UTC()); err != nil {
+		return fmt.Errorf("failed to update session summary: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// CreateStandingQuery inserts a standing query with its vector
+func (ss *SQLiteStore) CreateStandingQuery(ctx context.Context, query *models.StandingQuery) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "create_standing_query", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	vector, err := json.Marshal(query.Vector)
+	if err != nil {
+		return fmt.Errorf("failed to encode standing query vector: %w", err)
+	}
+
+	_, err = ss.db.ExecContext(ctx, `
+		INSERT INTO standing_queries (id, user_id, query, filter, threshold, vector, created_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+	`, query.ID, query.UserID, query.Query, query.Filter, query.Threshold, string(vector), query.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create standing query: %w", err)
+	}
+
+	return nil
+}
+
+// GetStandingQuery retrieves a standing query by ID
+func (ss *SQLiteStore) GetStandingQuery(ctx context.Context, id string) (*models.StandingQuery, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_standing_query", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query, err := scanStandingQuery(ss.db.QueryRowContext(ctx, `SELECT `+standingQueryColumns+` FROM standing_queries WHERE id = ?1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get standing query: %w", err)
+	}
+
+	return query, nil
+}
+
+// ListStandingQueries retrieves a user's standing queries with their vectors, oldest first
+func (ss *SQLiteStore) ListStandingQueries(ctx context.Context, userID string) ([]*models.StandingQuery, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "list_standing_queries", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	rows, err := ss.db.QueryContext(ctx, `SELECT `+standingQueryColumns+` FROM standing_queries WHERE user_id = ?1 ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list standing queries: %w", err)
+	}
+	defer rows.Close()
+
+	queries := []*models.StandingQuery{}
+	for rows.Next() {
+		query, err := scanStandingQuery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan standing query: %w", err)
+		}
+		queries = append(queries, query)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating standing queries: %w", err)
+	}
+
+	return queries, nil
+}
+
+// DeleteStandingQuery deletes a standing query
+func (ss *SQLiteStore) DeleteStandingQuery(ctx context.Context, id string) (bool, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "delete_standing_query", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	result, err := ss.db.ExecContext(ctx, `DELETE FROM standing_queries WHERE id = ?1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete standing query: %w", err)
+	}
+
+	return rowsAffected(result)
+}
+
+// UpdateStandingQueryVector replaces a standing query's vector
+func (ss *SQLiteStore) UpdateStandingQueryVector(ctx context.Context, id string, vector []float32) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "update_standing_query_vector", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	data, err := json.Marshal(vector)
+	if err != nil {
+		return fmt.Errorf("failed to encode standing query vector: %w", err)
+	}
+	if _, err := ss.db.ExecContext(ctx, `UPDATE standing_queries SET vector = ?2 WHERE id = ?1`, id, string(data)); err != nil {
+		return fmt.Errorf("failed to update standing query vector: %w", err)
+	}
+
+	return nil
+}
+
+// RecordStandingQueryMatch counts a match of a standing query
+func (ss *SQLiteStore) RecordStandingQueryMatch(ctx context.Context, id string, matchedAt time.Time) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "record_standing_query_match", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	_, err := ss.db.ExecContext(ctx, `
+		UPDATE standing_queries
+		SET match_count = match_count + 1, last_matched_at = max(COALESCE(last_matched_at, ?2), ?2)
+		WHERE id = ?1
+	`, id, matchedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to record standing query match: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// AddUsage adds usage records to the stored daily totals in one transaction
+func (ss *SQLiteStore) AddUsage(ctx context.Context, records []models.UsageRecord) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "add_usage", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO embedding_usage (day, tenant, model, requests, prompt_tokens, total_tokens)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+		ON CONFLICT (day, tenant, model) DO UPDATE SET
+			requests = requests + excluded.requests,
+			prompt_tokens = prompt_tokens + excluded.prompt_tokens,
+			total_tokens = total_tokens + excluded.total_tokens
+	`
+
+	for _, record := range records {
+		if _, err := tx.ExecContext(ctx, query, record.Day, record.Tenant, record.Model, record.Requests, record.PromptTokens, record.TotalTokens); err != nil {
+			return fmt.Errorf("failed to add usage: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit usage: %w", err)
+	}
+
+	return nil
+}
+
+// ListUsage retrieves the daily totals between two dates inclusive, oldest first, optionally of
+// one tenant. Days are stored as YYYY-MM-DD text, which compares in date order
+func (ss *SQLiteStore) ListUsage(ctx context.Context, from string, to string, tenant string) ([]models.UsageRecord, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "list_usage", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT day, tenant, model, requests, prompt_tokens, total_tokens
+		FROM embedding_usage
+		WHERE day BETWEEN ?1 AND ?2 AND (?3 = '' OR tenant = ?3)
+		ORDER BY day, tenant, model
+	`
+
+	rows, err := ss.db.QueryContext(ctx, query, from, to, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list usage: %w", err)
+	}
+	defer rows.Close()
+
+	records := []models.UsageRecord{}
+	for rows.Next() {
+		var record models.UsageRecord
+		if err := rows.Scan(&record.Day, &record.Tenant, &record.Model, &record.Requests, &record.PromptTokens, &record.TotalTokens); err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating usage: %w", err)
+	}
+
+	return records, nil
+}
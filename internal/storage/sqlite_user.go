@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/slowlog"
+	"refo-rag-server/internal/timeouts"
+)
+
+// CountUserData counts the records stored for a user. Query adapters are kept in Postgres only
+func (ss *SQLiteStore) CountUserData(ctx context.Context, userID string) (*models.UserDataCounts, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "count_user_data", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	query := `
+		SELECT
+			COALESCE((SELECT conversation_count FROM user_stats WHERE user_id = ?1), 0),
+			(SELECT COUNT(*) FROM messages m JOIN conversations c ON c.id = m.conversation_id WHERE c.user_id = ?1),
+			(SELECT COUNT(*) FROM personal_info WHERE user_id = ?1),
+			(SELECT COUNT(*) FROM sessions WHERE user_id = ?1),
+			(SELECT COUNT(*) FROM user_profiles WHERE user_id = ?1),
+			(SELECT COUNT(*) FROM users WHERE id = ?1),
+			(SELECT COUNT(*) FROM search_logs WHERE user_id = ?1),
+			(SELECT COUNT(*) FROM standing_queries WHERE user_id = ?1)
+	`
+
+	counts := &models.UserDataCounts{}
+	err := ss.db.QueryRowContext(ctx, query, userID).Scan(
+		&counts.Conversations,
+		&counts.Messages,
+		&counts.PersonalInfo,
+		&counts.Sessions,
+		&counts.Profiles,
+		&counts.Account,
+		&counts.SearchLogs,
+		&counts.StandingQueries,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count user data: %w", err)
+	}
+
+	return counts, nil
+}
+
+// DeleteUserData deletes all records stored for a user in one transaction and reports what was deleted
+func (ss *SQLiteStore) DeleteUserData(ctx context.Context, userID string) (*models.UserDataCounts, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "delete_user_data", time.Now())
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	counts := &models.UserDataCounts{}
+
+	// Messages are removed with their conversations
+	err = tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM messages m JOIN conversations c ON c.id = m.conversation_id WHERE c.user_id = ?1`,
+		userID,
+	).Scan(&counts.Messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count user messages: %w", err)
+	}
+
+	// Aliases are removed with the conversations they resolve to
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM id_aliases WHERE conversation_id IN (SELECT id FROM conversations WHERE user_id = ?1)`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete user conversation aliases: %w", err)
+	}
+
+	deletes := []struct {
+		query string
+		count *int64
+	}{
+		{`DELETE FROM conversations WHERE user_id = ?1`, &counts.Conversations},
+		{`DELETE FROM personal_info WHERE user_id = ?1`, &counts.PersonalInfo},
+		{`DELETE FROM sessions WHERE user_id = ?1`, &counts.Sessions},
+		{`DELETE FROM user_profiles WHERE user_id = ?1`, &counts.Profiles},
+		{`DELETE FROM users WHERE id = ?1`, &counts.Account},
+		{`DELETE FROM search_logs WHERE user_id = ?1`, &counts.SearchLogs},
+		{`DELETE FROM standing_queries WHERE user_id = ?1`, &counts.StandingQueries},
+	}
+	for _, d := range deletes {
+		result, err := tx.ExecContext(ctx, d.query, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete user data: %w", err)
+		}
+		if *d.count, err = result.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+	}
+
+	// The user's stats go with their data
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_stats WHERE user_id = ?1`, userID); err != nil {
+		return nil, fmt.Errorf("failed to delete user stats: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return counts, nil
+}
+
+// GetUserStats retrieves a user's conversation aggregates, kept by triggers on conversations; a
+// user without a stats row has zero stats
+func (ss *SQLiteStore) GetUserStats(ctx context.Context, userID string) (*models.UserStats, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "get_user_stats", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	stats := &models.UserStats{UserID: userID}
+	var lastConversationAt sql.NullTime
+	err := ss.db.QueryRowContext(ctx,
+		`SELECT conversation_count, last_conversation_at, total_tokens FROM user_stats WHERE user_id = ?1`,
+		userID,
+	).Scan(&stats.ConversationCount, &lastConversationAt, &stats.TotalTokens)
+	if err == sql.ErrNoRows {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user stats: %w", err)
+	}
+	if lastConversationAt.Valid {
+		stats.LastConversationAt = &lastConversationAt.Time
+	}
+
+	return stats, nil
+}
+
+// AddUserTokens adds embedding tokens spent on a user's conversations to the user's stats
+func (ss *SQLiteStore) AddUserTokens(ctx context.Context, userID string, tokens int64) error {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "add_user_tokens", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	_, err := ss.db.ExecContext(ctx, `
+		INSERT INTO user_stats (user_id, total_tokens) VALUES (?1, ?2)
+		ON CONFLICT (user_id) DO UPDATE SET total_tokens = total_tokens + excluded.total_tokens
+	`, userID, tokens)
+	if err != nil {
+		return fmt.Errorf("failed to add user tokens: %w", err)
+	}
+
+	return nil
+}
+
+// ListActiveUsers retrieves up to limit users, in ID order after afterUserID, whose last
+// conversation was created at or after since
+func (ss *SQLiteStore) ListActiveUsers(ctx context.Context, since time.Time, afterUserID string, limit int) ([]string, error) {
+	defer slowlog.Observe(ctx, slowlog.SQLite, "list_active_users", time.Now())
+	ctx, cancel := timeouts.With(ctx, timeouts.SQLite)
+	defer cancel()
+
+	rows, err := ss.db.QueryContext(ctx, `
+		SELECT user_id FROM user_stats
+		WHERE last_conversation_at >= ?1 AND user_id > ?2
+		ORDER BY user_id
+		LIMIT ?3
+	`, since.UTC(), afterUserID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active users: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
@@ -2,7 +2,9 @@ package storage
 
 import (
 	"context"
+	"time"
 
+	"refo-rag-server/internal/filter"
 	"refo-rag-server/internal/models"
 )
 
@@ -11,16 +13,309 @@ type ConversationStore interface {
 	// SaveConversation saves a new conversation to the database
 	SaveConversation(ctx context.Context, conversation *models.Conversation) error
 
+	// SaveConversationThen saves a conversation and runs beforeCommit in the same transaction,
+	// rolling the save back if it fails
+	SaveConversationThen(ctx context.Context, conversation *models.Conversation, beforeCommit func(ctx context.Context) error) error
+
+	// SaveConversationWithJob saves a conversation and enqueues a work queue item atomically,
+	// returning the item's ID
+	SaveConversationWithJob(ctx context.Context, conversation *models.Conversation, kind string, payload interface{}, availableAt time.Time) (int64, error)
+
 	// GetConversation retrieves a conversation by ID
 	GetConversation(ctx context.Context, id string) (*models.Conversation, error)
 
-	// GetConversationsByIDs retrieves multiple conversations by IDs
-	GetConversationsByIDs(ctx context.Context, ids []string) ([]*models.Conversation, error)
+	// GetConversationsByIDs retrieves multiple conversations in the order of ids and reports the
+	// IDs that have no stored conversation
+	GetConversationsByIDs(ctx context.Context, ids []string) ([]*models.Conversation, []string, error)
+
+	// GetConversationsByUser retrieves all of a user's conversations
+	GetConversationsByUser(ctx context.Context, userID string) ([]*models.Conversation, error)
+
+	// DeleteConversation deletes a conversation and its messages
+	DeleteConversation(ctx context.Context, id string) error
+
+	// ListForgettableConversations returns a page of conversations older than before whose decayed importance is below threshold
+	ListForgettableConversations(ctx context.Context, threshold float64, halfLife time.Duration, before time.Time, limit int, offset int) ([]string, error)
+
+	// SetConversationPinned pins or unpins a conversation; it reports false if the conversation doesn't exist
+	SetConversationPinned(ctx context.Context, id string, pinned bool) (bool, error)
+
+	// GetPinnedConversations retrieves a user's pinned conversations
+	GetPinnedConversations(ctx context.Context, userID string) ([]*models.Conversation, error)
+
+	// SetConversationSuppression suppresses or restores (nil) a conversation; it reports false if it doesn't exist
+	SetConversationSuppression(ctx context.Context, id string, suppression *models.Suppression) (bool, error)
+
+	// GetSuppressedConversations retrieves a user's suppressed conversations
+	GetSuppressedConversations(ctx context.Context, userID string) ([]*models.Conversation, error)
+
+	// GetTopConversationsByUser retrieves a user's highest-scored, then most recent conversations
+	GetTopConversationsByUser(ctx context.Context, userID string, limit int) ([]*models.Conversation, error)
+
+	// ListConversationIDs pages through conversation IDs in ID order, optionally of one user
+	ListConversationIDs(ctx context.Context, userID string, afterID string, limit int) ([]string, error)
+
+	// SetConversationContentHash records the hash of the text last embedded for a conversation
+	SetConversationContentHash(ctx context.Context, id string, contentHash string) error
+
+	// SetConversationMetadata replaces a conversation's metadata JSON; it reports false if the
+	// conversation doesn't exist
+	SetConversationMetadata(ctx context.Context, id string, metadata string) (bool, error)
+
+	// SetConversationUnembedded marks a conversation the embedding provider refused, or clears the
+	// mark (nil); it reports false if the conversation doesn't exist
+	SetConversationUnembedded(ctx context.Context, id string, unembedded *models.Unembedded) (bool, error)
+
+	// ListUnembeddedConversations retrieves a page of conversations the embedding provider
+	// refused, optionally of one user, and their total count
+	ListUnembeddedConversations(ctx context.Context, userID string, limit int, offset int) ([]*models.Conversation, int, error)
+
+	// SetConversationStatus moves a conversation to status if its current status is one of from;
+	// it reports false if the conversation doesn't exist or is in another status
+	SetConversationStatus(ctx context.Context, id string, status string, from []string) (bool, error)
+
+	// ListUserConversations retrieves a page of a user's conversations, optionally in one status,
+	// and their total count
+	ListUserConversations(ctx context.Context, userID string, status string, limit int, offset int) ([]*models.Conversation, int, error)
+
+	// ListUserConversationsBefore retrieves up to limit of a user's conversations created before a
+	// time, newest first
+	ListUserConversationsBefore(ctx context.Context, userID string, before time.Time, limit int) ([]*models.Conversation, error)
+
+	// CountUserConversationsBetween counts a user's conversations created at or after from and
+	// before to
+	CountUserConversationsBetween(ctx context.Context, userID string, from time.Time, to time.Time) (int, error)
+
+	// GetUserStats retrieves a user's conversation aggregates, zero for a user without any
+	GetUserStats(ctx context.Context, userID string) (*models.UserStats, error)
+
+	// AddUserTokens adds embedding tokens spent on a user's conversations to the user's stats
+	AddUserTokens(ctx context.Context, userID string, tokens int64) error
+
+	// SaveConversationAlias makes an old conversation ID resolve to another conversation
+	SaveConversationAlias(ctx context.Context, alias *models.ConversationAlias) error
+
+	// GetConversationAlias retrieves the alias of an old conversation ID, or nil if it isn't one
+	GetConversationAlias(ctx context.Context, aliasID string) (*models.ConversationAlias, error)
 
 	// Close closes the database connection
 	Close() error
 }
 
+// ConversationQuery selects conversations by owner, metadata and creation time
+type ConversationQuery struct {
+	UserID        string       // Only this user's conversations; every user's when empty
+	Filter        *filter.Expr // Metadata filter, matched as in searches; nil matches everything
+	CreatedAfter  *time.Time   // Only conversations created at or after this time
+	CreatedBefore *time.Time   // Only conversations created before this time
+}
+
+// ConversationMatcher is implemented by conversation stores that evaluate a ConversationQuery in
+// the database instead of loading every conversation to test it
+type ConversationMatcher interface {
+	// MatchConversationIDs returns up to limit IDs of the conversations query selects greater
+	// than afterID in ID order; pass the last ID returned to get the next page
+	MatchConversationIDs(ctx context.Context, query ConversationQuery, afterID string, limit int) ([]string, error)
+}
+
+// ChunkStore is implemented by conversation stores that keep the chunks of conversations embedded
+// in chunks, so an edited conversation only has its changed chunks embedded again
+type ChunkStore interface {
+	// GetConversationChunks retrieves a conversation's chunks in position order
+	GetConversationChunks(ctx context.Context, conversationID string) ([]models.ConversationChunk, error)
+
+	// ReplaceConversationChunks replaces a stored conversation's chunks
+	ReplaceConversationChunks(ctx context.Context, conversationID string, chunks []models.ConversationChunk) error
+}
+
+// LegacyConversationStore finds and upgrades legacy conversations: those saved before messages
+// were stored individually, or without a user ID
+type LegacyConversationStore interface {
+	// CountLegacyConversations counts the legacy conversations
+	CountLegacyConversations(ctx context.Context) (int64, error)
+
+	// ListLegacyConversationsAfter retrieves a page of legacy conversations in ID order, starting
+	// after afterID
+	ListLegacyConversationsAfter(ctx context.Context, afterID string, limit int) ([]*models.Conversation, error)
+
+	// UpgradeLegacyConversation stores a legacy conversation's user ID, question, answer and
+	// messages
+	UpgradeLegacyConversation(ctx context.Context, conversation *models.Conversation) error
+}
+
+// SessionStore defines the interface for storing sessions
+type SessionStore interface {
+	// CreateSession inserts a session; it reports false if the ID is taken
+	CreateSession(ctx context.Context, session *models.Session) (bool, error)
+
+	// GetSession retrieves a session by ID
+	GetSession(ctx context.Context, id string) (*models.Session, error)
+
+	// ListSessions retrieves a page of sessions and the total count
+	ListSessions(ctx context.Context, userID string, status string, limit int, offset int) ([]*models.Session, int, error)
+
+	// CloseSession marks a session closed
+	CloseSession(ctx context.Context, id string, closedAt time.Time) error
+
+	// UpdateSessionSummary stores a session's summary
+	UpdateSessionSummary(ctx context.Context, id string, summary string, updatedAt time.Time) error
+
+	// GetSessionConversations retrieves a session's conversations in chronological order
+	GetSessionConversations(ctx context.Context, sessionID string) ([]*models.Conversation, error)
+}
+
+// ProfileStore defines the interface for caching synthesized user profiles
+type ProfileStore interface {
+	// GetUserProfile retrieves a user's cached profile
+	GetUserProfile(ctx context.Context, userID string) (*models.UserProfile, error)
+
+	// SaveUserProfile inserts or replaces a user's cached profile
+	SaveUserProfile(ctx context.Context, profile *models.UserProfile) error
+
+	// ListStaleProfiles returns users whose profile was generated before the given time
+	ListStaleProfiles(ctx context.Context, before time.Time, limit int) ([]string, error)
+}
+
+// JobStore defines the interface for the administrative job log
+type JobStore interface {
+	// CreateJob records a started job
+	CreateJob(ctx context.Context, job *models.Job) error
+
+	// FinishJob records a job's final status and result
+	FinishJob(ctx context.Context, job *models.Job) error
+
+	// UpdateJobProgress replaces the partial result of a running job
+	UpdateJobProgress(ctx context.Context, id string, result []byte) error
+
+	// GetJob retrieves a job by ID
+	GetJob(ctx context.Context, id string) (*models.Job, error)
+
+	// ListJobs retrieves the most recent jobs, optionally of one kind
+	ListJobs(ctx context.Context, kind string, limit int) ([]*models.Job, error)
+}
+
+// DeletionCertificateStore keeps the signed certificates issued after purges
+type DeletionCertificateStore interface {
+	// SaveDeletionCertificate stores a signed certificate
+	SaveDeletionCertificate(ctx context.Context, cert *models.DeletionCertificate) error
+
+	// GetDeletionCertificate retrieves a certificate by ID, or nil if it doesn't exist
+	GetDeletionCertificate(ctx context.Context, id string) (*models.DeletionCertificate, error)
+
+	// ListDeletionCertificates retrieves the most recent certificates, optionally of one kind and target
+	ListDeletionCertificates(ctx context.Context, kind string, target string, limit int) ([]*models.DeletionCertificate, error)
+}
+
+// QueryAdapterStore keeps the per-user adapters applied to query embeddings
+type QueryAdapterStore interface {
+	// GetQueryAdapter retrieves a user's adapter for a model, or nil if there is none
+	GetQueryAdapter(ctx context.Context, userID string, model string) (*models.QueryAdapter, error)
+
+	// ListQueryAdapters retrieves all of a user's adapters, ordered by model
+	ListQueryAdapters(ctx context.Context, userID string) ([]*models.QueryAdapter, error)
+
+	// SaveQueryAdapter inserts or replaces a user's adapter for its model
+	SaveQueryAdapter(ctx context.Context, adapter *models.QueryAdapter) error
+
+	// DeleteQueryAdapter deletes a user's adapter for a model; it reports false if there was none
+	DeleteQueryAdapter(ctx context.Context, userID string, model string) (bool, error)
+}
+
+// StandingQueryStore keeps the searches run against each newly saved conversation
+type StandingQueryStore interface {
+	// CreateStandingQuery inserts a standing query with its vector
+	CreateStandingQuery(ctx context.Context, query *models.StandingQuery) error
+
+	// GetStandingQuery retrieves a standing query by ID, or nil if there is none
+	GetStandingQuery(ctx context.Context, id string) (*models.StandingQuery, error)
+
+	// ListStandingQueries retrieves a user's standing queries with their vectors, oldest first
+	ListStandingQueries(ctx context.Context, userID string) ([]*models.StandingQuery, error)
+
+	// DeleteStandingQuery deletes a standing query; it reports false if there was none
+	DeleteStandingQuery(ctx context.Context, id string) (bool, error)
+
+	// UpdateStandingQueryVector replaces a standing query's vector
+	UpdateStandingQueryVector(ctx context.Context, id string, vector []float32) error
+
+	// RecordStandingQueryMatch counts a match of a standing query
+	RecordStandingQueryMatch(ctx context.Context, id string, matchedAt time.Time) error
+}
+
+// UserStore defines the interface for operations spanning all of a user's records
+type UserStore interface {
+	// CountUserData counts the records stored for a user
+	CountUserData(ctx context.Context, userID string) (*models.UserDataCounts, error)
+
+	// DeleteUserData deletes all records stored for a user and reports what was deleted
+	DeleteUserData(ctx context.Context, userID string) (*models.UserDataCounts, error)
+}
+
+// AccountStore keeps the registry of end users
+type AccountStore interface {
+	// CreateUser registers a user; it reports false if the user is already registered
+	CreateUser(ctx context.Context, user *models.User) (bool, error)
+
+	// GetUser retrieves a registered user, or nil if the user isn't registered
+	GetUser(ctx context.Context, id string) (*models.User, error)
+
+	// ListUsers retrieves a page of registered users, optionally of one status
+	ListUsers(ctx context.Context, status string, limit int, offset int) ([]*models.User, int, error)
+
+	// UpdateUser saves a user's display name and retention exemption; it reports false if the user isn't registered
+	UpdateUser(ctx context.Context, user *models.User) (bool, error)
+
+	// DisableUser disables a user, registering it first if needed
+	DisableUser(ctx context.Context, id string, reason string, at time.Time) (*models.User, error)
+
+	// EnableUser re-enables a registered user, or returns nil if the user isn't registered
+	EnableUser(ctx context.Context, id string, at time.Time) (*models.User, error)
+}
+
+// ContentCipher encrypts conversation content before it is stored and decrypts it when read
+type ContentCipher interface {
+	Encrypt(ctx context.Context, plaintext string) (string, error)
+	Decrypt(ctx context.Context, value string) (string, error)
+}
+
+// APIKeyStore keeps service account keys, identified to clients by their secret's hash
+type APIKeyStore interface {
+	// CreateAPIKey stores a new API key under the hash of its secret
+	CreateAPIKey(ctx context.Context, key *models.APIKey, secretHash string) error
+
+	// GetAPIKey retrieves an API key, or nil if it doesn't exist
+	GetAPIKey(ctx context.Context, id string) (*models.APIKey, error)
+
+	// ListAPIKeys retrieves API keys newest first, optionally including revoked keys
+	ListAPIKeys(ctx context.Context, includeRevoked bool) ([]*models.APIKey, error)
+
+	// LoadAPIKeys retrieves the keys usable at now, keyed by secret hash
+	LoadAPIKeys(ctx context.Context, now time.Time) (map[string]*models.APIKey, error)
+
+	// RotateAPIKey stores a successor for a key and makes the old key expire at oldExpiresAt; it
+	// returns nil if the key doesn't exist or is already revoked or rotated
+	RotateAPIKey(ctx context.Context, id string, replacement *models.APIKey, secretHash string, oldExpiresAt time.Time) (*models.APIKey, error)
+
+	// RevokeAPIKey revokes an API key, or returns nil if it doesn't exist
+	RevokeAPIKey(ctx context.Context, id string, at time.Time) (*models.APIKey, error)
+}
+
+// FusionBanditStore keeps the relevance feedback each tenant's fusion bandit arms collected
+type FusionBanditStore interface {
+	// AddFusionBanditFeedback counts one rated trial of a tenant's arm, rewarded if relevant
+	AddFusionBanditFeedback(ctx context.Context, tenant string, arm string, relevant bool) error
+
+	// GetFusionBanditArms retrieves the stats of a tenant's arms that collected feedback
+	GetFusionBanditArms(ctx context.Context, tenant string) ([]models.FusionBanditArmStats, error)
+
+	// GetFusionBanditTenant retrieves a tenant's bandit state; a tenant without one is returned
+	// unfrozen
+	GetFusionBanditTenant(ctx context.Context, tenant string) (*models.FusionBanditTenant, error)
+
+	// SetFusionBanditFrozenArm pins a tenant's searches to arm, or unpins them when arm is empty
+	SetFusionBanditFrozenArm(ctx context.Context, tenant string, arm string) error
+}
+
 // PostgresStoreInterface defines the interface for PostgreSQL operations
 type PostgresStoreInterface interface {
 	ConversationStore
@@ -29,6 +324,57 @@ type PostgresStoreInterface interface {
 	Ping(ctx context.Context) error
 }
 
+// DataKeyStore keeps the wrapped per-tenant data keys of envelope encryption
+type DataKeyStore interface {
+	// ListDataKeys retrieves the data keys of a tenant, or of all tenants when tenant is empty
+	ListDataKeys(ctx context.Context, tenant string) ([]*models.DataKey, error)
+
+	// CreateDataKey stores a new data key version; it reports false if the version exists
+	CreateDataKey(ctx context.Context, key *models.DataKey) (bool, error)
+
+	// RewrapDataKey replaces a data key's wrapping if it is still wrapped by previousMasterKeyID
+	RewrapDataKey(ctx context.Context, key *models.DataKey, previousMasterKeyID string) (bool, error)
+}
+
+// SignatureNonceStore keeps the nonces of signed requests for as long as their signatures are
+// accepted, so a request replayed against any replica is recognised
+type SignatureNonceStore interface {
+	// ClaimSignatureNonce records a client's nonce until expiresAt; it reports false if the nonce
+	// is already recorded and unexpired at now
+	ClaimSignatureNonce(ctx context.Context, clientID string, nonce string, now time.Time, expiresAt time.Time) (bool, error)
+
+	// DeleteExpiredSignatureNonces deletes the nonces that expired before a time
+	DeleteExpiredSignatureNonces(ctx context.Context, before time.Time) (int64, error)
+}
+
+// RelationalStore is the relational surface the server runs on: conversations and personal
+// information with their sessions, profiles, admin jobs, work queue, accounts, keys and logs.
+// PostgresStore implements it, and so does SQLiteStore for single-node installs without Postgres
+type RelationalStore interface {
+	PostgresStoreInterface
+	SessionStore
+	ProfileStore
+	JobStore
+	QueueStore
+	DeadLetterStore
+	AccountStore
+	UserStore
+	UsageStore
+	APIKeyStore
+	DataKeyStore
+	SignatureNonceStore
+	StandingQueryStore
+	SearchLogStore
+	SearchQueryStore
+	ActiveUserStore
+
+	// SetContentCipher encrypts content saved from now on
+	SetContentCipher(cipher ContentCipher)
+
+	// Close closes the database
+	Close() error
+}
+
 // QdrantStoreInterface defines the interface for Qdrant operations
 type QdrantStoreInterface interface {
 	VectorStore
@@ -36,30 +382,105 @@ type QdrantStoreInterface interface {
 	CollectionExists(ctx context.Context) (bool, error)
 }
 
+// SearchOptions controls a vector search
+type SearchOptions struct {
+	// Limit is the maximum number of results
+	Limit int
+
+	// Filter is a Qdrant filter object applied to the payload; nil matches everything
+	Filter map[string]interface{}
+
+	// UserID scopes the search to one user's points; required by user-isolated collections
+	UserID string
+
+	// HNSWEf overrides the collection's HNSW search beam size; 0 keeps Qdrant's default
+	HNSWEf int
+}
+
 // VectorStore defines the interface for storing and searching vectors
 type VectorStore interface {
 	// SaveVector saves an embedding vector with metadata
 	SaveVector(ctx context.Context, conversationID string, vector []float32, metadata map[string]interface{}) error
 
 	// SearchVectors searches for similar vectors
-	SearchVectors(ctx context.Context, queryVector []float32, limit int) ([]models.ConversationSearchResult, error)
+	SearchVectors(ctx context.Context, queryVector []float32, opts SearchOptions) ([]models.ConversationSearchResult, error)
+
+	// RecommendVectors searches for the vectors most similar to the stored vectors of the positive
+	// conversations and least similar to those of the negative ones, excluding the examples; an
+	// example without a vector leaves no results
+	RecommendVectors(ctx context.Context, positive []string, negative []string, opts SearchOptions) ([]models.ConversationSearchResult, error)
 
 	// DeleteVector deletes a vector by conversation ID
 	DeleteVector(ctx context.Context, conversationID string) error
 
+	// UpdatePayload merges set into the payload of a vector and removes the unset keys, without
+	// uploading the vector again
+	UpdatePayload(ctx context.Context, conversationID string, set map[string]interface{}, unset []string) error
+
+	// DeleteUserVectors deletes all vectors belonging to a user
+	DeleteUserVectors(ctx context.Context, userID string) error
+
+	// CountUserVectors counts the vectors belonging to a user
+	CountUserVectors(ctx context.Context, userID string) (int64, error)
+
+	// GetPayloads retrieves the payloads stored for the given IDs; IDs without a vector are absent
+	GetPayloads(ctx context.Context, conversationIDs []string) (map[string]map[string]interface{}, error)
+
+	// GetVectors retrieves the vectors stored for the given IDs; IDs without a vector are absent
+	GetVectors(ctx context.Context, conversationIDs []string) (map[string][]float32, error)
+
 	// Close closes the vector store connection
 	Close() error
 }
 
+// MultiVectorStore stores several vectors per record and ranks searches by late interaction,
+// summing each query vector's best match among a record's vectors
+type MultiVectorStore interface {
+	// SaveMultiVector saves the vectors of a record with metadata
+	SaveMultiVector(ctx context.Context, id string, vectors [][]float32, metadata map[string]interface{}) error
+
+	// SearchMultiVector searches for the records best matching a multivector query
+	SearchMultiVector(ctx context.Context, queryVectors [][]float32, opts SearchOptions) ([]models.ConversationSearchResult, error)
+
+	// UpdatePayload merges set into the payload of a record and removes the unset keys
+	UpdatePayload(ctx context.Context, id string, set map[string]interface{}, unset []string) error
+}
+
+// PointScroller pages through a vector collection's points across users. It ignores user
+// isolation, so only admin-only jobs may use it
+type PointScroller interface {
+	// ScrollPointsMatching pages through the points matching a Qdrant filter with their vectors
+	// and payloads, in an order unrelated to their content
+	ScrollPointsMatching(ctx context.Context, filter map[string]interface{}, offset *uint64, limit int) ([]models.VectorPoint, *uint64, error)
+}
+
+// IndexInspector reports a vector collection's index and optimizer state
+type IndexInspector interface {
+	// GetIndexInfo retrieves the collection's index and optimizer state
+	GetIndexInfo(ctx context.Context) (*IndexInfo, error)
+}
+
 // EmbeddingProvider defines the interface for text embedding services
 type EmbeddingProvider interface {
-	// Embed converts text to a vector
+	// Embed converts text to a vector as is
 	Embed(ctx context.Context, text string) ([]float32, error)
 
+	// EmbedQuery converts search text to a vector, applying the model's query instruction
+	EmbedQuery(ctx context.Context, text string) ([]float32, error)
+
+	// EmbedDocument converts stored content to a vector, applying the model's document instruction
+	EmbedDocument(ctx context.Context, text string) ([]float32, error)
+
 	// EmbedBatch converts multiple texts to vectors
 	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
 }
 
+// CompletionProvider defines the interface for LLM text generation
+type CompletionProvider interface {
+	// Complete generates a response to the prompt under the given system instructions
+	Complete(ctx context.Context, systemPrompt string, prompt string) (string, error)
+}
+
 // PersonalInfoStore defines the interface for storing personal information
 type PersonalInfoStore interface {
 	// SavePersonalInfo saves personal information to the database
@@ -71,12 +492,140 @@ type PersonalInfoStore interface {
 	// GetPersonalInfoByUser retrieves all personal information for a user
 	GetPersonalInfoByUser(ctx context.Context, userID string) ([]*models.PersonalInfo, error)
 
-	// UpdatePersonalInfo updates existing personal information
+	// GetPersonalInfoByIDs retrieves entries in the order of ids, skipping those that don't exist
+	GetPersonalInfoByIDs(ctx context.Context, ids []string) ([]*models.PersonalInfo, error)
+
+	// UpdatePersonalInfo updates existing personal information, returning ErrPersonalInfoNotFound
+	// if the entry doesn't exist
 	UpdatePersonalInfo(ctx context.Context, personalInfo *models.PersonalInfo) error
 
-	// DeletePersonalInfo deletes personal information by ID
+	// SetPersonalInfoPinned pins or unpins an entry; it reports false if the entry doesn't exist
+	SetPersonalInfoPinned(ctx context.Context, id string, pinned bool) (bool, error)
+
+	// GetPinnedPersonalInfo retrieves a user's pinned personal information
+	GetPinnedPersonalInfo(ctx context.Context, userID string) ([]*models.PersonalInfo, error)
+
+	// SetPersonalInfoSuppression suppresses or restores (nil) an entry; it reports false if it doesn't exist
+	SetPersonalInfoSuppression(ctx context.Context, id string, suppression *models.Suppression) (bool, error)
+
+	// GetSuppressedPersonalInfo retrieves a user's suppressed personal information
+	GetSuppressedPersonalInfo(ctx context.Context, userID string) ([]*models.PersonalInfo, error)
+
+	// DeletePersonalInfo deletes personal information by ID, returning ErrPersonalInfoNotFound if
+	// the entry doesn't exist
 	DeletePersonalInfo(ctx context.Context, id string) error
 
+	// ListPersonalInfoAfter retrieves up to limit entries of all users with IDs after afterID, in ID order
+	ListPersonalInfoAfter(ctx context.Context, afterID string, limit int) ([]*models.PersonalInfo, error)
+
+	// CountPersonalInfo counts the entries of all users
+	CountPersonalInfo(ctx context.Context) (int64, error)
+
+	// UpdatePersonalInfoIfUnchanged updates an entry only if its updated_at still equals readAt,
+	// returning ErrPersonalInfoChanged otherwise
+	UpdatePersonalInfoIfUnchanged(ctx context.Context, personalInfo *models.PersonalInfo, readAt time.Time) error
+
+	// DeletePersonalInfoIfUnchanged deletes an entry only if its updated_at still equals readAt,
+	// returning ErrPersonalInfoChanged otherwise
+	DeletePersonalInfoIfUnchanged(ctx context.Context, id string, readAt time.Time) error
+
 	// Close closes the store
 	Close() error
 }
+
+// TableMaintenanceStore reports table sizes and reclaims dead rows
+type TableMaintenanceStore interface {
+	// TableStats reports the on-disk size, estimated rows and index sizes of each table
+	TableStats(ctx context.Context, tables []string) ([]models.TableStats, error)
+
+	// VacuumAnalyze vacuums a table and refreshes its planner statistics
+	VacuumAnalyze(ctx context.Context, table string) error
+}
+
+// QueueStore is the work queue shared by all replicas
+type QueueStore interface {
+	// Enqueue adds an item to the work queue, available immediately
+	Enqueue(ctx context.Context, kind string, payload interface{}) error
+
+	// ClaimQueueItems leases up to limit due items of the given kinds to owner
+	ClaimQueueItems(ctx context.Context, owner string, kinds []string, limit int, lease time.Duration) ([]*models.QueueItem, error)
+
+	// ExtendQueueLease extends an item's lease; it reports false if owner no longer holds it
+	ExtendQueueLease(ctx context.Context, id int64, owner string, lease time.Duration) (bool, error)
+
+	// CompleteQueueItem removes a processed item held by owner
+	CompleteQueueItem(ctx context.Context, id int64, owner string) error
+
+	// RetryQueueItem releases a failed item held by owner, making it available again at retryAt
+	RetryQueueItem(ctx context.Context, id int64, owner string, lastError string, retryAt time.Time) error
+
+	// DeleteQueueItem removes an item regardless of its lease
+	DeleteQueueItem(ctx context.Context, id int64) error
+
+	// DeadLetterQueueItem moves a failed item held by owner to the dead letter table
+	DeadLetterQueueItem(ctx context.Context, id int64, owner string, lastError string) error
+
+	// QueueStats reports the backlog of each queue item kind
+	QueueStats(ctx context.Context) ([]models.QueueKindStats, error)
+}
+
+// DeadLetterStore keeps queue items that exhausted their attempts
+type DeadLetterStore interface {
+	// ListDeadLetters retrieves a page of dead letters, newest first; an empty kind lists all kinds
+	ListDeadLetters(ctx context.Context, kind string, limit int, offset int) ([]*models.DeadLetter, int, error)
+
+	// GetDeadLetter retrieves a dead letter by ID, or nil if it doesn't exist
+	GetDeadLetter(ctx context.Context, id int64) (*models.DeadLetter, error)
+
+	// RequeueDeadLetter moves a dead letter back to the work queue; it reports false if it doesn't exist
+	RequeueDeadLetter(ctx context.Context, id int64) (bool, error)
+
+	// DeleteDeadLetter discards a dead letter; it reports false if it doesn't exist
+	DeleteDeadLetter(ctx context.Context, id int64) (bool, error)
+
+	// CountDeadLetters counts the dead letters of each kind
+	CountDeadLetters(ctx context.Context) (map[string]int64, error)
+}
+
+// UsageStore keeps daily embedding token totals
+type UsageStore interface {
+	// AddUsage adds usage records to the stored daily totals
+	AddUsage(ctx context.Context, records []models.UsageRecord) error
+
+	// ListUsage retrieves the daily totals between two dates inclusive (YYYY-MM-DD), optionally of one tenant
+	ListUsage(ctx context.Context, from string, to string, tenant string) ([]models.UsageRecord, error)
+}
+
+// SearchLogStore records conversation searches
+type SearchLogStore interface {
+	// LogSearch stores a search log entry
+	LogSearch(ctx context.Context, entry *models.SearchLog) error
+}
+
+// SearchQueryStore reads back logged search queries
+type SearchQueryStore interface {
+	// ListSearchQueries retrieves up to limit queries of a user's searches in a tenant that found
+	// results, logged at or after since, newest first
+	ListSearchQueries(ctx context.Context, tenant string, userID string, since time.Time, limit int) ([]string, error)
+}
+
+// ActiveUserStore finds the users who recently saved conversations
+type ActiveUserStore interface {
+	// ListActiveUsers retrieves up to limit users, in ID order after afterUserID, whose last
+	// conversation was created at or after since
+	ListActiveUsers(ctx context.Context, since time.Time, afterUserID string, limit int) ([]string, error)
+}
+
+// AnalyticsSink receives the rows of an analytics export
+type AnalyticsSink interface {
+	Conversation(conversation *models.Conversation) error
+	Message(message *models.AnalyticsMessage) error
+	Search(entry *models.SearchLog) error
+}
+
+// AnalyticsStore reads usage data for analytics exports
+type AnalyticsStore interface {
+	// ExportAnalytics streams the conversations, messages and search logs created in [from, to)
+	// to sink, all read from one snapshot
+	ExportAnalytics(ctx context.Context, from time.Time, to time.Time, sink AnalyticsSink) error
+}
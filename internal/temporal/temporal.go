@@ -0,0 +1,173 @@
+// Package temporal finds calendar expressions such as "last Tuesday" or "지난주" in search
+// queries and turns them into date ranges. Embeddings match what was said, not when, so a query
+// like "what did we discuss yesterday" finds yesterday's conversations only when the time
+// reference becomes a filter
+package temporal
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Range is a half-open time interval [Start, End)
+type Range struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Match is a temporal expression found in a query
+type Match struct {
+	// Expression is the text of the query that names the range
+	Expression string
+
+	Range Range
+}
+
+// rule recognizes one kind of expression; resolve receives the submatches and the start of today
+type rule struct {
+	pattern *regexp.Regexp
+	resolve func(groups []string, today time.Time) (Range, bool)
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+	"일": time.Sunday, "월": time.Monday, "화": time.Tuesday, "수": time.Wednesday,
+	"목": time.Thursday, "금": time.Friday, "토": time.Saturday,
+}
+
+// rules are tried in order, so longer expressions come before the ones they contain
+var rules = []rule{
+	{regexp.MustCompile(`(?i)\b(?:the )?day before yesterday\b|그저께|그제`), func(_ []string, today time.Time) (Range, bool) {
+		return days(today.AddDate(0, 0, -2), 1), true
+	}},
+	{regexp.MustCompile(`(?i)\byesterday\b|어제`), func(_ []string, today time.Time) (Range, bool) {
+		return days(today.AddDate(0, 0, -1), 1), true
+	}},
+	{regexp.MustCompile(`(?i)\btoday\b|오늘`), func(_ []string, today time.Time) (Range, bool) {
+		return days(today, 1), true
+	}},
+	{regexp.MustCompile(`(?:지난|저번)\s?주\s?([월화수목금토일])요일`), func(groups []string, today time.Time) (Range, bool) {
+		return days(weekStart(today).AddDate(0, 0, -7+mondayOffset(weekdays[groups[1]])), 1), true
+	}},
+	{regexp.MustCompile(`(?i)\blast (monday|tuesday|wednesday|thursday|friday|saturday|sunday)\b|(?:지난|저번)\s?([월화수목금토일])요일`), func(groups []string, today time.Time) (Range, bool) {
+		name := strings.ToLower(groups[1])
+		if name == "" {
+			name = groups[2]
+		}
+		back := (int(today.Weekday()) - int(weekdays[name]) + 7) % 7
+		if back == 0 {
+			back = 7
+		}
+		return days(today.AddDate(0, 0, -back), 1), true
+	}},
+	{regexp.MustCompile(`(?i)\b(\d{1,3}) days? ago\b|(\d{1,3})\s?일\s?전`), func(groups []string, today time.Time) (Range, bool) {
+		n, ok := number(groups[1], groups[2])
+		return days(today.AddDate(0, 0, -n), 1), ok
+	}},
+	{regexp.MustCompile(`(?i)\b(\d{1,3}) weeks? ago\b|(\d{1,2})\s?주\s?전`), func(groups []string, today time.Time) (Range, bool) {
+		n, ok := number(groups[1], groups[2])
+		return days(weekStart(today).AddDate(0, 0, -7*n), 7), ok
+	}},
+	{regexp.MustCompile(`(?i)\b(?:in the |over the )?(?:past|last) (\d{1,3}) days\b|최근\s?(\d{1,3})\s?일`), func(groups []string, today time.Time) (Range, bool) {
+		n, ok := number(groups[1], groups[2])
+		return days(today.AddDate(0, 0, 1-n), n), ok && n > 0
+	}},
+	{regexp.MustCompile(`(?i)\bthis week\b|이번\s?주`), func(_ []string, today time.Time) (Range, bool) {
+		return days(weekStart(today), 7), true
+	}},
+	{regexp.MustCompile(`(?i)\blast week\b|(?:지난|저번)\s?주`), func(_ []string, today time.Time) (Range, bool) {
+		return days(weekStart(today).AddDate(0, 0, -7), 7), true
+	}},
+	{regexp.MustCompile(`(?i)\bthis month\b|이번\s?달`), func(_ []string, today time.Time) (Range, bool) {
+		return months(monthStart(today), 1), true
+	}},
+	{regexp.MustCompile(`(?i)\blast month\b|(?:지난|저번)\s?달`), func(_ []string, today time.Time) (Range, bool) {
+		return months(monthStart(today).AddDate(0, -1, 0), 1), true
+	}},
+	{regexp.MustCompile(`(?i)\bthis year\b|올해`), func(_ []string, today time.Time) (Range, bool) {
+		return months(yearStart(today), 12), true
+	}},
+	{regexp.MustCompile(`(?i)\blast year\b|작년|지난\s?해`), func(_ []string, today time.Time) (Range, bool) {
+		return months(yearStart(today).AddDate(-1, 0, 0), 12), true
+	}},
+}
+
+// Find returns the first temporal expression of query, resolved against now in its location
+func Find(query string, now time.Time) (Match, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, r := range rules {
+		groups := r.pattern.FindStringSubmatch(query)
+		if groups == nil {
+			continue
+		}
+		if rng, ok := r.resolve(groups, today); ok {
+			return Match{Expression: withSuffix(query, groups[0]), Range: rng}, true
+		}
+	}
+	return Match{}, false
+}
+
+// suffixes attach to a temporal expression without changing it, like "yesterday's" or the Korean
+// particles of "지난주에"
+var suffixes = []string{"'s", "에는", "에서", "부터", "까지", "에", "의"}
+
+// withSuffix extends an expression found in query by a suffix directly following it
+func withSuffix(query string, expression string) string {
+	rest := query[strings.Index(query, expression)+len(expression):]
+	for _, suffix := range suffixes {
+		if strings.HasPrefix(rest, suffix) {
+			return expression + suffix
+		}
+	}
+	return expression
+}
+
+// Strip removes a matched expression from query, leaving the query unchanged if nothing else is left
+func Strip(query string, match Match) string {
+	stripped := strings.Join(strings.Fields(strings.Replace(query, match.Expression, " ", 1)), " ")
+	if stripped == "" {
+		return query
+	}
+	return stripped
+}
+
+// days returns the n days starting at start
+func days(start time.Time, n int) Range {
+	return Range{Start: start, End: start.AddDate(0, 0, n)}
+}
+
+// months returns the n months starting at start
+func months(start time.Time, n int) Range {
+	return Range{Start: start, End: start.AddDate(0, n, 0)}
+}
+
+// weekStart returns the Monday of today's week
+func weekStart(today time.Time) time.Time {
+	return today.AddDate(0, 0, -mondayOffset(today.Weekday()))
+}
+
+// mondayOffset returns the days from Monday to a weekday, counting Sunday as the last day
+func mondayOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+func monthStart(today time.Time) time.Time {
+	return time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+}
+
+func yearStart(today time.Time) time.Time {
+	return time.Date(today.Year(), time.January, 1, 0, 0, 0, 0, today.Location())
+}
+
+// number parses whichever of an English or Korean submatch is set
+func number(english string, korean string) (int, bool) {
+	text := english
+	if text == "" {
+		text = korean
+	}
+	n, err := strconv.Atoi(text)
+	return n, err == nil
+}
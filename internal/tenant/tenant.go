@@ -0,0 +1,27 @@
+package tenant
+
+import "context"
+
+// DefaultTenant is used when a request does not identify its tenant
+const DefaultTenant = "default"
+
+// Header is the request header carrying the tenant identifier
+const Header = "X-Tenant-ID"
+
+type contextKey struct{}
+
+// WithTenant returns a context carrying the tenant ID
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	if tenantID == "" {
+		tenantID = DefaultTenant
+	}
+	return context.WithValue(ctx, contextKey{}, tenantID)
+}
+
+// FromContext returns the tenant ID carried by the context, or DefaultTenant
+func FromContext(ctx context.Context) string {
+	if tenantID, ok := ctx.Value(contextKey{}).(string); ok && tenantID != "" {
+		return tenantID
+	}
+	return DefaultTenant
+}
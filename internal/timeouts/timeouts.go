@@ -0,0 +1,108 @@
+// Package timeouts bounds how long a single call to a dependency may take, so a stalled
+// embedding API, Qdrant node or database query fails the operation instead of holding it open.
+// The bound only shortens the caller's context: a call also ends when the request that made it
+// is cancelled, so a client that gives up frees the server's connections and provider calls.
+// Under a caller's propagated deadline a call may also take only its dependency's share of the
+// time left, leaving the rest to the stages after it
+package timeouts
+
+import (
+	"context"
+	"time"
+
+	"refo-rag-server/internal/deadline"
+	"refo-rag-server/internal/hotstate"
+)
+
+// Dependencies whose calls are bounded
+const (
+	Postgres   = "postgres"
+	SQLite     = "sqlite" // Embedded relational store; shares the Postgres limit
+	MySQL      = "mysql"  // Alternative relational store; shares the Postgres limit
+	Qdrant     = "qdrant"
+	Embedding  = "embedding"
+	Completion = "completion"
+)
+
+// Limits sets the longest a call to each dependency may take; zero means no limit
+type Limits struct {
+	Postgres   time.Duration
+	Qdrant     time.Duration
+	Embedding  time.Duration
+	Completion time.Duration
+}
+
+// Shares sets the largest share of the time left until a caller's propagated deadline a call to
+// each dependency may take; zero lets it take all the time left
+type Shares struct {
+	Postgres   float64
+	Qdrant     float64
+	Embedding  float64
+	Completion float64
+}
+
+var (
+	limits hotstate.Value[Limits]
+	shares hotstate.Value[Shares]
+)
+
+// Configure sets the limits for all dependencies
+func Configure(l Limits) {
+	limits.Store(l)
+}
+
+// ConfigureShares sets the deadline shares for all dependencies
+func ConfigureShares(s Shares) {
+	shares.Store(s)
+}
+
+// limit returns the configured limit for a dependency
+func limit(dependency string) time.Duration {
+	limits := limits.Load()
+	switch dependency {
+	case Postgres, SQLite, MySQL:
+		return limits.Postgres
+	case Qdrant:
+		return limits.Qdrant
+	case Embedding:
+		return limits.Embedding
+	case Completion:
+		return limits.Completion
+	}
+	return 0
+}
+
+// share returns the configured deadline share for a dependency
+func share(dependency string) float64 {
+	shares := shares.Load()
+	switch dependency {
+	case Postgres, SQLite, MySQL:
+		return shares.Postgres
+	case Qdrant:
+		return shares.Qdrant
+	case Embedding:
+		return shares.Embedding
+	case Completion:
+		return shares.Completion
+	}
+	return 0
+}
+
+// With returns a context that expires after the dependency's limit or, under a caller's
+// propagated deadline, its share of the time left if that is sooner; an earlier deadline already
+// on ctx is kept. It is meant to wrap one call:
+//
+//	ctx, cancel := timeouts.With(ctx, timeouts.Postgres)
+//	defer cancel()
+func With(ctx context.Context, dependency string) (context.Context, context.CancelFunc) {
+	d := limit(dependency)
+	if remaining, ok := deadline.Remaining(ctx); ok && share(dependency) > 0 {
+		if budget := time.Duration(float64(remaining) * share(dependency)); d <= 0 || budget < d {
+			d = max(budget, time.Millisecond)
+		}
+	}
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
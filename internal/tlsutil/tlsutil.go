@@ -0,0 +1,66 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// ClientOptions describes the certificate material for an outbound TLS connection
+type ClientOptions struct {
+	// CAFile is a PEM bundle of trusted roots; the system pool is used when empty
+	CAFile string
+
+	// CertFile and KeyFile enable mutual TLS when both are set
+	CertFile string
+	KeyFile  string
+
+	// ServerName overrides the hostname used for certificate verification
+	ServerName string
+}
+
+// ClientConfig builds a tls.Config from the given options
+func ClientConfig(opts ClientOptions) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: opts.ServerName,
+	}
+
+	if opts.CAFile != "" {
+		pool, err := LoadCertPool(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return nil, fmt.Errorf("client certificate and key must be configured together")
+	}
+
+	if opts.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// LoadCertPool reads a PEM bundle into a new certificate pool
+func LoadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle %s: %w", caFile, err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", caFile)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,128 @@
+// Package tracing exports OpenTelemetry traces of requests and their outbound calls. A request
+// carrying W3C traceparent and tracestate headers joins the caller's trace, so a client's trace
+// shows the embedding and Qdrant calls made on its behalf; the caller's sampling decision is kept
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation names the tracer of this server's spans
+const instrumentation = "refo-rag-server"
+
+// Options configures trace export
+type Options struct {
+	// Endpoint is the OTLP/HTTP traces URL, e.g. http://otel-collector:4318/v1/traces
+	Endpoint string
+
+	// ServiceName identifies this server in the trace backend
+	ServiceName string
+
+	// Environment and Release are attached to every span when set
+	Environment string
+	Release     string
+
+	// SampleRate is the fraction of traces started here that are recorded. Requests joining a
+	// caller's trace follow the caller's decision instead
+	SampleRate float64
+}
+
+// Setup installs the exporting tracer provider and the W3C trace context propagator. The returned
+// function flushes buffered spans and stops the exporter
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(opts.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	attrs := []attribute.KeyValue{attribute.String("service.name", opts.ServiceName)}
+	if opts.Environment != "" {
+		attrs = append(attrs, attribute.String("deployment.environment.name", opts.Environment))
+	}
+	if opts.Release != "" {
+		attrs = append(attrs, attribute.String("service.version", opts.Release))
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attrs...)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRate))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer of this server's spans; it records nothing until Setup is called
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// Extract returns ctx joined to the trace in the headers of an incoming request, if any
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// TraceID returns the ID of the trace ctx belongs to, or an empty string
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
+
+// Transport wraps an outbound round tripper so each request is a client span of the request's
+// trace, named after dependency, and carries the trace context to the dependency
+func Transport(dependency string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{dependency: dependency, base: base}
+}
+
+// transport records outbound requests as client spans
+type transport struct {
+	dependency string
+	base       http.RoundTripper
+}
+
+// RoundTrip sends the request inside a client span
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := Tracer().Start(req.Context(), t.dependency+" "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Hostname()),
+			attribute.String("url.path", req.URL.Path),
+		),
+	)
+	defer span.End()
+
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,151 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/models"
+)
+
+// Store persists aggregated usage
+type Store interface {
+	// AddUsage adds usage records to the stored daily totals
+	AddUsage(ctx context.Context, records []models.UsageRecord) error
+
+	// ListUsage retrieves the daily totals between two dates inclusive, optionally of one tenant
+	ListUsage(ctx context.Context, from string, to string, tenant string) ([]models.UsageRecord, error)
+}
+
+// readOnlyStore reads the stored usage but drops the usage added to it
+type readOnlyStore struct {
+	Store
+}
+
+// AddUsage drops the records
+func (readOnlyStore) AddUsage(ctx context.Context, records []models.UsageRecord) error {
+	return nil
+}
+
+// ReadOnly wraps store for a standby whose database is a read-only replica: the budget is still
+// enforced against the primary's stored spend, while the standby's own usage isn't recorded
+func ReadOnly(store Store) Store {
+	return readOnlyStore{store}
+}
+
+// Aggregator buffers usage per day, tenant and model and periodically adds it to the store, so
+// embedding calls don't each write to the database. It also enforces the embedding budget against
+// the stored spend, refreshed after every flush, plus the usage not yet flushed
+type Aggregator struct {
+	store  Store
+	budget Budget
+
+	mu      sync.Mutex
+	pending map[usageKey]*models.UsageRecord
+	stored  spend
+
+	// Quota warnings, sent from Run only: the highest threshold warned of per budget period
+	notifier     QuotaNotifier
+	shouldNotify func() bool
+	warned       map[string]float64
+}
+
+type usageKey struct {
+	day    string
+	tenant string
+	model  string
+}
+
+// NewAggregator creates an aggregator writing to store and enforcing budget
+func NewAggregator(store Store, budget Budget) *Aggregator {
+	return &Aggregator{
+		store:   store,
+		budget:  budget,
+		pending: make(map[usageKey]*models.UsageRecord),
+		warned:  make(map[string]float64),
+	}
+}
+
+// add buffers one embedding call's usage under today's UTC date
+func (a *Aggregator) add(tenantID string, model string, u models.EmbeddingUsage) {
+	key := usageKey{day: time.Now().UTC().Format(time.DateOnly), tenant: tenantID, model: model}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	record, ok := a.pending[key]
+	if !ok {
+		record = &models.UsageRecord{Day: key.day, Tenant: tenantID, Model: model}
+		a.pending[key] = record
+	}
+	record.Requests++
+	record.PromptTokens += int64(u.PromptTokens)
+	record.TotalTokens += int64(u.TotalTokens)
+}
+
+// Flush writes the buffered usage to the store; on failure it is kept for the next flush
+func (a *Aggregator) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	pending := a.pending
+	a.pending = make(map[usageKey]*models.UsageRecord)
+	a.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	records := make([]models.UsageRecord, 0, len(pending))
+	for _, record := range pending {
+		records = append(records, *record)
+	}
+	if err := a.store.AddUsage(ctx, records); err != nil {
+		a.mu.Lock()
+		for key, record := range pending {
+			if current, ok := a.pending[key]; ok {
+				record.Requests += current.Requests
+				record.PromptTokens += current.PromptTokens
+				record.TotalTokens += current.TotalTokens
+			}
+			a.pending[key] = record
+		}
+		a.mu.Unlock()
+		return fmt.Errorf("failed to store usage: %w", err)
+	}
+	return nil
+}
+
+// Run flushes the buffered usage every interval until ctx is cancelled; call Flush after the
+// server stops to write what was recorded since the last tick
+func (a *Aggregator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	a.refreshBudget(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.Flush(ctx); err != nil {
+				fmt.Printf("warning: %v\n", err)
+				errreport.Background(ctx, "usage_flush", err)
+			}
+			a.refreshBudget(ctx)
+		}
+	}
+}
+
+// refreshBudget reloads the stored spend when a budget is set; on failure the last spend is kept
+func (a *Aggregator) refreshBudget(ctx context.Context) {
+	if !a.budget.Enabled() {
+		return
+	}
+	if err := a.refresh(ctx); err != nil {
+		fmt.Printf("warning: %v\n", err)
+		errreport.Background(ctx, "usage_budget_refresh", err)
+		return
+	}
+	a.warnQuota(ctx)
+}
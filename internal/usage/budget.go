@@ -0,0 +1,141 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrBudgetExceeded is returned for embedding calls made once the day's or month's budget is spent
+var ErrBudgetExceeded = errors.New("embedding budget exceeded")
+
+// Budget periods
+const (
+	PeriodDay   = "day"
+	PeriodMonth = "month"
+)
+
+// Budget caps the embedding tokens billed per UTC day and month across all tenants. Caps may be
+// given in tokens, in dollars at a price per million tokens, or both, in which case the lower
+// applies; zero disables a cap
+type Budget struct {
+	DailyTokens   int64
+	MonthlyTokens int64
+
+	DailyUSD   float64
+	MonthlyUSD float64
+
+	// PricePerMillionTokens converts the dollar caps to tokens
+	PricePerMillionTokens float64
+
+	// Warnings are the shares of a cap, in ascending order, at which spend is reported as nearly
+	// exhausted before the cap refuses embedding calls
+	Warnings []float64
+}
+
+// Enabled reports whether any cap is set
+func (b Budget) Enabled() bool {
+	return b.dailyLimit() > 0 || b.monthlyLimit() > 0
+}
+
+func (b Budget) dailyLimit() int64 {
+	return b.limit(b.DailyTokens, b.DailyUSD)
+}
+
+func (b Budget) monthlyLimit() int64 {
+	return b.limit(b.MonthlyTokens, b.MonthlyUSD)
+}
+
+// limit returns the lower of a token cap and a dollar cap converted to tokens, ignoring unset caps
+func (b Budget) limit(tokens int64, usd float64) int64 {
+	if usd <= 0 || b.PricePerMillionTokens <= 0 {
+		return tokens
+	}
+	fromUSD := int64(usd / b.PricePerMillionTokens * 1e6)
+	if tokens <= 0 || fromUSD < tokens {
+		return fromUSD
+	}
+	return tokens
+}
+
+// BudgetError reports which budget was spent and when it resets
+type BudgetError struct {
+	Period   string
+	Limit    int64
+	Used     int64
+	ResetsAt time.Time
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%v: %d of %d tokens used this %s, resets at %s", ErrBudgetExceeded, e.Used, e.Limit, e.Period, e.ResetsAt.Format(time.RFC3339))
+}
+
+func (e *BudgetError) Unwrap() error {
+	return ErrBudgetExceeded
+}
+
+// spend is the tokens used in one UTC day and in its month
+type spend struct {
+	day         string // YYYY-MM-DD
+	dayTokens   int64
+	monthTokens int64
+}
+
+// checkBudget returns a BudgetError if the stored spend plus the unflushed usage reaches a cap;
+// the caller holds a.mu
+func (a *Aggregator) checkBudget(now time.Time) error {
+	for _, status := range a.quotas(now) {
+		if status.Used >= status.Limit {
+			return &BudgetError{Period: status.Period, Limit: status.Limit, Used: status.Used, ResetsAt: status.ResetsAt}
+		}
+	}
+	return nil
+}
+
+// spent returns the stored spend plus the unflushed usage of now's day and month; the caller
+// holds a.mu
+func (a *Aggregator) spent(now time.Time) spend {
+	day := now.Format(time.DateOnly)
+	month := day[:7]
+
+	used := spend{day: day}
+	if a.stored.day == day {
+		used.dayTokens = a.stored.dayTokens
+	}
+	if strings.HasPrefix(a.stored.day, month) {
+		used.monthTokens = a.stored.monthTokens
+	}
+	for key, record := range a.pending {
+		if key.day == day {
+			used.dayTokens += record.TotalTokens
+		}
+		if strings.HasPrefix(key.day, month) {
+			used.monthTokens += record.TotalTokens
+		}
+	}
+	return used
+}
+
+// refresh loads this month's stored spend of every replica
+func (a *Aggregator) refresh(ctx context.Context) error {
+	day := time.Now().UTC().Format(time.DateOnly)
+	records, err := a.store.ListUsage(ctx, day[:8]+"01", day, "")
+	if err != nil {
+		return fmt.Errorf("failed to load usage for the budget: %w", err)
+	}
+
+	stored := spend{day: day}
+	for _, record := range records {
+		stored.monthTokens += record.TotalTokens
+		if record.Day == day {
+			stored.dayTokens += record.TotalTokens
+		}
+	}
+
+	a.mu.Lock()
+	a.stored = stored
+	a.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,207 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"refo-rag-server/internal/errreport"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+)
+
+// QuotaStatus is the spend of one budget period against its cap
+type QuotaStatus struct {
+	Period    string
+	Limit     int64
+	Used      int64
+	Remaining int64
+	ResetsAt  time.Time
+
+	// Warning is the highest warning threshold the spend has reached, 0 if none
+	Warning float64
+}
+
+// quotas returns the status of each capped budget period, the day's first; the caller holds a.mu
+func (a *Aggregator) quotas(now time.Time) []QuotaStatus {
+	used := a.spent(now)
+	var statuses []QuotaStatus
+	if limit := a.budget.dailyLimit(); limit > 0 {
+		midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		statuses = append(statuses, a.budget.status(PeriodDay, limit, used.dayTokens, midnight.AddDate(0, 0, 1)))
+	}
+	if limit := a.budget.monthlyLimit(); limit > 0 {
+		firstOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		statuses = append(statuses, a.budget.status(PeriodMonth, limit, used.monthTokens, firstOfMonth.AddDate(0, 1, 0)))
+	}
+	return statuses
+}
+
+// status describes the spend of a period against its cap
+func (b Budget) status(period string, limit int64, used int64, resetsAt time.Time) QuotaStatus {
+	status := QuotaStatus{Period: period, Limit: limit, Used: used, Remaining: max(limit-used, 0), ResetsAt: resetsAt}
+	for _, threshold := range b.Warnings {
+		if float64(used) >= threshold*float64(limit) {
+			status.Warning = threshold
+		}
+	}
+	return status
+}
+
+// Quota returns the status of the budget period closest to its cap, or false when no budget is
+// set; responses report it so callers see a cap coming before it refuses their calls
+func Quota() (QuotaStatus, bool) {
+	a := aggregator.Load()
+	if a == nil || !a.budget.Enabled() {
+		return QuotaStatus{}, false
+	}
+
+	a.mu.Lock()
+	statuses := a.quotas(time.Now().UTC())
+	a.mu.Unlock()
+
+	tightest := statuses[0]
+	for _, status := range statuses[1:] {
+		if status.Remaining < tightest.Remaining {
+			tightest = status
+		}
+	}
+	return tightest, true
+}
+
+// QuotaNotifier is told when a budget period's spend first reaches a warning threshold
+type QuotaNotifier interface {
+	NotifyQuota(ctx context.Context, warning models.QuotaWarning) error
+}
+
+// SetQuotaNotifier sends quota warnings to notifier while shouldNotify reports true, typically on
+// the leader replica only, so replicas sharing the spend don't each send them. Warnings sent are
+// remembered in memory; a new leader may repeat the current period's. Call it before Run
+func (a *Aggregator) SetQuotaNotifier(notifier QuotaNotifier, shouldNotify func() bool) {
+	a.notifier = notifier
+	a.shouldNotify = shouldNotify
+}
+
+// warnQuota notifies each budget period whose spend reached a warning threshold it wasn't yet
+// notified of
+func (a *Aggregator) warnQuota(ctx context.Context) {
+	if a.notifier == nil || (a.shouldNotify != nil && !a.shouldNotify()) {
+		return
+	}
+
+	now := time.Now().UTC()
+	a.mu.Lock()
+	statuses := a.quotas(now)
+	a.mu.Unlock()
+
+	// Each day and month is warned afresh; forget the periods that ended
+	current := make(map[string]bool, len(statuses))
+	for _, status := range statuses {
+		current[quotaKey(status)] = true
+	}
+	for key := range a.warned {
+		if !current[key] {
+			delete(a.warned, key)
+		}
+	}
+
+	for _, status := range statuses {
+		key := quotaKey(status)
+		if status.Warning == 0 || status.Warning <= a.warned[key] {
+			continue
+		}
+
+		warning := models.QuotaWarning{
+			Event:     models.QuotaWarningEvent,
+			Period:    status.Period,
+			Threshold: status.Warning,
+			Limit:     status.Limit,
+			Used:      status.Used,
+			Remaining: status.Remaining,
+			ResetsAt:  status.ResetsAt.Format(time.RFC3339),
+			SentAt:    now.Format(time.RFC3339),
+		}
+		if err := a.notifier.NotifyQuota(ctx, warning); err != nil {
+			// Sent again after the next flush
+			metrics.QuotaWarnings.WithLabelValues(status.Period, "error").Inc()
+			fmt.Printf("warning: failed to send quota warning: %v\n", err)
+			errreport.Background(ctx, "quota_webhook", err)
+			continue
+		}
+		metrics.QuotaWarnings.WithLabelValues(status.Period, "sent").Inc()
+		a.warned[key] = status.Warning
+	}
+}
+
+// quotaKey identifies a budget period by its kind and end
+func quotaKey(status QuotaStatus) string {
+	return status.Period + "/" + status.ResetsAt.Format(time.DateOnly)
+}
+
+// Webhook posts events as JSON: quota warnings, and other alerts sent through Post. With a
+// secret, each request carries the Unix time in X-Webhook-Timestamp and "sha256=" followed by the
+// hex HMAC-SHA256 of "<timestamp>.<body>" in X-Webhook-Signature
+type Webhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// Webhook request headers
+const (
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+// NewWebhook creates a webhook notifier posting to url with client
+func NewWebhook(url string, secret string, client *http.Client) *Webhook {
+	return &Webhook{url: url, secret: []byte(secret), client: client}
+}
+
+// NotifyQuota posts a warning; any status other than 2xx is an error
+func (w *Webhook) NotifyQuota(ctx context.Context, warning models.QuotaWarning) error {
+	return w.Post(ctx, warning)
+}
+
+// Post posts an event encoded as JSON; any status other than 2xx is an error
+func (w *Webhook) Post(ctx context.Context, event interface{}) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(w.secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, w.secret)
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(body)
+		req.Header.Set(HeaderWebhookTimestamp, timestamp)
+		req.Header.Set(HeaderWebhookSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook event: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %d", resp.StatusCode)
+	}
+	return nil
+}
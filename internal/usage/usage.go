@@ -0,0 +1,97 @@
+// Package usage records the tokens consumed by embedding calls. Each call is added to the meter
+// of the request it served, counted in metrics, and aggregated per day, tenant and model into
+// the usage table so totals can be checked against the provider's invoices.
+package usage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"refo-rag-server/internal/hotstate"
+	"refo-rag-server/internal/metrics"
+	"refo-rag-server/internal/models"
+	"refo-rag-server/internal/tenant"
+)
+
+// Meter accumulates the tokens used while serving one request, or one part of it
+type Meter struct {
+	mu     sync.Mutex
+	usage  models.EmbeddingUsage
+	parent *Meter
+}
+
+type contextKey struct{}
+
+// WithMeter returns a context whose embedding calls are added to the returned meter and to the
+// meter ctx already carries, so metering part of a request doesn't hide it from the request's meter
+func WithMeter(ctx context.Context) (context.Context, *Meter) {
+	parent, _ := ctx.Value(contextKey{}).(*Meter)
+	meter := &Meter{parent: parent}
+	return context.WithValue(ctx, contextKey{}, meter), meter
+}
+
+// Usage returns the tokens recorded so far, or nil if there were none
+func (m *Meter) Usage() *models.EmbeddingUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.usage.TotalTokens == 0 {
+		return nil
+	}
+	usage := m.usage
+	return &usage
+}
+
+func (m *Meter) add(u models.EmbeddingUsage) {
+	m.mu.Lock()
+	m.usage.PromptTokens += u.PromptTokens
+	m.usage.TotalTokens += u.TotalTokens
+	m.mu.Unlock()
+
+	if m.parent != nil {
+		m.parent.add(u)
+	}
+}
+
+var aggregator hotstate.Value[*Aggregator]
+
+// SetAggregator sets the process-wide aggregator recorded usage is added to; nil disables aggregation
+func SetAggregator(a *Aggregator) {
+	aggregator.Store(a)
+}
+
+// Record adds the tokens of one embedding call to the request's meter, the metrics and the aggregator
+func Record(ctx context.Context, model string, u models.EmbeddingUsage) {
+	if meter, ok := ctx.Value(contextKey{}).(*Meter); ok {
+		meter.add(u)
+	}
+
+	metrics.EmbeddingTokens.WithLabelValues(model).Add(float64(u.TotalTokens))
+	metrics.EmbeddingRequests.WithLabelValues(model).Inc()
+	metrics.AddTenantEmbeddingTokens(tenant.FromContext(ctx), model, u.TotalTokens)
+
+	if a := aggregator.Load(); a != nil {
+		a.add(tenant.FromContext(ctx), model, u)
+	}
+}
+
+// CheckBudget returns a BudgetError wrapping ErrBudgetExceeded once the embedding budget is spent;
+// embedding providers call it before every request
+func CheckBudget() error {
+	a := aggregator.Load()
+	if a == nil || !a.budget.Enabled() {
+		return nil
+	}
+
+	a.mu.Lock()
+	err := a.checkBudget(time.Now().UTC())
+	a.mu.Unlock()
+
+	var budgetErr *BudgetError
+	if errors.As(err, &budgetErr) {
+		metrics.EmbeddingBudgetRejections.WithLabelValues(budgetErr.Period).Inc()
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package vectorio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// npyMagic starts every NumPy .npy file
+const npyMagic = "\x93NUMPY"
+
+// writeNPYHeader writes a version 1.0 .npy header for a C-ordered little-endian float32 matrix
+func writeNPYHeader(w io.Writer, rows int64, cols int) error {
+	header := fmt.Sprintf("{'descr': '<f4', 'fortran_order': False, 'shape': (%d, %d), }", rows, cols)
+	// The magic, version and length take 10 bytes; the header is padded so data starts 64-byte aligned
+	padding := 64 - (10+len(header)+1)%64
+	if padding == 64 {
+		padding = 0
+	}
+	header += strings.Repeat(" ", padding) + "\n"
+
+	prefix := make([]byte, 10)
+	copy(prefix, npyMagic)
+	prefix[6], prefix[7] = 1, 0
+	binary.LittleEndian.PutUint16(prefix[8:], uint16(len(header)))
+	if _, err := w.Write(prefix); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, header)
+	return err
+}
+
+var (
+	npyDescr   = regexp.MustCompile(`'descr':\s*'([^']*)'`)
+	npyFortran = regexp.MustCompile(`'fortran_order':\s*(True|False)`)
+	npyShape   = regexp.MustCompile(`'shape':\s*\((\d+),\s*(\d+),?\s*\)`)
+)
+
+// npyReader reads the rows of a C-ordered little-endian float32 matrix from a .npy file
+type npyReader struct {
+	r    *bufio.Reader
+	rows int64
+	cols int
+}
+
+// newNPYReader parses a .npy header; only 2-dimensional '<f4' matrices are accepted
+func newNPYReader(r io.Reader) (*npyReader, error) {
+	br := bufio.NewReader(r)
+	prefix := make([]byte, 8)
+	if _, err := io.ReadFull(br, prefix); err != nil || string(prefix[:6]) != npyMagic {
+		return nil, fmt.Errorf("not a .npy file")
+	}
+
+	var headerLen int
+	switch prefix[6] {
+	case 1:
+		var n uint16
+		if err := binary.Read(br, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("failed to read .npy header: %w", err)
+		}
+		headerLen = int(n)
+	case 2, 3:
+		var n uint32
+		if err := binary.Read(br, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("failed to read .npy header: %w", err)
+		}
+		headerLen = int(n)
+	default:
+		return nil, fmt.Errorf("unsupported .npy version %d", prefix[6])
+	}
+
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("failed to read .npy header: %w", err)
+	}
+
+	descr := npyDescr.FindSubmatch(header)
+	if descr == nil || string(descr[1]) != "<f4" {
+		return nil, fmt.Errorf(".npy data must be little-endian float32 ('<f4')")
+	}
+	if fortran := npyFortran.FindSubmatch(header); fortran == nil || string(fortran[1]) != "False" {
+		return nil, fmt.Errorf(".npy data must be C-ordered")
+	}
+	shape := npyShape.FindSubmatch(header)
+	if shape == nil {
+		return nil, fmt.Errorf(".npy data must be a 2-dimensional matrix")
+	}
+	rows, _ := strconv.ParseInt(string(shape[1]), 10, 64)
+	cols, _ := strconv.Atoi(string(shape[2]))
+
+	return &npyReader{r: br, rows: rows, cols: cols}, nil
+}
+
+// next reads the next row
+func (n *npyReader) next() ([]float32, error) {
+	row := make([]float32, n.cols)
+	if err := binary.Read(n.r, binary.LittleEndian, row); err != nil {
+		return nil, fmt.Errorf("failed to read .npy row: %w", err)
+	}
+	return row, nil
+}
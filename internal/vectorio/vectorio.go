@@ -0,0 +1,415 @@
+// Package vectorio moves stored embeddings in and out of the serving path so they can be studied
+// offline. An export writes a collection's vectors below <prefix>/<content_type>/<timestamp>/ as
+//
+//	vectors.parquet  id, payload (JSON) and embedding (list<float>) per point
+//	embeddings.npy   the vectors as an N x D float32 matrix for numpy.load
+//	metadata.jsonl   id and payload of row i of embeddings.npy on line i
+//	manifest.json    what was exported, written last
+//
+// An import reads vectors computed offline from a Parquet file with id and embedding columns, or
+// from a .npy matrix with a JSON lines file naming its rows, and replaces the vectors of the
+// matching stored points
+package vectorio
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"refo-rag-server/internal/blobstore"
+	"refo-rag-server/internal/models"
+)
+
+// batchSize bounds the points read or written per Qdrant request
+const batchSize = 256
+
+// File names inside an export directory
+const (
+	ParquetFile  = "vectors.parquet"
+	NumPyFile    = "embeddings.npy"
+	MetadataFile = "metadata.jsonl"
+	ManifestFile = "manifest.json"
+)
+
+// PointStore is the vector collection an export reads and an import writes
+type PointStore interface {
+	Collection() string
+	Dimension() int
+	ScrollPoints(ctx context.Context, offset *uint64, limit int) ([]models.VectorPoint, *uint64, error)
+	UpsertPoints(ctx context.Context, points []models.VectorPoint) error
+	GetPayloads(ctx context.Context, ids []string) (map[string]map[string]interface{}, error)
+}
+
+// IO exports and imports vectors through a blob store
+type IO struct {
+	blobs  blobstore.Store
+	prefix string
+}
+
+// New creates a vector exporter and importer writing below prefix in blobs
+func New(blobs blobstore.Store, prefix string) *IO {
+	return &IO{blobs: blobs, prefix: prefix}
+}
+
+// exportRow is the Parquet layout of an exported point
+type exportRow struct {
+	ID        string    `parquet:"id"`
+	Payload   string    `parquet:"payload,json"`
+	Embedding []float32 `parquet:"embedding,list"`
+}
+
+// importRow is the Parquet layout an import reads; other columns are ignored
+type importRow struct {
+	ID        string    `parquet:"id"`
+	Embedding []float32 `parquet:"embedding,list"`
+}
+
+// Export writes every point of a collection. Points written while the export runs may or may not
+// be included
+func (v *IO) Export(ctx context.Context, contentType string, model string, store PointStore) (*models.VectorExport, error) {
+	startTime := time.Now()
+	dir := fmt.Sprintf("%s/%s/%s", v.prefix, contentType, startTime.UTC().Format("20060102T150405Z"))
+	export := &models.VectorExport{
+		ContentType: contentType,
+		Collection:  store.Collection(),
+		Model:       model,
+		Dimension:   store.Dimension(),
+		ParquetKey:  dir + "/" + ParquetFile,
+		NumPyKey:    dir + "/" + NumPyFile,
+		MetadataKey: dir + "/" + MetadataFile,
+	}
+
+	// The .npy header needs the row count, so vectors are spooled to a temporary file first
+	spool, err := os.CreateTemp("", "vectors-*.f32")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spool file: %w", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	parquetBlob, err := v.blobs.Create(ctx, export.ParquetKey)
+	if err != nil {
+		return nil, err
+	}
+	metadataBlob, err := v.blobs.Create(ctx, export.MetadataKey)
+	if err != nil {
+		parquetBlob.Abort()
+		return nil, err
+	}
+
+	err = v.scrollInto(ctx, store, export, parquetBlob, metadataBlob, spool)
+	if err != nil {
+		parquetBlob.Abort()
+		metadataBlob.Abort()
+		return nil, err
+	}
+	if err := parquetBlob.Close(); err != nil {
+		metadataBlob.Abort()
+		return nil, err
+	}
+	if err := metadataBlob.Close(); err != nil {
+		return nil, err
+	}
+	if err := v.writeNPY(ctx, export, spool); err != nil {
+		return nil, err
+	}
+
+	export.ExportedAt = time.Now()
+	export.DurationMs = time.Since(startTime).Milliseconds()
+	manifest, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode export manifest: %w", err)
+	}
+	if err := v.put(ctx, dir+"/"+ManifestFile, manifest); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// scrollInto pages through the collection, writing each point to the Parquet and metadata blobs
+// and its vector to the spool file
+func (v *IO) scrollInto(ctx context.Context, store PointStore, export *models.VectorExport, parquetBlob io.Writer, metadataBlob io.Writer, spool io.Writer) error {
+	rows := parquet.NewGenericWriter[exportRow](parquetBlob, parquet.Compression(&parquet.Zstd))
+	metadata := bufio.NewWriter(metadataBlob)
+	vectors := bufio.NewWriter(spool)
+
+	var offset *uint64
+	for {
+		points, next, err := store.ScrollPoints(ctx, offset, batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to read points: %w", err)
+		}
+
+		for _, point := range points {
+			if len(point.Vector) != export.Dimension {
+				return fmt.Errorf("point %s has %d dimensions, the collection has %d", point.ID, len(point.Vector), export.Dimension)
+			}
+			payload, err := json.Marshal(point.Payload)
+			if err != nil {
+				return fmt.Errorf("failed to encode payload of %s: %w", point.ID, err)
+			}
+			if _, err := rows.Write([]exportRow{{ID: point.ID, Payload: string(payload), Embedding: point.Vector}}); err != nil {
+				return fmt.Errorf("failed to encode point %s: %w", point.ID, err)
+			}
+			line, err := json.Marshal(map[string]interface{}{"id": point.ID, "payload": point.Payload})
+			if err != nil {
+				return fmt.Errorf("failed to encode metadata of %s: %w", point.ID, err)
+			}
+			metadata.Write(line)
+			metadata.WriteByte('\n')
+			if err := binary.Write(vectors, binary.LittleEndian, point.Vector); err != nil {
+				return fmt.Errorf("failed to spool vectors: %w", err)
+			}
+			export.Points++
+		}
+
+		if next == nil {
+			break
+		}
+		offset = next
+	}
+
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("failed to encode vectors: %w", err)
+	}
+	if err := metadata.Flush(); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+	if err := vectors.Flush(); err != nil {
+		return fmt.Errorf("failed to spool vectors: %w", err)
+	}
+	return nil
+}
+
+// writeNPY copies the spooled vectors behind a .npy header
+func (v *IO) writeNPY(ctx context.Context, export *models.VectorExport, spool *os.File) error {
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind spool file: %w", err)
+	}
+
+	blob, err := v.blobs.Create(ctx, export.NumPyKey)
+	if err != nil {
+		return err
+	}
+	if err := writeNPYHeader(blob, export.Points, export.Dimension); err != nil {
+		blob.Abort()
+		return fmt.Errorf("failed to write .npy header: %w", err)
+	}
+	if _, err := io.Copy(blob, spool); err != nil {
+		blob.Abort()
+		return fmt.Errorf("failed to write .npy data: %w", err)
+	}
+	return blob.Close()
+}
+
+// Import replaces the vectors of stored points with vectors computed offline. Rows whose ID has no
+// stored point are counted as missing and skipped; payloads are kept
+func (v *IO) Import(ctx context.Context, req *models.VectorImportRequest, store PointStore) (*models.VectorImport, error) {
+	startTime := time.Now()
+	result := &models.VectorImport{ContentType: req.ContentType, Key: req.Key, DryRun: req.DryRun}
+
+	next, closeSource, err := v.openSource(ctx, req, store.Dimension())
+	if err != nil {
+		return nil, err
+	}
+	defer closeSource()
+
+	for {
+		batch, err := next(batchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if err := v.importBatch(ctx, store, batch, result); err != nil {
+			return nil, err
+		}
+	}
+
+	result.DurationMs = time.Since(startTime).Milliseconds()
+	return result, nil
+}
+
+// importBatch writes the rows of a batch whose points exist, keeping their payloads
+func (v *IO) importBatch(ctx context.Context, store PointStore, batch []models.VectorPoint, result *models.VectorImport) error {
+	ids := make([]string, 0, len(batch))
+	for _, point := range batch {
+		ids = append(ids, point.ID)
+	}
+	payloads, err := store.GetPayloads(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to read stored points: %w", err)
+	}
+
+	found := batch[:0]
+	for _, point := range batch {
+		payload, ok := payloads[point.ID]
+		if !ok {
+			result.Missing++
+			continue
+		}
+		point.Payload = payload
+		found = append(found, point)
+	}
+	result.Rows += int64(len(batch))
+
+	if !result.DryRun {
+		if err := store.UpsertPoints(ctx, found); err != nil {
+			return fmt.Errorf("failed to write vectors: %w", err)
+		}
+	}
+	result.Updated += int64(len(found))
+	return nil
+}
+
+// batchReader returns up to n rows per call and no rows once the source is exhausted
+type batchReader func(n int) ([]models.VectorPoint, error)
+
+// openSource opens the file an import reads, checking its vectors have the collection's dimension
+func (v *IO) openSource(ctx context.Context, req *models.VectorImportRequest, dimension int) (batchReader, func(), error) {
+	blob, err := v.blobs.Open(ctx, req.Key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", req.Key, err)
+	}
+
+	if strings.HasSuffix(req.Key, ".npy") {
+		return openNPYSource(ctx, v.blobs, blob, req, dimension)
+	}
+	defer blob.Close()
+	return openParquetSource(blob, dimension)
+}
+
+// openParquetSource spools a Parquet blob to a temporary file, since Parquet is read from the end
+func openParquetSource(blob io.Reader, dimension int) (batchReader, func(), error) {
+	spool, err := os.CreateTemp("", "vectors-*.parquet")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create spool file: %w", err)
+	}
+	cleanup := func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}
+
+	size, err := io.Copy(spool, blob)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to read Parquet file: %w", err)
+	}
+	file, err := parquet.OpenFile(spool, size)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to open Parquet file: %w", err)
+	}
+	for _, column := range []string{"id", "embedding"} {
+		if !hasField(file.Schema(), column) {
+			cleanup()
+			return nil, nil, fmt.Errorf("Parquet file has no %s column", column)
+		}
+	}
+
+	reader := parquet.NewGenericReader[importRow](file)
+	next := func(n int) ([]models.VectorPoint, error) {
+		rows := make([]importRow, n)
+		count, err := reader.Read(rows)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read Parquet rows: %w", err)
+		}
+		points := make([]models.VectorPoint, 0, count)
+		for _, row := range rows[:count] {
+			if row.ID == "" {
+				return nil, fmt.Errorf("Parquet row without an id")
+			}
+			if len(row.Embedding) != dimension {
+				return nil, fmt.Errorf("vector of %s has %d dimensions, the collection has %d", row.ID, len(row.Embedding), dimension)
+			}
+			points = append(points, models.VectorPoint{ID: row.ID, Vector: row.Embedding})
+		}
+		return points, nil
+	}
+	return next, func() { reader.Close(); cleanup() }, nil
+}
+
+// hasField reports whether a schema has a top-level field
+func hasField(schema *parquet.Schema, name string) bool {
+	for _, field := range schema.Fields() {
+		if field.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// openNPYSource reads a .npy matrix row by row alongside the JSON lines file naming its rows
+func openNPYSource(ctx context.Context, blobs blobstore.Store, blob io.ReadCloser, req *models.VectorImportRequest, dimension int) (batchReader, func(), error) {
+	if req.IDsKey == "" {
+		blob.Close()
+		return nil, nil, fmt.Errorf("importing %s needs ids_key, e.g. the export's %s", path.Base(req.Key), MetadataFile)
+	}
+	matrix, err := newNPYReader(blob)
+	if err != nil {
+		blob.Close()
+		return nil, nil, err
+	}
+	if matrix.cols != dimension {
+		blob.Close()
+		return nil, nil, fmt.Errorf(".npy vectors have %d dimensions, the collection has %d", matrix.cols, dimension)
+	}
+	idsBlob, err := blobs.Open(ctx, req.IDsKey)
+	if err != nil {
+		blob.Close()
+		return nil, nil, fmt.Errorf("failed to open %s: %w", req.IDsKey, err)
+	}
+
+	ids := bufio.NewScanner(idsBlob)
+	ids.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var read int64
+	next := func(n int) ([]models.VectorPoint, error) {
+		var points []models.VectorPoint
+		for len(points) < n && read < matrix.rows {
+			if !ids.Scan() {
+				if err := ids.Err(); err != nil {
+					return nil, fmt.Errorf("failed to read %s: %w", req.IDsKey, err)
+				}
+				return nil, fmt.Errorf("%s names %d rows, the matrix has %d", req.IDsKey, read, matrix.rows)
+			}
+			var line struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(ids.Bytes(), &line); err != nil || line.ID == "" {
+				return nil, fmt.Errorf("line %d of %s has no id", read+1, req.IDsKey)
+			}
+			vector, err := matrix.next()
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, models.VectorPoint{ID: line.ID, Vector: vector})
+			read++
+		}
+		return points, nil
+	}
+	return next, func() { blob.Close(); idsBlob.Close() }, nil
+}
+
+// put stores a small blob
+func (v *IO) put(ctx context.Context, key string, data []byte) error {
+	writer, err := v.blobs.Create(ctx, key)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Abort()
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return writer.Close()
+}